@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/core/loadtest"
+	"github.com/spf13/cobra"
+)
+
+// NewLoadTest 按流量曲线压测运行中 apiserver 的历史写入和查询接口，发版前验证限流和写入，
+// 只发送 HTTP 请求，不需要连接数据库
+func NewLoadTest() *cobra.Command {
+	var target, token, labAK, labSK, profile, mix, device string
+	var labID int64
+	var concurrency, batch int
+	var timeout, maxP99 time.Duration
+	var maxErrorRate float64
+	var expectLimited bool
+	loadCmd := &cobra.Command{
+		Use:          "loadtest",
+		Long:         `send synthetic traffic to the history ingest and query APIs and report latencies`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts := &loadtest.Options{
+				Target:      target,
+				Token:       token,
+				LabAK:       labAK,
+				LabSK:       labSK,
+				LabID:       labID,
+				Concurrency: concurrency,
+				Batch:       batch,
+				DeviceName:  device,
+				Timeout:     timeout,
+				OnProgressFn: func(elapsed time.Duration, rps float64, inflight int) {
+					fmt.Printf("%s target %.0f rps, %d in flight\n", elapsed.Truncate(time.Second), rps, inflight)
+				},
+			}
+			var err error
+			if opts.Profile, err = loadtest.ParseProfile(profile); err != nil {
+				return fmt.Errorf("invalid --profile: %w", err)
+			}
+			if opts.Mix, err = loadtest.ParseMix(mix); err != nil {
+				return fmt.Errorf("invalid --mix: %w", err)
+			}
+
+			fmt.Printf("running %s against %s\n", opts.Profile.Duration(), target)
+			report, err := loadtest.Run(cmd.Root().Context(), opts)
+			if report != nil {
+				printLoadTestReport(report)
+			}
+			if err != nil {
+				return err
+			}
+			return report.Check(&loadtest.Thresholds{
+				MaxP99:        maxP99,
+				MaxErrorRate:  maxErrorRate,
+				ExpectLimited: expectLimited,
+			})
+		},
+	}
+	loadCmd.Flags().StringVar(&target, "target", "http://localhost:48197/api", "API base URL of the apiserver under test")
+	loadCmd.Flags().StringVar(&token, "token", "", "user token for the query scenarios")
+	loadCmd.Flags().StringVar(&labAK, "lab-ak", "", "lab access key for the ingest and heartbeat scenarios")
+	loadCmd.Flags().StringVar(&labSK, "lab-sk", "", "lab access secret for the ingest and heartbeat scenarios")
+	loadCmd.Flags().Int64Var(&labID, "lab-id", 0, "lab id queried by the query scenarios")
+	loadCmd.Flags().StringVar(&profile, "profile", "smoke", "smoke, release, spike or stages like 1m@0-100,3m@100,30s@300")
+	loadCmd.Flags().StringVar(&mix, "mix", loadtest.DefaultMix, "scenario weights")
+	loadCmd.Flags().StringVar(&device, "device", "loadtest-device", "device name of the ingested records")
+	loadCmd.Flags().IntVar(&concurrency, "concurrency", 64, "max requests in flight")
+	loadCmd.Flags().IntVar(&batch, "batch", 50, "records per ingest request")
+	loadCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "timeout of a request")
+	loadCmd.Flags().DurationVar(&maxP99, "max-p99", 0, "fail when the p99 latency of a scenario exceeds it")
+	loadCmd.Flags().Float64Var(&maxErrorRate, "max-error-rate", 0.01, "fail when the error rate of a scenario exceeds it, 429 is not an error")
+	loadCmd.Flags().BoolVar(&expectLimited, "expect-limited", false, "fail when no request is rate limited")
+	return loadCmd
+}
+
+func printLoadTestReport(report *loadtest.Report) {
+	fmt.Printf("\nfinished in %s\n", report.Duration.Truncate(time.Millisecond))
+	fmt.Printf("%-10s %8s %8s %8s %8s %8s %8s %10s %10s %10s %10s\n",
+		"scenario", "requests", "ok", "429", "errors", "dropped", "rps", "p50", "p90", "p99", "max")
+	for _, s := range report.Scenarios {
+		fmt.Printf("%-10s %8d %8d %8d %8d %8d %8.1f %10s %10s %10s %10s\n",
+			s.Name, s.Requests, s.OK, s.Limited, s.Errors, s.Dropped, s.RPS,
+			s.P50.Truncate(time.Microsecond), s.P90.Truncate(time.Microsecond),
+			s.P99.Truncate(time.Microsecond), s.Max.Truncate(time.Microsecond))
+		if s.LastError != "" {
+			fmt.Printf("  last error: %s\n", s.LastError)
+		}
+	}
+	if in := report.Ingest; in != nil {
+		fmt.Printf("ingest stream %s: sent %d, acked %d, rejected %d, high water mark %d, max seq %d, missing %d\n",
+			in.Stream, in.Sent, in.Acked, in.Rejected, in.HighWaterMark, in.MaxSeq, in.Missing)
+	}
+}
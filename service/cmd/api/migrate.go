@@ -1,13 +1,19 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/scienceol/studio/service/pkg/middleware/db"
 	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/scienceol/studio/service/pkg/repo/eventstore"
+	"github.com/scienceol/studio/service/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 func NewMigrate() *cobra.Command {
-	return &cobra.Command{
+	migrateCmd := &cobra.Command{
 		Use:                "migrate",
 		Long:               `api server db migrate`,
 		SilenceUsage:       true,
@@ -15,11 +21,125 @@ func NewMigrate() *cobra.Command {
 		PersistentPostRunE: cleanGlobalResource,
 		PreRunE:            initMigrate,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return migrate.Table(cmd.Root().Context())
+			ctx := cmd.Root().Context()
+			return utils.IfErrReturn(func() error {
+				return migrate.Table(ctx)
+			}, func() error {
+				return migrate.Up(ctx, "")
+			}, func() error {
+				return eventstore.Migrate(ctx)
+			})
+		},
+		PostRunE: closeMigrate,
+	}
+	migrateCmd.AddCommand(newMigrateEvents(), newMigrateUp(), newMigrateDown(), newMigrateStatus())
+	return migrateCmd
+}
+
+// newMigrateUp 执行未执行的版本化迁移，不依赖服务启动
+func newMigrateUp() *cobra.Command {
+	var to string
+	upCmd := &cobra.Command{
+		Use:          "up",
+		Long:         `apply the pending versioned migrations`,
+		SilenceUsage: true,
+		PreRunE:      initMigrate,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Root().Context()
+			if err := migrate.Up(ctx, to); err != nil {
+				return err
+			}
+			return printMigrateStatus(ctx)
+		},
+		PostRunE: closeMigrate,
+	}
+	upCmd.Flags().StringVar(&to, "to", "", "only apply migrations up to and including this id")
+	return upCmd
+}
+
+// newMigrateDown 回滚版本化迁移，默认只回滚最后一个
+func newMigrateDown() *cobra.Command {
+	var to string
+	downCmd := &cobra.Command{
+		Use:          "down",
+		Long:         `roll back the last versioned migration`,
+		SilenceUsage: true,
+		PreRunE:      initMigrate,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Root().Context()
+			if err := migrate.Down(ctx, to); err != nil {
+				return err
+			}
+			return printMigrateStatus(ctx)
+		},
+		PostRunE: closeMigrate,
+	}
+	downCmd.Flags().StringVar(&to, "to", "", "roll back every migration applied after this id")
+	return downCmd
+}
+
+func newMigrateStatus() *cobra.Command {
+	return &cobra.Command{
+		Use:          "status",
+		Long:         `show the schema version and the state of the versioned migrations`,
+		SilenceUsage: true,
+		PreRunE:      initMigrate,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return printMigrateStatus(cmd.Root().Context())
 		},
-		PostRunE: func(cmd *cobra.Command, _ []string) error {
-			db.ClosePostgres(cmd.Context())
+		PostRunE: closeMigrate,
+	}
+}
+
+func printMigrateStatus(ctx context.Context) error {
+	status, err := migrate.GetStatus(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema version: %s (latest %s)\n", status.SchemaVersion, status.LatestSchemaVersion)
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied " + m.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%-45s %s\n", m.ID, state)
+	}
+	fmt.Printf("%d pending, device events partitioned: %t\n", status.Pending, status.EventsPartitioned)
+	return nil
+}
+
+// newMigrateEvents 将主库的设备事件复制到 timescale，可重复执行补齐双写期间缺失的记录
+func newMigrateEvents() *cobra.Command {
+	var afterID int64
+	var batchSize int
+	eventsCmd := &cobra.Command{
+		Use:          "events",
+		Long:         `copy device events of the main database to the event store backend`,
+		SilenceUsage: true,
+		PreRunE:      initMigrate,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Root().Context()
+			if err := eventstore.Migrate(ctx); err != nil {
+				return err
+			}
+			copied, err := eventstore.Backfill(ctx, afterID, batchSize, func(lastID, copied int64) {
+				fmt.Printf("copied %d events, last id %d\n", copied, lastID)
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("device events migrated, %d copied\n", copied)
 			return nil
 		},
+		PostRunE: closeMigrate,
 	}
+	eventsCmd.Flags().Int64Var(&afterID, "after-id", 0, "only copy events with id greater than after-id")
+	eventsCmd.Flags().IntVar(&batchSize, "batch-size", 1000, "events copied per batch")
+	return eventsCmd
+}
+
+func closeMigrate(cmd *cobra.Command, _ []string) error {
+	db.ClosePostgres(cmd.Context())
+	db.CloseEventDB(cmd.Context())
+	return nil
 }
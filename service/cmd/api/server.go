@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -13,8 +14,35 @@ import (
 
 	_ "github.com/scienceol/studio/service/docs" // 导入自动生成的 docs 包
 	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/i18n"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/alert"
+	"github.com/scienceol/studio/service/pkg/core/cdc"
+	"github.com/scienceol/studio/service/pkg/core/command"
+	"github.com/scienceol/studio/service/pkg/core/cost"
+	"github.com/scienceol/studio/service/pkg/core/deviceconfig"
+	"github.com/scienceol/studio/service/pkg/core/devicerisk"
+	"github.com/scienceol/studio/service/pkg/core/file"
+	"github.com/scienceol/studio/service/pkg/core/historychain"
+	"github.com/scienceol/studio/service/pkg/core/historycompact"
+	"github.com/scienceol/studio/service/pkg/core/historyexport"
+	"github.com/scienceol/studio/service/pkg/core/historyingest"
+	"github.com/scienceol/studio/service/pkg/core/inventory"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/core/labexport"
+	"github.com/scienceol/studio/service/pkg/core/liveness"
 	"github.com/scienceol/studio/service/pkg/core/notify/events"
+	"github.com/scienceol/studio/service/pkg/core/report"
+	"github.com/scienceol/studio/service/pkg/core/rule"
+	"github.com/scienceol/studio/service/pkg/core/schedule/deadletter"
+	"github.com/scienceol/studio/service/pkg/core/seed"
+	"github.com/scienceol/studio/service/pkg/core/stream"
+	"github.com/scienceol/studio/service/pkg/core/systemhealth"
+	"github.com/scienceol/studio/service/pkg/core/telemetry"
+	"github.com/scienceol/studio/service/pkg/core/upgrade"
+	"github.com/scienceol/studio/service/pkg/core/workflow/dependency"
 	"github.com/scienceol/studio/service/pkg/features"
+	"github.com/scienceol/studio/service/pkg/middleware/chaos"
 	"github.com/scienceol/studio/service/pkg/middleware/db"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
 	"github.com/scienceol/studio/service/pkg/middleware/otel"
@@ -28,6 +56,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 )
 
 func NewWeb() *cobra.Command {
@@ -43,6 +72,9 @@ func NewWeb() *cobra.Command {
 		RunE:               newRouter,
 		PostRunE:           cleanWebResource,
 	}
+	webServer.Flags().String("seed-lab", "", "populate the lab (UUID) with demo history after start, not allowed in prod")
+	webServer.Flags().Int("seed-executions", 2000, "executions generated by --seed-lab")
+	webServer.Flags().Int("seed-days", 30, "days the executions of --seed-lab spread over")
 
 	return webServer
 }
@@ -88,27 +120,73 @@ func initGlobalResource(_ *cobra.Command, _ []string) error {
 		},
 	})
 
+	// 新记录的 UUID 版本
+	if err := uuid.SetVersion(config.GetStudioConfig().Database.UUIDVersion); err != nil {
+		return fmt.Errorf("set uuid version fail: %w", err)
+	}
+
+	// 错误消息多语言
+	if err := i18n.Init(config.GetStudioConfig().I18n); err != nil {
+		return fmt.Errorf("init i18n fail: %w", err)
+	}
+
 	return nil
 }
 
 func initMigrate(cmd *cobra.Command, _ []string) error {
+	// 迁移和回填可能执行较长的语句，不限制语句时长
+	conns := dbConns()
+	conns.StatementTimeout = 0
 	config := config.Global()
 	// 初始化数据库
 	db.InitPostgres(cmd.Context(), &db.Config{
-		Host:   config.Database.Host,
-		Port:   config.Database.Port,
-		User:   config.Database.User,
-		PW:     config.Database.Password,
-		DBName: config.Database.Name,
+		Host:       config.Database.Host,
+		Port:       config.Database.Port,
+		User:       config.Database.User,
+		PW:         config.Database.Password,
+		DBName:     config.Database.Name,
+		Driver:     config.Database.Driver,
+		SQLitePath: config.Database.SQLitePath,
 		LogConf: db.LogConf{
 			Level: config.Log.LogLevel,
 		},
+		Conns: conns,
 	})
+	initEventDB(cmd.Context(), config, conns)
 
 	return nil
 }
 
+// dbConns 数据库连接池配置
+func dbConns() db.Conns {
+	return db.NewConns(config.GetStudioConfig().Database)
+}
+
+// initEventDB 配置 timescale 后端时初始化设备事件的独立存储
+func initEventDB(ctx context.Context, conf *config.GlobalConfig, conns db.Conns) {
+	if conf.EventStore.Backend != config.EventBackendTimescale {
+		return
+	}
+	db.InitEventDB(ctx, &db.Config{
+		Host:   conf.EventStore.Host,
+		Port:   conf.EventStore.Port,
+		User:   conf.EventStore.User,
+		PW:     conf.EventStore.Password,
+		DBName: conf.EventStore.Name,
+		LogConf: db.LogConf{
+			Level: conf.Log.LogLevel,
+		},
+		Conns: conns,
+	})
+}
+
 func initWeb(cmd *cobra.Command, _ []string) error {
+	// 下载链接和摄像头观看令牌由任一实例签发、任一实例校验，未配置共享密钥时拒绝启动
+	if err := utils.CheckSignedURLSecret(); err != nil {
+		return err
+	}
+
+	conns := dbConns()
 	config := config.Global()
 
 	// Automatically generate Swagger documentation upon startup.
@@ -127,20 +205,25 @@ func initWeb(cmd *cobra.Command, _ []string) error {
 
 	// 初始化数据库
 	db.InitPostgres(cmd.Context(), &db.Config{
-		Host:   config.Database.Host,
-		Port:   config.Database.Port,
-		User:   config.Database.User,
-		PW:     config.Database.Password,
-		DBName: config.Database.Name,
+		Host:       config.Database.Host,
+		Port:       config.Database.Port,
+		User:       config.Database.User,
+		PW:         config.Database.Password,
+		DBName:     config.Database.Name,
+		Driver:     config.Database.Driver,
+		SQLitePath: config.Database.SQLitePath,
 		LogConf: db.LogConf{
 			Level: config.Log.LogLevel,
 		},
+		Conns: conns,
 	})
+	initEventDB(cmd.Context(), config, conns)
 
 	// 检查数据库迁移状态
 	if err := migrate.HandleAutoMigration(cmd.Context(), config); err != nil {
 		return fmt.Errorf("database migration failed: %w", err)
 	}
+	migrate.CheckIndexes(cmd.Context())
 
 	// 初始化 redis
 	redis.InitRedis(cmd.Context(), &redis.Redis{
@@ -150,6 +233,11 @@ func initWeb(cmd *cobra.Command, _ []string) error {
 		DB:       config.Redis.DB,
 	})
 
+	// 非生产环境安装故障注入钩子，迁移完成后再安装
+	if err := chaos.Init(cmd.Context()); err != nil {
+		return fmt.Errorf("init chaos fail: %w", err)
+	}
+
 	return nil
 }
 
@@ -158,6 +246,87 @@ func newRouter(cmd *cobra.Command, _ []string) error {
 	router := gin.Default()
 
 	web.NewRouter(cmd.Root().Context(), router)
+	dependency.NewDispatcher().Start(cmd.Root().Context())
+	if err := deadletter.RegisterMetrics(); err != nil {
+		logger.Errorf(cmd.Context(), "register dead letter metrics err: %+v", err)
+	}
+	if err := liveness.RegisterMetrics(); err != nil {
+		logger.Errorf(cmd.Context(), "register device liveness metrics err: %+v", err)
+	}
+	if err := db.RegisterMetrics(); err != nil {
+		logger.Errorf(cmd.Context(), "register db pool metrics err: %+v", err)
+	}
+	if err := cdc.RegisterMetrics(); err != nil {
+		logger.Errorf(cmd.Context(), "register history cdc metrics err: %+v", err)
+	}
+	if err := liveness.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register device liveness job err: %+v", err)
+	}
+	if err := command.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register device command job err: %+v", err)
+	}
+	if err := telemetry.RegisterJobs(); err != nil {
+		logger.Errorf(cmd.Context(), "register telemetry jobs err: %+v", err)
+	}
+	if err := rule.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register device rule job err: %+v", err)
+	}
+	if err := alert.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register alert escalation job err: %+v", err)
+	}
+	if err := devicerisk.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register device risk scoring job err: %+v", err)
+	}
+	if err := deviceconfig.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register device config apply job err: %+v", err)
+	}
+	if err := upgrade.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register agent upgrade job err: %+v", err)
+	}
+	if err := stream.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register camera session job err: %+v", err)
+	}
+	if err := file.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register file upload job err: %+v", err)
+	}
+	if err := inventory.RegisterSyncJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register material inventory sync job err: %+v", err)
+	}
+	if err := cost.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register cost accounting job err: %+v", err)
+	}
+	if err := report.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register report subscription job err: %+v", err)
+	}
+	if err := labexport.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register lab export sweep job err: %+v", err)
+	}
+	if err := historyexport.RegisterJobs(); err != nil {
+		logger.Errorf(cmd.Context(), "register history export jobs err: %+v", err)
+	}
+	if err := historychain.RegisterJobs(); err != nil {
+		logger.Errorf(cmd.Context(), "register history chain jobs err: %+v", err)
+	}
+	if err := historycompact.RegisterJobs(); err != nil {
+		logger.Errorf(cmd.Context(), "register history compaction jobs err: %+v", err)
+	}
+	if err := cdc.RegisterJob(); err != nil {
+		logger.Errorf(cmd.Context(), "register history cdc job err: %+v", err)
+	}
+	if err := jobs.RegisterBuiltin(); err != nil {
+		logger.Errorf(cmd.Context(), "register builtin background jobs err: %+v", err)
+	}
+	jobs.Default().Start(cmd.Root().Context())
+	if err := systemhealth.RecordFeatureFlags(cmd.Context()); err != nil {
+		logger.Errorf(cmd.Context(), "record feature flags err: %+v", err)
+	}
+	if err := startSeed(cmd); err != nil {
+		return err
+	}
+	ingestServer, err := startHistoryIngest(cmd, configs.RPC.HistoryIngest.Port)
+	if err != nil {
+		return err
+	}
 	port := configs.Server.Port
 	addr := ":" + strconv.Itoa(port)
 
@@ -199,6 +368,50 @@ func newRouter(cmd *cobra.Command, _ []string) error {
 	if err := httpServer.Shutdown(ctx); err != nil {
 		fmt.Printf("shut down server err: %+v", err)
 	}
+	ingestServer.GracefulStop()
+	return nil
+}
+
+// startHistoryIngest 监听调度服务写入执行历史的内部 grpc，执行历史只由 web 服务写入
+func startHistoryIngest(cmd *cobra.Command, port int) (*grpc.Server, error) {
+	lis, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return nil, fmt.Errorf("listen history ingest port %d fail: %w", port, err)
+	}
+	server := historyingest.NewGRPCServer(config.Global().RPC.HistoryIngest.Token, historyingest.NewServer())
+	utils.SafelyGo(func() {
+		if err := server.Serve(lis); err != nil {
+			logger.Errorf(cmd.Context(), "serve history ingest err: %+v", err)
+		}
+	}, func(err error) {
+		logger.Errorf(cmd.Context(), "run history ingest server err: %+v", err)
+	})
+	fmt.Printf("📥 History ingest gRPC is running at: 0.0.0.0:%d\n", port)
+	return server, nil
+}
+
+// startSeed 按启动参数在后台为实验室生成演示历史数据，不阻塞服务启动
+func startSeed(cmd *cobra.Command) error {
+	labUUID, _ := cmd.Flags().GetString("seed-lab")
+	if labUUID == "" {
+		return nil
+	}
+	req := &seed.SeedReq{}
+	var err error
+	if req.LabUUID, err = uuid.FromString(labUUID); err != nil {
+		return fmt.Errorf("invalid --seed-lab: %w", err)
+	}
+	req.Executions, _ = cmd.Flags().GetInt("seed-executions")
+	req.Days, _ = cmd.Flags().GetInt("seed-days")
+
+	ctx := cmd.Root().Context()
+	utils.SafelyGo(func() {
+		if _, err := seed.New().Run(ctx, req, seed.StartupUserID); err != nil {
+			logger.Errorf(ctx, "seed lab %s err: %+v", labUUID, err)
+		}
+	}, func(err error) {
+		logger.Errorf(ctx, "seed lab %s panic: %+v", labUUID, err)
+	})
 	return nil
 }
 
@@ -208,6 +421,7 @@ func cleanWebResource(cmd *cobra.Command, _ []string) error {
 	events.NewEvents().Close(cmd.Context())
 	redis.CloseRedis(cmd.Context())
 	db.ClosePostgres(cmd.Context())
+	db.CloseEventDB(cmd.Context())
 	trace.CloseTrace()
 	return nil
 }
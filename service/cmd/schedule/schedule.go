@@ -13,7 +13,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/i18n"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
 	"github.com/scienceol/studio/service/pkg/core/notify/events"
+	"github.com/scienceol/studio/service/pkg/middleware/chaos"
 	"github.com/scienceol/studio/service/pkg/middleware/db"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
 	"github.com/scienceol/studio/service/pkg/middleware/redis"
@@ -105,17 +108,38 @@ func initSchedule(cmd *cobra.Command, _ []string) error {
 	// 	TraceSK:         conf.Trace.TraceSK,
 	// })
 
+	// 加载 YAML 配置文件，数据库连接池配置来自其中
+	if _, err := config.LoadStudioConfig("config", conf.Server.Env); err != nil {
+		logger.Warnf(cmd.Context(), "load studio config fail, using defaults: %+v", err)
+	}
+
 	// 初始化数据库
 	db.InitPostgres(cmd.Context(), &db.Config{
-		Host:   conf.Database.Host,
-		Port:   conf.Database.Port,
-		User:   conf.Database.User,
-		PW:     conf.Database.Password,
-		DBName: conf.Database.Name,
+		Host:       conf.Database.Host,
+		Port:       conf.Database.Port,
+		User:       conf.Database.User,
+		PW:         conf.Database.Password,
+		DBName:     conf.Database.Name,
+		Driver:     conf.Database.Driver,
+		SQLitePath: conf.Database.SQLitePath,
 		LogConf: db.LogConf{
 			Level: conf.Log.LogLevel,
 		},
+		Conns: db.NewConns(config.GetStudioConfig().Database),
 	})
+	if conf.EventStore.Backend == config.EventBackendTimescale {
+		db.InitEventDB(cmd.Context(), &db.Config{
+			Host:   conf.EventStore.Host,
+			Port:   conf.EventStore.Port,
+			User:   conf.EventStore.User,
+			PW:     conf.EventStore.Password,
+			DBName: conf.EventStore.Name,
+			LogConf: db.LogConf{
+				Level: conf.Log.LogLevel,
+			},
+			Conns: db.NewConns(config.GetStudioConfig().Database),
+		})
+	}
 
 	// 初始化 redis
 	redis.InitRedis(cmd.Context(), &redis.Redis{
@@ -125,6 +149,21 @@ func initSchedule(cmd *cobra.Command, _ []string) error {
 		DB:       conf.Redis.DB,
 	})
 
+	// 新记录的 UUID 版本
+	if err := uuid.SetVersion(config.GetStudioConfig().Database.UUIDVersion); err != nil {
+		return fmt.Errorf("set uuid version fail: %w", err)
+	}
+
+	// 错误消息多语言
+	if err := i18n.Init(config.GetStudioConfig().I18n); err != nil {
+		return fmt.Errorf("init i18n fail: %w", err)
+	}
+
+	// 非生产环境安装故障注入钩子
+	if err := chaos.Init(cmd.Context()); err != nil {
+		return fmt.Errorf("init chaos fail: %w", err)
+	}
+
 	return nil
 }
 
@@ -181,6 +220,7 @@ func cleanSchedule(cmd *cobra.Command, _ []string) error {
 	events.NewEvents().Close(cmd.Context())
 	redis.CloseRedis(cmd.Context())
 	db.ClosePostgres(cmd.Context())
+	db.CloseEventDB(cmd.Context())
 	trace.CloseTrace()
 	return nil
 }
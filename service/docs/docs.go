@@ -230,14 +230,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/edge/material": {
-            "put": {
+        "/v1/admin/assistant/audit": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "边缘端批量更新或插入物料",
+                "description": "发送给大模型的提示词和回答，包括失败的调用，只有管理员可以查看",
                 "consumes": [
                     "application/json"
                 ],
@@ -245,23 +245,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "Assistant"
                 ],
-                "summary": "边缘端更新/插入物料",
+                "summary": "获取助手审计记录",
                 "parameters": [
                     {
-                        "description": "边缘端更新/插入物料请求",
-                        "name": "material",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/material.UpsertMaterialReq"
-                        }
+                        "type": "string",
+                        "description": "调用类型 (diagnose, query)",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "用户ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -270,8 +291,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_AssistantAudit"
                                         }
                                     }
                                 }
@@ -279,14 +300,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "从边缘端创建物料（带 UUID 的节点）",
+            }
+        },
+        "/v1/admin/chaos": {
+            "get": {
+                "description": "返回当前实例注入的 redis 延迟、数据库错误和调度消息丢弃比例。生产环境不提供，仅管理员可查看",
                 "consumes": [
                     "application/json"
                 ],
@@ -294,23 +312,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
-                ],
-                "summary": "边缘端创建物料",
-                "parameters": [
-                    {
-                        "description": "边缘端创建物料请求",
-                        "name": "material",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/material.CreateMaterialReq"
-                        }
-                    }
+                    "Chaos"
                 ],
+                "summary": "故障注入配置",
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -319,8 +326,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/chaos.ChaosConf"
                                         }
                                     }
                                 }
@@ -328,26 +335,33 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/v1/edge/material/download": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
+            },
+            "put": {
+                "description": "修改处理该请求的实例注入的故障，用于验证本地限流、fail-open 和重试等降级路径，重启后恢复为配置文件的值。生产环境不提供，仅管理员可操作",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "边缘端下载物料图",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "Chaos"
+                ],
+                "summary": "修改故障注入配置",
+                "parameters": [
+                    {
+                        "description": "注入的故障",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/chaos.ChaosConf"
+                        }
+                    }
                 ],
-                "summary": "边缘端下载物料",
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -356,8 +370,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/chaos.ChaosConf"
                                         }
                                     }
                                 }
@@ -367,14 +381,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/edge/material/edge": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "从边缘端创建物料之间的连线",
+        "/v1/admin/health": {
+            "get": {
+                "description": "返回处理该请求的实例最近 5 分钟的请求速率、5xx 错误率、限流拒绝和降载数，数据库和 redis 耗时分位数，以及所有实例共享的排队执行数、死信队列、后台任务状态和最近的配置、功能开关、维护和任务调度变更。失败的分区为 null，原因在 errors 中按分区返回。仅管理员可查看",
                 "consumes": [
                     "application/json"
                 ],
@@ -382,23 +391,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
-                ],
-                "summary": "边缘端创建物料连线",
-                "parameters": [
-                    {
-                        "description": "创建连线请求",
-                        "name": "edges",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/material.CreateMaterialEdgeReq"
-                        }
-                    }
+                    "SystemHealth"
                 ],
+                "summary": "获取系统健康汇总",
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -407,8 +405,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/systemhealth.Response"
                                         }
                                     }
                                 }
@@ -418,14 +416,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/edge/material/query": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "通过 UUID 列表查询物料信息",
+        "/v1/admin/history/dump": {
+            "get": {
+                "description": "按实验室和时间段分页读取脱敏后的历史记录，供 ` + "`" + `history copy` + "`" + ` 命令复制到本地开发环境。用户 ID 替换为稳定的别名，邮箱、手机号和 IP 被屏蔽，JSON 中的敏感字段被移除。生产环境不提供，仅管理员可操作",
                 "consumes": [
                     "application/json"
                 ],
@@ -433,23 +426,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "HistoryCopy"
                 ],
-                "summary": "按 UUID 查询物料",
+                "summary": "读取待复制的历史记录",
                 "parameters": [
                     {
-                        "description": "物料 UUID 列表",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/material.MaterialQueryReq"
-                        }
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "workflow",
+                            "action",
+                            "device_event"
+                        ],
+                        "type": "string",
+                        "description": "记录类型",
+                        "name": "kind",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "上一页返回的 next_after_id",
+                        "name": "after_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 500，最大 2000",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -458,8 +485,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/historycopy.DumpResp"
                                         }
                                     }
                                 }
@@ -469,14 +496,13 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab": {
-            "post": {
+        "/v1/admin/impersonation": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "创建一个新的实验室环境",
                 "consumes": [
                     "application/json"
                 ],
@@ -484,23 +510,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Impersonation"
                 ],
-                "summary": "创建实验室环境",
+                "summary": "获取模拟会话列表",
                 "parameters": [
                     {
-                        "description": "实验室环境创建请求",
-                        "name": "lab",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/environment.LaboratoryEnvReq"
-                        }
+                        "type": "boolean",
+                        "description": "只返回有效的会话",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -509,8 +544,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_ImpersonationSession"
                                         }
                                     }
                                 }
@@ -519,13 +554,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "删除一个实验室环境",
+                "description": "管理员以实验室成员的身份访问该实验室，用于复现用户遇到的问题。返回的令牌以 \"Impersonate \u003ctoken\u003e\" 作为 Authorization 请求头，会话到期或撤销后失效，每个请求都写入审计日志",
                 "consumes": [
                     "application/json"
                 ],
@@ -533,23 +568,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Impersonation"
                 ],
-                "summary": "删除实验室环境",
+                "summary": "创建模拟会话",
                 "parameters": [
                     {
-                        "description": "删除实验室请求",
-                        "name": "lab",
+                        "description": "模拟会话",
+                        "name": "req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/environment.DelLabReq"
+                            "$ref": "#/definitions/impersonation.StartReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -558,8 +593,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/impersonation.StartResp"
                                         }
                                     }
                                 }
@@ -567,14 +602,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/v1/admin/impersonation/audit": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "更新一个已存在的实验室环境",
+                "description": "模拟会话的创建、撤销以及模拟会话发起的每个请求",
                 "consumes": [
                     "application/json"
                 ],
@@ -582,23 +619,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Impersonation"
                 ],
-                "summary": "更新实验室环境",
+                "summary": "获取审计日志",
                 "parameters": [
                     {
-                        "description": "实验室环境更新请求",
-                        "name": "lab",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/environment.UpdateEnvReq"
-                        }
+                        "type": "string",
+                        "description": "会话UUID",
+                        "name": "session_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "管理员ID",
+                        "name": "impersonator_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -607,8 +665,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_AuditLog"
                                         }
                                     }
                                 }
@@ -618,14 +676,13 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/invite/{lab_uuid}": {
-            "post": {
+        "/v1/admin/impersonation/{uuid}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "为实验室创建邀请链接",
                 "consumes": [
                     "application/json"
                 ],
@@ -633,21 +690,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Impersonation"
                 ],
-                "summary": "创建邀请链接",
+                "summary": "撤销模拟会话",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "实验室UUID",
-                        "name": "lab_uuid",
+                        "description": "会话UUID",
+                        "name": "uuid",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/jobs": {
+            "get": {
+                "description": "获取所有后台任务的调度配置、暂停状态与最近一次运行结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Jobs"
+                ],
+                "summary": "获取后台任务列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -656,8 +736,11 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/jobs.JobResponse"
+                                            }
                                         }
                                     }
                                 }
@@ -667,14 +750,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/invite/{uuid}": {
-            "get": {
-                "security": [
+        "/v1/admin/jobs/{name}/pause": {
+            "post": {
+                "description": "暂停任务的定时调度，正在运行的实例不受影响",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Jobs"
+                ],
+                "summary": "暂停后台任务",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "任务名称",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "description": "通过邀请链接加入实验室",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/jobs/{name}/resume": {
+            "post": {
+                "description": "恢复任务的定时调度",
                 "consumes": [
                     "application/json"
                 ],
@@ -682,48 +792,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Jobs"
                 ],
-                "summary": "接受邀请",
+                "summary": "恢复后台任务",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "邀请链接UUID",
-                        "name": "uuid",
+                        "description": "任务名称",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Resp"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
             }
         },
-        "/v1/lab/list": {
+        "/v1/admin/jobs/{name}/runs": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "获取当前用户的所有实验室",
+                "description": "分页获取后台任务的运行历史，最新的在前",
                 "consumes": [
                     "application/json"
                 ],
@@ -731,24 +824,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Jobs"
                 ],
-                "summary": "获取实验室列表",
+                "summary": "获取后台任务运行记录",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务名称",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
+                        "default": 1,
+                        "description": "页码",
                         "name": "page",
                         "in": "query"
                     },
                     {
                         "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
                         "name": "page_size",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -757,8 +861,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_BackgroundJobRun"
                                         }
                                     }
                                 }
@@ -768,14 +872,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/material": {
-            "get": {
-                "security": [
+        "/v1/admin/jobs/{name}/trigger": {
+            "post": {
+                "description": "请求主副本尽快执行一次任务，不影响原有调度，暂停中的任务同样可以手动执行",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Jobs"
+                ],
+                "summary": "立即执行后台任务",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "任务名称",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "description": "查询实验室物料",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/legal-hold": {
+            "get": {
                 "consumes": [
                     "application/json"
                 ],
@@ -783,24 +913,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "LegalHold"
                 ],
-                "summary": "查询物料",
+                "summary": "法律保全列表",
                 "parameters": [
                     {
                         "type": "string",
-                        "name": "id",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
                         "in": "query"
                     },
                     {
                         "type": "boolean",
-                        "name": "with_children",
+                        "description": "只看生效中或已解除的保全",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -809,8 +953,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-legalhold_HoldResp"
                                         }
                                     }
                                 }
@@ -819,13 +963,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "批量更新物料的唯一名称",
+            "post": {
+                "description": "对单次执行、工作流、实验室或时间段内的历史记录设置法律保全，保全期间保留策略不会清理这些记录。保全设置后不能修改，只能解除，设置和解除都会写入审计日志。仅管理员可操作",
                 "consumes": [
                     "application/json"
                 ],
@@ -833,23 +972,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "LegalHold"
                 ],
-                "summary": "批量更新物料",
+                "summary": "设置法律保全",
                 "parameters": [
                     {
-                        "description": "批量更新物料请求",
-                        "name": "material",
+                        "description": "保全范围",
+                        "name": "req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/material.UpdateMaterialReq"
+                            "$ref": "#/definitions/legalhold.PlaceReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -858,8 +997,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/legalhold.HoldResp"
                                         }
                                     }
                                 }
@@ -867,14 +1006,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/v1/admin/legal-hold/{uuid}/release": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "在实验室中创建物料图（节点与边）",
+                "description": "解除后记录按保留策略正常清理，已解除的保全不能再次解除",
                 "consumes": [
                     "application/json"
                 ],
@@ -882,23 +1018,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "LegalHold"
                 ],
-                "summary": "创建物料图",
+                "summary": "解除法律保全",
                 "parameters": [
                     {
-                        "description": "物料图创建请求",
-                        "name": "material",
+                        "type": "string",
+                        "description": "保全UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "解除原因",
+                        "name": "req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/material.GraphNodeReq"
+                            "$ref": "#/definitions/legalhold.ReleaseReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -907,8 +1050,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/legalhold.HoldResp"
                                         }
                                     }
                                 }
@@ -918,14 +1061,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/material/device/actions": {
-            "get": {
+        "/v1/admin/maintenance": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取设备对应的可用动作列表",
+                "description": "整个服务进入只读维护，写请求返回 503，调度器暂停领取任务。已在维护时更新提示和预计结束时间",
                 "consumes": [
                     "application/json"
                 ],
@@ -933,28 +1076,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "Maintenance"
                 ],
-                "summary": "设备可用动作",
+                "summary": "开始全局维护",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "实验室UUID",
-                        "name": "lab_uuid",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "设备名称",
-                        "name": "name",
-                        "in": "query",
-                        "required": true
+                        "description": "维护信息",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/maintenance.StartReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -963,8 +1101,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.MaintenanceWindow"
                                         }
                                     }
                                 }
@@ -972,16 +1110,38 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance"
+                ],
+                "summary": "结束全局维护",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
             }
         },
-        "/v1/lab/material/edge": {
-            "post": {
+        "/v1/admin/maintenance/lab/{lab_uuid}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "创建两个物料节点之间的连线",
+                "description": "实验室进入只读维护，该实验室的写请求返回 503，调度器暂停领取该实验室的任务",
                 "consumes": [
                     "application/json"
                 ],
@@ -989,23 +1149,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "Maintenance"
                 ],
-                "summary": "创建物料连线",
+                "summary": "开始实验室维护",
                 "parameters": [
                     {
-                        "description": "物料连线请求",
-                        "name": "edges",
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "维护信息",
+                        "name": "req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/material.GraphEdge"
+                            "$ref": "#/definitions/maintenance.StartReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1014,8 +1181,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.MaintenanceWindow"
                                         }
                                     }
                                 }
@@ -1023,44 +1190,55 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/v1/lab/material/resource": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取实验室的所有资源（设备）列表",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "Maintenance"
                 ],
-                "summary": "获取资源列表",
+                "summary": "结束实验室维护",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "实验室UUID",
                         "name": "lab_uuid",
-                        "in": "query",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "资源类型",
-                        "name": "type",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/migrations": {
+            "get": {
+                "description": "返回 AutoMigrate 的模型结构版本和每个版本化迁移是否已执行，未执行的迁移可通过 migrate up 命令执行。仅管理员可查看",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Migration"
+                ],
+                "summary": "数据库迁移状态",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1069,8 +1247,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/migrate.Status"
                                         }
                                     }
                                 }
@@ -1080,14 +1258,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/material/save": {
+        "/v1/admin/seed": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "保存当前实验室的物料图",
+                "description": "为实验室生成工作流执行、动作和设备事件历史，用于演示和统计接口的压测。实验室没有设备或工作流时会创建演示设备和工作流，生成的执行带有 seed=demo 标签。生产环境不提供，仅管理员可操作",
                 "consumes": [
                     "application/json"
                 ],
@@ -1095,23 +1268,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "Seed"
                 ],
-                "summary": "保存物料图",
+                "summary": "生成演示历史数据",
                 "parameters": [
                     {
-                        "description": "保存物料图请求",
-                        "name": "material",
+                        "description": "生成参数",
+                        "name": "req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/material.SaveGrapReq"
+                            "$ref": "#/definitions/seed.SeedReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1120,8 +1293,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/seed.SeedResp"
                                         }
                                     }
                                 }
@@ -1131,33 +1304,38 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/material/template/{template_uuid}": {
-            "get": {
+        "/v1/assistant/diagnose": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取物料模板详情",
+                "description": "汇总执行的步骤、错误、节点日志和执行期间的设备事件，由配置的大模型给出失败原因和处理建议。需开启 ai_assistant 功能，每个用户每小时的次数有限制，发送的提示词会被审计",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Material"
+                    "Assistant"
                 ],
-                "summary": "获取模板详情",
+                "summary": "诊断执行失败原因",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "模板UUID",
-                        "name": "template_uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "执行UUID",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/assistant.DiagnoseReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1166,8 +1344,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.Diagnosis"
                                         }
                                     }
                                 }
@@ -1177,46 +1355,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/member/{lab_uuid}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "获取指定实验室的成员列表",
+        "/v1/edge/actions": {
+            "post": {
+                "description": "edge 批量上报动作的开始（status 为 running）和结束，开始和结束使用 edge 生成的同一个 uuid，设备必须已在实验室注册，按 workflow_execution_uuid 关联实验室的工作流执行。请求体为 {\"actions\": [...]}，或 Content-Type: application/x-ndjson 时每行一个动作，支持 Content-Encoding: gzip。新结束的动作在同一事务中计入执行的步骤计数，重复上报已结束的动作不会重复计数；无效的动作被拒绝而其余动作照常写入，存在被拒绝的动作时响应状态码为 207",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/x-ndjson"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "EdgeEvent"
                 ],
-                "summary": "获取实验室成员",
+                "summary": "边缘端批量上报动作执行",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "实验室UUID",
-                        "name": "lab_uuid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page_size",
-                        "in": "query"
+                        "description": "动作，条目为 edgeevent.ActionReq",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/edgeevent.ActionIngestReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1225,25 +1391,48 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/edgeevent.IngestResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/edgeevent.IngestResp"
                                         }
                                     }
                                 }
                             ]
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "/v1/lab/member/{lab_uuid}/{member_uuid}": {
-            "delete": {
+        "/v1/edge/camera": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "从实验室中删除一个成员",
+                "description": "edge 批量注册或更新设备摄像头，host_id 为 edge 连接实时信令时使用的 id，同一 camera_id 整体覆盖",
                 "consumes": [
                     "application/json"
                 ],
@@ -1251,28 +1440,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Camera"
                 ],
-                "summary": "删除实验室成员",
+                "summary": "边缘端注册摄像头",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "实验室UUID",
-                        "name": "lab_uuid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "成员UUID",
-                        "name": "member_uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "摄像头注册请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/stream.RegisterReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1281,8 +1465,11 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.CameraStream"
+                                            }
                                         }
                                     }
                                 }
@@ -1292,14 +1479,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/resource": {
-            "post": {
+        "/v1/edge/camera/{camera_id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "从边缘端创建实验室资源",
+                "description": "edge 移除摄像头，正在观看的会话随之结束",
                 "consumes": [
                     "application/json"
                 ],
@@ -1307,45 +1494,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Camera"
                 ],
-                "summary": "创建实验室资源",
+                "summary": "边缘端注销摄像头",
                 "parameters": [
                     {
-                        "description": "资源请求",
-                        "name": "resource",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/environment.ResourceReq"
-                        }
+                        "type": "string",
+                        "description": "摄像头ID",
+                        "name": "camera_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Resp"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
             }
         },
-        "/v1/lab/run/workflow": {
-            "put": {
-                "description": "通过 HTTP 启动工作流任务，返回任务 UUID",
+        "/v1/edge/command": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "edge 长轮询拉取下一条指令，wait 为最长等待秒数（最大 25 秒），无指令时返回空",
                 "consumes": [
                     "application/json"
                 ],
@@ -1353,23 +1531,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "DeviceCommand"
                 ],
-                "summary": "启动工作流（无鉴权）",
+                "summary": "边缘端拉取设备指令",
                 "parameters": [
                     {
-                        "description": "启动请求",
-                        "name": "workflow",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/workflow.RunReq"
-                        }
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "设备名称，不传则拉取实验室全部设备",
+                        "name": "devices",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "最长等待秒数",
+                        "name": "wait",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1378,8 +1564,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceCommand"
                                         }
                                     }
                                 }
@@ -1389,14 +1575,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/user": {
-            "get": {
+        "/v1/edge/command/{uuid}/ack": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取当前用户信息",
+                "description": "edge 确认已收到指令，确认后开始计算结果超时",
                 "consumes": [
                     "application/json"
                 ],
@@ -1404,12 +1590,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "DeviceCommand"
+                ],
+                "summary": "边缘端确认设备指令",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "指令UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "获取用户信息",
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1418,8 +1613,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceCommand"
                                         }
                                     }
                                 }
@@ -1429,9 +1624,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/node/template/detail/{uuid}": {
-            "get": {
-                "description": "获取节点模板的详细信息",
+        "/v1/edge/command/{uuid}/result": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "edge 上报指令执行结果，写入设备事件并更新动作执行记录",
                 "consumes": [
                     "application/json"
                 ],
@@ -1439,21 +1639,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "DeviceCommand"
                 ],
-                "summary": "节点模板详情",
+                "summary": "边缘端上报指令结果",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "模板UUID",
+                        "description": "指令UUID",
                         "name": "uuid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "执行结果",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.ResultReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1462,8 +1671,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceCommand"
                                         }
                                     }
                                 }
@@ -1473,9 +1682,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/node/template/list": {
-            "get": {
-                "description": "获取实验室下的节点模板列表，支持按名称、标签和分页过滤",
+        "/v1/edge/device": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "edge 批量注册或更新设备及其能力描述，param_schema 为动作参数的 JSON Schema，同名设备整体覆盖",
                 "consumes": [
                     "application/json"
                 ],
@@ -1483,44 +1697,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Device"
                 ],
-                "summary": "节点模板列表",
+                "summary": "边缘端注册设备",
                 "parameters": [
                     {
-                        "type": "string",
-                        "name": "lab_uuid",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page_size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "tags",
-                        "in": "query"
+                        "description": "设备注册请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/device.RegisterReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1529,8 +1722,11 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Device"
+                                            }
                                         }
                                     }
                                 }
@@ -1540,9 +1736,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/node/template/tags/{lab_uuid}": {
-            "get": {
-                "description": "获取指定实验室的节点模板标签列表",
+        "/v1/edge/device/{name}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "edge 从设备注册表中移除设备，移除后该设备的动作不再做能力校验",
                 "consumes": [
                     "application/json"
                 ],
@@ -1550,43 +1751,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Device"
                 ],
-                "summary": "节点模板标签",
+                "summary": "边缘端注销设备",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "实验室UUID",
-                        "name": "lab_uuid",
+                        "description": "设备名称",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Resp"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
             }
         },
-        "/v1/lab/workflow/owner": {
-            "post": {
-                "description": "在指定实验室创建新的工作流",
+        "/v1/edge/device/{name}/config": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "edge 上报设备当前运行的配置，用于检测与分配配置的偏差",
                 "consumes": [
                     "application/json"
                 ],
@@ -1594,23 +1788,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "DeviceConfig"
                 ],
-                "summary": "创建工作流",
+                "summary": "边缘端上报设备配置",
                 "parameters": [
                     {
-                        "description": "工作流创建请求",
-                        "name": "workflow",
+                        "type": "string",
+                        "description": "设备名称",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "设备配置",
+                        "name": "req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/workflow.CreateReq"
+                            "$ref": "#/definitions/deviceconfig.ReportReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1619,8 +1820,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceConfigReport"
                                         }
                                     }
                                 }
@@ -1628,33 +1829,36 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "更新我创建的工作流（名称、发布状态、描述等）",
+            }
+        },
+        "/v1/edge/events": {
+            "post": {
+                "description": "使用设备密钥（Authorization: Device \u003ckey\u003e）批量上报设备事件。请求体为 {\"events\": [...]}，或 Content-Type: application/x-ndjson 时每行一个事件；Content-Encoding: gzip 时按解压后的大小限制。每个事件单独校验，无效的事件被拒绝而其余事件照常写入，存在被拒绝的事件时响应状态码为 207。超过设备密钥的上报频率时返回 429 和 Retry-After",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/x-ndjson"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "EdgeEvent"
                 ],
-                "summary": "更新工作流",
+                "summary": "设备批量上报事件",
                 "parameters": [
                     {
-                        "description": "工作流更新请求",
-                        "name": "workflow",
+                        "description": "事件，条目为 edgeevent.EventReq",
+                        "name": "req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/workflow.UpdateReq"
+                            "$ref": "#/definitions/edgeevent.IngestReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1663,20 +1867,43 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/edgeevent.IngestResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/edgeevent.IngestResp"
                                         }
                                     }
                                 }
                             ]
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "/v1/lab/workflow/owner/duplicate": {
-            "put": {
-                "description": "在目标实验室中复制现有工作流（自动匹配节点模板）",
+        "/v1/edge/file": {
+            "post": {
+                "description": "创建可续传的上传任务，之后通过 PATCH 按顺序上传分片。用户上传时 lab_uuid 必填，edge 上传使用所属实验室；可关联产生文件的动作执行记录",
                 "consumes": [
                     "application/json"
                 ],
@@ -1684,23 +1911,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "File"
                 ],
-                "summary": "复制工作流",
+                "summary": "创建文件上传",
                 "parameters": [
                     {
-                        "description": "复制请求",
-                        "name": "workflow",
+                        "description": "上传请求",
+                        "name": "req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/workflow.DuplicateReq"
+                            "$ref": "#/definitions/file.CreateReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1709,8 +1936,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.ResultFile"
                                         }
                                     }
                                 }
@@ -1720,9 +1947,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/owner/export": {
+        "/v1/edge/file/{uuid}": {
             "get": {
-                "description": "导出工作流为可跨实验室导入的 JSON 数据",
+                "description": "offset 为已接收的字节数，续传时从该位置开始",
                 "consumes": [
                     "application/json"
                 ],
@@ -1730,20 +1957,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "File"
                 ],
-                "summary": "导出工作流",
+                "summary": "获取文件及上传进度",
                 "parameters": [
                     {
                         "type": "string",
+                        "description": "文件UUID",
                         "name": "uuid",
-                        "in": "query",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1752,8 +1980,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.ResultFile"
                                         }
                                     }
                                 }
@@ -1761,11 +1989,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/v1/lab/workflow/owner/import": {
-            "post": {
-                "description": "将导出的工作流 JSON 导入到目标实验室",
+            },
+            "delete": {
+                "description": "删除文件，或取消未完成的上传",
                 "consumes": [
                     "application/json"
                 ],
@@ -1773,75 +1999,58 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "File"
                 ],
-                "summary": "导入工作流",
+                "summary": "删除文件",
                 "parameters": [
                     {
-                        "description": "导入请求",
-                        "name": "workflow",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/workflow.ImportReq"
-                        }
+                        "type": "string",
+                        "description": "文件UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Resp"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
-            }
-        },
-        "/v1/lab/workflow/owner/list": {
-            "get": {
-                "description": "获取我创建的工作流列表（滚动加载）",
+            },
+            "patch": {
+                "description": "请求体为分片原始内容，Upload-Offset 头必须等于已接收的字节数，否则返回当前偏移量供客户端续传。最后一个分片写入后校验内容类型、校验和并做病毒扫描",
                 "consumes": [
-                    "application/json"
+                    "application/offset+octet-stream"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "File"
                 ],
-                "summary": "工作流列表",
+                "summary": "上传文件分片",
                 "parameters": [
                     {
                         "type": "string",
-                        "name": "lab_uuid",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page",
-                        "in": "query"
+                        "description": "文件UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "name": "page_size",
-                        "in": "query"
+                        "description": "分片起始偏移量",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1850,8 +2059,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/model.ResultFile"
                                         }
                                     }
                                 }
@@ -1861,9 +2070,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/owner/{uuid}": {
-            "delete": {
-                "description": "删除我创建的工作流",
+        "/v1/edge/heartbeat": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "edge 定期上报心跳，devices 为空时视为实验室下所有设备在线，versions 按设备上报各自的 agent 版本，超过 offline_after_sec 未上报的设备标记为离线",
                 "consumes": [
                     "application/json"
                 ],
@@ -1871,21 +2085,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "DeviceLiveness"
                 ],
-                "summary": "删除工作流",
+                "summary": "边缘端心跳",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "工作流UUID",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "心跳请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/liveness.HeartbeatReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1894,8 +2110,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/liveness.HeartbeatResp"
                                         }
                                     }
                                 }
@@ -1905,31 +2121,38 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/task/download/{uuid}": {
-            "get": {
-                "description": "下载指定工作流的任务列表为 CSV 文件",
+        "/v1/edge/ingest": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "edge 在网络恢复后按序号重放离线期间缓存的动作执行结果，保留 edge 生成的 UUID 和原始时间；已写入的序号或 UUID 确认为 duplicate，无效的记录确认为 rejected",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/csv"
+                    "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Ingest"
                 ],
-                "summary": "下载工作流任务",
+                "summary": "边缘端重放缓存的执行结果",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "工作流UUID",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "缓存的记录",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ingest.IngestReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1938,8 +2161,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/ingest.IngestResp"
                                         }
                                     }
                                 }
@@ -1949,9 +2172,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/task/{uuid}": {
+        "/v1/edge/ingest/{stream}": {
             "get": {
-                "description": "获取指定工作流的任务列表（滚动分页）",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取流的高水位和缺失的序号，edge 可以丢弃高水位及以下的记录并从缺失的序号继续重放",
                 "consumes": [
                     "application/json"
                 ],
@@ -1959,31 +2187,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Ingest"
                 ],
-                "summary": "工作流任务列表",
+                "summary": "边缘端获取重放确认状态",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "工作流UUID",
-                        "name": "uuid",
+                        "description": "流标识",
+                        "name": "stream",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page_size",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -1992,8 +2210,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "$ref": "#/definitions/ingest.AckResp"
                                         }
                                     }
                                 }
@@ -2003,9 +2221,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/template/detail/{uuid}": {
-            "get": {
-                "description": "获取工作流的节点与边详情",
+        "/v1/edge/inventory/usage": {
+            "post": {
+                "description": "记录一次工作流执行消耗的物料批次并扣减剩余数量，任一批次剩余不足时整体失败。用户上报时 lab_uuid 必填",
                 "consumes": [
                     "application/json"
                 ],
@@ -2013,21 +2231,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Inventory"
                 ],
-                "summary": "工作流详情",
+                "summary": "记录物料消耗",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "工作流UUID",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "物料消耗",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/inventory.UsageReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "请求参数错误",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -2036,8 +2256,11 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "code": {
-                                            "$ref": "#/definitions/code.ErrCode"
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.MaterialUsage"
+                                            }
                                         }
                                     }
                                 }
@@ -2047,9 +2270,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/template/fork": {
+        "/v1/edge/material": {
             "put": {
-                "description": "将已有工作流模板 Fork 到目标实验室",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "边缘端批量更新或插入物料",
                 "consumes": [
                     "application/json"
                 ],
@@ -2057,21 +2285,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Material"
                 ],
-                "summary": "Fork 工作流模板",
+                "summary": "边缘端更新/插入物料",
                 "parameters": [
                     {
-                        "type": "string",
-                        "name": "source_workflow_uuid",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "name": "target_lab_uuid",
-                        "in": "query",
-                        "required": true
+                        "description": "边缘端更新/插入物料请求",
+                        "name": "material",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/material.UpsertMaterialReq"
+                        }
                     }
                 ],
                 "responses": {
@@ -2094,11 +2319,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/v1/lab/workflow/template/list": {
-            "get": {
-                "description": "获取工作流模板列表，支持按标签与分页过滤",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "从边缘端创建物料（带 UUID 的节点）",
                 "consumes": [
                     "application/json"
                 ],
@@ -2106,34 +2334,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Material"
                 ],
-                "summary": "工作流模板列表",
+                "summary": "边缘端创建物料",
                 "parameters": [
                     {
-                        "type": "string",
-                        "name": "lab_uuid",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page_size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "tags",
-                        "in": "query"
+                        "description": "边缘端创建物料请求",
+                        "name": "material",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/material.CreateMaterialReq"
+                        }
                     }
                 ],
                 "responses": {
@@ -2158,19 +2370,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/template/tags": {
+        "/v1/edge/material/download": {
             "get": {
-                "description": "获取全局工作流模板标签列表",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "边缘端下载物料图",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Material"
                 ],
-                "summary": "工作流模板标签",
+                "summary": "边缘端下载物料",
                 "responses": {
                     "200": {
                         "description": "请求参数错误",
@@ -2193,9 +2407,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/workflow/ws/workflow/{uuid}": {
-            "get": {
-                "description": "连接到指定工作流的 WebSocket 会话，用于实时编辑与运行",
+        "/v1/edge/material/edge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "从边缘端创建物料之间的连线",
                 "consumes": [
                     "application/json"
                 ],
@@ -2203,16 +2422,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workflow"
+                    "Material"
                 ],
-                "summary": "工作流 WebSocket",
+                "summary": "边缘端创建物料连线",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "工作流UUID",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "创建连线请求",
+                        "name": "edges",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/material.CreateMaterialEdgeReq"
+                        }
                     }
                 ],
                 "responses": {
@@ -2237,14 +2458,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/lab/{lab_uuid}": {
-            "get": {
+        "/v1/edge/material/query": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取单个实验室的详细信息",
+                "description": "通过 UUID 列表查询物料信息",
                 "consumes": [
                     "application/json"
                 ],
@@ -2252,22 +2473,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Laboratory"
+                    "Material"
                 ],
-                "summary": "获取实验室信息",
+                "summary": "按 UUID 查询物料",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "实验室UUID",
-                        "name": "lab_uuid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "是否包含成员列表",
-                        "name": "with_member",
-                        "in": "query"
+                        "description": "物料 UUID 列表",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/material.MaterialQueryReq"
+                        }
                     }
                 ],
                 "responses": {
@@ -2291,56 +2508,10739 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "code.ErrCode": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3,
-                1000,
-                1001,
-                1002,
-                1003,
-                5000,
-                5001,
-                5002,
-                5003,
-                5004,
-                5005,
-                5006,
-                5007,
-                5008,
-                5009,
-                5010,
-                5011,
-                5012,
-                10000,
-                10001,
-                10002,
-                10003,
-                10004,
-                10005,
-                10006,
-                10007,
-                10008,
-                10009,
-                10010,
-                20000,
-                20001,
-                20002,
-                20003,
-                20004,
-                20005,
-                20006,
-                20007,
-                20008,
-                20009,
-                20010,
-                22000,
+        },
+        "/v1/events/schemas": {
+            "get": {
+                "description": "返回所有 webhook 和事件总线事件的类型、已发布版本、事件信封 schema 以及 schema 演进规则",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "EventSchema"
+                ],
+                "summary": "获取事件 schema 列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/eventschema.CatalogResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/events/schemas/envelope": {
+            "get": {
+                "description": "事件总线上的事件都包裹在信封中，webhook 以信封的 data 为请求体，其他字段放在 X-Studio-Event 请求头中",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "EventSchema"
+                ],
+                "summary": "获取事件信封 schema",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/events/schemas/{type}/{version}": {
+            "get": {
+                "description": "返回事件类型某个版本的 JSON Schema，已发布的版本不会修改",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "EventSchema"
+                ],
+                "summary": "获取事件 schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "事件类型，如 execution.completed",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "schema 版本",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/export/download": {
+            "get": {
+                "description": "使用签名下载链接下载实验室数据归档",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "LabExport"
+                ],
+                "summary": "下载导出归档",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "下载令牌",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/file/download": {
+            "get": {
+                "description": "使用签名下载链接下载文件内容",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "下载文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "下载令牌",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/history/export/download": {
+            "get": {
+                "description": "使用签名下载链接下载导出的 NDJSON 文件，每行一条记录",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "下载历史记录导出",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "下载令牌",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "创建一个新的实验室环境",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "创建实验室环境",
+                "parameters": [
+                    {
+                        "description": "实验室环境创建请求",
+                        "name": "lab",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/environment.LaboratoryEnvReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除一个实验室环境",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "删除实验室环境",
+                "parameters": [
+                    {
+                        "description": "删除实验室请求",
+                        "name": "lab",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/environment.DelLabReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "更新一个已存在的实验室环境",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "更新实验室环境",
+                "parameters": [
+                    {
+                        "description": "实验室环境更新请求",
+                        "name": "lab",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/environment.UpdateEnvReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/action/result/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根据任务 UUID 查询动作执行结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Action"
+                ],
+                "summary": "查询动作执行结果",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询失败",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/action/run": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "手动触发设备执行指定动作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Action"
+                ],
+                "summary": "执行设备动作",
+                "parameters": [
+                    {
+                        "description": "动作执行请求",
+                        "name": "action",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/action.RunActionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/agent/upgrade": {
+            "get": {
+                "description": "获取实验室的升级任务，新的在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AgentUpgrade"
+                ],
+                "summary": "获取 agent 升级任务列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.AgentUpgradeCampaign"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "为符合过滤条件且版本不是目标版本的设备创建升级任务，按设备名称顺序通过设备指令队列向前 rollout_percent 的设备下发升级，仅实验室创建者可以操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AgentUpgrade"
+                ],
+                "summary": "创建 agent 升级任务",
+                "parameters": [
+                    {
+                        "description": "升级任务",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/upgrade.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/upgrade.CampaignResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/agent/upgrade/{uuid}": {
+            "get": {
+                "description": "获取升级任务的进度和各设备的升级状态",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AgentUpgrade"
+                ],
+                "summary": "获取 agent 升级任务详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "升级任务UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/upgrade.CampaignResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/agent/upgrade/{uuid}/pause": {
+            "post": {
+                "description": "暂停后不再下发新的升级，已下发的升级继续执行",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AgentUpgrade"
+                ],
+                "summary": "暂停 agent 升级任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "升级任务UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/upgrade.CampaignResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/agent/upgrade/{uuid}/rollback": {
+            "post": {
+                "description": "取消未下发的升级，为已下发升级的设备下发回到升级前版本的指令",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AgentUpgrade"
+                ],
+                "summary": "回滚 agent 升级任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "升级任务UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/upgrade.CampaignResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/agent/upgrade/{uuid}/rollout": {
+            "put": {
+                "description": "调整升级任务的放量比例，暂停的任务同时恢复运行",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AgentUpgrade"
+                ],
+                "summary": "调整 agent 升级放量比例",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "升级任务UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "放量比例",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/upgrade.RolloutReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/upgrade.CampaignResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/alert": {
+            "get": {
+                "description": "分页获取实验室告警，按触发时间倒序",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alert"
+                ],
+                "summary": "获取告警列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "状态过滤 (firing, acked, resolved)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "级别过滤 (info, warning, critical)",
+                        "name": "severity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备UUID",
+                        "name": "device_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "负责人ID",
+                        "name": "assignee_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "触发时间起 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "触发时间止 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_Alert"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/alert/silence": {
+            "get": {
+                "description": "获取实验室当前及将来的静默窗口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alert"
+                ],
+                "summary": "获取告警静默列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.AlertSilence"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "静默设备或整个实验室的告警，窗口内触发的告警不发送通知也不升级，最长 30 天",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alert"
+                ],
+                "summary": "创建告警静默",
+                "parameters": [
+                    {
+                        "description": "静默请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/alert.SilenceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.AlertSilence"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/alert/silence/{uuid}": {
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alert"
+                ],
+                "summary": "删除告警静默",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "静默UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/alert/{uuid}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alert"
+                ],
+                "summary": "获取告警详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "告警UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Alert"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/alert/{uuid}/ack": {
+            "post": {
+                "description": "确认触发中的告警，确认后不再升级",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alert"
+                ],
+                "summary": "确认告警",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "告警UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Alert"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/alert/{uuid}/assign": {
+            "put": {
+                "description": "指派告警负责人，负责人必须是实验室成员，assignee_id 为空时取消指派",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alert"
+                ],
+                "summary": "指派告警",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "告警UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "指派请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/alert.AssignReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Alert"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/alert/{uuid}/resolve": {
+            "post": {
+                "description": "手动恢复告警，规则条件仍满足时会重新触发",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alert"
+                ],
+                "summary": "恢复告警",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "告警UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Alert"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/approval/pending": {
+            "get": {
+                "description": "获取当前用户所在实验室中等待人工审批的工作流步骤，不传实验室时返回所有所在实验室",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Approval"
+                ],
+                "summary": "获取待审批列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-approval_ApprovalResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/approval/{uuid}/approve": {
+            "post": {
+                "description": "通过工作流步骤的审批，调度器将继续执行该步骤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Approval"
+                ],
+                "summary": "审批通过",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "审批UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "审批意见",
+                        "name": "req",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/approval.DecideRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/approval.ApprovalResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/approval/{uuid}/reject": {
+            "post": {
+                "description": "拒绝工作流步骤的审批，调度器将终止该步骤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Approval"
+                ],
+                "summary": "审批拒绝",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "审批UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "审批意见",
+                        "name": "req",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/approval.DecideRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/approval.ApprovalResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/camera": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Camera"
+                ],
+                "summary": "获取实验室摄像头",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.CameraStream"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/camera/session": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Camera"
+                ],
+                "summary": "获取正在进行的观看会话",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.StreamSession"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "需开启 realtime_camera 功能。返回短期有效的观看令牌，连接 /api/realtime/signal/client 时通过 token 参数传入，连接断开后会话结束",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Camera"
+                ],
+                "summary": "开始观看摄像头",
+                "parameters": [
+                    {
+                        "description": "观看请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/stream.StartSessionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/stream.StartSessionResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/camera/session/{uuid}/stop": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Camera"
+                ],
+                "summary": "结束观看会话",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "会话UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.StreamSession"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/cost": {
+            "get": {
+                "description": "按类别、计费项和日期汇总实验室费用，默认最近 30 天",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cost"
+                ],
+                "summary": "获取实验室费用明细",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始日期 (2006-01-02)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束日期 (2006-01-02)，包含当天",
+                        "name": "end",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/cost.BreakdownResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/cost/export": {
+            "get": {
+                "description": "导出计费系统使用的 CSV，每行为一天一个计费项的费用，account_id 为实验室UUID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Cost"
+                ],
+                "summary": "导出计费数据",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始日期 (2006-01-02)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束日期 (2006-01-02)，包含当天",
+                        "name": "end",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/cost/model": {
+            "get": {
+                "description": "获取实验室生效的计费模型，未单独设置时返回全局默认模型",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cost"
+                ],
+                "summary": "获取实验室计费模型",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.CostModel"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "实验室创建者设置机时、动作类型与存储单价，新价格从最近未结算的日期开始生效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cost"
+                ],
+                "summary": "设置实验室计费模型",
+                "parameters": [
+                    {
+                        "description": "计费模型",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/cost.ModelReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.CostModel"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/deadletter": {
+            "get": {
+                "description": "获取实验室中重试耗尽的调度任务",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeadLetter"
+                ],
+                "summary": "获取死信任务列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "状态过滤 (dead, requeued)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_DeadLetterJob"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/deadletter/{uuid}": {
+            "get": {
+                "description": "获取死信任务的完整消息及失败记录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeadLetter"
+                ],
+                "summary": "获取死信任务详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "死信UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeadLetterJob"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/deadletter/{uuid}/requeue": {
+            "post": {
+                "description": "将死信任务的原始消息重新投递到原队列",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeadLetter"
+                ],
+                "summary": "重新投递死信任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "死信UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device-key": {
+            "get": {
+                "description": "获取实验室的设备密钥，包括已撤销的密钥，仅实验室管理员可以操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "EdgeEvent"
+                ],
+                "summary": "获取设备密钥列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceAPIKey"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "创建设备上报事件使用的密钥，指定设备名称时只能上报该设备的事件。密钥明文只在创建时返回，仅实验室管理员可以操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "EdgeEvent"
+                ],
+                "summary": "创建设备密钥",
+                "parameters": [
+                    {
+                        "description": "设备密钥",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/edgeevent.CreateKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/edgeevent.CreateKeyResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device-key/{lab_uuid}/{uuid}": {
+            "delete": {
+                "description": "撤销后使用该密钥的上报立即被拒绝，仅实验室管理员可以操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "EdgeEvent"
+                ],
+                "summary": "撤销设备密钥",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备密钥UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/capacity/{lab_uuid}": {
+            "get": {
+                "description": "获取实验室已注册设备数与设备上限，max_devices 为 0 表示不限制",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "获取实验室设备容量",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/device.CapacityResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "实验室创建者单独设置设备上限，覆盖全局配置；低于已注册设备数时只阻止新增设备",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "设置实验室设备上限",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "设备上限",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/device.SetLimitReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/device.CapacityResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "删除实验室单独设置的设备上限，恢复使用全局配置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "恢复实验室默认设备上限",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/device.CapacityResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/command": {
+            "get": {
+                "description": "分页获取实验室设备指令，可按设备和状态过滤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceCommand"
+                ],
+                "summary": "获取设备指令列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备名称",
+                        "name": "device_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "状态过滤 (queued, delivered, acked, succeeded, failed, timeout)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_DeviceCommand"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "校验设备能力后将指令加入设备队列，由 edge 拉取执行，同时创建待执行的动作记录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceCommand"
+                ],
+                "summary": "下发设备指令",
+                "parameters": [
+                    {
+                        "description": "指令请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.EnqueueReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceCommand"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/command/group": {
+            "get": {
+                "description": "分页获取实验室的设备组指令及各自的汇总结果，新的在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceCommand"
+                ],
+                "summary": "获取设备组指令列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-command_GroupCommandResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "将指令扇出到设备组（或房间、工作台）下的所有设备，每个设备一条指令；不支持该动作或参数不符合能力声明的设备跳过并在 skipped 中返回",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceCommand"
+                ],
+                "summary": "下发设备组指令",
+                "parameters": [
+                    {
+                        "description": "设备组指令请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.GroupEnqueueReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/command.GroupCommandResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/command/group/{uuid}": {
+            "get": {
+                "description": "获取设备组指令的汇总结果：有未结束的指令时为 running，全部成功为 succeeded，部分失败或超时为 partial，全部失败或超时为 failed；同时返回每个设备的指令",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceCommand"
+                ],
+                "summary": "获取设备组指令详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备组指令UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/command.GroupCommandResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/command/{uuid}": {
+            "get": {
+                "description": "获取指令状态、投递次数及执行结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceCommand"
+                ],
+                "summary": "获取设备指令详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "指令UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceCommand"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/assignment/{lab_uuid}": {
+            "get": {
+                "description": "获取实验室的设备级和设备类型级配置分配",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "获取设备配置分配",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceConfigAssignment"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/assignment/{uuid}": {
+            "delete": {
+                "description": "删除配置分配，设备上已应用的配置不变",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "删除设备配置分配",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分配UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/history/{lab_uuid}": {
+            "get": {
+                "description": "分页获取配置下发记录及其确认和应用状态，新的在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "获取设备配置下发历史",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备名称",
+                        "name": "device_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_DeviceConfigApply"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/profile": {
+            "get": {
+                "description": "获取实验室的配置模板，按名称排序",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "获取设备配置模板列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceConfigProfile"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "创建按设备类型区分的 JSON 配置模板，config 为第一个版本，仅实验室创建者可以操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "创建设备配置模板",
+                "parameters": [
+                    {
+                        "description": "配置模板",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deviceconfig.CreateProfileReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/deviceconfig.ProfileResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/profile/{uuid}": {
+            "get": {
+                "description": "获取配置模板及其所有版本，新版本在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "获取设备配置模板详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "配置模板UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/deviceconfig.ProfileResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/profile/{uuid}/assign": {
+            "post": {
+                "description": "将配置模板分配给设备，device_name 为空时分配给模板设备类型的所有设备；设备级分配优先于设备类型的分配",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "分配设备配置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "配置模板UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "分配",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deviceconfig.AssignReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceConfigAssignment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/profile/{uuid}/version": {
+            "post": {
+                "description": "为配置模板创建新版本，push 为 true 时立即下发到跟随最新版本的设备",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "创建设备配置版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "配置模板UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "配置版本",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deviceconfig.CreateVersionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceConfigVersion"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/push/{lab_uuid}": {
+            "post": {
+                "description": "通过设备指令队列下发设备当前分配的配置版本，device_names 为空时下发到所有分配了配置的设备",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "下发设备配置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "下发设备",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deviceconfig.PushReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceConfigApply"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/config/status/{lab_uuid}": {
+            "get": {
+                "description": "获取各设备分配的配置版本、最近成功应用的版本和最近一次下发，并比较设备上报的配置与分配的配置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceConfig"
+                ],
+                "summary": "获取设备配置状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceConfigStatus"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/liveness/{lab_uuid}": {
+            "get": {
+                "description": "获取实验室内设备最近一次心跳时间、edge 版本与 IP",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceLiveness"
+                ],
+                "summary": "获取设备在线状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceLiveness"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/liveness/{lab_uuid}/versions": {
+            "get": {
+                "description": "按心跳上报的 agent 版本统计实验室设备数和在线设备数",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceLiveness"
+                ],
+                "summary": "获取设备 agent 版本分布",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.AgentVersionCount"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/lock/{lab_uuid}": {
+            "get": {
+                "description": "获取实验室内所有被占用设备的持有者和排队情况",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceLock"
+                ],
+                "summary": "获取设备锁状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/lock.State"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/registry/{lab_uuid}": {
+            "get": {
+                "description": "获取实验室所有已注册设备及其能力描述",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "获取实验室设备注册表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Device"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/registry/{lab_uuid}/{name}": {
+            "get": {
+                "description": "获取单个设备的注册信息与支持的动作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "获取设备能力描述",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备名称",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Device"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/risk/{lab_uuid}": {
+            "get": {
+                "description": "获取实验室各设备最近一次的维护风险评分 (0-100)，由错误事件频率、动作失败率和动作时长漂移计算，风险高的在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceRisk"
+                ],
+                "summary": "获取设备维护风险评分",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceRiskScore"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/device/risk/{lab_uuid}/{device_uuid}": {
+            "get": {
+                "description": "按时间顺序返回设备的维护风险评分，默认最近 30 天，最长 366 天",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DeviceRisk"
+                ],
+                "summary": "获取设备维护风险评分历史",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备UUID",
+                        "name": "device_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceRiskScore"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/edge/ingest/{lab_uuid}": {
+            "get": {
+                "description": "获取实验室各 edge 重放流的高水位和写入统计",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ingest"
+                ],
+                "summary": "获取实验室重放流",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.EdgeIngestStream"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/experiment": {
+            "get": {
+                "description": "获取实验室的实验列表及各实验的执行进度",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiment"
+                ],
+                "summary": "实验列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "实验状态 active|completed|archived",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-experiment_ExperimentResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "创建实验，实验室成员可以操作，同一实验室内名称不能重复；提交工作流时传入 experiment_uuid 即可关联到实验",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiment"
+                ],
+                "summary": "创建实验",
+                "parameters": [
+                    {
+                        "description": "实验",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/experiment.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/experiment.ExperimentResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/experiment/{uuid}": {
+            "get": {
+                "description": "获取实验及其执行进度",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiment"
+                ],
+                "summary": "获取实验",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/experiment.ExperimentResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "修改实验名称、描述、状态或计划执行次数，归档的实验不再接受新的执行",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiment"
+                ],
+                "summary": "修改实验",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "修改内容",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/experiment.UpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/experiment.ExperimentResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/experiment/{uuid}/execution": {
+            "post": {
+                "description": "将实验室的工作流执行关联到实验，已关联到其他实验的执行会被移过来",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiment"
+                ],
+                "summary": "关联执行到实验",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "执行UUID",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/experiment.ExecutionsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/experiment.ExecutionsResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "将工作流执行移出实验",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiment"
+                ],
+                "summary": "从实验移出执行",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "执行UUID",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/experiment.ExecutionsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/experiment.ExecutionsResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/experiment/{uuid}/stats": {
+            "get": {
+                "description": "获取实验的执行进度、成功率、耗时及按工作流的统计",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Experiment"
+                ],
+                "summary": "实验统计",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ExperimentStats"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/export": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "LabExport"
+                ],
+                "summary": "获取实验室数据导出列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.LabExport"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "在后台将实验室的历史记录、设备事件、报表、批注和配置导出为 zip 归档，仅实验室管理员可操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "LabExport"
+                ],
+                "summary": "创建实验室数据导出",
+                "parameters": [
+                    {
+                        "description": "导出",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/labexport.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.LabExport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/export/{uuid}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "LabExport"
+                ],
+                "summary": "获取实验室数据导出进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "导出UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.LabExport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/export/{uuid}/url": {
+            "post": {
+                "description": "生成带有效期的下载链接，链接无需登录即可访问，有效期不超过归档过期时间",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "LabExport"
+                ],
+                "summary": "生成导出归档签名下载链接",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "导出UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/labexport.URLResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/file": {
+            "get": {
+                "description": "获取实验室已上传完成的文件，可按动作执行记录过滤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "获取文件列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "动作执行记录UUID",
+                        "name": "action_execution_uuid",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.ResultFile"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "创建可续传的上传任务，之后通过 PATCH 按顺序上传分片。用户上传时 lab_uuid 必填，edge 上传使用所属实验室；可关联产生文件的动作执行记录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "创建文件上传",
+                "parameters": [
+                    {
+                        "description": "上传请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ResultFile"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/file/{uuid}": {
+            "get": {
+                "description": "offset 为已接收的字节数，续传时从该位置开始",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "获取文件及上传进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ResultFile"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "删除文件，或取消未完成的上传",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "删除文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "请求体为分片原始内容，Upload-Offset 头必须等于已接收的字节数，否则返回当前偏移量供客户端续传。最后一个分片写入后校验内容类型、校验和并做病毒扫描",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "上传文件分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片起始偏移量",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ResultFile"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/file/{uuid}/url": {
+            "post": {
+                "description": "生成带有效期的下载链接，链接无需登录即可访问",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "生成签名下载链接",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/file.URLResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/action/{action_uuid}": {
+            "get": {
+                "description": "获取单个动作的执行信息及完整的输入输出，已压缩的动作从归档中读取",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取动作执行详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "动作UUID",
+                        "name": "action_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/history.ActionExecutionDetailResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/annotation/{uuid}": {
+            "put": {
+                "description": "仅作者可以编辑，编辑前的内容保存在编辑历史中",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Annotation"
+                ],
+                "summary": "编辑批注",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "批注UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "批注",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/annotation.UpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ExecutionAnnotation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "作者与实验室管理员可以删除，回复保留",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Annotation"
+                ],
+                "summary": "删除批注",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "批注UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/annotation/{uuid}/revisions": {
+            "get": {
+                "description": "按时间顺序返回每次编辑前的内容",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Annotation"
+                ],
+                "summary": "获取批注编辑历史",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "批注UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.ExecutionAnnotationRevision"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/chain/anchor": {
+            "get": {
+                "description": "获取实验室哈希链定期发布到外部的链头锚点及发布结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "哈希链锚点列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_HistoryChainAnchor"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/chain/verify": {
+            "get": {
+                "description": "逐条校验实验室的工作流执行和审计日志哈希链，重新计算记录内容的哈希并与已发布的锚点比对，返回发现的篡改、删除和断链问题。按保留策略清理的记录只校验链接",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "校验历史记录哈希链",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ChainVerifyResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/changes": {
+            "get": {
+                "description": "按水位返回之后新建或更新的工作流执行、动作执行和设备事件，按 (updated_at, kind, id) 排序。没有变更时最多等待 wait 秒（最大 25 秒）后返回空列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "增量同步历史记录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "上次响应的 watermark，不传时从头同步",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "最多返回的变更数，最大 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "没有变更时最长等待秒数",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/history.ListChangesResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/device": {
+            "get": {
+                "description": "获取实验室的设备事件历史记录。v2 返回 ListResponseV2，条目为 DeviceEventV2。Accept: application/x-ndjson 时不分页，按列表顺序逐行流式返回条目，最多返回配置的行数，最后一行为 {\"end\": StreamEnd}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取设备事件历史",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "设备ID (可选)",
+                        "name": "device_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID，只返回该房间、工作台或设备组下设备的事件",
+                        "name": "topology_node",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "事件类型过滤，未知的类型返回参数错误并列出允许的取值",
+                        "name": "event_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "保存的视图UUID，展开为视图的过滤条件",
+                        "name": "view_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否统计总数，为 false 时 total 为 -1，按 has_more 翻页",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "稀疏字段集，只返回并查询这些字段，字段名为所请求版本的响应字段，可重复或以逗号分隔",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-history_DeviceEventResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/error-rule": {
+            "get": {
+                "description": "按匹配顺序返回实验室的规则",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ErrorRule"
+                ],
+                "summary": "获取错误分类规则列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.ErrorRule"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "失败或超时的执行与动作按优先级依次匹配实验室的规则，第一个匹配的规则决定错误分类，都不匹配时为 unclassified",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ErrorRule"
+                ],
+                "summary": "创建错误分类规则",
+                "parameters": [
+                    {
+                        "description": "规则",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/errorrule.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ErrorRule"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/error-rule/test": {
+            "post": {
+                "description": "用实验室当前的规则对一条错误信息分类，返回分类和匹配的规则",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ErrorRule"
+                ],
+                "summary": "测试错误分类",
+                "parameters": [
+                    {
+                        "description": "错误信息",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/errorrule.TestReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/errorrule.TestResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/error-rule/{uuid}": {
+            "put": {
+                "description": "只影响之后的失败记录，已分类的记录不会重新分类",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ErrorRule"
+                ],
+                "summary": "更新错误分类规则",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "规则UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "规则",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/errorrule.UpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ErrorRule"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ErrorRule"
+                ],
+                "summary": "删除错误分类规则",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "规则UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/export": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取历史记录导出列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.HistoryExport"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "在后台将符合过滤条件的历史记录分片导出为 NDJSON，每个分片写入后保存检查点，中断后从检查点继续。相同 Idempotency-Key（未传时为相同的类型和过滤条件）返回已有的导出，失败的导出从检查点重新执行",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "创建历史记录导出",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "幂等键",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "导出",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/historyexport.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/historyexport.ExportResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/export/{uuid}": {
+            "get": {
+                "description": "返回导出进度，导出完成后附带签名下载链接",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取历史记录导出进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "导出UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/historyexport.ExportResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/import": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取历史记录导入列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.HistoryImport"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "请求体为实验室导出归档(zip)或单张历史表(jsonl/parquet)，在后台按 UUID 导入，仅实验室管理员可操作",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "导入历史记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件格式 zip | jsonl | parquet",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jsonl、parquet 文件对应的历史表",
+                        "name": "table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "UUID 已存在时的处理方式 skip | overwrite | fail，默认 skip",
+                        "name": "policy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只校验并生成报告，不写入",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.HistoryImport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/import/{uuid}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取历史记录导入进度和校验报告",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "导入UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.HistoryImport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/search": {
+            "post": {
+                "description": "将问题（例如\"上周 3 号实验室离心机失败的运行\"）解析为历史查询条件并执行查询，同时返回解析出的条件，可修改后用于列表接口或保存为视图。开启 ai_assistant 功能并配置了模型时由模型解析，否则使用内置的中英文规则；问题中的 lab N 优先于 lab_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "自然语言搜索执行历史",
+                "parameters": [
+                    {
+                        "description": "问题",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/history.SearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/history.SearchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/view": {
+            "get": {
+                "description": "获取自己创建的以及实验室共享的视图",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SavedView"
+                ],
+                "summary": "获取保存的视图列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "适用列表 (workflow, device_event)",
+                        "name": "target",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.SavedView"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "保存历史查询的过滤条件组合，可共享给实验室成员；列表接口通过 view_id 使用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SavedView"
+                ],
+                "summary": "创建保存的视图",
+                "parameters": [
+                    {
+                        "description": "视图",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/savedview.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.SavedView"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/view/{uuid}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SavedView"
+                ],
+                "summary": "获取保存的视图",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "视图UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.SavedView"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "整体覆盖视图的名称、过滤条件与共享设置，仅创建者可以修改",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SavedView"
+                ],
+                "summary": "更新保存的视图",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "视图UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "视图",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/savedview.UpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.SavedView"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "仅创建者可以删除",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SavedView"
+                ],
+                "summary": "删除保存的视图",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "视图UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/workflow": {
+            "get": {
+                "description": "获取实验室的工作流执行历史记录。v2（/v2 前缀或 Accept: application/vnd.studio.v2+json）返回 ListResponseV2，条目为 WorkflowExecutionV2。Accept: application/x-ndjson 时不分页，按列表顺序逐行流式返回条目（不支持 include），最多返回配置的行数，最后一行为 {\"end\": StreamEnd}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取工作流执行历史列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "工作流ID (可选)",
+                        "name": "workflow_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "设备ID，只返回有动作在该设备上运行的执行",
+                        "name": "device_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID，只返回有动作在该房间、工作台或设备组下任一设备上运行的执行",
+                        "name": "topology_node",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "实验UUID，只返回关联到该实验的执行",
+                        "name": "experiment",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "状态过滤 (pending, running, success, failed, cancelled, timeout)，其他取值返回参数错误",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "保存的视图UUID，展开为视图的过滤条件",
+                        "name": "view_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否统计总数，为 false 时 total 为 -1，按 has_more 翻页",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "执行标签过滤 (key:value)，可重复",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只返回当前用户置顶的执行",
+                        "name": "pinned",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "按是否违反 SLA 过滤，只匹配设置了 SLA 且已结束的执行",
+                        "name": "breached",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "展开关联数据，支持 actions，每个执行最多返回 200 个动作",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上一页返回的 next_cursor，按第一页请求时的快照继续翻页，期间新写入的执行不会造成重复或遗漏；设置时忽略 page 且不统计总数",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "稀疏字段集，只返回并查询这些字段，字段名为所请求版本的响应字段，可重复或以逗号分隔",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-history_WorkflowExecutionListItem"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/workflow/execution/{execution_uuid}": {
+            "get": {
+                "description": "获取单次工作流执行的详细信息，包含所有动作及执行开始时的工作流定义快照。v2 返回 WorkflowExecutionDetailV2",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取工作流执行详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "执行UUID",
+                        "name": "execution_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/history.WorkflowExecutionDetailResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/workflow/execution/{execution_uuid}/annotation": {
+            "get": {
+                "description": "按时间顺序返回批注，回复嵌套在 replies 中，已删除的批注内容为空",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Annotation"
+                ],
+                "summary": "获取执行记录的批注",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "执行记录UUID",
+                        "name": "execution_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.ExecutionAnnotation"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "添加批注或回复已有批注，mentions 中的实验室成员会收到通知",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Annotation"
+                ],
+                "summary": "添加批注",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "执行记录UUID",
+                        "name": "execution_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "批注",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/annotation.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ExecutionAnnotation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/workflow/execution/{execution_uuid}/labels": {
+            "put": {
+                "description": "整体替换执行记录的键值标签，传入空对象清空标签",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "设置工作流执行标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "执行UUID",
+                        "name": "execution_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "标签",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/history.SetExecutionLabelsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/workflow/execution/{execution_uuid}/pin": {
+            "put": {
+                "description": "为当前用户置顶执行记录，列表接口使用 pinned=true 只返回置顶的执行",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "置顶工作流执行",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "执行UUID",
+                        "name": "execution_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "取消置顶工作流执行",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "执行UUID",
+                        "name": "execution_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/history/workflow/labels": {
+            "get": {
+                "description": "返回实验室执行记录使用的标签键及次数，传入 key 时返回该键的取值，用于过滤条件自动补全",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取工作流执行标签",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签键",
+                        "name": "key",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.LabelCount"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/inventory/material": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "获取物料批次列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "类型过滤 (sample, reagent, consumable)",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称或批号",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_Material"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "登记样品、试剂或耗材批次，同一实验室批号唯一",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "登记物料批次",
+                "parameters": [
+                    {
+                        "description": "物料批次",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/inventory.RegisterReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Material"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/inventory/material/{uuid}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "获取物料批次详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "物料批次UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Material"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/inventory/material/{uuid}/usage": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "获取物料批次消耗记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "物料批次UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.MaterialUsage"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/inventory/sync": {
+            "get": {
+                "description": "获取 LIMS 库存同步是否启用、同步间隔及最近的同步记录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "获取库存同步状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/inventory.SyncStatusResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "立即从 LIMS 同步实验室库存，同步在后台执行，已有进行中的同步时返回错误",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "手动触发库存同步",
+                "parameters": [
+                    {
+                        "description": "同步请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/inventory.SyncTriggerReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.MaterialSyncRun"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/inventory/sync/conflicts": {
+            "get": {
+                "description": "获取 LIMS 与本地库存无法自动对账的差异，默认只返回未解决的冲突",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "获取库存同步冲突",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含已解决的冲突",
+                        "name": "include_resolved",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.MaterialSyncConflict"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/inventory/usage": {
+            "post": {
+                "description": "记录一次工作流执行消耗的物料批次并扣减剩余数量，任一批次剩余不足时整体失败。用户上报时 lab_uuid 必填",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inventory"
+                ],
+                "summary": "记录物料消耗",
+                "parameters": [
+                    {
+                        "description": "物料消耗",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/inventory.UsageReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.MaterialUsage"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/invite/{lab_uuid}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "为实验室创建邀请链接",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "创建邀请链接",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/invite/{uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过邀请链接加入实验室",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "接受邀请",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "邀请链接UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/list": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户的所有实验室",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "获取实验室列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/material": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "查询实验室物料",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "查询物料",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "name": "with_children",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "批量更新物料的唯一名称",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "批量更新物料",
+                "parameters": [
+                    {
+                        "description": "批量更新物料请求",
+                        "name": "material",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/material.UpdateMaterialReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "在实验室中创建物料图（节点与边）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "创建物料图",
+                "parameters": [
+                    {
+                        "description": "物料图创建请求",
+                        "name": "material",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/material.GraphNodeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/material/device/actions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取设备对应的可用动作列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "设备可用动作",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备名称",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/material/edge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "创建两个物料节点之间的连线",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "创建物料连线",
+                "parameters": [
+                    {
+                        "description": "物料连线请求",
+                        "name": "edges",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/material.GraphEdge"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/material/resource": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取实验室的所有资源（设备）列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "获取资源列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "资源类型",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/material/resource/templates": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取实验室所有资源模板的详细信息，包括图标、描述、动作列表等",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "资源模板列表（详细）",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/material/save": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "保存当前实验室的物料图",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "保存物料图",
+                "parameters": [
+                    {
+                        "description": "保存物料图请求",
+                        "name": "material",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/material.SaveGrapReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/material/template/{template_uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取物料模板详情",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Material"
+                ],
+                "summary": "获取模板详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "模板UUID",
+                        "name": "template_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/member/{lab_uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定实验室的成员列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "获取实验室成员",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/member/{lab_uuid}/{member_uuid}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "从实验室中删除一个成员",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "删除实验室成员",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "成员UUID",
+                        "name": "member_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/purge": {
+            "post": {
+                "description": "提交确认令牌和实验室名称后删除实验室的历史记录、设备事件、报表、批注、配置和文件，实验室及成员保留",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "LabExport"
+                ],
+                "summary": "确认删除实验室全部数据",
+                "parameters": [
+                    {
+                        "description": "确认",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/labexport.PurgeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/labexport.PurgeResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/purge/token": {
+            "post": {
+                "description": "仅实验室创建者可申请，返回短时有效的确认令牌和需要输入的确认内容",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "LabExport"
+                ],
+                "summary": "申请删除实验室全部数据",
+                "parameters": [
+                    {
+                        "description": "申请",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/labexport.PurgeTokenReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/labexport.PurgeTokenResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/report/delivery/{uuid}/download": {
+            "get": {
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "下载已生成的报表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "发送记录UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/report/subscription": {
+            "get": {
+                "description": "获取当前用户在实验室创建的订阅",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "获取报表订阅列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.ReportSubscription"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "按天或按周将保存的视图或实验室统计生成 CSV/PDF 报表，通过邮件或 webhook 发送",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "创建报表订阅",
+                "parameters": [
+                    {
+                        "description": "订阅",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/report.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ReportSubscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/report/subscription/{uuid}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "获取报表订阅",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订阅UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ReportSubscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "整体覆盖发送设置，修改发送时间或重新启用后重新计算下次发送时间",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "更新报表订阅",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订阅UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "订阅",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/report.UpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ReportSubscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "已生成的报表与发送记录保留",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "删除报表订阅",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订阅UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/report/subscription/{uuid}/deliveries": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "获取报表发送记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订阅UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_ReportDelivery"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/reservation": {
+            "post": {
+                "description": "预约设备的一个时间段，与已有预约冲突时返回错误",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reservation"
+                ],
+                "summary": "预约设备",
+                "parameters": [
+                    {
+                        "description": "预约信息",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/reservation.CreateReservationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/reservation.ReservationResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/reservation/calendar": {
+            "get": {
+                "description": "获取时间窗口内实验室设备的预约情况",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reservation"
+                ],
+                "summary": "获取设备预约日历",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "设备ID (可选)",
+                        "name": "device_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/reservation.CalendarResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/reservation/{uuid}": {
+            "delete": {
+                "description": "取消自己创建的设备预约",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reservation"
+                ],
+                "summary": "取消预约",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "预约UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/resource": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "从边缘端创建实验室资源",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "创建实验室资源",
+                "parameters": [
+                    {
+                        "description": "资源请求",
+                        "name": "resource",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/environment.ResourceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/rule": {
+            "get": {
+                "description": "获取实验室所有设备规则",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "获取设备规则列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceRule"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "在滑动窗口内聚合设备事件并与阈值比较，例如温度持续 5 分钟高于 60 为 metric=temperature、aggregation=min、operator=gt、threshold=60、window_sec=300",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "创建设备规则",
+                "parameters": [
+                    {
+                        "description": "规则",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rule.RuleReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceRule"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/rule/test": {
+            "post": {
+                "description": "使用最近一个窗口的数据评估规则，返回每个设备的聚合值及是否命中，不会产生告警",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "试运行设备规则",
+                "parameters": [
+                    {
+                        "description": "规则",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rule.RuleReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/rule.TestResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/rule/{uuid}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "获取设备规则详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "规则UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceRule"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "整体覆盖规则配置，lab_uuid 不可修改",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "更新设备规则",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "规则UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "规则",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rule.RuleReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DeviceRule"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "删除规则并恢复其未恢复的告警",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "删除设备规则",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "规则UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/run/workflow": {
+            "put": {
+                "description": "通过 HTTP 启动工作流任务，返回任务 UUID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "启动工作流（无鉴权）",
+                "parameters": [
+                    {
+                        "description": "启动请求",
+                        "name": "workflow",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workflow.RunReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/telemetry/rollup": {
+            "get": {
+                "description": "获取设备 data_received 事件数值字段的分钟级或小时级汇总（min/max/avg/count），分钟级最多查询 7 天，小时级最多 90 天",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Telemetry"
+                ],
+                "summary": "获取设备数据汇总",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1m",
+                        "description": "汇总粒度 (1m, 1h)",
+                        "name": "resolution",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "设备UUID",
+                        "name": "device_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "指标名称",
+                        "name": "metric",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)，默认当前时间",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DeviceTelemetryRollup"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/telemetry/series": {
+            "get": {
+                "description": "从设备事件 event_data 中按字段路径（如 sensor.temperature）提取数值，按时间桶聚合，返回每个设备每个指标一条可直接绘图的序列",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Telemetry"
+                ],
+                "summary": "获取设备数值时间序列",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "设备UUID，不传则返回所有有数据的设备",
+                        "name": "device_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "指标字段路径，以 . 分隔",
+                        "name": "metric",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "data_received",
+                        "description": "事件类型",
+                        "name": "event_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "avg",
+                        "description": "聚合函数 (avg, min, max, sum, count)",
+                        "name": "agg",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "1m",
+                        "description": "时间桶大小，如 30s、5m、1h",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)，默认当前时间",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/telemetry.SeriesResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/topology/node": {
+            "post": {
+                "description": "创建房间、工作台或设备组：房间位于实验室下，工作台位于房间下，设备组位于实验室、房间或工作台下，仅实验室创建者可以操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Topology"
+                ],
+                "summary": "创建拓扑节点",
+                "parameters": [
+                    {
+                        "description": "拓扑节点",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/topology.CreateNodeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TopologyNode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/topology/node/{uuid}": {
+            "delete": {
+                "description": "删除没有子节点的拓扑节点，节点中的设备不会被删除",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Topology"
+                ],
+                "summary": "删除拓扑节点",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "修改节点名称、描述或移动到其他父节点，parent_uuid 为全 0 的 UUID 时移动到实验室下",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Topology"
+                ],
+                "summary": "修改拓扑节点",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "修改内容",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/topology.UpdateNodeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TopologyNode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/topology/node/{uuid}/device": {
+            "post": {
+                "description": "将设备放入房间或工作台，或加入设备组；每个设备只能位于一个房间或工作台，放入新位置时从原来的位置移出",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Topology"
+                ],
+                "summary": "添加节点设备",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "设备名称",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/topology.DevicesReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "将设备从房间、工作台或设备组移出",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Topology"
+                ],
+                "summary": "移除节点设备",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "设备名称",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/topology.DevicesReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/topology/{lab_uuid}": {
+            "get": {
+                "description": "获取实验室的房间、工作台和设备组树及各节点直接包含的设备",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Topology"
+                ],
+                "summary": "获取实验室拓扑",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.TopologyTree"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/user": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "获取用户信息",
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/batch": {
+            "post": {
+                "description": "按输入矩阵批量启动工作流，每行输入生成一个子任务",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "批量启动工作流",
+                "parameters": [
+                    {
+                        "description": "批量启动请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workflow.BatchRunReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/batch/{uuid}": {
+            "get": {
+                "description": "获取批次汇总状态、进度及子任务列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "获取批次状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "批次 uuid",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/batch/{uuid}/cancel": {
+            "post": {
+                "description": "取消批次内所有未结束的子任务",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "取消批次",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "批次 uuid",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/node/template/detail/{uuid}": {
+            "get": {
+                "description": "获取节点模板的详细信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "节点模板详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "模板UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/node/template/list": {
+            "get": {
+                "description": "获取实验室下的节点模板列表，支持按名称、标签和分页过滤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "节点模板列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/node/template/tags/{lab_uuid}": {
+            "get": {
+                "description": "获取指定实验室的节点模板标签列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "节点模板标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/owner": {
+            "post": {
+                "description": "在指定实验室创建新的工作流",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "创建工作流",
+                "parameters": [
+                    {
+                        "description": "工作流创建请求",
+                        "name": "workflow",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workflow.CreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "更新我创建的工作流（名称、发布状态、描述等）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "更新工作流",
+                "parameters": [
+                    {
+                        "description": "工作流更新请求",
+                        "name": "workflow",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workflow.UpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/owner/duplicate": {
+            "put": {
+                "description": "在目标实验室中复制现有工作流（自动匹配节点模板）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "复制工作流",
+                "parameters": [
+                    {
+                        "description": "复制请求",
+                        "name": "workflow",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workflow.DuplicateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/owner/export": {
+            "get": {
+                "description": "导出工作流为可跨实验室导入的 JSON 数据",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "导出工作流",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/owner/import": {
+            "post": {
+                "description": "将导出的工作流 JSON 导入到目标实验室",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "导入工作流",
+                "parameters": [
+                    {
+                        "description": "导入请求",
+                        "name": "workflow",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workflow.ImportReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/owner/list": {
+            "get": {
+                "description": "获取我创建的工作流列表（滚动加载）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "工作流列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "lab_uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/owner/{uuid}": {
+            "delete": {
+                "description": "删除我创建的工作流",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "删除工作流",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作流UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/task/download/{uuid}": {
+            "get": {
+                "description": "下载指定工作流的任务列表为 CSV 文件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "下载工作流任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作流UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/task/{uuid}": {
+            "get": {
+                "description": "获取指定工作流的任务列表（滚动分页）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "工作流任务列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作流UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/template/detail/{uuid}": {
+            "get": {
+                "description": "获取工作流的节点与边详情",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "工作流详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作流UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/template/fork": {
+            "put": {
+                "description": "将已有工作流模板 Fork 到目标实验室",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "Fork 工作流模板",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "source_workflow_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "target_lab_uuid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/template/list": {
+            "get": {
+                "description": "获取工作流模板列表，支持按标签与分页过滤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "工作流模板列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "lab_uuid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/template/tags": {
+            "get": {
+                "description": "获取全局工作流模板标签列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "工作流模板标签",
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/workflow/ws/workflow/{uuid}": {
+            "get": {
+                "description": "连接到指定工作流的 WebSocket 会话，用于实时编辑与运行",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "工作流 WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "工作流UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_id}/dashboard": {
+            "get": {
+                "description": "一次返回实验室统计、最近执行、未恢复告警、设备在线状态和排队执行数。各分区并发查询，失败的分区为 null，原因在 errors 中按分区返回，其他分区照常返回",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Dashboard"
+                ],
+                "summary": "获取实验室看板",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "统计开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "统计结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dashboard.Response"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_id}/queue": {
+            "get": {
+                "description": "按排队顺序返回实验室排队中和运行中执行的预计完成时间，根据工作流或各设备步骤的历史时长估算",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取实验室排队预测",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.QueueForecast"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_id}/stats": {
+            "get": {
+                "description": "获取实验室的工作流执行统计数据",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取实验室使用统计",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID，只统计该房间、工作台或设备组下设备的执行、动作和事件",
+                        "name": "topology_node",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.HistoryStats"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_id}/stats/device-utilization": {
+            "get": {
+                "description": "按设备统计动作执行的忙碌时间与心跳记录的在线时间，按利用率排名，并给出与上一周期相比的变化和每天的趋势。没有心跳记录的设备在线时间按整个周期计算。默认最近 30 天，最长 366 天",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取设备利用率报表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.UtilizationReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_id}/stats/failures": {
+            "get": {
+                "description": "按错误分类统计失败或超时的工作流执行与动作，分类由实验室的错误分类规则决定，未分类的记录计入 unclassified。默认最近 30 天，最长 366 天",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取失败分类统计",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.FailureStats"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_id}/stats/heatmap": {
+            "get": {
+                "description": "按星期和小时统计工作流执行的开始次数，用于查看设备使用规律和安排维护时间。matrix[weekday][hour]，weekday 0 为周日。默认最近 30 天，最长 366 天",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取实验室活动热力图",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "统计使用的时区 (IANA 名称，例如 Asia/Shanghai)",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ActivityHeatmap"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_id}/stats/slowest-steps": {
+            "get": {
+                "description": "按步骤聚合动作执行时长，返回平均耗时最长的步骤及其与时长预算的偏差",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取最慢步骤报表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "返回数量",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.StepDurationStats"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_id}/workflow/{workflow_uuid}/run": {
+            "post": {
+                "description": "实验室成员以自己的身份启动工作流：校验运行参数，创建 pending 状态的执行记录并投递到调度队列，返回执行 UUID 和排队位置预估",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow"
+                ],
+                "summary": "启动实验室工作流",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "工作流 uuid",
+                        "name": "workflow_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "启动请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workflow.LabRunReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab/{lab_uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取单个实验室的详细信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Laboratory"
+                ],
+                "summary": "获取实验室信息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否包含成员列表",
+                        "name": "with_member",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/maintenance/status": {
+            "get": {
+                "description": "返回全局和实验室的只读维护状态，供前端展示维护提示，无需登录",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance"
+                ],
+                "summary": "获取维护状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "实验室UUID",
+                        "name": "lab_uuid",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/maintenance.StatusResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notification": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "当前用户的站内通知，最新的在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "获取通知列表",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "只返回未读通知",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-model_Notification"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notification/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "每类通知的开关，未设置的类别默认开启",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "获取通知设置",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/notification.Preference"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "关闭的类别不再写入收件箱，未包含的类别保持不变",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "更新通知设置",
+                "parameters": [
+                    {
+                        "description": "通知设置",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/notification.PreferencesReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/notification.Preference"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notification/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "标记指定通知为已读，all 为 true 时标记全部未读通知",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "标记已读",
+                "parameters": [
+                    {
+                        "description": "标记已读请求",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/notification.MarkReadReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/notification.MarkReadResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notification/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events 通知流。连接后先发送 unread 事件（未读数量），之后每条新通知发送一个 notification 事件，事件 id 为通知的 uuid，空闲时每 30 秒发送 ping 事件。\n重连时带上 Last-Event-ID 请求头，会先补发保留期内错过的通知",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "新通知推送",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "断线前收到的最后一条通知的 uuid",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Notification"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notification/unread": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "获取未读数量",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/notification.UnreadResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/org/{org_id}/stats": {
+            "get": {
+                "description": "汇总组织内所有实验室的执行统计，包含各实验室明细、执行次数最多的工作流与设备，以及与上一个等长周期的对比。默认最近 30 天",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取组织统计",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "组织ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "排行数量",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.OrgStats"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/ws/action/{task_uuid}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "连接到指定任务的 WebSocket 会话，用于接收实时动作执行状态更新",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Action"
+                ],
+                "summary": "Action WebSocket 连接",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务UUID",
+                        "name": "task_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/ws/hub": {
+            "get": {
+                "description": "连接后发送 {\"action\":\"subscribe\",\"msg_uuid\":\"...\",\"data\":{\"topic\":\"...\"}} 订阅主题，action 为 unsubscribe 时取消订阅。主题为 execution:\u003c执行uuid\u003e（执行所在实验室的成员）、lab:\u003c实验室id\u003e:events（实验室成员）和 notifications:\u003c用户id\u003e（仅本人），订阅时检查权限。主题的消息以 action 为 message 推送，data 为 hub.Message。单个用户的连接数和单个连接的主题数有上限\n断线重连后订阅时在 data 中带上 resume_from（断线前收到的最后一条消息的 id），会先补发保留期内错过的消息，订阅结果为 hub.SubscribeResp，replay.complete 为 false 表示部分消息已超过保留期或被裁剪",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hub"
+                ],
+                "summary": "WebSocket 消息中心",
+                "responses": {
+                    "200": {
+                        "description": "未登录或连接数超过限制",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code": {
+                                            "$ref": "#/definitions/code.ErrCode"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/lab/history/device": {
+            "get": {
+                "description": "获取实验室的设备事件历史记录。v2 返回 ListResponseV2，条目为 DeviceEventV2。Accept: application/x-ndjson 时不分页，按列表顺序逐行流式返回条目，最多返回配置的行数，最后一行为 {\"end\": StreamEnd}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取设备事件历史",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "设备ID (可选)",
+                        "name": "device_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID，只返回该房间、工作台或设备组下设备的事件",
+                        "name": "topology_node",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "事件类型过滤，未知的类型返回参数错误并列出允许的取值",
+                        "name": "event_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "保存的视图UUID，展开为视图的过滤条件",
+                        "name": "view_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否统计总数，为 false 时 total 为 -1，按 has_more 翻页",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "稀疏字段集，只返回并查询这些字段，字段名为所请求版本的响应字段，可重复或以逗号分隔",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-history_DeviceEventResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/lab/history/workflow": {
+            "get": {
+                "description": "获取实验室的工作流执行历史记录。v2（/v2 前缀或 Accept: application/vnd.studio.v2+json）返回 ListResponseV2，条目为 WorkflowExecutionV2。Accept: application/x-ndjson 时不分页，按列表顺序逐行流式返回条目（不支持 include），最多返回配置的行数，最后一行为 {\"end\": StreamEnd}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取工作流执行历史列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "实验室ID",
+                        "name": "lab_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "工作流ID (可选)",
+                        "name": "workflow_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "设备ID，只返回有动作在该设备上运行的执行",
+                        "name": "device_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拓扑节点UUID，只返回有动作在该房间、工作台或设备组下任一设备上运行的执行",
+                        "name": "topology_node",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "实验UUID，只返回关联到该实验的执行",
+                        "name": "experiment",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "状态过滤 (pending, running, success, failed, cancelled, timeout)，其他取值返回参数错误",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间 (RFC3339格式)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间 (RFC3339格式)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "保存的视图UUID，展开为视图的过滤条件",
+                        "name": "view_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否统计总数，为 false 时 total 为 -1，按 has_more 翻页",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "执行标签过滤 (key:value)，可重复",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只返回当前用户置顶的执行",
+                        "name": "pinned",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "按是否违反 SLA 过滤，只匹配设置了 SLA 且已结束的执行",
+                        "name": "breached",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "展开关联数据，支持 actions，每个执行最多返回 200 个动作",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上一页返回的 next_cursor，按第一页请求时的快照继续翻页，期间新写入的执行不会造成重复或遗漏；设置时忽略 page 且不统计总数",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "稀疏字段集，只返回并查询这些字段，字段名为所请求版本的响应字段，可重复或以逗号分隔",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/common.ListResp-history_WorkflowExecutionListItem"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/lab/history/workflow/execution/{execution_uuid}": {
+            "get": {
+                "description": "获取单次工作流执行的详细信息，包含所有动作及执行开始时的工作流定义快照。v2 返回 WorkflowExecutionDetailV2",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "History"
+                ],
+                "summary": "获取工作流执行详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "执行UUID",
+                        "name": "execution_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/history.WorkflowExecutionDetailResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "action.RunActionReq": {
+            "type": "object",
+            "required": [
+                "action",
+                "action_type",
+                "device_id",
+                "lab_uuid"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "param": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "action.RunActionResp": {
+            "type": "object",
+            "properties": {
+                "action_name": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "feedback_data": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "return_info": {
+                    "$ref": "#/definitions/datatypes.JSONType-model_ReturnInfo"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "alert.AssignReq": {
+            "type": "object",
+            "properties": {
+                "assignee_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "alert.SilenceReq": {
+            "type": "object",
+            "required": [
+                "ends_at"
+            ],
+            "properties": {
+                "device_uuid": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "description": "默认立即开始",
+                    "type": "string"
+                }
+            }
+        },
+        "annotation.CreateReq": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "mentions": {
+                    "description": "提及的实验室成员用户 ID",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "parent_uuid": {
+                    "description": "回复的批注",
+                    "type": "string"
+                }
+            }
+        },
+        "annotation.UpdateReq": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "approval.ApprovalResponse": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "node_uuid": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewer_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ApprovalStatus"
+                },
+                "step_name": {
+                    "type": "string"
+                },
+                "task_uuid": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "approval.DecideRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                }
+            }
+        },
+        "assistant.DiagnoseReq": {
+            "type": "object",
+            "required": [
+                "execution_uuid"
+            ],
+            "properties": {
+                "execution_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "chaos.ChaosConf": {
+            "type": "object",
+            "properties": {
+                "db_error_rate": {
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "redis_latency_ms": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "redis_latency_rate": {
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0
+                },
+                "scheduler_drop_rate": {
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0
+                }
+            }
+        },
+        "code.ErrCode": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                1000,
+                1001,
+                1002,
+                1003,
+                5000,
+                5001,
+                5002,
+                5003,
+                5004,
+                5005,
+                5006,
+                5007,
+                5008,
+                5009,
+                5010,
+                5011,
+                5012,
+                10000,
+                10001,
+                10002,
+                10003,
+                10004,
+                10005,
+                10006,
+                10007,
+                10008,
+                10009,
+                10010,
+                20000,
+                20001,
+                20002,
+                20003,
+                20004,
+                20005,
+                20006,
+                20007,
+                20008,
+                20009,
+                20010,
+                22000,
                 22001,
                 22002,
                 22003,
@@ -2378,6 +13278,8 @@ const docTemplate = `{
                 28006,
                 28007,
                 28008,
+                28009,
+                28010,
                 30000,
                 30001,
                 30002,
@@ -2410,430 +13312,8922 @@ const docTemplate = `{
                 30029,
                 30030,
                 30031,
-                30032
+                30032,
+                30033,
+                30034,
+                32000,
+                32001,
+                32002,
+                32003,
+                32004,
+                32005,
+                32006,
+                32007,
+                32008,
+                32009,
+                32010,
+                32011,
+                34000,
+                34001,
+                34002,
+                36000,
+                36001,
+                36002,
+                36003,
+                36004,
+                36005,
+                38000,
+                38001,
+                38002,
+                40000,
+                40001,
+                40002,
+                42000,
+                42001,
+                42002,
+                42003,
+                42004,
+                42005,
+                44000,
+                44001,
+                44002,
+                44003,
+                46000,
+                46001,
+                47000,
+                47001,
+                48000,
+                48001,
+                49000,
+                49001,
+                49002,
+                50000,
+                50001,
+                50002,
+                51000,
+                51001,
+                52000,
+                52001,
+                53000,
+                53001,
+                53002,
+                54000,
+                55000,
+                56000,
+                56001,
+                56002,
+                57000,
+                57001,
+                58000,
+                58001,
+                58002,
+                59000,
+                59001,
+                59002,
+                59003,
+                60000,
+                60001,
+                61000,
+                61001,
+                62000,
+                62001,
+                63000,
+                64000,
+                65000,
+                66000,
+                66001,
+                66002,
+                66003,
+                67000,
+                68000
+            ],
+            "x-enum-comments": {
+                "AlertSilenceErr": "alert silence invalid",
+                "AlertStatusErr": "alert not in expected state",
+                "AnnotationDeletedErr": "annotation has been deleted",
+                "AnnotationMentionErr": "mentioned user is not a lab member",
+                "ApprovalAlreadyDecidedErr": "approval request already decided",
+                "ApprovalRejectedErr": "approval request rejected",
+                "ApprovalSelfDecideErr": "approval cannot be decided by the user who started the step",
+                "ApprovalStatusInvalidErr": "approval status invalid",
+                "AssistantDisabledErr": "assistant is disabled or no model provider is configured",
+                "AssistantProviderErr": "assistant model provider request failed",
+                "AssistantRateLimitErr": "assistant request limit of the user is exceeded",
+                "BohrBatchQueryErr": "bhor batch query user error",
+                "CallbackJobStatusKeyNotExistErr": "callback job status key note exists error",
+                "CallbackJobStatusTimeoutErr": "callback job status timeout error",
+                "CallbackParamErr": "callback parameter error",
+                "CameraFeatureDisabledErr": "realtime camera feature disabled",
+                "CanNotFoundEdgeSession": "can not found edge session",
+                "CanNotFoundMaterialNodeErr": "can not found material node error",
+                "CanNotFoundTargetNode": "can not found target node error",
+                "CanNotFoundWorkflowHandleErr": "can not found workflow handle error",
+                "CanNotGetLabIDErr": "cannot get lab id error",
+                "CanNotGetParentJobErr": "can not found parent node job error",
+                "CanNotGetWorkflowTaskErr": "can not get workflow task error",
+                "CanNotGetWorkflowUUIDErr": "can not get workflow uuid",
+                "CanNotGetworkflowErr": "workflow not found error",
+                "CasDoorCreateLabUserErr": "create lab user error",
+                "CasDoorQueryLabUserErr": "query lab user error",
+                "ChaosForbiddenErr": "failure injection is not allowed in this environment",
+                "CreateDataErr": "database create data error",
+                "DataNotMapAnyTypeErr": "data not map any type error",
+                "DeadLetterNotDeadErr": "dead letter job already requeued",
+                "DeleteDataErr": "database delete error",
+                "DeviceActionNotSupportedErr": "device does not declare the action",
+                "DeviceActionParamInvalidErr": "device action parameters invalid",
+                "DeviceCapabilityInvalidErr": "device capability descriptor invalid",
+                "DeviceCommandStateErr": "device command not in expected state",
+                "DeviceConfigExistErr": "device config profile with the same name already exists",
+                "DeviceConfigInvalidErr": "device config must be a JSON object",
+                "DeviceConfigNoTargetErr": "no device has the config assigned",
+                "DeviceGroupNoTargetErr": "no device of the group can receive the command",
+                "DeviceKeyRateLimitErr": "event reporting limit of the device key is exceeded",
+                "DeviceLimitExceededErr": "lab device limit exceeded",
+                "DeviceLockErr": "device lock error",
+                "DeviceLockNotHeldErr": "device lock not held",
+                "DeviceLockWaitTimeoutErr": "wait device lock timeout",
+                "DeviceReservedErr": "device is reserved by another user",
+                "EdgeConnectClosedErr": "connect closed when node running error",
+                "EdgeHandleNotExistErr": "node handle not exist",
+                "EdgeNodeNotExistErr": "edge node not exist",
+                "EdgeNotStartedErr": "edge not started error",
+                "ErrorRulePatternErr": "error rule pattern is not a valid regular expression",
+                "EventSchemaNotFoundErr": "event type or schema version not found",
+                "EventSchemaViolationErr": "event payload violates the published schema",
+                "ExchangeTokenErr": "exchange token failed",
+                "ExecWorkflowNodeScriptErr": "exec workflow script error",
+                "ExperimentClosedErr": "archived experiment does not accept executions",
+                "ExperimentExistErr": "experiment with the same name already exists",
+                "FileContentRejectedErr": "file content rejected by validation",
+                "FileStorageErr": "file storage error",
+                "FileTooLargeErr": "file exceeds size limit",
+                "FileURLInvalidErr": "file download url invalid or expired",
+                "FileUploadOffsetErr": "upload offset does not match received size",
+                "FileUploadStatusErr": "file not in expected upload state",
+                "FormatCSVTaskErr": "format csv data error",
+                "HistoryCopyForbiddenErr": "history copy is not allowed in this environment",
+                "HistoryCopySourceErr": "history copy source environment request failed",
+                "HistoryExportExpiredErr": "history export file expired or not ready",
+                "HistoryExportKindErr": "history export kind or filters invalid",
+                "HistoryImportFormatErr": "history import file format or table not supported",
+                "HistoryImportRunningErr": "history import already running for the lab",
+                "HistoryImportTooLargeErr": "history import file too large",
+                "HistoryVersionConflictErr": "execution history was updated by another writer",
+                "HubConnectionLimitErr": "too many websocket connections of the user",
+                "HubResumeTokenErr": "resume token of the replay buffer is not valid",
+                "HubTopicInvalidErr": "websocket topic is not valid",
+                "HubTopicLimitErr": "too many topics subscribed on the connection",
+                "ImpersonationForbiddenErr": "endpoint does not allow impersonated sessions",
+                "ImpersonationScopeErr": "impersonated session cannot access other laboratories",
+                "ImpersonationTargetErr": "impersonation target must be a lab member who is not an admin",
+                "InvalidDagErr": "invalid dag error",
+                "InvalidToken": "invalid token",
+                "InvalidateJWT": "invalidate jwt",
+                "InvalidateThirdID": "invalidate third id error",
+                "InviteExpiredErr": "invite expired error",
+                "JobAlreadyRegisteredErr": "background job already registered",
+                "JobCanceled": "job is canceled",
+                "JobNotRegisteredErr": "background job not registered",
+                "JobRetryTimeout": "job retry timeout error",
+                "JobRunFailErr": "job run fail error",
+                "JobScheduleInvalidErr": "background job schedule invalid",
+                "JobTimeoutErr": "job timeout error",
+                "LabAlreadyDeletedErr": "lab already deleted error",
+                "LabExportExpiredErr": "lab export archive expired or not ready",
+                "LabExportRunningErr": "lab export already running",
+                "LabIDIsEmptyErr": "lab id is empty error",
+                "LabInviteNotFoundErr": "can not found laboratory invite link error",
+                "LabNotFound": "laboratory not found error",
+                "LabPurgeConfirmErr": "lab data deletion confirmation invalid",
+                "LegalHoldInvalidErr": "legal hold target is missing or does not match the scope",
+                "LegalHoldReleasedErr": "legal hold is already released",
+                "LoginCallbackErr": "login process user info failed",
+                "LoginConfigErr": "login configuration error",
+                "LoginFormatErr": "login verification format error",
+                "LoginGetUserInfoErr": "get user info failed",
+                "LoginSetStateErr": "set login state error",
+                "LoginStateErr": "state verification failed",
+                "MachineAlreadyExistErr": "machine already exist error",
+                "MachineNodeStoppingErr": "machine is stopping",
+                "MachineNotExistErr": "machine not exist error",
+                "MachineReachMaxNumCountErr": "machine reach max number error",
+                "MachineStartUnknownErr": "start machine unknown error",
+                "MaintenanceReadOnlyErr": "service is in read-only maintenance",
+                "MarshalTargetDataErr": "marshal target data error",
+                "MaterialInsufficientErr": "material remaining quantity insufficient",
+                "MaterialInvalidErr": "material lot invalid",
+                "MaterialSyncDisabledErr": "material lims sync not enabled",
+                "MaterialSyncRunningErr": "material sync already running for the lab",
+                "MaxTplNodeDeepErr": "max template node deep error",
+                "ModelNotImplementTablerErr": "model not implement schema.Tabler",
+                "NoPermission": "no permission",
+                "NodeDataMarshalErr": "marshal node data error",
+                "NotBaseDBTypeErr": "not base db type error",
+                "NotPointerErr": "not pointer err",
+                "NotSlicePointerErr": "must be a pointer to a slice",
+                "NotifyActionAlreadyRegistryErr": "notify action already registry",
+                "NotifySendMsgErr": "notify send message error",
+                "NotifySubscribeChannelErr": "notify subscribe channel fail",
+                "ParamDataKeyInvalidateErr": "param data key invalidate error",
+                "ParamDataValueInvalidateErr": "param data value invalidate error",
+                "ParamErr": "parse parameter error",
+                "ParentNodeNotFoundErr": "parent node not found error",
+                "ParseLoginRedirectURLErr": "redirect login url error",
+                "PathHasEmptyName": "path has empty name error",
+                "PayloadTooLargeErr": "payload exceeds the size or depth limit",
+                "PermissionDenied": "permission denied",
+                "PointerIsNilErr": "pointer is nil error",
+                "QueryJobStatusKeyNotExistErr": "query job status key note exists error",
+                "QueryMachineStatusFailErr": "query machine status error",
+                "QueryRecordErr": "database query error",
+                "RPCHttpCodeErr": "rpc request http code error",
+                "RPCHttpCodeRespErr": "rpc request http code resp error",
+                "RPCHttpErr": "rpc request http error",
+                "RecordNotFound": "database record not found",
+                "RedisAddSetErr": "redis add user set error",
+                "RedisLuaRetErr": "redis lua return type error",
+                "RedisLuaScriptErr": "redis lua script error",
+                "RedisRemoveSetErr": "redis remove user set error",
+                "RefreshTokenErr": "refresh token failed",
+                "RefreshTokenParamErr": "refresh token parameter error",
+                "RegActionNameEmptyErr": "reg action name empty",
+                "ReportChannelDisabledErr": "delivery channel is not configured",
+                "ReportDeliveryMissingErr": "delivery has no stored report",
+                "ResNotExistErr": "resource not exist",
+                "ReservationConflictErr": "reservation conflicts with an existing one",
+                "ReservationTimeInvalidErr": "reservation time range invalid",
+                "ResourceIsEmptyErr": "resource is empty",
+                "ResourceNotExistErr": "resource not exist",
+                "RuleInvalidErr": "device rule invalid",
+                "SaveWorkflowEdgeErr": "batch save workflow edge error",
+                "SaveWorkflowNodeErr": "batch save nodes error",
+                "SavedViewExistErr": "saved view name already exists",
+                "SavedViewTargetErr": "saved view does not apply to this list",
+                "SeedForbiddenErr": "demo seeding is not allowed in this environment",
+                "SetLabHeartErr": "set lab heart error",
+                "StreamSessionEndedErr": "stream session already ended",
+                "StreamTokenInvalidErr": "stream viewing token invalid or expired",
+                "Success": "success",
+                "TargetDataNotMapAnyTypeErr": "target data not map any type error",
+                "TargetParamInvalidateErr": "target param invalidate error",
+                "TaskDependencyInvalidErr": "task dependency invalid",
+                "TemplateNodeNotFoundErr": "template node not found error",
+                "TopologyDeviceInvalidErr": "device does not exist in the lab",
+                "TopologyNodeExistErr": "topology node with the same name already exists under the parent",
+                "TopologyNodeNotEmptyErr": "topology node still has child nodes",
+                "TopologyParentInvalidErr": "topology node type is not allowed under the parent",
+                "UnDefineErr": "undefined",
+                "UnLogin": "not logged in",
+                "UnknownWSActionErr": "unknown material websocket action",
+                "UnknownWorkflowNodeTypeErr": "unknown workflow node type error",
+                "UnmarshalWSDataErr": "unmarshal material websocket data error",
+                "UpdateDataErr": "database update data error",
+                "UpdateNodeErr": "update material node error",
+                "UpgradeCampaignStatusErr": "upgrade campaign status does not allow the operation",
+                "UpgradeNoTargetErr": "no device matches the upgrade campaign filters",
+                "UpsertWorkflowEdgeErr": "upsert workflow edge error",
+                "UserIDIsEmptyErr": "user id is empty",
+                "ValueNotExistErr": "value not exist error",
+                "ValueSliceOutIndexErr": "value slice out index error",
+                "WorkflowHasCircularErr": "workflow has circular error",
+                "WorkflowInputInvalidErr": "workflow input invalid",
+                "WorkflowInputSchemaInvalidErr": "workflow input schema invalid",
+                "WorkflowNodeNoActionName": "workflow node no action name error",
+                "WorkflowNodeNoActionType": "workflow node no action type error",
+                "WorkflowNodeNoDeviceName": "workflow node no device name error",
+                "WorkflowNodeNotFoundErr": "workflow node not found error",
+                "WorkflowNodeScriptEmtpyErr": "workflow script empty error",
+                "WorkflowNotExistErr": "workflow not exist",
+                "WorkflowTaskAlreadyExistErr": "workflow task already exist error",
+                "WorkflowTaskFinished": "workflow task finished",
+                "WorkflowTaskNotFoundErr": "can not found workflow task error",
+                "WorkflowTaskStatusErr": "workflow task status error",
+                "WorkflowTaskStatusNotPendingErr": "workflow task not in pending status",
+                "WorkflowTemplateNotFoundErr": "can not found workflow template error"
+            },
+            "x-enum-descriptions": [
+                "success",
+                "undefined",
+                "no permission",
+                "invalidate jwt",
+                "parse parameter error",
+                "not pointer err",
+                "must be a pointer to a slice",
+                "pointer is nil error",
+                "login configuration error",
+                "set login state error",
+                "refresh token failed",
+                "state verification failed",
+                "exchange token failed",
+                "callback parameter error",
+                "get user info failed",
+                "login process user info failed",
+                "not logged in",
+                "login verification format error",
+                "invalid token",
+                "refresh token parameter error",
+                "redirect login url error",
+                "database create data error",
+                "database update data error",
+                "database record not found",
+                "database query error",
+                "database delete error",
+                "not base db type error",
+                "model not implement schema.Tabler",
+                "redis lua script error",
+                "redis lua return type error",
+                "redis add user set error",
+                "redis remove user set error",
+                "reg action name empty",
+                "resource is empty",
+                "resource not exist",
+                "can not found workflow template error",
+                "user id is empty",
+                "lab id is empty error",
+                "laboratory not found error",
+                "can not found laboratory invite link error",
+                "invite expired error",
+                "invalidate third id error",
+                "lab already deleted error",
+                "resource not exist",
+                "edge node not exist",
+                "node handle not exist",
+                "unknown material websocket action",
+                "unmarshal material websocket data error",
+                "cannot get lab id error",
+                "update material node error",
+                "parent node not found error",
+                "template node not found error",
+                "invalid dag error",
+                "max template node deep error",
+                "can not found material node error",
+                "machine already exist error",
+                "query machine status error",
+                "machine not exist error",
+                "machine reach max number error",
+                "machine is stopping",
+                "start machine unknown error",
+                "can not found target node error",
+                "path has empty name error",
+                "notify action already registry",
+                "notify subscribe channel fail",
+                "notify send message error",
+                "rpc request http error",
+                "rpc request http code error",
+                "rpc request http code resp error",
+                "create lab user error",
+                "query lab user error",
+                "bhor batch query user error",
+                "can not get workflow uuid",
+                "workflow not exist",
+                "upsert workflow edge error",
+                "permission denied",
+                "batch save nodes error",
+                "batch save workflow edge error",
+                "workflow node not found error",
+                "workflow not found error",
+                "format csv data error",
+                "workflow input schema invalid",
+                "workflow input invalid",
+                "workflow task already exist error",
+                "can not found edge session",
+                "workflow has circular error",
+                "connect closed when node running error",
+                "marshal node data error",
+                "job run fail error",
+                "can not found workflow task error",
+                "workflow task status error",
+                "workflow task finished",
+                "workflow node no device name error",
+                "workflow node no action name error",
+                "workflow node no action type error",
+                "query job status key note exists error",
+                "callback job status key note exists error",
+                "job timeout error",
+                "job retry timeout error",
+                "callback job status timeout error",
+                "job is canceled",
+                "can not get workflow task error",
+                "workflow task not in pending status",
+                "can not found workflow handle error",
+                "can not found parent node job error",
+                "param data key invalidate error",
+                "param data value invalidate error",
+                "data not map any type error",
+                "value slice out index error",
+                "value not exist error",
+                "set lab heart error",
+                "target data not map any type error",
+                "marshal target data error",
+                "target param invalidate error",
+                "workflow script empty error",
+                "unknown workflow node type error",
+                "exec workflow script error",
+                "edge not started error",
+                "approval request already decided",
+                "approval request rejected",
+                "approval status invalid",
+                "device lock error",
+                "wait device lock timeout",
+                "device lock not held",
+                "reservation time range invalid",
+                "reservation conflicts with an existing one",
+                "device is reserved by another user",
+                "task dependency invalid",
+                "dead letter job already requeued",
+                "approval cannot be decided by the user who started the step",
+                "background job schedule invalid",
+                "background job already registered",
+                "background job not registered",
+                "device capability descriptor invalid",
+                "device does not declare the action",
+                "device action parameters invalid",
+                "device command not in expected state",
+                "lab device limit exceeded",
+                "no device of the group can receive the command",
+                "device rule invalid",
+                "alert not in expected state",
+                "alert silence invalid",
+                "realtime camera feature disabled",
+                "stream viewing token invalid or expired",
+                "stream session already ended",
+                "upload offset does not match received size",
+                "file not in expected upload state",
+                "file exceeds size limit",
+                "file content rejected by validation",
+                "file storage error",
+                "file download url invalid or expired",
+                "material lot invalid",
+                "material remaining quantity insufficient",
+                "material lims sync not enabled",
+                "material sync already running for the lab",
+                "saved view name already exists",
+                "saved view does not apply to this list",
+                "delivery channel is not configured",
+                "delivery has no stored report",
+                "annotation has been deleted",
+                "mentioned user is not a lab member",
+                "lab export already running",
+                "lab export archive expired or not ready",
+                "lab data deletion confirmation invalid",
+                "history import already running for the lab",
+                "history import file format or table not supported",
+                "history import file too large",
+                "history export kind or filters invalid",
+                "history export file expired or not ready",
+                "event type or schema version not found",
+                "event payload violates the published schema",
+                "endpoint does not allow impersonated sessions",
+                "impersonated session cannot access other laboratories",
+                "impersonation target must be a lab member who is not an admin",
+                "service is in read-only maintenance",
+                "error rule pattern is not a valid regular expression",
+                "assistant is disabled or no model provider is configured",
+                "assistant request limit of the user is exceeded",
+                "assistant model provider request failed",
+                "upgrade campaign status does not allow the operation",
+                "no device matches the upgrade campaign filters",
+                "device config profile with the same name already exists",
+                "device config must be a JSON object",
+                "no device has the config assigned",
+                "topology node with the same name already exists under the parent",
+                "topology node type is not allowed under the parent",
+                "topology node still has child nodes",
+                "device does not exist in the lab",
+                "experiment with the same name already exists",
+                "archived experiment does not accept executions",
+                "legal hold target is missing or does not match the scope",
+                "legal hold is already released",
+                "history copy is not allowed in this environment",
+                "history copy source environment request failed",
+                "demo seeding is not allowed in this environment",
+                "failure injection is not allowed in this environment",
+                "payload exceeds the size or depth limit",
+                "too many websocket connections of the user",
+                "websocket topic is not valid",
+                "too many topics subscribed on the connection",
+                "resume token of the replay buffer is not valid",
+                "execution history was updated by another writer",
+                "event reporting limit of the device key is exceeded"
+            ],
+            "x-enum-varnames": [
+                "Success",
+                "UnDefineErr",
+                "NoPermission",
+                "InvalidateJWT",
+                "ParamErr",
+                "NotPointerErr",
+                "NotSlicePointerErr",
+                "PointerIsNilErr",
+                "LoginConfigErr",
+                "LoginSetStateErr",
+                "RefreshTokenErr",
+                "LoginStateErr",
+                "ExchangeTokenErr",
+                "CallbackParamErr",
+                "LoginGetUserInfoErr",
+                "LoginCallbackErr",
+                "UnLogin",
+                "LoginFormatErr",
+                "InvalidToken",
+                "RefreshTokenParamErr",
+                "ParseLoginRedirectURLErr",
+                "CreateDataErr",
+                "UpdateDataErr",
+                "RecordNotFound",
+                "QueryRecordErr",
+                "DeleteDataErr",
+                "NotBaseDBTypeErr",
+                "ModelNotImplementTablerErr",
+                "RedisLuaScriptErr",
+                "RedisLuaRetErr",
+                "RedisAddSetErr",
+                "RedisRemoveSetErr",
+                "RegActionNameEmptyErr",
+                "ResourceIsEmptyErr",
+                "ResourceNotExistErr",
+                "WorkflowTemplateNotFoundErr",
+                "UserIDIsEmptyErr",
+                "LabIDIsEmptyErr",
+                "LabNotFound",
+                "LabInviteNotFoundErr",
+                "InviteExpiredErr",
+                "InvalidateThirdID",
+                "LabAlreadyDeletedErr",
+                "ResNotExistErr",
+                "EdgeNodeNotExistErr",
+                "EdgeHandleNotExistErr",
+                "UnknownWSActionErr",
+                "UnmarshalWSDataErr",
+                "CanNotGetLabIDErr",
+                "UpdateNodeErr",
+                "ParentNodeNotFoundErr",
+                "TemplateNodeNotFoundErr",
+                "InvalidDagErr",
+                "MaxTplNodeDeepErr",
+                "CanNotFoundMaterialNodeErr",
+                "MachineAlreadyExistErr",
+                "QueryMachineStatusFailErr",
+                "MachineNotExistErr",
+                "MachineReachMaxNumCountErr",
+                "MachineNodeStoppingErr",
+                "MachineStartUnknownErr",
+                "CanNotFoundTargetNode",
+                "PathHasEmptyName",
+                "NotifyActionAlreadyRegistryErr",
+                "NotifySubscribeChannelErr",
+                "NotifySendMsgErr",
+                "RPCHttpErr",
+                "RPCHttpCodeErr",
+                "RPCHttpCodeRespErr",
+                "CasDoorCreateLabUserErr",
+                "CasDoorQueryLabUserErr",
+                "BohrBatchQueryErr",
+                "CanNotGetWorkflowUUIDErr",
+                "WorkflowNotExistErr",
+                "UpsertWorkflowEdgeErr",
+                "PermissionDenied",
+                "SaveWorkflowNodeErr",
+                "SaveWorkflowEdgeErr",
+                "WorkflowNodeNotFoundErr",
+                "CanNotGetworkflowErr",
+                "FormatCSVTaskErr",
+                "WorkflowInputSchemaInvalidErr",
+                "WorkflowInputInvalidErr",
+                "WorkflowTaskAlreadyExistErr",
+                "CanNotFoundEdgeSession",
+                "WorkflowHasCircularErr",
+                "EdgeConnectClosedErr",
+                "NodeDataMarshalErr",
+                "JobRunFailErr",
+                "WorkflowTaskNotFoundErr",
+                "WorkflowTaskStatusErr",
+                "WorkflowTaskFinished",
+                "WorkflowNodeNoDeviceName",
+                "WorkflowNodeNoActionName",
+                "WorkflowNodeNoActionType",
+                "QueryJobStatusKeyNotExistErr",
+                "CallbackJobStatusKeyNotExistErr",
+                "JobTimeoutErr",
+                "JobRetryTimeout",
+                "CallbackJobStatusTimeoutErr",
+                "JobCanceled",
+                "CanNotGetWorkflowTaskErr",
+                "WorkflowTaskStatusNotPendingErr",
+                "CanNotFoundWorkflowHandleErr",
+                "CanNotGetParentJobErr",
+                "ParamDataKeyInvalidateErr",
+                "ParamDataValueInvalidateErr",
+                "DataNotMapAnyTypeErr",
+                "ValueSliceOutIndexErr",
+                "ValueNotExistErr",
+                "SetLabHeartErr",
+                "TargetDataNotMapAnyTypeErr",
+                "MarshalTargetDataErr",
+                "TargetParamInvalidateErr",
+                "WorkflowNodeScriptEmtpyErr",
+                "UnknownWorkflowNodeTypeErr",
+                "ExecWorkflowNodeScriptErr",
+                "EdgeNotStartedErr",
+                "ApprovalAlreadyDecidedErr",
+                "ApprovalRejectedErr",
+                "ApprovalStatusInvalidErr",
+                "DeviceLockErr",
+                "DeviceLockWaitTimeoutErr",
+                "DeviceLockNotHeldErr",
+                "ReservationTimeInvalidErr",
+                "ReservationConflictErr",
+                "DeviceReservedErr",
+                "TaskDependencyInvalidErr",
+                "DeadLetterNotDeadErr",
+                "ApprovalSelfDecideErr",
+                "JobScheduleInvalidErr",
+                "JobAlreadyRegisteredErr",
+                "JobNotRegisteredErr",
+                "DeviceCapabilityInvalidErr",
+                "DeviceActionNotSupportedErr",
+                "DeviceActionParamInvalidErr",
+                "DeviceCommandStateErr",
+                "DeviceLimitExceededErr",
+                "DeviceGroupNoTargetErr",
+                "RuleInvalidErr",
+                "AlertStatusErr",
+                "AlertSilenceErr",
+                "CameraFeatureDisabledErr",
+                "StreamTokenInvalidErr",
+                "StreamSessionEndedErr",
+                "FileUploadOffsetErr",
+                "FileUploadStatusErr",
+                "FileTooLargeErr",
+                "FileContentRejectedErr",
+                "FileStorageErr",
+                "FileURLInvalidErr",
+                "MaterialInvalidErr",
+                "MaterialInsufficientErr",
+                "MaterialSyncDisabledErr",
+                "MaterialSyncRunningErr",
+                "SavedViewExistErr",
+                "SavedViewTargetErr",
+                "ReportChannelDisabledErr",
+                "ReportDeliveryMissingErr",
+                "AnnotationDeletedErr",
+                "AnnotationMentionErr",
+                "LabExportRunningErr",
+                "LabExportExpiredErr",
+                "LabPurgeConfirmErr",
+                "HistoryImportRunningErr",
+                "HistoryImportFormatErr",
+                "HistoryImportTooLargeErr",
+                "HistoryExportKindErr",
+                "HistoryExportExpiredErr",
+                "EventSchemaNotFoundErr",
+                "EventSchemaViolationErr",
+                "ImpersonationForbiddenErr",
+                "ImpersonationScopeErr",
+                "ImpersonationTargetErr",
+                "MaintenanceReadOnlyErr",
+                "ErrorRulePatternErr",
+                "AssistantDisabledErr",
+                "AssistantRateLimitErr",
+                "AssistantProviderErr",
+                "UpgradeCampaignStatusErr",
+                "UpgradeNoTargetErr",
+                "DeviceConfigExistErr",
+                "DeviceConfigInvalidErr",
+                "DeviceConfigNoTargetErr",
+                "TopologyNodeExistErr",
+                "TopologyParentInvalidErr",
+                "TopologyNodeNotEmptyErr",
+                "TopologyDeviceInvalidErr",
+                "ExperimentExistErr",
+                "ExperimentClosedErr",
+                "LegalHoldInvalidErr",
+                "LegalHoldReleasedErr",
+                "HistoryCopyForbiddenErr",
+                "HistoryCopySourceErr",
+                "SeedForbiddenErr",
+                "ChaosForbiddenErr",
+                "PayloadTooLargeErr",
+                "HubConnectionLimitErr",
+                "HubTopicInvalidErr",
+                "HubTopicLimitErr",
+                "HubResumeTokenErr",
+                "HistoryVersionConflictErr",
+                "DeviceKeyRateLimitErr"
+            ]
+        },
+        "command.EnqueueReq": {
+            "type": "object",
+            "required": [
+                "action",
+                "action_type",
+                "device_name",
+                "lab_uuid"
+            ],
+            "properties": {
+                "ack_timeout_sec": {
+                    "description": "下发后等待确认的时间，默认 30 秒",
+                    "type": "integer"
+                },
+                "action": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "description": "未确认时的最大下发次数，默认 3",
+                    "type": "integer"
+                },
+                "param": {
+                    "type": "object"
+                },
+                "result_timeout_sec": {
+                    "description": "确认后等待结果的时间，默认 10 分钟",
+                    "type": "integer"
+                }
+            }
+        },
+        "command.GroupCommandResp": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "commands": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeviceCommand"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "node_id": {
+                    "type": "integer"
+                },
+                "node_name": {
+                    "type": "string"
+                },
+                "param": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "skipped": {
+                    "description": "不支持该动作而跳过的设备",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.GroupCommandSkip"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/model.GroupCommandSummary"
+                },
+                "total": {
+                    "description": "下发的设备数",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "command.GroupEnqueueReq": {
+            "type": "object",
+            "required": [
+                "action",
+                "action_type",
+                "group_uuid",
+                "lab_uuid"
+            ],
+            "properties": {
+                "ack_timeout_sec": {
+                    "type": "integer"
+                },
+                "action": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "group_uuid": {
+                    "description": "设备组、房间或工作台",
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "param": {
+                    "type": "object"
+                },
+                "result_timeout_sec": {
+                    "type": "integer"
+                }
+            }
+        },
+        "command.ResultReq": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "object"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "common.Error": {
+            "type": "object",
+            "properties": {
+                "detail": {},
+                "info": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "msg": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "请求的 X-Request-ID",
+                    "type": "string"
+                },
+                "trace_id": {
+                    "description": "请求的 trace，反馈问题时用于查找 trace 和日志",
+                    "type": "string"
+                }
+            }
+        },
+        "common.ListResp-approval_ApprovalResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/approval.ApprovalResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-command_GroupCommandResp": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/command.GroupCommandResp"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-experiment_ExperimentResp": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/experiment.ExperimentResp"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-history_DeviceEventResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/history.DeviceEventResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-history_WorkflowExecutionListItem": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/history.WorkflowExecutionListItem"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-legalhold_HoldResp": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/legalhold.HoldResp"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_Alert": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Alert"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_AssistantAudit": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AssistantAudit"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_AuditLog": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AuditLog"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_BackgroundJobRun": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BackgroundJobRun"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_DeadLetterJob": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeadLetterJob"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_DeviceCommand": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeviceCommand"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_DeviceConfigApply": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeviceConfigApply"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_HistoryChainAnchor": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.HistoryChainAnchor"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_ImpersonationSession": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImpersonationSession"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_Material": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Material"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_Notification": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Notification"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ListResp-model_ReportDelivery": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ReportDelivery"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.Resp": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/code.ErrCode"
+                },
+                "data": {},
+                "error": {
+                    "$ref": "#/definitions/common.Error"
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "cost.BreakdownResp": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/cost.CategoryCost"
+                    }
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "daily": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/cost.DailyCost"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/cost.ItemCost"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "cost.CategoryCost": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "category": {
+                    "$ref": "#/definitions/model.CostCategory"
+                }
+            }
+        },
+        "cost.DailyCost": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "day": {
+                    "type": "string"
+                }
+            }
+        },
+        "cost.ItemCost": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "category": {
+                    "$ref": "#/definitions/model.CostCategory"
+                },
+                "item": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "cost.ModelReq": {
+            "type": "object",
+            "required": [
+                "currency",
+                "lab_uuid"
+            ],
+            "properties": {
+                "action_type_rates": {
+                    "description": "按动作类型定价的单次执行价格",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "device_hour_rate": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "device_hour_rates": {
+                    "description": "按设备名称单独定价",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "storage_gb_month_rate": {
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "dashboard.AlertSummary": {
+            "type": "object",
+            "properties": {
+                "acked": {
+                    "type": "integer"
+                },
+                "firing": {
+                    "type": "integer"
+                },
+                "items": {
+                    "description": "按触发时间倒序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Alert"
+                    }
+                }
+            }
+        },
+        "dashboard.ExecutionSummary": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "steps_completed": {
+                    "type": "integer"
+                },
+                "steps_failed": {
+                    "type": "integer"
+                },
+                "steps_total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "workflow_name": {
+                    "type": "string"
+                },
+                "workflow_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "dashboard.QueueDepth": {
+            "type": "object",
+            "properties": {
+                "pending": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dashboard.Response": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "$ref": "#/definitions/dashboard.AlertSummary"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/dashboard.SectionError"
+                    }
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "liveness": {
+                    "$ref": "#/definitions/model.LivenessSummary"
+                },
+                "queue": {
+                    "$ref": "#/definitions/dashboard.QueueDepth"
+                },
+                "recent_executions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dashboard.ExecutionSummary"
+                    }
+                },
+                "stats": {
+                    "$ref": "#/definitions/model.HistoryStats"
+                }
+            }
+        },
+        "dashboard.SectionError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/code.ErrCode"
+                },
+                "msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "datatypes.JSONType-model_Pose": {
+            "type": "object"
+        },
+        "datatypes.JSONType-model_ReturnInfo": {
+            "type": "object"
+        },
+        "device.CapacityResp": {
+            "type": "object",
+            "properties": {
+                "device_count": {
+                    "type": "integer"
+                },
+                "max_devices": {
+                    "description": "0 表示不限制",
+                    "type": "integer"
+                },
+                "overridden": {
+                    "description": "上限是否为实验室单独设置",
+                    "type": "boolean"
+                },
+                "remaining": {
+                    "description": "还可注册的设备数，不限制时为 -1",
+                    "type": "integer"
+                }
+            }
+        },
+        "device.DeviceReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "capabilities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeviceCapability"
+                    }
+                },
+                "class": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "vendor": {
+                    "type": "string"
+                }
+            }
+        },
+        "device.RegisterReq": {
+            "type": "object",
+            "required": [
+                "devices"
+            ],
+            "properties": {
+                "agent_version": {
+                    "type": "string"
+                },
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/device.DeviceReq"
+                    }
+                }
+            }
+        },
+        "device.SetLimitReq": {
+            "type": "object",
+            "required": [
+                "max_devices"
+            ],
+            "properties": {
+                "max_devices": {
+                    "description": "0 表示不限制",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "deviceconfig.AssignReq": {
+            "type": "object",
+            "properties": {
+                "device_name": {
+                    "type": "string"
+                },
+                "push": {
+                    "description": "立即下发到受影响的设备",
+                    "type": "boolean"
+                },
+                "version": {
+                    "description": "固定的版本，0 表示跟随最新版本",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "deviceconfig.CreateProfileReq": {
+            "type": "object",
+            "required": [
+                "config",
+                "lab_uuid",
+                "name"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "config": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "device_class": {
+                    "description": "适用的设备类型，为空时适用所有设备",
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "deviceconfig.CreateVersionReq": {
+            "type": "object",
+            "required": [
+                "config"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "config": {
+                    "type": "object"
+                },
+                "push": {
+                    "description": "立即下发到跟随最新版本的设备",
+                    "type": "boolean"
+                }
+            }
+        },
+        "deviceconfig.ProfileResp": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "device_class": {
+                    "description": "适用的设备类型，为空时适用所有设备",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "latest_version": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeviceConfigVersion"
+                    }
+                }
+            }
+        },
+        "deviceconfig.PushReq": {
+            "type": "object",
+            "properties": {
+                "device_names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "deviceconfig.ReportReq": {
+            "type": "object",
+            "required": [
+                "config"
+            ],
+            "properties": {
+                "config": {
+                    "type": "object"
+                }
+            }
+        },
+        "edgeevent.ActionIngestReq": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                }
+            }
+        },
+        "edgeevent.CreateKeyReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid",
+                "name"
+            ],
+            "properties": {
+                "device_name": {
+                    "description": "为空时可以上报实验室所有设备的事件",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "edgeevent.CreateKeyResp": {
+            "type": "object",
+            "properties": {
+                "authorization": {
+                    "description": "上报请求的 Authorization 请求头",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "description": "为空时可以上报实验室所有设备的事件",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "密钥的前几位，用于识别密钥",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "edgeevent.EventResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "accepted | rejected",
+                    "type": "string"
+                }
+            }
+        },
+        "edgeevent.IngestReq": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                }
+            }
+        },
+        "edgeevent.IngestResp": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/edgeevent.EventResult"
+                    }
+                }
+            }
+        },
+        "environment.DelLabReq": {
+            "type": "object",
+            "required": [
+                "uuid"
+            ],
+            "properties": {
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.InviteResp": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.LabInfoResp": {
+            "type": "object",
+            "properties": {
+                "access_key": {
+                    "type": "string"
+                },
+                "access_secret": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "is_online": {
+                    "type": "boolean"
+                },
+                "last_connected_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.EnvironmentStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.LabMemberListResp": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/environment.LabMemberResp"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "environment.LabMemberResp": {
+            "type": "object",
+            "properties": {
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "$ref": "#/definitions/model.LaboratoryMemberRole"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.LaboratoryEnvReq": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.LaboratoryEnvResp": {
+            "type": "object",
+            "properties": {
+                "access_key": {
+                    "type": "string"
+                },
+                "access_secret": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.LaboratoryListResp": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/environment.LaboratoryResp"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "environment.LaboratoryResp": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "is_online": {
+                    "type": "boolean"
+                },
+                "last_connected_at": {
+                    "type": "string"
+                },
+                "member_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.RegAction": {
+            "type": "object",
+            "properties": {
+                "feedback": {
+                    "type": "object"
+                },
+                "goal": {
+                    "type": "object"
+                },
+                "goal_default": {
+                    "type": "object"
+                },
+                "handles": {
+                    "type": "object"
+                },
+                "result": {
+                    "type": "object"
+                },
+                "schema": {
+                    "type": "object"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.RegClass": {
+            "type": "object",
+            "properties": {
+                "action_value_mappings": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/environment.RegAction"
+                    }
+                },
+                "module": {
+                    "type": "string"
+                },
+                "status_types": {
+                    "type": "object"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.RegHandle": {
+            "type": "object",
+            "properties": {
+                "data_key": {
+                    "type": "string"
+                },
+                "data_source": {
+                    "type": "string"
+                },
+                "data_type": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "handler_key": {
+                    "type": "string"
+                },
+                "io_type": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "side": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.RegInitParamSchema": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "$ref": "#/definitions/environment.RegSchema"
+                },
+                "data": {
+                    "$ref": "#/definitions/environment.RegSchema"
+                }
+            }
+        },
+        "environment.RegSchema": {
+            "type": "object",
+            "properties": {
+                "properties": {
+                    "type": "object"
+                },
+                "required": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "environment.Resource": {
+            "type": "object",
+            "required": [
+                "id",
+                "registry_type"
+            ],
+            "properties": {
+                "category": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "class": {
+                    "$ref": "#/definitions/environment.RegClass"
+                },
+                "config_info": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "file_path": {
+                    "type": "string"
+                },
+                "handles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/environment.RegHandle"
+                    }
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "init_param_schema": {
+                    "$ref": "#/definitions/environment.RegInitParamSchema"
+                },
+                "model": {
+                    "type": "object"
+                },
+                "registry_type": {
+                    "type": "string"
+                },
+                "selfDB": {
+                    "$ref": "#/definitions/model.ResourceNodeTemplate"
+                },
+                "version": {
+                    "type": "string",
+                    "default": "0.0.1"
+                }
+            }
+        },
+        "environment.ResourceReq": {
+            "type": "object",
+            "properties": {
+                "resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/environment.Resource"
+                    }
+                }
+            }
+        },
+        "environment.UpdateEnvReq": {
+            "type": "object",
+            "required": [
+                "uuid"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "errorrule.CreateReq": {
+            "type": "object",
+            "required": [
+                "category",
+                "lab_uuid",
+                "pattern"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "pattern": {
+                    "description": "正则表达式，不区分大小写",
+                    "type": "string",
+                    "maxLength": 1024
+                },
+                "priority": {
+                    "description": "越小越先匹配",
+                    "type": "integer"
+                }
+            }
+        },
+        "errorrule.TestReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid",
+                "message"
+            ],
+            "properties": {
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "errorrule.TestResp": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "rule": {
+                    "$ref": "#/definitions/model.ErrorRule"
+                }
+            }
+        },
+        "errorrule.UpdateReq": {
+            "type": "object",
+            "required": [
+                "category",
+                "pattern"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "pattern": {
+                    "type": "string",
+                    "maxLength": 1024
+                },
+                "priority": {
+                    "type": "integer"
+                }
+            }
+        },
+        "eventschema.CatalogResp": {
+            "type": "object",
+            "properties": {
+                "envelope": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/eventschema.Summary"
+                    }
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "eventschema.Summary": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "latest": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/eventschema.Type"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "eventschema.Type": {
+            "type": "string",
+            "enum": [
+                "execution.completed",
+                "device.disconnected",
+                "alert.fired",
+                "alert.resolved",
+                "alert.escalated",
+                "history.changed"
+            ],
+            "x-enum-varnames": [
+                "ExecutionCompleted",
+                "DeviceDisconnected",
+                "AlertFired",
+                "AlertResolved",
+                "AlertEscalated",
+                "HistoryChanged"
+            ]
+        },
+        "experiment.CreateReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid",
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "planned_runs": {
+                    "description": "计划执行次数，0 表示未设置",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "experiment.ExecutionsReq": {
+            "type": "object",
+            "required": [
+                "execution_uuids"
+            ],
+            "properties": {
+                "execution_uuids": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "experiment.ExecutionsResp": {
+            "type": "object",
+            "properties": {
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "experiment.ExperimentResp": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "planned_runs": {
+                    "description": "计划执行次数，0 表示未设置",
+                    "type": "integer"
+                },
+                "progress": {
+                    "$ref": "#/definitions/model.ExperimentProgress"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExperimentStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "experiment.UpdateReq": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
+                },
+                "planned_runs": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "status": {
+                    "description": "active | completed | archived",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ExperimentStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "file.CreateReq": {
+            "type": "object",
+            "required": [
+                "file_name",
+                "size"
+            ],
+            "properties": {
+                "action_execution_uuid": {
+                    "description": "产生该文件的动作执行记录",
+                    "type": "string"
+                },
+                "checksum": {
+                    "description": "可选，sha256 hex，上传完成时校验",
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "description": "用户上传时必填，edge 上传时使用 edge 所属实验室",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "file.URLResp": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "history.ActionExecutionDetailResponse": {
+            "type": "object",
+            "properties": {
+                "action_name": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "approval": {
+                    "$ref": "#/definitions/model.ApprovalRequest"
+                },
+                "compacted_at": {
+                    "description": "输入输出已归档，响应中为从归档读取的完整内容",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deviation_ms": {
+                    "description": "超出预算为正，未设预算时为空",
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error_category": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "expected_ms": {
+                    "type": "integer"
+                },
+                "input": {
+                    "type": "object"
+                },
+                "output": {
+                    "type": "object"
+                },
+                "over_budget": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "history.ActionExecutionResponse": {
+            "type": "object",
+            "properties": {
+                "action_name": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "approval": {
+                    "$ref": "#/definitions/model.ApprovalRequest"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deviation_ms": {
+                    "description": "超出预算为正，未设预算时为空",
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error_category": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "expected_ms": {
+                    "type": "integer"
+                },
+                "over_budget": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "history.DependencyResponse": {
+            "type": "object",
+            "properties": {
+                "dependents": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "depends_on": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "hold_status": {
+                    "$ref": "#/definitions/model.HeldTaskStatus"
+                },
+                "policy": {
+                    "$ref": "#/definitions/model.DependencyPolicy"
+                }
+            }
+        },
+        "history.DeviceEventResponse": {
+            "type": "object",
+            "properties": {
+                "device_uuid": {
+                    "type": "string"
+                },
+                "event_data": {},
+                "event_type": {
+                    "$ref": "#/definitions/model.DeviceEventType"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "history.HistoryChangeResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/history.ActionExecutionResponse"
+                },
+                "event": {
+                    "$ref": "#/definitions/history.DeviceEventResponse"
+                },
+                "execution": {
+                    "$ref": "#/definitions/history.WorkflowExecutionResponse"
+                },
+                "kind": {
+                    "$ref": "#/definitions/model.HistoryChangeKind"
+                },
+                "op": {
+                    "description": "created, updated",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "history.ListChangesResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/history.HistoryChangeResponse"
+                    }
+                },
+                "has_more": {
+                    "description": "还有变更未返回，应立即再次请求",
+                    "type": "boolean"
+                },
+                "watermark": {
+                    "description": "下次请求的 since，没有变更时与请求的 since 相同",
+                    "type": "string"
+                }
+            }
+        },
+        "history.SearchRequest": {
+            "type": "object",
+            "required": [
+                "lab_id",
+                "question"
+            ],
+            "properties": {
+                "lab_id": {
+                    "description": "问题中提到 lab N 时以问题为准",
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "question": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "history.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "interpretation": {
+                    "$ref": "#/definitions/model.HistoryInterpretation"
+                },
+                "results": {
+                    "description": "与对应列表接口的响应相同"
+                }
+            }
+        },
+        "history.SetExecutionLabelsRequest": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "history.WorkflowExecutionDetailResponse": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/history.ActionExecutionResponse"
+                    }
+                },
+                "batch_id": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "definition": {
+                    "description": "执行开始时的工作流定义，早于快照功能的执行为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.WorkflowSnapshot"
+                        }
+                    ]
+                },
+                "dependencies": {
+                    "$ref": "#/definitions/history.DependencyResponse"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error_category": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "estimate": {
+                    "description": "排队中或运行中时的预计完成时间",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ExecutionEstimate"
+                        }
+                    ]
+                },
+                "experiment_id": {
+                    "type": "integer"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "materials": {
+                    "description": "本次执行消耗的物料批次",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MaterialUsageSummary"
+                    }
+                },
+                "pinned": {
+                    "description": "当前用户是否置顶",
+                    "type": "boolean"
+                },
+                "sla_breached": {
+                    "description": "结束时计算",
+                    "type": "boolean"
+                },
+                "sla_target_ms": {
+                    "description": "0 表示未设置 SLA",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "steps_completed": {
+                    "type": "integer"
+                },
+                "steps_failed": {
+                    "type": "integer"
+                },
+                "steps_total": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "workflow_name": {
+                    "type": "string"
+                },
+                "workflow_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "history.WorkflowExecutionListItem": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/history.ActionExecutionResponse"
+                    }
+                },
+                "actions_truncated": {
+                    "description": "动作数超过上限，只返回前面的部分",
+                    "type": "boolean"
+                },
+                "batch_id": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error_category": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "experiment_id": {
+                    "type": "integer"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "pinned": {
+                    "description": "当前用户是否置顶",
+                    "type": "boolean"
+                },
+                "sla_breached": {
+                    "description": "结束时计算",
+                    "type": "boolean"
+                },
+                "sla_target_ms": {
+                    "description": "0 表示未设置 SLA",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "steps_completed": {
+                    "type": "integer"
+                },
+                "steps_failed": {
+                    "type": "integer"
+                },
+                "steps_total": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "workflow_name": {
+                    "type": "string"
+                },
+                "workflow_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "history.WorkflowExecutionResponse": {
+            "type": "object",
+            "properties": {
+                "batch_id": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error_category": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "experiment_id": {
+                    "type": "integer"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "pinned": {
+                    "description": "当前用户是否置顶",
+                    "type": "boolean"
+                },
+                "sla_breached": {
+                    "description": "结束时计算",
+                    "type": "boolean"
+                },
+                "sla_target_ms": {
+                    "description": "0 表示未设置 SLA",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "steps_completed": {
+                    "type": "integer"
+                },
+                "steps_failed": {
+                    "type": "integer"
+                },
+                "steps_total": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "workflow_name": {
+                    "type": "string"
+                },
+                "workflow_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "historycopy.DumpResp": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ActionExecutionHistory"
+                    }
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeviceEventHistory"
+                    }
+                },
+                "executions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WorkflowExecutionHistory"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "next_after_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "historyexport.CreateReq": {
+            "type": "object",
+            "required": [
+                "kind",
+                "lab_id"
+            ],
+            "properties": {
+                "filters": {
+                    "$ref": "#/definitions/model.HistoryExportFilters"
+                },
+                "kind": {
+                    "description": "workflow | action | device_event",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.HistoryExportKind"
+                        }
+                    ]
+                },
+                "lab_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "historyexport.ExportResp": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "执行次数，大于 1 表示从检查点恢复过",
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "download": {
+                    "$ref": "#/definitions/historyexport.URLResp"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "完成后导出文件的保留截止时间",
+                    "type": "string"
+                },
+                "filters": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "idempotency_key": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/model.HistoryExportKind"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "parts": {
+                    "description": "已写入存储的分片数",
+                    "type": "integer"
+                },
+                "progress": {
+                    "description": "完成百分比",
+                    "type": "integer"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.HistoryExportStatus"
+                },
+                "total_rows": {
+                    "description": "开始导出时统计的记录数",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "historyexport.URLResp": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "impersonation.StartReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid",
+                "reason",
+                "user_id"
+            ],
+            "properties": {
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "工单号或问题描述，写入审计日志",
+                    "type": "string"
+                },
+                "ttl_minutes": {
+                    "description": "为 0 时使用默认有效期，不超过最大有效期",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "impersonation.StartResp": {
+            "type": "object",
+            "properties": {
+                "authorization": {
+                    "description": "模拟请求的 Authorization 请求头",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "impersonator_id": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "description": "模拟会话只能访问该实验室",
+                    "type": "integer"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "被模拟的用户",
+                    "type": "string"
+                },
+                "user_name": {
+                    "type": "string"
+                },
+                "user_owner": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "ingest.AckResp": {
+            "type": "object",
+            "properties": {
+                "high_water_mark": {
+                    "type": "integer"
+                },
+                "max_seq": {
+                    "type": "integer"
+                },
+                "missing": {
+                    "description": "高水位和最大序号之间尚未收到的序号，最多 1000 个",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "stream": {
+                    "type": "string"
+                }
+            }
+        },
+        "ingest.IngestReq": {
+            "type": "object",
+            "required": [
+                "records",
+                "stream"
+            ],
+            "properties": {
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ingest.RecordReq"
+                    }
+                },
+                "stream": {
+                    "type": "string"
+                }
+            }
+        },
+        "ingest.IngestResp": {
+            "type": "object",
+            "properties": {
+                "acks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.IngestAck"
+                    }
+                },
+                "high_water_mark": {
+                    "description": "不大于该序号的记录都已写入，edge 可以丢弃",
+                    "type": "integer"
+                },
+                "max_seq": {
+                    "type": "integer"
+                },
+                "stream": {
+                    "type": "string"
+                }
+            }
+        },
+        "ingest.RecordReq": {
+            "type": "object",
+            "required": [
+                "seq"
+            ],
+            "properties": {
+                "action_name": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "description": "动作结束的原始时间",
+                    "type": "string"
+                },
+                "input": {
+                    "type": "object"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "output": {
+                    "type": "object"
+                },
+                "seq": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "started_at": {
+                    "description": "动作开始的原始时间",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "success | failed | timeout | cancelled",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ExecutionStatus"
+                        }
+                    ]
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "workflow_execution_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "inventory.RegisterReq": {
+            "type": "object",
+            "required": [
+                "kind",
+                "lab_uuid",
+                "lot_number",
+                "name",
+                "unit"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "sample | reagent | consumable",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.MaterialKind"
+                        }
+                    ]
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "lot_number": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "inventory.SyncStatusResp": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "interval_seconds": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "runs": {
+                    "description": "最近的同步记录，按开始时间倒序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MaterialSyncRun"
+                    }
+                }
+            }
+        },
+        "inventory.SyncTriggerReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid"
+            ],
+            "properties": {
+                "lab_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "inventory.UsageItem": {
+            "type": "object",
+            "required": [
+                "material_uuid"
+            ],
+            "properties": {
+                "action_execution_uuid": {
+                    "description": "消耗该物料的步骤",
+                    "type": "string"
+                },
+                "material_uuid": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                }
+            }
+        },
+        "inventory.UsageReq": {
+            "type": "object",
+            "required": [
+                "items",
+                "workflow_execution_uuid"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/inventory.UsageItem"
+                    }
+                },
+                "lab_uuid": {
+                    "description": "用户上报时必填，edge 上报时使用 edge 所属实验室",
+                    "type": "string"
+                },
+                "workflow_execution_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.JobResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "last_status": {
+                    "$ref": "#/definitions/model.JobRunStatus"
+                },
+                "max_retries": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "registered": {
+                    "description": "当前版本是否仍注册该任务",
+                    "type": "boolean"
+                },
+                "retry_backoff_sec": {
+                    "type": "integer"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "schedule_type": {
+                    "$ref": "#/definitions/model.JobScheduleType"
+                },
+                "timeout_sec": {
+                    "type": "integer"
+                },
+                "trigger_requested": {
+                    "description": "管理员请求立即执行",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "labexport.CreateReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid"
+            ],
+            "properties": {
+                "lab_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "labexport.PurgeReq": {
+            "type": "object",
+            "required": [
+                "confirm",
+                "lab_uuid",
+                "token"
+            ],
+            "properties": {
+                "confirm": {
+                    "description": "实验室名称",
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "labexport.PurgeResp": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "description": "删除的存储文件数",
+                    "type": "integer"
+                },
+                "rows": {
+                    "description": "删除的记录数",
+                    "type": "integer"
+                }
+            }
+        },
+        "labexport.PurgeTokenReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid"
+            ],
+            "properties": {
+                "lab_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "labexport.PurgeTokenResp": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "description": "需要输入的确认内容",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "labexport.URLResp": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "legalhold.HoldResp": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "execution_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "description": "不限实验室的时间段为 0",
+                    "type": "integer"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "placed_by": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "release_reason": {
+                    "type": "string"
+                },
+                "released_at": {
+                    "type": "string"
+                },
+                "released_by": {
+                    "type": "string"
+                },
+                "scope": {
+                    "$ref": "#/definitions/model.LegalHoldScope"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "target_uuid": {
+                    "description": "执行或工作流的 UUID",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "workflow_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "legalhold.PlaceReq": {
+            "type": "object",
+            "required": [
+                "reason",
+                "scope"
+            ],
+            "properties": {
+                "ends_at": {
+                    "description": "scope 为 time_range 时必填，不包含",
+                    "type": "string"
+                },
+                "execution_uuid": {
+                    "description": "scope 为 execution 时必填",
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "description": "scope 为 lab 时必填，time_range 时可选",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "scope": {
+                    "$ref": "#/definitions/model.LegalHoldScope"
+                },
+                "starts_at": {
+                    "description": "scope 为 time_range 时必填",
+                    "type": "string"
+                },
+                "workflow_uuid": {
+                    "description": "scope 为 workflow 时必填",
+                    "type": "string"
+                }
+            }
+        },
+        "legalhold.ReleaseReq": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "liveness.HeartbeatReq": {
+            "type": "object",
+            "properties": {
+                "agent_version": {
+                    "type": "string"
+                },
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "versions": {
+                    "description": "设备名称到该设备的 agent 版本，未列出的设备使用 agent_version",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "liveness.HeartbeatResp": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "integer"
+                },
+                "interval_sec": {
+                    "type": "integer"
+                },
+                "offline_after_sec": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lock.Lease": {
+            "type": "object",
+            "properties": {
+                "acquired_at": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "job_uuid": {
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "task_uuid": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "lock.State": {
+            "type": "object",
+            "properties": {
+                "device_name": {
+                    "type": "string"
+                },
+                "holder": {
+                    "$ref": "#/definitions/lock.Lease"
+                },
+                "ttl": {
+                    "type": "integer"
+                },
+                "waiters": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "login.RefreshTokenReq": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "login.RefreshTokenResp": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "maintenance.StartReq": {
+            "type": "object",
+            "properties": {
+                "eta": {
+                    "description": "预计结束时间，为空时未知",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "maintenance.StatusResp": {
+            "type": "object",
+            "properties": {
+                "global": {
+                    "$ref": "#/definitions/model.MaintenanceWindow"
+                },
+                "lab": {
+                    "$ref": "#/definitions/model.MaintenanceWindow"
+                },
+                "read_only": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "material.ActionInfo": {
+            "type": "object",
+            "properties": {
+                "feedback": {
+                    "type": "object"
+                },
+                "goal": {
+                    "type": "object"
+                },
+                "goal_default": {
+                    "type": "object"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "object"
+                },
+                "schema": {
+                    "type": "object"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.CreateMaterialEdgeReq": {
+            "type": "object",
+            "properties": {
+                "edges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.MaterialEdge"
+                    }
+                }
+            }
+        },
+        "material.CreateMaterialReq": {
+            "type": "object",
+            "properties": {
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.Material"
+                    }
+                }
+            }
+        },
+        "material.CreateMaterialResp": {
+            "type": "object",
+            "required": [
+                "id",
+                "name"
+            ],
+            "properties": {
+                "cloud_uuid": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "实际是数据库的 name",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "实际是数据库的 display name",
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.DeviceAction": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "object"
+                }
+            }
+        },
+        "material.DeviceActionResp": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.DeviceAction"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.DownloadMaterialResp": {
+            "type": "object",
+            "properties": {
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.Node"
+                    }
+                }
+            }
+        },
+        "material.Edge": {
+            "type": "object",
+            "properties": {
+                "source": {
+                    "type": "string"
+                },
+                "sourceHandle": {
+                    "description": "FIXME: 下面两个字段命令 unilab 需要修改命名称",
+                    "type": "string"
+                },
+                "source_uuid": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "targetHandle": {
+                    "type": "string"
+                },
+                "target_uuid": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.EdgeNode": {
+            "type": "object",
+            "properties": {
+                "class": {
+                    "type": "string"
+                },
+                "config": {
+                    "type": "object"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "extra": {
+                    "type": "object"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "object"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_uuid": {
+                    "type": "string"
+                },
+                "pose": {
+                    "$ref": "#/definitions/model.Pose"
+                },
+                "schema": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.DEVICETYPE"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.GraphEdge": {
+            "type": "object",
+            "properties": {
+                "edges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.Edge"
+                    }
+                }
+            }
+        },
+        "material.GraphNodeReq": {
+            "type": "object",
+            "properties": {
+                "edges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.Edge"
+                    }
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.Node"
+                    }
+                }
+            }
+        },
+        "material.Material": {
+            "type": "object",
+            "required": [
+                "class",
+                "id",
+                "name",
+                "type",
+                "uuid"
+            ],
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "class": {
+                    "type": "string"
+                },
+                "config": {
+                    "type": "object"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "实际是数据库的 name",
+                    "type": "string"
+                },
+                "model": {
+                    "type": "object"
+                },
+                "name": {
+                    "description": "实际是数据库的 display name",
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                },
+                "parent_uuid": {
+                    "type": "string"
+                },
+                "pose": {
+                    "type": "object"
+                },
+                "position": {
+                    "$ref": "#/definitions/model.Position"
+                },
+                "position_3d": {
+                    "$ref": "#/definitions/model.Position"
+                },
+                "schema": {
+                    "description": "FIXME: 这块后续要优化掉，从 reg 获取",
+                    "type": "object"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.DEVICETYPE"
+                },
+                "uuid": {
+                    "description": "有可能是 edge 编造 || 云端的",
+                    "type": "string"
+                }
+            }
+        },
+        "material.MaterialEdge": {
+            "type": "object",
+            "properties": {
+                "sourceHandle": {
+                    "type": "string"
+                },
+                "source_uuid": {
+                    "type": "string"
+                },
+                "targetHandle": {
+                    "type": "string"
+                },
+                "target_uuid": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.MaterialQueryReq": {
+            "type": "object",
+            "properties": {
+                "uuids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "material.MaterialQueryResp": {
+            "type": "object",
+            "properties": {
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.EdgeNode"
+                    }
+                }
+            }
+        },
+        "material.MaterialResp": {
+            "type": "object",
+            "properties": {
+                "child_nodes_uuid": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "class": {
+                    "type": "string"
+                },
+                "cloud_uuid": {
+                    "type": "string"
+                },
+                "collapsed": {
+                    "type": "boolean"
+                },
+                "config": {
+                    "type": "object"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "dirs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "disabled": {
+                    "type": "boolean"
+                },
+                "dragHandle": {
+                    "type": "string"
+                },
+                "eq_type": {
+                    "type": "string"
+                },
+                "experiment_env": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "minimized": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                },
+                "parent_node_uuid": {
+                    "type": "string"
+                },
+                "position": {
+                    "$ref": "#/definitions/model.Position"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "number"
+                }
+            }
+        },
+        "material.Node": {
+            "type": "object",
+            "required": [
+                "class",
+                "id",
+                "name",
+                "type"
+            ],
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "class": {
+                    "type": "string"
+                },
+                "config": {
+                    "type": "object"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "extra": {
+                    "type": "object"
+                },
+                "id": {
+                    "description": "实际是数据库的 name",
+                    "type": "string"
+                },
+                "model": {
+                    "type": "object"
+                },
+                "name": {
+                    "description": "实际是数据库的 display name",
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                },
+                "parent_uuid": {
+                    "type": "string"
+                },
+                "pose": {
+                    "type": "object"
+                },
+                "position": {
+                    "$ref": "#/definitions/model.Position"
+                },
+                "position_3d": {
+                    "$ref": "#/definitions/model.Position"
+                },
+                "schema": {
+                    "description": "FIXME: 这块后续要优化掉，从 reg 获取",
+                    "type": "object"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.DEVICETYPE"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.ResourceHandleTemplate": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "io_type": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "side": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.ResourceInfo": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parent_uuid": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.ResourceResp": {
+            "type": "object",
+            "properties": {
+                "resource_name_list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.ResourceInfo"
+                    }
+                }
+            }
+        },
+        "material.ResourceTemplateInfo": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "description": "该资源支持的动作列表",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.ActionInfo"
+                    }
+                },
+                "config_schema": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "data_schema": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "material_count": {
+                    "description": "该资源实例化的物料数量",
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "object"
+                },
+                "module": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "resource_type": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.ResourceTemplateResp": {
+            "type": "object",
+            "properties": {
+                "templates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.ResourceTemplateInfo"
+                    }
+                }
+            }
+        },
+        "material.SaveGrapReq": {
+            "type": "object",
+            "properties": {
+                "graph": {
+                    "$ref": "#/definitions/material.WSGraph"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.TemplateResp": {
+            "type": "object",
+            "properties": {
+                "config_infos": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "config_schema": {
+                    "type": "object"
+                },
+                "data_schema": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "footer": {
+                    "type": "string"
+                },
+                "handles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.ResourceHandleTemplate"
+                    }
+                },
+                "header": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "object"
+                },
+                "module": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_uuid": {
+                    "type": "string"
+                },
+                "pose": {
+                    "type": "object"
+                },
+                "resource_type": {
+                    "type": "string"
+                },
+                "status_types": {
+                    "type": "object"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.UpdateMaterialReq": {
+            "type": "object",
+            "properties": {
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.Node"
+                    }
+                }
+            }
+        },
+        "material.UpsertMaterialReq": {
+            "type": "object",
+            "properties": {
+                "mount_uuid": {
+                    "type": "string"
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.Material"
+                    }
+                }
+            }
+        },
+        "material.UpsertMaterialResp": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "cloud_uuid": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.WSEdge": {
+            "type": "object",
+            "properties": {
+                "source_handle_uuid": {
+                    "type": "string"
+                },
+                "source_node_uuid": {
+                    "type": "string"
+                },
+                "target_handle_uuid": {
+                    "type": "string"
+                },
+                "target_node_uuid": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "material.WSGraph": {
+            "type": "object",
+            "properties": {
+                "edges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.WSEdge"
+                    }
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.WSNode"
+                    }
+                }
+            }
+        },
+        "material.WSHandle": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "io_type": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "side": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "description": "NodeUUID    uuid.UUID ` + "`" + `json:\"node_uuid\"` + "`" + `",
+                    "type": "string"
+                }
+            }
+        },
+        "material.WSNode": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "handles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/material.WSHandle"
+                    }
+                },
+                "header": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "init_param_data": {
+                    "type": "object"
+                },
+                "model": {
+                    "type": "object"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_uuid": {
+                    "type": "string"
+                },
+                "plate_well_datas": {
+                    "type": "object"
+                },
+                "pose": {
+                    "type": "object"
+                },
+                "res_template_name": {
+                    "type": "string"
+                },
+                "res_template_uuid": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.DEVICETYPE"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "migrate.MigrationStatus": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "applied_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "migrate.Status": {
+            "type": "object",
+            "properties": {
+                "events_partitioned": {
+                    "type": "boolean"
+                },
+                "latest_schema_version": {
+                    "description": "当前代码的模型结构版本",
+                    "type": "string"
+                },
+                "migrations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/migrate.MigrationStatus"
+                    }
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "schema_version": {
+                    "description": "AutoMigrate 已执行的模型结构版本",
+                    "type": "string"
+                }
+            }
+        },
+        "model.ActionExecutionHistory": {
+            "type": "object",
+            "properties": {
+                "action_name": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "compacted_at": {
+                    "description": "输入输出已归档并替换为摘要",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error_category": {
+                    "description": "失败或超时时按实验室规则分类",
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "expected_ms": {
+                    "description": "执行时步骤的时长预算",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "input": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "metadata": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "output": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "traceparent": {
+                    "description": "执行动作时的 W3C trace context",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "workflow_execution_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ActivityHeatmap": {
+            "type": "object",
+            "properties": {
+                "end_time": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "matrix": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer",
+                            "format": "int64"
+                        }
+                    }
+                },
+                "peak": {
+                    "description": "执行最多的时段，没有执行时为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.HeatmapCell"
+                        }
+                    ]
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AgentUpgradeCampaign": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "filters": {
+                    "type": "object"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "max_failures": {
+                    "description": "失败数超过时自动暂停，0 表示不限制",
+                    "type": "integer"
+                },
+                "message": {
+                    "description": "自动暂停等状态变化的原因",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "package_url": {
+                    "type": "string"
+                },
+                "rollout_percent": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.UpgradeCampaignStatus"
+                },
+                "target_version": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AgentUpgradeTarget": {
+            "type": "object",
+            "properties": {
+                "command_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "dispatched_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "from_version": {
+                    "description": "升级前的版本，回滚到该版本",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "rollback_command_id": {
+                    "type": "integer"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.UpgradeTargetStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AgentVersionCount": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "integer"
+                },
+                "online": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Alert": {
+            "type": "object",
+            "properties": {
+                "acked_at": {
+                    "type": "string"
+                },
+                "acked_by": {
+                    "type": "string"
+                },
+                "assignee_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "escalated_at": {
+                    "type": "string"
+                },
+                "fired_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "resolved_by": {
+                    "description": "手动恢复的用户，自动恢复时为空",
+                    "type": "string"
+                },
+                "rule_id": {
+                    "type": "integer"
+                },
+                "rule_name": {
+                    "type": "string"
+                },
+                "severity": {
+                    "$ref": "#/definitions/model.AlertSeverity"
+                },
+                "silenced": {
+                    "description": "触发时处于静默窗口，不发送通知也不升级",
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.AlertStatus"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AlertChannel": {
+            "type": "object",
+            "properties": {
+                "type": {
+                    "$ref": "#/definitions/model.AlertChannelType"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AlertChannelType": {
+            "type": "string",
+            "enum": [
+                "broadcast",
+                "webhook"
+            ],
+            "x-enum-comments": {
+                "AlertChannelBroadcast": "通过消息中心推送给在线的实验室成员"
+            },
+            "x-enum-descriptions": [
+                "通过消息中心推送给在线的实验室成员",
+                ""
+            ],
+            "x-enum-varnames": [
+                "AlertChannelBroadcast",
+                "AlertChannelWebhook"
+            ]
+        },
+        "model.AlertSeverity": {
+            "type": "string",
+            "enum": [
+                "info",
+                "warning",
+                "critical"
+            ],
+            "x-enum-varnames": [
+                "AlertSeverityInfo",
+                "AlertSeverityWarning",
+                "AlertSeverityCritical"
+            ]
+        },
+        "model.AlertSilence": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AlertStatus": {
+            "type": "string",
+            "enum": [
+                "firing",
+                "acked",
+                "resolved"
+            ],
+            "x-enum-varnames": [
+                "AlertFiring",
+                "AlertAcked",
+                "AlertResolved"
+            ]
+        },
+        "model.ApprovalRequest": {
+            "type": "object",
+            "properties": {
+                "action_execution_id": {
+                    "type": "integer"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "node_uuid": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewer_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ApprovalStatus"
+                },
+                "step_name": {
+                    "type": "string"
+                },
+                "task_uuid": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ApprovalStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "approved",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "ApprovalStatusPending",
+                "ApprovalStatusApproved",
+                "ApprovalStatusRejected"
+            ]
+        },
+        "model.AssistantAudit": {
+            "type": "object",
+            "properties": {
+                "completion_tokens": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "execution_uuid": {
+                    "description": "历史查询时为空",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "diagnose | query",
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "prompt": {
+                    "type": "string"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "response": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "success | failed",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AuditAction": {
+            "type": "string",
+            "enum": [
+                "config.feature",
+                "config.chaos",
+                "maintenance.start",
+                "maintenance.end",
+                "job.pause",
+                "job.resume",
+                "impersonation.start",
+                "impersonation.revoke",
+                "impersonation.request",
+                "impersonation.denied",
+                "legal_hold.place",
+                "legal_hold.release"
+            ],
+            "x-enum-comments": {
+                "AuditChaosChange": "修改故障注入配置",
+                "AuditFeatureChange": "启动时功能开关与上次启动不同",
+                "AuditImpersonatedRequest": "模拟会话发起的请求",
+                "AuditImpersonationDenied": "模拟会话访问禁止模拟的接口或其他实验室",
+                "AuditJobPause": "暂停后台任务调度",
+                "AuditMaintenanceStart": "开始或更新维护"
+            },
+            "x-enum-descriptions": [
+                "启动时功能开关与上次启动不同",
+                "修改故障注入配置",
+                "开始或更新维护",
+                "",
+                "暂停后台任务调度",
+                "",
+                "",
+                "",
+                "模拟会话发起的请求",
+                "模拟会话访问禁止模拟的接口或其他实验室",
+                "",
+                ""
+            ],
+            "x-enum-varnames": [
+                "AuditFeatureChange",
+                "AuditChaosChange",
+                "AuditMaintenanceStart",
+                "AuditMaintenanceEnd",
+                "AuditJobPause",
+                "AuditJobResume",
+                "AuditImpersonationStart",
+                "AuditImpersonationRevoke",
+                "AuditImpersonatedRequest",
+                "AuditImpersonationDenied",
+                "AuditLegalHoldPlace",
+                "AuditLegalHoldRelease"
+            ]
+        },
+        "model.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/model.AuditAction"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "impersonator_id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "route": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.BackgroundJobRun": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instance": {
+                    "description": "执行的副本",
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "integer"
+                },
+                "job_name": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.JobRunStatus"
+                },
+                "trigger": {
+                    "$ref": "#/definitions/model.JobTrigger"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CameraStream": {
+            "type": "object",
+            "properties": {
+                "camera_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "host_id": {
+                    "description": "edge 连接 realtime 信令时使用的 host id",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ChainIssue": {
+            "type": "object",
+            "properties": {
+                "problem": {
+                    "$ref": "#/definitions/model.ChainProblem"
+                },
+                "record_type": {
+                    "$ref": "#/definitions/model.ChainRecordType"
+                },
+                "record_uuid": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ChainProblem": {
+            "type": "string",
+            "enum": [
+                "seq_gap",
+                "link_broken",
+                "hash_mismatch",
+                "content_mismatch",
+                "record_missing",
+                "anchor_mismatch"
+            ],
+            "x-enum-comments": {
+                "ChainAnchorMismatch": "已发布的锚点与链不一致",
+                "ChainContentMismatch": "记录内容被修改",
+                "ChainHashMismatch": "条目被修改",
+                "ChainLinkBroken": "prev_hash 与上一条的 hash 不一致",
+                "ChainRecordMissing": "记录被删除且不是保留策略清理的",
+                "ChainSeqGap": "序号不连续，有条目被删除"
+            },
+            "x-enum-descriptions": [
+                "序号不连续，有条目被删除",
+                "prev_hash 与上一条的 hash 不一致",
+                "条目被修改",
+                "记录内容被修改",
+                "记录被删除且不是保留策略清理的",
+                "已发布的锚点与链不一致"
+            ],
+            "x-enum-varnames": [
+                "ChainSeqGap",
+                "ChainLinkBroken",
+                "ChainHashMismatch",
+                "ChainContentMismatch",
+                "ChainRecordMissing",
+                "ChainAnchorMismatch"
+            ]
+        },
+        "model.ChainRecordType": {
+            "type": "string",
+            "enum": [
+                "workflow_execution",
+                "audit_log"
+            ],
+            "x-enum-comments": {
+                "ChainWorkflowExecution": "结束后的工作流执行"
+            },
+            "x-enum-descriptions": [
+                "结束后的工作流执行",
+                ""
+            ],
+            "x-enum-varnames": [
+                "ChainWorkflowExecution",
+                "ChainAuditLog"
+            ]
+        },
+        "model.ChainVerifyResult": {
+            "type": "object",
+            "properties": {
+                "anchors": {
+                    "description": "校验的锚点数",
+                    "type": "integer"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "head_hash": {
+                    "type": "string"
+                },
+                "head_seq": {
+                    "type": "integer"
+                },
+                "issues": {
+                    "description": "最多返回 maxChainIssues 条",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ChainIssue"
+                    }
+                },
+                "pruned": {
+                    "description": "已清理记录的条目数",
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "boolean"
+                },
+                "verified_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CostCategory": {
+            "type": "string",
+            "enum": [
+                "device_hour",
+                "action",
+                "storage"
+            ],
+            "x-enum-comments": {
+                "CostCategoryAction": "item 为动作类型",
+                "CostCategoryDeviceHour": "item 为设备名称",
+                "CostCategoryStorage": "item 为存储类型"
+            },
+            "x-enum-descriptions": [
+                "item 为设备名称",
+                "item 为动作类型",
+                "item 为存储类型"
+            ],
+            "x-enum-varnames": [
+                "CostCategoryDeviceHour",
+                "CostCategoryAction",
+                "CostCategoryStorage"
+            ]
+        },
+        "model.CostModel": {
+            "type": "object",
+            "properties": {
+                "action_type_rates": {
+                    "description": "按动作类型定价的单次执行价格",
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "device_hour_rate": {
+                    "description": "未单独定价设备的机时单价",
+                    "type": "number"
+                },
+                "device_hour_rates": {
+                    "description": "按设备名称定价的机时单价",
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "storage_gb_month_rate": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updated_by": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DEVICETYPE": {
+            "type": "string",
+            "enum": [
+                "repository",
+                "plate",
+                "container",
+                "resource",
+                "device",
+                "well",
+                "tip",
+                "tip_rack",
+                "tip_spot",
+                "deck",
+                "workstation"
+            ],
+            "x-enum-varnames": [
+                "MATERIALREPO",
+                "MATERIALPLATE",
+                "MATERIALCONTAINER",
+                "MATERIALRESOURCE",
+                "MATERIALDEVICE",
+                "MATERIALWELL",
+                "MATERIALTIP",
+                "MATERIALTIPRACK",
+                "MATERIALTIPSPOT",
+                "MATERIALDECK",
+                "MATERIALWORKSTATION"
+            ]
+        },
+        "model.DeadLetterJob": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failures": {
+                    "description": "[]DeadLetterFailure",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "description": "原始任务消息",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "queue": {
+                    "description": "重新投递的队列",
+                    "type": "string"
+                },
+                "requeue_count": {
+                    "type": "integer"
+                },
+                "requeued_at": {
+                    "type": "string"
+                },
+                "requeued_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.DeadLetterStatus"
+                },
+                "task_uuid": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeadLetterStats": {
+            "type": "object",
+            "properties": {
+                "depth": {
+                    "type": "integer"
+                },
+                "oldest_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeadLetterStatus": {
+            "type": "string",
+            "enum": [
+                "dead",
+                "requeued"
+            ],
+            "x-enum-varnames": [
+                "DeadLetterStatusDead",
+                "DeadLetterStatusRequeued"
+            ]
+        },
+        "model.DependencyPolicy": {
+            "type": "string",
+            "enum": [
+                "success",
+                "always"
+            ],
+            "x-enum-comments": {
+                "DependencyPolicyAlways": "前置任务全部结束即运行",
+                "DependencyPolicySuccess": "前置任务全部成功才运行，否则取消"
+            },
+            "x-enum-descriptions": [
+                "前置任务全部成功才运行，否则取消",
+                "前置任务全部结束即运行"
+            ],
+            "x-enum-varnames": [
+                "DependencyPolicySuccess",
+                "DependencyPolicyAlways"
+            ]
+        },
+        "model.Device": {
+            "type": "object",
+            "properties": {
+                "agent_version": {
+                    "type": "string"
+                },
+                "capabilities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeviceCapability"
+                    }
+                },
+                "class": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "与工作流节点 device_name 对应",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "vendor": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "description": "为空时可以上报实验室所有设备的事件",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "密钥的前几位，用于识别密钥",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceCapability": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "param_schema": {
+                    "type": "object"
+                }
+            }
+        },
+        "model.DeviceCommand": {
+            "type": "object",
+            "properties": {
+                "ack_deadline": {
+                    "type": "string"
+                },
+                "ack_timeout_sec": {
+                    "type": "integer"
+                },
+                "acked_at": {
+                    "type": "string"
+                },
+                "action": {
+                    "type": "string"
+                },
+                "action_execution_id": {
+                    "type": "integer"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "group_command_id": {
+                    "description": "设备组指令扇出的指令",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "param": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "result_deadline": {
+                    "type": "string"
+                },
+                "result_timeout_sec": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.DeviceCommandStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceCommandStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "delivered",
+                "acked",
+                "succeeded",
+                "failed",
+                "timeout"
+            ],
+            "x-enum-comments": {
+                "DeviceCommandAcked": "edge 已确认，等待结果",
+                "DeviceCommandDelivered": "已下发，等待确认",
+                "DeviceCommandQueued": "等待 edge 拉取",
+                "DeviceCommandTimeout": "重试耗尽或结果超时"
+            },
+            "x-enum-descriptions": [
+                "等待 edge 拉取",
+                "已下发，等待确认",
+                "edge 已确认，等待结果",
+                "",
+                "",
+                "重试耗尽或结果超时"
+            ],
+            "x-enum-varnames": [
+                "DeviceCommandQueued",
+                "DeviceCommandDelivered",
+                "DeviceCommandAcked",
+                "DeviceCommandSucceeded",
+                "DeviceCommandFailed",
+                "DeviceCommandTimeout"
+            ]
+        },
+        "model.DeviceConfigApply": {
+            "type": "object",
+            "properties": {
+                "acked_at": {
+                    "type": "string"
+                },
+                "command_uuid": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "profile_name": {
+                    "type": "string"
+                },
+                "profile_uuid": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.DeviceConfigApplyStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.DeviceConfigApplyStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "acked",
+                "applied",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "DeviceConfigApplyAcked": "edge 已确认，等待结果",
+                "DeviceConfigApplyPending": "指令等待 edge 拉取或确认"
+            },
+            "x-enum-descriptions": [
+                "指令等待 edge 拉取或确认",
+                "edge 已确认，等待结果",
+                "",
+                ""
+            ],
+            "x-enum-varnames": [
+                "DeviceConfigApplyPending",
+                "DeviceConfigApplyAcked",
+                "DeviceConfigApplyApplied",
+                "DeviceConfigApplyFailed"
+            ]
+        },
+        "model.DeviceConfigAssignment": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "device_class": {
+                    "description": "实验室级分配的设备类型，设备级分配为空",
+                    "type": "string"
+                },
+                "device_name": {
+                    "description": "为空表示实验室级分配",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "profile_name": {
+                    "type": "string"
+                },
+                "profile_uuid": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "固定的版本，0 表示跟随最新版本",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.DeviceConfigDrift": {
+            "type": "string",
+            "enum": [
+                "in_sync",
+                "drifted",
+                "unreported",
+                "unassigned"
+            ],
+            "x-enum-comments": {
+                "DeviceConfigUnassigned": "设备没有分配配置",
+                "DeviceConfigUnreported": "设备尚未上报配置"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "设备尚未上报配置",
+                "设备没有分配配置"
+            ],
+            "x-enum-varnames": [
+                "DeviceConfigInSync",
+                "DeviceConfigDrifted",
+                "DeviceConfigUnreported",
+                "DeviceConfigUnassigned"
+            ]
+        },
+        "model.DeviceConfigProfile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "device_class": {
+                    "description": "适用的设备类型，为空时适用所有设备",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "latest_version": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceConfigReport": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "reported_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceConfigStatus": {
+            "type": "object",
+            "properties": {
+                "applied_version": {
+                    "description": "最近一次成功应用的版本，0 表示没有",
+                    "type": "integer"
+                },
+                "desired_version": {
+                    "type": "integer"
+                },
+                "device_class": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "drift": {
+                    "$ref": "#/definitions/model.DeviceConfigDrift"
+                },
+                "drift_keys": {
+                    "description": "与分配配置不一致的顶层字段",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "last_apply": {
+                    "$ref": "#/definitions/model.DeviceConfigApply"
+                },
+                "profile_name": {
+                    "type": "string"
+                },
+                "profile_uuid": {
+                    "type": "string"
+                },
+                "reported_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceConfigVersion": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "config": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.DeviceEventHistory": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "event_data": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "event_type": {
+                    "$ref": "#/definitions/model.DeviceEventType"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceEventType": {
+            "type": "string",
+            "enum": [
+                "status_change",
+                "data_received",
+                "error",
+                "connected",
+                "disconnected",
+                "command_sent",
+                "command_result",
+                "lock_waiting",
+                "lock_acquired",
+                "lock_released",
+                "lock_lost",
+                "stream_started",
+                "stream_stopped"
+            ],
+            "x-enum-varnames": [
+                "DeviceEventStatusChange",
+                "DeviceEventDataReceived",
+                "DeviceEventError",
+                "DeviceEventConnected",
+                "DeviceEventDisconnected",
+                "DeviceEventCommandSent",
+                "DeviceEventCommandResult",
+                "DeviceEventLockWaiting",
+                "DeviceEventLockAcquired",
+                "DeviceEventLockReleased",
+                "DeviceEventLockLost",
+                "DeviceEventStreamStarted",
+                "DeviceEventStreamStopped"
+            ]
+        },
+        "model.DeviceLiveness": {
+            "type": "object",
+            "properties": {
+                "agent_version": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.LivenessStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceRank": {
+            "type": "object",
+            "properties": {
+                "device_name": {
+                    "type": "string"
+                },
+                "executions": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "total_duration_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.DeviceRiskLevel": {
+            "type": "string",
+            "enum": [
+                "normal",
+                "warning",
+                "critical"
+            ],
+            "x-enum-varnames": [
+                "DeviceRiskNormal",
+                "DeviceRiskWarning",
+                "DeviceRiskCritical"
+            ]
+        },
+        "model.DeviceRiskScore": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "description": "窗口内结束的动作数",
+                    "type": "integer"
+                },
+                "baseline_errors_per_day": {
+                    "description": "基线每天的错误事件数",
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "drift_score": {
+                    "description": "时长漂移的评分 (0-1)",
+                    "type": "number"
+                },
+                "duration_drift": {
+                    "description": "动作时长中位数相对基线的变化比例，按动作次数加权",
+                    "type": "number"
+                },
+                "error_events": {
+                    "description": "窗口内的错误事件数",
+                    "type": "integer"
+                },
+                "error_score": {
+                    "description": "错误频率的评分 (0-1)",
+                    "type": "number"
+                },
+                "errors_per_day": {
+                    "description": "窗口内每天的错误事件数",
+                    "type": "number"
+                },
+                "failed_actions": {
+                    "description": "窗口内失败的动作数",
+                    "type": "integer"
+                },
+                "failure_rate": {
+                    "description": "窗口内动作失败率",
+                    "type": "number"
+                },
+                "failure_score": {
+                    "description": "失败率的评分 (0-1)",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "level": {
+                    "$ref": "#/definitions/model.DeviceRiskLevel"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "scored_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceRule": {
+            "type": "object",
+            "properties": {
+                "aggregation": {
+                    "$ref": "#/definitions/model.TelemetryAgg"
+                },
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AlertChannel"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "description": "为空时匹配实验室所有设备",
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "escalate_after_min": {
+                    "description": "告警触发后超过该分钟数仍未确认时通知 escalation_channels，0 表示不升级",
+                    "type": "integer"
+                },
+                "escalation_channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AlertChannel"
+                    }
+                },
+                "event_type": {
+                    "$ref": "#/definitions/model.DeviceEventType"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "metric": {
+                    "description": "event_data 中以 . 分隔的字段路径，为空时只能统计事件数",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operator": {
+                    "$ref": "#/definitions/model.RuleOperator"
+                },
+                "severity": {
+                    "$ref": "#/definitions/model.AlertSeverity"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "window_sec": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.DeviceTelemetryRollup": {
+            "type": "object",
+            "properties": {
+                "avg_value": {
+                    "type": "number"
+                },
+                "bucket_start": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "max_value": {
+                    "type": "number"
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "min_value": {
+                    "type": "number"
+                },
+                "resolution": {
+                    "$ref": "#/definitions/model.TelemetryResolution"
+                },
+                "sum_value": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeviceUtilization": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "integer"
+                },
+                "available_ms": {
+                    "type": "integer"
+                },
+                "busy_ms": {
+                    "type": "integer"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "device_uuid": {
+                    "type": "string"
+                },
+                "idle_ms": {
+                    "type": "integer"
+                },
+                "liveness_tracked": {
+                    "description": "为 false 时没有心跳记录，在线时间按整个周期计算",
+                    "type": "boolean"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "utilization": {
+                    "description": "忙碌时间占在线时间的百分比，最高 100",
+                    "type": "number"
+                },
+                "utilization_change": {
+                    "description": "与上一周期相比变化的百分点，上一周期没有在线时间时为空",
+                    "type": "number"
+                }
+            }
+        },
+        "model.Diagnosis": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "confidence": {
+                    "description": "high | medium | low",
+                    "type": "string"
+                },
+                "execution_uuid": {
+                    "type": "string"
+                },
+                "failed_step": {
+                    "type": "string"
+                },
+                "remediation": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "root_cause": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DurationRange": {
+            "type": "object",
+            "properties": {
+                "expected_ms": {
+                    "type": "integer"
+                },
+                "high_ms": {
+                    "type": "integer"
+                },
+                "low_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.EdgeIngestStream": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "high_water_mark": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "last_ingested_at": {
+                    "type": "string"
+                },
+                "max_seq": {
+                    "description": "收到的最大序号",
+                    "type": "integer"
+                },
+                "stream": {
+                    "description": "edge 选择的流标识，如 agent 实例 ID",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.EnvironmentStatus": {
+            "type": "string",
+            "enum": [
+                "INIT",
+                "DELETED"
+            ],
+            "x-enum-varnames": [
+                "INIT",
+                "DELETED"
+            ]
+        },
+        "model.ErrorRule": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "pattern": {
+                    "description": "正则表达式，不区分大小写",
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "越小越先匹配",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ExecutionAnnotation": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "content": {
+                    "description": "删除后清空",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "删除后保留在讨论串中，内容不再返回",
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "edited_at": {
+                    "type": "string"
+                },
+                "execution_id": {
+                    "type": "integer"
+                },
+                "execution_uuid": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "parent_uuid": {
+                    "type": "string"
+                },
+                "replies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ExecutionAnnotation"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ExecutionAnnotationRevision": {
+            "type": "object",
+            "properties": {
+                "annotation_id": {
+                    "type": "integer"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "edited_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ExecutionEstimate": {
+            "type": "object",
+            "properties": {
+                "ahead": {
+                    "description": "排在前面且使用相同设备的执行数",
+                    "type": "integer"
+                },
+                "completion_earliest": {
+                    "description": "最早完成时间",
+                    "type": "string"
+                },
+                "completion_expected": {
+                    "description": "预计完成时间",
+                    "type": "string"
+                },
+                "completion_latest": {
+                    "description": "最晚完成时间",
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "整个执行的时长",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.DurationRange"
+                        }
+                    ]
+                },
+                "execution_uuid": {
+                    "type": "string"
+                },
+                "overdue": {
+                    "description": "已超过几乎所有历史执行的时长",
+                    "type": "boolean"
+                },
+                "remaining": {
+                    "description": "从现在起的剩余时长，包括排队等待",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.DurationRange"
+                        }
+                    ]
+                },
+                "samples": {
+                    "description": "工作流分布的样本数",
+                    "type": "integer"
+                },
+                "source": {
+                    "description": "workflow | steps | none",
+                    "type": "string"
+                },
+                "start_expected": {
+                    "description": "排队中的执行预计开始时间",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "unknown_steps": {
+                    "description": "按步骤估算时既没有历史也没有预算的步骤数",
+                    "type": "integer"
+                },
+                "workflow_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ExecutionStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "success",
+                "failed",
+                "cancelled",
+                "timeout"
+            ],
+            "x-enum-varnames": [
+                "ExecutionStatusPending",
+                "ExecutionStatusRunning",
+                "ExecutionStatusSuccess",
+                "ExecutionStatusFailed",
+                "ExecutionStatusCancelled",
+                "ExecutionStatusTimeout"
+            ]
+        },
+        "model.ExperimentProgress": {
+            "type": "object",
+            "properties": {
+                "cancelled": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "失败、超时",
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "percent": {
+                    "description": "已结束执行占计划次数（未设置时占全部执行）的百分比",
+                    "type": "number"
+                },
+                "planned_runs": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ExperimentStats": {
+            "type": "object",
+            "properties": {
+                "cancelled": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "失败、超时",
+                    "type": "integer"
+                },
+                "first_started_at": {
+                    "type": "string"
+                },
+                "last_completed_at": {
+                    "type": "string"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "percent": {
+                    "description": "已结束执行占计划次数（未设置时占全部执行）的百分比",
+                    "type": "number"
+                },
+                "planned_runs": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "integer"
+                },
+                "success_rate": {
+                    "description": "成功执行占已结束执行的比例",
+                    "type": "number"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_duration_ms": {
+                    "type": "integer"
+                },
+                "workflows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ExperimentWorkflowStats"
+                    }
+                }
+            }
+        },
+        "model.ExperimentStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "completed",
+                "archived"
+            ],
+            "x-enum-comments": {
+                "ExperimentActive": "进行中，可以关联执行",
+                "ExperimentArchived": "已归档，不再接受新的执行",
+                "ExperimentCompleted": "已完成，仍可以补充关联执行"
+            },
+            "x-enum-descriptions": [
+                "进行中，可以关联执行",
+                "已完成，仍可以补充关联执行",
+                "已归档，不再接受新的执行"
+            ],
+            "x-enum-varnames": [
+                "ExperimentActive",
+                "ExperimentCompleted",
+                "ExperimentArchived"
+            ]
+        },
+        "model.ExperimentWorkflowStats": {
+            "type": "object",
+            "properties": {
+                "avg_duration_ms": {
+                    "description": "已结束执行的平均耗时",
+                    "type": "number"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "workflow_name": {
+                    "type": "string"
+                },
+                "workflow_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.FailureCategoryCount": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "executions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.FailureStats": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "integer"
+                },
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FailureCategoryCount"
+                    }
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "executions": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.GroupCommandSkip": {
+            "type": "object",
+            "properties": {
+                "device_name": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.GroupCommandStatus": {
+            "type": "string",
+            "enum": [
+                "running",
+                "succeeded",
+                "partial",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "GroupCommandFailed": "全部失败或超时",
+                "GroupCommandPartial": "部分失败或超时",
+                "GroupCommandRunning": "还有指令未结束",
+                "GroupCommandSucceeded": "全部成功"
+            },
+            "x-enum-descriptions": [
+                "还有指令未结束",
+                "全部成功",
+                "部分失败或超时",
+                "全部失败或超时"
+            ],
+            "x-enum-varnames": [
+                "GroupCommandRunning",
+                "GroupCommandSucceeded",
+                "GroupCommandPartial",
+                "GroupCommandFailed"
+            ]
+        },
+        "model.GroupCommandSummary": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "description": "排队、已下发或已确认",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.GroupCommandStatus"
+                },
+                "succeeded": {
+                    "type": "integer"
+                },
+                "timeout": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.HeatmapCell": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "hour": {
+                    "type": "integer"
+                },
+                "weekday": {
+                    "description": "0 为周日，与 time.Weekday 一致",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.HeldTaskStatus": {
+            "type": "string",
+            "enum": [
+                "held",
+                "released",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "HeldTaskStatusHeld",
+                "HeldTaskStatusReleased",
+                "HeldTaskStatusCancelled"
+            ]
+        },
+        "model.HistoryChainAnchor": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "receipt": {
+                    "description": "外部服务的响应",
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "target": {
+                    "description": "发布地址，为空时只记录在本地",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.HistoryChangeKind": {
+            "type": "string",
+            "enum": [
+                "workflow_execution",
+                "action_execution",
+                "device_event"
+            ],
+            "x-enum-varnames": [
+                "HistoryChangeWorkflow",
+                "HistoryChangeAction",
+                "HistoryChangeEvent"
+            ]
+        },
+        "model.HistoryExport": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "执行次数，大于 1 表示从检查点恢复过",
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "完成后导出文件的保留截止时间",
+                    "type": "string"
+                },
+                "filters": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "idempotency_key": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/model.HistoryExportKind"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "parts": {
+                    "description": "已写入存储的分片数",
+                    "type": "integer"
+                },
+                "progress": {
+                    "description": "完成百分比",
+                    "type": "integer"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.HistoryExportStatus"
+                },
+                "total_rows": {
+                    "description": "开始导出时统计的记录数",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.HistoryExportFilters": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "labels": {
+                    "description": "key:value 形式的执行标签过滤",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "workflow_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.HistoryExportKind": {
+            "type": "string",
+            "enum": [
+                "workflow",
+                "action",
+                "device_event"
+            ],
+            "x-enum-comments": {
+                "HistoryExportAction": "动作执行历史",
+                "HistoryExportDeviceEvent": "设备事件历史",
+                "HistoryExportWorkflow": "工作流执行历史"
+            },
+            "x-enum-descriptions": [
+                "工作流执行历史",
+                "动作执行历史",
+                "设备事件历史"
+            ],
+            "x-enum-varnames": [
+                "HistoryExportWorkflow",
+                "HistoryExportAction",
+                "HistoryExportDeviceEvent"
+            ]
+        },
+        "model.HistoryExportStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed",
+                "expired"
+            ],
+            "x-enum-comments": {
+                "HistoryExportExpired": "导出文件已过期并从存储中删除",
+                "HistoryExportPending": "等待执行或从检查点继续"
+            },
+            "x-enum-descriptions": [
+                "等待执行或从检查点继续",
+                "",
+                "",
+                "",
+                "导出文件已过期并从存储中删除"
+            ],
+            "x-enum-varnames": [
+                "HistoryExportPending",
+                "HistoryExportRunning",
+                "HistoryExportCompleted",
+                "HistoryExportFailed",
+                "HistoryExportExpired"
+            ]
+        },
+        "model.HistoryImport": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dry_run": {
+                    "description": "只校验并生成报告，不写入",
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "format": {
+                    "$ref": "#/definitions/model.HistoryImportFormat"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "issues": {
+                    "description": "最多保留 MaxImportIssues 条",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "policy": {
+                    "$ref": "#/definitions/model.ImportConflictPolicy"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.HistoryImportStatus"
+                },
+                "table": {
+                    "description": "jsonl、parquet 文件对应的历史表",
+                    "type": "string"
+                },
+                "updated": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.HistoryImportFormat": {
+            "type": "string",
+            "enum": [
+                "zip",
+                "jsonl",
+                "parquet"
+            ],
+            "x-enum-comments": {
+                "HistoryImportJSONL": "单张历史表，每行一条 JSON 记录",
+                "HistoryImportParquet": "单张历史表",
+                "HistoryImportZip": "实验室导出归档，包含多张历史表"
+            },
+            "x-enum-descriptions": [
+                "实验室导出归档，包含多张历史表",
+                "单张历史表，每行一条 JSON 记录",
+                "单张历史表"
+            ],
+            "x-enum-varnames": [
+                "HistoryImportZip",
+                "HistoryImportJSONL",
+                "HistoryImportParquet"
+            ]
+        },
+        "model.HistoryImportStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "HistoryImportPending",
+                "HistoryImportRunning",
+                "HistoryImportCompleted",
+                "HistoryImportFailed"
+            ]
+        },
+        "model.HistoryInterpretation": {
+            "type": "object",
+            "properties": {
+                "device_name": {
+                    "description": "filters.device_id 对应的设备",
+                    "type": "string"
+                },
+                "filters": {
+                    "$ref": "#/definitions/model.SavedViewFilters"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "question": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "llm | rules",
+                    "type": "string"
+                },
+                "target": {
+                    "$ref": "#/definitions/model.SavedViewTarget"
+                },
+                "warnings": {
+                    "description": "无法识别或被忽略的部分",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "workflow_name": {
+                    "description": "filters.workflow_id 对应的工作流",
+                    "type": "string"
+                }
+            }
+        },
+        "model.HistoryStats": {
+            "type": "object",
+            "properties": {
+                "average_duration_ms": {
+                    "type": "number"
+                },
+                "failed_count": {
+                    "type": "integer"
+                },
+                "material_usages": {
+                    "description": "物料消耗记录数",
+                    "type": "integer"
+                },
+                "materials_consumed": {
+                    "description": "被消耗的物料批次数",
+                    "type": "integer"
+                },
+                "sla_breached_count": {
+                    "type": "integer"
+                },
+                "sla_compliance": {
+                    "description": "满足 SLA 的百分比，没有 SLA 执行时为 0",
+                    "type": "number"
+                },
+                "sla_tracked": {
+                    "description": "设置了 SLA 且已结束的执行数",
+                    "type": "integer"
+                },
+                "success_rate": {
+                    "type": "number"
+                },
+                "successful_count": {
+                    "type": "integer"
+                },
+                "total_actions_count": {
+                    "type": "integer"
+                },
+                "total_device_events": {
+                    "type": "integer"
+                },
+                "total_executions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ImpersonationSession": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "impersonator_id": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "description": "模拟会话只能访问该实验室",
+                    "type": "integer"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "被模拟的用户",
+                    "type": "string"
+                },
+                "user_name": {
+                    "type": "string"
+                },
+                "user_owner": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ImportConflictPolicy": {
+            "type": "string",
+            "enum": [
+                "skip",
+                "overwrite",
+                "fail"
+            ],
+            "x-enum-comments": {
+                "ImportConflictFail": "存在冲突时整个导入失败并回滚",
+                "ImportConflictOverwrite": "用导入的记录覆盖已有记录",
+                "ImportConflictSkip": "保留已有记录"
+            },
+            "x-enum-descriptions": [
+                "保留已有记录",
+                "用导入的记录覆盖已有记录",
+                "存在冲突时整个导入失败并回滚"
+            ],
+            "x-enum-varnames": [
+                "ImportConflictSkip",
+                "ImportConflictOverwrite",
+                "ImportConflictFail"
+            ]
+        },
+        "model.IngestAck": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.IngestAckStatus"
+                }
+            }
+        },
+        "model.IngestAckStatus": {
+            "type": "string",
+            "enum": [
+                "accepted",
+                "duplicate",
+                "rejected"
+            ],
+            "x-enum-comments": {
+                "IngestDuplicate": "序号或 UUID 已经写入，edge 可以丢弃",
+                "IngestRejected": "记录无效，重放也不会成功"
+            },
+            "x-enum-descriptions": [
+                "",
+                "序号或 UUID 已经写入，edge 可以丢弃",
+                "记录无效，重放也不会成功"
+            ],
+            "x-enum-varnames": [
+                "IngestAccepted",
+                "IngestDuplicate",
+                "IngestRejected"
+            ]
+        },
+        "model.JobRunStatus": {
+            "type": "string",
+            "enum": [
+                "running",
+                "success",
+                "failed",
+                "timeout"
+            ],
+            "x-enum-varnames": [
+                "JobRunStatusRunning",
+                "JobRunStatusSuccess",
+                "JobRunStatusFailed",
+                "JobRunStatusTimeout"
+            ]
+        },
+        "model.JobScheduleType": {
+            "type": "string",
+            "enum": [
+                "cron",
+                "interval",
+                "once"
+            ],
+            "x-enum-comments": {
+                "JobScheduleCron": "5 段 cron 表达式",
+                "JobScheduleInterval": "Go duration，例如 10m",
+                "JobScheduleOnce": "RFC3339 时间，只执行一次"
+            },
+            "x-enum-descriptions": [
+                "5 段 cron 表达式",
+                "Go duration，例如 10m",
+                "RFC3339 时间，只执行一次"
+            ],
+            "x-enum-varnames": [
+                "JobScheduleCron",
+                "JobScheduleInterval",
+                "JobScheduleOnce"
+            ]
+        },
+        "model.JobTrigger": {
+            "type": "string",
+            "enum": [
+                "schedule",
+                "manual"
+            ],
+            "x-enum-varnames": [
+                "JobTriggerSchedule",
+                "JobTriggerManual"
+            ]
+        },
+        "model.LabExport": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "完成后归档的保留截止时间",
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "progress": {
+                    "description": "完成百分比",
+                    "type": "integer"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.LabExportStatus"
+                },
+                "tables": {
+                    "description": "已导出的数据表数量",
+                    "type": "integer"
+                },
+                "total_tables": {
+                    "description": "需要导出的数据表数量",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.LabExportStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed",
+                "expired"
             ],
             "x-enum-comments": {
-                "BohrBatchQueryErr": "bhor batch query user error",
-                "CallbackJobStatusKeyNotExistErr": "callback job status key note exists error",
-                "CallbackJobStatusTimeoutErr": "callback job status timeout error",
-                "CallbackParamErr": "callback parameter error",
-                "CanNotFoundEdgeSession": "can not found edge session",
-                "CanNotFoundMaterialNodeErr": "can not found material node error",
-                "CanNotFoundTargetNode": "can not found target node error",
-                "CanNotFoundWorkflowHandleErr": "can not found workflow handle error",
-                "CanNotGetLabIDErr": "cannot get lab id error",
-                "CanNotGetParentJobErr": "can not found parent node job error",
-                "CanNotGetWorkflowTaskErr": "can not get workflow task error",
-                "CanNotGetWorkflowUUIDErr": "can not get workflow uuid",
-                "CanNotGetworkflowErr": "workflow not found error",
-                "CasDoorCreateLabUserErr": "create lab user error",
-                "CasDoorQueryLabUserErr": "query lab user error",
-                "CreateDataErr": "database create data error",
-                "DataNotMapAnyTypeErr": "data not map any type error",
-                "DeleteDataErr": "database delete error",
-                "EdgeConnectClosedErr": "connect closed when node running error",
-                "EdgeHandleNotExistErr": "node handle not exist",
-                "EdgeNodeNotExistErr": "edge node not exist",
-                "ExchangeTokenErr": "exchange token failed",
-                "ExecWorkflowNodeScriptErr": "exec workflow script error",
-                "FormatCSVTaskErr": "format csv data error",
-                "InvalidDagErr": "invalid dag error",
-                "InvalidToken": "invalid token",
-                "InvalidateJWT": "invalidate jwt",
-                "InvalidateThirdID": "invalidate third id error",
-                "InviteExpiredErr": "invite expired error",
-                "JobCanceled": "job is canceled",
-                "JobRetryTimeout": "job retry timeout error",
-                "JobRunFailErr": "job run fail error",
-                "JobTimeoutErr": "job timeout error",
-                "LabAlreadyDeletedErr": "lab already deleted error",
-                "LabIDIsEmptyErr": "lab id is empty error",
-                "LabInviteNotFoundErr": "can not found laboratory invite link error",
-                "LabNotFound": "laboratory not found error",
-                "LoginCallbackErr": "login process user info failed",
-                "LoginConfigErr": "login configuration error",
-                "LoginFormatErr": "login verification format error",
-                "LoginGetUserInfoErr": "get user info failed",
-                "LoginSetStateErr": "set login state error",
-                "LoginStateErr": "state verification failed",
-                "MachineAlreadyExistErr": "machine already exist error",
-                "MachineNodeStoppingErr": "machine is stopping",
-                "MachineNotExistErr": "machine not exist error",
-                "MachineReachMaxNumCountErr": "machine reach max number error",
-                "MachineStartUnknownErr": "start machine unknown error",
-                "MarshalTargetDataErr": "marshal target data error",
-                "MaxTplNodeDeepErr": "max template node deep error",
-                "ModelNotImplementTablerErr": "model not implement schema.Tabler",
-                "NoPermission": "no permission",
-                "NodeDataMarshalErr": "marshal node data error",
-                "NotBaseDBTypeErr": "not base db type error",
-                "NotPointerErr": "not pointer err",
-                "NotSlicePointerErr": "must be a pointer to a slice",
-                "NotifyActionAlreadyRegistryErr": "notify action already registry",
-                "NotifySendMsgErr": "notify send message error",
-                "NotifySubscribeChannelErr": "notify subscribe channel fail",
-                "ParamDataKeyInvalidateErr": "param data key invalidate error",
-                "ParamDataValueInvalidateErr": "param data value invalidate error",
-                "ParamErr": "parse parameter error",
-                "ParentNodeNotFoundErr": "parent node not found error",
-                "ParseLoginRedirectURLErr": "redirect login url error",
-                "PathHasEmptyName": "path has empty name error",
-                "PermissionDenied": "permission denied",
-                "PointerIsNilErr": "pointer is nil error",
-                "QueryJobStatusKeyNotExistErr": "query job status key note exists error",
-                "QueryMachineStatusFailErr": "query machine status error",
-                "QueryRecordErr": "database query error",
-                "RPCHttpCodeErr": "rpc request http code error",
-                "RPCHttpCodeRespErr": "rpc request http code resp error",
-                "RPCHttpErr": "rpc request http error",
-                "RecordNotFound": "database record not found",
-                "RedisAddSetErr": "redis add user set error",
-                "RedisLuaRetErr": "redis lua return type error",
-                "RedisLuaScriptErr": "redis lua script error",
-                "RedisRemoveSetErr": "redis remove user set error",
-                "RefreshTokenErr": "refresh token failed",
-                "RefreshTokenParamErr": "refresh token parameter error",
-                "RegActionNameEmptyErr": "reg action name empty",
-                "ResNotExistErr": "resource not exist",
-                "ResourceIsEmptyErr": "resource is empty",
-                "ResourceNotExistErr": "resource not exist",
-                "SaveWorkflowEdgeErr": "batch save workflow edge error",
-                "SaveWorkflowNodeErr": "batch save nodes error",
-                "Success": "success",
-                "TargetDataNotMapAnyTypeErr": "target data not map any type error",
-                "TargetParamInvalidateErr": "target param invalidate error",
-                "TemplateNodeNotFoundErr": "template node not found error",
-                "UnDefineErr": "undefined",
-                "UnLogin": "not logged in",
-                "UnknownWSActionErr": "unknown material websocket action",
-                "UnknownWorkflowNodeTypeErr": "unknown workflow node type error",
-                "UnmarshalWSDataErr": "unmarshal material websocket data error",
-                "UpdateDataErr": "database update data error",
-                "UpdateNodeErr": "update material node error",
-                "UpsertWorkflowEdgeErr": "upsert workflow edge error",
-                "UserIDIsEmptyErr": "user id is empty",
-                "ValueNotExistErr": "value not exist error",
-                "ValueSliceOutIndexErr": "value slice out index error",
-                "WorkflowHasCircularErr": "workflow has circular error",
-                "WorkflowNodeNoActionName": "workflow node no action name error",
-                "WorkflowNodeNoActionType": "workflow node no action type error",
-                "WorkflowNodeNoDeviceName": "workflow node no device name error",
-                "WorkflowNodeNotFoundErr": "workflow node not found error",
-                "WorkflowNodeScriptEmtpyErr": "workflow script empty error",
-                "WorkflowNotExistErr": "workflow not exist",
-                "WorkflowTaskAlreadyExistErr": "workflow task already exist error",
-                "WorkflowTaskFinished": "workflow task finished",
-                "WorkflowTaskNotFoundErr": "can not found workflow task error",
-                "WorkflowTaskStatusErr": "workflow task status error",
-                "WorkflowTaskStatusNotPendingErr": "workflow task not in pending status",
-                "WorkflowTemplateNotFoundErr": "can not found workflow template error"
+                "LabExportExpired": "归档已过期并从存储中删除"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "",
+                "",
+                "归档已过期并从存储中删除"
+            ],
+            "x-enum-varnames": [
+                "LabExportPending",
+                "LabExportRunning",
+                "LabExportCompleted",
+                "LabExportFailed",
+                "LabExportExpired"
+            ]
+        },
+        "model.LabStats": {
+            "type": "object",
+            "properties": {
+                "average_duration_ms": {
+                    "type": "number"
+                },
+                "failed_count": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "lab_name": {
+                    "type": "string"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "success_rate": {
+                    "type": "number"
+                },
+                "successful_count": {
+                    "type": "integer"
+                },
+                "total_actions_count": {
+                    "type": "integer"
+                },
+                "total_device_events": {
+                    "type": "integer"
+                },
+                "total_executions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.LabelCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.LaboratoryMemberRole": {
+            "type": "string",
+            "enum": [
+                "admin",
+                "normal"
+            ],
+            "x-enum-varnames": [
+                "LaboratoryMemberAdmin",
+                "LaboratoryMemberNormal"
+            ]
+        },
+        "model.LegalHoldScope": {
+            "type": "string",
+            "enum": [
+                "execution",
+                "workflow",
+                "lab",
+                "time_range"
+            ],
+            "x-enum-comments": {
+                "LegalHoldExecution": "单次工作流执行及其动作记录",
+                "LegalHoldLab": "实验室的所有历史记录",
+                "LegalHoldTimeRange": "时间段内的历史记录，可限定实验室",
+                "LegalHoldWorkflow": "工作流的所有执行"
+            },
+            "x-enum-descriptions": [
+                "单次工作流执行及其动作记录",
+                "工作流的所有执行",
+                "实验室的所有历史记录",
+                "时间段内的历史记录，可限定实验室"
+            ],
+            "x-enum-varnames": [
+                "LegalHoldExecution",
+                "LegalHoldWorkflow",
+                "LegalHoldLab",
+                "LegalHoldTimeRange"
+            ]
+        },
+        "model.LivenessStatus": {
+            "type": "string",
+            "enum": [
+                "online",
+                "offline"
+            ],
+            "x-enum-varnames": [
+                "LivenessOnline",
+                "LivenessOffline"
+            ]
+        },
+        "model.LivenessSummary": {
+            "type": "object",
+            "properties": {
+                "offline": {
+                    "type": "integer"
+                },
+                "online": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.MaintenanceWindow": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "eta": {
+                    "description": "预计结束时间，为空时未知",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "started_by": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Material": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "description": "LIMS 中的 id",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/model.MaterialKind"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "lot_number": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "description": "登记时的数量",
+                    "type": "number"
+                },
+                "remaining": {
+                    "description": "扣减消耗后的剩余数量",
+                    "type": "number"
+                },
+                "source": {
+                    "$ref": "#/definitions/model.MaterialSource"
+                },
+                "synced_at": {
+                    "description": "最近一次与 LIMS 同步的时间",
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MaterialKind": {
+            "type": "string",
+            "enum": [
+                "sample",
+                "reagent",
+                "consumable"
+            ],
+            "x-enum-varnames": [
+                "MaterialKindSample",
+                "MaterialKindReagent",
+                "MaterialKindConsumable"
+            ]
+        },
+        "model.MaterialSource": {
+            "type": "string",
+            "enum": [
+                "manual",
+                "lims"
+            ],
+            "x-enum-varnames": [
+                "MaterialSourceManual",
+                "MaterialSourceLIMS"
+            ]
+        },
+        "model.MaterialSyncConflict": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "local_value": {
+                    "type": "string"
+                },
+                "lot_number": {
+                    "type": "string"
+                },
+                "material_id": {
+                    "type": "integer"
+                },
+                "remote_value": {
+                    "type": "string"
+                },
+                "resolution": {
+                    "$ref": "#/definitions/model.MaterialSyncResolution"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "run_id": {
+                    "description": "最近一次发现该冲突的同步",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MaterialSyncResolution": {
+            "type": "string",
+            "enum": [
+                "kept_local",
+                "skipped"
+            ],
+            "x-enum-comments": {
+                "MaterialSyncKeptLocal": "保留本地值",
+                "MaterialSyncSkipped": "LIMS 数据未同步"
             },
             "x-enum-descriptions": [
-                "success",
-                "undefined",
-                "no permission",
-                "invalidate jwt",
-                "parse parameter error",
-                "not pointer err",
-                "must be a pointer to a slice",
-                "pointer is nil error",
-                "login configuration error",
-                "set login state error",
-                "refresh token failed",
-                "state verification failed",
-                "exchange token failed",
-                "callback parameter error",
-                "get user info failed",
-                "login process user info failed",
-                "not logged in",
-                "login verification format error",
-                "invalid token",
-                "refresh token parameter error",
-                "redirect login url error",
-                "database create data error",
-                "database update data error",
-                "database record not found",
-                "database query error",
-                "database delete error",
-                "not base db type error",
-                "model not implement schema.Tabler",
-                "redis lua script error",
-                "redis lua return type error",
-                "redis add user set error",
-                "redis remove user set error",
-                "reg action name empty",
-                "resource is empty",
-                "resource not exist",
-                "can not found workflow template error",
-                "user id is empty",
-                "lab id is empty error",
-                "laboratory not found error",
-                "can not found laboratory invite link error",
-                "invite expired error",
-                "invalidate third id error",
-                "lab already deleted error",
-                "resource not exist",
-                "edge node not exist",
-                "node handle not exist",
-                "unknown material websocket action",
-                "unmarshal material websocket data error",
-                "cannot get lab id error",
-                "update material node error",
-                "parent node not found error",
-                "template node not found error",
-                "invalid dag error",
-                "max template node deep error",
-                "can not found material node error",
-                "machine already exist error",
-                "query machine status error",
-                "machine not exist error",
-                "machine reach max number error",
-                "machine is stopping",
-                "start machine unknown error",
-                "can not found target node error",
-                "path has empty name error",
-                "notify action already registry",
-                "notify subscribe channel fail",
-                "notify send message error",
-                "rpc request http error",
-                "rpc request http code error",
-                "rpc request http code resp error",
-                "create lab user error",
-                "query lab user error",
-                "bhor batch query user error",
-                "can not get workflow uuid",
-                "workflow not exist",
-                "upsert workflow edge error",
-                "permission denied",
-                "batch save nodes error",
-                "batch save workflow edge error",
-                "workflow node not found error",
-                "workflow not found error",
-                "format csv data error",
-                "workflow task already exist error",
-                "can not found edge session",
-                "workflow has circular error",
-                "connect closed when node running error",
-                "marshal node data error",
-                "job run fail error",
-                "can not found workflow task error",
-                "workflow task status error",
-                "workflow task finished",
-                "workflow node no device name error",
-                "workflow node no action name error",
-                "workflow node no action type error",
-                "query job status key note exists error",
-                "callback job status key note exists error",
-                "job timeout error",
-                "job retry timeout error",
-                "callback job status timeout error",
-                "job is canceled",
-                "can not get workflow task error",
-                "workflow task not in pending status",
-                "can not found workflow handle error",
-                "can not found parent node job error",
-                "param data key invalidate error",
-                "param data value invalidate error",
-                "data not map any type error",
-                "value slice out index error",
-                "value not exist error",
-                "target data not map any type error",
-                "marshal target data error",
-                "target param invalidate error",
-                "workflow script empty error",
-                "unknown workflow node type error",
-                "exec workflow script error"
+                "保留本地值",
+                "LIMS 数据未同步"
+            ],
+            "x-enum-varnames": [
+                "MaterialSyncKeptLocal",
+                "MaterialSyncSkipped"
+            ]
+        },
+        "model.MaterialSyncRun": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
+                    "type": "integer"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fetched": {
+                    "description": "LIMS 返回的批次数",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.MaterialSyncStatus"
+                },
+                "trigger": {
+                    "$ref": "#/definitions/model.JobTrigger"
+                },
+                "updated": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MaterialSyncStatus": {
+            "type": "string",
+            "enum": [
+                "running",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "MaterialSyncRunning",
+                "MaterialSyncSucceeded",
+                "MaterialSyncFailed"
+            ]
+        },
+        "model.MaterialUsage": {
+            "type": "object",
+            "properties": {
+                "action_execution_id": {
+                    "type": "integer"
+                },
+                "action_execution_uuid": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "material_id": {
+                    "type": "integer"
+                },
+                "material_uuid": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "recorded_by": {
+                    "description": "edge 上报时为空",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "workflow_execution_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.MaterialUsageSummary": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "$ref": "#/definitions/model.MaterialKind"
+                },
+                "lot_number": {
+                    "type": "string"
+                },
+                "material_uuid": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Notification": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/model.NotificationKind"
+                },
+                "lab_uuid": {
+                    "type": "string"
+                },
+                "read_at": {
+                    "type": "string"
+                },
+                "ref_uuid": {
+                    "description": "关联的告警、审批或任务",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.NotificationKind": {
+            "type": "string",
+            "enum": [
+                "alert",
+                "approval",
+                "execution"
+            ],
+            "x-enum-comments": {
+                "NotificationAlert": "设备告警触发、升级、恢复",
+                "NotificationApproval": "等待审批、审批结果",
+                "NotificationExecution": "工作流执行结束"
+            },
+            "x-enum-descriptions": [
+                "设备告警触发、升级、恢复",
+                "等待审批、审批结果",
+                "工作流执行结束"
             ],
             "x-enum-varnames": [
-                "Success",
-                "UnDefineErr",
-                "NoPermission",
-                "InvalidateJWT",
-                "ParamErr",
-                "NotPointerErr",
-                "NotSlicePointerErr",
-                "PointerIsNilErr",
-                "LoginConfigErr",
-                "LoginSetStateErr",
-                "RefreshTokenErr",
-                "LoginStateErr",
-                "ExchangeTokenErr",
-                "CallbackParamErr",
-                "LoginGetUserInfoErr",
-                "LoginCallbackErr",
-                "UnLogin",
-                "LoginFormatErr",
-                "InvalidToken",
-                "RefreshTokenParamErr",
-                "ParseLoginRedirectURLErr",
-                "CreateDataErr",
-                "UpdateDataErr",
-                "RecordNotFound",
-                "QueryRecordErr",
-                "DeleteDataErr",
-                "NotBaseDBTypeErr",
-                "ModelNotImplementTablerErr",
-                "RedisLuaScriptErr",
-                "RedisLuaRetErr",
-                "RedisAddSetErr",
-                "RedisRemoveSetErr",
-                "RegActionNameEmptyErr",
-                "ResourceIsEmptyErr",
-                "ResourceNotExistErr",
-                "WorkflowTemplateNotFoundErr",
-                "UserIDIsEmptyErr",
-                "LabIDIsEmptyErr",
-                "LabNotFound",
-                "LabInviteNotFoundErr",
-                "InviteExpiredErr",
-                "InvalidateThirdID",
-                "LabAlreadyDeletedErr",
-                "ResNotExistErr",
-                "EdgeNodeNotExistErr",
-                "EdgeHandleNotExistErr",
-                "UnknownWSActionErr",
-                "UnmarshalWSDataErr",
-                "CanNotGetLabIDErr",
-                "UpdateNodeErr",
-                "ParentNodeNotFoundErr",
-                "TemplateNodeNotFoundErr",
-                "InvalidDagErr",
-                "MaxTplNodeDeepErr",
-                "CanNotFoundMaterialNodeErr",
-                "MachineAlreadyExistErr",
-                "QueryMachineStatusFailErr",
-                "MachineNotExistErr",
-                "MachineReachMaxNumCountErr",
-                "MachineNodeStoppingErr",
-                "MachineStartUnknownErr",
-                "CanNotFoundTargetNode",
-                "PathHasEmptyName",
-                "NotifyActionAlreadyRegistryErr",
-                "NotifySubscribeChannelErr",
-                "NotifySendMsgErr",
-                "RPCHttpErr",
-                "RPCHttpCodeErr",
-                "RPCHttpCodeRespErr",
-                "CasDoorCreateLabUserErr",
-                "CasDoorQueryLabUserErr",
-                "BohrBatchQueryErr",
-                "CanNotGetWorkflowUUIDErr",
-                "WorkflowNotExistErr",
-                "UpsertWorkflowEdgeErr",
-                "PermissionDenied",
-                "SaveWorkflowNodeErr",
-                "SaveWorkflowEdgeErr",
-                "WorkflowNodeNotFoundErr",
-                "CanNotGetworkflowErr",
-                "FormatCSVTaskErr",
-                "WorkflowTaskAlreadyExistErr",
-                "CanNotFoundEdgeSession",
-                "WorkflowHasCircularErr",
-                "EdgeConnectClosedErr",
-                "NodeDataMarshalErr",
-                "JobRunFailErr",
-                "WorkflowTaskNotFoundErr",
-                "WorkflowTaskStatusErr",
-                "WorkflowTaskFinished",
-                "WorkflowNodeNoDeviceName",
-                "WorkflowNodeNoActionName",
-                "WorkflowNodeNoActionType",
-                "QueryJobStatusKeyNotExistErr",
-                "CallbackJobStatusKeyNotExistErr",
-                "JobTimeoutErr",
-                "JobRetryTimeout",
-                "CallbackJobStatusTimeoutErr",
-                "JobCanceled",
-                "CanNotGetWorkflowTaskErr",
-                "WorkflowTaskStatusNotPendingErr",
-                "CanNotFoundWorkflowHandleErr",
-                "CanNotGetParentJobErr",
-                "ParamDataKeyInvalidateErr",
-                "ParamDataValueInvalidateErr",
-                "DataNotMapAnyTypeErr",
-                "ValueSliceOutIndexErr",
-                "ValueNotExistErr",
-                "TargetDataNotMapAnyTypeErr",
-                "MarshalTargetDataErr",
-                "TargetParamInvalidateErr",
-                "WorkflowNodeScriptEmtpyErr",
-                "UnknownWorkflowNodeTypeErr",
-                "ExecWorkflowNodeScriptErr"
+                "NotificationAlert",
+                "NotificationApproval",
+                "NotificationExecution"
+            ]
+        },
+        "model.OrgStats": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "$ref": "#/definitions/model.HistoryStats"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "lab_count": {
+                    "type": "integer"
+                },
+                "labs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.LabStats"
+                    }
+                },
+                "org_id": {
+                    "type": "string"
+                },
+                "previous": {
+                    "description": "上一个等长周期",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.HistoryStats"
+                        }
+                    ]
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "top_devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DeviceRank"
+                    }
+                },
+                "top_workflows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WorkflowRank"
+                    }
+                },
+                "trend": {
+                    "$ref": "#/definitions/model.StatsTrend"
+                }
+            }
+        },
+        "model.Pose": {
+            "type": "object",
+            "properties": {
+                "cross_section_type": {
+                    "type": "string"
+                },
+                "extra": {},
+                "layout": {
+                    "type": "string"
+                },
+                "position": {
+                    "$ref": "#/definitions/model.Position"
+                },
+                "position_3d": {
+                    "$ref": "#/definitions/model.Position"
+                },
+                "rotation": {
+                    "$ref": "#/definitions/model.Rotation"
+                },
+                "scale": {
+                    "$ref": "#/definitions/model.Scale"
+                },
+                "size": {
+                    "$ref": "#/definitions/model.Size"
+                }
+            }
+        },
+        "model.Position": {
+            "type": "object",
+            "properties": {
+                "x": {
+                    "type": "number"
+                },
+                "y": {
+                    "type": "number"
+                },
+                "z": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.QueueForecast": {
+            "type": "object",
+            "properties": {
+                "executions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ExecutionEstimate"
+                    }
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ReportChannel": {
+            "type": "string",
+            "enum": [
+                "email",
+                "webhook"
+            ],
+            "x-enum-comments": {
+                "ReportChannelEmail": "target 为收件人邮箱",
+                "ReportChannelWebhook": "target 为接收报表文件的 URL"
+            },
+            "x-enum-descriptions": [
+                "target 为收件人邮箱",
+                "target 为接收报表文件的 URL"
+            ],
+            "x-enum-varnames": [
+                "ReportChannelEmail",
+                "ReportChannelWebhook"
             ]
         },
-        "common.Error": {
+        "model.ReportDelivery": {
             "type": "object",
             "properties": {
-                "info": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "channel": {
+                    "$ref": "#/definitions/model.ReportChannel"
                 },
-                "msg": {
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "format": {
+                    "$ref": "#/definitions/model.ReportFormat"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "period_end": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ReportDeliveryStatus"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
                     "type": "string"
                 }
             }
         },
-        "common.Resp": {
+        "model.ReportDeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "success",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "ReportDeliverySuccess",
+                "ReportDeliveryFailed"
+            ]
+        },
+        "model.ReportFormat": {
+            "type": "string",
+            "enum": [
+                "csv",
+                "pdf"
+            ],
+            "x-enum-varnames": [
+                "ReportFormatCSV",
+                "ReportFormatPDF"
+            ]
+        },
+        "model.ReportSchedule": {
+            "type": "string",
+            "enum": [
+                "daily",
+                "weekly"
+            ],
+            "x-enum-varnames": [
+                "ReportScheduleDaily",
+                "ReportScheduleWeekly"
+            ]
+        },
+        "model.ReportSource": {
+            "type": "string",
+            "enum": [
+                "saved_view",
+                "lab_stats"
+            ],
+            "x-enum-comments": {
+                "ReportSourceLabStats": "实验室统计",
+                "ReportSourceSavedView": "保存的视图过滤出的历史记录"
+            },
+            "x-enum-descriptions": [
+                "保存的视图过滤出的历史记录",
+                "实验室统计"
+            ],
+            "x-enum-varnames": [
+                "ReportSourceSavedView",
+                "ReportSourceLabStats"
+            ]
+        },
+        "model.ReportSubscription": {
             "type": "object",
             "properties": {
-                "code": {
-                    "$ref": "#/definitions/code.ErrCode"
+                "channel": {
+                    "$ref": "#/definitions/model.ReportChannel"
                 },
-                "data": {},
-                "error": {
-                    "$ref": "#/definitions/common.Error"
+                "created_at": {
+                    "type": "string"
                 },
-                "timestamp": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "format": {
+                    "$ref": "#/definitions/model.ReportFormat"
+                },
+                "hour": {
+                    "description": "发送时刻，服务器本地时间",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "$ref": "#/definitions/model.ReportSchedule"
+                },
+                "source": {
+                    "$ref": "#/definitions/model.ReportSource"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "view_id": {
+                    "description": "source 为 saved_view 时使用",
+                    "type": "integer"
+                },
+                "view_uuid": {
+                    "type": "string"
+                },
+                "weekday": {
+                    "description": "每周报表的发送日，0 为周日",
                     "type": "integer"
                 }
             }
         },
-        "environment.DelLabReq": {
-            "type": "object",
-            "required": [
-                "uuid"
+        "model.ReservationStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "cancelled"
             ],
+            "x-enum-varnames": [
+                "ReservationStatusActive",
+                "ReservationStatusCancelled"
+            ]
+        },
+        "model.ResourceConfig": {
+            "type": "object",
             "properties": {
-                "uuid": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "class": {
+                    "type": "string"
+                },
+                "config": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                },
+                "position": {
+                    "$ref": "#/definitions/model.Position"
+                },
+                "sampleID": {},
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "environment.InviteResp": {
+        "model.ResourceNodeTemplate": {
             "type": "object",
             "properties": {
-                "url": {
+                "config_info": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ResourceConfig"
+                    }
+                },
+                "config_schema": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "data_schema": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "footer": {
+                    "type": "string"
+                },
+                "header": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "module": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentName": {
+                    "type": "string"
+                },
+                "parentNode": {
+                    "$ref": "#/definitions/model.ResourceNodeTemplate"
+                },
+                "pose": {
+                    "$ref": "#/definitions/datatypes.JSONType-model_Pose"
+                },
+                "resource_type": {
+                    "type": "string"
+                },
+                "status_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "tags": {
+                    "description": "label 标签",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "version": {
                     "type": "string"
                 }
             }
         },
-        "environment.LabInfoResp": {
+        "model.ResultFile": {
             "type": "object",
             "properties": {
-                "access_key": {
+                "action_execution_id": {
+                    "type": "integer"
+                },
+                "action_execution_uuid": {
+                    "type": "string"
+                },
+                "checksum": {
+                    "description": "sha256 hex",
                     "type": "string"
                 },
-                "access_secret": {
+                "chunks": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "description": "完成后为内容嗅探的结果",
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
-                "is_admin": {
-                    "type": "boolean"
+                "file_name": {
+                    "type": "string"
                 },
-                "name": {
+                "id": {
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "description": "已接收的字节数",
+                    "type": "integer"
+                },
+                "reject_reason": {
                     "type": "string"
                 },
+                "size": {
+                    "type": "integer"
+                },
                 "status": {
-                    "$ref": "#/definitions/model.EnvironmentStatus"
+                    "$ref": "#/definitions/model.ResultFileStatus"
                 },
                 "updated_at": {
                     "type": "string"
                 },
-                "user_id": {
+                "uploader_id": {
+                    "description": "edge 上传时为空",
                     "type": "string"
                 },
                 "uuid": {
@@ -2841,37 +22235,91 @@ const docTemplate = `{
                 }
             }
         },
-        "environment.LabMemberListResp": {
+        "model.ResultFileStatus": {
+            "type": "string",
+            "enum": [
+                "uploading",
+                "available",
+                "rejected"
+            ],
+            "x-enum-comments": {
+                "ResultFileRejected": "校验未通过，内容已删除"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "校验未通过，内容已删除"
+            ],
+            "x-enum-varnames": [
+                "ResultFileUploading",
+                "ResultFileAvailable",
+                "ResultFileRejected"
+            ]
+        },
+        "model.Rotation": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/environment.LabMemberResp"
-                    }
+                "x": {
+                    "type": "number"
                 },
-                "page": {
-                    "type": "integer"
+                "y": {
+                    "type": "number"
                 },
-                "page_size": {
+                "z": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.RuleEvaluation": {
+            "type": "object",
+            "properties": {
+                "device_id": {
                     "type": "integer"
                 },
-                "total": {
+                "device_uuid": {
+                    "type": "string"
+                },
+                "matched": {
+                    "type": "boolean"
+                },
+                "samples": {
                     "type": "integer"
+                },
+                "value": {
+                    "type": "number"
                 }
             }
         },
-        "environment.LabMemberResp": {
+        "model.RuleOperator": {
+            "type": "string",
+            "enum": [
+                "gt",
+                "gte",
+                "lt",
+                "lte",
+                "eq",
+                "neq"
+            ],
+            "x-enum-varnames": [
+                "RuleOpGT",
+                "RuleOpGTE",
+                "RuleOpLT",
+                "RuleOpLTE",
+                "RuleOpEQ",
+                "RuleOpNEQ"
+            ]
+        },
+        "model.SavedView": {
             "type": "object",
             "properties": {
-                "display_name": {
+                "created_at": {
                     "type": "string"
                 },
-                "email": {
-                    "type": "string"
+                "filters": {
+                    "type": "object"
                 },
-                "is_admin": {
-                    "type": "boolean"
+                "id": {
+                    "type": "integer"
                 },
                 "lab_id": {
                     "type": "integer"
@@ -2879,13 +22327,17 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
-                "phone": {
+                "owner_id": {
                     "type": "string"
                 },
-                "role": {
-                    "$ref": "#/definitions/model.LaboratoryMemberRole"
+                "shared": {
+                    "description": "共享给实验室所有成员",
+                    "type": "boolean"
                 },
-                "user_id": {
+                "target": {
+                    "$ref": "#/definitions/model.SavedViewTarget"
+                },
+                "updated_at": {
                     "type": "string"
                 },
                 "uuid": {
@@ -2893,199 +22345,264 @@ const docTemplate = `{
                 }
             }
         },
-        "environment.LaboratoryEnvReq": {
+        "model.SavedViewFilters": {
             "type": "object",
             "properties": {
-                "description": {
+                "device_id": {
+                    "type": "integer"
+                },
+                "end_time": {
                     "type": "string"
                 },
-                "name": {
+                "event_type": {
+                    "type": "string"
+                },
+                "labels": {
+                    "description": "key:value 形式的执行标签过滤",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "range": {
+                    "description": "相对时间范围，例如 24h、168h，展开时以当前时间计算开始时间，优先于 start_time",
+                    "type": "string"
+                },
+                "start_time": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "workflow_id": {
+                    "type": "integer"
                 }
             }
         },
-        "environment.LaboratoryEnvResp": {
+        "model.SavedViewTarget": {
+            "type": "string",
+            "enum": [
+                "workflow",
+                "device_event"
+            ],
+            "x-enum-comments": {
+                "SavedViewDeviceEvent": "设备事件历史",
+                "SavedViewWorkflow": "工作流执行历史"
+            },
+            "x-enum-descriptions": [
+                "工作流执行历史",
+                "设备事件历史"
+            ],
+            "x-enum-varnames": [
+                "SavedViewWorkflow",
+                "SavedViewDeviceEvent"
+            ]
+        },
+        "model.Scale": {
             "type": "object",
             "properties": {
-                "access_key": {
-                    "type": "string"
+                "x": {
+                    "type": "number"
                 },
-                "access_secret": {
-                    "type": "string"
+                "y": {
+                    "type": "number"
                 },
-                "name": {
-                    "type": "string"
+                "z": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.Size": {
+            "type": "object",
+            "properties": {
+                "depth": {
+                    "type": "number"
                 },
-                "uuid": {
-                    "type": "string"
+                "height": {
+                    "type": "number"
+                },
+                "width": {
+                    "type": "number"
                 }
             }
         },
-        "environment.LaboratoryListResp": {
+        "model.StatsTrend": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/environment.LaboratoryResp"
-                    }
+                "actions_change": {
+                    "type": "number"
                 },
-                "has_more": {
-                    "type": "boolean"
+                "average_duration_change": {
+                    "type": "number"
                 },
-                "page": {
+                "device_events_change": {
+                    "type": "number"
+                },
+                "executions_change": {
+                    "type": "number"
+                },
+                "success_rate_change": {
+                    "description": "百分点",
+                    "type": "number"
+                }
+            }
+        },
+        "model.StepDurationStats": {
+            "type": "object",
+            "properties": {
+                "action_name": {
+                    "type": "string"
+                },
+                "avg_deviation_ms": {
+                    "type": "number"
+                },
+                "avg_duration_ms": {
+                    "type": "number"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "executions": {
                     "type": "integer"
                 },
-                "page_size": {
+                "expected_ms": {
+                    "type": "integer"
+                },
+                "max_duration_ms": {
+                    "type": "integer"
+                },
+                "over_budget_count": {
                     "type": "integer"
                 }
             }
         },
-        "environment.LaboratoryResp": {
+        "model.StreamSession": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "client_id": {
                     "type": "string"
                 },
-                "description": {
+                "connected_at": {
                     "type": "string"
                 },
-                "is_admin": {
-                    "type": "boolean"
+                "created_at": {
+                    "type": "string"
                 },
-                "member_count": {
+                "device_id": {
                     "type": "integer"
                 },
-                "name": {
+                "device_uuid": {
                     "type": "string"
                 },
-                "updated_at": {
+                "end_reason": {
                     "type": "string"
                 },
-                "user_id": {
+                "ended_at": {
                     "type": "string"
                 },
-                "uuid": {
-                    "type": "string"
-                }
-            }
-        },
-        "environment.RegAction": {
-            "type": "object",
-            "properties": {
-                "feedback": {
-                    "type": "object"
+                "id": {
+                    "type": "integer"
                 },
-                "goal": {
-                    "type": "object"
+                "lab_id": {
+                    "type": "integer"
                 },
-                "goal_default": {
-                    "type": "object"
+                "started_at": {
+                    "type": "string"
                 },
-                "handles": {
-                    "type": "object"
+                "status": {
+                    "$ref": "#/definitions/model.StreamSessionStatus"
                 },
-                "result": {
-                    "type": "object"
+                "stream_id": {
+                    "type": "integer"
                 },
-                "schema": {
-                    "type": "object"
+                "stream_uuid": {
+                    "type": "string"
                 },
-                "type": {
+                "token_expiry": {
                     "type": "string"
-                }
-            }
-        },
-        "environment.RegClass": {
-            "type": "object",
-            "properties": {
-                "action_value_mappings": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "$ref": "#/definitions/environment.RegAction"
-                    }
                 },
-                "module": {
+                "updated_at": {
                     "type": "string"
                 },
-                "status_types": {
-                    "type": "object"
+                "user_id": {
+                    "type": "string"
                 },
-                "type": {
+                "uuid": {
                     "type": "string"
                 }
             }
         },
-        "environment.RegHandle": {
+        "model.StreamSessionStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "ended"
+            ],
+            "x-enum-varnames": [
+                "StreamSessionActive",
+                "StreamSessionEnded"
+            ]
+        },
+        "model.TelemetryAgg": {
+            "type": "string",
+            "enum": [
+                "avg",
+                "min",
+                "max",
+                "sum",
+                "count"
+            ],
+            "x-enum-varnames": [
+                "TelemetryAggAvg",
+                "TelemetryAggMin",
+                "TelemetryAggMax",
+                "TelemetryAggSum",
+                "TelemetryAggCount"
+            ]
+        },
+        "model.TelemetryResolution": {
+            "type": "string",
+            "enum": [
+                "1m",
+                "1h"
+            ],
+            "x-enum-varnames": [
+                "TelemetryMinute",
+                "TelemetryHour"
+            ]
+        },
+        "model.TopologyNode": {
             "type": "object",
             "properties": {
-                "data_key": {
-                    "type": "string"
-                },
-                "data_source": {
+                "created_at": {
                     "type": "string"
                 },
-                "data_type": {
+                "created_by": {
                     "type": "string"
                 },
                 "description": {
                     "type": "string"
                 },
-                "handler_key": {
-                    "type": "string"
-                },
-                "io_type": {
-                    "type": "string"
+                "id": {
+                    "type": "integer"
                 },
-                "label": {
-                    "type": "string"
+                "lab_id": {
+                    "type": "integer"
                 },
-                "side": {
+                "name": {
                     "type": "string"
-                }
-            }
-        },
-        "environment.RegInitParamSchema": {
-            "type": "object",
-            "properties": {
-                "config": {
-                    "$ref": "#/definitions/environment.RegSchema"
-                },
-                "data": {
-                    "$ref": "#/definitions/environment.RegSchema"
-                }
-            }
-        },
-        "environment.RegSchema": {
-            "type": "object",
-            "properties": {
-                "properties": {
-                    "type": "object"
                 },
-                "required": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "parent_id": {
+                    "description": "为空时位于实验室下",
+                    "type": "integer"
                 },
                 "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "environment.ResourceReq": {
-            "type": "object"
-        },
-        "environment.UpdateEnvReq": {
-            "type": "object",
-            "required": [
-                "uuid"
-            ],
-            "properties": {
-                "description": {
-                    "type": "string"
+                    "$ref": "#/definitions/model.TopologyNodeType"
                 },
-                "name": {
+                "updated_at": {
                     "type": "string"
                 },
                 "uuid": {
@@ -3093,72 +22610,73 @@ const docTemplate = `{
                 }
             }
         },
-        "login.RefreshTokenReq": {
-            "type": "object",
-            "required": [
-                "refresh_token"
+        "model.TopologyNodeType": {
+            "type": "string",
+            "enum": [
+                "room",
+                "bench",
+                "group"
             ],
-            "properties": {
-                "refresh_token": {
-                    "type": "string"
-                }
-            }
+            "x-enum-comments": {
+                "TopologyBench": "工作台，位于房间下",
+                "TopologyGroup": "设备组，位于实验室、房间或工作台下",
+                "TopologyRoom": "房间，位于实验室下"
+            },
+            "x-enum-descriptions": [
+                "房间，位于实验室下",
+                "工作台，位于房间下",
+                "设备组，位于实验室、房间或工作台下"
+            ],
+            "x-enum-varnames": [
+                "TopologyRoom",
+                "TopologyBench",
+                "TopologyGroup"
+            ]
         },
-        "login.RefreshTokenResp": {
+        "model.TopologyTree": {
             "type": "object",
             "properties": {
-                "access_token": {
-                    "type": "string"
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TopologyTree"
+                    }
                 },
-                "expires_in": {
-                    "type": "integer"
+                "created_at": {
+                    "type": "string"
                 },
-                "refresh_token": {
+                "created_by": {
                     "type": "string"
                 },
-                "token_type": {
+                "description": {
                     "type": "string"
-                }
-            }
-        },
-        "material.CreateMaterialEdgeReq": {
-            "type": "object",
-            "properties": {
-                "edges": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/material.MaterialEdge"
-                    }
-                }
-            }
-        },
-        "material.CreateMaterialReq": {
-            "type": "object",
-            "properties": {
-                "nodes": {
+                },
+                "devices": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/material.Material"
+                        "$ref": "#/definitions/model.TopologyTreeDevice"
                     }
-                }
-            }
-        },
-        "material.CreateMaterialResp": {
-            "type": "object",
-            "required": [
-                "id",
-                "name"
-            ],
-            "properties": {
-                "cloud_uuid": {
-                    "type": "string"
                 },
                 "id": {
-                    "description": "实际是数据库的 name",
-                    "type": "string"
+                    "type": "integer"
+                },
+                "lab_id": {
+                    "type": "integer"
                 },
                 "name": {
-                    "description": "实际是数据库的 display name",
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "为空时位于实验室下",
+                    "type": "integer"
+                },
+                "parent_uuid": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.TopologyNodeType"
+                },
+                "updated_at": {
                     "type": "string"
                 },
                 "uuid": {
@@ -3166,243 +22684,418 @@ const docTemplate = `{
                 }
             }
         },
-        "material.DeviceAction": {
+        "model.TopologyTreeDevice": {
             "type": "object",
             "properties": {
-                "action": {
-                    "type": "string"
+                "device_id": {
+                    "type": "integer"
                 },
-                "action_type": {
+                "device_uuid": {
                     "type": "string"
                 },
-                "schema": {
-                    "type": "object"
+                "name": {
+                    "type": "string"
                 }
             }
         },
-        "material.DeviceActionResp": {
+        "model.UpgradeCampaignStatus": {
+            "type": "string",
+            "enum": [
+                "running",
+                "paused",
+                "completed",
+                "rolling_back",
+                "rolled_back"
+            ],
+            "x-enum-comments": {
+                "UpgradeCampaignPaused": "手动暂停或失败数超过上限，不再下发新的升级"
+            },
+            "x-enum-descriptions": [
+                "",
+                "手动暂停或失败数超过上限，不再下发新的升级",
+                "",
+                "",
+                ""
+            ],
+            "x-enum-varnames": [
+                "UpgradeCampaignRunning",
+                "UpgradeCampaignPaused",
+                "UpgradeCampaignCompleted",
+                "UpgradeCampaignRollingBack",
+                "UpgradeCampaignRolledBack"
+            ]
+        },
+        "model.UpgradeFilters": {
             "type": "object",
             "properties": {
-                "actions": {
+                "device_names": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/material.DeviceAction"
+                        "type": "string"
                     }
                 },
-                "name": {
-                    "type": "string"
+                "online_only": {
+                    "type": "boolean"
+                },
+                "versions": {
+                    "description": "当前 agent 版本",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "material.DownloadMaterialResp": {
+        "model.UpgradeProgress": {
             "type": "object",
             "properties": {
-                "nodes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/material.Node"
+                "allowed": {
+                    "description": "按放量比例允许升级的设备数",
+                    "type": "integer"
+                },
+                "by_status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
                     }
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "material.Edge": {
+        "model.UpgradeTargetStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "dispatched",
+                "upgraded",
+                "failed",
+                "cancelled",
+                "rolling_back",
+                "rolled_back",
+                "rollback_failed"
+            ],
+            "x-enum-comments": {
+                "UpgradeTargetCancelled": "回滚时尚未下发",
+                "UpgradeTargetDispatched": "已下发升级指令，等待结果和新版本心跳",
+                "UpgradeTargetPending": "尚未下发"
+            },
+            "x-enum-descriptions": [
+                "尚未下发",
+                "已下发升级指令，等待结果和新版本心跳",
+                "",
+                "",
+                "回滚时尚未下发",
+                "",
+                "",
+                ""
+            ],
+            "x-enum-varnames": [
+                "UpgradeTargetPending",
+                "UpgradeTargetDispatched",
+                "UpgradeTargetUpgraded",
+                "UpgradeTargetFailed",
+                "UpgradeTargetCancelled",
+                "UpgradeTargetRollingBack",
+                "UpgradeTargetRolledBack",
+                "UpgradeTargetRollbackFailed"
+            ]
+        },
+        "model.UserData": {
             "type": "object",
             "properties": {
-                "source": {
-                    "type": "string"
-                },
-                "sourceHandle": {
-                    "description": "FIXME: 下面两个字段命令 unilab 需要修改命名称",
+                "accessKey": {
+                    "description": "只有实验室用户才会有这个值",
                     "type": "string"
                 },
-                "source_uuid": {
+                "accessSecret": {
                     "type": "string"
                 },
-                "target": {
+                "accessToken": {
                     "type": "string"
                 },
-                "targetHandle": {
+                "avatar": {
                     "type": "string"
                 },
-                "target_uuid": {
+                "displayName": {
                     "type": "string"
                 },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "material.EdgeNode": {
-            "type": "object",
-            "properties": {
-                "class": {
+                "email": {
                     "type": "string"
                 },
-                "config": {
-                    "type": "object"
-                },
-                "data": {
-                    "type": "object"
-                },
-                "description": {
+                "id": {
                     "type": "string"
                 },
-                "display_name": {
+                "name": {
                     "type": "string"
                 },
-                "extra": {
-                    "type": "object"
-                },
-                "icon": {
+                "org_id": {
                     "type": "string"
                 },
-                "model": {
-                    "type": "object"
-                },
-                "name": {
+                "owner": {
                     "type": "string"
                 },
-                "parent_uuid": {
+                "phone": {
                     "type": "string"
                 },
-                "pose": {
-                    "$ref": "#/definitions/model.Pose"
-                },
-                "schema": {
-                    "type": "object"
+                "signupApplication": {
+                    "type": "string"
                 },
                 "status": {
-                    "type": "string"
+                    "type": "integer"
                 },
                 "type": {
-                    "$ref": "#/definitions/model.DEVICETYPE"
+                    "type": "string"
                 },
-                "uuid": {
+                "user_no": {
                     "type": "string"
                 }
             }
         },
-        "material.GraphEdge": {
+        "model.UtilizationPoint": {
             "type": "object",
             "properties": {
-                "edges": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/material.Edge"
-                    }
+                "available_ms": {
+                    "type": "integer"
+                },
+                "busy_ms": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "utilization": {
+                    "type": "number"
                 }
             }
         },
-        "material.GraphNodeReq": {
+        "model.UtilizationReport": {
             "type": "object",
             "properties": {
-                "edges": {
+                "available_ms": {
+                    "type": "integer"
+                },
+                "busy_ms": {
+                    "type": "integer"
+                },
+                "devices": {
+                    "description": "按利用率从高到低排名",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/material.Edge"
+                        "$ref": "#/definitions/model.DeviceUtilization"
                     }
                 },
-                "nodes": {
+                "end_time": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "trend": {
+                    "description": "每天一个点，从周期开始计算",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/material.Node"
+                        "$ref": "#/definitions/model.UtilizationPoint"
                     }
+                },
+                "utilization": {
+                    "type": "number"
+                },
+                "utilization_change": {
+                    "type": "number"
                 }
             }
         },
-        "material.Material": {
+        "model.WorkflowExecutionHistory": {
             "type": "object",
-            "required": [
-                "class",
-                "id",
-                "name",
-                "type",
-                "uuid"
-            ],
             "properties": {
-                "children": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "batch_id": {
+                    "description": "批量运行时所属批次",
+                    "type": "integer"
                 },
-                "class": {
+                "completed_at": {
                     "type": "string"
                 },
-                "config": {
-                    "type": "object"
+                "created_at": {
+                    "type": "string"
                 },
-                "data": {
-                    "type": "object"
+                "definition_hash": {
+                    "description": "执行开始时的工作流定义快照，早于快照功能的执行为空",
+                    "type": "string"
                 },
-                "description": {
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error_category": {
+                    "description": "失败或超时时按实验室规则分类",
                     "type": "string"
                 },
-                "icon": {
+                "error_message": {
                     "type": "string"
                 },
+                "experiment_id": {
+                    "description": "提交时或之后关联的实验",
+                    "type": "integer"
+                },
                 "id": {
-                    "description": "实际是数据库的 name",
-                    "type": "string"
+                    "type": "integer"
                 },
-                "model": {
+                "input": {
+                    "description": "校验并补全默认值后的运行参数",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "labels": {
+                    "description": "提交时或之后添加的键值标签",
                     "type": "object"
                 },
-                "name": {
-                    "description": "实际是数据库的 display name",
+                "metadata": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "sla_breached": {
+                    "description": "结束时计算，未设置 SLA、未结束或已取消时为空",
+                    "type": "boolean"
+                },
+                "sla_target_ms": {
+                    "description": "提交时工作流的 SLA，0 表示未设置",
+                    "type": "integer"
+                },
+                "started_at": {
                     "type": "string"
                 },
-                "parent": {
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
+                },
+                "steps_completed": {
+                    "type": "integer"
+                },
+                "steps_failed": {
+                    "type": "integer"
+                },
+                "steps_total": {
+                    "type": "integer"
+                },
+                "traceparent": {
+                    "description": "提交请求的 W3C trace context，用于关联端到端 trace",
                     "type": "string"
                 },
-                "parent_uuid": {
+                "updated_at": {
                     "type": "string"
                 },
-                "pose": {
-                    "type": "object"
+                "user_id": {
+                    "type": "string"
                 },
-                "position": {
-                    "$ref": "#/definitions/model.Position"
+                "uuid": {
+                    "type": "string"
                 },
-                "position_3d": {
-                    "$ref": "#/definitions/model.Position"
+                "version": {
+                    "description": "乐观锁版本，每次更新加一",
+                    "type": "integer"
                 },
-                "schema": {
-                    "description": "FIXME: 这块后续要优化掉，从 reg 获取",
-                    "type": "object"
+                "workflow_id": {
+                    "type": "integer"
                 },
-                "type": {
-                    "$ref": "#/definitions/model.DEVICETYPE"
+                "workflow_name": {
+                    "type": "string"
                 },
-                "uuid": {
-                    "description": "有可能是 edge 编造 || 云端的",
+                "workflow_uuid": {
                     "type": "string"
                 }
             }
         },
-        "material.MaterialEdge": {
+        "model.WorkflowNodeType": {
+            "type": "string",
+            "enum": [
+                "Group",
+                "ILab",
+                "py_script"
+            ],
+            "x-enum-varnames": [
+                "WorkflowNodeGroup",
+                "WorkflowNodeILab",
+                "WorkflowPyScript"
+            ]
+        },
+        "model.WorkflowRank": {
             "type": "object",
             "properties": {
-                "sourceHandle": {
-                    "type": "string"
+                "executions": {
+                    "type": "integer"
                 },
-                "source_uuid": {
-                    "type": "string"
+                "lab_id": {
+                    "type": "integer"
                 },
-                "targetHandle": {
+                "successful_count": {
+                    "type": "integer"
+                },
+                "workflow_name": {
                     "type": "string"
                 },
-                "target_uuid": {
+                "workflow_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WorkflowSnapshot": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "type": {
+                "definition": {
+                    "type": "object"
+                },
+                "hash": {
                     "type": "string"
+                },
+                "workflow_id": {
+                    "type": "integer"
                 }
             }
         },
-        "material.MaterialQueryReq": {
+        "model.WorkflowTaskStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "canceled",
+                "failed",
+                "successed",
+                "timeout"
+            ],
+            "x-enum-varnames": [
+                "WorkflowTaskStatusPending",
+                "WorkflowTaskStatusRunnig",
+                "WorkflowTaskStatusCanceled",
+                "WorkflowTaskStatusFailed",
+                "WorkflowTaskStatusSuccessed",
+                "WorkflowTaskStatusTimeout"
+            ]
+        },
+        "notification.MarkReadReq": {
             "type": "object",
             "properties": {
+                "all": {
+                    "type": "boolean"
+                },
                 "uuids": {
                     "type": "array",
                     "items": {
@@ -3411,707 +23104,967 @@ const docTemplate = `{
                 }
             }
         },
-        "material.MaterialQueryResp": {
+        "notification.MarkReadResp": {
             "type": "object",
             "properties": {
-                "nodes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/material.EdgeNode"
-                    }
+                "marked": {
+                    "type": "integer"
+                },
+                "unread": {
+                    "type": "integer"
                 }
             }
         },
-        "material.MaterialResp": {
+        "notification.Preference": {
             "type": "object",
+            "required": [
+                "kind"
+            ],
             "properties": {
-                "child_nodes_uuid": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "enabled": {
+                    "type": "boolean"
                 },
-                "children": {
+                "kind": {
+                    "$ref": "#/definitions/model.NotificationKind"
+                }
+            }
+        },
+        "notification.PreferencesReq": {
+            "type": "object",
+            "required": [
+                "preferences"
+            ],
+            "properties": {
+                "preferences": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/notification.Preference"
                     }
+                }
+            }
+        },
+        "notification.UnreadResp": {
+            "type": "object",
+            "properties": {
+                "unread": {
+                    "type": "integer"
+                }
+            }
+        },
+        "report.CreateReq": {
+            "type": "object",
+            "required": [
+                "channel",
+                "format",
+                "lab_uuid",
+                "name",
+                "schedule",
+                "source",
+                "target"
+            ],
+            "properties": {
+                "channel": {
+                    "description": "email | webhook",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ReportChannel"
+                        }
+                    ]
                 },
-                "class": {
+                "format": {
+                    "description": "csv | pdf",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ReportFormat"
+                        }
+                    ]
+                },
+                "hour": {
+                    "type": "integer",
+                    "maximum": 23,
+                    "minimum": 0
+                },
+                "lab_uuid": {
                     "type": "string"
                 },
-                "cloud_uuid": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 120
+                },
+                "schedule": {
+                    "description": "daily | weekly",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ReportSchedule"
+                        }
+                    ]
+                },
+                "source": {
+                    "description": "saved_view | lab_stats",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ReportSource"
+                        }
+                    ]
+                },
+                "target": {
+                    "type": "string",
+                    "maxLength": 512
+                },
+                "view_uuid": {
+                    "description": "source 为 saved_view 时必填",
                     "type": "string"
                 },
-                "collapsed": {
+                "weekday": {
+                    "description": "每周报表的发送日，0 为周日",
+                    "type": "integer",
+                    "maximum": 6,
+                    "minimum": 0
+                }
+            }
+        },
+        "report.UpdateReq": {
+            "type": "object",
+            "required": [
+                "channel",
+                "format",
+                "name",
+                "schedule",
+                "target"
+            ],
+            "properties": {
+                "channel": {
+                    "$ref": "#/definitions/model.ReportChannel"
+                },
+                "enabled": {
                     "type": "boolean"
                 },
-                "config": {
-                    "type": "object"
+                "format": {
+                    "$ref": "#/definitions/model.ReportFormat"
                 },
-                "data": {
-                    "type": "object"
+                "hour": {
+                    "type": "integer",
+                    "maximum": 23,
+                    "minimum": 0
                 },
-                "description": {
-                    "type": "string"
+                "name": {
+                    "type": "string",
+                    "maxLength": 120
                 },
-                "device_id": {
+                "schedule": {
+                    "$ref": "#/definitions/model.ReportSchedule"
+                },
+                "target": {
+                    "type": "string",
+                    "maxLength": 512
+                },
+                "weekday": {
+                    "type": "integer",
+                    "maximum": 6,
+                    "minimum": 0
+                }
+            }
+        },
+        "reservation.CalendarResponse": {
+            "type": "object",
+            "properties": {
+                "end_time": {
                     "type": "string"
                 },
-                "dirs": {
+                "items": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/reservation.ReservationResponse"
                     }
                 },
-                "disabled": {
-                    "type": "boolean"
-                },
-                "dragHandle": {
+                "start_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "reservation.CreateReservationRequest": {
+            "type": "object",
+            "required": [
+                "device_name",
+                "end_time",
+                "lab_id",
+                "start_time"
+            ],
+            "properties": {
+                "device_name": {
                     "type": "string"
                 },
-                "eq_type": {
+                "end_time": {
                     "type": "string"
                 },
-                "experiment_env": {
+                "lab_id": {
                     "type": "integer"
                 },
-                "height": {
-                    "type": "number"
-                },
-                "id": {
+                "start_time": {
                     "type": "string"
                 },
-                "minimized": {
-                    "type": "boolean"
-                },
-                "name": {
+                "title": {
                     "type": "string"
+                }
+            }
+        },
+        "reservation.ReservationResponse": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "type": "integer"
                 },
-                "parent": {
+                "device_name": {
                     "type": "string"
                 },
-                "parent_node_uuid": {
+                "end_time": {
                     "type": "string"
                 },
-                "position": {
-                    "$ref": "#/definitions/model.Position"
+                "start_time": {
+                    "type": "string"
                 },
                 "status": {
-                    "type": "string"
+                    "$ref": "#/definitions/model.ReservationStatus"
                 },
-                "type": {
+                "title": {
                     "type": "string"
                 },
-                "version": {
+                "user_id": {
                     "type": "string"
                 },
-                "width": {
-                    "type": "number"
+                "uuid": {
+                    "type": "string"
                 }
             }
         },
-        "material.Node": {
+        "rule.RuleReq": {
             "type": "object",
             "required": [
-                "class",
-                "id",
+                "aggregation",
                 "name",
-                "type"
+                "operator",
+                "window_sec"
             ],
             "properties": {
-                "children": {
+                "aggregation": {
+                    "$ref": "#/definitions/model.TelemetryAgg"
+                },
+                "channels": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/model.AlertChannel"
                     }
                 },
-                "class": {
+                "description": {
                     "type": "string"
                 },
-                "config": {
-                    "type": "object"
-                },
-                "data": {
-                    "type": "object"
-                },
-                "description": {
+                "device_uuid": {
                     "type": "string"
                 },
-                "extra": {
-                    "type": "object"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "id": {
-                    "description": "实际是数据库的 name",
-                    "type": "string"
+                "escalate_after_min": {
+                    "description": "告警触发后超过该分钟数仍未确认时通知 escalation_channels，0 表示不升级",
+                    "type": "integer"
                 },
-                "model": {
-                    "type": "object"
+                "escalation_channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AlertChannel"
+                    }
                 },
-                "name": {
-                    "description": "实际是数据库的 display name",
+                "event_type": {
+                    "$ref": "#/definitions/model.DeviceEventType"
+                },
+                "lab_uuid": {
                     "type": "string"
                 },
-                "parent": {
+                "metric": {
                     "type": "string"
                 },
-                "parent_uuid": {
+                "name": {
                     "type": "string"
                 },
-                "pose": {
-                    "type": "object"
+                "operator": {
+                    "$ref": "#/definitions/model.RuleOperator"
                 },
-                "position": {
-                    "$ref": "#/definitions/model.Position"
+                "severity": {
+                    "$ref": "#/definitions/model.AlertSeverity"
                 },
-                "position_3d": {
-                    "$ref": "#/definitions/model.Position"
+                "threshold": {
+                    "type": "number"
                 },
-                "schema": {
-                    "description": "FIXME: 这块后续要优化掉，从 reg 获取",
-                    "type": "object"
+                "window_sec": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rule.TestResp": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RuleEvaluation"
+                    }
                 },
-                "type": {
-                    "$ref": "#/definitions/model.DEVICETYPE"
+                "window_end": {
+                    "type": "string"
                 },
-                "uuid": {
+                "window_start": {
                     "type": "string"
                 }
             }
         },
-        "material.ResourceHandleTemplate": {
+        "savedview.CreateReq": {
             "type": "object",
+            "required": [
+                "lab_uuid",
+                "name",
+                "target"
+            ],
             "properties": {
-                "display_name": {
-                    "type": "string"
+                "filters": {
+                    "$ref": "#/definitions/model.SavedViewFilters"
                 },
-                "io_type": {
+                "lab_uuid": {
                     "type": "string"
                 },
-                "key": {
-                    "type": "string"
+                "name": {
+                    "type": "string",
+                    "maxLength": 120
+                },
+                "shared": {
+                    "type": "boolean"
+                },
+                "target": {
+                    "description": "workflow | device_event",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.SavedViewTarget"
+                        }
+                    ]
+                }
+            }
+        },
+        "savedview.UpdateReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "filters": {
+                    "$ref": "#/definitions/model.SavedViewFilters"
                 },
                 "name": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 120
                 },
-                "side": {
-                    "type": "string"
+                "shared": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "seed.SeedReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid"
+            ],
+            "properties": {
+                "days": {
+                    "description": "分布在最近多少天，默认 30，最大 365",
+                    "type": "integer"
                 },
-                "source": {
-                    "type": "string"
+                "executions": {
+                    "description": "默认 2000，最大 20000",
+                    "type": "integer"
                 },
-                "type": {
+                "lab_uuid": {
                     "type": "string"
                 },
-                "uuid": {
+                "seed": {
+                    "description": "随机种子，相同种子生成相同分布的数据",
+                    "type": "integer"
+                },
+                "timezone": {
+                    "description": "工作时间所在时区，默认服务器时区",
                     "type": "string"
                 }
             }
         },
-        "material.ResourceInfo": {
+        "seed.SeedResp": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string"
+                "actions": {
+                    "type": "integer"
                 },
-                "parent_uuid": {
-                    "type": "string"
+                "devices": {
+                    "type": "integer"
                 },
-                "uuid": {
-                    "type": "string"
+                "events": {
+                    "type": "integer"
+                },
+                "executions": {
+                    "type": "integer"
+                },
+                "workflows": {
+                    "type": "integer"
                 }
             }
         },
-        "material.ResourceResp": {
+        "stream.RegisterReq": {
             "type": "object",
+            "required": [
+                "streams"
+            ],
             "properties": {
-                "resource_name_list": {
+                "streams": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/material.ResourceInfo"
+                        "$ref": "#/definitions/stream.StreamItem"
                     }
                 }
             }
         },
-        "material.SaveGrapReq": {
+        "stream.StartSessionReq": {
             "type": "object",
+            "required": [
+                "stream_uuid"
+            ],
             "properties": {
-                "graph": {
-                    "$ref": "#/definitions/material.WSGraph"
-                },
-                "lab_uuid": {
+                "stream_uuid": {
                     "type": "string"
                 }
             }
         },
-        "material.TemplateResp": {
+        "stream.StartSessionResp": {
             "type": "object",
             "properties": {
-                "config_infos": {
-                    "type": "array",
-                    "items": {
-                        "type": "object"
-                    }
-                },
-                "config_schema": {
-                    "type": "object"
-                },
-                "data_schema": {
-                    "type": "object"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "footer": {
-                    "type": "string"
-                },
-                "handles": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/material.ResourceHandleTemplate"
-                    }
-                },
-                "header": {
-                    "type": "string"
-                },
-                "icon": {
+                "camera_id": {
                     "type": "string"
                 },
-                "language": {
-                    "type": "string"
-                },
-                "model": {
-                    "type": "object"
-                },
-                "module": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "parent_uuid": {
-                    "type": "string"
-                },
-                "pose": {
-                    "type": "object"
-                },
-                "resource_type": {
-                    "type": "string"
-                },
-                "status_types": {
-                    "type": "object"
-                },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "user_id": {
+                "expires_at": {
                     "type": "string"
                 },
-                "uuid": {
+                "host_id": {
                     "type": "string"
                 },
-                "version": {
+                "session": {
+                    "$ref": "#/definitions/model.StreamSession"
+                },
+                "token": {
+                    "description": "连接 /realtime/client 时通过 token 参数传入",
                     "type": "string"
                 }
             }
         },
-        "material.UpdateMaterialReq": {
+        "stream.StreamItem": {
             "type": "object",
+            "required": [
+                "camera_id",
+                "device_name",
+                "host_id"
+            ],
             "properties": {
-                "nodes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/material.Node"
-                    }
+                "camera_id": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "host_id": {
+                    "description": "edge 连接 /realtime/host/:hostId 时使用的 id",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
                 }
             }
         },
-        "material.UpsertMaterialReq": {
+        "systemhealth.ConfigChange": {
             "type": "object",
             "properties": {
-                "mount_uuid": {
+                "action": {
+                    "$ref": "#/definitions/model.AuditAction"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "nodes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/material.Material"
-                    }
+                "detail": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "material.UpsertMaterialResp": {
+        "systemhealth.JobStatus": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "cloud_uuid": {
+                "last_error": {
                     "type": "string"
                 },
-                "display_name": {
+                "last_run_at": {
                     "type": "string"
                 },
+                "last_status": {
+                    "$ref": "#/definitions/model.JobRunStatus"
+                },
                 "name": {
                     "type": "string"
                 },
-                "uuid": {
+                "next_run_at": {
                     "type": "string"
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "registered": {
+                    "description": "当前版本是否仍注册该任务",
+                    "type": "boolean"
                 }
             }
         },
-        "material.WSEdge": {
+        "systemhealth.Latency": {
             "type": "object",
             "properties": {
-                "source_handle_uuid": {
-                    "type": "string"
+                "p50_ms": {
+                    "type": "number"
                 },
-                "source_node_uuid": {
-                    "type": "string"
+                "p95_ms": {
+                    "type": "number"
                 },
-                "target_handle_uuid": {
-                    "type": "string"
+                "p99_ms": {
+                    "type": "number"
                 },
-                "target_node_uuid": {
-                    "type": "string"
+                "samples": {
+                    "type": "integer"
+                }
+            }
+        },
+        "systemhealth.Queues": {
+            "type": "object",
+            "properties": {
+                "dead_letter": {
+                    "$ref": "#/definitions/model.DeadLetterStats"
                 },
-                "type": {
-                    "type": "string"
+                "pending_executions": {
+                    "type": "integer"
                 },
-                "uuid": {
-                    "type": "string"
+                "running_executions": {
+                    "type": "integer"
                 }
             }
         },
-        "material.WSGraph": {
+        "systemhealth.Response": {
             "type": "object",
             "properties": {
-                "edges": {
+                "config_changes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/material.WSEdge"
+                        "$ref": "#/definitions/systemhealth.ConfigChange"
                     }
                 },
-                "nodes": {
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/systemhealth.SectionError"
+                    }
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "jobs": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/material.WSNode"
+                        "$ref": "#/definitions/systemhealth.JobStatus"
+                    }
+                },
+                "latency": {
+                    "description": "按依赖，db 和 redis",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/systemhealth.Latency"
                     }
+                },
+                "queues": {
+                    "$ref": "#/definitions/systemhealth.Queues"
+                },
+                "traffic": {
+                    "$ref": "#/definitions/systemhealth.Traffic"
                 }
             }
         },
-        "material.WSHandle": {
+        "systemhealth.SectionError": {
             "type": "object",
             "properties": {
-                "display_name": {
-                    "type": "string"
+                "code": {
+                    "$ref": "#/definitions/code.ErrCode"
                 },
-                "io_type": {
+                "msg": {
                     "type": "string"
+                }
+            }
+        },
+        "systemhealth.Traffic": {
+            "type": "object",
+            "properties": {
+                "error_rate": {
+                    "description": "5xx 响应占全部请求的比例",
+                    "type": "number"
                 },
-                "key": {
-                    "type": "string"
+                "errors": {
+                    "description": "5xx 响应",
+                    "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "load_shed": {
+                    "type": "integer"
                 },
-                "side": {
-                    "type": "string"
+                "rate_limited": {
+                    "type": "integer"
                 },
-                "source": {
-                    "type": "string"
+                "rate_limited_rate": {
+                    "type": "number"
                 },
-                "type": {
-                    "type": "string"
+                "requests": {
+                    "type": "integer"
                 },
-                "uuid": {
-                    "description": "NodeUUID    uuid.UUID ` + "`" + `json:\"node_uuid\"` + "`" + `",
-                    "type": "string"
+                "requests_per_sec": {
+                    "type": "number"
+                },
+                "window_secs": {
+                    "type": "integer"
                 }
             }
         },
-        "material.WSNode": {
+        "telemetry.Series": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "object"
+                "device_name": {
+                    "type": "string"
                 },
-                "description": {
+                "device_uuid": {
                     "type": "string"
                 },
-                "display_name": {
+                "metric": {
                     "type": "string"
                 },
-                "handles": {
+                "points": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/material.WSHandle"
+                        "$ref": "#/definitions/telemetry.SeriesPoint"
                     }
-                },
-                "header": {
-                    "type": "string"
-                },
-                "icon": {
-                    "type": "string"
-                },
-                "init_param_data": {
-                    "type": "object"
-                },
-                "model": {
-                    "type": "object"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "parent_uuid": {
+                }
+            }
+        },
+        "telemetry.SeriesPoint": {
+            "type": "object",
+            "properties": {
+                "t": {
                     "type": "string"
                 },
-                "plate_well_datas": {
-                    "type": "object"
+                "v": {
+                    "type": "number"
+                }
+            }
+        },
+        "telemetry.SeriesResp": {
+            "type": "object",
+            "properties": {
+                "agg": {
+                    "$ref": "#/definitions/model.TelemetryAgg"
                 },
-                "pose": {
-                    "type": "object"
+                "interval_sec": {
+                    "type": "integer"
                 },
-                "res_template_name": {
+                "series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/telemetry.Series"
+                    }
+                }
+            }
+        },
+        "topology.CreateNodeReq": {
+            "type": "object",
+            "required": [
+                "lab_uuid",
+                "name",
+                "type"
+            ],
+            "properties": {
+                "description": {
                     "type": "string"
                 },
-                "res_template_uuid": {
+                "lab_uuid": {
                     "type": "string"
                 },
-                "schema": {
-                    "type": "object"
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
                 },
-                "status": {
+                "parent_uuid": {
                     "type": "string"
                 },
                 "type": {
-                    "$ref": "#/definitions/model.DEVICETYPE"
-                },
-                "uuid": {
-                    "type": "string"
+                    "description": "room | bench | group",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.TopologyNodeType"
+                        }
+                    ]
                 }
             }
         },
-        "model.DEVICETYPE": {
-            "type": "string",
-            "enum": [
-                "repository",
-                "plate",
-                "container",
-                "resource",
-                "device",
-                "well",
-                "tip",
-                "tip_rack",
-                "tip_spot",
-                "deck",
-                "workstation"
-            ],
-            "x-enum-varnames": [
-                "MATERIALREPO",
-                "MATERIALPLATE",
-                "MATERIALCONTAINER",
-                "MATERIALRESOURCE",
-                "MATERIALDEVICE",
-                "MATERIALWELL",
-                "MATERIALTIP",
-                "MATERIALTIPRACK",
-                "MATERIALTIPSPOT",
-                "MATERIALDECK",
-                "MATERIALWORKSTATION"
-            ]
-        },
-        "model.EnvironmentStatus": {
-            "type": "string",
-            "enum": [
-                "INIT",
-                "DELETED"
+        "topology.DevicesReq": {
+            "type": "object",
+            "required": [
+                "device_names"
             ],
-            "x-enum-varnames": [
-                "INIT",
-                "DELETED"
-            ]
+            "properties": {
+                "device_names": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
         },
-        "model.LaboratoryMemberRole": {
-            "type": "string",
-            "enum": [
-                "admin",
-                "normal"
-            ],
-            "x-enum-varnames": [
-                "LaboratoryMemberAdmin",
-                "LaboratoryMemberNormal"
-            ]
+        "topology.UpdateNodeReq": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
+                },
+                "parent_uuid": {
+                    "type": "string"
+                }
+            }
         },
-        "model.Pose": {
+        "upgrade.CampaignResp": {
             "type": "object",
             "properties": {
-                "cross_section_type": {
+                "created_at": {
                     "type": "string"
                 },
-                "extra": {},
-                "layout": {
+                "created_by": {
                     "type": "string"
                 },
-                "position": {
-                    "$ref": "#/definitions/model.Position"
+                "filters": {
+                    "type": "object"
                 },
-                "position_3d": {
-                    "$ref": "#/definitions/model.Position"
+                "finished_at": {
+                    "type": "string"
                 },
-                "rotation": {
-                    "$ref": "#/definitions/model.Rotation"
+                "id": {
+                    "type": "integer"
                 },
-                "scale": {
-                    "$ref": "#/definitions/model.Scale"
+                "lab_id": {
+                    "type": "integer"
                 },
-                "size": {
-                    "$ref": "#/definitions/model.Size"
+                "max_failures": {
+                    "description": "失败数超过时自动暂停，0 表示不限制",
+                    "type": "integer"
+                },
+                "message": {
+                    "description": "自动暂停等状态变化的原因",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "package_url": {
+                    "type": "string"
+                },
+                "progress": {
+                    "$ref": "#/definitions/model.UpgradeProgress"
+                },
+                "rollout_percent": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.UpgradeCampaignStatus"
+                },
+                "target_version": {
+                    "type": "string"
+                },
+                "targets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AgentUpgradeTarget"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
                 }
             }
         },
-        "model.Position": {
+        "upgrade.CreateReq": {
             "type": "object",
+            "required": [
+                "lab_uuid",
+                "name",
+                "rollout_percent",
+                "target_version"
+            ],
             "properties": {
-                "x": {
-                    "type": "number"
+                "filters": {
+                    "$ref": "#/definitions/model.UpgradeFilters"
                 },
-                "y": {
-                    "type": "number"
+                "lab_uuid": {
+                    "type": "string"
                 },
-                "z": {
-                    "type": "number"
+                "max_failures": {
+                    "description": "失败数超过时自动暂停，0 表示不限制",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string"
+                },
+                "package_url": {
+                    "type": "string"
+                },
+                "rollout_percent": {
+                    "description": "按顺序升级前百分之多少的设备",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "target_version": {
+                    "type": "string"
                 }
             }
         },
-        "model.Rotation": {
+        "upgrade.RolloutReq": {
             "type": "object",
+            "required": [
+                "rollout_percent"
+            ],
             "properties": {
-                "x": {
-                    "type": "number"
-                },
-                "y": {
-                    "type": "number"
-                },
-                "z": {
-                    "type": "number"
+                "rollout_percent": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
                 }
             }
         },
-        "model.Scale": {
+        "workflow.BatchChild": {
             "type": "object",
             "properties": {
-                "x": {
-                    "type": "number"
+                "inputs": {
+                    "type": "object"
                 },
-                "y": {
-                    "type": "number"
+                "status": {
+                    "$ref": "#/definitions/model.WorkflowTaskStatus"
                 },
-                "z": {
-                    "type": "number"
+                "task_uuid": {
+                    "type": "string"
                 }
             }
         },
-        "model.Size": {
+        "workflow.BatchProgress": {
             "type": "object",
             "properties": {
-                "depth": {
-                    "type": "number"
+                "canceled": {
+                    "type": "integer"
                 },
-                "height": {
-                    "type": "number"
+                "failed": {
+                    "type": "integer"
                 },
-                "width": {
-                    "type": "number"
+                "pending": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "model.UserData": {
+        "workflow.BatchResp": {
             "type": "object",
             "properties": {
-                "accessKey": {
-                    "description": "只有实验室用户才会有这个值",
-                    "type": "string"
-                },
-                "accessSecret": {
-                    "type": "string"
-                },
-                "accessToken": {
-                    "type": "string"
-                },
-                "avatar": {
-                    "type": "string"
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/workflow.BatchChild"
+                    }
                 },
-                "displayName": {
+                "created_at": {
                     "type": "string"
                 },
-                "email": {
+                "name": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
+                "progress": {
+                    "$ref": "#/definitions/workflow.BatchProgress"
                 },
-                "name": {
-                    "type": "string"
+                "status": {
+                    "$ref": "#/definitions/model.ExecutionStatus"
                 },
-                "org_id": {
+                "uuid": {
                     "type": "string"
                 },
-                "owner": {
+                "workflow_uuid": {
                     "type": "string"
-                },
-                "phone": {
+                }
+            }
+        },
+        "workflow.BatchRunReq": {
+            "type": "object",
+            "required": [
+                "inputs",
+                "workflow_uuid"
+            ],
+            "properties": {
+                "experiment_uuid": {
+                    "description": "所有子任务所属的实验",
                     "type": "string"
                 },
-                "signupApplication": {
-                    "type": "string"
+                "inputs": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": {}
+                    }
                 },
-                "status": {
-                    "type": "integer"
+                "labels": {
+                    "description": "所有子任务执行记录的键值标签",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "type": {
+                "name": {
                     "type": "string"
                 },
-                "user_no": {
+                "workflow_uuid": {
                     "type": "string"
                 }
             }
         },
-        "model.WorkflowNodeType": {
-            "type": "string",
-            "enum": [
-                "Group",
-                "ILab",
-                "py_script"
-            ],
-            "x-enum-varnames": [
-                "WorkflowNodeGroup",
-                "WorkflowNodeILab",
-                "WorkflowPyScript"
-            ]
-        },
-        "model.WorkflowTaskStatus": {
-            "type": "string",
-            "enum": [
-                "pending",
-                "running",
-                "canceled",
-                "failed",
-                "successed",
-                "timeout"
-            ],
-            "x-enum-varnames": [
-                "WorkflowTaskStatusPending",
-                "WorkflowTaskStatusRunnig",
-                "WorkflowTaskStatusCanceled",
-                "WorkflowTaskStatusFailed",
-                "WorkflowTaskStatusSuccessed",
-                "WorkflowTaskStatusTimeout"
-            ]
-        },
         "workflow.CreateReq": {
             "type": "object",
             "required": [
@@ -4161,6 +24114,9 @@ const docTemplate = `{
                         "$ref": "#/definitions/workflow.WSEdge"
                     }
                 },
+                "input_schema": {
+                    "type": "object"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -4170,6 +24126,9 @@ const docTemplate = `{
                         "$ref": "#/definitions/workflow.WSNode"
                     }
                 },
+                "sla_minutes": {
+                    "type": "integer"
+                },
                 "user_id": {
                     "type": "string"
                 },
@@ -4344,6 +24303,53 @@ const docTemplate = `{
                 }
             }
         },
+        "workflow.LabRunReq": {
+            "type": "object",
+            "properties": {
+                "dependency_policy": {
+                    "$ref": "#/definitions/model.DependencyPolicy"
+                },
+                "depends_on": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "experiment_uuid": {
+                    "type": "string"
+                },
+                "inputs": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "workflow.LabRunResp": {
+            "type": "object",
+            "properties": {
+                "estimate": {
+                    "description": "预计开始和完成时间，预估失败时为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ExecutionEstimate"
+                        }
+                    ]
+                },
+                "execution_uuid": {
+                    "type": "string"
+                },
+                "queue_position": {
+                    "description": "排在前面且使用相同设备的执行数，0 表示可以立即开始",
+                    "type": "integer"
+                }
+            }
+        },
         "workflow.ListResp": {
             "type": "object",
             "properties": {
@@ -4487,6 +24493,37 @@ const docTemplate = `{
                 "workflow_uuid"
             ],
             "properties": {
+                "dependency_policy": {
+                    "description": "前置任务失败时的处理策略，默认 success",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.DependencyPolicy"
+                        }
+                    ]
+                },
+                "depends_on": {
+                    "description": "前置任务 uuid，全部结束后才开始调度",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "experiment_uuid": {
+                    "description": "执行所属的实验",
+                    "type": "string"
+                },
+                "inputs": {
+                    "description": "按工作流 input_schema 校验",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "labels": {
+                    "description": "执行记录的键值标签",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "workflow_uuid": {
                     "type": "string"
                 }
@@ -4633,12 +24670,21 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
+                "input_schema": {
+                    "type": "object"
+                },
                 "name": {
                     "type": "string"
                 },
                 "published": {
                     "type": "boolean"
                 },
+                "sla_minutes": {
+                    "description": "0 清除 SLA，最长 30 天",
+                    "type": "integer",
+                    "maximum": 43200,
+                    "minimum": 0
+                },
                 "uuid": {
                     "type": "string"
                 }
@@ -4673,6 +24719,9 @@ const docTemplate = `{
                 "disabled": {
                     "type": "boolean"
                 },
+                "expected_ms": {
+                    "type": "integer"
+                },
                 "footer": {
                     "type": "string"
                 },
@@ -4694,6 +24743,9 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "need_approval": {
+                    "type": "boolean"
+                },
                 "param": {
                     "type": "object"
                 },
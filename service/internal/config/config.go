@@ -19,6 +19,10 @@ type GlobalConfig struct {
 	RPC           RPC      `mapstructure:",squash"`
 	Auth          Auth     `mapstructure:",squash"`
 	Storage 	  Storage  `mapstructure:",squash"`
+	Telemetry     Telemetry `mapstructure:",squash"`
+	Camera        Camera    `mapstructure:",squash"`
+	Upload        Upload    `mapstructure:",squash"`
+	EventStore    EventStore `mapstructure:",squash"`
 	// dynamicConfig *DynamicConfig
 }
 
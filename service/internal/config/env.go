@@ -8,11 +8,38 @@ type Database struct {
 	User        string `mapstructure:"DATABASE_USER" default:"postgres"`
 	Password    string `mapstructure:"DATABASE_PASSWORD" default:"postgres"`
 	AutoMigrate bool   `mapstructure:"DATABASE_AUTO_MIGRATE" default:"true"`
+	Driver      string `mapstructure:"DATABASE_DRIVER" default:"postgres"`              // postgres | sqlite，sqlite 仅用于本地开发
+	SQLitePath  string `mapstructure:"DATABASE_SQLITE_PATH" default:"./data/studio.db"` // sqlite 数据库文件
+}
+
+// 设备事件存储后端
+const (
+	EventBackendPostgres  = "postgres"
+	EventBackendTimescale = "timescale"
+)
+
+// EventStore 设备事件存储，迁移到 timescale 时先开启双写并执行 migrate events 迁移历史数据，
+// 数据追平后关闭 ReadPrimary 切换读取，最后关闭双写并再次执行 migrate events 同步 id 序列
+type EventStore struct {
+	Backend     string `mapstructure:"EVENT_STORE_BACKEND" default:"postgres"`   // postgres | timescale
+	DualWrite   bool   `mapstructure:"EVENT_STORE_DUAL_WRITE" default:"false"`   // 同时写入主库和 timescale，以主库写入结果为准
+	ReadPrimary bool   `mapstructure:"EVENT_STORE_READ_PRIMARY" default:"false"` // 双写期间列表和汇总查询仍读取主库
+	Host        string `mapstructure:"EVENT_STORE_HOST"`
+	Port        int    `mapstructure:"EVENT_STORE_PORT" default:"5432"`
+	Name        string `mapstructure:"EVENT_STORE_NAME"`
+	User        string `mapstructure:"EVENT_STORE_USER"`
+	Password    string `mapstructure:"EVENT_STORE_PASSWORD"`
+	ChunkHours  int    `mapstructure:"EVENT_STORE_CHUNK_HOURS" default:"24"` // hypertable 分块时长
 }
 
 type Storage struct {
-	Addr   string `mapstructure:"STORAGE_ADDR" default:"http://localhost:9000"`
-	Bucket string `mapstructure:"STORAGE_BUCKET" default:"studio"`
+	Addr      string `mapstructure:"STORAGE_ADDR" default:"http://localhost:9000"`
+	Bucket    string `mapstructure:"STORAGE_BUCKET" default:"studio"`
+	Backend   string `mapstructure:"STORAGE_BACKEND" default:"local"`            // 上传文件存储后端 local | s3
+	LocalDir  string `mapstructure:"STORAGE_LOCAL_DIR" default:"./data/storage"` // local 后端的根目录
+	Region    string `mapstructure:"STORAGE_REGION" default:"us-east-1"`
+	AccessKey string `mapstructure:"STORAGE_ACCESS_KEY"`
+	SecretKey string `mapstructure:"STORAGE_SECRET_KEY"`
 }
 
 type Redis struct {
@@ -42,10 +69,11 @@ type Auth struct {
 }
 
 type RPC struct {
-	Account  Account  `mapstructure:",squash"`
-	Bohr     Bohr     `mapstructure:",squash"`
-	BohrCore BohrCore `mapstructure:",squash"`
-	Sandbox  Sandbox  `mapstructure:",squash"`
+	Account       Account       `mapstructure:",squash"`
+	Bohr          Bohr          `mapstructure:",squash"`
+	BohrCore      BohrCore      `mapstructure:",squash"`
+	Sandbox       Sandbox       `mapstructure:",squash"`
+	HistoryIngest HistoryIngest `mapstructure:",squash"`
 }
 
 // bohr core rpc 信息
@@ -63,6 +91,13 @@ type Bohr struct {
 	Addr string `mapstructure:"BOHR_ADDR" default:"http://127.0.0.1"`
 }
 
+// 执行历史写入 grpc，web 服务监听 Port，调度服务调用 Addr。Token 不为空时校验调用方
+type HistoryIngest struct {
+	Addr  string `mapstructure:"HISTORY_INGEST_ADDR" default:"127.0.0.1:48199"`
+	Port  int    `mapstructure:"HISTORY_INGEST_PORT" default:"48199"`
+	Token string `mapstructure:"HISTORY_INGEST_TOKEN" default:""`
+}
+
 // 沙箱地址
 type Sandbox struct {
 	Addr   string `mapstructure:"SANDBOX_ADDR" default:"http://127.0.0.1"`
@@ -113,5 +148,30 @@ type Log struct {
 // }
 
 type Job struct {
-	JobQueueName string `mapstructure:"JOB_QUEUE_NAME" default:"studio_workflow_job_queue"`
+	JobQueueName         string   `mapstructure:"JOB_QUEUE_NAME" default:"studio_workflow_job_queue"`
+	AdminUserIDs         []string `mapstructure:"JOB_ADMIN_USER_IDS"`                     // 可以管理后台任务的用户
+	RunRetentionDays     int      `mapstructure:"JOB_RUN_RETENTION_DAYS" default:"30"`    // 后台任务运行记录保留天数
+	HistoryRetentionDays int      `mapstructure:"JOB_HISTORY_RETENTION_DAYS" default:"0"` // 执行历史保留天数，0 表示不清理
+}
+
+type Telemetry struct {
+	RawRetentionDays    int `mapstructure:"TELEMETRY_RAW_RETENTION_DAYS" default:"7"`     // data_received 原始事件保留天数，0 表示不清理
+	MinuteRetentionDays int `mapstructure:"TELEMETRY_MINUTE_RETENTION_DAYS" default:"30"` // 分钟级汇总保留天数，0 表示不清理
+	HourRetentionDays   int `mapstructure:"TELEMETRY_HOUR_RETENTION_DAYS" default:"0"`    // 小时级汇总保留天数，0 表示不清理
+}
+
+type Camera struct {
+	TokenSecret   string `mapstructure:"CAMERA_TOKEN_SECRET"`                  // 观看令牌签名密钥，为空时使用下载链接签名密钥
+	TokenTTLSec   int    `mapstructure:"CAMERA_TOKEN_TTL_SEC" default:"120"`   // 观看令牌有效期
+	MaxSessionMin int    `mapstructure:"CAMERA_MAX_SESSION_MIN" default:"720"` // 单次观看会话最长时间
+}
+
+type Upload struct {
+	MaxSizeMB    int    `mapstructure:"UPLOAD_MAX_SIZE_MB" default:"4096"` // 单个文件最大大小
+	ChunkMaxMB   int    `mapstructure:"UPLOAD_CHUNK_MAX_MB" default:"64"`  // 单次上传分片最大大小
+	AllowedTypes string `mapstructure:"UPLOAD_ALLOWED_TYPES"`              // 允许的内容类型，逗号分隔，支持 text/* 形式，为空时不限制
+	ClamdAddr    string `mapstructure:"UPLOAD_CLAMD_ADDR"`                 // clamd 地址，如 127.0.0.1:3310，为空时不做病毒扫描
+	URLSecret    string `mapstructure:"UPLOAD_URL_SECRET"`                 // 下载链接签名密钥，未配置 export.url_secret 时必须配置，多实例部署必须一致
+	URLTTLSec    int    `mapstructure:"UPLOAD_URL_TTL_SEC" default:"600"`  // 下载链接有效期
+	ExpireHours  int    `mapstructure:"UPLOAD_EXPIRE_HOURS" default:"24"`  // 未完成的上传保留时间
 }
@@ -17,7 +17,29 @@ type StudioConfig struct {
 	Observability ObservabilityConfig `mapstructure:"observability"`
 	Workflow      WorkflowConfig      `mapstructure:"workflow"`
 	Material      MaterialConfig      `mapstructure:"material"`
+	Cost          CostConfig          `mapstructure:"cost"`
+	Report        ReportConfig        `mapstructure:"report"`
+	Export        ExportConfig        `mapstructure:"export"`
 	Security      SecurityConfig      `mapstructure:"security"`
+	Compression   CompressionConfig   `mapstructure:"compression"`
+	ResponseCache ResponseCacheConfig `mapstructure:"response_cache"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	LoadShedding  LoadSheddingConfig  `mapstructure:"load_shedding"`
+	APIVersioning APIVersioningConfig `mapstructure:"api_versioning"`
+	Maintenance   MaintenanceConfig   `mapstructure:"maintenance"`
+	Assistant     AssistantConfig     `mapstructure:"assistant"`
+	DeviceRisk    DeviceRiskConfig    `mapstructure:"device_risk"`
+	HistoryChain  HistoryChainConfig  `mapstructure:"history_chain"`
+	Chaos         ChaosConfig         `mapstructure:"chaos"`
+	I18n          I18nConfig          `mapstructure:"i18n"`
+	PayloadLimits PayloadLimitsConfig `mapstructure:"payload_limits"`
+	Compaction    CompactionConfig    `mapstructure:"history_compaction"`
+	ZstdJSON      ZstdJSONConfig      `mapstructure:"json_compression"`
+	CDC           CDCConfig           `mapstructure:"cdc"`
+	ListStreaming ListStreamingConfig `mapstructure:"list_streaming"`
+	WSHub         WSHubConfig         `mapstructure:"ws_hub"`
+	Replay        ReplayConfig        `mapstructure:"replay"`
+	EdgeEvents    EdgeEventsConfig    `mapstructure:"edge_events"`
 }
 
 // ServerConfig from YAML
@@ -73,10 +95,18 @@ type LoggingConfig struct {
 
 // WorkflowConfig from YAML
 type WorkflowConfig struct {
-	MaxConcurrentExecutions int           `mapstructure:"max_concurrent_executions"`
-	DefaultTimeoutSeconds   int           `mapstructure:"default_timeout_seconds"`
-	MaxRetryAttempts        int           `mapstructure:"max_retry_attempts"`
-	Queue                   QueueConfig   `mapstructure:"queue"`
+	MaxConcurrentExecutions int              `mapstructure:"max_concurrent_executions"`
+	DefaultTimeoutSeconds   int              `mapstructure:"default_timeout_seconds"`
+	MaxRetryAttempts        int              `mapstructure:"max_retry_attempts"`
+	Queue                   QueueConfig      `mapstructure:"queue"`
+	DeviceLock              DeviceLockConfig `mapstructure:"device_lock"`
+}
+
+// DeviceLockConfig from YAML
+type DeviceLockConfig struct {
+	LeaseTTLSeconds    int    `mapstructure:"lease_ttl_seconds"`
+	WaitTimeoutSeconds int    `mapstructure:"wait_timeout_seconds"`
+	ReservationPolicy  string `mapstructure:"reservation_policy"` // reject or queue
 }
 
 // QueueConfig from YAML
@@ -87,14 +117,221 @@ type QueueConfig struct {
 
 // MaterialConfig from YAML
 type MaterialConfig struct {
-	SyncIntervalSeconds int `mapstructure:"sync_interval_seconds"`
-	MaxDevicesPerLab    int `mapstructure:"max_devices_per_lab"`
+	SyncIntervalSeconds int        `mapstructure:"sync_interval_seconds"`
+	MaxDevicesPerLab    int        `mapstructure:"max_devices_per_lab"`
+	LIMS                LIMSConfig `mapstructure:"lims"`
+}
+
+// LIMSConfig from YAML
+type LIMSConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	Endpoint       string `mapstructure:"endpoint"` // GET {endpoint}?lab_uuid=... 返回实验室库存
+	AuthToken      string `mapstructure:"auth_token"`
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+}
+
+// CostConfig from YAML, the default cost model of labs without their own
+type CostConfig struct {
+	Currency           string             `mapstructure:"currency"`
+	DeviceHourRate     float64            `mapstructure:"device_hour_rate"`
+	StorageGBMonthRate float64            `mapstructure:"storage_gb_month_rate"`
+	ActionTypeRates    map[string]float64 `mapstructure:"action_type_rates"`
+	RecomputeDays      int                `mapstructure:"recompute_days"` // 每次重新计算最近几天的费用
+}
+
+// ReportConfig from YAML
+type ReportConfig struct {
+	MaxRows int        `mapstructure:"max_rows"` // 单份报表最多包含的历史记录条数
+	SMTP    SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig from YAML, email delivery is disabled when host is empty
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// ExportConfig from YAML
+type ExportConfig struct {
+	ExpireHours     int    `mapstructure:"expire_hours"`       // 实验室导出归档和历史记录导出文件的保留时长
+	URLTTLSeconds   int    `mapstructure:"url_ttl_seconds"`    // 下载链接有效期
+	URLSecret       string `mapstructure:"url_secret"`         // 下载链接签名密钥，为空时使用 UPLOAD_URL_SECRET，多实例部署必须一致
+	ImportMaxSizeMB int    `mapstructure:"import_max_size_mb"` // 历史记录导入文件大小上限
+	HistoryPartRows int    `mapstructure:"history_part_rows"`  // 历史记录导出每个检查点分片的记录数
+}
+
+// CompressionConfig from YAML
+type CompressionConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	MinSizeBytes int      `mapstructure:"min_size_bytes"` // 小于该大小的响应不压缩
+	Encodings    []string `mapstructure:"encodings"`      // 按优先级排列，支持 zstd、gzip
+}
+
+// ResponseCacheConfig from YAML
+type ResponseCacheConfig struct {
+	Enabled bool           `mapstructure:"enabled"`
+	Routes  map[string]int `mapstructure:"routes"` // 路由路径到缓存秒数，未列出的路由不缓存
+}
+
+// DatabaseConfig from YAML, connection pool and statement settings of the postgres connections
+type DatabaseConfig struct {
+	PrepareStmt            bool `mapstructure:"prepare_stmt"` // 缓存预编译语句
+	MaxOpenConns           int  `mapstructure:"max_open_conns"`
+	MaxIdleConns           int  `mapstructure:"max_idle_conns"`
+	ConnMaxLifetimeSeconds int  `mapstructure:"conn_max_lifetime_seconds"`
+	ConnMaxIdleTimeSeconds int  `mapstructure:"conn_max_idle_time_seconds"`
+	StatementTimeoutMs     int  `mapstructure:"statement_timeout_ms"` // 0 表示不限制
+	UUIDVersion            int  `mapstructure:"uuid_version"`         // 新记录的 UUID 版本，4 或 7，默认 7
+}
+
+// LoadSheddingConfig from YAML
+type LoadSheddingConfig struct {
+	Enabled         bool               `mapstructure:"enabled"`
+	MaxInFlight     int                `mapstructure:"max_in_flight"`     // 满负载时的并发请求数
+	TargetLatencyMs int                `mapstructure:"target_latency_ms"` // 满负载时的平均请求耗时
+	ShedAt          map[string]float64 `mapstructure:"shed_at"`           // 各优先级开始拒绝请求的负载比例
+	Routes          map[string]string  `mapstructure:"routes"`            // 路由到优先级，未列出的为 normal
+}
+
+// ChaosConfig from YAML, dependency failures injected outside production to
+// verify the fallback paths, rates are fractions between 0 and 1
+type ChaosConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	RedisLatencyMs    int     `mapstructure:"redis_latency_ms"`    // 增加到 redis 命令的延迟
+	RedisLatencyRate  float64 `mapstructure:"redis_latency_rate"`  // 增加延迟的 redis 命令比例
+	DBErrorRate       float64 `mapstructure:"db_error_rate"`       // 返回错误的数据库语句比例
+	SchedulerDropRate float64 `mapstructure:"scheduler_drop_rate"` // 丢弃的调度队列消息比例
+}
+
+// I18nConfig from YAML, language of the error messages in API responses
+type I18nConfig struct {
+	DefaultLanguage string `mapstructure:"default_language"` // 请求未携带可用的 Accept-Language 时使用的语言
+	CatalogDir      string `mapstructure:"catalog_dir"`      // 部署自定义的消息目录，文件名为语言标签，如 zh.yaml
+}
+
+// PayloadLimitsConfig from YAML, limits of the JSON payloads stored with
+// history records: workflow input and result, action input and output, device
+// event data. Zero values use the defaults
+type PayloadLimitsConfig struct {
+	MaxBytes     int    `mapstructure:"max_bytes"`     // 单个负载的最大字节数
+	MaxDepth     int    `mapstructure:"max_depth"`     // 对象和数组的最大嵌套层数
+	Action       string `mapstructure:"action"`        // 超过限制时 reject 拒绝写入，truncate 截断，artifact 转存为结果文件
+	PreviewBytes int    `mapstructure:"preview_bytes"` // 截断时保留的前缀字节数
+}
+
+// CompactionConfig from YAML, archiving the input and output of old actions
+// to result files and keeping summaries in the records
+type CompactionConfig struct {
+	AfterDays     int `mapstructure:"after_days"`     // 创建超过该天数的动作参与压缩，0 表示不压缩
+	MinBytes      int `mapstructure:"min_bytes"`      // 输入和输出合计超过该字节数才压缩
+	BatchSize     int `mapstructure:"batch_size"`     // 每批读取的动作数
+	SummaryFields int `mapstructure:"summary_fields"` // 摘要保留的顶层简单字段数
+}
+
+// ZstdJSONConfig from YAML, zstd compression of the large history
+// payloads stored in jsonb columns
+type ZstdJSONConfig struct {
+	ThresholdBytes    int `mapstructure:"threshold_bytes"`     // 超过该字节数的负载压缩存储，0 表示不压缩
+	BackfillBatchSize int `mapstructure:"backfill_batch_size"` // 压缩已有记录时每批读取的行数
+}
+
+// CDCConfig from YAML
+type CDCConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	SlotName     string `mapstructure:"slot_name"`     // 逻辑复制槽名称，关闭时删除该槽
+	BatchSize    int    `mapstructure:"batch_size"`    // 每次从复制槽读取的变更数，按整个事务读取
+	MaxLagBytes  int64  `mapstructure:"max_lag_bytes"` // 复制槽积压的 WAL 超过该字节数时告警，0 表示不告警
+	IntervalSecs int    `mapstructure:"interval_secs"` // 读取复制槽的间隔
+}
+
+// ListStreamingConfig from YAML
+type ListStreamingConfig struct {
+	MaxRows         int `mapstructure:"max_rows"`          // 单次流式列表最多返回的行数，超过时截断
+	FlushRows       int `mapstructure:"flush_rows"`        // 累计写出该行数后刷新到客户端
+	FlushIntervalMs int `mapstructure:"flush_interval_ms"` // 距上次刷新超过该时间后刷新到客户端
+	MaxDurationSecs int `mapstructure:"max_duration_secs"` // 单次流式列表的最长耗时
+}
+
+// WSHubConfig from YAML, limits of the websocket hub
+type WSHubConfig struct {
+	MaxConnectionsPerUser  int   `mapstructure:"max_connections_per_user"`  // 每个用户在单个实例上的连接数
+	MaxTopicsPerConnection int   `mapstructure:"max_topics_per_connection"` // 单个连接订阅的主题数
+	MaxMessageBytes        int64 `mapstructure:"max_message_bytes"`         // 客户端发送的单条消息大小
+	SendBufferSize         int   `mapstructure:"send_buffer_size"`          // 单个连接待发送的消息数，超过时丢弃新消息
+}
+
+// ReplayConfig from YAML, messages kept for subscribers resuming after a reconnect
+type ReplayConfig struct {
+	RetentionSecs int   `mapstructure:"retention_secs"` // 断线重连后可补发的时间范围
+	MaxLen        int64 `mapstructure:"max_len"`        // 单个主题保留的消息数
+}
+
+// EdgeEventsConfig from YAML, devices reporting events with device API keys
+type EdgeEventsConfig struct {
+	MaxBatch          int   `mapstructure:"max_batch"`           // 单次上报的事件数
+	MaxBodyBytes      int64 `mapstructure:"max_body_bytes"`      // 解压后的请求体大小
+	RequestsPerMinute int   `mapstructure:"requests_per_minute"` // 每个设备密钥每分钟的上报次数
+}
+
+// APIVersioningConfig from YAML
+type APIVersioningConfig struct {
+	V1Sunset string `mapstructure:"v1_sunset"` // 已有 v2 的 v1 接口下线日期 (YYYY-MM-DD)，为空时不返回 Sunset
+}
+
+// MaintenanceConfig from YAML, puts the whole service in read-only
+// maintenance regardless of the windows started through the admin API
+type MaintenanceConfig struct {
+	ReadOnly bool   `mapstructure:"read_only"`
+	Message  string `mapstructure:"message"`
+	ETA      string `mapstructure:"eta"` // 预计结束时间 (RFC 3339)，为空时未知
+}
+
+// AssistantConfig from YAML, the LLM provider of the ai_assistant feature
+type AssistantConfig struct {
+	Provider        string `mapstructure:"provider"` // openai（包括兼容接口）| anthropic
+	BaseURL         string `mapstructure:"base_url"`
+	APIKey          string `mapstructure:"api_key"`
+	Model           string `mapstructure:"model"`
+	MaxTokens       int    `mapstructure:"max_tokens"`
+	TimeoutSeconds  int    `mapstructure:"timeout_seconds"`
+	RequestsPerHour int    `mapstructure:"requests_per_hour"` // 每个用户每小时调用模型的次数，诊断和历史查询共用
+}
+
+// DeviceRiskConfig from YAML, the device maintenance risk scoring job
+type DeviceRiskConfig struct {
+	IntervalMinutes int     `mapstructure:"interval_minutes"`
+	WindowDays      int     `mapstructure:"window_days"`   // 评分使用最近几天的历史
+	BaselineDays    int     `mapstructure:"baseline_days"` // 评分窗口之前作为基线的天数
+	WarningScore    float64 `mapstructure:"warning_score"` // 达到该分数时产生 warning 告警
+	CriticalScore   float64 `mapstructure:"critical_score"`
+	RetentionDays   int     `mapstructure:"retention_days"` // 评分历史保留天数
+}
+
+// HistoryChainConfig from YAML, the tamper-evident hash chain of finished
+// executions and audit log entries
+type HistoryChainConfig struct {
+	SealIntervalSeconds   int    `mapstructure:"seal_interval_seconds"`   // 将新记录加入哈希链的间隔
+	AnchorIntervalMinutes int    `mapstructure:"anchor_interval_minutes"` // 发布链头锚点的间隔
+	VerifyIntervalHours   int    `mapstructure:"verify_interval_hours"`   // 全量校验所有实验室哈希链的间隔
+	AnchorURL             string `mapstructure:"anchor_url"`              // 锚点以 JSON POST 到该地址，为空时只记录在本地
+	AnchorToken           string `mapstructure:"anchor_token"`            // 发布锚点时的 Bearer token
 }
 
 // SecurityConfig from YAML
 type SecurityConfig struct {
-	Validation ValidationConfig `mapstructure:"validation"`
-	CORS       CORSConfig       `mapstructure:"cors"`
+	Validation    ValidationConfig    `mapstructure:"validation"`
+	CORS          CORSConfig          `mapstructure:"cors"`
+	Impersonation ImpersonationConfig `mapstructure:"impersonation"`
+}
+
+// ImpersonationConfig from YAML
+type ImpersonationConfig struct {
+	AdminUserIDs      []string `mapstructure:"admin_user_ids"` // 可以模拟用户的管理员，为空时禁用模拟
+	DefaultTTLMinutes int      `mapstructure:"default_ttl_minutes"`
+	MaxTTLMinutes     int      `mapstructure:"max_ttl_minutes"`
 }
 
 // ValidationConfig from YAML
@@ -247,6 +484,24 @@ func defaultStudioConfig() *StudioConfig {
 				Format: "json",
 			},
 		},
+		Compression: CompressionConfig{
+			Enabled:      true,
+			MinSizeBytes: 1024,
+			Encodings:    []string{"zstd", "gzip"},
+		},
+		LoadShedding: LoadSheddingConfig{
+			Enabled:         true,
+			MaxInFlight:     512,
+			TargetLatencyMs: 2000,
+			ShedAt:          map[string]float64{"low": 0.6, "normal": 0.9},
+			Routes:          map[string]string{"/api/health": "critical"},
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:           100,
+			MaxIdleConns:           10,
+			ConnMaxLifetimeSeconds: 3600,
+			ConnMaxIdleTimeSeconds: 600,
+		},
 	}
 }
 
@@ -49,6 +49,16 @@ func TestDefaultStudioConfigObservability(t *testing.T) {
 	assert.Equal(t, "json", cfg.Observability.Logging.Format)
 }
 
+func TestDefaultStudioConfigDatabase(t *testing.T) {
+	cfg := defaultStudioConfig()
+
+	assert.False(t, cfg.Database.PrepareStmt)
+	assert.Equal(t, 100, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 10, cfg.Database.MaxIdleConns)
+	assert.Equal(t, 3600, cfg.Database.ConnMaxLifetimeSeconds)
+	assert.Equal(t, 0, cfg.Database.StatementTimeoutMs)
+}
+
 func TestGetStudioConfigWithoutLoad(t *testing.T) {
 	// Reset for test
 	oldConfig := studioConfig
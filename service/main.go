@@ -41,6 +41,8 @@ func main() {
 	root.SetContext(rootCtx)
 	root.AddCommand(api.NewWeb())
 	root.AddCommand(api.NewMigrate())
+	root.AddCommand(api.NewHistory())
+	root.AddCommand(api.NewLoadTest())
 	root.AddCommand(schedule.New())
 
 	if err := root.Execute(); err != nil {
@@ -89,6 +89,8 @@ func _() {
 	_ = x[WorkflowNodeNotFoundErr-28006]
 	_ = x[CanNotGetworkflowErr-28007]
 	_ = x[FormatCSVTaskErr-28008]
+	_ = x[WorkflowInputSchemaInvalidErr-28009]
+	_ = x[WorkflowInputInvalidErr-28010]
 	_ = x[WorkflowTaskAlreadyExistErr-30000]
 	_ = x[CanNotFoundEdgeSession-30001]
 	_ = x[WorkflowHasCircularErr-30002]
@@ -124,66 +126,304 @@ func _() {
 	_ = x[UnknownWorkflowNodeTypeErr-30032]
 	_ = x[ExecWorkflowNodeScriptErr-30033]
 	_ = x[EdgeNotStartedErr-30034]
+	_ = x[ApprovalAlreadyDecidedErr-32000]
+	_ = x[ApprovalRejectedErr-32001]
+	_ = x[ApprovalStatusInvalidErr-32002]
+	_ = x[DeviceLockErr-32003]
+	_ = x[DeviceLockWaitTimeoutErr-32004]
+	_ = x[DeviceLockNotHeldErr-32005]
+	_ = x[ReservationTimeInvalidErr-32006]
+	_ = x[ReservationConflictErr-32007]
+	_ = x[DeviceReservedErr-32008]
+	_ = x[TaskDependencyInvalidErr-32009]
+	_ = x[DeadLetterNotDeadErr-32010]
+	_ = x[ApprovalSelfDecideErr-32011]
+	_ = x[JobScheduleInvalidErr-34000]
+	_ = x[JobAlreadyRegisteredErr-34001]
+	_ = x[JobNotRegisteredErr-34002]
+	_ = x[DeviceCapabilityInvalidErr-36000]
+	_ = x[DeviceActionNotSupportedErr-36001]
+	_ = x[DeviceActionParamInvalidErr-36002]
+	_ = x[DeviceCommandStateErr-36003]
+	_ = x[DeviceLimitExceededErr-36004]
+	_ = x[DeviceGroupNoTargetErr-36005]
+	_ = x[RuleInvalidErr-38000]
+	_ = x[AlertStatusErr-38001]
+	_ = x[AlertSilenceErr-38002]
+	_ = x[CameraFeatureDisabledErr-40000]
+	_ = x[StreamTokenInvalidErr-40001]
+	_ = x[StreamSessionEndedErr-40002]
+	_ = x[FileUploadOffsetErr-42000]
+	_ = x[FileUploadStatusErr-42001]
+	_ = x[FileTooLargeErr-42002]
+	_ = x[FileContentRejectedErr-42003]
+	_ = x[FileStorageErr-42004]
+	_ = x[FileURLInvalidErr-42005]
+	_ = x[MaterialInvalidErr-44000]
+	_ = x[MaterialInsufficientErr-44001]
+	_ = x[MaterialSyncDisabledErr-44002]
+	_ = x[MaterialSyncRunningErr-44003]
+	_ = x[SavedViewExistErr-46000]
+	_ = x[SavedViewTargetErr-46001]
+	_ = x[ReportChannelDisabledErr-47000]
+	_ = x[ReportDeliveryMissingErr-47001]
+	_ = x[AnnotationDeletedErr-48000]
+	_ = x[AnnotationMentionErr-48001]
+	_ = x[LabExportRunningErr-49000]
+	_ = x[LabExportExpiredErr-49001]
+	_ = x[LabPurgeConfirmErr-49002]
+	_ = x[HistoryImportRunningErr-50000]
+	_ = x[HistoryImportFormatErr-50001]
+	_ = x[HistoryImportTooLargeErr-50002]
+	_ = x[HistoryExportKindErr-51000]
+	_ = x[HistoryExportExpiredErr-51001]
+	_ = x[EventSchemaNotFoundErr-52000]
+	_ = x[EventSchemaViolationErr-52001]
+	_ = x[ImpersonationForbiddenErr-53000]
+	_ = x[ImpersonationScopeErr-53001]
+	_ = x[ImpersonationTargetErr-53002]
+	_ = x[MaintenanceReadOnlyErr-54000]
+	_ = x[ErrorRulePatternErr-55000]
+	_ = x[AssistantDisabledErr-56000]
+	_ = x[AssistantRateLimitErr-56001]
+	_ = x[AssistantProviderErr-56002]
+	_ = x[UpgradeCampaignStatusErr-57000]
+	_ = x[UpgradeNoTargetErr-57001]
+	_ = x[DeviceConfigExistErr-58000]
+	_ = x[DeviceConfigInvalidErr-58001]
+	_ = x[DeviceConfigNoTargetErr-58002]
+	_ = x[TopologyNodeExistErr-59000]
+	_ = x[TopologyParentInvalidErr-59001]
+	_ = x[TopologyNodeNotEmptyErr-59002]
+	_ = x[TopologyDeviceInvalidErr-59003]
+	_ = x[ExperimentExistErr-60000]
+	_ = x[ExperimentClosedErr-60001]
+	_ = x[LegalHoldInvalidErr-61000]
+	_ = x[LegalHoldReleasedErr-61001]
+	_ = x[HistoryCopyForbiddenErr-62000]
+	_ = x[HistoryCopySourceErr-62001]
+	_ = x[SeedForbiddenErr-63000]
+	_ = x[ChaosForbiddenErr-64000]
+	_ = x[PayloadTooLargeErr-65000]
+	_ = x[HubConnectionLimitErr-66000]
+	_ = x[HubTopicInvalidErr-66001]
+	_ = x[HubTopicLimitErr-66002]
+	_ = x[HubResumeTokenErr-66003]
+	_ = x[HistoryVersionConflictErr-67000]
+	_ = x[DeviceKeyRateLimitErr-68000]
 }
 
-const (
-	_ErrCode_name_0 = "successundefinedno permissioninvalidate jwt"
-	_ErrCode_name_1 = "parse parameter errornot pointer errmust be a pointer to a slicepointer is nil error"
-	_ErrCode_name_2 = "login configuration errorset login state errorrefresh token failedstate verification failedexchange token failedcallback parameter errorget user info failedlogin process user info failednot logged inlogin verification format errorinvalid tokenrefresh token parameter errorredirect login url error"
-	_ErrCode_name_3 = "database create data errordatabase update data errordatabase record not founddatabase query errordatabase delete errornot base db type errormodel not implement schema.Tablerredis lua script errorredis lua return type errorredis add user set errorredis remove user set error"
-	_ErrCode_name_4 = "reg action name emptyresource is emptyresource not existcan not found workflow template erroruser id is emptylab id is empty errorlaboratory not found errorcan not found laboratory invite link errorinvite expired errorinvalidate third id errorlab already deleted error"
-	_ErrCode_name_5 = "resource not existedge node not existnode handle not existunknown material websocket actionunmarshal material websocket data errorcannot get lab id errorupdate material node errorparent node not found errortemplate node not found errorinvalid dag errormax template node deep errorcan not found material node errormachine already exist errorquery machine status errormachine not exist errormachine reach max number errormachine is stoppingstart machine unknown errorcan not found target node errorpath has empty name error"
-	_ErrCode_name_6 = "notify action already registrynotify subscribe channel failnotify send message error"
-	_ErrCode_name_7 = "rpc request http errorrpc request http code errorrpc request http code resp errorcreate lab user errorquery lab user errorbhor batch query user error"
-	_ErrCode_name_8 = "can not get workflow uuidworkflow not existupsert workflow edge errorpermission deniedbatch save nodes errorbatch save workflow edge errorworkflow node not found errorworkflow not found errorformat csv data error"
-	_ErrCode_name_9 = "workflow task already exist errorcan not found edge sessionworkflow has circular errorconnect closed when node running errormarshal node data errorjob run fail errorcan not found workflow task errorworkflow task status errorworkflow task finishedworkflow node no device name errorworkflow node no action name errorworkflow node no action type errorquery job status key note exists errorcallback job status key note exists errorjob timeout errorjob retry timeout errorcallback job status timeout errorjob is canceledcan not get workflow task errorworkflow task not in pending statuscan not found workflow handle errorcan not found parent node job errorparam data key invalidate errorparam data value invalidate errordata not map any type errorvalue slice out index errorvalue not exist errorset lab heart errortarget data not map any type errormarshal target data errortarget param invalidate errorworkflow script empty errorunknown workflow node type errorexec workflow script erroredge not started error"
-)
+const _ErrCode_name = "successundefinedno permissioninvalidate jwtparse parameter errornot pointer errmust be a pointer to a slicepointer is nil errorlogin configuration errorset login state errorrefresh token failedstate verification failedexchange token failedcallback parameter errorget user info failedlogin process user info failednot logged inlogin verification format errorinvalid tokenrefresh token parameter errorredirect login url errordatabase create data errordatabase update data errordatabase record not founddatabase query errordatabase delete errornot base db type errormodel not implement schema.Tablerredis lua script errorredis lua return type errorredis add user set errorredis remove user set errorreg action name emptyresource is emptyresource not existcan not found workflow template erroruser id is emptylab id is empty errorlaboratory not found errorcan not found laboratory invite link errorinvite expired errorinvalidate third id errorlab already deleted errorresource not existedge node not existnode handle not existunknown material websocket actionunmarshal material websocket data errorcannot get lab id errorupdate material node errorparent node not found errortemplate node not found errorinvalid dag errormax template node deep errorcan not found material node errormachine already exist errorquery machine status errormachine not exist errormachine reach max number errormachine is stoppingstart machine unknown errorcan not found target node errorpath has empty name errornotify action already registrynotify subscribe channel failnotify send message errorrpc request http errorrpc request http code errorrpc request http code resp errorcreate lab user errorquery lab user errorbhor batch query user errorcan not get workflow uuidworkflow not existupsert workflow edge errorpermission deniedbatch save nodes errorbatch save workflow edge errorworkflow node not found errorworkflow not found errorformat csv data errorworkflow input schema invalidworkflow input invalidworkflow task already exist errorcan not found edge sessionworkflow has circular errorconnect closed when node running errormarshal node data errorjob run fail errorcan not found workflow task errorworkflow task status errorworkflow task finishedworkflow node no device name errorworkflow node no action name errorworkflow node no action type errorquery job status key note exists errorcallback job status key note exists errorjob timeout errorjob retry timeout errorcallback job status timeout errorjob is canceledcan not get workflow task errorworkflow task not in pending statuscan not found workflow handle errorcan not found parent node job errorparam data key invalidate errorparam data value invalidate errordata not map any type errorvalue slice out index errorvalue not exist errorset lab heart errortarget data not map any type errormarshal target data errortarget param invalidate errorworkflow script empty errorunknown workflow node type errorexec workflow script erroredge not started errorapproval request already decidedapproval request rejectedapproval status invaliddevice lock errorwait device lock timeoutdevice lock not heldreservation time range invalidreservation conflicts with an existing onedevice is reserved by another usertask dependency invaliddead letter job already requeuedapproval cannot be decided by the user who started the stepbackground job schedule invalidbackground job already registeredbackground job not registereddevice capability descriptor invaliddevice does not declare the actiondevice action parameters invaliddevice command not in expected statelab device limit exceededno device of the group can receive the commanddevice rule invalidalert not in expected statealert silence invalidrealtime camera feature disabledstream viewing token invalid or expiredstream session already endedupload offset does not match received sizefile not in expected upload statefile exceeds size limitfile content rejected by validationfile storage errorfile download url invalid or expiredmaterial lot invalidmaterial remaining quantity insufficientmaterial lims sync not enabledmaterial sync already running for the labsaved view name already existssaved view does not apply to this listdelivery channel is not configureddelivery has no stored reportannotation has been deletedmentioned user is not a lab memberlab export already runninglab export archive expired or not readylab data deletion confirmation invalidhistory import already running for the labhistory import file format or table not supportedhistory import file too largehistory export kind or filters invalidhistory export file expired or not readyevent type or schema version not foundevent payload violates the published schemaendpoint does not allow impersonated sessionsimpersonated session cannot access other laboratoriesimpersonation target must be a lab member who is not an adminservice is in read-only maintenanceerror rule pattern is not a valid regular expressionassistant is disabled or no model provider is configuredassistant request limit of the user is exceededassistant model provider request failedupgrade campaign status does not allow the operationno device matches the upgrade campaign filtersdevice config profile with the same name already existsdevice config must be a JSON objectno device has the config assignedtopology node with the same name already exists under the parenttopology node type is not allowed under the parenttopology node still has child nodesdevice does not exist in the labexperiment with the same name already existsarchived experiment does not accept executionslegal hold target is missing or does not match the scopelegal hold is already releasedhistory copy is not allowed in this environmenthistory copy source environment request faileddemo seeding is not allowed in this environmentfailure injection is not allowed in this environmentpayload exceeds the size or depth limittoo many websocket connections of the userwebsocket topic is not validtoo many topics subscribed on the connectionresume token of the replay buffer is not validexecution history was updated by another writerevent reporting limit of the device key is exceeded"
 
-var (
-	_ErrCode_index_0 = [...]uint8{0, 7, 16, 29, 43}
-	_ErrCode_index_1 = [...]uint8{0, 21, 36, 64, 84}
-	_ErrCode_index_2 = [...]uint16{0, 25, 46, 66, 91, 112, 136, 156, 186, 199, 230, 243, 272, 296}
-	_ErrCode_index_3 = [...]uint16{0, 26, 52, 77, 97, 118, 140, 173, 195, 222, 246, 273}
-	_ErrCode_index_4 = [...]uint16{0, 21, 38, 56, 93, 109, 130, 156, 198, 218, 243, 268}
-	_ErrCode_index_5 = [...]uint16{0, 18, 37, 58, 91, 130, 153, 179, 206, 235, 252, 280, 313, 340, 366, 389, 419, 438, 465, 496, 521}
-	_ErrCode_index_6 = [...]uint8{0, 30, 59, 84}
-	_ErrCode_index_7 = [...]uint8{0, 22, 49, 81, 102, 122, 149}
-	_ErrCode_index_8 = [...]uint8{0, 25, 43, 69, 86, 108, 138, 167, 191, 212}
-	_ErrCode_index_9 = [...]uint16{0, 33, 59, 86, 124, 147, 165, 198, 224, 246, 280, 314, 348, 386, 427, 444, 467, 500, 515, 546, 581, 616, 651, 682, 715, 742, 769, 790, 809, 843, 868, 897, 924, 956, 982, 1004}
-)
+var _ErrCode_map = map[ErrCode]string{
+	0:     _ErrCode_name[0:7],
+	1:     _ErrCode_name[7:16],
+	2:     _ErrCode_name[16:29],
+	3:     _ErrCode_name[29:43],
+	1000:  _ErrCode_name[43:64],
+	1001:  _ErrCode_name[64:79],
+	1002:  _ErrCode_name[79:107],
+	1003:  _ErrCode_name[107:127],
+	5000:  _ErrCode_name[127:152],
+	5001:  _ErrCode_name[152:173],
+	5002:  _ErrCode_name[173:193],
+	5003:  _ErrCode_name[193:218],
+	5004:  _ErrCode_name[218:239],
+	5005:  _ErrCode_name[239:263],
+	5006:  _ErrCode_name[263:283],
+	5007:  _ErrCode_name[283:313],
+	5008:  _ErrCode_name[313:326],
+	5009:  _ErrCode_name[326:357],
+	5010:  _ErrCode_name[357:370],
+	5011:  _ErrCode_name[370:399],
+	5012:  _ErrCode_name[399:423],
+	10000: _ErrCode_name[423:449],
+	10001: _ErrCode_name[449:475],
+	10002: _ErrCode_name[475:500],
+	10003: _ErrCode_name[500:520],
+	10004: _ErrCode_name[520:541],
+	10005: _ErrCode_name[541:563],
+	10006: _ErrCode_name[563:596],
+	10007: _ErrCode_name[596:618],
+	10008: _ErrCode_name[618:645],
+	10009: _ErrCode_name[645:669],
+	10010: _ErrCode_name[669:696],
+	20000: _ErrCode_name[696:717],
+	20001: _ErrCode_name[717:734],
+	20002: _ErrCode_name[734:752],
+	20003: _ErrCode_name[752:789],
+	20004: _ErrCode_name[789:805],
+	20005: _ErrCode_name[805:826],
+	20006: _ErrCode_name[826:852],
+	20007: _ErrCode_name[852:894],
+	20008: _ErrCode_name[894:914],
+	20009: _ErrCode_name[914:939],
+	20010: _ErrCode_name[939:964],
+	22000: _ErrCode_name[964:982],
+	22001: _ErrCode_name[982:1001],
+	22002: _ErrCode_name[1001:1022],
+	22003: _ErrCode_name[1022:1055],
+	22004: _ErrCode_name[1055:1094],
+	22005: _ErrCode_name[1094:1117],
+	22006: _ErrCode_name[1117:1143],
+	22007: _ErrCode_name[1143:1170],
+	22008: _ErrCode_name[1170:1199],
+	22009: _ErrCode_name[1199:1216],
+	22010: _ErrCode_name[1216:1244],
+	22011: _ErrCode_name[1244:1277],
+	22012: _ErrCode_name[1277:1304],
+	22013: _ErrCode_name[1304:1330],
+	22014: _ErrCode_name[1330:1353],
+	22015: _ErrCode_name[1353:1383],
+	22016: _ErrCode_name[1383:1402],
+	22017: _ErrCode_name[1402:1429],
+	22018: _ErrCode_name[1429:1460],
+	22019: _ErrCode_name[1460:1485],
+	24000: _ErrCode_name[1485:1515],
+	24001: _ErrCode_name[1515:1544],
+	24002: _ErrCode_name[1544:1569],
+	26000: _ErrCode_name[1569:1591],
+	26001: _ErrCode_name[1591:1618],
+	26002: _ErrCode_name[1618:1650],
+	26003: _ErrCode_name[1650:1671],
+	26004: _ErrCode_name[1671:1691],
+	26005: _ErrCode_name[1691:1718],
+	28000: _ErrCode_name[1718:1743],
+	28001: _ErrCode_name[1743:1761],
+	28002: _ErrCode_name[1761:1787],
+	28003: _ErrCode_name[1787:1804],
+	28004: _ErrCode_name[1804:1826],
+	28005: _ErrCode_name[1826:1856],
+	28006: _ErrCode_name[1856:1885],
+	28007: _ErrCode_name[1885:1909],
+	28008: _ErrCode_name[1909:1930],
+	28009: _ErrCode_name[1930:1959],
+	28010: _ErrCode_name[1959:1981],
+	30000: _ErrCode_name[1981:2014],
+	30001: _ErrCode_name[2014:2040],
+	30002: _ErrCode_name[2040:2067],
+	30003: _ErrCode_name[2067:2105],
+	30004: _ErrCode_name[2105:2128],
+	30005: _ErrCode_name[2128:2146],
+	30006: _ErrCode_name[2146:2179],
+	30007: _ErrCode_name[2179:2205],
+	30008: _ErrCode_name[2205:2227],
+	30009: _ErrCode_name[2227:2261],
+	30010: _ErrCode_name[2261:2295],
+	30011: _ErrCode_name[2295:2329],
+	30012: _ErrCode_name[2329:2367],
+	30013: _ErrCode_name[2367:2408],
+	30014: _ErrCode_name[2408:2425],
+	30015: _ErrCode_name[2425:2448],
+	30016: _ErrCode_name[2448:2481],
+	30017: _ErrCode_name[2481:2496],
+	30018: _ErrCode_name[2496:2527],
+	30019: _ErrCode_name[2527:2562],
+	30020: _ErrCode_name[2562:2597],
+	30021: _ErrCode_name[2597:2632],
+	30022: _ErrCode_name[2632:2663],
+	30023: _ErrCode_name[2663:2696],
+	30024: _ErrCode_name[2696:2723],
+	30025: _ErrCode_name[2723:2750],
+	30026: _ErrCode_name[2750:2771],
+	30027: _ErrCode_name[2771:2790],
+	30028: _ErrCode_name[2790:2824],
+	30029: _ErrCode_name[2824:2849],
+	30030: _ErrCode_name[2849:2878],
+	30031: _ErrCode_name[2878:2905],
+	30032: _ErrCode_name[2905:2937],
+	30033: _ErrCode_name[2937:2963],
+	30034: _ErrCode_name[2963:2985],
+	32000: _ErrCode_name[2985:3017],
+	32001: _ErrCode_name[3017:3042],
+	32002: _ErrCode_name[3042:3065],
+	32003: _ErrCode_name[3065:3082],
+	32004: _ErrCode_name[3082:3106],
+	32005: _ErrCode_name[3106:3126],
+	32006: _ErrCode_name[3126:3156],
+	32007: _ErrCode_name[3156:3198],
+	32008: _ErrCode_name[3198:3232],
+	32009: _ErrCode_name[3232:3255],
+	32010: _ErrCode_name[3255:3287],
+	32011: _ErrCode_name[3287:3346],
+	34000: _ErrCode_name[3346:3377],
+	34001: _ErrCode_name[3377:3410],
+	34002: _ErrCode_name[3410:3439],
+	36000: _ErrCode_name[3439:3475],
+	36001: _ErrCode_name[3475:3509],
+	36002: _ErrCode_name[3509:3541],
+	36003: _ErrCode_name[3541:3577],
+	36004: _ErrCode_name[3577:3602],
+	36005: _ErrCode_name[3602:3648],
+	38000: _ErrCode_name[3648:3667],
+	38001: _ErrCode_name[3667:3694],
+	38002: _ErrCode_name[3694:3715],
+	40000: _ErrCode_name[3715:3747],
+	40001: _ErrCode_name[3747:3786],
+	40002: _ErrCode_name[3786:3814],
+	42000: _ErrCode_name[3814:3856],
+	42001: _ErrCode_name[3856:3889],
+	42002: _ErrCode_name[3889:3912],
+	42003: _ErrCode_name[3912:3947],
+	42004: _ErrCode_name[3947:3965],
+	42005: _ErrCode_name[3965:4001],
+	44000: _ErrCode_name[4001:4021],
+	44001: _ErrCode_name[4021:4061],
+	44002: _ErrCode_name[4061:4091],
+	44003: _ErrCode_name[4091:4132],
+	46000: _ErrCode_name[4132:4162],
+	46001: _ErrCode_name[4162:4200],
+	47000: _ErrCode_name[4200:4234],
+	47001: _ErrCode_name[4234:4263],
+	48000: _ErrCode_name[4263:4290],
+	48001: _ErrCode_name[4290:4324],
+	49000: _ErrCode_name[4324:4350],
+	49001: _ErrCode_name[4350:4389],
+	49002: _ErrCode_name[4389:4427],
+	50000: _ErrCode_name[4427:4469],
+	50001: _ErrCode_name[4469:4518],
+	50002: _ErrCode_name[4518:4547],
+	51000: _ErrCode_name[4547:4585],
+	51001: _ErrCode_name[4585:4625],
+	52000: _ErrCode_name[4625:4663],
+	52001: _ErrCode_name[4663:4706],
+	53000: _ErrCode_name[4706:4751],
+	53001: _ErrCode_name[4751:4804],
+	53002: _ErrCode_name[4804:4865],
+	54000: _ErrCode_name[4865:4900],
+	55000: _ErrCode_name[4900:4952],
+	56000: _ErrCode_name[4952:5008],
+	56001: _ErrCode_name[5008:5055],
+	56002: _ErrCode_name[5055:5094],
+	57000: _ErrCode_name[5094:5146],
+	57001: _ErrCode_name[5146:5192],
+	58000: _ErrCode_name[5192:5247],
+	58001: _ErrCode_name[5247:5282],
+	58002: _ErrCode_name[5282:5315],
+	59000: _ErrCode_name[5315:5379],
+	59001: _ErrCode_name[5379:5429],
+	59002: _ErrCode_name[5429:5464],
+	59003: _ErrCode_name[5464:5496],
+	60000: _ErrCode_name[5496:5540],
+	60001: _ErrCode_name[5540:5586],
+	61000: _ErrCode_name[5586:5642],
+	61001: _ErrCode_name[5642:5672],
+	62000: _ErrCode_name[5672:5719],
+	62001: _ErrCode_name[5719:5765],
+	63000: _ErrCode_name[5765:5812],
+	64000: _ErrCode_name[5812:5864],
+	65000: _ErrCode_name[5864:5903],
+	66000: _ErrCode_name[5903:5945],
+	66001: _ErrCode_name[5945:5973],
+	66002: _ErrCode_name[5973:6017],
+	66003: _ErrCode_name[6017:6063],
+	67000: _ErrCode_name[6063:6110],
+	68000: _ErrCode_name[6110:6161],
+}
 
 func (i ErrCode) String() string {
-	switch {
-	case 0 <= i && i <= 3:
-		return _ErrCode_name_0[_ErrCode_index_0[i]:_ErrCode_index_0[i+1]]
-	case 1000 <= i && i <= 1003:
-		i -= 1000
-		return _ErrCode_name_1[_ErrCode_index_1[i]:_ErrCode_index_1[i+1]]
-	case 5000 <= i && i <= 5012:
-		i -= 5000
-		return _ErrCode_name_2[_ErrCode_index_2[i]:_ErrCode_index_2[i+1]]
-	case 10000 <= i && i <= 10010:
-		i -= 10000
-		return _ErrCode_name_3[_ErrCode_index_3[i]:_ErrCode_index_3[i+1]]
-	case 20000 <= i && i <= 20010:
-		i -= 20000
-		return _ErrCode_name_4[_ErrCode_index_4[i]:_ErrCode_index_4[i+1]]
-	case 22000 <= i && i <= 22019:
-		i -= 22000
-		return _ErrCode_name_5[_ErrCode_index_5[i]:_ErrCode_index_5[i+1]]
-	case 24000 <= i && i <= 24002:
-		i -= 24000
-		return _ErrCode_name_6[_ErrCode_index_6[i]:_ErrCode_index_6[i+1]]
-	case 26000 <= i && i <= 26005:
-		i -= 26000
-		return _ErrCode_name_7[_ErrCode_index_7[i]:_ErrCode_index_7[i+1]]
-	case 28000 <= i && i <= 28008:
-		i -= 28000
-		return _ErrCode_name_8[_ErrCode_index_8[i]:_ErrCode_index_8[i+1]]
-	case 30000 <= i && i <= 30034:
-		i -= 30000
-		return _ErrCode_name_9[_ErrCode_index_9[i]:_ErrCode_index_9[i+1]]
-	default:
-		return "ErrCode(" + strconv.FormatInt(int64(i), 10) + ")"
+	if str, ok := _ErrCode_map[i]; ok {
+		return str
 	}
+	return "ErrCode(" + strconv.FormatInt(int64(i), 10) + ")"
 }
@@ -13,6 +13,7 @@ type ErrCode int
 type ErrCodeWithMsg struct {
 	ErrCode
 	msgs []string
+	errs []error
 }
 
 func (e ErrCodeWithMsg) String() string {
@@ -23,6 +24,11 @@ func (e ErrCodeWithMsg) Msgs() string {
 	return strings.Join(e.msgs, "\t\t\t")
 }
 
+// Unwrap 返回 WithErr 包装的原始错误，用于翻译参数校验错误
+func (e ErrCodeWithMsg) Unwrap() []error {
+	return e.errs
+}
+
 // const (
 // 	codeSplit = " &_&_& "
 // )
@@ -35,12 +41,26 @@ func (e ErrCode) WithMsgf(format string, msgs ...any) error {
 	return ErrCodeWithMsg{ErrCode: e, msgs: []string{fmt.Sprintf(format, msgs...)}}
 }
 
+// ErrCodeWithDetail 携带结构化错误详情，例如字段校验错误
+type ErrCodeWithDetail struct {
+	ErrCode
+	detail any
+}
+
+func (e ErrCodeWithDetail) Detail() any {
+	return e.detail
+}
+
+func (e ErrCode) WithDetail(detail any) error {
+	return ErrCodeWithDetail{ErrCode: e, detail: detail}
+}
+
 func (e ErrCode) WithErr(errs ...error) error {
 	msgs := make([]string, 0, len(errs))
 	for _, e := range errs {
 		msgs = append(msgs, e.Error())
 	}
-	return ErrCodeWithMsg{ErrCode: e, msgs: msgs}
+	return ErrCodeWithMsg{ErrCode: e, msgs: msgs, errs: errs}
 }
 
 func (e ErrCode) Int() int {
@@ -156,15 +176,17 @@ const (
 
 // workflow module errors
 const (
-	CanNotGetWorkflowUUIDErr ErrCode = iota + 28000 // can not get workflow uuid
-	WorkflowNotExistErr                             // workflow not exist
-	UpsertWorkflowEdgeErr                           // upsert workflow edge error
-	PermissionDenied                                // permission denied
-	SaveWorkflowNodeErr                             // batch save nodes error
-	SaveWorkflowEdgeErr                             // batch save workflow edge error
-	WorkflowNodeNotFoundErr                         // workflow node not found error
-	CanNotGetworkflowErr                            // workflow not found error
-	FormatCSVTaskErr                                // format csv data error
+	CanNotGetWorkflowUUIDErr      ErrCode = iota + 28000 // can not get workflow uuid
+	WorkflowNotExistErr                                  // workflow not exist
+	UpsertWorkflowEdgeErr                                // upsert workflow edge error
+	PermissionDenied                                     // permission denied
+	SaveWorkflowNodeErr                                  // batch save nodes error
+	SaveWorkflowEdgeErr                                  // batch save workflow edge error
+	WorkflowNodeNotFoundErr                              // workflow node not found error
+	CanNotGetworkflowErr                                 // workflow not found error
+	FormatCSVTaskErr                                     // format csv data error
+	WorkflowInputSchemaInvalidErr                        // workflow input schema invalid
+	WorkflowInputInvalidErr                              // workflow input invalid
 )
 
 // schedule module errors
@@ -205,3 +227,208 @@ const (
 	ExecWorkflowNodeScriptErr                              // exec workflow script error
 	EdgeNotStartedErr                                      // edge not started error
 )
+
+// execution control module errors
+const (
+	ApprovalAlreadyDecidedErr ErrCode = iota + 32000 // approval request already decided
+	ApprovalRejectedErr                              // approval request rejected
+	ApprovalStatusInvalidErr                         // approval status invalid
+	DeviceLockErr                                    // device lock error
+	DeviceLockWaitTimeoutErr                         // wait device lock timeout
+	DeviceLockNotHeldErr                             // device lock not held
+	ReservationTimeInvalidErr                        // reservation time range invalid
+	ReservationConflictErr                           // reservation conflicts with an existing one
+	DeviceReservedErr                                // device is reserved by another user
+	TaskDependencyInvalidErr                         // task dependency invalid
+	DeadLetterNotDeadErr                             // dead letter job already requeued
+	ApprovalSelfDecideErr                            // approval cannot be decided by the user who started the step
+)
+
+// background job module errors
+const (
+	JobScheduleInvalidErr   ErrCode = iota + 34000 // background job schedule invalid
+	JobAlreadyRegisteredErr                        // background job already registered
+	JobNotRegisteredErr                            // background job not registered
+)
+
+// device registry module errors
+const (
+	DeviceCapabilityInvalidErr  ErrCode = iota + 36000 // device capability descriptor invalid
+	DeviceActionNotSupportedErr                        // device does not declare the action
+	DeviceActionParamInvalidErr                        // device action parameters invalid
+	DeviceCommandStateErr                              // device command not in expected state
+	DeviceLimitExceededErr                             // lab device limit exceeded
+	DeviceGroupNoTargetErr                             // no device of the group can receive the command
+)
+
+// rule and alert module errors
+const (
+	RuleInvalidErr  ErrCode = iota + 38000 // device rule invalid
+	AlertStatusErr                         // alert not in expected state
+	AlertSilenceErr                        // alert silence invalid
+)
+
+// camera stream module errors
+const (
+	CameraFeatureDisabledErr ErrCode = iota + 40000 // realtime camera feature disabled
+	StreamTokenInvalidErr                           // stream viewing token invalid or expired
+	StreamSessionEndedErr                           // stream session already ended
+)
+
+// file upload module errors
+const (
+	FileUploadOffsetErr    ErrCode = iota + 42000 // upload offset does not match received size
+	FileUploadStatusErr                           // file not in expected upload state
+	FileTooLargeErr                               // file exceeds size limit
+	FileContentRejectedErr                        // file content rejected by validation
+	FileStorageErr                                // file storage error
+	FileURLInvalidErr                             // file download url invalid or expired
+)
+
+// material inventory module errors
+const (
+	MaterialInvalidErr      ErrCode = iota + 44000 // material lot invalid
+	MaterialInsufficientErr                        // material remaining quantity insufficient
+	MaterialSyncDisabledErr                        // material lims sync not enabled
+	MaterialSyncRunningErr                         // material sync already running for the lab
+)
+
+// saved view module errors
+const (
+	SavedViewExistErr  ErrCode = iota + 46000 // saved view name already exists
+	SavedViewTargetErr                        // saved view does not apply to this list
+)
+
+// report subscription module errors
+const (
+	ReportChannelDisabledErr ErrCode = iota + 47000 // delivery channel is not configured
+	ReportDeliveryMissingErr                        // delivery has no stored report
+)
+
+// execution annotation module errors
+const (
+	AnnotationDeletedErr ErrCode = iota + 48000 // annotation has been deleted
+	AnnotationMentionErr                        // mentioned user is not a lab member
+)
+
+// lab export module errors
+const (
+	LabExportRunningErr ErrCode = iota + 49000 // lab export already running
+	LabExportExpiredErr                        // lab export archive expired or not ready
+	LabPurgeConfirmErr                         // lab data deletion confirmation invalid
+)
+
+// history import module errors
+const (
+	HistoryImportRunningErr  ErrCode = iota + 50000 // history import already running for the lab
+	HistoryImportFormatErr                          // history import file format or table not supported
+	HistoryImportTooLargeErr                        // history import file too large
+)
+
+// history export module errors
+const (
+	HistoryExportKindErr    ErrCode = iota + 51000 // history export kind or filters invalid
+	HistoryExportExpiredErr                        // history export file expired or not ready
+)
+
+// event schema module errors
+const (
+	EventSchemaNotFoundErr  ErrCode = iota + 52000 // event type or schema version not found
+	EventSchemaViolationErr                        // event payload violates the published schema
+)
+
+// impersonation module errors
+const (
+	ImpersonationForbiddenErr ErrCode = iota + 53000 // endpoint does not allow impersonated sessions
+	ImpersonationScopeErr                            // impersonated session cannot access other laboratories
+	ImpersonationTargetErr                           // impersonation target must be a lab member who is not an admin
+)
+
+// maintenance module errors
+const (
+	MaintenanceReadOnlyErr ErrCode = iota + 54000 // service is in read-only maintenance
+)
+
+// error classification module errors
+const (
+	ErrorRulePatternErr ErrCode = iota + 55000 // error rule pattern is not a valid regular expression
+)
+
+// assistant module errors
+const (
+	AssistantDisabledErr  ErrCode = iota + 56000 // assistant is disabled or no model provider is configured
+	AssistantRateLimitErr                        // assistant request limit of the user is exceeded
+	AssistantProviderErr                         // assistant model provider request failed
+)
+
+// agent upgrade module errors
+const (
+	UpgradeCampaignStatusErr ErrCode = iota + 57000 // upgrade campaign status does not allow the operation
+	UpgradeNoTargetErr                              // no device matches the upgrade campaign filters
+)
+
+// device config module errors
+const (
+	DeviceConfigExistErr    ErrCode = iota + 58000 // device config profile with the same name already exists
+	DeviceConfigInvalidErr                         // device config must be a JSON object
+	DeviceConfigNoTargetErr                        // no device has the config assigned
+)
+
+// lab topology module errors
+const (
+	TopologyNodeExistErr     ErrCode = iota + 59000 // topology node with the same name already exists under the parent
+	TopologyParentInvalidErr                        // topology node type is not allowed under the parent
+	TopologyNodeNotEmptyErr                         // topology node still has child nodes
+	TopologyDeviceInvalidErr                        // device does not exist in the lab
+)
+
+// experiment module errors
+const (
+	ExperimentExistErr  ErrCode = iota + 60000 // experiment with the same name already exists
+	ExperimentClosedErr                        // archived experiment does not accept executions
+)
+
+// legal hold module errors
+const (
+	LegalHoldInvalidErr  ErrCode = iota + 61000 // legal hold target is missing or does not match the scope
+	LegalHoldReleasedErr                        // legal hold is already released
+)
+
+// history copy module errors
+const (
+	HistoryCopyForbiddenErr ErrCode = iota + 62000 // history copy is not allowed in this environment
+	HistoryCopySourceErr                           // history copy source environment request failed
+)
+
+// demo seed module errors
+const (
+	SeedForbiddenErr ErrCode = iota + 63000 // demo seeding is not allowed in this environment
+)
+
+// chaos module errors
+const (
+	ChaosForbiddenErr ErrCode = iota + 64000 // failure injection is not allowed in this environment
+)
+
+// payload limit module errors
+const (
+	PayloadTooLargeErr ErrCode = iota + 65000 // payload exceeds the size or depth limit
+)
+
+// websocket hub module errors
+const (
+	HubConnectionLimitErr ErrCode = iota + 66000 // too many websocket connections of the user
+	HubTopicInvalidErr                           // websocket topic is not valid
+	HubTopicLimitErr                             // too many topics subscribed on the connection
+	HubResumeTokenErr                            // resume token of the replay buffer is not valid
+)
+
+// execution history module errors
+const (
+	HistoryVersionConflictErr ErrCode = iota + 67000 // execution history was updated by another writer
+)
+
+// device event ingestion module errors
+const (
+	DeviceKeyRateLimitErr ErrCode = iota + 68000 // event reporting limit of the device key is exceeded
+)
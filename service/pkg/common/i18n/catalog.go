@@ -0,0 +1,298 @@
+package i18n
+
+import "github.com/scienceol/studio/service/pkg/common/code"
+
+// englishValidation 参数校验消息模板，标签加 _len 后缀的模板用于字符串和列表的长度限制
+var englishValidation = map[string]string{
+	"required":  "{field} is required",
+	"min":       "{field} must be greater than or equal to {param}",
+	"max":       "{field} must be less than or equal to {param}",
+	"len":       "{field} must be equal to {param}",
+	"gt":        "{field} must be greater than {param}",
+	"gte":       "{field} must be greater than or equal to {param}",
+	"lt":        "{field} must be less than {param}",
+	"lte":       "{field} must be less than or equal to {param}",
+	"min_len":   "{field} must have at least {param} characters or items",
+	"max_len":   "{field} must have at most {param} characters or items",
+	"len_len":   "{field} must have exactly {param} characters or items",
+	"oneof":     "{field} must be one of [{param}]",
+	"email":     "{field} must be a valid email address",
+	"url":       "{field} must be a valid URL",
+	"uuid":      "{field} must be a valid UUID",
+	"type":      "{field} must be of type {param}",
+	"rfc3339":   "{field} must be an RFC3339 time such as 2006-01-02T15:04:05Z",
+	"enum":      "{field} is not an allowed value",
+	"page":      "{field} must be greater than or equal to 1",
+	"page_size": "{field} must be between 1 and {param}",
+	"default":   "{field} is invalid",
+}
+
+var chineseValidation = map[string]string{
+	"required":  "{field} 为必填字段",
+	"min":       "{field} 必须大于或等于 {param}",
+	"max":       "{field} 必须小于或等于 {param}",
+	"len":       "{field} 必须等于 {param}",
+	"gt":        "{field} 必须大于 {param}",
+	"gte":       "{field} 必须大于或等于 {param}",
+	"lt":        "{field} 必须小于 {param}",
+	"lte":       "{field} 必须小于或等于 {param}",
+	"min_len":   "{field} 长度不能少于 {param}",
+	"max_len":   "{field} 长度不能超过 {param}",
+	"len_len":   "{field} 长度必须为 {param}",
+	"oneof":     "{field} 必须是 [{param}] 中的一个",
+	"email":     "{field} 必须是有效的邮箱地址",
+	"url":       "{field} 必须是有效的 URL",
+	"uuid":      "{field} 必须是有效的 UUID",
+	"type":      "{field} 的类型必须是 {param}",
+	"rfc3339":   "{field} 必须是 RFC3339 格式的时间，如 2006-01-02T15:04:05Z",
+	"enum":      "{field} 不是允许的取值",
+	"page":      "{field} 必须大于或等于 1",
+	"page_size": "{field} 必须在 1 到 {param} 之间",
+	"default":   "{field} 无效",
+}
+
+// chineseCodes 错误码的中文消息，新增错误码时需要同步补充
+var chineseCodes = map[code.ErrCode]string{
+	code.Success:       "成功",
+	code.UnDefineErr:   "未定义错误",
+	code.NoPermission:  "没有权限",
+	code.InvalidateJWT: "无效的 JWT",
+
+	code.ParamErr:           "参数解析错误",
+	code.NotPointerErr:      "参数不是指针",
+	code.NotSlicePointerErr: "参数必须是切片指针",
+	code.PointerIsNilErr:    "指针为空",
+
+	code.LoginConfigErr:           "登录配置错误",
+	code.LoginSetStateErr:         "设置登录状态失败",
+	code.RefreshTokenErr:          "刷新令牌失败",
+	code.LoginStateErr:            "登录状态校验失败",
+	code.ExchangeTokenErr:         "换取令牌失败",
+	code.CallbackParamErr:         "回调参数错误",
+	code.LoginGetUserInfoErr:      "获取用户信息失败",
+	code.LoginCallbackErr:         "登录处理用户信息失败",
+	code.UnLogin:                  "未登录",
+	code.LoginFormatErr:           "登录校验格式错误",
+	code.InvalidToken:             "无效的令牌",
+	code.RefreshTokenParamErr:     "刷新令牌参数错误",
+	code.ParseLoginRedirectURLErr: "登录跳转地址错误",
+
+	code.CreateDataErr:              "数据库创建数据失败",
+	code.UpdateDataErr:              "数据库更新数据失败",
+	code.RecordNotFound:             "记录不存在",
+	code.QueryRecordErr:             "数据库查询失败",
+	code.DeleteDataErr:              "数据库删除失败",
+	code.NotBaseDBTypeErr:           "不是基础数据库类型",
+	code.ModelNotImplementTablerErr: "模型未实现 schema.Tabler",
+	code.RedisLuaScriptErr:          "redis lua 脚本错误",
+	code.RedisLuaRetErr:             "redis lua 返回类型错误",
+	code.RedisAddSetErr:             "redis 添加用户集合失败",
+	code.RedisRemoveSetErr:          "redis 移除用户集合失败",
+
+	code.RegActionNameEmptyErr:       "注册动作名称为空",
+	code.ResourceIsEmptyErr:          "资源为空",
+	code.ResourceNotExistErr:         "资源不存在",
+	code.WorkflowTemplateNotFoundErr: "找不到工作流模板",
+	code.UserIDIsEmptyErr:            "用户 ID 为空",
+	code.LabIDIsEmptyErr:             "实验室 ID 为空",
+	code.LabNotFound:                 "实验室不存在",
+	code.LabInviteNotFoundErr:        "找不到实验室邀请链接",
+	code.InviteExpiredErr:            "邀请已过期",
+	code.InvalidateThirdID:           "无效的第三方 ID",
+	code.LabAlreadyDeletedErr:        "实验室已删除",
+
+	code.ResNotExistErr:             "资源不存在",
+	code.EdgeNodeNotExistErr:        "边缘节点不存在",
+	code.EdgeHandleNotExistErr:      "节点连接点不存在",
+	code.UnknownWSActionErr:         "未知的物料 websocket 动作",
+	code.UnmarshalWSDataErr:         "解析物料 websocket 数据失败",
+	code.CanNotGetLabIDErr:          "无法获取实验室 ID",
+	code.UpdateNodeErr:              "更新物料节点失败",
+	code.ParentNodeNotFoundErr:      "父节点不存在",
+	code.TemplateNodeNotFoundErr:    "模板节点不存在",
+	code.InvalidDagErr:              "无效的 DAG",
+	code.MaxTplNodeDeepErr:          "模板节点层级超过上限",
+	code.CanNotFoundMaterialNodeErr: "找不到物料节点",
+	code.MachineAlreadyExistErr:     "机器已存在",
+	code.QueryMachineStatusFailErr:  "查询机器状态失败",
+	code.MachineNotExistErr:         "机器不存在",
+	code.MachineReachMaxNumCountErr: "机器数量已达上限",
+	code.MachineNodeStoppingErr:     "机器正在停止",
+	code.MachineStartUnknownErr:     "启动机器时发生未知错误",
+	code.CanNotFoundTargetNode:      "找不到目标节点",
+	code.PathHasEmptyName:           "路径中存在空名称",
+
+	code.NotifyActionAlreadyRegistryErr: "通知动作已注册",
+	code.NotifySubscribeChannelErr:      "订阅通知频道失败",
+	code.NotifySendMsgErr:               "发送通知消息失败",
+
+	code.RPCHttpErr:              "rpc http 请求失败",
+	code.RPCHttpCodeErr:          "rpc http 状态码错误",
+	code.RPCHttpCodeRespErr:      "rpc http 响应错误",
+	code.CasDoorCreateLabUserErr: "创建实验室用户失败",
+	code.CasDoorQueryLabUserErr:  "查询实验室用户失败",
+	code.BohrBatchQueryErr:       "批量查询用户失败",
+
+	code.CanNotGetWorkflowUUIDErr:      "无法获取工作流 UUID",
+	code.WorkflowNotExistErr:           "工作流不存在",
+	code.UpsertWorkflowEdgeErr:         "保存工作流连线失败",
+	code.PermissionDenied:              "权限不足",
+	code.SaveWorkflowNodeErr:           "批量保存节点失败",
+	code.SaveWorkflowEdgeErr:           "批量保存工作流连线失败",
+	code.WorkflowNodeNotFoundErr:       "工作流节点不存在",
+	code.CanNotGetworkflowErr:          "找不到工作流",
+	code.FormatCSVTaskErr:              "CSV 数据格式错误",
+	code.WorkflowInputSchemaInvalidErr: "工作流输入参数定义无效",
+	code.WorkflowInputInvalidErr:       "工作流输入参数无效",
+
+	code.WorkflowTaskAlreadyExistErr:     "工作流任务已存在",
+	code.CanNotFoundEdgeSession:          "找不到边缘端会话",
+	code.WorkflowHasCircularErr:          "工作流存在环",
+	code.EdgeConnectClosedErr:            "节点运行时连接已关闭",
+	code.NodeDataMarshalErr:              "序列化节点数据失败",
+	code.JobRunFailErr:                   "任务运行失败",
+	code.WorkflowTaskNotFoundErr:         "找不到工作流任务",
+	code.WorkflowTaskStatusErr:           "工作流任务状态错误",
+	code.WorkflowTaskFinished:            "工作流任务已结束",
+	code.WorkflowNodeNoDeviceName:        "工作流节点缺少设备名称",
+	code.WorkflowNodeNoActionName:        "工作流节点缺少动作名称",
+	code.WorkflowNodeNoActionType:        "工作流节点缺少动作类型",
+	code.QueryJobStatusKeyNotExistErr:    "查询任务状态的键不存在",
+	code.CallbackJobStatusKeyNotExistErr: "回调任务状态的键不存在",
+	code.JobTimeoutErr:                   "任务超时",
+	code.JobRetryTimeout:                 "任务重试超时",
+	code.CallbackJobStatusTimeoutErr:     "回调任务状态超时",
+	code.JobCanceled:                     "任务已取消",
+	code.CanNotGetWorkflowTaskErr:        "无法获取工作流任务",
+	code.WorkflowTaskStatusNotPendingErr: "工作流任务不在等待状态",
+	code.CanNotFoundWorkflowHandleErr:    "找不到工作流连接点",
+	code.CanNotGetParentJobErr:           "找不到父节点任务",
+	code.ParamDataKeyInvalidateErr:       "参数数据的键无效",
+	code.ParamDataValueInvalidateErr:     "参数数据的值无效",
+	code.DataNotMapAnyTypeErr:            "数据不是对象类型",
+	code.ValueSliceOutIndexErr:           "数组下标越界",
+	code.ValueNotExistErr:                "值不存在",
+	code.SetLabHeartErr:                  "设置实验室心跳失败",
+	code.TargetDataNotMapAnyTypeErr:      "目标数据不是对象类型",
+	code.MarshalTargetDataErr:            "序列化目标数据失败",
+	code.TargetParamInvalidateErr:        "目标参数无效",
+	code.WorkflowNodeScriptEmtpyErr:      "工作流脚本为空",
+	code.UnknownWorkflowNodeTypeErr:      "未知的工作流节点类型",
+	code.ExecWorkflowNodeScriptErr:       "执行工作流脚本失败",
+	code.EdgeNotStartedErr:               "边缘端未启动",
+
+	code.ApprovalAlreadyDecidedErr: "审批请求已处理",
+	code.ApprovalRejectedErr:       "审批请求已拒绝",
+	code.ApprovalStatusInvalidErr:  "审批状态无效",
+	code.DeviceLockErr:             "设备锁错误",
+	code.DeviceLockWaitTimeoutErr:  "等待设备锁超时",
+	code.DeviceLockNotHeldErr:      "未持有设备锁",
+	code.ReservationTimeInvalidErr: "预约时间范围无效",
+	code.ReservationConflictErr:    "与已有预约冲突",
+	code.DeviceReservedErr:         "设备已被其他用户预约",
+	code.TaskDependencyInvalidErr:  "任务依赖无效",
+	code.DeadLetterNotDeadErr:      "死信任务已重新入队",
+	code.ApprovalSelfDecideErr:     "不能审批自己发起的步骤",
+
+	code.JobScheduleInvalidErr:   "后台任务调度配置无效",
+	code.JobAlreadyRegisteredErr: "后台任务已注册",
+	code.JobNotRegisteredErr:     "后台任务未注册",
+
+	code.DeviceCapabilityInvalidErr:  "设备能力描述无效",
+	code.DeviceActionNotSupportedErr: "设备未声明该动作",
+	code.DeviceActionParamInvalidErr: "设备动作参数无效",
+	code.DeviceCommandStateErr:       "设备指令不在预期状态",
+	code.DeviceLimitExceededErr:      "实验室设备数量超过上限",
+	code.DeviceGroupNoTargetErr:      "设备组中没有可以接收指令的设备",
+
+	code.RuleInvalidErr:  "设备规则无效",
+	code.AlertStatusErr:  "告警不在预期状态",
+	code.AlertSilenceErr: "告警静默设置无效",
+
+	code.CameraFeatureDisabledErr: "实时摄像头功能未开启",
+	code.StreamTokenInvalidErr:    "视频流观看令牌无效或已过期",
+	code.StreamSessionEndedErr:    "视频流会话已结束",
+
+	code.FileUploadOffsetErr:    "上传偏移量与已接收大小不一致",
+	code.FileUploadStatusErr:    "文件不在预期的上传状态",
+	code.FileTooLargeErr:        "文件超过大小限制",
+	code.FileContentRejectedErr: "文件内容未通过校验",
+	code.FileStorageErr:         "文件存储错误",
+	code.FileURLInvalidErr:      "文件下载地址无效或已过期",
+
+	code.MaterialInvalidErr:      "物料批次无效",
+	code.MaterialInsufficientErr: "物料剩余数量不足",
+	code.MaterialSyncDisabledErr: "未开启物料 LIMS 同步",
+	code.MaterialSyncRunningErr:  "实验室物料同步正在进行",
+
+	code.SavedViewExistErr:  "已存在同名的视图",
+	code.SavedViewTargetErr: "视图不适用于该列表",
+
+	code.ReportChannelDisabledErr: "投递渠道未配置",
+	code.ReportDeliveryMissingErr: "投递记录没有保存的报告",
+
+	code.AnnotationDeletedErr: "批注已删除",
+	code.AnnotationMentionErr: "提及的用户不是实验室成员",
+
+	code.LabExportRunningErr: "实验室导出正在进行",
+	code.LabExportExpiredErr: "实验室导出文件已过期或尚未生成",
+	code.LabPurgeConfirmErr:  "实验室数据删除确认无效",
+
+	code.HistoryImportRunningErr:  "实验室历史导入正在进行",
+	code.HistoryImportFormatErr:   "不支持的历史导入文件格式或数据表",
+	code.HistoryImportTooLargeErr: "历史导入文件过大",
+
+	code.HistoryExportKindErr:    "历史导出类型或筛选条件无效",
+	code.HistoryExportExpiredErr: "历史导出文件已过期或尚未生成",
+
+	code.EventSchemaNotFoundErr:  "事件类型或结构版本不存在",
+	code.EventSchemaViolationErr: "事件数据不符合已发布的结构",
+
+	code.ImpersonationForbiddenErr: "该接口不允许模拟登录会话访问",
+	code.ImpersonationScopeErr:     "模拟登录会话不能访问其他实验室",
+	code.ImpersonationTargetErr:    "模拟对象必须是非管理员的实验室成员",
+
+	code.MaintenanceReadOnlyErr: "服务处于只读维护中",
+
+	code.ErrorRulePatternErr: "错误规则不是有效的正则表达式",
+
+	code.AssistantDisabledErr:  "助手未开启或未配置模型服务",
+	code.AssistantRateLimitErr: "用户的助手请求次数超过上限",
+	code.AssistantProviderErr:  "助手模型服务请求失败",
+
+	code.UpgradeCampaignStatusErr: "升级活动的状态不允许该操作",
+	code.UpgradeNoTargetErr:       "没有设备符合升级活动的筛选条件",
+
+	code.DeviceConfigExistErr:    "已存在同名的设备配置",
+	code.DeviceConfigInvalidErr:  "设备配置必须是 JSON 对象",
+	code.DeviceConfigNoTargetErr: "没有设备分配了该配置",
+
+	code.TopologyNodeExistErr:     "父节点下已存在同名的拓扑节点",
+	code.TopologyParentInvalidErr: "父节点下不允许该类型的拓扑节点",
+	code.TopologyNodeNotEmptyErr:  "拓扑节点下仍有子节点",
+	code.TopologyDeviceInvalidErr: "设备不属于该实验室",
+
+	code.ExperimentExistErr:  "已存在同名的实验",
+	code.ExperimentClosedErr: "已归档的实验不再接收执行",
+
+	code.LegalHoldInvalidErr:  "法律保全对象不存在或与范围不符",
+	code.LegalHoldReleasedErr: "法律保全已解除",
+
+	code.HistoryCopyForbiddenErr: "当前环境不允许复制历史数据",
+	code.HistoryCopySourceErr:    "请求历史复制的源环境失败",
+
+	code.SeedForbiddenErr: "当前环境不允许生成演示数据",
+
+	code.ChaosForbiddenErr: "当前环境不允许注入故障",
+
+	code.PayloadTooLargeErr: "数据超过大小或嵌套层数限制",
+
+	code.HubConnectionLimitErr: "WebSocket 连接数超过限制",
+	code.HubTopicInvalidErr:    "订阅的主题无效",
+	code.HubTopicLimitErr:      "订阅的主题数超过限制",
+	code.HubResumeTokenErr:     "续传令牌无效",
+
+	code.HistoryVersionConflictErr: "执行历史已被其他操作更新，请重试",
+
+	code.DeviceKeyRateLimitErr: "设备密钥的事件上报次数超过上限，请稍后重试",
+}
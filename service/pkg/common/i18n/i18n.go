@@ -0,0 +1,211 @@
+// Package i18n localizes the error messages of API responses. The language is
+// negotiated from the Accept-Language header against the loaded catalogs, each
+// catalog maps error codes and parameter validation tags to messages. English
+// messages are the line comments of the code constants and need no catalog,
+// Chinese is built in, deployments add or override languages with catalog
+// files named by language tag in the configured directory.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/spf13/viper"
+	"golang.org/x/text/language"
+)
+
+const (
+	English = "en"
+	Chinese = "zh"
+)
+
+// Catalog 一种语言的消息目录
+type Catalog struct {
+	// Codes 错误码的消息，缺少的错误码使用英文消息
+	Codes map[code.ErrCode]string `mapstructure:"codes"`
+	// Validation 参数校验标签的消息模板，{field} 替换为字段名，{param} 替换为标签参数
+	Validation map[string]string `mapstructure:"validation"`
+}
+
+type registry struct {
+	sync.RWMutex
+	catalogs map[string]*Catalog
+	tags     []language.Tag
+	matcher  language.Matcher
+}
+
+var global atomic.Pointer[registry]
+
+func init() {
+	global.Store(newRegistry(English))
+}
+
+func newRegistry(defaultLang string) *registry {
+	r := &registry{catalogs: map[string]*Catalog{}}
+	r.register(English, &Catalog{Validation: englishValidation})
+	r.register(Chinese, &Catalog{Codes: chineseCodes, Validation: chineseValidation})
+	r.setDefault(defaultLang)
+	return r
+}
+
+// setDefault moves the closest loaded language to the front, the first
+// language is the fallback of the negotiation
+func (r *registry) setDefault(lang string) {
+	_, index, confidence := r.matcher.Match(language.Make(lang))
+	if confidence == language.No {
+		r.register(lang, &Catalog{})
+		index = len(r.tags) - 1
+	}
+	tag := r.tags[index]
+	r.tags = append([]language.Tag{tag}, append(r.tags[:index:index], r.tags[index+1:]...)...)
+	r.matcher = language.NewMatcher(r.tags)
+}
+
+// register merges the catalog into the language
+func (r *registry) register(lang string, c *Catalog) {
+	tag := language.Make(lang)
+	key := tag.String()
+	current, ok := r.catalogs[key]
+	if !ok {
+		current = &Catalog{Codes: map[code.ErrCode]string{}, Validation: map[string]string{}}
+		r.catalogs[key] = current
+		r.tags = append(r.tags, tag)
+		r.matcher = language.NewMatcher(r.tags)
+	}
+	for c, msg := range c.Codes {
+		current.Codes[c] = msg
+	}
+	for tag, msg := range c.Validation {
+		current.Validation[tag] = msg
+	}
+}
+
+// Init 按配置设置默认语言、加载部署的消息目录，并让参数校验错误使用请求中的字段名
+func Init(conf config.I18nConfig) error {
+	defaultLang := English
+	if conf.DefaultLanguage != "" {
+		tag, err := language.Parse(conf.DefaultLanguage)
+		if err != nil {
+			return fmt.Errorf("i18n default language %q: %w", conf.DefaultLanguage, err)
+		}
+		defaultLang = tag.String()
+	}
+	r := newRegistry(defaultLang)
+	if conf.CatalogDir != "" {
+		if err := r.load(conf.CatalogDir); err != nil {
+			return err
+		}
+	}
+	global.Store(r)
+	RegisterFieldNames()
+	return nil
+}
+
+// load reads <lang>.yaml, <lang>.yml and <lang>.json catalogs of the directory
+func (r *registry) load(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read i18n catalog dir: %w", err)
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		lang := strings.TrimSuffix(entry.Name(), ext)
+		if _, err := language.Parse(lang); err != nil {
+			return fmt.Errorf("i18n catalog %s is not named by a language tag: %w", entry.Name(), err)
+		}
+		v := viper.New()
+		v.SetConfigFile(filepath.Join(dir, entry.Name()))
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("read i18n catalog %s: %w", entry.Name(), err)
+		}
+		c := &Catalog{}
+		if err := v.Unmarshal(c); err != nil {
+			return fmt.Errorf("parse i18n catalog %s: %w", entry.Name(), err)
+		}
+		r.register(lang, c)
+	}
+	return nil
+}
+
+// Register 合并消息目录，供内置模块和测试扩展语言
+func Register(lang string, c *Catalog) {
+	r := global.Load()
+	r.Lock()
+	defer r.Unlock()
+	r.register(lang, c)
+}
+
+// Negotiate 按 Accept-Language 选择已加载的语言，没有匹配时使用默认语言
+func Negotiate(acceptLanguage string) string {
+	r := global.Load()
+	r.RLock()
+	defer r.RUnlock()
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return r.tags[0].String()
+	}
+	_, index, confidence := r.matcher.Match(tags...)
+	if confidence == language.No {
+		return r.tags[0].String()
+	}
+	return r.tags[index].String()
+}
+
+// Message 错误码在语言中的消息，目录中没有时使用英文消息
+func Message(lang string, c code.ErrCode) string {
+	r := global.Load()
+	r.RLock()
+	defer r.RUnlock()
+	msg := c.String()
+	r.lookup(lang, func(catalog *Catalog) bool {
+		m, ok := catalog.Codes[c]
+		if ok {
+			msg = m
+		}
+		return ok
+	})
+	return msg
+}
+
+// validationTemplate 校验标签在语言中的消息模板，依次查找语言和英文目录
+func validationTemplate(lang string, keys ...string) string {
+	r := global.Load()
+	r.RLock()
+	defer r.RUnlock()
+	tpl := englishValidation["default"]
+	for _, l := range []string{lang, English} {
+		if r.lookup(l, func(catalog *Catalog) bool {
+			for _, key := range keys {
+				if t, ok := catalog.Validation[key]; ok {
+					tpl = t
+					return true
+				}
+			}
+			return false
+		}) {
+			break
+		}
+	}
+	return tpl
+}
+
+// lookup 依次在语言和上级语言的目录中查找，如 zh-TW、zh，found 返回 true 时停止
+func (r *registry) lookup(lang string, found func(*Catalog) bool) bool {
+	for tag := language.Make(lang); ; tag = tag.Parent() {
+		if catalog, ok := r.catalogs[tag.String()]; ok && found(catalog) {
+			return true
+		}
+		if tag.IsRoot() {
+			return false
+		}
+	}
+}
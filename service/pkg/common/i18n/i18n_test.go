@@ -0,0 +1,267 @@
+package i18n_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/i18n"
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChineseCoversAllCodes(t *testing.T) {
+	require.NoError(t, i18n.Init(config.I18nConfig{}))
+	for c := code.ErrCode(0); c < 100000; c++ {
+		if strings.HasPrefix(c.String(), "ErrCode(") {
+			continue
+		}
+		assert.NotEqual(t, c.String(), i18n.Message(i18n.Chinese, c), "code %d has no chinese message", c)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	require.NoError(t, i18n.Init(config.I18nConfig{}))
+	for header, want := range map[string]string{
+		"":                        "en",
+		"zh-CN,zh;q=0.9,en;q=0.8": "zh",
+		"en-US,en;q=0.9":          "en",
+		"fr-FR":                   "en",
+		"fr;q=1.0, zh-Hans;q=0.5": "zh",
+		"not a language tag;;":    "en",
+	} {
+		assert.Equal(t, want, i18n.Negotiate(header), header)
+	}
+
+	require.NoError(t, i18n.Init(config.I18nConfig{DefaultLanguage: "zh-CN"}))
+	assert.Equal(t, "zh", i18n.Negotiate(""))
+	assert.Equal(t, "zh", i18n.Negotiate("fr"))
+	assert.Equal(t, "en", i18n.Negotiate("en-GB"))
+}
+
+func TestMessage(t *testing.T) {
+	require.NoError(t, i18n.Init(config.I18nConfig{}))
+	assert.Equal(t, "参数解析错误", i18n.Message(i18n.Chinese, code.ParamErr))
+	assert.Equal(t, "parse parameter error", i18n.Message(i18n.English, code.ParamErr))
+	assert.Equal(t, "parse parameter error", i18n.Message("fr", code.ParamErr))
+}
+
+type createReq struct {
+	Name  string    `json:"name" binding:"required,max=4"`
+	Count int       `json:"count" binding:"gte=1"`
+	Items []reqItem `json:"items" binding:"dive"`
+}
+
+type reqItem struct {
+	Key string `form:"key" binding:"required"`
+}
+
+func bindErr(t *testing.T, body string) error {
+	t.Helper()
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	req := &createReq{}
+	err := ctx.ShouldBindJSON(req)
+	require.Error(t, err)
+	return code.ParamErr.WithErr(err)
+}
+
+func TestFieldErrors(t *testing.T) {
+	require.NoError(t, i18n.Init(config.I18nConfig{}))
+	err := bindErr(t, `{"name":"too long","count":0,"items":[{}]}`)
+
+	fields, ok := i18n.FieldErrors(i18n.Chinese, err)
+	require.True(t, ok)
+	assert.Equal(t, []jsonschema.FieldError{
+		{Field: "name", Message: "name 长度不能超过 4"},
+		{Field: "count", Message: "count 必须大于或等于 1"},
+		{Field: "items[0].key", Message: "items[0].key 为必填字段"},
+	}, fields)
+
+	fields, ok = i18n.FieldErrors(i18n.English, err)
+	require.True(t, ok)
+	assert.Equal(t, "name must have at most 4 characters or items", fields[0].Message)
+
+	fields, ok = i18n.FieldErrors(i18n.Chinese, bindErr(t, `{"name":"a","count":"1"}`))
+	require.True(t, ok)
+	assert.Equal(t, []jsonschema.FieldError{{Field: "count", Message: "count 的类型必须是 int"}}, fields)
+
+	_, ok = i18n.FieldErrors(i18n.Chinese, code.ParamErr.WithMsg("lab id is empty"))
+	assert.False(t, ok)
+}
+
+type color string
+
+func (c color) Valid() bool {
+	return c == "red" || c == "blue"
+}
+
+type shape string
+
+func (s shape) Valid() bool {
+	return slices.Contains(s.Values(), string(s))
+}
+
+func (shape) Values() []string {
+	return []string{"circle", "square"}
+}
+
+type listReq struct {
+	Color     color  `form:"color" binding:"omitempty,enum"`
+	Shape     shape  `form:"shape" binding:"omitempty,enum"`
+	StartTime string `form:"start_time" binding:"omitempty,rfc3339"`
+	Page      int    `form:"page,default=1" binding:"page"`
+	PageSize  int    `form:"page_size,default=20" binding:"page_size=100"`
+}
+
+func TestRules(t *testing.T) {
+	require.NoError(t, i18n.Init(config.I18nConfig{}))
+	bind := func(query string) error {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+		return ctx.ShouldBindQuery(&listReq{})
+	}
+
+	assert.NoError(t, bind(""))
+	assert.NoError(t, bind("color=red&shape=circle&start_time=2025-01-01T08:00:00%2B08:00&page=3&page_size=100"))
+
+	err := bind("color=green&shape=oval&start_time=yesterday&page=0&page_size=500")
+	require.Error(t, err)
+	fields, ok := i18n.FieldErrors(i18n.Chinese, code.ParamErr.WithErr(err))
+	require.True(t, ok)
+	assert.Equal(t, []jsonschema.FieldError{
+		{Field: "color", Message: "color 不是允许的取值"},
+		{Field: "shape", Message: "shape 必须是 [circle square] 中的一个"},
+		{Field: "start_time", Message: "start_time 必须是 RFC3339 格式的时间，如 2006-01-02T15:04:05Z"},
+		{Field: "page", Message: "page 必须大于或等于 1"},
+		{Field: "page_size", Message: "page_size 必须在 1 到 100 之间"},
+	}, fields)
+}
+
+func TestCatalogDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "zh.yaml"), []byte(`
+codes:
+  1000: 请求参数有误
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ja.json"), []byte(`{
+  "codes": {"5008": "ログインしていません"},
+  "validation": {"required": "{field} は必須です"}
+}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "zh-CN.yaml"), []byte(`
+codes:
+  5008: 尚未登录
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o644))
+	require.NoError(t, i18n.Init(config.I18nConfig{DefaultLanguage: "zh", CatalogDir: dir}))
+	t.Cleanup(func() { _ = i18n.Init(config.I18nConfig{}) })
+
+	assert.Equal(t, "请求参数有误", i18n.Message(i18n.Chinese, code.ParamErr))
+	assert.Equal(t, "未登录", i18n.Message(i18n.Chinese, code.UnLogin))
+	assert.Equal(t, "zh-CN", i18n.Negotiate("zh-CN"))
+	assert.Equal(t, "尚未登录", i18n.Message("zh-CN", code.UnLogin))
+	assert.Equal(t, "请求参数有误", i18n.Message("zh-CN", code.ParamErr))
+	assert.Equal(t, "ja", i18n.Negotiate("ja-JP"))
+	assert.Equal(t, "ログインしていません", i18n.Message("ja", code.UnLogin))
+	assert.Equal(t, "parse parameter error", i18n.Message("ja", code.ParamErr))
+
+	fields, ok := i18n.FieldErrors("ja", bindErr(t, `{"count":1}`))
+	require.True(t, ok)
+	assert.Equal(t, "name は必須です", fields[0].Message)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "messages.yaml"), []byte("codes: {}"), 0o644))
+	assert.Error(t, i18n.Init(config.I18nConfig{CatalogDir: dir}))
+}
+
+func TestReplyErr(t *testing.T) {
+	require.NoError(t, i18n.Init(config.I18nConfig{}))
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/code", func(ctx *gin.Context) { common.ReplyErr(ctx, code.UnLogin) })
+	r.POST("/msg", func(ctx *gin.Context) { common.ReplyErr(ctx, code.UnLogin.WithMsg("token 已过期")) })
+	r.POST("/bind", func(ctx *gin.Context) {
+		req := &createReq{}
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+			return
+		}
+		common.ReplyOk(ctx)
+	})
+
+	do := func(path, lang, body string) (*httptest.ResponseRecorder, *common.Resp) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		resp := &common.Resp{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+		return w, resp
+	}
+
+	w, resp := do("/code", "zh-CN,zh;q=0.9", "")
+	assert.Equal(t, "zh", w.Header().Get("Content-Language"))
+	assert.Equal(t, code.UnLogin, resp.Code)
+	assert.Equal(t, "未登录", resp.Error.Msg)
+
+	w, resp = do("/code", "", "")
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+	assert.Equal(t, "not logged in", resp.Error.Msg)
+
+	_, resp = do("/msg", "en", "")
+	assert.Equal(t, code.UnLogin, resp.Code)
+	assert.Equal(t, "not logged in", resp.Error.Msg)
+	assert.Equal(t, "token 已过期", resp.Error.Detail)
+
+	_, resp = do("/bind", "zh", `{"count":1}`)
+	assert.Equal(t, code.ParamErr, resp.Code)
+	assert.Equal(t, "name 为必填字段", resp.Error.Msg)
+	assert.Equal(t, []any{map[string]any{"field": "name", "message": "name 为必填字段"}}, resp.Error.Detail)
+}
+
+func TestReplyErrTrace(t *testing.T) {
+	require.NoError(t, i18n.Init(config.I18nConfig{}))
+	gin.SetMode(gin.TestMode)
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) {
+		if ctx.GetHeader("traceparent") != "" {
+			ctx.Request = ctx.Request.WithContext(otel.ContextWithTraceParent(ctx.Request.Context(), ctx.GetHeader("traceparent")))
+		}
+		ctx.Next()
+	})
+	r.Use(otel.EnhancedMiddleware())
+	r.GET("/err", func(ctx *gin.Context) { common.ReplyErr(ctx, code.UnLogin) })
+
+	req := httptest.NewRequest(http.MethodGet, "/err", nil)
+	req.Header.Set("traceparent", traceParent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	resp := &common.Resp{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", resp.Error.TraceID)
+	assert.Equal(t, w.Header().Get(otel.RequestIDHeader), resp.Error.RequestID)
+	assert.NotEmpty(t, resp.Error.RequestID)
+	assert.Equal(t, `traceparent;desc="`+traceParent+`"`, w.Header().Get("Server-Timing"))
+
+	// Without a trace only the request id is returned
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/err", nil))
+	resp = &common.Resp{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	assert.Empty(t, resp.Error.TraceID)
+	assert.NotEmpty(t, resp.Error.RequestID)
+	assert.Empty(t, w.Header().Get("Server-Timing"))
+}
@@ -0,0 +1,69 @@
+package i18n
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// 未注册的校验标签会在绑定时 panic，因此随包初始化注册，不依赖 Init 的调用
+func init() {
+	RegisterRules()
+}
+
+// Enum is implemented by the enum types of the models, the enum rule rejects
+// the values it does not report as valid
+type Enum interface {
+	Valid() bool
+}
+
+// EnumValues is implemented by the enums that can list their values, the
+// message of the enum rule then names the allowed values
+type EnumValues interface {
+	Values() []string
+}
+
+// RegisterRules registers the custom binding rules on the gin validator:
+//
+//	rfc3339      the string is an RFC3339 timestamp
+//	enum         the value is a known value of an Enum type
+//	page         the page number starts from 1
+//	page_size=N  the page size is between 1 and N
+//
+// Combine rfc3339 and enum with omitempty for optional parameters
+func RegisterRules() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	_ = v.RegisterValidation("rfc3339", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse(time.RFC3339, fl.Field().String())
+		return err == nil
+	})
+	_ = v.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+		e, ok := fl.Field().Interface().(Enum)
+		return ok && e.Valid()
+	})
+	_ = v.RegisterValidation("page", func(fl validator.FieldLevel) bool {
+		return isInt(fl.Field()) && fl.Field().Int() >= 1
+	})
+	_ = v.RegisterValidation("page_size", func(fl validator.FieldLevel) bool {
+		limit, err := strconv.ParseInt(fl.Param(), 10, 64)
+		if err != nil || !isInt(fl.Field()) {
+			return false
+		}
+		size := fl.Field().Int()
+		return size >= 1 && size <= limit
+	})
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
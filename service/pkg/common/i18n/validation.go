@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
+)
+
+// RegisterFieldNames makes the gin validator report the json, form or uri
+// name of a field instead of the Go field name, so that the localized
+// validation messages name the fields the client sent
+func RegisterFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, key := range []string{"json", "form", "uri"} {
+			name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// FieldErrors translates the parameter validation and JSON type errors
+// wrapped in err, false when err has neither
+func FieldErrors(lang string, err error) ([]jsonschema.FieldError, bool) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		ret := make([]jsonschema.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			field := fieldPath(fe.Namespace())
+			keys, param := []string{fe.Tag(), "default"}, fe.Param()
+			switch fe.Kind() {
+			case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+				// min/max/len 对字符串和列表限制的是长度
+				keys = append([]string{fe.Tag() + "_len"}, keys...)
+			}
+			if e, ok := fe.Value().(EnumValues); ok && fe.Tag() == "enum" {
+				// 枚举能列出取值时按 oneof 提示允许的取值
+				keys, param = append([]string{"oneof"}, keys...), strings.Join(e.Values(), " ")
+			}
+			ret = append(ret, jsonschema.FieldError{
+				Field:   field,
+				Message: render(validationTemplate(lang, keys...), field, param),
+			})
+		}
+		return ret, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return []jsonschema.FieldError{{
+			Field:   field,
+			Message: render(validationTemplate(lang, "type"), field, typeErr.Type.String()),
+		}}, true
+	}
+	return nil, false
+}
+
+// fieldPath 去掉命名空间中的顶层结构体名，如 CreateReq.items[0].name
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+func render(tpl, field, param string) string {
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(tpl)
+}
@@ -0,0 +1,412 @@
+// Package jsonschema validates workflow run inputs against a JSON Schema.
+//
+// Only the subset of the specification used by workflow input forms is
+// supported: type, properties, required, additionalProperties, items, enum,
+// const, default, minimum/maximum (and exclusive variants), minLength,
+// maxLength, pattern, format (date-time, date and uuid), minItems and
+// maxItems. The annotations title, description, $id, $schema and $comment
+// are accepted and ignored; any other keyword is rejected by Compile.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes why a single input field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Types accepts both "type": "string" and "type": ["string", "null"]
+type Types []string
+
+func (t *Types) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+
+	var multi []string
+	if err := json.Unmarshal(b, &multi); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = multi
+	return nil
+}
+
+// Schema is a compiled JSON Schema node
+type Schema struct {
+	Type                 Types              `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	pattern     *regexp.Regexp
+	unsupported []string
+}
+
+var knownTypes = map[string]struct{}{
+	"object": {}, "array": {}, "string": {}, "number": {},
+	"integer": {}, "boolean": {}, "null": {},
+}
+
+// keywords lists the supported subset, Compile rejects everything else
+// instead of silently skipping constraints it does not understand
+var keywords = map[string]struct{}{
+	"type": {}, "properties": {}, "required": {}, "additionalProperties": {},
+	"items": {}, "enum": {}, "const": {}, "default": {},
+	"minimum": {}, "maximum": {}, "exclusiveMinimum": {}, "exclusiveMaximum": {},
+	"minLength": {}, "maxLength": {}, "pattern": {}, "format": {},
+	"minItems": {}, "maxItems": {},
+	"title": {}, "description": {}, "$id": {}, "$schema": {}, "$comment": {},
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// formats checks string values against the supported format names
+var formats = map[string]func(string) bool{
+	"date-time": func(v string) bool {
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	},
+	"date": func(v string) bool {
+		_, err := time.Parse(time.DateOnly, v)
+		return err == nil
+	},
+	"uuid": uuidPattern.MatchString,
+}
+
+// UnmarshalJSON decodes a schema node and records the keywords outside the
+// supported subset so that Compile can report them with their path
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	type plain Schema
+	if err := json.Unmarshal(b, (*plain)(s)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	for name := range fields {
+		if _, ok := keywords[name]; !ok {
+			s.unsupported = append(s.unsupported, name)
+		}
+	}
+	sort.Strings(s.unsupported)
+	return nil
+}
+
+// Compile parses and checks a schema document
+func Compile(raw []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	if err := s.compile("$"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Schema) compile(path string) error {
+	if len(s.unsupported) > 0 {
+		return fmt.Errorf("%s: unsupported keyword %s", path, strings.Join(s.unsupported, ", "))
+	}
+
+	for _, t := range s.Type {
+		if _, ok := knownTypes[t]; !ok {
+			return fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", path, err)
+		}
+		s.pattern = re
+	}
+
+	if s.Format != "" {
+		if _, ok := formats[s.Format]; !ok {
+			return fmt.Errorf("%s: unsupported format %q", path, s.Format)
+		}
+	}
+
+	for name, p := range s.Properties {
+		if p == nil {
+			return fmt.Errorf("%s.%s: empty schema", path, name)
+		}
+		if err := p.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		if err := s.Items.compile(path + "[]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the input and returns it normalized: missing properties
+// with a default are filled in and integral numbers become int64.
+func (s *Schema) Validate(input any) (any, []FieldError) {
+	errs := make([]FieldError, 0)
+	out := s.validate("", input, &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return out, nil
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func addErr(errs *[]FieldError, path, format string, args ...any) {
+	field := path
+	if field == "" {
+		field = "$"
+	}
+	*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func typeOf(v any) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return "integer"
+		}
+		return "number"
+	case int, int32, int64:
+		return "integer"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return reflect.TypeOf(v).String()
+	}
+}
+
+func (s *Schema) matchType(actual string) bool {
+	if len(s.Type) == 0 {
+		return true
+	}
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
+
+func equal(a, b any) bool {
+	if fa, ok := numeric(a); ok {
+		if fb, ok := numeric(b); ok {
+			return fa == fb
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func numeric(v any) (float64, bool) {
+	switch v.(type) {
+	case float64, int, int32, int64:
+		return toFloat(v), true
+	}
+	return 0, false
+}
+
+func (s *Schema) validate(path string, v any, errs *[]FieldError) any {
+	actual := typeOf(v)
+	if !s.matchType(actual) {
+		addErr(errs, path, "expected %s, got %s", strings.Join(s.Type, " or "), actual)
+		return v
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addErr(errs, path, "must be one of %v", s.Enum)
+		}
+	}
+
+	if s.Const != nil && !equal(s.Const, v) {
+		addErr(errs, path, "must be %v", s.Const)
+	}
+
+	switch actual {
+	case "integer", "number":
+		s.validateNumber(path, toFloat(v), errs)
+		if actual == "integer" && s.wantsInteger() {
+			return int64(toFloat(v))
+		}
+	case "string":
+		s.validateString(path, v.(string), errs)
+	case "array":
+		return s.validateArray(path, v.([]any), errs)
+	case "object":
+		return s.validateObject(path, v.(map[string]any), errs)
+	}
+	return v
+}
+
+func (s *Schema) wantsInteger() bool {
+	for _, t := range s.Type {
+		if t == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) validateNumber(path string, n float64, errs *[]FieldError) {
+	if s.Minimum != nil && n < *s.Minimum {
+		addErr(errs, path, "must be >= %v", *s.Minimum)
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		addErr(errs, path, "must be <= %v", *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum {
+		addErr(errs, path, "must be > %v", *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && n >= *s.ExclusiveMaximum {
+		addErr(errs, path, "must be < %v", *s.ExclusiveMaximum)
+	}
+}
+
+func (s *Schema) validateString(path, str string, errs *[]FieldError) {
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		addErr(errs, path, "length must be >= %d", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		addErr(errs, path, "length must be <= %d", *s.MaxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		addErr(errs, path, "must match pattern %s", s.Pattern)
+	}
+	if s.Format != "" && !formats[s.Format](str) {
+		addErr(errs, path, "must be a valid %s", s.Format)
+	}
+}
+
+func (s *Schema) validateArray(path string, arr []any, errs *[]FieldError) any {
+	if s.MinItems != nil && len(arr) < *s.MinItems {
+		addErr(errs, path, "must have at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(arr) > *s.MaxItems {
+		addErr(errs, path, "must have at most %d items", *s.MaxItems)
+	}
+	if s.Items == nil {
+		return arr
+	}
+
+	out := make([]any, 0, len(arr))
+	for i, item := range arr {
+		out = append(out, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs))
+	}
+	return out
+}
+
+func (s *Schema) validateObject(path string, obj map[string]any, errs *[]FieldError) any {
+	out := make(map[string]any, len(obj))
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok && s.defaultOf(name) == nil {
+			addErr(errs, join(path, name), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				addErr(errs, join(path, name), "is not allowed")
+				continue
+			}
+			out[name] = obj[name]
+			continue
+		}
+		out[name] = prop.validate(join(path, name), obj[name], errs)
+	}
+
+	for name, prop := range s.Properties {
+		if _, ok := obj[name]; !ok && prop.Default != nil {
+			out[name] = prop.validate(join(path, name), prop.Default, errs)
+		}
+	}
+	return out
+}
+
+func (s *Schema) defaultOf(name string) any {
+	if prop, ok := s.Properties[name]; ok {
+		return prop.Default
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["sample", "volume"],
+	"additionalProperties": false,
+	"properties": {
+		"sample": {"type": "string", "minLength": 1},
+		"volume": {"type": "number", "minimum": 0, "maximum": 100},
+		"repeat": {"type": "integer", "default": 1},
+		"mode": {"type": "string", "enum": ["fast", "slow"]}
+	}
+}`
+
+func decode(t *testing.T, s string) map[string]any {
+	m := map[string]any{}
+	assert.NoError(t, json.Unmarshal([]byte(s), &m))
+	return m
+}
+
+func TestCompileInvalid(t *testing.T) {
+	_, err := Compile([]byte(`{"type": "bogus"}`))
+	assert.Error(t, err)
+
+	_, err = Compile([]byte(`{"type": "string", "pattern": "("}`))
+	assert.Error(t, err)
+}
+
+func TestValidateNormalizes(t *testing.T) {
+	s, err := Compile([]byte(testSchema))
+	assert.NoError(t, err)
+
+	out, errs := s.Validate(decode(t, `{"sample": "A1", "volume": 10}`))
+	assert.Empty(t, errs)
+	ret := out.(map[string]any)
+	assert.Equal(t, int64(1), ret["repeat"])
+	assert.Equal(t, float64(10), ret["volume"])
+}
+
+func TestValidateFieldErrors(t *testing.T) {
+	s, err := Compile([]byte(testSchema))
+	assert.NoError(t, err)
+
+	_, errs := s.Validate(decode(t, `{"volume": 200, "mode": "other", "extra": true}`))
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	assert.ElementsMatch(t, []string{"sample", "volume", "mode", "extra"}, fields)
+}
+
+func TestCompileUnsupportedKeywords(t *testing.T) {
+	for _, c := range []struct {
+		schema string
+		err    string
+	}{
+		{`{"$ref": "#/definitions/sample"}`, `$: unsupported keyword $ref`},
+		{`{"oneOf": [{"type": "string"}, {"type": "number"}]}`, `$: unsupported keyword oneOf`},
+		{`{"anyOf": [{"type": "string"}], "allOf": [{"minLength": 1}]}`, `$: unsupported keyword allOf, anyOf`},
+		{`{"type": "object", "properties": {"mode": {"if": {"const": "fast"}, "then": {"maxLength": 4}}}}`, `$.mode: unsupported keyword if, then`},
+		{`{"type": "array", "items": {"type": "string", "uniqueItems": true}}`, `$[]: unsupported keyword uniqueItems`},
+		{`{"type": "string", "format": "email"}`, `$: unsupported format "email"`},
+	} {
+		_, err := Compile([]byte(c.schema))
+		if assert.Error(t, err, c.schema) {
+			assert.Equal(t, c.err, err.Error(), c.schema)
+		}
+	}
+
+	// 注解关键字不影响校验
+	_, err := Compile([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "/schemas/run",
+		"$comment": "run inputs",
+		"title": "Run",
+		"description": "inputs of one run",
+		"type": "object"
+	}`))
+	assert.NoError(t, err)
+}
+
+func TestValidateFormat(t *testing.T) {
+	s, err := Compile([]byte(`{
+		"type": "object",
+		"properties": {
+			"at": {"type": "string", "format": "date-time"},
+			"day": {"type": "string", "format": "date"},
+			"sample": {"type": "string", "format": "uuid"}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	_, errs := s.Validate(decode(t, `{"at": "2025-01-01T08:00:00+08:00", "day": "2025-01-01", "sample": "0192a1b2-0000-7000-8000-000000000001"}`))
+	assert.Empty(t, errs)
+
+	_, errs = s.Validate(decode(t, `{"at": "yesterday", "day": "2025-13-01", "sample": "A1"}`))
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	assert.ElementsMatch(t, []string{"at", "day", "sample"}, fields)
+}
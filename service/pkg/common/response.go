@@ -4,14 +4,19 @@ package common
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/olahol/melody"
 	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/i18n"
 	"github.com/scienceol/studio/service/pkg/common/uuid"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -21,8 +26,11 @@ const (
 )
 
 type Error struct {
-	Msg  string   `json:"msg"`
-	Info []string `json:"info,omitempty"`
+	Msg       string   `json:"msg"`
+	Info      []string `json:"info,omitempty"`
+	Detail    any      `json:"detail,omitempty"`
+	TraceID   string   `json:"trace_id,omitempty"`   // 请求的 trace，反馈问题时用于查找 trace 和日志
+	RequestID string   `json:"request_id,omitempty"` // 请求的 X-Request-ID
 }
 type RespT[T any] struct {
 	Code      code.ErrCode `json:"code"`
@@ -38,6 +46,9 @@ type Resp struct {
 	Timestamp int64        `json:"timestamp,omitempty"`
 }
 
+// PageResp 旧的分页结构，仅保留给已有的实验室和工作流列表，前端迁移前不改变这些接口的结构。
+//
+// Deprecated: 新的列表接口使用 ListResp
 type PageResp[T any] struct {
 	Total    int64 `json:"total"`
 	Page     int   `json:"page"`
@@ -45,6 +56,9 @@ type PageResp[T any] struct {
 	Data     T     `json:"data"`
 }
 
+// PageMoreResp 旧的按 has_more 翻页的结构，保留范围同 PageResp。
+//
+// Deprecated: 新的列表接口使用 ListResp，未统计总数时 total 为 -1
 type PageMoreResp[T any] struct {
 	HasMore  bool `json:"has_more"`
 	Page     int  `json:"page"`
@@ -52,6 +66,44 @@ type PageMoreResp[T any] struct {
 	Data     T    `json:"data"`
 }
 
+// ListResp 列表接口统一的分页结构。total 为 -1 表示未统计总数，此时 total_pages 同为 -1，
+// 按 has_more 翻页；按游标翻页的列表在 next_cursor 返回下一页的游标
+type ListResp[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	TotalPages int    `json:"total_pages"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewListResp 构造一页列表，has_more 按总数计算，未统计总数时由调用方设置
+func NewListResp[T any](items []T, total int64, page, pageSize int) *ListResp[T] {
+	if items == nil {
+		items = []T{}
+	}
+	resp := &ListResp[T]{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: TotalPages(total, pageSize),
+	}
+	if total >= 0 {
+		resp.HasMore = int64(page)*int64(pageSize) < total
+	}
+	return resp
+}
+
+// TotalPages 按每页数量计算总页数，总数未统计（为负数）时返回 -1
+func TotalPages(total int64, pageSize int) int {
+	if total < 0 || pageSize <= 0 {
+		return -1
+	}
+	return int((total + int64(pageSize) - 1) / int64(pageSize))
+}
+
 type PageReq struct {
 	Page     int `json:"page" form:"page" uri:"page"`
 	PageSize int `json:"page_size" form:"page_size" uri:"page_size"`
@@ -101,34 +153,67 @@ type WSData[T any] struct {
 }
 
 func ReplyErr(ctx *gin.Context, err error, msg ...string) {
+	lang := i18n.Negotiate(ctx.GetHeader("Accept-Language"))
+	ctx.Header("Content-Language", lang)
+	errCode, e := localizeErr(lang, err)
+	e.Info = msg
+	traceErr(ctx, e)
+	ctx.JSON(http.StatusOK, &Resp{
+		Code:  errCode,
+		Error: e,
+	})
+}
+
+// traceErr 在错误中带上请求的 trace id 和 request id，并通过 Server-Timing 返回 traceparent，
+// 用户反馈问题时提供其中任一标识即可定位到 trace 和日志
+func traceErr(ctx *gin.Context, e *Error) {
+	e.RequestID = ctx.Writer.Header().Get(otel.RequestIDHeader)
+	reqCtx := ctx.Request.Context()
+	if sc := trace.SpanContextFromContext(reqCtx); sc.IsValid() {
+		e.TraceID = sc.TraceID().String()
+		ctx.Header("Server-Timing", fmt.Sprintf("traceparent;desc=%q", otel.TraceParent(reqCtx)))
+	}
+}
+
+// LocalizeErr 按请求的语言构造错误码和错误信息，用于在成功响应中返回部分失败的原因
+func LocalizeErr(ctx *gin.Context, err error) (code.ErrCode, *Error) {
+	return localizeErr(i18n.Negotiate(ctx.GetHeader("Accept-Language")), err)
+}
+
+// localizeErr 按语言构造错误码和错误信息，参数校验错误逐个字段翻译并放在 detail 中，
+// 其他错误的 msg 为错误码的翻译
+func localizeErr(lang string, err error) (code.ErrCode, *Error) {
+	if errCode, ok := err.(code.ErrCodeWithDetail); ok {
+		return errCode.ErrCode, &Error{
+			Msg:    i18n.Message(lang, errCode.ErrCode),
+			Detail: errCode.Detail(),
+		}
+	}
+
 	if errCode, ok := err.(code.ErrCode); ok {
-		ctx.JSON(http.StatusOK, &Resp{
-			Code: errCode,
-			Error: &Error{
-				Msg:  errCode.String(),
-				Info: msg,
-			},
-		})
-		return
+		return errCode, &Error{Msg: i18n.Message(lang, errCode)}
 	}
 
 	if errCode, ok := err.(code.ErrCodeWithMsg); ok {
-		ctx.JSON(http.StatusOK, &Resp{
-			Code: errCode.ErrCode,
-			Error: &Error{
-				Msg:  errCode.Msgs(),
-				Info: msg,
-			},
-		})
-		return
+		if fields, ok := i18n.FieldErrors(lang, errCode); ok {
+			msgs := make([]string, 0, len(fields))
+			for _, f := range fields {
+				msgs = append(msgs, f.Message)
+			}
+			return errCode.ErrCode, &Error{
+				Msg:    strings.Join(msgs, "; "),
+				Detail: fields,
+			}
+		}
+		// 调用处附加的说明不在翻译目录中，按原文放在 detail 中，msg 始终为错误码的翻译
+		e := &Error{Msg: i18n.Message(lang, errCode.ErrCode)}
+		if msgs := errCode.Msgs(); msgs != "" {
+			e.Detail = msgs
+		}
+		return errCode.ErrCode, e
 	}
 
-	ctx.JSON(http.StatusOK, &Resp{
-		Code: code.UnDefineErr,
-		Error: &Error{
-			Msg: err.Error(),
-		},
-	})
+	return code.UnDefineErr, &Error{Msg: err.Error()}
 }
 
 func Reply(ctx *gin.Context, err error, data ...any) {
@@ -189,48 +274,14 @@ func ReplyWSOk(s *melody.Session, action string, msgUUID uuid.UUID, data ...any)
 }
 
 func ReplyWSErr(s *melody.Session, action string, msgUUID uuid.UUID, err error) error {
-	if errCode, ok := err.(code.ErrCode); ok {
-		d := &Resp{
-			Code: errCode,
-			Error: &Error{
-				Msg: errCode.String(),
-			},
-			Data: &WSData[any]{
-				WsMsgType: WsMsgType{
-					Action:  action,
-					MsgUUID: msgUUID,
-				},
-			},
-			Timestamp: time.Now().Unix(),
-		}
-
-		b, _ := json.Marshal(d)
-		return s.Write(b)
+	acceptLanguage := ""
+	if s.Request != nil {
+		acceptLanguage = s.Request.Header.Get("Accept-Language")
 	}
-
-	if errCode, ok := err.(code.ErrCodeWithMsg); ok {
-		d := &Resp{
-			Code: errCode.ErrCode,
-			Error: &Error{
-				Msg: errCode.Msgs(),
-			},
-			Data: &WSData[any]{
-				WsMsgType: WsMsgType{
-					Action:  action,
-					MsgUUID: msgUUID,
-				},
-			},
-			Timestamp: time.Now().Unix(),
-		}
-		b, _ := json.Marshal(d)
-		return s.Write(b)
-	}
-
+	errCode, e := localizeErr(i18n.Negotiate(acceptLanguage), err)
 	d := &Resp{
-		Code: code.UnDefineErr,
-		Error: &Error{
-			Msg: err.Error(),
-		},
+		Code:  errCode,
+		Error: e,
 		Data: &WSData[any]{
 			WsMsgType: WsMsgType{
 				Action:  action,
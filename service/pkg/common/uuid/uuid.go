@@ -5,6 +5,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/gofrs/uuid/v5"
 )
@@ -140,10 +142,73 @@ func NewV4() UUID {
 	return UUID{UUID: uuid.Must(uuid.NewV4())}
 }
 
+// NewV7 generates a time-ordered UUID, new rows of append-heavy tables stay
+// close together in the uuid index
+func NewV7() UUID {
+	return UUID{UUID: uuid.Must(uuid.NewV7())}
+}
+
+// NewV7AtTime generates a time-ordered UUID for a record created at t
+func NewV7AtTime(t time.Time) UUID {
+	return UUID{UUID: uuid.Must(uuid.NewV7AtTime(t))}
+}
+
+// 新记录使用的版本
+const (
+	Version4 = 4
+	Version7 = 7
+)
+
+var version atomic.Int32
+
+func init() {
+	version.Store(Version7)
+}
+
+// SetVersion 设置 New 生成的版本，0 使用默认的 v7
+func SetVersion(v int) error {
+	switch v {
+	case 0:
+		v = Version7
+	case Version4, Version7:
+	default:
+		return fmt.Errorf("unsupported uuid version %d", v)
+	}
+	version.Store(int32(v))
+	return nil
+}
+
+// New generates the identifier of a new record with the configured version.
+// Parsing accepts every version, so existing v4 identifiers keep working
+func New() UUID {
+	if version.Load() == Version4 {
+		return NewV4()
+	}
+	return NewV7()
+}
+
+// Time returns the creation time encoded in a v7 UUID
+func (u UUID) Time() (time.Time, bool) {
+	ts, err := uuid.TimestampFromV7(u.UUID)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t, err := ts.Time()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
 func NewNil() UUID {
 	return UUID{UUID: uuid.Nil}
 }
 
+// NewV5 derives a stable UUID from a name within the namespace
+func NewV5(ns UUID, name string) UUID {
+	return UUID{UUID: uuid.NewV5(ns.UUID, name)}
+}
+
 func (u UUID) String() string {
 	return u.UUID.String()
 }
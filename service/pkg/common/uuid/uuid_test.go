@@ -0,0 +1,63 @@
+package uuid
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetVersion(0)) })
+
+	assert.EqualValues(t, 7, New().Version())
+
+	require.NoError(t, SetVersion(Version4))
+	assert.EqualValues(t, 4, New().Version())
+
+	require.NoError(t, SetVersion(Version7))
+	assert.EqualValues(t, 7, New().Version())
+
+	assert.Error(t, SetVersion(1))
+	assert.EqualValues(t, 7, New().Version())
+}
+
+func TestNewV7Ordered(t *testing.T) {
+	// 前 48 位是毫秒时间戳，同一毫秒内的顺序不保证
+	prev := NewV7()
+	for range 1000 {
+		next := NewV7()
+		assert.LessOrEqual(t, bytes.Compare(prev.Bytes()[:6], next.Bytes()[:6]), 0)
+		prev = next
+	}
+}
+
+func TestTime(t *testing.T) {
+	at := time.Date(2026, 10, 17, 8, 30, 0, 123e6, time.UTC)
+	got, ok := NewV7AtTime(at).Time()
+	require.True(t, ok)
+	assert.True(t, at.Equal(got))
+
+	_, ok = NewV4().Time()
+	assert.False(t, ok)
+}
+
+func TestParseVersions(t *testing.T) {
+	for _, id := range []UUID{NewV4(), NewV7()} {
+		parsed, err := FromString(id.String())
+		require.NoError(t, err)
+		assert.Equal(t, id, parsed)
+
+		var scanned UUID
+		require.NoError(t, scanned.Scan(id.String()))
+		assert.Equal(t, id, scanned)
+
+		data, err := id.MarshalJSON()
+		require.NoError(t, err)
+		var decoded UUID
+		require.NoError(t, decoded.UnmarshalJSON(data))
+		assert.Equal(t, id, decoded)
+	}
+}
@@ -0,0 +1,330 @@
+// Package alert manages the lifecycle of device alerts raised by rules:
+// acknowledgment, assignment, silence windows and escalation.
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/alert"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/rule"
+)
+
+const (
+	escalateInterval = time.Minute
+	escalateBatch    = 200
+
+	maxSilence = 30 * 24 * time.Hour
+)
+
+// ListReq 告警列表查询
+type ListReq struct {
+	LabUUID    uuid.UUID  `form:"lab_uuid" binding:"required"`
+	Status     string     `form:"status"`
+	Severity   string     `form:"severity"`
+	DeviceUUID *uuid.UUID `form:"device_uuid"`
+	AssigneeID string     `form:"assignee_id"`
+	StartTime  *time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime    *time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int        `form:"page,default=1"`
+	PageSize   int        `form:"page_size,default=20"`
+}
+
+// AssignReq 指派告警负责人，assignee_id 为空时取消指派
+type AssignReq struct {
+	AssigneeID string `json:"assignee_id"`
+}
+
+// SilenceReq 创建静默窗口，device_uuid 为空时静默整个实验室
+type SilenceReq struct {
+	LabUUID    uuid.UUID  `json:"lab_uuid"`
+	DeviceUUID *uuid.UUID `json:"device_uuid"`
+	StartsAt   *time.Time `json:"starts_at"` // 默认立即开始
+	EndsAt     time.Time  `json:"ends_at" binding:"required"`
+	Reason     string     `json:"reason"`
+}
+
+type Service struct {
+	store    alert.AlertRepo
+	rules    rule.RuleRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	notifier *Notifier
+}
+
+func New() *Service {
+	return &Service{
+		store:    alert.New(),
+		rules:    rule.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		notifier: NewNotifier(),
+	}
+}
+
+// List 分页获取实验室告警，按触发时间倒序
+func (s *Service) List(ctx context.Context, req *ListReq) (*common.ListResp[*model.Alert], error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.labMember(ctx, req.LabUUID, userInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := &model.AlertQuery{
+		LabID:      labID,
+		DeviceUUID: req.DeviceUUID,
+		AssigneeID: req.AssigneeID,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+	if req.Status != "" {
+		status := model.AlertStatus(req.Status)
+		query.Status = &status
+	}
+	if req.Severity != "" {
+		severity := model.AlertSeverity(req.Severity)
+		query.Severity = &severity
+	}
+
+	items, total, err := s.store.ListAlerts(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(items, total, req.Page, req.PageSize), nil
+}
+
+// Get 获取告警详情
+func (s *Service) Get(ctx context.Context, alertUUID uuid.UUID) (*model.Alert, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetAlertByUUID(ctx, alertUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMember(ctx, data.LabID, userInfo.ID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Ack 确认告警，确认后不再升级
+func (s *Service) Ack(ctx context.Context, alertUUID uuid.UUID) (*model.Alert, error) {
+	data, err := s.Get(ctx, alertUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	if err := s.store.Ack(ctx, data, userInfo.ID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Resolve 手动恢复告警，规则条件仍满足时下一轮评估会重新触发
+func (s *Service) Resolve(ctx context.Context, alertUUID uuid.UUID) (*model.Alert, error) {
+	data, err := s.Get(ctx, alertUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	ok, err := s.store.Resolve(ctx, data, userInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, code.AlertStatusErr.WithMsg("alert already resolved")
+	}
+	return data, nil
+}
+
+// Assign 指派告警负责人，负责人必须是实验室成员
+func (s *Service) Assign(ctx context.Context, alertUUID uuid.UUID, req *AssignReq) (*model.Alert, error) {
+	data, err := s.Get(ctx, alertUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AssigneeID != "" {
+		if err := s.checkMember(ctx, data.LabID, req.AssigneeID); err != nil {
+			return nil, code.ParamErr.WithMsg("assignee is not a member of the lab")
+		}
+	}
+	if err := s.store.Assign(ctx, data, req.AssigneeID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateSilence 创建静默窗口，窗口内触发的告警不通知也不升级
+func (s *Service) CreateSilence(ctx context.Context, req *SilenceReq) (*model.AlertSilence, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.labMember(ctx, req.LabUUID, userInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if !req.EndsAt.After(startsAt) || !req.EndsAt.After(time.Now()) {
+		return nil, code.AlertSilenceErr.WithMsg("ends_at must be in the future and after starts_at")
+	}
+	if req.EndsAt.Sub(startsAt) > maxSilence {
+		return nil, code.AlertSilenceErr.WithMsgf("silence must not exceed %s", maxSilence)
+	}
+
+	data := &model.AlertSilence{
+		LabID:      labID,
+		DeviceUUID: req.DeviceUUID,
+		StartsAt:   startsAt,
+		EndsAt:     req.EndsAt,
+		Reason:     req.Reason,
+		CreatedBy:  userInfo.ID,
+	}
+	if err := s.store.CreateSilence(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListSilences 获取实验室当前及将来的静默窗口
+func (s *Service) ListSilences(ctx context.Context, labUUID uuid.UUID) ([]*model.AlertSilence, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.labMember(ctx, labUUID, userInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListSilences(ctx, labID, time.Now())
+}
+
+// DeleteSilence 删除静默窗口
+func (s *Service) DeleteSilence(ctx context.Context, silenceUUID uuid.UUID) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+
+	data, err := s.store.GetSilenceByUUID(ctx, silenceUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkMember(ctx, data.LabID, userInfo.ID); err != nil {
+		return err
+	}
+	return s.store.DeleteSilence(ctx, data.ID)
+}
+
+// Escalate 升级超过规则设定时间仍未确认的告警
+func (s *Service) Escalate(ctx context.Context) error {
+	items, err := s.store.ListEscalatable(ctx, time.Now(), escalateBatch)
+	if err != nil {
+		return err
+	}
+
+	rules := make(map[int64]*model.DeviceRule)
+	for _, item := range items {
+		silenced, err := s.store.IsSilenced(ctx, item.LabID, item.DeviceUUID, time.Now())
+		if err != nil {
+			return err
+		}
+		if silenced {
+			continue
+		}
+
+		ok, err := s.store.MarkEscalated(ctx, item)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		data, found := rules[item.RuleID]
+		if !found {
+			data = &model.DeviceRule{}
+			if err := s.baseDB.GetData(ctx, data, map[string]any{"id": item.RuleID}); err != nil {
+				logger.Warnf(ctx, "escalate alert get rule fail alert: %s, err: %+v", item.UUID, err)
+				continue
+			}
+			rules[item.RuleID] = data
+		}
+
+		channels := data.EscalationChannels
+		if len(channels) == 0 {
+			channels = data.Channels
+		}
+		s.notifier.Notify(ctx, AlertEventEscalated, data, item, channels)
+	}
+	return nil
+}
+
+func (s *Service) labMember(ctx context.Context, labUUID uuid.UUID, userID string) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	if err := s.checkMember(ctx, labID, userID); err != nil {
+		return 0, err
+	}
+	return labID, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, labID int64, userID string) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+// RegisterJob 注册告警升级后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "alert_escalation",
+		Description:  "升级超过规则设定时间仍未确认的告警",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     escalateInterval.String(),
+		Timeout:      5 * time.Minute,
+		Run:          s.Escalate,
+	})
+}
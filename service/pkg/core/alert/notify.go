@@ -0,0 +1,115 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/eventschema"
+	"github.com/scienceol/studio/service/pkg/core/notification"
+	"github.com/scienceol/studio/service/pkg/core/notify"
+	"github.com/scienceol/studio/service/pkg/core/notify/events"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+)
+
+const webhookTimeout = 5 * time.Second
+
+// AlertEvent represents the alert state change being notified
+type AlertEvent string
+
+const (
+	AlertEventFired     AlertEvent = "fired"
+	AlertEventResolved  AlertEvent = "resolved"
+	AlertEventEscalated AlertEvent = "escalated"
+)
+
+// AlertMsg 告警通知内容
+type AlertMsg struct {
+	Event AlertEvent        `json:"event"`
+	Rule  *model.DeviceRule `json:"rule"`
+	Alert *model.Alert      `json:"alert"`
+}
+
+// Notifier 将告警状态变化推送到通知渠道，通知失败只记录日志
+type Notifier struct {
+	msgCenter notify.MsgCenter
+	events    *eventschema.Dispatcher
+	inbox     *notification.Sender
+	client    *resty.Client
+	baseDB    repo.IDOrUUIDTranslate
+}
+
+func NewNotifier() *Notifier {
+	return &Notifier{
+		msgCenter: events.NewEvents(),
+		events:    eventschema.NewDispatcher(),
+		inbox:     notification.NewSender(),
+		client:    otel.RestyClientWithTracing().SetTimeout(webhookTimeout),
+		baseDB:    repo.NewBaseDB(),
+	}
+}
+
+// Notify 通知告警事件，静默的告警不发送。通知内容先按已发布的事件 schema
+// 校验，不符合的不发送；事件同时发布到事件总线，并发送站内通知给处理人，
+// 未指派时发送给实验室所有成员
+func (n *Notifier) Notify(ctx context.Context, event AlertEvent, data *model.DeviceRule, item *model.Alert, channels []model.AlertChannel) {
+	if item.Silenced {
+		return
+	}
+
+	msg := &AlertMsg{Event: event, Rule: data, Alert: item}
+	labUUID := n.baseDB.ID2UUID(ctx, &model.Laboratory{}, data.LabID)[data.LabID]
+	eventType := eventschema.Type("alert." + string(event))
+	studioEvent, err := eventschema.NewEvent(eventType, labUUID, msg)
+	if err != nil {
+		eventschema.Reject(ctx, eventType, err)
+		return
+	}
+	if err := n.events.Publish(ctx, studioEvent); err != nil {
+		logger.Warnf(ctx, "publish device alert event fail alert: %s, err: %+v", item.UUID, err)
+	}
+	n.sendInbox(ctx, event, item, labUUID)
+
+	for _, channel := range channels {
+		switch channel.Type {
+		case model.AlertChannelBroadcast:
+			if err := n.msgCenter.Broadcast(ctx, &notify.SendMsg{
+				Channel: notify.DeviceAlert,
+				LabUUID: labUUID,
+				Data:    msg,
+			}); err != nil {
+				logger.Warnf(ctx, "broadcast device alert fail alert: %s, err: %+v", item.UUID, err)
+			}
+		case model.AlertChannelWebhook:
+			resp, err := n.client.R().SetContext(ctx).SetHeaders(studioEvent.Headers()).SetBody(msg).Post(channel.URL)
+			if err != nil {
+				logger.Warnf(ctx, "send device alert webhook fail alert: %s, url: %s, err: %+v", item.UUID, channel.URL, err)
+				continue
+			}
+			if resp.IsError() {
+				logger.Warnf(ctx, "send device alert webhook fail alert: %s, url: %s, status: %d", item.UUID, channel.URL, resp.StatusCode())
+			}
+		}
+	}
+}
+
+func (n *Notifier) sendInbox(ctx context.Context, event AlertEvent, item *model.Alert, labUUID uuid.UUID) {
+	userIDs := []string{item.AssigneeID}
+	if item.AssigneeID == "" {
+		userIDs = n.inbox.LabMembers(ctx, item.LabID)
+	}
+	n.inbox.Send(ctx, &notification.Message{
+		Kind:    model.NotificationAlert,
+		UserIDs: userIDs,
+		LabID:   item.LabID,
+		LabUUID: labUUID,
+		RefUUID: item.UUID,
+		Title:   fmt.Sprintf("[%s] alert %s: %s", item.Severity, event, item.RuleName),
+		Body:    item.Message,
+	})
+}
@@ -0,0 +1,306 @@
+// Package annotation lets lab members attach threaded notes to workflow
+// executions, keeps the previous content of edited notes and notifies the
+// users mentioned in them.
+package annotation
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/notify"
+	"github.com/scienceol/studio/service/pkg/core/notify/events"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/annotation"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"gorm.io/datatypes"
+)
+
+const maxMentions = 20
+
+// CreateReq 添加批注
+type CreateReq struct {
+	Content    string     `json:"content" binding:"required,max=10000"`
+	ParentUUID *uuid.UUID `json:"parent_uuid"` // 回复的批注
+	Mentions   []string   `json:"mentions"`    // 提及的实验室成员用户 ID
+}
+
+// UpdateReq 编辑批注
+type UpdateReq struct {
+	Content  string   `json:"content" binding:"required,max=10000"`
+	Mentions []string `json:"mentions"`
+}
+
+// MentionMsg 提及通知内容
+type MentionMsg struct {
+	ExecutionUUID uuid.UUID                  `json:"execution_uuid"`
+	WorkflowName  string                     `json:"workflow_name"`
+	Annotation    *model.ExecutionAnnotation `json:"annotation"`
+}
+
+type Service struct {
+	store     annotation.AnnotationRepo
+	history   history.HistoryRepo
+	baseDB    repo.IDOrUUIDTranslate
+	envStore  repo.LaboratoryRepo
+	msgCenter notify.MsgCenter
+}
+
+func New() *Service {
+	return &Service{
+		store:     annotation.New(),
+		history:   history.New(),
+		baseDB:    repo.NewBaseDB(),
+		envStore:  environment.New(),
+		msgCenter: events.NewEvents(),
+	}
+}
+
+// List 获取执行记录的批注，回复嵌套在被回复的批注下
+func (s *Service) List(ctx context.Context, execUUID uuid.UUID) ([]*model.ExecutionAnnotation, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	exec, err := s.execution(ctx, userInfo.ID, execUUID)
+	if err != nil {
+		return nil, err
+	}
+	datas, err := s.store.ListAnnotations(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+	return model.AnnotationThreads(datas), nil
+}
+
+// Create 添加批注或回复，并通知被提及的成员
+func (s *Service) Create(ctx context.Context, execUUID uuid.UUID, req *CreateReq) (*model.ExecutionAnnotation, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	exec, err := s.execution(ctx, userInfo.ID, execUUID)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, code.ParamErr.WithMsg("content is empty")
+	}
+	mentions, err := s.checkMentions(ctx, exec.LabID, req.Mentions)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &model.ExecutionAnnotation{
+		LabID:         exec.LabID,
+		ExecutionID:   exec.ID,
+		ExecutionUUID: exec.UUID,
+		AuthorID:      userInfo.ID,
+		Content:       content,
+		Mentions:      datatypes.NewJSONType(mentions),
+	}
+	if req.ParentUUID != nil {
+		parent, err := s.store.GetAnnotationByUUID(ctx, *req.ParentUUID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ExecutionID != exec.ID {
+			return nil, code.ParamErr.WithMsg("parent annotation belongs to another execution")
+		}
+		if parent.DeletedAt != nil {
+			return nil, code.AnnotationDeletedErr
+		}
+		data.ParentID = &parent.ID
+		data.ParentUUID = &parent.UUID
+	}
+	if err := s.store.CreateAnnotation(ctx, data); err != nil {
+		return nil, err
+	}
+
+	s.notifyMentions(ctx, exec, data, mentions)
+	return data, nil
+}
+
+// Update 编辑批注，仅作者可以编辑，编辑前的内容保存为历史版本，只通知新提及的成员
+func (s *Service) Update(ctx context.Context, annotationUUID uuid.UUID, req *UpdateReq) (*model.ExecutionAnnotation, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetAnnotationByUUID(ctx, annotationUUID)
+	if err != nil {
+		return nil, err
+	}
+	if data.AuthorID != userInfo.ID {
+		return nil, code.NoPermission
+	}
+	if data.DeletedAt != nil {
+		return nil, code.AnnotationDeletedErr
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, code.ParamErr.WithMsg("content is empty")
+	}
+	mentions, err := s.checkMentions(ctx, data.LabID, req.Mentions)
+	if err != nil {
+		return nil, err
+	}
+
+	revision := &model.ExecutionAnnotationRevision{
+		AnnotationID: data.ID,
+		Content:      data.Content,
+		Mentions:     data.Mentions,
+		EditedBy:     userInfo.ID,
+	}
+	previous := data.Mentions.Data()
+	now := time.Now()
+	data.Content = content
+	data.Mentions = datatypes.NewJSONType(mentions)
+	data.EditedAt = &now
+	if err := s.store.UpdateAnnotation(ctx, data, revision); err != nil {
+		return nil, err
+	}
+
+	added := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		if !slices.Contains(previous, m) {
+			added = append(added, m)
+		}
+	}
+	if len(added) > 0 {
+		if exec, err := s.history.GetWorkflowExecution(ctx, data.ExecutionID); err == nil {
+			s.notifyMentions(ctx, exec, data, added)
+		}
+	}
+	return data, nil
+}
+
+// Delete 删除批注，作者与实验室管理员可以删除；回复保留在讨论串中
+func (s *Service) Delete(ctx context.Context, annotationUUID uuid.UUID) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+
+	data, err := s.store.GetAnnotationByUUID(ctx, annotationUUID)
+	if err != nil {
+		return err
+	}
+	if data.AuthorID != userInfo.ID {
+		count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+			"lab_id":  data.LabID,
+			"user_id": userInfo.ID,
+			"role":    model.LaboratoryMemberAdmin,
+		})
+		if err != nil || count == 0 {
+			return code.NoPermission
+		}
+	}
+	return s.store.DeleteAnnotation(ctx, data.ID, userInfo.ID)
+}
+
+// Revisions 获取批注的编辑历史
+func (s *Service) Revisions(ctx context.Context, annotationUUID uuid.UUID) ([]*model.ExecutionAnnotationRevision, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetAnnotationByUUID(ctx, annotationUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMemberByID(ctx, userInfo.ID, data.LabID); err != nil {
+		return nil, err
+	}
+	if data.DeletedAt != nil {
+		return nil, code.AnnotationDeletedErr
+	}
+	return s.store.ListRevisions(ctx, data.ID)
+}
+
+func (s *Service) execution(ctx context.Context, userID string, execUUID uuid.UUID) (*model.WorkflowExecutionHistory, error) {
+	exec, err := s.history.GetWorkflowExecutionByUUID(ctx, execUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMemberByID(ctx, userID, exec.LabID); err != nil {
+		return nil, err
+	}
+	return exec, nil
+}
+
+// checkMentions 去重并校验被提及的用户均为实验室成员
+func (s *Service) checkMentions(ctx context.Context, labID int64, mentions []string) ([]string, error) {
+	ids := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		m = strings.TrimSpace(m)
+		if m != "" && !slices.Contains(ids, m) {
+			ids = append(ids, m)
+		}
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	if len(ids) > maxMentions {
+		return nil, code.ParamErr.WithMsgf("at most %d mentions", maxMentions)
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if int(count) != len(ids) {
+		return nil, code.AnnotationMentionErr
+	}
+	return ids, nil
+}
+
+// notifyMentions 推送提及通知，作者本人不通知，通知失败只记录日志
+func (s *Service) notifyMentions(ctx context.Context, exec *model.WorkflowExecutionHistory, data *model.ExecutionAnnotation, mentions []string) {
+	labUUID := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, data.LabID)[data.LabID]
+	msg := &MentionMsg{
+		ExecutionUUID: exec.UUID,
+		WorkflowName:  exec.WorkflowName,
+		Annotation:    data,
+	}
+	for _, userID := range mentions {
+		if userID == data.AuthorID {
+			continue
+		}
+		if err := s.msgCenter.Broadcast(ctx, &notify.SendMsg{
+			Channel:      notify.UserMention,
+			LabUUID:      labUUID,
+			WorkflowUUID: exec.WorkflowUUID,
+			UserID:       userID,
+			Data:         msg,
+		}); err != nil {
+			logger.Warnf(ctx, "broadcast mention fail annotation: %s, user: %s, err: %+v", data.UUID, userID, err)
+		}
+	}
+}
+
+func (s *Service) checkMemberByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
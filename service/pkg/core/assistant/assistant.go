@@ -0,0 +1,193 @@
+// Package assistant diagnoses failed executions with the LLM provider
+// configured for the ai_assistant feature: it assembles the steps, errors,
+// node logs and device events of an execution into a prompt, asks the model
+// for a structured diagnosis and audits every prompt it sends.
+package assistant
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/features"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/ratelimit"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/assistant"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/llm"
+)
+
+const (
+	rateLimitWindow      = time.Hour
+	defaultRequestsLimit = 20
+)
+
+// localLimiter 限流在 redis 不可用时退化为进程内计数
+var localLimiter = ratelimit.NewLocalLimiter()
+
+// DiagnoseReq 诊断执行失败原因
+type DiagnoseReq struct {
+	ExecutionUUID uuid.UUID `json:"execution_uuid" binding:"required"`
+}
+
+// AuditReq 助手审计列表请求
+type AuditReq struct {
+	common.PageReq
+	Kind    string    `form:"kind"` // diagnose | query
+	UserID  string    `form:"user_id"`
+	LabUUID uuid.UUID `form:"lab_uuid"`
+}
+
+type Service struct {
+	store        assistant.AssistantRepo
+	historyStore history.HistoryRepo
+	envStore     repo.LaboratoryRepo
+	baseDB       repo.IDOrUUIDTranslate
+	llm          llm.LLM
+}
+
+func New() *Service {
+	return &Service{
+		store:        assistant.New(),
+		historyStore: history.New(),
+		envStore:     environment.New(),
+		baseDB:       repo.NewBaseDB(),
+		llm:          llm.New(),
+	}
+}
+
+// Diagnose 诊断执行失败的原因并给出处理建议，调用者必须是执行所在实验室的成员
+func (s *Service) Diagnose(ctx context.Context, req *DiagnoseReq) (*model.Diagnosis, error) {
+	if !features.IsEnabled(features.FeatureAIAssistant) || !s.llm.Enabled() {
+		return nil, code.AssistantDisabledErr
+	}
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	exec, err := s.historyStore.GetWorkflowExecutionByUUID(ctx, req.ExecutionUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMember(ctx, userInfo.ID, exec.LabID); err != nil {
+		return nil, err
+	}
+	if !s.allow(ctx, userInfo.ID) {
+		return nil, code.AssistantRateLimitErr
+	}
+
+	data, err := s.gather(ctx, exec)
+	if err != nil {
+		return nil, err
+	}
+	text, err := s.complete(ctx, &model.AssistantAudit{
+		Kind:          model.AssistantKindDiagnose,
+		UserID:        userInfo.ID,
+		LabID:         exec.LabID,
+		ExecutionID:   exec.ID,
+		ExecutionUUID: exec.UUID,
+	}, systemPrompt, buildPrompt(data))
+	if err != nil {
+		return nil, err
+	}
+
+	diagnosis := parseDiagnosis(text)
+	diagnosis.ExecutionUUID = exec.UUID
+	return diagnosis, nil
+}
+
+// complete 调用模型并记录审计，audit 需填好调用者和关联的对象
+func (s *Service) complete(ctx context.Context, audit *model.AssistantAudit, system, prompt string) (string, error) {
+	start := time.Now()
+	completion, err := s.llm.Complete(ctx, &llm.Request{
+		System: system,
+		Prompt: prompt,
+	})
+	audit.Provider = s.llm.Provider()
+	audit.Model = s.llm.Model()
+	audit.Prompt = prompt
+	audit.Status = model.AssistantStatusSuccess
+	audit.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		audit.Status = model.AssistantStatusFailed
+		audit.Error = err.Error()
+		s.audit(ctx, audit)
+		return "", code.AssistantProviderErr.WithErr(err)
+	}
+	audit.Response = completion.Text
+	audit.PromptTokens = completion.PromptTokens
+	audit.CompletionTokens = completion.CompletionTokens
+	s.audit(ctx, audit)
+	return completion.Text, nil
+}
+
+// ListAudit 获取发送给模型的提示词和回答，只有管理员可以查看
+func (s *Service) ListAudit(ctx context.Context, req *AuditReq) (*common.ListResp[*model.AssistantAudit], error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return nil, code.NoPermission
+	}
+	req.Normalize()
+
+	filter := &assistant.AuditFilter{Kind: req.Kind, UserID: req.UserID}
+	if !req.LabUUID.IsNil() {
+		filter.LabID = s.baseDB.UUID2ID(ctx, &model.Laboratory{}, req.LabUUID)[req.LabUUID]
+		if filter.LabID == 0 {
+			return nil, code.LabNotFound
+		}
+	}
+
+	datas, total, err := s.store.ListAudits(ctx, filter, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// allow 按用户限制每小时调用模型的次数，诊断和历史查询共用
+func (s *Service) allow(ctx context.Context, userID string) bool {
+	limit := config.GetStudioConfig().Assistant.RequestsPerHour
+	if limit <= 0 {
+		limit = defaultRequestsLimit
+	}
+	key := ratelimit.BuildKey(ratelimit.KeyTypeUser, userID, "assistant")
+
+	if client := redis.GetClient(); client != nil {
+		allowed, _, _, err := ratelimit.NewSlidingWindowLimiter(client).Allow(ctx, key, limit, rateLimitWindow)
+		if err == nil {
+			return allowed
+		}
+		logger.Warnf(ctx, "assistant rate limit redis err, fallback to local: %+v", err)
+	}
+	allowed, _, _ := localLimiter.Allow(key, limit, rateLimitWindow)
+	return allowed
+}
+
+func (s *Service) audit(ctx context.Context, data *model.AssistantAudit) {
+	if err := s.store.CreateAudit(context.WithoutCancel(ctx), data); err != nil {
+		logger.Errorf(ctx, "assistant audit fail execution: %s, err: %+v", data.ExecutionUUID, err)
+	}
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
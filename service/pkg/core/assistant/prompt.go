@@ -0,0 +1,264 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	maxActions      = 50
+	maxEvents       = 50
+	maxErrorLen     = 2000
+	maxDataLen      = 300
+	eventLookBehind = 10 * time.Minute // 开始前的设备事件也可能是失败原因
+)
+
+const systemPrompt = `You are an assistant diagnosing failed workflow executions of a laboratory automation platform.
+You receive the execution, its steps, the node logs and the recent events of the lab devices.
+Answer with a single JSON object and nothing else, with the fields:
+"summary": one or two sentences on what went wrong,
+"root_cause": the most likely root cause,
+"failed_step": the step that failed first, empty when unknown,
+"category": a short snake_case category of the failure, such as device_offline, timeout, invalid_parameter, material_shortage or script_error,
+"confidence": "high", "medium" or "low",
+"remediation": an ordered list of concrete actions for the operator.
+Base the diagnosis only on the given context and say so when it is not enough.`
+
+// nodeLog 工作流节点的返回信息
+type nodeLog struct {
+	Node   string
+	Status model.WorkflowJobStatus
+	Error  string
+}
+
+// promptData 诊断一次执行所需的上下文
+type promptData struct {
+	Execution *model.WorkflowExecutionHistory
+	Actions   []*model.ActionExecutionHistory
+	Nodes     []*nodeLog
+	Events    []*model.DeviceEventHistory // 时间倒序
+}
+
+// gather 加载执行的步骤、节点日志和执行期间的设备事件
+func (s *Service) gather(ctx context.Context, exec *model.WorkflowExecutionHistory) (*promptData, error) {
+	actions, err := s.historyStore.ListActionsByWorkflowExecution(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeLogs(ctx, exec)
+	if err != nil {
+		return nil, err
+	}
+
+	start := exec.StartedAt.Add(-eventLookBehind)
+	end := time.Now()
+	if exec.CompletedAt != nil {
+		end = *exec.CompletedAt
+	}
+	events, _, err := s.historyStore.ListDeviceEvents(ctx, &model.HistoryQueryParams{
+		LabID:     exec.LabID,
+		StartTime: &start,
+		EndTime:   &end,
+		Page:      1,
+		PageSize:  maxEvents,
+		SkipTotal: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &promptData{
+		Execution: exec,
+		Actions:   capActions(actions, maxActions),
+		Nodes:     nodes,
+		Events:    events,
+	}, nil
+}
+
+// nodeLogs 返回执行任务中失败或有错误信息的节点，执行 uuid 与任务 uuid 一致
+func (s *Service) nodeLogs(ctx context.Context, exec *model.WorkflowExecutionHistory) ([]*nodeLog, error) {
+	taskID := s.baseDB.UUID2ID(ctx, &model.WorkflowTask{}, exec.UUID)[exec.UUID]
+	if taskID == 0 {
+		return nil, nil
+	}
+
+	jobs := make([]*model.WorkflowNodeJob, 0, 8)
+	if err := s.baseDB.FindDatas(ctx, &jobs, map[string]any{
+		"workflow_task_id": taskID,
+	}); err != nil {
+		return nil, err
+	}
+
+	nodeIDs := make([]int64, 0, len(jobs))
+	failed := make([]*model.WorkflowNodeJob, 0, len(jobs))
+	for _, job := range jobs {
+		if job.ReturnInfo.Data().Error == "" &&
+			job.Status != model.WorkflowJobFailed && job.Status != model.WorkflowJobTimeout {
+			continue
+		}
+		failed = append(failed, job)
+		nodeIDs = append(nodeIDs, job.NodeID)
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	nodes := make([]*model.WorkflowNode, 0, len(nodeIDs))
+	if err := s.baseDB.FindDatas(ctx, &nodes, map[string]any{
+		"id": nodeIDs,
+	}, "id", "name"); err != nil {
+		return nil, err
+	}
+	names := make(map[int64]string, len(nodes))
+	for _, node := range nodes {
+		names[node.ID] = node.Name
+	}
+
+	logs := make([]*nodeLog, 0, len(failed))
+	for _, job := range failed {
+		name := names[job.NodeID]
+		if name == "" {
+			name = fmt.Sprintf("node %d", job.NodeID)
+		}
+		logs = append(logs, &nodeLog{
+			Node:   name,
+			Status: job.Status,
+			Error:  job.ReturnInfo.Data().Error,
+		})
+	}
+	return logs, nil
+}
+
+// capActions 步骤过多时保留全部失败的步骤，其余按顺序补足
+func capActions(actions []*model.ActionExecutionHistory, limit int) []*model.ActionExecutionHistory {
+	if len(actions) <= limit {
+		return actions
+	}
+
+	keep := make(map[int]bool, limit)
+	for i, action := range actions {
+		if len(keep) < limit && isFailed(action.Status) {
+			keep[i] = true
+		}
+	}
+	for i := range actions {
+		if len(keep) >= limit {
+			break
+		}
+		keep[i] = true
+	}
+
+	ret := make([]*model.ActionExecutionHistory, 0, limit)
+	for i, action := range actions {
+		if keep[i] {
+			ret = append(ret, action)
+		}
+	}
+	return ret
+}
+
+func isFailed(status model.ExecutionStatus) bool {
+	for _, s := range model.FailedStatuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// buildPrompt 将执行上下文整理为发送给模型的文本
+func buildPrompt(data *promptData) string {
+	exec := data.Execution
+	b := &strings.Builder{}
+
+	b.WriteString("## Execution\n")
+	fmt.Fprintf(b, "workflow: %s\n", exec.WorkflowName)
+	fmt.Fprintf(b, "status: %s\n", exec.Status)
+	fmt.Fprintf(b, "started_at: %s\n", exec.StartedAt.Format(time.RFC3339))
+	if exec.CompletedAt != nil {
+		fmt.Fprintf(b, "completed_at: %s\n", exec.CompletedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(b, "duration_ms: %d\n", exec.DurationMs)
+	fmt.Fprintf(b, "steps: %d total, %d completed, %d failed\n", exec.StepsTotal, exec.StepsCompleted, exec.StepsFailed)
+	if exec.ErrorMessage != nil && *exec.ErrorMessage != "" {
+		fmt.Fprintf(b, "error: %s\n", truncate(*exec.ErrorMessage, maxErrorLen))
+	}
+	if exec.ErrorCategory != "" {
+		fmt.Fprintf(b, "error_category: %s\n", exec.ErrorCategory)
+	}
+
+	b.WriteString("\n## Steps\n")
+	if len(data.Actions) == 0 {
+		b.WriteString("none recorded\n")
+	}
+	for i, action := range data.Actions {
+		fmt.Fprintf(b, "%d. [%s] %s / %s (%s), %d ms", i+1, action.Status, action.DeviceName, action.ActionName, action.ActionType, action.DurationMs)
+		if action.ExpectedMs > 0 {
+			fmt.Fprintf(b, ", expected %d ms", action.ExpectedMs)
+		}
+		b.WriteString("\n")
+		if action.ErrorMessage != nil && *action.ErrorMessage != "" {
+			fmt.Fprintf(b, "   error: %s\n", truncate(*action.ErrorMessage, maxErrorLen))
+		}
+		if isFailed(action.Status) {
+			if len(action.Input) > 0 {
+				fmt.Fprintf(b, "   input: %s\n", truncate(string(action.Input), maxDataLen))
+			}
+			if len(action.Output) > 0 {
+				fmt.Fprintf(b, "   output: %s\n", truncate(string(action.Output), maxDataLen))
+			}
+		}
+	}
+
+	if len(data.Nodes) > 0 {
+		b.WriteString("\n## Node logs\n")
+		for _, node := range data.Nodes {
+			fmt.Fprintf(b, "- %s [%s]: %s\n", node.Node, node.Status, truncate(node.Error, maxErrorLen))
+		}
+	}
+
+	b.WriteString("\n## Device events (newest first)\n")
+	if len(data.Events) == 0 {
+		b.WriteString("none recorded\n")
+	}
+	for _, event := range data.Events {
+		fmt.Fprintf(b, "- %s device %s %s", event.Timestamp.Format(time.RFC3339), event.DeviceUUID, event.EventType)
+		if len(event.EventData) > 0 {
+			fmt.Fprintf(b, " %s", truncate(string(event.EventData), maxDataLen))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// parseDiagnosis 解析模型返回的 JSON，去掉代码块标记；不是 JSON 时原文作为摘要
+func parseDiagnosis(text string) *model.Diagnosis {
+	text = strings.TrimSpace(text)
+	diagnosis := &model.Diagnosis{}
+
+	body := text
+	if start, end := strings.Index(body, "{"), strings.LastIndex(body, "}"); start >= 0 && end > start {
+		body = body[start : end+1]
+	}
+	if err := json.Unmarshal([]byte(body), diagnosis); err != nil || diagnosis.Summary == "" && diagnosis.RootCause == "" {
+		diagnosis = &model.Diagnosis{Summary: text}
+	}
+	if diagnosis.Remediation == nil {
+		diagnosis.Remediation = []string{}
+	}
+	return diagnosis
+}
+
+// truncate 按字符截断
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,79 @@
+package assistant
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDiagnosis(t *testing.T) {
+	d := parseDiagnosis("```json\n{\"summary\":\"pump offline\",\"root_cause\":\"usb\",\"failed_step\":\"aspirate\",\"category\":\"device_offline\",\"confidence\":\"high\",\"remediation\":[\"reconnect\"]}\n```")
+	assert.Equal(t, "pump offline", d.Summary)
+	assert.Equal(t, "usb", d.RootCause)
+	assert.Equal(t, "aspirate", d.FailedStep)
+	assert.Equal(t, "device_offline", d.Category)
+	assert.Equal(t, []string{"reconnect"}, d.Remediation)
+
+	d = parseDiagnosis("  the pump went offline  ")
+	assert.Equal(t, "the pump went offline", d.Summary)
+	assert.Equal(t, []string{}, d.Remediation)
+
+	d = parseDiagnosis(`{"foo": 1}`)
+	assert.Equal(t, `{"foo": 1}`, d.Summary)
+}
+
+func TestCapActions(t *testing.T) {
+	actions := make([]*model.ActionExecutionHistory, 0, 10)
+	for i := 0; i < 10; i++ {
+		status := model.ExecutionStatusSuccess
+		if i >= 8 {
+			status = model.ExecutionStatusFailed
+		}
+		actions = append(actions, &model.ActionExecutionHistory{ActionName: string(rune('a' + i)), Status: status})
+	}
+
+	ret := capActions(actions, 4)
+	names := make([]string, 0, len(ret))
+	for _, a := range ret {
+		names = append(names, a.ActionName)
+	}
+	assert.Equal(t, []string{"a", "b", "i", "j"}, names)
+	assert.Len(t, capActions(actions, 20), 10)
+}
+
+func TestBuildPrompt(t *testing.T) {
+	errMsg := "step aspirate failed"
+	actionErr := strings.Repeat("x", maxErrorLen+10)
+	completed := time.Date(2026, 1, 2, 3, 5, 0, 0, time.UTC)
+	prompt := buildPrompt(&promptData{
+		Execution: &model.WorkflowExecutionHistory{
+			WorkflowName:  "pcr",
+			Status:        model.ExecutionStatusFailed,
+			StartedAt:     time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC),
+			CompletedAt:   &completed,
+			ErrorMessage:  &errMsg,
+			ErrorCategory: "device_offline",
+			StepsTotal:    2,
+			StepsFailed:   1,
+		},
+		Actions: []*model.ActionExecutionHistory{
+			{DeviceName: "pump", ActionName: "aspirate", Status: model.ExecutionStatusFailed, ErrorMessage: &actionErr, Input: []byte(`{"volume":10}`)},
+		},
+		Nodes: []*nodeLog{{Node: "aspirate", Status: model.WorkflowJobFailed, Error: "not acknowledged"}},
+		Events: []*model.DeviceEventHistory{
+			{EventType: "disconnected", Timestamp: completed},
+		},
+	})
+
+	assert.Contains(t, prompt, "workflow: pcr")
+	assert.Contains(t, prompt, "error: step aspirate failed")
+	assert.Contains(t, prompt, "error_category: device_offline")
+	assert.Contains(t, prompt, `input: {"volume":10}`)
+	assert.Contains(t, prompt, "- aspirate [failed]: not acknowledged")
+	assert.Contains(t, prompt, "disconnected")
+	assert.NotContains(t, prompt, actionErr)
+	assert.Contains(t, prompt, strings.Repeat("x", maxErrorLen)+"...")
+}
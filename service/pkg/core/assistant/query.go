@@ -0,0 +1,551 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/features"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// maxVocabulary 发送给模型的工作流和设备名称数量上限
+const maxVocabulary = 200
+
+const querySystemPrompt = `You translate questions about the execution history of a laboratory automation platform into filters.
+Answer with a single JSON object and nothing else, with the fields, empty when not asked:
+"target": "workflow" for workflow executions (runs), "device_event" for device events,
+"status": one of pending, running, success, failed, cancelled, timeout, only for workflow executions,
+"event_type": one of status_change, data_received, error, connected, disconnected, command_sent, command_result, lock_waiting, lock_acquired, lock_released, lock_lost, stream_started, stream_stopped, only for device events,
+"workflow": the exact name of one of the given workflows,
+"device": the exact name of one of the given devices,
+"start_time" and "end_time": RFC3339 times computed from the given current time,
+"labels": execution labels as "key:value" strings.
+Never invent workflow or device names.`
+
+// InterpretReq 自然语言历史查询
+type InterpretReq struct {
+	LabID    int64  `json:"lab_id" binding:"required"` // 问题中提到 lab N 时以问题为准
+	Question string `json:"question" binding:"required,max=500"`
+}
+
+// phrase 规则语法中的一个关键词模式
+type phrase[T any] struct {
+	re    *regexp.Regexp
+	value T
+}
+
+func phrases[T any](pairs map[string]T, order []string) []phrase[T] {
+	ret := make([]phrase[T], 0, len(order))
+	for _, pattern := range order {
+		ret = append(ret, phrase[T]{re: regexp.MustCompile(pattern), value: pairs[pattern]})
+	}
+	return ret
+}
+
+var (
+	// 按顺序匹配，更具体的在前
+	statusOrder = []string{
+		`\btim(ed|e)[ -]?outs?\b|超时`,
+		`\bcancell?ed\b|\baborted\b|取消`,
+		`\bfail(ed|s|ing|ures?)?\b|\berrored\b|失败`,
+		`\brunning\b|\bin progress\b|\bongoing\b|运行中|进行中`,
+		`\bpending\b|\bqueued\b|\bwaiting\b|排队|等待`,
+		`\bsucce(eded|ssful|ss)\b|\bpassed\b|\bcompleted\b|成功|完成`,
+	}
+	statusPhrases = phrases(map[string]model.ExecutionStatus{
+		statusOrder[0]: model.ExecutionStatusTimeout,
+		statusOrder[1]: model.ExecutionStatusCancelled,
+		statusOrder[2]: model.ExecutionStatusFailed,
+		statusOrder[3]: model.ExecutionStatusRunning,
+		statusOrder[4]: model.ExecutionStatusPending,
+		statusOrder[5]: model.ExecutionStatusSuccess,
+	}, statusOrder)
+
+	// 这些事件类型本身就说明查询的是设备事件
+	eventOrder = []string{
+		`\bdisconnect(ed|ion|ions|s)?\b|\boffline\b|掉线|断开|离线`,
+		`\b(re)?connect(ed|ion|ions|s)?\b|\bonline\b|上线`,
+		`\blocks? lost\b|\blost (the )?locks?\b|锁丢失`,
+		`\bstreams? started\b|\bstarted streaming\b`,
+		`\bstreams? stopped\b|\bstopped streaming\b`,
+		`\bcommand results?\b`,
+		`\bcommands? sent\b`,
+		`\bstatus changes?\b|状态变化`,
+	}
+	eventPhrases = phrases(map[string]model.DeviceEventType{
+		eventOrder[0]: model.DeviceEventDisconnected,
+		eventOrder[1]: model.DeviceEventConnected,
+		eventOrder[2]: model.DeviceEventLockLost,
+		eventOrder[3]: model.DeviceEventStreamStarted,
+		eventOrder[4]: model.DeviceEventStreamStopped,
+		eventOrder[5]: model.DeviceEventCommandResult,
+		eventOrder[6]: model.DeviceEventCommandSent,
+		eventOrder[7]: model.DeviceEventStatusChange,
+	}, eventOrder)
+
+	eventWordRe  = regexp.MustCompile(`\bevents?\b|事件`)
+	errorWordRe  = regexp.MustCompile(`\berrors?\b|错误`)
+	labRe        = regexp.MustCompile(`\blab(?:oratory)?\s*#?\s*(\d+)\b|实验室\s*(\d+)`)
+	labelRe      = regexp.MustCompile(`(?:\blabel(?:s|ed|led)?|\btag(?:s|ged)?|标签)\s*([\w.-]+)\s*[=:：]\s*([\w.-]+)`)
+	lastNRe      = regexp.MustCompile(`\b(?:last|past|previous)\s+(\d+)\s*(hours?|h|days?|d|weeks?|w|months?)\b`)
+	lastNZhRe    = regexp.MustCompile(`(?:最近|过去|近)\s*(\d+)\s*(小时|天|日|周|个月|月)`)
+	lastHourRe   = regexp.MustCompile(`\b(?:last|past) hour\b|最近一小时`)
+	todayRe      = regexp.MustCompile(`\btoday\b|今天`)
+	yesterdayRe  = regexp.MustCompile(`\byesterday\b|昨天`)
+	thisWeekRe   = regexp.MustCompile(`\bthis week\b|本周|这周`)
+	lastWeekRe   = regexp.MustCompile(`\blast week\b|上周`)
+	thisMonthRe  = regexp.MustCompile(`\bthis month\b|本月|这个月`)
+	lastMonthRe  = regexp.MustCompile(`\blast month\b|上个?月`)
+	unitDuration = map[string]time.Duration{
+		"h": time.Hour, "hour": time.Hour, "hours": time.Hour, "小时": time.Hour,
+		"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour, "天": 24 * time.Hour, "日": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour, "周": 7 * 24 * time.Hour,
+	}
+)
+
+// vocabEntry 实验室的一个工作流或设备及其可匹配的名称
+type vocabEntry struct {
+	ID    int64
+	Name  string
+	Names []string
+}
+
+// vocabulary 实验室中可以被问题提到的工作流和设备
+type vocabulary struct {
+	Workflows []*vocabEntry
+	Devices   []*vocabEntry
+}
+
+// Interpret 将自然语言问题解析为历史查询条件。开启助手且配置了模型时由模型
+// 解析，否则或模型不可用时使用内置规则；工作流和设备名称只能解析为实验室中
+// 已有的对象
+func (s *Service) Interpret(ctx context.Context, req *InterpretReq) (*model.HistoryInterpretation, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		return nil, code.ParamErr.WithMsg("question is required")
+	}
+
+	labID := req.LabID
+	if mentioned := mentionedLab(question); mentioned > 0 {
+		labID = mentioned
+	}
+	if err := s.checkMember(ctx, userInfo.ID, labID); err != nil {
+		return nil, err
+	}
+	vocab, err := s.vocabulary(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var interp *model.HistoryInterpretation
+	var fallback string
+	switch {
+	case !features.IsEnabled(features.FeatureAIAssistant) || !s.llm.Enabled():
+	case !s.allow(ctx, userInfo.ID):
+		fallback = "assistant request limit exceeded, interpreted by rules"
+	default:
+		interp, err = s.interpretLLM(ctx, userInfo.ID, labID, question, now, vocab)
+		if err != nil {
+			logger.Warnf(ctx, "assistant interpret question fail, fallback to rules: %+v", err)
+			fallback = "assistant model unavailable, interpreted by rules"
+		}
+	}
+	if interp == nil {
+		interp = interpretRules(question, now, vocab)
+		if fallback != "" {
+			interp.Warnings = append([]string{fallback}, interp.Warnings...)
+		}
+	}
+	interp.Question = question
+	interp.LabID = labID
+	return interp, nil
+}
+
+// mentionedLab 返回问题中以 lab N 形式提到的实验室
+func mentionedLab(question string) int64 {
+	m := labRe.FindStringSubmatch(strings.ToLower(question))
+	if m == nil {
+		return 0
+	}
+	id := m[1]
+	if id == "" {
+		id = m[2]
+	}
+	labID, _ := strconv.ParseInt(id, 10, 64)
+	return labID
+}
+
+func (s *Service) vocabulary(ctx context.Context, labID int64) (*vocabulary, error) {
+	workflows := make([]*model.Workflow, 0, 32)
+	if err := s.baseDB.FindDatas(ctx, &workflows, map[string]any{
+		"lab_id": labID,
+	}, "id", "name"); err != nil {
+		return nil, err
+	}
+	devices := make([]*model.Device, 0, 32)
+	if err := s.baseDB.FindDatas(ctx, &devices, map[string]any{
+		"lab_id": labID,
+	}, "id", "name", "display_name"); err != nil {
+		return nil, err
+	}
+
+	vocab := &vocabulary{
+		Workflows: make([]*vocabEntry, 0, len(workflows)),
+		Devices:   make([]*vocabEntry, 0, len(devices)),
+	}
+	for _, w := range workflows {
+		vocab.Workflows = append(vocab.Workflows, &vocabEntry{ID: w.ID, Name: w.Name, Names: []string{w.Name}})
+	}
+	for _, d := range devices {
+		names := []string{d.Name}
+		if d.DisplayName != "" && d.DisplayName != d.Name {
+			names = append(names, d.DisplayName)
+		}
+		vocab.Devices = append(vocab.Devices, &vocabEntry{ID: d.ID, Name: d.Name, Names: names})
+	}
+	return vocab, nil
+}
+
+// llmFilter 模型返回的查询条件
+type llmFilter struct {
+	Target    string   `json:"target"`
+	Status    string   `json:"status"`
+	EventType string   `json:"event_type"`
+	Workflow  string   `json:"workflow"`
+	Device    string   `json:"device"`
+	StartTime string   `json:"start_time"`
+	EndTime   string   `json:"end_time"`
+	Labels    []string `json:"labels"`
+}
+
+func (s *Service) interpretLLM(ctx context.Context, userID string, labID int64, question string, now time.Time, vocab *vocabulary) (*model.HistoryInterpretation, error) {
+	text, err := s.complete(ctx, &model.AssistantAudit{
+		Kind:   model.AssistantKindQuery,
+		UserID: userID,
+		LabID:  labID,
+	}, querySystemPrompt, buildQueryPrompt(question, now, vocab))
+	if err != nil {
+		return nil, err
+	}
+
+	body := strings.TrimSpace(text)
+	if start, end := strings.Index(body, "{"), strings.LastIndex(body, "}"); start >= 0 && end > start {
+		body = body[start : end+1]
+	}
+	out := &llmFilter{}
+	if err := json.Unmarshal([]byte(body), out); err != nil {
+		return nil, code.AssistantProviderErr.WithMsgf("invalid answer: %s", truncate(text, maxDataLen))
+	}
+	return resolveLLM(out, vocab), nil
+}
+
+// buildQueryPrompt 列出实验室的工作流和设备名称，模型只能从中选择
+func buildQueryPrompt(question string, now time.Time, vocab *vocabulary) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "current time: %s\n", now.Format(time.RFC3339))
+	for _, group := range []struct {
+		title   string
+		entries []*vocabEntry
+	}{{"workflows", vocab.Workflows}, {"devices", vocab.Devices}} {
+		fmt.Fprintf(b, "\n%s:\n", group.title)
+		for i, entry := range group.entries {
+			if i == maxVocabulary {
+				fmt.Fprintf(b, "- ... %d more\n", len(group.entries)-i)
+				break
+			}
+			fmt.Fprintf(b, "- %s\n", strings.Join(entry.Names, " / "))
+		}
+	}
+	fmt.Fprintf(b, "\nquestion: %s\n", question)
+	return b.String()
+}
+
+// resolveLLM 校验模型返回的条件，名称必须与实验室中的对象完全一致
+func resolveLLM(out *llmFilter, vocab *vocabulary) *model.HistoryInterpretation {
+	interp := &model.HistoryInterpretation{
+		Target:   model.SavedViewWorkflow,
+		Source:   model.InterpretSourceLLM,
+		Warnings: []string{},
+	}
+	switch target := model.SavedViewTarget(out.Target); {
+	case target == "":
+	case target.Valid():
+		interp.Target = target
+	default:
+		interp.Warnings = append(interp.Warnings, fmt.Sprintf("unknown target %q ignored", out.Target))
+	}
+
+	if out.Status != "" {
+		if model.ExecutionStatus(out.Status).Valid() {
+			interp.Filters.Status = out.Status
+		} else {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("unknown status %q ignored", out.Status))
+		}
+	}
+	if out.EventType != "" {
+		if model.DeviceEventType(out.EventType).Valid() {
+			interp.Filters.EventType = out.EventType
+		} else {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("unknown event type %q ignored", out.EventType))
+		}
+	}
+	if out.Workflow != "" {
+		if entry := exactEntry(vocab.Workflows, out.Workflow); entry != nil {
+			setWorkflow(interp, entry)
+		} else {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("unknown workflow %q ignored", out.Workflow))
+		}
+	}
+	if out.Device != "" {
+		if entry := exactEntry(vocab.Devices, out.Device); entry != nil {
+			setDevice(interp, entry)
+		} else {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("unknown device %q ignored", out.Device))
+		}
+	}
+	for _, t := range []struct {
+		field string
+		value string
+		dst   **time.Time
+	}{{"start_time", out.StartTime, &interp.Filters.StartTime}, {"end_time", out.EndTime, &interp.Filters.EndTime}} {
+		if t.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, t.value)
+		if err != nil {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("invalid %s %q ignored", t.field, t.value))
+			continue
+		}
+		*t.dst = &parsed
+	}
+	for _, label := range out.Labels {
+		if _, err := model.ParseLabelFilters([]string{label}); err != nil {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("invalid label %q ignored", label))
+			continue
+		}
+		interp.Filters.Labels = append(interp.Filters.Labels, label)
+	}
+
+	finalize(interp)
+	return interp
+}
+
+// interpretRules 按内置的关键词规则解析问题，支持中英文的状态、事件、时间范围
+// 和标签，工作流和设备按名称匹配
+func interpretRules(question string, now time.Time, vocab *vocabulary) *model.HistoryInterpretation {
+	q := strings.ToLower(question)
+	interp := &model.HistoryInterpretation{
+		Target:   model.SavedViewWorkflow,
+		Source:   model.InterpretSourceRules,
+		Warnings: []string{},
+	}
+
+	for _, p := range eventPhrases {
+		if p.re.MatchString(q) {
+			interp.Target = model.SavedViewDeviceEvent
+			interp.Filters.EventType = string(p.value)
+			break
+		}
+	}
+	if eventWordRe.MatchString(q) {
+		interp.Target = model.SavedViewDeviceEvent
+		if interp.Filters.EventType == "" && errorWordRe.MatchString(q) {
+			interp.Filters.EventType = string(model.DeviceEventError)
+		}
+	}
+	if interp.Target == model.SavedViewWorkflow {
+		for _, p := range statusPhrases {
+			if p.re.MatchString(q) {
+				interp.Filters.Status = string(p.value)
+				break
+			}
+		}
+	}
+
+	if entry := mentionedEntry(q, vocab.Workflows); entry != nil {
+		setWorkflow(interp, entry)
+	}
+	if entry := mentionedEntry(q, vocab.Devices); entry != nil {
+		setDevice(interp, entry)
+	}
+	for _, m := range labelRe.FindAllStringSubmatch(question, -1) {
+		interp.Filters.Labels = append(interp.Filters.Labels, m[1]+":"+m[2])
+	}
+	interp.Filters.StartTime, interp.Filters.EndTime = timeRange(q, now)
+
+	f := interp.Filters
+	if f.Status == "" && f.EventType == "" && f.WorkflowID == nil && f.DeviceID == nil &&
+		len(f.Labels) == 0 && f.StartTime == nil && interp.Target == model.SavedViewWorkflow {
+		interp.Warnings = append(interp.Warnings, "no filter recognized in the question")
+	}
+	finalize(interp)
+	return interp
+}
+
+// timeRange 解析问题中的时间范围，日历上的周从周一开始，使用 now 的时区
+func timeRange(q string, now time.Time) (*time.Time, *time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	span := func(start, end time.Time) (*time.Time, *time.Time) {
+		return &start, &end
+	}
+
+	for _, re := range []*regexp.Regexp{lastNRe, lastNZhRe} {
+		if m := re.FindStringSubmatch(q); m != nil {
+			n, err := strconv.Atoi(m[1])
+			if err != nil || n <= 0 {
+				continue
+			}
+			if strings.HasPrefix(m[2], "month") || strings.HasSuffix(m[2], "月") {
+				return span(now.AddDate(0, -n, 0), now)
+			}
+			return span(now.Add(-time.Duration(n)*unitDuration[m[2]]), now)
+		}
+	}
+	switch {
+	case lastHourRe.MatchString(q):
+		return span(now.Add(-time.Hour), now)
+	case todayRe.MatchString(q):
+		return span(day, now)
+	case yesterdayRe.MatchString(q):
+		return span(day.AddDate(0, 0, -1), day)
+	case thisWeekRe.MatchString(q):
+		return span(monday, now)
+	case lastWeekRe.MatchString(q):
+		return span(monday.AddDate(0, 0, -7), monday)
+	case thisMonthRe.MatchString(q):
+		return span(month, now)
+	case lastMonthRe.MatchString(q):
+		return span(month.AddDate(0, -1, 0), month)
+	}
+	return nil, nil
+}
+
+// mentionedEntry 返回问题中提到的名称最长的对象，同名时取最新创建的
+func mentionedEntry(q string, entries []*vocabEntry) *vocabEntry {
+	var found *vocabEntry
+	foundLen := 0
+	for _, entry := range entries {
+		for _, name := range entry.Names {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if len([]rune(name)) < 2 || !containsPhrase(q, name) {
+				continue
+			}
+			if len(name) > foundLen || len(name) == foundLen && entry.ID > found.ID {
+				found, foundLen = entry, len(name)
+			}
+		}
+	}
+	return found
+}
+
+// containsPhrase 判断 q 中是否包含 name，字母数字开头或结尾的名称需在单词边界上，
+// 允许复数的 s
+func containsPhrase(q, name string) bool {
+	for offset := 0; offset < len(q); {
+		i := strings.Index(q[offset:], name)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(name)
+		if boundaryBefore(q, start, name) && boundaryAfter(q, end, name) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func boundaryBefore(q string, start int, name string) bool {
+	first := []rune(name)[0]
+	if start == 0 || !isWordRune(first) {
+		return true
+	}
+	prev := []rune(q[:start])
+	return !isWordRune(prev[len(prev)-1])
+}
+
+func boundaryAfter(q string, end int, name string) bool {
+	runes := []rune(name)
+	if end == len(q) || !isWordRune(runes[len(runes)-1]) {
+		return true
+	}
+	rest := q[end:]
+	if strings.HasPrefix(rest, "s") {
+		rest = rest[1:]
+	}
+	return rest == "" || !isWordRune([]rune(rest)[0])
+}
+
+// isWordRune 只有 ASCII 字母数字需要单词边界，中文名称直接按子串匹配
+func isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+func exactEntry(entries []*vocabEntry, name string) *vocabEntry {
+	var found *vocabEntry
+	for _, entry := range entries {
+		for _, n := range entry.Names {
+			if strings.EqualFold(strings.TrimSpace(n), strings.TrimSpace(name)) && (found == nil || entry.ID > found.ID) {
+				found = entry
+			}
+		}
+	}
+	return found
+}
+
+func setWorkflow(interp *model.HistoryInterpretation, entry *vocabEntry) {
+	id := entry.ID
+	interp.Filters.WorkflowID = &id
+	interp.WorkflowName = entry.Name
+}
+
+func setDevice(interp *model.HistoryInterpretation, entry *vocabEntry) {
+	id := entry.ID
+	interp.Filters.DeviceID = &id
+	interp.DeviceName = entry.Name
+}
+
+// finalize 去掉不适用于目标列表的条件，与保存视图的校验一致
+func finalize(interp *model.HistoryInterpretation) {
+	f := &interp.Filters
+	switch interp.Target {
+	case model.SavedViewWorkflow:
+		if f.EventType != "" {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("event type %s does not apply to workflow executions", f.EventType))
+			f.EventType = ""
+		}
+	case model.SavedViewDeviceEvent:
+		if f.Status != "" {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("status %s does not apply to device events", f.Status))
+			f.Status = ""
+		}
+		if f.WorkflowID != nil {
+			interp.Warnings = append(interp.Warnings, fmt.Sprintf("workflow %s does not apply to device events", interp.WorkflowName))
+			f.WorkflowID, interp.WorkflowName = nil, ""
+		}
+		if len(f.Labels) > 0 {
+			interp.Warnings = append(interp.Warnings, "labels do not apply to device events")
+			f.Labels = nil
+		}
+	}
+	if f.StartTime != nil && f.EndTime != nil && f.EndTime.Before(*f.StartTime) {
+		interp.Warnings = append(interp.Warnings, "end_time before start_time ignored")
+		f.EndTime = nil
+	}
+}
@@ -0,0 +1,105 @@
+package assistant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func testVocabulary() *vocabulary {
+	return &vocabulary{
+		Workflows: []*vocabEntry{
+			{ID: 1, Name: "PCR setup", Names: []string{"PCR setup"}},
+			{ID: 2, Name: "PCR", Names: []string{"PCR"}},
+		},
+		Devices: []*vocabEntry{
+			{ID: 10, Name: "centrifuge", Names: []string{"centrifuge", "离心机"}},
+			{ID: 11, Name: "pump", Names: []string{"pump"}},
+		},
+	}
+}
+
+func TestInterpretRules(t *testing.T) {
+	// 2026-10-14 is a Wednesday
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)
+	vocab := testVocabulary()
+
+	interp := interpretRules("failed centrifuge runs last week in lab 3", now, vocab)
+	assert.Equal(t, model.SavedViewWorkflow, interp.Target)
+	assert.Equal(t, "failed", interp.Filters.Status)
+	assert.Equal(t, int64(10), *interp.Filters.DeviceID)
+	assert.Equal(t, "centrifuge", interp.DeviceName)
+	assert.Nil(t, interp.Filters.WorkflowID)
+	assert.Equal(t, time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), *interp.Filters.StartTime)
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), *interp.Filters.EndTime)
+	assert.Empty(t, interp.Warnings)
+	assert.Equal(t, model.InterpretSourceRules, interp.Source)
+
+	interp = interpretRules("PCR setup runs that timed out in the past 3 days with label project=alpha", now, vocab)
+	assert.Equal(t, "timeout", interp.Filters.Status)
+	assert.Equal(t, int64(1), *interp.Filters.WorkflowID)
+	assert.Equal(t, []string{"project:alpha"}, interp.Filters.Labels)
+	assert.Equal(t, now.Add(-72*time.Hour), *interp.Filters.StartTime)
+
+	interp = interpretRules("pumps disconnected yesterday", now, vocab)
+	assert.Equal(t, model.SavedViewDeviceEvent, interp.Target)
+	assert.Equal(t, "disconnected", interp.Filters.EventType)
+	assert.Equal(t, int64(11), *interp.Filters.DeviceID)
+	assert.Equal(t, time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC), *interp.Filters.StartTime)
+
+	interp = interpretRules("离心机最近7天失败的运行", now, vocab)
+	assert.Equal(t, "failed", interp.Filters.Status)
+	assert.Equal(t, int64(10), *interp.Filters.DeviceID)
+	assert.Equal(t, now.Add(-7*24*time.Hour), *interp.Filters.StartTime)
+
+	interp = interpretRules("error events of PCR", now, vocab)
+	assert.Equal(t, model.SavedViewDeviceEvent, interp.Target)
+	assert.Equal(t, "error", interp.Filters.EventType)
+	assert.Nil(t, interp.Filters.WorkflowID)
+	assert.Len(t, interp.Warnings, 1)
+
+	interp = interpretRules("show me something", now, vocab)
+	assert.Equal(t, []string{"no filter recognized in the question"}, interp.Warnings)
+}
+
+func TestMentionedLab(t *testing.T) {
+	assert.Equal(t, int64(3), mentionedLab("failed runs in Lab 3"))
+	assert.Equal(t, int64(12), mentionedLab("实验室12 的运行"))
+	assert.Equal(t, int64(0), mentionedLab("failed runs in the lab"))
+}
+
+func TestContainsPhrase(t *testing.T) {
+	assert.True(t, containsPhrase("failed pcr runs", "pcr"))
+	assert.True(t, containsPhrase("all pumps", "pump"))
+	assert.False(t, containsPhrase("pumping station", "pump"))
+	assert.False(t, containsPhrase("apcr runs", "pcr"))
+	assert.True(t, containsPhrase("离心机失败", "离心机"))
+}
+
+func TestResolveLLM(t *testing.T) {
+	interp := resolveLLM(&llmFilter{
+		Target:    "workflow",
+		Status:    "failed",
+		Workflow:  "pcr setup",
+		Device:    "mixer",
+		StartTime: "2026-10-05T00:00:00Z",
+		EndTime:   "yesterday",
+		Labels:    []string{"project:alpha", "broken"},
+	}, testVocabulary())
+	assert.Equal(t, model.SavedViewWorkflow, interp.Target)
+	assert.Equal(t, "failed", interp.Filters.Status)
+	assert.Equal(t, int64(1), *interp.Filters.WorkflowID)
+	assert.Nil(t, interp.Filters.DeviceID)
+	assert.NotNil(t, interp.Filters.StartTime)
+	assert.Nil(t, interp.Filters.EndTime)
+	assert.Equal(t, []string{"project:alpha"}, interp.Filters.Labels)
+	assert.Len(t, interp.Warnings, 3)
+
+	interp = resolveLLM(&llmFilter{Target: "device_event", Status: "failed", EventType: "offline"}, testVocabulary())
+	assert.Equal(t, model.SavedViewDeviceEvent, interp.Target)
+	assert.Empty(t, interp.Filters.Status)
+	assert.Empty(t, interp.Filters.EventType)
+	assert.Len(t, interp.Warnings, 2)
+}
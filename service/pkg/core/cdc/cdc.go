@@ -0,0 +1,211 @@
+// Package cdc republishes the changes of the history tables, read from a
+// postgres logical replication slot, as ordered history.changed events.
+package cdc
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/eventschema"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/cdc"
+)
+
+const (
+	defaultSlotName  = "studio_history_cdc"
+	defaultBatchSize = 1000
+	defaultInterval  = 5 * time.Second
+)
+
+// Tables 发布变更的历史表，分区表的变更按父表发布
+var Tables = []string{
+	(&model.WorkflowExecutionHistory{}).TableName(),
+	(&model.ActionExecutionHistory{}).TableName(),
+	(&model.DeviceEventHistory{}).TableName(),
+}
+
+// Publisher publishes an event on the event bus
+type Publisher interface {
+	Dispatch(ctx context.Context, eventType eventschema.Type, labUUID uuid.UUID, data any) error
+}
+
+type Service struct {
+	store  cdc.CDCRepo
+	baseDB repo.IDOrUUIDTranslate
+	events Publisher
+	conf   func() config.CDCConfig
+}
+
+func New() *Service {
+	return &Service{
+		store:  cdc.New(),
+		baseDB: repo.NewBaseDB(),
+		events: eventschema.NewDispatcher(),
+		conf: func() config.CDCConfig {
+			return config.GetStudioConfig().CDC
+		},
+	}
+}
+
+// NewWithStore 使用指定的仓库、发布者和配置，用于测试
+func NewWithStore(store cdc.CDCRepo, baseDB repo.IDOrUUIDTranslate, events Publisher, conf config.CDCConfig) *Service {
+	return &Service{
+		store:  store,
+		baseDB: baseDB,
+		events: events,
+		conf:   func() config.CDCConfig { return conf },
+	}
+}
+
+func (s *Service) limits() config.CDCConfig {
+	conf := s.conf()
+	if conf.SlotName == "" {
+		conf.SlotName = defaultSlotName
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = defaultBatchSize
+	}
+	return conf
+}
+
+// Run 发布复制槽中已提交的历史表变更。关闭时删除复制槽，避免其保留的 WAL 占满磁盘；
+// 开启后首次运行创建复制槽，只发布之后的变更
+func (s *Service) Run(ctx context.Context) error {
+	if !s.store.Supported(ctx) {
+		return nil
+	}
+	conf := s.limits()
+	slot, err := s.store.Slot(ctx, conf.SlotName)
+	if err != nil {
+		return err
+	}
+	if !conf.Enabled {
+		if slot != nil {
+			logger.Infof(ctx, "history cdc disabled, drop replication slot: %s", conf.SlotName)
+			return s.store.DropSlot(ctx, conf.SlotName)
+		}
+		return nil
+	}
+	if slot == nil {
+		logger.Infof(ctx, "create history cdc replication slot: %s", conf.SlotName)
+		return s.store.CreateSlot(ctx, conf.SlotName)
+	}
+	if conf.MaxLagBytes > 0 && slot.LagBytes > conf.MaxLagBytes {
+		logger.Warnf(ctx, "history cdc replication slot %s lags %d bytes behind", conf.SlotName, slot.LagBytes)
+	}
+
+	confirmed := slot.ConfirmedFlushLSN
+	for ctx.Err() == nil {
+		rows, err := s.store.PeekChanges(ctx, conf.SlotName, conf.BatchSize)
+		if err != nil {
+			return err
+		}
+		txs, err := parseTransactions(rows, Tables)
+		if err != nil {
+			return err
+		}
+		published, err := s.publish(ctx, confirmed, txs)
+		if published != "" {
+			if err := s.store.Advance(ctx, conf.SlotName, published); err != nil {
+				return err
+			}
+			confirmed = published
+		}
+		if err != nil {
+			return err
+		}
+		if len(rows) < conf.BatchSize || published == "" {
+			break
+		}
+	}
+	return ctx.Err()
+}
+
+// publish publishes the changes of the transactions committed after confirmed
+// in commit order, returning the commit position of the last transaction
+// published completely. A transaction failing midway is published again on
+// the next run, consumers deduplicate by (lsn, seq)
+func (s *Service) publish(ctx context.Context, confirmed string, txs []*transaction) (string, error) {
+	var labIDs []int64
+	for _, tx := range txs {
+		for _, c := range tx.Changes {
+			if labID := rowID(c.Row, "lab_id"); labID > 0 {
+				labIDs = append(labIDs, labID)
+			}
+		}
+	}
+	var labs map[int64]uuid.UUID
+	if len(labIDs) > 0 {
+		labs = s.baseDB.ID2UUID(ctx, &model.Laboratory{}, labIDs...)
+	}
+
+	var published string
+	for _, tx := range txs {
+		if confirmed != "" && compareLSN(tx.LSN, confirmed) <= 0 {
+			// 已确认的事务，复制槽重启后可能再次返回
+			published = tx.LSN
+			continue
+		}
+		for i, c := range tx.Changes {
+			labID := rowID(c.Row, "lab_id")
+			if err := s.events.Dispatch(ctx, eventschema.HistoryChanged, labs[labID], &eventschema.HistoryChangedData{
+				Table:      c.Table,
+				Op:         c.Op,
+				LSN:        tx.LSN,
+				Seq:        i,
+				XID:        tx.XID,
+				CommitTime: tx.CommitTime,
+				ID:         rowID(c.Row, "id"),
+				LabID:      labID,
+				Row:        c.Row,
+			}); err != nil {
+				logger.Errorf(ctx, "publish history change fail lsn: %s, table: %s, err: %+v", tx.LSN, c.Table, err)
+				return published, err
+			}
+			otel.GetMetrics().RecordCDCChange(ctx, c.Table, c.Op)
+		}
+		if len(tx.Changes) > 0 && !tx.CommitTime.IsZero() {
+			otel.GetMetrics().RecordCDCDelay(ctx, time.Since(tx.CommitTime))
+		}
+		published = tx.LSN
+	}
+	return published, nil
+}
+
+// RegisterJob 注册历史表变更发布任务
+func RegisterJob() error {
+	s := New()
+	interval := defaultInterval
+	if secs := s.conf().IntervalSecs; secs > 0 {
+		interval = time.Duration(secs) * time.Second
+	}
+	return jobs.Register(&jobs.Definition{
+		Name:         "history_cdc",
+		Description:  "从逻辑复制槽读取历史表的变更并按提交顺序发布到事件总线",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     interval.String(),
+		Timeout:      time.Minute,
+		Run:          s.Run,
+	})
+}
+
+// RegisterMetrics 注册复制槽积压指标
+func RegisterMetrics() error {
+	s := New()
+	return otel.GetMetrics().ObserveCDCSlot(func(ctx context.Context) (int64, bool, error) {
+		if !s.store.Supported(ctx) {
+			return 0, false, nil
+		}
+		slot, err := s.store.Slot(ctx, s.limits().SlotName)
+		if err != nil || slot == nil {
+			return 0, false, err
+		}
+		return slot.LagBytes, true, nil
+	})
+}
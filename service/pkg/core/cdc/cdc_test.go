@@ -0,0 +1,225 @@
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/eventschema"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+// fakeSlot decodes the transactions committed after its confirmed position
+type fakeSlot struct {
+	slot    *model.ReplicationSlot
+	rows    []*model.DecodedChange
+	created bool
+	dropped bool
+}
+
+func (f *fakeSlot) Supported(context.Context) bool { return true }
+
+func (f *fakeSlot) Slot(context.Context, string) (*model.ReplicationSlot, error) {
+	return f.slot, nil
+}
+
+func (f *fakeSlot) CreateSlot(context.Context, string) error {
+	f.created = true
+	return nil
+}
+
+func (f *fakeSlot) DropSlot(context.Context, string) error {
+	f.dropped = true
+	return nil
+}
+
+func (f *fakeSlot) PeekChanges(_ context.Context, _ string, limit int) ([]*model.DecodedChange, error) {
+	var rows []*model.DecodedChange
+	for _, row := range f.rows {
+		rows = append(rows, row)
+		// 与 postgres 相同，达到 limit 后读完当前事务
+		if len(rows) >= limit && row.Data == commit {
+			break
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeSlot) Advance(_ context.Context, _ string, lsn string) error {
+	f.slot.ConfirmedFlushLSN = lsn
+	rows := f.rows[:0]
+	for _, row := range f.rows {
+		if compareLSN(row.LSN, lsn) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	f.rows = rows
+	return nil
+}
+
+type fakeLabs struct {
+	repo.IDOrUUIDTranslate
+	labs map[int64]uuid.UUID
+}
+
+func (f *fakeLabs) ID2UUID(_ context.Context, _ schema.Tabler, ids ...int64) map[int64]uuid.UUID {
+	res := map[int64]uuid.UUID{}
+	for _, id := range ids {
+		if labUUID, ok := f.labs[id]; ok {
+			res[id] = labUUID
+		}
+	}
+	return res
+}
+
+type published struct {
+	labUUID uuid.UUID
+	data    *eventschema.HistoryChangedData
+}
+
+type fakePublisher struct {
+	events []published
+	failAt int // 第几个事件发布失败，0 表示不失败
+}
+
+func (f *fakePublisher) Dispatch(_ context.Context, eventType eventschema.Type, labUUID uuid.UUID, data any) error {
+	if eventType != eventschema.HistoryChanged {
+		return errors.New("unexpected event type")
+	}
+	if f.failAt > 0 && len(f.events)+1 == f.failAt {
+		f.failAt = 0
+		return errors.New("broker unavailable")
+	}
+	f.events = append(f.events, published{labUUID: labUUID, data: data.(*eventschema.HistoryChangedData)})
+	return nil
+}
+
+const commit = `{"action":"C","timestamp":"2026-10-17 08:00:00.123456+00"}`
+
+// tx builds the wal2json rows of a transaction committed at commitLSN
+func tx(xid int64, commitLSN string, rows ...string) []*model.DecodedChange {
+	changes := []*model.DecodedChange{{LSN: commitLSN, XID: xid, Data: `{"action":"B"}`}}
+	for _, row := range rows {
+		changes = append(changes, &model.DecodedChange{LSN: commitLSN, XID: xid, Data: row})
+	}
+	return append(changes, &model.DecodedChange{LSN: commitLSN, XID: xid, Data: commit})
+}
+
+func newTestService(slot *fakeSlot, events *fakePublisher, labUUID uuid.UUID) *Service {
+	return NewWithStore(slot, &fakeLabs{labs: map[int64]uuid.UUID{7: labUUID}}, events, config.CDCConfig{
+		Enabled:   true,
+		BatchSize: 3,
+	})
+}
+
+func TestRunPublishesInCommitOrder(t *testing.T) {
+	ctx := context.Background()
+	labUUID := uuid.NewV4()
+	var rows []*model.DecodedChange
+	rows = append(rows, tx(100, "0/10",
+		`{"action":"I","table":"workflow_execution_history","columns":[{"name":"id","type":"bigint","value":1},{"name":"lab_id","type":"bigint","value":7},{"name":"params","type":"jsonb","value":"{\"a\":1}"}]}`,
+		`{"action":"I","table":"labs","columns":[{"name":"id","type":"bigint","value":9}]}`,
+	)...)
+	rows = append(rows, tx(101, "0/20",
+		`{"action":"U","table":"action_execution_history","columns":[{"name":"id","type":"bigint","value":2},{"name":"lab_id","type":"bigint","value":7}]}`,
+		`{"action":"I","table":"device_event_history_p202610","columns":[{"name":"id","type":"bigint","value":3},{"name":"lab_id","type":"bigint","value":7}]}`,
+	)...)
+	rows = append(rows, tx(102, "1/0",
+		`{"action":"D","table":"workflow_execution_history","identity":[{"name":"id","type":"bigint","value":1}]}`,
+	)...)
+	slot := &fakeSlot{slot: &model.ReplicationSlot{ConfirmedFlushLSN: "0/1"}, rows: rows}
+	events := &fakePublisher{}
+
+	require.NoError(t, newTestService(slot, events, labUUID).Run(ctx))
+
+	require.Len(t, events.events, 4)
+	first := events.events[0]
+	assert.Equal(t, labUUID, first.labUUID)
+	assert.Equal(t, "workflow_execution_history", first.data.Table)
+	assert.Equal(t, "insert", first.data.Op)
+	assert.Equal(t, "0/10", first.data.LSN)
+	assert.EqualValues(t, 100, first.data.XID)
+	assert.EqualValues(t, 1, first.data.ID)
+	assert.EqualValues(t, 7, first.data.LabID)
+	assert.JSONEq(t, `{"a":1}`, string(first.data.Row["params"].(json.RawMessage)))
+	assert.True(t, first.data.CommitTime.Equal(time.Date(2026, 10, 17, 8, 0, 0, 123456000, time.UTC)))
+
+	assert.Equal(t, "action_execution_history", events.events[1].data.Table)
+	assert.Equal(t, "update", events.events[1].data.Op)
+	assert.Equal(t, 0, events.events[1].data.Seq)
+	assert.Equal(t, "device_event_history", events.events[2].data.Table)
+	assert.Equal(t, 1, events.events[2].data.Seq)
+
+	deleted := events.events[3].data
+	assert.Equal(t, "delete", deleted.Op)
+	assert.EqualValues(t, 1, deleted.ID)
+	assert.Zero(t, deleted.LabID)
+
+	assert.Equal(t, "1/0", slot.slot.ConfirmedFlushLSN)
+	assert.Empty(t, slot.rows)
+}
+
+func TestRunSkipsConfirmedTransactions(t *testing.T) {
+	ctx := context.Background()
+	rows := append(tx(100, "0/10",
+		`{"action":"I","table":"workflow_execution_history","columns":[{"name":"id","type":"bigint","value":1}]}`,
+	), tx(101, "0/20",
+		`{"action":"I","table":"workflow_execution_history","columns":[{"name":"id","type":"bigint","value":2}]}`,
+	)...)
+	slot := &fakeSlot{slot: &model.ReplicationSlot{ConfirmedFlushLSN: "0/10"}, rows: rows}
+	events := &fakePublisher{}
+
+	require.NoError(t, newTestService(slot, events, uuid.NewV4()).Run(ctx))
+	require.Len(t, events.events, 1)
+	assert.EqualValues(t, 2, events.events[0].data.ID)
+}
+
+func TestRunAdvancesToPublishedTransaction(t *testing.T) {
+	ctx := context.Background()
+	rows := append(tx(100, "0/10",
+		`{"action":"I","table":"workflow_execution_history","columns":[{"name":"id","type":"bigint","value":1}]}`,
+	), tx(101, "0/20",
+		`{"action":"I","table":"workflow_execution_history","columns":[{"name":"id","type":"bigint","value":2}]}`,
+		`{"action":"I","table":"workflow_execution_history","columns":[{"name":"id","type":"bigint","value":3}]}`,
+	)...)
+	slot := &fakeSlot{slot: &model.ReplicationSlot{ConfirmedFlushLSN: "0/1"}, rows: rows}
+	events := &fakePublisher{failAt: 3}
+	s := newTestService(slot, events, uuid.NewV4())
+
+	require.Error(t, s.Run(ctx))
+	assert.Equal(t, "0/10", slot.slot.ConfirmedFlushLSN)
+
+	// 未完成的事务在下次运行时重新发布
+	require.NoError(t, s.Run(ctx))
+	require.Len(t, events.events, 4)
+	assert.EqualValues(t, 2, events.events[2].data.ID)
+	assert.EqualValues(t, 3, events.events[3].data.ID)
+	assert.Equal(t, "0/20", slot.slot.ConfirmedFlushLSN)
+}
+
+func TestRunManagesSlot(t *testing.T) {
+	ctx := context.Background()
+
+	slot := &fakeSlot{}
+	require.NoError(t, newTestService(slot, &fakePublisher{}, uuid.NewV4()).Run(ctx))
+	assert.True(t, slot.created)
+
+	slot = &fakeSlot{slot: &model.ReplicationSlot{ConfirmedFlushLSN: "0/1"}}
+	s := NewWithStore(slot, &fakeLabs{}, &fakePublisher{}, config.CDCConfig{})
+	require.NoError(t, s.Run(ctx))
+	assert.True(t, slot.dropped)
+}
+
+func TestCompareLSN(t *testing.T) {
+	assert.Equal(t, -1, compareLSN("0/FFFFFFFF", "1/0"))
+	assert.Equal(t, 1, compareLSN("16/B374D848", "16/B374D847"))
+	assert.Equal(t, 0, compareLSN("A/1", "a/1"))
+}
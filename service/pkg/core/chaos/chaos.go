@@ -0,0 +1,94 @@
+// Package chaos lets administrators change the dependency failures injected
+// into the instance serving the request, outside production only.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/chaos"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/impersonation"
+)
+
+// ChaosConf 注入的故障，比例为 0 到 1
+type ChaosConf struct {
+	Enabled           bool    `json:"enabled"`
+	RedisLatencyMs    int64   `json:"redis_latency_ms" binding:"min=0"`
+	RedisLatencyRate  float64 `json:"redis_latency_rate" binding:"min=0,max=1"`
+	DBErrorRate       float64 `json:"db_error_rate" binding:"min=0,max=1"`
+	SchedulerDropRate float64 `json:"scheduler_drop_rate" binding:"min=0,max=1"`
+}
+
+type Service struct {
+	audit impersonation.ImpersonationRepo
+}
+
+func New() *Service {
+	return &Service{audit: impersonation.New()}
+}
+
+// Get 当前实例注入的故障，仅管理员可查看
+func (s *Service) Get(ctx context.Context) (*ChaosConf, error) {
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
+	return toConf(chaos.Current()), nil
+}
+
+// Set 修改当前实例注入的故障，重启后恢复为配置文件的值，仅管理员可操作
+func (s *Service) Set(ctx context.Context, req *ChaosConf) (*ChaosConf, error) {
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
+	chaos.Set(chaos.Config{
+		Enabled:           req.Enabled,
+		RedisLatency:      time.Duration(req.RedisLatencyMs) * time.Millisecond,
+		RedisLatencyRate:  req.RedisLatencyRate,
+		DBErrorRate:       req.DBErrorRate,
+		SchedulerDropRate: req.SchedulerDropRate,
+	})
+	conf := chaos.Current()
+	userID := auth.GetCurrentUser(ctx).ID
+	logger.Warnf(ctx, "chaos changed by %s: %+v", userID, conf)
+	// 只影响当前实例，审计中记录实例便于排查
+	host, _ := os.Hostname()
+	if err := s.audit.CreateAuditLog(context.WithoutCancel(ctx), &model.AuditLog{
+		Action: model.AuditChaosChange,
+		UserID: userID,
+		Detail: fmt.Sprintf("instance: %s, %+v", host, *toConf(conf)),
+	}); err != nil {
+		logger.Errorf(ctx, "chaos audit fail: %+v", err)
+	}
+	return toConf(conf), nil
+}
+
+func (s *Service) check(ctx context.Context) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return code.NoPermission
+	}
+	// 生产环境不安装钩子
+	if !chaos.Installed() {
+		return code.ChaosForbiddenErr
+	}
+	return nil
+}
+
+func toConf(conf chaos.Config) *ChaosConf {
+	return &ChaosConf{
+		Enabled:           conf.Enabled,
+		RedisLatencyMs:    conf.RedisLatency.Milliseconds(),
+		RedisLatencyRate:  conf.RedisLatencyRate,
+		DBErrorRate:       conf.DBErrorRate,
+		SchedulerDropRate: conf.SchedulerDropRate,
+	}
+}
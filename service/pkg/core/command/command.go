@@ -0,0 +1,574 @@
+// Package command queues asynchronous commands per device for edge agents to
+// fetch, acknowledge and report results on, with delivery timeouts and retries.
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/device"
+	"github.com/scienceol/studio/service/pkg/core/errorrule"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/core/topology"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/command"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"gorm.io/datatypes"
+)
+
+const (
+	defaultAckTimeout    = 30 * time.Second
+	defaultResultTimeout = 10 * time.Minute
+	defaultMaxAttempts   = 3
+	maxAttempts          = 10
+
+	// MaxPollWait 长轮询最长等待时间，需小于 http server 的写超时
+	MaxPollWait  = 25 * time.Second
+	pollInterval = time.Second
+
+	sweepInterval = 5 * time.Second
+	sweepBatch    = 200
+)
+
+// EnqueueReq 下发设备指令
+type EnqueueReq struct {
+	LabUUID          uuid.UUID      `json:"lab_uuid" binding:"required"`
+	DeviceName       string         `json:"device_name" binding:"required"`
+	Action           string         `json:"action" binding:"required"`
+	ActionType       string         `json:"action_type" binding:"required"`
+	Param            datatypes.JSON `json:"param" swaggertype:"object"`
+	MaxAttempts      int            `json:"max_attempts"`       // 未确认时的最大下发次数，默认 3
+	AckTimeoutSec    int            `json:"ack_timeout_sec"`    // 下发后等待确认的时间，默认 30 秒
+	ResultTimeoutSec int            `json:"result_timeout_sec"` // 确认后等待结果的时间，默认 10 分钟
+}
+
+// GroupEnqueueReq 向拓扑节点（通常为设备组）下的所有设备下发同一指令，不支持该动作的设备跳过
+type GroupEnqueueReq struct {
+	LabUUID          uuid.UUID      `json:"lab_uuid" binding:"required"`
+	GroupUUID        uuid.UUID      `json:"group_uuid" binding:"required"` // 设备组、房间或工作台
+	Action           string         `json:"action" binding:"required"`
+	ActionType       string         `json:"action_type" binding:"required"`
+	Param            datatypes.JSON `json:"param" swaggertype:"object"`
+	MaxAttempts      int            `json:"max_attempts"`
+	AckTimeoutSec    int            `json:"ack_timeout_sec"`
+	ResultTimeoutSec int            `json:"result_timeout_sec"`
+}
+
+// GroupListReq 查询设备组指令
+type GroupListReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+	common.PageReq
+}
+
+// GroupCommandResp 设备组指令及其汇总结果，详情中包含每个设备的指令
+type GroupCommandResp struct {
+	*model.DeviceGroupCommand
+	Summary  *model.GroupCommandSummary `json:"summary"`
+	Commands []*model.DeviceCommand     `json:"commands,omitempty"`
+}
+
+// ListReq 查询设备指令
+type ListReq struct {
+	LabUUID    uuid.UUID `form:"lab_uuid" binding:"required"`
+	DeviceName string    `form:"device_name"`
+	Status     string    `form:"status"`
+	Page       int       `form:"page,default=1"`
+	PageSize   int       `form:"page_size,default=20"`
+}
+
+// PollReq edge 拉取指令，Devices 为空时拉取实验室所有设备的指令
+type PollReq struct {
+	Devices []string      `form:"devices"`
+	Wait    time.Duration `form:"-"`
+}
+
+// ResultReq edge 上报指令结果
+type ResultReq struct {
+	Success bool           `json:"success"`
+	Result  datatypes.JSON `json:"result" swaggertype:"object"`
+	Error   string         `json:"error"`
+}
+
+type Service struct {
+	store    command.CommandRepo
+	history  history.HistoryRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	devices  *device.Registry
+	topology *topology.Service
+}
+
+func New() *Service {
+	return &Service{
+		store:    command.New(),
+		history:  history.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		devices:  device.New(),
+		topology: topology.New(),
+	}
+}
+
+// Enqueue 校验设备能力后创建指令和待执行的动作记录
+func (s *Service) Enqueue(ctx context.Context, req *EnqueueReq) (*model.DeviceCommand, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MaxAttempts > maxAttempts {
+		return nil, code.ParamErr.WithMsgf("max_attempts must not exceed %d", maxAttempts)
+	}
+	if err := s.devices.ValidateAction(ctx, labID, req.DeviceName, req.Action, req.ActionType, req.Param); err != nil {
+		return nil, err
+	}
+	return s.Dispatch(ctx, labID, req, userInfo.ID)
+}
+
+// Dispatch 不校验设备能力直接创建指令，供服务内部下发 agent 升级等系统指令
+func (s *Service) Dispatch(ctx context.Context, labID int64, req *EnqueueReq, createdBy string) (*model.DeviceCommand, error) {
+	node := &model.MaterialNode{}
+	if err := s.baseDB.GetData(ctx, node, map[string]any{
+		"lab_id": labID,
+		"name":   req.DeviceName,
+		"type":   model.MATERIALDEVICE,
+	}, "id", "uuid"); err != nil {
+		node.UUID = uuid.NewNil()
+	}
+
+	cmd, action := newCommand(labID, node, req, createdBy)
+	if err := s.store.CreateCommand(ctx, cmd, action); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// EnqueueGroup 将指令扇出到拓扑节点下的所有设备，每个设备一条指令，不支持该动作或参数不符合的设备跳过
+func (s *Service) EnqueueGroup(ctx context.Context, req *GroupEnqueueReq) (*GroupCommandResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if req.MaxAttempts > maxAttempts {
+		return nil, code.ParamErr.WithMsgf("max_attempts must not exceed %d", maxAttempts)
+	}
+
+	group, deviceIDs, err := s.topology.Resolve(ctx, labID, req.GroupUUID)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*model.MaterialNode, 0, len(deviceIDs))
+	if len(deviceIDs) > 0 {
+		if err := s.baseDB.FindDatas(ctx, &nodes, map[string]any{
+			"id": deviceIDs,
+		}, "id", "uuid", "name"); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	groupCmd := &model.DeviceGroupCommand{
+		LabID:      labID,
+		NodeID:     group.ID,
+		NodeName:   group.Name,
+		Action:     req.Action,
+		ActionType: req.ActionType,
+		Param:      req.Param,
+		Skipped:    []model.GroupCommandSkip{},
+		CreatedBy:  userInfo.ID,
+	}
+	cmds := make([]*model.DeviceCommand, 0, len(nodes))
+	actions := make([]*model.ActionExecutionHistory, 0, len(nodes))
+	for _, node := range nodes {
+		if err := s.devices.ValidateAction(ctx, labID, node.Name, req.Action, req.ActionType, req.Param); err != nil {
+			groupCmd.Skipped = append(groupCmd.Skipped, model.GroupCommandSkip{
+				DeviceName: node.Name,
+				Error:      err.Error(),
+			})
+			continue
+		}
+		cmd, action := newCommand(labID, node, &EnqueueReq{
+			DeviceName:       node.Name,
+			Action:           req.Action,
+			ActionType:       req.ActionType,
+			Param:            req.Param,
+			MaxAttempts:      req.MaxAttempts,
+			AckTimeoutSec:    req.AckTimeoutSec,
+			ResultTimeoutSec: req.ResultTimeoutSec,
+		}, userInfo.ID)
+		cmds = append(cmds, cmd)
+		actions = append(actions, action)
+	}
+	if len(cmds) == 0 {
+		return nil, code.DeviceGroupNoTargetErr.WithMsgf("no device of %s can receive action %s", group.Name, req.Action)
+	}
+
+	if err := s.store.CreateGroupCommand(ctx, groupCmd, cmds, actions); err != nil {
+		return nil, err
+	}
+	return &GroupCommandResp{
+		DeviceGroupCommand: groupCmd,
+		Summary: model.SummarizeGroupCommand(map[model.DeviceCommandStatus]int64{
+			model.DeviceCommandQueued: int64(len(cmds)),
+		}),
+		Commands: cmds,
+	}, nil
+}
+
+// ListGroups 分页查询实验室的设备组指令及其汇总结果
+func (s *Service) ListGroups(ctx context.Context, req *GroupListReq) (*common.ListResp[*GroupCommandResp], error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	req.Normalize()
+
+	groups, total, err := s.store.ListGroupCommands(ctx, labID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+	counts, err := s.store.CountGroupStatuses(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*GroupCommandResp, 0, len(groups))
+	for _, g := range groups {
+		items = append(items, &GroupCommandResp{
+			DeviceGroupCommand: g,
+			Summary:            model.SummarizeGroupCommand(counts[g.ID]),
+		})
+	}
+	return common.NewListResp(items, total, req.Page, req.PageSize), nil
+}
+
+// GetGroup 获取设备组指令的汇总结果及每个设备的指令
+func (s *Service) GetGroup(ctx context.Context, groupUUID uuid.UUID) (*GroupCommandResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	group, err := s.store.GetGroupCommandByUUID(ctx, groupUUID)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  group.LabID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return nil, code.NoPermission
+	}
+
+	cmds, err := s.store.ListGroupMembers(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[model.DeviceCommandStatus]int64)
+	for _, cmd := range cmds {
+		counts[cmd.Status]++
+	}
+	return &GroupCommandResp{
+		DeviceGroupCommand: group,
+		Summary:            model.SummarizeGroupCommand(counts),
+		Commands:           cmds,
+	}, nil
+}
+
+// newCommand 创建待入队的指令及其待执行的动作记录，未指定的投递参数使用默认值
+func newCommand(labID int64, node *model.MaterialNode, req *EnqueueReq, createdBy string) (*model.DeviceCommand, *model.ActionExecutionHistory) {
+	if req.MaxAttempts <= 0 {
+		req.MaxAttempts = defaultMaxAttempts
+	}
+	if req.AckTimeoutSec <= 0 {
+		req.AckTimeoutSec = int(defaultAckTimeout / time.Second)
+	}
+	if req.ResultTimeoutSec <= 0 {
+		req.ResultTimeoutSec = int(defaultResultTimeout / time.Second)
+	}
+
+	cmd := &model.DeviceCommand{
+		LabID:            labID,
+		DeviceID:         node.ID,
+		DeviceUUID:       node.UUID,
+		DeviceName:       req.DeviceName,
+		Action:           req.Action,
+		ActionType:       req.ActionType,
+		Param:            req.Param,
+		MaxAttempts:      req.MaxAttempts,
+		AckTimeoutSec:    req.AckTimeoutSec,
+		ResultTimeoutSec: req.ResultTimeoutSec,
+		CreatedBy:        createdBy,
+	}
+	action := &model.ActionExecutionHistory{
+		LabID:      labID,
+		DeviceID:   node.ID,
+		DeviceUUID: node.UUID,
+		DeviceName: req.DeviceName,
+		ActionType: req.ActionType,
+		ActionName: req.Action,
+		Input:      req.Param,
+		Status:     model.ExecutionStatusPending,
+	}
+	return cmd, action
+}
+
+// Get 获取指令详情，仅实验室成员可以查看
+func (s *Service) Get(ctx context.Context, cmdUUID uuid.UUID) (*model.DeviceCommand, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	cmd, err := s.store.GetCommandByUUID(ctx, cmdUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  cmd.LabID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return nil, code.NoPermission
+	}
+	return cmd, nil
+}
+
+// List 分页查询实验室指令
+func (s *Service) List(ctx context.Context, req *ListReq) (*common.ListResp[*model.DeviceCommand], error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := &command.CommandQuery{
+		LabID:      labID,
+		DeviceName: req.DeviceName,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+	if req.Status != "" {
+		status := model.DeviceCommandStatus(req.Status)
+		query.Status = &status
+	}
+
+	items, total, err := s.store.ListCommands(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(items, total, req.Page, req.PageSize), nil
+}
+
+// Poll edge 长轮询拉取下一条指令，等待超时返回 nil
+func (s *Service) Poll(ctx context.Context, req *PollReq) (*model.DeviceCommand, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+
+	wait := min(max(req.Wait, 0), MaxPollWait)
+	deadline := time.Now().Add(wait)
+	for {
+		cmd, err := s.store.ClaimNext(ctx, labUser.LabID, req.Devices)
+		if err != nil {
+			return nil, err
+		}
+		if cmd != nil {
+			s.recordEvent(ctx, cmd, model.DeviceEventCommandSent, map[string]any{
+				"attempt": cmd.Attempts,
+			})
+			return cmd, nil
+		}
+
+		if !time.Now().Add(pollInterval).Before(deadline) {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Ack edge 确认收到指令
+func (s *Service) Ack(ctx context.Context, cmdUUID uuid.UUID) (*model.DeviceCommand, error) {
+	cmd, err := s.edgeCommand(ctx, cmdUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Ack(ctx, cmd); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// Result edge 上报指令执行结果
+func (s *Service) Result(ctx context.Context, cmdUUID uuid.UUID, req *ResultReq) (*model.DeviceCommand, error) {
+	cmd, err := s.edgeCommand(ctx, cmdUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := model.DeviceCommandSucceeded
+	var errMsg *string
+	var errCategory string
+	if !req.Success {
+		status = model.DeviceCommandFailed
+		errMsg = &req.Error
+		errCategory = errorrule.Classify(ctx, cmd.LabID, req.Error)
+	}
+	if err := s.store.Finish(ctx, cmd, status, req.Result, errMsg, errCategory); err != nil {
+		return nil, err
+	}
+
+	s.recordEvent(ctx, cmd, model.DeviceEventCommandResult, map[string]any{
+		"status": cmd.Status,
+		"error":  req.Error,
+	})
+	return cmd, nil
+}
+
+// Sweep 处理超时未确认或未上报结果的指令，未确认的在次数内重新入队
+func (s *Service) Sweep(ctx context.Context) error {
+	overdue, err := s.store.ListOverdue(ctx, time.Now(), sweepBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, cmd := range overdue {
+		if cmd.Status == model.DeviceCommandDelivered && cmd.Attempts < cmd.MaxAttempts {
+			if _, err := s.store.Requeue(ctx, cmd); err != nil {
+				return err
+			}
+			continue
+		}
+
+		msg := "result timeout"
+		if cmd.Status == model.DeviceCommandDelivered {
+			msg = "delivery not acknowledged"
+		}
+		category := errorrule.Classify(ctx, cmd.LabID, msg)
+		if err := s.store.Finish(ctx, cmd, model.DeviceCommandTimeout, nil, &msg, category); err != nil {
+			// 超时处理期间 edge 可能已上报结果
+			logger.Warnf(ctx, "finish overdue command fail uuid: %s, err: %+v", cmd.UUID, err)
+			continue
+		}
+		s.recordEvent(ctx, cmd, model.DeviceEventCommandResult, map[string]any{
+			"status": cmd.Status,
+			"error":  msg,
+		})
+	}
+	return nil
+}
+
+func (s *Service) edgeCommand(ctx context.Context, cmdUUID uuid.UUID) (*model.DeviceCommand, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+
+	cmd, err := s.store.GetCommandByUUID(ctx, cmdUUID)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.LabID != labUser.LabID {
+		return nil, code.NoPermission
+	}
+	return cmd, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+func (s *Service) recordEvent(ctx context.Context, cmd *model.DeviceCommand, eventType model.DeviceEventType, extra map[string]any) {
+	payload := map[string]any{
+		"command_uuid": cmd.UUID,
+		"device_name":  cmd.DeviceName,
+		"action":       cmd.Action,
+		"action_type":  cmd.ActionType,
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	data, _ := json.Marshal(payload)
+
+	if err := s.history.CreateDeviceEvent(ctx, &model.DeviceEventHistory{
+		LabID:      cmd.LabID,
+		DeviceID:   cmd.DeviceID,
+		DeviceUUID: cmd.DeviceUUID,
+		EventType:  eventType,
+		EventData:  data,
+		Timestamp:  time.Now(),
+	}); err != nil {
+		logger.Warnf(ctx, "record device command event fail uuid: %s, event: %s, err: %+v", cmd.UUID, eventType, err)
+	}
+}
+
+// RegisterJob 注册指令超时检测后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "device_command_timeout",
+		Description:  "重新下发未确认的设备指令，结果超时的指令标记为超时",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     sweepInterval.String(),
+		Timeout:      time.Minute,
+		Run:          s.Sweep,
+	})
+}
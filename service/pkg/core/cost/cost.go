@@ -0,0 +1,477 @@
+// Package cost prices lab activity from history records — device hours, action
+// executions and result file storage — using a per-lab cost model, and exposes
+// the daily cost breakdown and a billing export.
+package cost
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/cost"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"gorm.io/datatypes"
+)
+
+const (
+	day                  = 24 * time.Hour
+	defaultRangeDays     = 30
+	maxRangeDays         = 366
+	defaultRecomputeDays = 2
+	defaultCurrency      = "CNY"
+	// 存储按 GB-月定价，按天计费
+	daysPerMonth = 30
+)
+
+// billing export columns
+var exportHeader = []string{
+	"account_id", "usage_date", "category", "item", "quantity", "unit", "unit_price", "amount", "currency",
+}
+
+// ModelReq 设置实验室计费模型
+type ModelReq struct {
+	LabUUID            uuid.UUID          `json:"lab_uuid" binding:"required"`
+	Currency           string             `json:"currency" binding:"required,len=3"`
+	DeviceHourRate     float64            `json:"device_hour_rate" binding:"gte=0"`
+	DeviceHourRates    map[string]float64 `json:"device_hour_rates"` // 按设备名称单独定价
+	ActionTypeRates    map[string]float64 `json:"action_type_rates"` // 按动作类型定价的单次执行价格
+	StorageGBMonthRate float64            `json:"storage_gb_month_rate" binding:"gte=0"`
+}
+
+// RangeReq 按日期范围查询费用，默认最近 30 天
+type RangeReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+	Start   time.Time `form:"start" time_format:"2006-01-02"`
+	End     time.Time `form:"end" time_format:"2006-01-02"` // 包含当天
+}
+
+// CategoryCost 按类别汇总的费用
+type CategoryCost struct {
+	Category model.CostCategory `json:"category"`
+	Amount   float64            `json:"amount"`
+}
+
+// ItemCost 按计费项汇总的费用
+type ItemCost struct {
+	Category model.CostCategory `json:"category"`
+	Item     string             `json:"item"`
+	Unit     string             `json:"unit"`
+	Quantity float64            `json:"quantity"`
+	Amount   float64            `json:"amount"`
+}
+
+// DailyCost 每日费用
+type DailyCost struct {
+	Day    string  `json:"day"`
+	Amount float64 `json:"amount"`
+}
+
+// BreakdownResp 实验室费用明细
+type BreakdownResp struct {
+	Currency   string          `json:"currency"`
+	Start      string          `json:"start"`
+	End        string          `json:"end"`
+	Total      float64         `json:"total"`
+	Categories []*CategoryCost `json:"categories"`
+	Items      []*ItemCost     `json:"items"`
+	Daily      []*DailyCost    `json:"daily"`
+}
+
+type Service struct {
+	store    cost.CostRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    cost.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// GetModel 获取实验室生效的计费模型，未单独设置时返回全局默认模型
+func (s *Service) GetModel(ctx context.Context, labUUID uuid.UUID) (*model.CostModel, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.labModel(ctx, labID)
+}
+
+// SetModel 设置实验室计费模型，仅实验室创建者可以设置，新价格从最近未结算的日期开始生效
+func (s *Service) SetModel(ctx context.Context, req *ModelReq) (*model.CostModel, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	lab, err := s.envStore.GetLabByUUID(ctx, req.LabUUID, "id", "user_id")
+	if err != nil {
+		return nil, err
+	}
+	if lab.UserID != userInfo.ID {
+		return nil, code.NoPermission
+	}
+	for name, rate := range req.DeviceHourRates {
+		if rate < 0 {
+			return nil, code.ParamErr.WithMsgf("device %s rate must not be negative", name)
+		}
+	}
+	for actionType, rate := range req.ActionTypeRates {
+		if rate < 0 {
+			return nil, code.ParamErr.WithMsgf("action type %s rate must not be negative", actionType)
+		}
+	}
+
+	data := &model.CostModel{
+		LabID:              lab.ID,
+		Currency:           strings.ToUpper(req.Currency),
+		DeviceHourRate:     req.DeviceHourRate,
+		DeviceHourRates:    datatypes.NewJSONType(nonNilRates(req.DeviceHourRates)),
+		ActionTypeRates:    datatypes.NewJSONType(nonNilRates(req.ActionTypeRates)),
+		StorageGBMonthRate: req.StorageGBMonthRate,
+		UpdatedBy:          userInfo.ID,
+	}
+	if err := s.store.UpsertCostModel(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Breakdown 按类别、计费项和日期汇总实验室费用
+func (s *Service) Breakdown(ctx context.Context, req *RangeReq) (*BreakdownResp, error) {
+	labID, err := s.checkMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := dateRange(req)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.store.ListRecords(ctx, labID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &BreakdownResp{
+		Start:      start.Format(time.DateOnly),
+		End:        end.Format(time.DateOnly),
+		Categories: []*CategoryCost{},
+		Items:      []*ItemCost{},
+		Daily:      []*DailyCost{},
+	}
+	categories := make(map[model.CostCategory]*CategoryCost)
+	items := make(map[string]*ItemCost)
+	daily := make(map[string]*DailyCost)
+	for _, r := range records {
+		resp.Currency = r.Currency
+		resp.Total += r.Amount
+
+		c, ok := categories[r.Category]
+		if !ok {
+			c = &CategoryCost{Category: r.Category}
+			categories[r.Category] = c
+			resp.Categories = append(resp.Categories, c)
+		}
+		c.Amount += r.Amount
+
+		key := string(r.Category) + "\x00" + r.Item
+		item, ok := items[key]
+		if !ok {
+			item = &ItemCost{Category: r.Category, Item: r.Item, Unit: r.Unit}
+			items[key] = item
+			resp.Items = append(resp.Items, item)
+		}
+		item.Quantity += r.Quantity
+		item.Amount += r.Amount
+
+		dayKey := r.Day.Format(time.DateOnly)
+		d, ok := daily[dayKey]
+		if !ok {
+			d = &DailyCost{Day: dayKey}
+			daily[dayKey] = d
+			resp.Daily = append(resp.Daily, d)
+		}
+		d.Amount += r.Amount
+	}
+
+	if resp.Currency == "" {
+		m, err := s.labModel(ctx, labID)
+		if err != nil {
+			return nil, err
+		}
+		resp.Currency = m.Currency
+	}
+	resp.Total = round(resp.Total)
+	for _, c := range resp.Categories {
+		c.Amount = round(c.Amount)
+	}
+	for _, item := range resp.Items {
+		item.Quantity = round(item.Quantity)
+		item.Amount = round(item.Amount)
+	}
+	sort.SliceStable(resp.Items, func(i, j int) bool {
+		return resp.Items[i].Amount > resp.Items[j].Amount
+	})
+	for _, d := range resp.Daily {
+		d.Amount = round(d.Amount)
+	}
+	return resp, nil
+}
+
+// Export 导出计费系统使用的 CSV，每行为实验室一天一个计费项的费用
+func (s *Service) Export(ctx context.Context, req *RangeReq) (*bytes.Buffer, error) {
+	labID, err := s.checkMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := dateRange(req)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.store.ListRecords(ctx, labID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.Write(exportHeader); err != nil {
+		return nil, err
+	}
+	account := req.LabUUID.String()
+	for _, r := range records {
+		if err := w.Write([]string{
+			account,
+			r.Day.Format(time.DateOnly),
+			string(r.Category),
+			r.Item,
+			formatFloat(r.Quantity),
+			r.Unit,
+			formatFloat(r.UnitPrice),
+			formatFloat(r.Amount),
+			r.Currency,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Compute 重新计算最近几天所有实验室的费用，更早的日期视为已结算不再变化
+func (s *Service) Compute(ctx context.Context) error {
+	days := config.GetStudioConfig().Cost.RecomputeDays
+	if days <= 0 {
+		days = defaultRecomputeDays
+	}
+
+	today := truncateDay(time.Now())
+	for i := days - 1; i >= 0; i-- {
+		if err := s.computeDay(ctx, today.AddDate(0, 0, -i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) computeDay(ctx context.Context, date time.Time) error {
+	end := date.AddDate(0, 0, 1)
+	deviceHours, err := s.store.DeviceHours(ctx, date, end)
+	if err != nil {
+		return err
+	}
+	actions, err := s.store.ActionCounts(ctx, date, end)
+	if err != nil {
+		return err
+	}
+	storage, err := s.store.StorageGB(ctx, end)
+	if err != nil {
+		return err
+	}
+
+	labIDs := make([]int64, 0)
+	seen := make(map[int64]bool)
+	for _, usages := range [][]*model.CostUsage{deviceHours, actions, storage} {
+		for _, u := range usages {
+			if !seen[u.LabID] {
+				seen[u.LabID] = true
+				labIDs = append(labIDs, u.LabID)
+			}
+		}
+	}
+	models, err := s.store.ListCostModels(ctx, labIDs)
+	if err != nil {
+		return err
+	}
+	modelByLab := make(map[int64]*model.CostModel, len(models))
+	for _, m := range models {
+		modelByLab[m.LabID] = m
+	}
+
+	records := priceUsages(date, modelByLab, defaultModel(), deviceHours, actions, storage)
+	if err := s.store.ReplaceDay(ctx, date, records); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "cost accounting day: %s, labs: %d, records: %d", date.Format(time.DateOnly), len(labIDs), len(records))
+	return nil
+}
+
+// priceUsages 按实验室计费模型为一天的用量定价
+func priceUsages(date time.Time, models map[int64]*model.CostModel, fallback *model.CostModel,
+	deviceHours, actions, storage []*model.CostUsage) []*model.CostRecord {
+	modelOf := func(labID int64) *model.CostModel {
+		if m, ok := models[labID]; ok {
+			return m
+		}
+		return fallback
+	}
+
+	records := make([]*model.CostRecord, 0, len(deviceHours)+len(actions)+len(storage))
+	add := func(u *model.CostUsage, category model.CostCategory, unit string, price float64, currency string) {
+		if u.Quantity <= 0 {
+			return
+		}
+		records = append(records, &model.CostRecord{
+			LabID:     u.LabID,
+			Day:       date,
+			Category:  category,
+			Item:      u.Item,
+			Quantity:  round(u.Quantity),
+			Unit:      unit,
+			UnitPrice: price,
+			Amount:    round(u.Quantity * price),
+			Currency:  currency,
+		})
+	}
+
+	for _, u := range deviceHours {
+		m := modelOf(u.LabID)
+		add(u, model.CostCategoryDeviceHour, model.CostUnitHour, m.DeviceRate(u.Item), m.Currency)
+	}
+	for _, u := range actions {
+		m := modelOf(u.LabID)
+		add(u, model.CostCategoryAction, model.CostUnitCount, m.ActionRate(u.Item), m.Currency)
+	}
+	for _, u := range storage {
+		m := modelOf(u.LabID)
+		add(u, model.CostCategoryStorage, model.CostUnitGBDay, m.StorageGBMonthRate/daysPerMonth, m.Currency)
+	}
+	return records
+}
+
+func (s *Service) labModel(ctx context.Context, labID int64) (*model.CostModel, error) {
+	data, err := s.store.GetCostModel(ctx, labID)
+	if err == code.RecordNotFound {
+		data = defaultModel()
+		data.LabID = labID
+		return data, nil
+	}
+	return data, err
+}
+
+// defaultModel 全局配置的计费模型
+func defaultModel() *model.CostModel {
+	conf := config.GetStudioConfig().Cost
+	currency := strings.ToUpper(conf.Currency)
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	return &model.CostModel{
+		Currency:           currency,
+		DeviceHourRate:     conf.DeviceHourRate,
+		DeviceHourRates:    datatypes.NewJSONType(map[string]float64{}),
+		ActionTypeRates:    datatypes.NewJSONType(nonNilRates(conf.ActionTypeRates)),
+		StorageGBMonthRate: conf.StorageGBMonthRate,
+	}
+}
+
+func dateRange(req *RangeReq) (time.Time, time.Time, error) {
+	end := truncateDay(req.End)
+	if req.End.IsZero() {
+		end = truncateDay(time.Now())
+	}
+	start := truncateDay(req.Start)
+	if req.Start.IsZero() {
+		start = end.AddDate(0, 0, -(defaultRangeDays - 1))
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, code.ParamErr.WithMsg("start must not be after end")
+	}
+	if end.Sub(start) >= maxRangeDays*day {
+		return time.Time{}, time.Time{}, code.ParamErr.WithMsgf("range must not exceed %d days", maxRangeDays)
+	}
+	return start, end, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func nonNilRates(rates map[string]float64) map[string]float64 {
+	if rates == nil {
+		return map[string]float64{}
+	}
+	return rates
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (s *Service) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+// RegisterJob 注册费用计算后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "cost_accounting",
+		Description:  "按实验室计费模型从历史记录计算最近几天的费用",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     time.Hour.String(),
+		Timeout:      30 * time.Minute,
+		Run:          s.Compute,
+	})
+}
@@ -0,0 +1,65 @@
+package cost
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestPriceUsages(t *testing.T) {
+	date := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	fallback := &model.CostModel{
+		Currency:        "CNY",
+		DeviceHourRate:  10,
+		DeviceHourRates: datatypes.NewJSONType(map[string]float64{}),
+		ActionTypeRates: datatypes.NewJSONType(map[string]float64{}),
+	}
+	models := map[int64]*model.CostModel{
+		2: {
+			LabID:              2,
+			Currency:           "USD",
+			DeviceHourRate:     1,
+			DeviceHourRates:    datatypes.NewJSONType(map[string]float64{"robot": 4}),
+			ActionTypeRates:    datatypes.NewJSONType(map[string]float64{"pipette": 0.5}),
+			StorageGBMonthRate: 3,
+		},
+	}
+
+	records := priceUsages(date, models, fallback,
+		[]*model.CostUsage{{LabID: 1, Item: "robot", Quantity: 1.5}, {LabID: 2, Item: "robot", Quantity: 2}, {LabID: 2, Item: "oven", Quantity: 3}},
+		[]*model.CostUsage{{LabID: 2, Item: "pipette", Quantity: 4}, {LabID: 2, Item: "move", Quantity: 1}},
+		[]*model.CostUsage{{LabID: 2, Item: model.CostItemResult, Quantity: 10}, {LabID: 1, Item: model.CostItemResult, Quantity: 0}},
+	)
+
+	got := make(map[string]*model.CostRecord)
+	for _, r := range records {
+		assert.Equal(t, date, r.Day)
+		got[fmt.Sprintf("%d/%s/%s", r.LabID, r.Category, r.Item)] = r
+	}
+	assert.Len(t, got, 6)
+	assert.Equal(t, 15.0, got["1/device_hour/robot"].Amount)
+	assert.Equal(t, "CNY", got["1/device_hour/robot"].Currency)
+	assert.Equal(t, 8.0, got["2/device_hour/robot"].Amount)
+	assert.Equal(t, 3.0, got["2/device_hour/oven"].Amount)
+	assert.Equal(t, 2.0, got["2/action/pipette"].Amount)
+	assert.Equal(t, 0.0, got["2/action/move"].Amount)
+	assert.Equal(t, 1.0, got["2/storage/result_file"].Amount)
+	assert.Equal(t, model.CostUnitGBDay, got["2/storage/result_file"].Unit)
+}
+
+func TestDateRange(t *testing.T) {
+	start, end, err := dateRange(&RangeReq{})
+	assert.NoError(t, err)
+	assert.Equal(t, truncateDay(time.Now()), end)
+	assert.Equal(t, end.AddDate(0, 0, -29), start)
+
+	_, _, err = dateRange(&RangeReq{Start: time.Now(), End: time.Now().AddDate(0, 0, -1)})
+	assert.Error(t, err)
+
+	_, _, err = dateRange(&RangeReq{Start: time.Now().AddDate(-2, 0, 0), End: time.Now()})
+	assert.Error(t, err)
+}
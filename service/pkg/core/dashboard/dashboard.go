@@ -0,0 +1,276 @@
+// Package dashboard assembles the lab dashboard from the history, alert,
+// liveness and queue data in one call, each section failing on its own.
+package dashboard
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/alert"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/liveness"
+)
+
+// 看板的分区，响应的 errors 按分区返回失败原因
+const (
+	SectionStats            = "stats"
+	SectionRecentExecutions = "recent_executions"
+	SectionAlerts           = "alerts"
+	SectionLiveness         = "liveness"
+	SectionQueue            = "queue"
+)
+
+const (
+	recentExecutions = 10
+	activeAlerts     = 10
+	// 单个分区的最长耗时，超时的分区返回错误，不影响其他分区
+	sectionTimeout = 5 * time.Second
+)
+
+// Req 看板查询，时间范围只用于统计分区
+type Req struct {
+	LabID     int64      `uri:"lab_id" binding:"required"`
+	StartTime *time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   *time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// ExecutionSummary 最近执行的列表项，不含输入输出
+type ExecutionSummary struct {
+	UUID           uuid.UUID             `json:"uuid"`
+	WorkflowUUID   uuid.UUID             `json:"workflow_uuid"`
+	WorkflowName   string                `json:"workflow_name"`
+	UserID         string                `json:"user_id"`
+	Status         model.ExecutionStatus `json:"status"`
+	StepsTotal     int                   `json:"steps_total"`
+	StepsCompleted int                   `json:"steps_completed"`
+	StepsFailed    int                   `json:"steps_failed"`
+	DurationMs     int64                 `json:"duration_ms"`
+	StartedAt      time.Time             `json:"started_at"`
+	CompletedAt    *time.Time            `json:"completed_at"`
+}
+
+// AlertSummary 未恢复的告警数和最近触发的告警
+type AlertSummary struct {
+	Firing int64          `json:"firing"`
+	Acked  int64          `json:"acked"`
+	Items  []*model.Alert `json:"items"` // 按触发时间倒序
+}
+
+// QueueDepth 排队中和运行中的执行数
+type QueueDepth struct {
+	Pending int64 `json:"pending"`
+	Running int64 `json:"running"`
+}
+
+// Dashboard 实验室看板，失败的分区为空，原因在 Errors 中
+type Dashboard struct {
+	LabID            int64                  `json:"lab_id"`
+	GeneratedAt      time.Time              `json:"generated_at"`
+	Stats            *model.HistoryStats    `json:"stats"`
+	RecentExecutions []*ExecutionSummary    `json:"recent_executions"`
+	Alerts           *AlertSummary          `json:"alerts"`
+	Liveness         *model.LivenessSummary `json:"liveness"`
+	Queue            *QueueDepth            `json:"queue"`
+	Errors           map[string]error       `json:"-"`
+}
+
+type Service struct {
+	history  history.HistoryRepo
+	alerts   alert.AlertRepo
+	liveness liveness.LivenessRepo
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		history:  history.New(),
+		alerts:   alert.New(),
+		liveness: liveness.New(),
+		envStore: environment.New(),
+	}
+}
+
+// Get 并发查询看板的各分区，单个分区失败或超时只记录在 Errors 中
+func (s *Service) Get(ctx context.Context, req *Req) (*Dashboard, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  req.LabID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return nil, code.NoPermission
+	}
+
+	d := &Dashboard{LabID: req.LabID, GeneratedAt: time.Now()}
+	d.Errors = collect(ctx, map[string]func(context.Context) error{
+		SectionStats: func(ctx context.Context) (err error) {
+			d.Stats, err = s.history.GetLabStats(ctx, req.LabID, req.StartTime, req.EndTime, nil)
+			return err
+		},
+		SectionRecentExecutions: func(ctx context.Context) (err error) {
+			d.RecentExecutions, err = s.recentExecutions(ctx, req.LabID)
+			return err
+		},
+		SectionAlerts: func(ctx context.Context) (err error) {
+			d.Alerts, err = s.activeAlerts(ctx, req.LabID)
+			return err
+		},
+		SectionLiveness: func(ctx context.Context) (err error) {
+			d.Liveness, err = s.livenessSummary(ctx, req.LabID)
+			return err
+		},
+		SectionQueue: func(ctx context.Context) (err error) {
+			d.Queue, err = s.queueDepth(ctx, req.LabID)
+			return err
+		},
+	})
+	return d, nil
+}
+
+// collect runs the sections concurrently, returning the errors of the failed
+// ones by name. A section is given sectionTimeout and a panic fails only it.
+// Each section writes its own result, so they need no locking
+func collect(ctx context.Context, sections map[string]func(context.Context) error) map[string]error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[string]error)
+	)
+	for name, run := range sections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sctx, cancel := context.WithTimeout(ctx, sectionTimeout)
+			defer cancel()
+
+			err := func() (err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						logger.Errorf(ctx, "dashboard section %s panic: %v", name, r)
+						err = code.UnDefineErr
+					}
+				}()
+				return run(sctx)
+			}()
+			if err != nil {
+				logger.Errorf(ctx, "dashboard section %s fail: %+v", name, err)
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func (s *Service) recentExecutions(ctx context.Context, labID int64) ([]*ExecutionSummary, error) {
+	executions, _, err := s.history.ListWorkflowExecutions(ctx, &model.HistoryQueryParams{
+		LabID:     labID,
+		Page:      1,
+		PageSize:  recentExecutions,
+		SkipTotal: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(executions) > recentExecutions {
+		executions = executions[:recentExecutions]
+	}
+	ret := make([]*ExecutionSummary, 0, len(executions))
+	for _, e := range executions {
+		ret = append(ret, &ExecutionSummary{
+			UUID:           e.UUID,
+			WorkflowUUID:   e.WorkflowUUID,
+			WorkflowName:   e.WorkflowName,
+			UserID:         e.UserID,
+			Status:         e.Status,
+			StepsTotal:     e.StepsTotal,
+			StepsCompleted: e.StepsCompleted,
+			StepsFailed:    e.StepsFailed,
+			DurationMs:     e.DurationMs,
+			StartedAt:      e.StartedAt,
+			CompletedAt:    e.CompletedAt,
+		})
+	}
+	return ret, nil
+}
+
+// activeAlerts counts the firing and acked alerts and returns the latest of both
+func (s *Service) activeAlerts(ctx context.Context, labID int64) (*AlertSummary, error) {
+	summary := &AlertSummary{}
+	for _, status := range []model.AlertStatus{model.AlertFiring, model.AlertAcked} {
+		items, total, err := s.alerts.ListAlerts(ctx, &model.AlertQuery{
+			LabID:    labID,
+			Status:   &status,
+			Page:     1,
+			PageSize: activeAlerts,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if status == model.AlertFiring {
+			summary.Firing = total
+		} else {
+			summary.Acked = total
+		}
+		summary.Items = append(summary.Items, items...)
+	}
+	sort.SliceStable(summary.Items, func(i, j int) bool {
+		return summary.Items[i].FiredAt.After(summary.Items[j].FiredAt)
+	})
+	if len(summary.Items) > activeAlerts {
+		summary.Items = summary.Items[:activeAlerts]
+	}
+	return summary, nil
+}
+
+func (s *Service) livenessSummary(ctx context.Context, labID int64) (*model.LivenessSummary, error) {
+	devices, err := s.liveness.ListByLab(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	summary := &model.LivenessSummary{}
+	for _, device := range devices {
+		switch device.Status {
+		case model.LivenessOnline:
+			summary.Online++
+		case model.LivenessOffline:
+			summary.Offline++
+		}
+	}
+	return summary, nil
+}
+
+func (s *Service) queueDepth(ctx context.Context, labID int64) (*QueueDepth, error) {
+	depth := &QueueDepth{}
+	for _, status := range []model.ExecutionStatus{model.ExecutionStatusPending, model.ExecutionStatusRunning} {
+		_, count, err := s.history.ListWorkflowExecutions(ctx, &model.HistoryQueryParams{
+			LabID:    labID,
+			Status:   &status,
+			Page:     1,
+			PageSize: 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if status == model.ExecutionStatusPending {
+			depth.Pending = count.Total
+		} else {
+			depth.Running = count.Total
+		}
+	}
+	return depth, nil
+}
@@ -0,0 +1,41 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectIsolatesSections(t *testing.T) {
+	failed := errors.New("query fail")
+	var stats, queue int
+	errs := collect(context.Background(), map[string]func(context.Context) error{
+		SectionStats: func(context.Context) error {
+			stats = 1
+			return nil
+		},
+		SectionAlerts: func(context.Context) error {
+			return failed
+		},
+		SectionLiveness: func(context.Context) error {
+			panic("nil map")
+		},
+		SectionQueue: func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(sectionTimeout), deadline, time.Second)
+			queue = 2
+			return nil
+		},
+	})
+
+	assert.Equal(t, 1, stats)
+	assert.Equal(t, 2, queue)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, failed, errs[SectionAlerts])
+	assert.Equal(t, code.UnDefineErr, errs[SectionLiveness])
+}
@@ -0,0 +1,326 @@
+// Package device manages the device registry reported by edge agents and
+// validates dispatched actions against declared capabilities.
+package device
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/respcache"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/device"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"gorm.io/datatypes"
+)
+
+const maxDevicesPerRegister = 500
+
+// DeviceReq 设备描述，capabilities 声明支持的动作及参数 JSON Schema
+type DeviceReq struct {
+	Name         string                   `json:"name" binding:"required"`
+	DisplayName  string                   `json:"display_name"`
+	Class        string                   `json:"class"`
+	Vendor       string                   `json:"vendor"`
+	Model        string                   `json:"model"`
+	Capabilities []model.DeviceCapability `json:"capabilities"`
+}
+
+// CapacityResp 实验室设备容量
+type CapacityResp struct {
+	DeviceCount int64 `json:"device_count"`
+	MaxDevices  int   `json:"max_devices"` // 0 表示不限制
+	Remaining   int64 `json:"remaining"`   // 还可注册的设备数，不限制时为 -1
+	Overridden  bool  `json:"overridden"`  // 上限是否为实验室单独设置
+}
+
+// SetLimitReq 设置实验室设备上限
+type SetLimitReq struct {
+	MaxDevices *int `json:"max_devices" binding:"required,min=0"` // 0 表示不限制
+}
+
+// RegisterReq edge 批量注册设备
+type RegisterReq struct {
+	AgentVersion string       `json:"agent_version"`
+	Devices      []*DeviceReq `json:"devices" binding:"required,dive"`
+}
+
+type Registry struct {
+	store    device.DeviceRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Registry {
+	return &Registry{
+		store:    device.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// Register edge 注册或更新设备描述，已注册的同名设备整体覆盖
+func (r *Registry) Register(ctx context.Context, req *RegisterReq) ([]*model.Device, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+	if len(req.Devices) == 0 || len(req.Devices) > maxDevicesPerRegister {
+		return nil, code.ParamErr.WithMsgf("devices must contain 1 to %d items", maxDevicesPerRegister)
+	}
+
+	seen := make(map[string]struct{}, len(req.Devices))
+	devices := make([]*model.Device, 0, len(req.Devices))
+	for _, d := range req.Devices {
+		if _, ok := seen[d.Name]; ok {
+			return nil, code.ParamErr.WithMsgf("duplicate device name: %s", d.Name)
+		}
+		seen[d.Name] = struct{}{}
+
+		if err := validateCapabilities(d.Name, d.Capabilities); err != nil {
+			return nil, err
+		}
+		capabilities := d.Capabilities
+		if capabilities == nil {
+			capabilities = []model.DeviceCapability{}
+		}
+
+		devices = append(devices, &model.Device{
+			LabID:        labUser.LabID,
+			Name:         d.Name,
+			DisplayName:  d.DisplayName,
+			Class:        d.Class,
+			Vendor:       d.Vendor,
+			Model:        d.Model,
+			AgentVersion: req.AgentVersion,
+			Capabilities: datatypes.NewJSONSlice(capabilities),
+		})
+	}
+
+	limit, _, err := r.deviceLimit(ctx, labUser.LabID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.UpsertDevices(ctx, labUser.LabID, devices, limit); err != nil {
+		return nil, err
+	}
+	respcache.Invalidate(ctx, respcache.TagDevice)
+	return devices, nil
+}
+
+// Unregister edge 从注册表中移除设备
+func (r *Registry) Unregister(ctx context.Context, name string) error {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return code.UnLogin
+	}
+	if err := r.store.DeleteDevice(ctx, labUser.LabID, name); err != nil {
+		return err
+	}
+	respcache.Invalidate(ctx, respcache.TagDevice)
+	return nil
+}
+
+// List 获取实验室已注册设备，仅实验室成员可以查看
+func (r *Registry) List(ctx context.Context, labUUID uuid.UUID) ([]*model.Device, error) {
+	labID, err := r.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return r.store.ListDevices(ctx, labID)
+}
+
+// Get 获取单个设备描述
+func (r *Registry) Get(ctx context.Context, labUUID uuid.UUID, name string) (*model.Device, error) {
+	labID, err := r.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return r.store.GetDevice(ctx, labID, name)
+}
+
+// Capacity 获取实验室已注册设备数与设备上限
+func (r *Registry) Capacity(ctx context.Context, labUUID uuid.UUID) (*CapacityResp, error) {
+	labID, err := r.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return r.capacity(ctx, labID)
+}
+
+// SetLimit 单独设置实验室设备上限，覆盖全局配置，仅实验室创建者可以设置。
+// 上限低于已注册设备数时不影响已注册设备，只阻止新增设备
+func (r *Registry) SetLimit(ctx context.Context, labUUID uuid.UUID, req *SetLimitReq) (*CapacityResp, error) {
+	labID, userID, err := r.checkOwner(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.UpsertDeviceLimit(ctx, &model.DeviceLimit{
+		LabID:      labID,
+		MaxDevices: *req.MaxDevices,
+		UpdatedBy:  userID,
+	}); err != nil {
+		return nil, err
+	}
+	respcache.Invalidate(ctx, respcache.TagDevice)
+	return r.capacity(ctx, labID)
+}
+
+// ResetLimit 删除实验室单独设置的设备上限，恢复使用全局配置
+func (r *Registry) ResetLimit(ctx context.Context, labUUID uuid.UUID) (*CapacityResp, error) {
+	labID, _, err := r.checkOwner(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.DeleteDeviceLimit(ctx, labID); err != nil {
+		return nil, err
+	}
+	respcache.Invalidate(ctx, respcache.TagDevice)
+	return r.capacity(ctx, labID)
+}
+
+func (r *Registry) capacity(ctx context.Context, labID int64) (*CapacityResp, error) {
+	limit, overridden, err := r.deviceLimit(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.store.CountDevices(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := int64(-1)
+	if limit > 0 {
+		remaining = max(int64(limit)-count, 0)
+	}
+	return &CapacityResp{
+		DeviceCount: count,
+		MaxDevices:  limit,
+		Remaining:   remaining,
+		Overridden:  overridden,
+	}, nil
+}
+
+// deviceLimit 返回实验室设备上限，优先使用实验室单独设置的上限，0 表示不限制
+func (r *Registry) deviceLimit(ctx context.Context, labID int64) (int, bool, error) {
+	data, err := r.store.GetDeviceLimit(ctx, labID)
+	if err == nil {
+		return data.MaxDevices, true, nil
+	}
+	if err != code.RecordNotFound {
+		return 0, false, err
+	}
+	return max(config.GetStudioConfig().Material.MaxDevicesPerLab, 0), false, nil
+}
+
+func (r *Registry) checkOwner(ctx context.Context, labUUID uuid.UUID) (int64, string, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, "", code.UnLogin
+	}
+
+	lab, err := r.envStore.GetLabByUUID(ctx, labUUID, "id", "user_id")
+	if err != nil {
+		return 0, "", err
+	}
+	if lab.UserID != userInfo.ID {
+		return 0, "", code.NoPermission
+	}
+	return lab.ID, userInfo.ID, nil
+}
+
+func (r *Registry) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+
+	labID := r.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := r.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+// ValidateAction 校验动作是否在设备声明的能力范围内，参数是否符合声明的 schema。
+// 未注册的设备不做校验，兼容尚未上报能力的旧版 edge
+func (r *Registry) ValidateAction(ctx context.Context, labID int64, deviceName, action, actionType string, param []byte) error {
+	data, err := r.store.GetDevice(ctx, labID, deviceName)
+	if err == code.RecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	capability, ok := data.Capability(action)
+	if !ok {
+		return code.DeviceActionNotSupportedErr.WithMsgf("device %s does not support action %s", deviceName, action)
+	}
+	if capability.ActionType != "" && actionType != "" && capability.ActionType != actionType {
+		return code.DeviceActionNotSupportedErr.WithMsgf("device %s action %s expects type %s, got %s",
+			deviceName, action, capability.ActionType, actionType)
+	}
+	if len(capability.ParamSchema) == 0 || string(capability.ParamSchema) == "null" {
+		return nil
+	}
+
+	schema, err := jsonschema.Compile(capability.ParamSchema)
+	if err != nil {
+		return code.DeviceCapabilityInvalidErr.WithMsg(err.Error())
+	}
+
+	var input any = map[string]any{}
+	if len(param) > 0 && string(param) != "null" {
+		if err := json.Unmarshal(param, &input); err != nil {
+			return code.DeviceActionParamInvalidErr.WithMsg(err.Error())
+		}
+	}
+	if _, fieldErrs := schema.Validate(input); len(fieldErrs) > 0 {
+		return code.DeviceActionParamInvalidErr.WithDetail(fieldErrs)
+	}
+	return nil
+}
+
+// ValidateLabAction 同 ValidateAction，实验室以 uuid 指定
+func (r *Registry) ValidateLabAction(ctx context.Context, labUUID uuid.UUID, deviceName, action, actionType string, param []byte) error {
+	labID := r.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return code.LabNotFound
+	}
+	return r.ValidateAction(ctx, labID, deviceName, action, actionType, param)
+}
+
+func validateCapabilities(deviceName string, capabilities []model.DeviceCapability) error {
+	actions := make(map[string]struct{}, len(capabilities))
+	for _, c := range capabilities {
+		if c.Action == "" {
+			return code.DeviceCapabilityInvalidErr.WithMsgf("device %s has capability without action", deviceName)
+		}
+		if _, ok := actions[c.Action]; ok {
+			return code.DeviceCapabilityInvalidErr.WithMsgf("device %s declares action %s twice", deviceName, c.Action)
+		}
+		actions[c.Action] = struct{}{}
+
+		if len(c.ParamSchema) == 0 || string(c.ParamSchema) == "null" {
+			continue
+		}
+		if _, err := jsonschema.Compile(c.ParamSchema); err != nil {
+			return code.DeviceCapabilityInvalidErr.WithMsgf("device %s action %s param schema: %s", deviceName, c.Action, err.Error())
+		}
+	}
+	return nil
+}
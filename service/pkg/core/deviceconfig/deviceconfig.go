@@ -0,0 +1,669 @@
+// Package deviceconfig manages versioned JSON configuration profiles for
+// devices: profiles are assigned to devices or device classes, pushed through
+// the device command queue with the command acknowledgment tracked per push,
+// and compared with the configuration each device reports to detect drift.
+package deviceconfig
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/command"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/device"
+	"github.com/scienceol/studio/service/pkg/repo/deviceconfig"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"gorm.io/datatypes"
+)
+
+const (
+	sweepInterval = 15 * time.Second
+	sweepBatch    = 500
+)
+
+// CreateProfileReq 创建配置模板，config 为第一个版本
+type CreateProfileReq struct {
+	LabUUID     uuid.UUID       `json:"lab_uuid" binding:"required"`
+	Name        string          `json:"name" binding:"required"`
+	DeviceClass string          `json:"device_class"` // 适用的设备类型，为空时适用所有设备
+	Description string          `json:"description"`
+	Config      json.RawMessage `json:"config" binding:"required" swaggertype:"object"`
+	Comment     string          `json:"comment"`
+}
+
+// CreateVersionReq 为配置模板创建新版本
+type CreateVersionReq struct {
+	Config  json.RawMessage `json:"config" binding:"required" swaggertype:"object"`
+	Comment string          `json:"comment"`
+	Push    bool            `json:"push"` // 立即下发到跟随最新版本的设备
+}
+
+// AssignReq 将配置模板分配给设备，device_name 为空时分配给实验室中该模板设备类型的所有设备
+type AssignReq struct {
+	DeviceName string `json:"device_name"`
+	Version    int    `json:"version" binding:"gte=0"` // 固定的版本，0 表示跟随最新版本
+	Push       bool   `json:"push"`                    // 立即下发到受影响的设备
+}
+
+// PushReq 下发设备当前分配的配置，device_names 为空时下发到所有分配了配置的设备
+type PushReq struct {
+	DeviceNames []string `json:"device_names"`
+}
+
+// HistoryReq 查询配置下发历史
+type HistoryReq struct {
+	DeviceName string `form:"device_name"`
+	common.PageReq
+}
+
+// ReportReq edge 上报设备当前运行的配置
+type ReportReq struct {
+	Config json.RawMessage `json:"config" binding:"required" swaggertype:"object"`
+}
+
+// ProfileResp 配置模板及其版本
+type ProfileResp struct {
+	*model.DeviceConfigProfile
+	Versions []*model.DeviceConfigVersion `json:"versions"`
+}
+
+type Service struct {
+	store    deviceconfig.DeviceConfigRepo
+	devices  device.DeviceRepo
+	commands *command.Service
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    deviceconfig.New(),
+		devices:  device.New(),
+		commands: command.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// target 设备生效的分配、模板和版本
+type target struct {
+	device     *model.Device
+	assignment *model.DeviceConfigAssignment
+	profile    *model.DeviceConfigProfile
+	version    *model.DeviceConfigVersion
+}
+
+// CreateProfile 创建配置模板，仅实验室创建者可以操作
+func (s *Service) CreateProfile(ctx context.Context, req *CreateProfileReq) (*ProfileResp, error) {
+	labID, userID, err := s.checkOwner(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfig(req.Config); err != nil {
+		return nil, err
+	}
+	count, err := s.baseDB.Count(ctx, &model.DeviceConfigProfile{}, map[string]any{
+		"lab_id": labID,
+		"name":   req.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, code.DeviceConfigExistErr.WithMsgf("profile %s already exists", req.Name)
+	}
+
+	profile := &model.DeviceConfigProfile{
+		LabID:       labID,
+		Name:        req.Name,
+		DeviceClass: req.DeviceClass,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+	version := &model.DeviceConfigVersion{
+		Config:    datatypes.JSON(req.Config),
+		Comment:   req.Comment,
+		CreatedBy: userID,
+	}
+	if err := s.store.CreateProfile(ctx, profile, version); err != nil {
+		return nil, err
+	}
+	return &ProfileResp{
+		DeviceConfigProfile: profile,
+		Versions:            []*model.DeviceConfigVersion{version},
+	}, nil
+}
+
+// ListProfiles 获取实验室的配置模板
+func (s *Service) ListProfiles(ctx context.Context, labUUID uuid.UUID) ([]*model.DeviceConfigProfile, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListProfiles(ctx, labID)
+}
+
+// GetProfile 获取配置模板及所有版本，新版本在前
+func (s *Service) GetProfile(ctx context.Context, profileUUID uuid.UUID) (*ProfileResp, error) {
+	profile, err := s.store.GetProfileByUUID(ctx, profileUUID)
+	if err != nil {
+		return nil, err
+	}
+	labUUID := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, profile.LabID)[profile.LabID]
+	if _, err := s.checkMember(ctx, labUUID); err != nil {
+		return nil, err
+	}
+	versions, err := s.store.ListVersions(ctx, profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &ProfileResp{
+		DeviceConfigProfile: profile,
+		Versions:            versions,
+	}, nil
+}
+
+// CreateVersion 为配置模板创建新版本，push 时下发到跟随最新版本的设备
+func (s *Service) CreateVersion(ctx context.Context, profileUUID uuid.UUID, req *CreateVersionReq) (*model.DeviceConfigVersion, error) {
+	profile, userID, err := s.ownedProfile(ctx, profileUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfig(req.Config); err != nil {
+		return nil, err
+	}
+
+	version := &model.DeviceConfigVersion{
+		Config:    datatypes.JSON(req.Config),
+		Comment:   req.Comment,
+		CreatedBy: userID,
+	}
+	if err := s.store.CreateVersion(ctx, profile, version); err != nil {
+		return nil, err
+	}
+
+	if req.Push {
+		if _, err := s.push(ctx, profile.LabID, userID, func(t *target) bool {
+			return t.profile.ID == profile.ID && t.assignment.Version == 0
+		}); err != nil && err != code.DeviceConfigNoTargetErr {
+			return nil, err
+		}
+	}
+	return version, nil
+}
+
+// Assign 将配置模板分配给设备或设备类型，替换同一设备或设备类型原有的分配
+func (s *Service) Assign(ctx context.Context, profileUUID uuid.UUID, req *AssignReq) (*model.DeviceConfigAssignment, error) {
+	profile, userID, err := s.ownedProfile(ctx, profileUUID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Version > profile.LatestVersion {
+		return nil, code.ParamErr.WithMsgf("profile %s has no version %d", profile.Name, req.Version)
+	}
+
+	assignment := &model.DeviceConfigAssignment{
+		LabID:      profile.LabID,
+		ProfileID:  profile.ID,
+		DeviceName: req.DeviceName,
+		Version:    req.Version,
+		CreatedBy:  userID,
+	}
+	if req.DeviceName == "" {
+		assignment.DeviceClass = profile.DeviceClass
+	} else if _, err := s.devices.GetDevice(ctx, profile.LabID, req.DeviceName); err != nil {
+		if err == code.RecordNotFound {
+			return nil, code.ParamErr.WithMsgf("device %s is not registered", req.DeviceName)
+		}
+		return nil, err
+	}
+	if err := s.store.UpsertAssignment(ctx, assignment); err != nil {
+		return nil, err
+	}
+	assignment.ProfileUUID = profile.UUID
+	assignment.ProfileName = profile.Name
+
+	if req.Push {
+		if _, err := s.push(ctx, profile.LabID, userID, func(t *target) bool {
+			return t.assignment.ID == assignment.ID
+		}); err != nil && err != code.DeviceConfigNoTargetErr {
+			return nil, err
+		}
+	}
+	return assignment, nil
+}
+
+// ListAssignments 获取实验室的配置分配
+func (s *Service) ListAssignments(ctx context.Context, labUUID uuid.UUID) ([]*model.DeviceConfigAssignment, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.store.ListAssignments(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillProfiles(ctx, assignments); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// Unassign 删除配置分配，设备上已应用的配置不变
+func (s *Service) Unassign(ctx context.Context, assignmentUUID uuid.UUID) error {
+	assignment, err := s.store.GetAssignmentByUUID(ctx, assignmentUUID)
+	if err != nil {
+		return err
+	}
+	labUUID := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, assignment.LabID)[assignment.LabID]
+	if _, _, err := s.checkOwner(ctx, labUUID); err != nil {
+		return err
+	}
+	return s.store.DeleteAssignment(ctx, assignment.ID)
+}
+
+// Push 通过设备指令队列下发设备当前分配的配置版本
+func (s *Service) Push(ctx context.Context, labUUID uuid.UUID, req *PushReq) ([]*model.DeviceConfigApply, error) {
+	labID, userID, err := s.checkOwner(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(req.DeviceNames))
+	for _, name := range req.DeviceNames {
+		names[name] = true
+	}
+	return s.push(ctx, labID, userID, func(t *target) bool {
+		return len(names) == 0 || names[t.device.Name]
+	})
+}
+
+// Status 获取实验室各设备分配、已应用和上报的配置版本，以及上报配置与分配配置的偏差
+func (s *Service) Status(ctx context.Context, labUUID uuid.UUID) ([]*model.DeviceConfigStatus, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.devices.ListDevices(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.targets(ctx, labID, devices)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.store.ListLatestApplies(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := s.store.ListLatestApplies(ctx, labID, model.DeviceConfigApplyApplied)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := s.store.ListReports(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+
+	latestMap := make(map[string]*model.DeviceConfigApply, len(latest))
+	for _, a := range latest {
+		latestMap[a.DeviceName] = a
+	}
+	appliedMap := make(map[string]*model.DeviceConfigApply, len(applied))
+	for _, a := range applied {
+		appliedMap[a.DeviceName] = a
+	}
+	reportMap := make(map[string]*model.DeviceConfigReport, len(reports))
+	for _, r := range reports {
+		reportMap[r.DeviceName] = r
+	}
+
+	datas := make([]*model.DeviceConfigStatus, 0, len(devices))
+	for _, d := range devices {
+		status := &model.DeviceConfigStatus{
+			DeviceName:  d.Name,
+			DeviceClass: d.Class,
+			LastApply:   latestMap[d.Name],
+			Drift:       model.DeviceConfigUnassigned,
+		}
+		if a, ok := appliedMap[d.Name]; ok {
+			status.AppliedVersion = a.Version
+		}
+		report, reported := reportMap[d.Name]
+		if reported {
+			status.ReportedAt = &report.ReportedAt
+		}
+
+		t, ok := targets[d.Name]
+		if !ok {
+			datas = append(datas, status)
+			continue
+		}
+		status.ProfileUUID = t.profile.UUID
+		status.ProfileName = t.profile.Name
+		status.DesiredVersion = t.version.Version
+		status.Drift = model.DeviceConfigUnreported
+		if reported {
+			keys, equal := diffKeys(t.version.Config, report.Config)
+			status.Drift = model.DeviceConfigInSync
+			if !equal {
+				status.Drift = model.DeviceConfigDrifted
+				status.DriftKeys = keys
+			}
+		}
+		datas = append(datas, status)
+	}
+	return datas, nil
+}
+
+// History 获取配置下发历史，新的在前
+func (s *Service) History(ctx context.Context, labUUID uuid.UUID, req *HistoryReq) (*common.ListResp[*model.DeviceConfigApply], error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	req.Normalize()
+	datas, total, err := s.store.ListApplies(ctx, &deviceconfig.ApplyQuery{
+		LabID:      labID,
+		DeviceName: req.DeviceName,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// Report edge 上报设备当前运行的配置，用于偏差检测
+func (s *Service) Report(ctx context.Context, deviceName string, req *ReportReq) (*model.DeviceConfigReport, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+	if !json.Valid(req.Config) {
+		return nil, code.DeviceConfigInvalidErr
+	}
+
+	report := &model.DeviceConfigReport{
+		LabID:      labUser.LabID,
+		DeviceName: deviceName,
+		Config:     datatypes.JSON(req.Config),
+		ReportedAt: time.Now(),
+	}
+	if err := s.store.UpsertReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Sweep 按指令状态更新等待中的配置下发
+func (s *Service) Sweep(ctx context.Context) error {
+	applies, err := s.store.ListUnfinishedApplies(ctx, sweepBatch)
+	if err != nil {
+		return err
+	}
+	if len(applies) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(applies))
+	for _, a := range applies {
+		ids = append(ids, a.CommandID)
+	}
+	cmds := make([]*model.DeviceCommand, 0, len(ids))
+	if err := s.baseDB.FindDatas(ctx, &cmds, map[string]any{
+		"id": ids,
+	}, "id", "status", "error", "acked_at", "finished_at"); err != nil {
+		return err
+	}
+	cmdMap := make(map[int64]*model.DeviceCommand, len(cmds))
+	for _, cmd := range cmds {
+		cmdMap[cmd.ID] = cmd
+	}
+
+	for _, a := range applies {
+		cmd, ok := cmdMap[a.CommandID]
+		if !ok {
+			continue
+		}
+		status := applyStatus(cmd.Status)
+		if status == a.Status {
+			continue
+		}
+		updates := map[string]any{
+			"status":      status,
+			"acked_at":    cmd.AckedAt,
+			"finished_at": cmd.FinishedAt,
+		}
+		if status == model.DeviceConfigApplyFailed {
+			msg := string(cmd.Status)
+			if cmd.Error != nil && *cmd.Error != "" {
+				msg = *cmd.Error
+			}
+			updates["error"] = msg
+		}
+		if err := s.store.UpdateApply(ctx, a.ID, updates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// push 为符合条件的设备下发生效的配置版本，并记录下发历史
+func (s *Service) push(ctx context.Context, labID int64, userID string, match func(*target) bool) ([]*model.DeviceConfigApply, error) {
+	devices, err := s.devices.ListDevices(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.targets(ctx, labID, devices)
+	if err != nil {
+		return nil, err
+	}
+
+	applies := make([]*model.DeviceConfigApply, 0, len(targets))
+	for _, d := range devices {
+		t, ok := targets[d.Name]
+		if !ok || !match(t) {
+			continue
+		}
+		param, _ := json.Marshal(map[string]any{
+			"profile_uuid": t.profile.UUID,
+			"profile_name": t.profile.Name,
+			"version":      t.version.Version,
+			"config":       t.version.Config,
+		})
+		cmd, err := s.commands.Dispatch(ctx, labID, &command.EnqueueReq{
+			DeviceName: d.Name,
+			Action:     model.DeviceConfigAction,
+			ActionType: model.DeviceConfigAction,
+			Param:      param,
+		}, userID)
+		if err != nil {
+			return nil, err
+		}
+		applies = append(applies, &model.DeviceConfigApply{
+			LabID:       labID,
+			DeviceName:  d.Name,
+			ProfileID:   t.profile.ID,
+			ProfileUUID: t.profile.UUID,
+			ProfileName: t.profile.Name,
+			Version:     t.version.Version,
+			CommandID:   cmd.ID,
+			CommandUUID: cmd.UUID,
+			Status:      model.DeviceConfigApplyPending,
+			CreatedBy:   userID,
+		})
+	}
+	if len(applies) == 0 {
+		return nil, code.DeviceConfigNoTargetErr
+	}
+	if err := s.store.CreateApplies(ctx, applies); err != nil {
+		return nil, err
+	}
+	return applies, nil
+}
+
+// targets 解析实验室各设备生效的分配及对应的模板和版本
+func (s *Service) targets(ctx context.Context, labID int64, devices []*model.Device) (map[string]*target, error) {
+	assignments, err := s.store.ListAssignments(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := s.store.ListProfiles(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	profileMap := make(map[int64]*model.DeviceConfigProfile, len(profiles))
+	for _, p := range profiles {
+		profileMap[p.ID] = p
+	}
+
+	resolved := resolve(devices, assignments)
+	deviceMap := make(map[string]*model.Device, len(devices))
+	for _, d := range devices {
+		deviceMap[d.Name] = d
+	}
+	ret := make(map[string]*target, len(resolved))
+	keys := make([][2]int64, 0, len(resolved))
+	for name, a := range resolved {
+		p, ok := profileMap[a.ProfileID]
+		if !ok {
+			continue
+		}
+		version := a.Version
+		if version == 0 {
+			version = p.LatestVersion
+		}
+		ret[name] = &target{device: deviceMap[name], assignment: a, profile: p}
+		keys = append(keys, [2]int64{p.ID, int64(version)})
+	}
+
+	versions, err := s.store.GetVersions(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	versionMap := make(map[[2]int64]*model.DeviceConfigVersion, len(versions))
+	for _, v := range versions {
+		versionMap[[2]int64{v.ProfileID, int64(v.Version)}] = v
+	}
+	for name, t := range ret {
+		version := t.assignment.Version
+		if version == 0 {
+			version = t.profile.LatestVersion
+		}
+		v, ok := versionMap[[2]int64{t.profile.ID, int64(version)}]
+		if !ok {
+			delete(ret, name)
+			continue
+		}
+		t.version = v
+	}
+	return ret, nil
+}
+
+func (s *Service) fillProfiles(ctx context.Context, assignments []*model.DeviceConfigAssignment) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(assignments))
+	for _, a := range assignments {
+		ids = append(ids, a.ProfileID)
+	}
+	profiles := make([]*model.DeviceConfigProfile, 0, len(ids))
+	if err := s.baseDB.FindDatas(ctx, &profiles, map[string]any{
+		"id": ids,
+	}, "id", "uuid", "name"); err != nil {
+		return err
+	}
+	profileMap := make(map[int64]*model.DeviceConfigProfile, len(profiles))
+	for _, p := range profiles {
+		profileMap[p.ID] = p
+	}
+	for _, a := range assignments {
+		if p, ok := profileMap[a.ProfileID]; ok {
+			a.ProfileUUID = p.UUID
+			a.ProfileName = p.Name
+		}
+	}
+	return nil
+}
+
+func (s *Service) ownedProfile(ctx context.Context, profileUUID uuid.UUID) (*model.DeviceConfigProfile, string, error) {
+	profile, err := s.store.GetProfileByUUID(ctx, profileUUID)
+	if err != nil {
+		return nil, "", err
+	}
+	labUUID := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, profile.LabID)[profile.LabID]
+	_, userID, err := s.checkOwner(ctx, labUUID)
+	if err != nil {
+		return nil, "", err
+	}
+	return profile, userID, nil
+}
+
+// validateConfig 配置必须是 JSON 对象
+func validateConfig(config json.RawMessage) error {
+	var obj map[string]any
+	if err := json.Unmarshal(config, &obj); err != nil || obj == nil {
+		return code.DeviceConfigInvalidErr
+	}
+	return nil
+}
+
+// checkOwner 只有实验室创建者可以管理配置
+func (s *Service) checkOwner(ctx context.Context, labUUID uuid.UUID) (int64, string, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, "", code.UnLogin
+	}
+
+	lab, err := s.envStore.GetLabByUUID(ctx, labUUID, "id", "user_id")
+	if err != nil {
+		return 0, "", err
+	}
+	if lab.UserID != userInfo.ID {
+		return 0, "", code.NoPermission
+	}
+	return lab.ID, userInfo.ID, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+// RegisterJob 注册配置下发状态同步后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "device_config_apply",
+		Description:  "根据设备指令的确认和结果更新配置下发状态",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     sweepInterval.String(),
+		Timeout:      time.Minute,
+		Run:          s.Sweep,
+	})
+}
@@ -0,0 +1,85 @@
+package deviceconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// resolve 为每个设备选择生效的分配：设备级分配优先，其次是设备类型的实验室级分配，
+// 最后是适用所有类型的实验室级分配
+func resolve(devices []*model.Device, assignments []*model.DeviceConfigAssignment) map[string]*model.DeviceConfigAssignment {
+	byDevice := make(map[string]*model.DeviceConfigAssignment)
+	byClass := make(map[string]*model.DeviceConfigAssignment)
+	for _, a := range assignments {
+		if a.DeviceName != "" {
+			byDevice[a.DeviceName] = a
+			continue
+		}
+		byClass[a.DeviceClass] = a
+	}
+
+	ret := make(map[string]*model.DeviceConfigAssignment, len(devices))
+	for _, device := range devices {
+		if a, ok := byDevice[device.Name]; ok {
+			ret[device.Name] = a
+			continue
+		}
+		if a, ok := byClass[device.Class]; ok {
+			ret[device.Name] = a
+			continue
+		}
+		if a, ok := byClass[""]; ok {
+			ret[device.Name] = a
+		}
+	}
+	return ret
+}
+
+// diffKeys 比较分配的配置和设备上报的配置，返回值不同的顶层字段。
+// 任一方不是 JSON 对象时整体比较，不同时不返回字段
+func diffKeys(desired, reported []byte) ([]string, bool) {
+	var want, got any
+	if err := json.Unmarshal(desired, &want); err != nil {
+		return nil, false
+	}
+	if err := json.Unmarshal(reported, &got); err != nil {
+		return nil, false
+	}
+
+	wantObj, ok1 := want.(map[string]any)
+	gotObj, ok2 := got.(map[string]any)
+	if !ok1 || !ok2 {
+		return nil, reflect.DeepEqual(want, got)
+	}
+
+	keys := make([]string, 0)
+	for k, v := range wantObj {
+		if rv, ok := gotObj[k]; !ok || !reflect.DeepEqual(v, rv) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range gotObj {
+		if _, ok := wantObj[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, len(keys) == 0
+}
+
+// applyStatus 按指令状态返回配置下发的状态，指令仍在队列中时返回 pending
+func applyStatus(status model.DeviceCommandStatus) model.DeviceConfigApplyStatus {
+	switch status {
+	case model.DeviceCommandAcked:
+		return model.DeviceConfigApplyAcked
+	case model.DeviceCommandSucceeded:
+		return model.DeviceConfigApplyApplied
+	case model.DeviceCommandFailed, model.DeviceCommandTimeout:
+		return model.DeviceConfigApplyFailed
+	default:
+		return model.DeviceConfigApplyPending
+	}
+}
@@ -0,0 +1,56 @@
+package deviceconfig
+
+import (
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	devices := []*model.Device{
+		{Name: "robot", Class: "arm"},
+		{Name: "gripper", Class: "arm"},
+		{Name: "pump", Class: "liquid"},
+		{Name: "camera", Class: "vision"},
+	}
+	all := &model.DeviceConfigAssignment{ProfileID: 1}
+	arm := &model.DeviceConfigAssignment{ProfileID: 2, DeviceClass: "arm"}
+	robot := &model.DeviceConfigAssignment{ProfileID: 3, DeviceName: "robot"}
+
+	ret := resolve(devices, []*model.DeviceConfigAssignment{all, arm, robot})
+	assert.Same(t, robot, ret["robot"])
+	assert.Same(t, arm, ret["gripper"])
+	assert.Same(t, all, ret["pump"])
+	assert.Same(t, all, ret["camera"])
+
+	ret = resolve(devices, []*model.DeviceConfigAssignment{arm})
+	assert.Len(t, ret, 2)
+	_, ok := ret["pump"]
+	assert.False(t, ok)
+}
+
+func TestDiffKeys(t *testing.T) {
+	keys, equal := diffKeys([]byte(`{"speed": 10, "mode": {"a": 1}}`), []byte(`{"mode": {"a": 1}, "speed": 10}`))
+	assert.True(t, equal)
+	assert.Empty(t, keys)
+
+	keys, equal = diffKeys([]byte(`{"speed": 10, "mode": "fast", "limit": 3}`), []byte(`{"speed": 12, "mode": "fast", "debug": true}`))
+	assert.False(t, equal)
+	assert.Equal(t, []string{"debug", "limit", "speed"}, keys)
+
+	keys, equal = diffKeys([]byte(`{"speed": 10}`), []byte(`[1, 2]`))
+	assert.False(t, equal)
+	assert.Empty(t, keys)
+
+	_, equal = diffKeys([]byte(`{"speed": 10}`), []byte(`not json`))
+	assert.False(t, equal)
+}
+
+func TestApplyStatus(t *testing.T) {
+	assert.Equal(t, model.DeviceConfigApplyPending, applyStatus(model.DeviceCommandQueued))
+	assert.Equal(t, model.DeviceConfigApplyPending, applyStatus(model.DeviceCommandDelivered))
+	assert.Equal(t, model.DeviceConfigApplyAcked, applyStatus(model.DeviceCommandAcked))
+	assert.Equal(t, model.DeviceConfigApplyApplied, applyStatus(model.DeviceCommandSucceeded))
+	assert.Equal(t, model.DeviceConfigApplyFailed, applyStatus(model.DeviceCommandTimeout))
+}
@@ -0,0 +1,355 @@
+// Package devicerisk scores the maintenance risk of devices from the frequency
+// of their error events, the failure rate of their actions and the drift of
+// action durations, opening alerts for devices over the configured thresholds.
+package devicerisk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	coreAlert "github.com/scienceol/studio/service/pkg/core/alert"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/alert"
+	"github.com/scienceol/studio/service/pkg/repo/devicerisk"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+)
+
+const (
+	day = 24 * time.Hour
+
+	defaultInterval      = time.Hour
+	defaultWindowDays    = 7
+	defaultBaselineDays  = 30
+	defaultWarningScore  = 60
+	defaultCriticalScore = 80
+	defaultRetentionDays = 180
+
+	defaultHistoryRange = 30 * day
+	maxHistoryRange     = 366 * day
+
+	// 评分告警的名称，告警没有对应的设备规则
+	riskAlertName = "device maintenance risk"
+)
+
+// HistoryReq 查询设备评分历史，默认最近 30 天
+type HistoryReq struct {
+	StartTime *time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   *time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type Service struct {
+	store    devicerisk.DeviceRiskRepo
+	alerts   alert.AlertRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	notifier *coreAlert.Notifier
+}
+
+func New() *Service {
+	return &Service{
+		store:    devicerisk.New(),
+		alerts:   alert.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		notifier: coreAlert.NewNotifier(),
+	}
+}
+
+// settings 评分配置，未设置的项使用默认值
+type settings struct {
+	window        time.Duration
+	baseline      time.Duration
+	warningScore  float64
+	criticalScore float64
+	retention     time.Duration
+}
+
+func loadSettings() *settings {
+	conf := config.GetStudioConfig().DeviceRisk
+	s := &settings{
+		window:        defaultWindowDays * day,
+		baseline:      defaultBaselineDays * day,
+		warningScore:  defaultWarningScore,
+		criticalScore: defaultCriticalScore,
+		retention:     defaultRetentionDays * day,
+	}
+	if conf.WindowDays > 0 {
+		s.window = time.Duration(conf.WindowDays) * day
+	}
+	if conf.BaselineDays > 0 {
+		s.baseline = time.Duration(conf.BaselineDays) * day
+	}
+	if conf.WarningScore > 0 {
+		s.warningScore = conf.WarningScore
+	}
+	if conf.CriticalScore > 0 {
+		s.criticalScore = conf.CriticalScore
+	}
+	if s.criticalScore < s.warningScore {
+		s.criticalScore = s.warningScore
+	}
+	if conf.RetentionDays > 0 {
+		s.retention = time.Duration(conf.RetentionDays) * day
+	}
+	return s
+}
+
+// level 按阈值返回评分的风险等级
+func (s *settings) level(score float64) model.DeviceRiskLevel {
+	switch {
+	case score >= s.criticalScore:
+		return model.DeviceRiskCritical
+	case score >= s.warningScore:
+		return model.DeviceRiskWarning
+	default:
+		return model.DeviceRiskNormal
+	}
+}
+
+// List 获取实验室各设备最近一次的评分，风险高的在前
+func (s *Service) List(ctx context.Context, labUUID uuid.UUID) ([]*model.DeviceRiskScore, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListLatest(ctx, labID)
+}
+
+// History 获取设备的评分历史
+func (s *Service) History(ctx context.Context, labUUID, deviceUUID uuid.UUID, req *HistoryReq) ([]*model.DeviceRiskScore, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	if req.EndTime != nil {
+		end = *req.EndTime
+	}
+	start := end.Add(-defaultHistoryRange)
+	if req.StartTime != nil {
+		start = *req.StartTime
+	}
+	if !start.Before(end) {
+		return nil, code.ParamErr.WithMsg("start_time must be before end_time")
+	}
+	if end.Sub(start) > maxHistoryRange {
+		return nil, code.ParamErr.WithMsg("time range must not exceed 366 days")
+	}
+
+	deviceID := s.baseDB.UUID2ID(ctx, &model.MaterialNode{}, deviceUUID)[deviceUUID]
+	if deviceID == 0 {
+		return nil, code.RecordNotFound
+	}
+	return s.store.ListHistory(ctx, labID, deviceID, start, end)
+}
+
+// Score 为最近有错误事件或动作的设备评分并记录，按阈值产生、更新或恢复告警
+func (s *Service) Score(ctx context.Context) error {
+	conf := loadSettings()
+	now := time.Now()
+	since := now.Add(-conf.window)
+	baselineSince := since.Add(-conf.baseline)
+
+	errorCounts, err := s.store.ListErrorCounts(ctx, since, baselineSince)
+	if err != nil {
+		return err
+	}
+	actionCounts, err := s.store.ListActionCounts(ctx, since)
+	if err != nil {
+		return err
+	}
+	drifts, err := s.store.ListActionDrifts(ctx, since, baselineSince)
+	if err != nil {
+		return err
+	}
+
+	scores := collect(errorCounts, actionCounts, drifts, conf.window, conf.baseline)
+	if err := s.fillNames(ctx, scores); err != nil {
+		return err
+	}
+	for _, score := range scores {
+		score.ScoredAt = now
+		rate(score)
+		score.Level = conf.level(score.Score)
+	}
+	if err := s.store.CreateScores(ctx, scores); err != nil {
+		return err
+	}
+
+	if err := s.syncAlerts(ctx, conf, scores, now); err != nil {
+		return err
+	}
+
+	if _, err := s.store.DeleteBefore(ctx, now.Add(-conf.retention)); err != nil {
+		logger.Warnf(ctx, "delete expired device risk scores fail: %+v", err)
+	}
+	return nil
+}
+
+// fillNames 补全只有错误事件、没有动作的设备名称
+func (s *Service) fillNames(ctx context.Context, scores []*model.DeviceRiskScore) error {
+	ids := make([]int64, 0, len(scores))
+	for _, score := range scores {
+		if score.DeviceName == "" {
+			ids = append(ids, score.DeviceID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	nodes := make([]*model.MaterialNode, 0, len(ids))
+	if err := s.baseDB.FindDatas(ctx, &nodes, map[string]any{
+		"id": ids,
+	}, "id", "name"); err != nil {
+		return err
+	}
+	names := make(map[int64]string, len(nodes))
+	for _, node := range nodes {
+		names[node.ID] = node.Name
+	}
+	for _, score := range scores {
+		if score.DeviceName == "" {
+			score.DeviceName = names[score.DeviceID]
+		}
+	}
+	return nil
+}
+
+// syncAlerts 为达到阈值的设备产生告警，风险等级变化时恢复旧告警并产生新告警，
+// 低于阈值或本轮没有评分的设备恢复告警
+func (s *Service) syncAlerts(ctx context.Context, conf *settings, scores []*model.DeviceRiskScore, now time.Time) error {
+	opens, err := s.alerts.ListOpenByRule(ctx, model.DeviceRiskRuleID)
+	if err != nil {
+		return err
+	}
+	openMap := make(map[uuid.UUID]*model.Alert, len(opens))
+	for _, item := range opens {
+		openMap[item.DeviceUUID] = item
+	}
+
+	for _, score := range scores {
+		if score.Level == model.DeviceRiskNormal {
+			continue
+		}
+		severity := model.AlertSeverityWarning
+		threshold := conf.warningScore
+		if score.Level == model.DeviceRiskCritical {
+			severity = model.AlertSeverityCritical
+			threshold = conf.criticalScore
+		}
+
+		if open, ok := openMap[score.DeviceUUID]; ok {
+			if open.Severity == severity {
+				delete(openMap, score.DeviceUUID)
+				if err := s.alerts.UpdateValue(ctx, open.ID, score.Score); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		silenced, err := s.alerts.IsSilenced(ctx, score.LabID, score.DeviceUUID, now)
+		if err != nil {
+			return err
+		}
+		fired := &model.Alert{
+			LabID:      score.LabID,
+			RuleID:     model.DeviceRiskRuleID,
+			RuleName:   riskAlertName,
+			DeviceID:   score.DeviceID,
+			DeviceUUID: score.DeviceUUID,
+			Severity:   severity,
+			Status:     model.AlertFiring,
+			Value:      score.Score,
+			Threshold:  threshold,
+			Message:    describe(score),
+			Silenced:   silenced,
+			FiredAt:    now,
+		}
+		if err := s.alerts.CreateAlert(ctx, fired); err != nil {
+			return err
+		}
+		s.notifier.Notify(ctx, coreAlert.AlertEventFired, riskRule(score.LabID, severity, threshold), fired, broadcast)
+	}
+
+	for _, open := range openMap {
+		ok, err := s.alerts.Resolve(ctx, open, "")
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.notifier.Notify(ctx, coreAlert.AlertEventResolved, riskRule(open.LabID, open.Severity, open.Threshold), open, broadcast)
+		}
+	}
+	return nil
+}
+
+// 评分告警推送给在线的实验室成员
+var broadcast = []model.AlertChannel{{Type: model.AlertChannelBroadcast}}
+
+// riskRule 评分告警通知中的规则描述
+func riskRule(labID int64, severity model.AlertSeverity, threshold float64) *model.DeviceRule {
+	return &model.DeviceRule{
+		LabID:       labID,
+		Name:        riskAlertName,
+		Description: "maintenance risk score from error events, action failures and duration drift",
+		Enabled:     true,
+		Operator:    model.RuleOpGTE,
+		Threshold:   threshold,
+		Severity:    severity,
+	}
+}
+
+func describe(score *model.DeviceRiskScore) string {
+	return fmt.Sprintf("device %s maintenance risk score is %g: %.2f errors/day (baseline %.2f), %d of %d actions failed, durations %+.0f%% from baseline",
+		score.DeviceName, score.Score, score.ErrorsPerDay, score.BaselineErrorsPerDay,
+		score.FailedActions, score.Actions, score.DurationDrift*100)
+}
+
+func (s *Service) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+// RegisterJob 注册设备风险评分后台任务
+func RegisterJob() error {
+	s := New()
+	interval := defaultInterval
+	if minutes := config.GetStudioConfig().DeviceRisk.IntervalMinutes; minutes > 0 {
+		interval = time.Duration(minutes) * time.Minute
+	}
+	return jobs.Register(&jobs.Definition{
+		Name:         "device_risk_scoring",
+		Description:  "根据错误事件、动作失败率和时长漂移为设备计算维护风险评分，超过阈值时产生告警",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     interval.String(),
+		Timeout:      10 * time.Minute,
+		Run:          s.Score,
+	})
+}
@@ -0,0 +1,105 @@
+package devicerisk
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	// 各项评分的权重，合计为 1
+	errorWeight   = 0.4
+	failureWeight = 0.35
+	driftWeight   = 0.25
+
+	// 每天 errorScale 次错误时错误频率评分约为 0.63
+	errorScale = 2.0
+	// 错误频率达到基线的 1+errorGrowthFull 倍时增长评分为 1，基线过低时按 minBaselineRate 计算
+	errorGrowthFull = 3.0
+	minBaselineRate = 0.5
+	// 失败率达到 failureRateFull 时失败评分为 1，动作少于 minActions 时不评分
+	failureRateFull = 0.3
+	minActions      = 5
+	// 时长中位数比基线慢 driftFull 时漂移评分为 1，窗口和基线都至少 minDriftSamples 次才参与计算
+	driftFull       = 0.5
+	minDriftSamples = 3
+)
+
+// collect 合并各设备的错误事件、动作数和时长漂移，返回的评分尚未计算
+func collect(errorCounts []*model.DeviceErrorCount, actionCounts []*model.DeviceActionCount, drifts []*model.DeviceActionDrift,
+	window, baseline time.Duration,
+) []*model.DeviceRiskScore {
+	scores := make(map[int64]*model.DeviceRiskScore)
+	get := func(labID, deviceID int64) *model.DeviceRiskScore {
+		s, ok := scores[deviceID]
+		if !ok {
+			s = &model.DeviceRiskScore{LabID: labID, DeviceID: deviceID}
+			scores[deviceID] = s
+		}
+		return s
+	}
+
+	for _, c := range errorCounts {
+		s := get(c.LabID, c.DeviceID)
+		s.DeviceUUID = c.DeviceUUID
+		s.ErrorEvents = c.Recent
+		s.ErrorsPerDay = round(float64(c.Recent)/(window.Hours()/24), 3)
+		s.BaselineErrorsPerDay = round(float64(c.Baseline)/(baseline.Hours()/24), 3)
+	}
+	for _, c := range actionCounts {
+		s := get(c.LabID, c.DeviceID)
+		s.DeviceUUID = c.DeviceUUID
+		s.DeviceName = c.DeviceName
+		s.Actions = c.Actions
+		s.FailedActions = c.Failed
+	}
+
+	// 按窗口内的次数加权平均各动作的时长变化
+	weights := make(map[int64]float64)
+	for _, d := range drifts {
+		s, ok := scores[d.DeviceID]
+		if !ok || d.Recent < minDriftSamples || d.Baseline < minDriftSamples || d.BaselineP50Ms <= 0 {
+			continue
+		}
+		s.DurationDrift += float64(d.Recent) * (d.RecentP50Ms/d.BaselineP50Ms - 1)
+		weights[d.DeviceID] += float64(d.Recent)
+	}
+
+	ret := make([]*model.DeviceRiskScore, 0, len(scores))
+	for deviceID, s := range scores {
+		if w := weights[deviceID]; w > 0 {
+			s.DurationDrift = round(s.DurationDrift/w, 3)
+		}
+		ret = append(ret, s)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].DeviceID < ret[j].DeviceID })
+	return ret
+}
+
+// rate 计算各项评分和总分。错误评分一半来自窗口内的错误频率，一半来自相对基线的增长
+func rate(s *model.DeviceRiskScore) {
+	level := 1 - math.Exp(-s.ErrorsPerDay/errorScale)
+	growth := clamp((s.ErrorsPerDay - s.BaselineErrorsPerDay) / (math.Max(s.BaselineErrorsPerDay, minBaselineRate) * errorGrowthFull))
+	s.ErrorScore = round(0.5*level+0.5*growth, 3)
+
+	if s.Actions > 0 {
+		s.FailureRate = round(float64(s.FailedActions)/float64(s.Actions), 3)
+	}
+	if s.Actions >= minActions {
+		s.FailureScore = round(clamp(s.FailureRate/failureRateFull), 3)
+	}
+
+	s.DriftScore = round(clamp(s.DurationDrift/driftFull), 3)
+	s.Score = round(100*(errorWeight*s.ErrorScore+failureWeight*s.FailureScore+driftWeight*s.DriftScore), 1)
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func round(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
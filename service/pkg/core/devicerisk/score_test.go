@@ -0,0 +1,72 @@
+package devicerisk
+
+import (
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollect(t *testing.T) {
+	scores := collect(
+		[]*model.DeviceErrorCount{
+			{LabID: 1, DeviceID: 2, Recent: 14, Baseline: 30},
+			{LabID: 1, DeviceID: 3, Recent: 7},
+		},
+		[]*model.DeviceActionCount{
+			{LabID: 1, DeviceID: 2, DeviceName: "robot", Actions: 20, Failed: 4},
+		},
+		[]*model.DeviceActionDrift{
+			{DeviceID: 2, ActionName: "move", Recent: 30, RecentP50Ms: 1200, Baseline: 100, BaselineP50Ms: 1000},
+			{DeviceID: 2, ActionName: "grab", Recent: 10, RecentP50Ms: 1000, Baseline: 50, BaselineP50Ms: 1000},
+			// 样本不足不参与计算
+			{DeviceID: 2, ActionName: "home", Recent: 2, RecentP50Ms: 9000, Baseline: 50, BaselineP50Ms: 1000},
+			// 没有错误事件和动作的设备不评分
+			{DeviceID: 9, ActionName: "move", Recent: 10, RecentP50Ms: 1000, Baseline: 10, BaselineP50Ms: 1000},
+		},
+		7*day, 30*day,
+	)
+
+	assert.Len(t, scores, 2)
+	robot := scores[0]
+	assert.Equal(t, int64(2), robot.DeviceID)
+	assert.Equal(t, "robot", robot.DeviceName)
+	assert.Equal(t, 2.0, robot.ErrorsPerDay)
+	assert.Equal(t, 1.0, robot.BaselineErrorsPerDay)
+	assert.Equal(t, 0.15, robot.DurationDrift)
+	assert.Equal(t, int64(3), scores[1].DeviceID)
+	assert.Equal(t, 1.0, scores[1].ErrorsPerDay)
+}
+
+func TestRate(t *testing.T) {
+	healthy := &model.DeviceRiskScore{ErrorsPerDay: 0, Actions: 100, FailedActions: 1}
+	rate(healthy)
+	assert.Less(t, healthy.Score, 5.0)
+
+	// 错误频率是基线的 4 倍、失败率 30%、时长慢 50%
+	failing := &model.DeviceRiskScore{
+		ErrorsPerDay: 4, BaselineErrorsPerDay: 1,
+		Actions: 20, FailedActions: 6, DurationDrift: 0.5,
+	}
+	rate(failing)
+	assert.Equal(t, 0.3, failing.FailureRate)
+	assert.Equal(t, 1.0, failing.FailureScore)
+	assert.Equal(t, 1.0, failing.DriftScore)
+	assert.InDelta(t, 0.93, failing.ErrorScore, 0.01)
+	assert.InDelta(t, 97.3, failing.Score, 0.1)
+
+	// 动作太少时不按失败率评分，时长变快不算漂移
+	few := &model.DeviceRiskScore{Actions: 2, FailedActions: 2, DurationDrift: -0.3}
+	rate(few)
+	assert.Equal(t, 1.0, few.FailureRate)
+	assert.Equal(t, 0.0, few.FailureScore)
+	assert.Equal(t, 0.0, few.DriftScore)
+	assert.Equal(t, 0.0, few.Score)
+}
+
+func TestLevel(t *testing.T) {
+	conf := &settings{warningScore: 60, criticalScore: 80}
+	assert.Equal(t, model.DeviceRiskNormal, conf.level(59.9))
+	assert.Equal(t, model.DeviceRiskWarning, conf.level(60))
+	assert.Equal(t, model.DeviceRiskCritical, conf.level(95))
+}
@@ -0,0 +1,207 @@
+package edgeevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/errorrule"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/datatypes"
+)
+
+// ActionReq edge 上报的动作开始或结束，uuid 由 edge 生成，开始和结束使用同一个 uuid
+type ActionReq struct {
+	UUID                  uuid.UUID             `json:"uuid"`
+	WorkflowExecutionUUID *uuid.UUID            `json:"workflow_execution_uuid"`
+	DeviceName            string                `json:"device_name"`
+	ActionType            string                `json:"action_type"`
+	ActionName            string                `json:"action_name"`
+	Input                 datatypes.JSON        `json:"input" swaggertype:"object"`
+	Output                datatypes.JSON        `json:"output" swaggertype:"object"`
+	Status                model.ExecutionStatus `json:"status"`      // running 表示开始，success | failed | timeout | cancelled 表示结束
+	StartedAt             time.Time             `json:"started_at"`  // 动作开始的原始时间
+	FinishedAt            *time.Time            `json:"finished_at"` // 动作结束的原始时间
+	DurationMs            int64                 `json:"duration_ms"`
+	ExpectedMs            int64                 `json:"expected_ms"`
+	ErrorMessage          *string               `json:"error_message"`
+	Metadata              datatypes.JSON        `json:"metadata" swaggertype:"object"`
+}
+
+// ActionIngestReq JSON 请求体，NDJSON 请求体每行是一个 ActionReq
+type ActionIngestReq struct {
+	Actions []json.RawMessage `json:"actions" swaggertype:"array,object"`
+}
+
+// IngestActions 写入 edge 上报的动作，新结束的动作计入所属执行的步骤计数，
+// 重复上报已结束的动作不会重复计数
+func (s *Service) IngestActions(ctx context.Context, raws []json.RawMessage) (*IngestResp, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+
+	reqs := make([]*ActionReq, len(raws))
+	rejected := make(map[int]string)
+	for i, raw := range raws {
+		req := &ActionReq{}
+		if err := json.Unmarshal(raw, req); err != nil {
+			rejected[i] = fmt.Sprintf("invalid action: %s", err)
+			continue
+		}
+		reqs[i] = req
+	}
+
+	workflowIDs, err := s.workflowIDs(ctx, labUser.LabID, reqs)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if req != nil {
+			names = append(names, req.DeviceName)
+		}
+	}
+	devices, err := s.devices(ctx, labUser.LabID, names)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i, req := range reqs {
+		if req == nil {
+			continue
+		}
+		action, err := newAction(labUser.LabID, req, workflowIDs, devices, now)
+		if err != nil {
+			rejected[i] = err.Error()
+			continue
+		}
+		if action.ErrorMessage != nil && *action.ErrorMessage != "" {
+			action.ErrorCategory = errorrule.Classify(ctx, labUser.LabID, *action.ErrorMessage)
+		}
+		finished, err := s.history.ReportAction(ctx, action)
+		if err != nil {
+			rejected[i] = err.Error()
+			continue
+		}
+		if finished {
+			otel.GetMetrics().RecordActionExecution(ctx, action.ActionType, string(action.Status))
+		}
+	}
+
+	resp := &IngestResp{Results: make([]*EventResult, 0, len(raws))}
+	for i := range raws {
+		result := &EventResult{Index: i, Status: EventAccepted}
+		if msg, ok := rejected[i]; ok {
+			result.Status = EventRejected
+			result.Error = msg
+			resp.Rejected++
+		} else {
+			resp.Accepted++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// newAction 校验上报并转换为动作执行记录，保留原始时间
+func newAction(labID int64, req *ActionReq, workflowIDs map[uuid.UUID]int64, devices map[string]*model.MaterialNode, now time.Time) (*model.ActionExecutionHistory, error) {
+	if req.UUID.IsNil() {
+		return nil, fmt.Errorf("uuid is required")
+	}
+	if req.DeviceName == "" || req.ActionType == "" || req.ActionName == "" {
+		return nil, fmt.Errorf("device_name, action_type and action_name are required")
+	}
+	if req.Status != model.ExecutionStatusRunning && !req.Status.Finished() {
+		return nil, fmt.Errorf("status must be running or a finished status, got %q", req.Status)
+	}
+	if req.StartedAt.IsZero() || req.StartedAt.After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("started_at is missing or in the future")
+	}
+	if req.FinishedAt != nil && req.FinishedAt.Before(req.StartedAt) {
+		return nil, fmt.Errorf("finished_at is before started_at")
+	}
+	if req.DurationMs < 0 || req.ExpectedMs < 0 {
+		return nil, fmt.Errorf("duration_ms and expected_ms must not be negative")
+	}
+
+	var workflowExecID *int64
+	if req.WorkflowExecutionUUID != nil {
+		id, ok := workflowIDs[*req.WorkflowExecutionUUID]
+		if !ok {
+			return nil, fmt.Errorf("workflow execution %s not found", req.WorkflowExecutionUUID)
+		}
+		workflowExecID = &id
+	}
+
+	durationMs := req.DurationMs
+	updatedAt := req.StartedAt
+	if req.Status.Finished() {
+		updatedAt = req.StartedAt.Add(time.Duration(durationMs) * time.Millisecond)
+		if req.FinishedAt != nil {
+			updatedAt = *req.FinishedAt
+			if durationMs == 0 {
+				durationMs = req.FinishedAt.Sub(req.StartedAt).Milliseconds()
+			}
+		}
+	}
+
+	node, ok := devices[req.DeviceName]
+	if !ok {
+		return nil, fmt.Errorf("device %q not found in the lab", req.DeviceName)
+	}
+
+	return &model.ActionExecutionHistory{
+		BaseModel: model.BaseModel{
+			UUID:      req.UUID,
+			CreatedAt: req.StartedAt,
+			UpdatedAt: updatedAt,
+		},
+		WorkflowExecutionID: workflowExecID,
+		LabID:               labID,
+		DeviceID:            node.ID,
+		DeviceUUID:          node.UUID,
+		DeviceName:          req.DeviceName,
+		ActionType:          req.ActionType,
+		ActionName:          req.ActionName,
+		Input:               req.Input,
+		Output:              req.Output,
+		Status:              req.Status,
+		DurationMs:          durationMs,
+		ExpectedMs:          req.ExpectedMs,
+		ErrorMessage:        req.ErrorMessage,
+		Metadata:            req.Metadata,
+	}, nil
+}
+
+// workflowIDs 解析动作所属的工作流执行，只解析本实验室的执行
+func (s *Service) workflowIDs(ctx context.Context, labID int64, reqs []*ActionReq) (map[uuid.UUID]int64, error) {
+	uuids := make([]uuid.UUID, 0)
+	for _, req := range reqs {
+		if req != nil && req.WorkflowExecutionUUID != nil {
+			uuids = append(uuids, *req.WorkflowExecutionUUID)
+		}
+	}
+	ret := make(map[uuid.UUID]int64, len(uuids))
+	if len(uuids) == 0 {
+		return ret, nil
+	}
+
+	execs := make([]*model.WorkflowExecutionHistory, 0, len(uuids))
+	if err := s.baseDB.FindDatas(ctx, &execs, map[string]any{
+		"lab_id": labID,
+		"uuid":   uuids,
+	}, "id", "uuid"); err != nil {
+		return nil, err
+	}
+	for _, exec := range execs {
+		ret[exec.UUID] = exec.ID
+	}
+	return ret, nil
+}
@@ -0,0 +1,429 @@
+// Package edgeevent accepts the device events and action executions that
+// edge agents report in batches. Device events are reported with device API
+// keys, each key rate limited. Batches are JSON or NDJSON, optionally gzip
+// compressed; every item is validated on its own so one bad item does not
+// reject the rest of the batch.
+package edgeevent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/ratelimit"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/devicekey"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"gorm.io/datatypes"
+)
+
+const (
+	defaultMaxBatch          = 500
+	defaultMaxBodyBytes      = 8 << 20
+	defaultRequestsPerMinute = 120
+	rateLimitWindow          = time.Minute
+
+	// ContentTypeNDJSON 每行一条记录的请求体
+	ContentTypeNDJSON = "application/x-ndjson"
+	// 允许设备时钟比服务器快的时间
+	clockSkew = 5 * time.Minute
+	keyPrefix = "sdk_"
+)
+
+// localLimiter 限流在 redis 不可用时退化为进程内计数
+var localLimiter = ratelimit.NewLocalLimiter()
+
+// EventReq 设备上报的事件
+type EventReq struct {
+	DeviceName string                `json:"device_name"`
+	EventType  model.DeviceEventType `json:"event_type"`
+	EventData  datatypes.JSON        `json:"event_data" swaggertype:"object"` // 为空或 JSON 对象
+	Timestamp  time.Time             `json:"timestamp"`                       // 事件发生的原始时间
+}
+
+// IngestReq JSON 请求体，NDJSON 请求体每行是一个 EventReq
+type IngestReq struct {
+	Events []json.RawMessage `json:"events" swaggertype:"array,object"`
+}
+
+// EventResult 单条记录的处理结果，index 为记录在批次中的位置
+type EventResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"` // accepted | rejected
+	Error  string `json:"error,omitempty"`
+}
+
+// IngestResp 批次的处理结果，存在被拒绝的记录时响应状态码为 207
+type IngestResp struct {
+	Accepted int            `json:"accepted"`
+	Rejected int            `json:"rejected"`
+	Results  []*EventResult `json:"results"`
+}
+
+const (
+	EventAccepted = "accepted"
+	EventRejected = "rejected"
+)
+
+// CreateKeyReq 创建设备密钥
+type CreateKeyReq struct {
+	LabUUID    uuid.UUID `json:"lab_uuid" binding:"required"`
+	Name       string    `json:"name" binding:"required,max=255"`
+	DeviceName string    `json:"device_name" binding:"max=255"` // 为空时可以上报实验室所有设备的事件
+}
+
+// CreateKeyResp 设备密钥，明文只在创建时返回
+type CreateKeyResp struct {
+	*model.DeviceAPIKey
+	Key           string `json:"key"`
+	Authorization string `json:"authorization"` // 上报请求的 Authorization 请求头
+}
+
+// ListKeyReq 查询实验室的设备密钥
+type ListKeyReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+type Service struct {
+	keys     devicekey.DeviceKeyRepo
+	history  history.HistoryRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		keys:     devicekey.New(),
+		history:  history.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// CreateKey 创建设备密钥，仅实验室管理员可以操作
+func (s *Service) CreateKey(ctx context.Context, req *CreateKeyReq) (*CreateKeyResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	labID, err := s.checkAdmin(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, code.ParamErr.WithErr(err)
+	}
+	data := &model.DeviceAPIKey{
+		LabID:      labID,
+		Name:       req.Name,
+		DeviceName: req.DeviceName,
+		Prefix:     key[:len(keyPrefix)+8],
+		KeyHash:    auth.HashDeviceKey(key),
+		CreatedBy:  userInfo.ID,
+	}
+	if err := s.keys.CreateKey(ctx, data); err != nil {
+		return nil, err
+	}
+	return &CreateKeyResp{
+		DeviceAPIKey:  data,
+		Key:           key,
+		Authorization: string(auth.AuthTypeDevice) + " " + key,
+	}, nil
+}
+
+// ListKeys 获取实验室的设备密钥，包括已撤销的密钥
+func (s *Service) ListKeys(ctx context.Context, req *ListKeyReq) ([]*model.DeviceAPIKey, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	labID, err := s.checkAdmin(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.keys.ListKeys(ctx, labID)
+}
+
+// RevokeKey 撤销设备密钥，撤销后立即不能再上报
+func (s *Service) RevokeKey(ctx context.Context, labUUID, keyUUID uuid.UUID) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+	labID, err := s.checkAdmin(ctx, userInfo.ID, labUUID)
+	if err != nil {
+		return err
+	}
+	ok, err := s.keys.RevokeKey(ctx, labID, keyUUID, userInfo.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// Allow 按设备密钥限制每分钟的上报次数，返回需要等待的秒数
+func (s *Service) Allow(ctx context.Context) (int64, error) {
+	key := auth.GetDeviceKey(ctx)
+	if key == nil {
+		return 0, code.UnLogin
+	}
+
+	limit := config.GetStudioConfig().EdgeEvents.RequestsPerMinute
+	if limit <= 0 {
+		limit = defaultRequestsPerMinute
+	}
+	rlKey := ratelimit.BuildKey(ratelimit.KeyTypeUser, "device-key:"+key.UUID.String(), "edge-events")
+
+	if client := redis.GetClient(); client != nil {
+		allowed, _, retryAfter, err := ratelimit.NewSlidingWindowLimiter(client).Allow(ctx, rlKey, limit, rateLimitWindow)
+		if err == nil {
+			if !allowed {
+				return retryAfter, code.DeviceKeyRateLimitErr
+			}
+			return 0, nil
+		}
+		logger.Warnf(ctx, "edge events rate limit redis err, fallback to local: %+v", err)
+	}
+	if allowed, _, retryAfter := localLimiter.Allow(rlKey, limit, rateLimitWindow); !allowed {
+		return retryAfter, code.DeviceKeyRateLimitErr
+	}
+	return 0, nil
+}
+
+// Decode 读取请求体中的记录，JSON 请求体的记录在 field 字段中，gzip 压缩的请求体按解压后的大小限制
+func Decode(body io.Reader, contentType, contentEncoding, field string) ([]json.RawMessage, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, code.ParamErr.WithMsgf("invalid gzip body: %s", err)
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		return nil, code.ParamErr.WithMsgf("unsupported content encoding %q", contentEncoding)
+	}
+
+	maxBytes := config.GetStudioConfig().EdgeEvents.MaxBodyBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBodyBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		return nil, code.ParamErr.WithMsgf("read body: %s", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, code.PayloadTooLargeErr.WithMsgf("body exceeds %d bytes", maxBytes)
+	}
+
+	var items []json.RawMessage
+	if contentType == ContentTypeNDJSON {
+		items = splitLines(data)
+	} else {
+		req := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, code.ParamErr.WithErr(err)
+		}
+		if raw, ok := req[field]; ok {
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, code.ParamErr.WithErr(err)
+			}
+		}
+	}
+
+	maxBatch := config.GetStudioConfig().EdgeEvents.MaxBatch
+	if maxBatch <= 0 {
+		maxBatch = defaultMaxBatch
+	}
+	if len(items) == 0 || len(items) > maxBatch {
+		return nil, code.ParamErr.WithMsgf("%s must contain 1 to %d items", field, maxBatch)
+	}
+	return items, nil
+}
+
+// splitLines 拆分 NDJSON，跳过空行
+func splitLines(data []byte) []json.RawMessage {
+	items := make([]json.RawMessage, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		items = append(items, json.RawMessage(bytes.Clone(line)))
+	}
+	return items
+}
+
+// Ingest 写入设备上报的事件，无效的事件被拒绝，其余事件照常写入
+func (s *Service) Ingest(ctx context.Context, raws []json.RawMessage) (*IngestResp, error) {
+	key := auth.GetDeviceKey(ctx)
+	if key == nil {
+		return nil, code.UnLogin
+	}
+
+	reqs := make([]*EventReq, len(raws))
+	rejected := make(map[int]string)
+	for i, raw := range raws {
+		req := &EventReq{}
+		if err := json.Unmarshal(raw, req); err != nil {
+			rejected[i] = fmt.Sprintf("invalid event: %s", err)
+			continue
+		}
+		reqs[i] = req
+	}
+
+	names := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if req != nil {
+			names = append(names, req.DeviceName)
+		}
+	}
+	devices, err := s.devices(ctx, key.LabID, names)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	events := make([]*model.DeviceEventHistory, 0, len(reqs))
+	for i, req := range reqs {
+		if req == nil {
+			continue
+		}
+		event, err := newEvent(key, req, devices, now)
+		if err != nil {
+			rejected[i] = err.Error()
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if len(events) > 0 {
+		if _, err := s.history.IngestDeviceEvents(ctx, events); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.keys.TouchKey(context.WithoutCancel(ctx), key.ID, now); err != nil {
+		logger.Warnf(ctx, "touch device key fail key: %s, err: %+v", key.UUID, err)
+	}
+
+	resp := &IngestResp{Results: make([]*EventResult, 0, len(raws))}
+	for i := range raws {
+		result := &EventResult{Index: i, Status: EventAccepted}
+		if msg, ok := rejected[i]; ok {
+			result.Status = EventRejected
+			result.Error = msg
+			resp.Rejected++
+		} else {
+			resp.Accepted++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// newEvent 校验事件并转换为设备事件记录，保留原始时间
+func newEvent(key *model.DeviceAPIKey, req *EventReq, devices map[string]*model.MaterialNode, now time.Time) (*model.DeviceEventHistory, error) {
+	if req.DeviceName == "" {
+		return nil, fmt.Errorf("device_name is required")
+	}
+	if key.DeviceName != "" && req.DeviceName != key.DeviceName {
+		return nil, fmt.Errorf("device key is limited to device %q", key.DeviceName)
+	}
+	if !req.EventType.Valid() {
+		return nil, fmt.Errorf("event_type must be one of %s", strings.Join(req.EventType.Values(), ", "))
+	}
+	if req.Timestamp.IsZero() || req.Timestamp.After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("timestamp is missing or in the future")
+	}
+	if len(req.EventData) > 0 {
+		var obj map[string]any
+		if err := json.Unmarshal(req.EventData, &obj); err != nil || obj == nil {
+			return nil, fmt.Errorf("event_data must be a JSON object")
+		}
+	}
+	node, ok := devices[req.DeviceName]
+	if !ok {
+		return nil, fmt.Errorf("device %q not found in the lab", req.DeviceName)
+	}
+
+	return &model.DeviceEventHistory{
+		LabID:      key.LabID,
+		DeviceID:   node.ID,
+		DeviceUUID: node.UUID,
+		EventType:  req.EventType,
+		EventData:  req.EventData,
+		Timestamp:  req.Timestamp,
+	}, nil
+}
+
+// devices 按名称解析实验室的设备节点
+func (s *Service) devices(ctx context.Context, labID int64, names []string) (map[string]*model.MaterialNode, error) {
+	names = slices.DeleteFunc(names, func(name string) bool { return name == "" })
+	ret := make(map[string]*model.MaterialNode, len(names))
+	if len(names) == 0 {
+		return ret, nil
+	}
+
+	nodes := make([]*model.MaterialNode, 0, len(names))
+	if err := s.baseDB.FindDatas(ctx, &nodes, map[string]any{
+		"lab_id": labID,
+		"name":   names,
+		"type":   model.MATERIALDEVICE,
+	}, "id", "uuid", "name"); err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		ret[node.Name] = node
+	}
+	return ret, nil
+}
+
+// checkAdmin 设备密钥可以写入实验室的事件，仅管理员可以管理
+func (s *Service) checkAdmin(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+		"role":    model.LaboratoryMemberAdmin,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+func newKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return keyPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,151 @@
+package edgeevent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	events, err := Decode(strings.NewReader(`{"events":[{"device_name":"a"},{"device_name":"b"}]}`), "application/json", "", "events")
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	ndjson := "{\"device_name\":\"a\"}\n\n{not json}\n{\"device_name\":\"c\"}\n"
+	events, err = Decode(strings.NewReader(ndjson), ContentTypeNDJSON, "", "events")
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "{not json}", string(events[1]))
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(ndjson))
+	require.NoError(t, zw.Close())
+	events, err = Decode(&buf, ContentTypeNDJSON, "gzip", "events")
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	_, err = Decode(strings.NewReader(ndjson), ContentTypeNDJSON, "br", "events")
+	assert.Error(t, err)
+	_, err = Decode(strings.NewReader(ndjson), ContentTypeNDJSON, "gzip", "events")
+	assert.Error(t, err)
+	_, err = Decode(strings.NewReader(`{"events":[]}`), "application/json", "", "events")
+	assert.Error(t, err)
+	_, err = Decode(strings.NewReader(`{"events":[{}]}`), "application/json", "", "actions")
+	assert.Error(t, err)
+	_, err = Decode(strings.NewReader(strings.Repeat("{}\n", defaultMaxBatch+1)), ContentTypeNDJSON, "", "events")
+	assert.Error(t, err)
+}
+
+func TestNewEvent(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	deviceUUID := uuid.NewV4()
+	devices := map[string]*model.MaterialNode{
+		"robot": {BaseModel: model.BaseModel{ID: 7, UUID: deviceUUID}, Name: "robot"},
+	}
+	key := &model.DeviceAPIKey{LabID: 3}
+
+	valid := func() *EventReq {
+		return &EventReq{
+			DeviceName: "robot",
+			EventType:  model.DeviceEventError,
+			EventData:  []byte(`{"code":12}`),
+			Timestamp:  now.Add(-time.Minute),
+		}
+	}
+
+	event, err := newEvent(key, valid(), devices, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), event.LabID)
+	assert.Equal(t, int64(7), event.DeviceID)
+	assert.Equal(t, deviceUUID, event.DeviceUUID)
+	assert.Equal(t, now.Add(-time.Minute), event.Timestamp)
+
+	r := valid()
+	r.EventData = nil
+	_, err = newEvent(key, r, devices, now)
+	assert.NoError(t, err)
+
+	invalid := []func(r *EventReq){
+		func(r *EventReq) { r.DeviceName = "" },
+		func(r *EventReq) { r.DeviceName = "unknown" },
+		func(r *EventReq) { r.EventType = "reboot" },
+		func(r *EventReq) { r.Timestamp = time.Time{} },
+		func(r *EventReq) { r.Timestamp = now.Add(time.Hour) },
+		func(r *EventReq) { r.EventData = []byte(`[1,2]`) },
+	}
+	for _, mutate := range invalid {
+		r = valid()
+		mutate(r)
+		_, err = newEvent(key, r, devices, now)
+		assert.Error(t, err)
+	}
+
+	_, err = newEvent(&model.DeviceAPIKey{LabID: 3, DeviceName: "pump"}, valid(), devices, now)
+	assert.Error(t, err)
+}
+
+func TestNewAction(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-time.Hour)
+	finished := started.Add(1500 * time.Millisecond)
+	execUUID := uuid.NewV4()
+	devices := map[string]*model.MaterialNode{
+		"robot": {BaseModel: model.BaseModel{ID: 7, UUID: uuid.NewV4()}, Name: "robot"},
+	}
+	workflows := map[uuid.UUID]int64{execUUID: 42}
+
+	valid := func() *ActionReq {
+		return &ActionReq{
+			UUID:                  uuid.NewV4(),
+			WorkflowExecutionUUID: &execUUID,
+			DeviceName:            "robot",
+			ActionType:            "move",
+			ActionName:            "pick",
+			Status:                model.ExecutionStatusSuccess,
+			StartedAt:             started,
+			FinishedAt:            &finished,
+		}
+	}
+
+	action, err := newAction(3, valid(), workflows, devices, now)
+	require.NoError(t, err)
+	assert.Equal(t, started, action.CreatedAt)
+	assert.Equal(t, finished, action.UpdatedAt)
+	assert.Equal(t, int64(1500), action.DurationMs)
+	assert.Equal(t, int64(42), *action.WorkflowExecutionID)
+	assert.Equal(t, int64(7), action.DeviceID)
+
+	r := valid()
+	r.Status = model.ExecutionStatusRunning
+	r.FinishedAt = nil
+	r.WorkflowExecutionUUID = nil
+	action, err = newAction(3, r, workflows, devices, now)
+	require.NoError(t, err)
+	assert.Equal(t, started, action.UpdatedAt)
+	assert.Nil(t, action.WorkflowExecutionID)
+
+	invalid := []func(r *ActionReq){
+		func(r *ActionReq) { r.UUID = uuid.NewNil() },
+		func(r *ActionReq) { r.ActionType = "" },
+		func(r *ActionReq) { r.DeviceName = "unknown" },
+		func(r *ActionReq) { r.Status = model.ExecutionStatusPending },
+		func(r *ActionReq) { r.StartedAt = now.Add(time.Hour) },
+		func(r *ActionReq) { before := started.Add(-time.Second); r.FinishedAt = &before },
+		func(r *ActionReq) { r.ExpectedMs = -1 },
+		func(r *ActionReq) { other := uuid.NewV4(); r.WorkflowExecutionUUID = &other },
+	}
+	for _, mutate := range invalid {
+		r = valid()
+		mutate(r)
+		_, err = newAction(3, r, workflows, devices, now)
+		assert.Error(t, err)
+	}
+}
@@ -50,6 +50,7 @@ func (l *lab) CreateLaboratoryEnv(ctx context.Context, req *environment.Laborato
 		data = &model.Laboratory{
 			Name:         req.Name,
 			UserID:       userInfo.ID,
+			OrgID:        userInfo.Owner,
 			Status:       model.INIT,
 			AccessKey:    "tmp",
 			AccessSecret: "tmp",
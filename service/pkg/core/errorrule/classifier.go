@@ -0,0 +1,94 @@
+package errorrule
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/errorrule"
+)
+
+// cacheTTL 各进程缓存实验室规则的时间，其他进程修改规则后最多延迟这么久生效
+const cacheTTL = 30 * time.Second
+
+type compiledRule struct {
+	rule *model.ErrorRule
+	re   *regexp.Regexp
+}
+
+// labRules is the compiled rules of a lab loaded by a process
+type labRules struct {
+	rules    []*compiledRule
+	loadedAt time.Time
+}
+
+var (
+	cache sync.Map // lab id -> *labRules
+	store = sync.OnceValue(errorrule.New)
+)
+
+// compile matches patterns case-insensitively
+func compile(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// loadRules returns the compiled rules of a lab, reloading them when stale.
+// The previous rules are kept when reloading fails
+func loadRules(ctx context.Context, labID int64) []*compiledRule {
+	prev, _ := cache.Load(labID)
+	if prev != nil && time.Since(prev.(*labRules).loadedAt) < cacheTTL {
+		return prev.(*labRules).rules
+	}
+
+	datas, err := store().ListRules(ctx, labID)
+	if err != nil {
+		logger.Errorf(ctx, "error rule load lab: %d, err: %+v", labID, err)
+		if prev == nil {
+			return nil
+		}
+		return prev.(*labRules).rules
+	}
+
+	loaded := &labRules{rules: make([]*compiledRule, 0, len(datas)), loadedAt: time.Now()}
+	for _, data := range datas {
+		re, err := compile(data.Pattern)
+		if err != nil {
+			// 规则保存前已校验，这里只可能是直接修改了数据库
+			logger.Warnf(ctx, "error rule %s pattern invalid: %+v", data.UUID, err)
+			continue
+		}
+		loaded.rules = append(loaded.rules, &compiledRule{rule: data, re: re})
+	}
+	cache.Store(labID, loaded)
+	return loaded.rules
+}
+
+// invalidate makes the next classification of the lab reload its rules
+func invalidate(labID int64) {
+	cache.Delete(labID)
+}
+
+// match returns the first rule matching the message, nil when none
+func match(rules []*compiledRule, message string) *model.ErrorRule {
+	if message == "" {
+		return nil
+	}
+	for _, r := range rules {
+		if r.re.MatchString(message) {
+			return r.rule
+		}
+	}
+	return nil
+}
+
+// Classify returns the error category of a failure message of a lab,
+// unclassified when no rule of the lab matches
+func Classify(ctx context.Context, labID int64, message string) string {
+	if rule := match(loadRules(ctx, labID), message); rule != nil {
+		return rule.Category
+	}
+	return model.ErrorCategoryUnclassified
+}
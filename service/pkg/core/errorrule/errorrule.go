@@ -0,0 +1,215 @@
+// Package errorrule classifies the error messages of failed executions and
+// actions into categories with regular expression rules configured per lab,
+// so that failures can be counted by cause instead of by raw message.
+package errorrule
+
+import (
+	"context"
+	"strings"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/errorrule"
+)
+
+// CreateReq 创建错误分类规则
+type CreateReq struct {
+	LabUUID  uuid.UUID `json:"lab_uuid" binding:"required"`
+	Category string    `json:"category" binding:"required,max=64"`
+	Pattern  string    `json:"pattern" binding:"required,max=1024"` // 正则表达式，不区分大小写
+	Priority int       `json:"priority"`                            // 越小越先匹配
+}
+
+// UpdateReq 更新错误分类规则，已分类的失败记录不会重新分类
+type UpdateReq struct {
+	Category string `json:"category" binding:"required,max=64"`
+	Pattern  string `json:"pattern" binding:"required,max=1024"`
+	Priority int    `json:"priority"`
+}
+
+// ListReq 查询实验室的错误分类规则
+type ListReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// TestReq 用实验室当前的规则对错误信息分类，用于调试规则
+type TestReq struct {
+	LabUUID uuid.UUID `json:"lab_uuid" binding:"required"`
+	Message string    `json:"message" binding:"required"`
+}
+
+// TestResp 分类结果，没有规则匹配时 Rule 为空
+type TestResp struct {
+	Category string           `json:"category"`
+	Rule     *model.ErrorRule `json:"rule"`
+}
+
+type Service struct {
+	store    errorrule.ErrorRuleRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    store(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// Create 创建规则，实验室成员可以配置
+func (s *Service) Create(ctx context.Context, req *CreateReq) (*model.ErrorRule, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	category, err := validateRule(req.Category, req.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &model.ErrorRule{
+		LabID:     labID,
+		LabUUID:   req.LabUUID,
+		Category:  category,
+		Pattern:   req.Pattern,
+		Priority:  req.Priority,
+		CreatedBy: userInfo.ID,
+	}
+	if err := s.store.CreateRule(ctx, data); err != nil {
+		return nil, err
+	}
+	invalidate(labID)
+	return data, nil
+}
+
+// List 获取实验室的规则，按匹配顺序排列
+func (s *Service) List(ctx context.Context, req *ListReq) ([]*model.ErrorRule, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListRules(ctx, labID)
+}
+
+// Update 更新规则
+func (s *Service) Update(ctx context.Context, ruleUUID uuid.UUID, req *UpdateReq) (*model.ErrorRule, error) {
+	data, err := s.memberRule(ctx, ruleUUID)
+	if err != nil {
+		return nil, err
+	}
+	category, err := validateRule(req.Category, req.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Category = category
+	data.Pattern = req.Pattern
+	data.Priority = req.Priority
+	if err := s.store.UpdateRule(ctx, data); err != nil {
+		return nil, err
+	}
+	invalidate(data.LabID)
+	return data, nil
+}
+
+// Delete 删除规则
+func (s *Service) Delete(ctx context.Context, ruleUUID uuid.UUID) error {
+	data, err := s.memberRule(ctx, ruleUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteRule(ctx, data.ID); err != nil {
+		return err
+	}
+	invalidate(data.LabID)
+	return nil
+}
+
+// Test 返回错误信息按当前规则的分类
+func (s *Service) Test(ctx context.Context, req *TestReq) (*TestResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	invalidate(labID)
+	resp := &TestResp{Category: model.ErrorCategoryUnclassified}
+	if rule := match(loadRules(ctx, labID), req.Message); rule != nil {
+		resp.Category = rule.Category
+		resp.Rule = rule
+	}
+	return resp, nil
+}
+
+// validateRule checks the pattern compiles and returns the trimmed category
+func validateRule(category, pattern string) (string, error) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return "", code.ParamErr.WithMsg("category is required")
+	}
+	if category == model.ErrorCategoryUnclassified {
+		return "", code.ParamErr.WithMsgf("category %s is reserved", category)
+	}
+	if _, err := compile(pattern); err != nil {
+		return "", code.ErrorRulePatternErr.WithMsg(err.Error())
+	}
+	return category, nil
+}
+
+func (s *Service) memberRule(ctx context.Context, ruleUUID uuid.UUID) (*model.ErrorRule, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetRuleByUUID(ctx, ruleUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMemberByID(ctx, userInfo.ID, data.LabID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	if err := s.checkMemberByID(ctx, userID, labID); err != nil {
+		return 0, err
+	}
+	return labID, nil
+}
+
+func (s *Service) checkMemberByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
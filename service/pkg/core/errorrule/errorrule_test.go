@@ -0,0 +1,69 @@
+package errorrule
+
+import (
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	var rules []*compiledRule
+	for _, rule := range []*model.ErrorRule{
+		{Category: "device_offline", Pattern: `disconnect|not acknowledged`},
+		{Category: "timeout", Pattern: `time(d)? ?out`},
+		{Category: "liquid", Pattern: `^pipette: (clot|bubble)`},
+	} {
+		re, err := compile(rule.Pattern)
+		assert.NoError(t, err)
+		rules = append(rules, &compiledRule{rule: rule, re: re})
+	}
+
+	cases := map[string]string{
+		"Delivery not acknowledged":      "device_offline",
+		"result TIMEOUT":                 "timeout",
+		"device disconnected, timed out": "device_offline",
+		"pipette: clot detected":         "liquid",
+		"sensor: pipette: clot detected": "",
+		"":                               "",
+	}
+	for message, category := range cases {
+		rule := match(rules, message)
+		if category == "" {
+			assert.Nil(t, rule, message)
+			continue
+		}
+		if assert.NotNil(t, rule, message) {
+			assert.Equal(t, category, rule.Category, message)
+		}
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	category, err := validateRule("  timeout ", `time(d)? ?out`)
+	assert.NoError(t, err)
+	assert.Equal(t, "timeout", category)
+
+	_, err = validateRule(" ", `timeout`)
+	assert.Error(t, err)
+	_, err = validateRule(model.ErrorCategoryUnclassified, `timeout`)
+	assert.Error(t, err)
+	_, err = validateRule("timeout", `time(out`)
+	assert.Error(t, err)
+}
+
+func TestSortCategories(t *testing.T) {
+	stats := &model.FailureStats{Categories: []*model.FailureCategoryCount{
+		{Category: "timeout", Executions: 1, Actions: 1},
+		{Category: model.ErrorCategoryUnclassified, Executions: 3},
+		{Category: "liquid", Actions: 2},
+	}}
+	stats.SortCategories()
+	assert.Equal(t, model.ErrorCategoryUnclassified, stats.Categories[0].Category)
+	assert.Equal(t, "liquid", stats.Categories[1].Category)
+	assert.Equal(t, "timeout", stats.Categories[2].Category)
+
+	empty := &model.FailureStats{}
+	empty.SortCategories()
+	assert.NotNil(t, empty.Categories)
+}
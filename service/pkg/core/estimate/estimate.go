@@ -0,0 +1,318 @@
+// Package estimate predicts when pending and running executions complete from
+// the duration history of their workflow, or of their steps on each device,
+// and how long a step waits for a locked device.
+package estimate
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/schedule/lock"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	// 只使用最近 90 天的历史
+	historyWindow = 90 * 24 * time.Hour
+	// 每个工作流最多使用最近 50 次成功执行的时长
+	sampleLimit = 50
+	// 排队预测最多包含的执行数
+	maxQueue = 200
+)
+
+type Service struct {
+	historyStore history.HistoryRepo
+	baseDB       repo.IDOrUUIDTranslate
+	deviceLock   lock.DeviceLocker
+}
+
+func New() *Service {
+	return &Service{
+		historyStore: history.New(),
+		baseDB:       repo.NewBaseDB(),
+		deviceLock:   lock.New(redis.GetClient()),
+	}
+}
+
+// profile 工作流的设备和按步骤估算的时长
+type profile struct {
+	devices      []string // nil 表示有步骤的设备未知，与所有执行冲突
+	unknownSteps int
+	duration     model.DurationRange
+	known        bool // 至少有一个步骤有历史或预算
+	samples      model.DurationDistribution
+}
+
+// Queue 预测实验室排队中和运行中执行的完成时间，按排队顺序返回
+func (s *Service) Queue(ctx context.Context, labID int64) (*model.QueueForecast, error) {
+	now := time.Now()
+	executions, err := s.historyStore.ListActiveExecutions(ctx, labID, maxQueue)
+	if err != nil {
+		return nil, err
+	}
+	estimates, err := s.estimate(ctx, labID, executions, now)
+	if err != nil {
+		return nil, err
+	}
+	return &model.QueueForecast{
+		LabID:       labID,
+		GeneratedAt: now,
+		Executions:  estimates,
+	}, nil
+}
+
+// Execution 预测单个执行的完成时间，包括排在它前面的执行，已结束的执行返回 nil
+func (s *Service) Execution(ctx context.Context, exec *model.WorkflowExecutionHistory) (*model.ExecutionEstimate, error) {
+	if exec.Status != model.ExecutionStatusPending && exec.Status != model.ExecutionStatusRunning {
+		return nil, nil
+	}
+	forecast, err := s.Queue(ctx, exec.LabID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range forecast.Executions {
+		if e.ExecutionUUID == exec.UUID {
+			return e, nil
+		}
+	}
+
+	// 超出排队预测范围时只按自身时长估算
+	estimates, err := s.estimate(ctx, exec.LabID, []*model.WorkflowExecutionHistory{exec}, forecast.GeneratedAt)
+	if err != nil {
+		return nil, err
+	}
+	return estimates[0], nil
+}
+
+func (s *Service) estimate(ctx context.Context, labID int64, executions []*model.WorkflowExecutionHistory, now time.Time) ([]*model.ExecutionEstimate, error) {
+	estimates := make([]*model.ExecutionEstimate, 0, len(executions))
+	if len(executions) == 0 {
+		return estimates, nil
+	}
+
+	profiles, err := s.profiles(ctx, labID, executions, now)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := make([]*slot, 0, len(executions))
+	for _, exec := range executions {
+		e, sl := newEstimate(exec, profiles[exec.WorkflowID], now)
+		estimates = append(estimates, e)
+		slots = append(slots, sl)
+	}
+
+	for i, p := range schedule(slots) {
+		e := estimates[i]
+		if e.Source == model.EstimateSourceNone {
+			continue
+		}
+		e.Ahead = p.ahead
+		e.Remaining = p.end
+		e.CompletionEarliest = after(now, p.end.LowMs)
+		e.CompletionExpected = after(now, p.end.ExpectedMs)
+		e.CompletionLatest = after(now, p.end.HighMs)
+		if e.Status == model.ExecutionStatusPending {
+			e.StartExpected = after(now, p.start.ExpectedMs)
+		}
+	}
+	return estimates, nil
+}
+
+// profiles 加载执行所属工作流的时长分布、设备和步骤时长
+func (s *Service) profiles(ctx context.Context, labID int64, executions []*model.WorkflowExecutionHistory, now time.Time) (map[int64]*profile, error) {
+	workflowIDs := make([]int64, 0, len(executions))
+	profiles := make(map[int64]*profile, len(executions))
+	for _, exec := range executions {
+		if _, ok := profiles[exec.WorkflowID]; ok {
+			continue
+		}
+		profiles[exec.WorkflowID] = &profile{devices: []string{}}
+		workflowIDs = append(workflowIDs, exec.WorkflowID)
+	}
+
+	since := now.Add(-historyWindow)
+	samples, err := s.historyStore.ListDurationSamples(ctx, workflowIDs, since, sampleLimit)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.historyStore.ListStepDurationPercentiles(ctx, labID, since)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*model.WorkflowNode, 0, len(workflowIDs)*5)
+	if err := s.baseDB.FindDatas(ctx, &nodes, map[string]any{
+		"workflow_id": workflowIDs,
+		"type":        model.WorkflowNodeILab,
+		"disabled":    false,
+	}, "workflow_id", "device_name", "action_name", "expected_ms"); err != nil {
+		return nil, err
+	}
+
+	index := indexSteps(stats)
+	seen := make(map[int64]map[string]bool, len(workflowIDs))
+	for _, node := range nodes {
+		p := profiles[node.WorkflowID]
+		deviceName := utils.SafeValue(func() string { return *node.DeviceName }, "")
+		r, ok := index.step(deviceName, node.ActionName)
+		if !ok && node.ExpectedMs > 0 {
+			r, ok = model.DurationRange{LowMs: node.ExpectedMs, ExpectedMs: node.ExpectedMs, HighMs: node.ExpectedMs}, true
+		}
+		if ok {
+			p.duration = p.duration.Add(r)
+			p.known = true
+		} else {
+			p.unknownSteps++
+		}
+
+		if deviceName == "" {
+			p.devices = nil
+			continue
+		}
+		if p.devices == nil {
+			continue
+		}
+		if seen[node.WorkflowID] == nil {
+			seen[node.WorkflowID] = make(map[string]bool)
+		}
+		if !seen[node.WorkflowID][deviceName] {
+			seen[node.WorkflowID][deviceName] = true
+			p.devices = append(p.devices, deviceName)
+		}
+	}
+	for workflowID, p := range profiles {
+		p.samples = model.NewDurationDistribution(samples[workflowID])
+	}
+	return profiles, nil
+}
+
+// newEstimate 按工作流分布或步骤时长估算执行自身的时长和剩余时长，不含排队等待
+func newEstimate(exec *model.WorkflowExecutionHistory, p *profile, now time.Time) (*model.ExecutionEstimate, *slot) {
+	e := &model.ExecutionEstimate{
+		ExecutionUUID: exec.UUID,
+		WorkflowName:  exec.WorkflowName,
+		Status:        exec.Status,
+		Source:        model.EstimateSourceNone,
+		Samples:       len(p.samples),
+		UnknownSteps:  p.unknownSteps,
+	}
+	sl := &slot{devices: p.devices, running: exec.Status == model.ExecutionStatusRunning}
+
+	// 运行中按已完成的步骤比例估算按步骤估算的剩余时长
+	stepsLeft := p.duration
+	if sl.running && exec.StepsTotal > 0 {
+		stepsLeft = p.duration.Scale(float64(max(exec.StepsTotal-exec.StepsCompleted, 0)) / float64(exec.StepsTotal))
+	}
+
+	if r, ok := p.samples.Range(); ok {
+		e.Source = model.EstimateSourceWorkflow
+		e.Duration = r
+		sl.remaining = r
+		if sl.running {
+			left, ok := p.samples.Remaining(now.Sub(exec.StartedAt).Milliseconds())
+			if !ok {
+				e.Overdue = true
+				left = model.DurationRange{}
+				if p.known {
+					left = stepsLeft
+				}
+			}
+			sl.remaining = left
+		}
+		return e, sl
+	}
+
+	if p.known {
+		e.Source = model.EstimateSourceSteps
+		e.Duration = p.duration
+		sl.remaining = p.duration
+		if sl.running {
+			sl.remaining = stepsLeft
+		}
+	}
+	return e, sl
+}
+
+// stepIndex 按设备和动作、按设备索引的步骤时长
+type stepIndex struct {
+	actions map[[2]string]*model.StepDurationPercentiles
+	devices map[string]*model.StepDurationPercentiles
+}
+
+func indexSteps(stats []*model.StepDurationPercentiles) *stepIndex {
+	index := &stepIndex{
+		actions: make(map[[2]string]*model.StepDurationPercentiles, len(stats)),
+		devices: make(map[string]*model.StepDurationPercentiles),
+	}
+	for _, s := range stats {
+		if s.ActionName == "" {
+			index.devices[s.DeviceName] = s
+			continue
+		}
+		index.actions[[2]string{s.DeviceName, s.ActionName}] = s
+	}
+	return index
+}
+
+// step 返回动作在设备上的历史时长，动作样本不足时使用设备上所有动作的时长
+func (i *stepIndex) step(deviceName, actionName string) (model.DurationRange, bool) {
+	if r, ok := i.actions[[2]string{deviceName, actionName}].Range(); ok {
+		return r, true
+	}
+	return i.devices[deviceName].Range()
+}
+
+// DeviceWait 估算等待设备锁的时长：持有者当前步骤的剩余时长加上排在前面的等待者的步骤时长，
+// 设备没有足够历史时返回 false
+func (s *Service) DeviceWait(ctx context.Context, labID int64, labUUID uuid.UUID, deviceName, owner string) (model.DurationRange, bool) {
+	stats, err := s.historyStore.ListStepDurationPercentiles(ctx, labID, time.Now().Add(-historyWindow))
+	if err != nil {
+		return model.DurationRange{}, false
+	}
+	states, err := s.deviceLock.List(ctx, labUUID)
+	if err != nil {
+		return model.DurationRange{}, false
+	}
+	for _, state := range states {
+		if state.DeviceName == deviceName {
+			return deviceWait(state, indexSteps(stats).devices[deviceName], owner, time.Now())
+		}
+	}
+	return model.DurationRange{}, false
+}
+
+func deviceWait(state *lock.State, stat *model.StepDurationPercentiles, owner string, now time.Time) (model.DurationRange, bool) {
+	step, ok := stat.Range()
+	if !ok {
+		return model.DurationRange{}, false
+	}
+
+	var wait model.DurationRange
+	if state.Holder != nil && state.Holder.Owner != owner {
+		elapsed := now.Sub(state.Holder.AcquiredAt).Milliseconds()
+		wait = model.DurationRange{
+			LowMs:      max(step.LowMs-elapsed, 0),
+			ExpectedMs: max(step.ExpectedMs-elapsed, 0),
+			HighMs:     max(step.HighMs-elapsed, 0),
+		}
+	}
+	ahead := len(state.Waiters)
+	for i, waiter := range state.Waiters {
+		if waiter == owner {
+			ahead = i
+			break
+		}
+	}
+	return wait.Add(step.Scale(float64(ahead))), true
+}
+
+// after 返回 now 之后 ms 毫秒的时间
+func after(now time.Time, ms int64) *time.Time {
+	t := now.Add(time.Duration(ms) * time.Millisecond)
+	return &t
+}
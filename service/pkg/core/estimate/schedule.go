@@ -0,0 +1,91 @@
+package estimate
+
+import "github.com/scienceol/studio/service/pkg/model"
+
+// slot 排队模拟中的一个执行
+type slot struct {
+	devices   []string // nil 表示设备未知，与所有执行冲突
+	running   bool
+	remaining model.DurationRange // 从开始运行或从现在起的剩余时长
+}
+
+// placement 执行在排队模拟中相对现在的开始和结束时间，以及排在前面且有设备冲突的执行数
+type placement struct {
+	start model.DurationRange
+	end   model.DurationRange
+	ahead int
+}
+
+// schedule 按排队顺序模拟执行：运行中的执行从现在开始，排队中的执行在使用的设备都空闲后开始，
+// 分别按乐观、预期和悲观时长各模拟一次
+func schedule(slots []*slot) []*placement {
+	placements := make([]*placement, len(slots))
+	for i := range slots {
+		placements[i] = &placement{ahead: ahead(slots, i)}
+	}
+
+	scenarios := []struct {
+		get func(r *model.DurationRange) *int64
+	}{
+		{get: func(r *model.DurationRange) *int64 { return &r.LowMs }},
+		{get: func(r *model.DurationRange) *int64 { return &r.ExpectedMs }},
+		{get: func(r *model.DurationRange) *int64 { return &r.HighMs }},
+	}
+	for _, scenario := range scenarios {
+		freeAt := make(map[string]int64)
+		var barrier, last int64 // 设备未知的执行结束时间，所有执行的最晚结束时间
+		for i, sl := range slots {
+			var start int64
+			switch {
+			case sl.running:
+			case sl.devices == nil:
+				start = last
+			default:
+				start = barrier
+				for _, d := range sl.devices {
+					start = max(start, freeAt[d])
+				}
+			}
+			end := start + *scenario.get(&sl.remaining)
+
+			if sl.devices == nil {
+				barrier = max(barrier, end)
+			}
+			for _, d := range sl.devices {
+				freeAt[d] = max(freeAt[d], end)
+			}
+			last = max(last, end)
+			*scenario.get(&placements[i].start) = start
+			*scenario.get(&placements[i].end) = end
+		}
+	}
+	return placements
+}
+
+// ahead 返回排在第 i 个排队中的执行前面且与它有设备冲突的执行数
+func ahead(slots []*slot, i int) int {
+	if slots[i].running {
+		return 0
+	}
+	n := 0
+	for _, sl := range slots[:i] {
+		if conflicts(sl.devices, slots[i].devices) {
+			n++
+		}
+	}
+	return n
+}
+
+func conflicts(a, b []string) bool {
+	if a == nil || b == nil {
+		return true
+	}
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package estimate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/core/schedule/lock"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func rng(low, expected, high int64) model.DurationRange {
+	return model.DurationRange{LowMs: low, ExpectedMs: expected, HighMs: high}
+}
+
+func TestSchedule(t *testing.T) {
+	slots := []*slot{
+		{devices: []string{"robot"}, running: true, remaining: rng(10, 20, 30)},
+		{devices: []string{"reader"}, running: true, remaining: rng(5, 5, 5)},
+		{devices: []string{"robot", "reader"}, remaining: rng(100, 100, 100)},
+		{devices: []string{"shaker"}, remaining: rng(1, 2, 3)},
+		{devices: nil, remaining: rng(10, 10, 10)},
+		{devices: []string{"shaker"}, remaining: rng(1, 1, 1)},
+	}
+	p := schedule(slots)
+
+	assert.Equal(t, rng(0, 0, 0), p[0].start)
+	assert.Equal(t, rng(10, 20, 30), p[0].end)
+	// 等待 robot 和 reader 都空闲
+	assert.Equal(t, rng(10, 20, 30), p[2].start)
+	assert.Equal(t, rng(110, 120, 130), p[2].end)
+	assert.Equal(t, 2, p[2].ahead)
+	// 不冲突的执行立即开始
+	assert.Equal(t, rng(0, 0, 0), p[3].start)
+	assert.Equal(t, 0, p[3].ahead)
+	// 设备未知的执行等待所有执行结束，之后的执行都排在它后面
+	assert.Equal(t, rng(110, 120, 130), p[4].start)
+	assert.Equal(t, 4, p[4].ahead)
+	assert.Equal(t, rng(120, 130, 140), p[5].start)
+	assert.Equal(t, 2, p[5].ahead)
+}
+
+func TestDeviceWait(t *testing.T) {
+	now := time.Now()
+	stat := &model.StepDurationPercentiles{DeviceName: "robot", Samples: 10, P10Ms: 1000, P50Ms: 2000, P90Ms: 4000}
+	state := &lock.State{
+		DeviceName: "robot",
+		Holder:     &lock.Lease{Owner: "a", AcquiredAt: now.Add(-1500 * time.Millisecond)},
+		Waiters:    []string{"b", "c", "d"},
+	}
+
+	wait, ok := deviceWait(state, stat, "c", now)
+	assert.True(t, ok)
+	assert.Equal(t, rng(1000, 2500, 6500), wait)
+
+	// 不在等待队列中时排在所有等待者之后
+	wait, ok = deviceWait(state, stat, "e", now)
+	assert.True(t, ok)
+	assert.Equal(t, rng(3000, 6500, 14500), wait)
+
+	_, ok = deviceWait(state, &model.StepDurationPercentiles{Samples: 1}, "c", now)
+	assert.False(t, ok)
+}
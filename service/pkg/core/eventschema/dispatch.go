@@ -0,0 +1,113 @@
+package eventschema
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/hub"
+	"github.com/scienceol/studio/service/pkg/core/notify"
+	"github.com/scienceol/studio/service/pkg/core/notify/events"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+)
+
+const (
+	// Webhook 请求头，请求体为事件的 data
+	HeaderEventID      = "X-Studio-Event-ID"
+	HeaderEventType    = "X-Studio-Event"
+	HeaderEventVersion = "X-Studio-Event-Version"
+
+	resultSent    = "sent"
+	resultInvalid = "invalid"
+	resultFailed  = "failed"
+)
+
+// Event is the envelope of an event published on the event bus
+type Event struct {
+	ID         uuid.UUID `json:"id"`
+	Type       Type      `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	LabUUID    uuid.UUID `json:"lab_uuid"`
+	Data       any       `json:"data"`
+}
+
+// NewEvent wraps the payload with the latest schema version of its type after
+// validating it, so no event violating the published schema is emitted
+func NewEvent(eventType Type, labUUID uuid.UUID, data any) (*Event, error) {
+	def, ok := Get(eventType)
+	if !ok {
+		return nil, Validate(eventType, 0, data)
+	}
+	version := def.Latest().Version
+	if err := Validate(eventType, version, data); err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:         uuid.NewV4(),
+		Type:       eventType,
+		Version:    version,
+		OccurredAt: time.Now(),
+		LabUUID:    labUUID,
+		Data:       data,
+	}, nil
+}
+
+// Headers returns the webhook headers describing the event
+func (e *Event) Headers() map[string]string {
+	return map[string]string{
+		HeaderEventID:      e.ID.String(),
+		HeaderEventType:    string(e.Type),
+		HeaderEventVersion: strconv.Itoa(e.Version),
+	}
+}
+
+// Dispatcher publishes validated events on the event bus
+type Dispatcher struct {
+	msgCenter notify.MsgCenter
+	hub       *hub.Hub
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		msgCenter: events.NewEvents(),
+		hub:       hub.Default(),
+	}
+}
+
+// Dispatch validates the payload and publishes the event; events violating
+// their schema are dropped and counted
+func (d *Dispatcher) Dispatch(ctx context.Context, eventType Type, labUUID uuid.UUID, data any) error {
+	event, err := NewEvent(eventType, labUUID, data)
+	if err != nil {
+		Reject(ctx, eventType, err)
+		return err
+	}
+	return d.Publish(ctx, event)
+}
+
+// Publish publishes an event built by NewEvent
+func (d *Dispatcher) Publish(ctx context.Context, event *Event) error {
+	if err := d.msgCenter.Broadcast(ctx, &notify.SendMsg{
+		Channel:   notify.StudioEvent,
+		LabUUID:   event.LabUUID,
+		UUID:      event.ID,
+		Data:      event,
+		Timestamp: event.OccurredAt.Unix(),
+	}); err != nil {
+		otel.GetMetrics().RecordEventDispatch(ctx, string(event.Type), resultFailed)
+		return err
+	}
+	otel.GetMetrics().RecordEventDispatch(ctx, string(event.Type), resultSent)
+	// 同时推送给订阅了实验室事件的 WebSocket 连接
+	d.hub.PublishLabEvent(ctx, event.LabUUID, string(event.Type), event)
+	return nil
+}
+
+// Reject logs and counts an event dropped for violating its schema
+func Reject(ctx context.Context, eventType Type, err error) {
+	logger.Errorf(ctx, "drop event %s violating its schema: %+v", eventType, err)
+	otel.GetMetrics().RecordEventDispatch(ctx, string(eventType), resultInvalid)
+}
@@ -0,0 +1,41 @@
+package eventschema
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// ExecutionCompletedData is the payload of execution.completed
+type ExecutionCompletedData struct {
+	TaskUUID     uuid.UUID                `json:"task_uuid"`
+	WorkflowUUID uuid.UUID                `json:"workflow_uuid"`
+	UserID       string                   `json:"user_id"`
+	Status       model.WorkflowTaskStatus `json:"status"`
+	Message      string                   `json:"message,omitempty"`
+	FinishedAt   time.Time                `json:"finished_at"`
+}
+
+// DeviceDisconnectedData is the payload of device.disconnected
+type DeviceDisconnectedData struct {
+	DeviceUUID      uuid.UUID `json:"device_uuid"`
+	DeviceName      string    `json:"device_name"`
+	AgentVersion    string    `json:"agent_version,omitempty"`
+	IP              string    `json:"ip,omitempty"`
+	LastSeenAt      time.Time `json:"last_seen_at"`
+	OfflineAfterSec int       `json:"offline_after_sec"`
+}
+
+// HistoryChangedData is the payload of history.changed
+type HistoryChangedData struct {
+	Table      string         `json:"table"`
+	Op         string         `json:"op"`
+	LSN        string         `json:"lsn"`
+	Seq        int            `json:"seq"`
+	XID        int64          `json:"xid,omitempty"`
+	CommitTime time.Time      `json:"commit_time"`
+	ID         int64          `json:"id"`
+	LabID      int64          `json:"lab_id,omitempty"`
+	Row        map[string]any `json:"row"`
+}
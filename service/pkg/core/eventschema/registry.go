@@ -0,0 +1,285 @@
+// Package eventschema is the registry of the JSON Schemas of the events Studio
+// emits on the event bus and to webhooks. Every event type has numbered schema
+// versions that only evolve compatibly, so a consumer built against an older
+// version keeps accepting newer payloads. Events are validated against the
+// latest schema before they are sent, and invalid events are dropped.
+package eventschema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
+)
+
+// Type is the type of an event.
+type Type string
+
+const (
+	ExecutionCompleted Type = "execution.completed"
+	DeviceDisconnected Type = "device.disconnected"
+	AlertFired         Type = "alert.fired"
+	AlertResolved      Type = "alert.resolved"
+	AlertEscalated     Type = "alert.escalated"
+	HistoryChanged     Type = "history.changed"
+)
+
+// Rules are the schema evolution rules, enforced by CheckCompatible on every
+// pair of consecutive versions of a type
+var Rules = []string{
+	"A published schema version never changes; changes are released as the next version.",
+	"A new version may add properties and may make existing properties required.",
+	"A new version never removes a property, widens its type or adds values to its enum.",
+	"A new version never allows properties its predecessor rejected.",
+	"Payloads valid for a version are therefore valid for every earlier version; incompatible changes need a new event type.",
+}
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Version is a published schema version of an event type.
+type Version struct {
+	Version int             `json:"version"`
+	Schema  json.RawMessage `json:"-"`
+
+	compiled *jsonschema.Schema
+}
+
+// Definition lists the schema versions of an event type, oldest first.
+type Definition struct {
+	Type        Type       `json:"type"`
+	Description string     `json:"description"`
+	Versions    []*Version `json:"-"`
+}
+
+// Latest returns the newest schema version
+func (d *Definition) Latest() *Version {
+	return d.Versions[len(d.Versions)-1]
+}
+
+// Get returns a schema version
+func (d *Definition) Get(version int) (*Version, bool) {
+	if version < 1 || version > len(d.Versions) {
+		return nil, false
+	}
+	return d.Versions[version-1], true
+}
+
+// versions 每个事件类型已发布的版本数，新版本在此登记并添加 schemas/<type>.v<n>.json
+var versions = map[Type]int{
+	ExecutionCompleted: 1,
+	DeviceDisconnected: 1,
+	AlertFired:         1,
+	AlertResolved:      1,
+	AlertEscalated:     1,
+	HistoryChanged:     1,
+}
+
+var (
+	registry = mustLoad()
+	envelope = mustRead("schemas/envelope.json")
+)
+
+func mustLoad() map[Type]*Definition {
+	defs, err := load()
+	if err != nil {
+		panic(err)
+	}
+	return defs
+}
+
+func mustRead(name string) json.RawMessage {
+	raw, err := files.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+func load() (map[Type]*Definition, error) {
+	defs := make(map[Type]*Definition, len(versions))
+	for eventType, count := range versions {
+		def := &Definition{Type: eventType}
+		for n := 1; n <= count; n++ {
+			raw, err := files.ReadFile(fmt.Sprintf("schemas/%s.v%d.json", eventType, n))
+			if err != nil {
+				return nil, fmt.Errorf("event %s v%d: %w", eventType, n, err)
+			}
+			compiled, err := jsonschema.Compile(raw)
+			if err != nil {
+				return nil, fmt.Errorf("event %s v%d: %w", eventType, n, err)
+			}
+			def.Versions = append(def.Versions, &Version{Version: n, Schema: raw, compiled: compiled})
+			def.Description = compiled.Description
+		}
+		defs[eventType] = def
+	}
+	return defs, nil
+}
+
+// Get returns the definition of an event type
+func Get(eventType Type) (*Definition, bool) {
+	def, ok := registry[eventType]
+	return def, ok
+}
+
+// List returns the definitions of all event types ordered by type
+func List() []*Definition {
+	defs := make([]*Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Type < defs[j].Type })
+	return defs
+}
+
+// Envelope returns the schema of the envelope wrapping events on the event bus
+func Envelope() json.RawMessage {
+	return envelope
+}
+
+// Summary describes the published versions of an event type
+type Summary struct {
+	Type        Type   `json:"type"`
+	Description string `json:"description"`
+	Latest      int    `json:"latest"`
+	Versions    []int  `json:"versions"`
+}
+
+// CatalogResp lists every event type with the envelope schema and the
+// evolution rules
+type CatalogResp struct {
+	Envelope json.RawMessage `json:"envelope"`
+	Rules    []string        `json:"rules"`
+	Events   []*Summary      `json:"events"`
+}
+
+// Catalog returns the registry index
+func Catalog() *CatalogResp {
+	defs := List()
+	events := make([]*Summary, 0, len(defs))
+	for _, def := range defs {
+		summary := &Summary{Type: def.Type, Description: def.Description, Latest: def.Latest().Version}
+		for _, v := range def.Versions {
+			summary.Versions = append(summary.Versions, v.Version)
+		}
+		events = append(events, summary)
+	}
+	return &CatalogResp{Envelope: envelope, Rules: Rules, Events: events}
+}
+
+// Schema returns a published schema version of an event type
+func Schema(eventType Type, version int) (json.RawMessage, error) {
+	def, ok := Get(eventType)
+	if !ok {
+		return nil, code.EventSchemaNotFoundErr.WithMsgf("unknown event type %s", eventType)
+	}
+	v, ok := def.Get(version)
+	if !ok {
+		return nil, code.EventSchemaNotFoundErr.WithMsgf("unknown version %d of event %s", version, eventType)
+	}
+	return v.Schema, nil
+}
+
+// Validate checks an event payload against a schema version of its type
+func Validate(eventType Type, version int, data any) error {
+	def, ok := Get(eventType)
+	if !ok {
+		return code.EventSchemaViolationErr.WithMsgf("unknown event type %s", eventType)
+	}
+	v, ok := def.Get(version)
+	if !ok {
+		return code.EventSchemaViolationErr.WithMsgf("unknown version %d of event %s", version, eventType)
+	}
+
+	// 按序列化后的内容校验，与接收方看到的一致
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return code.EventSchemaViolationErr.WithErr(err)
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return code.EventSchemaViolationErr.WithErr(err)
+	}
+	if _, errs := v.compiled.Validate(decoded); len(errs) > 0 {
+		msgs := make([]string, 0, len(errs))
+		for _, e := range errs {
+			msgs = append(msgs, e.Field+" "+e.Message)
+		}
+		return code.EventSchemaViolationErr.WithMsgf("event %s v%d: %s", eventType, version, strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// CheckCompatible returns the violations of the evolution rules by next as
+// the successor of prev
+func CheckCompatible(prev, next *jsonschema.Schema) []string {
+	violations := make([]string, 0)
+	checkCompatible("$", prev, next, &violations)
+	return violations
+}
+
+func checkCompatible(path string, prev, next *jsonschema.Schema, violations *[]string) {
+	add := func(format string, args ...any) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(prev.Type) > 0 {
+		for _, t := range next.Type {
+			if !slices.Contains(prev.Type, t) && !(t == "integer" && slices.Contains(prev.Type, "number")) {
+				add("type %s added", t)
+			}
+		}
+		if len(next.Type) == 0 {
+			add("type constraint removed")
+		}
+	}
+	if len(prev.Enum) > 0 {
+		if len(next.Enum) == 0 {
+			add("enum removed")
+		}
+		for _, value := range next.Enum {
+			if !slices.Contains(prev.Enum, value) {
+				add("enum value %v added", value)
+			}
+		}
+	}
+	if prev.Const != nil && fmt.Sprint(prev.Const) != fmt.Sprint(next.Const) {
+		add("const changed")
+	}
+	for _, name := range prev.Required {
+		if !slices.Contains(next.Required, name) {
+			add("property %s no longer required", name)
+		}
+	}
+	for name, prop := range prev.Properties {
+		nextProp, ok := next.Properties[name]
+		if !ok {
+			add("property %s removed", name)
+			continue
+		}
+		checkCompatible(path+"."+name, prop, nextProp, violations)
+	}
+	if prev.AdditionalProperties != nil && !*prev.AdditionalProperties {
+		if next.AdditionalProperties == nil || *next.AdditionalProperties {
+			add("additional properties allowed")
+		}
+		for name := range next.Properties {
+			if _, ok := prev.Properties[name]; !ok {
+				add("property %s added where additional properties are rejected", name)
+			}
+		}
+	}
+	if prev.Items != nil {
+		if next.Items == nil {
+			add("items schema removed")
+		} else {
+			checkCompatible(path+"[]", prev.Items, next.Items, violations)
+		}
+	}
+}
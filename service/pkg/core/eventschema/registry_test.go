@@ -0,0 +1,154 @@
+package eventschema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	defs := List()
+	require.Len(t, defs, len(versions))
+	for _, def := range defs {
+		assert.NotEmpty(t, def.Description, def.Type)
+		for i := 1; i < len(def.Versions); i++ {
+			assert.Empty(t, CheckCompatible(def.Versions[i-1].compiled, def.Versions[i].compiled), "%s v%d", def.Type, i+1)
+		}
+	}
+
+	_, err := Schema(ExecutionCompleted, 1)
+	assert.NoError(t, err)
+	_, err = Schema(ExecutionCompleted, 99)
+	assert.Error(t, err)
+	_, err = Schema("workflow.unknown", 1)
+	assert.Error(t, err)
+}
+
+// published 已发布版本的摘要，已发布的 schema 不能修改，只能发布新版本
+var published = map[string]string{
+	"alert.escalated.v1":     "091667371e570af4d7d5e826656d42fef9fdb7c54fcf40f21253b5d404884169",
+	"alert.fired.v1":         "ccf1d5498c1dd08c360c2d09a35b36512f0dbb445ddd733a73ae6916c683b422",
+	"alert.resolved.v1":      "be088a9c8b2117ec3d615638809edb3b14b741baa52e6fa2e2e2c1e92d4a6318",
+	"device.disconnected.v1": "315be070977b2105aa3b553bed81fe3324174e246743e7c29c7d6b4d61fc8908",
+	"execution.completed.v1": "eb918944b7afdfe5992da3fa6313b432e06ff6eb5f9b5a4e304d90673eceb21d",
+	"history.changed.v1":     "3138db2c1e9ea38346f65c8cc38fd058e3e4369e9e9589ca3646daa9092afb39",
+}
+
+func TestPublishedSchemasUnchanged(t *testing.T) {
+	for _, def := range List() {
+		for _, v := range def.Versions {
+			name := fmt.Sprintf("%s.v%d", def.Type, v.Version)
+			sum := sha256.Sum256(v.Schema)
+			digest, ok := published[name]
+			if !assert.True(t, ok, "%s is not recorded in published, add %q", name, hex.EncodeToString(sum[:])) {
+				continue
+			}
+			assert.Equal(t, digest, hex.EncodeToString(sum[:]), "%s changed after being published", name)
+		}
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	prev := mustCompile(t, `{"type":"object","required":["a"],"properties":{
+		"a":{"type":"string","enum":["x","y"]},
+		"b":{"type":"integer"},
+		"c":{"type":"object","additionalProperties":false,"properties":{"d":{"type":"string"}}}}}`)
+
+	// 新增属性、新增必填属性、收窄枚举都是兼容的
+	next := mustCompile(t, `{"type":"object","required":["a","e"],"properties":{
+		"a":{"type":"string","enum":["x"]},
+		"b":{"type":"integer"},
+		"c":{"type":"object","additionalProperties":false,"properties":{"d":{"type":"string"}}},
+		"e":{"type":"number"}}}`)
+	assert.Empty(t, CheckCompatible(prev, next))
+
+	next = mustCompile(t, `{"type":"object","properties":{
+		"a":{"type":"string","enum":["x","y","z"]},
+		"b":{"type":["integer","string"]},
+		"c":{"type":"object","properties":{"d":{"type":"string"},"f":{"type":"string"}}}}}`)
+	assert.ElementsMatch(t, []string{
+		"$: property a no longer required",
+		"$.a: enum value z added",
+		"$.b: type string added",
+		"$.c: additional properties allowed",
+		"$.c: property f added where additional properties are rejected",
+	}, CheckCompatible(prev, next))
+
+	next = mustCompile(t, `{"type":"object","required":["a"],"properties":{"a":{"type":"string","enum":["x"]}}}`)
+	assert.ElementsMatch(t, []string{"$: property b removed", "$: property c removed"}, CheckCompatible(prev, next))
+}
+
+func TestValidate(t *testing.T) {
+	payload := &ExecutionCompletedData{
+		TaskUUID:     uuid.NewV4(),
+		WorkflowUUID: uuid.NewV4(),
+		UserID:       "u1",
+		Status:       model.WorkflowTaskStatusSuccessed,
+		FinishedAt:   time.Now(),
+	}
+	assert.NoError(t, Validate(ExecutionCompleted, 1, payload))
+
+	payload.Status = model.WorkflowTaskStatusRunnig
+	assert.Error(t, Validate(ExecutionCompleted, 1, payload))
+
+	disconnected := &DeviceDisconnectedData{
+		DeviceUUID:      uuid.NewV4(),
+		DeviceName:      "pump",
+		LastSeenAt:      time.Now(),
+		OfflineAfterSec: 30,
+	}
+	assert.NoError(t, Validate(DeviceDisconnected, 1, disconnected))
+
+	// nil UUID 序列化为空字符串，不符合 schema
+	disconnected.DeviceUUID = uuid.UUID{}
+	assert.Error(t, Validate(DeviceDisconnected, 1, disconnected))
+
+	assert.Error(t, Validate("workflow.unknown", 1, payload))
+}
+
+func TestValidateAlert(t *testing.T) {
+	now := time.Now()
+	msg := map[string]any{
+		"event": "fired",
+		"rule":  &model.DeviceRule{BaseModel: model.BaseModel{UUID: uuid.NewV4()}, Name: "temp", Severity: model.AlertSeverityCritical},
+		"alert": &model.Alert{
+			BaseModel:  model.BaseModel{UUID: uuid.NewV4()},
+			RuleName:   "temp",
+			DeviceUUID: uuid.NewV4(),
+			Severity:   model.AlertSeverityCritical,
+			Status:     model.AlertFiring,
+			Value:      42,
+			Threshold:  40,
+			FiredAt:    now,
+		},
+	}
+	assert.NoError(t, Validate(AlertFired, 1, msg))
+	assert.Error(t, Validate(AlertResolved, 1, msg))
+
+	event, err := NewEvent(AlertFired, uuid.NewV4(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, 1, event.Version)
+	assert.Equal(t, "1", event.Headers()[HeaderEventVersion])
+	assert.Equal(t, "alert.fired", event.Headers()[HeaderEventType])
+
+	envelope, err := jsonschema.Compile(Envelope())
+	require.NoError(t, err)
+	for _, name := range []string{"id", "type", "version", "occurred_at", "lab_uuid", "data"} {
+		assert.Contains(t, envelope.Properties, name)
+	}
+}
+
+func mustCompile(t *testing.T, raw string) *jsonschema.Schema {
+	t.Helper()
+	schema, err := jsonschema.Compile([]byte(raw))
+	require.NoError(t, err)
+	return schema
+}
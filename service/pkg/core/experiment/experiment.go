@@ -0,0 +1,288 @@
+// Package experiment groups workflow executions into experiments. Executions
+// join an experiment when submitted with its UUID or are attached afterwards,
+// and the experiment reports progress and stats over its executions.
+package experiment
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/experiment"
+)
+
+// CreateReq 创建实验
+type CreateReq struct {
+	LabUUID     uuid.UUID `json:"lab_uuid" binding:"required"`
+	Name        string    `json:"name" binding:"required,max=255"`
+	Description string    `json:"description"`
+	PlannedRuns int       `json:"planned_runs" binding:"min=0"` // 计划执行次数，0 表示未设置
+}
+
+// UpdateReq 修改实验，为空的字段不修改
+type UpdateReq struct {
+	Name        *string                 `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string                 `json:"description"`
+	Status      *model.ExperimentStatus `json:"status"` // active | completed | archived
+	PlannedRuns *int                    `json:"planned_runs" binding:"omitempty,min=0"`
+}
+
+// ListReq 实验列表
+type ListReq struct {
+	LabUUID uuid.UUID              `form:"lab_uuid" binding:"required"`
+	Status  model.ExperimentStatus `form:"status"`
+	common.PageReq
+}
+
+// ExecutionsReq 关联或移除实验的执行
+type ExecutionsReq struct {
+	ExecutionUUIDs []uuid.UUID `json:"execution_uuids" binding:"required,min=1,max=500"`
+}
+
+// ExperimentResp 实验及其执行进度
+type ExperimentResp struct {
+	*model.Experiment
+	Progress *model.ExperimentProgress `json:"progress"`
+}
+
+// ExecutionsResp 实际变更的执行数量
+type ExecutionsResp struct {
+	Updated int64 `json:"updated"`
+}
+
+type Service struct {
+	store    experiment.ExperimentRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    experiment.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// Create 创建实验，实验室成员可以操作，同一实验室内名称不能重复
+func (s *Service) Create(ctx context.Context, req *CreateReq) (*ExperimentResp, error) {
+	labID, userID, err := s.checkMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkName(ctx, labID, req.Name, 0); err != nil {
+		return nil, err
+	}
+
+	data := &model.Experiment{
+		LabID:       labID,
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      model.ExperimentActive,
+		PlannedRuns: req.PlannedRuns,
+		CreatedBy:   userID,
+	}
+	if err := s.store.Create(ctx, data); err != nil {
+		return nil, err
+	}
+	return &ExperimentResp{
+		Experiment: data,
+		Progress:   model.NewExperimentProgress(nil, data.PlannedRuns),
+	}, nil
+}
+
+// List 获取实验室的实验列表及各实验的执行进度
+func (s *Service) List(ctx context.Context, req *ListReq) (*common.ListResp[*ExperimentResp], error) {
+	labID, _, err := s.checkMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != "" && !req.Status.Valid() {
+		return nil, code.ParamErr.WithMsgf("unknown experiment status %q", req.Status)
+	}
+	req.Normalize()
+
+	datas, total, err := s.store.List(ctx, labID, req.Status, &req.PageReq)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(datas))
+	for _, d := range datas {
+		ids = append(ids, d.ID)
+	}
+	counts, err := s.store.CountStatuses(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*ExperimentResp, 0, len(datas))
+	for _, d := range datas {
+		items = append(items, &ExperimentResp{
+			Experiment: d,
+			Progress:   model.NewExperimentProgress(counts[d.ID], d.PlannedRuns),
+		})
+	}
+	return common.NewListResp(items, total, req.Page, req.PageSize), nil
+}
+
+// Get 获取实验及其执行进度
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*ExperimentResp, error) {
+	data, err := s.getExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.store.CountStatuses(ctx, []int64{data.ID})
+	if err != nil {
+		return nil, err
+	}
+	return &ExperimentResp{
+		Experiment: data,
+		Progress:   model.NewExperimentProgress(counts[data.ID], data.PlannedRuns),
+	}, nil
+}
+
+// Update 修改实验名称、描述、状态或计划执行次数
+func (s *Service) Update(ctx context.Context, id uuid.UUID, req *UpdateReq) (*ExperimentResp, error) {
+	data, err := s.getExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+	if req.Name != nil && *req.Name != data.Name {
+		if err := s.checkName(ctx, data.LabID, *req.Name, data.ID); err != nil {
+			return nil, err
+		}
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Status != nil {
+		if !req.Status.Valid() {
+			return nil, code.ParamErr.WithMsgf("unknown experiment status %q", *req.Status)
+		}
+		updates["status"] = *req.Status
+	}
+	if req.PlannedRuns != nil {
+		updates["planned_runs"] = *req.PlannedRuns
+	}
+	if len(updates) > 0 {
+		if err := s.store.Update(ctx, data.ID, updates); err != nil {
+			return nil, err
+		}
+	}
+	return s.Get(ctx, id)
+}
+
+// AddExecutions 将实验室的执行关联到实验，已关联到其他实验的执行会被移过来
+func (s *Service) AddExecutions(ctx context.Context, id uuid.UUID, req *ExecutionsReq) (*ExecutionsResp, error) {
+	data, err := s.getExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if data.Status == model.ExperimentArchived {
+		return nil, code.ExperimentClosedErr
+	}
+
+	updated, err := s.store.AssignExecutions(ctx, data.LabID, data.ID, req.ExecutionUUIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &ExecutionsResp{Updated: updated}, nil
+}
+
+// RemoveExecutions 将执行移出实验
+func (s *Service) RemoveExecutions(ctx context.Context, id uuid.UUID, req *ExecutionsReq) (*ExecutionsResp, error) {
+	data, err := s.getExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.store.DetachExecutions(ctx, data.ID, req.ExecutionUUIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &ExecutionsResp{Updated: updated}, nil
+}
+
+// Stats 获取实验的执行进度、耗时及按工作流的统计
+func (s *Service) Stats(ctx context.Context, id uuid.UUID) (*model.ExperimentStats, error) {
+	data, err := s.getExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.store.CountStatuses(ctx, []int64{data.ID})
+	if err != nil {
+		return nil, err
+	}
+	workflows, err := s.store.WorkflowStats(ctx, data.ID)
+	if err != nil {
+		return nil, err
+	}
+	span, err := s.store.Span(ctx, data.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &model.ExperimentStats{
+		ExperimentProgress: model.NewExperimentProgress(counts[data.ID], data.PlannedRuns),
+		TotalDurationMs:    span.TotalDurationMs,
+		FirstStartedAt:     span.FirstStartedAt,
+		LastCompletedAt:    span.LastCompletedAt,
+		Workflows:          workflows,
+	}
+	if finished := stats.Success + stats.Failed + stats.Cancelled; finished > 0 {
+		stats.SuccessRate = float64(stats.Success) / float64(finished) * 100
+	}
+	return stats, nil
+}
+
+func (s *Service) getExperiment(ctx context.Context, id uuid.UUID) (*model.Experiment, error) {
+	data, err := s.store.GetByUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	labUUID := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, data.LabID)[data.LabID]
+	if _, _, err := s.checkMember(ctx, labUUID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *Service) checkName(ctx context.Context, labID int64, name string, exceptID int64) error {
+	exist, err := s.store.GetByName(ctx, labID, name)
+	if err != nil {
+		return err
+	}
+	if exist != nil && exist.ID != exceptID {
+		return code.ExperimentExistErr.WithMsgf("%s already exists", name)
+	}
+	return nil
+}
+
+func (s *Service) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, string, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, "", code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, "", code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, "", code.NoPermission
+	}
+	return labID, userInfo.ID, nil
+}
@@ -0,0 +1,543 @@
+// Package file receives instrument output files in resumable chunks, validates
+// their content, keeps them in object storage linked to action executions and
+// serves them through signed, expiring download URLs.
+package file
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/file"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	mb = 1 << 20
+
+	defaultMaxSize   = 4096 * mb
+	defaultChunkMax  = 64 * mb
+	defaultURLTTL    = 10 * time.Minute
+	defaultExpire    = 24 * time.Hour
+	maxFileNameLen   = 255
+	downloadPath     = "/api/v1/file/download"
+	sweepBatch       = 100
+	checksumHexBytes = sha256.Size * 2
+)
+
+// CreateReq 创建上传
+type CreateReq struct {
+	LabUUID             uuid.UUID  `json:"lab_uuid"` // 用户上传时必填，edge 上传时使用 edge 所属实验室
+	FileName            string     `json:"file_name" binding:"required"`
+	Size                int64      `json:"size" binding:"required,gt=0"`
+	Checksum            string     `json:"checksum"`              // 可选，sha256 hex，上传完成时校验
+	ActionExecutionUUID *uuid.UUID `json:"action_execution_uuid"` // 产生该文件的动作执行记录
+}
+
+// ListReq 查询文件
+type ListReq struct {
+	LabUUID             uuid.UUID  `form:"lab_uuid"`
+	ActionExecutionUUID *uuid.UUID `form:"action_execution_uuid"`
+}
+
+// URLResp 签名下载链接
+type URLResp struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// urlClaims 下载链接令牌内容
+type urlClaims struct {
+	FileUUID  uuid.UUID `json:"fid"`
+	ExpiresAt int64     `json:"exp"`
+}
+
+type Service struct {
+	store    file.FileRepo
+	storage  storage.Storage
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	secret   []byte
+}
+
+func New() *Service {
+	return &Service{
+		store:    file.New(),
+		storage:  storage.Default(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		secret:   utils.SignedURLSecret(),
+	}
+}
+
+// Create 创建上传，之后按 offset 顺序上传分片
+func (s *Service) Create(ctx context.Context, req *CreateReq) (*model.ResultFile, error) {
+	labID, uploaderID, err := s.labScope(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.FileName)
+	if name == "" || len(name) > maxFileNameLen || strings.ContainsAny(name, "/\\\x00") {
+		return nil, code.ParamErr.WithMsg("invalid file_name")
+	}
+	if maxSize := maxFileSize(); req.Size > maxSize {
+		return nil, code.FileTooLargeErr.WithMsgf("file size must not exceed %d bytes", maxSize)
+	}
+	checksum := strings.ToLower(req.Checksum)
+	if checksum != "" {
+		if _, err := hex.DecodeString(checksum); err != nil || len(checksum) != checksumHexBytes {
+			return nil, code.ParamErr.WithMsg("checksum must be sha256 hex")
+		}
+	}
+
+	data := &model.ResultFile{
+		LabID:      labID,
+		FileName:   name,
+		Size:       req.Size,
+		Checksum:   checksum,
+		UploaderID: uploaderID,
+	}
+	if req.ActionExecutionUUID != nil {
+		action := &model.ActionExecutionHistory{}
+		if err := s.baseDB.GetData(ctx, action, map[string]any{
+			"uuid":   *req.ActionExecutionUUID,
+			"lab_id": labID,
+		}, "id", "uuid"); err != nil {
+			return nil, code.RecordNotFound.WithMsg("action execution not found")
+		}
+		data.ActionExecutionID = &action.ID
+		data.ActionExecutionUUID = &action.UUID
+	}
+
+	data.StorageKey = fmt.Sprintf("files/%d/%s", labID, uuid.NewV4())
+	if err := s.store.CreateFile(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WriteChunk 在 offset 处写入一个分片，offset 必须等于已接收的字节数，
+// 最后一个分片写入后完成校验
+func (s *Service) WriteChunk(ctx context.Context, fileUUID uuid.UUID, offset, length int64, body io.Reader) (*model.ResultFile, error) {
+	data, err := s.get(ctx, fileUUID)
+	if err != nil {
+		return nil, err
+	}
+	if data.Status != model.ResultFileUploading {
+		return nil, code.FileUploadStatusErr
+	}
+	if data.Offset == data.Size {
+		// 上次合并或校验失败，分片已全部收到，直接重试完成
+		if err := s.complete(ctx, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	if offset != data.Offset {
+		return nil, code.FileUploadOffsetErr.WithMsgf("expected offset %d", data.Offset)
+	}
+	if length <= 0 {
+		return nil, code.ParamErr.WithMsg("chunk length required")
+	}
+	if chunkMax := maxChunkSize(); length > chunkMax {
+		return nil, code.FileTooLargeErr.WithMsgf("chunk size must not exceed %d bytes", chunkMax)
+	}
+	if offset+length > data.Size {
+		return nil, code.FileTooLargeErr.WithMsg("chunk exceeds declared file size")
+	}
+
+	reader := bufio.NewReaderSize(io.LimitReader(body, length), sniffLen)
+	if offset == 0 {
+		// 首个分片提前校验内容类型，避免上传完整文件后才被拒绝
+		head, _ := reader.Peek(sniffLen)
+		if reason := checkHead(head); reason != "" {
+			return nil, code.FileContentRejectedErr.WithMsg(reason)
+		}
+	}
+
+	key := chunkKey(data, data.Chunks)
+	counter := &countingReader{r: reader}
+	if err := s.storage.Put(ctx, key, counter, length); err != nil {
+		logger.Errorf(ctx, "put file chunk fail uuid: %s, err: %+v", data.UUID, err)
+		return nil, code.FileStorageErr.WithErr(err)
+	}
+	if counter.n != length {
+		s.deleteObject(ctx, key)
+		return nil, code.ParamErr.WithMsgf("chunk truncated, received %d of %d bytes", counter.n, length)
+	}
+	if err := s.store.AppendChunk(ctx, data, length); err != nil {
+		s.deleteObject(ctx, key)
+		return nil, err
+	}
+
+	if data.Offset == data.Size {
+		if err := s.complete(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Get 查询文件及上传进度
+func (s *Service) Get(ctx context.Context, fileUUID uuid.UUID) (*model.ResultFile, error) {
+	return s.get(ctx, fileUUID)
+}
+
+// List 查询实验室已上传完成的文件
+func (s *Service) List(ctx context.Context, req *ListReq) ([]*model.ResultFile, error) {
+	labID, _, err := s.labScope(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var actionID *int64
+	if req.ActionExecutionUUID != nil {
+		id := s.baseDB.UUID2ID(ctx, &model.ActionExecutionHistory{}, *req.ActionExecutionUUID)[*req.ActionExecutionUUID]
+		if id == 0 {
+			return []*model.ResultFile{}, nil
+		}
+		actionID = &id
+	}
+	return s.store.ListFiles(ctx, labID, actionID)
+}
+
+// Delete 删除文件或取消未完成的上传
+func (s *Service) Delete(ctx context.Context, fileUUID uuid.UUID) error {
+	data, err := s.get(ctx, fileUUID)
+	if err != nil {
+		return err
+	}
+	s.purge(ctx, data)
+	return s.store.DeleteFile(ctx, data)
+}
+
+// DownloadURL 生成带有效期的签名下载链接
+func (s *Service) DownloadURL(ctx context.Context, fileUUID uuid.UUID) (*URLResp, error) {
+	data, err := s.get(ctx, fileUUID)
+	if err != nil {
+		return nil, err
+	}
+	if data.Status != model.ResultFileAvailable {
+		return nil, code.FileUploadStatusErr
+	}
+
+	expiresAt := time.Now().Add(urlTTL())
+	token := utils.SignClaims(s.secret, &urlClaims{
+		FileUUID:  data.UUID,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	return &URLResp{
+		URL:       downloadPath + "?token=" + url.QueryEscape(token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Open 校验下载令牌并打开文件内容，调用方负责关闭
+func (s *Service) Open(ctx context.Context, token string) (*model.ResultFile, io.ReadCloser, error) {
+	claims := &urlClaims{}
+	if !utils.VerifyClaims(s.secret, token, claims) {
+		return nil, nil, code.FileURLInvalidErr
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, nil, code.FileURLInvalidErr.WithMsg("url expired")
+	}
+
+	data, err := s.store.GetFileByUUID(ctx, claims.FileUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data.Status != model.ResultFileAvailable {
+		return nil, nil, code.FileUploadStatusErr
+	}
+
+	r, err := s.storage.Open(ctx, data.StorageKey)
+	if err != nil {
+		logger.Errorf(ctx, "open file fail uuid: %s, err: %+v", data.UUID, err)
+		return nil, nil, code.FileStorageErr.WithErr(err)
+	}
+	return data, r, nil
+}
+
+// Sweep 清理超时未完成的上传
+func (s *Service) Sweep(ctx context.Context) error {
+	stale, err := s.store.ListStaleUploads(ctx, time.Now().Add(-uploadExpire()), sweepBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, data := range stale {
+		s.purge(ctx, data)
+		if err := s.store.DeleteFile(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// complete 合并分片并校验校验和、内容类型与病毒扫描结果
+func (s *Service) complete(ctx context.Context, data *model.ResultFile) error {
+	hash := sha256.New()
+	head := &headWriter{}
+	merged := io.TeeReader(&chunkReader{ctx: ctx, storage: s.storage, file: data}, io.MultiWriter(hash, head))
+	if err := s.storage.Put(ctx, data.StorageKey, merged, data.Size); err != nil {
+		logger.Errorf(ctx, "merge file chunks fail uuid: %s, err: %+v", data.UUID, err)
+		return code.FileStorageErr.WithErr(err)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	reason := checkHead(head.buf)
+	if reason == "" && data.Checksum != "" && data.Checksum != checksum {
+		reason = "checksum mismatch"
+	}
+	if reason == "" {
+		virus, err := s.scan(ctx, data)
+		if err != nil {
+			logger.Errorf(ctx, "scan file fail uuid: %s, err: %+v", data.UUID, err)
+			return code.FileStorageErr.WithErr(err)
+		}
+		if virus != "" {
+			reason = "virus found: " + virus
+		}
+	}
+
+	if reason != "" {
+		s.deleteObject(ctx, data.StorageKey)
+		if err := s.store.MarkRejected(ctx, data, reason); err != nil {
+			return err
+		}
+		s.deleteChunks(ctx, data)
+		return code.FileContentRejectedErr.WithMsg(reason)
+	}
+	if err := s.store.MarkAvailable(ctx, data, sniffContentType(head.buf), checksum); err != nil {
+		return err
+	}
+	s.deleteChunks(ctx, data)
+	return nil
+}
+
+func (s *Service) scan(ctx context.Context, data *model.ResultFile) (string, error) {
+	addr := config.Global().Upload.ClamdAddr
+	if addr == "" {
+		return "", nil
+	}
+
+	r, err := s.storage.Open(ctx, data.StorageKey)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return scanClamd(ctx, addr, r)
+}
+
+// get 查询文件并校验访问权限，edge 只能访问本实验室的文件
+func (s *Service) get(ctx context.Context, fileUUID uuid.UUID) (*model.ResultFile, error) {
+	data, err := s.store.GetFileByUUID(ctx, fileUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if labUser := auth.GetLabUser(ctx); labUser != nil {
+		if labUser.LabID != data.LabID {
+			return nil, code.NoPermission
+		}
+		return data, nil
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if err := s.checkMemberByID(ctx, userInfo.ID, data.LabID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// labScope 返回请求所属实验室与上传者，edge 请求使用 edge 所属实验室
+func (s *Service) labScope(ctx context.Context, labUUID uuid.UUID) (int64, string, error) {
+	if labUser := auth.GetLabUser(ctx); labUser != nil {
+		return labUser.LabID, "", nil
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, "", code.UnLogin
+	}
+	if labUUID.IsNil() {
+		return 0, "", code.ParamErr.WithMsg("lab_uuid required")
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, "", code.LabNotFound
+	}
+	if err := s.checkMemberByID(ctx, userInfo.ID, labID); err != nil {
+		return 0, "", err
+	}
+	return labID, userInfo.ID, nil
+}
+
+func (s *Service) checkMemberByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+// purge 删除文件在存储中的全部内容
+func (s *Service) purge(ctx context.Context, data *model.ResultFile) {
+	if data.Status == model.ResultFileUploading {
+		s.deleteChunks(ctx, data)
+		return
+	}
+	s.deleteObject(ctx, data.StorageKey)
+}
+
+func (s *Service) deleteChunks(ctx context.Context, data *model.ResultFile) {
+	for i := 0; i < data.Chunks; i++ {
+		s.deleteObject(ctx, chunkKey(data, i))
+	}
+}
+
+func (s *Service) deleteObject(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		logger.Warnf(ctx, "delete storage object fail key: %s, err: %+v", key, err)
+	}
+}
+
+func chunkKey(data *model.ResultFile, index int) string {
+	return fmt.Sprintf("%s.chunks/%06d", data.StorageKey, index)
+}
+
+// checkHead 校验文件头，返回拒绝原因
+func checkHead(head []byte) string {
+	if isExecutable(head) {
+		return "executable content not allowed"
+	}
+	contentType := sniffContentType(head)
+	if !typeAllowed(parseAllowedTypes(config.Global().Upload.AllowedTypes), contentType) {
+		return "content type not allowed: " + contentType
+	}
+	return ""
+}
+
+// chunkReader 按顺序读取全部分片
+type chunkReader struct {
+	ctx     context.Context
+	storage storage.Storage
+	file    *model.ResultFile
+	index   int
+	current io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if c.index >= c.file.Chunks {
+				return 0, io.EOF
+			}
+			r, err := c.storage.Open(c.ctx, chunkKey(c.file, c.index))
+			if err != nil {
+				return 0, err
+			}
+			c.current = r
+			c.index++
+		}
+
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// headWriter 保留写入内容的前 sniffLen 字节
+type headWriter struct {
+	buf []byte
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	if remain := sniffLen - len(h.buf); remain > 0 {
+		h.buf = append(h.buf, p[:min(remain, len(p))]...)
+	}
+	return len(p), nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func maxFileSize() int64 {
+	if size := config.Global().Upload.MaxSizeMB; size > 0 {
+		return int64(size) * mb
+	}
+	return defaultMaxSize
+}
+
+func maxChunkSize() int64 {
+	if size := config.Global().Upload.ChunkMaxMB; size > 0 {
+		return int64(size) * mb
+	}
+	return defaultChunkMax
+}
+
+func urlTTL() time.Duration {
+	if sec := config.Global().Upload.URLTTLSec; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultURLTTL
+}
+
+func uploadExpire() time.Duration {
+	if hours := config.Global().Upload.ExpireHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultExpire
+}
+
+// RegisterJob 注册未完成上传清理后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "file_upload_cleanup",
+		Description:  "清理超时未完成的文件上传及其分片",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     time.Hour.String(),
+		Timeout:      10 * time.Minute,
+		Run:          s.Sweep,
+	})
+}
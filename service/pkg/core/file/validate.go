@@ -0,0 +1,66 @@
+package file
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLen http.DetectContentType 使用的最大字节数
+const sniffLen = 512
+
+// executableMagic 可执行文件头，仪器输出中不应出现
+var executableMagic = [][]byte{
+	[]byte("MZ"),               // Windows PE
+	[]byte("\x7fELF"),          // ELF
+	[]byte("\xcf\xfa\xed\xfe"), // Mach-O 64
+	[]byte("\xce\xfa\xed\xfe"), // Mach-O 32
+	[]byte("\xca\xfe\xba\xbe"), // Mach-O fat / Java class
+}
+
+// sniffContentType 根据文件头判断内容类型，去掉 charset 等参数
+func sniffContentType(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// isExecutable 判断文件头是否为可执行文件
+func isExecutable(head []byte) bool {
+	for _, magic := range executableMagic {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeAllowed 判断内容类型是否在允许列表中，列表为空时允许所有类型，支持 text/* 形式
+func typeAllowed(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, pattern := range allowed {
+		if pattern == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAllowedTypes 解析逗号分隔的内容类型配置
+func parseAllowedTypes(conf string) []string {
+	var types []string
+	for _, t := range strings.Split(conf, ",") {
+		if t = strings.TrimSpace(strings.ToLower(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
@@ -0,0 +1,24 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckContent(t *testing.T) {
+	assert.True(t, isExecutable([]byte("MZ\x90\x00")))
+	assert.True(t, isExecutable([]byte("\x7fELF\x02\x01")))
+	assert.False(t, isExecutable([]byte("time,value\n1,2\n")))
+
+	assert.Equal(t, "text/plain", sniffContentType([]byte("time,value\n1,2\n")))
+	assert.Equal(t, "image/png", sniffContentType([]byte("\x89PNG\r\n\x1a\n")))
+
+	allowed := parseAllowedTypes(" text/*, image/png ,")
+	assert.Equal(t, []string{"text/*", "image/png"}, allowed)
+	assert.True(t, typeAllowed(allowed, "text/plain"))
+	assert.True(t, typeAllowed(allowed, "image/png"))
+	assert.False(t, typeAllowed(allowed, "image/jpeg"))
+	assert.False(t, typeAllowed(allowed, "textual/plain"))
+	assert.True(t, typeAllowed(nil, "application/zip"))
+}
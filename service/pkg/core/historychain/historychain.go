@@ -0,0 +1,439 @@
+// Package historychain seals finished workflow executions and audit log
+// entries into an append-only hash chain per lab. A sealing job appends new
+// records, an anchoring job publishes each chain head outside the database,
+// and verification walks a chain to detect changed or removed records.
+package historychain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/core/notification"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/historychain"
+)
+
+const (
+	defaultSealInterval   = time.Minute
+	defaultAnchorInterval = time.Hour
+	defaultVerifyInterval = 24 * time.Hour
+
+	// 记录结束后等待一段时间再封存，避免结束时的并发更新被当作篡改
+	sealDelay   = time.Minute
+	sealBatch   = 500
+	verifyBatch = 500
+
+	publishTimeout = 30 * time.Second
+	maxReceiptLen  = 1024
+)
+
+// VerifyReq 校验实验室的哈希链
+type VerifyReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// AnchorListReq 锚点列表
+type AnchorListReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+	common.PageReq
+}
+
+// anchorPayload 发布到外部的锚点
+type anchorPayload struct {
+	LabUUID    uuid.UUID `json:"lab_uuid"`
+	Seq        int64     `json:"seq"`
+	Hash       string    `json:"hash"`
+	AnchoredAt time.Time `json:"anchored_at"`
+}
+
+type Service struct {
+	store    historychain.HistoryChainRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	inbox    *notification.Sender
+	client   *resty.Client
+}
+
+func New() *Service {
+	return &Service{
+		store:    historychain.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		inbox:    notification.NewSender(),
+		client:   otel.RestyClientWithTracing().SetTimeout(publishTimeout),
+	}
+}
+
+// Verify 校验实验室的哈希链
+func (s *Service) Verify(ctx context.Context, req *VerifyReq) (*model.ChainVerifyResult, error) {
+	labID, err := s.checkMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.verify(ctx, labID)
+}
+
+// ListAnchors 获取实验室已发布的链头锚点
+func (s *Service) ListAnchors(ctx context.Context, req *AnchorListReq) (*common.ListResp[*model.HistoryChainAnchor], error) {
+	labID, err := s.checkMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	req.Normalize()
+
+	datas, total, err := s.store.ListAnchors(ctx, labID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// Seal 将结束的执行和新的审计日志追加到各实验室的哈希链
+func (s *Service) Seal(ctx context.Context) error {
+	before := time.Now().Add(-sealDelay)
+	execs, err := s.store.ListUnsealedExecutions(ctx, before, sealBatch)
+	if err != nil {
+		return err
+	}
+	audits, err := s.store.ListUnsealedAuditLogs(ctx, before, sealBatch)
+	if err != nil {
+		return err
+	}
+
+	labIDs := make([]int64, 0)
+	byLab := make(map[int64][]*model.ChainRecord)
+	add := func(rec *model.ChainRecord) {
+		if _, ok := byLab[rec.LabID]; !ok {
+			labIDs = append(labIDs, rec.LabID)
+		}
+		byLab[rec.LabID] = append(byLab[rec.LabID], rec)
+	}
+	for _, e := range execs {
+		rec, err := e.ChainRecord()
+		if err != nil {
+			return err
+		}
+		add(rec)
+	}
+	for _, a := range audits {
+		rec, err := a.ChainRecord()
+		if err != nil {
+			return err
+		}
+		add(rec)
+	}
+
+	total := 0
+	for _, labID := range labIDs {
+		added, err := s.store.Append(ctx, labID, byLab[labID])
+		if err != nil {
+			return err
+		}
+		total += added
+	}
+	if total > 0 {
+		logger.Infof(ctx, "history chain sealed records: %d", total)
+	}
+	return nil
+}
+
+// Anchor 为有新条目的哈希链创建锚点并发布到外部，发布失败的锚点下次重新发布
+func (s *Service) Anchor(ctx context.Context) error {
+	heads, err := s.store.ListHeads(ctx)
+	if err != nil {
+		return err
+	}
+	anchored, err := s.store.LatestAnchorSeqs(ctx)
+	if err != nil {
+		return err
+	}
+
+	conf := config.GetStudioConfig().HistoryChain
+	labIDs := make([]int64, 0, len(heads))
+	for _, head := range heads {
+		labIDs = append(labIDs, head.LabID)
+	}
+	labUUIDs := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, labIDs...)
+
+	for _, head := range heads {
+		if head.Seq <= anchored[head.LabID] {
+			continue
+		}
+		anchor := &model.HistoryChainAnchor{
+			LabID:  head.LabID,
+			Seq:    head.Seq,
+			Hash:   head.Hash,
+			Target: conf.AnchorURL,
+		}
+		if err := s.store.CreateAnchor(ctx, anchor); err != nil {
+			return err
+		}
+		if conf.AnchorURL == "" {
+			continue
+		}
+
+		now := time.Now()
+		updates := map[string]any{"updated_at": now}
+		receipt, err := s.publish(ctx, conf.AnchorURL, conf.AnchorToken, &anchorPayload{
+			LabUUID:    labUUIDs[head.LabID],
+			Seq:        anchor.Seq,
+			Hash:       anchor.Hash,
+			AnchoredAt: anchor.CreatedAt,
+		})
+		if err != nil {
+			logger.Warnf(ctx, "publish history chain anchor lab=%d seq=%d fail: %+v", head.LabID, head.Seq, err)
+			updates["error"] = err.Error()
+		} else {
+			updates["published_at"] = now
+			updates["receipt"] = receipt
+		}
+		if err := s.store.UpdateAnchor(ctx, anchor.ID, updates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyAll 校验所有实验室的哈希链，发现篡改时通知实验室创建者
+func (s *Service) VerifyAll(ctx context.Context) error {
+	heads, err := s.store.ListHeads(ctx)
+	if err != nil {
+		return err
+	}
+	for _, head := range heads {
+		result, err := s.verify(ctx, head.LabID)
+		if err != nil {
+			return err
+		}
+		if result.Valid {
+			continue
+		}
+
+		logger.Errorf(ctx, "history chain of lab %d failed verification: %d issues, first %s at seq %d",
+			head.LabID, len(result.Issues), result.Issues[0].Problem, result.Issues[0].Seq)
+		if head.LabID == 0 {
+			continue
+		}
+		labs := make([]*model.Laboratory, 0, 1)
+		if err := s.baseDB.FindDatas(ctx, &labs, map[string]any{
+			"id": head.LabID,
+		}, "id", "uuid", "user_id"); err != nil || len(labs) == 0 {
+			continue
+		}
+		s.inbox.Send(ctx, &notification.Message{
+			Kind:    model.NotificationAlert,
+			UserIDs: []string{labs[0].UserID},
+			LabID:   labs[0].ID,
+			LabUUID: labs[0].UUID,
+			Title:   "history records failed integrity verification",
+			Body: fmt.Sprintf("%d issues found, first %s at seq %d",
+				len(result.Issues), result.Issues[0].Problem, result.Issues[0].Seq),
+		})
+	}
+	return nil
+}
+
+// verify walks the chain of a lab, recomputing the content hash of every
+// record that is still present and comparing the anchors with the chain
+func (s *Service) verify(ctx context.Context, labID int64) (*model.ChainVerifyResult, error) {
+	result := &model.ChainVerifyResult{
+		Valid:      true,
+		Issues:     []*model.ChainIssue{},
+		VerifiedAt: time.Now(),
+	}
+	anchors, err := s.store.AllAnchors(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	anchorsAt := make(map[int64][]*model.HistoryChainAnchor, len(anchors))
+	for _, a := range anchors {
+		anchorsAt[a.Seq] = append(anchorsAt[a.Seq], a)
+	}
+
+	var prev *model.HistoryChainEntry
+	for {
+		afterSeq := int64(0)
+		if prev != nil {
+			afterSeq = prev.Seq
+		}
+		entries, err := s.store.ListEntries(ctx, labID, afterSeq, verifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			break
+		}
+		contents, err := s.contents(ctx, entries)
+		if err != nil {
+			return nil, err
+		}
+		model.VerifyChainSegment(result, prev, entries, contents)
+
+		for _, e := range entries {
+			for _, a := range anchorsAt[e.Seq] {
+				if a.Hash != e.Hash {
+					result.AddIssue(&model.ChainIssue{Seq: e.Seq, RecordType: e.RecordType, RecordUUID: e.RecordUUID, Problem: model.ChainAnchorMismatch})
+				}
+			}
+			delete(anchorsAt, e.Seq)
+		}
+		prev = entries[len(entries)-1]
+		if len(entries) < verifyBatch {
+			break
+		}
+	}
+
+	// 锚点指向的条目已不存在
+	for seq := range anchorsAt {
+		result.AddIssue(&model.ChainIssue{Seq: seq, Problem: model.ChainAnchorMismatch})
+	}
+	result.Anchors = int64(len(anchors))
+	return result, nil
+}
+
+// contents recomputes the content hash of the records of entries by entry ID
+func (s *Service) contents(ctx context.Context, entries []*model.HistoryChainEntry) (map[int64]string, error) {
+	execIDs := make([]int64, 0, len(entries))
+	auditIDs := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		if e.PrunedAt != nil {
+			continue
+		}
+		switch e.RecordType {
+		case model.ChainWorkflowExecution:
+			execIDs = append(execIDs, e.RecordID)
+		case model.ChainAuditLog:
+			auditIDs = append(auditIDs, e.RecordID)
+		}
+	}
+
+	hashes := make(map[model.ChainRecordType]map[int64]string, 2)
+	hashes[model.ChainWorkflowExecution] = make(map[int64]string, len(execIDs))
+	hashes[model.ChainAuditLog] = make(map[int64]string, len(auditIDs))
+	execs, err := s.store.ListExecutions(ctx, execIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range execs {
+		rec, err := e.ChainRecord()
+		if err != nil {
+			return nil, err
+		}
+		hashes[model.ChainWorkflowExecution][e.ID] = rec.ContentHash
+	}
+	audits, err := s.store.ListAuditLogs(ctx, auditIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range audits {
+		rec, err := a.ChainRecord()
+		if err != nil {
+			return nil, err
+		}
+		hashes[model.ChainAuditLog][a.ID] = rec.ContentHash
+	}
+
+	contents := make(map[int64]string, len(entries))
+	for _, e := range entries {
+		if hash, ok := hashes[e.RecordType][e.RecordID]; ok {
+			contents[e.ID] = hash
+		}
+	}
+	return contents, nil
+}
+
+// publish posts the anchor to the external target and returns its response
+func (s *Service) publish(ctx context.Context, target, token string, payload *anchorPayload) (string, error) {
+	req := s.client.R().SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(payload)
+	if token != "" {
+		req.SetAuthToken(token)
+	}
+	resp, err := req.Post(target)
+	if err != nil {
+		return "", err
+	}
+	if resp.IsError() {
+		return "", fmt.Errorf("anchor target responded with status %d", resp.StatusCode())
+	}
+	receipt := resp.String()
+	if len(receipt) > maxReceiptLen {
+		receipt = receipt[:maxReceiptLen]
+	}
+	return receipt, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+// RegisterJobs 注册哈希链封存、锚点发布和全量校验任务
+func RegisterJobs() error {
+	s := New()
+	conf := config.GetStudioConfig().HistoryChain
+	interval := func(n int, unit, def time.Duration) string {
+		if n > 0 {
+			return (time.Duration(n) * unit).String()
+		}
+		return def.String()
+	}
+
+	if err := jobs.Register(&jobs.Definition{
+		Name:         "history_chain_seal",
+		Description:  "将结束的工作流执行和新的审计日志追加到实验室哈希链",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     interval(conf.SealIntervalSeconds, time.Second, defaultSealInterval),
+		Timeout:      5 * time.Minute,
+		Run:          s.Seal,
+	}); err != nil {
+		return err
+	}
+	if err := jobs.Register(&jobs.Definition{
+		Name:         "history_chain_anchor",
+		Description:  "发布各实验室哈希链的链头锚点",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     interval(conf.AnchorIntervalMinutes, time.Minute, defaultAnchorInterval),
+		Timeout:      10 * time.Minute,
+		Run:          s.Anchor,
+	}); err != nil {
+		return err
+	}
+	return jobs.Register(&jobs.Definition{
+		Name:         "history_chain_verify",
+		Description:  "校验所有实验室的哈希链，发现篡改时通知实验室创建者",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     interval(conf.VerifyIntervalHours, time.Hour, defaultVerifyInterval),
+		Timeout:      time.Hour,
+		Run:          s.VerifyAll,
+	})
+}
@@ -0,0 +1,48 @@
+package historycompact
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const defaultCompressBatch = 500
+
+// CompressBackfill 压缩已有记录中超过阈值的负载，未配置阈值时不处理
+func (s *Service) CompressBackfill(ctx context.Context) error {
+	threshold := model.CompressThreshold()
+	if threshold <= 0 {
+		return nil
+	}
+	limit := config.GetStudioConfig().ZstdJSON.BackfillBatchSize
+	if limit <= 0 {
+		limit = defaultCompressBatch
+	}
+
+	tables := []struct {
+		name     string
+		compress func(ctx context.Context, afterID int64, threshold, limit int) (int64, int, error)
+	}{
+		{(&model.WorkflowExecutionHistory{}).TableName(), s.store.CompressWorkflowExecutions},
+		{(&model.ActionExecutionHistory{}).TableName(), s.store.CompressActionExecutions},
+	}
+	for _, table := range tables {
+		var afterID int64
+		total := 0
+		for {
+			lastID, count, err := table.compress(ctx, afterID, threshold, limit)
+			if err != nil {
+				return err
+			}
+			total += count
+			if count < limit || ctx.Err() != nil {
+				break
+			}
+			afterID = lastID
+		}
+		logger.Infof(ctx, "history json compression table: %s, rows: %d", table.name, total)
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,78 @@
+package historycompact
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func loadThreshold(t *testing.T, threshold string) {
+	t.Helper()
+	dir := t.TempDir()
+	yaml := "json_compression:\n  threshold_bytes: " + threshold + "\n  backfill_batch_size: 2\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "studio.yaml"), []byte(yaml), 0o644))
+	_, err := config.LoadStudioConfig(dir, "")
+	require.NoError(t, err)
+}
+
+// rawColumn 直接读取数据库中的内容，不经过模型的序列化器
+func rawColumn(t *testing.T, table, column string, id int64) string {
+	t.Helper()
+	var ret string
+	require.NoError(t, db.DB().DBIns().Table(table).Select("COALESCE("+column+", '')").Where("id = ?", id).Scan(&ret).Error)
+	return ret
+}
+
+func TestCompressBackfill(t *testing.T) {
+	s := newService(t)
+	ctx := context.Background()
+	large := `{"points":[` + strings.Repeat(`{"x":1.5,"y":2.5},`, 200) + `{"x":1,"y":2}]}`
+
+	// 开启压缩前写入的记录
+	loadThreshold(t, "0")
+	exec := testutil.NewExecution(1, model.ExecutionStatusSuccess, time.Now())
+	exec.Input = datatypes.JSON(`{"plate":"A1"}`)
+	exec.Result = datatypes.JSON(large)
+	require.NoError(t, db.DB().DBIns().Create(exec).Error)
+	actions := make([]*model.ActionExecutionHistory, 0, 5)
+	for range 5 {
+		actions = append(actions, seedAction(t, 1, model.ExecutionStatusSuccess, time.Hour, large, `{"ok":true}`))
+	}
+	small := seedAction(t, 1, model.ExecutionStatusSuccess, time.Hour, `{"plate":"A1"}`, `{"ok":true}`)
+	assert.Equal(t, large, rawColumn(t, exec.TableName(), "result", exec.ID))
+
+	loadThreshold(t, "1024")
+	require.NoError(t, s.CompressBackfill(ctx))
+
+	assert.True(t, model.IsCompressedJSON([]byte(rawColumn(t, exec.TableName(), "result", exec.ID))))
+	assert.Equal(t, `{"plate":"A1"}`, rawColumn(t, exec.TableName(), "input", exec.ID))
+	for _, a := range actions {
+		assert.True(t, model.IsCompressedJSON([]byte(rawColumn(t, a.TableName(), "input", a.ID))))
+		assert.Equal(t, `{"ok":true}`, rawColumn(t, a.TableName(), "output", a.ID))
+	}
+	assert.Equal(t, `{"plate":"A1"}`, rawColumn(t, small.TableName(), "input", small.ID))
+
+	// 读取时透明解压
+	data := reload(t, actions[0])
+	assert.JSONEq(t, large, string(data.Input))
+	assert.Equal(t, actions[0].CreatedAt.Unix(), data.CreatedAt.Unix())
+	execData := &model.WorkflowExecutionHistory{}
+	require.NoError(t, db.DB().DBIns().First(execData, exec.ID).Error)
+	assert.JSONEq(t, large, string(execData.Result))
+
+	// 新写入的记录直接压缩
+	created := seedAction(t, 1, model.ExecutionStatusSuccess, time.Hour, "", large)
+	assert.True(t, model.IsCompressedJSON([]byte(rawColumn(t, created.TableName(), "output", created.ID))))
+	assert.JSONEq(t, large, string(reload(t, created).Output))
+}
@@ -0,0 +1,254 @@
+// Package historycompact rolls the input and output of old action executions
+// into summaries. The full payloads are archived to a result file of the lab
+// first, and Restore reads them back for the APIs returning action details.
+// It also compresses the large payloads written before compression was on.
+package historycompact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/historycompact"
+	"github.com/scienceol/studio/service/pkg/repo/payload"
+	"gorm.io/datatypes"
+)
+
+const (
+	day = 24 * time.Hour
+
+	defaultMinBytes      = 4096
+	defaultBatchSize     = 200
+	defaultSummaryFields = 16
+	// 摘要中字符串字段的最大长度，更长的只保留字段名
+	maxSummaryString = 128
+	maxSummaryKeys   = 256
+)
+
+type Service struct {
+	store     historycompact.HistoryCompactRepo
+	artifacts *payload.Artifacts
+	conf      func() config.CompactionConfig
+}
+
+func New() *Service {
+	return &Service{
+		store:     historycompact.New(),
+		artifacts: payload.NewArtifacts(),
+		conf: func() config.CompactionConfig {
+			return config.GetStudioConfig().Compaction
+		},
+	}
+}
+
+// NewWithStore 使用指定的仓库、存储和配置，用于测试
+func NewWithStore(store historycompact.HistoryCompactRepo, artifacts *payload.Artifacts, conf config.CompactionConfig) *Service {
+	return &Service{
+		store:     store,
+		artifacts: artifacts,
+		conf:      func() config.CompactionConfig { return conf },
+	}
+}
+
+func (s *Service) limits() config.CompactionConfig {
+	conf := s.conf()
+	if conf.MinBytes <= 0 {
+		conf.MinBytes = defaultMinBytes
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = defaultBatchSize
+	}
+	if conf.SummaryFields <= 0 {
+		conf.SummaryFields = defaultSummaryFields
+	}
+	return conf
+}
+
+// Compact 压缩所有超过保留天数的动作，未配置天数时不处理
+func (s *Service) Compact(ctx context.Context) error {
+	conf := s.limits()
+	if conf.AfterDays <= 0 {
+		return nil
+	}
+	before := time.Now().Add(-time.Duration(conf.AfterDays) * day)
+
+	var afterID int64
+	var count, reclaimed int
+	for {
+		actions, err := s.store.ListCompactable(ctx, before, conf.MinBytes, afterID, conf.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, a := range actions {
+			afterID = a.ID
+			saved, err := s.compact(ctx, a, conf.SummaryFields)
+			if err != nil {
+				return err
+			}
+			if saved > 0 {
+				count++
+				reclaimed += saved
+			}
+		}
+		if len(actions) < conf.BatchSize || ctx.Err() != nil {
+			break
+		}
+	}
+	logger.Infof(ctx, "history compaction actions: %d, reclaimed bytes: %d", count, reclaimed)
+	return ctx.Err()
+}
+
+// compact archives the payloads of an action and replaces them with
+// summaries, returning the bytes removed from the record
+func (s *Service) compact(ctx context.Context, a *model.ActionExecutionHistory, fields int) (int, error) {
+	archive, err := json.Marshal(&model.CompactedArchive{
+		Input:  json.RawMessage(a.Input),
+		Output: json.RawMessage(a.Output),
+	})
+	if err != nil {
+		return 0, err
+	}
+	sum := sha256.Sum256(archive)
+	key := fmt.Sprintf("compaction/%d/%s/%s.json", a.LabID, a.CreatedAt.Format("200601"), a.UUID)
+	fileUUID, err := s.artifacts.Save(ctx, a.LabID, fmt.Sprintf("action_%s.json", a.UUID), key, archive, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return 0, err
+	}
+
+	input, err := summarize(a.Input, fileUUID, fields)
+	if err != nil {
+		return 0, err
+	}
+	output, err := summarize(a.Output, fileUUID, fields)
+	if err != nil {
+		return 0, err
+	}
+	ok, err := s.store.Compact(ctx, a.ID, input, output)
+	if err != nil || !ok {
+		// 已被并发压缩时保留先写入的归档
+		return 0, err
+	}
+	return len(a.Input) + len(a.Output) - len(input) - len(output), nil
+}
+
+// Restore 将压缩过的动作替换为归档中的完整输入和输出
+func (s *Service) Restore(ctx context.Context, actions ...*model.ActionExecutionHistory) error {
+	for _, a := range actions {
+		if a.CompactedAt == nil {
+			continue
+		}
+		fileUUID, ok := archiveOf(a.Input, a.Output)
+		if !ok {
+			continue
+		}
+		data, err := s.artifacts.Load(ctx, fileUUID)
+		if err != nil {
+			logger.Errorf(ctx, "Restore compacted action fail uuid=%s: %+v", a.UUID, err)
+			return err
+		}
+		archive := &model.CompactedArchive{}
+		if err := json.Unmarshal(data, archive); err != nil {
+			return err
+		}
+		a.Input = datatypes.JSON(archive.Input)
+		a.Output = datatypes.JSON(archive.Output)
+	}
+	return nil
+}
+
+// summarize 生成负载的摘要，空负载保持不变
+func summarize(data datatypes.JSON, fileUUID uuid.UUID, fields int) (datatypes.JSON, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return data, nil
+	}
+	sum := sha256.Sum256(data)
+	summary := &model.PayloadSummary{
+		Compacted: true,
+		Size:      len(data),
+		SHA256:    hex.EncodeToString(sum[:]),
+		FileUUID:  fileUUID,
+	}
+
+	var object map[string]any
+	if json.Unmarshal(data, &object) == nil {
+		summary.Keys = make([]string, 0, len(object))
+		for k := range object {
+			summary.Keys = append(summary.Keys, k)
+		}
+		sort.Strings(summary.Keys)
+		for _, k := range summary.Keys {
+			if len(summary.Fields) >= fields {
+				break
+			}
+			switch v := object[k].(type) {
+			case bool, float64:
+			case string:
+				if len(v) > maxSummaryString {
+					continue
+				}
+			default:
+				continue
+			}
+			if summary.Fields == nil {
+				summary.Fields = make(map[string]any)
+			}
+			summary.Fields[k] = object[k]
+		}
+		if len(summary.Keys) > maxSummaryKeys {
+			summary.Keys = summary.Keys[:maxSummaryKeys]
+		}
+	}
+
+	ret, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// archiveOf 从摘要中读取归档文件
+func archiveOf(payloads ...datatypes.JSON) (uuid.UUID, bool) {
+	for _, data := range payloads {
+		summary := &model.PayloadSummary{}
+		if len(data) > 0 && json.Unmarshal(data, summary) == nil && summary.Compacted {
+			return summary.FileUUID, true
+		}
+	}
+	return uuid.UUID{}, false
+}
+
+// RegisterJobs 注册动作负载压缩和已有记录的负载压缩任务
+func RegisterJobs() error {
+	s := New()
+	if err := jobs.Register(&jobs.Definition{
+		Name:         "history_compaction",
+		Description:  "归档超过保留天数的动作输入输出，并在记录中替换为摘要",
+		ScheduleType: model.JobScheduleCron,
+		Schedule:     "15 4 * * *",
+		Timeout:      2 * time.Hour,
+		MaxRetries:   2,
+		RetryBackoff: 10 * time.Minute,
+		Run:          s.Compact,
+	}); err != nil {
+		return err
+	}
+	return jobs.Register(&jobs.Definition{
+		Name:         "history_json_compression",
+		Description:  "压缩开启压缩前写入的超过阈值的工作流和动作负载",
+		ScheduleType: model.JobScheduleCron,
+		Schedule:     "45 4 * * *",
+		Timeout:      2 * time.Hour,
+		MaxRetries:   2,
+		RetryBackoff: 10 * time.Minute,
+		Run:          s.CompressBackfill,
+	})
+}
@@ -0,0 +1,134 @@
+package historycompact
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/scienceol/studio/service/pkg/repo/file"
+	"github.com/scienceol/studio/service/pkg/repo/historycompact"
+	"github.com/scienceol/studio/service/pkg/repo/payload"
+	"github.com/scienceol/studio/service/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func newService(t *testing.T) *Service {
+	t.Helper()
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+	artifacts := payload.NewArtifactsWithStorage(file.New(), storage.NewLocal(t.TempDir()))
+	return NewWithStore(historycompact.New(), artifacts, config.CompactionConfig{
+		AfterDays:     30,
+		MinBytes:      256,
+		BatchSize:     2,
+		SummaryFields: 2,
+	})
+}
+
+func seedAction(t *testing.T, labID int64, status model.ExecutionStatus, age time.Duration, input, output string) *model.ActionExecutionHistory {
+	t.Helper()
+	action := testutil.NewAction(&model.WorkflowExecutionHistory{LabID: labID}, 10, status, time.Now().Add(-age))
+	if input != "" {
+		action.Input = datatypes.JSON(input)
+	}
+	if output != "" {
+		action.Output = datatypes.JSON(output)
+	}
+	require.NoError(t, db.DB().DBIns().Create(action).Error)
+	// BeforeCreate 总是写入当前时间
+	action.CreatedAt = time.Now().Add(-age)
+	require.NoError(t, db.DB().DBIns().Model(action).UpdateColumn("created_at", action.CreatedAt).Error)
+	return action
+}
+
+func reload(t *testing.T, action *model.ActionExecutionHistory) *model.ActionExecutionHistory {
+	t.Helper()
+	data := &model.ActionExecutionHistory{}
+	require.NoError(t, db.DB().DBIns().First(data, action.ID).Error)
+	return data
+}
+
+func TestCompact(t *testing.T) {
+	s := newService(t)
+	ctx := context.Background()
+	old := 60 * 24 * time.Hour
+	spectrum := `{"volume":2.5,"unit":"ml","ok":true,"points":[` + strings.Repeat("1,", 200) + `1]}`
+
+	compacted := make([]*model.ActionExecutionHistory, 0, 3)
+	for range 3 {
+		compacted = append(compacted, seedAction(t, 1, model.ExecutionStatusSuccess, old, `{"plate":"A1"}`, spectrum))
+	}
+	outputOnly := seedAction(t, 1, model.ExecutionStatusFailed, old, "", spectrum)
+	small := seedAction(t, 1, model.ExecutionStatusSuccess, old, `{"plate":"A1"}`, `{"ok":true}`)
+	recent := seedAction(t, 1, model.ExecutionStatusSuccess, time.Hour, "", spectrum)
+	running := seedAction(t, 1, model.ExecutionStatusRunning, old, "", spectrum)
+	held := seedAction(t, 2, model.ExecutionStatusSuccess, old, "", spectrum)
+	require.NoError(t, db.DB().DBIns().Create(&model.LegalHold{
+		Scope: model.LegalHoldLab, LabID: 2, Reason: "audit", PlacedBy: "u1",
+	}).Error)
+
+	require.NoError(t, s.Compact(ctx))
+
+	for _, a := range append(compacted, outputOnly) {
+		data := reload(t, a)
+		require.NotNil(t, data.CompactedAt)
+
+		summary := &model.PayloadSummary{}
+		require.NoError(t, json.Unmarshal(data.Output, summary))
+		assert.True(t, summary.Compacted)
+		assert.Equal(t, len(spectrum), summary.Size)
+		assert.Equal(t, []string{"ok", "points", "unit", "volume"}, summary.Keys)
+		// 只保留前两个简单字段，数组不进入摘要
+		assert.Equal(t, map[string]any{"ok": true, "unit": "ml"}, summary.Fields)
+
+		require.NoError(t, s.Restore(ctx, data))
+		assert.JSONEq(t, spectrum, string(data.Output))
+		if len(a.Input) > 0 {
+			assert.JSONEq(t, string(a.Input), string(data.Input))
+		} else {
+			assert.Empty(t, data.Input)
+		}
+	}
+
+	for _, a := range []*model.ActionExecutionHistory{small, recent, running, held} {
+		data := reload(t, a)
+		assert.Nil(t, data.CompactedAt, a.ID)
+		assert.JSONEq(t, string(a.Output), string(data.Output))
+	}
+
+	// 已压缩的动作不再重复处理
+	first := reload(t, compacted[0])
+	require.NoError(t, s.Compact(ctx))
+	assert.Equal(t, string(first.Output), string(reload(t, compacted[0]).Output))
+	var count int64
+	require.NoError(t, db.DB().DBIns().Model(&model.ResultFile{}).Count(&count).Error)
+	assert.EqualValues(t, 4, count)
+}
+
+func TestCompactDisabled(t *testing.T) {
+	s := newService(t)
+	s.conf = func() config.CompactionConfig { return config.CompactionConfig{} }
+	a := seedAction(t, 1, model.ExecutionStatusSuccess, 365*24*time.Hour, "", `{"points":[`+strings.Repeat("1,", 5000)+`1]}`)
+
+	require.NoError(t, s.Compact(context.Background()))
+	assert.Nil(t, reload(t, a).CompactedAt)
+}
+
+func TestRestoreSkipsUncompacted(t *testing.T) {
+	s := newService(t)
+	// 未压缩的动作即使内容形似摘要也不读取归档
+	a := &model.ActionExecutionHistory{Output: datatypes.JSON(`{"_compacted":true,"file_uuid":"00000000-0000-0000-0000-000000000001"}`)}
+	require.NoError(t, s.Restore(context.Background(), a))
+	assert.Contains(t, string(a.Output), "_compacted")
+}
@@ -0,0 +1,342 @@
+// Package historycopy copies history records of a lab from a staging
+// environment into a local development environment. The source serves pages
+// with personal data scrubbed, and the target stores them under UUIDs derived
+// from the target lab so that copying again updates the same records.
+package historycopy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/constant"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/historyimport"
+)
+
+const (
+	defaultDumpLimit = 500
+	maxDumpLimit     = 2000
+	fetchTimeout     = 60 * time.Second
+	dumpPath         = "/v1/admin/history/dump"
+)
+
+// DumpReq 读取一页待复制的历史记录
+type DumpReq struct {
+	LabUUID   uuid.UUID               `form:"lab_uuid" binding:"required"`
+	Kind      model.HistoryExportKind `form:"kind" binding:"required"` // workflow、action 或 device_event
+	StartTime *time.Time              `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   *time.Time              `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	AfterID   int64                   `form:"after_id"` // 上一页返回的 next_after_id
+	Limit     int                     `form:"limit"`    // 默认 500，最大 2000
+}
+
+// DumpResp 脱敏后的历史记录，按 id 升序，只有与 kind 对应的列表有值
+type DumpResp struct {
+	Executions  []*model.WorkflowExecutionHistory `json:"executions,omitempty"`
+	Actions     []*model.ActionExecutionHistory   `json:"actions,omitempty"`
+	Events      []*model.DeviceEventHistory       `json:"events,omitempty"`
+	NextAfterID int64                             `json:"next_after_id"`
+	HasMore     bool                              `json:"has_more"`
+}
+
+// CopyOptions configures copying history from a source environment
+type CopyOptions struct {
+	Source       string    // API base URL of the source environment, e.g. https://staging.example.com/api
+	Token        string    // admin token of the source environment
+	SourceLab    uuid.UUID // lab to copy in the source environment
+	TargetLab    uuid.UUID // local lab the records are copied into
+	StartTime    *time.Time
+	EndTime      *time.Time
+	BatchSize    int
+	OnProgressFn func(kind model.HistoryExportKind, copied int)
+}
+
+// CopyResult counts the records copied of every kind
+type CopyResult struct {
+	Executions int
+	Actions    int
+	Events     int
+}
+
+type Service struct {
+	history history.HistoryRepo
+	store   historyimport.HistoryImportRepo
+	baseDB  repo.IDOrUUIDTranslate
+	client  *resty.Client
+}
+
+func New() *Service {
+	return &Service{
+		history: history.New(),
+		store:   historyimport.New(),
+		baseDB:  repo.NewBaseDB(),
+		client:  otel.RestyClientWithTracing().SetTimeout(fetchTimeout),
+	}
+}
+
+// Dump 读取实验室一页历史记录并脱敏，供其他环境复制，生产环境不提供
+func (s *Service) Dump(ctx context.Context, req *DumpReq) (*DumpResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return nil, code.NoPermission
+	}
+	if config.Global().Server.Env == constant.EnvProd {
+		return nil, code.HistoryCopyForbiddenErr
+	}
+	if !req.Kind.Valid() {
+		return nil, code.HistoryExportKindErr.WithMsgf("unknown kind %q", req.Kind)
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultDumpLimit
+	}
+	req.Limit = min(req.Limit, maxDumpLimit)
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, req.LabUUID)[req.LabUUID]
+	if labID == 0 {
+		return nil, code.LabNotFound
+	}
+	params := &model.HistoryQueryParams{
+		LabID:     labID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+	}
+
+	// 多取一条判断是否还有下一页
+	data := &DumpResp{NextAfterID: req.AfterID}
+	limit := req.Limit + 1
+	switch req.Kind {
+	case model.HistoryExportWorkflow:
+		rows, err := s.history.ScanWorkflowExecutions(ctx, params, req.AfterID, math.MaxInt64, limit)
+		if err != nil {
+			return nil, err
+		}
+		data.Executions, data.HasMore = page(rows, req.Limit)
+		for _, row := range data.Executions {
+			scrubExecution(row)
+			data.NextAfterID = row.ID
+		}
+	case model.HistoryExportAction:
+		rows, err := s.history.ScanActionExecutions(ctx, params, req.AfterID, math.MaxInt64, limit)
+		if err != nil {
+			return nil, err
+		}
+		data.Actions, data.HasMore = page(rows, req.Limit)
+		for _, row := range data.Actions {
+			scrubAction(row)
+			data.NextAfterID = row.ID
+		}
+	case model.HistoryExportDeviceEvent:
+		rows, err := s.history.ScanDeviceEvents(ctx, params, req.AfterID, math.MaxInt64, limit)
+		if err != nil {
+			return nil, err
+		}
+		data.Events, data.HasMore = page(rows, req.Limit)
+		for _, row := range data.Events {
+			scrubEvent(row)
+			data.NextAfterID = row.ID
+		}
+	}
+	return data, nil
+}
+
+// Copy pulls the history of the source lab page by page and stores it in the
+// target lab. Workflows and devices are linked by UUID when the target lab has
+// them, batches, experiments and definition snapshots are not copied. Only a
+// development environment accepts copies
+func (s *Service) Copy(ctx context.Context, opts *CopyOptions) (*CopyResult, error) {
+	if config.Global().Server.Env != constant.EnvDev {
+		return nil, code.HistoryCopyForbiddenErr.WithMsgf("target environment is %q, only %q is allowed",
+			config.Global().Server.Env, constant.EnvDev)
+	}
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, opts.TargetLab)[opts.TargetLab]
+	if labID == 0 {
+		return nil, code.LabNotFound
+	}
+
+	c := &copier{s: s, opts: opts, labID: labID, execIDs: make(map[int64]int64)}
+	result := &CopyResult{}
+	var err error
+	// 先复制工作流执行，动作记录按源 id 关联到复制后的执行
+	if result.Executions, err = c.run(ctx, model.HistoryExportWorkflow); err != nil {
+		return result, err
+	}
+	if result.Actions, err = c.run(ctx, model.HistoryExportAction); err != nil {
+		return result, err
+	}
+	if result.Events, err = c.run(ctx, model.HistoryExportDeviceEvent); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+type copier struct {
+	s       *Service
+	opts    *CopyOptions
+	labID   int64
+	execIDs map[int64]int64 // 源环境执行 id -> 本地执行 id
+}
+
+func (c *copier) run(ctx context.Context, kind model.HistoryExportKind) (int, error) {
+	copied := 0
+	afterID := int64(0)
+	for {
+		data, err := c.fetch(ctx, kind, afterID)
+		if err != nil {
+			return copied, err
+		}
+		n, err := c.save(ctx, data)
+		if err != nil {
+			return copied, err
+		}
+		copied += n
+		if c.opts.OnProgressFn != nil {
+			c.opts.OnProgressFn(kind, copied)
+		}
+		if !data.HasMore || data.NextAfterID <= afterID {
+			return copied, nil
+		}
+		afterID = data.NextAfterID
+	}
+}
+
+func (c *copier) fetch(ctx context.Context, kind model.HistoryExportKind, afterID int64) (*DumpResp, error) {
+	query := map[string]string{
+		"lab_uuid": c.opts.SourceLab.String(),
+		"kind":     string(kind),
+		"after_id": strconv.FormatInt(afterID, 10),
+		"limit":    strconv.Itoa(c.opts.BatchSize),
+	}
+	if c.opts.StartTime != nil {
+		query["start_time"] = c.opts.StartTime.Format(time.RFC3339)
+	}
+	if c.opts.EndTime != nil {
+		query["end_time"] = c.opts.EndTime.Format(time.RFC3339)
+	}
+
+	ret := &common.RespT[*DumpResp]{}
+	resp, err := c.s.client.R().SetContext(ctx).
+		SetAuthToken(c.opts.Token).
+		SetQueryParams(query).
+		SetResult(ret).
+		SetError(ret).
+		Get(strings.TrimRight(c.opts.Source, "/") + dumpPath)
+	if err != nil {
+		return nil, code.HistoryCopySourceErr.WithErr(err)
+	}
+	if ret.Code != code.Success || ret.Data == nil {
+		msg := resp.Status()
+		if ret.Error != nil {
+			msg = fmt.Sprintf("%s: %s", msg, ret.Error.Msg)
+		}
+		return nil, code.HistoryCopySourceErr.WithMsgf("%s page after %d: %s", kind, afterID, msg)
+	}
+	return ret.Data, nil
+}
+
+// save remaps a page into the target lab and upserts it
+func (c *copier) save(ctx context.Context, data *DumpResp) (int, error) {
+	switch {
+	case len(data.Executions) > 0:
+		workflowUUIDs := make([]uuid.UUID, 0, len(data.Executions))
+		for _, row := range data.Executions {
+			workflowUUIDs = append(workflowUUIDs, row.WorkflowUUID)
+		}
+		workflowIDs, err := c.s.store.WorkflowIDs(ctx, c.labID, workflowUUIDs)
+		if err != nil {
+			return 0, err
+		}
+		sourceIDs := make([]int64, 0, len(data.Executions))
+		for _, row := range data.Executions {
+			sourceIDs = append(sourceIDs, row.ID)
+			c.remap(&row.BaseModel)
+			row.LabID = c.labID
+			row.WorkflowID = workflowIDs[row.WorkflowUUID]
+			row.BatchID, row.ExperimentID, row.DefinitionHash = nil, nil, ""
+		}
+		if err := c.s.store.SaveRecords(ctx, data.Executions); err != nil {
+			return 0, err
+		}
+		for i, row := range data.Executions {
+			c.execIDs[sourceIDs[i]] = row.ID
+		}
+		return len(data.Executions), nil
+	case len(data.Actions) > 0:
+		deviceUUIDs := make([]uuid.UUID, 0, len(data.Actions))
+		for _, row := range data.Actions {
+			deviceUUIDs = append(deviceUUIDs, row.DeviceUUID)
+		}
+		deviceIDs, err := c.s.store.DeviceIDs(ctx, c.labID, deviceUUIDs)
+		if err != nil {
+			return 0, err
+		}
+		for _, row := range data.Actions {
+			c.remap(&row.BaseModel)
+			row.LabID = c.labID
+			row.DeviceID = deviceIDs[row.DeviceUUID]
+			if row.WorkflowExecutionID != nil {
+				if id := c.execIDs[*row.WorkflowExecutionID]; id > 0 {
+					row.WorkflowExecutionID = &id
+				} else {
+					row.WorkflowExecutionID = nil
+				}
+			}
+		}
+		if err := c.s.store.SaveRecords(ctx, data.Actions); err != nil {
+			return 0, err
+		}
+		return len(data.Actions), nil
+	case len(data.Events) > 0:
+		deviceUUIDs := make([]uuid.UUID, 0, len(data.Events))
+		for _, row := range data.Events {
+			deviceUUIDs = append(deviceUUIDs, row.DeviceUUID)
+		}
+		deviceIDs, err := c.s.store.DeviceIDs(ctx, c.labID, deviceUUIDs)
+		if err != nil {
+			return 0, err
+		}
+		for _, row := range data.Events {
+			c.remap(&row.BaseModel)
+			row.LabID = c.labID
+			row.DeviceID = deviceIDs[row.DeviceUUID]
+		}
+		if err := c.s.store.SaveRecords(ctx, data.Events); err != nil {
+			return 0, err
+		}
+		return len(data.Events), nil
+	}
+	return 0, nil
+}
+
+// remap derives the local UUID from the target lab so that copying the same
+// record again replaces it instead of adding a duplicate
+func (c *copier) remap(base *model.BaseModel) {
+	base.ID = 0
+	base.UUID = copyUUID(c.opts.TargetLab, base.UUID)
+}
+
+func copyUUID(targetLab, source uuid.UUID) uuid.UUID {
+	return uuid.NewV5(targetLab, "history-copy:"+source.String())
+}
+
+func page[T any](rows []T, limit int) ([]T, bool) {
+	if len(rows) > limit {
+		return rows[:limit], true
+	}
+	return rows, false
+}
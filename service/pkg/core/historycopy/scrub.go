@@ -0,0 +1,128 @@
+package historycopy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/datatypes"
+)
+
+const redacted = "[redacted]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	ipPattern    = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	// 国际区号开头的号码和国内手机号，不匹配日期等普通数字
+	phonePattern = regexp.MustCompile(`\+\d{1,3}[ -]?\d{6,14}\b|\b1[3-9]\d{9}\b`)
+
+	// 键名包含这些片段的 JSON 字段整体脱敏
+	sensitiveKeys = []string{
+		"password", "passwd", "secret", "token", "api_key", "apikey", "credential",
+		"email", "phone", "mobile", "username", "user_name",
+	}
+)
+
+// scrubExecution removes personal data from an execution in place
+func scrubExecution(data *model.WorkflowExecutionHistory) {
+	data.UserID = pseudonym(data.UserID)
+	data.ErrorMessage = scrubTextPtr(data.ErrorMessage)
+	data.Input = scrubJSON(data.Input)
+	data.Result = scrubJSON(data.Result)
+	data.Metadata = scrubJSON(data.Metadata)
+	labels := make(map[string]string, len(data.Labels.Data()))
+	for k, v := range data.Labels.Data() {
+		labels[k] = scrubText(v)
+	}
+	data.Labels = datatypes.NewJSONType(labels)
+}
+
+// scrubAction removes personal data from an action in place
+func scrubAction(data *model.ActionExecutionHistory) {
+	data.ErrorMessage = scrubTextPtr(data.ErrorMessage)
+	data.Input = scrubJSON(data.Input)
+	data.Output = scrubJSON(data.Output)
+	data.Metadata = scrubJSON(data.Metadata)
+}
+
+// scrubEvent removes personal data from a device event in place
+func scrubEvent(data *model.DeviceEventHistory) {
+	data.EventData = scrubJSON(data.EventData)
+}
+
+// pseudonym replaces a user id with a stable alias, so records of the same
+// user still group together after the copy
+func pseudonym(userID string) string {
+	if userID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(userID))
+	return "user-" + hex.EncodeToString(sum[:6])
+}
+
+// scrubText masks emails, IP addresses and phone numbers in free text
+func scrubText(s string) string {
+	s = emailPattern.ReplaceAllString(s, "[email]")
+	s = ipPattern.ReplaceAllString(s, "[ip]")
+	return phonePattern.ReplaceAllString(s, "[phone]")
+}
+
+func scrubTextPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	scrubbed := scrubText(*s)
+	return &scrubbed
+}
+
+// scrubJSON redacts sensitive fields and masks free text of a JSON column,
+// a value that is not valid JSON is dropped
+func scrubJSON(data datatypes.JSON) datatypes.JSON {
+	if len(data) == 0 {
+		return data
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	out, err := json.Marshal(scrubValue(v))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func scrubValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if sensitiveKey(k) {
+				val[k] = redacted
+				continue
+			}
+			val[k] = scrubValue(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = scrubValue(item)
+		}
+		return val
+	case string:
+		return scrubText(val)
+	}
+	return v
+}
+
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package historycopy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/datatypes"
+)
+
+func TestScrubText(t *testing.T) {
+	cases := map[string]string{
+		"notify alice@example.com failed":       "notify [email] failed",
+		"connect 192.168.1.20:8080 refused":     "connect [ip]:8080 refused",
+		"call 13812345678 or +86 2087654321":    "call [phone] or [phone]",
+		"started 2024-10-17 12:00:00, 3 errors": "started 2024-10-17 12:00:00, 3 errors",
+	}
+	for in, want := range cases {
+		if got := scrubText(in); got != want {
+			t.Errorf("scrubText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestScrubJSON(t *testing.T) {
+	in := datatypes.JSON(`{"volume":5,"operator":{"Email":"bob@example.com","name":"bob"},"api_token":"abc","notes":["ping 10.0.0.1"]}`)
+	var got map[string]any
+	if err := json.Unmarshal(scrubJSON(in), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got["volume"] != float64(5) {
+		t.Errorf("volume = %v, want 5", got["volume"])
+	}
+	if got["api_token"] != redacted {
+		t.Errorf("api_token = %v, want redacted", got["api_token"])
+	}
+	operator := got["operator"].(map[string]any)
+	if operator["Email"] != redacted || operator["name"] != "bob" {
+		t.Errorf("operator = %v", operator)
+	}
+	if notes := got["notes"].([]any); notes[0] != "ping [ip]" {
+		t.Errorf("notes = %v", notes)
+	}
+
+	if out := scrubJSON(datatypes.JSON(`{broken`)); out != nil {
+		t.Errorf("invalid json = %s, want dropped", out)
+	}
+}
+
+func TestScrubExecution(t *testing.T) {
+	msg := "sent to alice@example.com"
+	data := &model.WorkflowExecutionHistory{
+		UserID:       "u-1",
+		ErrorMessage: &msg,
+		Labels:       datatypes.NewJSONType(map[string]string{"owner": "alice@example.com"}),
+	}
+	scrubExecution(data)
+	if !strings.HasPrefix(data.UserID, "user-") || data.UserID != pseudonym("u-1") {
+		t.Errorf("user id = %q", data.UserID)
+	}
+	if *data.ErrorMessage != "sent to [email]" || msg != "sent to alice@example.com" {
+		t.Errorf("error message = %q", *data.ErrorMessage)
+	}
+	if data.Labels.Data()["owner"] != "[email]" {
+		t.Errorf("labels = %v", data.Labels.Data())
+	}
+	if pseudonym("") != "" {
+		t.Error("empty user id should stay empty")
+	}
+}
+
+func TestCopyUUID(t *testing.T) {
+	lab, other := uuid.NewV4(), uuid.NewV4()
+	source := uuid.NewV4()
+	if copyUUID(lab, source) != copyUUID(lab, source) {
+		t.Error("copy uuid should be stable")
+	}
+	if copyUUID(lab, source) == source || copyUUID(lab, source) == copyUUID(other, source) {
+		t.Error("copy uuid should depend on the target lab")
+	}
+}
@@ -0,0 +1,337 @@
+// Package historyexport exports history lists too large for paginated reads:
+// a request creates an export job, the background worker streams the matching
+// rows into NDJSON parts in object storage and checkpoints after every part, so
+// an export interrupted by a crash or restart resumes where it stopped. The
+// finished export is downloaded through a signed URL as a single file.
+package historyexport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/historyexport"
+	"github.com/scienceol/studio/service/pkg/utils"
+	"gorm.io/datatypes"
+)
+
+const (
+	downloadPath    = "/api/v1/history/export/download"
+	defaultExpire   = 72 * time.Hour
+	defaultURLTTL   = 15 * time.Minute
+	defaultPartRows = 5000
+	maxKeyLength    = 128
+	workEvery       = 30 * time.Second
+	workTimeout     = 5 * time.Minute
+	// workBudget 单次任务处理导出的时长，留出时间在超时前保存检查点
+	workBudget = 4 * time.Minute
+	// staleAfter 运行中的导出超过该时间没有新的检查点视为已中断，由下次任务接着导出
+	staleAfter  = 2 * time.Minute
+	sweepEvery  = 10 * time.Minute
+	expireBatch = 100
+)
+
+// CreateReq 创建历史记录导出
+type CreateReq struct {
+	LabID   int64                      `json:"lab_id" binding:"required"`
+	Kind    model.HistoryExportKind    `json:"kind" binding:"required"` // workflow | action | device_event
+	Filters model.HistoryExportFilters `json:"filters"`
+	// 幂等键，来自 Idempotency-Key 请求头，为空时按导出类型和过滤条件生成
+	IdempotencyKey string `json:"-"`
+}
+
+// ListReq 查询历史记录导出
+type ListReq struct {
+	LabID int64 `form:"lab_id" binding:"required"`
+}
+
+// URLResp 签名下载链接
+type URLResp struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExportResp 导出进度，完成后附带签名下载链接
+type ExportResp struct {
+	*model.HistoryExport
+	Download *URLResp `json:"download,omitempty"`
+}
+
+// urlClaims 下载链接签名内容
+type urlClaims struct {
+	Purpose    string    `json:"p"`
+	ExportUUID uuid.UUID `json:"e"`
+	ExpiresAt  int64     `json:"x"`
+}
+
+const urlPurpose = "history_export"
+
+type Service struct {
+	store    historyexport.HistoryExportRepo
+	history  history.HistoryRepo
+	envStore repo.LaboratoryRepo
+	storage  storage.Storage
+	secret   []byte
+}
+
+func New() *Service {
+	return &Service{
+		store:    historyexport.New(),
+		history:  history.New(),
+		envStore: environment.New(),
+		storage:  storage.Default(),
+		secret:   utils.SignedURLSecret(),
+	}
+}
+
+// Create 创建历史记录导出，由后台任务执行。相同幂等键的请求返回已有的导出：
+// 失败的导出从检查点继续，过期的导出重新开始
+func (s *Service) Create(ctx context.Context, req *CreateReq) (*ExportResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if err := s.checkMember(ctx, userInfo.ID, req.LabID); err != nil {
+		return nil, err
+	}
+	if !req.Kind.Valid() {
+		return nil, code.HistoryExportKindErr.WithMsg("kind must be workflow, action or device_event")
+	}
+	if _, err := req.Filters.Params(req.LabID); err != nil {
+		return nil, code.HistoryExportKindErr.WithErr(err)
+	}
+	key := strings.TrimSpace(req.IdempotencyKey)
+	if len(key) > maxKeyLength {
+		return nil, code.ParamErr.WithMsg("idempotency key too long")
+	}
+	if key == "" {
+		key = requestKey(req.Kind, &req.Filters)
+	}
+
+	data := &model.HistoryExport{
+		BaseModel:      model.BaseModel{UUID: uuid.New()},
+		LabID:          req.LabID,
+		RequestedBy:    userInfo.ID,
+		IdempotencyKey: key,
+		Kind:           req.Kind,
+		Filters:        datatypes.NewJSONType(req.Filters),
+		Status:         model.HistoryExportPending,
+	}
+	created, err := s.store.CreateExport(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		if data, err = s.store.GetExportByKey(ctx, req.LabID, userInfo.ID, key); err != nil {
+			return nil, err
+		}
+		if err := s.retry(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+	return s.response(data), nil
+}
+
+// retry 重新排队失败或过期的导出
+func (s *Service) retry(ctx context.Context, data *model.HistoryExport) error {
+	switch data.Status {
+	case model.HistoryExportFailed:
+		// 已写入的分片保留，从检查点继续
+		data.Status = model.HistoryExportPending
+		data.Error = ""
+		return s.store.UpdateExport(ctx, data, "status", "error")
+	case model.HistoryExportExpired:
+		data.Status = model.HistoryExportPending
+		data.Progress, data.Rows, data.TotalRows, data.Parts, data.Size = 0, 0, 0, 0, 0
+		data.MaxID, data.CursorID, data.Attempts = 0, 0, 0
+		data.Error, data.CompletedAt, data.ExpiresAt = "", nil, nil
+		return s.store.UpdateExport(ctx, data, "status", "progress", "rows", "total_rows", "parts", "size",
+			"max_id", "cursor_id", "attempts", "error", "completed_at", "expires_at")
+	default:
+		return nil
+	}
+}
+
+// List 查询实验室的历史记录导出
+func (s *Service) List(ctx context.Context, req *ListReq) ([]*model.HistoryExport, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if err := s.checkMember(ctx, userInfo.ID, req.LabID); err != nil {
+		return nil, err
+	}
+	return s.store.ListExports(ctx, req.LabID)
+}
+
+// Get 查询导出进度，导出完成后返回签名下载链接
+func (s *Service) Get(ctx context.Context, exportUUID uuid.UUID) (*ExportResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetExportByUUID(ctx, exportUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMember(ctx, userInfo.ID, data.LabID); err != nil {
+		return nil, err
+	}
+	return s.response(data), nil
+}
+
+// Open 校验下载令牌并按顺序读取导出的全部分片，调用方负责关闭
+func (s *Service) Open(ctx context.Context, token string) (*model.HistoryExport, io.ReadCloser, error) {
+	claims := &urlClaims{}
+	if !utils.VerifyClaims(s.secret, token, claims) || claims.Purpose != urlPurpose {
+		return nil, nil, code.FileURLInvalidErr
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, nil, code.FileURLInvalidErr.WithMsg("url expired")
+	}
+
+	data, err := s.store.GetExportByUUID(ctx, claims.ExportUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !available(data, time.Now()) {
+		return nil, nil, code.HistoryExportExpiredErr
+	}
+	return data, &partsReader{ctx: ctx, storage: s.storage, data: data}, nil
+}
+
+// Sweep 删除过期导出的分片
+func (s *Service) Sweep(ctx context.Context) error {
+	datas, err := s.store.ListExpiredExports(ctx, time.Now(), expireBatch)
+	if err != nil {
+		return err
+	}
+	for _, data := range datas {
+		if err := s.deleteParts(ctx, data); err != nil {
+			logger.Warnf(ctx, "delete history export %s err: %+v", data.UUID, err)
+			continue
+		}
+		data.Status = model.HistoryExportExpired
+		if err := s.store.UpdateExport(ctx, data, "status"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) deleteParts(ctx context.Context, data *model.HistoryExport) error {
+	for n := 1; n <= data.Parts; n++ {
+		if err := s.storage.Delete(ctx, data.PartKey(n)); err != nil && err != storage.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) response(data *model.HistoryExport) *ExportResp {
+	resp := &ExportResp{HistoryExport: data}
+	now := time.Now()
+	if !available(data, now) {
+		return resp
+	}
+
+	expiresAt := now.Add(urlTTL())
+	if data.ExpiresAt.Before(expiresAt) {
+		expiresAt = *data.ExpiresAt
+	}
+	token := utils.SignClaims(s.secret, &urlClaims{
+		Purpose:    urlPurpose,
+		ExportUUID: data.UUID,
+		ExpiresAt:  expiresAt.Unix(),
+	})
+	resp.Download = &URLResp{
+		URL:       downloadPath + "?token=" + url.QueryEscape(token),
+		ExpiresAt: expiresAt,
+	}
+	return resp
+}
+
+// available reports whether the parts of an export can be downloaded
+func available(data *model.HistoryExport, now time.Time) bool {
+	return data.Status == model.HistoryExportCompleted &&
+		data.ExpiresAt != nil && now.Before(*data.ExpiresAt)
+}
+
+// requestKey 未指定幂等键时，相同类型和过滤条件的导出视为同一请求
+func requestKey(kind model.HistoryExportKind, filters *model.HistoryExportFilters) string {
+	raw, _ := json.Marshal(filters)
+	sum := sha256.Sum256(append([]byte(kind+":"), raw...))
+	return "auto:" + hex.EncodeToString(sum[:])
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+func urlTTL() time.Duration {
+	if sec := config.GetStudioConfig().Export.URLTTLSeconds; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultURLTTL
+}
+
+func expireAfter() time.Duration {
+	if hours := config.GetStudioConfig().Export.ExpireHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultExpire
+}
+
+func partRows() int {
+	if rows := config.GetStudioConfig().Export.HistoryPartRows; rows > 0 {
+		return rows
+	}
+	return defaultPartRows
+}
+
+// RegisterJobs 注册导出执行和过期清理后台任务
+func RegisterJobs() error {
+	s := New()
+	if err := jobs.Register(&jobs.Definition{
+		Name:         "history_export_worker",
+		Description:  "执行历史记录导出，从检查点继续中断的导出",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     workEvery.String(),
+		Timeout:      workTimeout,
+		Run:          s.Work,
+	}); err != nil {
+		return err
+	}
+	return jobs.Register(&jobs.Definition{
+		Name:         "history_export_sweep",
+		Description:  "删除过期的历史记录导出文件",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     sweepEvery.String(),
+		Timeout:      5 * time.Minute,
+		Run:          s.Sweep,
+	})
+}
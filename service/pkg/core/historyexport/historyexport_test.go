@@ -0,0 +1,67 @@
+package historyexport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestKey(t *testing.T) {
+	workflowID := int64(3)
+	filters := &model.HistoryExportFilters{WorkflowID: &workflowID, Status: "failed"}
+
+	key := requestKey(model.HistoryExportWorkflow, filters)
+	assert.Equal(t, key, requestKey(model.HistoryExportWorkflow, &model.HistoryExportFilters{WorkflowID: &workflowID, Status: "failed"}))
+	assert.NotEqual(t, key, requestKey(model.HistoryExportAction, filters))
+	assert.NotEqual(t, key, requestKey(model.HistoryExportWorkflow, &model.HistoryExportFilters{Status: "failed"}))
+	assert.LessOrEqual(t, len(key), maxKeyLength)
+}
+
+func TestEncodePart(t *testing.T) {
+	rows := []*model.DeviceEventHistory{
+		{BaseModel: model.BaseModel{ID: 7}, EventType: "connected"},
+		{BaseModel: model.BaseModel{ID: 9}, EventType: "disconnected"},
+	}
+	part, n, lastID, err := encodePart(rows, func(r *model.DeviceEventHistory) int64 { return r.ID })
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(9), lastID)
+	assert.Equal(t, 2, bytes.Count(part, []byte("\n")))
+
+	_, n, _, err = encodePart([]*model.DeviceEventHistory{}, func(r *model.DeviceEventHistory) int64 { return r.ID })
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestProgress(t *testing.T) {
+	assert.Equal(t, 0, progress(10, 0))
+	assert.Equal(t, 50, progress(5, 10))
+	// 完成前不显示 100
+	assert.Equal(t, 99, progress(10, 10))
+}
+
+func TestPartsReader(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	data := &model.HistoryExport{BaseModel: model.BaseModel{UUID: uuid.NewV4()}, Parts: 3}
+	for n, content := range []string{"{\"a\":1}\n", "", "{\"a\":2}\n{\"a\":3}\n"} {
+		require.NoError(t, store.Put(ctx, data.PartKey(n+1), bytes.NewReader([]byte(content)), int64(len(content))))
+	}
+
+	r := &partsReader{ctx: ctx, storage: store, data: data}
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n", string(content))
+	assert.NoError(t, r.Close())
+
+	data.Parts = 4
+	_, err = io.ReadAll(&partsReader{ctx: ctx, storage: store, data: data})
+	assert.Error(t, err)
+}
@@ -0,0 +1,249 @@
+package historyexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	outcomeCompleted = "completed"
+	outcomeFailed    = "failed"
+	outcomePaused    = "paused" // 本次任务时间用完，保存检查点后由下次任务继续
+)
+
+// Work 依次领取待执行和已中断的导出，直到没有导出或本次任务时间用完
+func (s *Service) Work(ctx context.Context) error {
+	deadline := time.Now().Add(workBudget)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		data, err := s.store.ClaimExport(ctx, time.Now().Add(-staleAfter))
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			return nil
+		}
+		s.process(ctx, data, deadline)
+	}
+	return nil
+}
+
+// process 从检查点开始导出，每写入一个分片保存一次检查点。分片按序号写入，
+// 写入后未保存检查点就中断时，恢复后以相同内容覆盖该分片
+func (s *Service) process(ctx context.Context, data *model.HistoryExport, deadline time.Time) {
+	resumed := data.CursorID > 0
+	if resumed {
+		logger.Infof(ctx, "history export %s resumed at part %d, attempt %d", data.UUID, data.Parts+1, data.Attempts)
+	}
+	filters := data.Filters.Data()
+	params, err := filters.Params(data.LabID)
+	if err != nil {
+		s.fail(ctx, data, resumed, err)
+		return
+	}
+
+	// 首次执行时确定导出范围，之后写入的记录不导出，恢复时范围不变
+	if data.MaxID == 0 {
+		total, maxID, err := s.history.ExportBounds(ctx, data.Kind, params)
+		if err != nil {
+			s.fail(ctx, data, resumed, err)
+			return
+		}
+		if maxID == 0 {
+			s.complete(ctx, data, resumed)
+			return
+		}
+		data.TotalRows, data.MaxID = total, maxID
+		if err := s.store.UpdateExport(ctx, data, "total_rows", "max_id"); err != nil {
+			s.fail(ctx, data, resumed, err)
+			return
+		}
+	}
+
+	limit := partRows()
+	for {
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			s.pause(ctx, data, resumed)
+			return
+		}
+
+		part, rows, lastID, err := s.readPart(ctx, data.Kind, params, data.CursorID, data.MaxID, limit)
+		if err != nil {
+			if ctx.Err() != nil {
+				s.pause(ctx, data, resumed)
+				return
+			}
+			s.fail(ctx, data, resumed, err)
+			return
+		}
+		if rows == 0 {
+			s.complete(ctx, data, resumed)
+			return
+		}
+
+		if err := s.storage.Put(ctx, data.PartKey(data.Parts+1), bytes.NewReader(part), int64(len(part))); err != nil {
+			if ctx.Err() != nil {
+				s.pause(ctx, data, resumed)
+				return
+			}
+			s.fail(ctx, data, resumed, fmt.Errorf("write part %d: %w", data.Parts+1, err))
+			return
+		}
+		data.Parts++
+		data.Rows += int64(rows)
+		data.Size += int64(len(part))
+		data.CursorID = lastID
+		data.Progress = progress(data.Rows, data.TotalRows)
+		if err := s.store.UpdateExport(context.WithoutCancel(ctx), data, "parts", "rows", "size", "cursor_id", "progress"); err != nil {
+			s.fail(ctx, data, resumed, err)
+			return
+		}
+		otel.GetMetrics().RecordHistoryExportRows(ctx, string(data.Kind), rows)
+
+		if rows < limit || lastID >= data.MaxID {
+			s.complete(ctx, data, resumed)
+			return
+		}
+	}
+}
+
+// readPart reads the rows of the next part as NDJSON
+func (s *Service) readPart(ctx context.Context, kind model.HistoryExportKind, params *model.HistoryQueryParams,
+	afterID, maxID int64, limit int) ([]byte, int, int64, error) {
+	switch kind {
+	case model.HistoryExportAction:
+		rows, err := s.history.ScanActionExecutions(ctx, params, afterID, maxID, limit)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return encodePart(rows, func(r *model.ActionExecutionHistory) int64 { return r.ID })
+	case model.HistoryExportDeviceEvent:
+		rows, err := s.history.ScanDeviceEvents(ctx, params, afterID, maxID, limit)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return encodePart(rows, func(r *model.DeviceEventHistory) int64 { return r.ID })
+	default:
+		rows, err := s.history.ScanWorkflowExecutions(ctx, params, afterID, maxID, limit)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return encodePart(rows, func(r *model.WorkflowExecutionHistory) int64 { return r.ID })
+	}
+}
+
+// encodePart encodes rows one JSON record per line, returning the id of the last row
+func encodePart[T any](rows []T, id func(T) int64) ([]byte, int, int64, error) {
+	if len(rows) == 0 {
+		return nil, 0, 0, nil
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	return buf.Bytes(), len(rows), id(rows[len(rows)-1]), nil
+}
+
+// progress 完成前最多为 99，导出期间删除的记录会使已导出数少于总数
+func progress(rows, total int64) int {
+	if total <= 0 {
+		return 0
+	}
+	return int(min(99, rows*100/total))
+}
+
+func (s *Service) complete(ctx context.Context, data *model.HistoryExport, resumed bool) {
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	expiresAt := now.Add(expireAfter())
+	data.Status = model.HistoryExportCompleted
+	data.Progress = 100
+	data.CompletedAt = &now
+	data.ExpiresAt = &expiresAt
+	if err := s.store.UpdateExport(ctx, data, "status", "progress", "completed_at", "expires_at"); err != nil {
+		logger.Errorf(ctx, "complete history export %s fail: %+v", data.UUID, err)
+		return
+	}
+	otel.GetMetrics().RecordHistoryExport(ctx, string(data.Kind), outcomeCompleted, resumed)
+}
+
+// pause 保存检查点并重新排队，由下次任务继续
+func (s *Service) pause(ctx context.Context, data *model.HistoryExport, resumed bool) {
+	ctx = context.WithoutCancel(ctx)
+	data.Status = model.HistoryExportPending
+	if err := s.store.UpdateExport(ctx, data, "status"); err != nil {
+		logger.Errorf(ctx, "pause history export %s fail: %+v", data.UUID, err)
+		return
+	}
+	otel.GetMetrics().RecordHistoryExport(ctx, string(data.Kind), outcomePaused, resumed)
+}
+
+// fail 标记导出失败，已写入的分片保留，重新提交相同请求时从检查点继续
+func (s *Service) fail(ctx context.Context, data *model.HistoryExport, resumed bool, cause error) {
+	ctx = context.WithoutCancel(ctx)
+	logger.Errorf(ctx, "history export %s fail at part %d: %+v", data.UUID, data.Parts+1, cause)
+	data.Status = model.HistoryExportFailed
+	data.Error = cause.Error()
+	if err := s.store.UpdateExport(ctx, data, "status", "error"); err != nil {
+		logger.Errorf(ctx, "fail history export %s fail: %+v", data.UUID, err)
+		return
+	}
+	otel.GetMetrics().RecordHistoryExport(ctx, string(data.Kind), outcomeFailed, resumed)
+}
+
+// partsReader reads the parts of an export in order as one file, opening each
+// part when the previous one is exhausted
+type partsReader struct {
+	ctx     context.Context
+	storage storage.Storage
+	data    *model.HistoryExport
+	next    int
+	current io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.next >= r.data.Parts {
+				return 0, io.EOF
+			}
+			r.next++
+			part, err := r.storage.Open(r.ctx, r.data.PartKey(r.next))
+			if err != nil {
+				return 0, fmt.Errorf("open part %d: %w", r.next, err)
+			}
+			r.current = part
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			_ = r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
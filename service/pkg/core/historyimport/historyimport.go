@@ -0,0 +1,222 @@
+// Package historyimport ingests execution history archives back into the
+// history tables of a lab for disaster recovery and environment seeding. A lab
+// export archive, or a single table as JSONL or Parquet, is uploaded and
+// imported in the background; records keep their UUIDs, existing records are
+// handled by a conflict policy and a dry run only produces the validation report.
+package historyimport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/historyimport"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	defaultMaxSizeMB = 512
+	batchSize        = 500
+	staleAfter       = time.Hour // 超过该时间未更新的导入视为已中断
+)
+
+// CreateReq 创建历史记录导入，文件内容为请求体
+type CreateReq struct {
+	LabUUID uuid.UUID                  `form:"lab_uuid" binding:"required"`
+	Format  model.HistoryImportFormat  `form:"format" binding:"required"` // zip | jsonl | parquet
+	Table   string                     `form:"table"`                     // jsonl、parquet 文件对应的历史表
+	Policy  model.ImportConflictPolicy `form:"policy"`                    // skip | overwrite | fail，默认 skip
+	DryRun  bool                       `form:"dry_run"`                   // 只校验并生成报告，不写入
+}
+
+// ListReq 查询历史记录导入
+type ListReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+type Service struct {
+	store    historyimport.HistoryImportRepo
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    historyimport.New(),
+		envStore: environment.New(),
+	}
+}
+
+// Create 保存上传的文件并在后台导入，同一实验室同时只能有一个导入
+func (s *Service) Create(ctx context.Context, req *CreateReq, body io.Reader) (*model.HistoryImport, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkAdmin(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateReq(req); err != nil {
+		return nil, err
+	}
+	if count, err := s.store.CountActiveImports(ctx, labID, time.Now().Add(-staleAfter)); err != nil {
+		return nil, err
+	} else if count > 0 {
+		return nil, code.HistoryImportRunningErr
+	}
+
+	path, size, err := spool(body)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &model.HistoryImport{
+		BaseModel:   model.BaseModel{UUID: uuid.New()},
+		LabID:       labID,
+		RequestedBy: userInfo.ID,
+		Format:      req.Format,
+		Table:       req.Table,
+		Policy:      req.Policy,
+		DryRun:      req.DryRun,
+		Size:        size,
+		Status:      model.HistoryImportPending,
+	}
+	if err := s.store.CreateImport(ctx, data); err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	importCtx := context.WithoutCancel(ctx)
+	utils.SafelyGo(func() {
+		defer os.Remove(path)
+		s.run(importCtx, data, path)
+	}, func(err error) {
+		logger.Errorf(importCtx, "history import %s panic: %+v", data.UUID, err)
+		data.Status = model.HistoryImportFailed
+		data.Error = fmt.Sprintf("panic: %v", err)
+		_ = s.store.UpdateImport(importCtx, data, "status", "error")
+	})
+	return data, nil
+}
+
+// List 查询实验室的历史记录导入
+func (s *Service) List(ctx context.Context, req *ListReq) ([]*model.HistoryImport, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkAdmin(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListImports(ctx, labID)
+}
+
+// Get 查询导入进度和校验报告
+func (s *Service) Get(ctx context.Context, importUUID uuid.UUID) (*model.HistoryImport, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetImportByUUID(ctx, importUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAdminByID(ctx, userInfo.ID, data.LabID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func validateReq(req *CreateReq) error {
+	switch req.Format {
+	case model.HistoryImportZip:
+		req.Table = ""
+	case model.HistoryImportJSONL, model.HistoryImportParquet:
+		if !slices.Contains(model.HistoryImportTables, req.Table) {
+			return code.HistoryImportFormatErr.WithMsgf("table must be one of %v", model.HistoryImportTables)
+		}
+	default:
+		return code.HistoryImportFormatErr.WithMsg("format must be zip, jsonl or parquet")
+	}
+
+	switch req.Policy {
+	case "":
+		req.Policy = model.ImportConflictSkip
+	case model.ImportConflictSkip, model.ImportConflictOverwrite, model.ImportConflictFail:
+	default:
+		return code.ParamErr.WithMsg("policy must be skip, overwrite or fail")
+	}
+	return nil
+}
+
+// spool 将上传内容写入临时文件，zip 和 parquet 需要随机读取
+func spool(body io.Reader) (string, int64, error) {
+	f, err := os.CreateTemp("", "history-import-*")
+	if err != nil {
+		return "", 0, code.FileStorageErr.WithErr(err)
+	}
+	defer f.Close()
+
+	limit := maxSize()
+	size, err := io.Copy(f, io.LimitReader(body, limit+1))
+	switch {
+	case err != nil:
+		err = code.ParamErr.WithErr(err)
+	case size > limit:
+		err = code.HistoryImportTooLargeErr.WithMsgf("file exceeds %d bytes", limit)
+	case size == 0:
+		err = code.ParamErr.WithMsg("empty file")
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", 0, err
+	}
+	return f.Name(), size, nil
+}
+
+func (s *Service) checkAdmin(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.envStore.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	if err := s.checkAdminByID(ctx, userID, labID); err != nil {
+		return 0, err
+	}
+	return labID, nil
+}
+
+// checkAdminByID 导入会写入实验室的历史记录，仅管理员可以操作
+func (s *Service) checkAdminByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+		"role":    model.LaboratoryMemberAdmin,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+func maxSize() int64 {
+	mb := config.GetStudioConfig().Export.ImportMaxSizeMB
+	if mb <= 0 {
+		mb = defaultMaxSizeMB
+	}
+	return int64(mb) << 20
+}
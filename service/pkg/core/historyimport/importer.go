@@ -0,0 +1,247 @@
+package historyimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/respcache"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// importer 一次导入的状态，ctx 用于更新导入进度，txCtx 用于写入历史记录
+type importer struct {
+	s     *Service
+	data  *model.HistoryImport
+	seen  map[string]map[uuid.UUID]bool
+	idMap map[int64]int64 // 来源库工作流执行 id 到导入后 id 的映射
+}
+
+// run 执行导入，结果记录在导入记录中
+func (s *Service) run(ctx context.Context, data *model.HistoryImport, path string) {
+	data.Status = model.HistoryImportRunning
+	if err := s.store.UpdateImport(ctx, data, "status"); err != nil {
+		return
+	}
+
+	err := s.ingest(ctx, data, path)
+	now := time.Now()
+	data.CompletedAt = &now
+	data.Status = model.HistoryImportCompleted
+	if err != nil {
+		logger.Warnf(ctx, "history import %s fail: %+v", data.UUID, err)
+		data.Status = model.HistoryImportFailed
+		data.Error = err.Error()
+		// 写入在同一事务中执行，失败时全部回滚
+		if !data.DryRun {
+			data.Inserted, data.Updated = 0, 0
+		}
+	}
+	_ = s.store.UpdateImport(ctx, data, "status", "error", "completed_at",
+		"processed", "inserted", "updated", "skipped", "invalid", "issues")
+	if data.Inserted > 0 || data.Updated > 0 {
+		respcache.Invalidate(ctx, respcache.TagStats)
+	}
+}
+
+func (s *Service) ingest(ctx context.Context, data *model.HistoryImport, path string) error {
+	src, err := openSource(path, data.Format, data.Table)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	im := &importer{
+		s:     s,
+		data:  data,
+		seen:  make(map[string]map[uuid.UUID]bool),
+		idMap: make(map[int64]int64),
+	}
+	if data.DryRun {
+		return im.consume(ctx, ctx, src)
+	}
+	return s.store.ExecTx(ctx, func(txCtx context.Context) error {
+		return im.consume(ctx, txCtx, src)
+	})
+}
+
+// consume 按表分批校验并写入记录
+func (im *importer) consume(ctx, txCtx context.Context, src source) error {
+	var (
+		table string
+		batch []*record
+	)
+	for {
+		raw, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if raw.table != table {
+			if err := im.flush(ctx, txCtx, table, batch); err != nil {
+				return err
+			}
+			table, batch = raw.table, nil
+		}
+
+		im.data.Processed++
+		rec, err := decode(raw)
+		if err != nil {
+			im.invalid(raw.table, raw.line, rec, err)
+			continue
+		}
+		seen := im.seen[table]
+		if seen == nil {
+			seen = make(map[uuid.UUID]bool)
+			im.seen[table] = seen
+		}
+		if seen[rec.base.UUID] {
+			im.invalid(table, rec.line, rec, errors.New("duplicate uuid in file"))
+			continue
+		}
+		seen[rec.base.UUID] = true
+
+		batch = append(batch, rec)
+		if len(batch) >= batchSize {
+			if err := im.flush(ctx, txCtx, table, batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	return im.flush(ctx, txCtx, table, batch)
+}
+
+// flush 按冲突策略处理一批记录，写入后更新导入进度
+func (im *importer) flush(ctx, txCtx context.Context, table string, batch []*record) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	uuids := make([]uuid.UUID, 0, len(batch))
+	for _, rec := range batch {
+		uuids = append(uuids, rec.base.UUID)
+	}
+	existing, err := im.s.store.ExistingRecords(txCtx, table, uuids)
+	if err != nil {
+		return err
+	}
+
+	writes := make([]*record, 0, len(batch))
+	for _, rec := range batch {
+		found, ok := existing[rec.base.UUID]
+		if !ok {
+			im.data.Inserted++
+			writes = append(writes, rec)
+			continue
+		}
+		if found.LabID != im.data.LabID {
+			im.invalid(table, rec.line, rec, errors.New("uuid belongs to another lab"))
+			continue
+		}
+
+		switch im.data.Policy {
+		case model.ImportConflictOverwrite:
+			im.data.Updated++
+			writes = append(writes, rec)
+		case model.ImportConflictFail:
+			im.issue(table, rec.line, rec, "uuid already exists")
+			if !im.data.DryRun {
+				return fmt.Errorf("%s line %d: uuid %s already exists", table, rec.line, rec.base.UUID)
+			}
+			im.data.Skipped++
+		default:
+			im.data.Skipped++
+		}
+		// 保留的已有工作流执行仍可被导入的动作记录关联
+		if table == tableWorkflow {
+			im.idMap[rec.oldID] = found.ID
+		}
+	}
+
+	if len(writes) > 0 && !im.data.DryRun {
+		if err := im.prepare(txCtx, writes); err != nil {
+			return err
+		}
+		if err := im.s.store.SaveRecords(txCtx, typedSlice(table, writes)); err != nil {
+			return err
+		}
+		if table == tableWorkflow {
+			for _, rec := range writes {
+				im.idMap[rec.oldID] = rec.base.ID
+			}
+		}
+	}
+
+	return im.s.store.UpdateImport(ctx, im.data,
+		"processed", "inserted", "updated", "skipped", "invalid", "issues")
+}
+
+// prepare 将记录归属到导入的实验室，并按 UUID 重新关联工作流、设备和工作流执行
+func (im *importer) prepare(txCtx context.Context, recs []*record) error {
+	labID := im.data.LabID
+	var workflowUUIDs, deviceUUIDs []uuid.UUID
+	for _, rec := range recs {
+		rec.base.ID = 0
+		switch v := rec.value.(type) {
+		case *model.WorkflowExecutionHistory:
+			workflowUUIDs = append(workflowUUIDs, v.WorkflowUUID)
+		case *model.ActionExecutionHistory:
+			deviceUUIDs = append(deviceUUIDs, v.DeviceUUID)
+		case *model.DeviceEventHistory:
+			deviceUUIDs = append(deviceUUIDs, v.DeviceUUID)
+		}
+	}
+
+	workflowIDs, err := im.s.store.WorkflowIDs(txCtx, labID, workflowUUIDs)
+	if err != nil {
+		return err
+	}
+	deviceIDs, err := im.s.store.DeviceIDs(txCtx, labID, deviceUUIDs)
+	if err != nil {
+		return err
+	}
+
+	// 实验室中不存在的工作流和设备 id 置为 0，批次不随历史记录导入
+	for _, rec := range recs {
+		switch v := rec.value.(type) {
+		case *model.WorkflowExecutionHistory:
+			v.LabID = labID
+			v.WorkflowID = workflowIDs[v.WorkflowUUID]
+			v.BatchID = nil
+		case *model.ActionExecutionHistory:
+			v.LabID = labID
+			v.DeviceID = deviceIDs[v.DeviceUUID]
+			if v.WorkflowExecutionID != nil {
+				if id := im.idMap[*v.WorkflowExecutionID]; id > 0 {
+					v.WorkflowExecutionID = &id
+				} else {
+					v.WorkflowExecutionID = nil
+				}
+			}
+		case *model.DeviceEventHistory:
+			v.LabID = labID
+			v.DeviceID = deviceIDs[v.DeviceUUID]
+		}
+	}
+	return nil
+}
+
+func (im *importer) invalid(table string, line int64, rec *record, err error) {
+	im.data.Invalid++
+	im.issue(table, line, rec, err.Error())
+}
+
+func (im *importer) issue(table string, line int64, rec *record, msg string) {
+	issue := model.ImportIssue{Table: table, Line: line, Message: msg}
+	if rec != nil && !rec.base.UUID.IsNil() {
+		issue.UUID = rec.base.UUID.String()
+	}
+	im.data.AddIssue(issue)
+}
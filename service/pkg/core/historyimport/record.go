@@ -0,0 +1,158 @@
+package historyimport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/datatypes"
+)
+
+const (
+	tableWorkflow = "workflow_execution_history"
+	tableAction   = "action_execution_history"
+	tableEvent    = "device_event_history"
+)
+
+// jsonColumns 历史表中的 json 列，parquet 文件中通常以字符串保存
+var jsonColumns = map[string]bool{
+	"result":     true,
+	"input":      true,
+	"output":     true,
+	"metadata":   true,
+	"labels":     true,
+	"event_data": true,
+}
+
+// record 解析并校验后的一条历史记录
+type record struct {
+	line  int64
+	value any // *model.WorkflowExecutionHistory | *model.ActionExecutionHistory | *model.DeviceEventHistory
+	base  *model.BaseModel
+	oldID int64 // 记录在来源库中的 id，用于关联导入的动作记录
+}
+
+// decode 将原始记录转换为历史表模型并校验必填字段
+func decode(raw *rawRecord) (*record, error) {
+	if raw.err != nil {
+		return nil, raw.err
+	}
+	for k, v := range raw.data {
+		if s, ok := v.(string); ok && jsonColumns[k] && json.Valid([]byte(s)) {
+			raw.data[k] = json.RawMessage(s)
+		}
+	}
+	b, err := json.Marshal(raw.data)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &record{line: raw.line}
+	switch raw.table {
+	case tableWorkflow:
+		data := &model.WorkflowExecutionHistory{}
+		if err := json.Unmarshal(b, data); err != nil {
+			return nil, err
+		}
+		if data.Labels.Data() == nil {
+			data.Labels = datatypes.NewJSONType(map[string]string{})
+		}
+		rec.value, rec.base = data, &data.BaseModel
+		err = validateWorkflow(data)
+	case tableAction:
+		data := &model.ActionExecutionHistory{}
+		if err := json.Unmarshal(b, data); err != nil {
+			return nil, err
+		}
+		rec.value, rec.base = data, &data.BaseModel
+		err = validateAction(data)
+	case tableEvent:
+		data := &model.DeviceEventHistory{}
+		if err := json.Unmarshal(b, data); err != nil {
+			return nil, err
+		}
+		rec.value, rec.base = data, &data.BaseModel
+		err = validateEvent(data)
+	default:
+		return nil, fmt.Errorf("unsupported table %q", raw.table)
+	}
+	if err != nil {
+		return rec, err
+	}
+	if rec.base.UUID.IsNil() {
+		return rec, errors.New("uuid is required")
+	}
+	rec.oldID = rec.base.ID
+	return rec, nil
+}
+
+func validateWorkflow(data *model.WorkflowExecutionHistory) error {
+	switch {
+	case data.WorkflowUUID.IsNil():
+		return errors.New("workflow_uuid is required")
+	case data.WorkflowName == "":
+		return errors.New("workflow_name is required")
+	case data.UserID == "":
+		return errors.New("user_id is required")
+	case data.StartedAt.IsZero():
+		return errors.New("started_at is required")
+	case !validStatus(data.Status):
+		return fmt.Errorf("invalid status %q", data.Status)
+	}
+	return model.ValidateLabels(data.Labels.Data())
+}
+
+func validateAction(data *model.ActionExecutionHistory) error {
+	switch {
+	case data.DeviceName == "":
+		return errors.New("device_name is required")
+	case data.ActionType == "":
+		return errors.New("action_type is required")
+	case data.ActionName == "":
+		return errors.New("action_name is required")
+	case !validStatus(data.Status):
+		return fmt.Errorf("invalid status %q", data.Status)
+	}
+	return nil
+}
+
+func validateEvent(data *model.DeviceEventHistory) error {
+	switch {
+	case data.EventType == "":
+		return errors.New("event_type is required")
+	case data.Timestamp.IsZero():
+		return errors.New("timestamp is required")
+	}
+	return nil
+}
+
+func validStatus(status model.ExecutionStatus) bool {
+	switch status {
+	case model.ExecutionStatusPending, model.ExecutionStatusRunning, model.ExecutionStatusSuccess,
+		model.ExecutionStatusFailed, model.ExecutionStatusCancelled, model.ExecutionStatusTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// typedSlice 将一批记录转换为对应历史表模型的切片以便批量写入
+func typedSlice(table string, recs []*record) any {
+	switch table {
+	case tableWorkflow:
+		return collect[*model.WorkflowExecutionHistory](recs)
+	case tableAction:
+		return collect[*model.ActionExecutionHistory](recs)
+	default:
+		return collect[*model.DeviceEventHistory](recs)
+	}
+}
+
+func collect[T any](recs []*record) []T {
+	datas := make([]T, 0, len(recs))
+	for _, rec := range recs {
+		datas = append(datas, rec.value.(T))
+	}
+	return datas
+}
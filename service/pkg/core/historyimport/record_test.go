@@ -0,0 +1,74 @@
+package historyimport
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const workflowLine = `{"id":3,"uuid":"0b7f0a3c-5a7c-4f55-9c63-1f2b0c6e8a11","workflow_uuid":"6a1c2d3e-4f50-4a1b-8c2d-3e4f5a6b7c8d",` +
+	`"workflow_name":"titration","user_id":"u1","status":"success","started_at":"2025-01-02T03:04:05Z","result":"{\"ok\":true}"}`
+
+func TestDecode(t *testing.T) {
+	rec, err := decode(&rawRecord{table: tableWorkflow, line: 1, data: map[string]any{
+		"id":            int64(3),
+		"uuid":          "0b7f0a3c-5a7c-4f55-9c63-1f2b0c6e8a11",
+		"workflow_uuid": "6a1c2d3e-4f50-4a1b-8c2d-3e4f5a6b7c8d",
+		"workflow_name": "titration",
+		"user_id":       "u1",
+		"status":        "success",
+		"started_at":    "2025-01-02T03:04:05Z",
+		"result":        `{"ok":true}`,
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), rec.oldID)
+	data := rec.value.(*model.WorkflowExecutionHistory)
+	assert.JSONEq(t, `{"ok":true}`, string(data.Result))
+	assert.NotNil(t, data.Labels.Data())
+
+	_, err = decode(&rawRecord{table: tableAction, data: map[string]any{
+		"uuid": "0b7f0a3c-5a7c-4f55-9c63-1f2b0c6e8a11", "device_name": "pump",
+		"action_type": "move", "action_name": "move", "status": "done",
+	}})
+	assert.EqualError(t, err, `invalid status "done"`)
+
+	_, err = decode(&rawRecord{table: tableEvent, data: map[string]any{
+		"event_type": "online", "timestamp": "2025-01-02T03:04:05Z",
+	}})
+	assert.EqualError(t, err, "uuid is required")
+}
+
+func TestZipSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.zip")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	zw := zip.NewWriter(f)
+	w, err := zw.Create(model.LabExportFile(tableWorkflow))
+	assert.NoError(t, err)
+	_, err = io.WriteString(w, workflowLine+"\n\nnot json\n")
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, f.Close())
+
+	src, err := openSource(path, model.HistoryImportZip, "")
+	assert.NoError(t, err)
+	defer src.Close()
+
+	raw, err := src.Next()
+	assert.NoError(t, err)
+	_, err = decode(raw)
+	assert.NoError(t, err)
+
+	raw, err = src.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), raw.line)
+	assert.Error(t, raw.err)
+
+	_, err = src.Next()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,175 @@
+package historyimport
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const maxLineSize = 16 << 20
+
+// rawRecord 从导入文件中读出的一条记录
+type rawRecord struct {
+	table string
+	line  int64
+	data  map[string]any
+	err   error // 记录无法解析，不影响后续记录
+}
+
+// source 按表顺序读出导入文件中的记录，读完返回 io.EOF
+type source interface {
+	Next() (*rawRecord, error)
+	Close() error
+}
+
+// openSource 按格式打开导入文件
+func openSource(path string, format model.HistoryImportFormat, table string) (source, error) {
+	switch format {
+	case model.HistoryImportZip:
+		return openZip(path)
+	case model.HistoryImportJSONL:
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonlSource{table: table, r: bufio.NewReaderSize(f, maxLineSize), closer: f}, nil
+	case model.HistoryImportParquet:
+		return openParquet(path, table)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// jsonlSource 每行一条 JSON 记录，空行忽略
+type jsonlSource struct {
+	table  string
+	r      *bufio.Reader
+	closer io.Closer
+	line   int64
+}
+
+func (s *jsonlSource) Next() (*rawRecord, error) {
+	for {
+		b, err := s.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			return nil, fmt.Errorf("%s line %d: longer than %d bytes", s.table, s.line+1, maxLineSize)
+		}
+		if err != nil && (err != io.EOF || len(b) == 0) {
+			return nil, err
+		}
+		s.line++
+		b = bytes.TrimSpace(b)
+		if len(b) == 0 {
+			continue
+		}
+
+		rec := &rawRecord{table: s.table, line: s.line}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&rec.data); err != nil {
+			rec.err = fmt.Errorf("invalid json: %w", err)
+		}
+		return rec, nil
+	}
+}
+
+func (s *jsonlSource) Close() error {
+	return s.closer.Close()
+}
+
+// zipSource 依次读出实验室导出归档中的历史表，归档中缺少的表跳过
+type zipSource struct {
+	zr      *zip.ReadCloser
+	tables  []string
+	current *jsonlSource
+}
+
+func openZip(path string) (*zipSource, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	return &zipSource{zr: zr, tables: model.HistoryImportTables}, nil
+}
+
+func (s *zipSource) Next() (*rawRecord, error) {
+	for {
+		if s.current != nil {
+			rec, err := s.current.Next()
+			if err != io.EOF {
+				return rec, err
+			}
+			_ = s.current.Close()
+			s.current = nil
+		}
+		if len(s.tables) == 0 {
+			return nil, io.EOF
+		}
+
+		table := s.tables[0]
+		s.tables = s.tables[1:]
+		f, err := s.zr.Open(model.LabExportFile(table))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.current = &jsonlSource{table: table, r: bufio.NewReaderSize(f, maxLineSize), closer: f}
+	}
+}
+
+func (s *zipSource) Close() error {
+	if s.current != nil {
+		_ = s.current.Close()
+	}
+	return s.zr.Close()
+}
+
+// parquetSource 单张表的 parquet 文件，列名与历史表字段的 json 名一致
+type parquetSource struct {
+	table string
+	f     *os.File
+	r     *parquet.Reader
+	line  int64
+}
+
+func openParquet(path string, table string) (*parquetSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	pf, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("open parquet: %w", err)
+	}
+	return &parquetSource{table: table, f: f, r: parquet.NewReader(pf)}, nil
+}
+
+func (s *parquetSource) Next() (*rawRecord, error) {
+	data := make(map[string]any)
+	if err := s.r.Read(&data); err != nil {
+		return nil, err
+	}
+	s.line++
+	return &rawRecord{table: s.table, line: s.line, data: data}, nil
+}
+
+func (s *parquetSource) Close() error {
+	_ = s.r.Close()
+	return s.f.Close()
+}
@@ -0,0 +1,105 @@
+package historyingest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// callTimeout 单次写入的超时，写入失败不应拖慢调度
+const callTimeout = 5 * time.Second
+
+// Client records history through the web service
+type Client struct {
+	conn  grpc.ClientConnInterface
+	token string
+}
+
+var defaultClient = sync.OnceValue(func() *Client {
+	conf := config.Global().RPC.HistoryIngest
+	client, err := Dial(conf.Addr, conf.Token)
+	if err != nil {
+		// 地址格式错误时每次调用都会失败并记录日志
+		return &Client{conn: &brokenConn{err: err}}
+	}
+	return client
+})
+
+// Default returns the client of the configured web service
+func Default() *Client {
+	return defaultClient()
+}
+
+// Dial creates a client of the service at addr, the connection is made on the first call
+func Dial(addr, token string) (*Client, error) {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, token), nil
+}
+
+// NewClient creates a client on an existing connection
+func NewClient(conn grpc.ClientConnInterface, token string) *Client {
+	return &Client{conn: conn, token: token}
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req any) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), callTimeout)
+	defer cancel()
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, tokenHeader, c.token)
+	}
+	return c.conn.Invoke(ctx, fullMethod(method), req, &Ack{}, grpc.CallContentSubtype(codecName))
+}
+
+// RecordExecutionStart 执行开始运行
+func (c *Client) RecordExecutionStart(ctx context.Context, req *ExecutionStartReq) error {
+	return c.invoke(ctx, "RecordExecutionStart", req)
+}
+
+// RecordExecutionProgress 更新执行的步骤进度，增量不是幂等的，失败时不能重试
+func (c *Client) RecordExecutionProgress(ctx context.Context, req *ExecutionProgressReq) error {
+	return c.invoke(ctx, "RecordExecutionProgress", req)
+}
+
+// RecordExecutionComplete 执行结束
+func (c *Client) RecordExecutionComplete(ctx context.Context, req *ExecutionCompleteReq) error {
+	return c.invoke(ctx, "RecordExecutionComplete", req)
+}
+
+// RecordActionResult 记录步骤的结果
+func (c *Client) RecordActionResult(ctx context.Context, req *ActionResultReq) error {
+	return c.invoke(ctx, "RecordActionResult", req)
+}
+
+// RecordApprovalRequest 创建等待审批的步骤记录和审批请求
+func (c *Client) RecordApprovalRequest(ctx context.Context, req *ApprovalRequestReq) error {
+	return c.invoke(ctx, "RecordApprovalRequest", req)
+}
+
+// RecordDeviceEvent 记录设备事件
+func (c *Client) RecordDeviceEvent(ctx context.Context, req *DeviceEventReq) error {
+	return c.invoke(ctx, "RecordDeviceEvent", req)
+}
+
+// brokenConn fails every call with the dial error
+type brokenConn struct {
+	err error
+}
+
+func (b *brokenConn) Invoke(context.Context, string, any, any, ...grpc.CallOption) error {
+	return b.err
+}
+
+func (b *brokenConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, b.err
+}
@@ -0,0 +1,178 @@
+package historyingest
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/scienceol/studio/service/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves srv on an in-memory listener and returns a client with the token
+func dial(t *testing.T, serverToken, clientToken string, srv IngestServer) *Client {
+	lis := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(serverToken, srv)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return NewClient(conn, clientToken)
+}
+
+func TestIngest(t *testing.T) {
+	ctx := context.Background()
+	store := testutil.NewFakeHistoryRepo()
+	startedAt := time.Now().Add(-time.Minute)
+	exec := &model.WorkflowExecutionHistory{
+		BaseModel:   model.BaseModel{UUID: uuid.NewV4()},
+		LabID:       3,
+		Status:      model.ExecutionStatusPending,
+		StartedAt:   startedAt,
+		SLATargetMs: time.Second.Milliseconds(),
+	}
+	require.NoError(t, store.CreateWorkflowExecution(ctx, exec))
+
+	client := dial(t, "secret", "secret", &Server{
+		history: store,
+		classify: func(_ context.Context, labID int64, message string) string {
+			return "device"
+		},
+	})
+
+	require.NoError(t, client.RecordExecutionStart(ctx, &ExecutionStartReq{ExecutionUUID: exec.UUID, StepsTotal: 2}))
+	require.NoError(t, client.RecordActionResult(ctx, &ActionResultReq{
+		ExecutionUUID: exec.UUID,
+		ActionType:    "python_script",
+		ActionName:    "mix",
+		Input:         []byte(`{"speed":1}`),
+		Status:        model.ExecutionStatusFailed,
+		DurationMs:    1500,
+		ErrorMessage:  "device offline",
+	}))
+	require.NoError(t, client.RecordExecutionProgress(ctx, &ExecutionProgressReq{ExecutionUUID: exec.UUID, FailedDelta: 1}))
+	// 旧版调度器上报的累计值只增不减，与增量合并
+	require.NoError(t, client.RecordExecutionProgress(ctx, &ExecutionProgressReq{ExecutionUUID: exec.UUID, StepsFailed: 1}))
+	require.Error(t, client.RecordExecutionProgress(ctx, &ExecutionProgressReq{ExecutionUUID: uuid.NewV4(), FailedDelta: 1}))
+	completedAt := startedAt.Add(30 * time.Second)
+	require.NoError(t, client.RecordExecutionComplete(ctx, &ExecutionCompleteReq{
+		ExecutionUUID: exec.UUID,
+		Status:        model.ExecutionStatusFailed,
+		CompletedAt:   completedAt,
+		ErrorMessage:  "device offline",
+	}))
+
+	got, err := store.GetWorkflowExecutionByUUID(ctx, exec.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ExecutionStatusFailed, got.Status)
+	assert.Equal(t, 2, got.StepsTotal)
+	assert.Equal(t, 1, got.StepsFailed)
+	assert.Equal(t, int64(30000), got.DurationMs)
+	require.NotNil(t, got.SLABreached)
+	assert.True(t, *got.SLABreached)
+	assert.Equal(t, "device", got.ErrorCategory)
+
+	actions := store.Actions()
+	require.Len(t, actions, 1)
+	assert.Equal(t, exec.ID, *actions[0].WorkflowExecutionID)
+	assert.Equal(t, int64(3), actions[0].LabID)
+	assert.Equal(t, "mix", actions[0].ActionName)
+	assert.JSONEq(t, `{"speed":1}`, string(actions[0].Input))
+	assert.Equal(t, "device", actions[0].ErrorCategory)
+
+	// 已开始的执行不会回到运行中
+	require.NoError(t, client.RecordExecutionStart(ctx, &ExecutionStartReq{ExecutionUUID: exec.UUID, StepsTotal: 5}))
+	got, _ = store.GetWorkflowExecutionByUUID(ctx, exec.UUID)
+	assert.Equal(t, model.ExecutionStatusFailed, got.Status)
+	assert.Equal(t, 2, got.StepsTotal)
+
+	err = client.RecordExecutionComplete(ctx, &ExecutionCompleteReq{ExecutionUUID: exec.UUID, Status: model.ExecutionStatusRunning})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = client.RecordExecutionStart(ctx, &ExecutionStartReq{ExecutionUUID: uuid.NewV4()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestIngestToken(t *testing.T) {
+	client := dial(t, "secret", "wrong", &Server{history: testutil.NewFakeHistoryRepo()})
+	err := client.RecordExecutionStart(context.Background(), &ExecutionStartReq{ExecutionUUID: uuid.NewV4()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestIngestApprovalReusesAction(t *testing.T) {
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+
+	srv := NewServer()
+	exec := &model.WorkflowExecutionHistory{
+		LabID:        3,
+		UserID:       "starter",
+		WorkflowUUID: uuid.NewV4(),
+		Status:       model.ExecutionStatusRunning,
+		StartedAt:    time.Now(),
+	}
+	require.NoError(t, srv.history.CreateWorkflowExecution(ctx, exec))
+	device := &model.MaterialNode{LabID: 3, Name: "pump", DisplayName: "pump", Type: model.MATERIALDEVICE}
+	require.NoError(t, srv.baseDB.CreateData(ctx, device))
+	client := dial(t, "", "", srv)
+
+	approvalUUID, actionUUID := uuid.NewV4(), uuid.NewV4()
+	require.NoError(t, client.RecordApprovalRequest(ctx, &ApprovalRequestReq{
+		ApprovalUUID:  approvalUUID,
+		ActionUUID:    actionUUID,
+		ExecutionUUID: exec.UUID,
+		NodeUUID:      uuid.NewV4(),
+		StepName:      "add reagent",
+		RequestedBy:   "starter",
+		DeviceName:    "pump",
+		ActionType:    "unilabos_msgs/action/Add",
+		ActionName:    "add",
+		Input:         []byte(`{"volume":5}`),
+	}))
+	approved, err := srv.approvals.DecideApproval(ctx, approvalUUID, model.ApprovalStatusApproved, "reviewer", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, client.RecordActionResult(ctx, &ActionResultReq{
+		ExecutionUUID: exec.UUID,
+		DeviceName:    "pump",
+		ActionType:    "unilabos_msgs/action/Add",
+		ActionName:    "add",
+		Status:        model.ExecutionStatusSuccess,
+		DurationMs:    1200,
+		ActionUUID:    &actionUUID,
+	}))
+
+	// 步骤的结果写入审批时创建的记录，审批记录保留在元数据中
+	actions, err := srv.history.ListActionsByWorkflowExecution(ctx, exec.ID)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, actionUUID, actions[0].UUID)
+	assert.Equal(t, approved.ActionExecutionID, actions[0].ID)
+	assert.Equal(t, device.UUID, actions[0].DeviceUUID)
+	assert.Equal(t, model.ExecutionStatusSuccess, actions[0].Status)
+	assert.Equal(t, int64(1200), actions[0].DurationMs)
+	assert.Contains(t, string(actions[0].Metadata), approvalUUID.String())
+
+	got, err := srv.history.GetWorkflowExecutionByUUID(ctx, exec.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.StepsCompleted)
+}
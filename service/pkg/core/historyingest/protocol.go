@@ -0,0 +1,173 @@
+// Package historyingest is the internal gRPC API through which the scheduler
+// records execution history. The web service serves it and owns the history
+// tables; the scheduler only holds a client and never writes them directly.
+//
+// Messages are encoded as JSON by a registered codec, so the service is
+// described by hand instead of generated from a proto file.
+package historyingest
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+const (
+	serviceName = "studio.history.v1.HistoryIngest"
+	codecName   = "json"
+	tokenHeader = "x-ingest-token"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes the messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+// ExecutionStartReq 执行开始运行
+type ExecutionStartReq struct {
+	ExecutionUUID uuid.UUID `json:"execution_uuid"`
+	StepsTotal    int       `json:"steps_total"`
+}
+
+// ExecutionProgressReq 执行的步骤进度，为本次新增的完成和失败步骤数。
+// 增量不是幂等的，每次进度最多投递一次，客户端不重试，失败时丢失该次进度而不会重复计数
+type ExecutionProgressReq struct {
+	ExecutionUUID  uuid.UUID `json:"execution_uuid"`
+	CompletedDelta int       `json:"completed_delta"`
+	FailedDelta    int       `json:"failed_delta"`
+
+	// Deprecated: 旧版调度器上报的累计值，滚动升级期间仍然接受，调度器全部升级后移除
+	StepsCompleted int `json:"steps_completed,omitempty"`
+	// Deprecated: 同 StepsCompleted
+	StepsFailed int `json:"steps_failed,omitempty"`
+}
+
+// ExecutionCompleteReq 执行结束，失败或超时时 error_message 为失败原因
+type ExecutionCompleteReq struct {
+	ExecutionUUID uuid.UUID             `json:"execution_uuid"`
+	Status        model.ExecutionStatus `json:"status"`
+	CompletedAt   time.Time             `json:"completed_at"`
+	ErrorMessage  string                `json:"error_message,omitempty"`
+}
+
+// ActionResultReq 执行中一个步骤的结果，设备按实验室和设备名查找
+type ActionResultReq struct {
+	ExecutionUUID uuid.UUID             `json:"execution_uuid"`
+	DeviceName    string                `json:"device_name"`
+	ActionType    string                `json:"action_type"`
+	ActionName    string                `json:"action_name"`
+	Input         json.RawMessage       `json:"input,omitempty"`
+	Output        json.RawMessage       `json:"output,omitempty"`
+	Status        model.ExecutionStatus `json:"status"`
+	DurationMs    int64                 `json:"duration_ms"`
+	ExpectedMs    int64                 `json:"expected_ms"`
+	ErrorMessage  string                `json:"error_message,omitempty"`
+	TraceParent   string                `json:"traceparent,omitempty"` // 执行步骤时的 W3C trace context
+
+	// ActionUUID 为空时新建步骤记录。经过审批的步骤为审批时创建的记录，
+	// 按 uuid 结束该记录并在同一事务中计入执行进度，调度器不再单独上报进度
+	ActionUUID *uuid.UUID `json:"action_uuid,omitempty"`
+}
+
+// ApprovalRequestReq 步骤等待人工审批，创建待执行的步骤记录和审批请求。
+// 两个 uuid 由调度器生成，分别用于轮询审批结果和在步骤结束时写入同一条步骤记录
+type ApprovalRequestReq struct {
+	ApprovalUUID  uuid.UUID       `json:"approval_uuid"`
+	ActionUUID    uuid.UUID       `json:"action_uuid"`
+	ExecutionUUID uuid.UUID       `json:"execution_uuid"`
+	NodeUUID      uuid.UUID       `json:"node_uuid"`
+	StepName      string          `json:"step_name"`
+	RequestedBy   string          `json:"requested_by"`
+	DeviceName    string          `json:"device_name"`
+	ActionType    string          `json:"action_type"`
+	ActionName    string          `json:"action_name"`
+	Input         json.RawMessage `json:"input,omitempty"`
+	ExpectedMs    int64           `json:"expected_ms"`
+}
+
+// DeviceEventReq 调度时产生的设备事件，如设备锁的获取和释放
+type DeviceEventReq struct {
+	LabUUID    uuid.UUID             `json:"lab_uuid"`
+	DeviceName string                `json:"device_name"`
+	EventType  model.DeviceEventType `json:"event_type"`
+	EventData  json.RawMessage       `json:"event_data,omitempty"`
+	Timestamp  time.Time             `json:"timestamp"`
+}
+
+// Ack 写入成功
+type Ack struct{}
+
+// IngestServer is implemented by the history owner
+type IngestServer interface {
+	RecordExecutionStart(ctx context.Context, req *ExecutionStartReq) (*Ack, error)
+	RecordExecutionProgress(ctx context.Context, req *ExecutionProgressReq) (*Ack, error)
+	RecordExecutionComplete(ctx context.Context, req *ExecutionCompleteReq) (*Ack, error)
+	RecordActionResult(ctx context.Context, req *ActionResultReq) (*Ack, error)
+	RecordApprovalRequest(ctx context.Context, req *ApprovalRequestReq) (*Ack, error)
+	RecordDeviceEvent(ctx context.Context, req *DeviceEventReq) (*Ack, error)
+}
+
+// unaryHandler adapts a typed method of the server to a grpc method handler
+func unaryHandler[Req any](method string, call func(IngestServer, context.Context, *Req) (*Ack, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return call(srv.(IngestServer), ctx, req.(*Req))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: fullMethod(method),
+			}, handler)
+		},
+	}
+}
+
+func fullMethod(method string) string {
+	return "/" + serviceName + "/" + method
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*IngestServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryHandler("RecordExecutionStart", IngestServer.RecordExecutionStart),
+		unaryHandler("RecordExecutionProgress", IngestServer.RecordExecutionProgress),
+		unaryHandler("RecordExecutionComplete", IngestServer.RecordExecutionComplete),
+		unaryHandler("RecordActionResult", IngestServer.RecordActionResult),
+		unaryHandler("RecordApprovalRequest", IngestServer.RecordApprovalRequest),
+		unaryHandler("RecordDeviceEvent", IngestServer.RecordDeviceEvent),
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// RegisterServer registers the ingestion service on a grpc server
+func RegisterServer(s grpc.ServiceRegistrar, srv IngestServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
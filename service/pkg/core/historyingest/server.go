@@ -0,0 +1,251 @@
+package historyingest
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/errorrule"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/approval"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Server writes the history reported by the scheduler
+type Server struct {
+	history   history.HistoryRepo
+	approvals approval.ApprovalRepo
+	baseDB    repo.IDOrUUIDTranslate
+	classify  func(ctx context.Context, labID int64, message string) string
+}
+
+var _ IngestServer = (*Server)(nil)
+
+func NewServer() *Server {
+	return &Server{
+		history:   history.New(),
+		approvals: approval.New(),
+		baseDB:    repo.NewBaseDB(),
+		classify:  errorrule.Classify,
+	}
+}
+
+// NewGRPCServer creates a grpc server serving srv. When token is not empty
+// every call must carry it in the x-ingest-token header
+func NewGRPCServer(token string, srv IngestServer) *grpc.Server {
+	s := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if token != "" {
+			md, _ := metadata.FromIncomingContext(ctx)
+			values := md.Get(tokenHeader)
+			if len(values) == 0 || subtle.ConstantTimeCompare([]byte(values[0]), []byte(token)) != 1 {
+				return nil, status.Error(codes.Unauthenticated, "invalid ingest token")
+			}
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			logger.Warnf(ctx, "history ingest %s fail: %+v", info.FullMethod, err)
+			return nil, toStatus(err)
+		}
+		return resp, nil
+	}))
+	RegisterServer(s, srv)
+	return s
+}
+
+// toStatus converts the error codes of the repositories to grpc status codes
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	c := codes.Internal
+	var withMsg code.ErrCodeWithMsg
+	errCode, ok := err.(code.ErrCode)
+	if !ok && errors.As(err, &withMsg) {
+		errCode, ok = withMsg.ErrCode, true
+	}
+	if ok {
+		switch errCode {
+		case code.RecordNotFound:
+			c = codes.NotFound
+		case code.ParamErr:
+			c = codes.InvalidArgument
+		case code.HistoryVersionConflictErr:
+			c = codes.Aborted
+		}
+	}
+	return status.Error(c, err.Error())
+}
+
+// RecordExecutionStart 执行开始运行，只更新仍在排队的执行。开始时间仍为提交时间，SLA 从提交开始计算
+func (s *Server) RecordExecutionStart(ctx context.Context, req *ExecutionStartReq) (*Ack, error) {
+	return &Ack{}, history.CompareAndUpdate(ctx, s.history, req.ExecutionUUID, func(exec *model.WorkflowExecutionHistory) (map[string]any, error) {
+		if exec.Status != model.ExecutionStatusPending {
+			return nil, nil
+		}
+		return map[string]any{
+			"status":      model.ExecutionStatusRunning,
+			"steps_total": req.StepsTotal,
+			"updated_at":  time.Now(),
+		}, nil
+	})
+}
+
+// RecordExecutionProgress 累加执行的步骤进度，并发写入时计数不会互相覆盖。
+// 旧版调度器上报累计值，按版本合并且计数只增不减，迟到的进度不会覆盖其他写入方的计数
+func (s *Server) RecordExecutionProgress(ctx context.Context, req *ExecutionProgressReq) (*Ack, error) {
+	if req.CompletedDelta < 0 || req.FailedDelta < 0 || req.StepsCompleted < 0 || req.StepsFailed < 0 {
+		return nil, code.ParamErr.WithMsg("step counters must not be negative")
+	}
+	if req.CompletedDelta == 0 && req.FailedDelta == 0 && (req.StepsCompleted > 0 || req.StepsFailed > 0) {
+		return &Ack{}, history.CompareAndUpdate(ctx, s.history, req.ExecutionUUID, func(exec *model.WorkflowExecutionHistory) (map[string]any, error) {
+			updates := map[string]any{"updated_at": time.Now()}
+			history.MergeSteps(exec, updates, req.StepsCompleted, req.StepsFailed)
+			return updates, nil
+		})
+	}
+	exec, err := s.history.GetWorkflowExecutionByUUID(ctx, req.ExecutionUUID)
+	if err != nil {
+		return nil, err
+	}
+	return &Ack{}, s.history.IncrementStepCounters(ctx, exec.ID, req.CompletedDelta, req.FailedDelta)
+}
+
+// RecordExecutionComplete 记录执行的结束状态、是否违反 SLA 以及失败原因的分类，耗时由数据库按开始时间计算。
+// 按读取时的版本写入，与并发的开始和进度写入冲突时重新读取后重试
+func (s *Server) RecordExecutionComplete(ctx context.Context, req *ExecutionCompleteReq) (*Ack, error) {
+	if !finished(req.Status) {
+		return nil, code.ParamErr.WithMsgf("status %s is not finished", req.Status)
+	}
+	completedAt := req.CompletedAt
+	if completedAt.IsZero() {
+		completedAt = time.Now()
+	}
+	return &Ack{}, history.CompareAndComplete(ctx, s.history, req.ExecutionUUID, completedAt, func(exec *model.WorkflowExecutionHistory) (map[string]any, error) {
+		updates := map[string]any{"status": req.Status}
+		if breached := exec.SLABreach(req.Status, completedAt); breached != nil {
+			updates["sla_breached"] = *breached
+		}
+		if req.ErrorMessage != "" && failed(req.Status) {
+			updates["error_message"] = req.ErrorMessage
+			updates["error_category"] = s.classify(ctx, exec.LabID, req.ErrorMessage)
+		}
+		return updates, nil
+	})
+}
+
+// RecordActionResult 记录执行中一个步骤的结果，带 action_uuid 时结束审批时创建的步骤记录
+func (s *Server) RecordActionResult(ctx context.Context, req *ActionResultReq) (*Ack, error) {
+	exec, err := s.history.GetWorkflowExecutionByUUID(ctx, req.ExecutionUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	action := &model.ActionExecutionHistory{
+		WorkflowExecutionID: &exec.ID,
+		LabID:               exec.LabID,
+		DeviceUUID:          uuid.NewNil(),
+		DeviceName:          req.DeviceName,
+		ActionType:          req.ActionType,
+		ActionName:          req.ActionName,
+		Input:               []byte(req.Input),
+		Output:              []byte(req.Output),
+		Status:              req.Status,
+		DurationMs:          req.DurationMs,
+		ExpectedMs:          req.ExpectedMs,
+		TraceParent:         req.TraceParent,
+	}
+	if req.DeviceName != "" {
+		action.DeviceID, action.DeviceUUID = s.device(ctx, exec.LabID, req.DeviceName)
+	}
+	if req.ErrorMessage != "" && failed(req.Status) {
+		action.ErrorMessage = &req.ErrorMessage
+		action.ErrorCategory = s.classify(ctx, exec.LabID, req.ErrorMessage)
+	}
+	if req.ActionUUID != nil {
+		action.UUID = *req.ActionUUID
+		action.UpdatedAt = time.Now()
+		_, err := s.history.ReportAction(ctx, action)
+		return &Ack{}, err
+	}
+	return &Ack{}, s.history.CreateActionExecution(ctx, action)
+}
+
+// RecordApprovalRequest 创建等待审批的步骤记录和审批请求，设备由审批仓库按名称关联
+func (s *Server) RecordApprovalRequest(ctx context.Context, req *ApprovalRequestReq) (*Ack, error) {
+	exec, err := s.history.GetWorkflowExecutionByUUID(ctx, req.ExecutionUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	action := &model.ActionExecutionHistory{
+		BaseModel:           model.BaseModel{UUID: req.ActionUUID},
+		WorkflowExecutionID: &exec.ID,
+		LabID:               exec.LabID,
+		DeviceName:          req.DeviceName,
+		ActionType:          req.ActionType,
+		ActionName:          req.ActionName,
+		Input:               []byte(req.Input),
+		ExpectedMs:          req.ExpectedMs,
+	}
+	return &Ack{}, s.approvals.CreateApproval(ctx, action, &model.ApprovalRequest{
+		BaseModel:   model.BaseModel{UUID: req.ApprovalUUID},
+		LabID:       exec.LabID,
+		TaskUUID:    req.ExecutionUUID,
+		NodeUUID:    req.NodeUUID,
+		StepName:    req.StepName,
+		RequestedBy: req.RequestedBy,
+	})
+}
+
+// RecordDeviceEvent 记录调度时产生的设备事件
+func (s *Server) RecordDeviceEvent(ctx context.Context, req *DeviceEventReq) (*Ack, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, req.LabUUID)[req.LabUUID]
+	if labID == 0 {
+		return nil, code.RecordNotFound.WithMsgf("can not found lab uuid: %s", req.LabUUID)
+	}
+
+	timestamp := req.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	deviceID, deviceUUID := s.device(ctx, labID, req.DeviceName)
+	return &Ack{}, s.history.CreateDeviceEvent(ctx, &model.DeviceEventHistory{
+		LabID:      labID,
+		DeviceID:   deviceID,
+		DeviceUUID: deviceUUID,
+		EventType:  req.EventType,
+		EventData:  []byte(req.EventData),
+		Timestamp:  timestamp,
+	})
+}
+
+// device finds a device of a lab by name, the history keeps the name when
+// the device is not registered
+func (s *Server) device(ctx context.Context, labID int64, name string) (int64, uuid.UUID) {
+	device := &model.MaterialNode{}
+	if err := s.baseDB.GetData(ctx, device, map[string]any{
+		"lab_id": labID,
+		"name":   name,
+		"type":   model.MATERIALDEVICE,
+	}, "id", "uuid"); err != nil {
+		return 0, uuid.NewNil()
+	}
+	return device.ID, device.UUID
+}
+
+func finished(status model.ExecutionStatus) bool {
+	return status != model.ExecutionStatusPending && status != model.ExecutionStatusRunning && status != ""
+}
+
+func failed(status model.ExecutionStatus) bool {
+	return status == model.ExecutionStatusFailed || status == model.ExecutionStatusTimeout
+}
@@ -0,0 +1,532 @@
+// Package hub is the central WebSocket hub. Clients connect once, subscribe
+// to topics they are allowed to read, and receive the messages published to
+// those topics by any instance; publishers broadcast through redis so a
+// message reaches the subscribers on every instance.
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/olahol/melody"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/notify"
+	"github.com/scienceol/studio/service/pkg/core/notify/events"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+)
+
+// 客户端发送的动作，服务端推送的消息 action 为 message
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+	ActionMessage     = "message"
+)
+
+const (
+	defaultMaxConnectionsPerUser  = 5
+	defaultMaxTopicsPerConnection = 50
+	defaultMaxMessageBytes        = 4096
+	defaultSendBufferSize         = 256
+
+	connType = "hub"
+
+	resultPublished = "published"
+	resultFailed    = "failed"
+	resultDelivered = "delivered"
+	resultDropped   = "dropped"
+
+	rejectConnectionLimit = "connection_limit"
+	rejectTopicLimit      = "topic_limit"
+	rejectTopicInvalid    = "topic_invalid"
+	rejectForbidden       = "forbidden"
+)
+
+// SubscribeReq 订阅或取消订阅主题。订阅时 resume_from 为断线前收到的最后一条消息的 id，
+// 会先补发保留期内错过的消息
+type SubscribeReq struct {
+	common.WsMsgType
+	Data struct {
+		Topic      Topic  `json:"topic"`
+		ResumeFrom string `json:"resume_from,omitempty"`
+	} `json:"data"`
+}
+
+// SubscribeResp 订阅的结果，续订时 replay 为补发的情况
+type SubscribeResp struct {
+	Topic  Topic         `json:"topic"`
+	Replay *ReplayResult `json:"replay,omitempty"`
+}
+
+// ReplayResult 补发的消息数，complete 为 false 表示部分消息已超过保留期或被裁剪
+type ReplayResult struct {
+	Replayed int  `json:"replayed"`
+	Complete bool `json:"complete"`
+}
+
+// Message 推送到主题订阅者的消息，id 为续订时使用的令牌
+type Message struct {
+	ID          string          `json:"id,omitempty"`
+	Topic       Topic           `json:"topic"`
+	Event       string          `json:"event"`
+	Data        json.RawMessage `json:"data"`
+	PublishedAt time.Time       `json:"published_at"`
+}
+
+// conn is the state of a connected session
+type conn struct {
+	userID string
+	topics map[Topic]struct{}
+	// replaying holds the messages delivered while the topic is replayed
+	replaying map[Topic][]*Message
+}
+
+type Hub struct {
+	ws        *melody.Melody
+	msgCenter notify.MsgCenter
+	labStore  repo.LaboratoryRepo
+	history   history.HistoryRepo
+	baseDB    repo.IDOrUUIDTranslate
+	replay    *replayBuffer // nil 时不支持续订
+	conf      config.WSHubConfig
+
+	registryOnce sync.Once
+
+	mu      sync.RWMutex
+	conns   map[*melody.Session]*conn
+	topics  map[Topic]map[*melody.Session]struct{}
+	perUser map[string]int // 包括正在升级的连接
+}
+
+var defaultHub = sync.OnceValue(func() *Hub {
+	h := newHub(limits(config.GetStudioConfig().WSHub), events.NewEvents())
+	retention, maxLen := ReplayLimits()
+	h.replay = newReplayBuffer(redis.GetClient(), retention, maxLen)
+	return h
+})
+
+// Default returns the hub of the process
+func Default() *Hub {
+	return defaultHub()
+}
+
+// limits returns the configured hub limits with defaults for unset values
+func limits(conf config.WSHubConfig) config.WSHubConfig {
+	if conf.MaxConnectionsPerUser <= 0 {
+		conf.MaxConnectionsPerUser = defaultMaxConnectionsPerUser
+	}
+	if conf.MaxTopicsPerConnection <= 0 {
+		conf.MaxTopicsPerConnection = defaultMaxTopicsPerConnection
+	}
+	if conf.MaxMessageBytes <= 0 {
+		conf.MaxMessageBytes = defaultMaxMessageBytes
+	}
+	if conf.SendBufferSize <= 0 {
+		conf.SendBufferSize = defaultSendBufferSize
+	}
+	return conf
+}
+
+func newHub(conf config.WSHubConfig, msgCenter notify.MsgCenter) *Hub {
+	h := &Hub{
+		ws:        melody.New(),
+		msgCenter: msgCenter,
+		labStore:  environment.New(),
+		history:   history.New(),
+		baseDB:    repo.NewBaseDB(),
+		conf:      conf,
+		conns:     make(map[*melody.Session]*conn),
+		topics:    make(map[Topic]map[*melody.Session]struct{}),
+		perUser:   make(map[string]int),
+	}
+	h.ws.Config.MaxMessageSize = conf.MaxMessageBytes
+	h.ws.Config.MessageBufferSize = conf.SendBufferSize
+	h.ws.HandleConnect(h.onConnect)
+	h.ws.HandleDisconnect(h.onDisconnect)
+	h.ws.HandleMessage(h.onMessage)
+	h.ws.HandleError(func(s *melody.Session, err error) {
+		// 发送缓冲区满时 melody 丢弃消息，此时已无法得知消息的主题
+		if errors.Is(err, melody.ErrMessageBufferFull) {
+			otel.GetMetrics().RecordHubMessage(s.Request.Context(), "", resultDropped)
+		}
+	})
+	return h
+}
+
+// Connect 升级为 WebSocket 连接，直到连接关闭才返回。超过单个用户的连接数时拒绝
+func (h *Hub) Connect(ctx *gin.Context) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+	h.registryOnce.Do(func() {
+		if err := h.msgCenter.Registry(context.Background(), notify.HubPublish, h.onBroadcast); err != nil {
+			logger.Errorf(ctx, "hub registry broadcast fail: %+v", err)
+		}
+	})
+
+	// 升级前占用连接数，避免并发连接同时通过检查
+	h.mu.Lock()
+	if h.perUser[userInfo.ID] >= h.conf.MaxConnectionsPerUser {
+		h.mu.Unlock()
+		otel.GetMetrics().RecordHubRejection(ctx, rejectConnectionLimit)
+		return code.HubConnectionLimitErr
+	}
+	h.perUser[userInfo.ID]++
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.perUser[userInfo.ID]--; h.perUser[userInfo.ID] <= 0 {
+			delete(h.perUser, userInfo.ID)
+		}
+	}()
+
+	if err := h.ws.HandleRequestWithKeys(ctx.Writer, ctx.Request, map[string]any{
+		"user_id": userInfo.ID,
+	}); err != nil {
+		logger.Errorf(ctx, "hub HandleRequestWithKeys err: %+v", err)
+	}
+	return nil
+}
+
+func (h *Hub) onConnect(s *melody.Session) {
+	h.mu.Lock()
+	h.conns[s] = &conn{
+		userID:    s.MustGet("user_id").(string),
+		topics:    make(map[Topic]struct{}),
+		replaying: make(map[Topic][]*Message),
+	}
+	h.mu.Unlock()
+	otel.GetMetrics().WebSocketConnected(s.Request.Context(), connType)
+}
+
+func (h *Hub) onDisconnect(s *melody.Session) {
+	h.mu.Lock()
+	if c, ok := h.conns[s]; ok {
+		for topic := range c.topics {
+			h.removeLocked(topic, s)
+		}
+		delete(h.conns, s)
+	}
+	h.mu.Unlock()
+	otel.GetMetrics().WebSocketDisconnected(s.Request.Context(), connType)
+}
+
+func (h *Hub) onMessage(s *melody.Session, msg []byte) {
+	ctx := s.Request.Context()
+	req := &SubscribeReq{}
+	if err := json.Unmarshal(msg, req); err != nil {
+		_ = common.ReplyWSErr(s, "", req.MsgUUID, code.ParamErr.WithErr(err))
+		return
+	}
+
+	switch req.Action {
+	case ActionSubscribe:
+		replayed, err := h.subscribe(ctx, s, req.Data.Topic, req.Data.ResumeFrom)
+		if err != nil {
+			_ = common.ReplyWSErr(s, req.Action, req.MsgUUID, err)
+			return
+		}
+		resp := &SubscribeResp{Topic: req.Data.Topic}
+		if replayed != nil {
+			resp.Replay = &ReplayResult{Replayed: len(replayed.msgs), Complete: replayed.complete}
+		}
+		_ = common.ReplyWSOk(s, req.Action, req.MsgUUID, resp)
+		if replayed != nil {
+			h.finishReplay(ctx, s, req.Data.Topic, replayed.msgs)
+		}
+	case ActionUnsubscribe:
+		h.unsubscribe(s, req.Data.Topic)
+		_ = common.ReplyWSOk(s, req.Action, req.MsgUUID, req.Data)
+	default:
+		_ = common.ReplyWSErr(s, req.Action, req.MsgUUID, code.ParamErr.WithMsg("unknown action"))
+	}
+}
+
+// replayed is the result of reading the replay buffer on subscribe
+type replayed struct {
+	msgs     []*Message
+	complete bool
+}
+
+// subscribe checks the topic against the connection limit and the permission
+// of the user. Permission is only checked here, leaving a lab later does not
+// end existing subscriptions. With a resume token the messages after it are
+// read from the replay buffer, live messages are held back until
+// finishReplay writes them after the replayed ones
+func (h *Hub) subscribe(ctx context.Context, s *melody.Session, topic Topic, resumeFrom string) (*replayed, error) {
+	h.mu.RLock()
+	c, ok := h.conns[s]
+	subscribed, count := false, 0
+	if ok {
+		_, subscribed = c.topics[topic]
+		count = len(c.topics)
+	}
+	h.mu.RUnlock()
+	if !ok {
+		return nil, code.UnLogin
+	}
+	// 已订阅的主题正在接收消息，不再补发
+	if subscribed {
+		return nil, nil
+	}
+	if count >= h.conf.MaxTopicsPerConnection {
+		otel.GetMetrics().RecordHubRejection(ctx, rejectTopicLimit)
+		return nil, code.HubTopicLimitErr
+	}
+
+	p, err := parseTopic(topic)
+	if err != nil {
+		otel.GetMetrics().RecordHubRejection(ctx, rejectTopicInvalid)
+		return nil, err
+	}
+	if err := h.authorize(ctx, c.userID, p); err != nil {
+		otel.GetMetrics().RecordHubRejection(ctx, rejectForbidden)
+		return nil, err
+	}
+	resume := resumeFrom != "" && h.replay != nil
+
+	h.mu.Lock()
+	if len(c.topics) >= h.conf.MaxTopicsPerConnection {
+		h.mu.Unlock()
+		return nil, code.HubTopicLimitErr
+	}
+	c.topics[topic] = struct{}{}
+	sessions, ok := h.topics[topic]
+	if !ok {
+		sessions = make(map[*melody.Session]struct{})
+		h.topics[topic] = sessions
+	}
+	sessions[s] = struct{}{}
+	if resume {
+		c.replaying[topic] = []*Message{}
+	}
+	h.mu.Unlock()
+	if !resume {
+		return nil, nil
+	}
+
+	// 先订阅再读取，读取期间发布的消息暂存在 replaying 中，不会遗漏
+	msgs, complete, err := h.replay.since(ctx, topic, resumeFrom)
+	if err != nil {
+		h.unsubscribe(s, topic)
+		return nil, err
+	}
+	return &replayed{msgs: msgs, complete: complete}, nil
+}
+
+// finishReplay writes the replayed messages followed by the live messages
+// held back during the replay, skipping those already replayed
+func (h *Hub) finishReplay(ctx context.Context, s *melody.Session, topic Topic, msgs []*Message) {
+	last := streamID{}
+	for _, msg := range msgs {
+		h.write(ctx, s, msg)
+		if id, ok := parseStreamID(msg.ID); ok {
+			last = id
+		}
+	}
+
+	// 持锁写入暂存的消息，保证之后推送的消息排在它们后面。Write 只是放入发送缓冲区，不会阻塞
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.conns[s]
+	if !ok {
+		return
+	}
+	for _, msg := range c.replaying[topic] {
+		if id, ok := parseStreamID(msg.ID); ok && !last.less(id) {
+			continue
+		}
+		h.write(ctx, s, msg)
+	}
+	delete(c.replaying, topic)
+}
+
+func (h *Hub) unsubscribe(s *melody.Session, topic Topic) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c, ok := h.conns[s]; ok {
+		delete(c.topics, topic)
+		delete(c.replaying, topic)
+		h.removeLocked(topic, s)
+	}
+}
+
+func (h *Hub) removeLocked(topic Topic, s *melody.Session) {
+	if sessions, ok := h.topics[topic]; ok {
+		delete(sessions, s)
+		if len(sessions) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// authorize checks that the user may read the topic
+func (h *Hub) authorize(ctx context.Context, userID string, p *parsedTopic) error {
+	switch p.kind {
+	case TopicExecution:
+		exec, err := h.history.GetWorkflowExecutionByUUID(ctx, p.executionUUID)
+		if err != nil {
+			return err
+		}
+		return h.checkMember(ctx, exec.LabID, userID)
+	case TopicLabEvents:
+		return h.checkMember(ctx, p.labID, userID)
+	default:
+		if p.userID != userID {
+			return code.NoPermission
+		}
+		return nil
+	}
+}
+
+func (h *Hub) checkMember(ctx context.Context, labID int64, userID string) error {
+	count, err := h.labStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+// Publish 向所有实例上的主题订阅者推送消息，并写入补发缓冲区供断线重连的订阅者续订。
+// 失败只记录日志和指标，写入补发缓冲区失败时消息仍会推送但没有 id
+func (h *Hub) Publish(ctx context.Context, topic Topic, event string, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		logger.Errorf(ctx, "hub marshal message topic: %s, event: %s, err: %+v", topic, event, err)
+		otel.GetMetrics().RecordHubMessage(ctx, kindOf(topic), resultFailed)
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	msg := &Message{
+		Topic:       topic,
+		Event:       event,
+		Data:        raw,
+		PublishedAt: time.Now(),
+	}
+	if h.replay != nil {
+		if msg.ID, err = h.replay.append(ctx, msg); err != nil {
+			logger.Warnf(ctx, "hub append replay topic: %s, event: %s, err: %+v", topic, event, err)
+		}
+	}
+	if err := h.msgCenter.Broadcast(ctx, &notify.SendMsg{
+		Channel: notify.HubPublish,
+		Data:    msg,
+	}); err != nil {
+		logger.Warnf(ctx, "hub publish topic: %s, event: %s, err: %+v", topic, event, err)
+		otel.GetMetrics().RecordHubMessage(ctx, kindOf(topic), resultFailed)
+		return
+	}
+	otel.GetMetrics().RecordHubMessage(ctx, kindOf(topic), resultPublished)
+}
+
+// PublishLabEvent 推送实验室的事件，按 uuid 查出实验室 id 作为主题
+func (h *Hub) PublishLabEvent(ctx context.Context, labUUID uuid.UUID, event string, data any) {
+	if labUUID.IsNil() {
+		return
+	}
+	labID := h.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return
+	}
+	h.Publish(ctx, LabEventsTopic(labID), event, data)
+}
+
+// onBroadcast delivers a message published by any instance to the local subscribers
+func (h *Hub) onBroadcast(ctx context.Context, msg string) error {
+	data := &struct {
+		Data *Message `json:"data"`
+	}{}
+	if err := json.Unmarshal([]byte(msg), data); err != nil {
+		return err
+	}
+	if data.Data == nil {
+		return nil
+	}
+	h.deliver(ctx, data.Data)
+	return nil
+}
+
+// deliver writes the message to the local subscribers of its topic, the
+// subscribers still replaying the topic get it after the replay
+func (h *Hub) deliver(ctx context.Context, msg *Message) {
+	h.mu.Lock()
+	sessions := make([]*melody.Session, 0, len(h.topics[msg.Topic]))
+	for s := range h.topics[msg.Topic] {
+		if c, ok := h.conns[s]; ok {
+			if pending, ok := c.replaying[msg.Topic]; ok {
+				c.replaying[msg.Topic] = append(pending, msg)
+				continue
+			}
+		}
+		sessions = append(sessions, s)
+	}
+	h.mu.Unlock()
+	if len(sessions) == 0 {
+		return
+	}
+
+	payload, err := marshalMessage(msg)
+	if err != nil {
+		logger.Errorf(ctx, "hub marshal message topic: %s, err: %+v", msg.Topic, err)
+		return
+	}
+	kind := kindOf(msg.Topic)
+	for _, s := range sessions {
+		result := resultDelivered
+		if err := s.Write(payload); err != nil {
+			result = resultDropped
+		}
+		otel.GetMetrics().RecordHubMessage(ctx, kind, result)
+	}
+}
+
+// write sends a single message to the session
+func (h *Hub) write(ctx context.Context, s *melody.Session, msg *Message) {
+	payload, err := marshalMessage(msg)
+	if err != nil {
+		logger.Errorf(ctx, "hub marshal message topic: %s, err: %+v", msg.Topic, err)
+		return
+	}
+	result := resultDelivered
+	if err := s.Write(payload); err != nil {
+		result = resultDropped
+	}
+	otel.GetMetrics().RecordHubMessage(ctx, kindOf(msg.Topic), result)
+}
+
+func marshalMessage(msg *Message) ([]byte, error) {
+	return json.Marshal(&common.Resp{
+		Code: code.Success,
+		Data: &common.WSData[*Message]{
+			WsMsgType: common.WsMsgType{Action: ActionMessage, MsgUUID: uuid.NewV4()},
+			Data:      msg,
+		},
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// Close 关闭所有连接
+func (h *Hub) Close() error {
+	return h.ws.Close()
+}
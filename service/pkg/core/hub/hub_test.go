@@ -0,0 +1,234 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/notify"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// localCenter delivers broadcasts to the registered handler in process
+type localCenter struct {
+	handlers map[notify.Action]notify.HandleFunc
+}
+
+func (c *localCenter) Registry(_ context.Context, action notify.Action, fn notify.HandleFunc) error {
+	c.handlers[action] = fn
+	return nil
+}
+
+func (c *localCenter) Broadcast(ctx context.Context, msg *notify.SendMsg) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if fn, ok := c.handlers[msg.Channel]; ok {
+		return fn(ctx, string(data))
+	}
+	return nil
+}
+
+func (c *localCenter) Close(context.Context) error { return nil }
+
+// reply is a message sent by the hub
+type reply struct {
+	Code code.ErrCode `json:"code"`
+	Data struct {
+		Action string          `json:"action"`
+		Data   json.RawMessage `json:"data"`
+	} `json:"data"`
+}
+
+func TestParseTopic(t *testing.T) {
+	execUUID := uuid.NewV4()
+	p, err := parseTopic(ExecutionTopic(execUUID))
+	require.NoError(t, err)
+	assert.Equal(t, execUUID, p.executionUUID)
+
+	p, err = parseTopic(LabEventsTopic(7))
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), p.labID)
+	assert.Equal(t, "lab", kindOf(LabEventsTopic(7)))
+
+	p, err = parseTopic(NotificationsTopic("u1"))
+	require.NoError(t, err)
+	assert.Equal(t, "u1", p.userID)
+
+	for _, topic := range []Topic{"", "execution:bad", "lab:0:events", "lab:1", "lab:1:alerts", "notifications:", "device:1"} {
+		_, err := parseTopic(topic)
+		var withMsg code.ErrCodeWithMsg
+		require.True(t, errors.As(err, &withMsg), topic)
+		assert.Equal(t, code.HubTopicInvalidErr, withMsg.ErrCode)
+	}
+}
+
+func TestHub(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := newHub(limits(config.WSHubConfig{MaxConnectionsPerUser: 1, MaxTopicsPerConnection: 1}),
+		&localCenter{handlers: make(map[notify.Action]notify.HandleFunc)})
+	defer h.Close()
+
+	router := gin.New()
+	router.GET("/ws", func(ctx *gin.Context) {
+		ctx.Set(auth.USERKEY, &model.UserData{ID: ctx.Query("user")})
+		if err := h.Connect(ctx); err != nil {
+			common.ReplyErr(ctx, err)
+		}
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?user=u1"
+
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	send := func(action string, topic Topic) reply {
+		require.NoError(t, ws.WriteJSON(map[string]any{
+			"action":   action,
+			"msg_uuid": uuid.NewV4(),
+			"data":     map[string]any{"topic": topic},
+		}))
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+		var r reply
+		require.NoError(t, ws.ReadJSON(&r))
+		return r
+	}
+
+	// 只能订阅自己的通知
+	assert.Equal(t, code.NoPermission, send(ActionSubscribe, NotificationsTopic("u2")).Code)
+	assert.Equal(t, code.HubTopicInvalidErr, send(ActionSubscribe, "device:1").Code)
+	assert.Equal(t, code.Success, send(ActionSubscribe, NotificationsTopic("u1")).Code)
+	// 重复订阅不占用主题数，超过主题数时在检查权限前拒绝
+	assert.Equal(t, code.Success, send(ActionSubscribe, NotificationsTopic("u1")).Code)
+	assert.Equal(t, code.HubTopicLimitErr, send(ActionSubscribe, LabEventsTopic(1)).Code)
+
+	h.Publish(context.Background(), NotificationsTopic("u2"), "notification", map[string]string{"title": "other"})
+	h.Publish(context.Background(), NotificationsTopic("u1"), "notification", map[string]string{"title": "hello"})
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var r reply
+	require.NoError(t, ws.ReadJSON(&r))
+	assert.Equal(t, ActionMessage, r.Data.Action)
+	msg := &Message{}
+	require.NoError(t, json.Unmarshal(r.Data.Data, msg))
+	assert.Equal(t, NotificationsTopic("u1"), msg.Topic)
+	assert.Equal(t, "notification", msg.Event)
+	assert.JSONEq(t, `{"title":"hello"}`, string(msg.Data))
+
+	// 超过单个用户的连接数时不升级
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	var rejected common.Resp
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rejected))
+	resp.Body.Close()
+	assert.Equal(t, code.HubConnectionLimitErr, rejected.Code)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// 取消订阅后不再推送，断开后释放连接数
+	assert.Equal(t, code.Success, send(ActionUnsubscribe, NotificationsTopic("u1")).Code)
+	h.mu.RLock()
+	assert.Empty(t, h.topics)
+	h.mu.RUnlock()
+	ws.Close()
+	assert.Eventually(t, func() bool {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		return len(h.perUser) == 0 && len(h.conns) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestHubResume(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := r.NewClient(&r.Options{Addr: miniredis.RunT(t).Addr()})
+	defer client.Close()
+	h := newHub(limits(config.WSHubConfig{}), &localCenter{handlers: make(map[notify.Action]notify.HandleFunc)})
+	h.replay = newReplayBuffer(client, time.Minute, 100)
+	defer h.Close()
+
+	router := gin.New()
+	router.GET("/ws", func(ctx *gin.Context) {
+		ctx.Set(auth.USERKEY, &model.UserData{ID: "u1"})
+		_ = h.Connect(ctx)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	read := func() reply {
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+		var r reply
+		require.NoError(t, ws.ReadJSON(&r))
+		return r
+	}
+	readMessage := func() *Message {
+		r := read()
+		require.Equal(t, ActionMessage, r.Data.Action)
+		msg := &Message{}
+		require.NoError(t, json.Unmarshal(r.Data.Data, msg))
+		return msg
+	}
+
+	// 先订阅一次，收到消息 id 后断开，模拟错过的消息
+	topic := NotificationsTopic("u1")
+	require.NoError(t, ws.WriteJSON(map[string]any{"action": ActionSubscribe, "data": map[string]any{"topic": topic}}))
+	require.Equal(t, code.Success, read().Code)
+	h.Publish(context.Background(), topic, "notification", 1)
+	first := readMessage()
+	require.NotEmpty(t, first.ID)
+	require.NoError(t, ws.WriteJSON(map[string]any{"action": ActionUnsubscribe, "data": map[string]any{"topic": topic}}))
+	require.Equal(t, code.Success, read().Code)
+	h.Publish(context.Background(), topic, "notification", 2)
+	h.Publish(context.Background(), topic, "notification", 3)
+
+	require.NoError(t, ws.WriteJSON(map[string]any{
+		"action": ActionSubscribe,
+		"data":   map[string]any{"topic": topic, "resume_from": first.ID},
+	}))
+	ack := read()
+	require.Equal(t, code.Success, ack.Code)
+	resp := &SubscribeResp{}
+	require.NoError(t, json.Unmarshal(ack.Data.Data, resp))
+	require.NotNil(t, resp.Replay)
+	assert.Equal(t, 2, resp.Replay.Replayed)
+	assert.True(t, resp.Replay.Complete)
+	assert.JSONEq(t, "2", string(readMessage().Data))
+	assert.JSONEq(t, "3", string(readMessage().Data))
+
+	// 补发结束后继续推送新消息
+	h.Publish(context.Background(), topic, "notification", 4)
+	assert.JSONEq(t, "4", string(readMessage().Data))
+
+	// 令牌无效时不保留订阅
+	require.NoError(t, ws.WriteJSON(map[string]any{"action": ActionUnsubscribe, "data": map[string]any{"topic": topic}}))
+	require.Equal(t, code.Success, read().Code)
+	require.NoError(t, ws.WriteJSON(map[string]any{
+		"action": ActionSubscribe,
+		"data":   map[string]any{"topic": topic, "resume_from": "bad"},
+	}))
+	assert.Equal(t, code.HubResumeTokenErr, read().Code)
+	h.mu.RLock()
+	assert.Empty(t, h.topics)
+	h.mu.RUnlock()
+}
@@ -0,0 +1,152 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+)
+
+const (
+	defaultReplayRetention = 5 * time.Minute
+	defaultReplayMaxLen    = 1000
+
+	replayKeyPrefix = "hub:replay:"
+	replayField     = "m"
+)
+
+// ReplayLimits returns the configured replay retention and per-topic length with defaults for unset values
+func ReplayLimits() (time.Duration, int64) {
+	conf := config.GetStudioConfig().Replay
+	retention, maxLen := defaultReplayRetention, int64(defaultReplayMaxLen)
+	if conf.RetentionSecs > 0 {
+		retention = time.Duration(conf.RetentionSecs) * time.Second
+	}
+	if conf.MaxLen > 0 {
+		maxLen = conf.MaxLen
+	}
+	return retention, maxLen
+}
+
+// replayBuffer keeps the recent messages of each topic in a redis stream. The
+// id of the stream entry is the resume token of the message
+type replayBuffer struct {
+	client    *r.Client
+	retention time.Duration
+	maxLen    int64
+	now       func() time.Time
+}
+
+func newReplayBuffer(client *r.Client, retention time.Duration, maxLen int64) *replayBuffer {
+	return &replayBuffer{client: client, retention: retention, maxLen: maxLen, now: time.Now}
+}
+
+func replayKey(topic Topic) string {
+	return replayKeyPrefix + string(topic)
+}
+
+// append stores the message and returns its resume token. The stream is
+// trimmed to maxLen and expires retention after its last message
+func (b *replayBuffer) append(ctx context.Context, msg *Message) (string, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	key := replayKey(msg.Topic)
+	var add *r.StringCmd
+	if _, err := b.client.TxPipelined(ctx, func(pipe r.Pipeliner) error {
+		add = pipe.XAdd(ctx, &r.XAddArgs{
+			Stream: key,
+			MaxLen: b.maxLen,
+			Approx: true,
+			Values: map[string]any{replayField: data},
+		})
+		pipe.Expire(ctx, key, b.retention)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return add.Val(), nil
+}
+
+// since returns the messages of the topic published after the token and
+// within the retention, oldest first. complete is false when messages after
+// the token were trimmed or are older than the retention
+func (b *replayBuffer) since(ctx context.Context, topic Topic, token string) ([]*Message, bool, error) {
+	after, ok := parseStreamID(token)
+	if !ok {
+		return nil, false, code.HubResumeTokenErr.WithMsgf("invalid resume token %s", token)
+	}
+	key := replayKey(topic)
+	oldest := streamID{ms: b.now().Add(-b.retention).UnixMilli()}
+
+	start := "(" + token
+	complete := true
+	if after.less(oldest) {
+		start, complete = oldest.String(), false
+	}
+	entries, err := b.client.XRangeN(ctx, key, start, "+", b.maxLen).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if complete {
+		// 裁剪从最旧的消息开始，令牌对应的消息仍在时之后的消息都未丢失
+		first, err := b.client.XRangeN(ctx, key, "-", "+", 1).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if len(first) > 0 {
+			id, _ := parseStreamID(first[0].ID)
+			complete = !after.less(id)
+		}
+	}
+
+	msgs := make([]*Message, 0, len(entries))
+	for _, entry := range entries {
+		raw, _ := entry.Values[replayField].(string)
+		msg := &Message{}
+		if err := json.Unmarshal([]byte(raw), msg); err != nil {
+			logger.Warnf(ctx, "hub decode replay message topic: %s, id: %s, err: %+v", topic, entry.ID, err)
+			continue
+		}
+		msg.ID = entry.ID
+		msgs = append(msgs, msg)
+	}
+	return msgs, complete, nil
+}
+
+// streamID is a parsed redis stream entry id
+type streamID struct {
+	ms  int64
+	seq int64
+}
+
+func parseStreamID(id string) (streamID, bool) {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return streamID{}, false
+	}
+	ms, err := strconv.ParseInt(msPart, 10, 64)
+	if err != nil || ms < 0 {
+		return streamID{}, false
+	}
+	seq, err := strconv.ParseInt(seqPart, 10, 64)
+	if err != nil || seq < 0 {
+		return streamID{}, false
+	}
+	return streamID{ms: ms, seq: seq}, true
+}
+
+func (s streamID) less(o streamID) bool {
+	return s.ms < o.ms || (s.ms == o.ms && s.seq < o.seq)
+}
+
+func (s streamID) String() string {
+	return strconv.FormatInt(s.ms, 10) + "-" + strconv.FormatInt(s.seq, 10)
+}
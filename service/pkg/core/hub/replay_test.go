@@ -0,0 +1,66 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayBuffer(t *testing.T) {
+	ctx := context.Background()
+	client := r.NewClient(&r.Options{Addr: miniredis.RunT(t).Addr()})
+	defer client.Close()
+	b := newReplayBuffer(client, time.Minute, 3)
+	topic := NotificationsTopic("u1")
+
+	ids := make([]string, 0, 5)
+	for _, event := range []string{"a", "b", "c"} {
+		id, err := b.append(ctx, &Message{Topic: topic, Event: event, Data: []byte(`{}`)})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	msgs, complete, err := b.since(ctx, topic, ids[0])
+	require.NoError(t, err)
+	assert.True(t, complete)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "b", msgs[0].Event)
+	assert.Equal(t, ids[1], msgs[0].ID)
+	assert.Equal(t, "c", msgs[1].Event)
+
+	msgs, complete, err = b.since(ctx, topic, ids[2])
+	require.NoError(t, err)
+	assert.True(t, complete)
+	assert.Empty(t, msgs)
+
+	// 超过长度上限后最旧的消息被裁剪，续订不完整
+	for _, event := range []string{"d", "e"} {
+		id, err := b.append(ctx, &Message{Topic: topic, Event: event, Data: []byte(`{}`)})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	msgs, complete, err = b.since(ctx, topic, ids[0])
+	require.NoError(t, err)
+	assert.False(t, complete)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, "c", msgs[0].Event)
+
+	// 令牌早于保留期时只返回保留期内的消息
+	b.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	msgs, complete, err = b.since(ctx, topic, ids[2])
+	require.NoError(t, err)
+	assert.False(t, complete)
+	assert.Empty(t, msgs)
+
+	_, _, err = b.since(ctx, topic, "bad")
+	var withMsg code.ErrCodeWithMsg
+	require.True(t, errors.As(err, &withMsg))
+	assert.Equal(t, code.HubResumeTokenErr, withMsg.ErrCode)
+}
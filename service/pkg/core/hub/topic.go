@@ -0,0 +1,75 @@
+package hub
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// TopicKind 主题类型，决定订阅时的权限检查
+type TopicKind string
+
+const (
+	TopicExecution     TopicKind = "execution"     // execution:<uuid>，执行所在实验室的成员可订阅
+	TopicLabEvents     TopicKind = "lab"           // lab:<id>:events，实验室成员可订阅
+	TopicNotifications TopicKind = "notifications" // notifications:<user_id>，仅本人可订阅
+)
+
+// Topic 订阅的主题
+type Topic string
+
+// ExecutionTopic 工作流执行的进度
+func ExecutionTopic(executionUUID uuid.UUID) Topic {
+	return Topic(string(TopicExecution) + ":" + executionUUID.String())
+}
+
+// LabEventsTopic 实验室的事件总线事件
+func LabEventsTopic(labID int64) Topic {
+	return Topic(string(TopicLabEvents) + ":" + strconv.FormatInt(labID, 10) + ":events")
+}
+
+// NotificationsTopic 用户的站内通知
+func NotificationsTopic(userID string) Topic {
+	return Topic(string(TopicNotifications) + ":" + userID)
+}
+
+// parsedTopic is a topic split into its kind and target
+type parsedTopic struct {
+	kind          TopicKind
+	executionUUID uuid.UUID
+	labID         int64
+	userID        string
+}
+
+// parseTopic validates the topic and returns its target
+func parseTopic(topic Topic) (*parsedTopic, error) {
+	parts := strings.Split(string(topic), ":")
+	p := &parsedTopic{kind: TopicKind(parts[0])}
+	switch {
+	case p.kind == TopicExecution && len(parts) == 2:
+		id, err := uuid.FromString(parts[1])
+		if err != nil || id.IsNil() {
+			return nil, code.HubTopicInvalidErr.WithMsgf("invalid execution uuid in topic %s", topic)
+		}
+		p.executionUUID = id
+	case p.kind == TopicLabEvents && len(parts) == 3 && parts[2] == "events":
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || id <= 0 {
+			return nil, code.HubTopicInvalidErr.WithMsgf("invalid lab id in topic %s", topic)
+		}
+		p.labID = id
+	case p.kind == TopicNotifications && len(parts) == 2 && parts[1] != "":
+		p.userID = parts[1]
+	default:
+		return nil, code.HubTopicInvalidErr.WithMsgf("unknown topic %s", topic)
+	}
+	return p, nil
+}
+
+// kindOf returns the kind of a topic for metrics, topics are validated on subscribe
+func kindOf(topic Topic) string {
+	kind, _, _ := strings.Cut(string(topic), ":")
+	return kind
+}
@@ -0,0 +1,243 @@
+// Package impersonation lets admins mint time-limited sessions acting as a
+// lab member to reproduce user-visible issues. Sessions are bound to one lab,
+// and every impersonated request is written to the audit log.
+package impersonation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/casdoor"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/impersonation"
+)
+
+const (
+	defaultTTL = 30 * time.Minute
+	maxTTL     = 2 * time.Hour
+)
+
+// StartReq 创建模拟会话请求
+type StartReq struct {
+	UserID     string    `json:"user_id" binding:"required"`
+	LabUUID    uuid.UUID `json:"lab_uuid" binding:"required"`
+	Reason     string    `json:"reason" binding:"required"` // 工单号或问题描述，写入审计日志
+	TTLMinutes int       `json:"ttl_minutes"`               // 为 0 时使用默认有效期，不超过最大有效期
+}
+
+// StartResp 模拟会话，令牌只在创建时返回
+type StartResp struct {
+	*model.ImpersonationSession
+	Token         string `json:"token"`
+	Authorization string `json:"authorization"` // 模拟请求的 Authorization 请求头
+}
+
+// ListReq 模拟会话列表请求
+type ListReq struct {
+	common.PageReq
+	Active bool `form:"active"` // 只返回未过期且未撤销的会话
+}
+
+// AuditReq 审计日志列表请求
+type AuditReq struct {
+	common.PageReq
+	SessionUUID    uuid.UUID `form:"session_uuid"`
+	ImpersonatorID string    `form:"impersonator_id"`
+	LabUUID        uuid.UUID `form:"lab_uuid"`
+}
+
+type Service struct {
+	store    impersonation.ImpersonationRepo
+	envStore repo.LaboratoryRepo
+	account  repo.Account
+	baseDB   repo.IDOrUUIDTranslate
+}
+
+func New() *Service {
+	return &Service{
+		store:    impersonation.New(),
+		envStore: environment.New(),
+		account:  casdoor.NewCasClient(),
+		baseDB:   repo.NewBaseDB(),
+	}
+}
+
+// Start 创建模拟会话，被模拟的用户必须是实验室成员且不是管理员
+func (s *Service) Start(ctx context.Context, req *StartReq) (*StartResp, error) {
+	admin, err := s.admin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID == admin.ID || auth.IsAdmin(req.UserID) {
+		return nil, code.ImpersonationTargetErr
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, req.LabUUID)[req.LabUUID]
+	if labID == 0 {
+		return nil, code.LabNotFound
+	}
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": req.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, code.ImpersonationTargetErr
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	data := &model.ImpersonationSession{
+		ImpersonatorID: admin.ID,
+		UserID:         req.UserID,
+		LabID:          labID,
+		LabUUID:        req.LabUUID,
+		Reason:         req.Reason,
+		TokenHash:      auth.HashImpersonationToken(token),
+		ExpiresAt:      time.Now().Add(sessionTTL(req.TTLMinutes)),
+	}
+	// 用户信息只用于展示，获取失败时只保留用户 ID
+	if user, err := s.account.GetUserInfo(ctx, req.UserID); err != nil {
+		logger.Warnf(ctx, "impersonation get user info fail user: %s, err: %+v", req.UserID, err)
+	} else if user != nil {
+		data.UserName = user.Name
+		data.UserOwner = user.Owner
+	}
+	if err := s.store.CreateSession(ctx, data); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, model.AuditImpersonationStart, data, req.Reason)
+
+	return &StartResp{
+		ImpersonationSession: data,
+		Token:                token,
+		Authorization:        string(auth.AuthTypeImpersonate) + " " + token,
+	}, nil
+}
+
+// Revoke 提前结束模拟会话
+func (s *Service) Revoke(ctx context.Context, sessionUUID uuid.UUID) error {
+	admin, err := s.admin(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := s.store.GetSessionByUUID(ctx, sessionUUID)
+	if err != nil {
+		return err
+	}
+	revoked, err := s.store.RevokeSession(ctx, data.ID, admin.ID)
+	if err != nil || !revoked {
+		return err
+	}
+	s.audit(ctx, model.AuditImpersonationRevoke, data, "revoked by "+admin.ID)
+	return nil
+}
+
+// List 获取模拟会话列表
+func (s *Service) List(ctx context.Context, req *ListReq) (*common.ListResp[*model.ImpersonationSession], error) {
+	if _, err := s.admin(ctx); err != nil {
+		return nil, err
+	}
+	req.Normalize()
+
+	datas, total, err := s.store.ListSessions(ctx, req.Active, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// ListAudit 获取审计日志
+func (s *Service) ListAudit(ctx context.Context, req *AuditReq) (*common.ListResp[*model.AuditLog], error) {
+	if _, err := s.admin(ctx); err != nil {
+		return nil, err
+	}
+	req.Normalize()
+
+	filter := &impersonation.AuditFilter{ImpersonatorID: req.ImpersonatorID}
+	if !req.SessionUUID.IsNil() {
+		data, err := s.store.GetSessionByUUID(ctx, req.SessionUUID)
+		if err != nil {
+			return nil, err
+		}
+		filter.SessionID = data.ID
+	}
+	if !req.LabUUID.IsNil() {
+		filter.LabID = s.baseDB.UUID2ID(ctx, &model.Laboratory{}, req.LabUUID)[req.LabUUID]
+		if filter.LabID == 0 {
+			return nil, code.LabNotFound
+		}
+	}
+
+	datas, total, err := s.store.ListAuditLogs(ctx, filter, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// admin 返回当前管理员，模拟会话不能管理模拟会话
+func (s *Service) admin(ctx context.Context) (*model.UserData, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if auth.GetImpersonation(ctx) != nil {
+		return nil, code.ImpersonationForbiddenErr
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return nil, code.NoPermission
+	}
+	return userInfo, nil
+}
+
+func (s *Service) audit(ctx context.Context, action model.AuditAction, data *model.ImpersonationSession, detail string) {
+	if err := s.store.CreateAuditLog(context.WithoutCancel(ctx), &model.AuditLog{
+		Action:         action,
+		UserID:         data.UserID,
+		ImpersonatorID: data.ImpersonatorID,
+		SessionID:      data.ID,
+		LabID:          data.LabID,
+		Detail:         detail,
+	}); err != nil {
+		logger.Errorf(ctx, "impersonation audit fail action: %s, session: %s, err: %+v", action, data.UUID, err)
+	}
+}
+
+// sessionTTL 返回会话有效期，不超过配置的最大有效期
+func sessionTTL(minutes int) time.Duration {
+	conf := config.GetStudioConfig().Security.Impersonation
+	ttl, limit := defaultTTL, maxTTL
+	if conf.DefaultTTLMinutes > 0 {
+		ttl = time.Duration(conf.DefaultTTLMinutes) * time.Minute
+	}
+	if conf.MaxTTLMinutes > 0 {
+		limit = time.Duration(conf.MaxTTLMinutes) * time.Minute
+	}
+	if minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	return min(ttl, limit)
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,324 @@
+// Package ingest replays the action results edge agents buffered during
+// network outages. Each agent numbers the records of its stream; records keep
+// their client-assigned UUIDs and original timestamps, duplicates are
+// acknowledged without being written again, and the high-water mark tells the
+// agent which records it can drop and where to resume after reconnecting.
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/errorrule"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/ingest"
+	"gorm.io/datatypes"
+)
+
+const (
+	maxBatch     = 500
+	maxStreamLen = 255
+	maxMissing   = 1000
+	// 允许 edge 时钟比服务器快的时间
+	clockSkew = 5 * time.Minute
+)
+
+// RecordReq 缓存的动作执行结果，uuid 由 edge 生成，seq 在流内递增
+type RecordReq struct {
+	Seq                   int64                 `json:"seq" binding:"required,min=1"`
+	UUID                  uuid.UUID             `json:"uuid"`
+	WorkflowExecutionUUID *uuid.UUID            `json:"workflow_execution_uuid"`
+	DeviceName            string                `json:"device_name"`
+	ActionType            string                `json:"action_type"`
+	ActionName            string                `json:"action_name"`
+	Input                 datatypes.JSON        `json:"input" swaggertype:"object"`
+	Output                datatypes.JSON        `json:"output" swaggertype:"object"`
+	Status                model.ExecutionStatus `json:"status"`      // success | failed | timeout | cancelled
+	StartedAt             time.Time             `json:"started_at"`  // 动作开始的原始时间
+	FinishedAt            *time.Time            `json:"finished_at"` // 动作结束的原始时间
+	DurationMs            int64                 `json:"duration_ms"`
+	ErrorMessage          *string               `json:"error_message"`
+	Metadata              datatypes.JSON        `json:"metadata" swaggertype:"object"`
+}
+
+// IngestReq edge 重放一批缓存的记录
+type IngestReq struct {
+	Stream  string       `json:"stream" binding:"required"`
+	Records []*RecordReq `json:"records" binding:"required,dive"`
+}
+
+// IngestResp 每条记录的确认和流的高水位
+type IngestResp struct {
+	Stream        string             `json:"stream"`
+	HighWaterMark int64              `json:"high_water_mark"` // 不大于该序号的记录都已写入，edge 可以丢弃
+	MaxSeq        int64              `json:"max_seq"`
+	Acks          []*model.IngestAck `json:"acks"`
+}
+
+// AckResp 流的确认状态，edge 重连后从高水位之后继续重放
+type AckResp struct {
+	Stream        string  `json:"stream"`
+	HighWaterMark int64   `json:"high_water_mark"`
+	MaxSeq        int64   `json:"max_seq"`
+	Missing       []int64 `json:"missing"` // 高水位和最大序号之间尚未收到的序号，最多 1000 个
+}
+
+type Service struct {
+	store    ingest.IngestRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    ingest.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// Ingest 写入 edge 重放的记录：已写入的序号或 UUID 确认为重复，无效的记录确认为拒绝，
+// 二者都占用序号使高水位可以前进
+func (s *Service) Ingest(ctx context.Context, req *IngestReq) (*IngestResp, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+	if len(req.Stream) > maxStreamLen {
+		return nil, code.ParamErr.WithMsgf("stream must not exceed %d characters", maxStreamLen)
+	}
+	if len(req.Records) == 0 || len(req.Records) > maxBatch {
+		return nil, code.ParamErr.WithMsgf("records must contain 1 to %d items", maxBatch)
+	}
+
+	workflowIDs, err := s.workflowIDs(ctx, labUser.LabID, req.Records)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.devices(ctx, labUser.LabID, req.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rejected := make(map[int64]string)
+	records := make([]*model.IngestRecord, 0, len(req.Records))
+	for _, r := range req.Records {
+		action, err := newAction(labUser.LabID, r, workflowIDs, devices, now)
+		if err != nil {
+			rejected[r.Seq] = err.Error()
+			records = append(records, &model.IngestRecord{Seq: r.Seq})
+			continue
+		}
+		if action.ErrorMessage != nil && *action.ErrorMessage != "" {
+			action.ErrorCategory = errorrule.Classify(ctx, labUser.LabID, *action.ErrorMessage)
+		}
+		records = append(records, &model.IngestRecord{Seq: r.Seq, Action: action})
+	}
+
+	result, err := s.store.Ingest(ctx, labUser.LabID, req.Stream, records)
+	if err != nil {
+		return nil, err
+	}
+
+	acks := make([]*model.IngestAck, 0, len(req.Records))
+	for _, r := range req.Records {
+		ack := &model.IngestAck{Seq: r.Seq, Status: model.IngestAccepted}
+		switch {
+		case result.Duplicates[r.Seq] && !result.Accepted[r.Seq]:
+			ack.Status = model.IngestDuplicate
+		case rejected[r.Seq] != "":
+			ack.Status = model.IngestRejected
+			ack.Error = rejected[r.Seq]
+		}
+		acks = append(acks, ack)
+	}
+	return &IngestResp{
+		Stream:        req.Stream,
+		HighWaterMark: result.Stream.HighWaterMark,
+		MaxSeq:        result.Stream.MaxSeq,
+		Acks:          acks,
+	}, nil
+}
+
+// Ack 获取流的高水位和缺失的序号，没有写入过的流高水位为 0
+func (s *Service) Ack(ctx context.Context, stream string) (*AckResp, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+
+	resp := &AckResp{Stream: stream, Missing: []int64{}}
+	st, err := s.store.GetStream(ctx, labUser.LabID, stream)
+	if err == code.RecordNotFound {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	received, err := s.store.ListReceivedSeqs(ctx, st.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp.HighWaterMark = st.HighWaterMark
+	resp.MaxSeq = st.MaxSeq
+	resp.Missing = model.MissingSeqs(st.HighWaterMark, st.MaxSeq, received, maxMissing)
+	return resp, nil
+}
+
+// ListStreams 获取实验室 edge 重放流的确认状态
+func (s *Service) ListStreams(ctx context.Context, labUUID uuid.UUID) ([]*model.EdgeIngestStream, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListStreams(ctx, labID)
+}
+
+// newAction 校验记录并转换为动作执行记录，保留原始时间
+func newAction(labID int64, r *RecordReq, workflowIDs map[uuid.UUID]int64, devices map[string]*model.MaterialNode, now time.Time) (*model.ActionExecutionHistory, error) {
+	if r.UUID.IsNil() {
+		return nil, fmt.Errorf("uuid is required")
+	}
+	if r.DeviceName == "" || r.ActionType == "" || r.ActionName == "" {
+		return nil, fmt.Errorf("device_name, action_type and action_name are required")
+	}
+	switch r.Status {
+	case model.ExecutionStatusSuccess, model.ExecutionStatusFailed,
+		model.ExecutionStatusTimeout, model.ExecutionStatusCancelled:
+	default:
+		return nil, fmt.Errorf("status must be a finished status, got %q", r.Status)
+	}
+	if r.StartedAt.IsZero() || r.StartedAt.After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("started_at is missing or in the future")
+	}
+	if r.FinishedAt != nil && r.FinishedAt.Before(r.StartedAt) {
+		return nil, fmt.Errorf("finished_at is before started_at")
+	}
+	if r.DurationMs < 0 {
+		return nil, fmt.Errorf("duration_ms must not be negative")
+	}
+
+	var workflowExecID *int64
+	if r.WorkflowExecutionUUID != nil {
+		id, ok := workflowIDs[*r.WorkflowExecutionUUID]
+		if !ok {
+			return nil, fmt.Errorf("workflow execution %s not found", r.WorkflowExecutionUUID)
+		}
+		workflowExecID = &id
+	}
+
+	durationMs := r.DurationMs
+	finishedAt := r.StartedAt.Add(time.Duration(durationMs) * time.Millisecond)
+	if r.FinishedAt != nil {
+		finishedAt = *r.FinishedAt
+		if durationMs == 0 {
+			durationMs = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+		}
+	}
+
+	action := &model.ActionExecutionHistory{
+		BaseModel: model.BaseModel{
+			UUID:      r.UUID,
+			CreatedAt: r.StartedAt,
+			UpdatedAt: finishedAt,
+		},
+		WorkflowExecutionID: workflowExecID,
+		LabID:               labID,
+		DeviceUUID:          uuid.NewNil(),
+		DeviceName:          r.DeviceName,
+		ActionType:          r.ActionType,
+		ActionName:          r.ActionName,
+		Input:               r.Input,
+		Output:              r.Output,
+		Status:              r.Status,
+		DurationMs:          durationMs,
+		ErrorMessage:        r.ErrorMessage,
+		Metadata:            r.Metadata,
+	}
+	if node, ok := devices[r.DeviceName]; ok {
+		action.DeviceID = node.ID
+		action.DeviceUUID = node.UUID
+	}
+	return action, nil
+}
+
+// workflowIDs 解析记录所属的工作流执行，只解析本实验室的执行
+func (s *Service) workflowIDs(ctx context.Context, labID int64, records []*RecordReq) (map[uuid.UUID]int64, error) {
+	uuids := make([]uuid.UUID, 0)
+	for _, r := range records {
+		if r.WorkflowExecutionUUID != nil {
+			uuids = append(uuids, *r.WorkflowExecutionUUID)
+		}
+	}
+	ret := make(map[uuid.UUID]int64, len(uuids))
+	if len(uuids) == 0 {
+		return ret, nil
+	}
+
+	execs := make([]*model.WorkflowExecutionHistory, 0, len(uuids))
+	if err := s.baseDB.FindDatas(ctx, &execs, map[string]any{
+		"lab_id": labID,
+		"uuid":   uuids,
+	}, "id", "uuid"); err != nil {
+		return nil, err
+	}
+	for _, exec := range execs {
+		ret[exec.UUID] = exec.ID
+	}
+	return ret, nil
+}
+
+// devices 按名称解析记录的设备节点，未找到的设备不关联节点
+func (s *Service) devices(ctx context.Context, labID int64, records []*RecordReq) (map[string]*model.MaterialNode, error) {
+	names := make([]string, 0, len(records))
+	for _, r := range records {
+		if r.DeviceName != "" {
+			names = append(names, r.DeviceName)
+		}
+	}
+	ret := make(map[string]*model.MaterialNode, len(names))
+	if len(names) == 0 {
+		return ret, nil
+	}
+
+	nodes := make([]*model.MaterialNode, 0, len(names))
+	if err := s.baseDB.FindDatas(ctx, &nodes, map[string]any{
+		"lab_id": labID,
+		"name":   names,
+		"type":   model.MATERIALDEVICE,
+	}, "id", "uuid", "name"); err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		ret[node.Name] = node
+	}
+	return ret, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
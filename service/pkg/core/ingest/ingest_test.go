@@ -0,0 +1,72 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAction(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-time.Hour)
+	finished := started.Add(1500 * time.Millisecond)
+	execUUID := uuid.NewV4()
+	deviceUUID := uuid.NewV4()
+	devices := map[string]*model.MaterialNode{
+		"robot": {BaseModel: model.BaseModel{ID: 7, UUID: deviceUUID}, Name: "robot"},
+	}
+	workflows := map[uuid.UUID]int64{execUUID: 42}
+
+	valid := func() *RecordReq {
+		return &RecordReq{
+			Seq:                   1,
+			UUID:                  uuid.NewV4(),
+			WorkflowExecutionUUID: &execUUID,
+			DeviceName:            "robot",
+			ActionType:            "move",
+			ActionName:            "pick",
+			Status:                model.ExecutionStatusSuccess,
+			StartedAt:             started,
+			FinishedAt:            &finished,
+		}
+	}
+
+	r := valid()
+	action, err := newAction(3, r, workflows, devices, now)
+	assert.NoError(t, err)
+	assert.Equal(t, r.UUID, action.UUID)
+	assert.Equal(t, started, action.CreatedAt)
+	assert.Equal(t, finished, action.UpdatedAt)
+	assert.Equal(t, int64(1500), action.DurationMs)
+	assert.Equal(t, int64(42), *action.WorkflowExecutionID)
+	assert.Equal(t, int64(7), action.DeviceID)
+	assert.Equal(t, deviceUUID, action.DeviceUUID)
+
+	r = valid()
+	r.DeviceName = "unknown"
+	r.FinishedAt = nil
+	r.DurationMs = 200
+	action, err = newAction(3, r, workflows, devices, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), action.DeviceID)
+	assert.Equal(t, started.Add(200*time.Millisecond), action.UpdatedAt)
+
+	invalid := []func(r *RecordReq){
+		func(r *RecordReq) { r.UUID = uuid.NewNil() },
+		func(r *RecordReq) { r.ActionName = "" },
+		func(r *RecordReq) { r.Status = model.ExecutionStatusRunning },
+		func(r *RecordReq) { r.StartedAt = time.Time{} },
+		func(r *RecordReq) { r.StartedAt = now.Add(time.Hour) },
+		func(r *RecordReq) { before := started.Add(-time.Second); r.FinishedAt = &before },
+		func(r *RecordReq) { other := uuid.NewV4(); r.WorkflowExecutionUUID = &other },
+	}
+	for _, mutate := range invalid {
+		r = valid()
+		mutate(r)
+		_, err = newAction(3, r, workflows, devices, now)
+		assert.Error(t, err)
+	}
+}
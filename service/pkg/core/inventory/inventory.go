@@ -0,0 +1,295 @@
+// Package inventory registers sample, reagent and consumable lots of a lab and
+// records which lots and how much of them each execution consumed.
+package inventory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/inventory"
+	"github.com/scienceol/studio/service/pkg/repo/lims"
+)
+
+const (
+	maxUsageItems = 100
+	maxPageSize   = 100
+)
+
+// RegisterReq 登记物料批次
+type RegisterReq struct {
+	LabUUID   uuid.UUID          `json:"lab_uuid" binding:"required"`
+	Name      string             `json:"name" binding:"required"`
+	LotNumber string             `json:"lot_number" binding:"required"`
+	Kind      model.MaterialKind `json:"kind" binding:"required"` // sample | reagent | consumable
+	Unit      string             `json:"unit" binding:"required"`
+	Quantity  float64            `json:"quantity" binding:"gt=0"`
+	ExpiresAt *time.Time         `json:"expires_at"`
+}
+
+// ListReq 查询物料批次
+type ListReq struct {
+	LabUUID  uuid.UUID          `form:"lab_uuid" binding:"required"`
+	Kind     model.MaterialKind `form:"kind"`
+	Keyword  string             `form:"keyword"`
+	Page     int                `form:"page,default=1"`
+	PageSize int                `form:"page_size,default=20"`
+}
+
+// UsageItem 一个批次的消耗量
+type UsageItem struct {
+	MaterialUUID        uuid.UUID  `json:"material_uuid" binding:"required"`
+	Quantity            float64    `json:"quantity" binding:"gt=0"`
+	ActionExecutionUUID *uuid.UUID `json:"action_execution_uuid"` // 消耗该物料的步骤
+	Note                string     `json:"note"`
+}
+
+// UsageReq 记录一次工作流执行的物料消耗
+type UsageReq struct {
+	LabUUID               uuid.UUID    `json:"lab_uuid"` // 用户上报时必填，edge 上报时使用 edge 所属实验室
+	WorkflowExecutionUUID uuid.UUID    `json:"workflow_execution_uuid" binding:"required"`
+	Items                 []*UsageItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type Service struct {
+	store    inventory.InventoryRepo
+	history  history.HistoryRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	lims     lims.LIMS
+}
+
+func New() *Service {
+	return &Service{
+		store:    inventory.New(),
+		history:  history.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		lims:     lims.New(),
+	}
+}
+
+// Register 登记物料批次，同一实验室批号唯一
+func (s *Service) Register(ctx context.Context, req *RegisterReq) (*model.Material, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Kind.Valid() {
+		return nil, code.MaterialInvalidErr.WithMsgf("unknown kind %s", req.Kind)
+	}
+	name := strings.TrimSpace(req.Name)
+	lot := strings.TrimSpace(req.LotNumber)
+	if name == "" || lot == "" {
+		return nil, code.MaterialInvalidErr.WithMsg("name and lot_number required")
+	}
+
+	count, err := s.baseDB.Count(ctx, &model.Material{}, map[string]any{
+		"lab_id":     labID,
+		"lot_number": lot,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, code.MaterialInvalidErr.WithMsgf("lot %s already registered", lot)
+	}
+
+	data := &model.Material{
+		LabID:     labID,
+		Name:      name,
+		LotNumber: lot,
+		Kind:      req.Kind,
+		Unit:      req.Unit,
+		Quantity:  req.Quantity,
+		Remaining: req.Quantity,
+		ExpiresAt: req.ExpiresAt,
+		CreatorID: userInfo.ID,
+		Source:    model.MaterialSourceManual,
+	}
+	if err := s.store.CreateMaterial(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// List 分页查询物料批次
+func (s *Service) List(ctx context.Context, req *ListReq) (*common.ListResp[*model.Material], error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > maxPageSize {
+		req.PageSize = 20
+	}
+
+	datas, total, err := s.store.ListMaterials(ctx, &inventory.MaterialQuery{
+		LabID:    labID,
+		Kind:     req.Kind,
+		Keyword:  strings.TrimSpace(req.Keyword),
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// Get 查询物料批次
+func (s *Service) Get(ctx context.Context, materialUUID uuid.UUID) (*model.Material, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetMaterialByUUID(ctx, materialUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMemberByID(ctx, userInfo.ID, data.LabID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListUsages 查询物料批次的消耗记录
+func (s *Service) ListUsages(ctx context.Context, materialUUID uuid.UUID) ([]*model.MaterialUsage, error) {
+	data, err := s.Get(ctx, materialUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListUsagesByMaterial(ctx, data.ID)
+}
+
+// RecordUsage 记录工作流执行消耗的物料并扣减剩余数量，任一批次不足时整体失败
+func (s *Service) RecordUsage(ctx context.Context, req *UsageReq) ([]*model.MaterialUsage, error) {
+	labID, recordedBy, err := s.labScope(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Items) > maxUsageItems {
+		return nil, code.ParamErr.WithMsgf("items must not exceed %d", maxUsageItems)
+	}
+
+	exec, err := s.history.GetWorkflowExecutionByUUID(ctx, req.WorkflowExecutionUUID)
+	if err != nil {
+		return nil, err
+	}
+	if exec.LabID != labID {
+		return nil, code.NoPermission
+	}
+
+	materials := make(map[uuid.UUID]*model.Material)
+	usages := make([]*model.MaterialUsage, 0, len(req.Items))
+	for _, item := range req.Items {
+		material, ok := materials[item.MaterialUUID]
+		if !ok {
+			material, err = s.store.GetMaterialByUUID(ctx, item.MaterialUUID)
+			if err != nil {
+				return nil, err
+			}
+			if material.LabID != labID {
+				return nil, code.NoPermission
+			}
+			materials[item.MaterialUUID] = material
+		}
+
+		usage := &model.MaterialUsage{
+			LabID:               labID,
+			MaterialID:          material.ID,
+			MaterialUUID:        material.UUID,
+			WorkflowExecutionID: exec.ID,
+			Quantity:            item.Quantity,
+			Note:                item.Note,
+			RecordedBy:          recordedBy,
+		}
+		if item.ActionExecutionUUID != nil {
+			action := &model.ActionExecutionHistory{}
+			if err := s.baseDB.GetData(ctx, action, map[string]any{
+				"uuid":                  *item.ActionExecutionUUID,
+				"workflow_execution_id": exec.ID,
+			}, "id", "uuid"); err != nil {
+				return nil, code.RecordNotFound.WithMsg("action execution not found in workflow execution")
+			}
+			usage.ActionExecutionID = &action.ID
+			usage.ActionExecutionUUID = &action.UUID
+		}
+		usages = append(usages, usage)
+	}
+
+	// 按批次顺序扣减，避免并发上报时互相等待行锁
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].MaterialID < usages[j].MaterialID
+	})
+	if err := s.store.RecordUsages(ctx, usages); err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+// labScope 返回请求所属实验室与记录人，edge 请求使用 edge 所属实验室
+func (s *Service) labScope(ctx context.Context, labUUID uuid.UUID) (int64, string, error) {
+	if labUser := auth.GetLabUser(ctx); labUser != nil {
+		return labUser.LabID, "", nil
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, "", code.UnLogin
+	}
+	if labUUID.IsNil() {
+		return 0, "", code.ParamErr.WithMsg("lab_uuid required")
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, labUUID)
+	if err != nil {
+		return 0, "", err
+	}
+	return labID, userInfo.ID, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	if err := s.checkMemberByID(ctx, userID, labID); err != nil {
+		return 0, err
+	}
+	return labID, nil
+}
+
+func (s *Service) checkMemberByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
@@ -0,0 +1,458 @@
+package inventory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/lims"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	defaultSyncInterval = 30 * time.Second
+	// 超过该时间仍未结束的同步视为已中断，不再阻止新的同步
+	syncRunTimeout   = 10 * time.Minute
+	syncRunRetention = 7 * 24 * time.Hour
+	syncStatusRuns   = 20
+
+	// 冲突字段
+	conflictFieldInvalid       = "invalid"
+	conflictFieldDuplicate     = "duplicate"
+	conflictFieldLotNumber     = "lot_number"
+	conflictFieldRemaining     = "remaining"
+	conflictFieldMissingRemote = "missing_remote"
+
+	floatEpsilon = 1e-9
+)
+
+// SyncStatusReq 查询实验室库存同步状态
+type SyncStatusReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// SyncStatusResp 实验室库存同步状态
+type SyncStatusResp struct {
+	Enabled         bool                     `json:"enabled"`
+	IntervalSeconds int                      `json:"interval_seconds"`
+	Running         bool                     `json:"running"`
+	Runs            []*model.MaterialSyncRun `json:"runs"` // 最近的同步记录，按开始时间倒序
+}
+
+// SyncTriggerReq 手动触发实验室库存同步
+type SyncTriggerReq struct {
+	LabUUID uuid.UUID `json:"lab_uuid" binding:"required"`
+}
+
+// ConflictListReq 查询同步冲突
+type ConflictListReq struct {
+	LabUUID         uuid.UUID `form:"lab_uuid" binding:"required"`
+	IncludeResolved bool      `form:"include_resolved"`
+}
+
+// syncPlan 一次同步需要写入的变更
+type syncPlan struct {
+	creates   []*model.Material
+	updates   []*model.Material
+	conflicts []*model.MaterialSyncConflict
+	changed   int // 字段发生变化的已有批次数
+}
+
+// SyncStatus 查询实验室库存同步状态
+func (s *Service) SyncStatus(ctx context.Context, req *SyncStatusReq) (*SyncStatusResp, error) {
+	labID, err := s.currentMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := s.store.ListSyncRuns(ctx, labID, syncStatusRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	running := false
+	for _, run := range runs {
+		if run.Status == model.MaterialSyncRunning && time.Since(run.StartedAt) < syncRunTimeout {
+			running = true
+			break
+		}
+	}
+
+	return &SyncStatusResp{
+		Enabled:         s.lims.Enabled(),
+		IntervalSeconds: int(syncInterval().Seconds()),
+		Running:         running,
+		Runs:            runs,
+	}, nil
+}
+
+// TriggerSync 手动触发实验室库存同步，同步在后台执行，返回本次同步记录
+func (s *Service) TriggerSync(ctx context.Context, req *SyncTriggerReq) (*model.MaterialSyncRun, error) {
+	labID, err := s.currentMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.lims.Enabled() {
+		return nil, code.MaterialSyncDisabledErr
+	}
+
+	run, err := s.startRun(ctx, labID, model.JobTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+
+	syncCtx := context.WithoutCancel(ctx)
+	utils.SafelyGo(func() {
+		s.runSync(syncCtx, run, req.LabUUID)
+	}, func(err error) {
+		logger.Errorf(syncCtx, "material sync lab %d panic: %+v", labID, err)
+	})
+	return run, nil
+}
+
+// ListConflicts 查询实验室库存同步冲突，默认只返回未解决的冲突
+func (s *Service) ListConflicts(ctx context.Context, req *ConflictListReq) ([]*model.MaterialSyncConflict, error) {
+	labID, err := s.currentMember(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListSyncConflicts(ctx, labID, req.IncludeResolved)
+}
+
+// SyncAll 依次同步所有实验室的库存，单个实验室失败不影响其他实验室
+func (s *Service) SyncAll(ctx context.Context) error {
+	if !s.lims.Enabled() {
+		return nil
+	}
+
+	if _, err := s.store.DeleteSyncRunsBefore(ctx, time.Now().Add(-syncRunRetention)); err != nil {
+		logger.Warnf(ctx, "prune material sync runs err: %+v", err)
+	}
+
+	labs, err := s.store.ListSyncLabs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, lab := range labs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		run, err := s.startRun(ctx, lab.ID, model.JobTriggerSchedule)
+		if err != nil {
+			if err != code.MaterialSyncRunningErr {
+				logger.Errorf(ctx, "start material sync lab %d err: %+v", lab.ID, err)
+			}
+			continue
+		}
+		s.runSync(ctx, run, lab.UUID)
+	}
+	return nil
+}
+
+// startRun 创建同步记录，实验室已有进行中的同步时返回 MaterialSyncRunningErr
+func (s *Service) startRun(ctx context.Context, labID int64, trigger model.JobTrigger) (*model.MaterialSyncRun, error) {
+	now := time.Now()
+	running, err := s.store.GetRunningSyncRun(ctx, labID, now.Add(-syncRunTimeout))
+	if err != nil && err != code.RecordNotFound {
+		return nil, err
+	}
+	if running != nil {
+		return nil, code.MaterialSyncRunningErr
+	}
+
+	run := &model.MaterialSyncRun{
+		LabID:     labID,
+		Trigger:   trigger,
+		StartedAt: now,
+	}
+	if err := s.store.CreateSyncRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// runSync 拉取 LIMS 库存并与本地批次对账，结果写入同步记录
+func (s *Service) runSync(ctx context.Context, run *model.MaterialSyncRun, labUUID uuid.UUID) {
+	if err := s.syncLab(ctx, run, labUUID); err != nil {
+		run.Status = model.MaterialSyncFailed
+		run.Error = err.Error()
+		logger.Errorf(ctx, "material sync lab %d err: %+v", run.LabID, err)
+	} else {
+		run.Status = model.MaterialSyncSucceeded
+	}
+
+	if err := s.store.FinishSyncRun(context.WithoutCancel(ctx), run); err != nil {
+		logger.Errorf(ctx, "finish material sync run %d err: %+v", run.ID, err)
+	}
+}
+
+func (s *Service) syncLab(ctx context.Context, run *model.MaterialSyncRun, labUUID uuid.UUID) error {
+	items, err := s.lims.ListInventory(ctx, labUUID)
+	if err != nil {
+		return err
+	}
+	locals, err := s.store.ListLabMaterials(ctx, run.LabID)
+	if err != nil {
+		return err
+	}
+	used, err := s.store.ListUsedSinceSync(ctx, run.LabID)
+	if err != nil {
+		return err
+	}
+
+	plan := reconcile(run, locals, used, items, time.Now())
+	if err := s.store.ApplySync(ctx, run, plan.creates, plan.updates, plan.conflicts); err != nil {
+		return err
+	}
+
+	run.Fetched = len(items)
+	run.Created = len(plan.creates)
+	run.Updated = plan.changed
+	run.Conflicts = len(plan.conflicts)
+	return nil
+}
+
+// reconcile 对比 LIMS 与本地批次：
+//   - 按 external_id 匹配，未匹配时按批号关联尚未关联 LIMS 的本地批次
+//   - 剩余数量不一致且本地在上次同步后有消耗记录时保留本地值并记录冲突，否则以 LIMS 为准
+//   - 数据不合法、重复或批号已被其他 LIMS 批次占用的条目跳过并记录冲突
+//   - 来自 LIMS 但本次未返回的本地批次保留并记录冲突
+func reconcile(run *model.MaterialSyncRun, locals []*model.Material, used map[int64]bool, items []*lims.Item, now time.Time) *syncPlan {
+	byExternal := make(map[string]*model.Material, len(locals))
+	byLot := make(map[string]*model.Material, len(locals))
+	for _, m := range locals {
+		if m.ExternalID != "" {
+			byExternal[m.ExternalID] = m
+		}
+		byLot[m.LotNumber] = m
+	}
+
+	plan := &syncPlan{}
+	conflicts := make(map[string]*model.MaterialSyncConflict)
+	addConflict := func(c *model.MaterialSyncConflict) {
+		c.LabID = run.LabID
+		c.RunID = run.ID
+		conflicts[c.ExternalID+"\x00"+c.Field] = c
+	}
+
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		externalID := strings.TrimSpace(item.ExternalID)
+		lot := strings.TrimSpace(item.LotNumber)
+		if reason := invalidItem(item); reason != "" {
+			addConflict(&model.MaterialSyncConflict{
+				ExternalID:  externalID,
+				Field:       conflictFieldInvalid,
+				LotNumber:   lot,
+				RemoteValue: reason,
+				Resolution:  model.MaterialSyncSkipped,
+			})
+			continue
+		}
+		if seen[externalID] {
+			addConflict(&model.MaterialSyncConflict{
+				ExternalID:  externalID,
+				Field:       conflictFieldDuplicate,
+				LotNumber:   lot,
+				RemoteValue: lot,
+				Resolution:  model.MaterialSyncSkipped,
+			})
+			continue
+		}
+		seen[externalID] = true
+
+		local := byExternal[externalID]
+		if local == nil {
+			local = byLot[lot]
+		}
+		// 批号已属于其他 LIMS 批次
+		if owner := byLot[lot]; owner != nil && owner != local ||
+			local != nil && local.ExternalID != "" && local.ExternalID != externalID {
+			if owner == nil {
+				owner = local
+			}
+			addConflict(&model.MaterialSyncConflict{
+				ExternalID:  externalID,
+				Field:       conflictFieldLotNumber,
+				MaterialID:  &owner.ID,
+				LotNumber:   lot,
+				LocalValue:  owner.ExternalID,
+				RemoteValue: externalID,
+				Resolution:  model.MaterialSyncSkipped,
+			})
+			continue
+		}
+
+		if local == nil {
+			created := &model.Material{
+				LabID:      run.LabID,
+				Name:       strings.TrimSpace(item.Name),
+				LotNumber:  lot,
+				Kind:       model.MaterialKind(item.Kind),
+				Unit:       strings.TrimSpace(item.Unit),
+				Quantity:   item.Quantity,
+				Remaining:  item.Remaining,
+				ExpiresAt:  item.ExpiresAt,
+				Source:     model.MaterialSourceLIMS,
+				ExternalID: externalID,
+				SyncedAt:   &now,
+			}
+			byExternal[externalID] = created
+			byLot[lot] = created
+			plan.creates = append(plan.creates, created)
+			continue
+		}
+
+		next := *local
+		next.Name = strings.TrimSpace(item.Name)
+		next.LotNumber = lot
+		next.Kind = model.MaterialKind(item.Kind)
+		next.Unit = strings.TrimSpace(item.Unit)
+		next.Quantity = item.Quantity
+		next.ExpiresAt = item.ExpiresAt
+		next.Source = model.MaterialSourceLIMS
+		next.ExternalID = externalID
+		next.SyncedAt = &now
+		if !floatEqual(local.Remaining, item.Remaining) {
+			if used[local.ID] {
+				// 本地消耗尚未反映到 LIMS，保留本地值，且不推进同步时间以便下次继续比对
+				next.SyncedAt = local.SyncedAt
+				addConflict(&model.MaterialSyncConflict{
+					ExternalID:  externalID,
+					Field:       conflictFieldRemaining,
+					MaterialID:  &local.ID,
+					LotNumber:   lot,
+					LocalValue:  formatFloat(local.Remaining),
+					RemoteValue: formatFloat(item.Remaining),
+					Resolution:  model.MaterialSyncKeptLocal,
+				})
+			} else {
+				next.Remaining = item.Remaining
+			}
+		}
+		if materialChanged(local, &next) {
+			plan.changed++
+		}
+		if local.LotNumber != lot {
+			delete(byLot, local.LotNumber)
+		}
+		byExternal[externalID] = &next
+		byLot[lot] = &next
+		plan.updates = append(plan.updates, &next)
+	}
+
+	for _, m := range locals {
+		if m.Source != model.MaterialSourceLIMS || m.ExternalID == "" || seen[m.ExternalID] {
+			continue
+		}
+		addConflict(&model.MaterialSyncConflict{
+			ExternalID: m.ExternalID,
+			Field:      conflictFieldMissingRemote,
+			MaterialID: &m.ID,
+			LotNumber:  m.LotNumber,
+			LocalValue: formatFloat(m.Remaining),
+			Resolution: model.MaterialSyncKeptLocal,
+		})
+	}
+
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		plan.conflicts = append(plan.conflicts, conflicts[key])
+	}
+	return plan
+}
+
+// invalidItem 返回 LIMS 条目不合法的原因，合法时返回空
+func invalidItem(item *lims.Item) string {
+	switch {
+	case strings.TrimSpace(item.ExternalID) == "":
+		return "external_id required"
+	case strings.TrimSpace(item.Name) == "":
+		return "name required"
+	case strings.TrimSpace(item.LotNumber) == "":
+		return "lot_number required"
+	case strings.TrimSpace(item.Unit) == "":
+		return "unit required"
+	case !model.MaterialKind(item.Kind).Valid():
+		return "unknown kind " + item.Kind
+	case item.Quantity < 0 || item.Remaining < 0:
+		return "quantity and remaining must not be negative"
+	}
+	return ""
+}
+
+func materialChanged(a, b *model.Material) bool {
+	return a.Name != b.Name ||
+		a.LotNumber != b.LotNumber ||
+		a.Kind != b.Kind ||
+		a.Unit != b.Unit ||
+		!floatEqual(a.Quantity, b.Quantity) ||
+		!floatEqual(a.Remaining, b.Remaining) ||
+		!timeEqual(a.ExpiresAt, b.ExpiresAt) ||
+		a.Source != b.Source ||
+		a.ExternalID != b.ExternalID
+}
+
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) < floatEpsilon
+}
+
+func timeEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func syncInterval() time.Duration {
+	if sec := config.GetStudioConfig().Material.SyncIntervalSeconds; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultSyncInterval
+}
+
+func (s *Service) currentMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+	return s.checkMember(ctx, userInfo.ID, labUUID)
+}
+
+// RegisterSyncJob 注册 LIMS 库存同步后台任务，未启用 LIMS 时不注册
+func RegisterSyncJob() error {
+	s := New()
+	if !s.lims.Enabled() {
+		return nil
+	}
+	return jobs.Register(&jobs.Definition{
+		Name:         "material_inventory_sync",
+		Description:  "按 sync_interval_seconds 从 LIMS 同步实验室物料库存",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     syncInterval().String(),
+		Timeout:      syncRunTimeout,
+		Run:          s.SyncAll,
+	})
+}
@@ -0,0 +1,73 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/lims"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcile(t *testing.T) {
+	synced := time.Now().Add(-time.Hour)
+	now := time.Now()
+	run := &model.MaterialSyncRun{BaseModel: model.BaseModel{ID: 9}, LabID: 1}
+
+	locals := []*model.Material{
+		{BaseModel: model.BaseModel{ID: 1}, LabID: 1, Name: "buffer", LotNumber: "L1", Kind: model.MaterialKindReagent, Unit: "mL",
+			Quantity: 100, Remaining: 80, Source: model.MaterialSourceLIMS, ExternalID: "E1", SyncedAt: &synced},
+		{BaseModel: model.BaseModel{ID: 2}, LabID: 1, Name: "tips", LotNumber: "L2", Kind: model.MaterialKindConsumable, Unit: "pcs",
+			Quantity: 96, Remaining: 90, Source: model.MaterialSourceLIMS, ExternalID: "E2", SyncedAt: &synced},
+		{BaseModel: model.BaseModel{ID: 3}, LabID: 1, Name: "serum", LotNumber: "L3", Kind: model.MaterialKindSample, Unit: "mL",
+			Quantity: 10, Remaining: 10, Source: model.MaterialSourceManual},
+		{BaseModel: model.BaseModel{ID: 4}, LabID: 1, Name: "gone", LotNumber: "L4", Kind: model.MaterialKindReagent, Unit: "g",
+			Quantity: 5, Remaining: 5, Source: model.MaterialSourceLIMS, ExternalID: "E4", SyncedAt: &synced},
+	}
+	items := []*lims.Item{
+		// 本地无消耗，以 LIMS 为准
+		{ExternalID: "E1", Name: "buffer", LotNumber: "L1", Kind: "reagent", Unit: "mL", Quantity: 100, Remaining: 70},
+		// 本地有消耗，保留本地
+		{ExternalID: "E2", Name: "tips", LotNumber: "L2", Kind: "consumable", Unit: "pcs", Quantity: 96, Remaining: 96},
+		// 按批号关联手动登记的批次
+		{ExternalID: "E3", Name: "serum", LotNumber: "L3", Kind: "sample", Unit: "mL", Quantity: 10, Remaining: 8},
+		{ExternalID: "E5", Name: "new", LotNumber: "L5", Kind: "reagent", Unit: "g", Quantity: 1, Remaining: 1},
+		{ExternalID: "E6", Name: "clash", LotNumber: "L1", Kind: "reagent", Unit: "g", Quantity: 1, Remaining: 1},
+		{ExternalID: "E7", Name: "bad", LotNumber: "L7", Kind: "unknown", Unit: "g", Quantity: 1, Remaining: 1},
+		{ExternalID: "E5", Name: "new", LotNumber: "L5", Kind: "reagent", Unit: "g", Quantity: 1, Remaining: 1},
+	}
+
+	plan := reconcile(run, locals, map[int64]bool{2: true}, items, now)
+
+	if assert.Len(t, plan.creates, 1) {
+		assert.Equal(t, "E5", plan.creates[0].ExternalID)
+		assert.Equal(t, model.MaterialSourceLIMS, plan.creates[0].Source)
+	}
+
+	updates := make(map[int64]*model.Material)
+	for _, m := range plan.updates {
+		updates[m.ID] = m
+	}
+	assert.Len(t, updates, 3)
+	assert.Equal(t, 70.0, updates[1].Remaining)
+	assert.Equal(t, now, *updates[1].SyncedAt)
+	assert.Equal(t, 90.0, updates[2].Remaining)
+	assert.Equal(t, synced, *updates[2].SyncedAt)
+	assert.Equal(t, "E3", updates[3].ExternalID)
+	assert.Equal(t, model.MaterialSourceLIMS, updates[3].Source)
+	assert.Equal(t, 8.0, updates[3].Remaining)
+	assert.Equal(t, 2, plan.changed)
+
+	fields := make(map[string]model.MaterialSyncResolution)
+	for _, c := range plan.conflicts {
+		assert.Equal(t, int64(9), c.RunID)
+		fields[c.ExternalID+"/"+c.Field] = c.Resolution
+	}
+	assert.Equal(t, map[string]model.MaterialSyncResolution{
+		"E2/remaining":      model.MaterialSyncKeptLocal,
+		"E4/missing_remote": model.MaterialSyncKeptLocal,
+		"E5/duplicate":      model.MaterialSyncSkipped,
+		"E6/lot_number":     model.MaterialSyncSkipped,
+		"E7/invalid":        model.MaterialSyncSkipped,
+	}, fields)
+}
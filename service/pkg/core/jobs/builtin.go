@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/job"
+)
+
+const day = 24 * time.Hour
+
+// RegisterBuiltin 注册服务自带的清理和维护任务
+func RegisterBuiltin() error {
+	conf := config.Global().Job
+	var errs []error
+
+	if conf.RunRetentionDays > 0 {
+		store := job.New()
+		errs = append(errs, Register(&Definition{
+			Name:         "background_job_run_cleanup",
+			Description:  "清理过期的后台任务运行记录",
+			ScheduleType: model.JobScheduleCron,
+			Schedule:     "15 3 * * *",
+			Timeout:      10 * time.Minute,
+			MaxRetries:   2,
+			RetryBackoff: time.Minute,
+			Run: func(ctx context.Context) error {
+				count, err := store.DeleteRunsBefore(ctx, time.Now().Add(-time.Duration(conf.RunRetentionDays)*day))
+				if err != nil {
+					return err
+				}
+				logger.Infof(ctx, "background job runs cleaned count: %d", count)
+				return nil
+			},
+		}))
+	}
+
+	if conf.HistoryRetentionDays > 0 {
+		store := history.New()
+		errs = append(errs, Register(&Definition{
+			Name:         "execution_history_cleanup",
+			Description:  "清理过期的工作流、动作与设备事件历史",
+			ScheduleType: model.JobScheduleCron,
+			Schedule:     "45 3 * * *",
+			Timeout:      30 * time.Minute,
+			MaxRetries:   2,
+			RetryBackoff: 5 * time.Minute,
+			Run: func(ctx context.Context) error {
+				count, err := store.CleanupOldRecords(ctx, time.Now().Add(-time.Duration(conf.HistoryRetentionDays)*day))
+				if err != nil {
+					return err
+				}
+				logger.Infof(ctx, "execution history cleaned count: %d", count)
+				return nil
+			},
+		}))
+	}
+
+	// 设备事件表分区后，提前创建后续月份的分区
+	errs = append(errs, Register(&Definition{
+		Name:         "device_event_partition_maintenance",
+		Description:  "创建设备事件后续月份的分区",
+		ScheduleType: model.JobScheduleCron,
+		Schedule:     "30 2 * * *",
+		Timeout:      10 * time.Minute,
+		MaxRetries:   2,
+		RetryBackoff: 5 * time.Minute,
+		Run:          migrate.EnsureEventPartitions,
+	}))
+
+	return errors.Join(errs...)
+}
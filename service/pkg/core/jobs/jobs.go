@@ -0,0 +1,291 @@
+// Package jobs runs persistent background jobs on the elected leader replica
+// with cron, interval or one-off schedules, per-run timeout and retries.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/schedule/leader"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/job"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const tickInterval = time.Second
+
+// Func 任务执行函数，ctx 在超时或失去主身份时取消
+type Func func(ctx context.Context) error
+
+// Definition 代码中注册的任务定义，启动时同步到数据库
+type Definition struct {
+	Name         string
+	Description  string
+	ScheduleType model.JobScheduleType
+	Schedule     string
+	Timeout      time.Duration // 单次尝试超时，0 表示不限制
+	MaxRetries   int           // 失败后的重试次数
+	RetryBackoff time.Duration // 第 n 次重试前等待 n * RetryBackoff
+	Run          Func
+
+	schedule Schedule
+}
+
+func (d *Definition) toModel() *model.BackgroundJob {
+	return &model.BackgroundJob{
+		Name:            d.Name,
+		Description:     d.Description,
+		ScheduleType:    d.ScheduleType,
+		Schedule:        d.Schedule,
+		TimeoutSec:      int(d.Timeout / time.Second),
+		MaxRetries:      d.MaxRetries,
+		RetryBackoffSec: int(d.RetryBackoff / time.Second),
+	}
+}
+
+// Manager 管理任务注册与调度
+type Manager struct {
+	store job.JobRepo
+
+	mu      sync.Mutex
+	defs    map[string]*Definition
+	running map[string]bool
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		store:   job.New(),
+		defs:    make(map[string]*Definition),
+		running: make(map[string]bool),
+	}
+}
+
+var defaultManager = NewManager()
+
+// Default 全局任务管理器
+func Default() *Manager {
+	return defaultManager
+}
+
+// Register 注册任务到全局任务管理器
+func Register(def *Definition) error {
+	return defaultManager.Register(def)
+}
+
+// Register 校验并注册任务，需在 Start 之前调用
+func (m *Manager) Register(def *Definition) error {
+	if def.Name == "" || def.Run == nil {
+		return code.JobScheduleInvalidErr.WithMsg("job name and run func are required")
+	}
+	schedule, err := ParseSchedule(def.ScheduleType, def.Schedule)
+	if err != nil {
+		return err
+	}
+	def.schedule = schedule
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.defs[def.Name]; ok {
+		return code.JobAlreadyRegisteredErr.WithMsg(def.Name)
+	}
+	m.defs[def.Name] = def
+	return nil
+}
+
+// Registered 任务是否在当前进程注册
+func (m *Manager) Registered(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.defs[name]
+	return ok
+}
+
+// Start 参与选主，成为主的副本负责调度所有任务
+func (m *Manager) Start(ctx context.Context) {
+	leader.NewElector("background_jobs").Run(ctx, m.loop)
+}
+
+func (m *Manager) loop(ctx context.Context) {
+	if err := m.syncDefinitions(ctx); err != nil {
+		logger.Errorf(ctx, "sync background job definitions fail: %+v", err)
+	}
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.tick(ctx)
+		}
+	}
+}
+
+// syncDefinitions 写入任务定义，调度变化或尚未调度时重新计算下一次执行时间
+func (m *Manager) syncDefinitions(ctx context.Context) error {
+	m.mu.Lock()
+	defs := make([]*Definition, 0, len(m.defs))
+	for _, def := range m.defs {
+		defs = append(defs, def)
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, def := range defs {
+		old, err := m.store.GetJobByName(ctx, def.Name)
+		if err != nil && err != code.RecordNotFound {
+			errs = append(errs, err)
+			continue
+		}
+
+		saved, err := m.store.SaveDefinition(ctx, def.toModel())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		changed := old == nil || old.ScheduleType != def.ScheduleType || old.Schedule != def.Schedule
+		// 调度未变化时保留原有进度，已执行过的一次性任务不再调度
+		if !changed && (saved.NextRunAt != nil || saved.LastRunAt != nil) {
+			continue
+		}
+
+		var next *time.Time
+		if at, ok := def.schedule.Next(time.Now()); ok {
+			next = &at
+		}
+		if err := m.store.SetNextRun(ctx, def.Name, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) tick(ctx context.Context) {
+	due, err := m.store.ListDueJobs(ctx, time.Now())
+	if err != nil {
+		return
+	}
+
+	for _, data := range due {
+		m.mu.Lock()
+		def, ok := m.defs[data.Name]
+		busy := m.running[data.Name]
+		m.mu.Unlock()
+		// 未在当前版本注册的历史任务或上一次仍在运行的任务跳过
+		if !ok || busy {
+			continue
+		}
+
+		var next *time.Time
+		if at, ok := def.schedule.Next(time.Now()); ok {
+			next = &at
+		}
+		claimed, err := m.store.ClaimJob(ctx, data, next)
+		if err != nil || !claimed {
+			continue
+		}
+
+		trigger := model.JobTriggerSchedule
+		if data.TriggerRequested {
+			trigger = model.JobTriggerManual
+		}
+
+		m.mu.Lock()
+		m.running[data.Name] = true
+		m.mu.Unlock()
+		utils.SafelyGo(func() {
+			defer func() {
+				m.mu.Lock()
+				delete(m.running, def.Name)
+				m.mu.Unlock()
+			}()
+			m.execute(ctx, def, data, trigger)
+		}, func(err error) {
+			logger.Errorf(ctx, "background job panic name: %s, err: %+v", def.Name, err)
+		})
+	}
+}
+
+// execute 执行一次任务并记录运行历史，失败按退避重试
+func (m *Manager) execute(ctx context.Context, def *Definition, data *model.BackgroundJob, trigger model.JobTrigger) {
+	run := &model.BackgroundJobRun{
+		JobID:     data.ID,
+		JobName:   def.Name,
+		Trigger:   trigger,
+		Status:    model.JobRunStatusRunning,
+		Instance:  leader.InstanceID(),
+		StartedAt: time.Now(),
+	}
+	if err := m.store.CreateRun(ctx, run); err != nil {
+		return
+	}
+
+	var err error
+	for attempt := 0; attempt <= def.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(attempt) * def.RetryBackoff):
+			}
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+
+		run.Attempts = attempt + 1
+		if err = m.attempt(ctx, def); err == nil {
+			break
+		}
+		logger.Warnf(ctx, "background job fail name: %s, attempt: %d, err: %+v", def.Name, run.Attempts, err)
+	}
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	switch {
+	case err == nil:
+		run.Status = model.JobRunStatusSuccess
+	case errors.Is(err, context.DeadlineExceeded):
+		run.Status = model.JobRunStatusTimeout
+		run.Error = err.Error()
+	default:
+		run.Status = model.JobRunStatusFailed
+		run.Error = err.Error()
+	}
+
+	otel.GetMetrics().RecordBackgroundJobRun(ctx, def.Name, string(run.Status), finished.Sub(run.StartedAt).Seconds())
+	// 失去主身份时 ctx 已取消，仍需写入结果
+	if err := m.store.FinishRun(context.WithoutCancel(ctx), run); err != nil {
+		logger.Errorf(ctx, "finish background job run fail name: %s, err: %+v", def.Name, err)
+	}
+}
+
+func (m *Manager) attempt(ctx context.Context, def *Definition) (err error) {
+	runCtx := ctx
+	if def.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, def.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	err = def.Run(runCtx)
+	if err == nil && runCtx.Err() != nil {
+		err = runCtx.Err()
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+package jobs
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// Schedule 计算任务下一次执行时间，没有后续执行时返回 false
+type Schedule interface {
+	Next(after time.Time) (time.Time, bool)
+}
+
+// ParseSchedule 解析 cron(5 段)、interval(Go duration) 与 once(RFC3339) 三种调度
+func ParseSchedule(typ model.JobScheduleType, spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch typ {
+	case model.JobScheduleCron:
+		return parseCron(spec)
+	case model.JobScheduleInterval:
+		d, err := time.ParseDuration(spec)
+		if err != nil {
+			return nil, code.JobScheduleInvalidErr.WithErr(err)
+		}
+		if d < time.Second {
+			return nil, code.JobScheduleInvalidErr.WithMsgf("interval must be at least 1s, got %s", spec)
+		}
+		return intervalSchedule(d), nil
+	case model.JobScheduleOnce:
+		at, err := time.Parse(time.RFC3339, spec)
+		if err != nil {
+			return nil, code.JobScheduleInvalidErr.WithErr(err)
+		}
+		return onceSchedule(at), nil
+	default:
+		return nil, code.JobScheduleInvalidErr.WithMsgf("unknown schedule type: %s", typ)
+	}
+}
+
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(after time.Time) (time.Time, bool) {
+	return after.Add(time.Duration(s)), true
+}
+
+type onceSchedule time.Time
+
+func (s onceSchedule) Next(after time.Time) (time.Time, bool) {
+	at := time.Time(s)
+	if !at.After(after) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// cronSchedule 每个字段用位图表示允许的取值
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日与星期同时受限时任意一个满足即可，与标准 cron 一致
+	domStar, dowStar bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week
+}
+
+func parseCron(spec string) (Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, code.JobScheduleInvalidErr.WithMsgf("cron needs 5 fields, got %d", len(parts))
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = b
+	}
+
+	return &cronSchedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			s, err := strconv.Atoi(item[idx+1:])
+			if err != nil || s <= 0 {
+				return 0, code.JobScheduleInvalidErr.WithMsgf("invalid cron step: %s", item)
+			}
+			rangePart, step = item[:idx], s
+		}
+
+		lo, hi := f.min, f.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, code.JobScheduleInvalidErr.WithMsgf("invalid cron value: %s", item)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, code.JobScheduleInvalidErr.WithMsgf("invalid cron value: %s", item)
+				}
+			} else if step > 1 {
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, code.JobScheduleInvalidErr.WithMsgf("cron value out of range: %s", item)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// maxCronSearch 限制搜索范围，避免 2 月 30 日这类永远不会命中的表达式死循环
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+func (s *cronSchedule) Next(after time.Time) (time.Time, bool) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(maxCronSearch)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParse(t *testing.T, typ model.JobScheduleType, spec string) Schedule {
+	s, err := ParseSchedule(typ, spec)
+	assert.NoError(t, err)
+	return s
+}
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC) // Wednesday
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 15, 0, 0, time.UTC)},
+		{"15 3 * * *", time.Date(2024, 2, 1, 3, 15, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 1, 31, 13, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1,5", time.Date(2024, 2, 2, 8, 30, 0, 0, time.UTC)},
+		// 日与星期同时限制时满足其一即可
+		{"0 0 1 * 4", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		next, ok := mustParse(t, model.JobScheduleCron, c.spec).Next(base)
+		assert.True(t, ok, c.spec)
+		assert.Equal(t, c.want, next, c.spec)
+	}
+
+	_, ok := mustParse(t, model.JobScheduleCron, "0 0 30 2 *").Next(base)
+	assert.False(t, ok)
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, c := range []struct {
+		typ  model.JobScheduleType
+		spec string
+	}{
+		{model.JobScheduleCron, "* * * *"},
+		{model.JobScheduleCron, "60 * * * *"},
+		{model.JobScheduleCron, "*/0 * * * *"},
+		{model.JobScheduleCron, "5-1 * * * *"},
+		{model.JobScheduleInterval, "10"},
+		{model.JobScheduleInterval, "10ms"},
+		{model.JobScheduleOnce, "tomorrow"},
+		{"weekly", "* * * * *"},
+	} {
+		_, err := ParseSchedule(c.typ, c.spec)
+		assert.Error(t, err, c.spec)
+	}
+}
+
+func TestIntervalAndOnce(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	next, ok := mustParse(t, model.JobScheduleInterval, "90s").Next(base)
+	assert.True(t, ok)
+	assert.Equal(t, base.Add(90*time.Second), next)
+
+	once := mustParse(t, model.JobScheduleOnce, "2024-01-02T00:00:00Z")
+	next, ok = once.Next(base)
+	assert.True(t, ok)
+	assert.Equal(t, base.Add(24*time.Hour), next)
+
+	_, ok = once.Next(next)
+	assert.False(t, ok)
+}
@@ -0,0 +1,176 @@
+package labexport
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	pageSize     = 1000
+	manifestName = "manifest.json"
+)
+
+// manifest 描述归档内容，位于归档根目录
+type manifest struct {
+	ExportUUID uuid.UUID       `json:"export_uuid"`
+	LabUUID    uuid.UUID       `json:"lab_uuid"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Tables     []manifestTable `json:"tables"`
+}
+
+type manifestTable struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Rows int64  `json:"rows"`
+}
+
+// run 导出实验室数据，结果记录在导出记录中
+func (s *Service) run(ctx context.Context, data *model.LabExport) {
+	data.Status = model.LabExportRunning
+	if err := s.store.UpdateExport(ctx, data, "status"); err != nil {
+		return
+	}
+
+	if err := s.export(ctx, data); err != nil {
+		logger.Errorf(ctx, "lab export %s fail: %+v", data.UUID, err)
+		data.Status = model.LabExportFailed
+		data.Error = err.Error()
+		_ = s.store.UpdateExport(ctx, data, "status", "error")
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(expireAfter())
+	data.Status = model.LabExportCompleted
+	data.Progress = 100
+	data.CompletedAt = &now
+	data.ExpiresAt = &expiresAt
+	_ = s.store.UpdateExport(ctx, data,
+		"status", "progress", "tables", "rows", "file_name", "size", "storage_key", "completed_at", "expires_at")
+}
+
+// export 将实验室数据写入临时 zip 文件后上传到存储
+func (s *Service) export(ctx context.Context, data *model.LabExport) error {
+	f, err := os.CreateTemp("", "lab-export-*.zip")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	labUUID := s.envStore.ID2UUID(ctx, &model.Laboratory{}, data.LabID)[data.LabID]
+	m := &manifest{ExportUUID: data.UUID, LabUUID: labUUID, CreatedAt: time.Now()}
+	zw := zip.NewWriter(f)
+	for i, table := range model.LabDataTables {
+		rows, err := s.writeTable(ctx, zw, table, data)
+		if err != nil {
+			return fmt.Errorf("export %s: %w", table.Name, err)
+		}
+		m.Tables = append(m.Tables, manifestTable{Name: table.Name, File: model.LabExportFile(table.Name), Rows: rows})
+
+		data.Tables = i + 1
+		data.Progress = data.Tables * 99 / data.TotalTables
+		if err := s.store.UpdateExport(ctx, data, "tables", "progress", "rows"); err != nil {
+			return err
+		}
+	}
+
+	w, err := zw.Create(manifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	data.FileName = fmt.Sprintf("lab_%s_%s.zip", labUUID.String()[:8], m.CreatedAt.Format("20060102150405"))
+	key := fmt.Sprintf("exports/%d/%s/%s", data.LabID, data.UUID, data.FileName)
+	if err := s.storage.Put(ctx, key, f, size); err != nil {
+		return fmt.Errorf("store archive: %w", err)
+	}
+	data.Size = size
+	data.StorageKey = key
+	return nil
+}
+
+// writeTable 按 id 顺序分页写出一张表，返回写出的记录数
+func (s *Service) writeTable(ctx context.Context, zw *zip.Writer, table model.LabTable, data *model.LabExport) (int64, error) {
+	w, err := zw.Create(model.LabExportFile(table.Name))
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(w)
+
+	var (
+		rows   int64
+		lastID int64
+	)
+	for page := 1; ; page++ {
+		if ctx.Err() != nil {
+			return rows, ctx.Err()
+		}
+		datas, err := s.store.TableRows(ctx, table, data.LabID, lastID, pageSize)
+		if err != nil {
+			return rows, err
+		}
+		for _, row := range datas {
+			if err := enc.Encode(normalizeRow(row)); err != nil {
+				return rows, err
+			}
+		}
+		rows += int64(len(datas))
+		data.Rows += int64(len(datas))
+		if len(datas) < pageSize {
+			return rows, nil
+		}
+
+		id, ok := datas[len(datas)-1]["id"].(int64)
+		if !ok {
+			return rows, fmt.Errorf("unexpected id type %T", datas[len(datas)-1]["id"])
+		}
+		lastID = id
+		// 大表导出时间较长，定期刷新更新时间避免被当作中断的导出
+		if page%progressInterval == 0 {
+			if err := s.store.UpdateExport(ctx, data, "rows"); err != nil {
+				return rows, err
+			}
+		}
+	}
+}
+
+// normalizeRow 数据库驱动以字节返回的 json 列按原始 JSON 写出，其余字节列按字符串写出
+func normalizeRow(row map[string]any) map[string]any {
+	for k, v := range row {
+		if b, ok := v.([]byte); ok {
+			if json.Valid(b) {
+				row[k] = json.RawMessage(b)
+			} else {
+				row[k] = string(b)
+			}
+		}
+	}
+	return row
+}
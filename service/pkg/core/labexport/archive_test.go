@@ -0,0 +1,37 @@
+package labexport
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeRow(t *testing.T) {
+	row := normalizeRow(map[string]any{
+		"id":     int64(7),
+		"labels": []byte(`{"project":"alpha"}`),
+		"raw":    []byte("not json"),
+	})
+	out, err := json.Marshal(row)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":7,"labels":{"project":"alpha"},"raw":"not json"}`, string(out))
+}
+
+func TestAvailable(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	data := &model.LabExport{Status: model.LabExportCompleted, StorageKey: "exports/1/a.zip", ExpiresAt: &later}
+	assert.True(t, available(data, now))
+
+	data.ExpiresAt = &earlier
+	assert.False(t, available(data, now))
+
+	data.ExpiresAt = &later
+	data.Status = model.LabExportRunning
+	assert.False(t, available(data, now))
+}
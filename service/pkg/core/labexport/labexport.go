@@ -0,0 +1,426 @@
+// Package labexport exports all data of a lab into a zip archive for
+// offboarding: the export runs in the background with progress tracking, the
+// archive is downloaded through a signed URL and removed from storage when it
+// expires. It also deletes all data of a lab after a confirmed request.
+package labexport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/respcache"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/labexport"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	downloadPath     = "/api/v1/export/download"
+	defaultExpire    = 72 * time.Hour
+	defaultURLTTL    = 15 * time.Minute
+	purgeTokenTTL    = 10 * time.Minute
+	staleAfter       = time.Hour
+	sweepEvery       = 10 * time.Minute
+	expireBatch      = 100
+	progressInterval = 20 // 每导出多少页记录更新一次进度
+)
+
+// CreateReq 创建实验室数据导出
+type CreateReq struct {
+	LabUUID uuid.UUID `json:"lab_uuid" binding:"required"`
+}
+
+// ListReq 查询实验室数据导出
+type ListReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// URLResp 签名下载链接
+type URLResp struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PurgeTokenReq 申请删除实验室全部数据
+type PurgeTokenReq struct {
+	LabUUID uuid.UUID `json:"lab_uuid" binding:"required"`
+}
+
+// PurgeTokenResp 删除确认令牌，确认时需同时提交实验室名称
+type PurgeTokenResp struct {
+	Token     string    `json:"token"`
+	Confirm   string    `json:"confirm"` // 需要输入的确认内容
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PurgeReq 确认删除实验室全部数据
+type PurgeReq struct {
+	LabUUID uuid.UUID `json:"lab_uuid" binding:"required"`
+	Token   string    `json:"token" binding:"required"`
+	Confirm string    `json:"confirm" binding:"required"` // 实验室名称
+}
+
+// PurgeResp 删除结果
+type PurgeResp struct {
+	Rows  int64 `json:"rows"`  // 删除的记录数
+	Files int   `json:"files"` // 删除的存储文件数
+}
+
+// urlClaims 下载链接签名内容
+type urlClaims struct {
+	ExportUUID uuid.UUID `json:"e"`
+	ExpiresAt  int64     `json:"x"`
+}
+
+// purgeClaims 删除确认令牌签名内容
+type purgeClaims struct {
+	Purpose   string    `json:"p"`
+	LabUUID   uuid.UUID `json:"l"`
+	UserID    string    `json:"u"`
+	ExpiresAt int64     `json:"x"`
+}
+
+const purgePurpose = "lab_purge"
+
+type Service struct {
+	store    labexport.LabExportRepo
+	envStore repo.LaboratoryRepo
+	storage  storage.Storage
+	secret   []byte
+}
+
+func New() *Service {
+	return &Service{
+		store:    labexport.New(),
+		envStore: environment.New(),
+		storage:  storage.Default(),
+		secret:   utils.SignedURLSecret(),
+	}
+}
+
+// Create 创建实验室数据导出，导出在后台执行，同一实验室同时只能有一个导出
+func (s *Service) Create(ctx context.Context, req *CreateReq) (*model.LabExport, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkAdmin(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNotRunning(ctx, labID); err != nil {
+		return nil, err
+	}
+
+	data := &model.LabExport{
+		BaseModel:   model.BaseModel{UUID: uuid.New()},
+		LabID:       labID,
+		RequestedBy: userInfo.ID,
+		Status:      model.LabExportPending,
+		TotalTables: len(model.LabDataTables),
+	}
+	if err := s.store.CreateExport(ctx, data); err != nil {
+		return nil, err
+	}
+
+	exportCtx := context.WithoutCancel(ctx)
+	utils.SafelyGo(func() {
+		s.run(exportCtx, data)
+	}, func(err error) {
+		logger.Errorf(exportCtx, "lab export %s panic: %+v", data.UUID, err)
+		data.Status = model.LabExportFailed
+		data.Error = fmt.Sprintf("panic: %v", err)
+		_ = s.store.UpdateExport(exportCtx, data, "status", "error")
+	})
+	return data, nil
+}
+
+// List 查询实验室的数据导出
+func (s *Service) List(ctx context.Context, req *ListReq) ([]*model.LabExport, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkAdmin(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListExports(ctx, labID)
+}
+
+// Get 查询导出进度
+func (s *Service) Get(ctx context.Context, exportUUID uuid.UUID) (*model.LabExport, error) {
+	return s.adminExport(ctx, exportUUID)
+}
+
+// DownloadURL 生成导出归档的签名下载链接，链接不会晚于归档过期
+func (s *Service) DownloadURL(ctx context.Context, exportUUID uuid.UUID) (*URLResp, error) {
+	data, err := s.adminExport(ctx, exportUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !available(data, time.Now()) {
+		return nil, code.LabExportExpiredErr
+	}
+
+	expiresAt := time.Now().Add(urlTTL())
+	if data.ExpiresAt.Before(expiresAt) {
+		expiresAt = *data.ExpiresAt
+	}
+	token := utils.SignClaims(s.secret, &urlClaims{
+		ExportUUID: data.UUID,
+		ExpiresAt:  expiresAt.Unix(),
+	})
+	return &URLResp{
+		URL:       downloadPath + "?token=" + url.QueryEscape(token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Open 校验下载令牌并打开导出归档，调用方负责关闭
+func (s *Service) Open(ctx context.Context, token string) (*model.LabExport, io.ReadCloser, error) {
+	claims := &urlClaims{}
+	if !utils.VerifyClaims(s.secret, token, claims) {
+		return nil, nil, code.FileURLInvalidErr
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, nil, code.FileURLInvalidErr.WithMsg("url expired")
+	}
+
+	data, err := s.store.GetExportByUUID(ctx, claims.ExportUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !available(data, time.Now()) {
+		return nil, nil, code.LabExportExpiredErr
+	}
+
+	r, err := s.storage.Open(ctx, data.StorageKey)
+	if err == storage.ErrNotFound {
+		return nil, nil, code.LabExportExpiredErr
+	}
+	if err != nil {
+		logger.Errorf(ctx, "open lab export fail export: %s, err: %+v", data.UUID, err)
+		return nil, nil, code.FileStorageErr.WithErr(err)
+	}
+	return data, r, nil
+}
+
+// PurgeToken 申请删除实验室全部数据，仅实验室创建者可以申请，返回短时有效的确认令牌
+func (s *Service) PurgeToken(ctx context.Context, req *PurgeTokenReq) (*PurgeTokenResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	lab, err := s.ownLab(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(purgeTokenTTL)
+	token := utils.SignClaims(s.secret, &purgeClaims{
+		Purpose:   purgePurpose,
+		LabUUID:   lab.UUID,
+		UserID:    userInfo.ID,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	return &PurgeTokenResp{
+		Token:     token,
+		Confirm:   lab.Name,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Purge 校验确认令牌和实验室名称后删除实验室的全部数据，实验室本身和成员保留
+func (s *Service) Purge(ctx context.Context, req *PurgeReq) (*PurgeResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	lab, err := s.ownLab(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	claims := &purgeClaims{}
+	if !utils.VerifyClaims(s.secret, req.Token, claims) ||
+		claims.Purpose != purgePurpose || claims.LabUUID != lab.UUID || claims.UserID != userInfo.ID {
+		return nil, code.LabPurgeConfirmErr
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, code.LabPurgeConfirmErr.WithMsg("confirmation expired")
+	}
+	if strings.TrimSpace(req.Confirm) != lab.Name {
+		return nil, code.LabPurgeConfirmErr.WithMsg("confirm does not match the lab name")
+	}
+	if err := s.checkNotRunning(ctx, lab.ID); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.store.StorageKeys(ctx, lab.ID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.store.PurgeLab(ctx, lab.ID)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "lab %s data purged by %s, rows: %d", lab.UUID, userInfo.ID, rows)
+	respcache.Invalidate(ctx, respcache.TagStats, respcache.TagTelemetry, respcache.TagDevice)
+
+	// 数据库记录已删除，存储中的文件删除失败只记录日志
+	files := 0
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil && err != storage.ErrNotFound {
+			logger.Warnf(ctx, "delete lab %s object %s err: %+v", lab.UUID, key, err)
+			continue
+		}
+		files++
+	}
+	return &PurgeResp{Rows: rows, Files: files}, nil
+}
+
+// Sweep 删除过期的导出归档，并将因服务重启中断的导出标记为失败
+func (s *Service) Sweep(ctx context.Context) error {
+	now := time.Now()
+	if n, err := s.store.FailStaleExports(ctx, now.Add(-staleAfter)); err != nil {
+		return err
+	} else if n > 0 {
+		logger.Warnf(ctx, "failed %d interrupted lab exports", n)
+	}
+
+	datas, err := s.store.ListExpiredExports(ctx, now, expireBatch)
+	if err != nil {
+		return err
+	}
+	for _, data := range datas {
+		if err := s.storage.Delete(ctx, data.StorageKey); err != nil && err != storage.ErrNotFound {
+			logger.Warnf(ctx, "delete lab export %s err: %+v", data.UUID, err)
+			continue
+		}
+		data.Status = model.LabExportExpired
+		data.StorageKey = ""
+		if err := s.store.UpdateExport(ctx, data, "status", "storage_key"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// available reports whether the archive of an export can be downloaded
+func available(data *model.LabExport, now time.Time) bool {
+	return data.Status == model.LabExportCompleted && data.StorageKey != "" &&
+		data.ExpiresAt != nil && now.Before(*data.ExpiresAt)
+}
+
+func (s *Service) adminExport(ctx context.Context, exportUUID uuid.UUID) (*model.LabExport, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetExportByUUID(ctx, exportUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAdminByID(ctx, userInfo.ID, data.LabID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *Service) checkNotRunning(ctx context.Context, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LabExport{}, map[string]any{
+		"lab_id": labID,
+		"status": []model.LabExportStatus{model.LabExportPending, model.LabExportRunning},
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return code.LabExportRunningErr
+	}
+	return nil
+}
+
+// ownLab 获取当前用户创建的实验室
+func (s *Service) ownLab(ctx context.Context, userID string, labUUID uuid.UUID) (*model.Laboratory, error) {
+	lab, err := s.envStore.GetLabByUUID(ctx, labUUID, "id", "uuid", "name", "user_id")
+	if err == code.RecordNotFound {
+		return nil, code.LabNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lab.UserID != userID {
+		return nil, code.NoPermission
+	}
+	return lab, nil
+}
+
+func (s *Service) checkAdmin(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.envStore.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	if err := s.checkAdminByID(ctx, userID, labID); err != nil {
+		return 0, err
+	}
+	return labID, nil
+}
+
+// checkAdminByID 导出包含实验室全部数据，仅管理员可以操作
+func (s *Service) checkAdminByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+		"role":    model.LaboratoryMemberAdmin,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+func urlTTL() time.Duration {
+	if sec := config.GetStudioConfig().Export.URLTTLSeconds; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultURLTTL
+}
+
+func expireAfter() time.Duration {
+	if hours := config.GetStudioConfig().Export.ExpireHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultExpire
+}
+
+// RegisterJob 注册导出归档清理后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "lab_export_sweep",
+		Description:  "删除过期的实验室导出归档",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     sweepEvery.String(),
+		Timeout:      5 * time.Minute,
+		Run:          s.Sweep,
+	})
+}
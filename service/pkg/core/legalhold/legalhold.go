@@ -0,0 +1,249 @@
+// Package legalhold places history records on legal hold. Held records are
+// kept by the retention cleanup until the hold is released, and placing or
+// releasing a hold is recorded in the audit log.
+package legalhold
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/legalhold"
+)
+
+// PlaceReq 设置法律保全
+type PlaceReq struct {
+	Scope         model.LegalHoldScope `json:"scope" binding:"required"`
+	ExecutionUUID uuid.UUID            `json:"execution_uuid"` // scope 为 execution 时必填
+	WorkflowUUID  uuid.UUID            `json:"workflow_uuid"`  // scope 为 workflow 时必填
+	LabUUID       uuid.UUID            `json:"lab_uuid"`       // scope 为 lab 时必填，time_range 时可选
+	StartsAt      *time.Time           `json:"starts_at"`      // scope 为 time_range 时必填
+	EndsAt        *time.Time           `json:"ends_at"`        // scope 为 time_range 时必填，不包含
+	Reason        string               `json:"reason" binding:"required"`
+}
+
+// ReleaseReq 解除法律保全
+type ReleaseReq struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ListReq 法律保全列表
+type ListReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid"`
+	Active  *bool     `form:"active"`
+	common.PageReq
+}
+
+// HoldResp 法律保全
+type HoldResp struct {
+	*model.LegalHold
+	LabUUID uuid.UUID `json:"lab_uuid"`
+}
+
+type Service struct {
+	store  legalhold.LegalHoldRepo
+	baseDB repo.IDOrUUIDTranslate
+}
+
+func New() *Service {
+	return &Service{
+		store:  legalhold.New(),
+		baseDB: repo.NewBaseDB(),
+	}
+}
+
+// Place 设置法律保全，保全范围内的历史记录不会被保留策略清理
+func (s *Service) Place(ctx context.Context, req *PlaceReq) (*HoldResp, error) {
+	userInfo, err := s.admin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &model.LegalHold{
+		BaseModel: model.BaseModel{UUID: uuid.New()},
+		Scope:     req.Scope,
+		Reason:    req.Reason,
+		PlacedBy:  userInfo.ID,
+	}
+	if err := s.resolveTarget(ctx, hold, req); err != nil {
+		return nil, err
+	}
+
+	audit := &model.AuditLog{
+		Action: model.AuditLegalHoldPlace,
+		UserID: userInfo.ID,
+		LabID:  hold.LabID,
+		Detail: fmt.Sprintf("hold: %s, %s, reason: %s", hold.UUID, describe(hold), hold.Reason),
+	}
+	if err := s.store.Place(ctx, hold, audit); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "legal hold placed: %s, %s, by: %s", hold.UUID, describe(hold), userInfo.ID)
+	return s.resp(ctx, hold), nil
+}
+
+// Release 解除法律保全，之后记录按保留策略正常清理
+func (s *Service) Release(ctx context.Context, holdUUID uuid.UUID, req *ReleaseReq) (*HoldResp, error) {
+	userInfo, err := s.admin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := s.store.GetByUUID(ctx, holdUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !hold.Active() {
+		return nil, code.LegalHoldReleasedErr
+	}
+
+	now := time.Now()
+	released, err := s.store.Release(ctx, hold.ID, map[string]any{
+		"released_at":    now,
+		"released_by":    userInfo.ID,
+		"release_reason": req.Reason,
+		"updated_at":     now,
+	}, &model.AuditLog{
+		Action: model.AuditLegalHoldRelease,
+		UserID: userInfo.ID,
+		LabID:  hold.LabID,
+		Detail: fmt.Sprintf("hold: %s, %s, reason: %s", hold.UUID, describe(hold), req.Reason),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !released {
+		return nil, code.LegalHoldReleasedErr
+	}
+	hold.ReleasedAt, hold.ReleasedBy, hold.ReleaseReason = &now, userInfo.ID, req.Reason
+	logger.Infof(ctx, "legal hold released: %s, by: %s", hold.UUID, userInfo.ID)
+	return s.resp(ctx, hold), nil
+}
+
+// List 获取法律保全列表
+func (s *Service) List(ctx context.Context, req *ListReq) (*common.ListResp[*HoldResp], error) {
+	if _, err := s.admin(ctx); err != nil {
+		return nil, err
+	}
+	req.Normalize()
+
+	filter := &legalhold.ListFilter{Active: req.Active}
+	if !req.LabUUID.IsNil() {
+		filter.LabID = s.baseDB.UUID2ID(ctx, &model.Laboratory{}, req.LabUUID)[req.LabUUID]
+		if filter.LabID == 0 {
+			return nil, code.LabNotFound
+		}
+	}
+	holds, total, err := s.store.List(ctx, filter, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	labIDs := make([]int64, 0, len(holds))
+	for _, h := range holds {
+		if h.LabID > 0 {
+			labIDs = append(labIDs, h.LabID)
+		}
+	}
+	labUUIDs := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, labIDs...)
+	datas := make([]*HoldResp, 0, len(holds))
+	for _, h := range holds {
+		datas = append(datas, &HoldResp{LegalHold: h, LabUUID: labUUIDs[h.LabID]})
+	}
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// resolveTarget fills the target of the hold from the request
+func (s *Service) resolveTarget(ctx context.Context, hold *model.LegalHold, req *PlaceReq) error {
+	if !req.Scope.Valid() {
+		return code.LegalHoldInvalidErr.WithMsg(fmt.Sprintf("unknown scope %q", req.Scope))
+	}
+	if !req.LabUUID.IsNil() {
+		hold.LabID = s.baseDB.UUID2ID(ctx, &model.Laboratory{}, req.LabUUID)[req.LabUUID]
+		if hold.LabID == 0 {
+			return code.LabNotFound
+		}
+	}
+
+	switch req.Scope {
+	case model.LegalHoldExecution:
+		if req.ExecutionUUID.IsNil() {
+			return code.LegalHoldInvalidErr.WithMsg("execution_uuid is required")
+		}
+		execs := make([]*model.WorkflowExecutionHistory, 0, 1)
+		if err := s.baseDB.FindDatas(ctx, &execs, map[string]any{
+			"uuid": req.ExecutionUUID,
+		}, "id", "uuid", "lab_id"); err != nil {
+			return err
+		}
+		if len(execs) == 0 {
+			return code.RecordNotFound.WithMsg("execution not found")
+		}
+		hold.ExecutionID, hold.LabID, hold.TargetUUID = execs[0].ID, execs[0].LabID, execs[0].UUID
+	case model.LegalHoldWorkflow:
+		if req.WorkflowUUID.IsNil() {
+			return code.LegalHoldInvalidErr.WithMsg("workflow_uuid is required")
+		}
+		workflows := make([]*model.Workflow, 0, 1)
+		if err := s.baseDB.FindDatas(ctx, &workflows, map[string]any{
+			"uuid": req.WorkflowUUID,
+		}, "id", "uuid", "lab_id"); err != nil {
+			return err
+		}
+		if len(workflows) == 0 {
+			return code.WorkflowNotExistErr
+		}
+		hold.WorkflowID, hold.LabID, hold.TargetUUID = workflows[0].ID, workflows[0].LabID, workflows[0].UUID
+	case model.LegalHoldLab:
+		if hold.LabID == 0 {
+			return code.LegalHoldInvalidErr.WithMsg("lab_uuid is required")
+		}
+	case model.LegalHoldTimeRange:
+		if req.StartsAt == nil || req.EndsAt == nil || !req.StartsAt.Before(*req.EndsAt) {
+			return code.LegalHoldInvalidErr.WithMsg("starts_at must be before ends_at")
+		}
+		hold.StartsAt, hold.EndsAt = req.StartsAt, req.EndsAt
+	}
+	return nil
+}
+
+func (s *Service) resp(ctx context.Context, hold *model.LegalHold) *HoldResp {
+	data := &HoldResp{LegalHold: hold}
+	if hold.LabID > 0 {
+		data.LabUUID = s.baseDB.ID2UUID(ctx, &model.Laboratory{}, hold.LabID)[hold.LabID]
+	}
+	return data
+}
+
+func (s *Service) admin(ctx context.Context) (*model.UserData, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return nil, code.NoPermission
+	}
+	return userInfo, nil
+}
+
+// describe summarizes what a hold covers for the audit log
+func describe(hold *model.LegalHold) string {
+	switch hold.Scope {
+	case model.LegalHoldExecution, model.LegalHoldWorkflow:
+		return fmt.Sprintf("scope: %s %s", hold.Scope, hold.TargetUUID)
+	case model.LegalHoldTimeRange:
+		desc := fmt.Sprintf("scope: %s %s - %s", hold.Scope, hold.StartsAt.Format(time.RFC3339), hold.EndsAt.Format(time.RFC3339))
+		if hold.LabID > 0 {
+			desc += fmt.Sprintf(", lab: %d", hold.LabID)
+		}
+		return desc
+	}
+	return fmt.Sprintf("scope: %s, lab: %d", hold.Scope, hold.LabID)
+}
@@ -0,0 +1,266 @@
+// Package liveness tracks edge agent heartbeats per device and records
+// disconnects once heartbeats stop.
+package liveness
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/eventschema"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/liveness"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	// HeartbeatInterval edge 上报心跳的建议间隔
+	HeartbeatInterval = 10 * time.Second
+	// OfflineAfter 连续错过 3 次心跳视为断开
+	OfflineAfter = 3 * HeartbeatInterval
+
+	watchdogBatch = 500
+)
+
+// HeartbeatReq edge 心跳请求，Devices 为空时视为实验室下所有设备在线
+type HeartbeatReq struct {
+	AgentVersion string            `json:"agent_version"`
+	Devices      []string          `json:"devices"`
+	Versions     map[string]string `json:"versions"` // 设备名称到该设备的 agent 版本，未列出的设备使用 agent_version
+	IP           string            `json:"-"`
+}
+
+// HeartbeatResp 心跳响应
+type HeartbeatResp struct {
+	Devices      int   `json:"devices"`
+	IntervalSec  int64 `json:"interval_sec"`
+	OfflineAfter int64 `json:"offline_after_sec"`
+}
+
+type Service struct {
+	store    liveness.LivenessRepo
+	history  history.HistoryRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	events   *eventschema.Dispatcher
+}
+
+func New() *Service {
+	return &Service{
+		store:    liveness.New(),
+		history:  history.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		events:   eventschema.NewDispatcher(),
+	}
+}
+
+// Heartbeat 记录实验室 edge 上报的心跳，重新上线的设备写入连接事件
+func (s *Service) Heartbeat(ctx context.Context, req *HeartbeatReq) (*HeartbeatResp, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+
+	condition := map[string]any{
+		"lab_id": labUser.LabID,
+		"type":   model.MATERIALDEVICE,
+	}
+	if len(req.Devices) > 0 {
+		condition["name"] = req.Devices
+	}
+	nodes := make([]*model.MaterialNode, 0, len(req.Devices))
+	if err := s.baseDB.FindDatas(ctx, &nodes, condition, "id", "uuid", "name"); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	devices := utils.FilterSlice(nodes, func(node *model.MaterialNode) (*model.DeviceLiveness, bool) {
+		version := req.AgentVersion
+		if v, ok := req.Versions[node.Name]; ok && v != "" {
+			version = v
+		}
+		return &model.DeviceLiveness{
+			DeviceID:     node.ID,
+			DeviceUUID:   node.UUID,
+			DeviceName:   node.Name,
+			AgentVersion: version,
+			IP:           req.IP,
+			LastSeenAt:   now,
+		}, true
+	})
+
+	connected, err := s.store.RecordHeartbeat(ctx, labUser.LabID, devices)
+	if err != nil {
+		return nil, err
+	}
+	s.recordEvents(ctx, connected, model.DeviceEventConnected)
+
+	return &HeartbeatResp{
+		Devices:      len(devices),
+		IntervalSec:  int64(HeartbeatInterval / time.Second),
+		OfflineAfter: int64(OfflineAfter / time.Second),
+	}, nil
+}
+
+// List 获取实验室设备在线状态，仅实验室成员可以查看
+func (s *Service) List(ctx context.Context, labUUID uuid.UUID) ([]*model.DeviceLiveness, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return nil, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return nil, code.NoPermission
+	}
+
+	return s.store.ListByLab(ctx, labID)
+}
+
+// Versions 按 agent 版本统计实验室设备数，设备多的版本在前
+func (s *Service) Versions(ctx context.Context, labUUID uuid.UUID) ([]*model.AgentVersionCount, error) {
+	devices, err := s.List(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]*model.AgentVersionCount)
+	ret := make([]*model.AgentVersionCount, 0, 4)
+	for _, device := range devices {
+		c, ok := counts[device.AgentVersion]
+		if !ok {
+			c = &model.AgentVersionCount{Version: device.AgentVersion}
+			counts[device.AgentVersion] = c
+			ret = append(ret, c)
+		}
+		c.Devices++
+		if device.Status == model.LivenessOnline {
+			c.Online++
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Devices != ret[j].Devices {
+			return ret[i].Devices > ret[j].Devices
+		}
+		return ret[i].Version < ret[j].Version
+	})
+	return ret, nil
+}
+
+// Sweep 将超时未上报心跳的设备标记为离线并写入断开事件
+func (s *Service) Sweep(ctx context.Context) error {
+	stale, err := s.store.ListStale(ctx, time.Now().Add(-OfflineAfter), watchdogBatch)
+	if err != nil {
+		return err
+	}
+
+	disconnected := make([]*model.DeviceLiveness, 0, len(stale))
+	for _, device := range stale {
+		ok, err := s.store.MarkOffline(ctx, device)
+		if err != nil {
+			return err
+		}
+		if ok {
+			disconnected = append(disconnected, device)
+		}
+	}
+	s.recordEvents(ctx, disconnected, model.DeviceEventDisconnected)
+	s.dispatchDisconnected(ctx, disconnected)
+	return nil
+}
+
+// dispatchDisconnected 发布 device.disconnected 事件
+func (s *Service) dispatchDisconnected(ctx context.Context, devices []*model.DeviceLiveness) {
+	if len(devices) == 0 {
+		return
+	}
+
+	labIDs := utils.FilterUniqSlice(devices, func(device *model.DeviceLiveness) (int64, bool) {
+		return device.LabID, true
+	})
+	labUUIDs := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, labIDs...)
+	for _, device := range devices {
+		if err := s.events.Dispatch(ctx, eventschema.DeviceDisconnected, labUUIDs[device.LabID], &eventschema.DeviceDisconnectedData{
+			DeviceUUID:      device.DeviceUUID,
+			DeviceName:      device.DeviceName,
+			AgentVersion:    device.AgentVersion,
+			IP:              device.IP,
+			LastSeenAt:      device.LastSeenAt,
+			OfflineAfterSec: int(OfflineAfter / time.Second),
+		}); err != nil {
+			logger.Warnf(ctx, "dispatch device disconnected event fail device: %s, err: %+v", device.DeviceUUID, err)
+		}
+	}
+}
+
+func (s *Service) recordEvents(ctx context.Context, devices []*model.DeviceLiveness, eventType model.DeviceEventType) {
+	if len(devices) == 0 {
+		return
+	}
+
+	now := time.Now()
+	events := utils.FilterSlice(devices, func(device *model.DeviceLiveness) (*model.DeviceEventHistory, bool) {
+		data, _ := json.Marshal(map[string]any{
+			"device_name":   device.DeviceName,
+			"agent_version": device.AgentVersion,
+			"ip":            device.IP,
+			"last_seen_at":  device.LastSeenAt,
+		})
+		return &model.DeviceEventHistory{
+			LabID:      device.LabID,
+			DeviceID:   device.DeviceID,
+			DeviceUUID: device.DeviceUUID,
+			EventType:  eventType,
+			EventData:  data,
+			Timestamp:  now,
+		}, true
+	})
+
+	if _, err := s.history.IngestDeviceEvents(ctx, events); err != nil {
+		logger.Warnf(ctx, "record device liveness events fail event: %s, err: %+v", eventType, err)
+	}
+}
+
+// RegisterJob 注册离线检测后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "device_liveness_watchdog",
+		Description:  "将超时未上报心跳的设备标记为离线",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     HeartbeatInterval.String(),
+		Timeout:      HeartbeatInterval,
+		Run:          s.Sweep,
+	})
+}
+
+// RegisterMetrics 注册设备在线状态指标
+func RegisterMetrics() error {
+	store := liveness.New()
+	return otel.GetMetrics().ObserveDeviceLiveness(func(ctx context.Context) (int64, int64, error) {
+		summary, err := store.Summary(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		return summary.Online, summary.Offline, nil
+	})
+}
@@ -0,0 +1,436 @@
+// Package loadtest drives synthetic traffic against the history ingest and
+// query APIs of a running apiserver before releases. Requests are issued at the
+// rate of an RPS profile regardless of how fast the server answers, so queueing
+// in the server shows up as latency and rate limiting as 429 responses instead
+// of slowing the generator down. After the run the ingest stream is checked to
+// make sure every acknowledged record was written.
+package loadtest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/ingest"
+	"github.com/scienceol/studio/service/pkg/core/liveness"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	ScenarioIngest    = "ingest"
+	ScenarioHeartbeat = "heartbeat"
+	ScenarioWorkflows = "workflows"
+	ScenarioEvents    = "events"
+	ScenarioStats     = "stats"
+
+	// DefaultMix 默认的请求比例，写入和列表查询为主
+	DefaultMix = "ingest=4,workflows=3,events=2,stats=1,heartbeat=1"
+
+	// tick 发送请求的调度间隔
+	tick             = 5 * time.Millisecond
+	progressInterval = 10 * time.Second
+)
+
+// labScenarios 使用实验室 AK/SK 认证的场景，其余场景使用用户令牌
+var labScenarios = map[string]bool{ScenarioIngest: true, ScenarioHeartbeat: true}
+
+// Options configures a load test run
+type Options struct {
+	Target      string // apiserver 的 API 地址，如 http://localhost:48197/api
+	Token       string // 查询接口使用的用户令牌
+	LabAK       string // 写入接口使用的实验室 AK
+	LabSK       string
+	LabID       int64 // 查询的实验室
+	Profile     Profile
+	Mix         map[string]int // 场景名到权重
+	Concurrency int            // 同时进行的最大请求数
+	Batch       int            // 每次写入的记录数
+	DeviceName  string         // 写入记录的设备名称
+	Timeout     time.Duration  // 单个请求的超时
+	// OnProgressFn 运行期间定期回调当前目标 RPS 和进行中的请求数
+	OnProgressFn func(elapsed time.Duration, rps float64, inflight int)
+}
+
+// IngestCheck 压测结束后写入流的确认状态
+type IngestCheck struct {
+	Stream        string `json:"stream"`
+	Sent          int64  `json:"sent"`     // 发送的记录数，包含失败的请求
+	Acked         int64  `json:"acked"`    // 响应确认写入的记录数
+	Rejected      int64  `json:"rejected"` // 服务端拒绝的记录数
+	HighWaterMark int64  `json:"high_water_mark"`
+	MaxSeq        int64  `json:"max_seq"`
+	Missing       int    `json:"missing"` // 高水位和最大序号之间未写入的序号数，来自失败的请求
+}
+
+// Report 压测结果
+type Report struct {
+	Duration  time.Duration     `json:"duration"`
+	Scenarios []*ScenarioReport `json:"scenarios"`
+	Ingest    *IngestCheck      `json:"ingest,omitempty"`
+}
+
+// Thresholds 发版前的通过条件，零值表示不检查
+type Thresholds struct {
+	MaxP99        time.Duration
+	MaxErrorRate  float64
+	ExpectLimited bool // 期望出现 429，用于验证峰值流量下限流生效
+}
+
+// ParseMix parses weights of the form "ingest=4,workflows=3"
+func ParseMix(s string) (map[string]int, error) {
+	mix := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		name, weightStr, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix %q, expect <scenario>=<weight>", part)
+		}
+		switch name {
+		case ScenarioIngest, ScenarioHeartbeat, ScenarioWorkflows, ScenarioEvents, ScenarioStats:
+		default:
+			return nil, fmt.Errorf("unknown scenario %q", name)
+		}
+		weight, err := strconv.Atoi(weightStr)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight of scenario %s: %q", name, weightStr)
+		}
+		if weight > 0 {
+			mix[name] = weight
+		}
+	}
+	if len(mix) == 0 {
+		return nil, errors.New("mix has no scenario")
+	}
+	return mix, nil
+}
+
+// scenario 一类请求，run 返回请求结果
+type scenario struct {
+	name   string
+	weight int
+	run    func(ctx context.Context) (outcome, string)
+	rec    *recorder
+}
+
+type runner struct {
+	opts      *Options
+	client    *resty.Client
+	labAuth   string
+	stream    string
+	seq       atomic.Int64
+	acked     atomic.Int64
+	rejected  atomic.Int64
+	scenarios []*scenario
+	total     int
+}
+
+// Run sends the traffic of the profile and reports the latencies of every scenario
+func Run(ctx context.Context, opts *Options) (*Report, error) {
+	r, err := newRunner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		inflight atomic.Int64
+		sem      = make(chan struct{}, opts.Concurrency)
+		ticker   = time.NewTicker(tick)
+		start    = time.Now()
+		last     = start
+		progress = start
+		pending  float64
+	)
+	defer ticker.Stop()
+	dispatch := func(sc *scenario) {
+		select {
+		case sem <- struct{}{}:
+		default:
+			sc.rec.drop()
+			return
+		}
+		wg.Add(1)
+		inflight.Add(1)
+		go func() {
+			defer func() {
+				inflight.Add(-1)
+				<-sem
+				wg.Done()
+			}()
+			begin := time.Now()
+			o, msg := sc.run(ctx)
+			sc.rec.record(o, time.Since(begin), msg)
+		}()
+	}
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case now := <-ticker.C:
+			elapsed := now.Sub(start)
+			if elapsed >= opts.Profile.Duration() {
+				break loop
+			}
+			rate := opts.Profile.Rate(elapsed)
+			// 累计到整数个请求再发送，低 RPS 时也能保持平均速率
+			pending += rate * now.Sub(last).Seconds()
+			last = now
+			for ; pending >= 1; pending-- {
+				dispatch(r.pick())
+			}
+			if opts.OnProgressFn != nil && now.Sub(progress) >= progressInterval {
+				progress = now
+				opts.OnProgressFn(elapsed, rate, int(inflight.Load()))
+			}
+		}
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := &Report{Duration: elapsed}
+	for _, sc := range r.scenarios {
+		report.Scenarios = append(report.Scenarios, sc.rec.report(sc.name, elapsed))
+	}
+	if opts.Mix[ScenarioIngest] > 0 {
+		// 中断时也检查已发送的记录
+		if report.Ingest, err = r.checkIngest(context.WithoutCancel(ctx)); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func newRunner(opts *Options) (*runner, error) {
+	if opts.Target == "" {
+		return nil, errors.New("target is required")
+	}
+	if len(opts.Profile) == 0 {
+		return nil, errors.New("profile is required")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 64
+	}
+	if opts.Batch <= 0 {
+		opts.Batch = 50
+	}
+	if opts.DeviceName == "" {
+		opts.DeviceName = "loadtest-device"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	for name := range opts.Mix {
+		if labScenarios[name] && (opts.LabAK == "" || opts.LabSK == "") {
+			return nil, fmt.Errorf("scenario %s needs the lab access key and secret", name)
+		}
+		if !labScenarios[name] && (opts.Token == "" || opts.LabID == 0) {
+			return nil, fmt.Errorf("scenario %s needs the user token and lab id", name)
+		}
+	}
+
+	// 压测客户端不接入链路追踪，避免产生大量 span
+	client := resty.New().
+		SetBaseURL(strings.TrimRight(opts.Target, "/")).
+		SetTimeout(opts.Timeout).
+		SetTransport(&http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        opts.Concurrency,
+			MaxIdleConnsPerHost: opts.Concurrency,
+			IdleConnTimeout:     90 * time.Second,
+		})
+	r := &runner{
+		opts:    opts,
+		client:  client,
+		labAuth: "Lab " + base64.StdEncoding.EncodeToString([]byte(opts.LabAK+":"+opts.LabSK)),
+		stream:  "loadtest-" + uuid.NewV4().String(),
+	}
+	runs := map[string]func(ctx context.Context) (outcome, string){
+		ScenarioIngest:    r.ingest,
+		ScenarioHeartbeat: r.heartbeat,
+		ScenarioWorkflows: r.query("/v1/lab/history/workflow", map[string]string{"page_size": "20", "include_total": "false"}),
+		ScenarioEvents:    r.query("/v1/lab/history/device", map[string]string{"page_size": "50", "include_total": "false"}),
+		ScenarioStats:     r.query(fmt.Sprintf("/v1/lab/%d/stats", opts.LabID), nil),
+	}
+	names := make([]string, 0, len(opts.Mix))
+	for name := range opts.Mix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.scenarios = append(r.scenarios, &scenario{
+			name:   name,
+			weight: opts.Mix[name],
+			run:    runs[name],
+			rec:    &recorder{},
+		})
+		r.total += opts.Mix[name]
+	}
+	return r, nil
+}
+
+func (r *runner) pick() *scenario {
+	n := rand.IntN(r.total)
+	for _, sc := range r.scenarios {
+		if n < sc.weight {
+			return sc
+		}
+		n -= sc.weight
+	}
+	return r.scenarios[len(r.scenarios)-1]
+}
+
+// ingest 写入一批已完成的动作，序号在流内递增
+func (r *runner) ingest(ctx context.Context) (outcome, string) {
+	now := time.Now()
+	end := r.seq.Add(int64(r.opts.Batch))
+	req := &ingest.IngestReq{Stream: r.stream, Records: make([]*ingest.RecordReq, 0, r.opts.Batch)}
+	for seq := end - int64(r.opts.Batch) + 1; seq <= end; seq++ {
+		req.Records = append(req.Records, &ingest.RecordReq{
+			Seq:        seq,
+			UUID:       uuid.New(),
+			DeviceName: r.opts.DeviceName,
+			ActionType: "loadtest",
+			ActionName: "noop",
+			Status:     model.ExecutionStatusSuccess,
+			StartedAt:  now.Add(-time.Second),
+			DurationMs: 1000,
+		})
+	}
+	ret := &common.RespT[*ingest.IngestResp]{}
+	resp, err := r.client.R().SetContext(ctx).
+		SetHeader("Authorization", r.labAuth).
+		SetBody(req).
+		SetResult(ret).
+		Post("/v1/edge/ingest")
+	o, msg := classify(resp, err, ret.Code, ret.Error)
+	if o == outcomeOK && ret.Data != nil {
+		for _, ack := range ret.Data.Acks {
+			if ack.Status == model.IngestRejected {
+				r.rejected.Add(1)
+			} else {
+				r.acked.Add(1)
+			}
+		}
+	}
+	return o, msg
+}
+
+func (r *runner) heartbeat(ctx context.Context) (outcome, string) {
+	ret := &common.RespT[json.RawMessage]{}
+	resp, err := r.client.R().SetContext(ctx).
+		SetHeader("Authorization", r.labAuth).
+		SetBody(&liveness.HeartbeatReq{AgentVersion: "loadtest", Devices: []string{r.opts.DeviceName}}).
+		SetResult(ret).
+		Post("/v1/edge/heartbeat")
+	return classify(resp, err, ret.Code, ret.Error)
+}
+
+func (r *runner) query(path string, params map[string]string) func(ctx context.Context) (outcome, string) {
+	query := map[string]string{"lab_id": strconv.FormatInt(r.opts.LabID, 10)}
+	for k, v := range params {
+		query[k] = v
+	}
+	return func(ctx context.Context) (outcome, string) {
+		ret := &common.RespT[json.RawMessage]{}
+		resp, err := r.client.R().SetContext(ctx).
+			SetAuthToken(r.opts.Token).
+			SetQueryParams(query).
+			SetResult(ret).
+			Get(path)
+		return classify(resp, err, ret.Code, ret.Error)
+	}
+}
+
+// classify 业务错误以 200 返回，需要同时检查响应码
+func classify(resp *resty.Response, err error, errCode code.ErrCode, respErr *common.Error) (outcome, string) {
+	switch {
+	case err != nil:
+		return outcomeError, err.Error()
+	case resp.StatusCode() == http.StatusTooManyRequests:
+		return outcomeLimited, ""
+	case resp.IsError():
+		return outcomeError, resp.Status()
+	case errCode != code.Success:
+		msg := strconv.Itoa(int(errCode))
+		if respErr != nil {
+			msg = fmt.Sprintf("%d %s", errCode, respErr.Msg)
+		}
+		return outcomeError, msg
+	}
+	return outcomeOK, ""
+}
+
+// checkIngest 读取写入流的确认状态，确认写入的记录都应在服务端可见
+func (r *runner) checkIngest(ctx context.Context) (*IngestCheck, error) {
+	check := &IngestCheck{
+		Stream:   r.stream,
+		Sent:     r.seq.Load(),
+		Acked:    r.acked.Load(),
+		Rejected: r.rejected.Load(),
+	}
+	if check.Sent == 0 {
+		return check, nil
+	}
+	ret := &common.RespT[*ingest.AckResp]{}
+	resp, err := r.client.R().SetContext(ctx).
+		SetHeader("Authorization", r.labAuth).
+		SetResult(ret).
+		Get("/v1/edge/ingest/" + r.stream)
+	if o, msg := classify(resp, err, ret.Code, ret.Error); o != outcomeOK || ret.Data == nil {
+		return check, fmt.Errorf("check ingest stream %s fail: %s", r.stream, msg)
+	}
+	check.HighWaterMark = ret.Data.HighWaterMark
+	check.MaxSeq = ret.Data.MaxSeq
+	check.Missing = len(ret.Data.Missing)
+	return check, nil
+}
+
+// Check returns an error describing every threshold the report violates
+func (rep *Report) Check(t *Thresholds) error {
+	failures := make([]string, 0)
+	limited := 0
+	for _, s := range rep.Scenarios {
+		limited += s.Limited
+		if t.MaxP99 > 0 && s.P99 > t.MaxP99 {
+			failures = append(failures, fmt.Sprintf("%s p99 %s exceeds %s", s.Name, s.P99, t.MaxP99))
+		}
+		if t.MaxErrorRate > 0 && s.ErrorRate() > t.MaxErrorRate {
+			failures = append(failures, fmt.Sprintf("%s error rate %.2f%% exceeds %.2f%%",
+				s.Name, 100*s.ErrorRate(), 100*t.MaxErrorRate))
+		}
+		if s.Dropped > 0 {
+			failures = append(failures, fmt.Sprintf("%s dropped %d requests, raise the concurrency", s.Name, s.Dropped))
+		}
+	}
+	if t.ExpectLimited && limited == 0 {
+		failures = append(failures, "no request was rate limited")
+	}
+	if in := rep.Ingest; in != nil {
+		if in.Rejected > 0 {
+			failures = append(failures, fmt.Sprintf("%d ingested records rejected", in.Rejected))
+		}
+		// 确认写入的记录数不能超过服务端记录的最大序号
+		if in.Acked > in.MaxSeq {
+			failures = append(failures, fmt.Sprintf("%d records acked but max seq is %d", in.Acked, in.MaxSeq))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
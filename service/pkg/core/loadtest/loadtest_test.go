@@ -0,0 +1,140 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/core/ingest"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProfile(t *testing.T) {
+	p, err := ParseProfile("1m@0-100, 30s@50")
+	require.NoError(t, err)
+	assert.Equal(t, Profile{
+		{Duration: time.Minute, From: 0, To: 100},
+		{Duration: 30 * time.Second, From: 50, To: 50},
+	}, p)
+	assert.Equal(t, 90*time.Second, p.Duration())
+	assert.InDelta(t, 50, p.Rate(30*time.Second), 0.001)
+	assert.InDelta(t, 50, p.Rate(70*time.Second), 0.001)
+	assert.Zero(t, p.Rate(2*time.Minute))
+
+	release, err := ParseProfile("release")
+	require.NoError(t, err)
+	assert.Len(t, release, 4)
+
+	for _, s := range []string{"", "1m", "x@10", "1m@-5", "1m@10-y"} {
+		_, err := ParseProfile(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("ingest=4,stats=0,events=1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{ScenarioIngest: 4, ScenarioEvents: 1}, mix)
+
+	for _, s := range []string{"stats=0", "foo=1", "ingest", "ingest=x"} {
+		_, err := ParseMix(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 0, 100)
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	assert.Equal(t, time.Millisecond, percentile(latencies[:1], 99))
+	assert.Zero(t, percentile(nil, 99))
+}
+
+func TestRun(t *testing.T) {
+	var queries, maxSeq atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/edge/ingest", func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Lab "))
+		req := &ingest.IngestReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		acks := make([]*model.IngestAck, 0, len(req.Records))
+		for _, rec := range req.Records {
+			acks = append(acks, &model.IngestAck{Seq: rec.Seq, Status: model.IngestAccepted})
+			for cur := maxSeq.Load(); rec.Seq > cur && !maxSeq.CompareAndSwap(cur, rec.Seq); cur = maxSeq.Load() {
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": &ingest.IngestResp{Stream: req.Stream, Acks: acks}})
+	})
+	mux.HandleFunc("GET /api/v1/edge/ingest/{stream}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": &ingest.AckResp{
+			Stream: r.PathValue("stream"), HighWaterMark: maxSeq.Load(), MaxSeq: maxSeq.Load(), Missing: []int64{},
+		}})
+	})
+	mux.HandleFunc("GET /api/v1/lab/history/workflow", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "7", r.URL.Query().Get("lab_id"))
+		// 每隔一个请求限流一次
+		if queries.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]any{}})
+	})
+	mux.HandleFunc("GET /api/v1/lab/7/stats", func(w http.ResponseWriter, _ *http.Request) {
+		// 业务错误以 200 返回
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": 1001, "error": map[string]any{"msg": "boom"}})
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	report, err := Run(t.Context(), &Options{
+		Target:  server.URL + "/api/",
+		Token:   "token",
+		LabAK:   "ak",
+		LabSK:   "sk",
+		LabID:   7,
+		Profile: Profile{{Duration: 500 * time.Millisecond, From: 200, To: 200}},
+		Mix:     map[string]int{ScenarioIngest: 2, ScenarioWorkflows: 2, ScenarioStats: 1},
+		Batch:   10,
+	})
+	require.NoError(t, err)
+
+	byName := make(map[string]*ScenarioReport)
+	total := 0
+	for _, s := range report.Scenarios {
+		byName[s.Name] = s
+		total += s.Requests
+	}
+	assert.InDelta(t, 100, total, 20)
+
+	in := byName[ScenarioIngest]
+	assert.Equal(t, in.Requests, in.OK)
+	assert.Positive(t, in.P99)
+	wf := byName[ScenarioWorkflows]
+	assert.Positive(t, wf.Limited)
+	assert.Zero(t, wf.Errors)
+	stats := byName[ScenarioStats]
+	assert.Equal(t, stats.Requests, stats.Errors)
+	assert.Equal(t, "1001 boom", stats.LastError)
+
+	require.NotNil(t, report.Ingest)
+	assert.Equal(t, int64(in.Requests*10), report.Ingest.Sent)
+	assert.Equal(t, report.Ingest.Sent, report.Ingest.Acked)
+	assert.Equal(t, report.Ingest.Sent, report.Ingest.MaxSeq)
+
+	assert.NoError(t, report.Check(&Thresholds{ExpectLimited: true, MaxP99: time.Minute}))
+	assert.ErrorContains(t, report.Check(&Thresholds{MaxErrorRate: 0.5}), "stats error rate")
+}
@@ -0,0 +1,87 @@
+package loadtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// presets 常用的流量曲线，release 用于发版前验证限流和写入
+var presets = map[string]string{
+	"smoke":   "30s@5",
+	"release": "1m@0-100,3m@100,30s@300,1m@100",
+	"spike":   "30s@50,15s@500,1m@50",
+}
+
+// Stage 流量曲线的一段，RPS 在时间段内从 From 线性变化到 To
+type Stage struct {
+	Duration time.Duration
+	From     float64
+	To       float64
+}
+
+// Profile 按顺序执行的流量曲线
+type Profile []Stage
+
+// ParseProfile parses a preset name or comma separated stages of the form
+// <duration>@<rps> for a constant rate and <duration>@<from>-<to> for a ramp,
+// e.g. "1m@0-100,3m@100,30s@300"
+func ParseProfile(s string) (Profile, error) {
+	if preset, ok := presets[s]; ok {
+		s = preset
+	}
+	profile := make(Profile, 0)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		durationStr, rateStr, ok := strings.Cut(part, "@")
+		if !ok {
+			return nil, fmt.Errorf("invalid stage %q, expect <duration>@<rps>", part)
+		}
+		d, err := time.ParseDuration(durationStr)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid stage duration %q", durationStr)
+		}
+		stage := Stage{Duration: d}
+		fromStr, toStr, ramp := strings.Cut(rateStr, "-")
+		if stage.From, err = parseRate(fromStr); err != nil {
+			return nil, err
+		}
+		stage.To = stage.From
+		if ramp {
+			if stage.To, err = parseRate(toStr); err != nil {
+				return nil, err
+			}
+		}
+		profile = append(profile, stage)
+	}
+	return profile, nil
+}
+
+func parseRate(s string) (float64, error) {
+	rate, err := strconv.ParseFloat(s, 64)
+	if err != nil || rate < 0 {
+		return 0, fmt.Errorf("invalid stage rps %q", s)
+	}
+	return rate, nil
+}
+
+// Duration 曲线的总时长
+func (p Profile) Duration() time.Duration {
+	var total time.Duration
+	for _, s := range p {
+		total += s.Duration
+	}
+	return total
+}
+
+// Rate returns the target requests per second at elapsed, 0 after the profile ends
+func (p Profile) Rate(elapsed time.Duration) float64 {
+	for _, s := range p {
+		if elapsed < s.Duration {
+			return s.From + (s.To-s.From)*float64(elapsed)/float64(s.Duration)
+		}
+		elapsed -= s.Duration
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package loadtest
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// outcome 一次请求的结果
+type outcome int
+
+const (
+	outcomeOK outcome = iota
+	outcomeLimited
+	outcomeError
+)
+
+// recorder 记录一个场景的请求结果和时延
+type recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	ok        int
+	limited   int
+	errors    int
+	dropped   int
+	lastErr   string
+}
+
+func (r *recorder) record(o outcome, latency time.Duration, errMsg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, latency)
+	switch o {
+	case outcomeOK:
+		r.ok++
+	case outcomeLimited:
+		r.limited++
+	default:
+		r.errors++
+		r.lastErr = errMsg
+	}
+}
+
+// drop 并发已满时未发出的请求，压测客户端成为瓶颈时结果不可信
+func (r *recorder) drop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropped++
+}
+
+// ScenarioReport 一个场景的统计，时延包含限流和失败的请求
+type ScenarioReport struct {
+	Name      string        `json:"name"`
+	Requests  int           `json:"requests"`
+	OK        int           `json:"ok"`
+	Limited   int           `json:"limited"` // 429 响应数
+	Errors    int           `json:"errors"`
+	Dropped   int           `json:"dropped"`
+	RPS       float64       `json:"rps"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P99       time.Duration `json:"p99"`
+	Max       time.Duration `json:"max"`
+	LastError string        `json:"last_error,omitempty"`
+}
+
+// ErrorRate 失败请求的比例，限流不算失败
+func (s *ScenarioReport) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+func (r *recorder) report(name string, elapsed time.Duration) *ScenarioReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	latencies := slices.Clone(r.latencies)
+	slices.Sort(latencies)
+	ret := &ScenarioReport{
+		Name:      name,
+		Requests:  len(latencies),
+		OK:        r.ok,
+		Limited:   r.limited,
+		Errors:    r.errors,
+		Dropped:   r.dropped,
+		P50:       percentile(latencies, 50),
+		P90:       percentile(latencies, 90),
+		P99:       percentile(latencies, 99),
+		Max:       percentile(latencies, 100),
+		LastError: r.lastErr,
+	}
+	if elapsed > 0 {
+		ret.RPS = float64(ret.Requests) / elapsed.Seconds()
+	}
+	return ret
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,264 @@
+// Package maintenance puts the whole service, or single labs, in read-only
+// maintenance. Write requests to an affected lab are rejected with 503 and
+// the scheduler stops taking tasks of the lab until the window ends.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/impersonation"
+	"github.com/scienceol/studio/service/pkg/repo/maintenance"
+)
+
+const (
+	// refreshInterval 各进程重新加载维护状态的间隔，其他进程修改后最多延迟这么久生效
+	refreshInterval = 5 * time.Second
+
+	startedByConfig = "config"
+)
+
+// StartReq 开始或更新维护请求
+type StartReq struct {
+	Message string     `json:"message"`
+	ETA     *time.Time `json:"eta"` // 预计结束时间，为空时未知
+}
+
+// StatusReq 维护状态请求
+type StatusReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid"`
+}
+
+// StatusResp 维护状态，供前端展示维护提示
+type StatusResp struct {
+	ReadOnly bool                     `json:"read_only"`
+	Global   *model.MaintenanceWindow `json:"global"`
+	Lab      *model.MaintenanceWindow `json:"lab"`
+}
+
+// snapshot is the maintenance state loaded by a process
+type snapshot struct {
+	global   *model.MaintenanceWindow
+	labs     map[int64]*model.MaintenanceWindow
+	loadedAt time.Time
+}
+
+var (
+	state     atomic.Pointer[snapshot]
+	refreshMu sync.Mutex
+	store     = sync.OnceValue(maintenance.New)
+)
+
+// load returns the current state, reloading it when stale. The previous state
+// is kept when reloading fails
+func load(ctx context.Context) *snapshot {
+	if snap := state.Load(); snap != nil && time.Since(snap.loadedAt) < refreshInterval {
+		return snap
+	}
+
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+	prev := state.Load()
+	if prev != nil && time.Since(prev.loadedAt) < refreshInterval {
+		return prev
+	}
+
+	windows, err := store().ListWindows(ctx)
+	if err != nil {
+		logger.Errorf(ctx, "maintenance load windows fail: %+v", err)
+		if prev == nil {
+			prev = &snapshot{labs: map[int64]*model.MaintenanceWindow{}}
+		}
+		// 数据库不可用时沿用之前的状态，稍后重试
+		state.Store(&snapshot{global: prev.global, labs: prev.labs, loadedAt: time.Now()})
+		return state.Load()
+	}
+
+	snap := &snapshot{labs: make(map[int64]*model.MaintenanceWindow, len(windows)), loadedAt: time.Now()}
+	for _, window := range windows {
+		if window.Global() {
+			snap.global = window
+		} else {
+			snap.labs[window.LabID] = window
+		}
+	}
+	state.Store(snap)
+	return snap
+}
+
+// invalidate makes the next lookup reload the state
+func invalidate() {
+	state.Store(nil)
+}
+
+// configWindow returns the global window forced by the studio configuration
+func configWindow(ctx context.Context) *model.MaintenanceWindow {
+	conf := config.GetStudioConfig().Maintenance
+	if !conf.ReadOnly {
+		return nil
+	}
+	window := &model.MaintenanceWindow{Message: conf.Message, StartedBy: startedByConfig}
+	if conf.ETA != "" {
+		eta, err := time.Parse(time.RFC3339, conf.ETA)
+		if err != nil {
+			logger.Warnf(ctx, "maintenance config eta %q invalid: %+v", conf.ETA, err)
+		} else {
+			window.ETA = &eta
+		}
+	}
+	return window
+}
+
+// globalWindow returns the window covering every lab, nil when none
+func globalWindow(ctx context.Context) *model.MaintenanceWindow {
+	if window := configWindow(ctx); window != nil {
+		return window
+	}
+	return load(ctx).global
+}
+
+// Window returns the window covering a lab, the global window first; labID
+// 0 only matches the global window
+func Window(ctx context.Context, labID int64) *model.MaintenanceWindow {
+	if window := globalWindow(ctx); window != nil {
+		return window
+	}
+	if labID == 0 {
+		return nil
+	}
+	return load(ctx).labs[labID]
+}
+
+// WindowByUUID returns the window covering a lab identified by UUID
+func WindowByUUID(ctx context.Context, labUUID uuid.UUID) *model.MaintenanceWindow {
+	if window := globalWindow(ctx); window != nil {
+		return window
+	}
+	return labWindow(ctx, labUUID)
+}
+
+func labWindow(ctx context.Context, labUUID uuid.UUID) *model.MaintenanceWindow {
+	if labUUID.IsNil() {
+		return nil
+	}
+	for _, window := range load(ctx).labs {
+		if window.LabUUID == labUUID {
+			return window
+		}
+	}
+	return nil
+}
+
+// Paused reports whether the scheduler must not start tasks of the lab
+func Paused(ctx context.Context, labID int64) bool {
+	return Window(ctx, labID) != nil
+}
+
+type Service struct {
+	store  maintenance.MaintenanceRepo
+	baseDB repo.IDOrUUIDTranslate
+	audit  impersonation.ImpersonationRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:  store(),
+		baseDB: repo.NewBaseDB(),
+		audit:  impersonation.New(),
+	}
+}
+
+// Status 返回全局和实验室的维护状态，未登录也可以访问
+func (s *Service) Status(ctx context.Context, req *StatusReq) *StatusResp {
+	resp := &StatusResp{Global: globalWindow(ctx), Lab: labWindow(ctx, req.LabUUID)}
+	resp.ReadOnly = resp.Global != nil || resp.Lab != nil
+	return resp
+}
+
+// Start 开始全局维护，labUUID 不为空时只维护该实验室；已在维护时更新提示和预计结束时间
+func (s *Service) Start(ctx context.Context, labUUID uuid.UUID, req *StartReq) (*model.MaintenanceWindow, error) {
+	userInfo, err := s.admin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &model.MaintenanceWindow{
+		LabUUID:   labUUID,
+		Message:   req.Message,
+		ETA:       req.ETA,
+		StartedBy: userInfo.ID,
+	}
+	if !labUUID.IsNil() {
+		data.LabID = s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+		if data.LabID == 0 {
+			return nil, code.LabNotFound
+		}
+	}
+	if err := s.store.UpsertWindow(ctx, data); err != nil {
+		return nil, err
+	}
+	invalidate()
+	logger.Infof(ctx, "maintenance started lab: %s, by: %s, eta: %v", labUUID, userInfo.ID, req.ETA)
+	s.record(ctx, model.AuditMaintenanceStart, userInfo.ID, data.LabID,
+		fmt.Sprintf("lab: %s, eta: %v, message: %s", labUUID, req.ETA, req.Message))
+	return data, nil
+}
+
+// End 结束全局维护，labUUID 不为空时结束该实验室的维护
+func (s *Service) End(ctx context.Context, labUUID uuid.UUID) error {
+	userInfo, err := s.admin(ctx)
+	if err != nil {
+		return err
+	}
+
+	var labID int64
+	if !labUUID.IsNil() {
+		labID = s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+		if labID == 0 {
+			return code.LabNotFound
+		}
+	}
+	ended, err := s.store.DeleteWindow(ctx, labID)
+	if err != nil {
+		return err
+	}
+	invalidate()
+	if ended {
+		logger.Infof(ctx, "maintenance ended lab: %s, by: %s", labUUID, userInfo.ID)
+		s.record(ctx, model.AuditMaintenanceEnd, userInfo.ID, labID, fmt.Sprintf("lab: %s", labUUID))
+	}
+	return nil
+}
+
+func (s *Service) admin(ctx context.Context) (*model.UserData, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return nil, code.NoPermission
+	}
+	return userInfo, nil
+}
+
+// record writes the maintenance change to the audit log, failures are only logged
+func (s *Service) record(ctx context.Context, action model.AuditAction, userID string, labID int64, detail string) {
+	if err := s.audit.CreateAuditLog(context.WithoutCancel(ctx), &model.AuditLog{
+		Action: action,
+		UserID: userID,
+		LabID:  labID,
+		Detail: detail,
+	}); err != nil {
+		logger.Errorf(ctx, "maintenance audit fail action: %s, err: %+v", action, err)
+	}
+}
@@ -0,0 +1,93 @@
+package maintenance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// preset stores a fresh snapshot so that lookups do not hit the database
+func preset(t *testing.T, windows ...*model.MaintenanceWindow) {
+	snap := &snapshot{labs: map[int64]*model.MaintenanceWindow{}, loadedAt: time.Now().Add(time.Hour)}
+	for _, window := range windows {
+		if window.Global() {
+			snap.global = window
+		} else {
+			snap.labs[window.LabID] = window
+		}
+	}
+	state.Store(snap)
+	t.Cleanup(invalidate)
+}
+
+func TestWindow(t *testing.T) {
+	lab := &model.MaintenanceWindow{LabID: 7, LabUUID: uuid.NewV4(), Message: "upgrade"}
+	preset(t, lab)
+
+	assert.Equal(t, lab, Window(t.Context(), 7))
+	assert.Nil(t, Window(t.Context(), 8))
+	assert.Nil(t, Window(t.Context(), 0))
+	assert.Equal(t, lab, WindowByUUID(t.Context(), lab.LabUUID))
+	assert.Nil(t, WindowByUUID(t.Context(), uuid.NewV4()))
+	assert.True(t, Paused(t.Context(), 7))
+	assert.False(t, Paused(t.Context(), 8))
+
+	global := &model.MaintenanceWindow{Message: "migration"}
+	preset(t, lab, global)
+	assert.Equal(t, global, Window(t.Context(), 8))
+	assert.Equal(t, global, Window(t.Context(), 0))
+	assert.True(t, Paused(t.Context(), 8))
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eta := time.Now().Add(10 * time.Minute)
+	lab := &model.MaintenanceWindow{LabID: 7, LabUUID: uuid.NewV4(), Message: "upgrade", ETA: &eta}
+	preset(t, lab)
+
+	r := gin.New()
+	r.Use(Middleware())
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	r.POST("/lab/:lab_uuid", ok)
+	r.GET("/lab/:lab_uuid", ok)
+	r.POST("/stats", ok)
+	r.PUT("/api/v1/admin/maintenance/lab/:lab_uuid", ok)
+
+	cases := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodPost, "/lab/" + lab.LabUUID.String(), http.StatusServiceUnavailable},
+		{http.MethodPost, "/lab/" + uuid.NewV4().String(), http.StatusOK},
+		{http.MethodGet, "/lab/" + lab.LabUUID.String(), http.StatusOK},
+		{http.MethodPost, "/stats?lab_id=7", http.StatusServiceUnavailable},
+		{http.MethodPost, "/stats?lab_id=8", http.StatusOK},
+		{http.MethodPost, "/stats", http.StatusOK},
+		{http.MethodPut, "/api/v1/admin/maintenance/lab/" + lab.LabUUID.String(), http.StatusOK},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		assert.Equal(t, c.want, w.Code, c.method+" "+c.path)
+		if c.want == http.StatusServiceUnavailable {
+			retry, err := strconv.Atoi(w.Header().Get("Retry-After"))
+			assert.NoError(t, err)
+			assert.InDelta(t, 600, retry, 2)
+			assert.Contains(t, w.Body.String(), "upgrade")
+		}
+	}
+
+	preset(t, &model.MaintenanceWindow{Message: "migration"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/stats", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, strconv.Itoa(defaultRetryAfter), w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,100 @@
+package maintenance
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// defaultRetryAfter is sent when the window has no ETA, in seconds
+const defaultRetryAfter = 60
+
+// exemptPrefixes are write routes still served during maintenance, so that
+// admins can end it and users can sign in
+var exemptPrefixes = []string{
+	"/api/v1/admin/maintenance",
+	"/api/auth/",
+	"/api/health",
+}
+
+// Middleware rejects write requests with 503 while the lab of the request, or
+// the whole service, is in maintenance. The lab is taken from the lab_uuid or
+// lab_id path param or query, request bodies are not inspected, so requests
+// without one are only rejected by a global window
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !isWrite(ctx.Request.Method) || exempt(ctx.FullPath()) {
+			ctx.Next()
+			return
+		}
+
+		window := requestWindow(ctx)
+		if window == nil {
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter(window, time.Now())))
+		ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, &common.Resp{
+			Code: code.MaintenanceReadOnlyErr,
+			Error: &common.Error{
+				Msg:    code.MaintenanceReadOnlyErr.String(),
+				Info:   []string{window.Message},
+				Detail: window,
+			},
+		})
+	}
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func exempt(route string) bool {
+	for _, prefix := range exemptPrefixes {
+		if strings.HasPrefix(route, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestWindow returns the window covering the lab of the request
+func requestWindow(ctx *gin.Context) *model.MaintenanceWindow {
+	for _, key := range []string{"lab_uuid", "lab_id"} {
+		value := ctx.Param(key)
+		if value == "" {
+			value = ctx.Query(key)
+		}
+		if value == "" {
+			continue
+		}
+		if labUUID, err := uuid.FromString(value); err == nil {
+			return WindowByUUID(ctx, labUUID)
+		}
+		if labID, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return Window(ctx, labID)
+		}
+	}
+	return globalWindow(ctx)
+}
+
+// retryAfter returns the seconds until the window is expected to end
+func retryAfter(window *model.MaintenanceWindow, now time.Time) int {
+	if window.ETA == nil || !window.ETA.After(now) {
+		return defaultRetryAfter
+	}
+	return int(window.ETA.Sub(now).Seconds()) + 1
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/scienceol/studio/service/pkg/middleware/auth"
 	"github.com/scienceol/studio/service/pkg/middleware/db"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/scienceol/studio/service/pkg/middleware/redis"
 	"github.com/scienceol/studio/service/pkg/repo"
 	eStore "github.com/scienceol/studio/service/pkg/repo/environment"
@@ -1551,9 +1552,11 @@ func (m *materialImpl) notify(ctx context.Context, labUUID uuid.UUID, action eng
 	}
 
 	data := engine.WorkflowInfo{
-		Action:  action,
-		LabUUID: labUUID,
-		Data:    uuids,
+		Action:      action,
+		LabUUID:     labUUID,
+		Data:        uuids,
+		TraceParent: otel.TraceParent(ctx),
+		Baggage:     otel.Baggage(ctx),
 	}
 
 	dataB, _ := json.Marshal(data)
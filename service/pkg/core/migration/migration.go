@@ -0,0 +1,35 @@
+// Package migration reports the database migration status to administrators.
+package migration
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+)
+
+type Service struct{}
+
+func New() *Service {
+	return &Service{}
+}
+
+// Status 模型结构版本和版本化迁移的执行状态，仅管理员可查看
+func (s *Service) Status(ctx context.Context) (*migrate.Status, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return nil, code.NoPermission
+	}
+
+	status, err := migrate.GetStatus(ctx)
+	if err != nil {
+		logger.Errorf(ctx, "migration Status fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return status, nil
+}
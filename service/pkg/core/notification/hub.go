@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"sync"
+
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// subscriberBuffer 每个连接缓存的未发送通知数量，客户端读取过慢时丢弃新通知，
+// 未读数量和收件箱不受影响
+const subscriberBuffer = 16
+
+// hub fans notifications out to the streams open in this process
+type hub struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan *model.Notification]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[string]map[chan *model.Notification]struct{})}
+}
+
+// subscribe opens a stream for a user, the returned func closes it
+func (h *hub) subscribe(userID string) (<-chan *model.Notification, func()) {
+	ch := make(chan *model.Notification, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan *model.Notification]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[userID], ch)
+		if len(h.subs[userID]) == 0 {
+			delete(h.subs, userID)
+		}
+	}
+}
+
+// publish sends a notification to every stream of its user without blocking
+func (h *hub) publish(data *model.Notification) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[data.UserID] {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Package notification keeps the per-user in-app notification inbox fed by
+// alerting, approvals and workflow execution, and streams new notifications
+// to the open inbox connections of each user.
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	wshub "github.com/scienceol/studio/service/pkg/core/hub"
+	"github.com/scienceol/studio/service/pkg/core/notify"
+	"github.com/scienceol/studio/service/pkg/core/notify/events"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/notification"
+)
+
+// maxMarkRead 单次标记已读的最大数量
+const maxMarkRead = 500
+
+var (
+	streams      = newHub()
+	registryOnce sync.Once
+)
+
+// ListReq 收件箱列表请求
+type ListReq struct {
+	common.PageReq
+	Unread bool `form:"unread"` // 只返回未读通知
+}
+
+// MarkReadReq 标记已读请求，all 为 true 时标记全部未读通知
+type MarkReadReq struct {
+	UUIDs []uuid.UUID `json:"uuids"`
+	All   bool        `json:"all"`
+}
+
+// MarkReadResp 标记结果
+type MarkReadResp struct {
+	Marked int64 `json:"marked"`
+	Unread int64 `json:"unread"`
+}
+
+// UnreadResp 未读数量
+type UnreadResp struct {
+	Unread int64 `json:"unread"`
+}
+
+// Preference 一类通知的开关
+type Preference struct {
+	Kind    model.NotificationKind `json:"kind" binding:"required"`
+	Enabled bool                   `json:"enabled"`
+}
+
+// PreferencesReq 更新通知设置请求，未包含的类别保持不变
+type PreferencesReq struct {
+	Preferences []*Preference `json:"preferences" binding:"required,dive"`
+}
+
+type Service struct {
+	store     notification.NotificationRepo
+	msgCenter notify.MsgCenter
+}
+
+func New() *Service {
+	return &Service{
+		store:     notification.New(),
+		msgCenter: events.NewEvents(),
+	}
+}
+
+// List 获取当前用户的通知，最新的在前
+func (s *Service) List(ctx context.Context, req *ListReq) (*common.ListResp[*model.Notification], error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	req.Normalize()
+	datas, total, err := s.store.ListNotifications(ctx, userInfo.ID, req.Unread, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// Unread 获取当前用户的未读数量
+func (s *Service) Unread(ctx context.Context) (*UnreadResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	unread, err := s.store.CountUnread(ctx, userInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &UnreadResp{Unread: unread}, nil
+}
+
+// MarkRead 标记当前用户的通知为已读
+func (s *Service) MarkRead(ctx context.Context, req *MarkReadReq) (*MarkReadResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !req.All && len(req.UUIDs) == 0 {
+		return nil, code.ParamErr.WithMsg("uuids or all is required")
+	}
+	if len(req.UUIDs) > maxMarkRead {
+		return nil, code.ParamErr.WithMsgf("uuids must not exceed %d", maxMarkRead)
+	}
+
+	uuids := req.UUIDs
+	if req.All {
+		uuids = nil
+	}
+	marked, err := s.store.MarkRead(ctx, userInfo.ID, uuids)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.store.CountUnread(ctx, userInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &MarkReadResp{Marked: marked, Unread: unread}, nil
+}
+
+// Preferences 获取当前用户每类通知的开关，未设置的类别默认开启
+func (s *Service) Preferences(ctx context.Context) ([]*Preference, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	datas, err := s.store.ListPreferences(ctx, userInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mergePreferences(datas), nil
+}
+
+// UpdatePreferences 更新当前用户的通知开关
+func (s *Service) UpdatePreferences(ctx context.Context, req *PreferencesReq) ([]*Preference, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	datas := make([]*model.NotificationPreference, 0, len(req.Preferences))
+	for _, pref := range req.Preferences {
+		if !pref.Kind.Valid() {
+			return nil, code.ParamErr.WithMsgf("unknown notification kind %s", pref.Kind)
+		}
+		datas = append(datas, &model.NotificationPreference{
+			UserID:  userInfo.ID,
+			Kind:    pref.Kind,
+			Enabled: pref.Enabled,
+		})
+	}
+	if err := s.store.UpsertPreferences(ctx, datas); err != nil {
+		return nil, err
+	}
+	return s.Preferences(ctx)
+}
+
+// Subscribe 打开当前进程内的通知流，返回的函数关闭通知流。首次调用时订阅
+// 其他进程发送的通知
+func (s *Service) Subscribe(ctx context.Context, userID string) (<-chan *model.Notification, func()) {
+	registryOnce.Do(func() {
+		if err := s.msgCenter.Registry(context.Background(), notify.UserNotify, onBroadcast); err != nil {
+			logger.Errorf(ctx, "notification registry broadcast fail: %+v", err)
+		}
+	})
+	return streams.subscribe(userID)
+}
+
+// Missed 获取断线重连前错过的通知，lastEventID 为断线前收到的最后一条通知的 uuid。
+// 只补发保留期内的通知，lastEventID 无效或不属于当前用户时不补发
+func (s *Service) Missed(ctx context.Context, userID string, lastEventID string) ([]*model.Notification, error) {
+	after, err := uuid.FromString(lastEventID)
+	if err != nil || after.IsNil() {
+		return nil, nil
+	}
+	retention, maxLen := wshub.ReplayLimits()
+	datas, err := s.store.ListNotificationsAfter(ctx, userID, after, time.Now().Add(-retention), int(maxLen))
+	if errors.Is(err, code.RecordNotFound) {
+		return nil, nil
+	}
+	return datas, err
+}
+
+// onBroadcast 将 Sender 广播的通知转发到本进程的通知流
+func onBroadcast(ctx context.Context, msg string) error {
+	data := &struct {
+		Data *model.Notification `json:"data"`
+	}{}
+	if err := json.Unmarshal([]byte(msg), data); err != nil {
+		return err
+	}
+	if data.Data == nil {
+		return nil
+	}
+	streams.publish(data.Data)
+	return nil
+}
+
+func mergePreferences(datas []*model.NotificationPreference) []*Preference {
+	set := make(map[model.NotificationKind]bool, len(datas))
+	for _, data := range datas {
+		set[data.Kind] = data.Enabled
+	}
+
+	prefs := make([]*Preference, 0, len(model.NotificationKinds))
+	for _, kind := range model.NotificationKinds {
+		enabled, ok := set[kind]
+		prefs = append(prefs, &Preference{Kind: kind, Enabled: !ok || enabled})
+	}
+	return prefs
+}
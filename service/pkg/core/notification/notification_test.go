@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/core/notify"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub(t *testing.T) {
+	h := newHub()
+	alice, closeAlice := h.subscribe("alice")
+	bob, closeBob := h.subscribe("bob")
+	defer closeBob()
+
+	h.publish(&model.Notification{UserID: "alice", Title: "hello"})
+	assert.Equal(t, "hello", (<-alice).Title)
+	assert.Empty(t, bob)
+
+	// 读取过慢时丢弃，不阻塞发送
+	for i := 0; i < subscriberBuffer+5; i++ {
+		h.publish(&model.Notification{UserID: "bob"})
+	}
+	assert.Len(t, bob, subscriberBuffer)
+
+	closeAlice()
+	assert.NotContains(t, h.subs, "alice")
+}
+
+func TestOnBroadcast(t *testing.T) {
+	ch, closeFn := streams.subscribe("carol")
+	defer closeFn()
+
+	msg, _ := json.Marshal(&notify.SendMsg{
+		Channel: notify.UserNotify,
+		UserID:  "carol",
+		Data:    &model.Notification{UserID: "carol", Kind: model.NotificationExecution, Title: "done"},
+	})
+	assert.NoError(t, onBroadcast(t.Context(), string(msg)))
+	got := <-ch
+	assert.Equal(t, model.NotificationExecution, got.Kind)
+	assert.Equal(t, "done", got.Title)
+}
+
+func TestMergePreferences(t *testing.T) {
+	prefs := mergePreferences([]*model.NotificationPreference{
+		{Kind: model.NotificationAlert, Enabled: false},
+		{Kind: model.NotificationExecution, Enabled: true},
+	})
+	assert.Equal(t, []*Preference{
+		{Kind: model.NotificationAlert, Enabled: false},
+		{Kind: model.NotificationApproval, Enabled: true},
+		{Kind: model.NotificationExecution, Enabled: true},
+	}, prefs)
+}
+
+func TestDedup(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, dedup([]string{"a", "", "b", "a"}))
+}
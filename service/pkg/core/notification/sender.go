@@ -0,0 +1,119 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	wshub "github.com/scienceol/studio/service/pkg/core/hub"
+	"github.com/scienceol/studio/service/pkg/core/notify"
+	"github.com/scienceol/studio/service/pkg/core/notify/events"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/notification"
+)
+
+// Message 一条站内通知，每个接收者收到一份
+type Message struct {
+	Kind    model.NotificationKind
+	UserIDs []string
+	LabID   int64
+	LabUUID uuid.UUID
+	RefUUID uuid.UUID
+	Title   string
+	Body    string
+}
+
+// Sender 写入接收者的收件箱并推送到其打开的通知流，告警、审批和执行结束时调用
+type Sender struct {
+	store     notification.NotificationRepo
+	msgCenter notify.MsgCenter
+	wsHub     *wshub.Hub
+	baseDB    repo.IDOrUUIDTranslate
+}
+
+func NewSender() *Sender {
+	return &Sender{
+		store:     notification.New(),
+		msgCenter: events.NewEvents(),
+		wsHub:     wshub.Default(),
+		baseDB:    repo.NewBaseDB(),
+	}
+}
+
+// Send 发送通知，关闭了该类通知的用户不接收，发送失败只记录日志
+func (s *Sender) Send(ctx context.Context, msg *Message) {
+	userIDs := dedup(msg.UserIDs)
+	if len(userIDs) == 0 {
+		return
+	}
+
+	disabled, err := s.store.DisabledUsers(ctx, msg.Kind, userIDs)
+	if err != nil {
+		return
+	}
+	if msg.LabUUID.IsNil() && msg.LabID != 0 {
+		msg.LabUUID = s.baseDB.ID2UUID(ctx, &model.Laboratory{}, msg.LabID)[msg.LabID]
+	}
+
+	datas := make([]*model.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if disabled[userID] {
+			continue
+		}
+		datas = append(datas, &model.Notification{
+			UserID:  userID,
+			Kind:    msg.Kind,
+			Title:   msg.Title,
+			Body:    msg.Body,
+			LabID:   msg.LabID,
+			LabUUID: msg.LabUUID,
+			RefUUID: msg.RefUUID,
+		})
+	}
+	if err := s.store.CreateNotifications(ctx, datas); err != nil {
+		return
+	}
+
+	for _, data := range datas {
+		if err := s.msgCenter.Broadcast(ctx, &notify.SendMsg{
+			Channel: notify.UserNotify,
+			LabUUID: data.LabUUID,
+			UserID:  data.UserID,
+			Data:    data,
+		}); err != nil {
+			logger.Warnf(ctx, "broadcast notification fail uuid: %s, user: %s, err: %+v", data.UUID, data.UserID, err)
+		}
+		s.wsHub.Publish(ctx, wshub.NotificationsTopic(data.UserID), "notification", data)
+	}
+}
+
+// LabMembers 返回实验室的成员，用于通知整个实验室
+func (s *Sender) LabMembers(ctx context.Context, labID int64) []string {
+	members := make([]*model.LaboratoryMember, 0)
+	if err := s.baseDB.FindDatas(ctx, &members, map[string]any{
+		"lab_id": labID,
+	}, "user_id"); err != nil {
+		logger.Warnf(ctx, "notification list lab members fail lab: %d, err: %+v", labID, err)
+		return nil
+	}
+
+	userIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	return userIDs
+}
+
+func dedup(userIDs []string) []string {
+	seen := make(map[string]bool, len(userIDs))
+	res := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID == "" || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		res = append(res, userID)
+	}
+	return res
+}
@@ -18,6 +18,11 @@ type Action string
 const (
 	MaterialModify Action = "material-modify"
 	WorkflowRun    Action = "workflow-run"
+	DeviceAlert    Action = "device-alert"
+	UserMention    Action = "user-mention" // 通知被提及的用户，UserID 为被提及的用户
+	StudioEvent    Action = "studio-event" // 对外发布的事件，Data 为 eventschema.Event
+	UserNotify     Action = "user-notify"  // 站内通知，UserID 为接收者，Data 为 model.Notification
+	HubPublish     Action = "hub-publish"  // WebSocket hub 主题消息，Data 为 hub.Message
 )
 
 type SendMsg struct {
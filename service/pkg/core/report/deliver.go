@@ -0,0 +1,123 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const base64LineLen = 76
+
+// rendered is a report file ready for delivery
+type rendered struct {
+	sub         *model.ReportSubscription
+	fileName    string
+	contentType string
+	data        []byte
+	periodStart time.Time
+	periodEnd   time.Time
+}
+
+// send delivers a rendered report through the channel of its subscription
+func (s *Service) send(ctx context.Context, r *rendered) error {
+	switch r.sub.Channel {
+	case model.ReportChannelEmail:
+		return sendEmail(r)
+	case model.ReportChannelWebhook:
+		return s.sendWebhook(ctx, r)
+	}
+	return fmt.Errorf("unknown channel %s", r.sub.Channel)
+}
+
+// sendWebhook posts the report file to the target URL
+func (s *Service) sendWebhook(ctx context.Context, r *rendered) error {
+	resp, err := s.client.R().SetContext(ctx).
+		SetHeader("Content-Type", r.contentType).
+		SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": r.fileName})).
+		SetHeader("X-Report-Subscription", r.sub.UUID.String()).
+		SetHeader("X-Report-Period-Start", r.periodStart.Format(time.RFC3339)).
+		SetHeader("X-Report-Period-End", r.periodEnd.Format(time.RFC3339)).
+		SetBody(r.data).
+		Post(r.sub.Target)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// sendEmail mails the report as an attachment
+func sendEmail(r *rendered) error {
+	conf := config.GetStudioConfig().Report.SMTP
+	if conf.Host == "" {
+		return code.ReportChannelDisabledErr.WithMsg("smtp is not configured")
+	}
+	msg, err := emailMessage(conf.From, r)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if conf.Username != "" {
+		auth = smtp.PlainAuth("", conf.Username, conf.Password, conf.Host)
+	}
+	addr := conf.Host + ":" + strconv.Itoa(conf.Port)
+	return smtp.SendMail(addr, auth, conf.From, []string{r.sub.Target}, msg)
+}
+
+// emailMessage builds a multipart message with a text body and the report attached
+func emailMessage(from string, r *rendered) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	subject := fmt.Sprintf("%s %s ~ %s", r.sub.Name,
+		r.periodStart.Format(time.DateTime), r.periodEnd.Format(time.DateTime))
+
+	fmt.Fprintf(buf, "From: %s\r\n", from)
+	fmt.Fprintf(buf, "To: %s\r\n", r.sub.Target)
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", w.Boundary())
+
+	body, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/plain; charset=UTF-8"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(body, "报表订阅 %s 的附件为 %s 至 %s 的数据。\r\n", r.sub.Name,
+		r.periodStart.Format(time.DateTime), r.periodEnd.Format(time.DateTime))
+
+	attachment, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(r.contentType, map[string]string{"name": r.fileName})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": r.fileName})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(r.data)
+	for len(encoded) > base64LineLen {
+		fmt.Fprintf(attachment, "%s\r\n", encoded[:base64LineLen])
+		encoded = encoded[base64LineLen:]
+	}
+	fmt.Fprintf(attachment, "%s\r\n", encoded)
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,167 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	// A4 横向，Courier 8pt 每个字符宽 4.8pt
+	pdfPageWidth    = 842
+	pdfPageHeight   = 595
+	pdfMargin       = 36
+	pdfFontSize     = 8
+	pdfLineHeight   = 11
+	pdfLineChars    = (pdfPageWidth - 2*pdfMargin) * 10 / 48
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	maxColumnChars  = 40
+)
+
+// table is the rendered content of a report
+type table struct {
+	title  string
+	header []string
+	rows   [][]string
+}
+
+// render encodes the table in the given format and returns the content type
+func render(t *table, format model.ReportFormat) ([]byte, string, error) {
+	if format == model.ReportFormatPDF {
+		return renderPDF(t), "application/pdf", nil
+	}
+	data, err := renderCSV(t)
+	return data, "text/csv", err
+}
+
+func renderCSV(t *table) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.Write(t.header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(t.rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderPDF lays the table out as fixed width text lines using the built-in
+// Courier font. Standard fonts only cover latin characters, others are
+// replaced by '?'; use CSV when the data contains CJK text
+func renderPDF(t *table) []byte {
+	lines := append([]string{t.title, ""}, textTable(t)...)
+	var pages [][]string
+	for len(lines) > 0 {
+		n := min(len(lines), pdfLinesPerPage)
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+
+	// 对象编号：1 catalog，2 pages，3 font，之后每页依次为 page 与 content
+	var objects []string
+	kids := make([]string, 0, len(pages))
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*i))
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		content := &strings.Builder{}
+		fmt.Fprintf(content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(content, "(%s) '\n", pdfEscape(line))
+		}
+		content.WriteString("ET")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// textTable formats the header and rows as aligned columns
+func textTable(t *table) []string {
+	widths := make([]int, len(t.header))
+	measure := func(row []string) {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = min(max(widths[i], len([]rune(cell))), maxColumnChars)
+			}
+		}
+	}
+	measure(t.header)
+	for _, row := range t.rows {
+		measure(row)
+	}
+
+	format := func(row []string) string {
+		line := &strings.Builder{}
+		for i, cell := range row {
+			if i >= len(widths) {
+				break
+			}
+			runes := []rune(cell)
+			if len(runes) > widths[i] {
+				runes = runes[:widths[i]]
+			}
+			line.WriteString(string(runes))
+			line.WriteString(strings.Repeat(" ", widths[i]-len(runes)+2))
+		}
+		out := []rune(strings.TrimRight(line.String(), " "))
+		if len(out) > pdfLineChars {
+			out = out[:pdfLineChars]
+		}
+		return string(out)
+	}
+
+	lines := make([]string, 0, len(t.rows)+2)
+	lines = append(lines, format(t.header))
+	total := 0
+	for _, w := range widths {
+		total += w + 2
+	}
+	lines = append(lines, strings.Repeat("-", min(max(total-2, 0), pdfLineChars)))
+	for _, row := range t.rows {
+		lines = append(lines, format(row))
+	}
+	return lines
+}
+
+func pdfEscape(s string) string {
+	b := &strings.Builder{}
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	tb := &table{title: "daily (runs)", header: []string{"name", "status"}}
+	for i := 0; i < pdfLinesPerPage+10; i++ {
+		tb.rows = append(tb.rows, []string{fmt.Sprintf("wf-%d", i), "success"})
+	}
+	tb.rows = append(tb.rows, []string{"混合, name", "failed"})
+
+	data, contentType, err := render(tb, model.ReportFormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv", contentType)
+	assert.True(t, bytes.HasPrefix(data, []byte("name,status\nwf-0,success\n")))
+	assert.True(t, bytes.HasSuffix(data, []byte("\"混合, name\",failed\n")))
+
+	data, contentType, err = render(tb, model.ReportFormatPDF)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", contentType)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	assert.Contains(t, string(data), "/Count 2 ")
+	assert.Contains(t, string(data), `(daily \(runs\)) '`)
+	assert.Contains(t, string(data), "(??, name  failed) '")
+
+	// xref 中的偏移指向对应的对象
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if assert.NotNil(t, m) {
+		xref, _ := strconv.Atoi(string(m[1]))
+		assert.True(t, bytes.HasPrefix(data[xref:], []byte("xref\n0 8\n")))
+		offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(data[xref:], -1)
+		assert.Len(t, offsets, 7)
+		for i, o := range offsets {
+			offset, _ := strconv.Atoi(string(o[1]))
+			assert.True(t, bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+		}
+	}
+}
@@ -0,0 +1,555 @@
+// Package report delivers saved history views and lab statistics on a daily
+// or weekly schedule: a background job renders each due subscription as CSV or
+// PDF, keeps the file in object storage and sends it by email or webhook,
+// recording every delivery.
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/report"
+	"github.com/scienceol/studio/service/pkg/repo/savedview"
+)
+
+const (
+	defaultMaxRows   = 5000
+	dueBatch         = 50
+	webhookTimeout   = 30 * time.Second
+	deliverEvery     = 5 * time.Minute
+	reportTimeLayout = time.DateTime
+)
+
+// CreateReq 创建报表订阅
+type CreateReq struct {
+	LabUUID  uuid.UUID            `json:"lab_uuid" binding:"required"`
+	Name     string               `json:"name" binding:"required,max=120"`
+	Source   model.ReportSource   `json:"source" binding:"required"`     // saved_view | lab_stats
+	ViewUUID *uuid.UUID           `json:"view_uuid"`                     // source 为 saved_view 时必填
+	Schedule model.ReportSchedule `json:"schedule" binding:"required"`   // daily | weekly
+	Weekday  int                  `json:"weekday" binding:"min=0,max=6"` // 每周报表的发送日，0 为周日
+	Hour     int                  `json:"hour" binding:"min=0,max=23"`
+	Format   model.ReportFormat   `json:"format" binding:"required"`  // csv | pdf
+	Channel  model.ReportChannel  `json:"channel" binding:"required"` // email | webhook
+	Target   string               `json:"target" binding:"required,max=512"`
+}
+
+// UpdateReq 更新报表订阅，报表来源不可修改
+type UpdateReq struct {
+	Name     string               `json:"name" binding:"required,max=120"`
+	Schedule model.ReportSchedule `json:"schedule" binding:"required"`
+	Weekday  int                  `json:"weekday" binding:"min=0,max=6"`
+	Hour     int                  `json:"hour" binding:"min=0,max=23"`
+	Format   model.ReportFormat   `json:"format" binding:"required"`
+	Channel  model.ReportChannel  `json:"channel" binding:"required"`
+	Target   string               `json:"target" binding:"required,max=512"`
+	Enabled  bool                 `json:"enabled"`
+}
+
+// ListReq 查询报表订阅
+type ListReq struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// ReportFile 报表文件
+type ReportFile struct {
+	Delivery    *model.ReportDelivery
+	ContentType string
+	Reader      io.ReadCloser
+}
+
+type Service struct {
+	store    report.ReportRepo
+	views    savedview.SavedViewRepo
+	history  history.HistoryRepo
+	storage  storage.Storage
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	client   *resty.Client
+}
+
+func New() *Service {
+	return &Service{
+		store:    report.New(),
+		views:    savedview.New(),
+		history:  history.New(),
+		storage:  storage.Default(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		client:   otel.RestyClientWithTracing().SetTimeout(webhookTimeout),
+	}
+}
+
+// Create 创建订阅，订阅保存的视图时视图须对当前用户可见且属于同一实验室
+func (s *Service) Create(ctx context.Context, req *CreateReq) (*model.ReportSubscription, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDelivery(req.Schedule, req.Format, req.Channel, req.Target); err != nil {
+		return nil, err
+	}
+
+	data := &model.ReportSubscription{
+		LabID:    labID,
+		OwnerID:  userInfo.ID,
+		Name:     strings.TrimSpace(req.Name),
+		Source:   req.Source,
+		Schedule: req.Schedule,
+		Weekday:  req.Weekday,
+		Hour:     req.Hour,
+		Format:   req.Format,
+		Channel:  req.Channel,
+		Target:   strings.TrimSpace(req.Target),
+		Enabled:  true,
+	}
+	switch req.Source {
+	case model.ReportSourceSavedView:
+		if req.ViewUUID == nil {
+			return nil, code.ParamErr.WithMsg("view_uuid is required for saved_view reports")
+		}
+		view, err := s.views.GetViewByUUID(ctx, *req.ViewUUID)
+		if err != nil {
+			return nil, err
+		}
+		if view.LabID != labID || (view.OwnerID != userInfo.ID && !view.Shared) {
+			return nil, code.NoPermission
+		}
+		data.ViewID = &view.ID
+		data.ViewUUID = &view.UUID
+	case model.ReportSourceLabStats:
+	default:
+		return nil, code.ParamErr.WithMsgf("unknown source %s", req.Source)
+	}
+	data.NextRunAt = data.Schedule.NextRun(time.Now(), time.Weekday(data.Weekday), data.Hour)
+
+	if err := s.store.CreateSubscription(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// List 获取当前用户在实验室的订阅
+func (s *Service) List(ctx context.Context, req *ListReq) ([]*model.ReportSubscription, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListSubscriptions(ctx, labID, userInfo.ID)
+}
+
+// Get 获取订阅，仅创建者可以查看
+func (s *Service) Get(ctx context.Context, subUUID uuid.UUID) (*model.ReportSubscription, error) {
+	return s.ownSubscription(ctx, subUUID)
+}
+
+// Update 更新订阅，修改发送时间后重新计算下次发送时间
+func (s *Service) Update(ctx context.Context, subUUID uuid.UUID, req *UpdateReq) (*model.ReportSubscription, error) {
+	data, err := s.ownSubscription(ctx, subUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDelivery(req.Schedule, req.Format, req.Channel, req.Target); err != nil {
+		return nil, err
+	}
+
+	reschedule := req.Schedule != data.Schedule || req.Weekday != data.Weekday ||
+		req.Hour != data.Hour || (req.Enabled && !data.Enabled)
+	data.Name = strings.TrimSpace(req.Name)
+	data.Schedule = req.Schedule
+	data.Weekday = req.Weekday
+	data.Hour = req.Hour
+	data.Format = req.Format
+	data.Channel = req.Channel
+	data.Target = strings.TrimSpace(req.Target)
+	data.Enabled = req.Enabled
+	if reschedule {
+		data.NextRunAt = data.Schedule.NextRun(time.Now(), time.Weekday(data.Weekday), data.Hour)
+	}
+	if err := s.store.UpdateSubscription(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete 删除订阅，已生成的报表与发送记录保留
+func (s *Service) Delete(ctx context.Context, subUUID uuid.UUID) error {
+	data, err := s.ownSubscription(ctx, subUUID)
+	if err != nil {
+		return err
+	}
+	return s.store.DeleteSubscription(ctx, data.ID)
+}
+
+// ListDeliveries 获取订阅的发送记录
+func (s *Service) ListDeliveries(ctx context.Context, subUUID uuid.UUID, req *common.PageReq) (*common.ListResp[*model.ReportDelivery], error) {
+	data, err := s.ownSubscription(ctx, subUUID)
+	if err != nil {
+		return nil, err
+	}
+	req.Normalize()
+
+	datas, total, err := s.store.ListDeliveries(ctx, data.ID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewListResp(datas, total, req.Page, req.PageSize), nil
+}
+
+// Download 下载已生成的报表，实验室成员可以下载
+func (s *Service) Download(ctx context.Context, deliveryUUID uuid.UUID) (*ReportFile, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetDeliveryByUUID(ctx, deliveryUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMemberByID(ctx, userInfo.ID, data.LabID); err != nil {
+		return nil, err
+	}
+	if data.StorageKey == "" {
+		return nil, code.ReportDeliveryMissingErr
+	}
+
+	reader, err := s.storage.Open(ctx, data.StorageKey)
+	if err == storage.ErrNotFound {
+		return nil, code.ReportDeliveryMissingErr
+	}
+	if err != nil {
+		logger.Errorf(ctx, "open report fail delivery: %s, err: %+v", data.UUID, err)
+		return nil, code.FileStorageErr.WithErr(err)
+	}
+	contentType := "text/csv"
+	if data.Format == model.ReportFormatPDF {
+		contentType = "application/pdf"
+	}
+	return &ReportFile{Delivery: data, ContentType: contentType, Reader: reader}, nil
+}
+
+// Deliver 生成并发送到期的订阅报表，单个订阅失败记录在发送记录中不影响其他订阅
+func (s *Service) Deliver(ctx context.Context) error {
+	now := time.Now()
+	subs, err := s.store.ListDueSubscriptions(ctx, now, dueBatch)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if err := s.deliver(ctx, sub, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver 处理一个订阅，返回的错误仅为记录发送结果失败
+func (s *Service) deliver(ctx context.Context, sub *model.ReportSubscription, now time.Time) error {
+	end := sub.NextRunAt
+	start := end.Add(-sub.Schedule.Period())
+	delivery := &model.ReportDelivery{
+		BaseModel:      model.BaseModel{UUID: uuid.New()},
+		SubscriptionID: sub.ID,
+		LabID:          sub.LabID,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Format:         sub.Format,
+		Channel:        sub.Channel,
+		Target:         sub.Target,
+		Status:         model.ReportDeliveryFailed,
+	}
+
+	if err := s.renderAndSend(ctx, sub, delivery); err != nil {
+		logger.Warnf(ctx, "deliver report fail subscription: %s, err: %+v", sub.UUID, err)
+		delivery.Error = err.Error()
+	} else {
+		delivery.Status = model.ReportDeliverySuccess
+		delivery.DeliveredAt = &now
+	}
+
+	// 停机错过的发送不再补发，从当前时间计算下次发送时间
+	sub.LastRunAt = &now
+	sub.NextRunAt = sub.Schedule.NextRun(now, time.Weekday(sub.Weekday), sub.Hour)
+	return s.store.FinishSubscriptionRun(ctx, sub, delivery)
+}
+
+func (s *Service) renderAndSend(ctx context.Context, sub *model.ReportSubscription, delivery *model.ReportDelivery) error {
+	t, err := s.buildTable(ctx, sub, delivery.PeriodStart, delivery.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	data, contentType, err := render(t, sub.Format)
+	if err != nil {
+		return err
+	}
+
+	delivery.FileName = fmt.Sprintf("report_%s_%s.%s", sub.UUID.String()[:8],
+		delivery.PeriodEnd.Format("20060102"), sub.Format)
+	delivery.Size = int64(len(data))
+	delivery.Rows = len(t.rows)
+	key := fmt.Sprintf("reports/%d/%s/%s/%s", sub.LabID, sub.UUID, delivery.UUID, delivery.FileName)
+	if err := s.storage.Put(ctx, key, bytes.NewReader(data), delivery.Size); err != nil {
+		return fmt.Errorf("store report: %w", err)
+	}
+	delivery.StorageKey = key
+
+	return s.send(ctx, &rendered{
+		sub:         sub,
+		fileName:    delivery.FileName,
+		contentType: contentType,
+		data:        data,
+		periodStart: delivery.PeriodStart,
+		periodEnd:   delivery.PeriodEnd,
+	})
+}
+
+// buildTable 查询报表周期内的数据
+func (s *Service) buildTable(ctx context.Context, sub *model.ReportSubscription, start, end time.Time) (*table, error) {
+	title := fmt.Sprintf("%s  %s ~ %s", sub.Name, start.Format(reportTimeLayout), end.Format(reportTimeLayout))
+	if sub.Source == model.ReportSourceLabStats {
+		stats, err := s.history.GetLabStats(ctx, sub.LabID, &start, &end, nil)
+		if err != nil {
+			return nil, err
+		}
+		utilization, err := s.history.GetDeviceUtilization(ctx, sub.LabID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		return statsTable(title, stats, utilization), nil
+	}
+
+	if sub.ViewID == nil {
+		return nil, fmt.Errorf("subscription has no saved view")
+	}
+	view, err := s.views.GetView(ctx, *sub.ViewID)
+	if err == code.RecordNotFound {
+		return nil, fmt.Errorf("saved view has been deleted")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 报表周期决定时间范围，视图中的其他过滤条件生效
+	params := model.NewHistoryQueryParams()
+	params.LabID = sub.LabID
+	params.StartTime = &start
+	params.EndTime = &end
+	params.PageSize = maxRows()
+	filters := view.Filters.Data()
+	filters.Apply(params, map[string]bool{"start_time": true, "end_time": true, "page_size": true}, end)
+
+	switch view.Target {
+	case model.SavedViewWorkflow:
+		datas, count, err := s.history.ListWorkflowExecutions(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return workflowTable(truncatedTitle(title, len(datas), count.Total), datas), nil
+	case model.SavedViewDeviceEvent:
+		datas, count, err := s.history.ListDeviceEvents(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return deviceEventTable(truncatedTitle(title, len(datas), count.Total), datas), nil
+	}
+	return nil, fmt.Errorf("unknown view target %s", view.Target)
+}
+
+// statsTable lists the lab statistics, followed by the lab utilization and the
+// utilization of each device in rank order
+func statsTable(title string, stats *model.HistoryStats, utilization *model.UtilizationReport) *table {
+	t := &table{
+		title:  title,
+		header: []string{"metric", "value"},
+		rows: [][]string{
+			{"total_executions", strconv.FormatInt(stats.TotalExecutions, 10)},
+			{"successful_count", strconv.FormatInt(stats.SuccessfulCount, 10)},
+			{"failed_count", strconv.FormatInt(stats.FailedCount, 10)},
+			{"success_rate", strconv.FormatFloat(stats.SuccessRate, 'f', 2, 64)},
+			{"average_duration_ms", strconv.FormatFloat(stats.AverageDurationMs, 'f', 0, 64)},
+			{"total_actions_count", strconv.FormatInt(stats.TotalActionsCount, 10)},
+			{"total_device_events", strconv.FormatInt(stats.TotalDeviceEvents, 10)},
+			{"material_usages", strconv.FormatInt(stats.MaterialUsages, 10)},
+			{"materials_consumed", strconv.FormatInt(stats.MaterialsConsumed, 10)},
+			{"device_utilization", strconv.FormatFloat(utilization.Utilization, 'f', 2, 64)},
+		},
+	}
+	for _, device := range utilization.Devices {
+		t.rows = append(t.rows,
+			[]string{"device_utilization:" + device.DeviceName, strconv.FormatFloat(device.Utilization, 'f', 2, 64)},
+			[]string{"device_busy_ms:" + device.DeviceName, strconv.FormatInt(device.BusyMs, 10)},
+			[]string{"device_idle_ms:" + device.DeviceName, strconv.FormatInt(device.IdleMs, 10)},
+		)
+	}
+	return t
+}
+
+func workflowTable(title string, datas []*model.WorkflowExecutionHistory) *table {
+	t := &table{
+		title: title,
+		header: []string{"execution_uuid", "workflow_name", "status", "steps_completed", "steps_total",
+			"duration_ms", "started_at", "completed_at", "user_id"},
+	}
+	for _, d := range datas {
+		completedAt := ""
+		if d.CompletedAt != nil {
+			completedAt = d.CompletedAt.Format(reportTimeLayout)
+		}
+		t.rows = append(t.rows, []string{
+			d.UUID.String(),
+			d.WorkflowName,
+			string(d.Status),
+			strconv.Itoa(d.StepsCompleted),
+			strconv.Itoa(d.StepsTotal),
+			strconv.FormatInt(d.DurationMs, 10),
+			d.StartedAt.Format(reportTimeLayout),
+			completedAt,
+			d.UserID,
+		})
+	}
+	return t
+}
+
+func deviceEventTable(title string, datas []*model.DeviceEventHistory) *table {
+	t := &table{
+		title:  title,
+		header: []string{"event_uuid", "device_uuid", "event_type", "timestamp", "event_data"},
+	}
+	for _, d := range datas {
+		t.rows = append(t.rows, []string{
+			d.UUID.String(),
+			d.DeviceUUID.String(),
+			string(d.EventType),
+			d.Timestamp.Format(reportTimeLayout),
+			string(d.EventData),
+		})
+	}
+	return t
+}
+
+func truncatedTitle(title string, rows int, total int64) string {
+	if int64(rows) < total {
+		return fmt.Sprintf("%s  (%d of %d records)", title, rows, total)
+	}
+	return title
+}
+
+// validateDelivery 校验发送周期、格式与渠道，邮件渠道须已配置 SMTP
+func validateDelivery(schedule model.ReportSchedule, format model.ReportFormat, channel model.ReportChannel, target string) error {
+	if schedule != model.ReportScheduleDaily && schedule != model.ReportScheduleWeekly {
+		return code.ParamErr.WithMsgf("unknown schedule %s", schedule)
+	}
+	if format != model.ReportFormatCSV && format != model.ReportFormatPDF {
+		return code.ParamErr.WithMsgf("unknown format %s", format)
+	}
+
+	target = strings.TrimSpace(target)
+	switch channel {
+	case model.ReportChannelEmail:
+		addr, err := mail.ParseAddress(target)
+		if err != nil || addr.Address != target {
+			return code.ParamErr.WithMsg("target must be an email address")
+		}
+		if config.GetStudioConfig().Report.SMTP.Host == "" {
+			return code.ReportChannelDisabledErr.WithMsg("email delivery is not configured")
+		}
+	case model.ReportChannelWebhook:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return code.ParamErr.WithMsg("target must be an http(s) URL")
+		}
+	default:
+		return code.ParamErr.WithMsgf("unknown channel %s", channel)
+	}
+	return nil
+}
+
+func maxRows() int {
+	if n := config.GetStudioConfig().Report.MaxRows; n > 0 {
+		return n
+	}
+	return defaultMaxRows
+}
+
+func (s *Service) ownSubscription(ctx context.Context, subUUID uuid.UUID) (*model.ReportSubscription, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetSubscriptionByUUID(ctx, subUUID)
+	if err != nil {
+		return nil, err
+	}
+	if data.OwnerID != userInfo.ID {
+		return nil, code.NoPermission
+	}
+	return data, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	if err := s.checkMemberByID(ctx, userID, labID); err != nil {
+		return 0, err
+	}
+	return labID, nil
+}
+
+func (s *Service) checkMemberByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+// RegisterJob 注册报表订阅发送任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "report_subscription",
+		Description:  "生成并发送到期的报表订阅",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     deliverEvery.String(),
+		Timeout:      30 * time.Minute,
+		Run:          s.Deliver,
+	})
+}
@@ -0,0 +1,424 @@
+// Package rule evaluates lab defined conditions over device events, raising
+// and resolving alerts and notifying the channels of each rule.
+package rule
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	coreAlert "github.com/scienceol/studio/service/pkg/core/alert"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/alert"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/rule"
+	"gorm.io/datatypes"
+)
+
+const (
+	evaluateInterval = 30 * time.Second
+
+	minWindow   = 10 * time.Second
+	maxWindow   = 24 * time.Hour
+	maxChannels = 10
+
+	maxEscalateAfter = 7 * 24 * 60
+)
+
+// metricPattern 指标为 event_data 中以 . 分隔的字段路径，例如 sensor.temperature
+var metricPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// RuleReq 创建或更新规则
+type RuleReq struct {
+	LabUUID     uuid.UUID             `json:"lab_uuid"`
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description"`
+	Enabled     *bool                 `json:"enabled"`
+	DeviceUUID  *uuid.UUID            `json:"device_uuid"`
+	EventType   model.DeviceEventType `json:"event_type"`
+	Metric      string                `json:"metric"`
+	Aggregation model.TelemetryAgg    `json:"aggregation" binding:"required"`
+	Operator    model.RuleOperator    `json:"operator" binding:"required"`
+	Threshold   float64               `json:"threshold"`
+	WindowSec   int                   `json:"window_sec" binding:"required"`
+	Severity    model.AlertSeverity   `json:"severity"`
+	Channels    []model.AlertChannel  `json:"channels"`
+	// 告警触发后超过该分钟数仍未确认时通知 escalation_channels，0 表示不升级
+	EscalateAfterMin   int                  `json:"escalate_after_min"`
+	EscalationChannels []model.AlertChannel `json:"escalation_channels"`
+}
+
+// TestResp 规则试运行结果，不会产生告警
+type TestResp struct {
+	WindowStart time.Time               `json:"window_start"`
+	WindowEnd   time.Time               `json:"window_end"`
+	Results     []*model.RuleEvaluation `json:"results"`
+}
+
+type Engine struct {
+	store    rule.RuleRepo
+	alerts   alert.AlertRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	notifier *coreAlert.Notifier
+}
+
+func New() *Engine {
+	return &Engine{
+		store:    rule.New(),
+		alerts:   alert.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		notifier: coreAlert.NewNotifier(),
+	}
+}
+
+// Create 创建规则
+func (e *Engine) Create(ctx context.Context, req *RuleReq) (*model.DeviceRule, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := e.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &model.DeviceRule{
+		LabID:     labID,
+		Enabled:   true,
+		CreatedBy: userInfo.ID,
+	}
+	if err := apply(data, req); err != nil {
+		return nil, err
+	}
+	if err := e.store.CreateRule(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Update 更新规则，规则条件变化后由下一轮评估重新判断已有告警
+func (e *Engine) Update(ctx context.Context, ruleUUID uuid.UUID, req *RuleReq) (*model.DeviceRule, error) {
+	data, err := e.Get(ctx, ruleUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := apply(data, req); err != nil {
+		return nil, err
+	}
+	if err := e.store.UpdateRule(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete 删除规则，其未恢复的告警一并标记为已恢复
+func (e *Engine) Delete(ctx context.Context, ruleUUID uuid.UUID) error {
+	data, err := e.Get(ctx, ruleUUID)
+	if err != nil {
+		return err
+	}
+
+	if err := e.store.DeleteRule(ctx, data.ID); err != nil {
+		return err
+	}
+	opens, err := e.alerts.ListOpenByRule(ctx, data.ID)
+	if err != nil {
+		return err
+	}
+	for _, item := range opens {
+		if _, err := e.alerts.Resolve(ctx, item, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get 获取规则，仅实验室成员可以查看
+func (e *Engine) Get(ctx context.Context, ruleUUID uuid.UUID) (*model.DeviceRule, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := e.store.GetRuleByUUID(ctx, ruleUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := e.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  data.LabID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return nil, code.NoPermission
+	}
+	return data, nil
+}
+
+// List 获取实验室所有规则
+func (e *Engine) List(ctx context.Context, labUUID uuid.UUID) ([]*model.DeviceRule, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := e.checkMember(ctx, userInfo.ID, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return e.store.ListRules(ctx, labID)
+}
+
+// Test 使用当前数据试运行规则，返回每个设备的聚合值及是否命中
+func (e *Engine) Test(ctx context.Context, req *RuleReq) (*TestResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := e.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &model.DeviceRule{LabID: labID}
+	if err := apply(data, req); err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	start := end.Add(-time.Duration(data.WindowSec) * time.Second)
+	results, err := e.store.Evaluate(ctx, data, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range results {
+		item.Matched = data.Operator.Match(item.Value, data.Threshold)
+	}
+	return &TestResp{
+		WindowStart: start,
+		WindowEnd:   end,
+		Results:     results,
+	}, nil
+}
+
+// EvaluateAll 评估所有启用的规则，命中的设备产生告警，不再命中的告警自动恢复
+func (e *Engine) EvaluateAll(ctx context.Context) error {
+	rules, err := e.store.ListEnabledRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, data := range rules {
+		if err := e.evaluate(ctx, data); err != nil {
+			logger.Errorf(ctx, "evaluate device rule fail id: %d, err: %+v", data.ID, err)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) evaluate(ctx context.Context, data *model.DeviceRule) error {
+	end := time.Now()
+	results, err := e.store.Evaluate(ctx, data, end.Add(-time.Duration(data.WindowSec)*time.Second), end)
+	if err != nil {
+		return err
+	}
+	opens, err := e.alerts.ListOpenByRule(ctx, data.ID)
+	if err != nil {
+		return err
+	}
+
+	openMap := make(map[uuid.UUID]*model.Alert, len(opens))
+	for _, item := range opens {
+		openMap[item.DeviceUUID] = item
+	}
+
+	for _, result := range results {
+		if !data.Operator.Match(result.Value, data.Threshold) {
+			continue
+		}
+
+		if open, ok := openMap[result.DeviceUUID]; ok {
+			delete(openMap, result.DeviceUUID)
+			if err := e.alerts.UpdateValue(ctx, open.ID, result.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		silenced, err := e.alerts.IsSilenced(ctx, data.LabID, result.DeviceUUID, end)
+		if err != nil {
+			return err
+		}
+		fired := &model.Alert{
+			LabID:      data.LabID,
+			RuleID:     data.ID,
+			RuleName:   data.Name,
+			DeviceID:   result.DeviceID,
+			DeviceUUID: result.DeviceUUID,
+			Severity:   data.Severity,
+			Status:     model.AlertFiring,
+			Value:      result.Value,
+			Threshold:  data.Threshold,
+			Message:    describe(data, result.Value),
+			Silenced:   silenced,
+			FiredAt:    end,
+		}
+		if err := e.alerts.CreateAlert(ctx, fired); err != nil {
+			return err
+		}
+		e.notifier.Notify(ctx, coreAlert.AlertEventFired, data, fired, data.Channels)
+	}
+
+	// 剩余的告警对应的设备不再满足条件
+	for _, open := range openMap {
+		ok, err := e.alerts.Resolve(ctx, open, "")
+		if err != nil {
+			return err
+		}
+		if ok {
+			e.notifier.Notify(ctx, coreAlert.AlertEventResolved, data, open, data.Channels)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) checkMember(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := e.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := e.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+// apply 校验请求并写入规则
+func apply(data *model.DeviceRule, req *RuleReq) error {
+	if req.EventType == "" {
+		req.EventType = model.DeviceEventDataReceived
+	}
+	if req.Severity == "" {
+		req.Severity = model.AlertSeverityWarning
+	}
+
+	switch req.Aggregation {
+	case model.TelemetryAggAvg, model.TelemetryAggMin, model.TelemetryAggMax, model.TelemetryAggSum:
+		if req.Metric == "" {
+			return code.RuleInvalidErr.WithMsgf("metric is required for aggregation %s", req.Aggregation)
+		}
+	case model.TelemetryAggCount:
+	default:
+		return code.RuleInvalidErr.WithMsgf("unknown aggregation: %s", req.Aggregation)
+	}
+	if req.Metric != "" && !metricPattern.MatchString(req.Metric) {
+		return code.RuleInvalidErr.WithMsgf("invalid metric: %s", req.Metric)
+	}
+	if !req.Operator.Valid() {
+		return code.RuleInvalidErr.WithMsgf("unknown operator: %s", req.Operator)
+	}
+	switch req.Severity {
+	case model.AlertSeverityInfo, model.AlertSeverityWarning, model.AlertSeverityCritical:
+	default:
+		return code.RuleInvalidErr.WithMsgf("unknown severity: %s", req.Severity)
+	}
+
+	window := time.Duration(req.WindowSec) * time.Second
+	if window < minWindow || window > maxWindow {
+		return code.RuleInvalidErr.WithMsgf("window_sec must be between %d and %d", int(minWindow.Seconds()), int(maxWindow.Seconds()))
+	}
+
+	if err := checkChannels(req.Channels); err != nil {
+		return err
+	}
+	if req.EscalateAfterMin < 0 || req.EscalateAfterMin > maxEscalateAfter {
+		return code.RuleInvalidErr.WithMsgf("escalate_after_min must be between 0 and %d", maxEscalateAfter)
+	}
+	if err := checkChannels(req.EscalationChannels); err != nil {
+		return err
+	}
+
+	data.Name = req.Name
+	data.Description = req.Description
+	if req.Enabled != nil {
+		data.Enabled = *req.Enabled
+	}
+	data.DeviceUUID = req.DeviceUUID
+	data.EventType = req.EventType
+	data.Metric = req.Metric
+	data.Aggregation = req.Aggregation
+	data.Operator = req.Operator
+	data.Threshold = req.Threshold
+	data.WindowSec = req.WindowSec
+	data.Severity = req.Severity
+	data.Channels = jsonChannels(req.Channels)
+	data.EscalateAfterMin = req.EscalateAfterMin
+	data.EscalationChannels = jsonChannels(req.EscalationChannels)
+	return nil
+}
+
+func checkChannels(channels []model.AlertChannel) error {
+	if len(channels) > maxChannels {
+		return code.RuleInvalidErr.WithMsgf("at most %d channels per rule", maxChannels)
+	}
+	for _, channel := range channels {
+		switch channel.Type {
+		case model.AlertChannelBroadcast:
+		case model.AlertChannelWebhook:
+			u, err := url.Parse(channel.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return code.RuleInvalidErr.WithMsgf("invalid webhook url: %s", channel.URL)
+			}
+		default:
+			return code.RuleInvalidErr.WithMsgf("unknown channel type: %s", channel.Type)
+		}
+	}
+	return nil
+}
+
+func jsonChannels(channels []model.AlertChannel) datatypes.JSONSlice[model.AlertChannel] {
+	if channels == nil {
+		channels = []model.AlertChannel{}
+	}
+	return datatypes.NewJSONSlice(channels)
+}
+
+func describe(data *model.DeviceRule, value float64) string {
+	metric := data.Metric
+	if metric == "" {
+		metric = string(data.EventType) + " events"
+	}
+	return fmt.Sprintf("%s(%s) over %ds is %g, %s %g",
+		data.Aggregation, metric, data.WindowSec, value, data.Operator, data.Threshold)
+}
+
+// RegisterJob 注册规则评估后台任务
+func RegisterJob() error {
+	e := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "device_rule_evaluation",
+		Description:  "评估设备事件规则，产生或恢复告警并通知",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     evaluateInterval.String(),
+		Timeout:      5 * time.Minute,
+		Run:          e.EvaluateAll,
+	})
+}
@@ -0,0 +1,272 @@
+// Package savedview stores named history filter presets so users can reopen
+// the same filtered history lists, and expands them for the list endpoints.
+package savedview
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/savedview"
+	"gorm.io/datatypes"
+)
+
+const maxPageSize = 100
+
+// CreateReq 创建保存的视图
+type CreateReq struct {
+	LabUUID uuid.UUID              `json:"lab_uuid" binding:"required"`
+	Name    string                 `json:"name" binding:"required,max=120"`
+	Target  model.SavedViewTarget  `json:"target" binding:"required"` // workflow | device_event
+	Filters model.SavedViewFilters `json:"filters"`
+	Shared  bool                   `json:"shared"`
+}
+
+// UpdateReq 更新保存的视图，整体覆盖名称、过滤条件与共享设置
+type UpdateReq struct {
+	Name    string                 `json:"name" binding:"required,max=120"`
+	Filters model.SavedViewFilters `json:"filters"`
+	Shared  bool                   `json:"shared"`
+}
+
+// ListReq 查询保存的视图
+type ListReq struct {
+	LabUUID uuid.UUID             `form:"lab_uuid" binding:"required"`
+	Target  model.SavedViewTarget `form:"target"`
+}
+
+type Service struct {
+	store    savedview.SavedViewRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    savedview.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// Create 创建视图，同一用户在同一实验室的视图名称唯一
+func (s *Service) Create(ctx context.Context, req *CreateReq) (*model.SavedView, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Target.Valid() {
+		return nil, code.ParamErr.WithMsgf("unknown target %s", req.Target)
+	}
+	name := strings.TrimSpace(req.Name)
+	if err := validateFilters(req.Target, &req.Filters); err != nil {
+		return nil, err
+	}
+	if err := s.checkName(ctx, labID, userInfo.ID, name); err != nil {
+		return nil, err
+	}
+
+	data := &model.SavedView{
+		LabID:   labID,
+		OwnerID: userInfo.ID,
+		Name:    name,
+		Target:  req.Target,
+		Filters: datatypes.NewJSONType(req.Filters),
+		Shared:  req.Shared,
+	}
+	if err := s.store.CreateView(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// List 获取自己创建的以及实验室共享的视图
+func (s *Service) List(ctx context.Context, req *ListReq) ([]*model.SavedView, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Target != "" && !req.Target.Valid() {
+		return nil, code.ParamErr.WithMsgf("unknown target %s", req.Target)
+	}
+	return s.store.ListViews(ctx, labID, userInfo.ID, req.Target)
+}
+
+// Get 获取视图，仅创建者或共享实验室的成员可以查看
+func (s *Service) Get(ctx context.Context, viewUUID uuid.UUID) (*model.SavedView, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	return s.visibleView(ctx, userInfo.ID, viewUUID)
+}
+
+// Update 更新视图，仅创建者可以修改
+func (s *Service) Update(ctx context.Context, viewUUID uuid.UUID, req *UpdateReq) (*model.SavedView, error) {
+	data, err := s.ownView(ctx, viewUUID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if err := validateFilters(data.Target, &req.Filters); err != nil {
+		return nil, err
+	}
+	if name != data.Name {
+		if err := s.checkName(ctx, data.LabID, data.OwnerID, name); err != nil {
+			return nil, err
+		}
+	}
+
+	data.Name = name
+	data.Filters = datatypes.NewJSONType(req.Filters)
+	data.Shared = req.Shared
+	if err := s.store.UpdateView(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete 删除视图，仅创建者可以删除
+func (s *Service) Delete(ctx context.Context, viewUUID uuid.UUID) error {
+	data, err := s.ownView(ctx, viewUUID)
+	if err != nil {
+		return err
+	}
+	return s.store.DeleteView(ctx, data.ID)
+}
+
+// Expand 返回列表接口 view_id 对应的过滤条件，视图须属于该实验室且适用于该列表
+func (s *Service) Expand(ctx context.Context, viewUUID uuid.UUID, labID int64, target model.SavedViewTarget) (*model.SavedViewFilters, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.visibleView(ctx, userInfo.ID, viewUUID)
+	if err != nil {
+		return nil, err
+	}
+	if data.LabID != labID {
+		return nil, code.NoPermission
+	}
+	if data.Target != target {
+		return nil, code.SavedViewTargetErr.WithMsgf("view %s is for %s", data.Name, data.Target)
+	}
+	filters := data.Filters.Data()
+	return &filters, nil
+}
+
+func (s *Service) visibleView(ctx context.Context, userID string, viewUUID uuid.UUID) (*model.SavedView, error) {
+	data, err := s.store.GetViewByUUID(ctx, viewUUID)
+	if err != nil {
+		return nil, err
+	}
+	if data.OwnerID == userID {
+		return data, nil
+	}
+	if !data.Shared {
+		return nil, code.NoPermission
+	}
+	if err := s.checkMemberByID(ctx, userID, data.LabID); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *Service) ownView(ctx context.Context, viewUUID uuid.UUID) (*model.SavedView, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	data, err := s.store.GetViewByUUID(ctx, viewUUID)
+	if err != nil {
+		return nil, err
+	}
+	if data.OwnerID != userInfo.ID {
+		return nil, code.NoPermission
+	}
+	return data, nil
+}
+
+func (s *Service) checkName(ctx context.Context, labID int64, ownerID, name string) error {
+	count, err := s.baseDB.Count(ctx, &model.SavedView{}, map[string]any{
+		"lab_id":   labID,
+		"owner_id": ownerID,
+		"name":     name,
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return code.SavedViewExistErr.WithMsgf("view %s already exists", name)
+	}
+	return nil
+}
+
+// validateFilters 校验过滤条件是否适用于目标列表
+func validateFilters(target model.SavedViewTarget, f *model.SavedViewFilters) error {
+	switch target {
+	case model.SavedViewWorkflow:
+		if f.EventType != "" {
+			return code.ParamErr.WithMsg("event_type does not apply to workflow history")
+		}
+		if _, err := model.ParseLabelFilters(f.Labels); err != nil {
+			return code.ParamErr.WithMsg(err.Error())
+		}
+	case model.SavedViewDeviceEvent:
+		if f.WorkflowID != nil || f.Status != "" || len(f.Labels) > 0 {
+			return code.ParamErr.WithMsg("workflow_id, status and labels do not apply to device event history")
+		}
+	}
+	if f.Range != "" {
+		if d, err := time.ParseDuration(f.Range); err != nil || d <= 0 {
+			return code.ParamErr.WithMsgf("invalid range %s", f.Range)
+		}
+	}
+	if f.StartTime != nil && f.EndTime != nil && f.EndTime.Before(*f.StartTime) {
+		return code.ParamErr.WithMsg("end_time must not be before start_time")
+	}
+	if f.PageSize < 0 || f.PageSize > maxPageSize {
+		return code.ParamErr.WithMsgf("page_size must be between 1 and %d", maxPageSize)
+	}
+	return nil
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	if err := s.checkMemberByID(ctx, userID, labID); err != nil {
+		return 0, err
+	}
+	return labID, nil
+}
+
+func (s *Service) checkMemberByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
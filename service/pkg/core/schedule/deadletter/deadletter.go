@@ -0,0 +1,121 @@
+// Package deadletter retries failed scheduler jobs and parks the ones that
+// exhaust their attempts in the dead-letter store for manual requeue.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/deadletter"
+)
+
+const (
+	defaultMaxAttempts = 3
+	retryBackoff       = 2 * time.Second
+)
+
+// Job 描述一个可以重新投递的调度任务
+type Job struct {
+	LabID    int64
+	TaskUUID uuid.UUID
+	Queue    string // 重新投递的队列
+	Action   string
+	Payload  string // 原始任务消息
+}
+
+// Runner 按 MaxRetryAttempts 重试任务，耗尽后写入死信
+type Runner struct {
+	store       deadletter.DeadLetterRepo
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewRunner() *Runner {
+	maxAttempts := config.GetStudioConfig().Workflow.MaxRetryAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	return &Runner{
+		store:       deadletter.New(),
+		maxAttempts: maxAttempts,
+		backoff:     retryBackoff,
+	}
+}
+
+// Run 执行 fn 直到成功；任务被取消时不再重试也不进入死信
+func (r *Runner) Run(ctx context.Context, job *Job, fn func(ctx context.Context) error) error {
+	failures := make([]model.DeadLetterFailure, 0, r.maxAttempts)
+	for attempt := 1; attempt <= r.maxAttempts; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, code.JobCanceled) || ctx.Err() != nil {
+			return err
+		}
+
+		logger.Warnf(ctx, "deadletter job %s attempt %d/%d fail: %+v", job.Action, attempt, r.maxAttempts, err)
+		failures = append(failures, model.DeadLetterFailure{
+			Attempt: attempt,
+			Error:   err.Error(),
+			At:      time.Now(),
+		})
+
+		if attempt < r.maxAttempts {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(r.backoff * time.Duration(attempt)):
+			}
+		}
+	}
+
+	r.bury(context.WithoutCancel(ctx), job, failures)
+	return code.JobRunFailErr.WithMsgf("exhausted %d attempts", r.maxAttempts)
+}
+
+func (r *Runner) bury(ctx context.Context, job *Job, failures []model.DeadLetterFailure) {
+	payload := json.RawMessage(job.Payload)
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(job.Payload)
+	}
+	failuresB, _ := json.Marshal(failures)
+
+	if err := r.store.CreateDeadLetter(ctx, &model.DeadLetterJob{
+		LabID:    job.LabID,
+		TaskUUID: job.TaskUUID,
+		Queue:    job.Queue,
+		Action:   job.Action,
+		Payload:  []byte(payload),
+		Failures: failuresB,
+		Attempts: len(failures),
+	}); err != nil {
+		logger.Errorf(ctx, "deadletter bury job %s fail: %+v", job.Action, err)
+	}
+}
+
+// RegisterMetrics 注册死信队列深度与最老任务时长指标
+func RegisterMetrics() error {
+	store := deadletter.New()
+	return otel.GetMetrics().ObserveDeadLetters(func(ctx context.Context) (int64, time.Duration, error) {
+		stats, err := store.Stats(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		var age time.Duration
+		if stats.OldestAt != nil {
+			age = time.Since(*stats.OldestAt)
+		}
+		return stats.Depth, age, nil
+	})
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"reflect"
 
+	"github.com/scienceol/studio/service/pkg/core/schedule/deadletter"
 	"github.com/scienceol/studio/service/pkg/core/schedule/edge"
 	"github.com/scienceol/studio/service/pkg/core/schedule/engine"
 	"github.com/scienceol/studio/service/pkg/core/schedule/engine/action"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/scienceol/studio/service/pkg/utils"
 )
 
@@ -22,6 +24,8 @@ func (e *EdgeImpl) onControlMessage(ctx context.Context, msg string) {
 		logger.Errorf(ctx, "onControlMessage err: %+v, msg: %s", err, msg)
 		return
 	}
+	ctx, span := otel.StartConsumerSpan(ctx, utils.LabControlName(e.labInfo.UUID), apiType.TraceParent, apiType.Baggage, string(apiType.Action))
+	defer span.End()
 
 	switch apiType.Action {
 	case edge.StartAction:
@@ -45,19 +49,33 @@ func (e *EdgeImpl) onStartAction(ctx context.Context, msg string) {
 	}
 
 	defer func() { e.actionTask = nil }()
-	e.actionTask = action.NewActionTask(ctx, &engine.TaskParam{
-		Session:    e.labInfo.Session,
-		Cancle:     e.cancel,
-		Sandbox:    e.labInfo.Sandbox,
-		BoardEvent: e.boardEvent,
-	})
-
-	if err := utils.SafelyRun(func() {
-		if err := e.actionTask.Run(ctx, &apiMsg.Data); err != nil {
-			logger.Errorf(ctx, "EdgeImpl.onStartAction run err: %+v", err)
+
+	// 失败按 MaxRetryAttempts 重试，耗尽后进入死信等待人工重新投递
+	job := &deadletter.Job{
+		LabID:    e.labInfo.ID,
+		TaskUUID: apiMsg.Data.TaskUUID,
+		Queue:    utils.LabControlName(e.labInfo.UUID),
+		Action:   string(apiMsg.Action),
+		Payload:  msg,
+	}
+	if err := deadletter.NewRunner().Run(ctx, job, func(ctx context.Context) error {
+		e.actionTask = action.NewActionTask(ctx, &engine.TaskParam{
+			Session:    e.labInfo.Session,
+			Cancle:     e.cancel,
+			Sandbox:    e.labInfo.Sandbox,
+			BoardEvent: e.boardEvent,
+		})
+
+		var runErr error
+		if err := utils.SafelyRun(func() {
+			runErr = e.actionTask.Run(ctx, &apiMsg.Data)
+		}); err != nil {
+			logger.Errorf(ctx, "EdgeImpl.onStartAction panic err: %+v", err)
+			return err
 		}
+		return runErr
 	}); err != nil {
-		logger.Errorf(ctx, "EdgeImpl.onNotebookJob err: %+v", err)
+		logger.Errorf(ctx, "EdgeImpl.onStartAction run err: %+v", err)
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 
 	r "github.com/redis/go-redis/v9"
 	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/maintenance"
 	"github.com/scienceol/studio/service/pkg/core/notify"
 	"github.com/scienceol/studio/service/pkg/core/notify/events"
 	"github.com/scienceol/studio/service/pkg/core/schedule/edge"
@@ -19,6 +20,9 @@ import (
 	"github.com/scienceol/studio/service/pkg/utils"
 )
 
+// pausePollInterval 维护期间检查维护是否结束的间隔
+const pausePollInterval = 5 * time.Second
+
 type EdgeImpl struct {
 	sessionCtx    context.Context
 	ctx           context.Context
@@ -129,6 +133,17 @@ func (e *EdgeImpl) startTaskConsumer(ctx context.Context) {
 	utils.SafelyGo(func() {
 		defer e.wait.Done()
 		for {
+			// 维护期间暂停领取任务，任务保留在队列中，维护结束后继续执行
+			if maintenance.Paused(ctx, e.labInfo.ID) {
+				select {
+				case <-ctx.Done():
+					logger.Infof(ctx, "EdgeImpl.startTask exit")
+					return
+				case <-time.After(pausePollInterval):
+				}
+				continue
+			}
+
 			res, err := e.rClient.BRPop(ctx, 10*time.Second, taskName).Result()
 			if err != nil && err == r.Nil {
 				continue
@@ -44,7 +44,9 @@ const (
 )
 
 type ApiControlMsg struct {
-	Action ApiControlAction `json:"action"`
+	Action      ApiControlAction `json:"action"`
+	TraceParent string           `json:"traceparent,omitempty"` // 投递请求的 W3C trace context
+	Baggage     string           `json:"baggage,omitempty"`     // 投递请求的 W3C baggage
 }
 
 type ApiControlData[T any] struct {
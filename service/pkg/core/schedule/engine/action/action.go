@@ -13,10 +13,12 @@ import (
 	"github.com/scienceol/studio/service/pkg/core/notify"
 	"github.com/scienceol/studio/service/pkg/core/schedule"
 	"github.com/scienceol/studio/service/pkg/core/schedule/engine"
+	"github.com/scienceol/studio/service/pkg/core/schedule/lock"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
 	"github.com/scienceol/studio/service/pkg/middleware/redis"
 	"github.com/scienceol/studio/service/pkg/model"
 	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/reservation"
 	"github.com/scienceol/studio/service/pkg/utils"
 )
 
@@ -38,17 +40,23 @@ type actionEngine struct {
 	actionStatus sync.Map
 	rClient      *r.Client
 	sanbox       repo.Sandbox
+	deviceLock   lock.DeviceLocker
+	lockEvents   *lock.EventRecorder
+	reservations reservation.ReservationRepo
 }
 
 func NewActionTask(ctx context.Context, param *engine.TaskParam) engine.Task {
 	d := &actionEngine{
-		session:    param.Session,
-		cancel:     param.Cancle,
-		ctx:        ctx,
-		wg:         sync.WaitGroup{},
-		rClient:    redis.GetClient(),
-		sanbox:     param.Sandbox,
-		boardEvent: param.BoardEvent,
+		session:      param.Session,
+		cancel:       param.Cancle,
+		ctx:          ctx,
+		wg:           sync.WaitGroup{},
+		rClient:      redis.GetClient(),
+		sanbox:       param.Sandbox,
+		boardEvent:   param.BoardEvent,
+		deviceLock:   lock.New(redis.GetClient()),
+		lockEvents:   lock.NewEventRecorder(),
+		reservations: reservation.New(),
 	}
 	d.stepFuncs = append(d.stepFuncs,
 		d.loadData, // 加载运行数据
@@ -106,9 +114,36 @@ func (d *actionEngine) Stop(_ context.Context) error {
 	return nil
 }
 
-func (d *actionEngine) runNode(ctx context.Context) error {
+func (d *actionEngine) runNode(ctx context.Context) (err error) {
+	// 独占设备，避免和工作流同时调度同一台设备
+	lease := &lock.Lease{
+		LabUUID:    d.data.LabUUID,
+		DeviceName: d.data.DeviceID,
+		Owner:      d.job.TaskUUID.String(),
+		TaskUUID:   d.job.TaskUUID,
+		JobUUID:    d.job.TaskUUID,
+		UserID:     d.job.UserID,
+	}
+	if err := lock.CheckReservation(ctx, d.reservations, lease, nil); err != nil {
+		return err
+	}
+
+	eventCtx := context.WithoutCancel(ctx)
+	var release func()
+	ctx, release, err = lock.Hold(ctx, d.deviceLock, lease, func(eventType model.DeviceEventType) {
+		d.lockEvents.Record(eventCtx, lease, eventType)
+	})
+	if err != nil {
+		return err
+	}
+	defer release()
+	// 租约丢失时 ctx 被取消，按锁丢失而非任务取消上报
+	defer func() {
+		err = lock.LostErr(ctx, err)
+	}()
+
 	// 查询 action 是否可以执行
-	err := d.queryAction(ctx)
+	err = d.queryAction(ctx)
 	if err != nil {
 		return err
 	}
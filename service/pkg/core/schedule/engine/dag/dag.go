@@ -11,16 +11,28 @@ import (
 
 	"github.com/olahol/melody"
 	"github.com/panjf2000/ants/v2"
+	"github.com/scienceol/studio/service/internal/config"
 	"github.com/scienceol/studio/service/pkg/common/code"
 	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/device"
+	"github.com/scienceol/studio/service/pkg/core/estimate"
+	"github.com/scienceol/studio/service/pkg/core/eventschema"
+	"github.com/scienceol/studio/service/pkg/core/historyingest"
+	"github.com/scienceol/studio/service/pkg/core/hub"
+	"github.com/scienceol/studio/service/pkg/core/notification"
 	"github.com/scienceol/studio/service/pkg/core/notify"
 	"github.com/scienceol/studio/service/pkg/core/notify/events"
 	"github.com/scienceol/studio/service/pkg/core/schedule"
 	"github.com/scienceol/studio/service/pkg/core/schedule/engine"
+	"github.com/scienceol/studio/service/pkg/core/schedule/lock"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
 	"github.com/scienceol/studio/service/pkg/model"
 	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/approval"
 	eStore "github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/reservation"
 	wfl "github.com/scienceol/studio/service/pkg/repo/workflow"
 	"github.com/scienceol/studio/service/pkg/utils"
 	"github.com/tidwall/gjson"
@@ -28,6 +40,9 @@ import (
 	"gorm.io/datatypes"
 )
 
+// 估算设备等待时长的超时，不能拖慢加锁
+const waitEstimateTimeout = 2 * time.Second
+
 type stepFunc func(ctx context.Context) error
 
 type dagEngine struct {
@@ -38,6 +53,13 @@ type dagEngine struct {
 
 	envStore      repo.LaboratoryRepo
 	workflowStore repo.WorkflowRepo
+	approvalStore approval.ApprovalRepo
+	historyIngest *historyingest.Client
+	deviceLock    lock.DeviceLocker
+	lockEvents    *lock.EventRecorder
+	reservations  reservation.ReservationRepo
+	devices       *device.Registry
+	estimator     *estimate.Service
 
 	nodes   []*model.WorkflowNode           // 所有节点
 	edges   []*model.WorkflowEdge           // 所有边
@@ -54,6 +76,9 @@ type dagEngine struct {
 	stepFuncs []stepFunc
 
 	boardEvent notify.MsgCenter
+	hub        *hub.Hub
+	events     *eventschema.Dispatcher
+	inbox      *notification.Sender
 	sandbox    repo.Sandbox
 
 	actionStatus sync.Map
@@ -69,10 +94,20 @@ func NewDagTask(ctx context.Context, param *engine.TaskParam) engine.Task {
 		ctx:             ctx,
 		envStore:        eStore.New(),
 		workflowStore:   wfl.New(),
+		approvalStore:   approval.New(),
+		historyIngest:   historyingest.Default(),
+		deviceLock:      lock.New(redis.GetClient()),
+		lockEvents:      lock.NewEventRecorder(),
+		reservations:    reservation.New(),
+		devices:         device.New(),
+		estimator:       estimate.New(),
 		dependencies:    make(map[*model.WorkflowNode]map[*model.WorkflowNode]struct{}),
 		pools:           pools,
 		wg:              sync.WaitGroup{},
 		boardEvent:      events.NewEvents(),
+		hub:             hub.Default(),
+		events:          eventschema.NewDispatcher(),
+		inbox:           notification.NewSender(),
 		jobMap:          make(map[uuid.UUID]*model.WorkflowNodeJob),
 		nodeMap:         make(map[int64]*model.WorkflowNodeJob),
 		nodeParentEdges: make(map[int64][]*engine.HandlePair),
@@ -333,6 +368,10 @@ func (d *dagEngine) findAllParents(nodeMap map[uuid.UUID]*model.WorkflowNode,
 // 运行入口
 func (d *dagEngine) Run(ctx context.Context, job *engine.WorkflowInfo) error {
 	d.job = job
+	// 延续提交请求的 trace，执行中写入的历史记录关联到同一个 trace
+	ctx, span := otel.StartConsumerSpan(ctx, config.Global().Job.JobQueueName, job.TraceParent, job.Baggage, string(job.Action))
+	defer span.End()
+
 	var err error
 	data := &engine.BoardMsg{
 		TaskStatus: "starting",
@@ -374,7 +413,12 @@ func (d *dagEngine) Run(ctx context.Context, job *engine.WorkflowInfo) error {
 	}
 
 	d.updateTaskStatus(ctx, taskStatus, d.job.TaskID)
+	d.finishExecution(ctx, taskStatus, err)
 	d.boardMsg(ctx, data)
+	d.completedEvent(ctx, taskStatus, err)
+	if err != nil {
+		span.RecordError(err)
+	}
 
 	d.wg.Wait()
 	return err
@@ -401,6 +445,8 @@ func (d *dagEngine) Stop(_ context.Context) error {
 }
 
 func (d *dagEngine) runAllNodes(ctx context.Context) error {
+	d.startExecution(ctx, len(d.dependencies))
+
 	var hasError atomic.Bool
 	var firstError atomic.Value
 	closeCtx, cancel := context.WithCancel(ctx)
@@ -551,7 +597,7 @@ func (d *dagEngine) parsePreNodeParam(ctx context.Context, node *model.WorkflowN
 	return nil
 }
 
-func (d *dagEngine) runNode(ctx context.Context, node *model.WorkflowNode, job *model.WorkflowNodeJob) error {
+func (d *dagEngine) runNode(ctx context.Context, node *model.WorkflowNode, job *model.WorkflowNodeJob) (err error) {
 	if err := d.parsePreNodeParam(ctx, node); err != nil {
 		return err
 	}
@@ -567,7 +613,9 @@ func (d *dagEngine) runNode(ctx context.Context, node *model.WorkflowNode, job *
 
 	d.boardMsg(ctx, data)
 
-	var err error
+	startTime := time.Now()
+	// 需要审批的步骤在审批时创建步骤记录，结束时写入同一条记录
+	var actionUUID *uuid.UUID
 	defer func() {
 		jobStatus := model.WorkflowJobFailed
 		data.Msg = "failed"
@@ -602,17 +650,55 @@ func (d *dagEngine) runNode(ctx context.Context, node *model.WorkflowNode, job *
 
 		d.boardMsg(ctx, data)
 		d.updateJob(ctx, jobStatus, job.ID)
+		d.checkDurationBudget(ctx, node, time.Since(startTime))
+		d.recordStep(ctx, node, job, jobStatus, time.Since(startTime), err, actionUUID)
 	}()
 
+	// 动作需在设备声明的能力范围内，校验通过后才记录或下发
+	if node.Type == model.WorkflowNodeILab {
+		err = d.devices.ValidateAction(ctx, d.job.LabData.ID, utils.SafeValue(func() string {
+			return *node.DeviceName
+		}, ""), node.ActionName, node.ActionType, node.Param)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 需要人工审批的节点，等待审批通过
+	if node.NeedApproval {
+		id := uuid.New()
+		actionUUID = &id
+		err = d.waitApproval(ctx, node, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 独占设备，避免多个任务同时调度同一台设备；
+	// stepCtx 在租约丢失时取消，结束状态仍使用 ctx 落库
+	stepCtx := ctx
+	if node.Type == model.WorkflowNodeILab {
+		var release func()
+		stepCtx, release, err = d.holdDevice(ctx, node, job)
+		if err != nil {
+			return err
+		}
+		defer release()
+		// 租约丢失导致的中断按锁丢失而非任务取消上报
+		defer func() {
+			err = lock.LostErr(stepCtx, err)
+		}()
+	}
+
 	// 查询 action 是否可以执行
 	if node.Type == model.WorkflowNodeILab {
-		err = d.queryAction(ctx, node, job)
+		err = d.queryAction(stepCtx, node, job)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = d.execNodeAction(ctx, node, job)
+	err = d.execNodeAction(stepCtx, node, job)
 	if err != nil {
 		return err
 	}
@@ -629,12 +715,156 @@ func (d *dagEngine) runNode(ctx context.Context, node *model.WorkflowNode, job *
 		ActionName: node.ActionName,
 	}
 
-	d.InitDeviceActionStatus(ctx, key, time.Now().Add(20*time.Second), false)
-	err = d.callbackAction(ctx, key, job)
+	d.InitDeviceActionStatus(stepCtx, key, time.Now().Add(20*time.Second), false)
+	err = d.callbackAction(stepCtx, key, job)
 
 	return err
 }
 
+// checkDurationBudget 步骤耗时超出预算时发出告警
+func (d *dagEngine) checkDurationBudget(ctx context.Context, node *model.WorkflowNode, elapsed time.Duration) {
+	if node.ExpectedMs <= 0 {
+		return
+	}
+
+	deviation := elapsed.Milliseconds() - node.ExpectedMs
+	if deviation <= 0 {
+		return
+	}
+
+	logger.Warnf(ctx, "node %s exceeded duration budget by %dms, expected: %dms",
+		node.UUID, deviation, node.ExpectedMs)
+	d.boardMsg(ctx, &engine.BoardMsg{
+		TaskStatus: "running",
+		Header:     node.ActionName,
+		NodeUUID:   node.UUID,
+		Type:       "warning",
+		Msg:        fmt.Sprintf("step exceeded duration budget by %dms (expected %dms)", deviation, node.ExpectedMs),
+		Timestamp:  time.Now(),
+	})
+}
+
+// waitApproval 通过 web 服务创建审批请求和待执行的步骤记录，轮询直到审批通过或拒绝
+func (d *dagEngine) waitApproval(ctx context.Context, node *model.WorkflowNode, actionUUID uuid.UUID) error {
+	req := &historyingest.ApprovalRequestReq{
+		ApprovalUUID:  uuid.New(),
+		ActionUUID:    actionUUID,
+		ExecutionUUID: d.job.TaskUUID,
+		NodeUUID:      node.UUID,
+		StepName:      node.Name,
+		RequestedBy:   d.job.UserID,
+		DeviceName: utils.SafeValue(func() string {
+			return *node.DeviceName
+		}, ""),
+		ActionType: node.ActionType,
+		ActionName: node.ActionName,
+		Input:      json.RawMessage(node.Param),
+		ExpectedMs: node.ExpectedMs,
+	}
+	if err := d.historyIngest.RecordApprovalRequest(ctx, req); err != nil {
+		return err
+	}
+	d.approvalNotify(ctx, req)
+
+	d.boardMsg(ctx, &engine.BoardMsg{
+		TaskStatus: "running",
+		JobStatus:  "pending",
+		Header:     node.ActionName,
+		NodeUUID:   node.UUID,
+		Type:       "info",
+		Msg:        "waiting for approval",
+		Timestamp:  time.Now(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return code.JobCanceled
+		default:
+		}
+
+		time.Sleep(time.Second)
+		ret, err := d.approvalStore.GetApprovalByUUID(ctx, req.ApprovalUUID)
+		if err != nil {
+			return err
+		}
+
+		switch ret.Status {
+		case model.ApprovalStatusApproved:
+			return nil
+		case model.ApprovalStatusRejected:
+			if ret.Comment != nil && *ret.Comment != "" {
+				return code.ApprovalRejectedErr.WithMsg(*ret.Comment)
+			}
+			return code.ApprovalRejectedErr
+		}
+	}
+}
+
+func (d *dagEngine) holdDevice(ctx context.Context, node *model.WorkflowNode, job *model.WorkflowNodeJob) (context.Context, func(), error) {
+	lease := &lock.Lease{
+		LabUUID:    d.job.LabUUID,
+		DeviceName: *node.DeviceName,
+		Owner:      job.UUID.String(),
+		TaskUUID:   d.job.TaskUUID,
+		JobUUID:    job.UUID,
+		UserID:     d.job.UserID,
+	}
+
+	// 设备被他人预约时按策略拒绝或等待预约结束
+	if err := lock.CheckReservation(ctx, d.reservations, lease, func(r *model.DeviceReservation) {
+		d.boardMsg(ctx, &engine.BoardMsg{
+			TaskStatus: "running",
+			JobStatus:  "pending",
+			Header:     node.ActionName,
+			NodeUUID:   node.UUID,
+			Type:       "info",
+			Msg:        fmt.Sprintf("device %s reserved until %s", lease.DeviceName, r.EndTime.Format(time.RFC3339)),
+			Timestamp:  time.Now(),
+		})
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	// 释放时任务可能已经取消，事件仍需落库
+	eventCtx := context.WithoutCancel(ctx)
+	return lock.Hold(ctx, d.deviceLock, lease, func(eventType model.DeviceEventType) {
+		if eventType == model.DeviceEventLockWaiting {
+			d.boardMsg(ctx, &engine.BoardMsg{
+				TaskStatus: "running",
+				JobStatus:  "pending",
+				Header:     node.ActionName,
+				NodeUUID:   node.UUID,
+				Type:       "info",
+				Msg:        d.waitingMsg(ctx, lease),
+				Timestamp:  time.Now(),
+			})
+		}
+		d.lockEvents.Record(eventCtx, lease, eventType)
+	})
+}
+
+// waitingMsg 等待设备锁的提示，设备有历史时附带预计等待时长
+func (d *dagEngine) waitingMsg(ctx context.Context, lease *lock.Lease) string {
+	msg := "waiting for device " + lease.DeviceName
+	if d.job.LabData == nil {
+		return msg
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, waitEstimateTimeout)
+	defer cancel()
+	wait, ok := d.estimator.DeviceWait(ctx, d.job.LabData.ID, lease.LabUUID, lease.DeviceName, lease.Owner)
+	if !ok {
+		return msg
+	}
+	return fmt.Sprintf("%s, estimated wait %s (%s - %s)", msg,
+		msDuration(wait.ExpectedMs), msDuration(wait.LowMs), msDuration(wait.HighMs))
+}
+
+func msDuration(ms int64) time.Duration {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Second)
+}
+
 func (d *dagEngine) queryAction(ctx context.Context, node *model.WorkflowNode, job *model.WorkflowNodeJob) error {
 	if node.Type == model.WorkflowPyScript {
 		return nil
@@ -865,6 +1095,128 @@ func (d *dagEngine) updateTaskStatus(ctx context.Context, status model.WorkflowT
 	}
 }
 
+// startExecution 通过 web 服务记录执行开始运行，执行历史与任务 uuid 一致
+func (d *dagEngine) startExecution(ctx context.Context, stepsTotal int) {
+	if err := d.historyIngest.RecordExecutionStart(ctx, &historyingest.ExecutionStartReq{
+		ExecutionUUID: d.job.TaskUUID,
+		StepsTotal:    stepsTotal,
+	}); err != nil {
+		logger.Warnf(ctx, "engine dag record execution start task uuid: %s, err: %+v", d.job.TaskUUID, err)
+	}
+}
+
+// recordStep 记录步骤的结果并更新执行的步骤进度，失败只打印日志不影响调度
+func (d *dagEngine) recordStep(ctx context.Context, node *model.WorkflowNode, job *model.WorkflowNodeJob,
+	jobStatus model.WorkflowJobStatus, elapsed time.Duration, cause error, actionUUID *uuid.UUID,
+) {
+	status, progress := model.ExecutionStatusFailed, &historyingest.ExecutionProgressReq{ExecutionUUID: d.job.TaskUUID}
+	switch jobStatus {
+	case model.WorkflowJobSuccess:
+		status = model.ExecutionStatusSuccess
+		progress.CompletedDelta = 1
+	case model.WorkflowJobCanceled:
+		status = model.ExecutionStatusCancelled
+	case model.WorkflowJobTimeout:
+		status = model.ExecutionStatusTimeout
+		progress.FailedDelta = 1
+	default:
+		progress.FailedDelta = 1
+	}
+
+	output, _ := json.Marshal(job.ReturnInfo)
+	req := &historyingest.ActionResultReq{
+		ExecutionUUID: d.job.TaskUUID,
+		DeviceName: utils.SafeValue(func() string {
+			return *node.DeviceName
+		}, ""),
+		ActionType:  node.ActionType,
+		ActionName:  node.ActionName,
+		Input:       json.RawMessage(node.Param),
+		Output:      output,
+		Status:      status,
+		DurationMs:  elapsed.Milliseconds(),
+		ExpectedMs:  node.ExpectedMs,
+		TraceParent: otel.TraceParent(ctx),
+		ActionUUID:  actionUUID,
+	}
+	if cause != nil {
+		req.ErrorMessage = cause.Error()
+	}
+	if err := d.historyIngest.RecordActionResult(ctx, req); err != nil {
+		logger.Warnf(ctx, "engine dag record action result task uuid: %s, node: %s, err: %+v", d.job.TaskUUID, node.UUID, err)
+	}
+	// 结束审批时创建的步骤记录时 web 服务已在同一事务中计入进度
+	if actionUUID != nil || (progress.CompletedDelta == 0 && progress.FailedDelta == 0) {
+		return
+	}
+	if err := d.historyIngest.RecordExecutionProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "engine dag record execution progress task uuid: %s, err: %+v", d.job.TaskUUID, err)
+	}
+}
+
+// finishExecution 通过 web 服务记录执行的结束状态，耗时、SLA 和失败原因的分类由 web 服务计算
+func (d *dagEngine) finishExecution(ctx context.Context, status model.WorkflowTaskStatus, cause error) {
+	req := &historyingest.ExecutionCompleteReq{
+		ExecutionUUID: d.job.TaskUUID,
+		Status:        model.TaskExecutionStatus(status),
+		CompletedAt:   time.Now(),
+	}
+	if cause != nil {
+		req.ErrorMessage = cause.Error()
+	}
+	if err := d.historyIngest.RecordExecutionComplete(ctx, req); err != nil {
+		logger.Errorf(ctx, "engine dag finish execution history task uuid: %s, err: %+v", d.job.TaskUUID, err)
+	}
+}
+
+// completedEvent 发布 execution.completed 事件，并通知提交任务的用户
+func (d *dagEngine) completedEvent(ctx context.Context, status model.WorkflowTaskStatus, cause error) {
+	payload := &eventschema.ExecutionCompletedData{
+		TaskUUID:     d.job.TaskUUID,
+		WorkflowUUID: d.job.WorkflowUUID,
+		UserID:       d.job.UserID,
+		Status:       status,
+		FinishedAt:   time.Now(),
+	}
+	if cause != nil {
+		payload.Message = cause.Error()
+	}
+	if err := d.events.Dispatch(context.Background(), eventschema.ExecutionCompleted, d.job.LabUUID, payload); err != nil {
+		logger.Errorf(ctx, "engine dag dispatch completed event task uuid: %s, err: %+v", d.job.TaskUUID, err)
+	}
+
+	d.inbox.Send(context.Background(), &notification.Message{
+		Kind:    model.NotificationExecution,
+		UserIDs: []string{d.job.UserID},
+		LabID: utils.SafeValue(func() int64 {
+			return d.job.LabData.ID
+		}, 0),
+		LabUUID: d.job.LabUUID,
+		RefUUID: d.job.TaskUUID,
+		Title:   fmt.Sprintf("workflow execution %s", status),
+		Body:    payload.Message,
+	})
+}
+
+// approvalNotify 通知实验室其他成员有步骤等待审批
+func (d *dagEngine) approvalNotify(ctx context.Context, req *historyingest.ApprovalRequestReq) {
+	labID := d.job.LabData.ID
+	userIDs := make([]string, 0)
+	for _, userID := range d.inbox.LabMembers(ctx, labID) {
+		if userID != req.RequestedBy {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	d.inbox.Send(ctx, &notification.Message{
+		Kind:    model.NotificationApproval,
+		UserIDs: userIDs,
+		LabID:   labID,
+		LabUUID: d.job.LabUUID,
+		RefUUID: req.ApprovalUUID,
+		Title:   fmt.Sprintf("step %s is waiting for approval", req.StepName),
+	})
+}
+
 func (d *dagEngine) updateJob(ctx context.Context, status model.WorkflowJobStatus, jobID int64) {
 	data := &model.WorkflowNodeJob{
 		Status: status,
@@ -891,6 +1243,7 @@ func (d *dagEngine) boardMsg(ctx context.Context, msg *engine.BoardMsg) {
 	}); err != nil {
 		logger.Errorf(ctx, "schedule board msg fail err: %+v", err)
 	}
+	d.hub.Publish(ctx, hub.ExecutionTopic(d.job.TaskUUID), "board", msg)
 }
 
 func (d *dagEngine) GetDeviceActionStatus(ctx context.Context, key engine.ActionKey) (engine.ActionValue, bool) {
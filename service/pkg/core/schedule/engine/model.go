@@ -34,6 +34,11 @@ type WorkflowInfo struct {
 	UserID       string    `json:"user_id"` // 提交用户 id
 	Data         any       `json:"data"`    // FIXME: 修复，暂时给物料添加使用
 
+	Inputs map[string]any `json:"inputs,omitempty"` // 校验后的运行参数
+
+	TraceParent string `json:"traceparent,omitempty"` // 提交请求的 W3C trace context，调度时延续同一个 trace
+	Baggage     string `json:"baggage,omitempty"`     // 提交请求的 W3C baggage，携带用户、实验室和组织
+
 	LabData *model.Laboratory `json:"-"`
 	TaskID  int64             `json:"-"`
 }
@@ -0,0 +1,170 @@
+// Package leader provides redis lease based leader election so that
+// singleton background jobs run on exactly one replica.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const defaultLeaseTTL = 15 * time.Second
+
+// KEYS[1] 租约 ARGV[1] 持有者 ARGV[2] ttl 毫秒
+var renewScript = r.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+    return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// KEYS[1] 租约 ARGV[1] 持有者
+var releaseScript = r.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+    return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+var instanceID = func() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "studio"
+	}
+	return fmt.Sprintf("%s-%s", host, uuid.NewV4().String())
+}()
+
+// InstanceID 当前副本的唯一标识
+func InstanceID() string {
+	return instanceID
+}
+
+// Elector 基于 redis 租约的选主，租约过期后其他副本自动接管
+type Elector struct {
+	name     string
+	ttl      time.Duration
+	rClient  *r.Client
+	isLeader atomic.Bool
+}
+
+func NewElector(name string) *Elector {
+	return &Elector{
+		name:    name,
+		ttl:     defaultLeaseTTL,
+		rClient: redis.GetClient(),
+	}
+}
+
+// Name 选主名称
+func (e *Elector) Name() string {
+	return e.name
+}
+
+// IsLeader 当前副本是否为主
+func (e *Elector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Leader 查询当前主副本标识，无主时返回空字符串
+func (e *Elector) Leader(ctx context.Context) (string, error) {
+	owner, err := e.rClient.Get(ctx, utils.LeaderName(e.name)).Result()
+	if err == r.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", code.RedisLuaRetErr.WithErr(err)
+	}
+	return owner, nil
+}
+
+// Run 后台参与选主，成为主后执行 fn，失去主身份时取消 fn 的 ctx，
+// fn 返回后重新参与选主，直到 ctx 取消
+func (e *Elector) Run(ctx context.Context, fn func(ctx context.Context)) {
+	if err := otel.GetMetrics().ObserveLeader(e.name, instanceID, e.IsLeader); err != nil {
+		logger.Errorf(ctx, "register leader metric fail name: %s, err: %+v", e.name, err)
+	}
+
+	utils.SafelyGo(func() {
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+		for {
+			if e.tryAcquire(ctx) {
+				e.lead(ctx, fn)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}, func(err error) {
+		logger.Errorf(ctx, "leader election exit name: %s, err: %+v", e.name, err)
+	})
+}
+
+func (e *Elector) tryAcquire(ctx context.Context) bool {
+	ok, err := e.rClient.SetNX(ctx, utils.LeaderName(e.name), instanceID, e.ttl).Result()
+	if err != nil {
+		logger.Warnf(ctx, "acquire leader fail name: %s, err: %+v", e.name, err)
+		return false
+	}
+	return ok
+}
+
+func (e *Elector) lead(ctx context.Context, fn func(ctx context.Context)) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e.isLeader.Store(true)
+	logger.Infof(ctx, "became leader name: %s, instance: %s", e.name, instanceID)
+	defer func() {
+		e.isLeader.Store(false)
+		_ = releaseScript.Run(context.Background(), e.rClient, []string{utils.LeaderName(e.name)}, instanceID).Err()
+		logger.Infof(ctx, "lost leader name: %s, instance: %s", e.name, instanceID)
+	}()
+
+	done := make(chan struct{})
+	utils.SafelyGo(func() {
+		defer close(done)
+		fn(leaderCtx)
+	}, func(err error) {
+		logger.Errorf(ctx, "leader job exit name: %s, err: %+v", e.name, err)
+	})
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if !e.renew(ctx) {
+				cancel()
+				<-done
+				return
+			}
+		}
+	}
+}
+
+func (e *Elector) renew(ctx context.Context) bool {
+	ret, err := renewScript.Run(ctx, e.rClient, []string{utils.LeaderName(e.name)},
+		instanceID, e.ttl.Milliseconds()).Int()
+	if err != nil {
+		// 续约失败无法确认是否仍持有租约，主动让出避免多副本同时运行
+		logger.Warnf(ctx, "renew leader fail name: %s, err: %+v", e.name, err)
+		return false
+	}
+	return ret == 1
+}
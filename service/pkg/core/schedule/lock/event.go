@@ -0,0 +1,43 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/core/historyingest"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// EventRecorder 通过 web 服务将设备锁事件写入设备事件历史
+type EventRecorder struct {
+	ingest *historyingest.Client
+}
+
+func NewEventRecorder() *EventRecorder {
+	return &EventRecorder{
+		ingest: historyingest.Default(),
+	}
+}
+
+// Record 记录事件，失败只打印日志不影响调度
+func (e *EventRecorder) Record(ctx context.Context, lease *Lease, eventType model.DeviceEventType) {
+	data, _ := json.Marshal(map[string]any{
+		"device_name": lease.DeviceName,
+		"owner":       lease.Owner,
+		"task_uuid":   lease.TaskUUID,
+		"job_uuid":    lease.JobUUID,
+		"user_id":     lease.UserID,
+	})
+
+	if err := e.ingest.RecordDeviceEvent(ctx, &historyingest.DeviceEventReq{
+		LabUUID:    lease.LabUUID,
+		DeviceName: lease.DeviceName,
+		EventType:  eventType,
+		EventData:  data,
+		Timestamp:  time.Now(),
+	}); err != nil {
+		logger.Warnf(ctx, "record device lock event fail device: %s, event: %s, err: %+v", lease.DeviceName, eventType, err)
+	}
+}
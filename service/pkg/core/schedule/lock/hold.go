@@ -0,0 +1,79 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+// Hold 排队获取设备锁，持有期间后台自动续约，返回的函数用于释放。
+// 返回的 context 在租约丢失时取消，步骤需使用它驱动设备，避免失去锁后继续执行
+func Hold(ctx context.Context, locker DeviceLocker, lease *Lease, onEvent func(model.DeviceEventType)) (context.Context, func(), error) {
+	if onEvent == nil {
+		onEvent = func(model.DeviceEventType) {}
+	}
+
+	if err := locker.Acquire(ctx, lease, func() {
+		onEvent(model.DeviceEventLockWaiting)
+	}); err != nil {
+		return nil, nil, err
+	}
+	onEvent(model.DeviceEventLockAcquired)
+
+	holdCtx, cancel := context.WithCancelCause(ctx)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	utils.SafelyGo(func() {
+		defer close(done)
+		ticker := time.NewTicker(locker.LeaseTTL() / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				err := locker.Renew(context.Background(), lease)
+				if err == nil {
+					continue
+				}
+
+				logger.Warnf(ctx, "renew device lock fail device: %s, owner: %s, err: %+v", lease.DeviceName, lease.Owner, err)
+				if errors.Is(err, code.DeviceLockNotHeldErr) {
+					onEvent(model.DeviceEventLockLost)
+					cancel(code.DeviceLockNotHeldErr)
+					return
+				}
+			}
+		}
+	}, func(err error) {
+		logger.Errorf(ctx, "renew device lock SafelyGo err: %+v", err)
+	})
+
+	var once sync.Once
+	return holdCtx, func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+			cancel(nil)
+			if err := locker.Release(context.Background(), lease); err == nil {
+				onEvent(model.DeviceEventLockReleased)
+			}
+		})
+	}, nil
+}
+
+// LostErr 租约丢失导致步骤中断时，将 err 替换为 DeviceLockNotHeldErr，
+// 避免被当作任务取消处理
+func LostErr(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), code.DeviceLockNotHeldErr) {
+		return code.DeviceLockNotHeldErr
+	}
+	return err
+}
@@ -0,0 +1,310 @@
+// Package lock provides redis based device leases so that one device runs
+// at most one action at a time across all schedulers.
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	defaultLeaseTTL    = 30 * time.Second
+	defaultWaitTimeout = 10 * time.Minute
+	pollInterval       = 500 * time.Millisecond
+)
+
+// Lease 设备租约，Owner 为持有者唯一标识
+type Lease struct {
+	LabUUID    uuid.UUID `json:"lab_uuid"`
+	DeviceName string    `json:"device_name"`
+	Owner      string    `json:"owner"`
+	TaskUUID   uuid.UUID `json:"task_uuid"`
+	JobUUID    uuid.UUID `json:"job_uuid"`
+	UserID     string    `json:"user_id"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// State 设备锁当前状态
+type State struct {
+	DeviceName string        `json:"device_name"`
+	Holder     *Lease        `json:"holder"`
+	TTL        time.Duration `json:"ttl" swaggertype:"integer"`
+	Waiters    []string      `json:"waiters"`
+}
+
+// DeviceLocker 设备锁服务
+type DeviceLocker interface {
+	// Acquire 排队等待直到获取设备锁，ctx 取消返回 JobCanceled
+	Acquire(ctx context.Context, lease *Lease, onWait func()) error
+	// TryAcquire 尝试获取一次，未获取到时进入等待队列
+	TryAcquire(ctx context.Context, lease *Lease) (bool, error)
+	// Renew 续约，租约已丢失返回 DeviceLockNotHeldErr
+	Renew(ctx context.Context, lease *Lease) error
+	// Release 释放设备锁并退出等待队列
+	Release(ctx context.Context, lease *Lease) error
+	// List 获取实验室所有设备锁状态
+	List(ctx context.Context, labUUID uuid.UUID) ([]*State, error)
+	// LeaseTTL 租约时长
+	LeaseTTL() time.Duration
+}
+
+// KEYS[1] 锁 KEYS[2] 等待队列(zset, score 为入队时间) KEYS[3] 等待者活跃时间(hash)
+// ARGV[1] owner ARGV[2] 租约内容 ARGV[3] ttl 毫秒 ARGV[4] 当前毫秒 ARGV[5] 等待者失活毫秒
+var acquireScript = r.NewScript(`
+local now = tonumber(ARGV[4])
+local stale = tonumber(ARGV[5])
+local cur = redis.call('GET', KEYS[1])
+if cur then
+    local ok, info = pcall(cjson.decode, cur)
+    if ok and info.owner == ARGV[1] then
+        redis.call('PEXPIRE', KEYS[1], ARGV[3])
+        return 1
+    end
+end
+
+redis.call('ZADD', KEYS[2], 'NX', now, ARGV[1])
+redis.call('HSET', KEYS[3], ARGV[1], now)
+redis.call('PEXPIRE', KEYS[2], stale * 2)
+redis.call('PEXPIRE', KEYS[3], stale * 2)
+
+-- 清理已经不再轮询的等待者，避免阻塞队列
+local waiters = redis.call('ZRANGE', KEYS[2], 0, -1)
+for _, w in ipairs(waiters) do
+    local seen = tonumber(redis.call('HGET', KEYS[3], w) or '0')
+    if now - seen > stale then
+        redis.call('ZREM', KEYS[2], w)
+        redis.call('HDEL', KEYS[3], w)
+    end
+end
+
+if cur then
+    return 0
+end
+
+local head = redis.call('ZRANGE', KEYS[2], 0, 0)[1]
+if head ~= ARGV[1] then
+    return 0
+end
+
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+return 1
+`)
+
+// KEYS[1] 锁 ARGV[1] owner ARGV[2] ttl 毫秒
+var renewScript = r.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if not cur then
+    return 0
+end
+local ok, info = pcall(cjson.decode, cur)
+if ok and info.owner == ARGV[1] then
+    redis.call('PEXPIRE', KEYS[1], ARGV[2])
+    return 1
+end
+return 0
+`)
+
+// KEYS[1] 锁 KEYS[2] 等待队列 KEYS[3] 等待者活跃时间 ARGV[1] owner
+var releaseScript = r.NewScript(`
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+local cur = redis.call('GET', KEYS[1])
+if not cur then
+    return 0
+end
+local ok, info = pcall(cjson.decode, cur)
+if ok and info.owner == ARGV[1] then
+    redis.call('DEL', KEYS[1])
+    return 1
+end
+return 0
+`)
+
+type deviceLock struct {
+	client      *r.Client
+	leaseTTL    time.Duration
+	waitTimeout time.Duration
+}
+
+// New 创建设备锁服务
+func New(client *r.Client) DeviceLocker {
+	conf := config.GetStudioConfig().Workflow.DeviceLock
+	d := &deviceLock{
+		client:      client,
+		leaseTTL:    defaultLeaseTTL,
+		waitTimeout: defaultWaitTimeout,
+	}
+	if conf.LeaseTTLSeconds > 0 {
+		d.leaseTTL = time.Duration(conf.LeaseTTLSeconds) * time.Second
+	}
+	if conf.WaitTimeoutSeconds > 0 {
+		d.waitTimeout = time.Duration(conf.WaitTimeoutSeconds) * time.Second
+	}
+
+	return d
+}
+
+func (d *deviceLock) LeaseTTL() time.Duration {
+	return d.leaseTTL
+}
+
+func (d *deviceLock) keys(lease *Lease) []string {
+	return []string{
+		utils.DeviceLockName(lease.LabUUID, lease.DeviceName),
+		utils.DeviceLockQueueName(lease.LabUUID, lease.DeviceName),
+		utils.DeviceLockWaitersName(lease.LabUUID, lease.DeviceName),
+	}
+}
+
+func (d *deviceLock) TryAcquire(ctx context.Context, lease *Lease) (bool, error) {
+	lease.AcquiredAt = time.Now()
+	b, _ := json.Marshal(lease)
+	// 等待者至少每个轮询周期刷新一次，超过 10 个周期未刷新视为失活
+	stale := 10 * pollInterval
+	ret, err := acquireScript.Run(ctx, d.client, d.keys(lease),
+		lease.Owner, string(b), d.leaseTTL.Milliseconds(),
+		time.Now().UnixMilli(), stale.Milliseconds()).Int()
+	if err != nil {
+		logger.Errorf(ctx, "TryAcquire device lock fail device: %s, err: %+v", lease.DeviceName, err)
+		return false, code.DeviceLockErr.WithErr(err)
+	}
+
+	return ret == 1, nil
+}
+
+func (d *deviceLock) Acquire(ctx context.Context, lease *Lease, onWait func()) error {
+	deadline := time.Now().Add(d.waitTimeout)
+	waiting := false
+	for {
+		ok, err := d.TryAcquire(ctx, lease)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		if !waiting {
+			waiting = true
+			if onWait != nil {
+				onWait()
+			}
+		}
+
+		if time.Now().After(deadline) {
+			d.dequeue(lease)
+			return code.DeviceLockWaitTimeoutErr
+		}
+
+		select {
+		case <-ctx.Done():
+			d.dequeue(lease)
+			return code.JobCanceled
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// dequeue 放弃等待，ctx 可能已经取消，使用独立的 context
+func (d *deviceLock) dequeue(lease *Lease) {
+	if err := d.Release(context.Background(), lease); err != nil {
+		logger.Errorf(context.Background(), "dequeue device lock fail device: %s, err: %+v", lease.DeviceName, err)
+	}
+}
+
+func (d *deviceLock) Renew(ctx context.Context, lease *Lease) error {
+	ret, err := renewScript.Run(ctx, d.client, d.keys(lease)[:1],
+		lease.Owner, d.leaseTTL.Milliseconds()).Int()
+	if err != nil {
+		logger.Errorf(ctx, "Renew device lock fail device: %s, err: %+v", lease.DeviceName, err)
+		return code.DeviceLockErr.WithErr(err)
+	}
+	if ret == 0 {
+		return code.DeviceLockNotHeldErr
+	}
+
+	return nil
+}
+
+func (d *deviceLock) Release(ctx context.Context, lease *Lease) error {
+	if err := releaseScript.Run(ctx, d.client, d.keys(lease), lease.Owner).Err(); err != nil && !errors.Is(err, r.Nil) {
+		logger.Errorf(ctx, "Release device lock fail device: %s, err: %+v", lease.DeviceName, err)
+		return code.DeviceLockErr.WithErr(err)
+	}
+
+	return nil
+}
+
+func (d *deviceLock) List(ctx context.Context, labUUID uuid.UUID) ([]*State, error) {
+	lockPrefix := utils.DeviceLockName(labUUID, "")
+	queuePrefix := utils.DeviceLockQueueName(labUUID, "")
+
+	states := make(map[string]*State)
+	getState := func(deviceName string) *State {
+		s, ok := states[deviceName]
+		if !ok {
+			s = &State{DeviceName: deviceName, Waiters: []string{}}
+			states[deviceName] = s
+		}
+		return s
+	}
+
+	iter := d.client.Scan(ctx, 0, lockPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		s := getState(strings.TrimPrefix(key, lockPrefix))
+		val, err := d.client.Get(ctx, key).Result()
+		if err != nil {
+			if errors.Is(err, r.Nil) {
+				continue
+			}
+			return nil, code.DeviceLockErr.WithErr(err)
+		}
+		holder := &Lease{}
+		if err := json.Unmarshal([]byte(val), holder); err == nil {
+			s.Holder = holder
+		}
+		s.TTL, _ = d.client.PTTL(ctx, key).Result()
+	}
+	if err := iter.Err(); err != nil {
+		logger.Errorf(ctx, "List device lock scan fail lab: %s, err: %+v", labUUID, err)
+		return nil, code.DeviceLockErr.WithErr(err)
+	}
+
+	iter = d.client.Scan(ctx, 0, queuePrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		s := getState(strings.TrimPrefix(key, queuePrefix))
+		waiters, err := d.client.ZRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, code.DeviceLockErr.WithErr(err)
+		}
+		s.Waiters = waiters
+	}
+	if err := iter.Err(); err != nil {
+		logger.Errorf(ctx, "List device lock queue scan fail lab: %s, err: %+v", labUUID, err)
+		return nil, code.DeviceLockErr.WithErr(err)
+	}
+
+	ret := make([]*State, 0, len(states))
+	for _, s := range states {
+		if s.Holder == nil && len(s.Waiters) == 0 {
+			continue
+		}
+		ret = append(ret, s)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,240 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+// newTestLock 基于 miniredis 创建设备锁，租约过期需通过 FastForward 推进
+func newTestLock(t *testing.T, leaseTTL time.Duration) (*deviceLock, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := r.NewClient(&r.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return &deviceLock{
+		client:      client,
+		leaseTTL:    leaseTTL,
+		waitTimeout: time.Minute,
+	}, mr
+}
+
+func newLease(labUUID uuid.UUID, owner string) *Lease {
+	return &Lease{LabUUID: labUUID, DeviceName: "arm", Owner: owner}
+}
+
+func TestRenewExtendsLease(t *testing.T) {
+	ctx := context.Background()
+	l, mr := newTestLock(t, 3*time.Second)
+	lease := newLease(uuid.NewV4(), "a")
+	key := utils.DeviceLockName(lease.LabUUID, lease.DeviceName)
+
+	ok, err := l.TryAcquire(ctx, lease)
+	if err != nil || !ok {
+		t.Fatalf("TryAcquire = %v, %v, want true", ok, err)
+	}
+
+	mr.FastForward(2 * time.Second)
+	if ttl := mr.TTL(key); ttl != time.Second {
+		t.Fatalf("ttl before renew = %s, want 1s", ttl)
+	}
+	if err := l.Renew(ctx, lease); err != nil {
+		t.Fatalf("Renew: %v", err)
+	}
+	if ttl := mr.TTL(key); ttl != 3*time.Second {
+		t.Fatalf("ttl after renew = %s, want 3s", ttl)
+	}
+
+	// 续约后越过原过期时间，锁仍由 a 持有
+	mr.FastForward(2 * time.Second)
+	ok, err = l.TryAcquire(ctx, newLease(lease.LabUUID, "b"))
+	if err != nil || ok {
+		t.Fatalf("TryAcquire by b = %v, %v, want false", ok, err)
+	}
+}
+
+func TestRenewAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	l, mr := newTestLock(t, 3*time.Second)
+	lease := newLease(uuid.NewV4(), "a")
+
+	if ok, err := l.TryAcquire(ctx, lease); err != nil || !ok {
+		t.Fatalf("TryAcquire = %v, %v, want true", ok, err)
+	}
+
+	mr.FastForward(4 * time.Second)
+	if err := l.Renew(ctx, lease); !errors.Is(err, code.DeviceLockNotHeldErr) {
+		t.Fatalf("Renew after expiry = %v, want DeviceLockNotHeldErr", err)
+	}
+
+	ok, err := l.TryAcquire(ctx, newLease(lease.LabUUID, "b"))
+	if err != nil || !ok {
+		t.Fatalf("TryAcquire by b after expiry = %v, %v, want true", ok, err)
+	}
+	// a 的租约已被 b 接管，续约不能抢回
+	if err := l.Renew(ctx, lease); !errors.Is(err, code.DeviceLockNotHeldErr) {
+		t.Fatalf("Renew by a = %v, want DeviceLockNotHeldErr", err)
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newTestLock(t, 3*time.Second)
+	labUUID := uuid.NewV4()
+	holder := newLease(labUUID, "holder")
+	first := newLease(labUUID, "first")
+	second := newLease(labUUID, "second")
+
+	if ok, err := l.TryAcquire(ctx, holder); err != nil || !ok {
+		t.Fatalf("TryAcquire holder = %v, %v, want true", ok, err)
+	}
+	for _, lease := range []*Lease{first, second} {
+		if ok, err := l.TryAcquire(ctx, lease); err != nil || ok {
+			t.Fatalf("TryAcquire %s = %v, %v, want false", lease.Owner, ok, err)
+		}
+		// 入队分数为毫秒时间戳，保证两个等待者的先后顺序
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	states, err := l.List(ctx, labUUID)
+	if err != nil || len(states) != 1 {
+		t.Fatalf("List = %v, %v", states, err)
+	}
+	if w := states[0].Waiters; len(w) != 2 || w[0] != "first" || w[1] != "second" {
+		t.Fatalf("waiters = %v, want [first second]", w)
+	}
+
+	if err := l.Release(ctx, holder); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	// 锁空闲时也只有队首能获取
+	if ok, err := l.TryAcquire(ctx, second); err != nil || ok {
+		t.Fatalf("TryAcquire second = %v, %v, want false", ok, err)
+	}
+	if ok, err := l.TryAcquire(ctx, first); err != nil || !ok {
+		t.Fatalf("TryAcquire first = %v, %v, want true", ok, err)
+	}
+
+	if err := l.Release(ctx, first); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if ok, err := l.TryAcquire(ctx, second); err != nil || !ok {
+		t.Fatalf("TryAcquire second = %v, %v, want true", ok, err)
+	}
+}
+
+// eventRecorder 记录 Hold 上报的锁事件
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []model.DeviceEventType
+}
+
+func (e *eventRecorder) record(t model.DeviceEventType) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, t)
+}
+
+func (e *eventRecorder) has(t model.DeviceEventType) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ev := range e.events {
+		if ev == t {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHoldRenews(t *testing.T) {
+	l, mr := newTestLock(t, 300*time.Millisecond)
+	lease := newLease(uuid.NewV4(), "a")
+	key := utils.DeviceLockName(lease.LabUUID, lease.DeviceName)
+
+	holdCtx, release, err := Hold(context.Background(), l, lease, nil)
+	if err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	defer release()
+
+	// miniredis 不随真实时间过期，推进时间后等待后台续约把 TTL 重置
+	mr.FastForward(200 * time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for mr.TTL(key) != 300*time.Millisecond {
+		if time.Now().After(deadline) {
+			t.Fatalf("lease not renewed, ttl = %s", mr.TTL(key))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if holdCtx.Err() != nil {
+		t.Fatalf("hold ctx canceled while lease renewed: %v", context.Cause(holdCtx))
+	}
+}
+
+func TestHoldCancelsOnLeaseLost(t *testing.T) {
+	l, mr := newTestLock(t, 300*time.Millisecond)
+	lease := newLease(uuid.NewV4(), "a")
+	events := &eventRecorder{}
+
+	holdCtx, release, err := Hold(context.Background(), l, lease, events.record)
+	if err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	defer release()
+
+	// 租约过期后被其他调度器获取
+	mr.FastForward(time.Second)
+	if ok, err := l.TryAcquire(context.Background(), newLease(lease.LabUUID, "b")); err != nil || !ok {
+		t.Fatalf("TryAcquire by b = %v, %v, want true", ok, err)
+	}
+
+	select {
+	case <-holdCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("hold ctx not canceled after lease lost")
+	}
+	if cause := context.Cause(holdCtx); !errors.Is(cause, code.DeviceLockNotHeldErr) {
+		t.Fatalf("cause = %v, want DeviceLockNotHeldErr", cause)
+	}
+	if !events.has(model.DeviceEventLockLost) {
+		t.Fatal("lock lost event not reported")
+	}
+
+	if err := LostErr(holdCtx, code.JobCanceled); !errors.Is(err, code.DeviceLockNotHeldErr) {
+		t.Fatalf("LostErr = %v, want DeviceLockNotHeldErr", err)
+	}
+	if err := LostErr(holdCtx, nil); err != nil {
+		t.Fatalf("LostErr(nil) = %v, want nil", err)
+	}
+}
+
+func TestHoldReleaseKeepsCause(t *testing.T) {
+	l, _ := newTestLock(t, 300*time.Millisecond)
+	lease := newLease(uuid.NewV4(), "a")
+	events := &eventRecorder{}
+
+	holdCtx, release, err := Hold(context.Background(), l, lease, events.record)
+	if err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	release()
+
+	if holdCtx.Err() == nil {
+		t.Fatal("hold ctx not canceled after release")
+	}
+	if err := LostErr(holdCtx, code.JobCanceled); !errors.Is(err, code.JobCanceled) {
+		t.Fatalf("LostErr after release = %v, want JobCanceled", err)
+	}
+	if !events.has(model.DeviceEventLockReleased) {
+		t.Fatal("lock released event not reported")
+	}
+}
@@ -0,0 +1,55 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/reservation"
+)
+
+const (
+	ReservationReject = "reject" // 设备被他人预约时直接失败
+	ReservationQueue  = "queue"  // 设备被他人预约时等待预约结束
+
+	reservationPollInterval = 5 * time.Second
+)
+
+// CheckReservation 设备在预约时间内只允许预约人使用，其他人的任务按配置拒绝或等待
+func CheckReservation(ctx context.Context, store reservation.ReservationRepo, lease *Lease,
+	onWait func(r *model.DeviceReservation),
+) error {
+	policy := config.GetStudioConfig().Workflow.DeviceLock.ReservationPolicy
+	waiting := false
+	for {
+		r, err := store.GetActiveReservation(ctx, lease.LabUUID, lease.DeviceName, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if r == nil || r.UserID == lease.UserID {
+			return nil
+		}
+
+		if policy == ReservationReject {
+			return code.DeviceReservedErr.WithMsgf("device %s reserved until %s", lease.DeviceName, r.EndTime.Format(time.RFC3339))
+		}
+
+		if !waiting {
+			waiting = true
+			if onWait != nil {
+				onWait(r)
+			}
+		}
+
+		// 预约可能被提前取消，定期重新检查
+		wait := min(time.Until(r.EndTime), reservationPollInterval)
+		select {
+		case <-ctx.Done():
+			return code.JobCanceled
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/datatypes"
+)
+
+// action 设备动作及其典型时长
+type action struct {
+	Type     string
+	Name     string
+	MedianMs float64
+}
+
+// actionCatalog 液体处理实验室常见的动作，时长为中位数
+var actionCatalog = []action{
+	{Type: "liquid", Name: "aspirate", MedianMs: 8_000},
+	{Type: "liquid", Name: "dispense", MedianMs: 9_000},
+	{Type: "liquid", Name: "transfer", MedianMs: 45_000},
+	{Type: "liquid", Name: "mix", MedianMs: 20_000},
+	{Type: "plate", Name: "move_plate", MedianMs: 15_000},
+	{Type: "plate", Name: "shake", MedianMs: 120_000},
+	{Type: "plate", Name: "wash", MedianMs: 60_000},
+	{Type: "thermal", Name: "incubate", MedianMs: 600_000},
+	{Type: "thermal", Name: "centrifuge", MedianMs: 300_000},
+	{Type: "measure", Name: "read_absorbance", MedianMs: 90_000},
+}
+
+var (
+	errorMessages = []string{
+		"device timeout: no response within 30s",
+		"liquid level detection failed",
+		"plate not detected at target position",
+		"gripper collision detected",
+		"temperature out of range",
+		"tip pickup failed",
+	}
+	projects = []string{"assay-dev", "screening", "qc", "method-transfer"}
+)
+
+// hourWeights 一天中各小时提交执行的相对频率，工作时间集中
+var hourWeights = [24]float64{1, 1, 1, 1, 1, 2, 3, 6, 10, 12, 12, 10, 7, 9, 12, 12, 10, 8, 6, 4, 3, 2, 2, 1}
+
+// Device 生成历史记录使用的设备
+type Device struct {
+	ID   int64
+	UUID uuid.UUID
+	Name string
+}
+
+// Workflow 生成历史记录使用的工作流
+type Workflow struct {
+	ID         int64
+	UUID       uuid.UUID
+	Name       string
+	SLAMinutes int
+}
+
+// Options configures the generated history
+type Options struct {
+	LabID      int64
+	UserID     string
+	Executions int
+	Days       int
+	Seed       uint64 // 相同的种子和设备、工作流生成相同分布的记录，UUID 除外
+	Now        time.Time
+	Location   *time.Location // 工作时间所在的时区
+}
+
+// Execution 一次执行及其动作，动作的 workflow_execution_id 在执行写入后设置
+type Execution struct {
+	Execution *model.WorkflowExecutionHistory
+	Actions   []*model.ActionExecutionHistory
+}
+
+// step 工作流中的一个步骤
+type step struct {
+	device *Device
+	action action
+}
+
+// plan 工作流的步骤和失败率，同一工作流的执行时长和失败率相近
+type plan struct {
+	workflow    *Workflow
+	steps       []step
+	failureRate float64
+	weight      float64
+}
+
+type generator struct {
+	rng   *rand.Rand
+	opts  *Options
+	plans []*plan
+	total float64
+}
+
+func newGenerator(opts *Options, devices []*Device, workflows []*Workflow) *generator {
+	g := &generator{
+		rng:  rand.New(rand.NewPCG(opts.Seed, uint64(opts.LabID))),
+		opts: opts,
+	}
+	for i, wf := range workflows {
+		p := &plan{
+			workflow: wf,
+			// 少数工作流失败率明显偏高，便于在失败统计中看到差异
+			failureRate: 0.03 + 0.15*math.Pow(g.rng.Float64(), 3),
+			// 执行次数按排名递减
+			weight: 1 / float64(i+1),
+		}
+		for n := 3 + g.rng.IntN(8); n > 0; n-- {
+			p.steps = append(p.steps, step{
+				device: devices[g.rng.IntN(len(devices))],
+				action: actionCatalog[g.rng.IntN(len(actionCatalog))],
+			})
+		}
+		g.plans = append(g.plans, p)
+		g.total += p.weight
+	}
+	return g
+}
+
+// Generate builds the executions sorted by start time and the device events
+// of the period
+func Generate(opts *Options, devices []*Device, workflows []*Workflow) ([]*Execution, []*model.DeviceEventHistory) {
+	if len(devices) == 0 || len(workflows) == 0 {
+		return nil, nil
+	}
+	g := newGenerator(opts, devices, workflows)
+	starts := make([]time.Time, 0, opts.Executions)
+	for i := 0; i < opts.Executions; i++ {
+		starts = append(starts, g.startTime())
+	}
+	slices.SortFunc(starts, time.Time.Compare)
+
+	executions := make([]*Execution, 0, opts.Executions)
+	events := g.connectionEvents(devices)
+	for _, startedAt := range starts {
+		exec := g.execution(g.pickPlan(), startedAt)
+		executions = append(executions, exec)
+		events = append(events, g.actionEvents(exec)...)
+	}
+	return executions, events
+}
+
+// startTime 在时间段内按工作日和工作时间加权取一个时间点
+func (g *generator) startTime() time.Time {
+	end := g.opts.Now.In(g.opts.Location)
+	for {
+		day := end.AddDate(0, 0, -g.rng.IntN(g.opts.Days))
+		// 周末提交较少
+		if wd := day.Weekday(); (wd == time.Saturday || wd == time.Sunday) && g.rng.Float64() > 0.3 {
+			continue
+		}
+		t := time.Date(day.Year(), day.Month(), day.Day(), g.hour(), g.rng.IntN(60), g.rng.IntN(60), 0, g.opts.Location)
+		if t.Before(end) {
+			return t
+		}
+	}
+}
+
+func (g *generator) hour() int {
+	var total float64
+	for _, w := range hourWeights {
+		total += w
+	}
+	r := g.rng.Float64() * total
+	for h, w := range hourWeights {
+		if r < w {
+			return h
+		}
+		r -= w
+	}
+	return len(hourWeights) - 1
+}
+
+func (g *generator) pickPlan() *plan {
+	r := g.rng.Float64() * g.total
+	for _, p := range g.plans {
+		if r < p.weight {
+			return p
+		}
+		r -= p.weight
+	}
+	return g.plans[len(g.plans)-1]
+}
+
+// duration 对数正态分布的时长，长尾与实际设备动作接近
+func (g *generator) duration(medianMs float64) int64 {
+	return int64(math.Max(500, medianMs*math.Exp(0.35*g.rng.NormFloat64())))
+}
+
+func (g *generator) execution(p *plan, startedAt time.Time) *Execution {
+	exec := &model.WorkflowExecutionHistory{
+		BaseModel:    g.base(startedAt),
+		LabID:        g.opts.LabID,
+		UserID:       g.opts.UserID,
+		WorkflowID:   p.workflow.ID,
+		WorkflowUUID: p.workflow.UUID,
+		WorkflowName: p.workflow.Name,
+		StepsTotal:   len(p.steps),
+		StartedAt:    startedAt,
+		Labels: datatypes.NewJSONType(map[string]string{
+			"seed":    "demo",
+			"project": projects[g.rng.IntN(len(projects))],
+		}),
+		SLATargetMs: int64(p.workflow.SLAMinutes) * time.Minute.Milliseconds(),
+	}
+
+	status := model.ExecutionStatusSuccess
+	failAt := -1
+	switch r := g.rng.Float64(); {
+	case r < p.failureRate:
+		status = model.ExecutionStatusFailed
+		failAt = g.rng.IntN(len(p.steps))
+	case r < p.failureRate+0.03:
+		status = model.ExecutionStatusCancelled
+		failAt = g.rng.IntN(len(p.steps))
+	case r < p.failureRate+0.04:
+		status = model.ExecutionStatusTimeout
+		failAt = len(p.steps) - 1
+	}
+
+	actions := make([]*model.ActionExecutionHistory, 0, len(p.steps))
+	t := startedAt
+	for i, s := range p.steps {
+		t = t.Add(time.Duration(1+g.rng.IntN(5)) * time.Second)
+		if !t.Before(g.opts.Now) {
+			// 下一步还未开始
+			status = model.ExecutionStatusRunning
+			break
+		}
+		a := &model.ActionExecutionHistory{
+			BaseModel:  g.base(t),
+			LabID:      g.opts.LabID,
+			DeviceID:   s.device.ID,
+			DeviceUUID: s.device.UUID,
+			DeviceName: s.device.Name,
+			ActionType: s.action.Type,
+			ActionName: s.action.Name,
+			Status:     model.ExecutionStatusSuccess,
+			DurationMs: g.duration(s.action.MedianMs),
+			ExpectedMs: int64(s.action.MedianMs * 1.5),
+		}
+		end := t.Add(time.Duration(a.DurationMs) * time.Millisecond)
+		actions = append(actions, a)
+		switch {
+		case end.After(g.opts.Now):
+			// 仍在执行的动作
+			a.Status, a.DurationMs = model.ExecutionStatusRunning, 0
+			status = model.ExecutionStatusRunning
+		case i == failAt:
+			a.Status = status
+			if status == model.ExecutionStatusFailed {
+				msg := errorMessages[g.rng.IntN(len(errorMessages))]
+				a.ErrorMessage, exec.ErrorMessage = &msg, &msg
+				exec.StepsFailed = 1
+			}
+		default:
+			exec.StepsCompleted++
+		}
+		t = end
+		if a.Status != model.ExecutionStatusSuccess {
+			break
+		}
+	}
+
+	exec.Status = status
+	if status != model.ExecutionStatusRunning {
+		completedAt := t
+		exec.CompletedAt = &completedAt
+		exec.DurationMs = completedAt.Sub(startedAt).Milliseconds()
+		exec.SLABreached = exec.SLABreach(status, completedAt)
+		exec.UpdatedAt = completedAt
+	}
+	return &Execution{Execution: exec, Actions: actions}
+}
+
+// connectionEvents 设备在时间段开始时已连接，之后偶尔断开几分钟
+func (g *generator) connectionEvents(devices []*Device) []*model.DeviceEventHistory {
+	start := g.opts.Now.AddDate(0, 0, -g.opts.Days)
+	events := make([]*model.DeviceEventHistory, 0)
+	for _, d := range devices {
+		events = append(events, g.event(d, model.DeviceEventConnected, start, nil))
+		for day := 0; day < g.opts.Days; day++ {
+			if g.rng.Float64() > 0.15 {
+				continue
+			}
+			down := start.AddDate(0, 0, day).Add(time.Duration(g.rng.Int64N(int64(24 * time.Hour))))
+			up := down.Add(time.Duration(1+g.rng.IntN(45)) * time.Minute)
+			if up.After(g.opts.Now) {
+				continue
+			}
+			events = append(events,
+				g.event(d, model.DeviceEventDisconnected, down, map[string]any{"reason": "heartbeat lost"}),
+				g.event(d, model.DeviceEventConnected, up, nil))
+		}
+	}
+	return events
+}
+
+// actionEvents 动作开始和结束时设备的状态变化，失败时记录错误事件
+func (g *generator) actionEvents(exec *Execution) []*model.DeviceEventHistory {
+	events := make([]*model.DeviceEventHistory, 0, 2*len(exec.Actions))
+	for _, a := range exec.Actions {
+		d := &Device{ID: a.DeviceID, UUID: a.DeviceUUID, Name: a.DeviceName}
+		events = append(events, g.event(d, model.DeviceEventStatusChange, a.CreatedAt, map[string]any{"status": "busy", "action": a.ActionName}))
+		if a.Status == model.ExecutionStatusRunning {
+			continue
+		}
+		end := a.CreatedAt.Add(time.Duration(a.DurationMs) * time.Millisecond)
+		if a.Status == model.ExecutionStatusFailed {
+			events = append(events, g.event(d, model.DeviceEventError, end, map[string]any{"message": *a.ErrorMessage}))
+		}
+		events = append(events, g.event(d, model.DeviceEventStatusChange, end, map[string]any{"status": "idle"}))
+	}
+	return events
+}
+
+func (g *generator) event(d *Device, eventType model.DeviceEventType, t time.Time, data map[string]any) *model.DeviceEventHistory {
+	e := &model.DeviceEventHistory{
+		BaseModel:  g.base(t),
+		LabID:      g.opts.LabID,
+		DeviceID:   d.ID,
+		DeviceUUID: d.UUID,
+		EventType:  eventType,
+		Timestamp:  t,
+	}
+	if data != nil {
+		e.EventData, _ = json.Marshal(data)
+	}
+	return e
+}
+
+func (g *generator) base(t time.Time) model.BaseModel {
+	return model.BaseModel{UUID: uuid.New(), CreatedAt: t, UpdatedAt: t}
+}
+
+// demoName 补充的设备和工作流名称
+func demoName(kind string, i int) string {
+	return fmt.Sprintf("demo-%s-%d", kind, i+1)
+}
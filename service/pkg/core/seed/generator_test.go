@@ -0,0 +1,107 @@
+package seed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInputs() ([]*Device, []*Workflow) {
+	devices := []*Device{
+		{ID: 1, UUID: uuid.NewV4(), Name: "pipette"},
+		{ID: 2, UUID: uuid.NewV4(), Name: "incubator"},
+		{ID: 3, UUID: uuid.NewV4(), Name: "reader"},
+	}
+	workflows := []*Workflow{
+		{ID: 10, UUID: uuid.NewV4(), Name: "elisa", SLAMinutes: 60},
+		{ID: 11, UUID: uuid.NewV4(), Name: "pcr-setup"},
+		{ID: 12, UUID: uuid.NewV4(), Name: "serial-dilution"},
+	}
+	return devices, workflows
+}
+
+func TestGenerate(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	devices, workflows := testInputs()
+	opts := &Options{LabID: 1, UserID: "u", Executions: 3000, Days: 30, Seed: 42, Now: now, Location: time.UTC}
+	executions, events := Generate(opts, devices, workflows)
+	require.Len(t, executions, 3000)
+	assert.NotEmpty(t, events)
+
+	statuses := make(map[model.ExecutionStatus]int)
+	perWorkflow := make(map[int64]int)
+	working := 0
+	for i, e := range executions {
+		exec := e.Execution
+		statuses[exec.Status]++
+		perWorkflow[exec.WorkflowID]++
+		assert.True(t, exec.StartedAt.Before(now))
+		assert.False(t, exec.StartedAt.Before(now.AddDate(0, 0, -30)))
+		if i > 0 {
+			assert.False(t, exec.StartedAt.Before(executions[i-1].Execution.StartedAt), "sorted by start time")
+		}
+		if h := exec.StartedAt.Hour(); h >= 8 && h < 19 {
+			working++
+		}
+		assert.Equal(t, "demo", exec.Labels.Data()["seed"])
+
+		require.NotEmpty(t, e.Actions)
+		for _, a := range e.Actions {
+			assert.False(t, a.CreatedAt.Before(exec.StartedAt))
+		}
+		last := e.Actions[len(e.Actions)-1]
+		switch exec.Status {
+		case model.ExecutionStatusSuccess:
+			assert.Equal(t, exec.StepsTotal, exec.StepsCompleted)
+			require.NotNil(t, exec.CompletedAt)
+			assert.Equal(t, exec.CompletedAt.Sub(exec.StartedAt).Milliseconds(), exec.DurationMs)
+		case model.ExecutionStatusFailed:
+			assert.Equal(t, model.ExecutionStatusFailed, last.Status)
+			assert.NotNil(t, last.ErrorMessage)
+			assert.Equal(t, 1, exec.StepsFailed)
+		case model.ExecutionStatusRunning:
+			assert.Nil(t, exec.CompletedAt)
+		}
+		if exec.SLATargetMs > 0 && exec.Status != model.ExecutionStatusRunning && exec.Status != model.ExecutionStatusCancelled {
+			assert.NotNil(t, exec.SLABreached)
+		}
+	}
+
+	// 大部分执行成功，失败率在合理范围
+	assert.Greater(t, statuses[model.ExecutionStatusSuccess], 2300)
+	assert.Greater(t, statuses[model.ExecutionStatusFailed], 30)
+	assert.Positive(t, statuses[model.ExecutionStatusCancelled])
+	// 执行集中在工作时间，排名靠前的工作流执行更多
+	assert.Greater(t, working, 2000)
+	assert.Greater(t, perWorkflow[10], perWorkflow[12])
+
+	types := make(map[model.DeviceEventType]int)
+	for _, e := range events {
+		types[e.EventType]++
+		assert.Contains(t, []int64{1, 2, 3}, e.DeviceID)
+	}
+	assert.Equal(t, statuses[model.ExecutionStatusFailed], types[model.DeviceEventError])
+	assert.GreaterOrEqual(t, types[model.DeviceEventConnected], len(devices))
+	assert.Positive(t, types[model.DeviceEventStatusChange])
+
+	// 相同种子生成相同分布
+	again, _ := Generate(opts, devices, workflows)
+	for i := range executions {
+		assert.Equal(t, executions[i].Execution.StartedAt, again[i].Execution.StartedAt)
+		assert.Equal(t, executions[i].Execution.Status, again[i].Execution.Status)
+	}
+}
+
+func TestGenerateEmpty(t *testing.T) {
+	devices, workflows := testInputs()
+	opts := &Options{LabID: 1, Executions: 10, Days: 1, Now: time.Now(), Location: time.UTC}
+	executions, events := Generate(opts, nil, workflows)
+	assert.Empty(t, executions)
+	assert.Empty(t, events)
+	executions, _ = Generate(opts, devices, nil)
+	assert.Empty(t, executions)
+}
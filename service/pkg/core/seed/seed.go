@@ -0,0 +1,214 @@
+// Package seed populates a lab with synthetic history for demos and for load
+// testing the statistics endpoints. Executions, actions and device events follow
+// plausible distributions of start time, status, duration and device usage.
+package seed
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/constant"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/seed"
+)
+
+const (
+	defaultExecutions = 2000
+	maxExecutions     = 20000
+	defaultDays       = 30
+	maxDays           = 365
+	demoDevices       = 6
+	demoWorkflows     = 8
+	maxWorkflows      = 20
+	// executionBatch 每批写入的执行数，动作在所属执行写入后写入
+	executionBatch = 500
+)
+
+// StartupUserID 启动参数生成的执行记录的用户
+const StartupUserID = "seed"
+
+// SeedReq 生成演示历史数据
+type SeedReq struct {
+	LabUUID    uuid.UUID `json:"lab_uuid" binding:"required"`
+	Executions int       `json:"executions"` // 默认 2000，最大 20000
+	Days       int       `json:"days"`       // 分布在最近多少天，默认 30，最大 365
+	Seed       uint64    `json:"seed"`       // 随机种子，相同种子生成相同分布的数据
+	Timezone   string    `json:"timezone"`   // 工作时间所在时区，默认服务器时区
+}
+
+// SeedResp 生成的记录数
+type SeedResp struct {
+	Executions int `json:"executions"`
+	Actions    int `json:"actions"`
+	Events     int `json:"events"`
+	Devices    int `json:"devices"`
+	Workflows  int `json:"workflows"`
+}
+
+type Service struct {
+	store  seed.SeedRepo
+	baseDB repo.IDOrUUIDTranslate
+}
+
+func New() *Service {
+	return &Service{
+		store:  seed.New(),
+		baseDB: repo.NewBaseDB(),
+	}
+}
+
+// Seed 为实验室生成演示历史数据，生产环境不提供，仅管理员可操作
+func (s *Service) Seed(ctx context.Context, req *SeedReq) (*SeedResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return nil, code.NoPermission
+	}
+	return s.Run(ctx, req, userInfo.ID)
+}
+
+// Run generates the history of the lab on behalf of userID, it is used by the
+// admin endpoint and the apiserver --seed-lab flag
+func (s *Service) Run(ctx context.Context, req *SeedReq, userID string) (*SeedResp, error) {
+	if config.Global().Server.Env == constant.EnvProd {
+		return nil, code.SeedForbiddenErr
+	}
+	if req.Executions <= 0 {
+		req.Executions = defaultExecutions
+	}
+	if req.Days <= 0 {
+		req.Days = defaultDays
+	}
+	req.Executions = min(req.Executions, maxExecutions)
+	req.Days = min(req.Days, maxDays)
+	loc := time.Local
+	if req.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(req.Timezone); err != nil {
+			return nil, code.ParamErr.WithErr(err)
+		}
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, req.LabUUID)[req.LabUUID]
+	if labID == 0 {
+		return nil, code.LabNotFound
+	}
+	devices, err := s.devices(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	workflows, err := s.workflows(ctx, labID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	executions, events := Generate(&Options{
+		LabID:      labID,
+		UserID:     userID,
+		Executions: req.Executions,
+		Days:       req.Days,
+		Seed:       req.Seed,
+		Now:        time.Now(),
+		Location:   loc,
+	}, devices, workflows)
+
+	resp := &SeedResp{Devices: len(devices), Workflows: len(workflows)}
+	for start := 0; start < len(executions); start += executionBatch {
+		batch := executions[start:min(start+executionBatch, len(executions))]
+		execs := make([]*model.WorkflowExecutionHistory, 0, len(batch))
+		for _, e := range batch {
+			execs = append(execs, e.Execution)
+		}
+		if err := s.store.InsertHistory(ctx, execs); err != nil {
+			return resp, err
+		}
+		actions := make([]*model.ActionExecutionHistory, 0, len(batch)*8)
+		for _, e := range batch {
+			for _, a := range e.Actions {
+				a.WorkflowExecutionID = &e.Execution.ID
+				actions = append(actions, a)
+			}
+		}
+		if len(actions) > 0 {
+			if err := s.store.InsertHistory(ctx, actions); err != nil {
+				return resp, err
+			}
+		}
+		resp.Executions += len(execs)
+		resp.Actions += len(actions)
+	}
+	if len(events) > 0 {
+		if err := s.store.InsertHistory(ctx, events); err != nil {
+			return resp, err
+		}
+		resp.Events = len(events)
+	}
+	logger.Infof(ctx, "seed lab %d done, %d executions, %d actions, %d events",
+		labID, resp.Executions, resp.Actions, resp.Events)
+	return resp, nil
+}
+
+// devices 使用实验室已有的设备，没有设备时创建演示设备
+func (s *Service) devices(ctx context.Context, labID int64) ([]*Device, error) {
+	nodes, err := s.store.LabDevices(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		for i := 0; i < demoDevices; i++ {
+			name := demoName("device", i)
+			nodes = append(nodes, &model.MaterialNode{
+				BaseModel:   model.BaseModel{UUID: uuid.New()},
+				LabID:       labID,
+				Name:        name,
+				DisplayName: name,
+				Type:        model.MATERIALDEVICE,
+				Status:      "idle",
+			})
+		}
+		if err := s.store.CreateDevices(ctx, nodes); err != nil {
+			return nil, err
+		}
+	}
+	devices := make([]*Device, 0, len(nodes))
+	for _, n := range nodes {
+		devices = append(devices, &Device{ID: n.ID, UUID: n.UUID, Name: n.DisplayName})
+	}
+	return devices, nil
+}
+
+// workflows 使用实验室已有的工作流，没有工作流时创建演示工作流
+func (s *Service) workflows(ctx context.Context, labID int64, userID string) ([]*Workflow, error) {
+	datas, err := s.store.LabWorkflows(ctx, labID, maxWorkflows)
+	if err != nil {
+		return nil, err
+	}
+	if len(datas) == 0 {
+		for i := 0; i < demoWorkflows; i++ {
+			datas = append(datas, &model.Workflow{
+				BaseModel: model.BaseModel{UUID: uuid.New()},
+				UserID:    userID,
+				LabID:     labID,
+				Name:      demoName("workflow", i),
+				// 部分工作流设置 SLA，便于查看 SLA 统计
+				SLAMinutes: []int{0, 30, 60}[i%3],
+			})
+		}
+		if err := s.store.CreateWorkflows(ctx, datas); err != nil {
+			return nil, err
+		}
+	}
+	workflows := make([]*Workflow, 0, len(datas))
+	for _, w := range datas {
+		workflows = append(workflows, &Workflow{ID: w.ID, UUID: w.UUID, Name: w.Name, SLAMinutes: w.SLAMinutes})
+	}
+	return workflows, nil
+}
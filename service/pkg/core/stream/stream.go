@@ -0,0 +1,414 @@
+// Package stream registers device camera streams and issues short-lived signed
+// tokens for viewing them over the realtime signaling channel, tracking each
+// viewing session from start to stop.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/features"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/stream"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	defaultTokenTTL      = 2 * time.Minute
+	defaultMaxSession    = 12 * time.Hour
+	maxStreamsPerRequest = 100
+
+	// websocket 连接指标中的连接类型
+	connTypeCamera = "camera"
+
+	// 会话结束原因
+	EndReasonStopped      = "stopped"
+	EndReasonDisconnected = "viewer_disconnected"
+	EndReasonTokenExpired = "token_expired"
+	EndReasonMaxDuration  = "max_duration"
+	EndReasonUnregistered = "stream_unregistered"
+
+	sweepBatch = 200
+)
+
+// StreamItem edge 上报的摄像头
+type StreamItem struct {
+	DeviceName string `json:"device_name" binding:"required"`
+	CameraID   string `json:"camera_id" binding:"required"`
+	HostID     string `json:"host_id" binding:"required"` // edge 连接 /realtime/host/:hostId 时使用的 id
+	Name       string `json:"name"`
+}
+
+// RegisterReq edge 批量注册摄像头
+type RegisterReq struct {
+	Streams []*StreamItem `json:"streams" binding:"required,dive"`
+}
+
+// StartSessionReq 开始观看摄像头
+type StartSessionReq struct {
+	StreamUUID uuid.UUID `json:"stream_uuid" binding:"required"`
+}
+
+// StartSessionResp 观看会话与信令连接参数
+type StartSessionResp struct {
+	Session   *model.StreamSession `json:"session"`
+	Token     string               `json:"token"` // 连接 /realtime/client 时通过 token 参数传入
+	ExpiresAt time.Time            `json:"expires_at"`
+	HostID    string               `json:"host_id"`
+	CameraID  string               `json:"camera_id"`
+}
+
+type Service struct {
+	store    stream.StreamRepo
+	history  history.HistoryRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+	secret   []byte
+}
+
+func New() *Service {
+	return &Service{
+		store:    stream.New(),
+		history:  history.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+		secret:   tokenSecret(),
+	}
+}
+
+// RegisterStreams edge 注册或更新摄像头，设备须为实验室中的设备节点
+func (s *Service) RegisterStreams(ctx context.Context, req *RegisterReq) ([]*model.CameraStream, error) {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return nil, code.UnLogin
+	}
+	if len(req.Streams) > maxStreamsPerRequest {
+		return nil, code.ParamErr.WithMsgf("streams must not exceed %d", maxStreamsPerRequest)
+	}
+
+	nodes := make(map[string]*model.MaterialNode)
+	streams := make([]*model.CameraStream, 0, len(req.Streams))
+	for _, item := range req.Streams {
+		node, ok := nodes[item.DeviceName]
+		if !ok {
+			node = &model.MaterialNode{}
+			if err := s.baseDB.GetData(ctx, node, map[string]any{
+				"lab_id": labUser.LabID,
+				"name":   item.DeviceName,
+				"type":   model.MATERIALDEVICE,
+			}, "id", "uuid", "name"); err != nil {
+				return nil, code.CanNotFoundMaterialNodeErr.WithMsgf("device %s not found", item.DeviceName)
+			}
+			nodes[item.DeviceName] = node
+		}
+
+		streams = append(streams, &model.CameraStream{
+			LabID:      labUser.LabID,
+			DeviceID:   node.ID,
+			DeviceUUID: node.UUID,
+			DeviceName: node.Name,
+			CameraID:   item.CameraID,
+			HostID:     item.HostID,
+			Name:       item.Name,
+		})
+	}
+
+	if err := s.store.UpsertStreams(ctx, streams); err != nil {
+		return nil, err
+	}
+	return streams, nil
+}
+
+// UnregisterStream edge 删除摄像头，并结束其上的观看会话
+func (s *Service) UnregisterStream(ctx context.Context, cameraID string) error {
+	labUser := auth.GetLabUser(ctx)
+	if labUser == nil {
+		return code.UnLogin
+	}
+
+	sessions, err := s.store.ListActiveSessions(ctx, labUser.LabID)
+	if err != nil {
+		return err
+	}
+	streams, err := s.store.ListStreams(ctx, labUser.LabID)
+	if err != nil {
+		return err
+	}
+	for _, st := range streams {
+		if st.CameraID != cameraID {
+			continue
+		}
+		for _, session := range sessions {
+			if session.StreamID == st.ID {
+				s.end(ctx, session, EndReasonUnregistered)
+			}
+		}
+	}
+
+	return s.store.DeleteStream(ctx, labUser.LabID, cameraID)
+}
+
+// ListStreams 查询实验室的摄像头
+func (s *Service) ListStreams(ctx context.Context, labUUID uuid.UUID) ([]*model.CameraStream, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListStreams(ctx, labID)
+}
+
+// StartSession 开始观看，返回用于建立信令连接的短期令牌
+func (s *Service) StartSession(ctx context.Context, req *StartSessionReq) (*StartSessionResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !features.IsEnabled(features.FeatureRealtimeCamera) {
+		return nil, code.CameraFeatureDisabledErr
+	}
+
+	st, err := s.store.GetStreamByUUID(ctx, req.StreamUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMemberByID(ctx, userInfo.ID, st.LabID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &model.StreamSession{
+		LabID:       st.LabID,
+		StreamID:    st.ID,
+		StreamUUID:  st.UUID,
+		DeviceID:    st.DeviceID,
+		DeviceUUID:  st.DeviceUUID,
+		UserID:      userInfo.ID,
+		StartedAt:   now,
+		TokenExpiry: now.Add(tokenTTL()),
+	}
+	if err := s.store.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	s.recordEvent(ctx, session, model.DeviceEventStreamStarted, map[string]any{
+		"camera_id": st.CameraID,
+	})
+
+	return &StartSessionResp{
+		Session: session,
+		Token: signToken(s.secret, &tokenClaims{
+			SessionUUID: session.UUID,
+			UserID:      session.UserID,
+			ExpiresAt:   session.TokenExpiry.Unix(),
+		}),
+		ExpiresAt: session.TokenExpiry,
+		HostID:    st.HostID,
+		CameraID:  st.CameraID,
+	}, nil
+}
+
+// Attach 校验观看令牌并将信令连接关联到会话
+func (s *Service) Attach(ctx context.Context, token, clientID string) (*model.StreamSession, error) {
+	if !features.IsEnabled(features.FeatureRealtimeCamera) {
+		return nil, code.CameraFeatureDisabledErr
+	}
+
+	claims, err := parseToken(s.secret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.store.GetSessionByUUID(ctx, claims.SessionUUID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != claims.UserID {
+		return nil, code.StreamTokenInvalidErr
+	}
+	if session.Status != model.StreamSessionActive {
+		return nil, code.StreamSessionEndedErr
+	}
+	if err := s.store.MarkConnected(ctx, session, clientID); err != nil {
+		return nil, err
+	}
+
+	otel.GetMetrics().WebSocketConnected(ctx, connTypeCamera)
+	return session, nil
+}
+
+// Detach 信令连接断开时结束会话
+func (s *Service) Detach(ctx context.Context, session *model.StreamSession) {
+	otel.GetMetrics().WebSocketDisconnected(ctx, connTypeCamera)
+	s.end(ctx, session, EndReasonDisconnected)
+}
+
+// StopSession 用户主动结束观看会话
+func (s *Service) StopSession(ctx context.Context, sessionUUID uuid.UUID) (*model.StreamSession, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	session, err := s.store.GetSessionByUUID(ctx, sessionUUID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userInfo.ID {
+		return nil, code.NoPermission
+	}
+	if session.Status != model.StreamSessionActive {
+		return nil, code.StreamSessionEndedErr
+	}
+
+	if !s.end(ctx, session, EndReasonStopped) {
+		return nil, code.StreamSessionEndedErr
+	}
+	return session, nil
+}
+
+// ListSessions 查询实验室正在进行的观看会话
+func (s *Service) ListSessions(ctx context.Context, labUUID uuid.UUID) ([]*model.StreamSession, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListActiveSessions(ctx, labID)
+}
+
+// Sweep 结束令牌过期仍未连接或超过最长观看时间的会话
+func (s *Service) Sweep(ctx context.Context) error {
+	now := time.Now()
+	stale, err := s.store.ListStaleSessions(ctx, now, now.Add(-maxSession()), sweepBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range stale {
+		reason := EndReasonMaxDuration
+		if session.ConnectedAt == nil {
+			reason = EndReasonTokenExpired
+		}
+		s.end(ctx, session, reason)
+	}
+	return nil
+}
+
+// end 结束会话并记录设备事件，会话已结束时返回 false
+func (s *Service) end(ctx context.Context, session *model.StreamSession, reason string) bool {
+	ok, err := s.store.EndSession(ctx, session, reason)
+	if err != nil {
+		logger.Warnf(ctx, "end stream session fail uuid: %s, err: %+v", session.UUID, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	s.recordEvent(ctx, session, model.DeviceEventStreamStopped, map[string]any{
+		"reason":       reason,
+		"duration_sec": int64(session.EndedAt.Sub(session.StartedAt).Seconds()),
+	})
+	return true
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+	if err := s.checkMemberByID(ctx, userID, labID); err != nil {
+		return 0, err
+	}
+	return labID, nil
+}
+
+func (s *Service) checkMemberByID(ctx context.Context, userID string, labID int64) error {
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+func (s *Service) recordEvent(ctx context.Context, session *model.StreamSession, eventType model.DeviceEventType, extra map[string]any) {
+	payload := map[string]any{
+		"session_uuid": session.UUID,
+		"stream_uuid":  session.StreamUUID,
+		"user_id":      session.UserID,
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	data, _ := json.Marshal(payload)
+
+	if err := s.history.CreateDeviceEvent(ctx, &model.DeviceEventHistory{
+		LabID:      session.LabID,
+		DeviceID:   session.DeviceID,
+		DeviceUUID: session.DeviceUUID,
+		EventType:  eventType,
+		EventData:  data,
+		Timestamp:  time.Now(),
+	}); err != nil {
+		logger.Warnf(ctx, "record stream session event fail uuid: %s, event: %s, err: %+v", session.UUID, eventType, err)
+	}
+}
+
+// tokenSecret 未配置观看令牌密钥时使用共享的下载链接签名密钥，
+// 保证令牌在所有实例和重启后都能校验
+func tokenSecret() []byte {
+	if secret := config.Global().Camera.TokenSecret; secret != "" {
+		return []byte(secret)
+	}
+	return utils.SignedURLSecret()
+}
+
+func tokenTTL() time.Duration {
+	if sec := config.Global().Camera.TokenTTLSec; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultTokenTTL
+}
+
+func maxSession() time.Duration {
+	if minutes := config.Global().Camera.MaxSessionMin; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultMaxSession
+}
+
+// RegisterJob 注册观看会话清理后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "camera_session_cleanup",
+		Description:  "结束令牌过期未连接或超过最长观看时间的摄像头观看会话",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     time.Minute.String(),
+		Timeout:      time.Minute,
+		Run:          s.Sweep,
+	})
+}
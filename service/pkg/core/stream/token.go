@@ -0,0 +1,32 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+// tokenClaims 观看令牌内容，令牌只用于建立信令连接，连接后由会话状态控制
+type tokenClaims struct {
+	SessionUUID uuid.UUID `json:"sid"`
+	UserID      string    `json:"uid"`
+	ExpiresAt   int64     `json:"exp"`
+}
+
+func signToken(secret []byte, claims *tokenClaims) string {
+	return utils.SignClaims(secret, claims)
+}
+
+// parseToken 校验签名与有效期
+func parseToken(secret []byte, token string, now time.Time) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+	if !utils.VerifyClaims(secret, token, claims) {
+		return nil, code.StreamTokenInvalidErr
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, code.StreamTokenInvalidErr.WithMsg("token expired")
+	}
+	return claims, nil
+}
@@ -0,0 +1,38 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+	claims := &tokenClaims{
+		SessionUUID: uuid.NewV4(),
+		UserID:      "user",
+		ExpiresAt:   now.Add(time.Minute).Unix(),
+	}
+	token := signToken(secret, claims)
+
+	got, err := parseToken(secret, token, now)
+	require.NoError(t, err)
+	assert.Equal(t, claims.SessionUUID, got.SessionUUID)
+	assert.Equal(t, claims.UserID, got.UserID)
+
+	_, err = parseToken(secret, token, now.Add(2*time.Minute))
+	assert.Error(t, err, "expired token")
+
+	_, err = parseToken([]byte("other"), token, now)
+	assert.Error(t, err, "wrong secret")
+
+	_, err = parseToken(secret, token[:len(token)-2]+"xx", now)
+	assert.Error(t, err, "tampered signature")
+
+	_, err = parseToken(secret, "garbage", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,310 @@
+// Package systemhealth assembles the admin health summary: the traffic and
+// dependency latency of the instance serving the request, and the queue
+// depths, background job states and recent configuration changes shared by
+// all instances.
+package systemhealth
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/features"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/deadletter"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/impersonation"
+	"github.com/scienceol/studio/service/pkg/repo/job"
+)
+
+// 汇总的分区，响应的 errors 按分区返回失败原因
+const (
+	SectionQueues        = "queues"
+	SectionJobs          = "jobs"
+	SectionConfigChanges = "config_changes"
+)
+
+const recentConfigChanges = 20
+
+// Traffic 当前实例最近一个统计窗口的请求量，比例为 0 到 1
+type Traffic struct {
+	WindowSecs      int64   `json:"window_secs"`
+	Requests        int64   `json:"requests"`
+	RequestsPerSec  float64 `json:"requests_per_sec"`
+	Errors          int64   `json:"errors"`     // 5xx 响应
+	ErrorRate       float64 `json:"error_rate"` // 5xx 响应占全部请求的比例
+	RateLimited     int64   `json:"rate_limited"`
+	RateLimitedRate float64 `json:"rate_limited_rate"`
+	LoadShed        int64   `json:"load_shed"`
+}
+
+// Latency 依赖耗时的分位数，单位毫秒
+type Latency struct {
+	Samples int     `json:"samples"`
+	P50Ms   float64 `json:"p50_ms"`
+	P95Ms   float64 `json:"p95_ms"`
+	P99Ms   float64 `json:"p99_ms"`
+}
+
+// Queues 所有实验室排队中和运行中的执行数，以及死信队列
+type Queues struct {
+	PendingExecutions int64                  `json:"pending_executions"`
+	RunningExecutions int64                  `json:"running_executions"`
+	DeadLetter        *model.DeadLetterStats `json:"dead_letter"`
+}
+
+// JobStatus 后台任务的调度状态和最近一次运行结果
+type JobStatus struct {
+	Name       string             `json:"name"`
+	Registered bool               `json:"registered"` // 当前版本是否仍注册该任务
+	Paused     bool               `json:"paused"`
+	NextRunAt  *time.Time         `json:"next_run_at"`
+	LastRunAt  *time.Time         `json:"last_run_at"`
+	LastStatus model.JobRunStatus `json:"last_status"`
+	LastError  string             `json:"last_error"`
+}
+
+// ConfigChange 配置、功能开关、维护或任务调度的变更
+type ConfigChange struct {
+	Action    model.AuditAction `json:"action"`
+	UserID    string            `json:"user_id"`
+	LabID     int64             `json:"lab_id"`
+	Detail    string            `json:"detail"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Health 系统健康汇总，traffic 和 latency 只统计处理该请求的实例，失败的分区为空，原因在 Errors 中
+type Health struct {
+	Instance      string              `json:"instance"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+	Traffic       *Traffic            `json:"traffic"`
+	Latency       map[string]*Latency `json:"latency"` // 按依赖，db 和 redis
+	Queues        *Queues             `json:"queues"`
+	Jobs          []*JobStatus        `json:"jobs"`
+	ConfigChanges []*ConfigChange     `json:"config_changes"`
+	Errors        map[string]error    `json:"-"`
+}
+
+type Service struct {
+	history    history.HistoryRepo
+	deadLetter deadletter.DeadLetterRepo
+	jobs       job.JobRepo
+	manager    *jobs.Manager
+	audit      impersonation.ImpersonationRepo
+	health     func() otel.HealthSnapshot
+}
+
+func New() *Service {
+	return &Service{
+		history:    history.New(),
+		deadLetter: deadletter.New(),
+		jobs:       job.New(),
+		manager:    jobs.Default(),
+		audit:      impersonation.New(),
+		health:     otel.GetMetrics().Health,
+	}
+}
+
+// Get 返回系统健康汇总，仅管理员可查看；单个分区失败只记录在 Errors 中
+func (s *Service) Get(ctx context.Context) (*Health, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	if !auth.IsAdmin(userInfo.ID) {
+		return nil, code.NoPermission
+	}
+
+	h := &Health{GeneratedAt: time.Now()}
+	h.Instance, _ = os.Hostname()
+	h.Traffic, h.Latency = summarize(s.health())
+
+	h.Errors = make(map[string]error)
+	sections := map[string]func() error{
+		SectionQueues: func() (err error) {
+			h.Queues, err = s.queues(ctx)
+			return err
+		},
+		SectionJobs: func() (err error) {
+			h.Jobs, err = s.jobStatuses(ctx)
+			return err
+		},
+		SectionConfigChanges: func() (err error) {
+			h.ConfigChanges, err = s.configChanges(ctx)
+			return err
+		},
+	}
+	for name, run := range sections {
+		if err := run(); err != nil {
+			logger.Errorf(ctx, "system health section %s fail: %+v", name, err)
+			h.Errors[name] = err
+		}
+	}
+	return h, nil
+}
+
+// summarize converts the counts of the window to rates and the latencies to milliseconds
+func summarize(snapshot otel.HealthSnapshot) (*Traffic, map[string]*Latency) {
+	t := &Traffic{
+		WindowSecs:  int64(snapshot.Window / time.Second),
+		Requests:    snapshot.Requests,
+		Errors:      snapshot.Errors,
+		RateLimited: snapshot.RateLimited,
+		LoadShed:    snapshot.LoadShed,
+	}
+	if t.WindowSecs > 0 {
+		t.RequestsPerSec = float64(t.Requests) / float64(t.WindowSecs)
+	}
+	if t.Requests > 0 {
+		t.ErrorRate = float64(t.Errors) / float64(t.Requests)
+		t.RateLimitedRate = float64(t.RateLimited) / float64(t.Requests)
+	}
+
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	latency := make(map[string]*Latency, len(snapshot.Latency))
+	for source, p := range snapshot.Latency {
+		latency[source] = &Latency{Samples: p.Samples, P50Ms: ms(p.P50), P95Ms: ms(p.P95), P99Ms: ms(p.P99)}
+	}
+	return t, latency
+}
+
+func (s *Service) queues(ctx context.Context) (*Queues, error) {
+	q := &Queues{}
+	for _, status := range []model.ExecutionStatus{model.ExecutionStatusPending, model.ExecutionStatusRunning} {
+		_, count, err := s.history.ListWorkflowExecutions(ctx, &model.HistoryQueryParams{
+			Status:   &status,
+			Page:     1,
+			PageSize: 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if status == model.ExecutionStatusPending {
+			q.PendingExecutions = count.Total
+		} else {
+			q.RunningExecutions = count.Total
+		}
+	}
+
+	var err error
+	if q.DeadLetter, err = s.deadLetter.Stats(ctx); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (s *Service) jobStatuses(ctx context.Context) ([]*JobStatus, error) {
+	datas, err := s.jobs.ListJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*JobStatus, 0, len(datas))
+	for _, data := range datas {
+		ret = append(ret, &JobStatus{
+			Name:       data.Name,
+			Registered: s.manager.Registered(data.Name),
+			Paused:     data.Paused,
+			NextRunAt:  data.NextRunAt,
+			LastRunAt:  data.LastRunAt,
+			LastStatus: data.LastStatus,
+			LastError:  data.LastError,
+		})
+	}
+	return ret, nil
+}
+
+func (s *Service) configChanges(ctx context.Context) ([]*ConfigChange, error) {
+	logs, _, err := s.audit.ListAuditLogs(ctx, &impersonation.AuditFilter{
+		Actions: model.ConfigChangeActions,
+	}, 1, recentConfigChanges)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*ConfigChange, 0, len(logs))
+	for _, l := range logs {
+		ret = append(ret, &ConfigChange{
+			Action:    l.Action,
+			UserID:    l.UserID,
+			LabID:     l.LabID,
+			Detail:    l.Detail,
+			CreatedAt: l.CreatedAt,
+		})
+	}
+	return ret, nil
+}
+
+// featureChange is the detail of an AuditFeatureChange entry
+type featureChange struct {
+	Instance string          `json:"instance"`
+	Flags    map[string]bool `json:"flags"`
+	Changed  []string        `json:"changed"`
+}
+
+// RecordFeatureFlags compares the feature flags loaded at startup with the
+// ones recorded by the last startup, and records them when they differ. Flags
+// are only read from the config file, so a change shows up as the first
+// startup with the new file
+func RecordFeatureFlags(ctx context.Context) error {
+	manager := features.GetManager()
+	if manager == nil {
+		return nil
+	}
+	return recordFeatureFlags(ctx, impersonation.New(), manager.GetAll())
+}
+
+func recordFeatureFlags(ctx context.Context, audit impersonation.ImpersonationRepo, flags map[string]bool) error {
+	logs, _, err := audit.ListAuditLogs(ctx, &impersonation.AuditFilter{
+		Actions: []model.AuditAction{model.AuditFeatureChange},
+	}, 1, 1)
+	if err != nil {
+		return err
+	}
+	var last featureChange
+	if len(logs) > 0 {
+		if err := json.Unmarshal([]byte(logs[0].Detail), &last); err != nil {
+			logger.Warnf(ctx, "decode last feature flags fail: %+v", err)
+		}
+	}
+	changed := changedFlags(last.Flags, flags)
+	if len(changed) == 0 {
+		return nil
+	}
+
+	change := featureChange{Flags: flags, Changed: changed}
+	change.Instance, _ = os.Hostname()
+	detail, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	return audit.CreateAuditLog(ctx, &model.AuditLog{
+		Action: model.AuditFeatureChange,
+		UserID: "system",
+		Detail: string(detail),
+	})
+}
+
+// changedFlags returns the sorted names of the flags added, removed or switched
+func changedFlags(prev, cur map[string]bool) []string {
+	var changed []string
+	for name, on := range cur {
+		if was, ok := prev[name]; !ok || was != on {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := cur[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
@@ -0,0 +1,47 @@
+package systemhealth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	traffic, latency := summarize(otel.HealthSnapshot{
+		Window:      otel.HealthWindow,
+		Requests:    600,
+		Errors:      6,
+		RateLimited: 30,
+		Latency: map[string]otel.LatencyPercentiles{
+			otel.LatencyRedis: {Samples: 3, P50: 1500 * time.Microsecond, P95: 2 * time.Millisecond, P99: 3 * time.Millisecond},
+		},
+	})
+
+	assert.Equal(t, &Traffic{
+		WindowSecs:      300,
+		Requests:        600,
+		RequestsPerSec:  2,
+		Errors:          6,
+		ErrorRate:       0.01,
+		RateLimited:     30,
+		RateLimitedRate: 0.05,
+	}, traffic)
+	assert.Equal(t, &Latency{Samples: 3, P50Ms: 1.5, P95Ms: 2, P99Ms: 3}, latency[otel.LatencyRedis])
+
+	// 没有请求时比例为 0
+	traffic, _ = summarize(otel.HealthSnapshot{Window: otel.HealthWindow})
+	assert.Zero(t, traffic.ErrorRate)
+}
+
+func TestChangedFlags(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, changedFlags(nil, map[string]bool{"b": false, "a": true}))
+	assert.Empty(t, changedFlags(map[string]bool{"a": true}, map[string]bool{"a": true}))
+	assert.Equal(t, []string{"a", "c", "d"}, changedFlags(
+		map[string]bool{"a": true, "b": true, "c": false},
+		map[string]bool{"a": false, "b": true, "d": true},
+	))
+	// 删除的开关同样视为变更
+	assert.Equal(t, []string{"a"}, changedFlags(map[string]bool{"a": false}, map[string]bool{}))
+}
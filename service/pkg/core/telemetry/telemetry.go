@@ -0,0 +1,359 @@
+// Package telemetry downsamples numeric device data into minute and hour
+// rollups and expires raw high-frequency events.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/respcache"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/telemetry"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+const (
+	day = 24 * time.Hour
+
+	// 每次重新汇总最近的窗口，覆盖迟到的数据
+	minuteLookback = 10 * time.Minute
+	hourLookback   = 2 * time.Hour
+
+	maxRollupPoints = 10000
+
+	maxSeriesDevices = 20
+	maxSeriesMetrics = 10
+	maxSeriesPoints  = 2000
+)
+
+// metricPattern 指标为 event_data 中以 . 分隔的字段路径，例如 sensor.temperature
+var metricPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// maxRange 单次查询的最大时间跨度
+var maxRange = map[model.TelemetryResolution]time.Duration{
+	model.TelemetryMinute: 7 * day,
+	model.TelemetryHour:   90 * day,
+}
+
+// RollupReq 查询设备数据汇总
+type RollupReq struct {
+	LabUUID    uuid.UUID                 `form:"lab_uuid" binding:"required"`
+	Resolution model.TelemetryResolution `form:"resolution,default=1m"`
+	Devices    []string                  `form:"device_uuid"`
+	Metrics    []string                  `form:"metric"`
+	StartTime  time.Time                 `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00" binding:"required"`
+	EndTime    time.Time                 `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// SeriesReq 查询设备数值时间序列
+type SeriesReq struct {
+	LabUUID   uuid.UUID             `form:"lab_uuid" binding:"required"`
+	Devices   []string              `form:"device_uuid"`
+	Metrics   []string              `form:"metric" binding:"required"`
+	EventType model.DeviceEventType `form:"event_type,default=data_received"`
+	Agg       model.TelemetryAgg    `form:"agg,default=avg"`
+	Interval  string                `form:"interval,default=1m"`
+	StartTime time.Time             `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00" binding:"required"`
+	EndTime   time.Time             `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// SeriesPoint 时间序列中的一个点，T 为桶的起始时间
+type SeriesPoint struct {
+	T time.Time `json:"t"`
+	V float64   `json:"v"`
+}
+
+// Series 单个设备单个指标的时间序列
+type Series struct {
+	DeviceUUID uuid.UUID     `json:"device_uuid"`
+	DeviceName string        `json:"device_name"`
+	Metric     string        `json:"metric"`
+	Points     []SeriesPoint `json:"points"`
+}
+
+// SeriesResp 时间序列查询结果
+type SeriesResp struct {
+	IntervalSec int64              `json:"interval_sec"`
+	Agg         model.TelemetryAgg `json:"agg"`
+	Series      []*Series          `json:"series"`
+}
+
+type Service struct {
+	store    telemetry.TelemetryRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    telemetry.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// Rollups 查询实验室设备数据汇总，仅实验室成员可以查看
+func (s *Service) Rollups(ctx context.Context, req *RollupReq) ([]*model.DeviceTelemetryRollup, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	limit, ok := maxRange[req.Resolution]
+	if !ok {
+		return nil, code.ParamErr.WithMsgf("unknown resolution: %s", req.Resolution)
+	}
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, code.ParamErr.WithMsg("end_time must be after start_time")
+	}
+	if req.EndTime.Sub(req.StartTime) > limit {
+		return nil, code.ParamErr.WithMsgf("time range must not exceed %s for resolution %s", limit, req.Resolution)
+	}
+
+	devices, err := parseDevices(req.Devices)
+	if err != nil {
+		return nil, err
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.ListRollups(ctx, &model.TelemetryRollupQuery{
+		LabID:      labID,
+		Resolution: req.Resolution,
+		Devices:    devices,
+		Metrics:    req.Metrics,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Limit:      maxRollupPoints,
+	})
+}
+
+// Series 按时间桶聚合设备事件中的数值字段，返回每个设备每个指标一条序列
+func (s *Service) Series(ctx context.Context, req *SeriesReq) (*SeriesResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	interval, err := time.ParseDuration(req.Interval)
+	if err != nil || interval < time.Second {
+		return nil, code.ParamErr.WithMsg("interval must be a duration of at least 1s, e.g. 30s, 5m, 1h")
+	}
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, code.ParamErr.WithMsg("end_time must be after start_time")
+	}
+	if req.EndTime.Sub(req.StartTime)/interval > maxSeriesPoints {
+		return nil, code.ParamErr.WithMsgf("too many points, time range / interval must not exceed %d", maxSeriesPoints)
+	}
+	if len(req.Devices) > maxSeriesDevices {
+		return nil, code.ParamErr.WithMsgf("at most %d devices per request", maxSeriesDevices)
+	}
+	if len(req.Metrics) > maxSeriesMetrics {
+		return nil, code.ParamErr.WithMsgf("at most %d metrics per request", maxSeriesMetrics)
+	}
+	for _, metric := range req.Metrics {
+		if !metricPattern.MatchString(metric) {
+			return nil, code.ParamErr.WithMsgf("invalid metric: %s", metric)
+		}
+	}
+
+	devices, err := parseDevices(req.Devices)
+	if err != nil {
+		return nil, err
+	}
+
+	labID, err := s.checkMember(ctx, userInfo.ID, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SeriesResp{
+		IntervalSec: int64(interval / time.Second),
+		Agg:         req.Agg,
+		Series:      make([]*Series, 0, len(req.Metrics)),
+	}
+	for _, metric := range req.Metrics {
+		points, err := s.store.Series(ctx, &model.TelemetrySeriesQuery{
+			LabID:     labID,
+			Devices:   devices,
+			EventType: req.EventType,
+			Path:      strings.Split(metric, "."),
+			Agg:       req.Agg,
+			Interval:  interval,
+			StartTime: req.StartTime,
+			EndTime:   req.EndTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var current *Series
+		for _, p := range points {
+			if current == nil || current.DeviceUUID != p.DeviceUUID {
+				current = &Series{DeviceUUID: p.DeviceUUID, Metric: metric}
+				resp.Series = append(resp.Series, current)
+			}
+			current.Points = append(current.Points, SeriesPoint{T: p.Bucket, V: p.Value})
+		}
+	}
+
+	if err := s.fillDeviceNames(ctx, labID, resp.Series); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Service) fillDeviceNames(ctx context.Context, labID int64, series []*Series) error {
+	if len(series) == 0 {
+		return nil
+	}
+
+	uuids := utils.FilterUniqSlice(series, func(item *Series) (uuid.UUID, bool) {
+		return item.DeviceUUID, true
+	})
+	nodes := make([]*model.MaterialNode, 0, len(uuids))
+	if err := s.baseDB.FindDatas(ctx, &nodes, map[string]any{
+		"lab_id": labID,
+		"uuid":   uuids,
+	}, "uuid", "name"); err != nil {
+		return err
+	}
+
+	names := utils.Slice2Map(nodes, func(node *model.MaterialNode) (uuid.UUID, string) {
+		return node.UUID, node.Name
+	})
+	for _, item := range series {
+		item.DeviceName = names[item.DeviceUUID]
+	}
+	return nil
+}
+
+func (s *Service) checkMember(ctx context.Context, userID string, labUUID uuid.UUID) (int64, error) {
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+func parseDevices(values []string) ([]uuid.UUID, error) {
+	devices := make([]uuid.UUID, 0, len(values))
+	for _, v := range values {
+		deviceUUID, err := uuid.FromString(v)
+		if err != nil {
+			return nil, code.ParamErr.WithMsgf("invalid device UUID: %s", v)
+		}
+		devices = append(devices, deviceUUID)
+	}
+	return devices, nil
+}
+
+// rollup 重新汇总最近 lookback 内的桶，包括当前未结束的桶
+func (s *Service) rollup(ctx context.Context, resolution model.TelemetryResolution, lookback time.Duration) error {
+	now := time.Now()
+	start := now.Add(-lookback).Truncate(resolution.Duration())
+	count, err := s.store.Rollup(ctx, resolution, start, now)
+	if err != nil {
+		return err
+	}
+	logger.Infof(ctx, "telemetry rollup resolution: %s, buckets: %d", resolution, count)
+	if count > 0 {
+		respcache.Invalidate(ctx, respcache.TagTelemetry)
+	}
+	return nil
+}
+
+// expire 清理过期的原始数据和汇总数据
+func (s *Service) expire(ctx context.Context) error {
+	conf := config.Global().Telemetry
+	now := time.Now()
+
+	if conf.RawRetentionDays > 0 {
+		count, err := s.store.DeleteRawBefore(ctx, now.Add(-time.Duration(conf.RawRetentionDays)*day))
+		if err != nil {
+			return err
+		}
+		logger.Infof(ctx, "telemetry raw events expired count: %d", count)
+	}
+
+	retentions := map[model.TelemetryResolution]int{
+		model.TelemetryMinute: conf.MinuteRetentionDays,
+		model.TelemetryHour:   conf.HourRetentionDays,
+	}
+	for resolution, days := range retentions {
+		if days <= 0 {
+			continue
+		}
+		count, err := s.store.DeleteRollupsBefore(ctx, resolution, now.Add(-time.Duration(days)*day))
+		if err != nil {
+			return err
+		}
+		logger.Infof(ctx, "telemetry rollups expired resolution: %s, count: %d", resolution, count)
+	}
+	return nil
+}
+
+// RegisterJobs 注册数据汇总与过期清理后台任务
+func RegisterJobs() error {
+	s := New()
+	return errors.Join(
+		jobs.Register(&jobs.Definition{
+			Name:         "telemetry_rollup_minute",
+			Description:  "将设备 data_received 事件中的数值字段汇总为分钟级数据",
+			ScheduleType: model.JobScheduleInterval,
+			Schedule:     "1m",
+			Timeout:      5 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return s.rollup(ctx, model.TelemetryMinute, minuteLookback)
+			},
+		}),
+		jobs.Register(&jobs.Definition{
+			Name:         "telemetry_rollup_hour",
+			Description:  "将分钟级设备数据汇总为小时级数据",
+			ScheduleType: model.JobScheduleInterval,
+			Schedule:     "10m",
+			Timeout:      10 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return s.rollup(ctx, model.TelemetryHour, hourLookback)
+			},
+		}),
+		jobs.Register(&jobs.Definition{
+			Name:         "telemetry_expire",
+			Description:  "清理过期的设备原始数据事件与汇总数据",
+			ScheduleType: model.JobScheduleCron,
+			Schedule:     "30 4 * * *",
+			Timeout:      30 * time.Minute,
+			MaxRetries:   2,
+			RetryBackoff: 5 * time.Minute,
+			Run:          s.expire,
+		}),
+	)
+}
@@ -0,0 +1,379 @@
+// Package topology manages the resource hierarchy of a lab: rooms contain
+// benches, devices are placed in a room or bench, and device groups collect
+// devices across the hierarchy. History queries and stats resolve a node to
+// the devices below it so they can be filtered by room or group.
+package topology
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/topology"
+)
+
+// CreateNodeReq 创建房间、工作台或设备组，parent_uuid 为空时位于实验室下
+type CreateNodeReq struct {
+	LabUUID     uuid.UUID              `json:"lab_uuid" binding:"required"`
+	ParentUUID  *uuid.UUID             `json:"parent_uuid"`
+	Type        model.TopologyNodeType `json:"type" binding:"required"` // room | bench | group
+	Name        string                 `json:"name" binding:"required,max=255"`
+	Description string                 `json:"description"`
+}
+
+// UpdateNodeReq 修改节点，为空的字段不修改；parent_uuid 为全 0 的 UUID 时移动到实验室下
+type UpdateNodeReq struct {
+	Name        *string    `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string    `json:"description"`
+	ParentUUID  *uuid.UUID `json:"parent_uuid"`
+}
+
+// DevicesReq 按设备名称添加或移除节点的设备
+type DevicesReq struct {
+	DeviceNames []string `json:"device_names" binding:"required,min=1"`
+}
+
+type Service struct {
+	store    topology.TopologyRepo
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    topology.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// CreateNode 创建拓扑节点，仅实验室创建者可以操作
+func (s *Service) CreateNode(ctx context.Context, req *CreateNodeReq) (*model.TopologyNode, error) {
+	labID, userID, err := s.checkOwner(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Type.Valid() {
+		return nil, code.ParamErr.WithMsgf("unknown topology node type %q", req.Type)
+	}
+
+	var parent *model.TopologyNode
+	if req.ParentUUID != nil && !req.ParentUUID.IsNil() {
+		if parent, err = s.store.GetNodeByUUID(ctx, *req.ParentUUID); err != nil {
+			return nil, err
+		}
+		if parent.LabID != labID {
+			return nil, code.TopologyParentInvalidErr.WithMsg("parent belongs to another lab")
+		}
+	}
+	if !req.Type.AllowedUnder(parent) {
+		return nil, code.TopologyParentInvalidErr.WithMsgf("%s is not allowed under %s", req.Type, parentType(parent))
+	}
+	nodes, err := s.store.ListNodes(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	if nameTaken(nodes, parent, req.Name, 0) {
+		return nil, code.TopologyNodeExistErr.WithMsgf("%s already exists", req.Name)
+	}
+
+	node := &model.TopologyNode{
+		LabID:       labID,
+		Type:        req.Type,
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+	if parent != nil {
+		node.ParentID = &parent.ID
+	}
+	if err := s.store.CreateNode(ctx, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// Tree 获取实验室的拓扑树及各节点直接包含的设备
+func (s *Service) Tree(ctx context.Context, labUUID uuid.UUID) ([]*model.TopologyTree, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.store.ListNodes(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.store.ListDevices(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(memberships))
+	for _, m := range memberships {
+		ids = append(ids, m.DeviceID)
+	}
+	devices := make(map[int64]*model.MaterialNode, len(ids))
+	if len(ids) > 0 {
+		datas := make([]*model.MaterialNode, 0, len(ids))
+		if err := s.baseDB.FindDatas(ctx, &datas, map[string]any{
+			"id": ids,
+		}, "id", "uuid", "name"); err != nil {
+			return nil, err
+		}
+		for _, d := range datas {
+			devices[d.ID] = d
+		}
+	}
+
+	byNode := make(map[int64][]*model.TopologyTreeDevice, len(nodes))
+	for _, m := range memberships {
+		d, ok := devices[m.DeviceID]
+		if !ok {
+			// 设备已删除
+			continue
+		}
+		byNode[m.NodeID] = append(byNode[m.NodeID], &model.TopologyTreeDevice{
+			DeviceID:   d.ID,
+			DeviceUUID: d.UUID,
+			Name:       d.Name,
+		})
+	}
+	return model.BuildTopologyTree(nodes, byNode), nil
+}
+
+// UpdateNode 修改节点名称、描述或移动到其他父节点，节点类型不能修改
+func (s *Service) UpdateNode(ctx context.Context, nodeUUID uuid.UUID, req *UpdateNodeReq) (*model.TopologyNode, error) {
+	node, err := s.ownedNode(ctx, nodeUUID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.store.ListNodes(ctx, node.LabID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]any)
+	parent := findNode(nodes, node.ParentID)
+	if req.ParentUUID != nil {
+		parent = nil
+		if !req.ParentUUID.IsNil() {
+			if parent, err = s.store.GetNodeByUUID(ctx, *req.ParentUUID); err != nil {
+				return nil, err
+			}
+			if parent.LabID != node.LabID {
+				return nil, code.TopologyParentInvalidErr.WithMsg("parent belongs to another lab")
+			}
+		}
+		if !node.Type.AllowedUnder(parent) {
+			return nil, code.TopologyParentInvalidErr.WithMsgf("%s is not allowed under %s", node.Type, parentType(parent))
+		}
+		if parent != nil {
+			node.ParentID = &parent.ID
+		} else {
+			node.ParentID = nil
+		}
+		updates["parent_id"] = node.ParentID
+	}
+	if req.Name != nil {
+		node.Name = *req.Name
+		updates["name"] = node.Name
+	}
+	if req.Description != nil {
+		node.Description = *req.Description
+		updates["description"] = node.Description
+	}
+	if len(updates) == 0 {
+		return node, nil
+	}
+	if nameTaken(nodes, parent, node.Name, node.ID) {
+		return nil, code.TopologyNodeExistErr.WithMsgf("%s already exists", node.Name)
+	}
+	if err := s.store.UpdateNode(ctx, node.ID, updates); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// DeleteNode 删除没有子节点的拓扑节点，节点的设备归属一并删除
+func (s *Service) DeleteNode(ctx context.Context, nodeUUID uuid.UUID) error {
+	node, err := s.ownedNode(ctx, nodeUUID)
+	if err != nil {
+		return err
+	}
+	count, err := s.baseDB.Count(ctx, &model.TopologyNode{}, map[string]any{
+		"parent_id": node.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return code.TopologyNodeNotEmptyErr
+	}
+	return s.store.DeleteNode(ctx, node.ID)
+}
+
+// AddDevices 将设备放入房间或工作台，或加入设备组；放入房间或工作台时设备从原来的位置移出
+func (s *Service) AddDevices(ctx context.Context, nodeUUID uuid.UUID, req *DevicesReq) error {
+	node, err := s.ownedNode(ctx, nodeUUID)
+	if err != nil {
+		return err
+	}
+	ids, err := s.deviceIDs(ctx, node.LabID, req.DeviceNames)
+	if err != nil {
+		return err
+	}
+	return s.store.AddDevices(ctx, node, ids)
+}
+
+// RemoveDevices 将设备从节点移出
+func (s *Service) RemoveDevices(ctx context.Context, nodeUUID uuid.UUID, req *DevicesReq) error {
+	node, err := s.ownedNode(ctx, nodeUUID)
+	if err != nil {
+		return err
+	}
+	ids, err := s.deviceIDs(ctx, node.LabID, req.DeviceNames)
+	if err != nil {
+		return err
+	}
+	return s.store.RemoveDevices(ctx, node.ID, ids)
+}
+
+// DeviceIDs 获取节点及其下所有节点的设备，用于按房间、工作台或设备组过滤历史；
+// 节点不属于该实验室时返回 RecordNotFound
+func (s *Service) DeviceIDs(ctx context.Context, labID int64, nodeUUID uuid.UUID) ([]int64, error) {
+	_, ids, err := s.Resolve(ctx, labID, nodeUUID)
+	return ids, err
+}
+
+// Resolve 获取实验室的拓扑节点及其下所有节点的设备
+func (s *Service) Resolve(ctx context.Context, labID int64, nodeUUID uuid.UUID) (*model.TopologyNode, []int64, error) {
+	node, err := s.store.GetNodeByUUID(ctx, nodeUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if node.LabID != labID {
+		return nil, nil, code.RecordNotFound
+	}
+	nodes, err := s.store.ListNodes(ctx, labID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids, err := s.store.DeviceIDs(ctx, model.SubtreeIDs(nodes, node.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, ids, nil
+}
+
+// deviceIDs 按名称解析实验室的设备节点，有未知设备时报错
+func (s *Service) deviceIDs(ctx context.Context, labID int64, names []string) ([]int64, error) {
+	datas := make([]*model.MaterialNode, 0, len(names))
+	if err := s.baseDB.FindDatas(ctx, &datas, map[string]any{
+		"lab_id": labID,
+		"name":   names,
+		"type":   model.MATERIALDEVICE,
+	}, "id", "name"); err != nil {
+		return nil, err
+	}
+	found := make(map[string]int64, len(datas))
+	for _, d := range datas {
+		found[d.Name] = d.ID
+	}
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		id, ok := found[name]
+		if !ok {
+			return nil, code.TopologyDeviceInvalidErr.WithMsgf("device %s not found", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *Service) ownedNode(ctx context.Context, nodeUUID uuid.UUID) (*model.TopologyNode, error) {
+	node, err := s.store.GetNodeByUUID(ctx, nodeUUID)
+	if err != nil {
+		return nil, err
+	}
+	labUUID := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, node.LabID)[node.LabID]
+	if _, _, err := s.checkOwner(ctx, labUUID); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (s *Service) checkOwner(ctx context.Context, labUUID uuid.UUID) (int64, string, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, "", code.UnLogin
+	}
+
+	lab, err := s.envStore.GetLabByUUID(ctx, labUUID, "id", "user_id")
+	if err != nil {
+		return 0, "", err
+	}
+	if lab.UserID != userInfo.ID {
+		return 0, "", code.NoPermission
+	}
+	return lab.ID, userInfo.ID, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+// nameTaken 同一父节点下名称不能重复
+func nameTaken(nodes []*model.TopologyNode, parent *model.TopologyNode, name string, exceptID int64) bool {
+	for _, n := range nodes {
+		if n.ID == exceptID || n.Name != name {
+			continue
+		}
+		if parent == nil && n.ParentID == nil {
+			return true
+		}
+		if parent != nil && n.ParentID != nil && *n.ParentID == parent.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func findNode(nodes []*model.TopologyNode, id *int64) *model.TopologyNode {
+	if id == nil {
+		return nil
+	}
+	for _, n := range nodes {
+		if n.ID == *id {
+			return n
+		}
+	}
+	return nil
+}
+
+func parentType(parent *model.TopologyNode) string {
+	if parent == nil {
+		return "lab"
+	}
+	return string(parent.Type)
+}
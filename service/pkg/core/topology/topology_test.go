@@ -0,0 +1,23 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameTaken(t *testing.T) {
+	roomID := int64(1)
+	room := &model.TopologyNode{BaseModel: model.BaseModel{ID: roomID}, Type: model.TopologyRoom, Name: "east"}
+	nodes := []*model.TopologyNode{
+		room,
+		{BaseModel: model.BaseModel{ID: 2}, Type: model.TopologyBench, Name: "bench-1", ParentID: &roomID},
+	}
+
+	assert.True(t, nameTaken(nodes, nil, "east", 0))
+	assert.False(t, nameTaken(nodes, nil, "east", roomID))
+	assert.False(t, nameTaken(nodes, nil, "bench-1", 0))
+	assert.True(t, nameTaken(nodes, room, "bench-1", 0))
+	assert.False(t, nameTaken(nodes, room, "bench-2", 0))
+}
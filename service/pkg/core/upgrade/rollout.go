@@ -0,0 +1,88 @@
+package upgrade
+
+import (
+	"slices"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+// selectTargets 选择符合过滤条件且版本不是目标版本的设备，按设备名称排序
+func selectTargets(devices []*model.DeviceLiveness, filters *model.UpgradeFilters, targetVersion string) []*model.AgentUpgradeTarget {
+	targets := make([]*model.AgentUpgradeTarget, 0, len(devices))
+	for _, device := range devices {
+		if device.AgentVersion == targetVersion {
+			continue
+		}
+		if filters.OnlineOnly && device.Status != model.LivenessOnline {
+			continue
+		}
+		if len(filters.DeviceNames) > 0 && !slices.Contains(filters.DeviceNames, device.DeviceName) {
+			continue
+		}
+		if len(filters.Versions) > 0 && !slices.Contains(filters.Versions, device.AgentVersion) {
+			continue
+		}
+		targets = append(targets, &model.AgentUpgradeTarget{
+			LabID:       device.LabID,
+			DeviceID:    device.DeviceID,
+			DeviceUUID:  device.DeviceUUID,
+			DeviceName:  device.DeviceName,
+			FromVersion: device.AgentVersion,
+			Status:      model.UpgradeTargetPending,
+		})
+	}
+
+	slices.SortFunc(targets, func(a, b *model.AgentUpgradeTarget) int {
+		switch {
+		case a.DeviceName < b.DeviceName:
+			return -1
+		case a.DeviceName > b.DeviceName:
+			return 1
+		}
+		return 0
+	})
+	for i, target := range targets {
+		target.Seq = i + 1
+	}
+	return targets
+}
+
+// settle 根据指令结果和心跳上报的版本判断设备是否升级（或回滚）完成：
+// 指令失败或超时为失败，指令成功且心跳上报期望版本为成功，
+// 指令成功后超过 verifyTimeout 仍未上报期望版本为失败
+func settle(cmd *model.DeviceCommand, reported, expected string, now time.Time) (done bool, ok bool, msg string) {
+	if cmd == nil {
+		return true, false, "command not found"
+	}
+	switch cmd.Status {
+	case model.DeviceCommandFailed, model.DeviceCommandTimeout:
+		msg = utils.SafeValue(func() string { return *cmd.Error }, "")
+		if msg == "" {
+			msg = "command " + string(cmd.Status)
+		}
+		return true, false, msg
+	case model.DeviceCommandSucceeded:
+		if reported == expected {
+			return true, true, ""
+		}
+		if cmd.FinishedAt != nil && now.Sub(*cmd.FinishedAt) > verifyTimeout {
+			return true, false, "agent did not report version " + expected + ", reported " + reported
+		}
+	}
+	return false, false, ""
+}
+
+// newProgress 统计各状态的设备数和按放量比例允许升级的设备数
+func newProgress(campaign *model.AgentUpgradeCampaign, targets []*model.AgentUpgradeTarget) *model.UpgradeProgress {
+	progress := &model.UpgradeProgress{
+		Total:    len(targets),
+		Allowed:  model.RolloutLimit(len(targets), campaign.RolloutPercent),
+		ByStatus: make(map[model.UpgradeTargetStatus]int),
+	}
+	for _, target := range targets {
+		progress.ByStatus[target.Status]++
+	}
+	return progress
+}
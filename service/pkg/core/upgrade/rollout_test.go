@@ -0,0 +1,92 @@
+package upgrade
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectTargets(t *testing.T) {
+	devices := []*model.DeviceLiveness{
+		{DeviceName: "robot", AgentVersion: "1.0.0", Status: model.LivenessOnline},
+		{DeviceName: "arm", AgentVersion: "1.1.0", Status: model.LivenessOnline},
+		{DeviceName: "pump", AgentVersion: "1.0.0", Status: model.LivenessOffline},
+		// 已是目标版本
+		{DeviceName: "heater", AgentVersion: "2.0.0", Status: model.LivenessOnline},
+	}
+
+	targets := selectTargets(devices, &model.UpgradeFilters{}, "2.0.0")
+	assert.Len(t, targets, 3)
+	assert.Equal(t, "arm", targets[0].DeviceName)
+	assert.Equal(t, 1, targets[0].Seq)
+	assert.Equal(t, "1.1.0", targets[0].FromVersion)
+	assert.Equal(t, model.UpgradeTargetPending, targets[0].Status)
+	assert.Equal(t, "robot", targets[2].DeviceName)
+
+	targets = selectTargets(devices, &model.UpgradeFilters{Versions: []string{"1.0.0"}, OnlineOnly: true}, "2.0.0")
+	assert.Len(t, targets, 1)
+	assert.Equal(t, "robot", targets[0].DeviceName)
+
+	targets = selectTargets(devices, &model.UpgradeFilters{DeviceNames: []string{"pump", "heater"}}, "2.0.0")
+	assert.Len(t, targets, 1)
+	assert.Equal(t, "pump", targets[0].DeviceName)
+}
+
+func TestSettle(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	stale := now.Add(-verifyTimeout - time.Minute)
+	msg := "disk full"
+
+	done, _, _ := settle(&model.DeviceCommand{Status: model.DeviceCommandAcked}, "1.0.0", "2.0.0", now)
+	assert.False(t, done)
+
+	done, ok, errMsg := settle(&model.DeviceCommand{Status: model.DeviceCommandFailed, Error: &msg}, "1.0.0", "2.0.0", now)
+	assert.True(t, done)
+	assert.False(t, ok)
+	assert.Equal(t, msg, errMsg)
+
+	done, ok, errMsg = settle(&model.DeviceCommand{Status: model.DeviceCommandTimeout}, "1.0.0", "2.0.0", now)
+	assert.True(t, done)
+	assert.False(t, ok)
+	assert.Equal(t, "command timeout", errMsg)
+
+	done, ok, _ = settle(&model.DeviceCommand{Status: model.DeviceCommandSucceeded, FinishedAt: &recent}, "2.0.0", "2.0.0", now)
+	assert.True(t, done)
+	assert.True(t, ok)
+
+	// 指令成功但心跳尚未上报新版本
+	done, _, _ = settle(&model.DeviceCommand{Status: model.DeviceCommandSucceeded, FinishedAt: &recent}, "1.0.0", "2.0.0", now)
+	assert.False(t, done)
+
+	done, ok, _ = settle(&model.DeviceCommand{Status: model.DeviceCommandSucceeded, FinishedAt: &stale}, "1.0.0", "2.0.0", now)
+	assert.True(t, done)
+	assert.False(t, ok)
+
+	done, ok, _ = settle(nil, "1.0.0", "2.0.0", now)
+	assert.True(t, done)
+	assert.False(t, ok)
+}
+
+func TestNewProgress(t *testing.T) {
+	campaign := &model.AgentUpgradeCampaign{RolloutPercent: 25}
+	targets := []*model.AgentUpgradeTarget{
+		{Status: model.UpgradeTargetUpgraded},
+		{Status: model.UpgradeTargetDispatched},
+		{Status: model.UpgradeTargetPending},
+		{Status: model.UpgradeTargetPending},
+		{Status: model.UpgradeTargetPending},
+	}
+
+	progress := newProgress(campaign, targets)
+	assert.Equal(t, 5, progress.Total)
+	assert.Equal(t, 2, progress.Allowed)
+	assert.Equal(t, 3, progress.ByStatus[model.UpgradeTargetPending])
+	assert.Equal(t, 1, progress.ByStatus[model.UpgradeTargetUpgraded])
+
+	assert.Equal(t, 0, model.RolloutLimit(0, 50))
+	assert.Equal(t, 1, model.RolloutLimit(10, 1))
+	assert.Equal(t, 10, model.RolloutLimit(10, 100))
+}
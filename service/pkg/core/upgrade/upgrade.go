@@ -0,0 +1,512 @@
+// Package upgrade rolls edge agent upgrades out to the devices of a lab in
+// campaigns: upgrade commands go through the device command queue to a growing
+// percentage of the targets, a device counts as upgraded once its heartbeat
+// reports the target version, and a campaign can be rolled back to the
+// versions the devices had before.
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/command"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/liveness"
+	"github.com/scienceol/studio/service/pkg/repo/upgrade"
+	"gorm.io/datatypes"
+)
+
+const (
+	sweepInterval = 15 * time.Second
+
+	// 升级指令成功后等待心跳上报新版本的时间
+	verifyTimeout = 10 * time.Minute
+	// 升级需要下载安装包，结果超时比普通指令长
+	upgradeResultTimeout = 30 * time.Minute
+
+	// 系统下发的升级指令的创建人
+	systemUser = "system"
+)
+
+// CreateReq 创建升级任务
+type CreateReq struct {
+	LabUUID        uuid.UUID            `json:"lab_uuid" binding:"required"`
+	Name           string               `json:"name" binding:"required"`
+	TargetVersion  string               `json:"target_version" binding:"required"`
+	PackageURL     string               `json:"package_url"`
+	RolloutPercent int                  `json:"rollout_percent" binding:"required,min=1,max=100"` // 按顺序升级前百分之多少的设备
+	Filters        model.UpgradeFilters `json:"filters"`
+	MaxFailures    int                  `json:"max_failures" binding:"gte=0"` // 失败数超过时自动暂停，0 表示不限制
+}
+
+// RolloutReq 调整放量比例，暂停的任务同时恢复
+type RolloutReq struct {
+	RolloutPercent int `json:"rollout_percent" binding:"required,min=1,max=100"`
+}
+
+// CampaignResp 升级任务及其进度
+type CampaignResp struct {
+	*model.AgentUpgradeCampaign
+	Progress *model.UpgradeProgress      `json:"progress"`
+	Targets  []*model.AgentUpgradeTarget `json:"targets,omitempty"`
+}
+
+type Service struct {
+	store    upgrade.UpgradeRepo
+	liveness liveness.LivenessRepo
+	commands *command.Service
+	baseDB   repo.IDOrUUIDTranslate
+	envStore repo.LaboratoryRepo
+}
+
+func New() *Service {
+	return &Service{
+		store:    upgrade.New(),
+		liveness: liveness.New(),
+		commands: command.New(),
+		baseDB:   repo.NewBaseDB(),
+		envStore: environment.New(),
+	}
+}
+
+// Create 创建升级任务，仅实验室创建者可以创建。目标为符合过滤条件且版本不是目标版本的设备，按设备名称排序
+func (s *Service) Create(ctx context.Context, req *CreateReq) (*CampaignResp, error) {
+	userID, labID, err := s.checkOwner(ctx, req.LabUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := s.liveness.ListByLab(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	targets := selectTargets(devices, &req.Filters, req.TargetVersion)
+	if len(targets) == 0 {
+		return nil, code.UpgradeNoTargetErr
+	}
+
+	campaign := &model.AgentUpgradeCampaign{
+		LabID:          labID,
+		Name:           req.Name,
+		TargetVersion:  req.TargetVersion,
+		PackageURL:     req.PackageURL,
+		RolloutPercent: req.RolloutPercent,
+		Filters:        datatypes.NewJSONType(req.Filters),
+		MaxFailures:    req.MaxFailures,
+		Status:         model.UpgradeCampaignRunning,
+		CreatedBy:      userID,
+	}
+	if err := s.store.CreateCampaign(ctx, campaign, targets); err != nil {
+		return nil, err
+	}
+
+	// 立即下发第一批，失败时由后台任务重试
+	if err := s.advance(ctx, campaign); err != nil {
+		logger.Warnf(ctx, "advance upgrade campaign fail uuid: %s, err: %+v", campaign.UUID, err)
+	}
+	return s.detail(ctx, campaign)
+}
+
+// List 获取实验室的升级任务，新的在前
+func (s *Service) List(ctx context.Context, labUUID uuid.UUID) ([]*model.AgentUpgradeCampaign, error) {
+	labID, err := s.checkMember(ctx, labUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListCampaigns(ctx, labID)
+}
+
+// Get 获取升级任务的进度和各设备状态
+func (s *Service) Get(ctx context.Context, campaignUUID uuid.UUID) (*CampaignResp, error) {
+	campaign, err := s.store.GetCampaignByUUID(ctx, campaignUUID)
+	if err != nil {
+		return nil, err
+	}
+	labUUID := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, campaign.LabID)[campaign.LabID]
+	if _, err := s.checkMember(ctx, labUUID); err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, campaign)
+}
+
+// Rollout 调整放量比例，暂停的任务恢复运行
+func (s *Service) Rollout(ctx context.Context, campaignUUID uuid.UUID, req *RolloutReq) (*CampaignResp, error) {
+	campaign, err := s.ownedCampaign(ctx, campaignUUID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status != model.UpgradeCampaignRunning && campaign.Status != model.UpgradeCampaignPaused {
+		return nil, code.UpgradeCampaignStatusErr.WithMsgf("campaign is %s", campaign.Status)
+	}
+
+	ok, err := s.store.UpdateCampaign(ctx, campaign, campaign.Status, map[string]any{
+		"rollout_percent": req.RolloutPercent,
+		"status":          model.UpgradeCampaignRunning,
+		"message":         "",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, code.UpgradeCampaignStatusErr.WithMsg("campaign status changed")
+	}
+	campaign.RolloutPercent = req.RolloutPercent
+	campaign.Status = model.UpgradeCampaignRunning
+	campaign.Message = ""
+
+	if err := s.advance(ctx, campaign); err != nil {
+		logger.Warnf(ctx, "advance upgrade campaign fail uuid: %s, err: %+v", campaign.UUID, err)
+	}
+	return s.detail(ctx, campaign)
+}
+
+// Pause 暂停升级任务，已下发的升级继续执行，不再下发新的升级
+func (s *Service) Pause(ctx context.Context, campaignUUID uuid.UUID) (*CampaignResp, error) {
+	campaign, err := s.ownedCampaign(ctx, campaignUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setStatus(ctx, campaign, model.UpgradeCampaignRunning, model.UpgradeCampaignPaused, "paused by user"); err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, campaign)
+}
+
+// Rollback 回滚升级任务：未下发的设备取消，已下发的设备下发回到升级前版本的指令
+func (s *Service) Rollback(ctx context.Context, campaignUUID uuid.UUID) (*CampaignResp, error) {
+	campaign, err := s.ownedCampaign(ctx, campaignUUID)
+	if err != nil {
+		return nil, err
+	}
+	switch campaign.Status {
+	case model.UpgradeCampaignRunning, model.UpgradeCampaignPaused, model.UpgradeCampaignCompleted:
+	default:
+		return nil, code.UpgradeCampaignStatusErr.WithMsgf("campaign is %s", campaign.Status)
+	}
+	if err := s.setStatus(ctx, campaign, campaign.Status, model.UpgradeCampaignRollingBack, "rolled back by user"); err != nil {
+		return nil, err
+	}
+
+	targets, err := s.store.ListTargets(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, target := range targets {
+		switch {
+		case target.Status == model.UpgradeTargetPending:
+			if err := s.finishTarget(ctx, target, model.UpgradeTargetCancelled, "", now); err != nil {
+				return nil, err
+			}
+		case target.CommandID == 0:
+			// 没有下发过升级指令，不需要回滚
+		case target.FromVersion == "":
+			if err := s.finishTarget(ctx, target, model.UpgradeTargetRollbackFailed, "previous version unknown", now); err != nil {
+				return nil, err
+			}
+		default:
+			// 指令按设备顺序执行，回滚指令在未完成的升级之后执行
+			cmd, err := s.dispatch(ctx, campaign, target, target.FromVersion, true)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.store.UpdateTarget(ctx, target.ID, map[string]any{
+				"status":              model.UpgradeTargetRollingBack,
+				"rollback_command_id": cmd.ID,
+				"error":               "",
+				"finished_at":         nil,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.Get(ctx, campaignUUID)
+}
+
+// Sweep 推进运行中和回滚中的升级任务，暂停的任务只更新已下发设备的状态
+func (s *Service) Sweep(ctx context.Context) error {
+	campaigns, err := s.store.ListActiveCampaigns(ctx)
+	if err != nil {
+		return err
+	}
+	for _, campaign := range campaigns {
+		if err := s.advance(ctx, campaign); err != nil {
+			logger.Errorf(ctx, "advance upgrade campaign fail uuid: %s, err: %+v", campaign.UUID, err)
+		}
+	}
+	return nil
+}
+
+// advance 根据指令结果和心跳版本更新设备状态，运行中的任务按放量比例下发新的升级，
+// 失败数超过上限时暂停，所有设备结束后完成
+func (s *Service) advance(ctx context.Context, campaign *model.AgentUpgradeCampaign) error {
+	targets, err := s.store.ListTargets(ctx, campaign.ID)
+	if err != nil {
+		return err
+	}
+	commands, err := s.loadCommands(ctx, targets)
+	if err != nil {
+		return err
+	}
+	devices, err := s.liveness.ListByLab(ctx, campaign.LabID)
+	if err != nil {
+		return err
+	}
+	versions := make(map[string]string, len(devices))
+	for _, device := range devices {
+		versions[device.DeviceName] = device.AgentVersion
+	}
+
+	now := time.Now()
+	for _, target := range targets {
+		var cmd *model.DeviceCommand
+		expected := campaign.TargetVersion
+		next := model.UpgradeTargetUpgraded
+		failed := model.UpgradeTargetFailed
+		switch target.Status {
+		case model.UpgradeTargetDispatched:
+			cmd = commands[target.CommandID]
+		case model.UpgradeTargetRollingBack:
+			cmd = commands[target.RollbackCommandID]
+			expected = target.FromVersion
+			next = model.UpgradeTargetRolledBack
+			failed = model.UpgradeTargetRollbackFailed
+		default:
+			continue
+		}
+
+		done, ok, msg := settle(cmd, versions[target.DeviceName], expected, now)
+		if !done {
+			continue
+		}
+		status := next
+		if !ok {
+			status = failed
+		}
+		if err := s.finishTarget(ctx, target, status, msg, now); err != nil {
+			return err
+		}
+	}
+
+	if campaign.Status == model.UpgradeCampaignRollingBack {
+		for _, target := range targets {
+			if !target.Status.Finished() {
+				return nil
+			}
+		}
+		return s.setStatus(ctx, campaign, model.UpgradeCampaignRollingBack, model.UpgradeCampaignRolledBack, "")
+	}
+
+	if campaign.Status != model.UpgradeCampaignRunning {
+		return nil
+	}
+	progress := newProgress(campaign, targets)
+	failures := progress.ByStatus[model.UpgradeTargetFailed]
+	if campaign.MaxFailures > 0 && failures > campaign.MaxFailures {
+		return s.setStatus(ctx, campaign, model.UpgradeCampaignRunning, model.UpgradeCampaignPaused,
+			fmt.Sprintf("%d upgrades failed, more than %d allowed", failures, campaign.MaxFailures))
+	}
+
+	started := progress.Total - progress.ByStatus[model.UpgradeTargetPending]
+	for _, target := range targets {
+		if started >= progress.Allowed {
+			break
+		}
+		if target.Status != model.UpgradeTargetPending {
+			continue
+		}
+		cmd, err := s.dispatch(ctx, campaign, target, campaign.TargetVersion, false)
+		if err != nil {
+			return err
+		}
+		if err := s.store.UpdateTarget(ctx, target.ID, map[string]any{
+			"status":        model.UpgradeTargetDispatched,
+			"command_id":    cmd.ID,
+			"dispatched_at": now,
+		}); err != nil {
+			return err
+		}
+		target.Status = model.UpgradeTargetDispatched
+		started++
+	}
+
+	for _, target := range targets {
+		if !target.Status.Finished() {
+			return nil
+		}
+	}
+	return s.setStatus(ctx, campaign, model.UpgradeCampaignRunning, model.UpgradeCampaignCompleted, "")
+}
+
+// dispatch 通过设备指令队列下发升级或回滚指令
+func (s *Service) dispatch(ctx context.Context, campaign *model.AgentUpgradeCampaign, target *model.AgentUpgradeTarget, version string, rollback bool) (*model.DeviceCommand, error) {
+	// 回滚时 edge 使用本地保留的旧版本
+	packageURL := campaign.PackageURL
+	if rollback {
+		packageURL = ""
+	}
+	param, _ := json.Marshal(map[string]any{
+		"version":       version,
+		"package_url":   packageURL,
+		"campaign_uuid": campaign.UUID,
+		"rollback":      rollback,
+	})
+	return s.commands.Dispatch(ctx, campaign.LabID, &command.EnqueueReq{
+		DeviceName:       target.DeviceName,
+		Action:           model.AgentUpgradeAction,
+		ActionType:       model.AgentUpgradeAction,
+		Param:            param,
+		ResultTimeoutSec: int(upgradeResultTimeout / time.Second),
+	}, systemUser)
+}
+
+func (s *Service) loadCommands(ctx context.Context, targets []*model.AgentUpgradeTarget) (map[int64]*model.DeviceCommand, error) {
+	ids := make([]int64, 0, len(targets))
+	for _, target := range targets {
+		switch target.Status {
+		case model.UpgradeTargetDispatched:
+			ids = append(ids, target.CommandID)
+		case model.UpgradeTargetRollingBack:
+			ids = append(ids, target.RollbackCommandID)
+		}
+	}
+	if len(ids) == 0 {
+		return map[int64]*model.DeviceCommand{}, nil
+	}
+
+	cmds := make([]*model.DeviceCommand, 0, len(ids))
+	if err := s.baseDB.FindDatas(ctx, &cmds, map[string]any{
+		"id": ids,
+	}, "id", "status", "error", "finished_at"); err != nil {
+		return nil, err
+	}
+	ret := make(map[int64]*model.DeviceCommand, len(cmds))
+	for _, cmd := range cmds {
+		ret[cmd.ID] = cmd
+	}
+	return ret, nil
+}
+
+func (s *Service) finishTarget(ctx context.Context, target *model.AgentUpgradeTarget, status model.UpgradeTargetStatus, msg string, now time.Time) error {
+	if err := s.store.UpdateTarget(ctx, target.ID, map[string]any{
+		"status":      status,
+		"error":       msg,
+		"finished_at": now,
+	}); err != nil {
+		return err
+	}
+	target.Status = status
+	target.Error = msg
+	target.FinishedAt = &now
+	return nil
+}
+
+func (s *Service) setStatus(ctx context.Context, campaign *model.AgentUpgradeCampaign, from, to model.UpgradeCampaignStatus, msg string) error {
+	updates := map[string]any{
+		"status":  to,
+		"message": msg,
+	}
+	var finishedAt *time.Time
+	if to == model.UpgradeCampaignCompleted || to == model.UpgradeCampaignRolledBack {
+		now := time.Now()
+		finishedAt = &now
+		updates["finished_at"] = now
+	}
+	ok, err := s.store.UpdateCampaign(ctx, campaign, from, updates)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return code.UpgradeCampaignStatusErr.WithMsg("campaign status changed")
+	}
+	campaign.Status = to
+	campaign.Message = msg
+	if finishedAt != nil {
+		campaign.FinishedAt = finishedAt
+	}
+	return nil
+}
+
+func (s *Service) detail(ctx context.Context, campaign *model.AgentUpgradeCampaign) (*CampaignResp, error) {
+	targets, err := s.store.ListTargets(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &CampaignResp{
+		AgentUpgradeCampaign: campaign,
+		Progress:             newProgress(campaign, targets),
+		Targets:              targets,
+	}, nil
+}
+
+func (s *Service) ownedCampaign(ctx context.Context, campaignUUID uuid.UUID) (*model.AgentUpgradeCampaign, error) {
+	campaign, err := s.store.GetCampaignByUUID(ctx, campaignUUID)
+	if err != nil {
+		return nil, err
+	}
+	labUUID := s.baseDB.ID2UUID(ctx, &model.Laboratory{}, campaign.LabID)[campaign.LabID]
+	if _, _, err := s.checkOwner(ctx, labUUID); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// checkOwner 只有实验室创建者可以管理升级任务
+func (s *Service) checkOwner(ctx context.Context, labUUID uuid.UUID) (string, int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return "", 0, code.UnLogin
+	}
+
+	lab, err := s.envStore.GetLabByUUID(ctx, labUUID, "id", "user_id")
+	if err != nil {
+		return "", 0, err
+	}
+	if lab.UserID != userInfo.ID {
+		return "", 0, code.NoPermission
+	}
+	return userInfo.ID, lab.ID, nil
+}
+
+func (s *Service) checkMember(ctx context.Context, labUUID uuid.UUID) (int64, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return 0, code.UnLogin
+	}
+
+	labID := s.baseDB.UUID2ID(ctx, &model.Laboratory{}, labUUID)[labUUID]
+	if labID == 0 {
+		return 0, code.LabNotFound
+	}
+
+	count, err := s.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return 0, code.NoPermission
+	}
+	return labID, nil
+}
+
+// RegisterJob 注册升级任务推进后台任务
+func RegisterJob() error {
+	s := New()
+	return jobs.Register(&jobs.Definition{
+		Name:         "agent_upgrade_rollout",
+		Description:  "根据指令结果和心跳版本更新升级任务进度，按放量比例下发 agent 升级指令",
+		ScheduleType: model.JobScheduleInterval,
+		Schedule:     sweepInterval.String(),
+		Timeout:      time.Minute,
+		Run:          s.Sweep,
+	})
+}
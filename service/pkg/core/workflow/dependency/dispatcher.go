@@ -0,0 +1,98 @@
+// Package dependency releases tasks submitted with depends_on once their
+// prerequisites have finished.
+package dependency
+
+import (
+	"context"
+	"time"
+
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/schedule/leader"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/dependency"
+)
+
+const (
+	dispatchInterval = 2 * time.Second
+	dispatchBatch    = 100
+)
+
+// Dispatcher 轮询等待中的任务，前置任务结束后投递到任务队列
+type Dispatcher struct {
+	store   dependency.DependencyRepo
+	rClient *r.Client
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		store:   dependency.New(),
+		rClient: redis.GetClient(),
+	}
+}
+
+// Start 后台运行直到 ctx 取消，仅在选主成功的副本上执行
+func (d *Dispatcher) Start(ctx context.Context) {
+	leader.NewElector("dependency_dispatcher").Run(ctx, d.loop)
+}
+
+func (d *Dispatcher) loop(ctx context.Context) {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Dispatch(ctx)
+		}
+	}
+}
+
+// Dispatch 处理一轮等待中的任务
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	helds, err := d.store.ListHeldTasks(ctx, dispatchBatch)
+	if err != nil {
+		return
+	}
+
+	for _, held := range helds {
+		if err := d.dispatchOne(ctx, held); err != nil {
+			logger.Errorf(ctx, "dispatch held task fail task: %s, err: %+v", held.TaskUUID, err)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchOne(ctx context.Context, held *model.WorkflowHeldTask) error {
+	statuses, err := d.store.PrerequisiteStatus(ctx, held.TaskUUID)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, status := range statuses {
+		switch status {
+		case model.WorkflowTaskStatusPending, model.WorkflowTaskStatusRunnig:
+			return nil
+		case model.WorkflowTaskStatusSuccessed:
+		default:
+			failed = true
+		}
+	}
+
+	if failed && held.Policy != model.DependencyPolicyAlways {
+		_, err := d.store.CancelHeldTask(ctx, held, "prerequisite task did not succeed")
+		return err
+	}
+
+	_, err = d.store.ReleaseHeldTask(ctx, held, func() error {
+		if err := d.rClient.LPush(ctx, config.Global().Job.JobQueueName, []byte(held.Payload)).Err(); err != nil {
+			return code.ParamErr.WithMsgf("push workflow redis msg err: %+v", err)
+		}
+		return nil
+	})
+	return err
+}
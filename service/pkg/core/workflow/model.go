@@ -160,6 +160,8 @@ type WSNode struct {
 	Disabled     bool                           `json:"disabled"`
 	Minimized    bool                           `json:"minimized"`
 	LabNodeType  string                         `json:"lab_node_type"`
+	NeedApproval bool                           `json:"need_approval"`
+	ExpectedMs   int64                          `json:"expected_ms"`
 }
 
 type WSEdge struct {
@@ -214,18 +216,20 @@ type WSCreateNode struct {
 }
 
 type WSUpdateNode struct {
-	UUID       uuid.UUID                       `json:"uuid"`
-	ParentUUID *uuid.UUID                      `json:"parent_uuid,omitempty"`
-	Status     *string                         `json:"status,omitempty"`
-	Type       *model.WorkflowNodeType         `json:"type,omitempty"`
-	Icon       *string                         `json:"icon,omitempty"`
-	Pose       *datatypes.JSONType[model.Pose] `json:"pose,omitempty" swaggertype:"object"`
-	Param      *datatypes.JSON                 `json:"param,omitempty" swaggertype:"object"`
-	Footer     *string                         `json:"footer,omitempty"`
-	Name       *string                         `json:"name,omitempty"`
-	Disabled   *bool                           `json:"disabled,omitempty"`
-	Minimized  *bool                           `json:"minimized,omitempty"`
-	DeviceName *string                         `json:"device_name,omitempty"`
+	UUID         uuid.UUID                       `json:"uuid"`
+	ParentUUID   *uuid.UUID                      `json:"parent_uuid,omitempty"`
+	Status       *string                         `json:"status,omitempty"`
+	Type         *model.WorkflowNodeType         `json:"type,omitempty"`
+	Icon         *string                         `json:"icon,omitempty"`
+	Pose         *datatypes.JSONType[model.Pose] `json:"pose,omitempty" swaggertype:"object"`
+	Param        *datatypes.JSON                 `json:"param,omitempty" swaggertype:"object"`
+	Footer       *string                         `json:"footer,omitempty"`
+	Name         *string                         `json:"name,omitempty"`
+	Disabled     *bool                           `json:"disabled,omitempty"`
+	Minimized    *bool                           `json:"minimized,omitempty"`
+	DeviceName   *string                         `json:"device_name,omitempty"`
+	NeedApproval *bool                           `json:"need_approval,omitempty"`
+	ExpectedMs   *int64                          `json:"expected_ms,omitempty"`
 }
 
 type WSDelNodes struct {
@@ -257,12 +261,14 @@ type ListResult struct {
 
 // 工作流详情响应
 type DetailResp struct {
-	UUID        uuid.UUID `json:"uuid"`
-	Name        string    `json:"name"`
-	Description *string   `json:"description,omitempty"`
-	UserID      string    `json:"user_id"`
-	Nodes       []*WSNode `json:"nodes"`
-	Edges       []*WSEdge `json:"edges"`
+	UUID        uuid.UUID      `json:"uuid"`
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	UserID      string         `json:"user_id"`
+	InputSchema datatypes.JSON `json:"input_schema,omitempty" swaggertype:"object"`
+	SLAMinutes  int            `json:"sla_minutes"`
+	Nodes       []*WSNode      `json:"nodes"`
+	Edges       []*WSEdge      `json:"edges"`
 }
 
 // 获取任务列表
@@ -284,10 +290,12 @@ type TaskResp struct {
 }
 
 type UpdateReq struct {
-	UUID        uuid.UUID `json:"uuid" binding:"required"`
-	Name        *string   `json:"name"`
-	Published   *bool     `json:"published"`
-	Description *string   `json:"description"`
+	UUID        uuid.UUID       `json:"uuid" binding:"required"`
+	Name        *string         `json:"name"`
+	Published   *bool           `json:"published"`
+	Description *string         `json:"description"`
+	InputSchema *datatypes.JSON `json:"input_schema,omitempty" swaggertype:"object"`
+	SLAMinutes  *int            `json:"sla_minutes" binding:"omitempty,min=0,max=43200"` // 0 清除 SLA，最长 30 天
 }
 
 type DelReq struct {
@@ -385,11 +393,75 @@ type ExportData struct {
 	Edges        []*ExportEdge `json:"edges"`
 }
 
+// DefinitionSnapshot 执行开始时的工作流定义，按内容哈希存储并由执行记录引用
+type DefinitionSnapshot struct {
+	*ExportData
+	InputSchema datatypes.JSON `json:"input_schema,omitempty" swaggertype:"object"`
+}
+
 type ImportReq struct {
 	TargetLabUUID uuid.UUID   `json:"target_lab_uuid" binding:"required"`
 	Data          *ExportData `json:"data" binding:"required"`
 }
 
 type RunReq struct {
-	WorkflowUUID uuid.UUID `json:"workflow_uuid" binding:"required"`
+	WorkflowUUID     uuid.UUID              `json:"workflow_uuid" binding:"required"`
+	Inputs           map[string]any         `json:"inputs"`                      // 按工作流 input_schema 校验
+	DependsOn        []uuid.UUID            `json:"depends_on,omitempty"`        // 前置任务 uuid，全部结束后才开始调度
+	DependencyPolicy model.DependencyPolicy `json:"dependency_policy,omitempty"` // 前置任务失败时的处理策略，默认 success
+	Labels           map[string]string      `json:"labels,omitempty"`            // 执行记录的键值标签
+	ExperimentUUID   *uuid.UUID             `json:"experiment_uuid,omitempty"`   // 执行所属的实验
+}
+
+// LabRunReq 按路径中的实验室和工作流启动，字段含义同 RunReq
+type LabRunReq struct {
+	Inputs           map[string]any         `json:"inputs"`
+	DependsOn        []uuid.UUID            `json:"depends_on,omitempty"`
+	DependencyPolicy model.DependencyPolicy `json:"dependency_policy,omitempty"`
+	Labels           map[string]string      `json:"labels,omitempty"`
+	ExperimentUUID   *uuid.UUID             `json:"experiment_uuid,omitempty"`
+}
+
+type LabRunResp struct {
+	ExecutionUUID uuid.UUID                `json:"execution_uuid"`
+	QueuePosition int                      `json:"queue_position"`     // 排在前面且使用相同设备的执行数，0 表示可以立即开始
+	Estimate      *model.ExecutionEstimate `json:"estimate,omitempty"` // 预计开始和完成时间，预估失败时为空
+}
+
+// 批量运行请求，每行输入生成一个子任务
+type BatchRunReq struct {
+	WorkflowUUID   uuid.UUID         `json:"workflow_uuid" binding:"required"`
+	Name           string            `json:"name"`
+	Inputs         []map[string]any  `json:"inputs" binding:"required,min=1"`
+	Labels         map[string]string `json:"labels,omitempty"`          // 所有子任务执行记录的键值标签
+	ExperimentUUID *uuid.UUID        `json:"experiment_uuid,omitempty"` // 所有子任务所属的实验
+}
+
+type BatchReq struct {
+	UUID uuid.UUID `json:"uuid" uri:"uuid" form:"uuid" binding:"required"`
+}
+
+type BatchProgress struct {
+	Total    int `json:"total"`
+	Pending  int `json:"pending"`
+	Running  int `json:"running"`
+	Success  int `json:"success"`
+	Failed   int `json:"failed"`
+	Canceled int `json:"canceled"`
+}
+
+type BatchChild struct {
+	TaskUUID uuid.UUID                `json:"task_uuid"`
+	Status   model.WorkflowTaskStatus `json:"status"`
+	Inputs   datatypes.JSON           `json:"inputs" swaggertype:"object"`
+}
+
+type BatchResp struct {
+	UUID         uuid.UUID             `json:"uuid"`
+	WorkflowUUID uuid.UUID             `json:"workflow_uuid"`
+	Name         string                `json:"name"`
+	Status       model.ExecutionStatus `json:"status"`
+	Progress     BatchProgress         `json:"progress"`
+	Children     []*BatchChild         `json:"children"`
+	CreatedAt    time.Time             `json:"created_at"`
 }
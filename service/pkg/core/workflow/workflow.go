@@ -32,4 +32,8 @@ type Service interface {
 	ExportWorkflow(ctx context.Context, req *ExportReq) (*ExportData, error)
 	ImportWorkflow(ctx context.Context, req *ImportReq) (*CreateResp, error)
 	HttpRunWorkflow(ctx context.Context, req *RunReq) (uuid.UUID, error)
+	RunLabWorkflow(ctx context.Context, labID int64, workflowUUID uuid.UUID, req *LabRunReq) (*LabRunResp, error)
+	BatchRunWorkflow(ctx context.Context, req *BatchRunReq) (*BatchResp, error)
+	GetBatch(ctx context.Context, req *BatchReq) (*BatchResp, error)
+	CancelBatch(ctx context.Context, req *BatchReq) error
 }
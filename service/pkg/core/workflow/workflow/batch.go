@@ -0,0 +1,281 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
+	"github.com/scienceol/studio/service/pkg/core/schedule/engine"
+	"github.com/scienceol/studio/service/pkg/core/workflow"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// 单次批量提交的子任务上限，对应 4 块 96 孔板
+const maxBatchSize = 384
+
+// BatchRunWorkflow 按输入矩阵批量启动工作流，每行输入生成一个子任务
+func (w *workflowImpl) BatchRunWorkflow(ctx context.Context, req *workflow.BatchRunReq) (*workflow.BatchResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	if len(req.Inputs) == 0 || len(req.Inputs) > maxBatchSize {
+		return nil, code.ParamErr.WithMsgf("inputs size must be between 1 and %d", maxBatchSize)
+	}
+	if err := model.ValidateLabels(req.Labels); err != nil {
+		return nil, code.ParamErr.WithMsg(err.Error())
+	}
+
+	wk, err := w.workflowStore.GetWorkflowByUUID(ctx, req.WorkflowUUID)
+	if err != nil {
+		return nil, err
+	}
+	experimentID, err := w.resolveExperiment(ctx, wk, req.ExperimentUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 全部校验通过后再提交，避免只启动部分子任务
+	inputs := make([]map[string]any, 0, len(req.Inputs))
+	fieldErrs := make([]jsonschema.FieldError, 0)
+	for i, row := range req.Inputs {
+		normalized, errs, err := w.checkInputs(wk, row)
+		if err != nil {
+			return nil, err
+		}
+		for _, fe := range errs {
+			fe.Field = fmt.Sprintf("inputs[%d].%s", i, fe.Field)
+			fieldErrs = append(fieldErrs, fe)
+		}
+		inputs = append(inputs, normalized)
+	}
+	if len(fieldErrs) > 0 {
+		return nil, code.WorkflowInputInvalidErr.WithDetail(fieldErrs)
+	}
+
+	labMap := w.workflowStore.ID2UUID(ctx, &model.Laboratory{}, wk.LabID)
+	labUUID, ok := labMap[wk.LabID]
+	if !ok {
+		return nil, code.ParamErr.WithMsg("can not get lab uuid")
+	}
+
+	definitionHash, err := w.snapshotDefinition(ctx, wk)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &model.WorkflowBatch{
+		LabID:        wk.LabID,
+		WorkflowID:   wk.ID,
+		WorkflowUUID: wk.UUID,
+		UserID:       userInfo.ID,
+		Name:         req.Name,
+		Total:        len(inputs),
+		Status:       model.ExecutionStatusRunning,
+	}
+	children := make([]*workflow.BatchChild, 0, len(inputs))
+	err = w.workflowStore.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := w.batchStore.CreateBatch(txCtx, b); err != nil {
+			return err
+		}
+
+		conf := config.Global().Job
+		for _, row := range inputs {
+			task := &model.WorkflowTask{LabID: wk.LabID, WorkflowID: wk.ID, UserID: userInfo.ID}
+			if err := w.workflowStore.CreateWorkflowTask(txCtx, task); err != nil {
+				return err
+			}
+			if err := w.createExecutionHistory(txCtx, wk, task, row, definitionHash, &b.ID, experimentID, req.Labels); err != nil {
+				return err
+			}
+
+			data := engine.WorkflowInfo{
+				Action:       engine.StartJob,
+				TaskUUID:     task.UUID,
+				WorkflowUUID: wk.UUID,
+				LabUUID:      labUUID,
+				UserID:       userInfo.ID,
+				Inputs:       row,
+				TraceParent:  otel.TraceParent(ctx),
+				Baggage:      otel.Baggage(ctx),
+			}
+			dataB, _ := json.Marshal(data)
+			if ret := w.rClient.LPush(ctx, conf.JobQueueName, dataB); ret.Err() != nil {
+				logger.Errorf(ctx, "BatchRunWorkflow send data error: %+v", ret.Err())
+				return code.ParamErr.WithMsgf("push workflow redis msg err: %+v", ret.Err())
+			}
+
+			inputB, _ := json.Marshal(row)
+			children = append(children, &workflow.BatchChild{
+				TaskUUID: task.UUID,
+				Status:   model.WorkflowTaskStatusPending,
+				Inputs:   inputB,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &workflow.BatchResp{
+		UUID:         b.UUID,
+		WorkflowUUID: wk.UUID,
+		Name:         b.Name,
+		Status:       model.ExecutionStatusPending,
+		Progress: workflow.BatchProgress{
+			Total:   len(children),
+			Pending: len(children),
+		},
+		Children:  children,
+		CreatedAt: b.CreatedAt,
+	}, nil
+}
+
+// GetBatch 获取批次状态及子任务进度
+func (w *workflowImpl) GetBatch(ctx context.Context, req *workflow.BatchReq) (*workflow.BatchResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+
+	b, err := w.batchStore.GetBatchByUUID(ctx, req.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if b.UserID != userInfo.ID {
+		return nil, code.PermissionDenied
+	}
+
+	tasks, err := w.batchStore.ListBatchTasks(ctx, b.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := workflow.BatchProgress{Total: len(tasks)}
+	children := make([]*workflow.BatchChild, 0, len(tasks))
+	for _, t := range tasks {
+		switch t.Status {
+		case model.WorkflowTaskStatusPending:
+			progress.Pending++
+		case model.WorkflowTaskStatusRunnig:
+			progress.Running++
+		case model.WorkflowTaskStatusSuccessed:
+			progress.Success++
+		case model.WorkflowTaskStatusCanceled:
+			progress.Canceled++
+		default:
+			progress.Failed++
+		}
+		children = append(children, &workflow.BatchChild{
+			TaskUUID: t.UUID,
+			Status:   t.Status,
+			Inputs:   t.Input,
+		})
+	}
+
+	return &workflow.BatchResp{
+		UUID:         b.UUID,
+		WorkflowUUID: b.WorkflowUUID,
+		Name:         b.Name,
+		Status:       batchStatus(b, progress),
+		Progress:     progress,
+		Children:     children,
+		CreatedAt:    b.CreatedAt,
+	}, nil
+}
+
+// batchStatus 汇总子任务状态得到批次状态
+func batchStatus(b *model.WorkflowBatch, p workflow.BatchProgress) model.ExecutionStatus {
+	switch {
+	case b.Status == model.ExecutionStatusCancelled:
+		return model.ExecutionStatusCancelled
+	case p.Running > 0:
+		return model.ExecutionStatusRunning
+	case p.Pending == p.Total:
+		return model.ExecutionStatusPending
+	case p.Pending > 0:
+		return model.ExecutionStatusRunning
+	case p.Success == p.Total:
+		return model.ExecutionStatusSuccess
+	default:
+		return model.ExecutionStatusFailed
+	}
+}
+
+// CancelBatch 取消批次内所有未结束的子任务
+func (w *workflowImpl) CancelBatch(ctx context.Context, req *workflow.BatchReq) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+
+	b, err := w.batchStore.GetBatchByUUID(ctx, req.UUID)
+	if err != nil {
+		return err
+	}
+	if b.UserID != userInfo.ID {
+		return code.PermissionDenied
+	}
+	if b.Status == model.ExecutionStatusCancelled {
+		return code.WorkflowTaskFinished.WithMsg("batch already cancelled")
+	}
+
+	tasks, err := w.batchStore.ListBatchTasks(ctx, b.ID)
+	if err != nil {
+		return err
+	}
+
+	labMap := w.workflowStore.ID2UUID(ctx, &model.Laboratory{}, b.LabID)
+	labUUID, ok := labMap[b.LabID]
+	if !ok {
+		return code.ParamErr.WithMsg("can not get lab info")
+	}
+
+	return w.workflowStore.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := w.batchStore.CancelBatch(txCtx, b.ID); err != nil {
+			return err
+		}
+
+		conf := config.Global().Job
+		for _, t := range tasks {
+			if t.Status != model.WorkflowTaskStatusPending && t.Status != model.WorkflowTaskStatusRunnig {
+				continue
+			}
+
+			if err := w.workflowStore.UpdateData(txCtx, &model.WorkflowTask{
+				Status: model.WorkflowTaskStatusCanceled,
+				BaseModel: model.BaseModel{
+					UpdatedAt: time.Now(),
+				},
+			}, map[string]any{
+				"uuid": t.UUID,
+			}, "status", "updated_at"); err != nil {
+				return err
+			}
+
+			data := engine.WorkflowInfo{
+				Action:       engine.StopJob,
+				TaskUUID:     t.UUID,
+				WorkflowUUID: b.WorkflowUUID,
+				LabUUID:      labUUID,
+				UserID:       b.UserID,
+				TraceParent:  otel.TraceParent(ctx),
+				Baggage:      otel.Baggage(ctx),
+			}
+			dataB, _ := json.Marshal(data)
+			if ret := w.rClient.LPush(ctx, conf.JobQueueName, dataB); ret.Err() != nil {
+				return code.ParamErr.WithMsgf("push workflow redis msg err: %+v", ret.Err())
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,159 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/workflow"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/batch"
+	wfl "github.com/scienceol/studio/service/pkg/repo/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+// newBatchTest 基于内存数据库创建工作流服务，仓储需在数据库初始化后构造
+func newBatchTest(t *testing.T) (*workflowImpl, repo.IDOrUUIDTranslate) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+
+	return &workflowImpl{
+		workflowStore: wfl.New(),
+		batchStore:    batch.New(),
+	}, repo.NewBaseDB()
+}
+
+// userCtx 构造带登录用户的请求上下文
+func userCtx(userID string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Set(auth.USERKEY, &model.UserData{ID: userID})
+	return c
+}
+
+func TestBatchRunRejectsWholeMatrix(t *testing.T) {
+	w, base := newBatchTest(t)
+	ctx := userCtx("owner")
+
+	wk := &model.Workflow{
+		LabID:  1,
+		UserID: "owner",
+		Name:   "dilution",
+		InputSchema: datatypes.JSON(`{
+			"type": "object",
+			"properties": {"volume": {"type": "number", "minimum": 0}},
+			"required": ["volume"]
+		}`),
+	}
+	require.NoError(t, base.CreateData(ctx, wk))
+
+	_, err := w.BatchRunWorkflow(ctx, &workflow.BatchRunReq{
+		WorkflowUUID: wk.UUID,
+		Inputs: []map[string]any{
+			{"volume": 1},
+			{},
+			{"volume": -1},
+		},
+	})
+	var detailErr code.ErrCodeWithDetail
+	require.True(t, errors.As(err, &detailErr))
+	require.Equal(t, code.WorkflowInputInvalidErr, detailErr.ErrCode)
+	fieldErrs, ok := detailErr.Detail().([]jsonschema.FieldError)
+	require.True(t, ok)
+	fields := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"inputs[1].volume", "inputs[2].volume"}, fields)
+
+	// 有一行不合法时不创建批次和任何子任务
+	batchCount, err := base.Count(ctx, &model.WorkflowBatch{}, map[string]any{})
+	require.NoError(t, err)
+	assert.Zero(t, batchCount)
+	taskCount, err := base.Count(ctx, &model.WorkflowTask{}, map[string]any{})
+	require.NoError(t, err)
+	assert.Zero(t, taskCount)
+}
+
+func TestBatchStatus(t *testing.T) {
+	running := &model.WorkflowBatch{Status: model.ExecutionStatusRunning}
+	cancelled := &model.WorkflowBatch{Status: model.ExecutionStatusCancelled}
+
+	cases := []struct {
+		name  string
+		batch *model.WorkflowBatch
+		p     workflow.BatchProgress
+		want  model.ExecutionStatus
+	}{
+		{"all pending", running, workflow.BatchProgress{Total: 3, Pending: 3}, model.ExecutionStatusPending},
+		{"some running", running, workflow.BatchProgress{Total: 3, Pending: 1, Running: 1, Success: 1}, model.ExecutionStatusRunning},
+		{"pending after finished", running, workflow.BatchProgress{Total: 3, Pending: 1, Failed: 2}, model.ExecutionStatusRunning},
+		{"all success", running, workflow.BatchProgress{Total: 3, Success: 3}, model.ExecutionStatusSuccess},
+		{"success and failed", running, workflow.BatchProgress{Total: 3, Success: 2, Failed: 1}, model.ExecutionStatusFailed},
+		{"success and canceled", running, workflow.BatchProgress{Total: 2, Success: 1, Canceled: 1}, model.ExecutionStatusFailed},
+		{"cancelled batch", cancelled, workflow.BatchProgress{Total: 3, Running: 1, Success: 2}, model.ExecutionStatusCancelled},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, batchStatus(c.batch, c.p))
+		})
+	}
+}
+
+func TestBatchOwnerOnly(t *testing.T) {
+	w, base := newBatchTest(t)
+	ctx := userCtx("owner")
+
+	b := &model.WorkflowBatch{
+		LabID:        1,
+		WorkflowID:   1,
+		WorkflowUUID: uuid.NewV4(),
+		UserID:       "owner",
+		Total:        3,
+		Status:       model.ExecutionStatusRunning,
+	}
+	require.NoError(t, base.CreateData(ctx, b))
+	for _, status := range []model.WorkflowTaskStatus{
+		model.WorkflowTaskStatusSuccessed,
+		model.WorkflowTaskStatusFailed,
+		model.WorkflowTaskStatusPending,
+	} {
+		task := &model.WorkflowTask{LabID: 1, WorkflowID: 1, UserID: "owner", Status: status}
+		require.NoError(t, base.CreateData(ctx, task))
+		require.NoError(t, base.CreateData(ctx, &model.WorkflowExecutionHistory{
+			BaseModel:    model.BaseModel{UUID: task.UUID},
+			LabID:        1,
+			UserID:       "owner",
+			WorkflowID:   1,
+			WorkflowUUID: b.WorkflowUUID,
+			WorkflowName: "dilution",
+			BatchID:      &b.ID,
+		}))
+	}
+
+	other := userCtx("other")
+	_, err := w.GetBatch(other, &workflow.BatchReq{UUID: b.UUID})
+	assert.ErrorIs(t, err, code.PermissionDenied)
+	assert.ErrorIs(t, w.CancelBatch(other, &workflow.BatchReq{UUID: b.UUID}), code.PermissionDenied)
+
+	// 非创建者取消失败后批次与子任务保持原状
+	resp, err := w.GetBatch(ctx, &workflow.BatchReq{UUID: b.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, model.ExecutionStatusRunning, resp.Status)
+	assert.Equal(t, workflow.BatchProgress{Total: 3, Pending: 1, Success: 1, Failed: 1}, resp.Progress)
+}
@@ -17,16 +17,22 @@ import (
 	"github.com/scienceol/studio/service/internal/config"
 	"github.com/scienceol/studio/service/pkg/common"
 	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
 	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/estimate"
 	"github.com/scienceol/studio/service/pkg/core/notify"
 	"github.com/scienceol/studio/service/pkg/core/notify/events"
 	"github.com/scienceol/studio/service/pkg/core/schedule/engine"
 	"github.com/scienceol/studio/service/pkg/core/workflow"
 	"github.com/scienceol/studio/service/pkg/middleware/auth"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/scienceol/studio/service/pkg/middleware/redis"
 	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/batch"
+	"github.com/scienceol/studio/service/pkg/repo/dependency"
 	el "github.com/scienceol/studio/service/pkg/repo/environment"
+	"github.com/scienceol/studio/service/pkg/repo/history"
 	mStore "github.com/scienceol/studio/service/pkg/repo/material"
 	"github.com/scienceol/studio/service/pkg/model"
 	"github.com/scienceol/studio/service/pkg/repo/tags"
@@ -40,6 +46,9 @@ type workflowImpl struct {
 	labStore      repo.LaboratoryRepo
 	materialStore repo.MaterialRepo
 	tagsStore     repo.Tags
+	historyStore  history.HistoryRepo
+	batchStore    batch.BatchRepo
+	depStore      dependency.DependencyRepo
 	rClient       *r.Client
 	wsClient      *melody.Melody
 	*schemaHelper
@@ -52,6 +61,9 @@ func New(ctx context.Context, wsClient *melody.Melody) workflow.Service {
 		wsClient:      wsClient,
 		materialStore: mStore.NewMaterialImpl(),
 		tagsStore:     tags.NewTag(),
+		historyStore:  history.New(),
+		batchStore:    batch.New(),
+		depStore:      dependency.New(),
 		rClient:       redis.GetClient(),
 		schemaHelper: &schemaHelper{
 			materialStore: mStore.NewMaterialImpl(),
@@ -327,19 +339,21 @@ func (w *workflowImpl) fetchGraph(ctx context.Context, s *melody.Session) (any,
 
 	nodes := utils.FilterSlice(resp.Nodes, func(node *repo.WorkflowNodeInfo) (*workflow.WSNode, bool) {
 		data := &workflow.WSNode{
-			UUID:        node.Node.UUID,
-			ParentUUID:  nodeIDUUIDMap[node.Node.ParentID],
-			UserID:      node.Node.UserID,
-			Status:      node.Node.Status,
-			Type:        node.Node.Type,
-			Icon:        node.Node.Icon,
-			Pose:        node.Node.Pose,
-			Footer:      utils.Or(node.Node.Footer, ""),
-			Param:       node.Node.Param,
-			DeviceName:  node.Node.DeviceName,
-			LabNodeType: node.Node.LabNodeType,
-			Disabled:    node.Node.Disabled,
-			Minimized:   node.Node.Minimized,
+			UUID:         node.Node.UUID,
+			ParentUUID:   nodeIDUUIDMap[node.Node.ParentID],
+			UserID:       node.Node.UserID,
+			Status:       node.Node.Status,
+			Type:         node.Node.Type,
+			Icon:         node.Node.Icon,
+			Pose:         node.Node.Pose,
+			Footer:       utils.Or(node.Node.Footer, ""),
+			Param:        node.Node.Param,
+			DeviceName:   node.Node.DeviceName,
+			LabNodeType:  node.Node.LabNodeType,
+			Disabled:     node.Node.Disabled,
+			Minimized:    node.Node.Minimized,
+			NeedApproval: node.Node.NeedApproval,
+			ExpectedMs:   node.Node.ExpectedMs,
 			Handles: utils.FilterSlice(node.Handles, func(h *model.WorkflowHandleTemplate) (*workflow.WSNodeHandle, bool) {
 				return &workflow.WSNodeHandle{
 					UUID:        h.UUID,
@@ -761,6 +775,19 @@ func (w *workflowImpl) upateNode(ctx context.Context, _ *melody.Session, b []byt
 		keys = append(keys, "device_name")
 	}
 
+	if reqData.NeedApproval != nil {
+		d.NeedApproval = *reqData.NeedApproval
+		keys = append(keys, "need_approval")
+	}
+
+	if reqData.ExpectedMs != nil {
+		if *reqData.ExpectedMs < 0 {
+			return nil, code.ParamErr.WithMsg("expected_ms must not be negative")
+		}
+		d.ExpectedMs = *reqData.ExpectedMs
+		keys = append(keys, "expected_ms")
+	}
+
 	if len(keys) == 0 {
 		return nil, nil
 	}
@@ -903,6 +930,11 @@ func (w *workflowImpl) runWorkflow(ctx context.Context, s *melody.Session, b []b
 	if err := json.Unmarshal(b, req); err != nil {
 		return nil, code.ParamErr.WithMsg(err.Error())
 	}
+	// 未传参数时按空输入校验，传了则必须是对象
+	inputs, ok := req.Data.(map[string]any)
+	if req.Data != nil && !ok {
+		return nil, code.ParamErr.WithMsg("workflow inputs must be an object")
+	}
 
 	userInfo := auth.GetCurrentUser(ctx)
 	if userInfo == nil {
@@ -924,6 +956,11 @@ func (w *workflowImpl) runWorkflow(ctx context.Context, s *melody.Session, b []b
 	//
 	// utils.Range(nodes)
 
+	inputs, err = w.validateInputs(wk, inputs)
+	if err != nil {
+		return nil, err
+	}
+
 	labMap := w.workflowStore.ID2UUID(ctx, &model.Laboratory{}, wk.LabID)
 
 	labUUID, ok := labMap[wk.LabID]
@@ -931,6 +968,11 @@ func (w *workflowImpl) runWorkflow(ctx context.Context, s *melody.Session, b []b
 		return nil, code.ParamErr.WithMsg("can not get lab uuid")
 	}
 
+	definitionHash, err := w.snapshotDefinition(ctx, wk)
+	if err != nil {
+		return nil, err
+	}
+
 	var taskUUID uuid.UUID
 
 	err = w.workflowStore.ExecTx(ctx, func(txCtx context.Context) error {
@@ -943,6 +985,10 @@ func (w *workflowImpl) runWorkflow(ctx context.Context, s *melody.Session, b []b
 			return err
 		}
 		taskUUID = task.UUID
+		if err := w.createExecutionHistory(txCtx, wk, task, inputs, definitionHash, nil, nil, nil); err != nil {
+			return err
+		}
+
 		conf := config.Global().Job
 		data := engine.WorkflowInfo{
 			Action:       engine.StartJob,
@@ -950,6 +996,9 @@ func (w *workflowImpl) runWorkflow(ctx context.Context, s *melody.Session, b []b
 			WorkflowUUID: wk.UUID,
 			LabUUID:      labUUID,
 			UserID:       wk.UserID,
+			Inputs:       inputs,
+			TraceParent:  otel.TraceParent(ctx),
+			Baggage:      otel.Baggage(ctx),
 		}
 
 		dataB, _ := json.Marshal(data)
@@ -982,7 +1031,75 @@ func (w *workflowImpl) HttpRunWorkflow(ctx context.Context, req *workflow.RunReq
 	}
 
 	// 基于工作流记录的创建者作为 user_id（无 token 情况）
-	userID := wk.UserID
+	return w.startWorkflow(ctx, wk, wk.UserID, req)
+}
+
+// RunLabWorkflow 实验室成员以自己的身份启动实验室的工作流，返回执行 uuid 和排队位置
+func (w *workflowImpl) RunLabWorkflow(ctx context.Context, labID int64, workflowUUID uuid.UUID, req *workflow.LabRunReq) (*workflow.LabRunResp, error) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return nil, code.UnLogin
+	}
+	count, err := w.labStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return nil, code.NoPermission
+	}
+
+	wk, err := w.workflowStore.GetWorkflowByUUID(ctx, workflowUUID)
+	if err != nil {
+		return nil, err
+	}
+	if wk.LabID != labID {
+		return nil, code.WorkflowNotExistErr
+	}
+
+	taskUUID, err := w.startWorkflow(ctx, wk, userInfo.ID, &workflow.RunReq{
+		WorkflowUUID:     workflowUUID,
+		Inputs:           req.Inputs,
+		DependsOn:        req.DependsOn,
+		DependencyPolicy: req.DependencyPolicy,
+		Labels:           req.Labels,
+		ExperimentUUID:   req.ExperimentUUID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	otel.GetMetrics().RecordWorkflowExecution(ctx, strconv.FormatInt(labID, 10), string(model.ExecutionStatusPending))
+
+	resp := &workflow.LabRunResp{ExecutionUUID: taskUUID}
+	// 任务已经提交，预估失败不影响启动结果
+	exec, err := w.historyStore.GetWorkflowExecutionByUUID(ctx, taskUUID)
+	if err == nil {
+		resp.Estimate, err = estimate.New().Execution(ctx, exec)
+	}
+	if err != nil {
+		logger.Warnf(ctx, "RunLabWorkflow estimate fail uuid: %s, err: %+v", taskUUID, err)
+	} else if resp.Estimate != nil {
+		resp.QueuePosition = resp.Estimate.Ahead
+	}
+	return resp, nil
+}
+
+// startWorkflow 校验运行参数，创建任务和 pending 状态的执行记录，并投递到调度队列
+func (w *workflowImpl) startWorkflow(ctx context.Context, wk *model.Workflow, userID string, req *workflow.RunReq) (uuid.UUID, error) {
+	inputs, err := w.validateInputs(wk, req.Inputs)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if err := model.ValidateLabels(req.Labels); err != nil {
+		return uuid.UUID{}, code.ParamErr.WithMsg(err.Error())
+	}
+
+	if err := w.checkDependsOn(ctx, wk, req); err != nil {
+		return uuid.UUID{}, err
+	}
+	experimentID, err := w.resolveExperiment(ctx, wk, req.ExperimentUUID)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
 
 	// 获取 lab uuid
 	labMap := w.workflowStore.ID2UUID(ctx, &model.Laboratory{}, wk.LabID)
@@ -991,6 +1108,11 @@ func (w *workflowImpl) HttpRunWorkflow(ctx context.Context, req *workflow.RunReq
 		return uuid.UUID{}, code.ParamErr.WithMsg("can not get lab uuid")
 	}
 
+	definitionHash, err := w.snapshotDefinition(ctx, wk)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
 	var taskUUID uuid.UUID
 	err = w.workflowStore.ExecTx(ctx, func(txCtx context.Context) error {
 		task := &model.WorkflowTask{LabID: wk.LabID, WorkflowID: wk.ID, UserID: userID}
@@ -998,6 +1120,9 @@ func (w *workflowImpl) HttpRunWorkflow(ctx context.Context, req *workflow.RunReq
 			return err
 		}
 		taskUUID = task.UUID
+		if err := w.createExecutionHistory(txCtx, wk, task, inputs, definitionHash, nil, experimentID, req.Labels); err != nil {
+			return err
+		}
 
 		conf := config.Global().Job
 		data := engine.WorkflowInfo{
@@ -1006,8 +1131,22 @@ func (w *workflowImpl) HttpRunWorkflow(ctx context.Context, req *workflow.RunReq
 			WorkflowUUID: wk.UUID,
 			LabUUID:      labUUID,
 			UserID:       userID,
+			Inputs:       inputs,
+			TraceParent:  otel.TraceParent(ctx),
+			Baggage:      otel.Baggage(ctx),
 		}
 		dataB, _ := json.Marshal(data)
+
+		// 存在前置任务时暂存消息，由依赖调度器在前置任务结束后投递
+		if len(req.DependsOn) > 0 {
+			return w.depStore.HoldTask(txCtx, &model.WorkflowHeldTask{
+				TaskUUID: task.UUID,
+				LabID:    wk.LabID,
+				Policy:   req.DependencyPolicy,
+				Payload:  dataB,
+			}, req.DependsOn)
+		}
+
 		ret := w.rClient.LPush(ctx, conf.JobQueueName, dataB)
 		if ret.Err() != nil {
 			logger.Errorf(ctx, "http runWorkflow ============ send data error: %+v", ret.Err())
@@ -1021,6 +1160,157 @@ func (w *workflowImpl) HttpRunWorkflow(ctx context.Context, req *workflow.RunReq
 	return taskUUID, nil
 }
 
+// checkDependsOn 校验前置任务存在且属于同一实验室
+func (w *workflowImpl) checkDependsOn(ctx context.Context, wk *model.Workflow, req *workflow.RunReq) error {
+	if len(req.DependsOn) == 0 {
+		return nil
+	}
+
+	switch req.DependencyPolicy {
+	case "":
+		req.DependencyPolicy = model.DependencyPolicySuccess
+	case model.DependencyPolicySuccess, model.DependencyPolicyAlways:
+	default:
+		return code.TaskDependencyInvalidErr.WithMsgf("unknown dependency policy: %s", req.DependencyPolicy)
+	}
+
+	req.DependsOn = utils.RemoveDuplicates(req.DependsOn)
+	tasks := make([]*model.WorkflowTask, 0, len(req.DependsOn))
+	if err := w.workflowStore.FindDatas(ctx, &tasks, map[string]any{
+		"uuid": req.DependsOn,
+	}, "uuid", "lab_id"); err != nil {
+		return err
+	}
+
+	if len(tasks) != len(req.DependsOn) {
+		return code.TaskDependencyInvalidErr.WithMsg("depends on unknown task")
+	}
+	for _, t := range tasks {
+		if t.LabID != wk.LabID {
+			return code.TaskDependencyInvalidErr.WithMsgf("task %s belongs to another lab", t.UUID)
+		}
+	}
+	return nil
+}
+
+// validateInputs 按工作流的 input_schema 校验运行参数，返回补全默认值后的参数
+func (w *workflowImpl) validateInputs(wk *model.Workflow, inputs map[string]any) (map[string]any, error) {
+	ret, fieldErrs, err := w.checkInputs(wk, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(fieldErrs) > 0 {
+		return nil, code.WorkflowInputInvalidErr.WithDetail(fieldErrs)
+	}
+	return ret, nil
+}
+
+// checkInputs 校验运行参数，字段错误单独返回以便批量提交时汇总
+func (w *workflowImpl) checkInputs(wk *model.Workflow, inputs map[string]any) (map[string]any, []jsonschema.FieldError, error) {
+	if inputs == nil {
+		inputs = map[string]any{}
+	}
+
+	if len(wk.InputSchema) == 0 || string(wk.InputSchema) == "null" {
+		return inputs, nil, nil
+	}
+
+	schema, err := jsonschema.Compile(wk.InputSchema)
+	if err != nil {
+		return nil, nil, code.WorkflowInputSchemaInvalidErr.WithMsg(err.Error())
+	}
+
+	normalized, fieldErrs := schema.Validate(inputs)
+	if len(fieldErrs) > 0 {
+		return nil, fieldErrs, nil
+	}
+
+	ret, ok := normalized.(map[string]any)
+	if !ok {
+		return nil, nil, code.WorkflowInputSchemaInvalidErr.WithMsg("input schema root must be an object")
+	}
+	return ret, nil, nil
+}
+
+// resolveExperiment 校验提交时指定的实验属于工作流所在实验室且未归档
+func (w *workflowImpl) resolveExperiment(ctx context.Context, wk *model.Workflow, experimentUUID *uuid.UUID) (*int64, error) {
+	if experimentUUID == nil || experimentUUID.IsNil() {
+		return nil, nil
+	}
+
+	datas := make([]*model.Experiment, 0, 1)
+	if err := w.workflowStore.FindDatas(ctx, &datas, map[string]any{
+		"uuid": *experimentUUID,
+	}, "id", "lab_id", "status"); err != nil {
+		return nil, err
+	}
+	if len(datas) == 0 || datas[0].LabID != wk.LabID {
+		return nil, code.ParamErr.WithMsg("experiment not found in lab")
+	}
+	if datas[0].Status == model.ExperimentArchived {
+		return nil, code.ExperimentClosedErr
+	}
+	return &datas[0].ID, nil
+}
+
+// snapshotDefinition 保存工作流当前定义的快照并返回其哈希，定义未变化时复用已有快照
+func (w *workflowImpl) snapshotDefinition(ctx context.Context, wk *model.Workflow) (string, error) {
+	data, err := w.exportData(ctx, wk)
+	if err != nil {
+		return "", err
+	}
+
+	// 节点与边按稳定顺序排列，相同定义得到相同哈希
+	sort.Slice(data.Nodes, func(i, j int) bool {
+		return data.Nodes[i].UUID.String() < data.Nodes[j].UUID.String()
+	})
+	sort.Slice(data.Edges, func(i, j int) bool {
+		a, b := data.Edges[i], data.Edges[j]
+		return fmt.Sprint(a.SourceNodeUUID, a.SourceHandleKey, a.TargetNodeUUID, a.TargetHandleKey) <
+			fmt.Sprint(b.SourceNodeUUID, b.SourceHandleKey, b.TargetNodeUUID, b.TargetHandleKey)
+	})
+	definition, err := json.Marshal(&workflow.DefinitionSnapshot{
+		ExportData:  data,
+		InputSchema: wk.InputSchema,
+	})
+	if err != nil {
+		return "", code.ParamErr.WithErr(err)
+	}
+
+	snapshot := model.NewWorkflowSnapshot(wk.ID, definition)
+	if err := w.historyStore.SaveWorkflowSnapshot(ctx, snapshot); err != nil {
+		return "", err
+	}
+	return snapshot.Hash, nil
+}
+
+// createExecutionHistory 创建执行历史，uuid 与任务 uuid 一致
+func (w *workflowImpl) createExecutionHistory(ctx context.Context, wk *model.Workflow, task *model.WorkflowTask, inputs map[string]any, definitionHash string, batchID, experimentID *int64, labels map[string]string) error {
+	inputB, _ := json.Marshal(inputs)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return w.historyStore.CreateWorkflowExecution(ctx, &model.WorkflowExecutionHistory{
+		BaseModel: model.BaseModel{
+			UUID: task.UUID,
+		},
+		LabID:          wk.LabID,
+		UserID:         task.UserID,
+		WorkflowID:     wk.ID,
+		WorkflowUUID:   wk.UUID,
+		WorkflowName:   wk.Name,
+		BatchID:        batchID,
+		ExperimentID:   experimentID,
+		Status:         model.ExecutionStatusPending,
+		StartedAt:      time.Now(),
+		Input:          inputB,
+		Labels:         datatypes.NewJSONType(labels),
+		SLATargetMs:    int64(wk.SLAMinutes) * time.Minute.Milliseconds(),
+		DefinitionHash: definitionHash,
+		TraceParent:    otel.TraceParent(ctx),
+	})
+}
+
 func (w *workflowImpl) stopWorkflow(ctx context.Context, s *melody.Session, b []byte) (any, error) {
 	req := &common.WSData[uuid.UUID]{}
 	if err := json.Unmarshal(b, req); err != nil || req.Data.IsNil() {
@@ -1067,6 +1357,8 @@ func (w *workflowImpl) stopWorkflow(ctx context.Context, s *melody.Session, b []
 		WorkflowUUID: wk.UUID,
 		LabUUID:      labUUID,
 		UserID:       wk.UserID,
+		TraceParent:  otel.TraceParent(ctx),
+		Baggage:      otel.Baggage(ctx),
 	}
 
 	err = w.workflowStore.ExecTx(ctx, func(txCtx context.Context) error {
@@ -1777,6 +2069,8 @@ func (w *workflowImpl) GetWorkflowDetail(ctx context.Context, req *workflow.Deta
 		Name:        wf.Name,
 		Description: wf.Description,
 		UserID:      wf.UserID,
+		InputSchema: wf.InputSchema,
+		SLAMinutes:  wf.SLAMinutes,
 		Nodes: utils.FilterSlice(wfNodes, func(node *model.WorkflowNode) (*workflow.WSNode, bool) {
 			return &workflow.WSNode{
 				UUID:       node.UUID,
@@ -1835,6 +2129,11 @@ func (w *workflowImpl) ExportWorkflow(ctx context.Context, req *workflow.ExportR
 	// 	return nil, code.NoPermission
 	// }
 
+	return w.exportData(ctx, wk)
+}
+
+// exportData 导出工作流的节点、边与参数，句柄以可匹配的 key 和 io 表示
+func (w *workflowImpl) exportData(ctx context.Context, wk *model.Workflow) (*workflow.ExportData, error) {
 	nodes, err := w.workflowStore.GetWorkflowNodes(ctx, map[string]any{
 		"workflow_id": wk.ID,
 	})
@@ -2110,6 +2409,21 @@ func (w *workflowImpl) UpdateWorkflow(ctx context.Context, req *workflow.UpdateR
 		keys = append(keys, "description")
 	}
 
+	if req.InputSchema != nil {
+		if len(*req.InputSchema) > 0 && string(*req.InputSchema) != "null" {
+			if _, err := jsonschema.Compile(*req.InputSchema); err != nil {
+				return code.WorkflowInputSchemaInvalidErr.WithMsg(err.Error())
+			}
+		}
+		wk.InputSchema = *req.InputSchema
+		keys = append(keys, "input_schema")
+	}
+
+	if req.SLAMinutes != nil {
+		wk.SLAMinutes = *req.SLAMinutes
+		keys = append(keys, "sla_minutes")
+	}
+
 	if len(keys) == 0 {
 		return nil
 	}
@@ -0,0 +1,22 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWorkflowInputsMustBeObject(t *testing.T) {
+	w := &workflowImpl{}
+	ctx := userCtx("owner")
+
+	for _, data := range []string{`[1, 2]`, `"volume"`, `10`, `true`} {
+		_, err := w.runWorkflow(ctx, nil, []byte(`{"action": "run_workflow", "data": `+data+`}`))
+		var withMsg code.ErrCodeWithMsg
+		if assert.True(t, errors.As(err, &withMsg), data) {
+			assert.Equal(t, code.ParamErr, withMsg.ErrCode, data)
+		}
+	}
+}
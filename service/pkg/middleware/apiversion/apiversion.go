@@ -0,0 +1,131 @@
+// Package apiversion resolves the API version of a request from the URL prefix
+// and Accept header, and marks deprecated versions with deprecation headers.
+package apiversion
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+)
+
+// Version is an API version.
+type Version int
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+
+	// Latest is the newest supported version
+	Latest = V2
+)
+
+const (
+	// HeaderVersion reports the version the response was serialized with
+	HeaderVersion = "X-API-Version"
+
+	// mediaTypePrefix 以 application/vnd.studio.v2+json 形式指定版本
+	mediaTypePrefix = "application/vnd.studio.v"
+
+	contextKey = "api_version"
+)
+
+// Config holds the API versioning configuration.
+type Config struct {
+	// Sunset is the date deprecated versions are removed, zero omits the Sunset header
+	Sunset time.Time
+}
+
+var conf = &Config{}
+
+// Init sets the API versioning configuration
+func Init(config *Config) {
+	if config == nil {
+		config = &Config{}
+	}
+	conf = config
+}
+
+// Middleware returns the Gin middleware handler resolving the request version.
+// A version requested by the Accept header overrides the one of the URL prefix,
+// so clients can adopt new response shapes before switching URLs
+func Middleware(urlVersion Version) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, ok := Negotiate(c.GetHeader("Accept"), urlVersion)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{
+				"error": fmt.Sprintf("unsupported API version, latest is %d", Latest),
+			})
+			return
+		}
+		c.Set(contextKey, version)
+		c.Header(HeaderVersion, strconv.Itoa(int(version)))
+		c.Writer.Header().Add("Vary", "Accept")
+		c.Next()
+	}
+}
+
+// Negotiate returns the version requested by the Accept header, either as the
+// application/vnd.studio.vN+json media type or a version=N parameter, or
+// fallback when none is requested. It reports false for unsupported versions
+func Negotiate(accept string, fallback Version) (Version, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		value := ""
+		if rest, ok := strings.CutPrefix(mediaType, mediaTypePrefix); ok {
+			value, _, _ = strings.Cut(rest, "+")
+		} else {
+			for _, param := range strings.Split(params, ";") {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(param), "version="); ok {
+					value = v
+				}
+			}
+		}
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < int(V1) || n > int(Latest) {
+			return 0, false
+		}
+		return Version(n), true
+	}
+	return fallback, true
+}
+
+// Get returns the resolved version of the request, V1 when unresolved
+func Get(c *gin.Context) Version {
+	if v, ok := c.Get(contextKey); ok {
+		if version, ok := v.(Version); ok {
+			return version
+		}
+	}
+	return V1
+}
+
+// Deprecated returns the Gin middleware handler for v1 routes with a v2
+// successor. Requests served as v1 get the Deprecation, Sunset and successor
+// Link headers and are counted
+func Deprecated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if Get(c) == V1 {
+			c.Header("Deprecation", "true")
+			if !conf.Sunset.IsZero() {
+				c.Header("Sunset", conf.Sunset.UTC().Format(http.TimeFormat))
+			}
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor(c.Request.URL.Path)))
+			otel.GetMetrics().RecordDeprecatedAPI(c.Request.Context(), c.FullPath(), int(V1))
+		}
+		c.Next()
+	}
+}
+
+func successor(path string) string {
+	return strings.Replace(path, "/v1/", fmt.Sprintf("/v%d/", Latest), 1)
+}
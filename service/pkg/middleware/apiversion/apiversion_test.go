@@ -0,0 +1,76 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   Version
+		wantOK bool
+	}{
+		{"", V1, true},
+		{"application/json", V1, true},
+		{"application/vnd.studio.v2+json", V2, true},
+		{"text/html, application/vnd.studio.v1+json;q=0.9", V1, true},
+		{"application/json; version=2", V2, true},
+		{"application/vnd.studio.v9+json", 0, false},
+		{"application/json; version=abc", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := Negotiate(c.accept, V1)
+		assert.Equal(t, c.wantOK, ok, c.accept)
+		assert.Equal(t, c.want, got, c.accept)
+	}
+}
+
+func newRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, "%d", Get(c))
+	}
+	router.GET("/api/v1/items", Middleware(V1), Deprecated(), handler)
+	router.GET("/api/v2/items", Middleware(V2), handler)
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	Init(&Config{Sunset: time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)})
+	defer Init(nil)
+	router := newRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/items?page=2", nil))
+	assert.Equal(t, "1", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get(HeaderVersion))
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Wed, 30 Jun 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v2/items>; rel="successor-version"`, w.Header().Get("Link"))
+
+	// Accept 请求 v2 时 v1 路由输出 v2 且不再标记弃用
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+	req.Header.Set("Accept", "application/vnd.studio.v2+json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "2", w.Body.String())
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/items", nil))
+	assert.Equal(t, "2", w.Body.String())
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/items", nil)
+	req.Header.Set("Accept", "application/vnd.studio.v3+json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	// AuthTypeDevice 设备上报事件使用的设备密钥
+	AuthTypeDevice AuthType = "Device"
+
+	// DEVICEKEY 设备密钥的请求只设置该键，不能访问用户和实验室的接口
+	DEVICEKEY = "AUTH_DEVICE_KEY"
+
+	deviceAPIKey = "AUTH_DEVICE_API_KEY"
+)
+
+// HashDeviceKey returns the stored hash of a device API key
+func HashDeviceKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetDeviceKey returns the device API key of the request, nil when the request
+// is not authenticated with a device API key
+func GetDeviceKey(ctx context.Context) *model.DeviceAPIKey {
+	gCtx, ok := ctx.(*gin.Context)
+	if !ok {
+		return nil
+	}
+
+	key, exists := gCtx.Get(deviceAPIKey)
+	if !exists {
+		return nil
+	}
+	return key.(*model.DeviceAPIKey)
+}
+
+func (u *userAuth) getDeviceUser(ctx *gin.Context, token string) (*model.UserData, string) {
+	key, err := u.deviceKeys.GetKeyByHash(ctx, HashDeviceKey(token))
+	if err != nil {
+		logger.Errorf(ctx, "getDeviceUser get key err: %+v", err)
+		return nil, DEVICEKEY
+	}
+	if !key.Active() {
+		logger.Warnf(ctx, "getDeviceUser key %s revoked", key.UUID)
+		return nil, DEVICEKEY
+	}
+
+	ctx.Set(deviceAPIKey, key)
+	return &model.UserData{
+		ID:    key.CreatedBy,
+		Name:  key.Name,
+		LabID: key.LabID,
+	}, DEVICEKEY
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// AuthTypeImpersonate 管理员模拟用户的会话令牌
+	AuthTypeImpersonate AuthType = "Impersonate"
+
+	IMPERSONATIONKEY = "AUTH_IMPERSONATION_KEY"
+
+	// HeaderImpersonator 模拟会话的响应头，便于前端提示当前处于模拟状态
+	HeaderImpersonator = "X-Studio-Impersonator"
+
+	deniedKey = "AUTH_IMPERSONATION_DENIED"
+)
+
+// labScopeKeys 请求路径和查询参数中表示实验室的参数，值可以是实验室 ID 或 UUID
+var labScopeKeys = []string{"lab_uuid", "lab_id"}
+
+// IsAdmin reports whether the user may impersonate other users
+func IsAdmin(userID string) bool {
+	return userID != "" && slices.Contains(config.GetStudioConfig().Security.Impersonation.AdminUserIDs, userID)
+}
+
+// HashImpersonationToken returns the stored hash of an impersonation token
+func HashImpersonationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetImpersonation returns the impersonation session of the request, nil
+// when the request is not impersonated
+func GetImpersonation(ctx context.Context) *model.ImpersonationSession {
+	gCtx, ok := ctx.(*gin.Context)
+	if !ok {
+		return nil
+	}
+
+	session, exists := gCtx.Get(IMPERSONATIONKEY)
+	if !exists {
+		return nil
+	}
+	return session.(*model.ImpersonationSession)
+}
+
+// NoImpersonation 敏感接口禁止模拟会话访问
+func NoImpersonation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if GetImpersonation(ctx) == nil {
+			ctx.Next()
+			return
+		}
+		deny(ctx, code.ImpersonationForbiddenErr)
+	}
+}
+
+func (u *userAuth) getImpersonatedUser(ctx *gin.Context, token string) (*model.UserData, string) {
+	session, err := u.sessions.GetSessionByToken(ctx, HashImpersonationToken(token))
+	if err != nil {
+		logger.Errorf(ctx, "getImpersonatedUser get session err: %+v", err)
+		return nil, USERKEY
+	}
+	if !session.Active(time.Now()) {
+		logger.Warnf(ctx, "getImpersonatedUser session %s expired or revoked", session.UUID)
+		return nil, USERKEY
+	}
+
+	ctx.Set(IMPERSONATIONKEY, session)
+	return &model.UserData{
+		ID:    session.UserID,
+		Name:  session.UserName,
+		Owner: session.UserOwner,
+	}, USERKEY
+}
+
+// impersonated 处理模拟会话的请求：标记 span，拒绝访问其他实验室，并在请求
+// 结束后写入审计日志
+func (u *userAuth) impersonated(ctx *gin.Context, session *model.ImpersonationSession) {
+	span := trace.SpanFromContext(ctx.Request.Context())
+	span.SetAttributes(
+		attribute.String("user.id", session.UserID),
+		attribute.String("impersonation.impersonator_id", session.ImpersonatorID),
+		attribute.String("impersonation.session", session.UUID.String()),
+	)
+	ctx.Header(HeaderImpersonator, session.ImpersonatorID)
+
+	if !inLabScope(ctx, session) {
+		deny(ctx, code.ImpersonationScopeErr)
+	} else {
+		ctx.Next()
+	}
+
+	action := model.AuditImpersonatedRequest
+	detail := ""
+	if reason, ok := ctx.Get(deniedKey); ok {
+		action = model.AuditImpersonationDenied
+		detail = reason.(string)
+	}
+	if err := u.sessions.CreateAuditLog(context.WithoutCancel(ctx), &model.AuditLog{
+		Action:         action,
+		UserID:         session.UserID,
+		ImpersonatorID: session.ImpersonatorID,
+		SessionID:      session.ID,
+		LabID:          session.LabID,
+		Method:         ctx.Request.Method,
+		Route:          ctx.FullPath(),
+		Path:           ctx.Request.URL.Path,
+		Status:         ctx.Writer.Status(),
+		IP:             ctx.ClientIP(),
+		RequestID:      ctx.Writer.Header().Get("X-Request-ID"),
+		Detail:         detail,
+	}); err != nil {
+		logger.Errorf(ctx, "impersonated request audit fail session: %s, err: %+v", session.UUID, err)
+	}
+}
+
+// inLabScope 检查请求路径和查询参数中的实验室是否为会话的实验室，请求体中的
+// 实验室不在此检查，仍受被模拟用户的成员权限限制
+func inLabScope(ctx *gin.Context, session *model.ImpersonationSession) bool {
+	allowed := func(value string) bool {
+		return value == "" || value == session.LabUUID.String() || value == strconv.FormatInt(session.LabID, 10)
+	}
+	for _, key := range labScopeKeys {
+		if !allowed(ctx.Param(key)) || !allowed(ctx.Query(key)) {
+			return false
+		}
+	}
+	return true
+}
+
+func deny(ctx *gin.Context, errCode code.ErrCode) {
+	ctx.Set(deniedKey, errCode.String())
+	ctx.AbortWithStatusJSON(http.StatusForbidden, &common.Resp{
+		Code: errCode,
+		Error: &common.Error{
+			Msg: errCode.String(),
+		},
+	})
+}
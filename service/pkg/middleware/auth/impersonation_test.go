@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInLabScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	session := &model.ImpersonationSession{LabID: 7, LabUUID: uuid.NewV4()}
+
+	cases := []struct {
+		route string
+		path  string
+		want  bool
+	}{
+		{"/member/:lab_uuid", "/member/" + session.LabUUID.String(), true},
+		{"/member/:lab_uuid", "/member/" + uuid.NewV4().String(), false},
+		{"/:lab_id/stats", "/7/stats", true},
+		{"/:lab_id/stats", "/8/stats", false},
+		{"/history", "/history?lab_id=7", true},
+		{"/history", "/history?lab_id=8", false},
+		{"/history", "/history?lab_uuid=" + uuid.NewV4().String(), false},
+		{"/info/:uuid", "/info/" + uuid.NewV4().String(), true},
+	}
+	for _, c := range cases {
+		var got bool
+		r := gin.New()
+		r.GET(c.route, func(ctx *gin.Context) { got = inLabScope(ctx, session) })
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, c.path, nil))
+		assert.Equal(t, c.want, got, c.path)
+	}
+}
+
+func TestNoImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	session := &model.ImpersonationSession{ExpiresAt: time.Now().Add(time.Minute)}
+
+	serve := func(impersonated bool) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/purge", func(ctx *gin.Context) {
+			if impersonated {
+				ctx.Set(IMPERSONATIONKEY, session)
+			}
+		}, NoImpersonation(), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/purge", nil))
+		return w
+	}
+	assert.Equal(t, http.StatusOK, serve(false).Code)
+	assert.Equal(t, http.StatusForbidden, serve(true).Code)
+}
+
+func TestSessionActive(t *testing.T) {
+	now := time.Now()
+	session := &model.ImpersonationSession{ExpiresAt: now.Add(time.Minute)}
+	assert.True(t, session.Active(now))
+	assert.False(t, session.Active(now.Add(2*time.Minute)))
+
+	session.RevokedAt = &now
+	assert.False(t, session.Active(now))
+}
@@ -15,10 +15,13 @@ import (
 	"github.com/scienceol/studio/service/pkg/common"
 	"github.com/scienceol/studio/service/pkg/common/code"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/scienceol/studio/service/pkg/model"
 	"github.com/scienceol/studio/service/pkg/repo"
 	"github.com/scienceol/studio/service/pkg/repo/bohr"
 	"github.com/scienceol/studio/service/pkg/repo/casdoor"
+	"github.com/scienceol/studio/service/pkg/repo/devicekey"
+	"github.com/scienceol/studio/service/pkg/repo/impersonation"
 	"github.com/scienceol/studio/service/pkg/utils"
 	"golang.org/x/oauth2"
 )
@@ -34,6 +37,8 @@ const (
 
 type userAuth struct {
 	client      repo.LabAccount
+	sessions    impersonation.ImpersonationRepo
+	deviceKeys  devicekey.DeviceKeyRepo
 	AuthFuncMap map[AuthType]func(ctx *gin.Context, authHeader string) (*model.UserData, string)
 }
 
@@ -93,10 +98,14 @@ func AuthLab() func(ctx *gin.Context) {
 	once.Do(func() {
 		authClient = &userAuth{}
 		authClient.AuthFuncMap = map[AuthType]func(ctx *gin.Context, authHeader string) (*model.UserData, string){
-			AuthTypeBearer: authClient.getNormalUser,
-			AuthTypeLab:    authClient.getLabUser,
-			AuthTypeBohr:   authClient.getBohrUser,
+			AuthTypeBearer:      authClient.getNormalUser,
+			AuthTypeLab:         authClient.getLabUser,
+			AuthTypeBohr:        authClient.getBohrUser,
+			AuthTypeImpersonate: authClient.getImpersonatedUser,
+			AuthTypeDevice:      authClient.getDeviceUser,
 		}
+		authClient.sessions = impersonation.New()
+		authClient.deviceKeys = devicekey.New()
 
 		if config.Global().OAuth2.AuthSource == config.AuthBohr {
 			authClient.client = bohr.NewLab()
@@ -115,10 +124,14 @@ func Auth() func(ctx *gin.Context) {
 		authClient = &userAuth{}
 
 		authClient.AuthFuncMap = map[AuthType]func(ctx *gin.Context, authHeader string) (*model.UserData, string){
-			AuthTypeBearer: authClient.getNormalUser,
-			AuthTypeLab:    authClient.getLabUser,
-			AuthTypeBohr:   authClient.getBohrUser,
+			AuthTypeBearer:      authClient.getNormalUser,
+			AuthTypeLab:         authClient.getLabUser,
+			AuthTypeBohr:        authClient.getBohrUser,
+			AuthTypeImpersonate: authClient.getImpersonatedUser,
+			AuthTypeDevice:      authClient.getDeviceUser,
 		}
+		authClient.sessions = impersonation.New()
+		authClient.deviceKeys = devicekey.New()
 
 		if config.Global().OAuth2.AuthSource == config.AuthBohr {
 			authClient.client = bohr.NewLab()
@@ -184,9 +197,24 @@ func (u *userAuth) AuthUser(ctx *gin.Context) {
 
 	// 将用户信息保存到上下文
 	ctx.Set(authKey, userInfo)
+	setIdentity(ctx, userInfo)
+	if session := GetImpersonation(ctx); session != nil {
+		u.impersonated(ctx, session)
+		return
+	}
 	ctx.Next()
 }
 
+// setIdentity 将用户、实验室和组织写入 baggage，后续的 span、日志和内部服务调用都会带上。
+// 实验室用户和设备密钥使用所属实验室，其余请求使用路径中的 lab_id
+func setIdentity(ctx *gin.Context, userInfo *model.UserData) {
+	labID := ctx.Param("lab_id")
+	if userInfo.LabID != 0 {
+		labID = strconv.FormatInt(userInfo.LabID, 10)
+	}
+	otel.SetIdentity(ctx, userInfo.ID, labID, utils.Or(userInfo.OrgID, userInfo.Owner))
+}
+
 func (u *userAuth) getBohrUser(ctx *gin.Context, authHeader string) (*model.UserData, string) {
 	user := &utils.Claims{}
 	if err := utils.ParseJWTWithPublicKey(authHeader, utils.DefaultPublicKey, user); err != nil {
@@ -0,0 +1,138 @@
+// Package chaos injects dependency failures in dev, test and uat environments
+// to verify the fallback paths: Redis latency for the local rate limiter and
+// fail-open behaviour, database errors on a share of queries, and dropped
+// scheduler queue messages for the retry and dead letter handling. The hooks
+// are installed on the Redis client and the database at startup and read the
+// current settings on every call, so failures can be turned on and off at
+// runtime through the admin API without restarting the instance.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/constant"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"gorm.io/gorm"
+)
+
+// ErrInjected 注入的数据库错误
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Config 注入的故障，比例为 0 到 1，零值表示不注入
+type Config struct {
+	Enabled bool
+	// RedisLatency 为 RedisLatencyRate 比例的 redis 命令增加的延迟
+	RedisLatency     time.Duration
+	RedisLatencyRate float64
+	// DBErrorRate 返回 ErrInjected 的数据库语句比例
+	DBErrorRate float64
+	// SchedulerDropRate 丢弃的调度队列消息比例，调用方看到的是写入成功
+	SchedulerDropRate float64
+}
+
+var (
+	current   atomic.Pointer[Config]
+	installed atomic.Bool
+)
+
+// Allowed 生产环境不允许注入故障
+func Allowed(env string) bool {
+	switch env {
+	case constant.EnvDev, constant.EnvTest, constant.EnvUat:
+		return true
+	default:
+		return false
+	}
+}
+
+// Init installs the hooks on the Redis client and the databases outside
+// production and applies the chaos section of the studio config. It must run
+// after the migrations so that injected errors cannot fail the startup.
+func Init(ctx context.Context) error {
+	studio := config.GetStudioConfig().Chaos
+	conf := Config{
+		Enabled:           studio.Enabled,
+		RedisLatency:      time.Duration(studio.RedisLatencyMs) * time.Millisecond,
+		RedisLatencyRate:  studio.RedisLatencyRate,
+		DBErrorRate:       studio.DBErrorRate,
+		SchedulerDropRate: studio.SchedulerDropRate,
+	}
+	if env := config.Global().Server.Env; !Allowed(env) {
+		if conf.Enabled {
+			logger.Warnf(ctx, "chaos is not allowed in %s environment, ignored", env)
+		}
+		return nil
+	}
+	dbs := []*gorm.DB{db.DB().DBIns()}
+	if eventDB := db.EventDB(); eventDB != nil {
+		dbs = append(dbs, eventDB.DBIns())
+	}
+	if err := install(redis.GetClient(), dbs...); err != nil {
+		return err
+	}
+	Set(conf)
+	if conf.Enabled {
+		logger.Warnf(ctx, "chaos enabled: %+v", conf)
+	}
+	return nil
+}
+
+func install(client *r.Client, dbs ...*gorm.DB) error {
+	if client != nil {
+		client.AddHook(RedisHook())
+	}
+	for _, tx := range dbs {
+		if err := tx.Use(Plugin{}); err != nil {
+			return err
+		}
+	}
+	installed.Store(true)
+	return nil
+}
+
+// Installed 钩子已安装时才能通过管理接口修改注入的故障
+func Installed() bool {
+	return installed.Load()
+}
+
+// Set replaces the injected failures, rates are clamped to [0, 1]
+func Set(conf Config) {
+	conf.RedisLatencyRate = clamp(conf.RedisLatencyRate)
+	conf.DBErrorRate = clamp(conf.DBErrorRate)
+	conf.SchedulerDropRate = clamp(conf.SchedulerDropRate)
+	conf.RedisLatency = max(conf.RedisLatency, 0)
+	current.Store(&conf)
+}
+
+// Current 当前注入的故障
+func Current() Config {
+	if conf := current.Load(); conf != nil {
+		return *conf
+	}
+	return Config{}
+}
+
+// active 返回启用时的配置，未启用时为 nil
+func active() *Config {
+	if conf := current.Load(); conf != nil && conf.Enabled {
+		return conf
+	}
+	return nil
+}
+
+// hit 以 rate 的概率返回 true
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+func clamp(rate float64) float64 {
+	return min(max(rate, 0), 1)
+}
@@ -0,0 +1,78 @@
+package chaos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSet(t *testing.T) {
+	t.Cleanup(func() { Set(Config{}) })
+	Set(Config{Enabled: true, RedisLatency: -time.Second, RedisLatencyRate: 2, DBErrorRate: -1, SchedulerDropRate: 0.5})
+	assert.Equal(t, Config{Enabled: true, RedisLatencyRate: 1, SchedulerDropRate: 0.5}, Current())
+
+	assert.True(t, Allowed("dev"))
+	assert.True(t, Allowed("uat"))
+	assert.False(t, Allowed("prod"))
+	assert.False(t, Allowed(""))
+}
+
+func TestRedisHook(t *testing.T) {
+	t.Cleanup(func() { Set(Config{}) })
+	ctx := context.Background()
+	client := r.NewClient(&r.Options{Addr: miniredis.RunT(t).Addr()})
+	client.AddHook(RedisHook())
+
+	// 未启用时不注入
+	Set(Config{SchedulerDropRate: 1})
+	require.NoError(t, client.LPush(ctx, "lab_task_queue_a", "job").Err())
+	assert.EqualValues(t, 1, client.LLen(ctx, "lab_task_queue_a").Val())
+
+	Set(Config{Enabled: true, SchedulerDropRate: 1})
+	// 调用方看到写入成功，消息被丢弃
+	require.NoError(t, client.LPush(ctx, "lab_control_queue_a", "stop").Err())
+	assert.Zero(t, client.LLen(ctx, "lab_control_queue_a").Val())
+	require.NoError(t, client.LPush(ctx, "other_queue", "x").Err())
+	assert.EqualValues(t, 1, client.LLen(ctx, "other_queue").Val())
+
+	Set(Config{Enabled: true, RedisLatency: 50 * time.Millisecond, RedisLatencyRate: 1})
+	start := time.Now()
+	require.NoError(t, client.Ping(ctx).Err())
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// 请求超时时提前返回
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.Ping(timeoutCtx).Err(), context.DeadlineExceeded)
+}
+
+func TestPlugin(t *testing.T) {
+	t.Cleanup(func() { Set(Config{}) })
+	conn, err := db.OpenSQLite(":memory:", &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.Use(Plugin{}))
+	type item struct {
+		ID   int64
+		Name string
+	}
+	require.NoError(t, conn.AutoMigrate(&item{}))
+
+	Set(Config{Enabled: true, DBErrorRate: 1})
+	assert.ErrorIs(t, conn.Create(&item{Name: "a"}).Error, ErrInjected)
+	assert.ErrorIs(t, conn.Find(&[]item{}).Error, ErrInjected)
+	assert.ErrorIs(t, conn.Exec("DELETE FROM items").Error, ErrInjected)
+	var count int64
+	assert.ErrorIs(t, conn.Raw("SELECT count(*) FROM items").Scan(&count).Error, ErrInjected)
+
+	Set(Config{Enabled: false, DBErrorRate: 1})
+	require.NoError(t, conn.Create(&item{Name: "a"}).Error)
+	require.NoError(t, conn.Model(&item{}).Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+}
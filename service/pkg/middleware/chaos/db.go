@@ -0,0 +1,40 @@
+package chaos
+
+import (
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"gorm.io/gorm"
+)
+
+// Plugin 按比例让数据库语句返回 ErrInjected，语句不会发送到数据库
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "chaos"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	// 在开启事务前注入，失败的语句不会留下未提交的事务
+	for _, err := range []error{
+		cb.Create().Before("*").Register("chaos:create", injectError),
+		cb.Query().Before("*").Register("chaos:query", injectError),
+		cb.Update().Before("*").Register("chaos:update", injectError),
+		cb.Delete().Before("*").Register("chaos:delete", injectError),
+		cb.Row().Before("*").Register("chaos:row", injectError),
+		cb.Raw().Before("*").Register("chaos:raw", injectError),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func injectError(tx *gorm.DB) {
+	conf := active()
+	if conf == nil || tx.Error != nil || !hit(conf.DBErrorRate) {
+		return
+	}
+	logger.Warnf(tx.Statement.Context, "chaos: fail statement on %s", tx.Statement.Table)
+	_ = tx.AddError(ErrInjected)
+}
@@ -0,0 +1,101 @@
+package chaos
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+// schedulerQueuePrefixes 调度器消费的实验室队列，工作流任务队列名称来自配置
+var schedulerQueuePrefixes = []string{
+	strings.TrimSuffix(utils.LabTaskPrefix, "%s"),
+	strings.TrimSuffix(utils.LabControlPrefix, "%s"),
+}
+
+type redisHook struct{}
+
+// RedisHook returns the go-redis hook injecting latency and dropping scheduler queue messages
+func RedisHook() r.Hook {
+	return redisHook{}
+}
+
+func (redisHook) DialHook(next r.DialHook) r.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (redisHook) ProcessHook(next r.ProcessHook) r.ProcessHook {
+	return func(ctx context.Context, cmd r.Cmder) error {
+		conf := active()
+		if conf == nil {
+			return next(ctx, cmd)
+		}
+		if err := delay(ctx, conf); err != nil {
+			return err
+		}
+		if queue, ok := schedulerPush(cmd); ok && hit(conf.SchedulerDropRate) {
+			logger.Warnf(ctx, "chaos: drop %s to %s", cmd.Name(), queue)
+			return nil
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (redisHook) ProcessPipelineHook(next r.ProcessPipelineHook) r.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []r.Cmder) error {
+		if conf := active(); conf != nil {
+			if err := delay(ctx, conf); err != nil {
+				return err
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// delay 按比例等待注入的延迟，请求取消时提前返回
+func delay(ctx context.Context, conf *Config) error {
+	if conf.RedisLatency <= 0 || !hit(conf.RedisLatencyRate) {
+		return nil
+	}
+	timer := time.NewTimer(conf.RedisLatency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// schedulerPush 判断命令是否向调度队列写入消息
+func schedulerPush(cmd r.Cmder) (string, bool) {
+	switch cmd.Name() {
+	case "lpush", "rpush":
+	default:
+		return "", false
+	}
+	args := cmd.Args()
+	if len(args) < 2 {
+		return "", false
+	}
+	queue, _ := args[1].(string)
+	if queue == "" {
+		return "", false
+	}
+	if conf := config.Global(); conf != nil && queue == conf.Job.JobQueueName {
+		return queue, true
+	}
+	for _, prefix := range schedulerQueuePrefixes {
+		if strings.HasPrefix(queue, prefix) {
+			return queue, true
+		}
+	}
+	return "", false
+}
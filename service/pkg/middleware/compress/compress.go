@@ -0,0 +1,276 @@
+// Package compress provides gzip/zstd response compression middleware with
+// Accept-Encoding negotiation.
+package compress
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+
+	defaultMinSize = 1024
+)
+
+// Config holds the compression configuration.
+type Config struct {
+	// Enabled determines if responses are compressed
+	Enabled bool
+
+	// MinSize is the smallest response body in bytes that is compressed
+	MinSize int
+
+	// Encodings lists the supported encodings in server preference order
+	Encodings []string
+}
+
+// DefaultConfig returns the default compression configuration.
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled:   true,
+		MinSize:   defaultMinSize,
+		Encodings: []string{EncodingZstd, EncodingGzip},
+	}
+}
+
+// compressibleTypes 图片、压缩包等已压缩的内容不再压缩
+var compressibleTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/javascript",
+	"application/xml",
+	"text/",
+	"image/svg+xml",
+}
+
+var gzipPool = sync.Pool{New: func() any {
+	w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+	return w
+}}
+
+var zstdPool = sync.Pool{New: func() any {
+	w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+	return w
+}}
+
+// Middleware returns the Gin middleware handler.
+func Middleware(config *Config) gin.HandlerFunc {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	minSize := config.MinSize
+	if minSize <= 0 {
+		minSize = defaultMinSize
+	}
+	encodings := config.Encodings
+	if len(encodings) == 0 {
+		encodings = DefaultConfig().Encodings
+	}
+
+	return func(c *gin.Context) {
+		if !config.Enabled || c.Request.Method == http.MethodHead || isUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+		encoding := Negotiate(c.GetHeader("Accept-Encoding"), encodings)
+		c.Header("Vary", "Accept-Encoding")
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		w := &writer{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// Negotiate picks the first of the supported encodings accepted by the
+// Accept-Encoding header, honouring q=0 and the * wildcard
+func Negotiate(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+	accepted := make(map[string]bool)
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		ok := true
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				ok = false
+			}
+		}
+		if name == "*" {
+			wildcard = ok
+			continue
+		}
+		accepted[name] = ok
+	}
+	for _, encoding := range supported {
+		if ok, found := accepted[encoding]; found {
+			if ok {
+				return encoding
+			}
+			continue
+		}
+		if wildcard {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func isUpgrade(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
+
+func compressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// writer 缓存响应直到超过 minSize 再决定是否压缩，小响应原样输出
+type writer struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	buf      bytes.Buffer
+	decided  bool
+	encoder  io.WriteCloser
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 流式响应不等待 minSize
+func (w *writer) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap 供 http.ResponseController 访问底层连接
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeaderNow 推迟到决定是否压缩之后，避免提前发送响应头
+func (w *writer) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Written 缓存中的数据也视为已写入
+func (w *writer) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *writer) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	status := w.Status()
+	if compress && status != http.StatusNoContent && status != http.StatusNotModified &&
+		header.Get("Content-Encoding") == "" && compressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.encoder = newEncoder(w.encoding, w.ResponseWriter)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+func (w *writer) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+	}
+}
+
+// pooledEncoder 关闭时归还编码器
+type pooledEncoder struct {
+	io.Writer
+	flush func() error
+	close func() error
+}
+
+func (e *pooledEncoder) Flush() error {
+	return e.flush()
+}
+
+func (e *pooledEncoder) Close() error {
+	return e.close()
+}
+
+func newEncoder(encoding string, dst io.Writer) io.WriteCloser {
+	switch encoding {
+	case EncodingZstd:
+		enc := zstdPool.Get().(*zstd.Encoder)
+		enc.Reset(dst)
+		return &pooledEncoder{Writer: enc, flush: enc.Flush, close: func() error {
+			err := enc.Close()
+			enc.Reset(io.Discard)
+			zstdPool.Put(enc)
+			return err
+		}}
+	default:
+		enc := gzipPool.Get().(*gzip.Writer)
+		enc.Reset(dst)
+		return &pooledEncoder{Writer: enc, flush: enc.Flush, close: func() error {
+			err := enc.Close()
+			enc.Reset(io.Discard)
+			gzipPool.Put(enc)
+			return err
+		}}
+	}
+}
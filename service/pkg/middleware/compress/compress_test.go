@@ -0,0 +1,98 @@
+package compress
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	supported := []string{EncodingZstd, EncodingGzip}
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"gzip", EncodingGzip},
+		{"gzip, deflate, br, zstd", EncodingZstd},
+		{"zstd;q=0, gzip;q=0.5", EncodingGzip},
+		{"*", EncodingZstd},
+		{"*, zstd;q=0", EncodingGzip},
+		{"identity", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Negotiate(tt.header, supported))
+		})
+	}
+}
+
+func newRouter(body string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(DefaultConfig()))
+	router.GET("/data", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": body})
+	})
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	body := strings.Repeat("studio", 500)
+
+	t.Run("gzip", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		newRouter(body).ServeHTTP(w, req)
+
+		assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		r, err := gzip.NewReader(w.Body)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), body)
+	})
+
+	t.Run("zstd", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		req.Header.Set("Accept-Encoding", "gzip, zstd")
+		w := httptest.NewRecorder()
+		newRouter(body).ServeHTTP(w, req)
+
+		assert.Equal(t, EncodingZstd, w.Header().Get("Content-Encoding"))
+		r, err := zstd.NewReader(w.Body)
+		assert.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), body)
+	})
+
+	t.Run("small response", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		newRouter("ok").ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.JSONEq(t, `{"data":"ok"}`, w.Body.String())
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		w := httptest.NewRecorder()
+		newRouter(body).ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Body.String(), body)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/scienceol/studio/service/internal/config"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
 	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/plugin/opentelemetry/tracing"
@@ -35,6 +36,9 @@ type Config struct {
 	PW     string
 	DBName string
 	DryRun bool
+	// Driver 为 DriverSQLite 时使用 SQLitePath 的本地文件，忽略连接配置
+	Driver     string
+	SQLitePath string
 	LogConf
 
 	Conns
@@ -44,6 +48,42 @@ type Conns struct {
 	TimeoutIdle time.Duration
 	MaxIdleConn int
 	MaxOpenConn int
+	// MaxIdleTime closes connections idle longer than it, zero keeps them
+	MaxIdleTime time.Duration
+	// PrepareStmt caches prepared statements on each connection
+	PrepareStmt bool
+	// StatementTimeout sets the server-side statement_timeout, zero disables it
+	StatementTimeout time.Duration
+}
+
+// NewConns 由 studio 配置生成连接池配置，未配置的项使用默认值
+func NewConns(conf config.DatabaseConfig) Conns {
+	return Conns{
+		TimeoutIdle:      time.Duration(conf.ConnMaxLifetimeSeconds) * time.Second,
+		MaxIdleConn:      conf.MaxIdleConns,
+		MaxOpenConn:      conf.MaxOpenConns,
+		MaxIdleTime:      time.Duration(conf.ConnMaxIdleTimeSeconds) * time.Second,
+		PrepareStmt:      conf.PrepareStmt,
+		StatementTimeout: time.Duration(conf.StatementTimeoutMs) * time.Millisecond,
+	}
+}
+
+// open 按驱动初始化数据库连接
+func open(ctx context.Context, conf *Config) *gorm.DB {
+	var dbIns *gorm.DB
+	if conf.Driver == DriverSQLite {
+		dbIns = initSQLite(ctx, conf)
+	} else {
+		dbIns = initPG(ctx, conf)
+	}
+	if dbIns == nil {
+		return nil
+	}
+	if err := dbIns.Use(latencyPlugin{}); err != nil {
+		logger.Fatalf(ctx, "db latency err: %+v", err)
+		return nil
+	}
+	return dbIns
 }
 
 func initPG(ctx context.Context, conf *Config) *gorm.DB {
@@ -114,14 +154,19 @@ func (conf *Config) LogLevel() gormLogger.LogLevel {
 }
 
 func (conf *Config) SDN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable TimeZone=Asia/Shanghai",
+	sdn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable TimeZone=Asia/Shanghai",
 		conf.Host, conf.Port, conf.User, conf.PW, conf.DBName)
+	if conf.StatementTimeout > 0 {
+		sdn += fmt.Sprintf(" statement_timeout=%d", conf.StatementTimeout.Milliseconds())
+	}
+	return sdn
 }
 
 func newInstances(ctx context.Context, conf *Config) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.Open(conf.SDN()), &gorm.Config{
-		DryRun: conf.DryRun,
-		Logger: NewGormLogger(logger.BaseLogger(), conf.LogLevel(), conf.SlowThreshold),
+		DryRun:      conf.DryRun,
+		PrepareStmt: conf.PrepareStmt,
+		Logger:      NewGormLogger(logger.BaseLogger(), conf.LogLevel(), conf.SlowThreshold),
 	})
 	if err != nil {
 		logger.Fatalf(ctx, "sql: can't establish connection with mdsn: %s, err: %+v", conf.SDN(), err)
@@ -137,6 +182,7 @@ func newInstances(ctx context.Context, conf *Config) (*gorm.DB, error) {
 	sqlDB.SetMaxIdleConns(conf.MaxIdleConn)
 	sqlDB.SetMaxOpenConns(conf.MaxOpenConn)
 	sqlDB.SetConnMaxLifetime(conf.TimeoutIdle)
+	sqlDB.SetConnMaxIdleTime(conf.MaxIdleTime)
 
 	return db, nil
 }
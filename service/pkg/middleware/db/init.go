@@ -2,7 +2,9 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"gorm.io/gorm"
 )
 
@@ -14,8 +16,9 @@ type Datastore struct {
 
 var client *Datastore
 
+// InitPostgres 初始化主库，conf.Driver 为 DriverSQLite 时使用本地 sqlite 文件
 func InitPostgres(ctx context.Context, conf *Config) {
-	client = &Datastore{db: initPG(ctx, conf)}
+	client = &Datastore{db: open(ctx, conf)}
 }
 
 func ClosePostgres(ctx context.Context) {
@@ -30,6 +33,24 @@ func DB() *Datastore {
 	return client
 }
 
+var eventClient *Datastore
+
+// InitEventDB 初始化设备事件的独立存储，未初始化时设备事件保存在主库
+func InitEventDB(ctx context.Context, conf *Config) {
+	eventClient = &Datastore{db: initPG(ctx, conf)}
+}
+
+func CloseEventDB(_ context.Context) {
+	if eventClient != nil {
+		_ = eventClient.Close()
+	}
+}
+
+// EventDB 返回设备事件的独立存储，未配置时为 nil
+func EventDB() *Datastore {
+	return eventClient
+}
+
 func (ds *Datastore) Close() error {
 	sqlDb, err := ds.db.DB()
 	if err != nil {
@@ -56,3 +77,19 @@ func (ds *Datastore) DBWithContext(ctx context.Context) *gorm.DB {
 	}
 	return ds.db.WithContext(ctx)
 }
+
+// RegisterMetrics 注册主库与设备事件库的连接池指标
+func RegisterMetrics() error {
+	return otel.GetMetrics().ObserveDBPool(func() map[string]sql.DBStats {
+		stats := make(map[string]sql.DBStats, 2)
+		for name, ds := range map[string]*Datastore{"main": client, "event": eventClient} {
+			if ds == nil {
+				continue
+			}
+			if sqlDB, err := ds.db.DB(); err == nil {
+				stats[name] = sqlDB.Stats()
+			}
+		}
+		return stats
+	})
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"gorm.io/gorm"
+)
+
+const latencyStartKey = "latency:start"
+
+// latencyPlugin 记录每条语句的耗时，供指标和管理员健康概览使用
+type latencyPlugin struct{}
+
+func (latencyPlugin) Name() string {
+	return "latency"
+}
+
+func (latencyPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("*").Register("latency:before_create", startTimer),
+		cb.Create().After("*").Register("latency:after_create", recordLatency("create")),
+		cb.Query().Before("*").Register("latency:before_query", startTimer),
+		cb.Query().After("*").Register("latency:after_query", recordLatency("query")),
+		cb.Update().Before("*").Register("latency:before_update", startTimer),
+		cb.Update().After("*").Register("latency:after_update", recordLatency("update")),
+		cb.Delete().Before("*").Register("latency:before_delete", startTimer),
+		cb.Delete().After("*").Register("latency:after_delete", recordLatency("delete")),
+		cb.Row().Before("*").Register("latency:before_row", startTimer),
+		cb.Row().After("*").Register("latency:after_row", recordLatency("row")),
+		cb.Raw().Before("*").Register("latency:before_raw", startTimer),
+		cb.Raw().After("*").Register("latency:after_raw", recordLatency("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startTimer(tx *gorm.DB) {
+	tx.InstanceSet(latencyStartKey, time.Now())
+}
+
+func recordLatency(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		v, ok := tx.InstanceGet(latencyStartKey)
+		if !ok {
+			return
+		}
+		if start, ok := v.(time.Time); ok {
+			otel.GetMetrics().RecordDBQuery(tx.Statement.Context, operation, time.Since(start))
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// 数据库驱动
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite" // 本地开发使用，jsonb/uuid/数组列存为 TEXT
+)
+
+// sqliteDriverName 写入前将时间转换为 UTC 的 sqlite 驱动，
+// sqlite 按文本比较时间，时区不一致时范围查询和排序会出错
+const sqliteDriverName = "sqlite-utc"
+
+// sqliteUUID 替代 gen_random_uuid() 生成 v4 UUID
+const sqliteUUID = `(lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', abs(random()) % 4 + 1, 1) ||
+substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6))))`
+
+var registerSQLite sync.Once
+
+// IsSQLite 判断连接是否为 sqlite，仓储层据此替换 Postgres 专有的查询
+func IsSQLite(tx *gorm.DB) bool {
+	return tx.Dialector.Name() == sqlite.DriverName
+}
+
+func initSQLite(ctx context.Context, conf *Config) *gorm.DB {
+	dbIns, err := OpenSQLite(conf.SQLitePath, &gorm.Config{
+		DryRun: conf.DryRun,
+		Logger: NewGormLogger(logger.BaseLogger(), conf.LogLevel(), conf.SlowThreshold),
+	})
+	if err != nil {
+		logger.Fatalf(ctx, "sqlite: can't open %s, err: %+v", conf.SQLitePath, err)
+		return nil
+	}
+	return dbIns
+}
+
+// OpenSQLite opens a sqlite database file, ":memory:" opens a private in-memory database
+func OpenSQLite(path string, conf *gorm.Config) (*gorm.DB, error) {
+	registerSQLite.Do(func() {
+		sql.Register(sqliteDriverName, utcDriver{})
+	})
+
+	dsn := path
+	if path == ":memory:" {
+		dsn = "file::memory:"
+	} else if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create sqlite dir fail: %w", err)
+		}
+	}
+	// WAL 允许读写并发，busy_timeout 让并发写入排队而不是直接失败
+	dsn += "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_time_format=sqlite"
+
+	dbIns, err := gorm.Open(sqliteDialector{sqlite.Dialector{DriverName: sqliteDriverName, DSN: dsn}}, conf)
+	if err != nil {
+		return nil, err
+	}
+	if path == ":memory:" {
+		// 每个内存库连接都是独立的数据库
+		sqlDB, err := dbIns.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	return dbIns, nil
+}
+
+// sqliteDialector 将 Postgres 专有的列类型和索引降级为 sqlite 可用的形式
+type sqliteDialector struct {
+	sqlite.Dialector
+}
+
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqliteMigrator{sqlite.Migrator{Migrator: migrator.Migrator{Config: migrator.Config{
+		DB:                          db,
+		Dialector:                   d,
+		CreateIndexAfterCreateTable: true,
+	}}}}
+}
+
+type sqliteMigrator struct {
+	sqlite.Migrator
+}
+
+func (m sqliteMigrator) DataTypeOf(field *schema.Field) string {
+	return sqliteType(m.Migrator.DataTypeOf(field))
+}
+
+func (m sqliteMigrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	expr := m.Migrator.FullDataTypeOf(field)
+	dataType := m.Migrator.DataTypeOf(field)
+	expr.SQL = sqliteType(dataType) + strings.TrimPrefix(expr.SQL, dataType)
+	expr.SQL = strings.ReplaceAll(expr.SQL, "gen_random_uuid()", sqliteUUID)
+	return expr
+}
+
+// CreateIndex 忽略 gin 等 sqlite 不支持的索引方法
+func (m sqliteMigrator) CreateIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		idx := stmt.Schema.LookIndex(name)
+		if idx == nil {
+			return fmt.Errorf("failed to create index with name %v", name)
+		}
+		opts := m.BuildIndexOptions(idx.Fields, stmt)
+		values := []interface{}{clause.Column{Name: idx.Name}, clause.Table{Name: stmt.Table}, opts}
+
+		createIndexSQL := "CREATE "
+		if idx.Class != "" {
+			createIndexSQL += idx.Class + " "
+		}
+		createIndexSQL += "INDEX ? ON ??"
+		if idx.Where != "" {
+			createIndexSQL += " WHERE " + idx.Where
+		}
+		return m.DB.Exec(createIndexSQL, values...).Error
+	})
+}
+
+// sqliteType jsonb、uuid 和数组列存为 TEXT
+func sqliteType(dataType string) string {
+	t := strings.ToLower(dataType)
+	if t == "json" || t == "jsonb" || t == "uuid" || strings.HasSuffix(t, "[]") {
+		return "text"
+	}
+	return dataType
+}
+
+// utcDriver 包装 sqlite 驱动，写入的时间参数统一为 UTC
+type utcDriver struct {
+	gosqlite.Driver
+}
+
+func (d utcDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return utcConn{conn}, nil
+}
+
+type utcConn struct {
+	driver.Conn
+}
+
+func (c utcConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.Execer) //nolint:staticcheck // sqlite 驱动只实现了旧接口
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.Exec(query, args)
+}
+
+func (c utcConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.Queryer) //nolint:staticcheck // sqlite 驱动只实现了旧接口
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return queryer.Query(query, args)
+}
+
+func (c utcConn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch v := nv.Value.(type) {
+	case time.Time:
+		nv.Value = v.UTC()
+		return nil
+	case *time.Time:
+		if v != nil {
+			nv.Value = v.UTC()
+			return nil
+		}
+	}
+	return driver.ErrSkip
+}
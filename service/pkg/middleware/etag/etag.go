@@ -0,0 +1,97 @@
+// Package etag provides ETag and If-None-Match conditional GET middleware.
+package etag
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware returns the Gin middleware handler. It buffers successful GET
+// responses, tags them with a weak ETag of the body and replies 304 when the
+// request already holds it. Weak tags stay valid across response compression.
+// Streamed NDJSON responses are passed through untagged
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || strings.Contains(c.GetHeader("Accept"), "application/x-ndjson") {
+			c.Next()
+			return
+		}
+
+		w := &writer{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.Status() != http.StatusOK {
+			w.flush()
+			return
+		}
+		tag := Tag(w.buf.Bytes())
+		w.Header().Set("ETag", tag)
+		if Match(c.GetHeader("If-None-Match"), tag) {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Length")
+			w.ResponseWriter.WriteHeader(http.StatusNotModified)
+			w.ResponseWriter.WriteHeaderNow()
+			return
+		}
+		w.flush()
+	}
+}
+
+// Tag returns the weak ETag of body
+func Tag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Match reports whether the If-None-Match header matches tag using the weak
+// comparison
+func Match(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// writer 缓存完整响应以计算 ETag
+type writer struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// WriteHeaderNow 推迟到比较 ETag 之后
+func (w *writer) WriteHeaderNow() {}
+
+func (w *writer) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *writer) flush() {
+	if w.buf.Len() == 0 {
+		return
+	}
+	_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+}
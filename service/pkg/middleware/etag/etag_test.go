@@ -0,0 +1,63 @@
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/middleware/compress"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tag := Tag([]byte("studio"))
+	assert.True(t, Match(tag, tag))
+	assert.True(t, Match(`"other", `+tag, tag))
+	assert.True(t, Match(tag[2:], tag))
+	assert.True(t, Match("*", tag))
+	assert.False(t, Match("", tag))
+	assert.False(t, Match(`W/"other"`, tag))
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(compress.Middleware(&compress.Config{Enabled: true, MinSize: 1}))
+	router.GET("/stats", Middleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"total": 3})
+	})
+	router.GET("/missing", Middleware(), func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	tag := w.Header().Get("ETag")
+	assert.Equal(t, Tag([]byte(`{"total":3}`)), tag)
+	assert.JSONEq(t, `{"total":3}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("If-None-Match", tag)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+	req = httptest.NewRequest(http.MethodGet, "/missing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+	// 流式响应不缓存，直接写出
+	req = httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+}
@@ -0,0 +1,209 @@
+// Package loadshed provides adaptive load-shedding middleware that rejects
+// low-priority requests early while the service is saturated.
+package loadshed
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+)
+
+// Class is the priority class of a route.
+type Class string
+
+const (
+	// ClassCritical routes are never shed
+	ClassCritical Class = "critical"
+	// ClassNormal is the class of routes not listed in the configuration
+	ClassNormal Class = "normal"
+	// ClassLow routes are shed first
+	ClassLow Class = "low"
+	// ClassExempt routes are neither shed nor counted, e.g. long polls and websockets
+	ClassExempt Class = "exempt"
+)
+
+const (
+	ReasonInFlight = "in_flight"
+	ReasonLatency  = "latency"
+
+	// latencyWeight 最近请求耗时在滑动平均中的权重
+	latencyWeight = 0.2
+	// latencyWindow 超过该时长没有完成的请求时不再参考耗时
+	latencyWindow = 10 * time.Second
+)
+
+// Config holds the load shedding configuration.
+type Config struct {
+	// Enabled determines if requests are shed
+	Enabled bool
+
+	// MaxInFlight is the number of concurrent requests at full load
+	MaxInFlight int
+
+	// TargetLatency is the smoothed request latency at full load
+	TargetLatency time.Duration
+
+	// ShedAt maps a class to the load, as a fraction of full load, from which
+	// its requests are shed. Classes without a positive value are never shed
+	ShedAt map[Class]float64
+
+	// Routes maps a route pattern to its class, unlisted routes are normal.
+	// Patterns ending in /* match by prefix
+	Routes map[string]Class
+}
+
+// DefaultConfig returns the default load shedding configuration.
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled:       true,
+		MaxInFlight:   512,
+		TargetLatency: 2 * time.Second,
+		ShedAt: map[Class]float64{
+			ClassLow:    0.6,
+			ClassNormal: 0.9,
+		},
+		Routes: map[string]Class{},
+	}
+}
+
+// Shedder tracks the in-flight requests and recent latency of the service.
+type Shedder struct {
+	config   *Config
+	inFlight atomic.Int64
+
+	mu       sync.Mutex
+	latency  float64 // 请求耗时的指数滑动平均，单位秒
+	lastDone time.Time
+}
+
+// New creates a new load shedder.
+func New(config *Config) *Shedder {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Shedder{config: config}
+}
+
+// Load returns the current load as a fraction of full load and the signal
+// driving it
+func (s *Shedder) Load() (float64, string) {
+	var inFlight float64
+	if s.config.MaxInFlight > 0 {
+		inFlight = float64(s.inFlight.Load()) / float64(s.config.MaxInFlight)
+	}
+
+	var latency float64
+	if s.config.TargetLatency > 0 {
+		s.mu.Lock()
+		if time.Since(s.lastDone) < latencyWindow {
+			latency = s.latency / s.config.TargetLatency.Seconds()
+		}
+		s.mu.Unlock()
+	}
+
+	if latency > inFlight {
+		return latency, ReasonLatency
+	}
+	return inFlight, ReasonInFlight
+}
+
+// InFlight returns the number of counted requests in progress
+func (s *Shedder) InFlight() int64 {
+	return s.inFlight.Load()
+}
+
+// Class returns the class of the route
+func (s *Shedder) Class(route string) Class {
+	if class, ok := s.config.Routes[route]; ok {
+		return class
+	}
+	// 多个前缀匹配时取最长的
+	match, matched := ClassNormal, -1
+	for pattern, class := range s.config.Routes {
+		prefix, ok := strings.CutSuffix(pattern, "/*")
+		if ok && len(prefix) > matched && (route == prefix || strings.HasPrefix(route, prefix+"/")) {
+			match, matched = class, len(prefix)
+		}
+	}
+	return match
+}
+
+// shed reports whether a request of the class is rejected at the current load
+func (s *Shedder) shed(class Class) (bool, string) {
+	threshold := s.config.ShedAt[class]
+	if class == ClassCritical || class == ClassExempt || threshold <= 0 {
+		return false, ""
+	}
+	load, reason := s.Load()
+	return load >= threshold, reason
+}
+
+func (s *Shedder) observe(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.lastDone) >= latencyWindow {
+		s.latency = d.Seconds()
+	} else {
+		s.latency = latencyWeight*d.Seconds() + (1-latencyWeight)*s.latency
+	}
+	s.lastDone = time.Now()
+}
+
+// Middleware returns the Gin middleware handler. Shed requests get 503 with
+// Retry-After before reaching any handler
+func (s *Shedder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.config.Enabled || isUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		class := s.Class(route)
+		if class == ClassExempt {
+			c.Next()
+			return
+		}
+
+		if shed, reason := s.shed(class); shed {
+			otel.GetMetrics().RecordLoadShed(c.Request.Context(), route, string(class), reason)
+			c.Header("Retry-After", strconv.Itoa(s.retryAfter()))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Service overloaded, please retry later",
+			})
+			return
+		}
+
+		s.inFlight.Add(1)
+		start := time.Now()
+		defer func() {
+			s.inFlight.Add(-1)
+			s.observe(time.Since(start))
+		}()
+		c.Next()
+	}
+}
+
+// RegisterMetrics registers gauges for the in-flight requests and load
+func (s *Shedder) RegisterMetrics() error {
+	return otel.GetMetrics().ObserveLoad(func() (int64, float64) {
+		load, _ := s.Load()
+		return s.InFlight(), load
+	})
+}
+
+// retryAfter 负载越高建议的重试间隔越长，最多 10 秒
+func (s *Shedder) retryAfter() int {
+	load, _ := s.Load()
+	return int(math.Min(10, math.Max(1, math.Ceil(load*2))))
+}
+
+func isUpgrade(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
@@ -0,0 +1,85 @@
+package loadshed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClass(t *testing.T) {
+	s := New(&Config{Routes: map[string]Class{
+		"/api/health":           ClassCritical,
+		"/api/v1/edge/*":        ClassCritical,
+		"/api/v1/edge/command":  ClassExempt,
+		"/api/v1/lab/history/*": ClassLow,
+	}})
+
+	assert.Equal(t, ClassCritical, s.Class("/api/health"))
+	assert.Equal(t, ClassCritical, s.Class("/api/v1/edge/device"))
+	assert.Equal(t, ClassExempt, s.Class("/api/v1/edge/command"))
+	assert.Equal(t, ClassLow, s.Class("/api/v1/lab/history/workflow"))
+	assert.Equal(t, ClassNormal, s.Class("/api/v1/lab/historyx"))
+	assert.Equal(t, ClassNormal, s.Class("/api/v1/lab/workflow"))
+}
+
+func TestLoad(t *testing.T) {
+	s := New(&Config{MaxInFlight: 10, TargetLatency: time.Second})
+	load, _ := s.Load()
+	assert.Zero(t, load)
+
+	s.inFlight.Add(5)
+	load, reason := s.Load()
+	assert.Equal(t, 0.5, load)
+	assert.Equal(t, ReasonInFlight, reason)
+
+	s.observe(2 * time.Second)
+	load, reason = s.Load()
+	assert.Equal(t, 2.0, load)
+	assert.Equal(t, ReasonLatency, reason)
+
+	// 长时间没有完成的请求时忽略耗时
+	s.lastDone = time.Now().Add(-latencyWindow)
+	load, reason = s.Load()
+	assert.Equal(t, 0.5, load)
+	assert.Equal(t, ReasonInFlight, reason)
+}
+
+func TestMiddlewareShedsByClass(t *testing.T) {
+	s := New(&Config{
+		Enabled:     true,
+		MaxInFlight: 10,
+		ShedAt:      map[Class]float64{ClassLow: 0.5, ClassNormal: 0.9},
+		Routes: map[string]Class{
+			"/health": ClassCritical,
+			"/stats":  ClassLow,
+		},
+	})
+	s.inFlight.Add(6)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(s.Middleware())
+	for _, path := range []string{"/health", "/stats", "/items"} {
+		router.GET(path, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	}
+
+	codes := map[string]int{}
+	for _, path := range []string{"/health", "/stats", "/items"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		codes[path] = w.Code
+		if w.Code == http.StatusServiceUnavailable {
+			assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		}
+	}
+	assert.Equal(t, http.StatusOK, codes["/health"])
+	assert.Equal(t, http.StatusServiceUnavailable, codes["/stats"])
+	assert.Equal(t, http.StatusOK, codes["/items"])
+	assert.Equal(t, int64(6), s.InFlight())
+}
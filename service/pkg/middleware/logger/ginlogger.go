@@ -108,6 +108,11 @@ func shouldSkipResponseBodyLogging(req *http.Request) bool {
 		return true
 	}
 
+	// 流式列表
+	if strings.Contains(req.Header.Get("Accept"), "application/x-ndjson") {
+		return true
+	}
+
 	// HTTP CONNECT 方法
 	if req.Method == http.MethodConnect {
 		return true
@@ -121,7 +126,8 @@ func shouldSkipResponseBodyLogging(req *http.Request) bool {
 	// 文件上传/下载（基于 Content-Type）
 	contentType := req.Header.Get("Content-Type")
 	if strings.Contains(contentType, "multipart/form-data") ||
-		strings.Contains(contentType, "application/octet-stream") {
+		strings.Contains(contentType, "application/octet-stream") ||
+		strings.Contains(contentType, "application/offset+octet-stream") {
 		return true
 	}
 
@@ -130,6 +136,11 @@ func shouldSkipResponseBodyLogging(req *http.Request) bool {
 		return true
 	}
 
+	// 排除文件下载
+	if strings.HasPrefix(req.URL.Path, "/api/v1/file/download") {
+		return true
+	}
+
 	return false
 }
 
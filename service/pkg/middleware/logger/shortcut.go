@@ -5,7 +5,9 @@ import (
 	"fmt"
 
 	"github.com/scienceol/studio/service/pkg/common/constant"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
 )
 
 var (
@@ -93,7 +95,7 @@ func Debugf(ctx context.Context, format string, v ...any) {
 		return
 	}
 
-	CtxLogger(ctx).Debug(fmt.Sprintf(format, v...))
+	CtxLogger(ctx).Debug(fmt.Sprintf(format, v...), identityFields(ctx)...)
 }
 
 func Infof(ctx context.Context, format string, v ...any) {
@@ -102,7 +104,7 @@ func Infof(ctx context.Context, format string, v ...any) {
 		return
 	}
 
-	CtxLogger(ctx).Info(fmt.Sprintf(format, v...))
+	CtxLogger(ctx).Info(fmt.Sprintf(format, v...), identityFields(ctx)...)
 }
 
 func Warnf(ctx context.Context, format string, v ...any) {
@@ -111,7 +113,7 @@ func Warnf(ctx context.Context, format string, v ...any) {
 		return
 	}
 
-	CtxLogger(ctx).Warn(fmt.Sprintf(format, v...))
+	CtxLogger(ctx).Warn(fmt.Sprintf(format, v...), identityFields(ctx)...)
 }
 
 func Errorf(ctx context.Context, format string, v ...any) {
@@ -120,7 +122,7 @@ func Errorf(ctx context.Context, format string, v ...any) {
 		return
 	}
 
-	CtxLogger(ctx).Error(fmt.Sprintf(format, v...))
+	CtxLogger(ctx).Error(fmt.Sprintf(format, v...), identityFields(ctx)...)
 }
 
 func Fatalf(ctx context.Context, format string, v ...any) {
@@ -129,7 +131,20 @@ func Fatalf(ctx context.Context, format string, v ...any) {
 		return
 	}
 
-	CtxLogger(ctx).Fatal(fmt.Sprintf(format, v...))
+	CtxLogger(ctx).Fatal(fmt.Sprintf(format, v...), identityFields(ctx)...)
+}
+
+// identityFields 日志带上 baggage 中的用户、实验室和组织
+func identityFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	attrs := otel.Identity(ctx)
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = append(fields, zap.String(string(attr.Key), attr.Value.AsString()))
+	}
+	return fields
 }
 
 func Close() error {
@@ -0,0 +1,123 @@
+package otel
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Baggage members carrying the identity of the caller. They are set by the
+// auth middleware and copied onto every span started under the request.
+const (
+	BaggageUserID = "user.id"
+	BaggageLabID  = "lab.id"
+	BaggageOrgID  = "org.id"
+)
+
+// identityKeys lists the identity members in the order they are reported.
+var identityKeys = []string{BaggageUserID, BaggageLabID, BaggageOrgID}
+
+// WithIdentity returns a context whose baggage carries the given identity.
+// Empty values are left out, other baggage members are kept.
+func WithIdentity(ctx context.Context, userID, labID, orgID string) context.Context {
+	bag := baggage.FromContext(ctx)
+	for i, value := range []string{userID, labID, orgID} {
+		if value == "" {
+			continue
+		}
+		member, err := baggage.NewMemberRaw(identityKeys[i], value)
+		if err != nil {
+			continue
+		}
+		if b, err := bag.SetMember(member); err == nil {
+			bag = b
+		}
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// withoutIdentity drops identity members the client sent in the baggage
+// header, only the auth middleware may set them.
+func withoutIdentity(ctx context.Context) context.Context {
+	bag := baggage.FromContext(ctx)
+	if bag.Len() == 0 {
+		return ctx
+	}
+	for _, key := range identityKeys {
+		bag = bag.DeleteMember(key)
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// SetIdentity stores the identity in the request baggage and in the Gin
+// context, so spans, logs and outgoing requests of the handler carry it.
+func SetIdentity(c *gin.Context, userID, labID, orgID string) {
+	c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), userID, labID, orgID))
+	if userID != "" {
+		c.Set(UserIDContextKey, userID)
+	}
+	if labID != "" {
+		c.Set(LabIDContextKey, labID)
+	}
+}
+
+// Identity returns the identity members of the baggage in ctx as attributes.
+func Identity(ctx context.Context) []attribute.KeyValue {
+	bag := baggage.FromContext(ctx)
+	attrs := make([]attribute.KeyValue, 0, len(identityKeys))
+	for _, key := range identityKeys {
+		if value := bag.Member(key).Value(); value != "" {
+			attrs = append(attrs, attribute.String(key, value))
+		}
+	}
+	return attrs
+}
+
+// Baggage returns the W3C baggage header value of ctx, empty when there is
+// no baggage to propagate.
+func Baggage(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.Baggage{}.Inject(ctx, carrier)
+	return carrier.Get("baggage")
+}
+
+// ContextWithBaggage returns a context carrying the baggage of a W3C baggage
+// header value, ctx is returned unchanged when the value is empty.
+func ContextWithBaggage(ctx context.Context, value string) context.Context {
+	if value == "" {
+		return ctx
+	}
+	return propagation.Baggage{}.Extract(ctx, propagation.MapCarrier{"baggage": value})
+}
+
+// BaggageSpanProcessor copies the identity baggage onto spans when they start,
+// so database and scheduler spans can be filtered by user, lab and org.
+type BaggageSpanProcessor struct{}
+
+var _ sdktrace.SpanProcessor = BaggageSpanProcessor{}
+
+// OnStart implements sdktrace.SpanProcessor. Server spans are skipped, their
+// parent baggage comes from the client and EnhancedMiddleware sets the
+// authenticated identity on them instead.
+func (BaggageSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if s.SpanKind() == trace.SpanKindServer {
+		return
+	}
+	if attrs := Identity(parent); len(attrs) > 0 {
+		s.SetAttributes(attrs...)
+	}
+}
+
+// OnEnd implements sdktrace.SpanProcessor.
+func (BaggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+// Shutdown implements sdktrace.SpanProcessor.
+func (BaggageSpanProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush implements sdktrace.SpanProcessor.
+func (BaggageSpanProcessor) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,141 @@
+package otel
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// HealthWindow is the period summarized by HealthSnapshot.
+	HealthWindow = 5 * time.Minute
+
+	healthBucket   = 10 * time.Second
+	healthBuckets  = int(HealthWindow / healthBucket)
+	latencySamples = 2048
+)
+
+// Latency sources of HealthSnapshot.
+const (
+	LatencyDB    = "db"
+	LatencyRedis = "redis"
+)
+
+// LatencyPercentiles summarizes the latency samples of a dependency.
+type LatencyPercentiles struct {
+	Samples int
+	P50     time.Duration
+	P95     time.Duration
+	P99     time.Duration
+}
+
+// HealthSnapshot summarizes the requests served and the dependency latencies
+// of this instance over the last HealthWindow.
+type HealthSnapshot struct {
+	Window      time.Duration
+	Requests    int64
+	Errors      int64 // 5xx responses
+	RateLimited int64
+	LoadShed    int64
+	Latency     map[string]LatencyPercentiles
+}
+
+type healthCounts struct {
+	slot        int64
+	requests    int64
+	errors      int64
+	rateLimited int64
+	loadShed    int64
+}
+
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// latencyRing keeps the latest latencySamples samples of a dependency
+type latencyRing struct {
+	samples [latencySamples]latencySample
+	next    int
+}
+
+// healthWindow counts requests in healthBucket slots and keeps recent latency
+// samples, so the admin health summary needs no metrics backend
+type healthWindow struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	buckets [healthBuckets]healthCounts
+	latency map[string]*latencyRing
+}
+
+func newHealthWindow() *healthWindow {
+	return &healthWindow{now: time.Now, latency: make(map[string]*latencyRing)}
+}
+
+func (w *healthWindow) count(fn func(c *healthCounts)) {
+	slot := w.now().UnixNano() / int64(healthBucket)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c := &w.buckets[slot%int64(healthBuckets)]
+	if c.slot != slot {
+		*c = healthCounts{slot: slot}
+	}
+	fn(c)
+}
+
+func (w *healthWindow) observe(source string, d time.Duration) {
+	now := w.now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ring, ok := w.latency[source]
+	if !ok {
+		ring = &latencyRing{}
+		w.latency[source] = ring
+	}
+	ring.samples[ring.next] = latencySample{at: now, duration: d}
+	ring.next = (ring.next + 1) % latencySamples
+}
+
+func (w *healthWindow) snapshot() HealthSnapshot {
+	now := w.now()
+	slot := now.UnixNano() / int64(healthBucket)
+	since := now.Add(-HealthWindow)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := HealthSnapshot{Window: HealthWindow, Latency: make(map[string]LatencyPercentiles, len(w.latency))}
+	for _, c := range w.buckets {
+		if c.slot <= slot-int64(healthBuckets) {
+			continue
+		}
+		s.Requests += c.requests
+		s.Errors += c.errors
+		s.RateLimited += c.rateLimited
+		s.LoadShed += c.loadShed
+	}
+	for source, ring := range w.latency {
+		durations := make([]time.Duration, 0, latencySamples)
+		for _, sample := range ring.samples {
+			if !sample.at.IsZero() && sample.at.After(since) {
+				durations = append(durations, sample.duration)
+			}
+		}
+		s.Latency[source] = percentiles(durations)
+	}
+	return s
+}
+
+// percentiles returns the nearest-rank percentiles of the durations
+func percentiles(durations []time.Duration) LatencyPercentiles {
+	ret := LatencyPercentiles{Samples: len(durations)}
+	if len(durations) == 0 {
+		return ret
+	}
+	slices.Sort(durations)
+	rank := func(p int) time.Duration {
+		i := (len(durations)*p + 99) / 100
+		return durations[max(i-1, 0)]
+	}
+	ret.P50, ret.P95, ret.P99 = rank(50), rank(95), rank(99)
+	return ret
+}
@@ -3,7 +3,9 @@ package otel
 
 import (
 	"context"
+	"database/sql"
 	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -20,6 +22,7 @@ type Metrics struct {
 	// HTTP metrics
 	HTTPRequestsTotal     metric.Int64Counter
 	HTTPRequestDuration   metric.Float64Histogram
+	HTTPRequestsInFlight  metric.Int64UpDownCounter
 
 	// Workflow metrics
 	WorkflowExecutionsTotal    metric.Int64Counter
@@ -30,6 +33,49 @@ type Metrics struct {
 
 	// WebSocket metrics
 	WebSocketConnections metric.Int64UpDownCounter
+
+	// Background job metrics
+	BackgroundJobRunsTotal   metric.Int64Counter
+	BackgroundJobRunDuration metric.Float64Histogram
+
+	// Response cache metrics
+	ResponseCacheTotal metric.Int64Counter
+
+	// Load shedding metrics
+	LoadShedTotal metric.Int64Counter
+
+	// API versioning metrics
+	DeprecatedAPITotal metric.Int64Counter
+
+	// History export metrics
+	HistoryExportsTotal    metric.Int64Counter
+	HistoryExportRowsTotal metric.Int64Counter
+
+	// Event schema metrics
+	EventsDispatchedTotal metric.Int64Counter
+
+	// Payload limit metrics
+	OversizedPayloadsTotal metric.Int64Counter
+
+	// History change stream metrics
+	CDCChangesTotal metric.Int64Counter
+	CDCDelay        metric.Float64Histogram
+
+	// Rate limit metrics
+	RateLimitedTotal metric.Int64Counter
+
+	// Dependency latency metrics
+	DBQueryDuration      metric.Float64Histogram
+	RedisCommandDuration metric.Float64Histogram
+
+	// WebSocket hub metrics
+	HubMessagesTotal   metric.Int64Counter
+	HubRejectionsTotal metric.Int64Counter
+
+	health *healthWindow
+
+	// budget bounds the distinct values of high-cardinality attributes
+	budget *attributeBudget
 }
 
 var (
@@ -48,7 +94,7 @@ func GetMetrics() *Metrics {
 
 func initMetrics() *Metrics {
 	meter := otel.Meter(MeterName)
-	m := &Metrics{}
+	m := &Metrics{health: newHealthWindow(), budget: newAttributeBudget(DefaultAttributeBudget)}
 
 	var err error
 
@@ -72,6 +118,15 @@ func initMetrics() *Metrics {
 		otel.Handle(err)
 	}
 
+	m.HTTPRequestsInFlight, err = meter.Int64UpDownCounter(
+		"studio_http_requests_in_flight",
+		metric.WithDescription("Current number of HTTP requests being served, including WebSocket connections"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
 	// Workflow metrics
 	m.WorkflowExecutionsTotal, err = meter.Int64Counter(
 		"studio_workflow_executions_total",
@@ -112,34 +167,228 @@ func initMetrics() *Metrics {
 		otel.Handle(err)
 	}
 
+	// Background job metrics
+	m.BackgroundJobRunsTotal, err = meter.Int64Counter(
+		"studio_background_job_runs_total",
+		metric.WithDescription("Total number of background job runs"),
+		metric.WithUnit("{run}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	m.BackgroundJobRunDuration, err = meter.Float64Histogram(
+		"studio_background_job_run_duration_seconds",
+		metric.WithDescription("Background job run duration in seconds"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// Response cache metrics
+	m.ResponseCacheTotal, err = meter.Int64Counter(
+		"studio_response_cache_total",
+		metric.WithDescription("Total number of response cache lookups by result"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// Load shedding metrics
+	m.LoadShedTotal, err = meter.Int64Counter(
+		"studio_load_shed_total",
+		metric.WithDescription("Total number of requests rejected by load shedding"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// API versioning metrics
+	m.DeprecatedAPITotal, err = meter.Int64Counter(
+		"studio_api_deprecated_requests_total",
+		metric.WithDescription("Total number of requests served by a deprecated API version"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// History export metrics
+	m.HistoryExportsTotal, err = meter.Int64Counter(
+		"studio_history_exports_total",
+		metric.WithDescription("Total number of history export runs by outcome"),
+		metric.WithUnit("{run}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	m.HistoryExportRowsTotal, err = meter.Int64Counter(
+		"studio_history_export_rows_total",
+		metric.WithDescription("Total number of history rows written by exports"),
+		metric.WithUnit("{row}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	m.EventsDispatchedTotal, err = meter.Int64Counter(
+		"studio_events_dispatched_total",
+		metric.WithDescription("Total number of events emitted or dropped for violating their schema"),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// Payload limit metrics
+	m.OversizedPayloadsTotal, err = meter.Int64Counter(
+		"studio_oversized_payloads_total",
+		metric.WithDescription("Total number of JSON payloads over the size or depth limit"),
+		metric.WithUnit("{payload}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// History change stream metrics
+	m.CDCChangesTotal, err = meter.Int64Counter(
+		"studio_cdc_changes_total",
+		metric.WithDescription("Total number of history row changes republished from the replication slot"),
+		metric.WithUnit("{change}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	m.CDCDelay, err = meter.Float64Histogram(
+		"studio_cdc_delay_seconds",
+		metric.WithDescription("Time from the commit of a history transaction to the publication of its changes"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.5, 1, 2, 5, 10, 30, 60, 300, 900, 3600),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// Rate limit metrics
+	m.RateLimitedTotal, err = meter.Int64Counter(
+		"studio_rate_limit_rejections_total",
+		metric.WithDescription("Total number of requests rejected by rate limiting"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// Dependency latency metrics
+	m.DBQueryDuration, err = meter.Float64Histogram(
+		"studio_db_query_duration_seconds",
+		metric.WithDescription("Database statement duration in seconds"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	m.RedisCommandDuration, err = meter.Float64Histogram(
+		"studio_redis_command_duration_seconds",
+		metric.WithDescription("Redis command duration in seconds"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	// WebSocket hub metrics
+	m.HubMessagesTotal, err = meter.Int64Counter(
+		"studio_ws_hub_messages_total",
+		metric.WithDescription("Total number of messages published to or delivered by the WebSocket hub"),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	m.HubRejectionsTotal, err = meter.Int64Counter(
+		"studio_ws_hub_rejections_total",
+		metric.WithDescription("Total number of WebSocket hub connections and subscriptions rejected"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
 	return m
 }
 
 // RecordHTTPRequest records an HTTP request metric.
 func (m *Metrics) RecordHTTPRequest(ctx context.Context, method, path string, statusCode int, userID string) {
 	attrs := []attribute.KeyValue{
-		attribute.String("http.method", method),
-		attribute.String("http.route", path),
+		attribute.String("http.method", normalizeMethod(method)),
+		m.budget.String("http.route", path),
 		attribute.Int("http.status_code", statusCode),
 	}
 	if userID != "" {
-		attrs = append(attrs, attribute.String("user.id", userID))
+		attrs = append(attrs, m.budget.String("user.id", userID))
 	}
 	m.HTTPRequestsTotal.Add(ctx, 1, metric.WithAttributes(attrs...))
+	m.health.count(func(c *healthCounts) {
+		c.requests++
+		if statusCode >= 500 {
+			c.errors++
+		}
+	})
+}
+
+// RecordRateLimited records a request rejected by rate limiting.
+func (m *Metrics) RecordRateLimited(ctx context.Context, route string) {
+	m.RateLimitedTotal.Add(ctx, 1, metric.WithAttributes(
+		m.budget.String("http.route", route),
+	))
+	m.health.count(func(c *healthCounts) { c.rateLimited++ })
+}
+
+// RecordDBQuery records the duration of a database statement.
+func (m *Metrics) RecordDBQuery(ctx context.Context, operation string, duration time.Duration) {
+	m.DBQueryDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("operation", operation),
+	))
+	m.health.observe(LatencyDB, duration)
+}
+
+// RecordRedisCommand records the duration of a Redis command or pipeline.
+func (m *Metrics) RecordRedisCommand(ctx context.Context, command string, duration time.Duration) {
+	m.RedisCommandDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("command", command),
+	))
+	m.health.observe(LatencyRedis, duration)
+}
+
+// Health summarizes the requests and dependency latencies of this instance
+// over the last HealthWindow.
+func (m *Metrics) Health() HealthSnapshot {
+	return m.health.snapshot()
 }
 
 // RecordHTTPDuration records HTTP request duration.
 func (m *Metrics) RecordHTTPDuration(ctx context.Context, method, path string, durationSeconds float64) {
 	m.HTTPRequestDuration.Record(ctx, durationSeconds, metric.WithAttributes(
-		attribute.String("http.method", method),
-		attribute.String("http.route", path),
+		attribute.String("http.method", normalizeMethod(method)),
+		m.budget.String("http.route", path),
 	))
 }
 
 // RecordWorkflowExecution records a workflow execution metric.
 func (m *Metrics) RecordWorkflowExecution(ctx context.Context, labID, status string) {
 	m.WorkflowExecutionsTotal.Add(ctx, 1, metric.WithAttributes(
-		attribute.String("lab.id", labID),
+		m.budget.String("lab.id", labID),
 		attribute.String("status", status),
 	))
 }
@@ -147,7 +396,7 @@ func (m *Metrics) RecordWorkflowExecution(ctx context.Context, labID, status str
 // RecordWorkflowDuration records workflow execution duration.
 func (m *Metrics) RecordWorkflowDuration(ctx context.Context, labID string, durationSeconds float64) {
 	m.WorkflowExecutionDuration.Record(ctx, durationSeconds, metric.WithAttributes(
-		attribute.String("lab.id", labID),
+		m.budget.String("lab.id", labID),
 	))
 }
 
@@ -159,6 +408,22 @@ func (m *Metrics) RecordActionExecution(ctx context.Context, actionType, status
 	))
 }
 
+// HTTPRequestStarted increments the in-flight HTTP request counter.
+func (m *Metrics) HTTPRequestStarted(ctx context.Context, method, route string) {
+	m.HTTPRequestsInFlight.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("http.method", normalizeMethod(method)),
+		m.budget.String("http.route", route),
+	))
+}
+
+// HTTPRequestFinished decrements the in-flight HTTP request counter.
+func (m *Metrics) HTTPRequestFinished(ctx context.Context, method, route string) {
+	m.HTTPRequestsInFlight.Add(ctx, -1, metric.WithAttributes(
+		attribute.String("http.method", normalizeMethod(method)),
+		m.budget.String("http.route", route),
+	))
+}
+
 // WebSocketConnected increments the WebSocket connection counter.
 func (m *Metrics) WebSocketConnected(ctx context.Context, connType string) {
 	m.WebSocketConnections.Add(ctx, 1, metric.WithAttributes(
@@ -173,3 +438,306 @@ func (m *Metrics) WebSocketDisconnected(ctx context.Context, connType string) {
 	))
 }
 
+// RecordHubMessage records a WebSocket hub message of a topic kind, result is
+// published, failed, delivered or dropped.
+func (m *Metrics) RecordHubMessage(ctx context.Context, topicKind, result string) {
+	m.HubMessagesTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("topic.kind", topicKind),
+		attribute.String("result", result),
+	))
+}
+
+// RecordHubRejection records a WebSocket hub connection or subscription rejected for reason.
+func (m *Metrics) RecordHubRejection(ctx context.Context, reason string) {
+	m.HubRejectionsTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("reason", reason),
+	))
+}
+
+// RecordBackgroundJobRun records a finished background job run and its duration.
+func (m *Metrics) RecordBackgroundJobRun(ctx context.Context, job, status string, durationSeconds float64) {
+	attrs := metric.WithAttributes(
+		attribute.String("job", job),
+		attribute.String("status", status),
+	)
+	m.BackgroundJobRunsTotal.Add(ctx, 1, attrs)
+	m.BackgroundJobRunDuration.Record(ctx, durationSeconds, attrs)
+}
+
+// RecordResponseCache records a response cache lookup, result is hit, miss or bypass.
+func (m *Metrics) RecordResponseCache(ctx context.Context, route, result string) {
+	m.ResponseCacheTotal.Add(ctx, 1, metric.WithAttributes(
+		m.budget.String("http.route", route),
+		attribute.String("result", result),
+	))
+}
+
+// RecordLoadShed records a request rejected by load shedding, reason is the
+// signal that triggered it.
+func (m *Metrics) RecordLoadShed(ctx context.Context, route, class, reason string) {
+	m.LoadShedTotal.Add(ctx, 1, metric.WithAttributes(
+		m.budget.String("http.route", route),
+		attribute.String("class", class),
+		attribute.String("reason", reason),
+	))
+	m.health.count(func(c *healthCounts) { c.loadShed++ })
+}
+
+// RecordDeprecatedAPI records a request served by a deprecated API version.
+func (m *Metrics) RecordDeprecatedAPI(ctx context.Context, route string, version int) {
+	m.DeprecatedAPITotal.Add(ctx, 1, metric.WithAttributes(
+		m.budget.String("http.route", route),
+		attribute.Int("api.version", version),
+	))
+}
+
+// RecordHistoryExport records a history export run ending with outcome
+// completed, failed or paused; resumed marks runs continuing from a checkpoint.
+func (m *Metrics) RecordHistoryExport(ctx context.Context, kind, outcome string, resumed bool) {
+	m.HistoryExportsTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("kind", kind),
+		attribute.String("outcome", outcome),
+		attribute.Bool("resumed", resumed),
+	))
+}
+
+// RecordHistoryExportRows records the rows of a checkpointed history export part.
+func (m *Metrics) RecordHistoryExportRows(ctx context.Context, kind string, rows int) {
+	m.HistoryExportRowsTotal.Add(ctx, int64(rows), metric.WithAttributes(
+		attribute.String("kind", kind),
+	))
+}
+
+// RecordEventDispatch records an event with result sent, failed or invalid.
+func (m *Metrics) RecordEventDispatch(ctx context.Context, eventType, result string) {
+	m.EventsDispatchedTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("event.type", eventType),
+		attribute.String("result", result),
+	))
+}
+
+// RecordOversizedPayload records a payload of table.field over the limit,
+// action is rejected, truncated or artifact.
+func (m *Metrics) RecordOversizedPayload(ctx context.Context, table, field, action string) {
+	m.OversizedPayloadsTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("table", table),
+		attribute.String("field", field),
+		attribute.String("action", action),
+	))
+}
+
+// RecordCDCChange records a history row change of table republished on the
+// event bus, op is insert, update or delete.
+func (m *Metrics) RecordCDCChange(ctx context.Context, table, op string) {
+	m.CDCChangesTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("table", table),
+		attribute.String("op", op),
+	))
+}
+
+// RecordCDCDelay records the time from the commit of a transaction to the publication of its changes.
+func (m *Metrics) RecordCDCDelay(ctx context.Context, delay time.Duration) {
+	m.CDCDelay.Record(ctx, delay.Seconds())
+}
+
+// CDCSlotStatsFunc reports the WAL retained by the replication slot, ok is false when the slot does not exist.
+type CDCSlotStatsFunc func(ctx context.Context) (lagBytes int64, ok bool, err error)
+
+// ObserveCDCSlot registers a gauge for the WAL the history change stream has not confirmed yet.
+func (m *Metrics) ObserveCDCSlot(stats CDCSlotStatsFunc) error {
+	meter := otel.Meter(MeterName)
+
+	gauge, err := meter.Int64ObservableGauge(
+		"studio_cdc_slot_lag_bytes",
+		metric.WithDescription("Bytes of WAL retained by the history change stream replication slot"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		lag, ok, err := stats(ctx)
+		if err != nil || !ok {
+			return err
+		}
+		o.ObserveInt64(gauge, lag)
+		return nil
+	}, gauge)
+	return err
+}
+
+// DeadLetterStatsFunc reports the current dead-letter queue depth and the age of the oldest entry.
+type DeadLetterStatsFunc func(ctx context.Context) (depth int64, oldestAge time.Duration, err error)
+
+// ObserveDeadLetters registers gauges for the dead-letter queue depth and age.
+func (m *Metrics) ObserveDeadLetters(stats DeadLetterStatsFunc) error {
+	meter := otel.Meter(MeterName)
+
+	depthGauge, err := meter.Int64ObservableGauge(
+		"studio_dead_letter_depth",
+		metric.WithDescription("Number of scheduler jobs waiting in the dead-letter queue"),
+		metric.WithUnit("{job}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	ageGauge, err := meter.Float64ObservableGauge(
+		"studio_dead_letter_oldest_age_seconds",
+		metric.WithDescription("Age of the oldest job in the dead-letter queue"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		depth, oldestAge, err := stats(ctx)
+		if err != nil {
+			return err
+		}
+		o.ObserveInt64(depthGauge, depth)
+		o.ObserveFloat64(ageGauge, oldestAge.Seconds())
+		return nil
+	}, depthGauge, ageGauge)
+	return err
+}
+
+// ObserveLeader registers a gauge reporting whether this instance currently leads the named election.
+func (m *Metrics) ObserveLeader(election, instance string, isLeader func() bool) error {
+	meter := otel.Meter(MeterName)
+
+	gauge, err := meter.Int64ObservableGauge(
+		"studio_leader",
+		metric.WithDescription("Whether this instance is the current leader (1) or not (0)"),
+		metric.WithUnit("{leader}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	attrs := metric.WithAttributes(
+		attribute.String("election", election),
+		attribute.String("instance", instance),
+	)
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		var v int64
+		if isLeader() {
+			v = 1
+		}
+		o.ObserveInt64(gauge, v, attrs)
+		return nil
+	}, gauge)
+	return err
+}
+
+// LivenessSummaryFunc reports how many devices are currently online and offline.
+type LivenessSummaryFunc func(ctx context.Context) (online, offline int64, err error)
+
+// ObserveDeviceLiveness registers a gauge for the number of devices by liveness status.
+func (m *Metrics) ObserveDeviceLiveness(summary LivenessSummaryFunc) error {
+	meter := otel.Meter(MeterName)
+
+	gauge, err := meter.Int64ObservableGauge(
+		"studio_device_liveness",
+		metric.WithDescription("Number of devices by edge heartbeat liveness status"),
+		metric.WithUnit("{device}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		online, offline, err := summary(ctx)
+		if err != nil {
+			return err
+		}
+		o.ObserveInt64(gauge, online, metric.WithAttributes(attribute.String("status", "online")))
+		o.ObserveInt64(gauge, offline, metric.WithAttributes(attribute.String("status", "offline")))
+		return nil
+	}, gauge)
+	return err
+}
+
+// DBPoolStatsFunc reports the connection pool statistics of each database by name.
+type DBPoolStatsFunc func() map[string]sql.DBStats
+
+// ObserveDBPool registers gauges for the database connection pool saturation.
+func (m *Metrics) ObserveDBPool(stats DBPoolStatsFunc) error {
+	meter := otel.Meter(MeterName)
+
+	connGauge, err := meter.Int64ObservableGauge(
+		"studio_db_pool_connections",
+		metric.WithDescription("Number of database connections by state (open, in_use, idle, max_open)"),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	waitCounter, err := meter.Int64ObservableCounter(
+		"studio_db_pool_wait_total",
+		metric.WithDescription("Total number of connections waited for because the pool was exhausted"),
+		metric.WithUnit("{wait}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	waitDuration, err := meter.Float64ObservableCounter(
+		"studio_db_pool_wait_duration_seconds",
+		metric.WithDescription("Total time blocked waiting for a database connection"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for name, s := range stats() {
+			db := attribute.String("db", name)
+			o.ObserveInt64(connGauge, int64(s.OpenConnections), metric.WithAttributes(db, attribute.String("state", "open")))
+			o.ObserveInt64(connGauge, int64(s.InUse), metric.WithAttributes(db, attribute.String("state", "in_use")))
+			o.ObserveInt64(connGauge, int64(s.Idle), metric.WithAttributes(db, attribute.String("state", "idle")))
+			o.ObserveInt64(connGauge, int64(s.MaxOpenConnections), metric.WithAttributes(db, attribute.String("state", "max_open")))
+			o.ObserveInt64(waitCounter, s.WaitCount, metric.WithAttributes(db))
+			o.ObserveFloat64(waitDuration, s.WaitDuration.Seconds(), metric.WithAttributes(db))
+		}
+		return nil
+	}, connGauge, waitCounter, waitDuration)
+	return err
+}
+
+// LoadStatsFunc reports the requests in flight and the load as a fraction of full load.
+type LoadStatsFunc func() (inFlight int64, load float64)
+
+// ObserveLoad registers gauges for the load seen by load shedding.
+func (m *Metrics) ObserveLoad(stats LoadStatsFunc) error {
+	meter := otel.Meter(MeterName)
+
+	inFlightGauge, err := meter.Int64ObservableGauge(
+		"studio_http_in_flight",
+		metric.WithDescription("Number of HTTP requests in flight counted by load shedding"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	loadGauge, err := meter.Float64ObservableGauge(
+		"studio_load",
+		metric.WithDescription("Service load as a fraction of full load, requests are shed by class above their threshold"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		inFlight, load := stats()
+		o.ObserveInt64(inFlightGauge, inFlight)
+		o.ObserveFloat64(loadGauge, load)
+		return nil
+	}, inFlightGauge, loadGauge)
+	return err
+}
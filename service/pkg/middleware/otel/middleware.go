@@ -44,6 +44,18 @@ func EnhancedMiddleware() gin.HandlerFunc {
 		// Add request ID to span
 		span.SetAttributes(attribute.String("request.id", requestID))
 
+		// Identity baggage is only trusted when set by the auth middleware
+		c.Request = c.Request.WithContext(withoutIdentity(c.Request.Context()))
+
+		// Get the matched route pattern (not the actual path with params),
+		// unmatched paths are reported together to keep series bounded
+		routePattern := Route(c)
+
+		// Count the request in flight until the handler returns, WebSocket
+		// connections stay counted while they are open
+		metrics.HTTPRequestStarted(c.Request.Context(), c.Request.Method, routePattern)
+		defer metrics.HTTPRequestFinished(c.Request.Context(), c.Request.Method, routePattern)
+
 		// Process request
 		c.Next()
 
@@ -58,23 +70,8 @@ func EnhancedMiddleware() gin.HandlerFunc {
 			}
 		}
 
-		// Add user ID to span if available
-		if userID != "" {
-			span.SetAttributes(attribute.String("user.id", userID))
-		}
-
-		// Get lab ID from context if available
-		if labID, exists := c.Get(LabIDContextKey); exists {
-			if labIDStr, ok := labID.(string); ok {
-				span.SetAttributes(attribute.String("lab.id", labIDStr))
-			}
-		}
-
-		// Get the matched route pattern (not the actual path with params)
-		routePattern := c.FullPath()
-		if routePattern == "" {
-			routePattern = c.Request.URL.Path
-		}
+		// Add user, lab and org IDs to span if available
+		span.SetAttributes(Identity(c.Request.Context())...)
 
 		// Record HTTP metrics
 		metrics.RecordHTTPRequest(c.Request.Context(), c.Request.Method, routePattern, c.Writer.Status(), userID)
@@ -88,9 +85,10 @@ func EnhancedMiddleware() gin.HandlerFunc {
 	}
 }
 
-// SetLabID sets the lab ID in the Gin context for later use in span attributes.
+// SetLabID sets the lab ID in the Gin context and the request baggage for
+// later use in span attributes.
 func SetLabID(c *gin.Context, labID string) {
-	c.Set(LabIDContextKey, labID)
+	SetIdentity(c, "", labID, "")
 
 	// Also add to current span
 	span := trace.SpanFromContext(c.Request.Context())
@@ -1,12 +1,18 @@
 package otel
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestGetMetrics(t *testing.T) {
@@ -20,6 +26,7 @@ func TestGetMetrics(t *testing.T) {
 	// Check that all metrics are initialized
 	assert.NotNil(t, m1.HTTPRequestsTotal)
 	assert.NotNil(t, m1.HTTPRequestDuration)
+	assert.NotNil(t, m1.HTTPRequestsInFlight)
 	assert.NotNil(t, m1.WorkflowExecutionsTotal)
 	assert.NotNil(t, m1.WorkflowExecutionDuration)
 	assert.NotNil(t, m1.ActionExecutionsTotal)
@@ -106,3 +113,170 @@ func TestInjectExtractTraceContext(t *testing.T) {
 	// assert.NotNil(t, ctx)
 }
 
+func TestTraceParent(t *testing.T) {
+	// Without a span there is nothing to propagate
+	assert.Empty(t, TraceParent(context.Background()))
+	assert.Equal(t, context.Background(), ContextWithTraceParent(context.Background(), ""))
+
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := ContextWithTraceParent(context.Background(), traceParent)
+	sc := trace.SpanContextFromContext(ctx)
+	assert.True(t, sc.IsRemote())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID().String())
+	assert.Equal(t, traceParent, TraceParent(ctx))
+
+	// Malformed values are ignored rather than starting a broken trace
+	assert.False(t, trace.SpanContextFromContext(ContextWithTraceParent(context.Background(), "garbage")).IsValid())
+
+	// The consumer span stays in the producer's trace
+	ctx, span := StartConsumerSpan(context.Background(), "queue", traceParent, "", "start_job")
+	defer span.End()
+	assert.Equal(t, sc.TraceID(), trace.SpanContextFromContext(ctx).TraceID())
+}
+
+func TestIdentityBaggage(t *testing.T) {
+	ctx := WithIdentity(context.Background(), "u1", "", "org-9")
+	ctx = WithIdentity(ctx, "", "42", "")
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String(BaggageUserID, "u1"),
+		attribute.String(BaggageLabID, "42"),
+		attribute.String(BaggageOrgID, "org-9"),
+	}, Identity(ctx))
+
+	// The baggage survives a round trip through a queue message
+	restored := ContextWithBaggage(context.Background(), Baggage(ctx))
+	assert.Equal(t, Identity(ctx), Identity(restored))
+	assert.Empty(t, Baggage(context.Background()))
+
+	assert.Empty(t, Identity(withoutIdentity(ctx)))
+}
+
+func TestEnhancedMiddlewareIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(func(c *gin.Context) {
+		// Client supplied baggage, as extracted by otelgin
+		c.Request = c.Request.WithContext(ContextWithBaggage(c.Request.Context(), "user.id=spoofed,lab.id=1"))
+		c.Next()
+	})
+	router.Use(EnhancedMiddleware())
+	router.GET("/test/:lab_id", func(c *gin.Context) {
+		assert.Empty(t, Identity(c.Request.Context()))
+
+		SetIdentity(c, "u1", c.Param("lab_id"), "")
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String(BaggageUserID, "u1"),
+			attribute.String(BaggageLabID, "7"),
+		}, Identity(c))
+		assert.Equal(t, "u1", c.GetString(UserIDContextKey))
+		c.String(http.StatusOK, "OK")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/7", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBaggageSpanProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(BaggageSpanProcessor{}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	tracer := tp.Tracer("test")
+
+	ctx := WithIdentity(context.Background(), "u1", "42", "")
+	_, server := tracer.Start(ctx, "server", trace.WithSpanKind(trace.SpanKindServer))
+	server.End()
+	_, query := tracer.Start(ctx, "db.query", trace.WithSpanKind(trace.SpanKindClient))
+	query.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Empty(t, spans[0].Attributes())
+	assert.ElementsMatch(t, Identity(ctx), spans[1].Attributes())
+}
+
+func TestRouteAndSpanName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ctx, span := tracer.Start(c.Request.Context(), SpanNameFormatter(c), trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		c.Header("X-Route", Route(c))
+	})
+	router.GET("/lab/:uuid", func(c *gin.Context) { c.Status(http.StatusOK) })
+	ws := router.Group("/ws", SpanName("WS /ws"))
+	ws.GET("/:topic", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/lab/1", "/missing/2", "/ws/hub"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	req := httptest.NewRequest("PURGE", "/missing/3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, UnmatchedRoute, w.Header().Get("X-Route"))
+
+	spans := recorder.Ended()
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	assert.Equal(t, []string{"GET /lab/:uuid", "GET unmatched", "WS /ws", "_OTHER unmatched"}, names)
+}
+
+func TestAttributeBudget(t *testing.T) {
+	b := newAttributeBudget(2)
+
+	assert.Equal(t, "a", b.value("http.route", "a"))
+	assert.Equal(t, "b", b.value("http.route", "b"))
+	assert.Equal(t, OverflowValue, b.value("http.route", "c"))
+	assert.Equal(t, "a", b.value("http.route", "a"), "known values are kept after the budget is used up")
+	assert.Equal(t, "c", b.value("user.id", "c"), "budgets are per key")
+	assert.Equal(t, attribute.String("http.route", OverflowValue), b.String("http.route", "d"))
+}
+
+func TestHealthWindow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := newHealthWindow()
+	w.now = func() time.Time { return now }
+
+	for i := range 100 {
+		w.count(func(c *healthCounts) { c.requests++ })
+		w.observe(LatencyDB, time.Duration(i+1)*time.Millisecond)
+	}
+	w.count(func(c *healthCounts) { c.errors++ })
+	w.count(func(c *healthCounts) { c.rateLimited++ })
+
+	s := w.snapshot()
+	assert.Equal(t, HealthWindow, s.Window)
+	assert.Equal(t, int64(100), s.Requests)
+	assert.Equal(t, int64(1), s.Errors)
+	assert.Equal(t, int64(1), s.RateLimited)
+	assert.Equal(t, LatencyPercentiles{
+		Samples: 100,
+		P50:     50 * time.Millisecond,
+		P95:     95 * time.Millisecond,
+		P99:     99 * time.Millisecond,
+	}, s.Latency[LatencyDB])
+
+	// 新的计数落在新的时间片，超出窗口的计数和耗时不再统计
+	now = now.Add(HealthWindow - healthBucket)
+	w.count(func(c *healthCounts) { c.requests++ })
+	assert.Equal(t, int64(101), w.snapshot().Requests)
+
+	now = now.Add(healthBucket)
+	s = w.snapshot()
+	assert.Equal(t, int64(1), s.Requests)
+	assert.Equal(t, int64(0), s.Errors)
+	assert.Equal(t, 0, s.Latency[LatencyDB].Samples)
+}
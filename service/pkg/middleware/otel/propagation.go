@@ -11,6 +11,15 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+var (
+	// externalPropagator propagates only the trace, the baggage carries the
+	// identity of the caller and must not leave for external services.
+	externalPropagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+	// internalPropagator propagates the trace and the baggage to internal services.
+	internalPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+)
+
 // PropagatingHTTPClient returns a resty client that propagates trace context.
 func PropagatingHTTPClient() *resty.Client {
 	client := resty.New()
@@ -23,8 +32,7 @@ func PropagatingHTTPClient() *resty.Client {
 		}
 
 		// Inject trace context into request headers
-		propagator := otel.GetTextMapPropagator()
-		propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+		externalPropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 		return nil
 	})
@@ -88,7 +96,19 @@ func SetClientSpanStatus(span trace.Span, statusCode int, err error) {
 
 // RestyClientWithTracing returns a configured resty client with full tracing support.
 // This includes trace context propagation and span creation for each request.
+// The baggage is not propagated, use InternalRestyClient for internal services.
 func RestyClientWithTracing() *resty.Client {
+	return restyClientWithTracing(externalPropagator)
+}
+
+// InternalRestyClient returns a resty client with full tracing support that
+// also propagates the baggage, so internal services see the user, lab and org
+// of the request.
+func InternalRestyClient() *resty.Client {
+	return restyClientWithTracing(internalPropagator)
+}
+
+func restyClientWithTracing(propagator propagation.TextMapPropagator) *resty.Client {
 	client := resty.New()
 
 	client.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
@@ -111,7 +131,6 @@ func RestyClientWithTracing() *resty.Client {
 		req.SetContext(ctx)
 
 		// Inject trace context into request headers
-		propagator := otel.GetTextMapPropagator()
 		propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 		// Store span reference for cleanup in OnAfterResponse
@@ -0,0 +1,45 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceParentKey is the W3C trace context field carried in queue messages.
+const traceParentKey = "traceparent"
+
+// TraceParent returns the W3C traceparent of the span in ctx, or an empty
+// string when ctx carries no valid span context.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceParentKey)
+}
+
+// ContextWithTraceParent returns a context whose remote parent is the span
+// described by traceparent. ctx is returned unchanged when traceparent is
+// empty or malformed.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceParentKey: traceParent})
+}
+
+// StartConsumerSpan starts a consumer span for a message taken from queue,
+// continuing the trace and baggage of the producer when they are set.
+func StartConsumerSpan(ctx context.Context, queue, traceParent, bag, operation string) (context.Context, trace.Span) {
+	ctx = ContextWithBaggage(ContextWithTraceParent(ctx, traceParent), bag)
+	return otel.Tracer(MeterName).Start(ctx, queue+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", queue),
+			attribute.String("messaging.operation", operation),
+		),
+	)
+}
@@ -0,0 +1,100 @@
+package otel
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// UnmatchedRoute is reported instead of the raw URL path for requests that
+	// matched no route, so 404 scans do not create new series.
+	UnmatchedRoute = "unmatched"
+
+	// OverflowValue replaces attribute values once their key exceeded its
+	// cardinality budget.
+	OverflowValue = "other"
+
+	// DefaultAttributeBudget is the number of distinct values kept per
+	// metric attribute key before new values are reported as OverflowValue.
+	DefaultAttributeBudget = 1000
+)
+
+// Route returns the matched route pattern of the request, or UnmatchedRoute
+// when the request matched no route.
+func Route(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return UnmatchedRoute
+}
+
+// SpanNameFormatter names server spans after the method and the normalized
+// route. It is meant for otelgin.WithSpanNameFormatter.
+func SpanNameFormatter(c *gin.Context) string {
+	return normalizeMethod(c.Request.Method) + " " + Route(c)
+}
+
+// SpanName returns a middleware that renames the server span of every route
+// in the group to name, e.g. for long-lived WebSocket connections.
+func SpanName(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		trace.SpanFromContext(c.Request.Context()).SetName(name)
+		c.Next()
+	}
+}
+
+// attributeBudget bounds the number of distinct values reported per
+// attribute key. Values seen before keep being reported as is.
+type attributeBudget struct {
+	mu     sync.Mutex
+	limit  int
+	values map[string]map[string]struct{}
+}
+
+func newAttributeBudget(limit int) *attributeBudget {
+	return &attributeBudget{
+		limit:  limit,
+		values: make(map[string]map[string]struct{}),
+	}
+}
+
+// String returns the attribute for key and value, with value replaced by
+// OverflowValue once key has used up its budget.
+func (b *attributeBudget) String(key, value string) attribute.KeyValue {
+	return attribute.String(key, b.value(key, value))
+}
+
+func (b *attributeBudget) value(key, value string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seen, ok := b.values[key]
+	if !ok {
+		seen = make(map[string]struct{})
+		b.values[key] = seen
+	}
+	if _, ok := seen[value]; ok {
+		return value
+	}
+	if len(seen) >= b.limit {
+		return OverflowValue
+	}
+	seen[value] = struct{}{}
+	return value
+}
+
+// normalizeMethod keeps the standard HTTP methods and reports the rest as
+// "_OTHER", matching the semantic conventions.
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodConnect,
+		http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "_OTHER"
+}
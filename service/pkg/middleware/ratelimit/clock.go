@@ -0,0 +1,64 @@
+package ratelimit
+
+import "time"
+
+// DefaultRecoveryInterval is how often Redis is probed after falling back to
+// the local limiter.
+const DefaultRecoveryInterval = 30 * time.Second
+
+// Clock is the time source of the limiters. Embedders can inject their own
+// clock to control window boundaries and Redis recovery checks.
+type Clock interface {
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time.
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock returns the wall clock used by default.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+// Option configures the limiters.
+type Option func(*options)
+
+type options struct {
+	clock            Clock
+	recoveryInterval time.Duration
+}
+
+// WithClock sets the time source of the limiter.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// WithRecoveryInterval sets how often Redis is probed while the middleware
+// uses the local fallback.
+func WithRecoveryInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.recoveryInterval = d
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		clock:            SystemClock(),
+		recoveryInterval: DefaultRecoveryInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -35,17 +35,20 @@ type Limiter interface {
 
 // RateLimitMiddleware holds the rate limiting middleware state.
 type RateLimitMiddleware struct {
-	config        *Config
-	redisClient   *redis.Client
-	slidingWindow *SlidingWindowLimiter
-	localLimiter  *LocalLimiter
-	useLocal      bool
-	mu            sync.RWMutex
+	config           *Config
+	redisClient      *redis.Client
+	slidingWindow    *SlidingWindowLimiter
+	localLimiter     *LocalLimiter
+	clock            Clock
+	recoveryInterval time.Duration
+	useLocal         bool
+	mu               sync.RWMutex
 }
 
 // LocalLimiter provides in-memory rate limiting as fallback.
 type LocalLimiter struct {
 	counters map[string]*localCounter
+	clock    Clock
 	mu       sync.RWMutex
 }
 
@@ -55,9 +58,10 @@ type localCounter struct {
 }
 
 // NewLocalLimiter creates a new local rate limiter.
-func NewLocalLimiter() *LocalLimiter {
+func NewLocalLimiter(opts ...Option) *LocalLimiter {
 	return &LocalLimiter{
 		counters: make(map[string]*localCounter),
+		clock:    newOptions(opts).clock,
 	}
 }
 
@@ -66,7 +70,7 @@ func (l *LocalLimiter) Allow(key string, limit int, window time.Duration) (bool,
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	now := time.Now()
+	now := l.clock.Now()
 	counter, exists := l.counters[key]
 
 	if !exists || now.After(counter.resetTime) {
@@ -87,20 +91,23 @@ func (l *LocalLimiter) Allow(key string, limit int, window time.Duration) (bool,
 }
 
 // New creates a new rate limiting middleware.
-func New(redisClient *redis.Client, config *Config) *RateLimitMiddleware {
+func New(redisClient *redis.Client, config *Config, opts ...Option) *RateLimitMiddleware {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
+	o := newOptions(opts)
 	m := &RateLimitMiddleware{
-		config:       config,
-		redisClient:  redisClient,
-		localLimiter: NewLocalLimiter(),
-		useLocal:     redisClient == nil,
+		config:           config,
+		redisClient:      redisClient,
+		localLimiter:     NewLocalLimiter(WithClock(o.clock)),
+		clock:            o.clock,
+		recoveryInterval: o.recoveryInterval,
+		useLocal:         redisClient == nil,
 	}
 
 	if redisClient != nil {
-		m.slidingWindow = NewSlidingWindowLimiter(redisClient)
+		m.slidingWindow = NewSlidingWindowLimiter(redisClient, WithClock(o.clock))
 	}
 
 	return m
@@ -137,15 +144,14 @@ func (m *RateLimitMiddleware) Middleware() gin.HandlerFunc {
 
 		if !allowed {
 			// Calculate retry after
-			retryAfter := resetTime - time.Now().Unix()
+			retryAfter := resetTime - m.clock.Now().Unix()
 			if retryAfter < 1 {
 				retryAfter = 1
 			}
 			c.Header(HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
 
-			// Record metric
-			metrics := otel.GetMetrics()
-			metrics.RecordHTTPRequest(c.Request.Context(), c.Request.Method, c.FullPath(), http.StatusTooManyRequests, "")
+			// Record metric, the request itself is recorded by the otel middleware
+			otel.GetMetrics().RecordRateLimited(c.Request.Context(), otel.Route(c))
 
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"error":       "Rate limit exceeded",
@@ -202,37 +208,40 @@ func (m *RateLimitMiddleware) checkLimit(c *gin.Context, key string, tier *TierC
 		// Log error and fall back to local limiter if configured
 		if m.config.FallbackToLocal {
 			m.mu.Lock()
+			// Only the request that switches to the fallback starts the recovery check
+			switched := !m.useLocal
 			m.useLocal = true
 			m.mu.Unlock()
 
-			// Schedule recovery check
-			go m.scheduleRedisRecoveryCheck()
+			if switched {
+				go m.scheduleRedisRecoveryCheck()
+			}
 
 			return m.localLimiter.Allow(key, limit, window)
 		}
 		// If no fallback, allow the request (fail open)
-		return true, limit, time.Now().Add(window).Unix()
+		return true, limit, m.clock.Now().Add(window).Unix()
 	}
 
 	return allowed, remaining, resetTime
 }
 
 func (m *RateLimitMiddleware) scheduleRedisRecoveryCheck() {
-	time.Sleep(30 * time.Second)
-
 	if m.redisClient == nil {
 		return
 	}
 
-	// Try to ping Redis
 	ctx := context.Background()
-	if err := m.redisClient.Ping(ctx).Err(); err == nil {
-		m.mu.Lock()
-		m.useLocal = false
-		m.mu.Unlock()
-	} else {
-		// Schedule another check
-		go m.scheduleRedisRecoveryCheck()
+	for {
+		<-m.clock.After(m.recoveryInterval)
+
+		// Try to ping Redis, check again later until it answers
+		if err := m.redisClient.Ping(ctx).Err(); err == nil {
+			m.mu.Lock()
+			m.useLocal = false
+			m.mu.Unlock()
+			return
+		}
 	}
 }
 
@@ -6,24 +6,27 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
 )
 
 // SlidingWindowLimiter implements a sliding window rate limiter using Redis.
 type SlidingWindowLimiter struct {
 	client *redis.Client
+	clock  Clock
 }
 
 // NewSlidingWindowLimiter creates a new sliding window rate limiter.
-func NewSlidingWindowLimiter(client *redis.Client) *SlidingWindowLimiter {
+func NewSlidingWindowLimiter(client *redis.Client, opts ...Option) *SlidingWindowLimiter {
 	return &SlidingWindowLimiter{
 		client: client,
+		clock:  newOptions(opts).clock,
 	}
 }
 
 // Allow checks if a request is allowed under the rate limit.
 // Returns (allowed, remaining, resetTime, error).
 func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, int64, error) {
-	now := time.Now()
+	now := l.clock.Now()
 	windowStart := now.Add(-window)
 	windowEnd := now.Add(window)
 
@@ -34,6 +37,7 @@ func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, limit int,
 		local window_start = tonumber(ARGV[2])
 		local limit = tonumber(ARGV[3])
 		local window_ms = tonumber(ARGV[4])
+		local member = ARGV[5]
 
 		-- Remove old entries outside the window
 		redis.call('ZREMRANGEBYSCORE', key, '-inf', window_start)
@@ -43,7 +47,7 @@ func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, limit int,
 
 		if count < limit then
 			-- Add the current request
-			redis.call('ZADD', key, now, now .. ':' .. math.random())
+			redis.call('ZADD', key, now, member)
 			-- Set expiration
 			redis.call('PEXPIRE', key, window_ms)
 			return {1, limit - count - 1, 0}
@@ -63,8 +67,12 @@ func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, limit int,
 	windowStartMs := windowStart.UnixMilli()
 	windowMs := window.Milliseconds()
 
+	// Scripts may run with a fixed random seed, so the member is made unique
+	// here to keep requests within the same millisecond apart
+	member := strconv.FormatInt(nowMs, 10) + ":" + uuid.NewV4().String()
+
 	result, err := script.Run(ctx, l.client, []string{key},
-		nowMs, windowStartMs, limit, windowMs).Slice()
+		nowMs, windowStartMs, limit, windowMs, member).Slice()
 	if err != nil {
 		return false, 0, 0, err
 	}
@@ -83,18 +91,20 @@ func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, limit int,
 // This is an alternative to sliding window for different use cases.
 type TokenBucketLimiter struct {
 	client *redis.Client
+	clock  Clock
 }
 
 // NewTokenBucketLimiter creates a new token bucket rate limiter.
-func NewTokenBucketLimiter(client *redis.Client) *TokenBucketLimiter {
+func NewTokenBucketLimiter(client *redis.Client, opts ...Option) *TokenBucketLimiter {
 	return &TokenBucketLimiter{
 		client: client,
+		clock:  newOptions(opts).clock,
 	}
 }
 
 // Allow checks if a request is allowed under the token bucket rate limit.
 func (l *TokenBucketLimiter) Allow(ctx context.Context, key string, rate float64, burst int) (bool, int, int64, error) {
-	now := time.Now()
+	now := l.clock.Now()
 
 	// Token bucket Lua script
 	script := redis.NewScript(`
@@ -142,11 +152,12 @@ func (l *TokenBucketLimiter) Allow(ctx context.Context, key string, rate float64
 
 // GetCurrentCount returns the current request count for a key.
 func (l *SlidingWindowLimiter) GetCurrentCount(ctx context.Context, key string, window time.Duration) (int64, error) {
-	now := time.Now()
+	now := l.clock.Now()
 	windowStart := now.Add(-window)
 
+	// The window start is exclusive, matching the entries Allow keeps
 	count, err := l.client.ZCount(ctx, key,
-		strconv.FormatInt(windowStart.UnixMilli(), 10),
+		"("+strconv.FormatInt(windowStart.UnixMilli(), 10),
 		strconv.FormatInt(now.UnixMilli(), 10)).Result()
 	if err != nil {
 		return 0, err
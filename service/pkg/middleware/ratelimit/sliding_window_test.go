@@ -0,0 +1,207 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindowBoundaries(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+	limiter := NewSlidingWindowLimiter(h.client, WithClock(h.clock))
+	const key = "ratelimit:test:window"
+
+	for want := 2; want >= 0; want-- {
+		allowed, remaining, reset, err := limiter.Allow(ctx, key, 3, time.Second)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, want, remaining)
+		assert.Equal(t, h.clock.Now().Add(time.Second).Unix(), reset)
+	}
+	allowed, _, _, err := limiter.Allow(ctx, key, 3, time.Second)
+	require.NoError(t, err)
+	assert.False(t, allowed, "request over the limit")
+
+	// Entries stay in the window until it has fully elapsed
+	h.advance(999 * time.Millisecond)
+	allowed, _, _, err = limiter.Allow(ctx, key, 3, time.Second)
+	require.NoError(t, err)
+	assert.False(t, allowed, "one millisecond before the window ends")
+
+	// The window start is exclusive, so entries exactly one window old are dropped
+	h.advance(time.Millisecond)
+	allowed, remaining, _, err := limiter.Allow(ctx, key, 3, time.Second)
+	require.NoError(t, err)
+	assert.True(t, allowed, "window elapsed")
+	assert.Equal(t, 2, remaining)
+
+	// The window slides per request rather than resetting at fixed boundaries
+	h.advance(500 * time.Millisecond)
+	for range 2 {
+		allowed, _, _, err = limiter.Allow(ctx, key, 3, time.Second)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _, _, err = limiter.Allow(ctx, key, 3, time.Second)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	count, err := limiter.GetCurrentCount(ctx, key, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	h.advance(500 * time.Millisecond)
+	count, err = limiter.GetCurrentCount(ctx, key, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count, "the oldest entry left the window")
+	allowed, _, _, err = limiter.Allow(ctx, key, 3, time.Second)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestSlidingWindowKeyExpires(t *testing.T) {
+	h := newHarness(t)
+	limiter := NewSlidingWindowLimiter(h.client, WithClock(h.clock))
+	const key = "ratelimit:test:expire"
+
+	_, _, _, err := limiter.Allow(context.Background(), key, 3, time.Second)
+	require.NoError(t, err)
+	assert.True(t, h.redis.Exists(key))
+
+	h.advance(time.Second)
+	assert.False(t, h.redis.Exists(key), "idle keys expire after one window")
+}
+
+func TestSlidingWindowConcurrent(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+	limiter := NewSlidingWindowLimiter(h.client, WithClock(h.clock))
+	const key = "ratelimit:test:concurrent"
+
+	// All requests land in the same millisecond and must still be counted apart
+	var allowedCount atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowed, _, _, err := limiter.Allow(ctx, key, 20, time.Minute)
+			assert.NoError(t, err)
+			if allowed {
+				allowedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(20), allowedCount.Load())
+	count, err := limiter.GetCurrentCount(ctx, key, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), count)
+}
+
+func TestLocalLimiterWindowReset(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := NewLocalLimiter(WithClock(clock))
+
+	allowed, _, reset := limiter.Allow("test:key", 1, time.Second)
+	assert.True(t, allowed)
+	assert.Equal(t, clock.Now().Add(time.Second).Unix(), reset)
+
+	clock.Advance(time.Second)
+	allowed, _, _ = limiter.Allow("test:key", 1, time.Second)
+	assert.False(t, allowed, "the fixed window ends after its reset time")
+
+	clock.Advance(time.Nanosecond)
+	allowed, _, _ = limiter.Allow("test:key", 1, time.Second)
+	assert.True(t, allowed)
+}
+
+func newTestContext() *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+	return c
+}
+
+func TestMiddlewareRedisRecovery(t *testing.T) {
+	h := newHarness(t)
+	m := New(h.client, &Config{Enabled: true, FallbackToLocal: true},
+		WithClock(h.clock), WithRecoveryInterval(time.Second))
+	tier := &TierConfig{RequestsPerMinute: 2, Window: time.Minute}
+	const key = "ratelimit:test:recovery"
+
+	allowed, _, _ := m.checkLimit(newTestContext(), key, tier)
+	assert.True(t, allowed)
+	assert.False(t, m.IsUsingLocalFallback())
+
+	// Redis outage switches to the local limiter, which enforces its own count
+	h.redis.Close()
+	allowed, _, _ = m.checkLimit(newTestContext(), key, tier)
+	assert.True(t, allowed)
+	assert.True(t, m.IsUsingLocalFallback())
+	allowed, _, _ = m.checkLimit(newTestContext(), key, tier)
+	assert.True(t, allowed)
+	allowed, _, _ = m.checkLimit(newTestContext(), key, tier)
+	assert.False(t, allowed, "local limit reached")
+
+	// A failed probe keeps the fallback and schedules the next one
+	require.Eventually(t, func() bool { return h.clock.Waiters() == 1 }, time.Second, time.Millisecond)
+	h.clock.Advance(time.Second)
+	require.Eventually(t, func() bool { return h.clock.Waiters() == 1 }, time.Second, time.Millisecond)
+	assert.True(t, m.IsUsingLocalFallback())
+
+	require.NoError(t, h.redis.Restart())
+	require.Eventually(t, func() bool {
+		h.clock.Advance(time.Second)
+		return !m.IsUsingLocalFallback()
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, h.clock.Waiters(), "recovery check stops once Redis answers")
+
+	// Back on Redis, the request made before the outage still counts
+	allowed, remaining, _ := m.checkLimit(newTestContext(), key, tier)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, remaining)
+}
+
+func TestMiddlewareFailOpen(t *testing.T) {
+	h := newHarness(t)
+	m := New(h.client, &Config{Enabled: true}, WithClock(h.clock))
+	tier := &TierConfig{RequestsPerMinute: 1, Window: time.Minute}
+
+	h.redis.Close()
+	allowed, remaining, reset := m.checkLimit(newTestContext(), "ratelimit:test:open", tier)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, h.clock.Now().Add(time.Minute).Unix(), reset)
+	assert.False(t, m.IsUsingLocalFallback())
+	assert.Equal(t, 0, h.clock.Waiters(), "no recovery check without fallback")
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+	limiter := NewTokenBucketLimiter(h.client, WithClock(h.clock))
+	const key = "ratelimit:test:bucket"
+
+	for range 2 {
+		allowed, _, _, err := limiter.Allow(ctx, key, 1, 2)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _, _, err := limiter.Allow(ctx, key, 1, 2)
+	require.NoError(t, err)
+	assert.False(t, allowed, "burst used up")
+
+	h.advance(time.Second)
+	allowed, _, _, err = limiter.Allow(ctx, key, 1, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed, "one token refilled")
+}
@@ -37,6 +37,7 @@ func initRedis(conf *Redis) (*r.Client, error) {
 		DB:       conf.DB,
 	})
 	client.AddHook(newTracingHook(addr))
+	client.AddHook(latencyHook{})
 	if err := client.Ping(context.Background()).Err(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+)
+
+// latencyHook 记录每条命令和每个 pipeline 的耗时，供指标和管理员健康概览使用
+type latencyHook struct{}
+
+func (latencyHook) DialHook(hook r.DialHook) r.DialHook {
+	return hook
+}
+
+func (latencyHook) ProcessHook(hook r.ProcessHook) r.ProcessHook {
+	return func(ctx context.Context, cmd r.Cmder) error {
+		start := time.Now()
+		err := hook(ctx, cmd)
+		otel.GetMetrics().RecordRedisCommand(ctx, cmd.Name(), time.Since(start))
+		return err
+	}
+}
+
+func (latencyHook) ProcessPipelineHook(hook r.ProcessPipelineHook) r.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []r.Cmder) error {
+		start := time.Now()
+		err := hook(ctx, cmds)
+		otel.GetMetrics().RecordRedisCommand(ctx, "pipeline", time.Since(start))
+		return err
+	}
+}
+
+var _ r.Hook = latencyHook{}
@@ -0,0 +1,188 @@
+// Package respcache provides a Redis-backed response cache middleware for
+// idempotent GET endpoints, invalidated by tag when the underlying data changes.
+package respcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/apiversion"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+)
+
+// Tags group cached routes invalidated together
+const (
+	TagStats     = "stats"
+	TagTelemetry = "telemetry"
+	TagDevice    = "device"
+)
+
+const (
+	HeaderCache = "X-Cache"
+
+	resultHit    = "hit"
+	resultMiss   = "miss"
+	resultBypass = "bypass"
+)
+
+// Config holds the response cache configuration.
+type Config struct {
+	// Enabled determines if responses are cached
+	Enabled bool
+
+	// Routes maps a route path, as registered with gin, to its TTL. Routes
+	// not listed are not cached
+	Routes map[string]time.Duration
+}
+
+var conf = &Config{}
+
+// Init sets the response cache configuration
+func Init(config *Config) {
+	if config == nil {
+		config = &Config{}
+	}
+	conf = config
+}
+
+type entry struct {
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// Middleware returns the Gin middleware handler caching the route under tag.
+// Cached entries are scoped to the caller so permission checks of the
+// handler still apply
+func Middleware(tag string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := redis.GetClient()
+		ttl, ok := conf.Routes[c.FullPath()]
+		if !conf.Enabled || !ok || ttl <= 0 || client == nil || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key, err := cacheKey(ctx, client, tag, c)
+		if err != nil {
+			logger.Warnf(ctx, "response cache key fail route: %s, err: %+v", c.FullPath(), err)
+			record(ctx, c.FullPath(), resultBypass)
+			c.Next()
+			return
+		}
+
+		if data, err := client.Get(ctx, key).Bytes(); err == nil {
+			var cached entry
+			if err := json.Unmarshal(data, &cached); err == nil {
+				record(ctx, c.FullPath(), resultHit)
+				c.Header(HeaderCache, "HIT")
+				c.Data(http.StatusOK, cached.ContentType, cached.Body)
+				c.Abort()
+				return
+			}
+		} else if err != r.Nil {
+			logger.Warnf(ctx, "response cache get fail route: %s, err: %+v", c.FullPath(), err)
+		}
+
+		record(ctx, c.FullPath(), resultMiss)
+		c.Header(HeaderCache, "MISS")
+		w := &writer{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.Status() != http.StatusOK || !succeeded(w.buf.Bytes()) {
+			return
+		}
+		data, _ := json.Marshal(&entry{ContentType: w.Header().Get("Content-Type"), Body: w.buf.Bytes()})
+		if err := client.Set(ctx, key, data, ttl).Err(); err != nil {
+			logger.Warnf(ctx, "response cache set fail route: %s, err: %+v", c.FullPath(), err)
+		}
+	}
+}
+
+// Invalidate drops the cached responses of the tags. Entries are versioned by
+// tag, so bumping the version orphans them until their TTL expires
+func Invalidate(ctx context.Context, tags ...string) {
+	client := redis.GetClient()
+	if client == nil {
+		return
+	}
+	for _, tag := range tags {
+		if err := client.Incr(ctx, versionKey(tag)).Err(); err != nil {
+			logger.Warnf(ctx, "response cache invalidate fail tag: %s, err: %+v", tag, err)
+		}
+	}
+}
+
+func versionKey(tag string) string {
+	return "respcache:version:" + tag
+}
+
+// cacheKey 由路由、查询参数、路径参数、调用方身份和接口版本组成
+func cacheKey(ctx context.Context, client *r.Client, tag string, c *gin.Context) (string, error) {
+	version, err := client.Get(ctx, versionKey(tag)).Int64()
+	if err != nil && err != r.Nil {
+		return "", err
+	}
+
+	scope := ""
+	if user := auth.GetCurrentUser(c); user != nil {
+		scope = "user:" + user.ID
+	} else if labUser := auth.GetLabUser(c); labUser != nil {
+		scope = fmt.Sprintf("lab:%d", labUser.LabID)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(c.Request.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Request.URL.Query().Encode()))
+	h.Write([]byte{0})
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write([]byte{byte(apiversion.Get(c))})
+	return fmt.Sprintf("respcache:%s:%d:%s", tag, version, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// succeeded 业务错误同样以 200 返回，只缓存成功的响应
+func succeeded(body []byte) bool {
+	var resp struct {
+		Code code.ErrCode `json:"code"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false
+	}
+	return resp.Code == code.Success
+}
+
+func record(ctx context.Context, route, result string) {
+	otel.GetMetrics().RecordResponseCache(ctx, route, result)
+}
+
+// writer 缓存响应体的同时写出
+type writer struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,38 @@
+package respcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSucceeded(t *testing.T) {
+	assert.True(t, succeeded([]byte(`{"code":0,"data":{"total":3}}`)))
+	assert.False(t, succeeded([]byte(`{"code":2,"error":{"msg":"no permission"}}`)))
+	assert.False(t, succeeded([]byte(`not json`)))
+}
+
+func TestMiddlewareWithoutRedis(t *testing.T) {
+	Init(&Config{Enabled: true, Routes: map[string]time.Duration{"/stats": time.Minute}})
+	defer Init(nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	calls := 0
+	router.GET("/stats", Middleware(TagStats), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"code": 0})
+	})
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(HeaderCache))
+	}
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,51 @@
+// Package storage stores uploaded files on local disk or an S3 compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/scienceol/studio/service/internal/config"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage 对象存储，key 使用 / 分隔
+type Storage interface {
+	// Put 写入对象，size 为 -1 时表示长度未知
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	defaultStorage Storage
+	defaultOnce    sync.Once
+)
+
+// Default 按配置创建的全局存储
+func Default() Storage {
+	defaultOnce.Do(func() {
+		conf := config.Global().Storage
+		if conf.Backend == BackendS3 {
+			defaultStorage = NewS3(&S3Config{
+				Endpoint:  conf.Addr,
+				Bucket:    conf.Bucket,
+				Region:    conf.Region,
+				AccessKey: conf.AccessKey,
+				SecretKey: conf.SecretKey,
+			})
+			return
+		}
+		defaultStorage = NewLocal(conf.LocalDir)
+	})
+	return defaultStorage
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	mOtel "github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/sethvargo/go-envconfig"
 	"go.opentelemetry.io/contrib/instrumentation/host"
 	"go.opentelemetry.io/contrib/instrumentation/runtime"
@@ -299,7 +300,7 @@ func (c *Config) initMetric(metricsExporter metric.Exporter, stop func()) error
 	if err := host.Start(host.WithMeterProvider(meterProvider)); err != nil {
 		return err
 	}
-	// 默认集成Golang runtime指标
+	// 默认集成Golang runtime指标，包括 GC 次数和暂停时长（process.runtime.go.gc.*）、goroutine 数量和内存统计
 	err = runtime.Start(runtime.WithMeterProvider(meterProvider), runtime.WithMinimumReadMemStatsInterval(time.Second))
 	c.stop = append(c.stop, func() {
 		_ = meterProvider.Shutdown(context.Background())
@@ -310,11 +311,15 @@ func (c *Config) initMetric(metricsExporter metric.Exporter, stop func()) error
 
 // 初始化Traces，默认全量上传
 func (c *Config) initTracer(traceExporter oteltrace.SpanExporter, stop func(), config *Config) error {
+	// 不导出 trace 时也传递 trace context 和 baggage，下游服务仍能关联请求
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 	if traceExporter == nil {
 		return nil
 	}
 	// 建议使用AlwaysSample全量上传Trace数据，若您的数据太多，可以使用sdktrace.ProbabilitySampler进行采样上传
 	tp := oteltrace.NewTracerProvider(
+		// 先把 baggage 中的用户、实验室和组织写入 span，再交给 batcher 导出
+		oteltrace.WithSpanProcessor(mOtel.BaggageSpanProcessor{}),
 		oteltrace.WithBatcher(
 			traceExporter,
 		),
@@ -322,7 +327,6 @@ func (c *Config) initTracer(traceExporter oteltrace.SpanExporter, stop func(), c
 		oteltrace.WithResource(c.Resource),
 	)
 	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 	c.stop = append(c.stop, func() {
 		_ = tp.Shutdown(context.Background())
 		stop()
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// AlertStatus represents the lifecycle state of an alert
+type AlertStatus string
+
+const (
+	AlertFiring   AlertStatus = "firing"
+	AlertAcked    AlertStatus = "acked"
+	AlertResolved AlertStatus = "resolved"
+)
+
+// Alert is raised when a device rule matches and resolved once it no longer
+// does or by hand. Firing alerts that stay unacknowledged are escalated
+type Alert struct {
+	BaseModel
+	LabID       int64         `gorm:"type:bigint;not null;index:idx_alert_lab" json:"lab_id"`
+	RuleID      int64         `gorm:"type:bigint;not null;index:idx_alert_rule_device,priority:1" json:"rule_id"`
+	RuleName    string        `gorm:"type:varchar(255);not null" json:"rule_name"`
+	DeviceID    int64         `gorm:"type:bigint;not null" json:"device_id"`
+	DeviceUUID  uuid.UUID     `gorm:"type:uuid;not null;index:idx_alert_rule_device,priority:2" json:"device_uuid"`
+	Severity    AlertSeverity `gorm:"type:varchar(20);not null" json:"severity"`
+	Status      AlertStatus   `gorm:"type:varchar(20);not null;index:idx_alert_status" json:"status"`
+	Value       float64       `gorm:"not null" json:"value"`
+	Threshold   float64       `gorm:"not null" json:"threshold"`
+	Message     string        `gorm:"type:text" json:"message"`
+	Silenced    bool          `gorm:"not null;default:false" json:"silenced"` // 触发时处于静默窗口，不发送通知也不升级
+	AssigneeID  string        `gorm:"type:varchar(120);index:idx_alert_assignee" json:"assignee_id"`
+	FiredAt     time.Time     `gorm:"not null;index:idx_alert_fired" json:"fired_at"`
+	AckedBy     string        `gorm:"type:varchar(120)" json:"acked_by"`
+	AckedAt     *time.Time    `json:"acked_at"`
+	EscalatedAt *time.Time    `json:"escalated_at"`
+	ResolvedBy  string        `gorm:"type:varchar(120)" json:"resolved_by"` // 手动恢复的用户，自动恢复时为空
+	ResolvedAt  *time.Time    `json:"resolved_at"`
+}
+
+func (*Alert) TableName() string {
+	return "alert"
+}
+
+// AlertSilence suppresses notifications of alerts fired for a device, or for
+// every device of the lab when DeviceUUID is empty, during a time window
+type AlertSilence struct {
+	BaseModel
+	LabID      int64      `gorm:"type:bigint;not null;index:idx_alert_silence_lab_end,priority:1" json:"lab_id"`
+	DeviceUUID *uuid.UUID `gorm:"type:uuid" json:"device_uuid"`
+	StartsAt   time.Time  `gorm:"not null" json:"starts_at"`
+	EndsAt     time.Time  `gorm:"not null;index:idx_alert_silence_lab_end,priority:2" json:"ends_at"`
+	Reason     string     `gorm:"type:text" json:"reason"`
+	CreatedBy  string     `gorm:"type:varchar(120);not null" json:"created_by"`
+}
+
+func (*AlertSilence) TableName() string {
+	return "alert_silence"
+}
+
+// AlertQuery represents filters of the alert feed
+type AlertQuery struct {
+	LabID      int64
+	Status     *AlertStatus
+	Severity   *AlertSeverity
+	DeviceUUID *uuid.UUID
+	AssigneeID string
+	StartTime  *time.Time
+	EndTime    *time.Time
+	Page       int
+	PageSize   int
+}
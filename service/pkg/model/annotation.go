@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"gorm.io/datatypes"
+)
+
+// ExecutionAnnotation is a note on a workflow execution; replies point to the
+// annotation they answer and form a thread
+type ExecutionAnnotation struct {
+	BaseModel
+	LabID         int64                        `gorm:"type:bigint;not null" json:"lab_id"`
+	ExecutionID   int64                        `gorm:"type:bigint;not null;index:idx_annotation_execution" json:"execution_id"`
+	ExecutionUUID uuid.UUID                    `gorm:"type:uuid;not null" json:"execution_uuid"`
+	ParentID      *int64                       `gorm:"type:bigint;index:idx_annotation_parent" json:"parent_id"`
+	ParentUUID    *uuid.UUID                   `gorm:"type:uuid" json:"parent_uuid"`
+	AuthorID      string                       `gorm:"type:varchar(120);not null" json:"author_id"`
+	Content       string                       `gorm:"type:text;not null" json:"content"` // 删除后清空
+	Mentions      datatypes.JSONType[[]string] `gorm:"type:jsonb" json:"mentions" swaggertype:"array,string"`
+	EditedAt      *time.Time                   `json:"edited_at"`
+	DeletedBy     string                       `gorm:"type:varchar(120)" json:"deleted_by"`
+	DeletedAt     *time.Time                   `json:"deleted_at"` // 删除后保留在讨论串中，内容不再返回
+	Replies       []*ExecutionAnnotation       `gorm:"-" json:"replies,omitempty"`
+}
+
+func (*ExecutionAnnotation) TableName() string {
+	return "execution_annotation"
+}
+
+// ExecutionAnnotationRevision keeps the content of an annotation before each edit
+type ExecutionAnnotationRevision struct {
+	BaseModel
+	AnnotationID int64                        `gorm:"type:bigint;not null;index:idx_annotation_revision" json:"annotation_id"`
+	Content      string                       `gorm:"type:text;not null" json:"content"`
+	Mentions     datatypes.JSONType[[]string] `gorm:"type:jsonb" json:"mentions" swaggertype:"array,string"`
+	EditedBy     string                       `gorm:"type:varchar(120);not null" json:"edited_by"`
+}
+
+func (*ExecutionAnnotationRevision) TableName() string {
+	return "execution_annotation_revision"
+}
+
+// AnnotationThreads nests replies under the annotations they answer; the input
+// is ordered by creation time and replies to missing parents become top level
+func AnnotationThreads(datas []*ExecutionAnnotation) []*ExecutionAnnotation {
+	byID := make(map[int64]*ExecutionAnnotation, len(datas))
+	for _, d := range datas {
+		d.Replies = nil
+		byID[d.ID] = d
+	}
+
+	roots := make([]*ExecutionAnnotation, 0, len(datas))
+	for _, d := range datas {
+		if d.ParentID != nil {
+			if parent, ok := byID[*d.ParentID]; ok && parent != d {
+				parent.Replies = append(parent.Replies, d)
+				continue
+			}
+		}
+		roots = append(roots, d)
+	}
+	return roots
+}
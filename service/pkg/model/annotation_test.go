@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotationThreads(t *testing.T) {
+	id := func(v int64) *int64 { return &v }
+	datas := []*ExecutionAnnotation{
+		{BaseModel: BaseModel{ID: 1}},
+		{BaseModel: BaseModel{ID: 2}, ParentID: id(1)},
+		{BaseModel: BaseModel{ID: 3}},
+		{BaseModel: BaseModel{ID: 4}, ParentID: id(2)},
+		{BaseModel: BaseModel{ID: 5}, ParentID: id(1)},
+		// 父批注不存在时作为顶层批注
+		{BaseModel: BaseModel{ID: 6}, ParentID: id(99)},
+	}
+
+	roots := AnnotationThreads(datas)
+	if assert.Len(t, roots, 3) {
+		assert.Equal(t, []int64{1, 3, 6}, []int64{roots[0].ID, roots[1].ID, roots[2].ID})
+		if assert.Len(t, roots[0].Replies, 2) {
+			assert.Equal(t, int64(2), roots[0].Replies[0].ID)
+			assert.Equal(t, int64(5), roots[0].Replies[1].ID)
+			if assert.Len(t, roots[0].Replies[0].Replies, 1) {
+				assert.Equal(t, int64(4), roots[0].Replies[0].Replies[0].ID)
+			}
+		}
+		assert.Empty(t, roots[1].Replies)
+	}
+}
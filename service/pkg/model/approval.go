@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// ApprovalStatus represents the decision state of an approval request
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending  ApprovalStatus = "pending"
+	ApprovalStatusApproved ApprovalStatus = "approved"
+	ApprovalStatusRejected ApprovalStatus = "rejected"
+)
+
+// ApprovalRequest gates a single workflow step until a human approves or rejects it
+type ApprovalRequest struct {
+	BaseModel
+	LabID             int64          `gorm:"type:bigint;not null;index:idx_ar_lab_status,priority:1" json:"lab_id"`
+	ActionExecutionID int64          `gorm:"type:bigint;not null;index:idx_ar_action" json:"action_execution_id"`
+	TaskUUID          uuid.UUID      `gorm:"type:uuid;not null;index:idx_ar_task" json:"task_uuid"`
+	NodeUUID          uuid.UUID      `gorm:"type:uuid;not null" json:"node_uuid"`
+	StepName          string         `gorm:"type:varchar(255);not null" json:"step_name"`
+	RequestedBy       string         `gorm:"type:varchar(120);not null" json:"requested_by"`
+	Status            ApprovalStatus `gorm:"type:varchar(50);not null;default:'pending';index:idx_ar_lab_status,priority:2" json:"status"`
+	ReviewerID        *string        `gorm:"type:varchar(120)" json:"reviewer_id"`
+	Comment           *string        `gorm:"type:text" json:"comment"`
+	ReviewedAt        *time.Time     `json:"reviewed_at"`
+}
+
+func (*ApprovalRequest) TableName() string {
+	return "approval_request"
+}
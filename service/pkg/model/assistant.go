@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+const (
+	AssistantStatusSuccess = "success"
+	AssistantStatusFailed  = "failed"
+
+	AssistantKindDiagnose = "diagnose" // 诊断执行失败原因
+	AssistantKindQuery    = "query"    // 解析历史查询问题
+)
+
+// AssistantAudit records every prompt sent to the assistant model provider
+// and its answer, failed calls included
+type AssistantAudit struct {
+	BaseModel
+	Kind             string    `gorm:"type:varchar(20);not null;default:'diagnose'" json:"kind"` // diagnose | query
+	UserID           string    `gorm:"type:varchar(120);not null;index:idx_aa_user" json:"user_id"`
+	LabID            int64     `gorm:"type:bigint;not null;index:idx_aa_lab" json:"lab_id"`
+	ExecutionID      int64     `gorm:"type:bigint;not null;default:0" json:"-"`
+	ExecutionUUID    uuid.UUID `gorm:"type:uuid" json:"execution_uuid"` // 历史查询时为空
+	Provider         string    `gorm:"type:varchar(32);not null" json:"provider"`
+	Model            string    `gorm:"type:varchar(120);not null" json:"model"`
+	Prompt           string    `gorm:"type:text;not null" json:"prompt"`
+	Response         string    `gorm:"type:text" json:"response"`
+	Status           string    `gorm:"type:varchar(20);not null" json:"status"` // success | failed
+	Error            string    `gorm:"type:text" json:"error"`
+	LatencyMs        int64     `gorm:"type:bigint" json:"latency_ms"`
+	PromptTokens     int       `gorm:"type:int" json:"prompt_tokens"`
+	CompletionTokens int       `gorm:"type:int" json:"completion_tokens"`
+}
+
+func (*AssistantAudit) TableName() string {
+	return "assistant_audit"
+}
+
+// Diagnosis is the structured answer of the assistant for a failed execution
+type Diagnosis struct {
+	ExecutionUUID uuid.UUID `json:"execution_uuid"`
+	Summary       string    `json:"summary"`
+	RootCause     string    `json:"root_cause"`
+	FailedStep    string    `json:"failed_step"`
+	Category      string    `json:"category"`
+	Confidence    string    `json:"confidence"` // high | medium | low
+	Remediation   []string  `json:"remediation"`
+}
@@ -15,6 +15,10 @@ type BaseModel struct {
 }
 
 func (b *BaseModel) BeforeCreate(*gorm.DB) error {
+	// 由应用生成按配置版本的 UUID，数据库默认值只用于直接写入的 SQL
+	if b.UUID.IsNil() {
+		b.UUID = uuid.New()
+	}
 	b.CreatedAt = time.Now()
 	b.UpdatedAt = time.Now()
 	return nil
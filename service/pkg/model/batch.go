@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// WorkflowBatch groups the child executions created from one input matrix
+type WorkflowBatch struct {
+	BaseModel
+	LabID        int64           `gorm:"type:bigint;not null;index:idx_wb_lab" json:"lab_id"`
+	WorkflowID   int64           `gorm:"type:bigint;not null;index:idx_wb_workflow" json:"workflow_id"`
+	WorkflowUUID uuid.UUID       `gorm:"type:uuid;not null" json:"workflow_uuid"`
+	UserID       string          `gorm:"type:varchar(120);not null;index:idx_wb_user" json:"user_id"`
+	Name         string          `gorm:"type:varchar(255);not null;default:''" json:"name"`
+	Total        int             `gorm:"type:int;not null;default:0" json:"total"`
+	Status       ExecutionStatus `gorm:"type:varchar(50);not null;default:'running'" json:"status"` // 仅记录 running/cancelled，其余状态由子任务汇总
+	CancelledAt  *time.Time      `json:"cancelled_at"`
+}
+
+func (*WorkflowBatch) TableName() string {
+	return "workflow_batch"
+}
@@ -0,0 +1,17 @@
+package model
+
+// ReplicationSlot is the state of a postgres logical replication slot
+type ReplicationSlot struct {
+	Name              string
+	Plugin            string
+	Active            bool   // 有复制连接正在使用该槽
+	ConfirmedFlushLSN string // 已确认的位置，之前的 WAL 可以回收
+	LagBytes          int64  // 当前 WAL 位置与已确认位置的差距
+}
+
+// DecodedChange is a row of the logical decoding output of a slot
+type DecodedChange struct {
+	LSN  string
+	XID  int64
+	Data string
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"gorm.io/datatypes"
+)
+
+// DeviceCommandStatus represents the delivery state of a device command
+type DeviceCommandStatus string
+
+const (
+	DeviceCommandQueued    DeviceCommandStatus = "queued"    // 等待 edge 拉取
+	DeviceCommandDelivered DeviceCommandStatus = "delivered" // 已下发，等待确认
+	DeviceCommandAcked     DeviceCommandStatus = "acked"     // edge 已确认，等待结果
+	DeviceCommandSucceeded DeviceCommandStatus = "succeeded"
+	DeviceCommandFailed    DeviceCommandStatus = "failed"
+	DeviceCommandTimeout   DeviceCommandStatus = "timeout" // 重试耗尽或结果超时
+)
+
+// DeviceCommand is an asynchronous command queued for a device and fetched by its edge agent
+type DeviceCommand struct {
+	BaseModel
+	LabID             int64               `gorm:"type:bigint;not null;index:idx_dc_lab_status,priority:1" json:"lab_id"`
+	DeviceID          int64               `gorm:"type:bigint;not null" json:"device_id"`
+	DeviceUUID        uuid.UUID           `gorm:"type:uuid;not null" json:"device_uuid"`
+	DeviceName        string              `gorm:"type:varchar(255);not null" json:"device_name"`
+	Action            string              `gorm:"type:varchar(255);not null" json:"action"`
+	ActionType        string              `gorm:"type:varchar(100);not null" json:"action_type"`
+	Param             datatypes.JSON      `gorm:"type:jsonb" json:"param"`
+	Status            DeviceCommandStatus `gorm:"type:varchar(20);not null;index:idx_dc_lab_status,priority:2" json:"status"`
+	Attempts          int                 `gorm:"type:int;not null;default:0" json:"attempts"`
+	MaxAttempts       int                 `gorm:"type:int;not null;default:1" json:"max_attempts"`
+	AckTimeoutSec     int                 `gorm:"type:int;not null" json:"ack_timeout_sec"`
+	ResultTimeoutSec  int                 `gorm:"type:int;not null" json:"result_timeout_sec"`
+	DeliveredAt       *time.Time          `json:"delivered_at"`
+	AckDeadline       *time.Time          `gorm:"index:idx_dc_ack_deadline" json:"ack_deadline"`
+	AckedAt           *time.Time          `json:"acked_at"`
+	ResultDeadline    *time.Time          `gorm:"index:idx_dc_result_deadline" json:"result_deadline"`
+	FinishedAt        *time.Time          `json:"finished_at"`
+	Result            datatypes.JSON      `gorm:"type:jsonb" json:"result"`
+	Error             *string             `gorm:"type:text" json:"error"`
+	ActionExecutionID int64               `gorm:"type:bigint;not null" json:"action_execution_id"`
+	GroupCommandID    *int64              `gorm:"type:bigint;index:idx_dc_group" json:"group_command_id,omitempty"` // 设备组指令扇出的指令
+	CreatedBy         string              `gorm:"type:varchar(120);not null" json:"created_by"`
+}
+
+func (*DeviceCommand) TableName() string {
+	return "device_command"
+}
+
+// Finished reports whether the command reached a terminal state
+func (c *DeviceCommand) Finished() bool {
+	switch c.Status {
+	case DeviceCommandSucceeded, DeviceCommandFailed, DeviceCommandTimeout:
+		return true
+	}
+	return false
+}
+
+// GroupCommandSkip is a device of the group the command was not sent to
+type GroupCommandSkip struct {
+	DeviceName string `json:"device_name"`
+	Error      string `json:"error"`
+}
+
+// DeviceGroupCommand fans one command out to every device below a topology
+// node through the command queue, one DeviceCommand per device
+type DeviceGroupCommand struct {
+	BaseModel
+	LabID      int64                                 `gorm:"type:bigint;not null;index:idx_dgc_lab" json:"lab_id"`
+	NodeID     int64                                 `gorm:"type:bigint;not null" json:"node_id"`
+	NodeName   string                                `gorm:"type:varchar(255);not null" json:"node_name"`
+	Action     string                                `gorm:"type:varchar(255);not null" json:"action"`
+	ActionType string                                `gorm:"type:varchar(100);not null" json:"action_type"`
+	Param      datatypes.JSON                        `gorm:"type:jsonb" json:"param"`
+	Total      int                                   `gorm:"type:int;not null" json:"total"`                  // 下发的设备数
+	Skipped    datatypes.JSONSlice[GroupCommandSkip] `gorm:"type:jsonb;not null;default:'[]'" json:"skipped"` // 不支持该动作而跳过的设备
+	CreatedBy  string                                `gorm:"type:varchar(120);not null" json:"created_by"`
+}
+
+func (*DeviceGroupCommand) TableName() string {
+	return "device_group_command"
+}
+
+// GroupCommandStatus is the aggregated state of the commands of a group command
+type GroupCommandStatus string
+
+const (
+	GroupCommandRunning   GroupCommandStatus = "running"   // 还有指令未结束
+	GroupCommandSucceeded GroupCommandStatus = "succeeded" // 全部成功
+	GroupCommandPartial   GroupCommandStatus = "partial"   // 部分失败或超时
+	GroupCommandFailed    GroupCommandStatus = "failed"    // 全部失败或超时
+)
+
+// GroupCommandSummary aggregates the results of the commands of a group command
+type GroupCommandSummary struct {
+	Status    GroupCommandStatus `json:"status"`
+	Total     int64              `json:"total"`
+	Pending   int64              `json:"pending"` // 排队、已下发或已确认
+	Succeeded int64              `json:"succeeded"`
+	Failed    int64              `json:"failed"`
+	Timeout   int64              `json:"timeout"`
+}
+
+// SummarizeGroupCommand aggregates the command counts by status
+func SummarizeGroupCommand(counts map[DeviceCommandStatus]int64) *GroupCommandSummary {
+	summary := &GroupCommandSummary{}
+	for status, n := range counts {
+		summary.Total += n
+		switch status {
+		case DeviceCommandSucceeded:
+			summary.Succeeded += n
+		case DeviceCommandFailed:
+			summary.Failed += n
+		case DeviceCommandTimeout:
+			summary.Timeout += n
+		default:
+			summary.Pending += n
+		}
+	}
+
+	switch {
+	case summary.Pending > 0:
+		summary.Status = GroupCommandRunning
+	case summary.Succeeded == summary.Total:
+		summary.Status = GroupCommandSucceeded
+	case summary.Succeeded == 0:
+		summary.Status = GroupCommandFailed
+	default:
+		summary.Status = GroupCommandPartial
+	}
+	return summary
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeGroupCommand(t *testing.T) {
+	summary := SummarizeGroupCommand(map[DeviceCommandStatus]int64{
+		DeviceCommandQueued:    1,
+		DeviceCommandAcked:     2,
+		DeviceCommandSucceeded: 3,
+		DeviceCommandTimeout:   1,
+	})
+	assert.Equal(t, GroupCommandRunning, summary.Status)
+	assert.Equal(t, int64(7), summary.Total)
+	assert.Equal(t, int64(3), summary.Pending)
+	assert.Equal(t, int64(3), summary.Succeeded)
+	assert.Equal(t, int64(1), summary.Timeout)
+
+	summary = SummarizeGroupCommand(map[DeviceCommandStatus]int64{DeviceCommandSucceeded: 4})
+	assert.Equal(t, GroupCommandSucceeded, summary.Status)
+
+	summary = SummarizeGroupCommand(map[DeviceCommandStatus]int64{
+		DeviceCommandSucceeded: 2,
+		DeviceCommandFailed:    1,
+	})
+	assert.Equal(t, GroupCommandPartial, summary.Status)
+
+	summary = SummarizeGroupCommand(map[DeviceCommandStatus]int64{
+		DeviceCommandFailed:  2,
+		DeviceCommandTimeout: 1,
+	})
+	assert.Equal(t, GroupCommandFailed, summary.Status)
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/scienceol/studio/service/internal/config"
+	"gorm.io/datatypes"
+	"gorm.io/gorm/schema"
+)
+
+// CompressedJSONSerializer 是 jsonb 列的 gorm 序列化器名称。超过阈值的内容以
+// zstd 压缩后包在 {"_zstd": "<base64>"} 中写入，读取时透明解压
+const CompressedJSONSerializer = "zstdjson"
+
+// CompressedMarker 压缩信封的字段名，也用于 SQL 中识别已压缩的行
+const CompressedMarker = "_zstd"
+
+// compressedEnvelope 压缩后写入数据库的 JSON
+type compressedEnvelope struct {
+	Zstd []byte `json:"_zstd"`
+}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func init() {
+	schema.RegisterSerializer(CompressedJSONSerializer, compressedJSON{})
+}
+
+// CompressThreshold 超过该字节数的负载压缩存储，0 表示不压缩
+func CompressThreshold() int {
+	return config.GetStudioConfig().ZstdJSON.ThresholdBytes
+}
+
+// CompressJSON wraps data in a compressed envelope when it is over threshold
+// and compression makes it smaller, otherwise data is returned unchanged
+func CompressJSON(data []byte, threshold int) ([]byte, error) {
+	if threshold <= 0 || len(data) <= threshold || IsCompressedJSON(data) {
+		return data, nil
+	}
+	ret, err := json.Marshal(&compressedEnvelope{Zstd: zstdEncoder.EncodeAll(data, nil)})
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) >= len(data) {
+		return data, nil
+	}
+	return ret, nil
+}
+
+// DecompressJSON returns the original JSON of a compressed envelope and any
+// other data unchanged
+func DecompressJSON(data []byte) ([]byte, error) {
+	if !IsCompressedJSON(data) {
+		return data, nil
+	}
+	envelope := &compressedEnvelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return nil, err
+	}
+	ret, err := zstdDecoder.DecodeAll(envelope.Zstd, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decompress json: %w", err)
+	}
+	return ret, nil
+}
+
+// IsCompressedJSON 是否为压缩信封，jsonb 读出时冒号后带空格
+func IsCompressedJSON(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if !bytes.HasPrefix(data, []byte(`{"`+CompressedMarker+`"`)) {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil || len(fields) != 1 {
+		return false
+	}
+	_, ok := fields[CompressedMarker]
+	return ok
+}
+
+// compressedJSON serializes datatypes.JSON fields, compressing on write with
+// the configured threshold and decompressing on read. Rows written before
+// compression was enabled are read as they are
+type compressedJSON struct{}
+
+func (compressedJSON) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var data []byte
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		data = bytes.Clone(v)
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported json value %T of %s", dbValue, field.Name)
+	}
+	data, err := DecompressJSON(data)
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).Set(reflect.ValueOf(datatypes.JSON(data)))
+	return nil
+}
+
+func (compressedJSON) Value(_ context.Context, _ *schema.Field, _ reflect.Value, fieldValue any) (any, error) {
+	data, ok := fieldValue.(datatypes.JSON)
+	if !ok {
+		return nil, fmt.Errorf("unsupported json field %T", fieldValue)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	data, err := CompressJSON(data, CompressThreshold())
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
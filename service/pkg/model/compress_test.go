@@ -0,0 +1,72 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressJSON(t *testing.T) {
+	data := []byte(`{"points":[` + strings.Repeat(`{"x":1.5,"y":2.5},`, 500) + `{"x":1,"y":2}]}`)
+
+	compressed, err := CompressJSON(data, 1024)
+	require.NoError(t, err)
+	assert.True(t, IsCompressedJSON(compressed))
+	assert.Less(t, len(compressed)*5, len(data))
+
+	// 已压缩的内容不重复压缩
+	again, err := CompressJSON(compressed, 16)
+	require.NoError(t, err)
+	assert.Equal(t, compressed, again)
+
+	ret, err := DecompressJSON(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, data, ret)
+
+	for _, threshold := range []int{0, len(data)} {
+		ret, err := CompressJSON(data, threshold)
+		require.NoError(t, err)
+		assert.Equal(t, data, ret)
+	}
+}
+
+func TestCompressJSONIncompressible(t *testing.T) {
+	random := make([]byte, 4096)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+	data := []byte(`"` + base64.StdEncoding.EncodeToString(random) + `"`)
+
+	ret, err := CompressJSON(data, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, data, ret)
+}
+
+func TestIsCompressedJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{`{"_zstd":"KLUv/QQAAQAAew=="}`, true},
+		{`{"_zstd": "KLUv/QQAAQAAew=="}`, true}, // jsonb 读出的格式
+		{`{"_zstd":"a","other":1}`, false},
+		{`{"other":{"_zstd":"a"}}`, false},
+		{`{"_zstd"`, false},
+		{`[1,2]`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsCompressedJSON([]byte(tt.data)), tt.data)
+	}
+
+	// 不是压缩信封的内容原样返回
+	ret, err := DecompressJSON([]byte(`{"_zstd":"a","other":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"_zstd":"a","other":1}`, string(ret))
+
+	_, err = DecompressJSON([]byte(`{"_zstd":"bm90IHpzdGQ="}`))
+	assert.Error(t, err)
+}
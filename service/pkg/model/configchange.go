@@ -0,0 +1,21 @@
+package model
+
+// 配置和功能开关变更的审计动作
+const (
+	AuditFeatureChange    AuditAction = "config.feature"    // 启动时功能开关与上次启动不同
+	AuditChaosChange      AuditAction = "config.chaos"      // 修改故障注入配置
+	AuditMaintenanceStart AuditAction = "maintenance.start" // 开始或更新维护
+	AuditMaintenanceEnd   AuditAction = "maintenance.end"
+	AuditJobPause         AuditAction = "job.pause" // 暂停后台任务调度
+	AuditJobResume        AuditAction = "job.resume"
+)
+
+// ConfigChangeActions are the audit actions listed as configuration changes
+var ConfigChangeActions = []AuditAction{
+	AuditFeatureChange,
+	AuditChaosChange,
+	AuditMaintenanceStart,
+	AuditMaintenanceEnd,
+	AuditJobPause,
+	AuditJobResume,
+}
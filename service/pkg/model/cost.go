@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// CostModel is the pricing of one lab; labs without one use the configured defaults
+type CostModel struct {
+	BaseModel
+	LabID              int64                                  `gorm:"type:bigint;not null;uniqueIndex:idx_cost_model_lab" json:"lab_id"`
+	Currency           string                                 `gorm:"type:varchar(8);not null" json:"currency"`
+	DeviceHourRate     float64                                `gorm:"not null;default:0" json:"device_hour_rate"`               // 未单独定价设备的机时单价
+	DeviceHourRates    datatypes.JSONType[map[string]float64] `gorm:"type:jsonb" json:"device_hour_rates" swaggertype:"object"` // 按设备名称定价的机时单价
+	ActionTypeRates    datatypes.JSONType[map[string]float64] `gorm:"type:jsonb" json:"action_type_rates" swaggertype:"object"` // 按动作类型定价的单次执行价格
+	StorageGBMonthRate float64                                `gorm:"not null;default:0" json:"storage_gb_month_rate"`
+	UpdatedBy          string                                 `gorm:"type:varchar(120)" json:"updated_by"`
+}
+
+func (*CostModel) TableName() string {
+	return "cost_model"
+}
+
+// DeviceRate returns the device-hour price of a device
+func (m *CostModel) DeviceRate(deviceName string) float64 {
+	if rate, ok := m.DeviceHourRates.Data()[deviceName]; ok {
+		return rate
+	}
+	return m.DeviceHourRate
+}
+
+// ActionRate returns the per-execution price of an action type, 0 when not priced
+func (m *CostModel) ActionRate(actionType string) float64 {
+	return m.ActionTypeRates.Data()[actionType]
+}
+
+// CostCategory classifies a cost record
+type CostCategory string
+
+const (
+	CostCategoryDeviceHour CostCategory = "device_hour" // item 为设备名称
+	CostCategoryAction     CostCategory = "action"      // item 为动作类型
+	CostCategoryStorage    CostCategory = "storage"     // item 为存储类型
+)
+
+// Cost record units
+const (
+	CostUnitHour   = "hour"
+	CostUnitCount  = "count"
+	CostUnitGBDay  = "gb_day"
+	CostItemResult = "result_file"
+)
+
+// CostRecord is the cost of one item of a lab on one day, computed from history records
+type CostRecord struct {
+	BaseModel
+	LabID     int64        `gorm:"type:bigint;not null;uniqueIndex:idx_cost_record,priority:1" json:"lab_id"`
+	Day       time.Time    `gorm:"type:date;not null;uniqueIndex:idx_cost_record,priority:2;index:idx_cost_record_day" json:"day"`
+	Category  CostCategory `gorm:"type:varchar(20);not null;uniqueIndex:idx_cost_record,priority:3" json:"category"`
+	Item      string       `gorm:"type:varchar(255);not null;uniqueIndex:idx_cost_record,priority:4" json:"item"`
+	Quantity  float64      `gorm:"not null" json:"quantity"`
+	Unit      string       `gorm:"type:varchar(20);not null" json:"unit"`
+	UnitPrice float64      `gorm:"not null" json:"unit_price"`
+	Amount    float64      `gorm:"not null" json:"amount"`
+	Currency  string       `gorm:"type:varchar(8);not null" json:"currency"`
+}
+
+func (*CostRecord) TableName() string {
+	return "cost_record"
+}
+
+// CostUsage is the usage of one item of a lab aggregated from history records
+type CostUsage struct {
+	LabID    int64   `json:"lab_id"`
+	Item     string  `json:"item"`
+	Quantity float64 `json:"quantity"`
+}
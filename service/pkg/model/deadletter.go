@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"gorm.io/datatypes"
+)
+
+// DeadLetterStatus represents the state of a dead-lettered job
+type DeadLetterStatus string
+
+const (
+	DeadLetterStatusDead     DeadLetterStatus = "dead"
+	DeadLetterStatusRequeued DeadLetterStatus = "requeued"
+)
+
+// DeadLetterFailure is one failed attempt of a job
+type DeadLetterFailure struct {
+	Attempt int       `json:"attempt"`
+	Error   string    `json:"error"`
+	At      time.Time `json:"at"`
+}
+
+// DeadLetterJob keeps a scheduler job that exhausted its retries
+type DeadLetterJob struct {
+	BaseModel
+	LabID        int64            `gorm:"type:bigint;not null;index:idx_dlj_lab_status,priority:1" json:"lab_id"`
+	TaskUUID     uuid.UUID        `gorm:"type:uuid" json:"task_uuid"`
+	Queue        string           `gorm:"type:varchar(255);not null" json:"queue"` // 重新投递的队列
+	Action       string           `gorm:"type:varchar(50);not null" json:"action"`
+	Payload      datatypes.JSON   `gorm:"type:jsonb;not null" json:"payload"`  // 原始任务消息
+	Failures     datatypes.JSON   `gorm:"type:jsonb;not null" json:"failures"` // []DeadLetterFailure
+	Attempts     int              `gorm:"type:int;not null;default:0" json:"attempts"`
+	Status       DeadLetterStatus `gorm:"type:varchar(20);not null;default:'dead';index:idx_dlj_lab_status,priority:2" json:"status"`
+	RequeueCount int              `gorm:"type:int;not null;default:0" json:"requeue_count"`
+	RequeuedBy   *string          `gorm:"type:varchar(120)" json:"requeued_by"`
+	RequeuedAt   *time.Time       `json:"requeued_at"`
+}
+
+func (*DeadLetterJob) TableName() string {
+	return "dead_letter_job"
+}
+
+// DeadLetterStats represents the depth and age of the dead-letter queue
+type DeadLetterStats struct {
+	Depth    int64      `json:"depth"`
+	OldestAt *time.Time `json:"oldest_at"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"gorm.io/datatypes"
+)
+
+// DependencyPolicy decides what happens to a dependent task when its prerequisites end
+type DependencyPolicy string
+
+const (
+	DependencyPolicySuccess DependencyPolicy = "success" // 前置任务全部成功才运行，否则取消
+	DependencyPolicyAlways  DependencyPolicy = "always"  // 前置任务全部结束即运行
+)
+
+// HeldTaskStatus represents the state of a task waiting on its prerequisites
+type HeldTaskStatus string
+
+const (
+	HeldTaskStatusHeld      HeldTaskStatus = "held"
+	HeldTaskStatusReleased  HeldTaskStatus = "released"
+	HeldTaskStatusCancelled HeldTaskStatus = "cancelled"
+)
+
+// WorkflowTaskDependency is an edge of the execution dependency graph
+type WorkflowTaskDependency struct {
+	BaseModel
+	TaskUUID      uuid.UUID `gorm:"type:uuid;not null;index:idx_wtd_task" json:"task_uuid"`
+	DependsOnUUID uuid.UUID `gorm:"type:uuid;not null;index:idx_wtd_depends_on" json:"depends_on_uuid"`
+}
+
+func (*WorkflowTaskDependency) TableName() string {
+	return "workflow_task_dependency"
+}
+
+// WorkflowHeldTask keeps the queue payload of a task until its prerequisites finish
+type WorkflowHeldTask struct {
+	BaseModel
+	TaskUUID uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_wht_task" json:"task_uuid"`
+	LabID    int64            `gorm:"type:bigint;not null" json:"lab_id"`
+	Policy   DependencyPolicy `gorm:"type:varchar(20);not null;default:'success'" json:"policy"`
+	Status   HeldTaskStatus   `gorm:"type:varchar(20);not null;default:'held';index:idx_wht_status" json:"status"`
+	Payload  datatypes.JSON   `gorm:"type:jsonb" json:"payload"` // 释放时投递到任务队列的消息
+}
+
+func (*WorkflowHeldTask) TableName() string {
+	return "workflow_held_task"
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"encoding/json"
+
+	"gorm.io/datatypes"
+)
+
+// DeviceCapability describes one action a device supports and the JSON Schema of its parameters
+type DeviceCapability struct {
+	Action      string          `json:"action"`
+	ActionType  string          `json:"action_type"`
+	Description string          `json:"description,omitempty"`
+	ParamSchema json.RawMessage `json:"param_schema,omitempty" swaggertype:"object"`
+}
+
+// Device is a device registered by an edge agent together with its capabilities
+type Device struct {
+	BaseModel
+	LabID        int64                                 `gorm:"type:bigint;not null;uniqueIndex:idx_device_lab_name,priority:1" json:"lab_id"`
+	Name         string                                `gorm:"type:varchar(255);not null;uniqueIndex:idx_device_lab_name,priority:2" json:"name"` // 与工作流节点 device_name 对应
+	DisplayName  string                                `gorm:"type:varchar(255)" json:"display_name"`
+	Class        string                                `gorm:"type:varchar(255)" json:"class"`
+	Vendor       string                                `gorm:"type:varchar(255)" json:"vendor"`
+	Model        string                                `gorm:"type:varchar(255)" json:"model"`
+	AgentVersion string                                `gorm:"type:varchar(64)" json:"agent_version"`
+	Capabilities datatypes.JSONSlice[DeviceCapability] `gorm:"type:jsonb;not null;default:'[]'" json:"capabilities"`
+}
+
+func (*Device) TableName() string {
+	return "device"
+}
+
+// Capability returns the declared capability for the action
+func (d *Device) Capability(action string) (*DeviceCapability, bool) {
+	for i := range d.Capabilities {
+		if d.Capabilities[i].Action == action {
+			return &d.Capabilities[i], true
+		}
+	}
+	return nil, false
+}
+
+// DeviceLimit overrides the configured maximum number of registered devices for one lab
+type DeviceLimit struct {
+	BaseModel
+	LabID      int64  `gorm:"type:bigint;not null;uniqueIndex:idx_device_limit_lab" json:"lab_id"`
+	MaxDevices int    `gorm:"not null" json:"max_devices"` // 0 表示不限制
+	UpdatedBy  string `gorm:"type:varchar(120)" json:"updated_by"`
+}
+
+func (*DeviceLimit) TableName() string {
+	return "device_limit"
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"gorm.io/datatypes"
+)
+
+// DeviceConfigAction is the action and action type of the device commands that
+// apply a configuration, param {"profile_uuid", "profile_name", "version", "config"}
+const DeviceConfigAction = "apply_config"
+
+// DeviceConfigProfile is a named, versioned JSON configuration for the devices
+// of a class. Every change creates a new DeviceConfigVersion
+type DeviceConfigProfile struct {
+	BaseModel
+	LabID         int64  `gorm:"type:bigint;not null;uniqueIndex:idx_dcp_lab_name,priority:1" json:"lab_id"`
+	Name          string `gorm:"type:varchar(255);not null;uniqueIndex:idx_dcp_lab_name,priority:2" json:"name"`
+	DeviceClass   string `gorm:"type:varchar(255);not null;default:''" json:"device_class"` // 适用的设备类型，为空时适用所有设备
+	Description   string `gorm:"type:text" json:"description"`
+	LatestVersion int    `gorm:"type:int;not null" json:"latest_version"`
+	CreatedBy     string `gorm:"type:varchar(120);not null" json:"created_by"`
+}
+
+func (*DeviceConfigProfile) TableName() string {
+	return "device_config_profile"
+}
+
+// DeviceConfigVersion is one immutable version of a profile configuration
+type DeviceConfigVersion struct {
+	BaseModel
+	ProfileID int64          `gorm:"type:bigint;not null;uniqueIndex:idx_dcv_profile_version,priority:1" json:"-"`
+	Version   int            `gorm:"type:int;not null;uniqueIndex:idx_dcv_profile_version,priority:2" json:"version"`
+	Config    datatypes.JSON `gorm:"type:jsonb;not null" json:"config" swaggertype:"object"`
+	Comment   string         `gorm:"type:text" json:"comment"`
+	CreatedBy string         `gorm:"type:varchar(120);not null" json:"created_by"`
+}
+
+func (*DeviceConfigVersion) TableName() string {
+	return "device_config_version"
+}
+
+// DeviceConfigAssignment assigns a profile to one device, or with an empty
+// DeviceName to all devices of the profile class in the lab. A device uses its
+// own assignment first, then the one of its class, then the one for all classes
+type DeviceConfigAssignment struct {
+	BaseModel
+	LabID       int64  `gorm:"type:bigint;not null;uniqueIndex:idx_dca_lab_target,priority:1" json:"lab_id"`
+	ProfileID   int64  `gorm:"type:bigint;not null;index:idx_dca_profile" json:"-"`
+	DeviceClass string `gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_dca_lab_target,priority:2" json:"device_class"` // 实验室级分配的设备类型，设备级分配为空
+	DeviceName  string `gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_dca_lab_target,priority:3" json:"device_name"`  // 为空表示实验室级分配
+	Version     int    `gorm:"type:int;not null;default:0" json:"version"`                                                          // 固定的版本，0 表示跟随最新版本
+	CreatedBy   string `gorm:"type:varchar(120);not null" json:"created_by"`
+
+	ProfileUUID uuid.UUID `gorm:"-" json:"profile_uuid"`
+	ProfileName string    `gorm:"-" json:"profile_name"`
+}
+
+func (*DeviceConfigAssignment) TableName() string {
+	return "device_config_assignment"
+}
+
+// DeviceConfigApplyStatus follows the command that pushes a configuration
+type DeviceConfigApplyStatus string
+
+const (
+	DeviceConfigApplyPending DeviceConfigApplyStatus = "pending" // 指令等待 edge 拉取或确认
+	DeviceConfigApplyAcked   DeviceConfigApplyStatus = "acked"   // edge 已确认，等待结果
+	DeviceConfigApplyApplied DeviceConfigApplyStatus = "applied"
+	DeviceConfigApplyFailed  DeviceConfigApplyStatus = "failed"
+)
+
+// DeviceConfigApply records one push of a profile version to a device, which
+// is the history of the versions applied to the device
+type DeviceConfigApply struct {
+	BaseModel
+	LabID       int64                   `gorm:"type:bigint;not null;index:idx_dcap_lab_device,priority:1" json:"lab_id"`
+	DeviceName  string                  `gorm:"type:varchar(255);not null;index:idx_dcap_lab_device,priority:2" json:"device_name"`
+	ProfileID   int64                   `gorm:"type:bigint;not null" json:"-"`
+	ProfileUUID uuid.UUID               `gorm:"type:uuid;not null" json:"profile_uuid"`
+	ProfileName string                  `gorm:"type:varchar(255);not null" json:"profile_name"`
+	Version     int                     `gorm:"type:int;not null" json:"version"`
+	CommandID   int64                   `gorm:"type:bigint;not null" json:"-"`
+	CommandUUID uuid.UUID               `gorm:"type:uuid;not null" json:"command_uuid"`
+	Status      DeviceConfigApplyStatus `gorm:"type:varchar(20);not null;index:idx_dcap_status" json:"status"`
+	Error       string                  `gorm:"type:text" json:"error"`
+	CreatedBy   string                  `gorm:"type:varchar(120);not null" json:"created_by"`
+	AckedAt     *time.Time              `json:"acked_at"`
+	FinishedAt  *time.Time              `json:"finished_at"`
+}
+
+func (*DeviceConfigApply) TableName() string {
+	return "device_config_apply"
+}
+
+// DeviceConfigReport is the configuration a device last reported running
+type DeviceConfigReport struct {
+	BaseModel
+	LabID      int64          `gorm:"type:bigint;not null;uniqueIndex:idx_dcr_lab_device,priority:1" json:"lab_id"`
+	DeviceName string         `gorm:"type:varchar(255);not null;uniqueIndex:idx_dcr_lab_device,priority:2" json:"device_name"`
+	Config     datatypes.JSON `gorm:"type:jsonb;not null" json:"config" swaggertype:"object"`
+	ReportedAt time.Time      `gorm:"not null" json:"reported_at"`
+}
+
+func (*DeviceConfigReport) TableName() string {
+	return "device_config_report"
+}
+
+// DeviceConfigDrift compares the configuration a device reports with the
+// version assigned to it
+type DeviceConfigDrift string
+
+const (
+	DeviceConfigInSync     DeviceConfigDrift = "in_sync"
+	DeviceConfigDrifted    DeviceConfigDrift = "drifted"
+	DeviceConfigUnreported DeviceConfigDrift = "unreported" // 设备尚未上报配置
+	DeviceConfigUnassigned DeviceConfigDrift = "unassigned" // 设备没有分配配置
+)
+
+// DeviceConfigStatus is the assigned, applied and reported configuration of a device
+type DeviceConfigStatus struct {
+	DeviceName     string             `json:"device_name"`
+	DeviceClass    string             `json:"device_class"`
+	ProfileUUID    uuid.UUID          `json:"profile_uuid"`
+	ProfileName    string             `json:"profile_name"`
+	DesiredVersion int                `json:"desired_version"`
+	AppliedVersion int                `json:"applied_version"` // 最近一次成功应用的版本，0 表示没有
+	LastApply      *DeviceConfigApply `json:"last_apply"`
+	Drift          DeviceConfigDrift  `json:"drift"`
+	DriftKeys      []string           `json:"drift_keys,omitempty"` // 与分配配置不一致的顶层字段
+	ReportedAt     *time.Time         `json:"reported_at"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// DeviceAPIKey is a key devices of a lab use to report events, limited to one
+// device when DeviceName is set. Only the hash of the key is stored
+type DeviceAPIKey struct {
+	BaseModel
+	LabID      int64      `gorm:"type:bigint;not null;index:idx_device_key_lab" json:"lab_id"`
+	Name       string     `gorm:"type:varchar(255);not null" json:"name"`
+	DeviceName string     `gorm:"type:varchar(255)" json:"device_name"`    // 为空时可以上报实验室所有设备的事件
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"` // 密钥的前几位，用于识别密钥
+	KeyHash    string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_device_key_hash" json:"-"`
+	CreatedBy  string     `gorm:"type:varchar(120);not null" json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedBy  string     `gorm:"type:varchar(120)" json:"revoked_by"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (*DeviceAPIKey) TableName() string {
+	return "device_api_key"
+}
+
+// Active reports whether the key can still be used
+func (k *DeviceAPIKey) Active() bool {
+	return k.RevokedAt == nil
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// DeviceRiskLevel is the maintenance risk level of a score
+type DeviceRiskLevel string
+
+const (
+	DeviceRiskNormal   DeviceRiskLevel = "normal"
+	DeviceRiskWarning  DeviceRiskLevel = "warning"
+	DeviceRiskCritical DeviceRiskLevel = "critical"
+)
+
+// DeviceRiskRuleID is the rule id of the alerts opened by risk scoring, which
+// are not raised by a device rule
+const DeviceRiskRuleID int64 = 0
+
+// DeviceRiskScore is one maintenance risk scoring of a device, from its error
+// events, action failures and action duration drift in the scoring window
+// compared with the baseline before it. Score ranges 0-100
+type DeviceRiskScore struct {
+	BaseModel
+	LabID                int64           `gorm:"type:bigint;not null;index:idx_drs_lab_scored,priority:1" json:"lab_id"`
+	DeviceID             int64           `gorm:"type:bigint;not null;index:idx_drs_device_scored,priority:1" json:"device_id"`
+	DeviceUUID           uuid.UUID       `gorm:"type:uuid;not null" json:"device_uuid"`
+	DeviceName           string          `gorm:"type:varchar(255);not null" json:"device_name"`
+	Score                float64         `gorm:"not null" json:"score"`
+	Level                DeviceRiskLevel `gorm:"type:varchar(20);not null" json:"level"`
+	ErrorEvents          int64           `gorm:"not null;default:0" json:"error_events"`            // 窗口内的错误事件数
+	ErrorsPerDay         float64         `gorm:"not null;default:0" json:"errors_per_day"`          // 窗口内每天的错误事件数
+	BaselineErrorsPerDay float64         `gorm:"not null;default:0" json:"baseline_errors_per_day"` // 基线每天的错误事件数
+	Actions              int64           `gorm:"not null;default:0" json:"actions"`                 // 窗口内结束的动作数
+	FailedActions        int64           `gorm:"not null;default:0" json:"failed_actions"`          // 窗口内失败的动作数
+	FailureRate          float64         `gorm:"not null;default:0" json:"failure_rate"`            // 窗口内动作失败率
+	DurationDrift        float64         `gorm:"not null;default:0" json:"duration_drift"`          // 动作时长中位数相对基线的变化比例，按动作次数加权
+	ErrorScore           float64         `gorm:"not null;default:0" json:"error_score"`             // 错误频率的评分 (0-1)
+	FailureScore         float64         `gorm:"not null;default:0" json:"failure_score"`           // 失败率的评分 (0-1)
+	DriftScore           float64         `gorm:"not null;default:0" json:"drift_score"`             // 时长漂移的评分 (0-1)
+	ScoredAt             time.Time       `gorm:"not null;index:idx_drs_lab_scored,priority:2;index:idx_drs_device_scored,priority:2" json:"scored_at"`
+}
+
+func (*DeviceRiskScore) TableName() string {
+	return "device_risk_score"
+}
+
+// DeviceErrorCount is the number of error events of a device in the scoring
+// window and in the baseline
+type DeviceErrorCount struct {
+	LabID      int64
+	DeviceID   int64
+	DeviceUUID uuid.UUID
+	Recent     int64
+	Baseline   int64
+}
+
+// DeviceActionCount is the number of finished and failed actions of a device
+// in the scoring window
+type DeviceActionCount struct {
+	LabID      int64
+	DeviceID   int64
+	DeviceUUID uuid.UUID
+	DeviceName string
+	Actions    int64
+	Failed     int64
+}
+
+// DeviceActionDrift is the median duration of the successful runs of an
+// action of a device in the scoring window and in the baseline
+type DeviceActionDrift struct {
+	DeviceID      int64
+	ActionName    string
+	Recent        int64
+	RecentP50Ms   float64
+	Baseline      int64
+	BaselineP50Ms float64
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// ErrorCategoryUnclassified is stamped on failures no rule of the lab matches
+const ErrorCategoryUnclassified = "unclassified"
+
+// ErrorRule classifies failure messages of a lab: the first rule in priority
+// order whose pattern matches the message gives the error category
+type ErrorRule struct {
+	BaseModel
+	LabID     int64     `gorm:"type:bigint;not null;index:idx_er_lab" json:"-"`
+	LabUUID   uuid.UUID `gorm:"type:uuid;not null" json:"lab_uuid"`
+	Category  string    `gorm:"type:varchar(64);not null" json:"category"`
+	Pattern   string    `gorm:"type:text;not null" json:"pattern"`           // 正则表达式，不区分大小写
+	Priority  int       `gorm:"type:int;not null;default:0" json:"priority"` // 越小越先匹配
+	CreatedBy string    `gorm:"type:varchar(120);not null" json:"created_by"`
+}
+
+func (*ErrorRule) TableName() string {
+	return "error_rule"
+}
+
+// FailureCategoryCount is the number of failed executions and actions of one
+// error category
+type FailureCategoryCount struct {
+	Category   string `json:"category"`
+	Executions int64  `json:"executions"`
+	Actions    int64  `json:"actions"`
+}
+
+// FailureStats groups the failed, or timed out, executions and actions of a
+// lab in a period by error category, most frequent first. Failures recorded
+// before classification count as unclassified
+type FailureStats struct {
+	LabID      int64                   `json:"lab_id"`
+	StartTime  time.Time               `json:"start_time"`
+	EndTime    time.Time               `json:"end_time"`
+	Executions int64                   `json:"executions"`
+	Actions    int64                   `json:"actions"`
+	Categories []*FailureCategoryCount `json:"categories"`
+}
+
+// FailedStatuses are the statuses whose error messages are classified
+var FailedStatuses = []ExecutionStatus{ExecutionStatusFailed, ExecutionStatusTimeout}
+
+// SortCategories orders the categories by total failures, then by name
+func (f *FailureStats) SortCategories() {
+	if f.Categories == nil {
+		f.Categories = []*FailureCategoryCount{}
+	}
+	sort.Slice(f.Categories, func(i, j int) bool {
+		a, b := f.Categories[i], f.Categories[j]
+		if a.Executions+a.Actions != b.Executions+b.Actions {
+			return a.Executions+a.Actions > b.Executions+b.Actions
+		}
+		return a.Category < b.Category
+	})
+}
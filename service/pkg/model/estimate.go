@@ -0,0 +1,138 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// MinEstimateSamples is the number of past durations needed to estimate from
+// a distribution
+const MinEstimateSamples = 3
+
+const (
+	EstimateSourceWorkflow = "workflow" // 该工作流最近成功执行的时长分布
+	EstimateSourceSteps    = "steps"    // 各步骤在对应设备上的历史时长之和，没有历史时用步骤时长预算
+	EstimateSourceNone     = "none"     // 没有可用的历史数据
+)
+
+// DurationRange is a duration estimate in milliseconds, the expected value
+// with a low and a high bound (10th, 50th and 90th percentiles)
+type DurationRange struct {
+	LowMs      int64 `json:"low_ms"`
+	ExpectedMs int64 `json:"expected_ms"`
+	HighMs     int64 `json:"high_ms"`
+}
+
+// Add sums two ranges, bound by bound
+func (r DurationRange) Add(o DurationRange) DurationRange {
+	return DurationRange{
+		LowMs:      r.LowMs + o.LowMs,
+		ExpectedMs: r.ExpectedMs + o.ExpectedMs,
+		HighMs:     r.HighMs + o.HighMs,
+	}
+}
+
+// Scale multiplies the range by a factor
+func (r DurationRange) Scale(f float64) DurationRange {
+	return DurationRange{
+		LowMs:      int64(math.Round(float64(r.LowMs) * f)),
+		ExpectedMs: int64(math.Round(float64(r.ExpectedMs) * f)),
+		HighMs:     int64(math.Round(float64(r.HighMs) * f)),
+	}
+}
+
+// DurationDistribution is a sorted set of past durations in milliseconds
+type DurationDistribution []int64
+
+// NewDurationDistribution sorts a copy of the samples
+func NewDurationDistribution(samples []int64) DurationDistribution {
+	d := make(DurationDistribution, len(samples))
+	copy(d, samples)
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	return d
+}
+
+// percentile returns the nearest-rank percentile of a sorted distribution
+func (d DurationDistribution) percentile(p float64) int64 {
+	rank := int(math.Ceil(p*float64(len(d)))) - 1
+	rank = max(0, min(rank, len(d)-1))
+	return d[rank]
+}
+
+// Range returns the estimate of the whole duration, false with fewer than
+// MinEstimateSamples samples
+func (d DurationDistribution) Range() (DurationRange, bool) {
+	if len(d) < MinEstimateSamples {
+		return DurationRange{}, false
+	}
+	return DurationRange{
+		LowMs:      d.percentile(0.1),
+		ExpectedMs: d.percentile(0.5),
+		HighMs:     d.percentile(0.9),
+	}, true
+}
+
+// Remaining returns the estimate of the time left for a run that has already
+// lasted elapsedMs, from the past runs that lasted longer. False when too few
+// past runs lasted that long, the run is then overdue
+func (d DurationDistribution) Remaining(elapsedMs int64) (DurationRange, bool) {
+	i := sort.Search(len(d), func(i int) bool { return d[i] > elapsedMs })
+	longer := make(DurationDistribution, 0, len(d)-i)
+	for _, v := range d[i:] {
+		longer = append(longer, v-elapsedMs)
+	}
+	return longer.Range()
+}
+
+// StepDurationPercentiles are the past durations of an action on a device,
+// or of all actions on the device when ActionName is empty
+type StepDurationPercentiles struct {
+	DeviceName string  `json:"device_name"`
+	ActionName string  `json:"action_name"`
+	Samples    int64   `json:"samples"`
+	P10Ms      float64 `json:"p10_ms"`
+	P50Ms      float64 `json:"p50_ms"`
+	P90Ms      float64 `json:"p90_ms"`
+}
+
+// Range converts the percentiles, false with fewer than MinEstimateSamples samples
+func (s *StepDurationPercentiles) Range() (DurationRange, bool) {
+	if s == nil || s.Samples < MinEstimateSamples {
+		return DurationRange{}, false
+	}
+	return DurationRange{
+		LowMs:      int64(math.Round(s.P10Ms)),
+		ExpectedMs: int64(math.Round(s.P50Ms)),
+		HighMs:     int64(math.Round(s.P90Ms)),
+	}, true
+}
+
+// ExecutionEstimate is the expected completion of a pending or running
+// execution. Completion times are empty when there is no history to estimate from
+type ExecutionEstimate struct {
+	ExecutionUUID      uuid.UUID       `json:"execution_uuid"`
+	WorkflowName       string          `json:"workflow_name"`
+	Status             ExecutionStatus `json:"status"`
+	Source             string          `json:"source"`                   // workflow | steps | none
+	Samples            int             `json:"samples"`                  // 工作流分布的样本数
+	UnknownSteps       int             `json:"unknown_steps"`            // 按步骤估算时既没有历史也没有预算的步骤数
+	Overdue            bool            `json:"overdue"`                  // 已超过几乎所有历史执行的时长
+	Duration           DurationRange   `json:"duration"`                 // 整个执行的时长
+	Remaining          DurationRange   `json:"remaining"`                // 从现在起的剩余时长，包括排队等待
+	Ahead              int             `json:"ahead"`                    // 排在前面且使用相同设备的执行数
+	CompletionEarliest *time.Time      `json:"completion_earliest"`      // 最早完成时间
+	CompletionExpected *time.Time      `json:"completion_expected"`      // 预计完成时间
+	CompletionLatest   *time.Time      `json:"completion_latest"`        // 最晚完成时间
+	StartExpected      *time.Time      `json:"start_expected,omitempty"` // 排队中的执行预计开始时间
+}
+
+// QueueForecast is the expected completion of the pending and running
+// executions of a lab, in queue order
+type QueueForecast struct {
+	LabID       int64                `json:"lab_id"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	Executions  []*ExecutionEstimate `json:"executions"`
+}
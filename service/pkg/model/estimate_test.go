@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurationDistribution(t *testing.T) {
+	d := NewDurationDistribution([]int64{500, 100, 400, 200, 300, 900, 600, 800, 700, 1000})
+	r, ok := d.Range()
+	assert.True(t, ok)
+	assert.Equal(t, DurationRange{LowMs: 100, ExpectedMs: 500, HighMs: 900}, r)
+
+	// 已运行 550ms，只用更长的 5 次执行估算剩余时长
+	left, ok := d.Remaining(550)
+	assert.True(t, ok)
+	assert.Equal(t, DurationRange{LowMs: 50, ExpectedMs: 250, HighMs: 450}, left)
+
+	// 超过了几乎所有历史执行
+	_, ok = d.Remaining(850)
+	assert.False(t, ok)
+
+	_, ok = NewDurationDistribution([]int64{1, 2}).Range()
+	assert.False(t, ok)
+}
+
+func TestStepDurationPercentilesRange(t *testing.T) {
+	var s *StepDurationPercentiles
+	_, ok := s.Range()
+	assert.False(t, ok)
+
+	s = &StepDurationPercentiles{Samples: 3, P10Ms: 10.4, P50Ms: 20.5, P90Ms: 30}
+	r, ok := s.Range()
+	assert.True(t, ok)
+	assert.Equal(t, DurationRange{LowMs: 10, ExpectedMs: 21, HighMs: 30}, r)
+	assert.Equal(t, DurationRange{LowMs: 20, ExpectedMs: 42, HighMs: 60}, r.Scale(2))
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// ExperimentStatus is the lifecycle state of an experiment
+type ExperimentStatus string
+
+const (
+	ExperimentActive    ExperimentStatus = "active"    // 进行中，可以关联执行
+	ExperimentCompleted ExperimentStatus = "completed" // 已完成，仍可以补充关联执行
+	ExperimentArchived  ExperimentStatus = "archived"  // 已归档，不再接受新的执行
+)
+
+// Valid reports whether the experiment status is known
+func (s ExperimentStatus) Valid() bool {
+	switch s {
+	case ExperimentActive, ExperimentCompleted, ExperimentArchived:
+		return true
+	}
+	return false
+}
+
+// Experiment groups the workflow executions of one scientific experiment
+type Experiment struct {
+	BaseModel
+	LabID       int64            `gorm:"type:bigint;not null;uniqueIndex:idx_exp_lab_name,priority:1" json:"lab_id"`
+	Name        string           `gorm:"type:varchar(255);not null;uniqueIndex:idx_exp_lab_name,priority:2" json:"name"`
+	Description string           `gorm:"type:text" json:"description"`
+	Status      ExperimentStatus `gorm:"type:varchar(32);not null;default:'active'" json:"status"`
+	PlannedRuns int              `gorm:"type:int;not null;default:0" json:"planned_runs"` // 计划执行次数，0 表示未设置
+	CreatedBy   string           `gorm:"type:varchar(120)" json:"created_by"`
+}
+
+func (*Experiment) TableName() string {
+	return "experiment"
+}
+
+// ExperimentProgress counts the executions of an experiment by status
+type ExperimentProgress struct {
+	Total       int64   `json:"total"`
+	Pending     int64   `json:"pending"`
+	Running     int64   `json:"running"`
+	Success     int64   `json:"success"`
+	Failed      int64   `json:"failed"` // 失败、超时
+	Cancelled   int64   `json:"cancelled"`
+	PlannedRuns int     `json:"planned_runs"`
+	Percent     float64 `json:"percent"` // 已结束执行占计划次数（未设置时占全部执行）的百分比
+}
+
+// NewExperimentProgress builds the progress from execution counts by status
+func NewExperimentProgress(counts map[ExecutionStatus]int64, plannedRuns int) *ExperimentProgress {
+	p := &ExperimentProgress{
+		Pending:     counts[ExecutionStatusPending],
+		Running:     counts[ExecutionStatusRunning],
+		Success:     counts[ExecutionStatusSuccess],
+		Failed:      counts[ExecutionStatusFailed] + counts[ExecutionStatusTimeout],
+		Cancelled:   counts[ExecutionStatusCancelled],
+		PlannedRuns: plannedRuns,
+	}
+	for _, n := range counts {
+		p.Total += n
+	}
+
+	finished := p.Success + p.Failed + p.Cancelled
+	target := p.Total
+	if plannedRuns > 0 {
+		target = int64(plannedRuns)
+	}
+	if target > 0 {
+		p.Percent = float64(min(finished, target)) * 100 / float64(target)
+	}
+	return p
+}
+
+// ExperimentWorkflowStats summarises the executions of one workflow in an experiment
+type ExperimentWorkflowStats struct {
+	WorkflowID    int64     `json:"-"`
+	WorkflowUUID  uuid.UUID `json:"workflow_uuid"`
+	WorkflowName  string    `json:"workflow_name"`
+	Total         int64     `json:"total"`
+	Success       int64     `json:"success"`
+	Failed        int64     `json:"failed"`
+	AvgDurationMs float64   `json:"avg_duration_ms"` // 已结束执行的平均耗时
+}
+
+// ExperimentStats is the progress of an experiment with per-workflow stats
+type ExperimentStats struct {
+	*ExperimentProgress
+	SuccessRate     float64                    `json:"success_rate"` // 成功执行占已结束执行的比例
+	TotalDurationMs int64                      `json:"total_duration_ms"`
+	FirstStartedAt  *time.Time                 `json:"first_started_at"`
+	LastCompletedAt *time.Time                 `json:"last_completed_at"`
+	Workflows       []*ExperimentWorkflowStats `json:"workflows"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewExperimentProgress(t *testing.T) {
+	counts := map[ExecutionStatus]int64{
+		ExecutionStatusPending:   1,
+		ExecutionStatusRunning:   2,
+		ExecutionStatusSuccess:   4,
+		ExecutionStatusFailed:    1,
+		ExecutionStatusTimeout:   1,
+		ExecutionStatusCancelled: 1,
+	}
+
+	p := NewExperimentProgress(counts, 0)
+	assert.Equal(t, int64(10), p.Total)
+	assert.Equal(t, int64(2), p.Failed)
+	assert.InDelta(t, 70.0, p.Percent, 0.001)
+
+	p = NewExperimentProgress(counts, 20)
+	assert.Equal(t, 20, p.PlannedRuns)
+	assert.InDelta(t, 35.0, p.Percent, 0.001)
+
+	// 超出计划次数时封顶
+	p = NewExperimentProgress(counts, 5)
+	assert.InDelta(t, 100.0, p.Percent, 0.001)
+
+	p = NewExperimentProgress(nil, 0)
+	assert.Zero(t, p.Total)
+	assert.Zero(t, p.Percent)
+}
+
+func TestExperimentStatusValid(t *testing.T) {
+	assert.True(t, ExperimentActive.Valid())
+	assert.True(t, ExperimentArchived.Valid())
+	assert.False(t, ExperimentStatus("paused").Valid())
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// ResultFileStatus represents the upload state of a result file
+type ResultFileStatus string
+
+const (
+	ResultFileUploading ResultFileStatus = "uploading"
+	ResultFileAvailable ResultFileStatus = "available"
+	ResultFileRejected  ResultFileStatus = "rejected" // 校验未通过，内容已删除
+)
+
+// ResultFile is an instrument output file uploaded in chunks, optionally linked
+// to the action execution that produced it
+type ResultFile struct {
+	BaseModel
+	LabID               int64            `gorm:"type:bigint;not null;index:idx_result_file_lab" json:"lab_id"`
+	ActionExecutionID   *int64           `gorm:"type:bigint;index:idx_result_file_action" json:"action_execution_id"`
+	ActionExecutionUUID *uuid.UUID       `gorm:"type:uuid" json:"action_execution_uuid"`
+	FileName            string           `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType         string           `gorm:"type:varchar(255)" json:"content_type"` // 完成后为内容嗅探的结果
+	Size                int64            `gorm:"type:bigint;not null" json:"size"`
+	Offset              int64            `gorm:"type:bigint;not null;default:0" json:"offset"` // 已接收的字节数
+	Chunks              int              `gorm:"not null;default:0" json:"chunks"`
+	Checksum            string           `gorm:"type:varchar(64)" json:"checksum"` // sha256 hex
+	Status              ResultFileStatus `gorm:"type:varchar(20);not null;index:idx_result_file_status" json:"status"`
+	StorageKey          string           `gorm:"type:varchar(512);not null" json:"-"`
+	UploaderID          string           `gorm:"type:varchar(120)" json:"uploader_id"` // edge 上传时为空
+	RejectReason        string           `gorm:"type:text" json:"reject_reason"`
+	CompletedAt         *time.Time       `json:"completed_at"`
+}
+
+func (*ResultFile) TableName() string {
+	return "result_file"
+}
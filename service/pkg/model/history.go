@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"time"
 
 	"github.com/scienceol/studio/service/pkg/common/uuid"
@@ -19,30 +20,95 @@ const (
 	ExecutionStatusTimeout   ExecutionStatus = "timeout"
 )
 
+// executionStatuses lists the known execution statuses
+var executionStatuses = []ExecutionStatus{
+	ExecutionStatusPending, ExecutionStatusRunning, ExecutionStatusSuccess,
+	ExecutionStatusFailed, ExecutionStatusCancelled, ExecutionStatusTimeout,
+}
+
+// Valid reports whether the execution status is known
+func (s ExecutionStatus) Valid() bool {
+	return slices.Contains(executionStatuses, s)
+}
+
+// Values lists the known execution statuses, reported when a filter is not one of them
+func (ExecutionStatus) Values() []string {
+	return enumValues(executionStatuses)
+}
+
+// Finished reports whether the execution status is final
+func (s ExecutionStatus) Finished() bool {
+	switch s {
+	case ExecutionStatusSuccess, ExecutionStatusFailed, ExecutionStatusCancelled, ExecutionStatusTimeout:
+		return true
+	}
+	return false
+}
+
+// TaskExecutionStatus maps the final status of a workflow task to the status
+// of its execution history
+func TaskExecutionStatus(status WorkflowTaskStatus) ExecutionStatus {
+	switch status {
+	case WorkflowTaskStatusSuccessed:
+		return ExecutionStatusSuccess
+	case WorkflowTaskStatusCanceled:
+		return ExecutionStatusCancelled
+	case WorkflowTaskStatusTimeout:
+		return ExecutionStatusTimeout
+	case WorkflowTaskStatusRunnig:
+		return ExecutionStatusRunning
+	case WorkflowTaskStatusPending:
+		return ExecutionStatusPending
+	default:
+		return ExecutionStatusFailed
+	}
+}
+
 // WorkflowExecutionHistory records the history of workflow executions
 type WorkflowExecutionHistory struct {
 	BaseModel
-	LabID          int64           `gorm:"type:bigint;not null;index:idx_weh_lab" json:"lab_id"`
-	UserID         string          `gorm:"type:varchar(120);not null;index:idx_weh_user" json:"user_id"`
-	WorkflowID     int64           `gorm:"type:bigint;not null;index:idx_weh_workflow" json:"workflow_id"`
-	WorkflowUUID   uuid.UUID       `gorm:"type:uuid;not null" json:"workflow_uuid"`
-	WorkflowName   string          `gorm:"type:varchar(255);not null" json:"workflow_name"`
-	Status         ExecutionStatus `gorm:"type:varchar(50);not null;default:'pending';index:idx_weh_status" json:"status"`
-	StepsTotal     int             `gorm:"type:int;not null;default:0" json:"steps_total"`
-	StepsCompleted int             `gorm:"type:int;not null;default:0" json:"steps_completed"`
-	StepsFailed    int             `gorm:"type:int;not null;default:0" json:"steps_failed"`
-	DurationMs     int64           `gorm:"type:bigint;default:0" json:"duration_ms"`
-	ErrorMessage   *string         `gorm:"type:text" json:"error_message"`
-	Result         datatypes.JSON  `gorm:"type:jsonb" json:"result"`
-	StartedAt      time.Time       `gorm:"not null;index:idx_weh_started" json:"started_at"`
-	CompletedAt    *time.Time      `json:"completed_at"`
-	Metadata       datatypes.JSON  `gorm:"type:jsonb" json:"metadata"`
+	LabID          int64                                 `gorm:"type:bigint;not null;index:idx_weh_lab" json:"lab_id"`
+	UserID         string                                `gorm:"type:varchar(120);not null;index:idx_weh_user" json:"user_id"`
+	WorkflowID     int64                                 `gorm:"type:bigint;not null;index:idx_weh_workflow" json:"workflow_id"`
+	WorkflowUUID   uuid.UUID                             `gorm:"type:uuid;not null" json:"workflow_uuid"`
+	WorkflowName   string                                `gorm:"type:varchar(255);not null" json:"workflow_name"`
+	BatchID        *int64                                `gorm:"type:bigint;index:idx_weh_batch" json:"batch_id"`           // 批量运行时所属批次
+	ExperimentID   *int64                                `gorm:"type:bigint;index:idx_weh_experiment" json:"experiment_id"` // 提交时或之后关联的实验
+	Status         ExecutionStatus                       `gorm:"type:varchar(50);not null;default:'pending';index:idx_weh_status" json:"status"`
+	StepsTotal     int                                   `gorm:"type:int;not null;default:0" json:"steps_total"`
+	StepsCompleted int                                   `gorm:"type:int;not null;default:0" json:"steps_completed"`
+	StepsFailed    int                                   `gorm:"type:int;not null;default:0" json:"steps_failed"`
+	DurationMs     int64                                 `gorm:"type:bigint;default:0" json:"duration_ms"`
+	ErrorMessage   *string                               `gorm:"type:text" json:"error_message"`
+	ErrorCategory  string                                `gorm:"type:varchar(64);index:idx_weh_error_category" json:"error_category"` // 失败或超时时按实验室规则分类
+	Result         datatypes.JSON                        `gorm:"type:jsonb;serializer:zstdjson" json:"result"`
+	Input          datatypes.JSON                        `gorm:"type:jsonb;serializer:zstdjson" json:"input"` // 校验并补全默认值后的运行参数
+	StartedAt      time.Time                             `gorm:"not null;index:idx_weh_started" json:"started_at"`
+	CompletedAt    *time.Time                            `json:"completed_at"`
+	Metadata       datatypes.JSON                        `gorm:"type:jsonb" json:"metadata"`
+	Labels         datatypes.JSONType[map[string]string] `gorm:"type:jsonb;not null;default:'{}';index:idx_weh_labels,type:gin" json:"labels" swaggertype:"object"` // 提交时或之后添加的键值标签
+	SLATargetMs    int64                                 `gorm:"type:bigint;not null;default:0" json:"sla_target_ms"`                                               // 提交时工作流的 SLA，0 表示未设置
+	SLABreached    *bool                                 `json:"sla_breached"`                                                                                      // 结束时计算，未设置 SLA、未结束或已取消时为空
+	DefinitionHash string                                `gorm:"type:varchar(64)" json:"definition_hash"`                                                           // 执行开始时的工作流定义快照，早于快照功能的执行为空
+	Version        int64                                 `gorm:"type:bigint;not null;default:0" json:"version"`                                                     // 乐观锁版本，每次更新加一
+	TraceParent    string                                `gorm:"type:varchar(64)" json:"traceparent,omitempty"`                                                     // 提交请求的 W3C trace context，用于关联端到端 trace
 }
 
 func (*WorkflowExecutionHistory) TableName() string {
 	return "workflow_execution_history"
 }
 
+// SLABreach reports whether the execution missed its SLA when finishing with
+// status at completedAt: only a success within the target meets it. Nil when
+// no SLA is set or the execution was cancelled
+func (e *WorkflowExecutionHistory) SLABreach(status ExecutionStatus, completedAt time.Time) *bool {
+	if e.SLATargetMs <= 0 || status == ExecutionStatusCancelled {
+		return nil
+	}
+	breached := status != ExecutionStatusSuccess || completedAt.Sub(e.StartedAt).Milliseconds() > e.SLATargetMs
+	return &breached
+}
+
 // ActionExecutionHistory records the history of device action executions
 type ActionExecutionHistory struct {
 	BaseModel
@@ -53,18 +119,31 @@ type ActionExecutionHistory struct {
 	DeviceName          string          `gorm:"type:varchar(255);not null" json:"device_name"`
 	ActionType          string          `gorm:"type:varchar(100);not null;index:idx_aeh_action" json:"action_type"`
 	ActionName          string          `gorm:"type:varchar(255);not null" json:"action_name"`
-	Input               datatypes.JSON  `gorm:"type:jsonb" json:"input"`
-	Output              datatypes.JSON  `gorm:"type:jsonb" json:"output"`
+	Input               datatypes.JSON  `gorm:"type:jsonb;serializer:zstdjson" json:"input"`
+	Output              datatypes.JSON  `gorm:"type:jsonb;serializer:zstdjson" json:"output"`
 	Status              ExecutionStatus `gorm:"type:varchar(50);not null;default:'pending';index:idx_aeh_status" json:"status"`
 	DurationMs          int64           `gorm:"type:bigint;default:0" json:"duration_ms"`
+	ExpectedMs          int64           `gorm:"type:bigint;not null;default:0" json:"expected_ms"` // 执行时步骤的时长预算
 	ErrorMessage        *string         `gorm:"type:text" json:"error_message"`
+	ErrorCategory       string          `gorm:"type:varchar(64);index:idx_aeh_error_category" json:"error_category"` // 失败或超时时按实验室规则分类
 	Metadata            datatypes.JSON  `gorm:"type:jsonb" json:"metadata"`
+	CompactedAt         *time.Time      `gorm:"index:idx_aeh_compacted" json:"compacted_at,omitempty"` // 输入输出已归档并替换为摘要
+	TraceParent         string          `gorm:"type:varchar(64)" json:"traceparent,omitempty"`         // 执行动作时的 W3C trace context
 }
 
 func (*ActionExecutionHistory) TableName() string {
 	return "action_execution_history"
 }
 
+// BudgetDeviation returns how much the action ran over (positive) or under
+// (negative) its duration budget; ok is false when no budget was declared
+func (a *ActionExecutionHistory) BudgetDeviation() (deviationMs int64, ok bool) {
+	if a.ExpectedMs <= 0 {
+		return 0, false
+	}
+	return a.DurationMs - a.ExpectedMs, true
+}
+
 // DeviceEventType represents the type of device event
 type DeviceEventType string
 
@@ -76,8 +155,41 @@ const (
 	DeviceEventDisconnected  DeviceEventType = "disconnected"
 	DeviceEventCommandSent   DeviceEventType = "command_sent"
 	DeviceEventCommandResult DeviceEventType = "command_result"
+	DeviceEventLockWaiting   DeviceEventType = "lock_waiting"
+	DeviceEventLockAcquired  DeviceEventType = "lock_acquired"
+	DeviceEventLockReleased  DeviceEventType = "lock_released"
+	DeviceEventLockLost      DeviceEventType = "lock_lost"
+	DeviceEventStreamStarted DeviceEventType = "stream_started"
+	DeviceEventStreamStopped DeviceEventType = "stream_stopped"
 )
 
+// deviceEventTypes lists the known device event types
+var deviceEventTypes = []DeviceEventType{
+	DeviceEventStatusChange, DeviceEventDataReceived, DeviceEventError,
+	DeviceEventConnected, DeviceEventDisconnected, DeviceEventCommandSent,
+	DeviceEventCommandResult, DeviceEventLockWaiting, DeviceEventLockAcquired,
+	DeviceEventLockReleased, DeviceEventLockLost, DeviceEventStreamStarted,
+	DeviceEventStreamStopped,
+}
+
+// Valid reports whether the device event type is known
+func (t DeviceEventType) Valid() bool {
+	return slices.Contains(deviceEventTypes, t)
+}
+
+// Values lists the known device event types, reported when a filter is not one of them
+func (DeviceEventType) Values() []string {
+	return enumValues(deviceEventTypes)
+}
+
+func enumValues[T ~string](values []T) []string {
+	ret := make([]string, 0, len(values))
+	for _, v := range values {
+		ret = append(ret, string(v))
+	}
+	return ret
+}
+
 // DeviceEventHistory records device events
 type DeviceEventHistory struct {
 	BaseModel
@@ -95,16 +207,46 @@ func (*DeviceEventHistory) TableName() string {
 
 // HistoryQueryParams represents query parameters for history queries
 type HistoryQueryParams struct {
-	LabID      int64
-	UserID     string
-	WorkflowID *int64
-	DeviceID   *int64
-	Status     *ExecutionStatus
-	EventType  *DeviceEventType
-	StartTime  *time.Time
-	EndTime    *time.Time
-	Page       int
-	PageSize   int
+	LabID        int64
+	UserID       string
+	WorkflowID   *int64
+	DeviceID     *int64
+	DeviceIDs    []int64 // 拓扑节点下的设备，不为 nil 时只匹配这些设备，为空切片时不匹配任何记录
+	ExperimentID *int64  // 关联到该实验的执行
+	Status       *ExecutionStatus
+	EventType    *DeviceEventType
+	Labels       map[string]string // 需全部匹配的执行标签
+	PinnedBy     string            // 只返回该用户置顶的执行
+	Breached     *bool             // 按是否违反 SLA 过滤，只匹配设置了 SLA 且已结束的执行
+	StartTime    *time.Time
+	EndTime      *time.Time
+	Page         int
+	PageSize     int
+	SkipTotal    bool           // 不统计总数，多取一条判断是否还有下一页
+	Cursor       *HistoryCursor // 游标翻页的位置，设置时忽略 Page 且不统计总数
+	Columns      []string       // 列表只查询这些列，为空时查询全部，稀疏字段集用于跳过大的 JSON 列
+}
+
+// PageCount is the pagination count of a history list, Total is -1 when skipped
+type PageCount struct {
+	Total   int64
+	HasMore bool
+	Next    *HistoryCursor // 下一页的游标，只有工作流执行列表返回
+}
+
+// NewPageCount computes the count of a page fetched with PageSize+1 rows when
+// the total is skipped, returning the number of rows to keep
+func NewPageCount(params *HistoryQueryParams, fetched int, total int64) (PageCount, int) {
+	if params.SkipTotal || params.Cursor != nil {
+		if fetched > params.PageSize {
+			return PageCount{Total: -1, HasMore: true}, params.PageSize
+		}
+		return PageCount{Total: -1}, fetched
+	}
+	return PageCount{
+		Total:   total,
+		HasMore: int64((params.Page-1)*params.PageSize+fetched) < total,
+	}, fetched
 }
 
 // NewHistoryQueryParams creates a new HistoryQueryParams with defaults
@@ -124,5 +266,161 @@ type HistoryStats struct {
 	AverageDurationMs  float64 `json:"average_duration_ms"`
 	TotalActionsCount  int64   `json:"total_actions_count"`
 	TotalDeviceEvents  int64   `json:"total_device_events"`
+	MaterialUsages     int64   `json:"material_usages"`    // 物料消耗记录数
+	MaterialsConsumed  int64   `json:"materials_consumed"` // 被消耗的物料批次数
+	SLATracked         int64   `json:"sla_tracked"`        // 设置了 SLA 且已结束的执行数
+	SLABreachedCount   int64   `json:"sla_breached_count"`
+	SLACompliance      float64 `json:"sla_compliance"` // 满足 SLA 的百分比，没有 SLA 执行时为 0
+}
+
+// LabStats is the share of one lab in organization statistics
+type LabStats struct {
+	LabID             int64     `json:"lab_id"`
+	LabUUID           uuid.UUID `json:"lab_uuid"`
+	LabName           string    `json:"lab_name"`
+	TotalExecutions   int64     `json:"total_executions"`
+	SuccessfulCount   int64     `json:"successful_count"`
+	FailedCount       int64     `json:"failed_count"`
+	SuccessRate       float64   `json:"success_rate"`
+	AverageDurationMs float64   `json:"average_duration_ms"`
+	TotalActionsCount int64     `json:"total_actions_count"`
+	TotalDeviceEvents int64     `json:"total_device_events"`
+	DurationCount     int64     `json:"-"` // 参与平均耗时计算的执行数
+}
+
+// WorkflowRank is a workflow ranked by executions across an organization
+type WorkflowRank struct {
+	LabID           int64     `json:"lab_id"`
+	WorkflowUUID    uuid.UUID `json:"workflow_uuid"`
+	WorkflowName    string    `json:"workflow_name"`
+	Executions      int64     `json:"executions"`
+	SuccessfulCount int64     `json:"successful_count"`
+}
+
+// DeviceRank is a device ranked by action executions across an organization
+type DeviceRank struct {
+	LabID           int64  `json:"lab_id"`
+	DeviceName      string `json:"device_name"`
+	Executions      int64  `json:"executions"`
+	TotalDurationMs int64  `json:"total_duration_ms"`
+}
+
+// StatsTrend compares a period with the previous one of the same length; changes
+// are percentages and nil when the previous value is zero
+type StatsTrend struct {
+	ExecutionsChange      *float64 `json:"executions_change"`
+	SuccessRateChange     float64  `json:"success_rate_change"` // 百分点
+	AverageDurationChange *float64 `json:"average_duration_change"`
+	ActionsChange         *float64 `json:"actions_change"`
+	DeviceEventsChange    *float64 `json:"device_events_change"`
+}
+
+// OrgStats aggregates history statistics across the labs of an organization
+type OrgStats struct {
+	OrgID        string          `json:"org_id"`
+	StartTime    time.Time       `json:"start_time"`
+	EndTime      time.Time       `json:"end_time"`
+	LabCount     int             `json:"lab_count"`
+	Current      *HistoryStats   `json:"current"`
+	Previous     *HistoryStats   `json:"previous"` // 上一个等长周期
+	Trend        *StatsTrend     `json:"trend"`
+	Labs         []*LabStats     `json:"labs"`
+	TopWorkflows []*WorkflowRank `json:"top_workflows"`
+	TopDevices   []*DeviceRank   `json:"top_devices"`
+}
+
+// SumLabStats totals per-lab statistics, weighting average durations by executions
+func SumLabStats(labs []*LabStats) *HistoryStats {
+	stats := &HistoryStats{}
+	var durationTotal float64
+	var durationCount int64
+	for _, l := range labs {
+		stats.TotalExecutions += l.TotalExecutions
+		stats.SuccessfulCount += l.SuccessfulCount
+		stats.FailedCount += l.FailedCount
+		stats.TotalActionsCount += l.TotalActionsCount
+		stats.TotalDeviceEvents += l.TotalDeviceEvents
+		durationTotal += l.AverageDurationMs * float64(l.DurationCount)
+		durationCount += l.DurationCount
+	}
+	if stats.TotalExecutions > 0 {
+		stats.SuccessRate = float64(stats.SuccessfulCount) / float64(stats.TotalExecutions) * 100
+	}
+	if durationCount > 0 {
+		stats.AverageDurationMs = durationTotal / float64(durationCount)
+	}
+	return stats
+}
+
+// NewStatsTrend compares current statistics with the previous period
+func NewStatsTrend(current, previous *HistoryStats) *StatsTrend {
+	return &StatsTrend{
+		ExecutionsChange:      percentChange(float64(current.TotalExecutions), float64(previous.TotalExecutions)),
+		SuccessRateChange:     current.SuccessRate - previous.SuccessRate,
+		AverageDurationChange: percentChange(current.AverageDurationMs, previous.AverageDurationMs),
+		ActionsChange:         percentChange(float64(current.TotalActionsCount), float64(previous.TotalActionsCount)),
+		DeviceEventsChange:    percentChange(float64(current.TotalDeviceEvents), float64(previous.TotalDeviceEvents)),
+	}
+}
+
+func percentChange(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := (current - previous) / previous * 100
+	return &change
+}
+
+// HeatmapCell is the number of execution starts in one hour of one weekday
+type HeatmapCell struct {
+	Weekday int   `json:"weekday"` // 0 为周日，与 time.Weekday 一致
+	Hour    int   `json:"hour"`
+	Count   int64 `json:"count"`
+}
+
+// ActivityHeatmap counts the execution starts of a lab by weekday and hour of
+// day in a timezone; Matrix[weekday][hour] is the count
+type ActivityHeatmap struct {
+	LabID     int64        `json:"lab_id"`
+	StartTime time.Time    `json:"start_time"`
+	EndTime   time.Time    `json:"end_time"`
+	Timezone  string       `json:"timezone"`
+	Total     int64        `json:"total"`
+	Matrix    [7][24]int64 `json:"matrix"`
+	Peak      *HeatmapCell `json:"peak"` // 执行最多的时段，没有执行时为空
+}
+
+// NewActivityHeatmap fills the matrix from the aggregated cells, ignoring
+// cells out of range
+func NewActivityHeatmap(cells []*HeatmapCell) *ActivityHeatmap {
+	heatmap := &ActivityHeatmap{}
+	for _, cell := range cells {
+		if cell.Weekday < 0 || cell.Weekday > 6 || cell.Hour < 0 || cell.Hour > 23 {
+			continue
+		}
+		heatmap.Matrix[cell.Weekday][cell.Hour] += cell.Count
+		heatmap.Total += cell.Count
+	}
+
+	for weekday, hours := range heatmap.Matrix {
+		for hour, count := range hours {
+			if count > 0 && (heatmap.Peak == nil || count > heatmap.Peak.Count) {
+				heatmap.Peak = &HeatmapCell{Weekday: weekday, Hour: hour, Count: count}
+			}
+		}
+	}
+	return heatmap
+}
+
+// StepDurationStats represents aggregated durations of one workflow step
+type StepDurationStats struct {
+	ActionName      string  `json:"action_name"`
+	DeviceName      string  `json:"device_name"`
+	Executions      int64   `json:"executions"`
+	AvgDurationMs   float64 `json:"avg_duration_ms"`
+	MaxDurationMs   int64   `json:"max_duration_ms"`
+	ExpectedMs      int64   `json:"expected_ms"`
+	OverBudgetCount int64   `json:"over_budget_count"`
+	AvgDeviationMs  float64 `json:"avg_deviation_ms"`
 }
 
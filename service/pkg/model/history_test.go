@@ -120,3 +120,110 @@ func TestHistoryStats(t *testing.T) {
 	assert.Equal(t, int64(1000), stats.TotalDeviceEvents)
 }
 
+
+func TestOrgStatsTrend(t *testing.T) {
+	labs := []*LabStats{
+		{LabID: 1, TotalExecutions: 8, SuccessfulCount: 6, FailedCount: 2, AverageDurationMs: 100, DurationCount: 8, TotalActionsCount: 40},
+		{LabID: 2, TotalExecutions: 2, SuccessfulCount: 2, AverageDurationMs: 400, DurationCount: 2, TotalActionsCount: 10},
+		{LabID: 3},
+	}
+	current := SumLabStats(labs)
+	assert.Equal(t, int64(10), current.TotalExecutions)
+	assert.Equal(t, 80.0, current.SuccessRate)
+	assert.Equal(t, 160.0, current.AverageDurationMs)
+	assert.Equal(t, int64(50), current.TotalActionsCount)
+
+	previous := &HistoryStats{TotalExecutions: 5, SuccessRate: 60, AverageDurationMs: 200, TotalActionsCount: 50}
+	trend := NewStatsTrend(current, previous)
+	assert.Equal(t, 100.0, *trend.ExecutionsChange)
+	assert.InDelta(t, 20.0, trend.SuccessRateChange, 1e-9)
+	assert.Equal(t, -20.0, *trend.AverageDurationChange)
+	assert.Equal(t, 0.0, *trend.ActionsChange)
+	assert.Nil(t, trend.DeviceEventsChange)
+}
+
+func TestSavedViewFiltersApply(t *testing.T) {
+	workflowID := int64(7)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	filters := &SavedViewFilters{WorkflowID: &workflowID, Status: "failed", StartTime: &start, PageSize: 50}
+	params := NewHistoryQueryParams()
+	filters.Apply(params, map[string]bool{}, now)
+	assert.Equal(t, &workflowID, params.WorkflowID)
+	assert.Equal(t, ExecutionStatusFailed, *params.Status)
+	assert.Equal(t, start, *params.StartTime)
+	assert.Equal(t, 50, params.PageSize)
+
+	// 显式传入的过滤条件优先，相对范围优先于固定开始时间
+	explicit := ExecutionStatusSuccess
+	params = NewHistoryQueryParams()
+	params.Status = &explicit
+	filters.Range = "24h"
+	filters.Apply(params, map[string]bool{"status": true, "page_size": true}, now)
+	assert.Equal(t, ExecutionStatusSuccess, *params.Status)
+	assert.Equal(t, now.Add(-24*time.Hour), *params.StartTime)
+	assert.Equal(t, 20, params.PageSize)
+}
+
+func TestNewPageCount(t *testing.T) {
+	params := &HistoryQueryParams{Page: 2, PageSize: 10}
+	count, keep := NewPageCount(params, 10, 25)
+	assert.Equal(t, PageCount{Total: 25, HasMore: true}, count)
+	assert.Equal(t, 10, keep)
+
+	count, _ = NewPageCount(params, 5, 15)
+	assert.False(t, count.HasMore)
+
+	params.SkipTotal = true
+	count, keep = NewPageCount(params, 11, 0)
+	assert.Equal(t, PageCount{Total: -1, HasMore: true}, count)
+	assert.Equal(t, 10, keep)
+
+	count, keep = NewPageCount(params, 7, 0)
+	assert.Equal(t, PageCount{Total: -1}, count)
+	assert.Equal(t, 7, keep)
+}
+
+func TestSLABreach(t *testing.T) {
+	started := time.Now()
+	exec := &WorkflowExecutionHistory{StartedAt: started, SLATargetMs: time.Hour.Milliseconds()}
+
+	within := exec.SLABreach(ExecutionStatusSuccess, started.Add(30*time.Minute))
+	assert.NotNil(t, within)
+	assert.False(t, *within)
+
+	late := exec.SLABreach(ExecutionStatusSuccess, started.Add(2*time.Hour))
+	assert.NotNil(t, late)
+	assert.True(t, *late)
+
+	// 失败的执行没有得到结果，视为违反 SLA
+	failed := exec.SLABreach(ExecutionStatusFailed, started.Add(time.Minute))
+	assert.NotNil(t, failed)
+	assert.True(t, *failed)
+
+	assert.Nil(t, exec.SLABreach(ExecutionStatusCancelled, started.Add(2*time.Hour)))
+	assert.Nil(t, (&WorkflowExecutionHistory{StartedAt: started}).SLABreach(ExecutionStatusSuccess, started))
+}
+
+func TestTaskExecutionStatus(t *testing.T) {
+	assert.Equal(t, ExecutionStatusSuccess, TaskExecutionStatus(WorkflowTaskStatusSuccessed))
+	assert.Equal(t, ExecutionStatusCancelled, TaskExecutionStatus(WorkflowTaskStatusCanceled))
+	assert.Equal(t, ExecutionStatusTimeout, TaskExecutionStatus(WorkflowTaskStatusTimeout))
+	assert.Equal(t, ExecutionStatusFailed, TaskExecutionStatus(WorkflowTaskStatusFailed))
+}
+
+func TestNewActivityHeatmap(t *testing.T) {
+	heatmap := NewActivityHeatmap([]*HeatmapCell{
+		{Weekday: 1, Hour: 9, Count: 3},
+		{Weekday: 1, Hour: 14, Count: 7},
+		{Weekday: 5, Hour: 23, Count: 2},
+		{Weekday: 7, Hour: 0, Count: 5},
+	})
+	assert.Equal(t, int64(12), heatmap.Total)
+	assert.Equal(t, int64(3), heatmap.Matrix[1][9])
+	assert.Equal(t, int64(2), heatmap.Matrix[5][23])
+	assert.Equal(t, &HeatmapCell{Weekday: 1, Hour: 14, Count: 7}, heatmap.Peak)
+
+	assert.Nil(t, NewActivityHeatmap(nil).Peak)
+}
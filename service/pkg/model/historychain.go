@@ -0,0 +1,274 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// ChainRecordType is the kind of record sealed into the history chain
+type ChainRecordType string
+
+const (
+	ChainWorkflowExecution ChainRecordType = "workflow_execution" // 结束后的工作流执行
+	ChainAuditLog          ChainRecordType = "audit_log"
+)
+
+// HistoryChainEntry seals a record into the append-only hash chain of its
+// lab. Hash covers the content hash of the record and the hash of the
+// previous entry, so changing or removing any sealed record breaks every
+// later link. Entries are never updated except to mark records removed by
+// the retention cleanup
+type HistoryChainEntry struct {
+	BaseModel
+	LabID       int64           `gorm:"type:bigint;not null;uniqueIndex:idx_hce_lab_seq,priority:1" json:"lab_id"` // 审计日志不属于实验室时为 0
+	Seq         int64           `gorm:"type:bigint;not null;uniqueIndex:idx_hce_lab_seq,priority:2" json:"seq"`    // 实验室内从 1 开始连续递增
+	RecordType  ChainRecordType `gorm:"type:varchar(32);not null;uniqueIndex:idx_hce_record,priority:1" json:"record_type"`
+	RecordID    int64           `gorm:"type:bigint;not null;uniqueIndex:idx_hce_record,priority:2" json:"record_id"`
+	RecordUUID  uuid.UUID       `gorm:"type:uuid;not null" json:"record_uuid"`
+	ContentHash string          `gorm:"type:varchar(64);not null" json:"content_hash"`
+	PrevHash    string          `gorm:"type:varchar(64);not null;default:''" json:"prev_hash"` // 第一条为空
+	Hash        string          `gorm:"type:varchar(64);not null" json:"hash"`
+	PrunedAt    *time.Time      `json:"pruned_at"` // 记录按保留策略清理的时间，之后只校验链接不校验内容
+}
+
+func (*HistoryChainEntry) TableName() string {
+	return "history_chain"
+}
+
+// HistoryChainAnchor is the head of a lab chain published outside the
+// database, so that a rewrite of the whole chain can still be detected
+type HistoryChainAnchor struct {
+	BaseModel
+	LabID       int64      `gorm:"type:bigint;not null;index:idx_hca_lab" json:"lab_id"`
+	Seq         int64      `gorm:"type:bigint;not null" json:"seq"`
+	Hash        string     `gorm:"type:varchar(64);not null" json:"hash"`
+	Target      string     `gorm:"type:text" json:"target"` // 发布地址，为空时只记录在本地
+	PublishedAt *time.Time `json:"published_at"`
+	Receipt     string     `gorm:"type:text" json:"receipt"` // 外部服务的响应
+	Error       string     `gorm:"type:text" json:"error"`
+}
+
+func (*HistoryChainAnchor) TableName() string {
+	return "history_chain_anchor"
+}
+
+// ChainRecord is a record ready to be sealed
+type ChainRecord struct {
+	LabID       int64
+	Type        ChainRecordType
+	ID          int64
+	UUID        uuid.UUID
+	ContentHash string
+}
+
+// ChainProblem is the kind of a chain verification issue
+type ChainProblem string
+
+const (
+	ChainSeqGap          ChainProblem = "seq_gap"          // 序号不连续，有条目被删除
+	ChainLinkBroken      ChainProblem = "link_broken"      // prev_hash 与上一条的 hash 不一致
+	ChainHashMismatch    ChainProblem = "hash_mismatch"    // 条目被修改
+	ChainContentMismatch ChainProblem = "content_mismatch" // 记录内容被修改
+	ChainRecordMissing   ChainProblem = "record_missing"   // 记录被删除且不是保留策略清理的
+	ChainAnchorMismatch  ChainProblem = "anchor_mismatch"  // 已发布的锚点与链不一致
+)
+
+// ChainIssue is a verification failure at a chain entry
+type ChainIssue struct {
+	Seq        int64           `json:"seq"`
+	RecordType ChainRecordType `json:"record_type,omitempty"`
+	RecordUUID uuid.UUID       `json:"record_uuid"`
+	Problem    ChainProblem    `json:"problem"`
+}
+
+// ChainVerifyResult is the outcome of verifying the chain of a lab
+type ChainVerifyResult struct {
+	Valid      bool          `json:"valid"`
+	Entries    int64         `json:"entries"`
+	Pruned     int64         `json:"pruned"`  // 已清理记录的条目数
+	Anchors    int64         `json:"anchors"` // 校验的锚点数
+	HeadSeq    int64         `json:"head_seq"`
+	HeadHash   string        `json:"head_hash"`
+	Issues     []*ChainIssue `json:"issues"` // 最多返回 maxChainIssues 条
+	VerifiedAt time.Time     `json:"verified_at"`
+}
+
+// maxChainIssues limits the issues kept in a verification result
+const maxChainIssues = 100
+
+// AddIssue records an issue, keeping at most maxChainIssues
+func (r *ChainVerifyResult) AddIssue(issue *ChainIssue) {
+	r.Valid = false
+	if len(r.Issues) < maxChainIssues {
+		r.Issues = append(r.Issues, issue)
+	}
+}
+
+// ChainHash links an entry to the previous one
+func ChainHash(prevHash string, labID, seq int64, recordType ChainRecordType, recordUUID uuid.UUID, contentHash string) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%d|%d|%s|%s|%s", prevHash, labID, seq, recordType, recordUUID, contentHash))
+	return hex.EncodeToString(sum[:])
+}
+
+// NextChainEntry builds the entry sealing the record after head, nil for an empty chain
+func NextChainEntry(head *HistoryChainEntry, rec *ChainRecord) *HistoryChainEntry {
+	entry := &HistoryChainEntry{
+		LabID:       rec.LabID,
+		Seq:         1,
+		RecordType:  rec.Type,
+		RecordID:    rec.ID,
+		RecordUUID:  rec.UUID,
+		ContentHash: rec.ContentHash,
+	}
+	if head != nil {
+		entry.Seq = head.Seq + 1
+		entry.PrevHash = head.Hash
+	}
+	entry.Hash = ChainHash(entry.PrevHash, entry.LabID, entry.Seq, entry.RecordType, entry.RecordUUID, entry.ContentHash)
+	return entry
+}
+
+// VerifyChainSegment checks entries in seq order following prev, nil when
+// entries start the chain. contents maps entry ID to the content hash
+// recomputed from its record, entries of removed records are absent
+func VerifyChainSegment(result *ChainVerifyResult, prev *HistoryChainEntry, entries []*HistoryChainEntry, contents map[int64]string) {
+	for _, e := range entries {
+		issue := func(p ChainProblem) {
+			result.AddIssue(&ChainIssue{Seq: e.Seq, RecordType: e.RecordType, RecordUUID: e.RecordUUID, Problem: p})
+		}
+		expectSeq, expectPrev := int64(1), ""
+		if prev != nil {
+			expectSeq, expectPrev = prev.Seq+1, prev.Hash
+		}
+		if e.Seq != expectSeq {
+			issue(ChainSeqGap)
+		}
+		if e.PrevHash != expectPrev {
+			issue(ChainLinkBroken)
+		}
+		if ChainHash(e.PrevHash, e.LabID, e.Seq, e.RecordType, e.RecordUUID, e.ContentHash) != e.Hash {
+			issue(ChainHashMismatch)
+		}
+		if e.PrunedAt != nil {
+			result.Pruned++
+		} else if hash, ok := contents[e.ID]; !ok {
+			issue(ChainRecordMissing)
+		} else if hash != e.ContentHash {
+			issue(ChainContentMismatch)
+		}
+
+		result.Entries++
+		result.HeadSeq, result.HeadHash = e.Seq, e.Hash
+		prev = e
+	}
+}
+
+// ChainContentHash hashes the sealed content of a record
+func ChainContentHash(content any) (string, error) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// chainTime formats a time the way it reads back from postgres
+func chainTime(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+func chainJSON(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.RawMessage(data)
+}
+
+// executionChainContent is the part of a finished execution sealed into the
+// chain; labels, pins and the experiment can still change afterwards
+type executionChainContent struct {
+	UUID           uuid.UUID       `json:"uuid"`
+	LabID          int64           `json:"lab_id"`
+	UserID         string          `json:"user_id"`
+	WorkflowUUID   uuid.UUID       `json:"workflow_uuid"`
+	WorkflowName   string          `json:"workflow_name"`
+	BatchID        *int64          `json:"batch_id"`
+	Status         ExecutionStatus `json:"status"`
+	StepsTotal     int             `json:"steps_total"`
+	StepsCompleted int             `json:"steps_completed"`
+	StepsFailed    int             `json:"steps_failed"`
+	DurationMs     int64           `json:"duration_ms"`
+	ErrorMessage   *string         `json:"error_message"`
+	ErrorCategory  string          `json:"error_category"`
+	Result         json.RawMessage `json:"result"`
+	Input          json.RawMessage `json:"input"`
+	StartedAt      string          `json:"started_at"`
+	CompletedAt    string          `json:"completed_at"`
+	SLATargetMs    int64           `json:"sla_target_ms"`
+	SLABreached    *bool           `json:"sla_breached"`
+	DefinitionHash string          `json:"definition_hash,omitempty"`
+}
+
+// ChainRecord returns the execution ready to be sealed, it must be read back
+// from the database so that json columns are in their stored form
+func (e *WorkflowExecutionHistory) ChainRecord() (*ChainRecord, error) {
+	content := &executionChainContent{
+		UUID:           e.UUID,
+		LabID:          e.LabID,
+		UserID:         e.UserID,
+		WorkflowUUID:   e.WorkflowUUID,
+		WorkflowName:   e.WorkflowName,
+		BatchID:        e.BatchID,
+		Status:         e.Status,
+		StepsTotal:     e.StepsTotal,
+		StepsCompleted: e.StepsCompleted,
+		StepsFailed:    e.StepsFailed,
+		DurationMs:     e.DurationMs,
+		ErrorMessage:   e.ErrorMessage,
+		ErrorCategory:  e.ErrorCategory,
+		Result:         chainJSON(e.Result),
+		Input:          chainJSON(e.Input),
+		StartedAt:      chainTime(e.StartedAt),
+		SLATargetMs:    e.SLATargetMs,
+		SLABreached:    e.SLABreached,
+		DefinitionHash: e.DefinitionHash,
+	}
+	if e.CompletedAt != nil {
+		content.CompletedAt = chainTime(*e.CompletedAt)
+	}
+	hash, err := ChainContentHash(content)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainRecord{LabID: e.LabID, Type: ChainWorkflowExecution, ID: e.ID, UUID: e.UUID, ContentHash: hash}, nil
+}
+
+// ChainRecord returns the audit log entry ready to be sealed
+func (a *AuditLog) ChainRecord() (*ChainRecord, error) {
+	hash, err := ChainContentHash(map[string]any{
+		"uuid":            a.UUID,
+		"action":          a.Action,
+		"user_id":         a.UserID,
+		"impersonator_id": a.ImpersonatorID,
+		"session_id":      a.SessionID,
+		"lab_id":          a.LabID,
+		"method":          a.Method,
+		"route":           a.Route,
+		"path":            a.Path,
+		"status":          a.Status,
+		"ip":              a.IP,
+		"request_id":      a.RequestID,
+		"detail":          a.Detail,
+		"created_at":      chainTime(a.CreatedAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ChainRecord{LabID: a.LabID, Type: ChainAuditLog, ID: a.ID, UUID: a.UUID, ContentHash: hash}, nil
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func buildChain(n int) ([]*HistoryChainEntry, map[int64]string) {
+	entries := make([]*HistoryChainEntry, 0, n)
+	contents := make(map[int64]string, n)
+	var head *HistoryChainEntry
+	for i := 1; i <= n; i++ {
+		e := NextChainEntry(head, &ChainRecord{
+			LabID:       7,
+			Type:        ChainAuditLog,
+			ID:          int64(i),
+			UUID:        uuid.NewV4(),
+			ContentHash: ChainHash("", 0, int64(i), ChainAuditLog, uuid.NewNil(), "content"),
+		})
+		e.ID = int64(i)
+		contents[e.ID] = e.ContentHash
+		entries = append(entries, e)
+		head = e
+	}
+	return entries, contents
+}
+
+func TestNextChainEntry(t *testing.T) {
+	entries, _ := buildChain(3)
+	assert.Equal(t, int64(1), entries[0].Seq)
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Equal(t, int64(3), entries[2].Seq)
+	assert.Equal(t, entries[1].Hash, entries[2].PrevHash)
+}
+
+func TestVerifyChainSegment(t *testing.T) {
+	entries, contents := buildChain(5)
+
+	result := &ChainVerifyResult{Valid: true}
+	VerifyChainSegment(result, nil, entries[:2], contents)
+	VerifyChainSegment(result, entries[1], entries[2:], contents)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(5), result.Entries)
+	assert.Equal(t, entries[4].Hash, result.HeadHash)
+
+	// 记录内容被修改
+	tampered := map[int64]string{}
+	for k, v := range contents {
+		tampered[k] = v
+	}
+	tampered[3] = "changed"
+	result = &ChainVerifyResult{Valid: true}
+	VerifyChainSegment(result, nil, entries, tampered)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ChainContentMismatch, result.Issues[0].Problem)
+	assert.Equal(t, int64(3), result.Issues[0].Seq)
+
+	// 记录被删除，清理过的记录只校验链接
+	delete(tampered, 3)
+	delete(tampered, 4)
+	now := time.Now()
+	entries[3].PrunedAt = &now
+	result = &ChainVerifyResult{Valid: true}
+	VerifyChainSegment(result, nil, entries, tampered)
+	assert.Len(t, result.Issues, 1)
+	assert.Equal(t, ChainRecordMissing, result.Issues[0].Problem)
+	assert.Equal(t, int64(1), result.Pruned)
+	entries[3].PrunedAt = nil
+
+	// 条目被删除
+	result = &ChainVerifyResult{Valid: true}
+	VerifyChainSegment(result, nil, append([]*HistoryChainEntry{entries[0]}, entries[2:]...), contents)
+	assert.Equal(t, ChainSeqGap, result.Issues[0].Problem)
+	assert.Equal(t, ChainLinkBroken, result.Issues[1].Problem)
+
+	// 条目被修改
+	entries[1].ContentHash = "rewritten"
+	result = &ChainVerifyResult{Valid: true}
+	VerifyChainSegment(result, nil, entries, contents)
+	assert.Equal(t, ChainHashMismatch, result.Issues[0].Problem)
+}
+
+func TestExecutionChainRecord(t *testing.T) {
+	completed := time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.Local)
+	e := &WorkflowExecutionHistory{
+		BaseModel:   BaseModel{ID: 9, UUID: uuid.NewV4()},
+		LabID:       3,
+		Status:      ExecutionStatusSuccess,
+		Result:      []byte(`{"ok":true}`),
+		StartedAt:   completed.Add(-time.Minute),
+		CompletedAt: &completed,
+	}
+	a, err := e.ChainRecord()
+	assert.NoError(t, err)
+	assert.Equal(t, ChainWorkflowExecution, a.Type)
+	assert.Equal(t, int64(3), a.LabID)
+
+	// 标签等可变字段不影响内容哈希
+	e.Labels = datatypes.NewJSONType(map[string]string{"k": "v"})
+	b, _ := e.ChainRecord()
+	assert.Equal(t, a.ContentHash, b.ContentHash)
+
+	e.Status = ExecutionStatusFailed
+	c, _ := e.ChainRecord()
+	assert.NotEqual(t, a.ContentHash, c.ContentHash)
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// HistoryChangeKind 同步接口返回的记录类型
+type HistoryChangeKind string
+
+const (
+	HistoryChangeWorkflow HistoryChangeKind = "workflow_execution"
+	HistoryChangeAction   HistoryChangeKind = "action_execution"
+	HistoryChangeEvent    HistoryChangeKind = "device_event"
+)
+
+// HistoryChangeKinds 更新时间相同的记录按该顺序排列
+var HistoryChangeKinds = []HistoryChangeKind{HistoryChangeWorkflow, HistoryChangeAction, HistoryChangeEvent}
+
+// Order 类型在 HistoryChangeKinds 中的位置，未知类型为 -1
+func (k HistoryChangeKind) Order() int {
+	for i, kind := range HistoryChangeKinds {
+		if kind == k {
+			return i
+		}
+	}
+	return -1
+}
+
+// HistoryWatermark is the position of the last change returned by the sync
+// API. Changes are ordered by (updated_at, kind, id), so the position only
+// moves forward and records updated again are returned again
+type HistoryWatermark struct {
+	UpdatedAt time.Time         `json:"t"`
+	Kind      HistoryChangeKind `json:"k"`
+	ID        int64             `json:"i"`
+}
+
+// ErrInvalidWatermark 水位无法解析
+var ErrInvalidWatermark = errors.New("invalid watermark")
+
+// Encode 编码为不透明的水位字符串
+func (w *HistoryWatermark) Encode() string {
+	data, _ := json.Marshal(w)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseHistoryWatermark 解析 Encode 生成的水位
+func ParseHistoryWatermark(s string) (*HistoryWatermark, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidWatermark
+	}
+	w := &HistoryWatermark{}
+	if err := json.Unmarshal(data, w); err != nil || w.Kind.Order() < 0 || w.UpdatedAt.IsZero() {
+		return nil, ErrInvalidWatermark
+	}
+	return w, nil
+}
+
+// Before 水位是否排在 o 之前
+func (w *HistoryWatermark) Before(o *HistoryWatermark) bool {
+	if !w.UpdatedAt.Equal(o.UpdatedAt) {
+		return w.UpdatedAt.Before(o.UpdatedAt)
+	}
+	if w.Kind != o.Kind {
+		return w.Kind.Order() < o.Kind.Order()
+	}
+	return w.ID < o.ID
+}
+
+// HistoryChange is a history record created or updated after a watermark,
+// exactly one of Execution, Action and Event is set according to Kind
+type HistoryChange struct {
+	Kind      HistoryChangeKind
+	Created   bool // 在起始水位之后创建，没有起始水位时总是 true
+	Execution *WorkflowExecutionHistory
+	Action    *ActionExecutionHistory
+	Event     *DeviceEventHistory
+}
+
+func (c *HistoryChange) base() *BaseModel {
+	switch {
+	case c.Execution != nil:
+		return &c.Execution.BaseModel
+	case c.Action != nil:
+		return &c.Action.BaseModel
+	default:
+		return &c.Event.BaseModel
+	}
+}
+
+// Watermark 该变更的位置
+func (c *HistoryChange) Watermark() *HistoryWatermark {
+	b := c.base()
+	return &HistoryWatermark{UpdatedAt: b.UpdatedAt, Kind: c.Kind, ID: b.ID}
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// HistoryCursor is the position of a cursor page of workflow executions. The
+// snapshot is taken by the first page: later pages only return executions
+// with id up to SnapshotID and started at or before SnapshotAt, so rows
+// written while paging do not shift the pages. StartedAt and ID are the last
+// execution returned, the next page continues after it in
+// (started_at DESC, id DESC) order
+type HistoryCursor struct {
+	LabID      int64     `json:"l"`
+	SnapshotAt time.Time `json:"sa"`
+	SnapshotID int64     `json:"si"`
+	StartedAt  time.Time `json:"a"`
+	ID         int64     `json:"i"`
+}
+
+// ErrInvalidCursor 游标无法解析
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode 编码为不透明的游标字符串
+func (c *HistoryCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseHistoryCursor 解析 Encode 生成的游标
+func ParseHistoryCursor(s string) (*HistoryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := &HistoryCursor{}
+	if err := json.Unmarshal(data, c); err != nil || c.SnapshotID <= 0 || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Includes 记录是否在快照内且排在游标之后
+func (c *HistoryCursor) Includes(startedAt time.Time, id int64) bool {
+	if id > c.SnapshotID || startedAt.After(c.SnapshotAt) {
+		return false
+	}
+	return startedAt.Before(c.StartedAt) || (startedAt.Equal(c.StartedAt) && id < c.ID)
+}
+
+// NextHistoryCursor returns the cursor of the page after the executions when
+// there are more, keeping the snapshot of the current cursor or, on the first
+// page, anchoring a new one at the first execution and snapshotID
+func NextHistoryCursor(params *HistoryQueryParams, count PageCount, snapshotID int64, executions []*WorkflowExecutionHistory) *HistoryCursor {
+	if !count.HasMore || len(executions) == 0 {
+		return nil
+	}
+	last := executions[len(executions)-1]
+	next := &HistoryCursor{
+		LabID:     params.LabID,
+		StartedAt: last.StartedAt,
+		ID:        last.ID,
+	}
+	if params.Cursor != nil {
+		next.SnapshotAt, next.SnapshotID = params.Cursor.SnapshotAt, params.Cursor.SnapshotID
+	} else {
+		next.SnapshotAt, next.SnapshotID = executions[0].StartedAt, snapshotID
+	}
+	return next
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// HistoryExportKind is the history list an export reads
+type HistoryExportKind string
+
+const (
+	HistoryExportWorkflow    HistoryExportKind = "workflow"     // 工作流执行历史
+	HistoryExportAction      HistoryExportKind = "action"       // 动作执行历史
+	HistoryExportDeviceEvent HistoryExportKind = "device_event" // 设备事件历史
+)
+
+// Valid reports whether the kind is known
+func (k HistoryExportKind) Valid() bool {
+	switch k {
+	case HistoryExportWorkflow, HistoryExportAction, HistoryExportDeviceEvent:
+		return true
+	default:
+		return false
+	}
+}
+
+// HistoryExportStatus is the state of a history export
+type HistoryExportStatus string
+
+const (
+	HistoryExportPending   HistoryExportStatus = "pending" // 等待执行或从检查点继续
+	HistoryExportRunning   HistoryExportStatus = "running"
+	HistoryExportCompleted HistoryExportStatus = "completed"
+	HistoryExportFailed    HistoryExportStatus = "failed"
+	HistoryExportExpired   HistoryExportStatus = "expired" // 导出文件已过期并从存储中删除
+)
+
+// HistoryExportFilters are the history query filters of an export
+type HistoryExportFilters struct {
+	WorkflowID *int64     `json:"workflow_id,omitempty"`
+	DeviceID   *int64     `json:"device_id,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	EventType  string     `json:"event_type,omitempty"`
+	Labels     []string   `json:"labels,omitempty"` // key:value 形式的执行标签过滤
+	StartTime  *time.Time `json:"start_time,omitempty"`
+	EndTime    *time.Time `json:"end_time,omitempty"`
+}
+
+// Params converts the filters into the query parameters of a lab
+func (f *HistoryExportFilters) Params(labID int64) (*HistoryQueryParams, error) {
+	params := &HistoryQueryParams{
+		LabID:      labID,
+		WorkflowID: f.WorkflowID,
+		DeviceID:   f.DeviceID,
+		StartTime:  f.StartTime,
+		EndTime:    f.EndTime,
+	}
+	if f.Status != "" {
+		status := ExecutionStatus(f.Status)
+		params.Status = &status
+	}
+	if f.EventType != "" {
+		eventType := DeviceEventType(f.EventType)
+		params.EventType = &eventType
+	}
+	if len(f.Labels) > 0 {
+		labels, err := ParseLabelFilters(f.Labels)
+		if err != nil {
+			return nil, err
+		}
+		params.Labels = labels
+	}
+	return params, nil
+}
+
+// HistoryExport streams the history rows matching a filter into NDJSON parts
+// in object storage. The worker checkpoints after every part, so an export
+// interrupted by a crash or restart resumes from its last part
+type HistoryExport struct {
+	BaseModel
+	LabID          int64                                    `gorm:"type:bigint;not null;uniqueIndex:idx_history_export_key,priority:1" json:"lab_id"`
+	RequestedBy    string                                   `gorm:"type:varchar(120);not null;uniqueIndex:idx_history_export_key,priority:2" json:"requested_by"`
+	IdempotencyKey string                                   `gorm:"type:varchar(128);not null;uniqueIndex:idx_history_export_key,priority:3" json:"idempotency_key"`
+	Kind           HistoryExportKind                        `gorm:"type:varchar(20);not null" json:"kind"`
+	Filters        datatypes.JSONType[HistoryExportFilters] `gorm:"type:jsonb;not null" json:"filters" swaggertype:"object"`
+	Status         HistoryExportStatus                      `gorm:"type:varchar(20);not null;index:idx_history_export_status" json:"status"`
+	Progress       int                                      `gorm:"not null;default:0" json:"progress"` // 完成百分比
+	Rows           int64                                    `gorm:"type:bigint;not null;default:0" json:"rows"`
+	TotalRows      int64                                    `gorm:"type:bigint;not null;default:0" json:"total_rows"` // 开始导出时统计的记录数
+	Parts          int                                      `gorm:"not null;default:0" json:"parts"`                  // 已写入存储的分片数
+	Size           int64                                    `gorm:"type:bigint;not null;default:0" json:"size"`
+	MaxID          int64                                    `gorm:"type:bigint;not null;default:0" json:"-"` // 开始导出时的最大记录 id，之后写入的记录不导出
+	CursorID       int64                                    `gorm:"type:bigint;not null;default:0" json:"-"` // 检查点：已导出的最后一条记录 id
+	Attempts       int                                      `gorm:"not null;default:0" json:"attempts"`      // 执行次数，大于 1 表示从检查点恢复过
+	Error          string                                   `gorm:"type:text" json:"error"`
+	CompletedAt    *time.Time                               `json:"completed_at"`
+	ExpiresAt      *time.Time                               `gorm:"index:idx_history_export_expires" json:"expires_at"` // 完成后导出文件的保留截止时间
+}
+
+func (*HistoryExport) TableName() string {
+	return "history_export"
+}
+
+// PartKey is the object storage key of the n-th part of an export, from 1
+func (h *HistoryExport) PartKey(n int) string {
+	return fmt.Sprintf("history-export/%s/part-%06d.ndjson", h.UUID, n)
+}
+
+// FileName is the name of the downloaded export file
+func (h *HistoryExport) FileName() string {
+	return fmt.Sprintf("history-%s-%s.ndjson", h.Kind, h.UUID)
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// HistoryImportFormat is the file format of a history import
+type HistoryImportFormat string
+
+const (
+	HistoryImportZip     HistoryImportFormat = "zip"     // 实验室导出归档，包含多张历史表
+	HistoryImportJSONL   HistoryImportFormat = "jsonl"   // 单张历史表，每行一条 JSON 记录
+	HistoryImportParquet HistoryImportFormat = "parquet" // 单张历史表
+)
+
+// ImportConflictPolicy decides what happens to a record whose UUID already exists
+type ImportConflictPolicy string
+
+const (
+	ImportConflictSkip      ImportConflictPolicy = "skip"      // 保留已有记录
+	ImportConflictOverwrite ImportConflictPolicy = "overwrite" // 用导入的记录覆盖已有记录
+	ImportConflictFail      ImportConflictPolicy = "fail"      // 存在冲突时整个导入失败并回滚
+)
+
+// HistoryImportStatus is the state of a history import
+type HistoryImportStatus string
+
+const (
+	HistoryImportPending   HistoryImportStatus = "pending"
+	HistoryImportRunning   HistoryImportStatus = "running"
+	HistoryImportCompleted HistoryImportStatus = "completed"
+	HistoryImportFailed    HistoryImportStatus = "failed"
+)
+
+// MaxImportIssues caps the issues kept in the report of an import
+const MaxImportIssues = 100
+
+// ImportIssue is an invalid or conflicting record found by an import
+type ImportIssue struct {
+	Table   string `json:"table"`
+	Line    int64  `json:"line"` // 记录在文件中的序号，从 1 开始
+	UUID    string `json:"uuid,omitempty"`
+	Message string `json:"message"`
+}
+
+// HistoryImport ingests execution history from an exported archive back into
+// the history tables of a lab, keeping the UUIDs of the records
+type HistoryImport struct {
+	BaseModel
+	LabID       int64                             `gorm:"type:bigint;not null;index:idx_history_import_lab" json:"lab_id"`
+	RequestedBy string                            `gorm:"type:varchar(120);not null" json:"requested_by"`
+	Format      HistoryImportFormat               `gorm:"type:varchar(20);not null" json:"format"`
+	Table       string                            `gorm:"type:varchar(64)" json:"table"` // jsonl、parquet 文件对应的历史表
+	Policy      ImportConflictPolicy              `gorm:"type:varchar(20);not null" json:"policy"`
+	DryRun      bool                              `gorm:"not null;default:false" json:"dry_run"` // 只校验并生成报告，不写入
+	Size        int64                             `gorm:"type:bigint;not null;default:0" json:"size"`
+	Status      HistoryImportStatus               `gorm:"type:varchar(20);not null" json:"status"`
+	Processed   int64                             `gorm:"type:bigint;not null;default:0" json:"processed"`
+	Inserted    int64                             `gorm:"type:bigint;not null;default:0" json:"inserted"`
+	Updated     int64                             `gorm:"type:bigint;not null;default:0" json:"updated"`
+	Skipped     int64                             `gorm:"type:bigint;not null;default:0" json:"skipped"`
+	Invalid     int64                             `gorm:"type:bigint;not null;default:0" json:"invalid"`
+	Issues      datatypes.JSONType[[]ImportIssue] `gorm:"type:jsonb;not null;default:'[]'" json:"issues" swaggertype:"array,object"` // 最多保留 MaxImportIssues 条
+	Error       string                            `gorm:"type:text" json:"error"`
+	CompletedAt *time.Time                        `json:"completed_at"`
+}
+
+func (*HistoryImport) TableName() string {
+	return "history_import"
+}
+
+// AddIssue records an issue, keeping at most MaxImportIssues of them
+func (h *HistoryImport) AddIssue(issue ImportIssue) {
+	issues := h.Issues.Data()
+	if len(issues) >= MaxImportIssues {
+		return
+	}
+	h.Issues = datatypes.NewJSONType(append(issues, issue))
+}
+
+// HistoryImportTables lists the tables an import writes, parents before the
+// children that refer to them
+var HistoryImportTables = []string{
+	"workflow_execution_history",
+	"action_execution_history",
+	"device_event_history",
+}
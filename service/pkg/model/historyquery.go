@@ -0,0 +1,20 @@
+package model
+
+const (
+	InterpretSourceLLM   = "llm"   // 由助手配置的大模型解析
+	InterpretSourceRules = "rules" // 由内置的规则解析
+)
+
+// HistoryInterpretation is the history filter understood from a
+// natural-language question, in the shape of a saved view so that it can be
+// corrected and saved
+type HistoryInterpretation struct {
+	Question     string           `json:"question"`
+	LabID        int64            `json:"lab_id"`
+	Target       SavedViewTarget  `json:"target"`
+	Filters      SavedViewFilters `json:"filters"`
+	WorkflowName string           `json:"workflow_name,omitempty"` // filters.workflow_id 对应的工作流
+	DeviceName   string           `json:"device_name,omitempty"`   // filters.device_id 对应的设备
+	Source       string           `json:"source"`                  // llm | rules
+	Warnings     []string         `json:"warnings"`                // 无法识别或被忽略的部分
+}
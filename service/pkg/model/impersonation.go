@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// ImpersonationSession is a time-limited session of an admin acting as a lab
+// member. Only the hash of its token is stored
+type ImpersonationSession struct {
+	BaseModel
+	ImpersonatorID string     `gorm:"type:varchar(120);not null;index:idx_impersonation_impersonator" json:"impersonator_id"`
+	UserID         string     `gorm:"type:varchar(120);not null" json:"user_id"` // 被模拟的用户
+	UserName       string     `gorm:"type:varchar(255)" json:"user_name"`
+	UserOwner      string     `gorm:"type:varchar(120)" json:"user_owner"`
+	LabID          int64      `gorm:"type:bigint;not null" json:"lab_id"` // 模拟会话只能访问该实验室
+	LabUUID        uuid.UUID  `gorm:"type:uuid;not null" json:"lab_uuid"`
+	Reason         string     `gorm:"type:text;not null" json:"reason"`
+	TokenHash      string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_impersonation_token" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedBy      string     `gorm:"type:varchar(120)" json:"revoked_by"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+}
+
+func (*ImpersonationSession) TableName() string {
+	return "impersonation_session"
+}
+
+// Active reports whether the session can still be used
+func (s *ImpersonationSession) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// AuditAction is the kind of an audit log entry
+type AuditAction string
+
+const (
+	AuditImpersonationStart  AuditAction = "impersonation.start"
+	AuditImpersonationRevoke AuditAction = "impersonation.revoke"
+	AuditImpersonatedRequest AuditAction = "impersonation.request" // 模拟会话发起的请求
+	AuditImpersonationDenied AuditAction = "impersonation.denied"  // 模拟会话访问禁止模拟的接口或其他实验室
+)
+
+// AuditLog records an action of a user, with the admin acting on their
+// behalf when the action is impersonated
+type AuditLog struct {
+	BaseModel
+	Action         AuditAction `gorm:"type:varchar(50);not null" json:"action"`
+	UserID         string      `gorm:"type:varchar(120);not null" json:"user_id"`
+	ImpersonatorID string      `gorm:"type:varchar(120);index:idx_audit_impersonator" json:"impersonator_id"`
+	SessionID      int64       `gorm:"type:bigint;index:idx_audit_session" json:"session_id"`
+	LabID          int64       `gorm:"type:bigint" json:"lab_id"`
+	Method         string      `gorm:"type:varchar(10)" json:"method"`
+	Route          string      `gorm:"type:varchar(255)" json:"route"`
+	Path           string      `gorm:"type:text" json:"path"`
+	Status         int         `json:"status"`
+	IP             string      `gorm:"type:varchar(64)" json:"ip"`
+	RequestID      string      `gorm:"type:varchar(64)" json:"request_id"`
+	Detail         string      `gorm:"type:text" json:"detail"`
+}
+
+func (*AuditLog) TableName() string {
+	return "audit_log"
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"time"
+)
+
+// EdgeIngestStream tracks the replay of the action results an edge agent
+// buffered while offline. Records of a stream carry increasing sequence
+// numbers; HighWaterMark is the largest sequence number up to which every
+// record has been ingested, records above it are kept in EdgeIngestSeq
+type EdgeIngestStream struct {
+	BaseModel
+	LabID          int64     `gorm:"type:bigint;not null;uniqueIndex:idx_eis_lab_stream,priority:1" json:"lab_id"`
+	Stream         string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_eis_lab_stream,priority:2" json:"stream"` // edge 选择的流标识，如 agent 实例 ID
+	HighWaterMark  int64     `gorm:"type:bigint;not null;default:0" json:"high_water_mark"`
+	MaxSeq         int64     `gorm:"type:bigint;not null;default:0" json:"max_seq"` // 收到的最大序号
+	Accepted       int64     `gorm:"type:bigint;not null;default:0" json:"accepted"`
+	Duplicates     int64     `gorm:"type:bigint;not null;default:0" json:"duplicates"`
+	LastIngestedAt time.Time `gorm:"not null" json:"last_ingested_at"`
+}
+
+func (*EdgeIngestStream) TableName() string {
+	return "edge_ingest_stream"
+}
+
+// EdgeIngestSeq is a sequence number ingested above the high-water mark of
+// its stream, removed once the high-water mark passes it
+type EdgeIngestSeq struct {
+	StreamID int64 `gorm:"type:bigint;primaryKey" json:"-"`
+	Seq      int64 `gorm:"type:bigint;primaryKey" json:"seq"`
+}
+
+func (*EdgeIngestSeq) TableName() string {
+	return "edge_ingest_seq"
+}
+
+// IngestAckStatus is the outcome of one replayed record
+type IngestAckStatus string
+
+const (
+	IngestAccepted  IngestAckStatus = "accepted"
+	IngestDuplicate IngestAckStatus = "duplicate" // 序号或 UUID 已经写入，edge 可以丢弃
+	IngestRejected  IngestAckStatus = "rejected"  // 记录无效，重放也不会成功
+)
+
+// IngestAck acknowledges one replayed record
+type IngestAck struct {
+	Seq    int64           `json:"seq"`
+	Status IngestAckStatus `json:"status"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// IngestRecord is a buffered action result with its sequence number, ready to
+// be written with its client-assigned UUID and original timestamps. A record
+// without Action only consumes its sequence number, for records rejected as invalid
+type IngestRecord struct {
+	Seq    int64
+	Action *ActionExecutionHistory
+}
+
+// IngestResult is the outcome of ingesting a batch of a stream
+type IngestResult struct {
+	Stream     *EdgeIngestStream
+	Accepted   map[int64]bool // 写入的序号
+	Duplicates map[int64]bool // 已写入过的序号或 UUID
+}
+
+// AdvanceHighWaterMark returns the high-water mark after the sequence numbers
+// above it, sorted ascending, have been ingested
+func AdvanceHighWaterMark(hwm int64, seqs []int64) int64 {
+	for _, seq := range seqs {
+		if seq > hwm+1 {
+			break
+		}
+		if seq == hwm+1 {
+			hwm = seq
+		}
+	}
+	return hwm
+}
+
+// MissingSeqs lists the sequence numbers between the high-water mark and the
+// largest received one that have not been ingested, at most limit of them
+func MissingSeqs(hwm, maxSeq int64, received []int64, limit int) []int64 {
+	got := make(map[int64]bool, len(received))
+	for _, seq := range received {
+		got[seq] = true
+	}
+	missing := make([]int64, 0)
+	for seq := hwm + 1; seq < maxSeq && len(missing) < limit; seq++ {
+		if !got[seq] {
+			missing = append(missing, seq)
+		}
+	}
+	return missing
+}
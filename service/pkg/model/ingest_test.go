@@ -0,0 +1,20 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdvanceHighWaterMark(t *testing.T) {
+	assert.Equal(t, int64(0), AdvanceHighWaterMark(0, nil))
+	assert.Equal(t, int64(3), AdvanceHighWaterMark(0, []int64{1, 2, 3, 5, 6}))
+	assert.Equal(t, int64(4), AdvanceHighWaterMark(2, []int64{2, 3, 4, 6}))
+	assert.Equal(t, int64(10), AdvanceHighWaterMark(10, []int64{12, 13}))
+}
+
+func TestMissingSeqs(t *testing.T) {
+	assert.Equal(t, []int64{}, MissingSeqs(5, 5, nil, 10))
+	assert.Equal(t, []int64{6, 8}, MissingSeqs(5, 10, []int64{7, 9, 10}, 10))
+	assert.Equal(t, []int64{1, 2}, MissingSeqs(0, 100, nil, 2))
+}
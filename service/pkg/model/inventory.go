@@ -0,0 +1,138 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// MaterialKind classifies a registered material lot
+type MaterialKind string
+
+const (
+	MaterialKindSample     MaterialKind = "sample"
+	MaterialKindReagent    MaterialKind = "reagent"
+	MaterialKindConsumable MaterialKind = "consumable"
+)
+
+// Valid reports whether the kind is known
+func (k MaterialKind) Valid() bool {
+	switch k {
+	case MaterialKindSample, MaterialKindReagent, MaterialKindConsumable:
+		return true
+	}
+	return false
+}
+
+// MaterialSource records where a material lot was registered
+type MaterialSource string
+
+const (
+	MaterialSourceManual MaterialSource = "manual"
+	MaterialSourceLIMS   MaterialSource = "lims"
+)
+
+// Material is a tracked lot of a sample, reagent or consumable in a lab
+type Material struct {
+	BaseModel
+	LabID      int64          `gorm:"type:bigint;not null;uniqueIndex:idx_material_lab_lot,priority:1;index:idx_material_lab_external,priority:1" json:"lab_id"`
+	Name       string         `gorm:"type:varchar(255);not null" json:"name"`
+	LotNumber  string         `gorm:"type:varchar(255);not null;uniqueIndex:idx_material_lab_lot,priority:2" json:"lot_number"`
+	Kind       MaterialKind   `gorm:"type:varchar(20);not null" json:"kind"`
+	Unit       string         `gorm:"type:varchar(32);not null" json:"unit"`
+	Quantity   float64        `gorm:"not null" json:"quantity"`  // 登记时的数量
+	Remaining  float64        `gorm:"not null" json:"remaining"` // 扣减消耗后的剩余数量
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	CreatorID  string         `gorm:"type:varchar(120)" json:"creator_id"`
+	Source     MaterialSource `gorm:"type:varchar(20);not null;default:'manual'" json:"source"`
+	ExternalID string         `gorm:"type:varchar(255);index:idx_material_lab_external,priority:2" json:"external_id"` // LIMS 中的 id
+	SyncedAt   *time.Time     `json:"synced_at"`                                                                       // 最近一次与 LIMS 同步的时间
+}
+
+func (*Material) TableName() string {
+	return "material"
+}
+
+// MaterialUsage records the quantity of a material lot consumed by an execution
+type MaterialUsage struct {
+	BaseModel
+	LabID               int64      `gorm:"type:bigint;not null;index:idx_material_usage_lab" json:"lab_id"`
+	MaterialID          int64      `gorm:"type:bigint;not null;index:idx_material_usage_material" json:"material_id"`
+	MaterialUUID        uuid.UUID  `gorm:"type:uuid;not null" json:"material_uuid"`
+	WorkflowExecutionID int64      `gorm:"type:bigint;not null;index:idx_material_usage_wf_exec" json:"workflow_execution_id"`
+	ActionExecutionID   *int64     `gorm:"type:bigint" json:"action_execution_id"`
+	ActionExecutionUUID *uuid.UUID `gorm:"type:uuid" json:"action_execution_uuid"`
+	Quantity            float64    `gorm:"not null" json:"quantity"`
+	Note                string     `gorm:"type:text" json:"note"`
+	RecordedBy          string     `gorm:"type:varchar(120)" json:"recorded_by"` // edge 上报时为空
+}
+
+func (*MaterialUsage) TableName() string {
+	return "material_usage"
+}
+
+// MaterialUsageSummary is the total quantity of one lot consumed by an execution
+type MaterialUsageSummary struct {
+	MaterialUUID uuid.UUID    `json:"material_uuid"`
+	Name         string       `json:"name"`
+	LotNumber    string       `json:"lot_number"`
+	Kind         MaterialKind `json:"kind"`
+	Unit         string       `json:"unit"`
+	Quantity     float64      `json:"quantity"`
+}
+
+// MaterialSyncStatus represents the state of an inventory sync run
+type MaterialSyncStatus string
+
+const (
+	MaterialSyncRunning   MaterialSyncStatus = "running"
+	MaterialSyncSucceeded MaterialSyncStatus = "succeeded"
+	MaterialSyncFailed    MaterialSyncStatus = "failed"
+)
+
+// MaterialSyncRun is one reconciliation of a lab's inventory with the LIMS
+type MaterialSyncRun struct {
+	BaseModel
+	LabID      int64              `gorm:"type:bigint;not null;index:idx_material_sync_run_lab,priority:1" json:"lab_id"`
+	Trigger    JobTrigger         `gorm:"type:varchar(20);not null" json:"trigger"`
+	Status     MaterialSyncStatus `gorm:"type:varchar(20);not null" json:"status"`
+	StartedAt  time.Time          `gorm:"not null;index:idx_material_sync_run_lab,priority:2" json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at"`
+	Fetched    int                `gorm:"not null;default:0" json:"fetched"` // LIMS 返回的批次数
+	Created    int                `gorm:"not null;default:0" json:"created"`
+	Updated    int                `gorm:"not null;default:0" json:"updated"`
+	Conflicts  int                `gorm:"not null;default:0" json:"conflicts"`
+	Error      string             `gorm:"type:text" json:"error"`
+}
+
+func (*MaterialSyncRun) TableName() string {
+	return "material_sync_run"
+}
+
+// MaterialSyncResolution records how a sync conflict was handled
+type MaterialSyncResolution string
+
+const (
+	MaterialSyncKeptLocal MaterialSyncResolution = "kept_local" // 保留本地值
+	MaterialSyncSkipped   MaterialSyncResolution = "skipped"    // LIMS 数据未同步
+)
+
+// MaterialSyncConflict is a difference between the LIMS and the local registry
+// that a sync could not reconcile; it stays open until a run no longer sees it
+type MaterialSyncConflict struct {
+	BaseModel
+	LabID       int64                  `gorm:"type:bigint;not null;uniqueIndex:idx_material_sync_conflict,priority:1" json:"lab_id"`
+	ExternalID  string                 `gorm:"type:varchar(255);not null;uniqueIndex:idx_material_sync_conflict,priority:2" json:"external_id"`
+	Field       string                 `gorm:"type:varchar(64);not null;uniqueIndex:idx_material_sync_conflict,priority:3" json:"field"`
+	MaterialID  *int64                 `gorm:"type:bigint" json:"material_id"`
+	LotNumber   string                 `gorm:"type:varchar(255)" json:"lot_number"`
+	LocalValue  string                 `gorm:"type:text" json:"local_value"`
+	RemoteValue string                 `gorm:"type:text" json:"remote_value"`
+	Resolution  MaterialSyncResolution `gorm:"type:varchar(20);not null" json:"resolution"`
+	RunID       int64                  `gorm:"type:bigint;not null" json:"run_id"` // 最近一次发现该冲突的同步
+	ResolvedAt  *time.Time             `json:"resolved_at"`
+}
+
+func (*MaterialSyncConflict) TableName() string {
+	return "material_sync_conflict"
+}
@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// JobScheduleType represents how a background job is scheduled
+type JobScheduleType string
+
+const (
+	JobScheduleCron     JobScheduleType = "cron"     // 5 段 cron 表达式
+	JobScheduleInterval JobScheduleType = "interval" // Go duration，例如 10m
+	JobScheduleOnce     JobScheduleType = "once"     // RFC3339 时间，只执行一次
+)
+
+// JobRunStatus represents the result of one background job run
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning JobRunStatus = "running"
+	JobRunStatusSuccess JobRunStatus = "success"
+	JobRunStatusFailed  JobRunStatus = "failed"
+	JobRunStatusTimeout JobRunStatus = "timeout"
+)
+
+// JobTrigger represents what started a background job run
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual"
+)
+
+// BackgroundJob is the persistent definition and schedule state of a background job
+type BackgroundJob struct {
+	BaseModel
+	Name             string          `gorm:"type:varchar(120);not null;uniqueIndex:idx_bj_name" json:"name"`
+	Description      string          `gorm:"type:text" json:"description"`
+	ScheduleType     JobScheduleType `gorm:"type:varchar(20);not null" json:"schedule_type"`
+	Schedule         string          `gorm:"type:varchar(120);not null" json:"schedule"`
+	TimeoutSec       int             `gorm:"type:int;not null;default:0" json:"timeout_sec"`
+	MaxRetries       int             `gorm:"type:int;not null;default:0" json:"max_retries"`
+	RetryBackoffSec  int             `gorm:"type:int;not null;default:0" json:"retry_backoff_sec"`
+	Paused           bool            `gorm:"not null;default:false" json:"paused"`
+	TriggerRequested bool            `gorm:"not null;default:false" json:"trigger_requested"` // 管理员请求立即执行
+	NextRunAt        *time.Time      `gorm:"index:idx_bj_next_run" json:"next_run_at"`
+	LastRunAt        *time.Time      `json:"last_run_at"`
+	LastStatus       JobRunStatus    `gorm:"type:varchar(20)" json:"last_status"`
+	LastError        string          `gorm:"type:text" json:"last_error"`
+}
+
+func (*BackgroundJob) TableName() string {
+	return "background_job"
+}
+
+// BackgroundJobRun is one execution of a background job, including retries
+type BackgroundJobRun struct {
+	BaseModel
+	JobID      int64        `gorm:"type:bigint;not null;index:idx_bjr_job_created,priority:1" json:"job_id"`
+	JobName    string       `gorm:"type:varchar(120);not null" json:"job_name"`
+	Trigger    JobTrigger   `gorm:"type:varchar(20);not null" json:"trigger"`
+	Status     JobRunStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Attempts   int          `gorm:"type:int;not null;default:0" json:"attempts"`
+	Instance   string       `gorm:"type:varchar(255)" json:"instance"` // 执行的副本
+	StartedAt  time.Time    `gorm:"not null;index:idx_bjr_job_created,priority:2" json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at"`
+	DurationMs int64        `gorm:"type:bigint;not null;default:0" json:"duration_ms"`
+	Error      string       `gorm:"type:text" json:"error"`
+}
+
+func (*BackgroundJobRun) TableName() string {
+	return "background_job_run"
+}
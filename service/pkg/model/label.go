@@ -0,0 +1,59 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	MaxExecutionLabels = 32
+	maxLabelValueLen   = 255
+)
+
+// label keys are letters, digits and . _ - /, e.g. project or team.owner
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]{0,62}$`)
+
+// LabelCount is a label key or value with the number of executions using it
+type LabelCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// ValidateLabels checks the keys, values and number of execution labels
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > MaxExecutionLabels {
+		return fmt.Errorf("at most %d labels", MaxExecutionLabels)
+	}
+	for k, v := range labels {
+		if !labelKeyPattern.MatchString(k) {
+			return fmt.Errorf("invalid label key %q", k)
+		}
+		if len(v) > maxLabelValueLen {
+			return fmt.Errorf("value of label %s exceeds %d bytes", k, maxLabelValueLen)
+		}
+	}
+	return nil
+}
+
+// ParseLabelFilters parses key:value label filters, all of which must match
+func ParseLabelFilters(filters []string) (map[string]string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(filters))
+	for _, f := range filters {
+		k, v, ok := strings.Cut(f, ":")
+		if !ok {
+			return nil, fmt.Errorf("label filter %q must be key:value", f)
+		}
+		if prev, exists := labels[k]; exists && prev != v {
+			return nil, fmt.Errorf("conflicting filters for label %s", k)
+		}
+		labels[k] = v
+	}
+	if err := ValidateLabels(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabelFilters(t *testing.T) {
+	labels, err := ParseLabelFilters([]string{"project:alpha", "team.owner:a:b", "empty:"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"project": "alpha", "team.owner": "a:b", "empty": ""}, labels)
+
+	labels, err = ParseLabelFilters(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, labels)
+
+	for _, filters := range [][]string{
+		{"project"},
+		{"project:alpha", "project:beta"},
+		{":alpha"},
+		{"bad key:alpha"},
+		{"project:" + strings.Repeat("x", maxLabelValueLen+1)},
+	} {
+		_, err := ParseLabelFilters(filters)
+		assert.Error(t, err, filters)
+	}
+}
@@ -0,0 +1,119 @@
+package model
+
+import "time"
+
+// LabExportStatus is the state of a lab data export
+type LabExportStatus string
+
+const (
+	LabExportPending   LabExportStatus = "pending"
+	LabExportRunning   LabExportStatus = "running"
+	LabExportCompleted LabExportStatus = "completed"
+	LabExportFailed    LabExportStatus = "failed"
+	LabExportExpired   LabExportStatus = "expired" // 归档已过期并从存储中删除
+)
+
+// Finished reports whether the export no longer changes
+func (s LabExportStatus) Finished() bool {
+	switch s {
+	case LabExportCompleted, LabExportFailed, LabExportExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// LabExport is an asynchronous dump of all data of a lab into a zip archive
+type LabExport struct {
+	BaseModel
+	LabID       int64           `gorm:"type:bigint;not null;index:idx_lab_export_lab" json:"lab_id"`
+	RequestedBy string          `gorm:"type:varchar(120);not null" json:"requested_by"`
+	Status      LabExportStatus `gorm:"type:varchar(20);not null;index:idx_lab_export_status" json:"status"`
+	Progress    int             `gorm:"not null;default:0" json:"progress"`     // 完成百分比
+	Tables      int             `gorm:"not null;default:0" json:"tables"`       // 已导出的数据表数量
+	TotalTables int             `gorm:"not null;default:0" json:"total_tables"` // 需要导出的数据表数量
+	Rows        int64           `gorm:"type:bigint;not null;default:0" json:"rows"`
+	FileName    string          `gorm:"type:varchar(255)" json:"file_name"`
+	Size        int64           `gorm:"type:bigint;not null;default:0" json:"size"`
+	StorageKey  string          `gorm:"type:varchar(512)" json:"-"`
+	Error       string          `gorm:"type:text" json:"error"`
+	CompletedAt *time.Time      `json:"completed_at"`
+	ExpiresAt   *time.Time      `gorm:"index:idx_lab_export_expires" json:"expires_at"` // 完成后归档的保留截止时间
+}
+
+func (*LabExport) TableName() string {
+	return "lab_export"
+}
+
+// LabExportFile is the path in an export archive of the rows of a lab table,
+// one JSON record per line
+func LabExportFile(table string) string {
+	return "data/" + table + ".ndjson"
+}
+
+// LabTable is a table holding data of a lab; Filter selects the rows of one
+// lab with a single placeholder for the lab id
+type LabTable struct {
+	Name   string
+	Filter string
+	Omit   []string // 导出时去除的敏感列
+	Keep   bool     // 删除实验室数据时保留，实验室本身和成员由实验室删除流程处理
+}
+
+const (
+	labWorkflowIDs   = "SELECT id FROM workflow WHERE lab_id = ?"
+	labAnnotationIDs = "SELECT id FROM execution_annotation WHERE lab_id = ?"
+)
+
+// LabDataTables lists the tables exported and deleted with a lab, parents before
+// the children whose filters refer to them
+var LabDataTables = []LabTable{
+	{Name: "laboratory", Filter: "id = ?", Omit: []string{"access_key", "access_secret"}, Keep: true},
+	{Name: "laboratory_member", Filter: "lab_id = ?", Keep: true},
+	// 配置
+	{Name: "resource_node_template", Filter: "lab_id = ?"},
+	{Name: "resource_handle_template", Filter: "resource_node_id IN (SELECT id FROM resource_node_template WHERE lab_id = ?)"},
+	{Name: "workflow_node_template", Filter: "lab_id = ?"},
+	{Name: "workflow_handle_template", Filter: "workflow_node_id IN (SELECT id FROM workflow_node_template WHERE lab_id = ?)"},
+	{Name: "material_node", Filter: "lab_id = ?"},
+	{Name: "material_edge", Filter: "source_node_uuid IN (SELECT uuid FROM material_node WHERE lab_id = ?)"},
+	{Name: "workflow", Filter: "lab_id = ?"},
+	{Name: "workflow_node", Filter: "workflow_id IN (" + labWorkflowIDs + ")"},
+	{Name: "workflow_edge", Filter: "source_node_uuid IN (SELECT uuid FROM workflow_node WHERE workflow_id IN (" + labWorkflowIDs + "))"},
+	{Name: "device", Filter: "lab_id = ?"},
+	{Name: "device_limit", Filter: "lab_id = ?"},
+	{Name: "device_rule", Filter: "lab_id = ?"},
+	{Name: "alert_silence", Filter: "lab_id = ?"},
+	{Name: "camera_stream", Filter: "lab_id = ?"},
+	{Name: "cost_model", Filter: "lab_id = ?"},
+	{Name: "saved_view", Filter: "lab_id = ?"},
+	{Name: "report_subscription", Filter: "lab_id = ?"},
+	// 运行数据
+	{Name: "workflow_task", Filter: "lab_id = ?"},
+	{Name: "workflow_node_job", Filter: "lab_id = ?"},
+	{Name: "workflow_batch", Filter: "lab_id = ?"},
+	{Name: "workflow_held_task", Filter: "lab_id = ?"},
+	{Name: "workflow_task_dependency", Filter: "task_uuid IN (SELECT uuid FROM workflow_task WHERE lab_id = ?)"},
+	{Name: "approval_request", Filter: "lab_id = ?"},
+	{Name: "dead_letter_job", Filter: "lab_id = ?"},
+	{Name: "device_reservation", Filter: "lab_id = ?"},
+	{Name: "device_command", Filter: "lab_id = ?"},
+	{Name: "device_liveness", Filter: "lab_id = ?"},
+	{Name: "device_telemetry_rollup", Filter: "lab_id = ?"},
+	{Name: "alert", Filter: "lab_id = ?"},
+	{Name: "stream_session", Filter: "lab_id = ?"},
+	{Name: "material", Filter: "lab_id = ?"},
+	{Name: "material_usage", Filter: "lab_id = ?"},
+	{Name: "material_sync_run", Filter: "lab_id = ?"},
+	{Name: "material_sync_conflict", Filter: "lab_id = ?"},
+	{Name: "result_file", Filter: "lab_id = ?"},
+	{Name: "cost_record", Filter: "lab_id = ?"},
+	{Name: "report_delivery", Filter: "lab_id = ?"},
+	// 历史记录
+	{Name: "workflow_execution_history", Filter: "lab_id = ?"},
+	{Name: "action_execution_history", Filter: "lab_id = ?"},
+	{Name: "device_event_history", Filter: "lab_id = ?"},
+	{Name: "execution_annotation", Filter: "lab_id = ?"},
+	{Name: "execution_annotation_revision", Filter: "annotation_id IN (" + labAnnotationIDs + ")"},
+	{Name: "execution_pin", Filter: "lab_id = ?"},
+}
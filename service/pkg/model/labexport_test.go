@@ -0,0 +1,27 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabDataTables(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, table := range LabDataTables {
+		assert.False(t, seen[table.Name], table.Name)
+		assert.Equal(t, 1, strings.Count(table.Filter, "?"), table.Name)
+		// 子表的过滤条件只能引用排在前面的表，删除时倒序执行
+		words := strings.Fields(table.Filter)
+		for i, word := range words {
+			if word == "FROM" && i+1 < len(words) {
+				assert.True(t, seen[words[i+1]], "%s refers to %s", table.Name, words[i+1])
+			}
+		}
+		seen[table.Name] = true
+	}
+	assert.True(t, seen["workflow_execution_history"])
+	assert.True(t, LabExportFailed.Finished())
+	assert.False(t, LabExportRunning.Finished())
+}
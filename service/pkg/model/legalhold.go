@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// LegalHoldScope is what a legal hold covers
+type LegalHoldScope string
+
+const (
+	LegalHoldExecution LegalHoldScope = "execution"  // 单次工作流执行及其动作记录
+	LegalHoldWorkflow  LegalHoldScope = "workflow"   // 工作流的所有执行
+	LegalHoldLab       LegalHoldScope = "lab"        // 实验室的所有历史记录
+	LegalHoldTimeRange LegalHoldScope = "time_range" // 时间段内的历史记录，可限定实验室
+)
+
+// Valid reports whether the scope is known
+func (s LegalHoldScope) Valid() bool {
+	switch s {
+	case LegalHoldExecution, LegalHoldWorkflow, LegalHoldLab, LegalHoldTimeRange:
+		return true
+	}
+	return false
+}
+
+// Audit actions of legal holds
+const (
+	AuditLegalHoldPlace   AuditAction = "legal_hold.place"
+	AuditLegalHoldRelease AuditAction = "legal_hold.release"
+)
+
+// LegalHold keeps history records from being removed by the retention
+// cleanup while it is active. A hold cannot be changed once placed, it can
+// only be released
+type LegalHold struct {
+	BaseModel
+	Scope         LegalHoldScope `gorm:"type:varchar(20);not null" json:"scope"`
+	LabID         int64          `gorm:"type:bigint;not null;default:0;index:idx_legal_hold_lab" json:"lab_id"` // 不限实验室的时间段为 0
+	ExecutionID   int64          `gorm:"type:bigint;not null;default:0" json:"execution_id"`
+	WorkflowID    int64          `gorm:"type:bigint;not null;default:0" json:"workflow_id"`
+	TargetUUID    uuid.UUID      `gorm:"type:uuid" json:"target_uuid"` // 执行或工作流的 UUID
+	StartsAt      *time.Time     `json:"starts_at"`
+	EndsAt        *time.Time     `json:"ends_at"`
+	Reason        string         `gorm:"type:text;not null" json:"reason"`
+	PlacedBy      string         `gorm:"type:varchar(120);not null" json:"placed_by"`
+	ReleasedAt    *time.Time     `gorm:"index:idx_legal_hold_released" json:"released_at"`
+	ReleasedBy    string         `gorm:"type:varchar(120)" json:"released_by"`
+	ReleaseReason string         `gorm:"type:text" json:"release_reason"`
+}
+
+func (*LegalHold) TableName() string {
+	return "legal_hold"
+}
+
+// Active reports whether the hold still keeps records
+func (h *LegalHold) Active() bool {
+	return h.ReleasedAt == nil
+}
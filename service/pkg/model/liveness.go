@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// LivenessStatus represents whether a device is reachable through its edge agent
+type LivenessStatus string
+
+const (
+	LivenessOnline  LivenessStatus = "online"
+	LivenessOffline LivenessStatus = "offline"
+)
+
+// DeviceLiveness tracks the last heartbeat of a device reported by its edge agent
+type DeviceLiveness struct {
+	BaseModel
+	LabID        int64          `gorm:"type:bigint;not null;uniqueIndex:idx_dl_lab_device,priority:1" json:"lab_id"`
+	DeviceID     int64          `gorm:"type:bigint;not null" json:"device_id"`
+	DeviceUUID   uuid.UUID      `gorm:"type:uuid;not null" json:"device_uuid"`
+	DeviceName   string         `gorm:"type:varchar(255);not null;uniqueIndex:idx_dl_lab_device,priority:2" json:"device_name"`
+	AgentVersion string         `gorm:"type:varchar(64)" json:"agent_version"`
+	IP           string         `gorm:"type:varchar(64)" json:"ip"`
+	Status       LivenessStatus `gorm:"type:varchar(20);not null;index:idx_dl_status_seen,priority:1" json:"status"`
+	LastSeenAt   time.Time      `gorm:"not null;index:idx_dl_status_seen,priority:2" json:"last_seen_at"`
+}
+
+func (*DeviceLiveness) TableName() string {
+	return "device_liveness"
+}
+
+// LivenessSummary counts devices by liveness status across the fleet
+type LivenessSummary struct {
+	Online  int64 `json:"online"`
+	Offline int64 `json:"offline"`
+}
+
+// AgentVersionCount counts the devices of a lab reporting an agent version
+type AgentVersionCount struct {
+	Version string `json:"version"`
+	Devices int64  `json:"devices"`
+	Online  int64  `json:"online"`
+}
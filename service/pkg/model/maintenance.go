@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// MaintenanceWindow puts the whole service, or one lab, in read-only
+// maintenance until it is deleted
+type MaintenanceWindow struct {
+	BaseModel
+	LabID     int64      `gorm:"type:bigint;not null;uniqueIndex:idx_maintenance_lab" json:"-"` // 0 表示全局维护
+	LabUUID   uuid.UUID  `gorm:"type:uuid" json:"lab_uuid"`
+	Message   string     `gorm:"type:text" json:"message"`
+	ETA       *time.Time `json:"eta"` // 预计结束时间，为空时未知
+	StartedBy string     `gorm:"type:varchar(120);not null" json:"started_by"`
+}
+
+func (*MaintenanceWindow) TableName() string {
+	return "maintenance_window"
+}
+
+// Global reports whether the window covers every lab
+func (m *MaintenanceWindow) Global() bool {
+	return m.LabID == 0
+}
@@ -0,0 +1,131 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"gorm.io/gorm"
+)
+
+// compositeIndex 历史查询常用过滤组合对应的复合索引
+type compositeIndex struct {
+	Name    string
+	Table   string
+	Columns string
+	Method  string // 为空时为 btree
+	// EventStore 设备事件使用独立存储时该索引由 eventstore 维护
+	EventStore bool
+}
+
+// compositeIndexes 对应历史列表和统计的过滤组合：实验室 + 状态/工作流/用户/设备/类型 + 时间范围
+var compositeIndexes = []compositeIndex{
+	{Name: "idx_weh_lab_started", Table: "workflow_execution_history", Columns: "lab_id, started_at DESC"},
+	{Name: "idx_weh_lab_status_started", Table: "workflow_execution_history", Columns: "lab_id, status, started_at DESC"},
+	{Name: "idx_weh_lab_workflow_started", Table: "workflow_execution_history", Columns: "lab_id, workflow_id, started_at DESC"},
+	{Name: "idx_weh_lab_user_started", Table: "workflow_execution_history", Columns: "lab_id, user_id, started_at DESC"},
+	{Name: "idx_weh_lab_sla_started", Table: "workflow_execution_history", Columns: "lab_id, sla_breached, started_at DESC"},
+	{Name: "idx_aeh_lab_created", Table: "action_execution_history", Columns: "lab_id, created_at DESC"},
+	{Name: "idx_aeh_lab_status_created", Table: "action_execution_history", Columns: "lab_id, status, created_at DESC"},
+	{Name: "idx_aeh_lab_device_created", Table: "action_execution_history", Columns: "lab_id, device_id, created_at DESC"},
+	{Name: "idx_deh_lab_time", Table: "device_event_history", Columns: "lab_id, timestamp DESC", EventStore: true},
+	{Name: "idx_deh_lab_device_time", Table: "device_event_history", Columns: "lab_id, device_id, timestamp DESC", EventStore: true},
+	{Name: "idx_deh_lab_type_time", Table: "device_event_history", Columns: "lab_id, event_type, timestamp DESC", EventStore: true},
+}
+
+// brinIndexes 历史表按时间追加写入，BRIN 索引体积很小，用于保留策略清理和大范围时间扫描
+var brinIndexes = []compositeIndex{
+	{Name: "idx_weh_started_brin", Table: "workflow_execution_history", Columns: "started_at", Method: "brin"},
+	{Name: "idx_aeh_created_brin", Table: "action_execution_history", Columns: "created_at", Method: "brin"},
+}
+
+// changeIndexes 按更新时间增量同步历史记录
+var changeIndexes = []compositeIndex{
+	{Name: "idx_weh_lab_updated", Table: "workflow_execution_history", Columns: "lab_id, updated_at, id"},
+	{Name: "idx_aeh_lab_updated", Table: "action_execution_history", Columns: "lab_id, updated_at, id"},
+	{Name: "idx_deh_lab_updated", Table: "device_event_history", Columns: "lab_id, updated_at, id", EventStore: true},
+}
+
+// createIndexes 使用 CONCURRENTLY 创建，不阻塞历史记录写入，sqlite 不支持 CONCURRENTLY，
+// 分区表也不支持 CONCURRENTLY
+func createIndexes(conn *gorm.DB, indexes []compositeIndex, concurrently bool) error {
+	for _, idx := range indexes {
+		sql := "CREATE INDEX "
+		if concurrently && !db.IsSQLite(conn) {
+			sql += "CONCURRENTLY "
+		}
+		sql += fmt.Sprintf("IF NOT EXISTS %s ON %s", idx.Name, idx.Table)
+		if idx.Method != "" {
+			sql += " USING " + idx.Method
+		}
+		if err := conn.Exec(fmt.Sprintf("%s (%s)", sql, idx.Columns)).Error; err != nil {
+			return fmt.Errorf("create index %s fail: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
+
+// dropIndexes 删除索引，回滚迁移时使用
+func dropIndexes(conn *gorm.DB, indexes []compositeIndex) error {
+	drop := "DROP INDEX CONCURRENTLY IF EXISTS %s"
+	if db.IsSQLite(conn) {
+		drop = "DROP INDEX IF EXISTS %s"
+	}
+	for _, idx := range indexes {
+		if err := conn.Exec(fmt.Sprintf(drop, idx.Name)).Error; err != nil {
+			return fmt.Errorf("drop index %s fail: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
+
+// CheckIndexes logs the expected history indexes that are missing or left
+// invalid by an interrupted concurrent build
+func CheckIndexes(ctx context.Context) {
+	// sqlite 同步创建索引，不会留下无效索引
+	if db.IsSQLite(db.DB().DBIns()) {
+		return
+	}
+	all := append(append(append([]compositeIndex{}, compositeIndexes...), brinIndexes...), changeIndexes...)
+	expected := make([]compositeIndex, 0, len(all))
+	names := make([]string, 0, len(all))
+	for _, idx := range all {
+		// 设备事件在独立存储中查询
+		if idx.EventStore && db.EventDB() != nil {
+			continue
+		}
+		expected = append(expected, idx)
+		names = append(names, idx.Name)
+	}
+
+	var found []struct {
+		Name  string
+		Valid bool
+	}
+	if err := db.DB().DBWithContext(ctx).Raw(`
+SELECT c.relname AS name, i.indisvalid AS valid
+FROM pg_index i
+JOIN pg_class c ON c.oid = i.indexrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relname IN ?`, names).Scan(&found).Error; err != nil {
+		logger.Warnf(ctx, "check composite indexes fail: %+v", err)
+		return
+	}
+
+	valid := make(map[string]bool, len(found))
+	for _, f := range found {
+		valid[f.Name] = f.Valid
+	}
+	for _, idx := range expected {
+		ok, exists := valid[idx.Name]
+		switch {
+		case !exists:
+			logger.Warnf(ctx, "index advisor: missing index %s on %s (%s), history queries filtering these columns fall back to sequential scans; run migrate to create it",
+				idx.Name, idx.Table, idx.Columns)
+		case !ok:
+			logger.Warnf(ctx, "index advisor: index %s on %s is invalid, drop it and run migrate to rebuild it",
+				idx.Name, idx.Table)
+		}
+	}
+}
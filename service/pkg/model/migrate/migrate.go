@@ -8,7 +8,7 @@ import (
 	"github.com/scienceol/studio/service/pkg/utils"
 )
 
-func Table(_ context.Context) error {
+func Table(ctx context.Context) error {
 	return utils.IfErrReturn(func() error {
 		return db.DB().DBIns().AutoMigrate(
 			&model.Laboratory{},             // 实验室
@@ -31,12 +31,110 @@ func Table(_ context.Context) error {
 			&model.WorkflowExecutionHistory{},
 			&model.ActionExecutionHistory{},
 			&model.DeviceEventHistory{},
+			// Execution control tables
+			&model.ApprovalRequest{},
+			&model.DeviceReservation{},
+			&model.WorkflowBatch{},
+			&model.WorkflowTaskDependency{},
+			&model.WorkflowHeldTask{},
+			&model.DeadLetterJob{},
+			// Background job tables
+			&model.BackgroundJob{},
+			&model.BackgroundJobRun{},
+			// Device tables
+			&model.DeviceLiveness{},
+			&model.Device{},
+			&model.DeviceLimit{},
+			&model.DeviceCommand{},
+			// Telemetry tables
+			&model.DeviceTelemetryRollup{},
+			// Rule and alert tables
+			&model.DeviceRule{},
+			&model.Alert{},
+			&model.AlertSilence{},
+			// Camera stream tables
+			&model.CameraStream{},
+			&model.StreamSession{},
+			// Result file tables
+			&model.ResultFile{},
+			// Material inventory tables
+			&model.Material{},
+			&model.MaterialUsage{},
+			&model.MaterialSyncRun{},
+			&model.MaterialSyncConflict{},
+			// Cost accounting tables
+			&model.CostModel{},
+			&model.CostRecord{},
+			// Saved view tables
+			&model.SavedView{},
+			// Report subscription tables
+			&model.ReportSubscription{},
+			&model.ReportDelivery{},
+			// Execution annotation tables
+			&model.ExecutionAnnotation{},
+			&model.ExecutionAnnotationRevision{},
+			// Execution pin tables
+			&model.ExecutionPin{},
+			// Lab export tables
+			&model.LabExport{},
+			// History import tables
+			&model.HistoryImport{},
+			// History export tables
+			&model.HistoryExport{},
+			// Impersonation tables
+			&model.ImpersonationSession{},
+			&model.AuditLog{},
+			// Device API key tables
+			&model.DeviceAPIKey{},
+			// Maintenance tables
+			&model.MaintenanceWindow{},
+			// Notification tables
+			&model.Notification{},
+			&model.NotificationPreference{},
+			// Error classification tables
+			&model.ErrorRule{},
+			// Assistant tables
+			&model.AssistantAudit{},
+			// Device risk tables
+			&model.DeviceRiskScore{},
+			// Agent upgrade tables
+			&model.AgentUpgradeCampaign{},
+			&model.AgentUpgradeTarget{},
+			// Device config tables
+			&model.DeviceConfigProfile{},
+			&model.DeviceConfigVersion{},
+			&model.DeviceConfigAssignment{},
+			&model.DeviceConfigApply{},
+			&model.DeviceConfigReport{},
+			// Edge ingest tables
+			&model.EdgeIngestStream{},
+			&model.EdgeIngestSeq{},
+			// Lab topology tables
+			&model.TopologyNode{},
+			&model.TopologyDevice{},
+			// Device group command tables
+			&model.DeviceGroupCommand{},
+			// Experiment tables
+			&model.Experiment{},
+			// History chain tables
+			&model.HistoryChainEntry{},
+			&model.HistoryChainAnchor{},
+			// Legal hold tables
+			&model.LegalHold{},
+			// Workflow definition snapshot tables
+			&model.WorkflowSnapshot{},
 		) // 动作节点handle 模板
 	}, func() error {
-		// 创建 gin 索引
+		// 创建 gin 索引，sqlite 没有 gin 索引
+		if db.IsSQLite(db.DB().DBIns()) {
+			return nil
+		}
 		return db.DB().DBIns().Exec(`CREATE INDEX IF NOT EXISTS idx_workflow_tags ON workflow USING gin(tags) WHERE published = true;`).Error
 	}, func() error {
 		// 创建 gin 索引
+		if db.IsSQLite(db.DB().DBIns()) {
+			return nil
+		}
 		return db.DB().DBIns().Exec(`CREATE INDEX IF NOT EXISTS idx_resource_node_template_tags ON resource_node_template USING gin(tags);`).Error
 	})
 }
@@ -56,25 +56,28 @@ func HandleAutoMigration(ctx context.Context, config *config.GlobalConfig) error
 	// 检查是否需要迁移
 	if currentVersion == latestVersion {
 		logger.Infof(ctx, "✅ Database is up to date")
-		return nil
-	}
+	} else {
+		logger.Infof(ctx, "🚀 Starting database migrations from version %s to %s...", currentVersion, latestVersion)
 
-	logger.Infof(ctx, "🚀 Starting database migrations from version %s to %s...", currentVersion, latestVersion)
+		// 执行迁移
+		if err := RunMigrations(ctx); err != nil {
+			logger.Errorf(ctx, "❌ Migration failed: %v", err)
+			return fmt.Errorf("migration failed: %w", err)
+		}
 
-	// 执行迁移
-	if err := RunMigrations(ctx); err != nil {
-		logger.Errorf(ctx, "❌ Migration failed: %v", err)
-		return fmt.Errorf("migration failed: %w", err)
+		// 获取迁移后的版本确认
+		finalVersion, err := GetCurrentVersion(ctx)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to verify final migration version: %v", err)
+		} else {
+			logger.Infof(ctx, "✅ Migration completed successfully - Current version: %s", finalVersion)
+		}
 	}
 
-	// 获取迁移后的版本确认
-	finalVersion, err := GetCurrentVersion(ctx)
-	if err != nil {
-		logger.Warnf(ctx, "Failed to verify final migration version: %v", err)
-	} else {
-		logger.Infof(ctx, "✅ Migration completed successfully - Current version: %s", finalVersion)
+	// 版本化迁移与模型结构无关，每次启动都检查
+	if err := Up(ctx, ""); err != nil {
+		return fmt.Errorf("versioned migration failed: %w", err)
 	}
-
 	return nil
 }
 
@@ -0,0 +1,191 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// 设备事件按月分区，保留策略清理和按时间范围的查询只需扫描相关分区
+const (
+	eventTable = "device_event_history"
+	// partitionMonthsAhead 提前创建的月份分区数，超出范围的事件写入默认分区
+	partitionMonthsAhead = 3
+)
+
+// eventPartition 一个月份分区，范围左闭右开
+type eventPartition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// monthPartitions returns the monthly partitions covering from through to, in UTC
+func monthPartitions(from, to time.Time) []eventPartition {
+	from, to = from.UTC(), to.UTC()
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	partitions := make([]eventPartition, 0)
+	for !month.After(to) {
+		next := month.AddDate(0, 1, 0)
+		partitions = append(partitions, eventPartition{
+			Name: fmt.Sprintf("%s_p%s", eventTable, month.Format("200601")),
+			From: month,
+			To:   next,
+		})
+		month = next
+	}
+	return partitions
+}
+
+func createPartitions(tx *gorm.DB, partitions []eventPartition) error {
+	for _, p := range partitions {
+		// DDL 不支持参数绑定，边界使用字面量
+		if err := tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			p.Name, eventTable, p.From.Format(time.RFC3339), p.To.Format(time.RFC3339))).Error; err != nil {
+			return fmt.Errorf("create partition %s fail: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// eventsPartitioned 判断设备事件表是否已转换为分区表
+func eventsPartitioned(tx *gorm.DB) (bool, error) {
+	if db.IsSQLite(tx) {
+		return false, nil
+	}
+	var count int64
+	if err := tx.Raw(`
+SELECT count(*) FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relname = ? AND c.relkind = 'p'`, eventTable).Scan(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// EnsureEventPartitions creates the device event partitions of the current
+// month and the following months, it does nothing before the table is partitioned
+func EnsureEventPartitions(ctx context.Context) error {
+	conn := db.DB().DBWithContext(ctx)
+	partitioned, err := eventsPartitioned(conn)
+	if err != nil || !partitioned {
+		return err
+	}
+	now := time.Now()
+	if err := createPartitions(conn, monthPartitions(now, now.AddDate(0, partitionMonthsAhead, 0))); err != nil {
+		// 默认分区中已有该月份的事件时无法创建分区
+		logger.Errorf(ctx, "EnsureEventPartitions fail: %+v", err)
+		return err
+	}
+	return nil
+}
+
+// partitionEvents converts device_event_history into a table partitioned by
+// month on timestamp. The unique index on uuid has to include the partition key.
+func partitionEvents(tx *gorm.DB) error {
+	partitioned, err := eventsPartitioned(tx)
+	if err != nil || partitioned {
+		return err
+	}
+
+	var bounds struct {
+		MinTime *time.Time
+	}
+	if err := tx.Raw(fmt.Sprintf("SELECT min(timestamp) AS min_time FROM %s", eventTable)).Scan(&bounds).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	from := now
+	if bounds.MinTime != nil && bounds.MinTime.Before(now) {
+		from = *bounds.MinTime
+	}
+
+	old := eventTable + "_unpartitioned"
+	return utils.IfErrReturn(exec(tx,
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", eventTable, old),
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS) PARTITION BY RANGE (timestamp)", eventTable, old),
+		fmt.Sprintf("CREATE TABLE %s_default PARTITION OF %s DEFAULT", eventTable, eventTable),
+	), func() error {
+		return createPartitions(tx, monthPartitions(from, now.AddDate(0, partitionMonthsAhead, 0)))
+	}, exec(tx,
+		fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", eventTable, old),
+	), func() error {
+		return moveSequence(tx, old)
+	}, exec(tx,
+		fmt.Sprintf("DROP TABLE %s", old),
+		fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (id, timestamp)", eventTable),
+		fmt.Sprintf("CREATE UNIQUE INDEX idx_device_event_history_uuid ON %s (uuid, timestamp)", eventTable),
+	), func() error {
+		return createEventIndexes(tx)
+	})
+}
+
+// unpartitionEvents converts the partitioned device_event_history back into a plain table
+func unpartitionEvents(tx *gorm.DB) error {
+	partitioned, err := eventsPartitioned(tx)
+	if err != nil || !partitioned {
+		return err
+	}
+
+	old := eventTable + "_partitioned"
+	return utils.IfErrReturn(exec(tx,
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", eventTable, old),
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS)", eventTable, old),
+		fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", eventTable, old),
+	), func() error {
+		return moveSequence(tx, old)
+	}, exec(tx,
+		// 分区随父表一起删除
+		fmt.Sprintf("DROP TABLE %s", old),
+		fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (id)", eventTable),
+		fmt.Sprintf("CREATE UNIQUE INDEX idx_device_event_history_uuid ON %s (uuid)", eventTable),
+	), func() error {
+		return createEventIndexes(tx)
+	})
+}
+
+// moveSequence 将 id 序列改为属于新表，删除旧表时序列不会被一起删除
+func moveSequence(tx *gorm.DB, oldTable string) error {
+	var sequence *string
+	if err := tx.Raw("SELECT pg_get_serial_sequence(?, 'id')", oldTable).Scan(&sequence).Error; err != nil {
+		return err
+	}
+	if sequence == nil {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.id", *sequence, eventTable)).Error
+}
+
+// createEventIndexes 重建模型声明的单列索引和设备事件的复合索引，
+// 数据复制完成后再建索引比逐行维护索引快
+func createEventIndexes(tx *gorm.DB) error {
+	indexes := []compositeIndex{
+		{Name: "idx_deh_lab", Table: eventTable, Columns: "lab_id"},
+		{Name: "idx_deh_device", Table: eventTable, Columns: "device_id"},
+		{Name: "idx_deh_type", Table: eventTable, Columns: "event_type"},
+		{Name: "idx_deh_time", Table: eventTable, Columns: "timestamp"},
+	}
+	for _, idx := range append(append([]compositeIndex{}, compositeIndexes...), changeIndexes...) {
+		if idx.Table == eventTable {
+			indexes = append(indexes, idx)
+		}
+	}
+	return createIndexes(tx, indexes, false)
+}
+
+// exec returns a step running the statements in order
+func exec(tx *gorm.DB, stmts ...string) func() error {
+	return func() error {
+		for _, stmt := range stmts {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("%s fail: %w", stmt, err)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,250 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"gorm.io/gorm"
+)
+
+// AutoMigrate 只能新增表和列，索引调整、分区这类需要回滚的结构变更使用版本化迁移，
+// 按 ID 顺序执行，已执行的迁移记录在 schema_versions 表
+
+// migrationLockKey 多个实例同时启动时只有一个执行迁移
+const migrationLockKey = 7_310_001
+
+// SchemaVersion 已执行的版本化迁移
+type SchemaVersion struct {
+	ID        string    `gorm:"column:id;primaryKey;size:255"`
+	AppliedAt time.Time `gorm:"column:applied_at;default:CURRENT_TIMESTAMP"`
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_versions"
+}
+
+// versionedMigration 一个版本化迁移，ID 以日期开头保证顺序
+type versionedMigration struct {
+	ID          string
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+}
+
+// migrations 只能在末尾追加，已发布的迁移不能修改
+var migrations = []versionedMigration{
+	{
+		ID:          "202610170001_history_composite_indexes",
+		Description: "历史查询的复合索引",
+		Up: func(tx *gorm.DB) error {
+			return createIndexes(tx, compositeIndexes, true)
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropIndexes(tx, compositeIndexes)
+		},
+	},
+	{
+		ID:          "202610170002_history_brin_indexes",
+		Description: "历史表时间列的 BRIN 索引",
+		Up: func(tx *gorm.DB) error {
+			// sqlite 没有 BRIN 索引
+			if db.IsSQLite(tx) {
+				return nil
+			}
+			return createIndexes(tx, brinIndexes, true)
+		},
+		Down: func(tx *gorm.DB) error {
+			if db.IsSQLite(tx) {
+				return nil
+			}
+			return dropIndexes(tx, brinIndexes)
+		},
+	},
+	{
+		ID:          "202610170003_device_event_partitions",
+		Description: "设备事件表按月分区",
+		Up: func(tx *gorm.DB) error {
+			if db.IsSQLite(tx) {
+				return nil
+			}
+			// 复制数据期间锁表，大表应在维护窗口内通过 migrate up 执行
+			return tx.Transaction(partitionEvents)
+		},
+		Down: func(tx *gorm.DB) error {
+			if db.IsSQLite(tx) {
+				return nil
+			}
+			return tx.Transaction(unpartitionEvents)
+		},
+	},
+	{
+		ID:          "202610170004_history_change_indexes",
+		Description: "按更新时间增量同步历史记录的索引",
+		Up: func(tx *gorm.DB) error {
+			// 分区表不支持 CONCURRENTLY
+			partitioned, err := eventsPartitioned(tx)
+			if err != nil {
+				return err
+			}
+			for _, idx := range changeIndexes {
+				if err := createIndexes(tx, []compositeIndex{idx}, !(idx.Table == eventTable && partitioned)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropIndexes(tx, changeIndexes)
+		},
+	},
+}
+
+// MigrationStatus 版本化迁移的执行状态
+type MigrationStatus struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+}
+
+// Status 数据库迁移状态
+type Status struct {
+	SchemaVersion       string             `json:"schema_version"`        // AutoMigrate 已执行的模型结构版本
+	LatestSchemaVersion string             `json:"latest_schema_version"` // 当前代码的模型结构版本
+	Migrations          []*MigrationStatus `json:"migrations"`
+	Pending             int                `json:"pending"`
+	EventsPartitioned   bool               `json:"events_partitioned"`
+}
+
+func newMigrator(conn *gorm.DB) (*gormigrate.Gormigrate, error) {
+	// 提前建表，gormigrate 只写入 id，执行时间使用列默认值
+	if err := conn.AutoMigrate(&SchemaVersion{}); err != nil {
+		return nil, fmt.Errorf("create schema_versions fail: %w", err)
+	}
+	list := make([]*gormigrate.Migration, 0, len(migrations))
+	for _, m := range migrations {
+		list = append(list, &gormigrate.Migration{ID: m.ID, Migrate: m.Up, Rollback: m.Down})
+	}
+	return gormigrate.New(conn, &gormigrate.Options{
+		TableName:    SchemaVersion{}.TableName(),
+		IDColumnName: "id",
+		IDColumnSize: 255,
+		// CONCURRENTLY 建索引不能在事务中执行，需要事务的迁移自行开启
+		UseTransaction: false,
+	}, list), nil
+}
+
+// withMigrationLock holds a session advisory lock while fn runs
+func withMigrationLock(ctx context.Context, conn *gorm.DB, fn func() error) error {
+	if db.IsSQLite(conn) {
+		return fn()
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return err
+	}
+	lockConn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer lockConn.Close()
+	if _, err := lockConn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock fail: %w", err)
+	}
+	defer func() {
+		if _, err := lockConn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			logger.Warnf(ctx, "release migration lock fail: %+v", err)
+		}
+	}()
+	return fn()
+}
+
+// Up applies the pending versioned migrations, up to and including to when it is not empty
+func Up(ctx context.Context, to string) error {
+	conn := db.DB().DBWithContext(ctx)
+	return withMigrationLock(ctx, conn, func() error {
+		m, err := newMigrator(conn)
+		if err != nil {
+			return err
+		}
+		if to == "" {
+			err = m.Migrate()
+		} else {
+			err = m.MigrateTo(to)
+		}
+		if err != nil {
+			logger.Errorf(ctx, "versioned migration fail: %+v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last applied migration, or every migration applied after to when it is not empty
+func Down(ctx context.Context, to string) error {
+	conn := db.DB().DBWithContext(ctx)
+	return withMigrationLock(ctx, conn, func() error {
+		m, err := newMigrator(conn)
+		if err != nil {
+			return err
+		}
+		if to == "" {
+			err = m.RollbackLast()
+		} else {
+			err = m.RollbackTo(to)
+		}
+		if err != nil {
+			logger.Errorf(ctx, "versioned migration rollback fail: %+v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// GetStatus returns the schema version and the state of every versioned migration
+func GetStatus(ctx context.Context) (*Status, error) {
+	conn := db.DB().DBWithContext(ctx)
+	current, err := GetCurrentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := GetLatestVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.AutoMigrate(&SchemaVersion{}); err != nil {
+		return nil, fmt.Errorf("create schema_versions fail: %w", err)
+	}
+	var applied []*SchemaVersion
+	if err := conn.Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[string]time.Time, len(applied))
+	for _, v := range applied {
+		appliedAt[v.ID] = v.AppliedAt
+	}
+
+	status := &Status{
+		SchemaVersion:       current,
+		LatestSchemaVersion: latest,
+		Migrations:          make([]*MigrationStatus, 0, len(migrations)),
+	}
+	for _, m := range migrations {
+		s := &MigrationStatus{ID: m.ID, Description: m.Description}
+		if t, ok := appliedAt[m.ID]; ok {
+			s.Applied = true
+			s.AppliedAt = &t
+		} else {
+			status.Pending++
+		}
+		status.Migrations = append(status.Migrations, s)
+	}
+	if status.EventsPartitioned, err = eventsPartitioned(conn); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedMigrations(t *testing.T) {
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, Table(ctx))
+
+	status, err := GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), status.Pending)
+	assert.False(t, status.EventsPartitioned)
+
+	require.NoError(t, Up(ctx, migrations[0].ID))
+	status, err = GetStatus(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.Migrations[0].Applied)
+	assert.NotNil(t, status.Migrations[0].AppliedAt)
+	assert.Equal(t, len(migrations)-1, status.Pending)
+	assert.True(t, db.DB().DBIns().Migrator().HasIndex("workflow_execution_history", "idx_weh_lab_started"))
+
+	require.NoError(t, Up(ctx, ""))
+	// 重复执行不会重跑已执行的迁移
+	require.NoError(t, Up(ctx, ""))
+	status, err = GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, status.Pending)
+
+	require.NoError(t, Down(ctx, migrations[0].ID))
+	status, err = GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations)-1, status.Pending)
+	require.NoError(t, Down(ctx, ""))
+	assert.False(t, db.DB().DBIns().Migrator().HasIndex("workflow_execution_history", "idx_weh_lab_started"))
+}
+
+func TestMonthPartitions(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	// 上海时间 11 月 1 日凌晨仍属于 UTC 的 10 月
+	partitions := monthPartitions(time.Date(2026, 11, 1, 2, 0, 0, 0, shanghai), time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.Len(t, partitions, 4)
+	assert.Equal(t, "device_event_history_p202610", partitions[0].Name)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), partitions[0].From)
+	assert.Equal(t, partitions[1].From, partitions[0].To)
+	assert.Equal(t, "device_event_history_p202701", partitions[3].Name)
+	assert.Equal(t, time.Date(2027, 2, 1, 0, 0, 0, 0, time.UTC), partitions[3].To)
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// NotificationKind is the subsystem a notification comes from
+type NotificationKind string
+
+const (
+	NotificationAlert     NotificationKind = "alert"     // 设备告警触发、升级、恢复
+	NotificationApproval  NotificationKind = "approval"  // 等待审批、审批结果
+	NotificationExecution NotificationKind = "execution" // 工作流执行结束
+)
+
+// NotificationKinds lists every kind, in the order shown in the preferences
+var NotificationKinds = []NotificationKind{NotificationAlert, NotificationApproval, NotificationExecution}
+
+// Valid reports whether the kind is known
+func (k NotificationKind) Valid() bool {
+	for _, kind := range NotificationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is one entry of a user's in-app inbox
+type Notification struct {
+	BaseModel
+	UserID  string           `gorm:"type:varchar(120);not null;index:idx_notification_user,priority:1" json:"user_id"`
+	Kind    NotificationKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Title   string           `gorm:"type:varchar(255);not null" json:"title"`
+	Body    string           `gorm:"type:text" json:"body"`
+	LabID   int64            `gorm:"type:bigint;not null;default:0" json:"-"`
+	LabUUID uuid.UUID        `gorm:"type:uuid" json:"lab_uuid"`
+	RefUUID uuid.UUID        `gorm:"type:uuid" json:"ref_uuid"` // 关联的告警、审批或任务
+	ReadAt  *time.Time       `gorm:"index:idx_notification_user,priority:2" json:"read_at"`
+}
+
+func (*Notification) TableName() string {
+	return "notification"
+}
+
+// NotificationPreference turns one kind of in-app notification on or off for
+// a user; kinds without a preference are enabled
+type NotificationPreference struct {
+	BaseModel
+	UserID  string           `gorm:"type:varchar(120);not null;uniqueIndex:idx_np_user_kind,priority:1" json:"user_id"`
+	Kind    NotificationKind `gorm:"type:varchar(20);not null;uniqueIndex:idx_np_user_kind,priority:2" json:"kind"`
+	Enabled bool             `gorm:"not null" json:"enabled"`
+}
+
+func (*NotificationPreference) TableName() string {
+	return "notification_preference"
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// PayloadLimitReason 负载超过的限制
+type PayloadLimitReason string
+
+const (
+	PayloadLimitSize  PayloadLimitReason = "size"
+	PayloadLimitDepth PayloadLimitReason = "depth"
+)
+
+// PayloadStub replaces a JSON payload over the size or depth limit in the
+// record. The original payload is kept as a result file when FileUUID is set,
+// otherwise only the preview is left
+type PayloadStub struct {
+	Truncated bool               `json:"_truncated"` // 固定为 true，用于识别替代的内容
+	Reason    PayloadLimitReason `json:"reason"`
+	Size      int                `json:"size"`                // 原始负载的字节数
+	SHA256    string             `json:"sha256"`              // 原始负载的 sha256 hex
+	FileUUID  *uuid.UUID         `json:"file_uuid,omitempty"` // 转存的结果文件，通过文件下载接口获取
+	Preview   string             `json:"preview,omitempty"`   // 截断时保留的前缀
+}
+
+// PayloadSummary replaces the input or output of an action compacted after
+// the full payloads were archived to the result file FileUUID, which holds
+// {"input": ..., "output": ...}
+type PayloadSummary struct {
+	Compacted bool           `json:"_compacted"` // 固定为 true，用于识别替代的内容
+	Size      int            `json:"size"`       // 原始负载的字节数
+	SHA256    string         `json:"sha256"`     // 原始负载的 sha256 hex
+	FileUUID  uuid.UUID      `json:"file_uuid"`
+	Keys      []string       `json:"keys,omitempty"`   // 对象顶层的字段名
+	Fields    map[string]any `json:"fields,omitempty"` // 顶层的简单字段取值
+}
+
+// CompactedArchive 压缩时归档的完整负载
+type CompactedArchive struct {
+	Input  json.RawMessage `json:"input,omitempty"`
+	Output json.RawMessage `json:"output,omitempty"`
+}
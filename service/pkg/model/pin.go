@@ -0,0 +1,13 @@
+package model
+
+// ExecutionPin marks a workflow execution as pinned by a user, CreatedAt is the pin time
+type ExecutionPin struct {
+	BaseModel
+	UserID      string `gorm:"type:varchar(120);not null;uniqueIndex:idx_execution_pin_user,priority:1" json:"user_id"`
+	ExecutionID int64  `gorm:"type:bigint;not null;uniqueIndex:idx_execution_pin_user,priority:2;index:idx_execution_pin_execution" json:"execution_id"`
+	LabID       int64  `gorm:"type:bigint;not null" json:"lab_id"`
+}
+
+func (*ExecutionPin) TableName() string {
+	return "execution_pin"
+}
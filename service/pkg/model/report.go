@@ -0,0 +1,120 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// ReportSource is what a report subscription renders
+type ReportSource string
+
+const (
+	ReportSourceSavedView ReportSource = "saved_view" // 保存的视图过滤出的历史记录
+	ReportSourceLabStats  ReportSource = "lab_stats"  // 实验室统计
+)
+
+// ReportSchedule is how often a report subscription is delivered
+type ReportSchedule string
+
+const (
+	ReportScheduleDaily  ReportSchedule = "daily"
+	ReportScheduleWeekly ReportSchedule = "weekly"
+)
+
+// Period returns the time span covered by one report
+func (s ReportSchedule) Period() time.Duration {
+	if s == ReportScheduleWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// NextRun returns the first run time after the given time; daily reports run
+// at hour every day and weekly reports at hour on weekday
+func (s ReportSchedule) NextRun(after time.Time, weekday time.Weekday, hour int) time.Time {
+	y, m, d := after.Date()
+	next := time.Date(y, m, d, hour, 0, 0, 0, after.Location())
+	if s == ReportScheduleWeekly {
+		next = next.AddDate(0, 0, (int(weekday)-int(next.Weekday())+7)%7)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	}
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// ReportFormat is the file format of a rendered report
+type ReportFormat string
+
+const (
+	ReportFormatCSV ReportFormat = "csv"
+	ReportFormatPDF ReportFormat = "pdf"
+)
+
+// ReportChannel is how a rendered report is delivered
+type ReportChannel string
+
+const (
+	ReportChannelEmail   ReportChannel = "email"   // target 为收件人邮箱
+	ReportChannelWebhook ReportChannel = "webhook" // target 为接收报表文件的 URL
+)
+
+// ReportSubscription delivers a saved view or the lab statistics on a schedule
+type ReportSubscription struct {
+	BaseModel
+	LabID     int64          `gorm:"type:bigint;not null;index:idx_report_sub_lab" json:"lab_id"`
+	OwnerID   string         `gorm:"type:varchar(120);not null" json:"owner_id"`
+	Name      string         `gorm:"type:varchar(120);not null" json:"name"`
+	Source    ReportSource   `gorm:"type:varchar(20);not null" json:"source"`
+	ViewID    *int64         `gorm:"type:bigint;index:idx_report_sub_view" json:"view_id"` // source 为 saved_view 时使用
+	ViewUUID  *uuid.UUID     `gorm:"type:uuid" json:"view_uuid"`
+	Schedule  ReportSchedule `gorm:"type:varchar(20);not null" json:"schedule"`
+	Weekday   int            `gorm:"not null;default:1" json:"weekday"` // 每周报表的发送日，0 为周日
+	Hour      int            `gorm:"not null;default:8" json:"hour"`    // 发送时刻，服务器本地时间
+	Format    ReportFormat   `gorm:"type:varchar(10);not null" json:"format"`
+	Channel   ReportChannel  `gorm:"type:varchar(20);not null" json:"channel"`
+	Target    string         `gorm:"type:varchar(512);not null" json:"target"`
+	Enabled   bool           `gorm:"not null;default:true" json:"enabled"`
+	NextRunAt time.Time      `gorm:"not null;index:idx_report_sub_next" json:"next_run_at"`
+	LastRunAt *time.Time     `json:"last_run_at"`
+}
+
+func (*ReportSubscription) TableName() string {
+	return "report_subscription"
+}
+
+// ReportDeliveryStatus is the result of one delivery
+type ReportDeliveryStatus string
+
+const (
+	ReportDeliverySuccess ReportDeliveryStatus = "success"
+	ReportDeliveryFailed  ReportDeliveryStatus = "failed"
+)
+
+// ReportDelivery records one rendered report of a subscription and whether it was delivered
+type ReportDelivery struct {
+	BaseModel
+	SubscriptionID int64                `gorm:"type:bigint;not null;index:idx_report_delivery_sub" json:"subscription_id"`
+	LabID          int64                `gorm:"type:bigint;not null" json:"lab_id"`
+	PeriodStart    time.Time            `gorm:"not null" json:"period_start"`
+	PeriodEnd      time.Time            `gorm:"not null" json:"period_end"`
+	Format         ReportFormat         `gorm:"type:varchar(10);not null" json:"format"`
+	Channel        ReportChannel        `gorm:"type:varchar(20);not null" json:"channel"`
+	Target         string               `gorm:"type:varchar(512);not null" json:"target"`
+	FileName       string               `gorm:"type:varchar(255)" json:"file_name"`
+	Size           int64                `gorm:"type:bigint;not null;default:0" json:"size"`
+	Rows           int                  `gorm:"not null;default:0" json:"rows"`
+	StorageKey     string               `gorm:"type:varchar(512)" json:"-"` // 渲染失败时为空
+	Status         ReportDeliveryStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Error          string               `gorm:"type:text" json:"error"`
+	DeliveredAt    *time.Time           `json:"delivered_at"`
+}
+
+func (*ReportDelivery) TableName() string {
+	return "report_delivery"
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportScheduleNextRun(t *testing.T) {
+	// 2026-03-04 为周三
+	now := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2026, 3, 5, 8, 0, 0, 0, time.UTC), ReportScheduleDaily.NextRun(now, time.Monday, 8))
+	assert.Equal(t, time.Date(2026, 3, 4, 18, 0, 0, 0, time.UTC), ReportScheduleDaily.NextRun(now, time.Monday, 18))
+	assert.Equal(t, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC), ReportScheduleWeekly.NextRun(now, time.Monday, 8))
+	assert.Equal(t, time.Date(2026, 3, 4, 18, 0, 0, 0, time.UTC), ReportScheduleWeekly.NextRun(now, time.Wednesday, 18))
+	// 当天的发送时间已过，顺延一周
+	assert.Equal(t, time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC), ReportScheduleWeekly.NextRun(now, time.Wednesday, 8))
+	// 恰好为发送时间时取下一次
+	at := time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 5, 8, 0, 0, 0, time.UTC), ReportScheduleDaily.NextRun(at, time.Monday, 8))
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+)
+
+// ReservationStatus represents the state of a device reservation
+type ReservationStatus string
+
+const (
+	ReservationStatusActive    ReservationStatus = "active"
+	ReservationStatusCancelled ReservationStatus = "cancelled"
+)
+
+// DeviceReservation books a device for a user during a time window
+type DeviceReservation struct {
+	BaseModel
+	LabID      int64             `gorm:"type:bigint;not null;index:idx_dr_lab_time,priority:1" json:"lab_id"`
+	DeviceID   int64             `gorm:"type:bigint;not null;index:idx_dr_device_time,priority:1" json:"device_id"`
+	DeviceName string            `gorm:"type:varchar(255);not null" json:"device_name"`
+	UserID     string            `gorm:"type:varchar(120);not null;index:idx_dr_user" json:"user_id"`
+	Title      string            `gorm:"type:varchar(255);not null;default:''" json:"title"`
+	StartTime  time.Time         `gorm:"not null;index:idx_dr_lab_time,priority:2;index:idx_dr_device_time,priority:2" json:"start_time"`
+	EndTime    time.Time         `gorm:"not null" json:"end_time"`
+	Status     ReservationStatus `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+}
+
+func (*DeviceReservation) TableName() string {
+	return "device_reservation"
+}
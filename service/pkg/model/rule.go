@@ -0,0 +1,110 @@
+package model
+
+import (
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"gorm.io/datatypes"
+)
+
+// RuleOperator compares the aggregated value of a rule with its threshold
+type RuleOperator string
+
+const (
+	RuleOpGT  RuleOperator = "gt"
+	RuleOpGTE RuleOperator = "gte"
+	RuleOpLT  RuleOperator = "lt"
+	RuleOpLTE RuleOperator = "lte"
+	RuleOpEQ  RuleOperator = "eq"
+	RuleOpNEQ RuleOperator = "neq"
+)
+
+// Valid reports whether the operator is known
+func (o RuleOperator) Valid() bool {
+	switch o {
+	case RuleOpGT, RuleOpGTE, RuleOpLT, RuleOpLTE, RuleOpEQ, RuleOpNEQ:
+		return true
+	default:
+		return false
+	}
+}
+
+// Match reports whether value satisfies the operator against threshold
+func (o RuleOperator) Match(value, threshold float64) bool {
+	switch o {
+	case RuleOpGT:
+		return value > threshold
+	case RuleOpGTE:
+		return value >= threshold
+	case RuleOpLT:
+		return value < threshold
+	case RuleOpLTE:
+		return value <= threshold
+	case RuleOpEQ:
+		return value == threshold
+	case RuleOpNEQ:
+		return value != threshold
+	default:
+		return false
+	}
+}
+
+// AlertSeverity represents how urgent an alert is
+type AlertSeverity string
+
+const (
+	AlertSeverityInfo     AlertSeverity = "info"
+	AlertSeverityWarning  AlertSeverity = "warning"
+	AlertSeverityCritical AlertSeverity = "critical"
+)
+
+// AlertChannelType represents where alerts of a rule are delivered
+type AlertChannelType string
+
+const (
+	AlertChannelBroadcast AlertChannelType = "broadcast" // 通过消息中心推送给在线的实验室成员
+	AlertChannelWebhook   AlertChannelType = "webhook"
+)
+
+// AlertChannel is one notification target of a rule
+type AlertChannel struct {
+	Type AlertChannelType `json:"type"`
+	URL  string           `json:"url,omitempty"`
+}
+
+// DeviceRule is a lab defined condition over device events in a sliding window.
+// The events of each device in the window are aggregated and compared with the
+// threshold, e.g. "temperature > 60 for 5 minutes" is metric=temperature,
+// aggregation=min, operator=gt, threshold=60, window_sec=300, and "error count
+// > 3/min" is event_type=error, aggregation=count, operator=gt, threshold=3,
+// window_sec=60
+type DeviceRule struct {
+	BaseModel
+	LabID              int64                             `gorm:"type:bigint;not null;index:idx_device_rule_lab" json:"lab_id"`
+	Name               string                            `gorm:"type:varchar(255);not null" json:"name"`
+	Description        string                            `gorm:"type:text" json:"description"`
+	Enabled            bool                              `gorm:"not null;default:true;index:idx_device_rule_enabled" json:"enabled"`
+	DeviceUUID         *uuid.UUID                        `gorm:"type:uuid" json:"device_uuid"` // 为空时匹配实验室所有设备
+	EventType          DeviceEventType                   `gorm:"type:varchar(50);not null" json:"event_type"`
+	Metric             string                            `gorm:"type:varchar(255)" json:"metric"` // event_data 中以 . 分隔的字段路径，为空时只能统计事件数
+	Aggregation        TelemetryAgg                      `gorm:"type:varchar(20);not null" json:"aggregation"`
+	Operator           RuleOperator                      `gorm:"type:varchar(20);not null" json:"operator"`
+	Threshold          float64                           `gorm:"not null" json:"threshold"`
+	WindowSec          int                               `gorm:"not null" json:"window_sec"`
+	Severity           AlertSeverity                     `gorm:"type:varchar(20);not null" json:"severity"`
+	Channels           datatypes.JSONSlice[AlertChannel] `gorm:"type:jsonb;not null;default:'[]'" json:"channels"`
+	EscalateAfterMin   int                               `gorm:"not null;default:0" json:"escalate_after_min"` // 告警触发后超过该分钟数仍未确认时通知 escalation_channels，0 表示不升级
+	EscalationChannels datatypes.JSONSlice[AlertChannel] `gorm:"type:jsonb;not null;default:'[]'" json:"escalation_channels"`
+	CreatedBy          string                            `gorm:"type:varchar(120);not null" json:"created_by"`
+}
+
+func (*DeviceRule) TableName() string {
+	return "device_rule"
+}
+
+// RuleEvaluation is the aggregated value of a rule for one device
+type RuleEvaluation struct {
+	DeviceID   int64     `json:"device_id"`
+	DeviceUUID uuid.UUID `json:"device_uuid"`
+	Value      float64   `json:"value"`
+	Samples    int64     `json:"samples"`
+	Matched    bool      `json:"matched" gorm:"-"`
+}
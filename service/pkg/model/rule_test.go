@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleOperatorMatch(t *testing.T) {
+	tests := []struct {
+		op    RuleOperator
+		value float64
+		want  bool
+	}{
+		{RuleOpGT, 61, true},
+		{RuleOpGT, 60, false},
+		{RuleOpGTE, 60, true},
+		{RuleOpLT, 59, true},
+		{RuleOpLT, 60, false},
+		{RuleOpLTE, 60, true},
+		{RuleOpEQ, 60, true},
+		{RuleOpNEQ, 60, false},
+		{RuleOperator("unknown"), 60, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.op), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.op.Match(tt.value, 60))
+		})
+	}
+	assert.False(t, RuleOperator("unknown").Valid())
+	assert.True(t, RuleOpGTE.Valid())
+}
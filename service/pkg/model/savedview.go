@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// SavedViewTarget is the history list a saved view applies to
+type SavedViewTarget string
+
+const (
+	SavedViewWorkflow    SavedViewTarget = "workflow"     // 工作流执行历史
+	SavedViewDeviceEvent SavedViewTarget = "device_event" // 设备事件历史
+)
+
+// Valid reports whether the target is known
+func (t SavedViewTarget) Valid() bool {
+	return t == SavedViewWorkflow || t == SavedViewDeviceEvent
+}
+
+// SavedViewFilters are the serialized history query filters of a saved view
+type SavedViewFilters struct {
+	WorkflowID *int64     `json:"workflow_id,omitempty"`
+	DeviceID   *int64     `json:"device_id,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	EventType  string     `json:"event_type,omitempty"`
+	Labels     []string   `json:"labels,omitempty"` // key:value 形式的执行标签过滤
+	StartTime  *time.Time `json:"start_time,omitempty"`
+	EndTime    *time.Time `json:"end_time,omitempty"`
+	// 相对时间范围，例如 24h、168h，展开时以当前时间计算开始时间，优先于 start_time
+	Range    string `json:"range,omitempty"`
+	PageSize int    `json:"page_size,omitempty"`
+}
+
+// Apply fills the query parameters from the filters; set reports the filter
+// names given explicitly in the request, which take precedence over the view
+func (f *SavedViewFilters) Apply(params *HistoryQueryParams, set map[string]bool, now time.Time) {
+	if f.WorkflowID != nil && !set["workflow_id"] {
+		params.WorkflowID = f.WorkflowID
+	}
+	if f.DeviceID != nil && !set["device_id"] {
+		params.DeviceID = f.DeviceID
+	}
+	if f.Status != "" && !set["status"] {
+		status := ExecutionStatus(f.Status)
+		params.Status = &status
+	}
+	if f.EventType != "" && !set["event_type"] {
+		eventType := DeviceEventType(f.EventType)
+		params.EventType = &eventType
+	}
+	if len(f.Labels) > 0 && !set["label"] {
+		if labels, err := ParseLabelFilters(f.Labels); err == nil {
+			params.Labels = labels
+		}
+	}
+	if !set["start_time"] {
+		if d, err := time.ParseDuration(f.Range); err == nil && d > 0 {
+			start := now.Add(-d)
+			params.StartTime = &start
+		} else if f.StartTime != nil {
+			params.StartTime = f.StartTime
+		}
+	}
+	if f.EndTime != nil && !set["end_time"] && f.Range == "" {
+		params.EndTime = f.EndTime
+	}
+	if f.PageSize > 0 && !set["page_size"] {
+		params.PageSize = f.PageSize
+	}
+}
+
+// SavedView is a named history filter preset of a user, optionally shared with the lab
+type SavedView struct {
+	BaseModel
+	LabID   int64                                `gorm:"type:bigint;not null;uniqueIndex:idx_saved_view_owner_name,priority:1" json:"lab_id"`
+	OwnerID string                               `gorm:"type:varchar(120);not null;uniqueIndex:idx_saved_view_owner_name,priority:2" json:"owner_id"`
+	Name    string                               `gorm:"type:varchar(120);not null;uniqueIndex:idx_saved_view_owner_name,priority:3" json:"name"`
+	Target  SavedViewTarget                      `gorm:"type:varchar(20);not null" json:"target"`
+	Filters datatypes.JSONType[SavedViewFilters] `gorm:"type:jsonb;not null" json:"filters" swaggertype:"object"`
+	Shared  bool                                 `gorm:"not null;default:false" json:"shared"` // 共享给实验室所有成员
+}
+
+func (*SavedView) TableName() string {
+	return "saved_view"
+}
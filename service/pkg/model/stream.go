@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// CameraStream is a camera of a device that an edge host can stream over the realtime signaling channel
+type CameraStream struct {
+	BaseModel
+	LabID      int64     `gorm:"type:bigint;not null;uniqueIndex:idx_camera_stream_lab_camera,priority:1" json:"lab_id"`
+	DeviceID   int64     `gorm:"type:bigint;not null" json:"device_id"`
+	DeviceUUID uuid.UUID `gorm:"type:uuid;not null" json:"device_uuid"`
+	DeviceName string    `gorm:"type:varchar(255);not null" json:"device_name"`
+	CameraID   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_camera_stream_lab_camera,priority:2" json:"camera_id"`
+	HostID     string    `gorm:"type:varchar(255);not null" json:"host_id"` // edge 连接 realtime 信令时使用的 host id
+	Name       string    `gorm:"type:varchar(255)" json:"name"`
+}
+
+func (*CameraStream) TableName() string {
+	return "camera_stream"
+}
+
+// StreamSessionStatus represents whether a viewing session is still open
+type StreamSessionStatus string
+
+const (
+	StreamSessionActive StreamSessionStatus = "active"
+	StreamSessionEnded  StreamSessionStatus = "ended"
+)
+
+// StreamSession is one user viewing a camera stream, opened with a short-lived
+// signed token and closed when the viewer disconnects, stops it or it expires
+type StreamSession struct {
+	BaseModel
+	LabID       int64               `gorm:"type:bigint;not null;index:idx_stream_session_lab_status,priority:1" json:"lab_id"`
+	StreamID    int64               `gorm:"type:bigint;not null;index:idx_stream_session_stream" json:"stream_id"`
+	StreamUUID  uuid.UUID           `gorm:"type:uuid;not null" json:"stream_uuid"`
+	DeviceID    int64               `gorm:"type:bigint;not null" json:"device_id"`
+	DeviceUUID  uuid.UUID           `gorm:"type:uuid;not null" json:"device_uuid"`
+	UserID      string              `gorm:"type:varchar(120);not null" json:"user_id"`
+	Status      StreamSessionStatus `gorm:"type:varchar(20);not null;index:idx_stream_session_lab_status,priority:2" json:"status"`
+	ClientID    string              `gorm:"type:varchar(255)" json:"client_id"`
+	StartedAt   time.Time           `gorm:"not null" json:"started_at"`
+	TokenExpiry time.Time           `gorm:"not null" json:"token_expiry"`
+	ConnectedAt *time.Time          `json:"connected_at"`
+	EndedAt     *time.Time          `json:"ended_at"`
+	EndReason   string              `gorm:"type:varchar(64)" json:"end_reason"`
+}
+
+func (*StreamSession) TableName() string {
+	return "stream_session"
+}
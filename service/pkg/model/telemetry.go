@@ -0,0 +1,101 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// TelemetryResolution represents the bucket size of a telemetry rollup
+type TelemetryResolution string
+
+const (
+	TelemetryMinute TelemetryResolution = "1m"
+	TelemetryHour   TelemetryResolution = "1h"
+)
+
+// Duration returns the bucket size
+func (r TelemetryResolution) Duration() time.Duration {
+	switch r {
+	case TelemetryMinute:
+		return time.Minute
+	case TelemetryHour:
+		return time.Hour
+	default:
+		return 0
+	}
+}
+
+// TruncUnit returns the postgres date_trunc unit of the resolution
+func (r TelemetryResolution) TruncUnit() string {
+	switch r {
+	case TelemetryMinute:
+		return "minute"
+	case TelemetryHour:
+		return "hour"
+	default:
+		return ""
+	}
+}
+
+// DeviceTelemetryRollup summarizes the numeric fields of data_received events
+// per device and metric over a time bucket
+type DeviceTelemetryRollup struct {
+	BaseModel
+	Resolution  TelemetryResolution `gorm:"type:varchar(8);not null;uniqueIndex:idx_dtr_bucket,priority:1" json:"resolution"`
+	LabID       int64               `gorm:"type:bigint;not null;index:idx_dtr_lab" json:"lab_id"`
+	DeviceID    int64               `gorm:"type:bigint;not null;uniqueIndex:idx_dtr_bucket,priority:2" json:"device_id"`
+	DeviceUUID  uuid.UUID           `gorm:"type:uuid;not null" json:"device_uuid"`
+	Metric      string              `gorm:"type:varchar(255);not null;uniqueIndex:idx_dtr_bucket,priority:3" json:"metric"`
+	BucketStart time.Time           `gorm:"not null;uniqueIndex:idx_dtr_bucket,priority:4" json:"bucket_start"`
+	MinValue    float64             `gorm:"not null" json:"min_value"`
+	MaxValue    float64             `gorm:"not null" json:"max_value"`
+	SumValue    float64             `gorm:"not null" json:"sum_value"`
+	Count       int64               `gorm:"not null" json:"count"`
+	AvgValue    float64             `gorm:"-" json:"avg_value"`
+}
+
+func (*DeviceTelemetryRollup) TableName() string {
+	return "device_telemetry_rollup"
+}
+
+// TelemetryRollupQuery represents query parameters for telemetry rollups
+type TelemetryRollupQuery struct {
+	LabID      int64
+	Resolution TelemetryResolution
+	Devices    []uuid.UUID
+	Metrics    []string
+	StartTime  time.Time
+	EndTime    time.Time
+	Limit      int
+}
+
+// TelemetryAgg represents the aggregation function of a telemetry series
+type TelemetryAgg string
+
+const (
+	TelemetryAggAvg   TelemetryAgg = "avg"
+	TelemetryAggMin   TelemetryAgg = "min"
+	TelemetryAggMax   TelemetryAgg = "max"
+	TelemetryAggSum   TelemetryAgg = "sum"
+	TelemetryAggCount TelemetryAgg = "count"
+)
+
+// TelemetrySeriesQuery represents a bucketed query over one numeric field of device events
+type TelemetrySeriesQuery struct {
+	LabID     int64
+	Devices   []uuid.UUID
+	EventType DeviceEventType
+	Path      []string // event_data 中的字段路径
+	Agg       TelemetryAgg
+	Interval  time.Duration
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// TelemetryPoint is one aggregated bucket of a device series
+type TelemetryPoint struct {
+	DeviceUUID uuid.UUID
+	Bucket     time.Time
+	Value      float64
+}
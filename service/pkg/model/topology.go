@@ -0,0 +1,152 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// TopologyNodeType is the level of a node in the lab resource hierarchy
+type TopologyNodeType string
+
+const (
+	TopologyRoom  TopologyNodeType = "room"  // 房间，位于实验室下
+	TopologyBench TopologyNodeType = "bench" // 工作台，位于房间下
+	TopologyGroup TopologyNodeType = "group" // 设备组，位于实验室、房间或工作台下
+)
+
+// Valid reports whether the node type is known
+func (t TopologyNodeType) Valid() bool {
+	switch t {
+	case TopologyRoom, TopologyBench, TopologyGroup:
+		return true
+	}
+	return false
+}
+
+// Placement reports whether the node places devices physically; a device is
+// placed in at most one room or bench but may belong to any number of groups
+func (t TopologyNodeType) Placement() bool {
+	return t == TopologyRoom || t == TopologyBench
+}
+
+// AllowedUnder reports whether a node of the type can be created under the
+// parent, nil for the lab itself
+func (t TopologyNodeType) AllowedUnder(parent *TopologyNode) bool {
+	switch t {
+	case TopologyRoom:
+		return parent == nil
+	case TopologyBench:
+		return parent != nil && parent.Type == TopologyRoom
+	case TopologyGroup:
+		return parent == nil || parent.Type.Placement()
+	}
+	return false
+}
+
+// TopologyNode is a room, bench or device group of a lab
+type TopologyNode struct {
+	BaseModel
+	LabID       int64            `gorm:"type:bigint;not null;index:idx_ltn_lab" json:"lab_id"`
+	ParentID    *int64           `gorm:"type:bigint;index:idx_ltn_parent" json:"parent_id"` // 为空时位于实验室下
+	Type        TopologyNodeType `gorm:"type:varchar(32);not null" json:"type"`
+	Name        string           `gorm:"type:varchar(255);not null" json:"name"`
+	Description string           `gorm:"type:text" json:"description"`
+	CreatedBy   string           `gorm:"type:varchar(120)" json:"created_by"`
+}
+
+func (*TopologyNode) TableName() string {
+	return "lab_topology_node"
+}
+
+// TopologyDevice places a device in a room or bench, or adds it to a group
+type TopologyDevice struct {
+	BaseModel
+	LabID    int64 `gorm:"type:bigint;not null;index:idx_ltd_lab" json:"lab_id"`
+	NodeID   int64 `gorm:"type:bigint;not null;uniqueIndex:idx_ltd_node_device,priority:1" json:"node_id"`
+	DeviceID int64 `gorm:"type:bigint;not null;uniqueIndex:idx_ltd_node_device,priority:2;index:idx_ltd_device" json:"device_id"` // 设备物料节点 ID
+}
+
+func (*TopologyDevice) TableName() string {
+	return "lab_topology_device"
+}
+
+// TopologyTreeDevice is a device directly under a topology node
+type TopologyTreeDevice struct {
+	DeviceID   int64     `json:"device_id"`
+	DeviceUUID uuid.UUID `json:"device_uuid"`
+	Name       string    `json:"name"`
+}
+
+// TopologyTree is a topology node with its devices and child nodes
+type TopologyTree struct {
+	*TopologyNode
+	ParentUUID *uuid.UUID            `json:"parent_uuid"`
+	Devices    []*TopologyTreeDevice `json:"devices"`
+	Children   []*TopologyTree       `json:"children"`
+}
+
+// SubtreeIDs returns the ID of the node and of all nodes below it
+func SubtreeIDs(nodes []*TopologyNode, rootID int64) []int64 {
+	children := make(map[int64][]int64, len(nodes))
+	for _, node := range nodes {
+		if node.ParentID != nil {
+			children[*node.ParentID] = append(children[*node.ParentID], node.ID)
+		}
+	}
+	ids := []int64{rootID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids
+}
+
+// BuildTopologyTree arranges the nodes of a lab into trees rooted at the lab,
+// ordered by type and name, with the devices of each node attached
+func BuildTopologyTree(nodes []*TopologyNode, devices map[int64][]*TopologyTreeDevice) []*TopologyTree {
+	trees := make(map[int64]*TopologyTree, len(nodes))
+	for _, node := range nodes {
+		ds := devices[node.ID]
+		if ds == nil {
+			ds = []*TopologyTreeDevice{}
+		}
+		trees[node.ID] = &TopologyTree{TopologyNode: node, Devices: ds, Children: []*TopologyTree{}}
+	}
+
+	roots := make([]*TopologyTree, 0)
+	for _, node := range nodes {
+		tree := trees[node.ID]
+		if node.ParentID == nil {
+			roots = append(roots, tree)
+			continue
+		}
+		parent, ok := trees[*node.ParentID]
+		if !ok {
+			roots = append(roots, tree)
+			continue
+		}
+		tree.ParentUUID = &parent.UUID
+		parent.Children = append(parent.Children, tree)
+	}
+
+	var sortTrees func(ts []*TopologyTree)
+	sortTrees = func(ts []*TopologyTree) {
+		sort.SliceStable(ts, func(i, j int) bool {
+			if ts[i].Type != ts[j].Type {
+				return topologyOrder[ts[i].Type] < topologyOrder[ts[j].Type]
+			}
+			return ts[i].Name < ts[j].Name
+		})
+		for _, t := range ts {
+			sortTrees(t.Children)
+		}
+	}
+	sortTrees(roots)
+	return roots
+}
+
+var topologyOrder = map[TopologyNodeType]int{
+	TopologyRoom:  0,
+	TopologyBench: 1,
+	TopologyGroup: 2,
+}
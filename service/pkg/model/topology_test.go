@@ -0,0 +1,65 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopologyAllowedUnder(t *testing.T) {
+	room := &TopologyNode{Type: TopologyRoom}
+	bench := &TopologyNode{Type: TopologyBench}
+	group := &TopologyNode{Type: TopologyGroup}
+
+	assert.True(t, TopologyRoom.AllowedUnder(nil))
+	assert.False(t, TopologyRoom.AllowedUnder(room))
+	assert.True(t, TopologyBench.AllowedUnder(room))
+	assert.False(t, TopologyBench.AllowedUnder(nil))
+	assert.False(t, TopologyBench.AllowedUnder(bench))
+	assert.True(t, TopologyGroup.AllowedUnder(nil))
+	assert.True(t, TopologyGroup.AllowedUnder(room))
+	assert.True(t, TopologyGroup.AllowedUnder(bench))
+	assert.False(t, TopologyGroup.AllowedUnder(group))
+	assert.False(t, TopologyNodeType("floor").AllowedUnder(nil))
+}
+
+func TestSubtreeIDs(t *testing.T) {
+	parent := func(id int64) *int64 { return &id }
+	nodes := []*TopologyNode{
+		{BaseModel: BaseModel{ID: 1}, Type: TopologyRoom},
+		{BaseModel: BaseModel{ID: 2}, Type: TopologyBench, ParentID: parent(1)},
+		{BaseModel: BaseModel{ID: 3}, Type: TopologyGroup, ParentID: parent(2)},
+		{BaseModel: BaseModel{ID: 4}, Type: TopologyRoom},
+		{BaseModel: BaseModel{ID: 5}, Type: TopologyGroup, ParentID: parent(1)},
+	}
+
+	assert.ElementsMatch(t, []int64{1, 2, 3, 5}, SubtreeIDs(nodes, 1))
+	assert.Equal(t, []int64{2, 3}, SubtreeIDs(nodes, 2))
+	assert.Equal(t, []int64{4}, SubtreeIDs(nodes, 4))
+}
+
+func TestBuildTopologyTree(t *testing.T) {
+	parent := func(id int64) *int64 { return &id }
+	nodes := []*TopologyNode{
+		{BaseModel: BaseModel{ID: 1}, Type: TopologyRoom, Name: "b-room"},
+		{BaseModel: BaseModel{ID: 2}, Type: TopologyGroup, Name: "pumps"},
+		{BaseModel: BaseModel{ID: 3}, Type: TopologyRoom, Name: "a-room"},
+		{BaseModel: BaseModel{ID: 4}, Type: TopologyGroup, Name: "arms", ParentID: parent(1)},
+		{BaseModel: BaseModel{ID: 5}, Type: TopologyBench, Name: "bench", ParentID: parent(1)},
+	}
+	devices := map[int64][]*TopologyTreeDevice{5: {{DeviceID: 9, Name: "robot"}}}
+
+	trees := BuildTopologyTree(nodes, devices)
+	assert.Len(t, trees, 3)
+	assert.Equal(t, "a-room", trees[0].Name)
+	assert.Equal(t, "b-room", trees[1].Name)
+	assert.Equal(t, "pumps", trees[2].Name)
+
+	room := trees[1]
+	assert.Len(t, room.Children, 2)
+	assert.Equal(t, "bench", room.Children[0].Name)
+	assert.Equal(t, "arms", room.Children[1].Name)
+	assert.Equal(t, room.UUID, *room.Children[0].ParentUUID)
+	assert.Equal(t, "robot", room.Children[0].Devices[0].Name)
+	assert.Empty(t, room.Devices)
+}
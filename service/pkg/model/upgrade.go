@@ -0,0 +1,114 @@
+package model
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"gorm.io/datatypes"
+)
+
+// AgentUpgradeAction is the action and action type of the device commands that
+// upgrade the edge agent, param {"version", "package_url", "campaign_uuid", "rollback"}
+const AgentUpgradeAction = "agent_upgrade"
+
+// UpgradeCampaignStatus represents the lifecycle state of an upgrade campaign
+type UpgradeCampaignStatus string
+
+const (
+	UpgradeCampaignRunning     UpgradeCampaignStatus = "running"
+	UpgradeCampaignPaused      UpgradeCampaignStatus = "paused" // 手动暂停或失败数超过上限，不再下发新的升级
+	UpgradeCampaignCompleted   UpgradeCampaignStatus = "completed"
+	UpgradeCampaignRollingBack UpgradeCampaignStatus = "rolling_back"
+	UpgradeCampaignRolledBack  UpgradeCampaignStatus = "rolled_back"
+)
+
+// UpgradeTargetStatus represents the upgrade state of one device of a campaign
+type UpgradeTargetStatus string
+
+const (
+	UpgradeTargetPending        UpgradeTargetStatus = "pending"    // 尚未下发
+	UpgradeTargetDispatched     UpgradeTargetStatus = "dispatched" // 已下发升级指令，等待结果和新版本心跳
+	UpgradeTargetUpgraded       UpgradeTargetStatus = "upgraded"
+	UpgradeTargetFailed         UpgradeTargetStatus = "failed"
+	UpgradeTargetCancelled      UpgradeTargetStatus = "cancelled" // 回滚时尚未下发
+	UpgradeTargetRollingBack    UpgradeTargetStatus = "rolling_back"
+	UpgradeTargetRolledBack     UpgradeTargetStatus = "rolled_back"
+	UpgradeTargetRollbackFailed UpgradeTargetStatus = "rollback_failed"
+)
+
+// Finished reports whether the target needs no more work in its phase
+func (s UpgradeTargetStatus) Finished() bool {
+	switch s {
+	case UpgradeTargetPending, UpgradeTargetDispatched, UpgradeTargetRollingBack:
+		return false
+	}
+	return true
+}
+
+// UpgradeFilters selects the devices of a campaign among the devices with a
+// heartbeat, empty filters match all
+type UpgradeFilters struct {
+	DeviceNames []string `json:"device_names,omitempty"`
+	Versions    []string `json:"versions,omitempty"` // 当前 agent 版本
+	OnlineOnly  bool     `json:"online_only"`
+}
+
+// AgentUpgradeCampaign upgrades the edge agent of the matched devices of a lab
+// to a target version, dispatching upgrades to the first RolloutPercent of the
+// targets through the device command queue
+type AgentUpgradeCampaign struct {
+	BaseModel
+	LabID          int64                              `gorm:"type:bigint;not null;index:idx_auc_lab" json:"lab_id"`
+	Name           string                             `gorm:"type:varchar(255);not null" json:"name"`
+	TargetVersion  string                             `gorm:"type:varchar(64);not null" json:"target_version"`
+	PackageURL     string                             `gorm:"type:text" json:"package_url"`
+	RolloutPercent int                                `gorm:"type:int;not null" json:"rollout_percent"`
+	Filters        datatypes.JSONType[UpgradeFilters] `gorm:"type:jsonb;not null" json:"filters" swaggertype:"object"`
+	MaxFailures    int                                `gorm:"type:int;not null;default:0" json:"max_failures"` // 失败数超过时自动暂停，0 表示不限制
+	Status         UpgradeCampaignStatus              `gorm:"type:varchar(20);not null;index:idx_auc_status" json:"status"`
+	Message        string                             `gorm:"type:text" json:"message"` // 自动暂停等状态变化的原因
+	CreatedBy      string                             `gorm:"type:varchar(120);not null" json:"created_by"`
+	FinishedAt     *time.Time                         `json:"finished_at"`
+}
+
+func (*AgentUpgradeCampaign) TableName() string {
+	return "agent_upgrade_campaign"
+}
+
+// AgentUpgradeTarget is one device of an upgrade campaign, upgraded in Seq order
+type AgentUpgradeTarget struct {
+	BaseModel
+	CampaignID        int64               `gorm:"type:bigint;not null;uniqueIndex:idx_aut_campaign_device,priority:1" json:"-"`
+	LabID             int64               `gorm:"type:bigint;not null" json:"lab_id"`
+	DeviceID          int64               `gorm:"type:bigint;not null" json:"device_id"`
+	DeviceUUID        uuid.UUID           `gorm:"type:uuid;not null" json:"device_uuid"`
+	DeviceName        string              `gorm:"type:varchar(255);not null;uniqueIndex:idx_aut_campaign_device,priority:2" json:"device_name"`
+	Seq               int                 `gorm:"type:int;not null" json:"seq"`
+	FromVersion       string              `gorm:"type:varchar(64);not null" json:"from_version"` // 升级前的版本，回滚到该版本
+	Status            UpgradeTargetStatus `gorm:"type:varchar(20);not null" json:"status"`
+	CommandID         int64               `gorm:"type:bigint;not null;default:0" json:"command_id"`
+	RollbackCommandID int64               `gorm:"type:bigint;not null;default:0" json:"rollback_command_id"`
+	Error             string              `gorm:"type:text" json:"error"`
+	DispatchedAt      *time.Time          `json:"dispatched_at"`
+	FinishedAt        *time.Time          `json:"finished_at"`
+}
+
+func (*AgentUpgradeTarget) TableName() string {
+	return "agent_upgrade_target"
+}
+
+// UpgradeProgress counts the targets of a campaign by status
+type UpgradeProgress struct {
+	Total    int                         `json:"total"`
+	Allowed  int                         `json:"allowed"` // 按放量比例允许升级的设备数
+	ByStatus map[UpgradeTargetStatus]int `json:"by_status"`
+}
+
+// RolloutLimit is the number of targets the rollout percentage allows, at least one
+func RolloutLimit(total, percent int) int {
+	if total == 0 || percent <= 0 {
+		return 0
+	}
+	n := (total*percent + 99) / 100
+	return max(1, min(n, total))
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+)
+
+// UtilizationBucket is the length of one point of the utilization trend
+const UtilizationBucket = 24 * time.Hour
+
+// DeviceBusyTime is the total action duration of a device in a period
+type DeviceBusyTime struct {
+	DeviceID   int64
+	DeviceUUID uuid.UUID
+	DeviceName string
+	Actions    int64
+	BusyMs     int64
+}
+
+// UtilizationData is the raw data a utilization report is computed from
+type UtilizationData struct {
+	Devices      []*DeviceBusyTime               // 实验室的设备及本周期的忙碌时间，包括没有执行动作的设备
+	PreviousBusy map[int64]int64                 // 上一周期各设备的忙碌时间
+	BucketBusy   map[int]int64                   // 本周期每个趋势点的忙碌时间，key 为从周期开始的序号
+	Events       map[int64][]*DeviceEventHistory // 各设备按时间排序的上线/断开事件，包括上一周期开始前的最后一条
+}
+
+// DeviceUtilization is the busy time of a device against the time it was online
+type DeviceUtilization struct {
+	Rank              int       `json:"rank"`
+	DeviceUUID        uuid.UUID `json:"device_uuid"`
+	DeviceName        string    `json:"device_name"`
+	Actions           int64     `json:"actions"`
+	BusyMs            int64     `json:"busy_ms"`
+	AvailableMs       int64     `json:"available_ms"`
+	IdleMs            int64     `json:"idle_ms"`
+	Utilization       float64   `json:"utilization"`        // 忙碌时间占在线时间的百分比，最高 100
+	LivenessTracked   bool      `json:"liveness_tracked"`   // 为 false 时没有心跳记录，在线时间按整个周期计算
+	UtilizationChange *float64  `json:"utilization_change"` // 与上一周期相比变化的百分点，上一周期没有在线时间时为空
+}
+
+// UtilizationPoint is the lab utilization of one bucket of the trend
+type UtilizationPoint struct {
+	Start       time.Time `json:"start"`
+	BusyMs      int64     `json:"busy_ms"`
+	AvailableMs int64     `json:"available_ms"`
+	Utilization float64   `json:"utilization"`
+}
+
+// UtilizationReport compares the busy time of the devices of a lab with their
+// online time in a period, ranked by utilization, with the change against the
+// previous period of the same length and a daily trend
+type UtilizationReport struct {
+	LabID             int64                `json:"lab_id"`
+	StartTime         time.Time            `json:"start_time"`
+	EndTime           time.Time            `json:"end_time"`
+	BusyMs            int64                `json:"busy_ms"`
+	AvailableMs       int64                `json:"available_ms"`
+	Utilization       float64              `json:"utilization"`
+	UtilizationChange *float64             `json:"utilization_change"`
+	Devices           []*DeviceUtilization `json:"devices"` // 按利用率从高到低排名
+	Trend             []*UtilizationPoint  `json:"trend"`   // 每天一个点，从周期开始计算
+}
+
+// OnlineMs sums the time a device was online in [start, end) from its
+// connected and disconnected events in time order; the last event before start
+// gives the state at start. ok is false when the device has no such event
+func OnlineMs(events []*DeviceEventHistory, start, end time.Time) (int64, bool) {
+	var (
+		online   bool
+		since    = start
+		total    time.Duration
+		observed bool
+	)
+	for _, event := range events {
+		if event.EventType != DeviceEventConnected && event.EventType != DeviceEventDisconnected {
+			continue
+		}
+		observed = true
+		if !event.Timestamp.After(start) {
+			online = event.EventType == DeviceEventConnected
+			continue
+		}
+		if !event.Timestamp.Before(end) {
+			break
+		}
+		if online {
+			total += event.Timestamp.Sub(since)
+		}
+		online = event.EventType == DeviceEventConnected
+		since = event.Timestamp
+	}
+	if online && end.After(since) {
+		total += end.Sub(since)
+	}
+	return total.Milliseconds(), observed
+}
+
+// NewUtilizationReport computes the report of [start, end); availability is
+// only counted up to now
+func NewUtilizationReport(data *UtilizationData, start, end, now time.Time) *UtilizationReport {
+	report := &UtilizationReport{StartTime: start, EndTime: end, Devices: []*DeviceUtilization{}}
+	availableEnd := end
+	if now.Before(availableEnd) {
+		availableEnd = now
+	}
+	period := end.Sub(start)
+	prevStart := start.Add(-period)
+
+	buckets := int((period + UtilizationBucket - 1) / UtilizationBucket)
+	report.Trend = make([]*UtilizationPoint, 0, buckets)
+	for i := range buckets {
+		report.Trend = append(report.Trend, &UtilizationPoint{
+			Start:  start.Add(time.Duration(i) * UtilizationBucket),
+			BusyMs: data.BucketBusy[i],
+		})
+	}
+
+	var prevBusy, prevAvailable int64
+	for _, device := range data.Devices {
+		events := data.Events[device.DeviceID]
+		available, tracked := OnlineMs(events, start, availableEnd)
+		previous, _ := OnlineMs(events, prevStart, start)
+		if !tracked {
+			available = max(availableEnd.Sub(start).Milliseconds(), 0)
+			previous = period.Milliseconds()
+		}
+
+		item := &DeviceUtilization{
+			DeviceUUID:      device.DeviceUUID,
+			DeviceName:      device.DeviceName,
+			Actions:         device.Actions,
+			BusyMs:          device.BusyMs,
+			AvailableMs:     available,
+			IdleMs:          max(available-device.BusyMs, 0),
+			Utilization:     utilizationPercent(device.BusyMs, available),
+			LivenessTracked: tracked,
+		}
+		if previous > 0 {
+			change := item.Utilization - utilizationPercent(data.PreviousBusy[device.DeviceID], previous)
+			item.UtilizationChange = &change
+		}
+		report.Devices = append(report.Devices, item)
+
+		report.BusyMs += device.BusyMs
+		report.AvailableMs += available
+		prevBusy += data.PreviousBusy[device.DeviceID]
+		prevAvailable += previous
+
+		for i, point := range report.Trend {
+			bucketEnd := point.Start.Add(UtilizationBucket)
+			if bucketEnd.After(availableEnd) {
+				bucketEnd = availableEnd
+			}
+			if !bucketEnd.After(point.Start) {
+				continue
+			}
+			if tracked {
+				online, _ := OnlineMs(events, point.Start, bucketEnd)
+				report.Trend[i].AvailableMs += online
+			} else {
+				report.Trend[i].AvailableMs += bucketEnd.Sub(point.Start).Milliseconds()
+			}
+		}
+	}
+
+	report.Utilization = utilizationPercent(report.BusyMs, report.AvailableMs)
+	if prevAvailable > 0 {
+		change := report.Utilization - utilizationPercent(prevBusy, prevAvailable)
+		report.UtilizationChange = &change
+	}
+	for _, point := range report.Trend {
+		point.Utilization = utilizationPercent(point.BusyMs, point.AvailableMs)
+	}
+
+	sort.SliceStable(report.Devices, func(i, j int) bool {
+		if report.Devices[i].Utilization != report.Devices[j].Utilization {
+			return report.Devices[i].Utilization > report.Devices[j].Utilization
+		}
+		return report.Devices[i].BusyMs > report.Devices[j].BusyMs
+	})
+	for i, device := range report.Devices {
+		device.Rank = i + 1
+	}
+	return report
+}
+
+// utilizationPercent caps at 100, actions overlapping on a device or running
+// while its heartbeats were missed can exceed the online time
+func utilizationPercent(busyMs, availableMs int64) float64 {
+	if availableMs <= 0 {
+		return 0
+	}
+	return min(float64(busyMs)/float64(availableMs)*100, 100)
+}
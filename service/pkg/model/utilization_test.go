@@ -0,0 +1,98 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnlineMs(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	at := func(h int, eventType DeviceEventType) *DeviceEventHistory {
+		return &DeviceEventHistory{EventType: eventType, Timestamp: start.Add(time.Duration(h) * time.Hour)}
+	}
+
+	// 周期开始前已上线，2 点断开，5 点重新上线直到周期结束
+	online, ok := OnlineMs([]*DeviceEventHistory{
+		at(-3, DeviceEventConnected),
+		at(2, DeviceEventDisconnected),
+		at(3, DeviceEventError),
+		at(5, DeviceEventConnected),
+		at(12, DeviceEventDisconnected),
+	}, start, end)
+	assert.True(t, ok)
+	assert.Equal(t, (7 * time.Hour).Milliseconds(), online)
+
+	online, ok = OnlineMs([]*DeviceEventHistory{at(-1, DeviceEventDisconnected)}, start, end)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), online)
+
+	_, ok = OnlineMs(nil, start, end)
+	assert.False(t, ok)
+}
+
+func TestNewUtilizationReport(t *testing.T) {
+	start := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+	hour := time.Hour.Milliseconds()
+
+	report := NewUtilizationReport(&UtilizationData{
+		Devices: []*DeviceBusyTime{
+			{DeviceID: 1, DeviceName: "pipette", Actions: 4, BusyMs: 6 * hour},
+			{DeviceID: 2, DeviceName: "reader", Actions: 2, BusyMs: 6 * hour},
+			{DeviceID: 3, DeviceName: "shaker"},
+		},
+		PreviousBusy: map[int64]int64{1: 12 * hour},
+		BucketBusy:   map[int]int64{0: 8 * hour, 1: 4 * hour},
+		Events: map[int64][]*DeviceEventHistory{
+			// 上一周期开始时上线，本周期第二天开始断开
+			1: {
+				{EventType: DeviceEventConnected, Timestamp: start.Add(-48 * time.Hour)},
+				{EventType: DeviceEventDisconnected, Timestamp: start.Add(24 * time.Hour)},
+			},
+			3: {{EventType: DeviceEventConnected, Timestamp: start.Add(time.Hour)}},
+		},
+	}, start, end, end.Add(time.Hour))
+
+	assert.Len(t, report.Devices, 3)
+	pipette := report.Devices[0]
+	assert.Equal(t, "pipette", pipette.DeviceName)
+	assert.Equal(t, 1, pipette.Rank)
+	assert.Equal(t, 24*hour, pipette.AvailableMs)
+	assert.Equal(t, 18*hour, pipette.IdleMs)
+	assert.InDelta(t, 25, pipette.Utilization, 0.001)
+	assert.True(t, pipette.LivenessTracked)
+	if assert.NotNil(t, pipette.UtilizationChange) {
+		assert.InDelta(t, 0, *pipette.UtilizationChange, 0.001)
+	}
+
+	// 没有心跳记录的设备按整个周期计算在线时间
+	reader := report.Devices[1]
+	assert.Equal(t, "reader", reader.DeviceName)
+	assert.False(t, reader.LivenessTracked)
+	assert.Equal(t, 48*hour, reader.AvailableMs)
+	assert.InDelta(t, 12.5, reader.Utilization, 0.001)
+
+	shaker := report.Devices[2]
+	assert.Equal(t, 3, shaker.Rank)
+	assert.Equal(t, 47*hour, shaker.AvailableMs)
+	assert.Equal(t, float64(0), shaker.Utilization)
+	assert.Nil(t, shaker.UtilizationChange)
+
+	assert.Equal(t, 12*hour, report.BusyMs)
+	assert.Equal(t, 119*hour, report.AvailableMs)
+	assert.InDelta(t, 1200.0/119, report.Utilization, 0.001)
+
+	assert.Len(t, report.Trend, 2)
+	assert.Equal(t, 71*hour, report.Trend[0].AvailableMs)
+	assert.Equal(t, 48*hour, report.Trend[1].AvailableMs)
+	assert.InDelta(t, 100.0/12, report.Trend[1].Utilization, 0.001)
+}
+
+func TestUtilizationPercent(t *testing.T) {
+	assert.Equal(t, float64(0), utilizationPercent(10, 0))
+	assert.Equal(t, float64(50), utilizationPercent(5, 10))
+	assert.Equal(t, float64(100), utilizationPercent(30, 10))
+}
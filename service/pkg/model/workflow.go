@@ -15,6 +15,8 @@ type Workflow struct {
 	Published   bool                        `gorm:"type:bool;not null;default:false" json:"published"`
 	Tags        datatypes.JSONSlice[string] `gorm:"type:jsonb" json:"tags"`
 	Description *string                     `gorm:"type:text" json:"description"`
+	InputSchema datatypes.JSON              `gorm:"type:jsonb" json:"input_schema"`                 // 运行参数 JSON Schema
+	SLAMinutes  int                         `gorm:"type:int;not null;default:0" json:"sla_minutes"` // 提交后应在多少分钟内得到结果，0 表示未设置
 }
 
 func (*Workflow) TableName() string {
@@ -54,6 +56,8 @@ type WorkflowNode struct {
 	Disabled       bool                     `gorm:"type:bool;not null;default:false" json:"disabled"`
 	Minimized      bool                     `gorm:"type:bool;not null;default:false" json:"minimized"`
 	Script         *string                  `gorm:"type:text" json:"script"`
+	NeedApproval   bool                     `gorm:"type:bool;not null;default:false" json:"need_approval"` // 执行前需要人工审批
+	ExpectedMs     int64                    `gorm:"type:bigint;not null;default:0" json:"expected_ms"`     // 预期执行时长(毫秒)，0 表示不设预算
 
 	OldNode *WorkflowNode `gorm:"-"` // 复制的节点
 }
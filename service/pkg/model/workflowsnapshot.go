@@ -0,0 +1,33 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// WorkflowSnapshot is a workflow definition (nodes, edges, params and input
+// schema) as it was when an execution started. Snapshots are addressed by the
+// hash of their content, so executions of an unchanged workflow share one
+type WorkflowSnapshot struct {
+	Hash       string         `gorm:"type:varchar(64);primaryKey" json:"hash"`
+	WorkflowID int64          `gorm:"type:bigint;not null;index:idx_ws_workflow" json:"workflow_id"`
+	Definition datatypes.JSON `gorm:"type:jsonb;not null" json:"definition" swaggertype:"object"`
+	CreatedAt  time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (*WorkflowSnapshot) TableName() string {
+	return "workflow_snapshot"
+}
+
+// NewWorkflowSnapshot addresses the encoded definition of a workflow by its hash
+func NewWorkflowSnapshot(workflowID int64, definition []byte) *WorkflowSnapshot {
+	sum := sha256.Sum256(definition)
+	return &WorkflowSnapshot{
+		Hash:       hex.EncodeToString(sum[:]),
+		WorkflowID: workflowID,
+		Definition: definition,
+	}
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWorkflowSnapshot(t *testing.T) {
+	a := NewWorkflowSnapshot(1, []byte(`{"nodes":[],"edges":[]}`))
+	b := NewWorkflowSnapshot(2, []byte(`{"nodes":[],"edges":[]}`))
+	c := NewWorkflowSnapshot(1, []byte(`{"nodes":[{"name":"x"}],"edges":[]}`))
+
+	assert.Len(t, a.Hash, 64)
+	assert.Equal(t, a.Hash, b.Hash)
+	assert.NotEqual(t, a.Hash, c.Hash)
+}
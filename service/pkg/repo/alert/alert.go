@@ -0,0 +1,300 @@
+// Package alert provides repository operations for device alerts and silences.
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// AlertRepo defines the interface for alert repository operations
+type AlertRepo interface {
+	CreateAlert(ctx context.Context, alert *model.Alert) error
+	GetAlertByUUID(ctx context.Context, uuid uuid.UUID) (*model.Alert, error)
+	ListAlerts(ctx context.Context, q *model.AlertQuery) ([]*model.Alert, int64, error)
+	// ListOpenByRule lists the alerts of a rule that are not resolved
+	ListOpenByRule(ctx context.Context, ruleID int64) ([]*model.Alert, error)
+	UpdateValue(ctx context.Context, id int64, value float64) error
+	// Ack acknowledges a firing alert, failing with AlertStatusErr otherwise
+	Ack(ctx context.Context, alert *model.Alert, userID string) error
+	// Resolve resolves the alert unless it was resolved already, userID is
+	// empty when the rule no longer matches
+	Resolve(ctx context.Context, alert *model.Alert, userID string) (bool, error)
+	Assign(ctx context.Context, alert *model.Alert, assigneeID string) error
+	// ListEscalatable lists firing, not silenced alerts whose rule escalation delay has passed
+	ListEscalatable(ctx context.Context, now time.Time, limit int) ([]*model.Alert, error)
+	// MarkEscalated marks the alert escalated unless it was acked, resolved or escalated meanwhile
+	MarkEscalated(ctx context.Context, alert *model.Alert) (bool, error)
+
+	CreateSilence(ctx context.Context, silence *model.AlertSilence) error
+	GetSilenceByUUID(ctx context.Context, uuid uuid.UUID) (*model.AlertSilence, error)
+	// ListSilences lists the silences of a lab that did not end before since
+	ListSilences(ctx context.Context, labID int64, since time.Time) ([]*model.AlertSilence, error)
+	DeleteSilence(ctx context.Context, id int64) error
+	// IsSilenced reports whether a silence of the lab covering the device is active at
+	IsSilenced(ctx context.Context, labID int64, deviceUUID uuid.UUID, at time.Time) (bool, error)
+}
+
+type alertImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new alert repository instance
+func New() AlertRepo {
+	return &alertImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateAlert creates a firing alert
+func (a *alertImpl) CreateAlert(ctx context.Context, alert *model.Alert) error {
+	if err := a.DBWithContext(ctx).Create(alert).Error; err != nil {
+		logger.Errorf(ctx, "CreateAlert fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetAlertByUUID retrieves an alert by UUID
+func (a *alertImpl) GetAlertByUUID(ctx context.Context, id uuid.UUID) (*model.Alert, error) {
+	var data model.Alert
+	if err := a.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetAlertByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListAlerts lists alerts of a lab, newest first
+func (a *alertImpl) ListAlerts(ctx context.Context, q *model.AlertQuery) ([]*model.Alert, int64, error) {
+	var datas []*model.Alert
+	var total int64
+
+	query := a.DBWithContext(ctx).Model(&model.Alert{}).Where("lab_id = ?", q.LabID)
+	if q.Status != nil {
+		query = query.Where("status = ?", *q.Status)
+	}
+	if q.Severity != nil {
+		query = query.Where("severity = ?", *q.Severity)
+	}
+	if q.DeviceUUID != nil {
+		query = query.Where("device_uuid = ?", *q.DeviceUUID)
+	}
+	if q.AssigneeID != "" {
+		query = query.Where("assignee_id = ?", q.AssigneeID)
+	}
+	if q.StartTime != nil {
+		query = query.Where("fired_at >= ?", *q.StartTime)
+	}
+	if q.EndTime != nil {
+		query = query.Where("fired_at <= ?", *q.EndTime)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListAlerts count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (q.Page - 1) * q.PageSize
+	if err := query.Order("fired_at DESC, id DESC").Offset(offset).Limit(q.PageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListAlerts fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// ListOpenByRule lists unresolved alerts of a rule
+func (a *alertImpl) ListOpenByRule(ctx context.Context, ruleID int64) ([]*model.Alert, error) {
+	var datas []*model.Alert
+	if err := a.DBWithContext(ctx).
+		Where("rule_id = ? AND status <> ?", ruleID, model.AlertResolved).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListOpenByRule fail rule_id=%d: %+v", ruleID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateValue records the latest evaluated value of an open alert
+func (a *alertImpl) UpdateValue(ctx context.Context, id int64, value float64) error {
+	if err := a.DBWithContext(ctx).Model(&model.Alert{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"value":      value,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		logger.Errorf(ctx, "UpdateValue alert fail id=%d: %+v", id, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// Ack acknowledges a firing alert
+func (a *alertImpl) Ack(ctx context.Context, alert *model.Alert, userID string) error {
+	now := time.Now()
+	ret := a.DBWithContext(ctx).Model(&model.Alert{}).
+		Where("id = ? AND status = ?", alert.ID, model.AlertFiring).
+		Updates(map[string]any{
+			"status":     model.AlertAcked,
+			"acked_by":   userID,
+			"acked_at":   now,
+			"updated_at": now,
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "Ack alert fail id=%d: %+v", alert.ID, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.AlertStatusErr.WithMsg("only firing alerts can be acknowledged")
+	}
+
+	alert.Status = model.AlertAcked
+	alert.AckedBy = userID
+	alert.AckedAt = &now
+	return nil
+}
+
+// Resolve marks an alert resolved
+func (a *alertImpl) Resolve(ctx context.Context, alert *model.Alert, userID string) (bool, error) {
+	now := time.Now()
+	ret := a.DBWithContext(ctx).Model(&model.Alert{}).
+		Where("id = ? AND status <> ?", alert.ID, model.AlertResolved).
+		Updates(map[string]any{
+			"status":      model.AlertResolved,
+			"resolved_by": userID,
+			"resolved_at": now,
+			"updated_at":  now,
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "Resolve alert fail id=%d: %+v", alert.ID, ret.Error)
+		return false, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return false, nil
+	}
+
+	alert.Status = model.AlertResolved
+	alert.ResolvedBy = userID
+	alert.ResolvedAt = &now
+	return true, nil
+}
+
+// Assign sets the user responsible for an alert, an empty assignee clears it
+func (a *alertImpl) Assign(ctx context.Context, alert *model.Alert, assigneeID string) error {
+	if err := a.DBWithContext(ctx).Model(&model.Alert{}).
+		Where("id = ?", alert.ID).
+		Updates(map[string]any{
+			"assignee_id": assigneeID,
+			"updated_at":  time.Now(),
+		}).Error; err != nil {
+		logger.Errorf(ctx, "Assign alert fail id=%d: %+v", alert.ID, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+
+	alert.AssigneeID = assigneeID
+	return nil
+}
+
+// ListEscalatable lists alerts due for escalation
+func (a *alertImpl) ListEscalatable(ctx context.Context, now time.Time, limit int) ([]*model.Alert, error) {
+	var datas []*model.Alert
+	if err := a.DBWithContext(ctx).Model(&model.Alert{}).
+		Joins("JOIN device_rule ON device_rule.id = alert.rule_id").
+		Where("alert.status = ? AND alert.silenced = ? AND alert.escalated_at IS NULL", model.AlertFiring, false).
+		Where("device_rule.escalate_after_min > 0").
+		Where("alert.fired_at < ?::timestamptz - device_rule.escalate_after_min * INTERVAL '1 minute'", now).
+		Order("alert.id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListEscalatable alerts fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// MarkEscalated records that a firing alert was escalated
+func (a *alertImpl) MarkEscalated(ctx context.Context, alert *model.Alert) (bool, error) {
+	now := time.Now()
+	ret := a.DBWithContext(ctx).Model(&model.Alert{}).
+		Where("id = ? AND status = ? AND escalated_at IS NULL", alert.ID, model.AlertFiring).
+		Updates(map[string]any{
+			"escalated_at": now,
+			"updated_at":   now,
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "MarkEscalated alert fail id=%d: %+v", alert.ID, ret.Error)
+		return false, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return false, nil
+	}
+
+	alert.EscalatedAt = &now
+	return true, nil
+}
+
+// CreateSilence creates a silence window
+func (a *alertImpl) CreateSilence(ctx context.Context, silence *model.AlertSilence) error {
+	if err := a.DBWithContext(ctx).Create(silence).Error; err != nil {
+		logger.Errorf(ctx, "CreateSilence fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetSilenceByUUID retrieves a silence by UUID
+func (a *alertImpl) GetSilenceByUUID(ctx context.Context, id uuid.UUID) (*model.AlertSilence, error) {
+	var data model.AlertSilence
+	if err := a.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetSilenceByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListSilences lists current and upcoming silences of a lab
+func (a *alertImpl) ListSilences(ctx context.Context, labID int64, since time.Time) ([]*model.AlertSilence, error) {
+	var datas []*model.AlertSilence
+	if err := a.DBWithContext(ctx).
+		Where("lab_id = ? AND ends_at > ?", labID, since).
+		Order("starts_at ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListSilences fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// DeleteSilence deletes a silence window
+func (a *alertImpl) DeleteSilence(ctx context.Context, id int64) error {
+	if err := a.DBWithContext(ctx).Where("id = ?", id).Delete(&model.AlertSilence{}).Error; err != nil {
+		logger.Errorf(ctx, "DeleteSilence fail id=%d: %+v", id, err)
+		return code.DeleteDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// IsSilenced checks for an active lab wide or device silence
+func (a *alertImpl) IsSilenced(ctx context.Context, labID int64, deviceUUID uuid.UUID, at time.Time) (bool, error) {
+	var count int64
+	if err := a.DBWithContext(ctx).Model(&model.AlertSilence{}).
+		Where("lab_id = ? AND starts_at <= ? AND ends_at > ?", labID, at, at).
+		Where("device_uuid IS NULL OR device_uuid = ?", deviceUUID).
+		Count(&count).Error; err != nil {
+		logger.Errorf(ctx, "IsSilenced fail: %+v", err)
+		return false, code.QueryRecordErr.WithErr(err)
+	}
+	return count > 0, nil
+}
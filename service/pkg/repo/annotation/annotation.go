@@ -0,0 +1,135 @@
+// Package annotation provides repository operations for execution annotations.
+package annotation
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AnnotationRepo defines the interface for execution annotation repository operations
+type AnnotationRepo interface {
+	CreateAnnotation(ctx context.Context, data *model.ExecutionAnnotation) error
+	GetAnnotationByUUID(ctx context.Context, annotationUUID uuid.UUID) (*model.ExecutionAnnotation, error)
+	// ListAnnotations lists all annotations of an execution ordered by creation time
+	ListAnnotations(ctx context.Context, executionID int64) ([]*model.ExecutionAnnotation, error)
+	// UpdateAnnotation saves the previous content as a revision and updates the annotation
+	UpdateAnnotation(ctx context.Context, data *model.ExecutionAnnotation, revision *model.ExecutionAnnotationRevision) error
+	// DeleteAnnotation marks an annotation deleted and clears its content
+	DeleteAnnotation(ctx context.Context, id int64, deletedBy string) error
+	ListRevisions(ctx context.Context, annotationID int64) ([]*model.ExecutionAnnotationRevision, error)
+}
+
+type annotationImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new execution annotation repository instance
+func New() AnnotationRepo {
+	return &annotationImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateAnnotation creates an annotation
+func (a *annotationImpl) CreateAnnotation(ctx context.Context, data *model.ExecutionAnnotation) error {
+	if err := a.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateAnnotation fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetAnnotationByUUID retrieves an annotation by UUID
+func (a *annotationImpl) GetAnnotationByUUID(ctx context.Context, annotationUUID uuid.UUID) (*model.ExecutionAnnotation, error) {
+	var data model.ExecutionAnnotation
+	if err := a.DBWithContext(ctx).Where("uuid = ?", annotationUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetAnnotationByUUID fail uuid=%s: %+v", annotationUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListAnnotations lists annotations of an execution, oldest first
+func (a *annotationImpl) ListAnnotations(ctx context.Context, executionID int64) ([]*model.ExecutionAnnotation, error) {
+	var datas []*model.ExecutionAnnotation
+	if err := a.DBWithContext(ctx).
+		Where("execution_id = ?", executionID).
+		Order("created_at ASC, id ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListAnnotations fail execution=%d: %+v", executionID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateAnnotation updates the content and mentions of an annotation
+func (a *annotationImpl) UpdateAnnotation(ctx context.Context, data *model.ExecutionAnnotation, revision *model.ExecutionAnnotationRevision) error {
+	return a.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := a.DBWithContext(txCtx).Create(revision).Error; err != nil {
+			logger.Errorf(ctx, "UpdateAnnotation create revision fail id=%d: %+v", data.ID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		ret := a.DBWithContext(txCtx).Model(&model.ExecutionAnnotation{}).
+			Where("id = ? AND deleted_at IS NULL", data.ID).
+			Updates(map[string]any{
+				"content":    data.Content,
+				"mentions":   data.Mentions,
+				"edited_at":  data.EditedAt,
+				"updated_at": time.Now(),
+			})
+		if ret.Error != nil {
+			logger.Errorf(ctx, "UpdateAnnotation fail id=%d: %+v", data.ID, ret.Error)
+			return code.UpdateDataErr.WithErr(ret.Error)
+		}
+		if ret.RowsAffected == 0 {
+			return code.AnnotationDeletedErr
+		}
+		return nil
+	})
+}
+
+// DeleteAnnotation soft deletes an annotation so its replies stay in the thread
+func (a *annotationImpl) DeleteAnnotation(ctx context.Context, id int64, deletedBy string) error {
+	now := time.Now()
+	ret := a.DBWithContext(ctx).Model(&model.ExecutionAnnotation{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]any{
+			"content":    "",
+			"mentions":   datatypes.NewJSONType([]string{}),
+			"deleted_by": deletedBy,
+			"deleted_at": now,
+			"updated_at": now,
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteAnnotation fail id=%d: %+v", id, ret.Error)
+		return code.DeleteDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.AnnotationDeletedErr
+	}
+	return nil
+}
+
+// ListRevisions lists the previous contents of an annotation, oldest first
+func (a *annotationImpl) ListRevisions(ctx context.Context, annotationID int64) ([]*model.ExecutionAnnotationRevision, error) {
+	var datas []*model.ExecutionAnnotationRevision
+	if err := a.DBWithContext(ctx).
+		Where("annotation_id = ?", annotationID).
+		Order("created_at ASC, id ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListRevisions fail annotation=%d: %+v", annotationID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
@@ -0,0 +1,245 @@
+// Package approval provides repository operations for step approval requests.
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ApprovalRepo defines the interface for approval repository operations
+type ApprovalRepo interface {
+	// CreateApproval creates the pending action execution history record and
+	// the approval request linked to it in a single transaction
+	CreateApproval(ctx context.Context, action *model.ActionExecutionHistory, req *model.ApprovalRequest) error
+	GetApprovalByUUID(ctx context.Context, uuid uuid.UUID) (*model.ApprovalRequest, error)
+	ListPendingApprovals(ctx context.Context, labIDs []int64, page, pageSize int) ([]*model.ApprovalRequest, int64, error)
+	ListApprovalsByActions(ctx context.Context, actionExecIDs []int64) ([]*model.ApprovalRequest, error)
+
+	// DecideApproval moves a pending approval to approved or rejected and
+	// records the decision in the linked action execution history
+	DecideApproval(ctx context.Context, uuid uuid.UUID, status model.ApprovalStatus, reviewerID string, comment *string) (*model.ApprovalRequest, error)
+}
+
+type approvalImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new approval repository instance
+func New() ApprovalRepo {
+	return &approvalImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// approvalAudit is the approval record kept in action execution metadata
+type approvalAudit struct {
+	ApprovalUUID uuid.UUID            `json:"approval_uuid"`
+	RequestedBy  string               `json:"requested_by"`
+	RequestedAt  time.Time            `json:"requested_at"`
+	Status       model.ApprovalStatus `json:"status"`
+	ReviewerID   *string              `json:"reviewer_id,omitempty"`
+	Comment      *string              `json:"comment,omitempty"`
+	ReviewedAt   *time.Time           `json:"reviewed_at,omitempty"`
+}
+
+func auditMetadata(req *model.ApprovalRequest) datatypes.JSON {
+	b, _ := json.Marshal(map[string]any{
+		"approval": approvalAudit{
+			ApprovalUUID: req.UUID,
+			RequestedBy:  req.RequestedBy,
+			RequestedAt:  req.CreatedAt,
+			Status:       req.Status,
+			ReviewerID:   req.ReviewerID,
+			Comment:      req.Comment,
+			ReviewedAt:   req.ReviewedAt,
+		},
+	})
+	return datatypes.JSON(b)
+}
+
+// CreateApproval creates a pending action execution and its approval request
+func (a *approvalImpl) CreateApproval(ctx context.Context, action *model.ActionExecutionHistory, req *model.ApprovalRequest) error {
+	return a.ExecTx(ctx, func(txCtx context.Context) error {
+		// 关联到执行和设备，审批记录才会出现在执行详情和设备历史中
+		if err := a.link(txCtx, action, req.TaskUUID); err != nil {
+			return err
+		}
+
+		action.Status = model.ExecutionStatusPending
+		if err := a.DBWithContext(txCtx).Create(action).Error; err != nil {
+			logger.Errorf(ctx, "CreateApproval create action execution fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+
+		req.ActionExecutionID = action.ID
+		req.Status = model.ApprovalStatusPending
+		if err := a.DBWithContext(txCtx).Create(req).Error; err != nil {
+			logger.Errorf(ctx, "CreateApproval create approval fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+
+		if err := a.DBWithContext(txCtx).Model(&model.ActionExecutionHistory{}).
+			Where("id = ?", action.ID).
+			Update("metadata", auditMetadata(req)).Error; err != nil {
+			logger.Errorf(ctx, "CreateApproval update action metadata fail id=%d: %+v", action.ID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// link fills the workflow execution of the task and the device of the lab
+// by name when the caller did not set them. The execution history shares
+// the uuid of the task; an unregistered device keeps only its name
+func (a *approvalImpl) link(ctx context.Context, action *model.ActionExecutionHistory, taskUUID uuid.UUID) error {
+	if action.WorkflowExecutionID == nil {
+		exec := &model.WorkflowExecutionHistory{}
+		err := a.DBWithContext(ctx).Select("id").Where("uuid = ?", taskUUID).First(exec).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			logger.Errorf(ctx, "CreateApproval query workflow execution fail uuid=%s: %+v", taskUUID, err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+		if err == nil {
+			action.WorkflowExecutionID = &exec.ID
+		}
+	}
+
+	if action.DeviceUUID.IsNil() && action.DeviceName != "" {
+		device := &model.MaterialNode{}
+		err := a.DBWithContext(ctx).Select("id", "uuid").
+			Where("lab_id = ? AND name = ? AND type = ?", action.LabID, action.DeviceName, model.MATERIALDEVICE).
+			First(device).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			logger.Errorf(ctx, "CreateApproval query device fail name=%s: %+v", action.DeviceName, err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+		if err == nil {
+			action.DeviceID, action.DeviceUUID = device.ID, device.UUID
+		}
+	}
+	return nil
+}
+
+// GetApprovalByUUID retrieves an approval request by UUID
+func (a *approvalImpl) GetApprovalByUUID(ctx context.Context, id uuid.UUID) (*model.ApprovalRequest, error) {
+	var req model.ApprovalRequest
+	if err := a.DBWithContext(ctx).Where("uuid = ?", id).First(&req).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetApprovalByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &req, nil
+}
+
+// ListPendingApprovals lists pending approval requests of the given labs with pagination
+func (a *approvalImpl) ListPendingApprovals(ctx context.Context, labIDs []int64, page, pageSize int) ([]*model.ApprovalRequest, int64, error) {
+	var reqs []*model.ApprovalRequest
+	var total int64
+	if len(labIDs) == 0 {
+		return []*model.ApprovalRequest{}, 0, nil
+	}
+
+	query := a.DBWithContext(ctx).Model(&model.ApprovalRequest{}).
+		Where("lab_id IN ? AND status = ?", labIDs, model.ApprovalStatusPending)
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListPendingApprovals count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at ASC").Offset(offset).Limit(pageSize).Find(&reqs).Error; err != nil {
+		logger.Errorf(ctx, "ListPendingApprovals find fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	return reqs, total, nil
+}
+
+// ListApprovalsByActions lists the approval requests linked to the given action executions
+func (a *approvalImpl) ListApprovalsByActions(ctx context.Context, actionExecIDs []int64) ([]*model.ApprovalRequest, error) {
+	if len(actionExecIDs) == 0 {
+		return []*model.ApprovalRequest{}, nil
+	}
+
+	var reqs []*model.ApprovalRequest
+	if err := a.DBWithContext(ctx).
+		Where("action_execution_id IN ?", actionExecIDs).
+		Order("created_at ASC").
+		Find(&reqs).Error; err != nil {
+		logger.Errorf(ctx, "ListApprovalsByActions fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return reqs, nil
+}
+
+// DecideApproval approves or rejects a pending approval request
+func (a *approvalImpl) DecideApproval(ctx context.Context, id uuid.UUID, status model.ApprovalStatus,
+	reviewerID string, comment *string,
+) (*model.ApprovalRequest, error) {
+	if status != model.ApprovalStatusApproved && status != model.ApprovalStatusRejected {
+		return nil, code.ApprovalStatusInvalidErr
+	}
+
+	var req model.ApprovalRequest
+	err := a.ExecTx(ctx, func(txCtx context.Context) error {
+		now := time.Now()
+		// 条件更新保证同一个审批只能被决定一次
+		res := a.DBWithContext(txCtx).Model(&model.ApprovalRequest{}).
+			Where("uuid = ? AND status = ?", id, model.ApprovalStatusPending).
+			Updates(map[string]any{
+				"status":      status,
+				"reviewer_id": reviewerID,
+				"comment":     comment,
+				"reviewed_at": now,
+				"updated_at":  now,
+			})
+		if res.Error != nil {
+			logger.Errorf(ctx, "DecideApproval update fail uuid=%s: %+v", id, res.Error)
+			return code.UpdateDataErr.WithErr(res.Error)
+		}
+
+		if err := a.DBWithContext(txCtx).Where("uuid = ?", id).First(&req).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return code.RecordNotFound
+			}
+			logger.Errorf(ctx, "DecideApproval query fail uuid=%s: %+v", id, err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+
+		if res.RowsAffected == 0 {
+			return code.ApprovalAlreadyDecidedErr
+		}
+
+		updates := map[string]any{
+			"metadata": auditMetadata(&req),
+		}
+		if status == model.ApprovalStatusRejected {
+			updates["status"] = model.ExecutionStatusCancelled
+			updates["error_message"] = "approval rejected"
+		}
+		if err := a.DBWithContext(txCtx).Model(&model.ActionExecutionHistory{}).
+			Where("id = ?", req.ActionExecutionID).
+			Updates(updates).Error; err != nil {
+			logger.Errorf(ctx, "DecideApproval update action execution fail id=%d: %+v", req.ActionExecutionID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
@@ -0,0 +1,74 @@
+// Package assistant provides repository operations for the audit of the
+// prompts sent to the assistant model provider.
+package assistant
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+)
+
+// AuditFilter filters assistant audit entries, zero values match all entries
+type AuditFilter struct {
+	Kind   string
+	UserID string
+	LabID  int64
+}
+
+// AssistantRepo defines the interface for assistant repository operations
+type AssistantRepo interface {
+	CreateAudit(ctx context.Context, data *model.AssistantAudit) error
+	// ListAudits lists audit entries newest first
+	ListAudits(ctx context.Context, filter *AuditFilter, page, pageSize int) ([]*model.AssistantAudit, int64, error)
+}
+
+type assistantImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new assistant repository instance
+func New() AssistantRepo {
+	return &assistantImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateAudit creates an audit entry
+func (a *assistantImpl) CreateAudit(ctx context.Context, data *model.AssistantAudit) error {
+	if err := a.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateAudit fail execution=%s: %+v", data.ExecutionUUID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListAudits lists audit entries
+func (a *assistantImpl) ListAudits(ctx context.Context, filter *AuditFilter, page, pageSize int) ([]*model.AssistantAudit, int64, error) {
+	var datas []*model.AssistantAudit
+	var total int64
+
+	query := a.DBWithContext(ctx).Model(&model.AssistantAudit{})
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.LabID > 0 {
+		query = query.Where("lab_id = ?", filter.LabID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListAudits count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListAudits fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
@@ -0,0 +1,97 @@
+// Package batch provides repository operations for workflow batch runs.
+package batch
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// BatchTask is a child execution of a batch with its live task status
+type BatchTask struct {
+	UUID   uuid.UUID                `gorm:"column:uuid"`
+	Status model.WorkflowTaskStatus `gorm:"column:status"`
+	Input  datatypes.JSON           `gorm:"column:input"`
+}
+
+// BatchRepo defines the interface for batch repository operations
+type BatchRepo interface {
+	CreateBatch(ctx context.Context, batch *model.WorkflowBatch) error
+	GetBatchByUUID(ctx context.Context, uuid uuid.UUID) (*model.WorkflowBatch, error)
+	// CancelBatch marks the batch cancelled, child tasks are stopped by the caller
+	CancelBatch(ctx context.Context, id int64) error
+	// ListBatchTasks lists child executions in submission order
+	ListBatchTasks(ctx context.Context, batchID int64) ([]*BatchTask, error)
+}
+
+type batchImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new batch repository instance
+func New() BatchRepo {
+	return &batchImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateBatch creates a batch record
+func (b *batchImpl) CreateBatch(ctx context.Context, batch *model.WorkflowBatch) error {
+	if err := b.DBWithContext(ctx).Create(batch).Error; err != nil {
+		logger.Errorf(ctx, "CreateBatch fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetBatchByUUID retrieves a batch by UUID
+func (b *batchImpl) GetBatchByUUID(ctx context.Context, id uuid.UUID) (*model.WorkflowBatch, error) {
+	var data model.WorkflowBatch
+	if err := b.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetBatchByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// CancelBatch marks the batch as cancelled
+func (b *batchImpl) CancelBatch(ctx context.Context, id int64) error {
+	now := time.Now()
+	if err := b.DBWithContext(ctx).Model(&model.WorkflowBatch{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":       model.ExecutionStatusCancelled,
+			"cancelled_at": now,
+			"updated_at":   now,
+		}).Error; err != nil {
+		logger.Errorf(ctx, "CancelBatch fail id=%d: %+v", id, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListBatchTasks joins the child execution history with its workflow task
+func (b *batchImpl) ListBatchTasks(ctx context.Context, batchID int64) ([]*BatchTask, error) {
+	var datas []*BatchTask
+	if err := b.DBWithContext(ctx).
+		Table("workflow_execution_history").
+		Select("workflow_execution_history.uuid, workflow_task.status, workflow_execution_history.input").
+		Joins("JOIN workflow_task ON workflow_task.uuid = workflow_execution_history.uuid").
+		Where("workflow_execution_history.batch_id = ?", batchID).
+		Order("workflow_execution_history.id ASC").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListBatchTasks fail batch id=%d: %+v", batchID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
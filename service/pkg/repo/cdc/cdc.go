@@ -0,0 +1,104 @@
+// Package cdc provides repository operations on the postgres logical
+// replication slot the history change stream is read from.
+package cdc
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+)
+
+// Plugin is the output plugin of the slot
+const Plugin = "wal2json"
+
+// CDCRepo defines the interface for replication slot operations
+type CDCRepo interface {
+	// Supported reports whether the database can decode its WAL, false on sqlite
+	Supported(ctx context.Context) bool
+	// Slot returns the state of the slot in the current database, nil when it does not exist
+	Slot(ctx context.Context, name string) (*model.ReplicationSlot, error)
+	CreateSlot(ctx context.Context, name string) error
+	DropSlot(ctx context.Context, name string) error
+	// PeekChanges decodes the changes after the confirmed position as wal2json
+	// format version 2 without consuming them. Whole transactions are returned,
+	// so there may be more rows than limit
+	PeekChanges(ctx context.Context, name string, limit int) ([]*model.DecodedChange, error)
+	// Advance confirms the changes up to lsn, the slot no longer returns them
+	Advance(ctx context.Context, name, lsn string) error
+}
+
+type cdcImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new replication slot repository instance
+func New() CDCRepo {
+	return &cdcImpl{IDOrUUIDTranslate: repo.NewBaseDB()}
+}
+
+// Supported is false on sqlite, which has no replication slots
+func (c *cdcImpl) Supported(ctx context.Context) bool {
+	return !db.IsSQLite(c.DBWithContext(ctx))
+}
+
+// Slot reads the slot from pg_replication_slots
+func (c *cdcImpl) Slot(ctx context.Context, name string) (*model.ReplicationSlot, error) {
+	var slots []*model.ReplicationSlot
+	if err := c.DBWithContext(ctx).Raw(`
+SELECT slot_name AS name, plugin, active,
+	COALESCE(confirmed_flush_lsn::text, '') AS confirmed_flush_lsn,
+	COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn), 0)::bigint AS lag_bytes
+FROM pg_replication_slots
+WHERE slot_name = ? AND database = current_database()`, name).Scan(&slots).Error; err != nil {
+		logger.Errorf(ctx, "Slot fail name=%s: %+v", name, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	if len(slots) == 0 {
+		return nil, nil
+	}
+	return slots[0], nil
+}
+
+// CreateSlot creates a logical slot decoding with wal2json
+func (c *cdcImpl) CreateSlot(ctx context.Context, name string) error {
+	if err := c.DBWithContext(ctx).Exec(`SELECT pg_create_logical_replication_slot(?, ?)`, name, Plugin).Error; err != nil {
+		logger.Errorf(ctx, "CreateSlot fail name=%s: %+v", name, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// DropSlot drops the slot, releasing the WAL it retains
+func (c *cdcImpl) DropSlot(ctx context.Context, name string) error {
+	if err := c.DBWithContext(ctx).Exec(`SELECT pg_drop_replication_slot(?)`, name).Error; err != nil {
+		logger.Errorf(ctx, "DropSlot fail name=%s: %+v", name, err)
+		return code.DeleteDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// PeekChanges reads the decoded changes with their transaction ids and commit times
+func (c *cdcImpl) PeekChanges(ctx context.Context, name string, limit int) ([]*model.DecodedChange, error) {
+	var changes []*model.DecodedChange
+	if err := c.DBWithContext(ctx).Raw(`
+SELECT lsn::text AS lsn, xid::text::bigint AS xid, data
+FROM pg_logical_slot_peek_changes(?, NULL, ?,
+	'format-version', '2', 'include-xids', '1', 'include-timestamp', '1')`, name, limit).Scan(&changes).Error; err != nil {
+		logger.Errorf(ctx, "PeekChanges fail name=%s: %+v", name, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return changes, nil
+}
+
+// Advance moves the confirmed position of the slot
+func (c *cdcImpl) Advance(ctx context.Context, name, lsn string) error {
+	if err := c.DBWithContext(ctx).Exec(`SELECT pg_replication_slot_advance(?, ?::pg_lsn)`, name, lsn).Error; err != nil {
+		logger.Errorf(ctx, "Advance fail name=%s lsn=%s: %+v", name, lsn, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,417 @@
+// Package command provides repository operations for queued device commands.
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/payload"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CommandQuery represents filters for listing device commands
+type CommandQuery struct {
+	LabID      int64
+	DeviceName string
+	Status     *model.DeviceCommandStatus
+	Page       int
+	PageSize   int
+}
+
+// CommandRepo defines the interface for device command repository operations
+type CommandRepo interface {
+	// CreateCommand stores the command together with its pending action execution record
+	CreateCommand(ctx context.Context, cmd *model.DeviceCommand, action *model.ActionExecutionHistory) error
+	GetCommandByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceCommand, error)
+	ListCommands(ctx context.Context, query *CommandQuery) ([]*model.DeviceCommand, int64, error)
+	// ClaimNext delivers the oldest queued command of the given devices,
+	// returning nil when there is none
+	ClaimNext(ctx context.Context, labID int64, deviceNames []string) (*model.DeviceCommand, error)
+	// Ack moves a delivered command to acked and starts its result deadline
+	Ack(ctx context.Context, cmd *model.DeviceCommand) error
+	// Finish stores the result of a delivered or acked command and its action
+	// execution, errCategory is the classified category of errMsg
+	Finish(ctx context.Context, cmd *model.DeviceCommand, status model.DeviceCommandStatus, result datatypes.JSON, errMsg *string, errCategory string) error
+	// ListOverdue lists commands whose ack or result deadline passed
+	ListOverdue(ctx context.Context, now time.Time, limit int) ([]*model.DeviceCommand, error)
+	// Requeue puts an unacknowledged command back in the queue
+	Requeue(ctx context.Context, cmd *model.DeviceCommand) (bool, error)
+
+	// CreateGroupCommand stores the group command with the commands fanned out
+	// to its devices and their pending action execution records
+	CreateGroupCommand(ctx context.Context, group *model.DeviceGroupCommand, cmds []*model.DeviceCommand, actions []*model.ActionExecutionHistory) error
+	GetGroupCommandByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceGroupCommand, error)
+	// ListGroupCommands lists the group commands of a lab, newest first
+	ListGroupCommands(ctx context.Context, labID int64, page, pageSize int) ([]*model.DeviceGroupCommand, int64, error)
+	// CountGroupStatuses counts the commands of each group command by status
+	CountGroupStatuses(ctx context.Context, groupIDs []int64) (map[int64]map[model.DeviceCommandStatus]int64, error)
+	// ListGroupMembers lists the commands of a group command by device name
+	ListGroupMembers(ctx context.Context, groupID int64) ([]*model.DeviceCommand, error)
+}
+
+type commandImpl struct {
+	repo.IDOrUUIDTranslate
+	payloads *payload.Limiter
+}
+
+// New creates a new device command repository instance
+func New() CommandRepo {
+	return &commandImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+		payloads:          payload.New(),
+	}
+}
+
+// CreateCommand creates the action execution and the queued command
+func (c *commandImpl) CreateCommand(ctx context.Context, cmd *model.DeviceCommand, action *model.ActionExecutionHistory) error {
+	if err := c.payloads.ActionExecution(ctx, action); err != nil {
+		return err
+	}
+	return c.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := c.DBWithContext(txCtx).Create(action).Error; err != nil {
+			logger.Errorf(ctx, "CreateCommand action fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+
+		cmd.ActionExecutionID = action.ID
+		cmd.Status = model.DeviceCommandQueued
+		if err := c.DBWithContext(txCtx).Create(cmd).Error; err != nil {
+			logger.Errorf(ctx, "CreateCommand fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// GetCommandByUUID retrieves a command by UUID
+func (c *commandImpl) GetCommandByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceCommand, error) {
+	var data model.DeviceCommand
+	if err := c.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetCommandByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListCommands lists commands of a lab, newest first
+func (c *commandImpl) ListCommands(ctx context.Context, q *CommandQuery) ([]*model.DeviceCommand, int64, error) {
+	var datas []*model.DeviceCommand
+	var total int64
+
+	query := c.DBWithContext(ctx).Model(&model.DeviceCommand{}).Where("lab_id = ?", q.LabID)
+	if q.DeviceName != "" {
+		query = query.Where("device_name = ?", q.DeviceName)
+	}
+	if q.Status != nil {
+		query = query.Where("status = ?", *q.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListCommands count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (q.Page - 1) * q.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(q.PageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListCommands fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// ClaimNext locks and delivers the next queued command
+func (c *commandImpl) ClaimNext(ctx context.Context, labID int64, deviceNames []string) (*model.DeviceCommand, error) {
+	var claimed *model.DeviceCommand
+	err := c.ExecTx(ctx, func(txCtx context.Context) error {
+		query := c.DBWithContext(txCtx).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("lab_id = ? AND status = ?", labID, model.DeviceCommandQueued)
+		if len(deviceNames) > 0 {
+			query = query.Where("device_name IN ?", deviceNames)
+		}
+
+		var data model.DeviceCommand
+		if err := query.Order("id ASC").First(&data).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			logger.Errorf(ctx, "ClaimNext query fail: %+v", err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+
+		now := time.Now()
+		deadline := now.Add(time.Duration(data.AckTimeoutSec) * time.Second)
+		if err := c.DBWithContext(txCtx).Model(&model.DeviceCommand{}).
+			Where("id = ?", data.ID).
+			Updates(map[string]any{
+				"status":       model.DeviceCommandDelivered,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"delivered_at": now,
+				"ack_deadline": deadline,
+				"updated_at":   now,
+			}).Error; err != nil {
+			logger.Errorf(ctx, "ClaimNext update fail id=%d: %+v", data.ID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+
+		data.Status = model.DeviceCommandDelivered
+		data.Attempts++
+		data.DeliveredAt = &now
+		data.AckDeadline = &deadline
+		claimed = &data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// Ack acknowledges a delivered command
+func (c *commandImpl) Ack(ctx context.Context, cmd *model.DeviceCommand) error {
+	now := time.Now()
+	deadline := now.Add(time.Duration(cmd.ResultTimeoutSec) * time.Second)
+	return c.ExecTx(ctx, func(txCtx context.Context) error {
+		ret := c.DBWithContext(txCtx).Model(&model.DeviceCommand{}).
+			Where("id = ? AND status = ?", cmd.ID, model.DeviceCommandDelivered).
+			Updates(map[string]any{
+				"status":          model.DeviceCommandAcked,
+				"acked_at":        now,
+				"result_deadline": deadline,
+				"updated_at":      now,
+			})
+		if ret.Error != nil {
+			logger.Errorf(ctx, "Ack command fail id=%d: %+v", cmd.ID, ret.Error)
+			return code.UpdateDataErr.WithErr(ret.Error)
+		}
+		if ret.RowsAffected == 0 {
+			return code.DeviceCommandStateErr.WithMsg("command is not waiting for acknowledgment")
+		}
+
+		if err := c.DBWithContext(txCtx).Model(&model.ActionExecutionHistory{}).
+			Where("id = ?", cmd.ActionExecutionID).
+			Updates(map[string]any{
+				"status":     model.ExecutionStatusRunning,
+				"updated_at": now,
+			}).Error; err != nil {
+			logger.Errorf(ctx, "Ack command update action fail id=%d: %+v", cmd.ActionExecutionID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+
+		cmd.Status = model.DeviceCommandAcked
+		cmd.AckedAt = &now
+		cmd.ResultDeadline = &deadline
+		return nil
+	})
+}
+
+// Finish records the final state of a command
+func (c *commandImpl) Finish(ctx context.Context, cmd *model.DeviceCommand, status model.DeviceCommandStatus, result datatypes.JSON, errMsg *string, errCategory string) error {
+	// 命令和动作执行记录保存同一份结果
+	result, err := c.payloads.Apply(ctx, cmd.LabID, (&model.ActionExecutionHistory{}).TableName(), "output", result)
+	if err != nil {
+		return err
+	}
+	// map 更新不经过模型的序列化器，动作输出需自行压缩
+	output, err := model.CompressJSON(result, model.CompressThreshold())
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return c.ExecTx(ctx, func(txCtx context.Context) error {
+		ret := c.DBWithContext(txCtx).Model(&model.DeviceCommand{}).
+			Where("id = ? AND status IN ?", cmd.ID, []model.DeviceCommandStatus{
+				model.DeviceCommandDelivered, model.DeviceCommandAcked,
+			}).
+			Updates(map[string]any{
+				"status":      status,
+				"result":      result,
+				"error":       errMsg,
+				"finished_at": now,
+				"updated_at":  now,
+			})
+		if ret.Error != nil {
+			logger.Errorf(ctx, "Finish command fail id=%d: %+v", cmd.ID, ret.Error)
+			return code.UpdateDataErr.WithErr(ret.Error)
+		}
+		if ret.RowsAffected == 0 {
+			return code.DeviceCommandStateErr.WithMsg("command already finished")
+		}
+
+		execStatus := model.ExecutionStatusFailed
+		switch status {
+		case model.DeviceCommandSucceeded:
+			execStatus = model.ExecutionStatusSuccess
+		case model.DeviceCommandTimeout:
+			execStatus = model.ExecutionStatusTimeout
+		}
+
+		var durationMs int64
+		if cmd.DeliveredAt != nil {
+			durationMs = now.Sub(*cmd.DeliveredAt).Milliseconds()
+		}
+		if err := c.DBWithContext(txCtx).Model(&model.ActionExecutionHistory{}).
+			Where("id = ?", cmd.ActionExecutionID).
+			Updates(map[string]any{
+				"status":         execStatus,
+				"output":         output,
+				"error_message":  errMsg,
+				"error_category": errCategory,
+				"duration_ms":    durationMs,
+				"updated_at":     now,
+			}).Error; err != nil {
+			logger.Errorf(ctx, "Finish command update action fail id=%d: %+v", cmd.ActionExecutionID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+
+		cmd.Status = status
+		cmd.Result = result
+		cmd.Error = errMsg
+		cmd.FinishedAt = &now
+		return nil
+	})
+}
+
+// ListOverdue lists delivered commands past their ack deadline and acked commands past their result deadline
+func (c *commandImpl) ListOverdue(ctx context.Context, now time.Time, limit int) ([]*model.DeviceCommand, error) {
+	var datas []*model.DeviceCommand
+	if err := c.DBWithContext(ctx).
+		Where("(status = ? AND ack_deadline < ?) OR (status = ? AND result_deadline < ?)",
+			model.DeviceCommandDelivered, now, model.DeviceCommandAcked, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListOverdue commands fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// Requeue conditionally puts a delivered command back in the queue
+func (c *commandImpl) Requeue(ctx context.Context, cmd *model.DeviceCommand) (bool, error) {
+	ret := c.DBWithContext(ctx).Model(&model.DeviceCommand{}).
+		Where("id = ? AND status = ? AND attempts = ?", cmd.ID, model.DeviceCommandDelivered, cmd.Attempts).
+		Updates(map[string]any{
+			"status":       model.DeviceCommandQueued,
+			"ack_deadline": nil,
+			"updated_at":   time.Now(),
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "Requeue command fail id=%d: %+v", cmd.ID, ret.Error)
+		return false, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected == 1, nil
+}
+
+// CreateGroupCommand creates the group command and its queued commands
+func (c *commandImpl) CreateGroupCommand(ctx context.Context, group *model.DeviceGroupCommand, cmds []*model.DeviceCommand, actions []*model.ActionExecutionHistory) error {
+	for _, action := range actions {
+		if err := c.payloads.ActionExecution(ctx, action); err != nil {
+			return err
+		}
+	}
+	return c.ExecTx(ctx, func(txCtx context.Context) error {
+		group.Total = len(cmds)
+		if err := c.DBWithContext(txCtx).Create(group).Error; err != nil {
+			logger.Errorf(ctx, "CreateGroupCommand fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		if err := c.DBWithContext(txCtx).Create(&actions).Error; err != nil {
+			logger.Errorf(ctx, "CreateGroupCommand actions fail group id=%d: %+v", group.ID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+
+		for i, cmd := range cmds {
+			cmd.ActionExecutionID = actions[i].ID
+			cmd.GroupCommandID = &group.ID
+			cmd.Status = model.DeviceCommandQueued
+		}
+		if err := c.DBWithContext(txCtx).Create(&cmds).Error; err != nil {
+			logger.Errorf(ctx, "CreateGroupCommand commands fail group id=%d: %+v", group.ID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// GetGroupCommandByUUID retrieves a group command by UUID
+func (c *commandImpl) GetGroupCommandByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceGroupCommand, error) {
+	var data model.DeviceGroupCommand
+	if err := c.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetGroupCommandByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListGroupCommands lists group commands of a lab, newest first
+func (c *commandImpl) ListGroupCommands(ctx context.Context, labID int64, page, pageSize int) ([]*model.DeviceGroupCommand, int64, error) {
+	var datas []*model.DeviceGroupCommand
+	var total int64
+
+	query := c.DBWithContext(ctx).Model(&model.DeviceGroupCommand{}).Where("lab_id = ?", labID)
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListGroupCommands count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListGroupCommands fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// CountGroupStatuses counts the commands of the group commands by status
+func (c *commandImpl) CountGroupStatuses(ctx context.Context, groupIDs []int64) (map[int64]map[model.DeviceCommandStatus]int64, error) {
+	ret := make(map[int64]map[model.DeviceCommandStatus]int64, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return ret, nil
+	}
+
+	var rows []struct {
+		GroupCommandID int64
+		Status         model.DeviceCommandStatus
+		Count          int64
+	}
+	if err := c.DBWithContext(ctx).Model(&model.DeviceCommand{}).
+		Select("group_command_id, status, COUNT(*) AS count").
+		Where("group_command_id IN ?", groupIDs).
+		Group("group_command_id, status").
+		Scan(&rows).Error; err != nil {
+		logger.Errorf(ctx, "CountGroupStatuses fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	for _, row := range rows {
+		if ret[row.GroupCommandID] == nil {
+			ret[row.GroupCommandID] = make(map[model.DeviceCommandStatus]int64)
+		}
+		ret[row.GroupCommandID][row.Status] = row.Count
+	}
+	return ret, nil
+}
+
+// ListGroupMembers lists the commands of a group command
+func (c *commandImpl) ListGroupMembers(ctx context.Context, groupID int64) ([]*model.DeviceCommand, error) {
+	var datas []*model.DeviceCommand
+	if err := c.DBWithContext(ctx).
+		Where("group_command_id = ?", groupID).
+		Order("device_name ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListGroupMembers fail group id=%d: %+v", groupID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
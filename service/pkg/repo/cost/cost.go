@@ -0,0 +1,170 @@
+// Package cost provides repository operations for lab cost models and computed cost records.
+package cost
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const insertBatch = 500
+
+// finished action statuses that are billed
+var billedStatuses = []model.ExecutionStatus{
+	model.ExecutionStatusSuccess,
+	model.ExecutionStatusFailed,
+	model.ExecutionStatusCancelled,
+	model.ExecutionStatusTimeout,
+}
+
+// CostRepo defines the interface for cost accounting repository operations
+type CostRepo interface {
+	GetCostModel(ctx context.Context, labID int64) (*model.CostModel, error)
+	ListCostModels(ctx context.Context, labIDs []int64) ([]*model.CostModel, error)
+	// UpsertCostModel creates or replaces the cost model of a lab
+	UpsertCostModel(ctx context.Context, data *model.CostModel) error
+
+	// DeviceHours sums the run time of finished actions per lab and device created in [start, end)
+	DeviceHours(ctx context.Context, start, end time.Time) ([]*model.CostUsage, error)
+	// ActionCounts counts finished actions per lab and action type created in [start, end)
+	ActionCounts(ctx context.Context, start, end time.Time) ([]*model.CostUsage, error)
+	// StorageGB sums the size in GB of result files available before the given time per lab
+	StorageGB(ctx context.Context, before time.Time) ([]*model.CostUsage, error)
+	// ReplaceDay replaces all cost records of a day
+	ReplaceDay(ctx context.Context, day time.Time, records []*model.CostRecord) error
+	// ListRecords lists cost records of a lab for days in [start, end]
+	ListRecords(ctx context.Context, labID int64, start, end time.Time) ([]*model.CostRecord, error)
+}
+
+type costImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new cost accounting repository instance
+func New() CostRepo {
+	return &costImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// GetCostModel retrieves the cost model of a lab
+func (c *costImpl) GetCostModel(ctx context.Context, labID int64) (*model.CostModel, error) {
+	var data model.CostModel
+	if err := c.DBWithContext(ctx).Where("lab_id = ?", labID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetCostModel fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListCostModels lists the cost models of the given labs
+func (c *costImpl) ListCostModels(ctx context.Context, labIDs []int64) ([]*model.CostModel, error) {
+	if len(labIDs) == 0 {
+		return nil, nil
+	}
+
+	var datas []*model.CostModel
+	if err := c.DBWithContext(ctx).Where("lab_id IN ?", labIDs).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListCostModels fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpsertCostModel creates or replaces the cost model of a lab
+func (c *costImpl) UpsertCostModel(ctx context.Context, data *model.CostModel) error {
+	data.UpdatedAt = time.Now()
+	if err := c.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "lab_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"currency", "device_hour_rate", "device_hour_rates", "action_type_rates",
+			"storage_gb_month_rate", "updated_by", "updated_at",
+		}),
+	}).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "UpsertCostModel fail lab=%d: %+v", data.LabID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// DeviceHours sums action run time per lab and device
+func (c *costImpl) DeviceHours(ctx context.Context, start, end time.Time) ([]*model.CostUsage, error) {
+	var datas []*model.CostUsage
+	if err := c.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select("lab_id, device_name AS item, SUM(duration_ms) / 3600000.0 AS quantity").
+		Where("created_at >= ? AND created_at < ? AND status IN ?", start, end, billedStatuses).
+		Group("lab_id, device_name").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "DeviceHours fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ActionCounts counts actions per lab and action type
+func (c *costImpl) ActionCounts(ctx context.Context, start, end time.Time) ([]*model.CostUsage, error) {
+	var datas []*model.CostUsage
+	if err := c.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select("lab_id, action_type AS item, COUNT(*) AS quantity").
+		Where("created_at >= ? AND created_at < ? AND status IN ?", start, end, billedStatuses).
+		Group("lab_id, action_type").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ActionCounts fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// StorageGB sums stored result file sizes per lab
+func (c *costImpl) StorageGB(ctx context.Context, before time.Time) ([]*model.CostUsage, error) {
+	var datas []*model.CostUsage
+	if err := c.DBWithContext(ctx).Model(&model.ResultFile{}).
+		Select("lab_id, ? AS item, SUM(size) / 1073741824.0 AS quantity", model.CostItemResult).
+		Where("status = ? AND completed_at < ?", model.ResultFileAvailable, before).
+		Group("lab_id").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "StorageGB fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ReplaceDay deletes the cost records of a day and inserts the recomputed ones
+func (c *costImpl) ReplaceDay(ctx context.Context, day time.Time, records []*model.CostRecord) error {
+	return c.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := c.DBWithContext(txCtx).Where("day = ?", day).Delete(&model.CostRecord{}).Error; err != nil {
+			logger.Errorf(ctx, "ReplaceDay delete fail day=%s: %+v", day.Format(time.DateOnly), err)
+			return code.DeleteDataErr.WithErr(err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if err := c.DBWithContext(txCtx).CreateInBatches(records, insertBatch).Error; err != nil {
+			logger.Errorf(ctx, "ReplaceDay create fail day=%s: %+v", day.Format(time.DateOnly), err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// ListRecords lists cost records of a lab ordered by day
+func (c *costImpl) ListRecords(ctx context.Context, labID int64, start, end time.Time) ([]*model.CostRecord, error) {
+	var datas []*model.CostRecord
+	if err := c.DBWithContext(ctx).
+		Where("lab_id = ? AND day >= ? AND day <= ?", labID, start, end).
+		Order("day ASC, category ASC, item ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListRecords fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
@@ -0,0 +1,123 @@
+// Package deadletter provides repository operations for dead-lettered scheduler jobs.
+package deadletter
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// DeadLetterRepo defines the interface for dead-letter repository operations
+type DeadLetterRepo interface {
+	CreateDeadLetter(ctx context.Context, job *model.DeadLetterJob) error
+	GetDeadLetterByUUID(ctx context.Context, uuid uuid.UUID) (*model.DeadLetterJob, error)
+	ListDeadLetters(ctx context.Context, labID int64, status *model.DeadLetterStatus, page, pageSize int) ([]*model.DeadLetterJob, int64, error)
+	// RequeueDeadLetter marks the entry requeued and publishes it in the same
+	// transaction, failing with DeadLetterNotDeadErr if it was already requeued
+	RequeueDeadLetter(ctx context.Context, job *model.DeadLetterJob, userID string, publish func() error) error
+	// Stats returns depth and oldest entry of the queue across all labs
+	Stats(ctx context.Context) (*model.DeadLetterStats, error)
+}
+
+type deadLetterImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new dead-letter repository instance
+func New() DeadLetterRepo {
+	return &deadLetterImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateDeadLetter stores a job that exhausted its retries
+func (d *deadLetterImpl) CreateDeadLetter(ctx context.Context, job *model.DeadLetterJob) error {
+	job.Status = model.DeadLetterStatusDead
+	if err := d.DBWithContext(ctx).Create(job).Error; err != nil {
+		logger.Errorf(ctx, "CreateDeadLetter fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetDeadLetterByUUID retrieves an entry by UUID
+func (d *deadLetterImpl) GetDeadLetterByUUID(ctx context.Context, id uuid.UUID) (*model.DeadLetterJob, error) {
+	var data model.DeadLetterJob
+	if err := d.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetDeadLetterByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListDeadLetters lists entries of a lab, newest first
+func (d *deadLetterImpl) ListDeadLetters(ctx context.Context, labID int64, status *model.DeadLetterStatus, page, pageSize int) ([]*model.DeadLetterJob, int64, error) {
+	var datas []*model.DeadLetterJob
+	var total int64
+
+	query := d.DBWithContext(ctx).Model(&model.DeadLetterJob{}).Where("lab_id = ?", labID)
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListDeadLetters count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListDeadLetters fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// RequeueDeadLetter marks the entry requeued and publishes it
+func (d *deadLetterImpl) RequeueDeadLetter(ctx context.Context, job *model.DeadLetterJob, userID string, publish func() error) error {
+	return d.ExecTx(ctx, func(txCtx context.Context) error {
+		ret := d.DBWithContext(txCtx).Model(&model.DeadLetterJob{}).
+			Where("id = ? AND status = ?", job.ID, model.DeadLetterStatusDead).
+			Updates(map[string]any{
+				"status":        model.DeadLetterStatusRequeued,
+				"requeue_count": gorm.Expr("requeue_count + 1"),
+				"requeued_by":   userID,
+				"requeued_at":   time.Now(),
+				"updated_at":    time.Now(),
+			})
+		if ret.Error != nil {
+			logger.Errorf(ctx, "RequeueDeadLetter fail id=%d: %+v", job.ID, ret.Error)
+			return code.UpdateDataErr.WithErr(ret.Error)
+		}
+		if ret.RowsAffected == 0 {
+			return code.DeadLetterNotDeadErr
+		}
+
+		return publish()
+	})
+}
+
+// Stats returns depth and oldest entry of the queue
+func (d *deadLetterImpl) Stats(ctx context.Context) (*model.DeadLetterStats, error) {
+	var ret struct {
+		Depth    int64
+		OldestAt *time.Time
+	}
+	if err := d.DBWithContext(ctx).Model(&model.DeadLetterJob{}).
+		Select("COUNT(*) AS depth, MIN(created_at) AS oldest_at").
+		Where("status = ?", model.DeadLetterStatusDead).
+		Scan(&ret).Error; err != nil {
+		logger.Errorf(ctx, "DeadLetter Stats fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &model.DeadLetterStats{Depth: ret.Depth, OldestAt: ret.OldestAt}, nil
+}
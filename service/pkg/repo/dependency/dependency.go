@@ -0,0 +1,193 @@
+// Package dependency provides repository operations for execution dependency chaining.
+package dependency
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+)
+
+// DependencyRepo defines the interface for dependency repository operations
+type DependencyRepo interface {
+	// HoldTask records the dependency edges and parks the task payload
+	HoldTask(ctx context.Context, held *model.WorkflowHeldTask, dependsOn []uuid.UUID) error
+	// ListHeldTasks lists tasks still waiting on prerequisites, oldest first
+	ListHeldTasks(ctx context.Context, limit int) ([]*model.WorkflowHeldTask, error)
+	// PrerequisiteStatus returns the current task status of every prerequisite
+	PrerequisiteStatus(ctx context.Context, taskUUID uuid.UUID) ([]model.WorkflowTaskStatus, error)
+	// ReleaseHeldTask marks the task released and publishes it in the same transaction,
+	// false if another replica got there first
+	ReleaseHeldTask(ctx context.Context, held *model.WorkflowHeldTask, publish func() error) (bool, error)
+	// CancelHeldTask cancels the held task together with its workflow task and history
+	CancelHeldTask(ctx context.Context, held *model.WorkflowHeldTask, reason string) (bool, error)
+	// ListEdges lists the edges where the task is either side
+	ListEdges(ctx context.Context, taskUUID uuid.UUID) ([]*model.WorkflowTaskDependency, error)
+	// GetHeldTask returns the held record of the task, nil if it was never held
+	GetHeldTask(ctx context.Context, taskUUID uuid.UUID) (*model.WorkflowHeldTask, error)
+}
+
+type dependencyImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new dependency repository instance
+func New() DependencyRepo {
+	return &dependencyImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+func (d *dependencyImpl) HoldTask(ctx context.Context, held *model.WorkflowHeldTask, dependsOn []uuid.UUID) error {
+	edges := make([]*model.WorkflowTaskDependency, 0, len(dependsOn))
+	for _, id := range dependsOn {
+		edges = append(edges, &model.WorkflowTaskDependency{
+			TaskUUID:      held.TaskUUID,
+			DependsOnUUID: id,
+		})
+	}
+
+	return d.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := d.DBWithContext(txCtx).Create(&edges).Error; err != nil {
+			logger.Errorf(ctx, "HoldTask create edges fail task=%s: %+v", held.TaskUUID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+
+		held.Status = model.HeldTaskStatusHeld
+		if err := d.DBWithContext(txCtx).Create(held).Error; err != nil {
+			logger.Errorf(ctx, "HoldTask fail task=%s: %+v", held.TaskUUID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+func (d *dependencyImpl) ListHeldTasks(ctx context.Context, limit int) ([]*model.WorkflowHeldTask, error) {
+	var datas []*model.WorkflowHeldTask
+	if err := d.DBWithContext(ctx).
+		Where("status = ?", model.HeldTaskStatusHeld).
+		Order("id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListHeldTasks fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+func (d *dependencyImpl) PrerequisiteStatus(ctx context.Context, taskUUID uuid.UUID) ([]model.WorkflowTaskStatus, error) {
+	var statuses []model.WorkflowTaskStatus
+	if err := d.DBWithContext(ctx).
+		Table("workflow_task_dependency").
+		Joins("JOIN workflow_task ON workflow_task.uuid = workflow_task_dependency.depends_on_uuid").
+		Where("workflow_task_dependency.task_uuid = ?", taskUUID).
+		Pluck("workflow_task.status", &statuses).Error; err != nil {
+		logger.Errorf(ctx, "PrerequisiteStatus fail task=%s: %+v", taskUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return statuses, nil
+}
+
+// transitHeldTask 仅 held 状态可以流转，保证多副本下只处理一次
+func (d *dependencyImpl) transitHeldTask(ctx context.Context, id int64, status model.HeldTaskStatus) (bool, error) {
+	ret := d.DBWithContext(ctx).Model(&model.WorkflowHeldTask{}).
+		Where("id = ? AND status = ?", id, model.HeldTaskStatusHeld).
+		Updates(map[string]any{
+			"status":     status,
+			"updated_at": time.Now(),
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "TransitHeldTask fail id=%d: %+v", id, ret.Error)
+		return false, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected == 1, nil
+}
+
+func (d *dependencyImpl) ReleaseHeldTask(ctx context.Context, held *model.WorkflowHeldTask, publish func() error) (bool, error) {
+	released := false
+	err := d.ExecTx(ctx, func(txCtx context.Context) error {
+		ok, err := d.transitHeldTask(txCtx, held.ID, model.HeldTaskStatusReleased)
+		if err != nil || !ok {
+			return err
+		}
+
+		released = true
+		return publish()
+	})
+	if err != nil {
+		return false, err
+	}
+	return released, nil
+}
+
+func (d *dependencyImpl) CancelHeldTask(ctx context.Context, held *model.WorkflowHeldTask, reason string) (bool, error) {
+	cancelled := false
+	err := d.ExecTx(ctx, func(txCtx context.Context) error {
+		ok, err := d.transitHeldTask(txCtx, held.ID, model.HeldTaskStatusCancelled)
+		if err != nil || !ok {
+			return err
+		}
+
+		now := time.Now()
+		if err := d.DBWithContext(txCtx).Model(&model.WorkflowTask{}).
+			Where("uuid = ?", held.TaskUUID).
+			Updates(map[string]any{
+				"status":        model.WorkflowTaskStatusCanceled,
+				"finished_time": now,
+				"updated_at":    now,
+			}).Error; err != nil {
+			logger.Errorf(ctx, "CancelHeldTask update task fail task=%s: %+v", held.TaskUUID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+
+		if err := d.DBWithContext(txCtx).Model(&model.WorkflowExecutionHistory{}).
+			Where("uuid = ?", held.TaskUUID).
+			Updates(map[string]any{
+				"status":        model.ExecutionStatusCancelled,
+				"error_message": reason,
+				"completed_at":  now,
+				"updated_at":    now,
+			}).Error; err != nil {
+			logger.Errorf(ctx, "CancelHeldTask update history fail task=%s: %+v", held.TaskUUID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+
+		cancelled = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return cancelled, nil
+}
+
+func (d *dependencyImpl) ListEdges(ctx context.Context, taskUUID uuid.UUID) ([]*model.WorkflowTaskDependency, error) {
+	var datas []*model.WorkflowTaskDependency
+	if err := d.DBWithContext(ctx).
+		Where("task_uuid = ? OR depends_on_uuid = ?", taskUUID, taskUUID).
+		Order("id ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListEdges fail task=%s: %+v", taskUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+func (d *dependencyImpl) GetHeldTask(ctx context.Context, taskUUID uuid.UUID) (*model.WorkflowHeldTask, error) {
+	var datas []*model.WorkflowHeldTask
+	if err := d.DBWithContext(ctx).
+		Where("task_uuid = ?", taskUUID).
+		Limit(1).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "GetHeldTask fail task=%s: %+v", taskUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	if len(datas) == 0 {
+		return nil, nil
+	}
+	return datas[0], nil
+}
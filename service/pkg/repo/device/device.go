@@ -0,0 +1,180 @@
+// Package device provides repository operations for the device registry.
+package device
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeviceRepo defines the interface for device registry repository operations
+type DeviceRepo interface {
+	// UpsertDevices registers devices of a lab by name, replacing their descriptors.
+	// When limit is positive and the devices add new names, the lab must stay within limit
+	UpsertDevices(ctx context.Context, labID int64, devices []*model.Device, limit int) error
+	CountDevices(ctx context.Context, labID int64) (int64, error)
+	GetDevice(ctx context.Context, labID int64, name string) (*model.Device, error)
+	ListDevices(ctx context.Context, labID int64) ([]*model.Device, error)
+	DeleteDevice(ctx context.Context, labID int64, name string) error
+
+	GetDeviceLimit(ctx context.Context, labID int64) (*model.DeviceLimit, error)
+	UpsertDeviceLimit(ctx context.Context, limit *model.DeviceLimit) error
+	DeleteDeviceLimit(ctx context.Context, labID int64) error
+}
+
+type deviceImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new device registry repository instance
+func New() DeviceRepo {
+	return &deviceImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// UpsertDevices creates or updates devices, holding the lab row lock so that
+// concurrent registrations of one lab cannot exceed the limit together
+func (d *deviceImpl) UpsertDevices(ctx context.Context, labID int64, devices []*model.Device, limit int) error {
+	if len(devices) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	names := make([]string, 0, len(devices))
+	for _, device := range devices {
+		device.UpdatedAt = now
+		names = append(names, device.Name)
+	}
+
+	return d.ExecTx(ctx, func(txCtx context.Context) error {
+		if limit > 0 {
+			lab := &model.Laboratory{}
+			if err := d.DBWithContext(txCtx).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id").Where("id = ?", labID).Take(lab).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return code.LabNotFound
+				}
+				logger.Errorf(ctx, "UpsertDevices lock lab fail lab=%d: %+v", labID, err)
+				return code.QueryRecordErr.WithErr(err)
+			}
+
+			var total, registered int64
+			if err := d.DBWithContext(txCtx).Model(&model.Device{}).
+				Where("lab_id = ?", labID).Count(&total).Error; err != nil {
+				logger.Errorf(ctx, "UpsertDevices count fail lab=%d: %+v", labID, err)
+				return code.QueryRecordErr.WithErr(err)
+			}
+			if err := d.DBWithContext(txCtx).Model(&model.Device{}).
+				Where("lab_id = ? AND name IN ?", labID, names).Count(&registered).Error; err != nil {
+				logger.Errorf(ctx, "UpsertDevices count fail lab=%d: %+v", labID, err)
+				return code.QueryRecordErr.WithErr(err)
+			}
+
+			// 只更新已注册设备时不受限制，已超限的实验室仍可更新设备描述
+			added := int64(len(devices)) - registered
+			if added > 0 && total+added > int64(limit) {
+				return code.DeviceLimitExceededErr.WithMsgf(
+					"lab has %d devices, registering %d new exceeds limit %d", total, added, limit)
+			}
+		}
+
+		if err := d.DBWithContext(txCtx).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "lab_id"}, {Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"display_name", "class", "vendor", "model", "agent_version", "capabilities", "updated_at",
+			}),
+		}).Create(&devices).Error; err != nil {
+			logger.Errorf(ctx, "UpsertDevices fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// CountDevices counts registered devices of a lab
+func (d *deviceImpl) CountDevices(ctx context.Context, labID int64) (int64, error) {
+	var count int64
+	if err := d.DBWithContext(ctx).Model(&model.Device{}).Where("lab_id = ?", labID).Count(&count).Error; err != nil {
+		logger.Errorf(ctx, "CountDevices fail lab=%d: %+v", labID, err)
+		return 0, code.QueryRecordErr.WithErr(err)
+	}
+	return count, nil
+}
+
+// GetDevice retrieves a device by lab and name
+func (d *deviceImpl) GetDevice(ctx context.Context, labID int64, name string) (*model.Device, error) {
+	var data model.Device
+	if err := d.DBWithContext(ctx).Where("lab_id = ? AND name = ?", labID, name).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetDevice fail lab=%d name=%s: %+v", labID, name, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListDevices lists devices of a lab ordered by name
+func (d *deviceImpl) ListDevices(ctx context.Context, labID int64) ([]*model.Device, error) {
+	var datas []*model.Device
+	if err := d.DBWithContext(ctx).Where("lab_id = ?", labID).Order("name ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListDevices fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// DeleteDevice removes a device from the registry
+func (d *deviceImpl) DeleteDevice(ctx context.Context, labID int64, name string) error {
+	ret := d.DBWithContext(ctx).Where("lab_id = ? AND name = ?", labID, name).Delete(&model.Device{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteDevice fail lab=%d name=%s: %+v", labID, name, ret.Error)
+		return code.DeleteDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// GetDeviceLimit retrieves the device limit override of a lab
+func (d *deviceImpl) GetDeviceLimit(ctx context.Context, labID int64) (*model.DeviceLimit, error) {
+	var data model.DeviceLimit
+	if err := d.DBWithContext(ctx).Where("lab_id = ?", labID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetDeviceLimit fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// UpsertDeviceLimit creates or replaces the device limit override of a lab
+func (d *deviceImpl) UpsertDeviceLimit(ctx context.Context, limit *model.DeviceLimit) error {
+	limit.UpdatedAt = time.Now()
+	if err := d.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lab_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_devices", "updated_by", "updated_at"}),
+	}).Create(limit).Error; err != nil {
+		logger.Errorf(ctx, "UpsertDeviceLimit fail lab=%d: %+v", limit.LabID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// DeleteDeviceLimit removes the device limit override of a lab
+func (d *deviceImpl) DeleteDeviceLimit(ctx context.Context, labID int64) error {
+	if err := d.DBWithContext(ctx).Where("lab_id = ?", labID).Delete(&model.DeviceLimit{}).Error; err != nil {
+		logger.Errorf(ctx, "DeleteDeviceLimit fail lab=%d: %+v", labID, err)
+		return code.DeleteDataErr.WithErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+// Package deviceconfig provides repository operations for device configuration
+// profiles, their assignments, pushes and the configurations devices report.
+package deviceconfig
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApplyQuery filters the config push history of a lab
+type ApplyQuery struct {
+	LabID      int64
+	DeviceName string
+	Page       int
+	PageSize   int
+}
+
+// DeviceConfigRepo defines the interface for device config repository operations
+type DeviceConfigRepo interface {
+	// CreateProfile stores the profile together with its first version
+	CreateProfile(ctx context.Context, profile *model.DeviceConfigProfile, version *model.DeviceConfigVersion) error
+	GetProfileByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceConfigProfile, error)
+	ListProfiles(ctx context.Context, labID int64) ([]*model.DeviceConfigProfile, error)
+	// CreateVersion stores the next version of the profile and bumps its latest version
+	CreateVersion(ctx context.Context, profile *model.DeviceConfigProfile, version *model.DeviceConfigVersion) error
+	// ListVersions lists the versions of a profile, newest first
+	ListVersions(ctx context.Context, profileID int64) ([]*model.DeviceConfigVersion, error)
+	// GetVersions loads the versions by [profile id, version] pairs
+	GetVersions(ctx context.Context, keys [][2]int64) ([]*model.DeviceConfigVersion, error)
+
+	// UpsertAssignment creates the assignment or replaces the one of the same target
+	UpsertAssignment(ctx context.Context, assignment *model.DeviceConfigAssignment) error
+	GetAssignmentByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceConfigAssignment, error)
+	ListAssignments(ctx context.Context, labID int64) ([]*model.DeviceConfigAssignment, error)
+	DeleteAssignment(ctx context.Context, id int64) error
+
+	CreateApplies(ctx context.Context, applies []*model.DeviceConfigApply) error
+	ListApplies(ctx context.Context, q *ApplyQuery) ([]*model.DeviceConfigApply, int64, error)
+	// ListLatestApplies returns the latest push to each device of a lab with one of the statuses, any status when empty
+	ListLatestApplies(ctx context.Context, labID int64, statuses ...model.DeviceConfigApplyStatus) ([]*model.DeviceConfigApply, error)
+	// ListUnfinishedApplies lists the pushes waiting for their command, oldest first
+	ListUnfinishedApplies(ctx context.Context, limit int) ([]*model.DeviceConfigApply, error)
+	UpdateApply(ctx context.Context, id int64, updates map[string]any) error
+
+	UpsertReport(ctx context.Context, report *model.DeviceConfigReport) error
+	ListReports(ctx context.Context, labID int64) ([]*model.DeviceConfigReport, error)
+}
+
+type deviceConfigImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new device config repository instance
+func New() DeviceConfigRepo {
+	return &deviceConfigImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateProfile creates the profile and its first version
+func (d *deviceConfigImpl) CreateProfile(ctx context.Context, profile *model.DeviceConfigProfile, version *model.DeviceConfigVersion) error {
+	return d.ExecTx(ctx, func(txCtx context.Context) error {
+		profile.LatestVersion = 1
+		if err := d.DBWithContext(txCtx).Create(profile).Error; err != nil {
+			logger.Errorf(ctx, "CreateProfile fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		version.ProfileID = profile.ID
+		version.Version = 1
+		if err := d.DBWithContext(txCtx).Create(version).Error; err != nil {
+			logger.Errorf(ctx, "CreateProfile version fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// GetProfileByUUID retrieves a profile by UUID
+func (d *deviceConfigImpl) GetProfileByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceConfigProfile, error) {
+	var data model.DeviceConfigProfile
+	if err := d.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetProfileByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListProfiles lists the profiles of a lab ordered by name
+func (d *deviceConfigImpl) ListProfiles(ctx context.Context, labID int64) ([]*model.DeviceConfigProfile, error) {
+	var datas []*model.DeviceConfigProfile
+	if err := d.DBWithContext(ctx).Where("lab_id = ?", labID).Order("name ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListProfiles fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// CreateVersion numbers the version under the profile row lock so that
+// concurrent changes get consecutive versions
+func (d *deviceConfigImpl) CreateVersion(ctx context.Context, profile *model.DeviceConfigProfile, version *model.DeviceConfigVersion) error {
+	return d.ExecTx(ctx, func(txCtx context.Context) error {
+		locked := &model.DeviceConfigProfile{}
+		if err := d.DBWithContext(txCtx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "latest_version").Where("id = ?", profile.ID).Take(locked).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return code.RecordNotFound
+			}
+			logger.Errorf(ctx, "CreateVersion lock profile fail id=%d: %+v", profile.ID, err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+
+		version.ProfileID = profile.ID
+		version.Version = locked.LatestVersion + 1
+		if err := d.DBWithContext(txCtx).Create(version).Error; err != nil {
+			logger.Errorf(ctx, "CreateVersion fail profile id=%d: %+v", profile.ID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		if err := d.DBWithContext(txCtx).Model(&model.DeviceConfigProfile{}).
+			Where("id = ?", profile.ID).
+			Updates(map[string]any{
+				"latest_version": version.Version,
+				"updated_at":     time.Now(),
+			}).Error; err != nil {
+			logger.Errorf(ctx, "CreateVersion update profile fail id=%d: %+v", profile.ID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+		profile.LatestVersion = version.Version
+		return nil
+	})
+}
+
+// ListVersions lists the versions of a profile
+func (d *deviceConfigImpl) ListVersions(ctx context.Context, profileID int64) ([]*model.DeviceConfigVersion, error) {
+	var datas []*model.DeviceConfigVersion
+	if err := d.DBWithContext(ctx).Where("profile_id = ?", profileID).Order("version DESC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListVersions fail profile id=%d: %+v", profileID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// GetVersions loads versions by profile and version number
+func (d *deviceConfigImpl) GetVersions(ctx context.Context, keys [][2]int64) ([]*model.DeviceConfigVersion, error) {
+	datas := make([]*model.DeviceConfigVersion, 0, len(keys))
+	if len(keys) == 0 {
+		return datas, nil
+	}
+	pairs := make([][]any, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, []any{key[0], key[1]})
+	}
+	if err := d.DBWithContext(ctx).Where("(profile_id, version) IN ?", pairs).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "GetVersions fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpsertAssignment creates or replaces the assignment of a device or device class
+func (d *deviceConfigImpl) UpsertAssignment(ctx context.Context, assignment *model.DeviceConfigAssignment) error {
+	assignment.UpdatedAt = time.Now()
+	if err := d.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lab_id"}, {Name: "device_class"}, {Name: "device_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"profile_id", "version", "created_by", "updated_at"}),
+	}).Create(assignment).Error; err != nil {
+		logger.Errorf(ctx, "UpsertAssignment fail lab id=%d: %+v", assignment.LabID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetAssignmentByUUID retrieves an assignment by UUID
+func (d *deviceConfigImpl) GetAssignmentByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceConfigAssignment, error) {
+	var data model.DeviceConfigAssignment
+	if err := d.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetAssignmentByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListAssignments lists the assignments of a lab
+func (d *deviceConfigImpl) ListAssignments(ctx context.Context, labID int64) ([]*model.DeviceConfigAssignment, error) {
+	var datas []*model.DeviceConfigAssignment
+	if err := d.DBWithContext(ctx).
+		Where("lab_id = ?", labID).
+		Order("device_name ASC, device_class ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListAssignments fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// DeleteAssignment removes an assignment
+func (d *deviceConfigImpl) DeleteAssignment(ctx context.Context, id int64) error {
+	if err := d.DBWithContext(ctx).Where("id = ?", id).Delete(&model.DeviceConfigAssignment{}).Error; err != nil {
+		logger.Errorf(ctx, "DeleteAssignment fail id=%d: %+v", id, err)
+		return code.DeleteDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// CreateApplies records config pushes
+func (d *deviceConfigImpl) CreateApplies(ctx context.Context, applies []*model.DeviceConfigApply) error {
+	if len(applies) == 0 {
+		return nil
+	}
+	if err := d.DBWithContext(ctx).Create(&applies).Error; err != nil {
+		logger.Errorf(ctx, "CreateApplies fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListApplies lists the config pushes of a lab, newest first
+func (d *deviceConfigImpl) ListApplies(ctx context.Context, q *ApplyQuery) ([]*model.DeviceConfigApply, int64, error) {
+	var datas []*model.DeviceConfigApply
+	var total int64
+
+	query := d.DBWithContext(ctx).Model(&model.DeviceConfigApply{}).Where("lab_id = ?", q.LabID)
+	if q.DeviceName != "" {
+		query = query.Where("device_name = ?", q.DeviceName)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListApplies count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (q.Page - 1) * q.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(q.PageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListApplies fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// ListLatestApplies returns the latest push to each device
+func (d *deviceConfigImpl) ListLatestApplies(ctx context.Context, labID int64, statuses ...model.DeviceConfigApplyStatus) ([]*model.DeviceConfigApply, error) {
+	var datas []*model.DeviceConfigApply
+	query := d.DBWithContext(ctx).
+		Select("DISTINCT ON (device_name) *").
+		Where("lab_id = ?", labID)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	if err := query.Order("device_name ASC, id DESC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListLatestApplies fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListUnfinishedApplies lists pending and acked pushes
+func (d *deviceConfigImpl) ListUnfinishedApplies(ctx context.Context, limit int) ([]*model.DeviceConfigApply, error) {
+	var datas []*model.DeviceConfigApply
+	if err := d.DBWithContext(ctx).
+		Where("status IN ?", []model.DeviceConfigApplyStatus{model.DeviceConfigApplyPending, model.DeviceConfigApplyAcked}).
+		Order("id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListUnfinishedApplies fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateApply updates a config push
+func (d *deviceConfigImpl) UpdateApply(ctx context.Context, id int64, updates map[string]any) error {
+	updates["updated_at"] = time.Now()
+	if err := d.DBWithContext(ctx).Model(&model.DeviceConfigApply{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		logger.Errorf(ctx, "UpdateApply fail id=%d: %+v", id, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// UpsertReport stores the configuration a device reports, replacing the previous report
+func (d *deviceConfigImpl) UpsertReport(ctx context.Context, report *model.DeviceConfigReport) error {
+	report.UpdatedAt = time.Now()
+	if err := d.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lab_id"}, {Name: "device_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"config", "reported_at", "updated_at"}),
+	}).Create(report).Error; err != nil {
+		logger.Errorf(ctx, "UpsertReport fail lab id=%d device=%s: %+v", report.LabID, report.DeviceName, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListReports lists the configurations reported by the devices of a lab
+func (d *deviceConfigImpl) ListReports(ctx context.Context, labID int64) ([]*model.DeviceConfigReport, error) {
+	var datas []*model.DeviceConfigReport
+	if err := d.DBWithContext(ctx).Where("lab_id = ?", labID).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListReports fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
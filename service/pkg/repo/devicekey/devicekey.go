@@ -0,0 +1,100 @@
+// Package devicekey provides repository operations for the API keys devices
+// use to report events.
+package devicekey
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// DeviceKeyRepo defines the interface for device API key repository operations
+type DeviceKeyRepo interface {
+	CreateKey(ctx context.Context, data *model.DeviceAPIKey) error
+	// GetKeyByHash retrieves a key by the hash of the key
+	GetKeyByHash(ctx context.Context, keyHash string) (*model.DeviceAPIKey, error)
+	// ListKeys lists the keys of a lab newest first, revoked ones included
+	ListKeys(ctx context.Context, labID int64) ([]*model.DeviceAPIKey, error)
+	// RevokeKey revokes a key of the lab, reporting false when it does not
+	// exist or was already revoked
+	RevokeKey(ctx context.Context, labID int64, keyUUID uuid.UUID, revokedBy string) (bool, error)
+	// TouchKey records the last time the key was used
+	TouchKey(ctx context.Context, keyID int64, usedAt time.Time) error
+}
+
+type deviceKeyImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new device API key repository instance
+func New() DeviceKeyRepo {
+	return &deviceKeyImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateKey creates a device API key
+func (d *deviceKeyImpl) CreateKey(ctx context.Context, data *model.DeviceAPIKey) error {
+	if err := d.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateKey fail lab=%d: %+v", data.LabID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetKeyByHash retrieves a device API key by key hash
+func (d *deviceKeyImpl) GetKeyByHash(ctx context.Context, keyHash string) (*model.DeviceAPIKey, error) {
+	var data model.DeviceAPIKey
+	if err := d.DBWithContext(ctx).Where("key_hash = ?", keyHash).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetKeyByHash fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListKeys lists the device API keys of a lab
+func (d *deviceKeyImpl) ListKeys(ctx context.Context, labID int64) ([]*model.DeviceAPIKey, error) {
+	datas := make([]*model.DeviceAPIKey, 0)
+	if err := d.DBWithContext(ctx).Where("lab_id = ?", labID).Order("id DESC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListKeys fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// RevokeKey revokes a device API key
+func (d *deviceKeyImpl) RevokeKey(ctx context.Context, labID int64, keyUUID uuid.UUID, revokedBy string) (bool, error) {
+	now := time.Now()
+	res := d.DBWithContext(ctx).Model(&model.DeviceAPIKey{}).
+		Where("lab_id = ? AND uuid = ? AND revoked_at IS NULL", labID, keyUUID).
+		Updates(map[string]any{
+			"revoked_by": revokedBy,
+			"revoked_at": now,
+			"updated_at": now,
+		})
+	if res.Error != nil {
+		logger.Errorf(ctx, "RevokeKey fail uuid=%s: %+v", keyUUID, res.Error)
+		return false, code.UpdateDataErr.WithErr(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// TouchKey updates the last used time of a device API key
+func (d *deviceKeyImpl) TouchKey(ctx context.Context, keyID int64, usedAt time.Time) error {
+	if err := d.DBWithContext(ctx).Model(&model.DeviceAPIKey{}).
+		Where("id = ?", keyID).
+		Update("last_used_at", usedAt).Error; err != nil {
+		logger.Errorf(ctx, "TouchKey fail id=%d: %+v", keyID, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
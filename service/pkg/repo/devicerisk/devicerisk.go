@@ -0,0 +1,147 @@
+// Package devicerisk provides repository operations for device maintenance risk scoring.
+package devicerisk
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+)
+
+// DeviceRiskRepo defines the interface for device risk repository operations
+type DeviceRiskRepo interface {
+	// ListErrorCounts counts the error events of each device since baselineSince,
+	// split at since between the baseline and the scoring window
+	ListErrorCounts(ctx context.Context, since, baselineSince time.Time) ([]*model.DeviceErrorCount, error)
+	// ListActionCounts counts the finished and failed actions of each device since the time
+	ListActionCounts(ctx context.Context, since time.Time) ([]*model.DeviceActionCount, error)
+	// ListActionDrifts returns the median duration of the successful runs of
+	// each action of each device, in the scoring window and in the baseline
+	ListActionDrifts(ctx context.Context, since, baselineSince time.Time) ([]*model.DeviceActionDrift, error)
+	CreateScores(ctx context.Context, scores []*model.DeviceRiskScore) error
+	// ListLatest returns the latest score of each device of a lab
+	ListLatest(ctx context.Context, labID int64) ([]*model.DeviceRiskScore, error)
+	// ListHistory returns the scores of a device of a lab in [start, end) by time
+	ListHistory(ctx context.Context, labID, deviceID int64, start, end time.Time) ([]*model.DeviceRiskScore, error)
+	// DeleteBefore deletes the scores older than the time
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type deviceRiskImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new device risk repository instance
+func New() DeviceRiskRepo {
+	return &deviceRiskImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// ListErrorCounts counts the error events by device
+func (d *deviceRiskImpl) ListErrorCounts(ctx context.Context, since, baselineSince time.Time) ([]*model.DeviceErrorCount, error) {
+	var datas []*model.DeviceErrorCount
+	if err := d.DBWithContext(ctx).Model(&model.DeviceEventHistory{}).
+		Select(`lab_id, device_id, device_uuid,
+			COUNT(*) FILTER (WHERE timestamp >= ?) AS recent,
+			COUNT(*) FILTER (WHERE timestamp < ?) AS baseline`, since, since).
+		Where("event_type = ? AND timestamp >= ?", model.DeviceEventError, baselineSince).
+		Group("lab_id, device_id, device_uuid").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListErrorCounts fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListActionCounts counts the finished and failed actions by device
+func (d *deviceRiskImpl) ListActionCounts(ctx context.Context, since time.Time) ([]*model.DeviceActionCount, error) {
+	var datas []*model.DeviceActionCount
+	if err := d.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select(`lab_id, device_id, device_uuid, MAX(device_name) AS device_name,
+			COUNT(*) AS actions,
+			COUNT(*) FILTER (WHERE status = ?) AS failed`, model.ExecutionStatusFailed).
+		Where("created_at >= ? AND status IN ?", since,
+			[]model.ExecutionStatus{model.ExecutionStatusSuccess, model.ExecutionStatusFailed}).
+		Group("lab_id, device_id, device_uuid").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListActionCounts fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListActionDrifts returns the recent and baseline median durations by device and action
+func (d *deviceRiskImpl) ListActionDrifts(ctx context.Context, since, baselineSince time.Time) ([]*model.DeviceActionDrift, error) {
+	var datas []*model.DeviceActionDrift
+	if err := d.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select(`device_id, action_name,
+			COUNT(*) FILTER (WHERE created_at >= ?) AS recent,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY duration_ms) FILTER (WHERE created_at >= ?), 0) AS recent_p50_ms,
+			COUNT(*) FILTER (WHERE created_at < ?) AS baseline,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY duration_ms) FILTER (WHERE created_at < ?), 0) AS baseline_p50_ms`,
+			since, since, since, since).
+		Where("status = ? AND duration_ms > 0 AND created_at >= ?", model.ExecutionStatusSuccess, baselineSince).
+		Group("device_id, action_name").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListActionDrifts fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// CreateScores records one scoring run
+func (d *deviceRiskImpl) CreateScores(ctx context.Context, scores []*model.DeviceRiskScore) error {
+	if len(scores) == 0 {
+		return nil
+	}
+	if err := d.DBWithContext(ctx).CreateInBatches(scores, 200).Error; err != nil {
+		logger.Errorf(ctx, "CreateScores fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListLatest returns the latest score of each device of a lab, riskiest first
+func (d *deviceRiskImpl) ListLatest(ctx context.Context, labID int64) ([]*model.DeviceRiskScore, error) {
+	var datas []*model.DeviceRiskScore
+	latest := d.DBWithContext(ctx).Model(&model.DeviceRiskScore{}).
+		Select("DISTINCT ON (device_id) *").
+		Where("lab_id = ?", labID).
+		Order("device_id, scored_at DESC")
+	if err := d.DBWithContext(ctx).Table("(?) AS latest", latest).
+		Order("score DESC, device_name ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListLatest device risk fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListHistory returns the scores of a device
+func (d *deviceRiskImpl) ListHistory(ctx context.Context, labID, deviceID int64, start, end time.Time) ([]*model.DeviceRiskScore, error) {
+	var datas []*model.DeviceRiskScore
+	if err := d.DBWithContext(ctx).
+		Where("lab_id = ? AND device_id = ? AND scored_at >= ? AND scored_at < ?", labID, deviceID, start, end).
+		Order("scored_at ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListHistory device risk fail device id=%d: %+v", deviceID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// DeleteBefore deletes expired scores
+func (d *deviceRiskImpl) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := d.DBWithContext(ctx).
+		Where("scored_at < ?", before).
+		Delete(&model.DeviceRiskScore{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteBefore device risk fail: %+v", ret.Error)
+		return 0, code.DeleteDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
@@ -0,0 +1,97 @@
+// Package errorrule provides repository operations for error classification rules.
+package errorrule
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// ErrorRuleRepo defines the interface for error rule repository operations
+type ErrorRuleRepo interface {
+	CreateRule(ctx context.Context, data *model.ErrorRule) error
+	GetRuleByUUID(ctx context.Context, ruleUUID uuid.UUID) (*model.ErrorRule, error)
+	// ListRules lists the rules of a lab in matching order
+	ListRules(ctx context.Context, labID int64) ([]*model.ErrorRule, error)
+	UpdateRule(ctx context.Context, data *model.ErrorRule) error
+	DeleteRule(ctx context.Context, id int64) error
+}
+
+type errorRuleImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new error rule repository instance
+func New() ErrorRuleRepo {
+	return &errorRuleImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateRule creates an error rule
+func (e *errorRuleImpl) CreateRule(ctx context.Context, data *model.ErrorRule) error {
+	if err := e.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateRule fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetRuleByUUID retrieves an error rule by UUID
+func (e *errorRuleImpl) GetRuleByUUID(ctx context.Context, ruleUUID uuid.UUID) (*model.ErrorRule, error) {
+	var data model.ErrorRule
+	if err := e.DBWithContext(ctx).Where("uuid = ?", ruleUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetRuleByUUID fail uuid=%s: %+v", ruleUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListRules lists rules by priority, earlier rules first on ties
+func (e *errorRuleImpl) ListRules(ctx context.Context, labID int64) ([]*model.ErrorRule, error) {
+	var datas []*model.ErrorRule
+	if err := e.DBWithContext(ctx).Where("lab_id = ?", labID).
+		Order("priority ASC, id ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListRules fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateRule updates the category, pattern and priority of a rule
+func (e *errorRuleImpl) UpdateRule(ctx context.Context, data *model.ErrorRule) error {
+	data.UpdatedAt = time.Now()
+	ret := e.DBWithContext(ctx).Model(data).
+		Select("category", "pattern", "priority", "updated_at").
+		Updates(data)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "UpdateRule fail id=%d: %+v", data.ID, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// DeleteRule deletes an error rule
+func (e *errorRuleImpl) DeleteRule(ctx context.Context, id int64) error {
+	ret := e.DBWithContext(ctx).Where("id = ?", id).Delete(&model.ErrorRule{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteRule fail id=%d: %+v", id, ret.Error)
+		return code.DeleteDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
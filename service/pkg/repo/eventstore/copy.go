@@ -0,0 +1,120 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/gorm"
+)
+
+// ingestBatch 单次 COPY 的行数，拆分过大的批次以限制单条语句占用连接的时间
+const ingestBatch = 5000
+
+// id 和 uuid 使用表的默认值生成
+var copyColumns = []string{
+	"created_at", "updated_at", "lab_id", "device_id", "device_uuid", "event_type", "event_data", "timestamp",
+}
+
+var errCopyUnsupported = errors.New("copy is not supported by the connection")
+
+// eventSource 将事件转换为 COPY 的行
+type eventSource struct {
+	events []*model.DeviceEventHistory
+	now    time.Time
+	idx    int
+	row    []any
+}
+
+func newEventSource(events []*model.DeviceEventHistory, now time.Time) *eventSource {
+	return &eventSource{events: events, now: now, row: make([]any, len(copyColumns))}
+}
+
+func (s *eventSource) Next() bool {
+	s.idx++
+	return s.idx <= len(s.events)
+}
+
+// Values 复用同一个切片，CopyFrom 在读取下一行前已完成编码
+func (s *eventSource) Values() ([]any, error) {
+	event := s.events[s.idx-1]
+	var data any
+	if len(event.EventData) > 0 {
+		data = []byte(event.EventData)
+	}
+	s.row[0] = s.now
+	s.row[1] = s.now
+	s.row[2] = event.LabID
+	s.row[3] = event.DeviceID
+	s.row[4] = [16]byte(event.DeviceUUID.UUID)
+	s.row[5] = string(event.EventType)
+	s.row[6] = data
+	s.row[7] = event.Timestamp
+	return s.row, nil
+}
+
+func (s *eventSource) Err() error {
+	return nil
+}
+
+// IngestEvents writes events with COPY, falling back to batched inserts when
+// the connection is inside a transaction or the COPY fails. Events written by
+// COPY do not get their id and uuid filled in
+func (b *backend) IngestEvents(ctx context.Context, events []*model.DeviceEventHistory) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	var total int64
+	for start := 0; start < len(events); start += ingestBatch {
+		batch := events[start:min(start+ingestBatch, len(events))]
+		count, err := b.copyFrom(ctx, batch)
+		if err != nil {
+			if !errors.Is(err, errCopyUnsupported) {
+				logger.Warnf(ctx, "IngestEvents copy fail backend=%s, fallback to insert: %+v", b.name, err)
+			}
+			if err := b.CreateEvents(ctx, batch); err != nil {
+				return total, err
+			}
+			count = int64(len(batch))
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// copyFrom COPY 是单条语句，失败时不会写入部分数据
+func (b *backend) copyFrom(ctx context.Context, events []*model.DeviceEventHistory) (int64, error) {
+	pool := b.db(ctx).Statement.ConnPool
+	// 开启 PrepareStmt 时连接池被包装
+	if prepared, ok := pool.(*gorm.PreparedStmtDB); ok {
+		pool = prepared.ConnPool
+	}
+	sqlDB, ok := pool.(*sql.DB)
+	if !ok {
+		return 0, errCopyUnsupported
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var count int64
+	err = conn.Raw(func(driverConn any) error {
+		pgConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errCopyUnsupported
+		}
+		var err error
+		count, err = pgConn.Conn().CopyFrom(ctx, pgx.Identifier{(&model.DeviceEventHistory{}).TableName()},
+			copyColumns, newEventSource(events, time.Now()))
+		return err
+	})
+	return count, err
+}
@@ -0,0 +1,113 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func newEvents(n int) []*model.DeviceEventHistory {
+	now := time.Now()
+	deviceUUID := uuid.NewV4()
+	events := make([]*model.DeviceEventHistory, 0, n)
+	for i := range n {
+		events = append(events, &model.DeviceEventHistory{
+			LabID:      1,
+			DeviceID:   int64(i%50 + 1),
+			DeviceUUID: deviceUUID,
+			EventType:  model.DeviceEventDataReceived,
+			EventData:  datatypes.JSON(fmt.Sprintf(`{"temperature":%d}`, i)),
+			Timestamp:  now.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+	return events
+}
+
+func TestEventSource(t *testing.T) {
+	events := newEvents(2)
+	events[1].EventData = nil
+	now := time.Now()
+	src := newEventSource(events, now)
+
+	assert.True(t, src.Next())
+	row, err := src.Values()
+	assert.NoError(t, err)
+	assert.Len(t, row, len(copyColumns))
+	assert.Equal(t, now, row[0])
+	assert.Equal(t, int64(1), row[3])
+	assert.Equal(t, [16]byte(events[0].DeviceUUID.UUID), row[4])
+	assert.Equal(t, "data_received", row[5])
+	assert.Equal(t, []byte(`{"temperature":0}`), row[6])
+
+	assert.True(t, src.Next())
+	row, _ = src.Values()
+	assert.Nil(t, row[6])
+	assert.False(t, src.Next())
+	assert.NoError(t, src.Err())
+}
+
+func BenchmarkEventSource(b *testing.B) {
+	events := newEvents(ingestBatch)
+	b.ReportAllocs()
+	for b.Loop() {
+		src := newEventSource(events, time.Now())
+		for src.Next() {
+			_, _ = src.Values()
+		}
+	}
+}
+
+// benchBackend 连接 EVENT_STORE_BENCH_DSN 指定的数据库，未配置时跳过
+func benchBackend(b *testing.B) *backend {
+	dsn := os.Getenv("EVENT_STORE_BENCH_DSN")
+	if dsn == "" {
+		b.Skip("EVENT_STORE_BENCH_DSN not set")
+	}
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger.Discard})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := conn.AutoMigrate(&model.DeviceEventHistory{}); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		conn.Exec("TRUNCATE device_event_history")
+	})
+	return &backend{name: "bench", db: func(ctx context.Context) *gorm.DB {
+		return conn.WithContext(ctx)
+	}}
+}
+
+// BenchmarkIngest 对比 COPY 与分批 INSERT 的写入吞吐
+func BenchmarkIngest(b *testing.B) {
+	store := benchBackend(b)
+	ctx := context.Background()
+	for _, size := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("copy-%d", size), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := store.IngestEvents(ctx, newEvents(size)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(size*b.N)/b.Elapsed().Seconds(), "events/s")
+		})
+		b.Run(fmt.Sprintf("insert-%d", size), func(b *testing.B) {
+			for b.Loop() {
+				if err := store.CreateEvents(ctx, newEvents(size)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(size*b.N)/b.Elapsed().Seconds(), "events/s")
+		})
+	}
+}
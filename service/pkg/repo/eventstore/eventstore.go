@@ -0,0 +1,153 @@
+// Package eventstore routes device event history to its storage backend. Events
+// are kept in the main Postgres database by default, or in a TimescaleDB
+// hypertable when the event volume outgrows it. While migrating, events can be
+// written to both stores and reads switched once the history has been copied.
+package eventstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/legalhold"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EventStore defines the storage operations of device event history
+type EventStore interface {
+	// ReadDB returns the connection that device event lists and aggregations query
+	ReadDB(ctx context.Context) *gorm.DB
+	CreateEvents(ctx context.Context, events []*model.DeviceEventHistory) error
+	// IngestEvents writes a large batch of events, returning the number written.
+	// The id and uuid of the events may not be filled in
+	IngestEvents(ctx context.Context, events []*model.DeviceEventHistory) (int64, error)
+	// DeleteBefore deletes events older than before, of every type when eventType is nil,
+	// keeping the events covered by the legal holds
+	DeleteBefore(ctx context.Context, before time.Time, eventType *model.DeviceEventType, holds []*model.LegalHold) (int64, error)
+}
+
+// New returns the event store selected by the EVENT_STORE_* configuration
+func New() EventStore {
+	primary := &backend{name: config.EventBackendPostgres, db: repo.NewBaseDB().DBWithContext}
+	conf := config.Global().EventStore
+	if conf.Backend != config.EventBackendTimescale || db.EventDB() == nil {
+		return primary
+	}
+
+	timescale := newTimescale()
+	if !conf.DualWrite {
+		return timescale
+	}
+	return &dualStore{primary: primary, secondary: timescale, readPrimary: conf.ReadPrimary}
+}
+
+type backend struct {
+	name string
+	db   func(ctx context.Context) *gorm.DB
+}
+
+// newTimescale 独立连接不参与主库事务
+func newTimescale() *backend {
+	eventDB := db.EventDB()
+	return &backend{
+		name: config.EventBackendTimescale,
+		db: func(ctx context.Context) *gorm.DB {
+			return eventDB.DBIns().WithContext(ctx)
+		},
+	}
+}
+
+func (b *backend) ReadDB(ctx context.Context) *gorm.DB {
+	return b.db(ctx)
+}
+
+// CreateEvents creates events in batch
+func (b *backend) CreateEvents(ctx context.Context, events []*model.DeviceEventHistory) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := b.db(ctx).CreateInBatches(events, 100).Error; err != nil {
+		logger.Errorf(ctx, "CreateEvents fail backend=%s: %+v", b.name, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// copyEvents writes events that already exist in another store, keeping their
+// id, uuid and timestamps and skipping the ones already copied
+func (b *backend) copyEvents(ctx context.Context, events []*model.DeviceEventHistory) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	ret := b.db(ctx).Session(&gorm.Session{SkipHooks: true}).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(events, 500)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "copyEvents fail backend=%s: %+v", b.name, ret.Error)
+		return 0, code.CreateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
+
+// DeleteBefore deletes events older than before
+func (b *backend) DeleteBefore(ctx context.Context, before time.Time, eventType *model.DeviceEventType, holds []*model.LegalHold) (int64, error) {
+	query := b.db(ctx).Where("timestamp < ?", before)
+	if eventType != nil {
+		query = query.Where("event_type = ?", *eventType)
+	}
+	query = legalhold.Exclude(query, holds, legalhold.Columns{LabID: "lab_id", Time: "timestamp"})
+	ret := query.Delete(&model.DeviceEventHistory{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteBefore fail backend=%s: %+v", b.name, ret.Error)
+		return 0, code.DeleteDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
+
+// dualStore 迁移期间以主库为准写入，再以相同 id 写入 timescale。写入 timescale 失败只记录日志，
+// 缺失的记录由 migrate events 补齐
+type dualStore struct {
+	primary     *backend
+	secondary   *backend
+	readPrimary bool
+}
+
+func (d *dualStore) ReadDB(ctx context.Context) *gorm.DB {
+	if d.readPrimary {
+		return d.primary.ReadDB(ctx)
+	}
+	return d.secondary.ReadDB(ctx)
+}
+
+func (d *dualStore) CreateEvents(ctx context.Context, events []*model.DeviceEventHistory) error {
+	if err := d.primary.CreateEvents(ctx, events); err != nil {
+		return err
+	}
+	_, _ = d.secondary.copyEvents(ctx, events)
+	return nil
+}
+
+// IngestEvents 双写需要主库生成的 id，不使用 COPY
+func (d *dualStore) IngestEvents(ctx context.Context, events []*model.DeviceEventHistory) (int64, error) {
+	if err := d.CreateEvents(ctx, events); err != nil {
+		return 0, err
+	}
+	return int64(len(events)), nil
+}
+
+func (d *dualStore) DeleteBefore(ctx context.Context, before time.Time, eventType *model.DeviceEventType, holds []*model.LegalHold) (int64, error) {
+	count, err := d.primary.DeleteBefore(ctx, before, eventType, holds)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := d.secondary.DeleteBefore(ctx, before, eventType, holds); err != nil {
+		return count, err
+	}
+	return count, nil
+}
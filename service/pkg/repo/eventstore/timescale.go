@@ -0,0 +1,112 @@
+package eventstore
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const defaultChunkHours = 24
+
+// hypertable 的唯一约束必须包含分区列，主键为 (id, timestamp)
+const timescaleTableSQL = `
+CREATE TABLE IF NOT EXISTS device_event_history (
+	id          bigserial NOT NULL,
+	uuid        uuid NOT NULL DEFAULT gen_random_uuid(),
+	created_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	lab_id      bigint NOT NULL,
+	device_id   bigint NOT NULL,
+	device_uuid uuid NOT NULL,
+	event_type  varchar(50) NOT NULL,
+	event_data  jsonb,
+	timestamp   timestamptz NOT NULL,
+	PRIMARY KEY (id, timestamp)
+)`
+
+var timescaleIndexSQL = []string{
+	`CREATE INDEX IF NOT EXISTS idx_deh_lab_time ON device_event_history (lab_id, timestamp DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_deh_device_time ON device_event_history (device_id, timestamp DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_deh_type_time ON device_event_history (event_type, timestamp DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_deh_lab_device_time ON device_event_history (lab_id, device_id, timestamp DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_deh_lab_type_time ON device_event_history (lab_id, event_type, timestamp DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_deh_lab_updated ON device_event_history (lab_id, updated_at, id)`,
+}
+
+// 迁移期间写入的记录使用主库的 id，切换后需要将序列推进到已有的最大 id
+const syncSequenceSQL = `
+SELECT setval(pg_get_serial_sequence('device_event_history', 'id'),
+	GREATEST((SELECT MAX(id) FROM device_event_history), 1))`
+
+// Migrate creates the device event hypertable when the timescale backend is configured
+func Migrate(ctx context.Context) error {
+	eventDB := db.EventDB()
+	if eventDB == nil {
+		return nil
+	}
+	hours := config.Global().EventStore.ChunkHours
+	if hours <= 0 {
+		hours = defaultChunkHours
+	}
+
+	conn := eventDB.DBIns().WithContext(ctx)
+	if err := conn.Exec(`CREATE EXTENSION IF NOT EXISTS timescaledb`).Error; err != nil {
+		return err
+	}
+	if err := conn.Exec(timescaleTableSQL).Error; err != nil {
+		return err
+	}
+	if err := conn.Exec(`SELECT create_hypertable('device_event_history', 'timestamp',
+		chunk_time_interval => make_interval(hours => ?), if_not_exists => TRUE)`, hours).Error; err != nil {
+		return err
+	}
+	for _, sql := range timescaleIndexSQL {
+		if err := conn.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backfill copies the events of the main database with id greater than afterID
+// to timescale in id order, then syncs the id sequence of timescale. Events
+// already copied are skipped so it can be re-run to fill gaps
+func Backfill(ctx context.Context, afterID int64, batchSize int, progress func(lastID, copied int64)) (int64, error) {
+	if db.EventDB() == nil {
+		return 0, code.ParamErr.WithMsg("event store backend is not timescale")
+	}
+	target := newTimescale()
+	source := db.DB().DBIns().WithContext(ctx)
+
+	var copied int64
+	for {
+		var events []*model.DeviceEventHistory
+		if err := source.Where("id > ?", afterID).Order("id ASC").Limit(batchSize).Find(&events).Error; err != nil {
+			logger.Errorf(ctx, "Backfill events fail after id=%d: %+v", afterID, err)
+			return copied, code.QueryRecordErr.WithErr(err)
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		count, err := target.copyEvents(ctx, events)
+		if err != nil {
+			return copied, err
+		}
+		copied += count
+		afterID = events[len(events)-1].ID
+		if progress != nil {
+			progress(afterID, copied)
+		}
+	}
+
+	if err := target.db(ctx).Exec(syncSequenceSQL).Error; err != nil {
+		logger.Errorf(ctx, "Backfill sync sequence fail: %+v", err)
+		return copied, code.UpdateDataErr.WithErr(err)
+	}
+	return copied, nil
+}
@@ -0,0 +1,228 @@
+// Package experiment provides repository operations for experiments that
+// group workflow executions.
+package experiment
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// ExecutionSpan is the time range and total duration of the executions of an experiment
+type ExecutionSpan struct {
+	FirstStartedAt  *time.Time
+	LastCompletedAt *time.Time
+	TotalDurationMs int64
+}
+
+// ExperimentRepo defines the interface for experiment repository operations
+type ExperimentRepo interface {
+	Create(ctx context.Context, data *model.Experiment) error
+	GetByUUID(ctx context.Context, id uuid.UUID) (*model.Experiment, error)
+	// GetByName returns nil when the lab has no experiment with the name
+	GetByName(ctx context.Context, labID int64, name string) (*model.Experiment, error)
+	List(ctx context.Context, labID int64, status model.ExperimentStatus, page *common.PageReq) ([]*model.Experiment, int64, error)
+	Update(ctx context.Context, id int64, updates map[string]any) error
+
+	// AssignExecutions moves the lab executions into the experiment and
+	// returns the number of executions changed
+	AssignExecutions(ctx context.Context, labID, experimentID int64, executionUUIDs []uuid.UUID) (int64, error)
+	// DetachExecutions removes the executions from the experiment
+	DetachExecutions(ctx context.Context, experimentID int64, executionUUIDs []uuid.UUID) (int64, error)
+	// CountStatuses counts the executions of the experiments by status
+	CountStatuses(ctx context.Context, experimentIDs []int64) (map[int64]map[model.ExecutionStatus]int64, error)
+	// WorkflowStats summarises the executions of an experiment by workflow
+	WorkflowStats(ctx context.Context, experimentID int64) ([]*model.ExperimentWorkflowStats, error)
+	Span(ctx context.Context, experimentID int64) (*ExecutionSpan, error)
+}
+
+type experimentImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new experiment repository instance
+func New() ExperimentRepo {
+	return &experimentImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// Create creates an experiment
+func (e *experimentImpl) Create(ctx context.Context, data *model.Experiment) error {
+	if err := e.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "Create experiment fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetByUUID retrieves an experiment by UUID
+func (e *experimentImpl) GetByUUID(ctx context.Context, id uuid.UUID) (*model.Experiment, error) {
+	var data model.Experiment
+	if err := e.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetByUUID experiment fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// GetByName retrieves an experiment of a lab by name
+func (e *experimentImpl) GetByName(ctx context.Context, labID int64, name string) (*model.Experiment, error) {
+	var datas []*model.Experiment
+	if err := e.DBWithContext(ctx).
+		Where("lab_id = ? AND name = ?", labID, name).
+		Limit(1).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "GetByName experiment fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	if len(datas) == 0 {
+		return nil, nil
+	}
+	return datas[0], nil
+}
+
+// List lists the experiments of a lab, newest first
+func (e *experimentImpl) List(ctx context.Context, labID int64, status model.ExperimentStatus, page *common.PageReq) ([]*model.Experiment, int64, error) {
+	query := e.DBWithContext(ctx).Model(&model.Experiment{}).Where("lab_id = ?", labID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "List experiment count fail lab=%d: %+v", labID, err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	datas := make([]*model.Experiment, 0)
+	if err := query.Order("id DESC").
+		Offset(page.Offest()).
+		Limit(page.PageSize).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "List experiment fail lab=%d: %+v", labID, err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// Update updates an experiment
+func (e *experimentImpl) Update(ctx context.Context, id int64, updates map[string]any) error {
+	if err := e.DBWithContext(ctx).Model(&model.Experiment{}).
+		Where("id = ?", id).Updates(updates).Error; err != nil {
+		logger.Errorf(ctx, "Update experiment fail id=%d: %+v", id, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// AssignExecutions moves executions of a lab into an experiment
+func (e *experimentImpl) AssignExecutions(ctx context.Context, labID, experimentID int64, executionUUIDs []uuid.UUID) (int64, error) {
+	if len(executionUUIDs) == 0 {
+		return 0, nil
+	}
+	ret := e.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("lab_id = ? AND uuid IN ?", labID, executionUUIDs).
+		Where("experiment_id IS DISTINCT FROM ?", experimentID).
+		Updates(map[string]any{
+			"experiment_id": experimentID,
+			"updated_at":    time.Now(),
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "AssignExecutions fail experiment=%d: %+v", experimentID, ret.Error)
+		return 0, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
+
+// DetachExecutions removes executions from an experiment
+func (e *experimentImpl) DetachExecutions(ctx context.Context, experimentID int64, executionUUIDs []uuid.UUID) (int64, error) {
+	if len(executionUUIDs) == 0 {
+		return 0, nil
+	}
+	ret := e.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("experiment_id = ? AND uuid IN ?", experimentID, executionUUIDs).
+		Updates(map[string]any{
+			"experiment_id": nil,
+			"updated_at":    time.Now(),
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DetachExecutions fail experiment=%d: %+v", experimentID, ret.Error)
+		return 0, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
+
+// CountStatuses counts the executions of experiments by status
+func (e *experimentImpl) CountStatuses(ctx context.Context, experimentIDs []int64) (map[int64]map[model.ExecutionStatus]int64, error) {
+	counts := make(map[int64]map[model.ExecutionStatus]int64, len(experimentIDs))
+	if len(experimentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ExperimentID int64
+		Status       model.ExecutionStatus
+		Count        int64
+	}
+	if err := e.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Select("experiment_id, status, COUNT(*) AS count").
+		Where("experiment_id IN ?", experimentIDs).
+		Group("experiment_id, status").
+		Scan(&rows).Error; err != nil {
+		logger.Errorf(ctx, "CountStatuses fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	for _, row := range rows {
+		if counts[row.ExperimentID] == nil {
+			counts[row.ExperimentID] = make(map[model.ExecutionStatus]int64)
+		}
+		counts[row.ExperimentID][row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// WorkflowStats summarises the executions of an experiment by workflow
+func (e *experimentImpl) WorkflowStats(ctx context.Context, experimentID int64) ([]*model.ExperimentWorkflowStats, error) {
+	datas := make([]*model.ExperimentWorkflowStats, 0)
+	if err := e.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Select(`workflow_id, workflow_uuid, MAX(workflow_name) AS workflow_name,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status = ?) AS success,
+			COUNT(*) FILTER (WHERE status IN ?) AS failed,
+			COALESCE(AVG(duration_ms) FILTER (WHERE completed_at IS NOT NULL), 0) AS avg_duration_ms`,
+			model.ExecutionStatusSuccess,
+			[]model.ExecutionStatus{model.ExecutionStatusFailed, model.ExecutionStatusTimeout}).
+		Where("experiment_id = ?", experimentID).
+		Group("workflow_id, workflow_uuid").
+		Order("total DESC, workflow_id ASC").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "WorkflowStats fail experiment=%d: %+v", experimentID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// Span returns the time range and total duration of the executions of an experiment
+func (e *experimentImpl) Span(ctx context.Context, experimentID int64) (*ExecutionSpan, error) {
+	span := &ExecutionSpan{}
+	if err := e.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Select(`MIN(started_at) AS first_started_at, MAX(completed_at) AS last_completed_at,
+			COALESCE(SUM(duration_ms), 0) AS total_duration_ms`).
+		Where("experiment_id = ?", experimentID).
+		Scan(span).Error; err != nil {
+		logger.Errorf(ctx, "Span fail experiment=%d: %+v", experimentID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return span, nil
+}
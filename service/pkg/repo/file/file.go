@@ -0,0 +1,172 @@
+// Package file provides repository operations for uploaded result files.
+package file
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// FileRepo defines the interface for result file repository operations
+type FileRepo interface {
+	CreateFile(ctx context.Context, file *model.ResultFile) error
+	GetFileByUUID(ctx context.Context, uuid uuid.UUID) (*model.ResultFile, error)
+	// ListFiles lists available files of a lab, optionally of one action execution
+	ListFiles(ctx context.Context, labID int64, actionExecutionID *int64) ([]*model.ResultFile, error)
+	// AppendChunk advances the upload offset, failing with FileUploadOffsetErr
+	// when another chunk was written at the same offset first
+	AppendChunk(ctx context.Context, file *model.ResultFile, size int64) error
+	MarkAvailable(ctx context.Context, file *model.ResultFile, contentType, checksum string) error
+	MarkRejected(ctx context.Context, file *model.ResultFile, reason string) error
+	DeleteFile(ctx context.Context, file *model.ResultFile) error
+	// ListStaleUploads lists unfinished uploads not written to since before
+	ListStaleUploads(ctx context.Context, before time.Time, limit int) ([]*model.ResultFile, error)
+}
+
+type fileImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new result file repository instance
+func New() FileRepo {
+	return &fileImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateFile creates a file in uploading state
+func (f *fileImpl) CreateFile(ctx context.Context, file *model.ResultFile) error {
+	file.Status = model.ResultFileUploading
+	if err := f.DBWithContext(ctx).Create(file).Error; err != nil {
+		logger.Errorf(ctx, "CreateFile fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetFileByUUID retrieves a file by UUID
+func (f *fileImpl) GetFileByUUID(ctx context.Context, id uuid.UUID) (*model.ResultFile, error) {
+	var data model.ResultFile
+	if err := f.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetFileByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListFiles lists available files ordered by completion time
+func (f *fileImpl) ListFiles(ctx context.Context, labID int64, actionExecutionID *int64) ([]*model.ResultFile, error) {
+	query := f.DBWithContext(ctx).Where("lab_id = ? AND status = ?", labID, model.ResultFileAvailable)
+	if actionExecutionID != nil {
+		query = query.Where("action_execution_id = ?", *actionExecutionID)
+	}
+
+	var datas []*model.ResultFile
+	if err := query.Order("completed_at DESC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListFiles fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// AppendChunk records a chunk written at the current offset
+func (f *fileImpl) AppendChunk(ctx context.Context, file *model.ResultFile, size int64) error {
+	now := time.Now()
+	ret := f.DBWithContext(ctx).Model(&model.ResultFile{}).
+		Where("id = ? AND status = ? AND \"offset\" = ?", file.ID, model.ResultFileUploading, file.Offset).
+		Updates(map[string]any{
+			"offset":     gorm.Expr("\"offset\" + ?", size),
+			"chunks":     gorm.Expr("chunks + 1"),
+			"updated_at": now,
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "AppendChunk fail id=%d: %+v", file.ID, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.FileUploadOffsetErr
+	}
+
+	file.Offset += size
+	file.Chunks++
+	file.UpdatedAt = now
+	return nil
+}
+
+// MarkAvailable marks a fully received and validated file as available
+func (f *fileImpl) MarkAvailable(ctx context.Context, file *model.ResultFile, contentType, checksum string) error {
+	now := time.Now()
+	ret := f.DBWithContext(ctx).Model(&model.ResultFile{}).
+		Where("id = ? AND status = ?", file.ID, model.ResultFileUploading).
+		Updates(map[string]any{
+			"status":       model.ResultFileAvailable,
+			"content_type": contentType,
+			"checksum":     checksum,
+			"completed_at": now,
+			"updated_at":   now,
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "MarkAvailable fail id=%d: %+v", file.ID, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.FileUploadStatusErr
+	}
+
+	file.Status = model.ResultFileAvailable
+	file.ContentType = contentType
+	file.Checksum = checksum
+	file.CompletedAt = &now
+	return nil
+}
+
+// MarkRejected marks a file whose content failed validation
+func (f *fileImpl) MarkRejected(ctx context.Context, file *model.ResultFile, reason string) error {
+	now := time.Now()
+	if err := f.DBWithContext(ctx).Model(&model.ResultFile{}).
+		Where("id = ?", file.ID).
+		Updates(map[string]any{
+			"status":        model.ResultFileRejected,
+			"reject_reason": reason,
+			"updated_at":    now,
+		}).Error; err != nil {
+		logger.Errorf(ctx, "MarkRejected fail id=%d: %+v", file.ID, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+
+	file.Status = model.ResultFileRejected
+	file.RejectReason = reason
+	return nil
+}
+
+// DeleteFile deletes a file record
+func (f *fileImpl) DeleteFile(ctx context.Context, file *model.ResultFile) error {
+	if err := f.DBWithContext(ctx).Delete(&model.ResultFile{}, file.ID).Error; err != nil {
+		logger.Errorf(ctx, "DeleteFile fail id=%d: %+v", file.ID, err)
+		return code.DeleteDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListStaleUploads lists abandoned uploads
+func (f *fileImpl) ListStaleUploads(ctx context.Context, before time.Time, limit int) ([]*model.ResultFile, error) {
+	var datas []*model.ResultFile
+	if err := f.DBWithContext(ctx).
+		Where("status = ? AND updated_at < ?", model.ResultFileUploading, before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListStaleUploads fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
@@ -0,0 +1,85 @@
+package history
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/gorm"
+)
+
+// ListChanges reads up to limit rows of each kind after the watermark and
+// merges them, so the first limit changes are complete across the kinds
+func (h *historyImpl) ListChanges(ctx context.Context, labID int64, since *model.HistoryWatermark, until time.Time, limit int) ([]*model.HistoryChange, error) {
+	var executions []*model.WorkflowExecutionHistory
+	if err := changesQuery(h.DBWithContext(ctx), labID, since, until, model.HistoryChangeWorkflow).
+		Limit(limit).Find(&executions).Error; err != nil {
+		logger.Errorf(ctx, "ListChanges executions fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	var actions []*model.ActionExecutionHistory
+	// 变更中不返回动作的输入输出
+	if err := changesQuery(h.DBWithContext(ctx), labID, since, until, model.HistoryChangeAction).
+		Omit("input", "output").Limit(limit).Find(&actions).Error; err != nil {
+		logger.Errorf(ctx, "ListChanges actions fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	var events []*model.DeviceEventHistory
+	if err := changesQuery(h.events.ReadDB(ctx), labID, since, until, model.HistoryChangeEvent).
+		Limit(limit).Find(&events).Error; err != nil {
+		logger.Errorf(ctx, "ListChanges events fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	changes := make([]*model.HistoryChange, 0, len(executions)+len(actions)+len(events))
+	for _, e := range executions {
+		changes = append(changes, &model.HistoryChange{Kind: model.HistoryChangeWorkflow, Created: createdSince(e.CreatedAt, since), Execution: e})
+	}
+	for _, a := range actions {
+		changes = append(changes, &model.HistoryChange{Kind: model.HistoryChangeAction, Created: createdSince(a.CreatedAt, since), Action: a})
+	}
+	for _, e := range events {
+		changes = append(changes, &model.HistoryChange{Kind: model.HistoryChangeEvent, Created: createdSince(e.CreatedAt, since), Event: e})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Watermark().Before(changes[j].Watermark())
+	})
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+// changesQuery selects the rows of a kind after the watermark in
+// (updated_at, id) order. Rows with the same updated_at as the watermark
+// follow it when their kind sorts after the watermark kind
+func changesQuery(conn *gorm.DB, labID int64, since *model.HistoryWatermark, until time.Time, kind model.HistoryChangeKind) *gorm.DB {
+	var query *gorm.DB
+	switch kind {
+	case model.HistoryChangeAction:
+		query = conn.Model(&model.ActionExecutionHistory{})
+	case model.HistoryChangeEvent:
+		query = conn.Model(&model.DeviceEventHistory{})
+	default:
+		query = conn.Model(&model.WorkflowExecutionHistory{})
+	}
+	query = query.Where("lab_id = ? AND updated_at <= ?", labID, until)
+	if since != nil {
+		switch order := kind.Order(); {
+		case order < since.Kind.Order():
+			query = query.Where("updated_at > ?", since.UpdatedAt)
+		case order == since.Kind.Order():
+			query = query.Where("updated_at > ? OR (updated_at = ? AND id > ?)", since.UpdatedAt, since.UpdatedAt, since.ID)
+		default:
+			query = query.Where("updated_at >= ?", since.UpdatedAt)
+		}
+	}
+	return query.Order("updated_at ASC, id ASC")
+}
+
+func createdSince(createdAt time.Time, since *model.HistoryWatermark) bool {
+	return since == nil || createdAt.After(since.UpdatedAt)
+}
@@ -3,14 +3,24 @@ package history
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/scienceol/studio/service/pkg/common/code"
 	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
 	"github.com/scienceol/studio/service/pkg/model"
 	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/eventstore"
+	"github.com/scienceol/studio/service/pkg/repo/legalhold"
+	"github.com/scienceol/studio/service/pkg/repo/payload"
+	"github.com/scienceol/studio/service/pkg/utils"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // HistoryRepo defines the interface for history repository operations
@@ -18,23 +28,109 @@ type HistoryRepo interface {
 	// Workflow Execution History
 	CreateWorkflowExecution(ctx context.Context, exec *model.WorkflowExecutionHistory) error
 	UpdateWorkflowExecution(ctx context.Context, id int64, updates map[string]interface{}) error
+	// UpdateWorkflowExecutionVersion updates an execution only when its version
+	// is still the one read, returning HistoryVersionConflictErr otherwise
+	UpdateWorkflowExecutionVersion(ctx context.Context, id, version int64, updates map[string]interface{}) error
+	// IncrementStepCounters adds to the step counters of an execution in a single statement
+	IncrementStepCounters(ctx context.Context, id int64, completedDelta, failedDelta int) error
+	// CompleteWorkflowExecution sets the final columns of an execution when its
+	// version is still the one read and derives duration_ms from started_at and
+	// completedAt, returning HistoryVersionConflictErr otherwise
+	CompleteWorkflowExecution(ctx context.Context, id, version int64, completedAt time.Time, updates map[string]interface{}) error
 	GetWorkflowExecution(ctx context.Context, id int64) (*model.WorkflowExecutionHistory, error)
 	GetWorkflowExecutionByUUID(ctx context.Context, uuid uuid.UUID) (*model.WorkflowExecutionHistory, error)
-	ListWorkflowExecutions(ctx context.Context, params *model.HistoryQueryParams) ([]*model.WorkflowExecutionHistory, int64, error)
+	ListWorkflowExecutions(ctx context.Context, params *model.HistoryQueryParams) ([]*model.WorkflowExecutionHistory, model.PageCount, error)
+	// SetExecutionLabels replaces the labels of a workflow execution
+	SetExecutionLabels(ctx context.Context, id int64, labels map[string]string) error
+	// ListLabelKeys lists the label keys used by executions of a lab, most used first
+	ListLabelKeys(ctx context.Context, labID int64, limit int) ([]*model.LabelCount, error)
+	// ListLabelValues lists the values of a label key used by executions of a lab, most used first
+	ListLabelValues(ctx context.Context, labID int64, key string, limit int) ([]*model.LabelCount, error)
+	// PinExecution pins an execution for a user, pinning twice keeps the first pin time
+	PinExecution(ctx context.Context, pin *model.ExecutionPin) error
+	UnpinExecution(ctx context.Context, userID string, executionID int64) error
+	// PinnedExecutionIDs returns which of the executions are pinned by the user
+	PinnedExecutionIDs(ctx context.Context, userID string, executionIDs []int64) (map[int64]bool, error)
+	// SaveWorkflowSnapshot stores a workflow definition snapshot, storing the same content twice is a no-op
+	SaveWorkflowSnapshot(ctx context.Context, snapshot *model.WorkflowSnapshot) error
+	// GetWorkflowSnapshot gets a workflow definition snapshot by its hash
+	GetWorkflowSnapshot(ctx context.Context, hash string) (*model.WorkflowSnapshot, error)
 
 	// Action Execution History
 	CreateActionExecution(ctx context.Context, exec *model.ActionExecutionHistory) error
 	CreateActionExecutionBatch(ctx context.Context, execs []*model.ActionExecutionHistory) error
-	ListActionExecutions(ctx context.Context, params *model.HistoryQueryParams) ([]*model.ActionExecutionHistory, int64, error)
+	// ReportAction writes an action start or completion by its UUID and, in
+	// the same transaction, adds a newly finished action to the step counters
+	// of its execution. It reports whether the action finished with this write
+	ReportAction(ctx context.Context, action *model.ActionExecutionHistory) (bool, error)
+	ListActionExecutions(ctx context.Context, params *model.HistoryQueryParams) ([]*model.ActionExecutionHistory, model.PageCount, error)
+	GetActionExecutionByUUID(ctx context.Context, uuid uuid.UUID) (*model.ActionExecutionHistory, error)
 	ListActionsByWorkflowExecution(ctx context.Context, workflowExecID int64) ([]*model.ActionExecutionHistory, error)
+	// ListActionsByWorkflowExecutions loads the first limit actions of each
+	// workflow execution in a single query, ordered by execution and time
+	ListActionsByWorkflowExecutions(ctx context.Context, workflowExecIDs []int64, limit int) ([]*model.ActionExecutionHistory, error)
 
 	// Device Event History
 	CreateDeviceEvent(ctx context.Context, event *model.DeviceEventHistory) error
 	CreateDeviceEventBatch(ctx context.Context, events []*model.DeviceEventHistory) error
-	ListDeviceEvents(ctx context.Context, params *model.HistoryQueryParams) ([]*model.DeviceEventHistory, int64, error)
+	// IngestDeviceEvents writes burst device events with COPY, falling back to
+	// batched inserts. The id and uuid of the events are not filled in
+	IngestDeviceEvents(ctx context.Context, events []*model.DeviceEventHistory) (int64, error)
+	ListDeviceEvents(ctx context.Context, params *model.HistoryQueryParams) ([]*model.DeviceEventHistory, model.PageCount, error)
 
 	// Statistics
-	GetLabStats(ctx context.Context, labID int64, startTime, endTime *time.Time) (*model.HistoryStats, error)
+	// GetLabStats aggregates the history of a lab, limited to the devices when deviceIDs is not nil
+	GetLabStats(ctx context.Context, labID int64, startTime, endTime *time.Time, deviceIDs []int64) (*model.HistoryStats, error)
+	// ListOrgLabs lists the labs of an organization that are not deleted
+	ListOrgLabs(ctx context.Context, orgID string) ([]*model.Laboratory, error)
+	// GetOrgStats aggregates statistics of the labs in [startTime, endTime) with
+	// the previous period of the same length and the top N workflows and devices
+	GetOrgStats(ctx context.Context, labs []*model.Laboratory, startTime, endTime time.Time, topN int) (*model.OrgStats, error)
+	ListSlowestSteps(ctx context.Context, labID int64, startTime, endTime *time.Time, limit int) ([]*model.StepDurationStats, error)
+	// ActivityHeatmap counts the execution starts in [startTime, endTime) by
+	// weekday and hour of day in the timezone
+	ActivityHeatmap(ctx context.Context, labID int64, startTime, endTime time.Time, timezone string) ([]*model.HeatmapCell, error)
+	// GetDeviceUtilization compares the action time of each device in
+	// [startTime, endTime) with its online time from the liveness events
+	GetDeviceUtilization(ctx context.Context, labID int64, startTime, endTime time.Time) (*model.UtilizationReport, error)
+	// GetFailureStats counts the failed executions and actions in
+	// [startTime, endTime) by error category
+	GetFailureStats(ctx context.Context, labID int64, startTime, endTime time.Time) (*model.FailureStats, error)
+	// ListActiveExecutions lists the pending and running executions of a lab in queue order
+	ListActiveExecutions(ctx context.Context, labID int64, limit int) ([]*model.WorkflowExecutionHistory, error)
+	// ListDurationSamples returns the durations of the latest limit successful
+	// executions since the time of each workflow
+	ListDurationSamples(ctx context.Context, workflowIDs []int64, since time.Time, limit int) (map[int64][]int64, error)
+	// ListStepDurationPercentiles returns the duration percentiles of the
+	// successful actions since the time of a lab, by device and action and by device
+	ListStepDurationPercentiles(ctx context.Context, labID int64, since time.Time) ([]*model.StepDurationPercentiles, error)
+
+	// Export
+	// ExportBounds counts the rows of a history list matching the filters and returns their largest id
+	ExportBounds(ctx context.Context, kind model.HistoryExportKind, params *model.HistoryQueryParams) (int64, int64, error)
+	// ScanWorkflowExecutions reads the executions matching the filters with id in (afterID, maxID] in id order
+	ScanWorkflowExecutions(ctx context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.WorkflowExecutionHistory, error)
+	// ScanActionExecutions reads the actions matching the filters with id in (afterID, maxID] in id order
+	ScanActionExecutions(ctx context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.ActionExecutionHistory, error)
+	// ScanDeviceEvents reads the device events matching the filters with id in (afterID, maxID] in id order
+	ScanDeviceEvents(ctx context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.DeviceEventHistory, error)
+
+	// Streaming
+	// StreamWorkflowExecutions calls fn with up to limit executions matching
+	// the filters in list order as they are read, ignoring the page. Reading
+	// stops at the first error of fn, which is returned
+	StreamWorkflowExecutions(ctx context.Context, params *model.HistoryQueryParams, limit int, fn func(*model.WorkflowExecutionHistory) error) error
+	// StreamDeviceEvents calls fn with up to limit device events matching the
+	// filters in list order as they are read, ignoring the page
+	StreamDeviceEvents(ctx context.Context, params *model.HistoryQueryParams, limit int, fn func(*model.DeviceEventHistory) error) error
+	// LabPinnedExecutionIDs returns the executions of a lab pinned by the user
+	LabPinnedExecutionIDs(ctx context.Context, userID string, labID int64) (map[int64]bool, error)
+
+	// Sync
+	// ListChanges lists up to limit executions, actions and device events of a
+	// lab created or updated after the watermark and not after until, in
+	// (updated_at, kind, id) order. All records are returned when since is nil
+	ListChanges(ctx context.Context, labID int64, since *model.HistoryWatermark, until time.Time, limit int) ([]*model.HistoryChange, error)
 
 	// Cleanup
 	CleanupOldRecords(ctx context.Context, before time.Time) (int64, error)
@@ -42,17 +138,34 @@ type HistoryRepo interface {
 
 type historyImpl struct {
 	repo.IDOrUUIDTranslate
+	events   eventstore.EventStore
+	holds    legalhold.LegalHoldRepo
+	payloads *payload.Limiter
+}
+
+// executionHoldColumns matches legal holds against workflow executions
+var executionHoldColumns = legalhold.Columns{
+	LabID:       "lab_id",
+	ExecutionID: "id",
+	WorkflowID:  "workflow_id",
+	Time:        "started_at",
 }
 
 // New creates a new history repository instance
 func New() HistoryRepo {
 	return &historyImpl{
 		IDOrUUIDTranslate: repo.NewBaseDB(),
+		events:            eventstore.New(),
+		holds:             legalhold.New(),
+		payloads:          payload.New(),
 	}
 }
 
 // CreateWorkflowExecution creates a new workflow execution history record
 func (h *historyImpl) CreateWorkflowExecution(ctx context.Context, exec *model.WorkflowExecutionHistory) error {
+	if err := h.payloads.WorkflowExecution(ctx, exec); err != nil {
+		return err
+	}
 	if err := h.DBWithContext(ctx).Create(exec).Error; err != nil {
 		logger.Errorf(ctx, "CreateWorkflowExecution fail: %+v", err)
 		return code.CreateDataErr.WithErr(err)
@@ -60,16 +173,96 @@ func (h *historyImpl) CreateWorkflowExecution(ctx context.Context, exec *model.W
 	return nil
 }
 
-// UpdateWorkflowExecution updates a workflow execution history record
+// UpdateWorkflowExecution updates a workflow execution history record. The
+// version is bumped so that concurrent versioned updates detect the write
 func (h *historyImpl) UpdateWorkflowExecution(ctx context.Context, id int64, updates map[string]interface{}) error {
 	if err := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
-		Where("id = ?", id).Updates(updates).Error; err != nil {
+		Where("id = ?", id).Updates(bumpVersion(updates)).Error; err != nil {
 		logger.Errorf(ctx, "UpdateWorkflowExecution fail id=%d: %+v", id, err)
 		return code.UpdateDataErr.WithErr(err)
 	}
 	return nil
 }
 
+// UpdateWorkflowExecutionVersion updates a workflow execution history record with optimistic locking
+func (h *historyImpl) UpdateWorkflowExecutionVersion(ctx context.Context, id, version int64, updates map[string]interface{}) error {
+	ret := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("id = ? AND version = ?", id, version).Updates(bumpVersion(updates))
+	if ret.Error != nil {
+		logger.Errorf(ctx, "UpdateWorkflowExecutionVersion fail id=%d: %+v", id, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		if _, err := h.GetWorkflowExecution(ctx, id); err != nil {
+			return err
+		}
+		return code.HistoryVersionConflictErr
+	}
+	return nil
+}
+
+// IncrementStepCounters adds to the step counters without reading them first
+func (h *historyImpl) IncrementStepCounters(ctx context.Context, id int64, completedDelta, failedDelta int) error {
+	if completedDelta == 0 && failedDelta == 0 {
+		return nil
+	}
+	res := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("id = ?", id).Updates(bumpVersion(map[string]interface{}{
+		"steps_completed": gorm.Expr("steps_completed + ?", completedDelta),
+		"steps_failed":    gorm.Expr("steps_failed + ?", failedDelta),
+		"updated_at":      time.Now(),
+	}))
+	if res.Error != nil {
+		logger.Errorf(ctx, "IncrementStepCounters fail id=%d: %+v", id, res.Error)
+		return code.UpdateDataErr.WithErr(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// CompleteWorkflowExecution finishes an execution with optimistic locking,
+// the duration is computed by the database from the stored started_at
+func (h *historyImpl) CompleteWorkflowExecution(ctx context.Context, id, version int64, completedAt time.Time, updates map[string]interface{}) error {
+	datas := bumpVersion(updates)
+	datas["completed_at"] = completedAt
+	datas["updated_at"] = time.Now()
+	if db.IsSQLite(h.DBWithContext(ctx)) {
+		// sqlite 按文本存储时间，读取开始时间后在内存中计算
+		exec, err := h.GetWorkflowExecution(ctx, id)
+		if err != nil {
+			return err
+		}
+		datas["duration_ms"] = max(completedAt.Sub(exec.StartedAt).Milliseconds(), 0)
+	} else {
+		datas["duration_ms"] = gorm.Expr("GREATEST(CAST(EXTRACT(EPOCH FROM (CAST(? AS timestamptz) - started_at)) * 1000 AS bigint), 0)", completedAt)
+	}
+	ret := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("id = ? AND version = ?", id, version).Updates(datas)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "CompleteWorkflowExecution fail id=%d: %+v", id, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		if _, err := h.GetWorkflowExecution(ctx, id); err != nil {
+			return err
+		}
+		return code.HistoryVersionConflictErr
+	}
+	return nil
+}
+
+// bumpVersion copies the updates with the version incremented
+func bumpVersion(updates map[string]interface{}) map[string]interface{} {
+	datas := make(map[string]interface{}, len(updates)+1)
+	for k, v := range updates {
+		datas[k] = v
+	}
+	datas["version"] = gorm.Expr("version + 1")
+	return datas
+}
+
 // GetWorkflowExecution retrieves a workflow execution by ID
 func (h *historyImpl) GetWorkflowExecution(ctx context.Context, id int64) (*model.WorkflowExecutionHistory, error) {
 	var exec model.WorkflowExecutionHistory
@@ -96,28 +289,194 @@ func (h *historyImpl) GetWorkflowExecutionByUUID(ctx context.Context, uuid uuid.
 	return &exec, nil
 }
 
-// ListWorkflowExecutions lists workflow executions with pagination
-func (h *historyImpl) ListWorkflowExecutions(ctx context.Context, params *model.HistoryQueryParams) ([]*model.WorkflowExecutionHistory, int64, error) {
+// pageLimit 跳过总数统计或按游标翻页时多取一条判断是否还有下一页
+func pageLimit(params *model.HistoryQueryParams) int {
+	if params.SkipTotal || params.Cursor != nil {
+		return params.PageSize + 1
+	}
+	return params.PageSize
+}
+
+// ListWorkflowExecutions lists workflow executions with pagination. The first
+// page takes a snapshot of the executions and returns the cursor of the next
+// page in it, pages read with the cursor continue in the same snapshot
+func (h *historyImpl) ListWorkflowExecutions(ctx context.Context, params *model.HistoryQueryParams) ([]*model.WorkflowExecutionHistory, model.PageCount, error) {
+	if err := checkFilters(params); err != nil {
+		return nil, model.PageCount{}, err
+	}
 	var executions []*model.WorkflowExecutionHistory
 	var total int64
 
 	query := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{})
 	query = h.applyWorkflowFilters(query, params)
 
+	var snapshotID int64
+	if c := params.Cursor; c != nil {
+		query = query.Where("id <= ? AND started_at <= ?", c.SnapshotID, c.SnapshotAt)
+	} else if params.Page == 1 {
+		if err := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+			Select("COALESCE(MAX(id), 0)").Scan(&snapshotID).Error; err != nil {
+			logger.Errorf(ctx, "ListWorkflowExecutions snapshot fail: %+v", err)
+			return nil, model.PageCount{}, code.QueryRecordErr.WithErr(err)
+		}
+		query = query.Where("id <= ?", snapshotID)
+	}
+
 	// Count total
-	if err := query.Count(&total).Error; err != nil {
-		logger.Errorf(ctx, "ListWorkflowExecutions count fail: %+v", err)
-		return nil, 0, code.QueryRecordErr.WithErr(err)
+	if !params.SkipTotal && params.Cursor == nil {
+		if err := query.Count(&total).Error; err != nil {
+			logger.Errorf(ctx, "ListWorkflowExecutions count fail: %+v", err)
+			return nil, model.PageCount{}, code.QueryRecordErr.WithErr(err)
+		}
 	}
 
 	// Get paginated results
-	offset := (params.Page - 1) * params.PageSize
-	if err := query.Order("started_at DESC").Offset(offset).Limit(params.PageSize).Find(&executions).Error; err != nil {
+	if c := params.Cursor; c != nil {
+		query = query.Where("started_at < ? OR (started_at = ? AND id < ?)", c.StartedAt, c.StartedAt, c.ID)
+	} else {
+		query = query.Offset((params.Page - 1) * params.PageSize)
+	}
+	if len(params.Columns) > 0 {
+		query = query.Select(params.Columns)
+	}
+	if err := query.Order("started_at DESC, id DESC").Limit(pageLimit(params)).Find(&executions).Error; err != nil {
 		logger.Errorf(ctx, "ListWorkflowExecutions find fail: %+v", err)
-		return nil, 0, code.QueryRecordErr.WithErr(err)
+		return nil, model.PageCount{}, code.QueryRecordErr.WithErr(err)
+	}
+
+	count, keep := model.NewPageCount(params, len(executions), total)
+	executions = executions[:keep]
+	if params.Cursor != nil || params.Page == 1 {
+		count.Next = model.NextHistoryCursor(params, count, snapshotID, executions)
+	}
+	return executions, count, nil
+}
+
+// SetExecutionLabels replaces the labels of a workflow execution
+func (h *historyImpl) SetExecutionLabels(ctx context.Context, id int64, labels map[string]string) error {
+	ret := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"labels":     datatypes.NewJSONType(labels),
+			"updated_at": time.Now(),
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "SetExecutionLabels fail id=%d: %+v", id, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// ListLabelKeys counts the executions using each label key
+func (h *historyImpl) ListLabelKeys(ctx context.Context, labID int64, limit int) ([]*model.LabelCount, error) {
+	var datas []*model.LabelCount
+	query := h.DBWithContext(ctx).
+		Table("workflow_execution_history, jsonb_object_keys(labels) AS k").
+		Select("k AS value, COUNT(*) AS count").
+		Where("lab_id = ? AND jsonb_typeof(labels) = 'object'", labID).
+		Group("k")
+	if db.IsSQLite(query) {
+		query = h.DBWithContext(ctx).
+			Table("workflow_execution_history, json_each(labels) AS k").
+			Select("k.key AS value, COUNT(*) AS count").
+			Where("lab_id = ? AND json_type(labels) = 'object'", labID).
+			Group("k.key")
+	}
+	if err := query.
+		Order("count DESC, value ASC").
+		Limit(limit).
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListLabelKeys fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListLabelValues counts the executions using each value of a label key
+func (h *historyImpl) ListLabelValues(ctx context.Context, labID int64, key string, limit int) ([]*model.LabelCount, error) {
+	var datas []*model.LabelCount
+	if err := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Select("labels ->> ? AS value, COUNT(*) AS count", key).
+		Where("lab_id = ? AND labels ->> ? IS NOT NULL", labID, key).
+		Group("value").
+		Order("count DESC, value ASC").
+		Limit(limit).
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListLabelValues fail lab=%d key=%s: %+v", labID, key, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// PinExecution pins an execution for a user
+func (h *historyImpl) PinExecution(ctx context.Context, pin *model.ExecutionPin) error {
+	if err := h.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "execution_id"}},
+		DoNothing: true,
+	}).Create(pin).Error; err != nil {
+		logger.Errorf(ctx, "PinExecution fail execution=%d: %+v", pin.ExecutionID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// UnpinExecution removes the pin of a user, unpinning an execution that is not pinned is not an error
+func (h *historyImpl) UnpinExecution(ctx context.Context, userID string, executionID int64) error {
+	if err := h.DBWithContext(ctx).
+		Where("user_id = ? AND execution_id = ?", userID, executionID).
+		Delete(&model.ExecutionPin{}).Error; err != nil {
+		logger.Errorf(ctx, "UnpinExecution fail execution=%d: %+v", executionID, err)
+		return code.DeleteDataErr.WithErr(err)
 	}
+	return nil
+}
 
-	return executions, total, nil
+// PinnedExecutionIDs returns the pinned subset of the executions
+func (h *historyImpl) PinnedExecutionIDs(ctx context.Context, userID string, executionIDs []int64) (map[int64]bool, error) {
+	pinned := make(map[int64]bool)
+	if userID == "" || len(executionIDs) == 0 {
+		return pinned, nil
+	}
+
+	var ids []int64
+	if err := h.DBWithContext(ctx).Model(&model.ExecutionPin{}).
+		Where("user_id = ? AND execution_id IN ?", userID, executionIDs).
+		Pluck("execution_id", &ids).Error; err != nil {
+		logger.Errorf(ctx, "PinnedExecutionIDs fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	for _, id := range ids {
+		pinned[id] = true
+	}
+	return pinned, nil
+}
+
+// SaveWorkflowSnapshot stores a snapshot unless one with the same hash exists
+func (h *historyImpl) SaveWorkflowSnapshot(ctx context.Context, snapshot *model.WorkflowSnapshot) error {
+	if err := h.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}},
+		DoNothing: true,
+	}).Create(snapshot).Error; err != nil {
+		logger.Errorf(ctx, "SaveWorkflowSnapshot fail workflow=%d: %+v", snapshot.WorkflowID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetWorkflowSnapshot gets a snapshot by hash
+func (h *historyImpl) GetWorkflowSnapshot(ctx context.Context, hash string) (*model.WorkflowSnapshot, error) {
+	var snapshot model.WorkflowSnapshot
+	if err := h.DBWithContext(ctx).Where("hash = ?", hash).First(&snapshot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetWorkflowSnapshot fail hash=%s: %+v", hash, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &snapshot, nil
 }
 
 func (h *historyImpl) applyWorkflowFilters(query *gorm.DB, params *model.HistoryQueryParams) *gorm.DB {
@@ -130,9 +489,34 @@ func (h *historyImpl) applyWorkflowFilters(query *gorm.DB, params *model.History
 	if params.WorkflowID != nil {
 		query = query.Where("workflow_id = ?", *params.WorkflowID)
 	}
+	if params.DeviceID != nil {
+		// 执行中有动作在该设备上运行
+		query = query.Where("id IN (SELECT workflow_execution_id FROM action_execution_history WHERE device_id = ?)", *params.DeviceID)
+	}
+	if params.DeviceIDs != nil {
+		query = query.Where("id IN (SELECT workflow_execution_id FROM action_execution_history WHERE device_id IN ?)", params.DeviceIDs)
+	}
+	if params.ExperimentID != nil {
+		query = query.Where("experiment_id = ?", *params.ExperimentID)
+	}
 	if params.Status != nil {
 		query = query.Where("status = ?", *params.Status)
 	}
+	if len(params.Labels) > 0 && db.IsSQLite(query) {
+		for k, v := range params.Labels {
+			query = query.Where("labels ->> ? = ?", k, v)
+		}
+	} else if len(params.Labels) > 0 {
+		// jsonb 包含查询可以使用 labels 上的 GIN 索引
+		labels, _ := json.Marshal(params.Labels)
+		query = query.Where("labels @> ?::jsonb", string(labels))
+	}
+	if params.PinnedBy != "" {
+		query = query.Where("id IN (SELECT execution_id FROM execution_pin WHERE user_id = ?)", params.PinnedBy)
+	}
+	if params.Breached != nil {
+		query = query.Where("sla_breached = ?", *params.Breached)
+	}
 	if params.StartTime != nil {
 		query = query.Where("started_at >= ?", *params.StartTime)
 	}
@@ -144,6 +528,9 @@ func (h *historyImpl) applyWorkflowFilters(query *gorm.DB, params *model.History
 
 // CreateActionExecution creates a new action execution history record
 func (h *historyImpl) CreateActionExecution(ctx context.Context, exec *model.ActionExecutionHistory) error {
+	if err := h.payloads.ActionExecution(ctx, exec); err != nil {
+		return err
+	}
 	if err := h.DBWithContext(ctx).Create(exec).Error; err != nil {
 		logger.Errorf(ctx, "CreateActionExecution fail: %+v", err)
 		return code.CreateDataErr.WithErr(err)
@@ -156,6 +543,11 @@ func (h *historyImpl) CreateActionExecutionBatch(ctx context.Context, execs []*m
 	if len(execs) == 0 {
 		return nil
 	}
+	for _, exec := range execs {
+		if err := h.payloads.ActionExecution(ctx, exec); err != nil {
+			return err
+		}
+	}
 	if err := h.DBWithContext(ctx).CreateInBatches(execs, 100).Error; err != nil {
 		logger.Errorf(ctx, "CreateActionExecutionBatch fail: %+v", err)
 		return code.CreateDataErr.WithErr(err)
@@ -163,26 +555,112 @@ func (h *historyImpl) CreateActionExecutionBatch(ctx context.Context, execs []*m
 	return nil
 }
 
+// ReportAction creates the action when its UUID is new and finishes a
+// pending or running action otherwise; reports on a finished action are
+// ignored so retried reports do not count a step twice
+func (h *historyImpl) ReportAction(ctx context.Context, action *model.ActionExecutionHistory) (bool, error) {
+	if err := h.payloads.ActionExecution(ctx, action); err != nil {
+		return false, err
+	}
+
+	finished := false
+	err := h.ExecTx(ctx, func(txCtx context.Context) error {
+		stored := &model.ActionExecutionHistory{}
+		err := h.DBWithContext(txCtx).Select("id", "lab_id", "workflow_execution_id", "status").
+			Where("uuid = ?", action.UUID).First(stored).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			if err := h.DBWithContext(txCtx).Create(action).Error; err != nil {
+				logger.Errorf(ctx, "ReportAction create fail uuid=%s: %+v", action.UUID, err)
+				return code.CreateDataErr.WithErr(err)
+			}
+		case err != nil:
+			logger.Errorf(ctx, "ReportAction query fail uuid=%s: %+v", action.UUID, err)
+			return code.QueryRecordErr.WithErr(err)
+		case stored.LabID != action.LabID:
+			return code.NoPermission
+		case stored.Status.Finished() || !action.Status.Finished():
+			return nil
+		default:
+			res := h.DBWithContext(txCtx).Model(&model.ActionExecutionHistory{}).
+				Where("id = ? AND status IN ?", stored.ID, []model.ExecutionStatus{model.ExecutionStatusPending, model.ExecutionStatusRunning}).
+				Updates(map[string]interface{}{
+					"status":         action.Status,
+					"output":         action.Output,
+					"duration_ms":    action.DurationMs,
+					"error_message":  action.ErrorMessage,
+					"error_category": action.ErrorCategory,
+					"updated_at":     action.UpdatedAt,
+				})
+			if res.Error != nil {
+				logger.Errorf(ctx, "ReportAction update fail id=%d: %+v", stored.ID, res.Error)
+				return code.UpdateDataErr.WithErr(res.Error)
+			}
+			if res.RowsAffected == 0 {
+				// 并发的上报已经结束了该动作
+				return nil
+			}
+			action.ID = stored.ID
+			action.WorkflowExecutionID = stored.WorkflowExecutionID
+		}
+
+		finished = action.Status.Finished()
+		if !finished || action.WorkflowExecutionID == nil {
+			return nil
+		}
+		switch action.Status {
+		case model.ExecutionStatusSuccess:
+			return h.IncrementStepCounters(txCtx, *action.WorkflowExecutionID, 1, 0)
+		case model.ExecutionStatusFailed, model.ExecutionStatusTimeout:
+			return h.IncrementStepCounters(txCtx, *action.WorkflowExecutionID, 0, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return finished, nil
+}
+
 // ListActionExecutions lists action executions with pagination
-func (h *historyImpl) ListActionExecutions(ctx context.Context, params *model.HistoryQueryParams) ([]*model.ActionExecutionHistory, int64, error) {
+func (h *historyImpl) ListActionExecutions(ctx context.Context, params *model.HistoryQueryParams) ([]*model.ActionExecutionHistory, model.PageCount, error) {
+	if err := checkFilters(params); err != nil {
+		return nil, model.PageCount{}, err
+	}
 	var executions []*model.ActionExecutionHistory
 	var total int64
 
 	query := h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{})
 	query = h.applyActionFilters(query, params)
 
-	if err := query.Count(&total).Error; err != nil {
-		logger.Errorf(ctx, "ListActionExecutions count fail: %+v", err)
-		return nil, 0, code.QueryRecordErr.WithErr(err)
+	if !params.SkipTotal {
+		if err := query.Count(&total).Error; err != nil {
+			logger.Errorf(ctx, "ListActionExecutions count fail: %+v", err)
+			return nil, model.PageCount{}, code.QueryRecordErr.WithErr(err)
+		}
 	}
 
 	offset := (params.Page - 1) * params.PageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(params.PageSize).Find(&executions).Error; err != nil {
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageLimit(params)).Find(&executions).Error; err != nil {
 		logger.Errorf(ctx, "ListActionExecutions find fail: %+v", err)
-		return nil, 0, code.QueryRecordErr.WithErr(err)
+		return nil, model.PageCount{}, code.QueryRecordErr.WithErr(err)
 	}
 
-	return executions, total, nil
+	count, keep := model.NewPageCount(params, len(executions), total)
+	return executions[:keep], count, nil
+}
+
+// GetActionExecutionByUUID retrieves an action execution by UUID
+func (h *historyImpl) GetActionExecutionByUUID(ctx context.Context, uuid uuid.UUID) (*model.ActionExecutionHistory, error) {
+	var action model.ActionExecutionHistory
+	if err := h.DBWithContext(ctx).Where("uuid = ?", uuid).First(&action).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetActionExecutionByUUID fail uuid=%s: %+v", uuid, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &action, nil
 }
 
 // ListActionsByWorkflowExecution retrieves all actions for a workflow execution
@@ -196,6 +674,38 @@ func (h *historyImpl) ListActionsByWorkflowExecution(ctx context.Context, workfl
 	return executions, nil
 }
 
+// ListActionsByWorkflowExecutions retrieves the actions of several workflow executions, at most limit per execution
+func (h *historyImpl) ListActionsByWorkflowExecutions(ctx context.Context, workflowExecIDs []int64, limit int) ([]*model.ActionExecutionHistory, error) {
+	if len(workflowExecIDs) == 0 {
+		return []*model.ActionExecutionHistory{}, nil
+	}
+
+	ranked := h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY workflow_execution_id ORDER BY created_at ASC, id ASC) AS rn").
+		Where("workflow_execution_id IN ?", workflowExecIDs)
+	var actions []*model.ActionExecutionHistory
+	if err := h.DBWithContext(ctx).Table("(?) AS t", ranked).
+		Where("rn <= ?", limit).
+		Order("workflow_execution_id ASC, created_at ASC, id ASC").
+		Find(&actions).Error; err != nil {
+		logger.Errorf(ctx, "ListActionsByWorkflowExecutions fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return actions, nil
+}
+
+// checkFilters rejects status and event type filters that are not known
+// values, which would otherwise match nothing without telling the caller why
+func checkFilters(params *model.HistoryQueryParams) error {
+	if s := params.Status; s != nil && !s.Valid() {
+		return code.ParamErr.WithMsgf("invalid status %q, allowed values: %s", *s, strings.Join(s.Values(), ", "))
+	}
+	if t := params.EventType; t != nil && !t.Valid() {
+		return code.ParamErr.WithMsgf("invalid event_type %q, allowed values: %s", *t, strings.Join(t.Values(), ", "))
+	}
+	return nil
+}
+
 func (h *historyImpl) applyActionFilters(query *gorm.DB, params *model.HistoryQueryParams) *gorm.DB {
 	if params.LabID > 0 {
 		query = query.Where("lab_id = ?", params.LabID)
@@ -203,6 +713,12 @@ func (h *historyImpl) applyActionFilters(query *gorm.DB, params *model.HistoryQu
 	if params.DeviceID != nil {
 		query = query.Where("device_id = ?", *params.DeviceID)
 	}
+	if params.DeviceIDs != nil {
+		query = query.Where("device_id IN ?", params.DeviceIDs)
+	}
+	if params.ExperimentID != nil {
+		query = query.Where("workflow_execution_id IN (SELECT id FROM workflow_execution_history WHERE experiment_id = ?)", *params.ExperimentID)
+	}
 	if params.Status != nil {
 		query = query.Where("status = ?", *params.Status)
 	}
@@ -217,45 +733,54 @@ func (h *historyImpl) applyActionFilters(query *gorm.DB, params *model.HistoryQu
 
 // CreateDeviceEvent creates a new device event history record
 func (h *historyImpl) CreateDeviceEvent(ctx context.Context, event *model.DeviceEventHistory) error {
-	if err := h.DBWithContext(ctx).Create(event).Error; err != nil {
-		logger.Errorf(ctx, "CreateDeviceEvent fail: %+v", err)
-		return code.CreateDataErr.WithErr(err)
-	}
-	return nil
+	return h.CreateDeviceEventBatch(ctx, []*model.DeviceEventHistory{event})
 }
 
 // CreateDeviceEventBatch creates multiple device events in batch
 func (h *historyImpl) CreateDeviceEventBatch(ctx context.Context, events []*model.DeviceEventHistory) error {
-	if len(events) == 0 {
-		return nil
+	if err := h.payloads.DeviceEvents(ctx, events); err != nil {
+		return err
 	}
-	if err := h.DBWithContext(ctx).CreateInBatches(events, 100).Error; err != nil {
-		logger.Errorf(ctx, "CreateDeviceEventBatch fail: %+v", err)
-		return code.CreateDataErr.WithErr(err)
+	return h.events.CreateEvents(ctx, events)
+}
+
+// IngestDeviceEvents writes device events through the high-throughput path
+func (h *historyImpl) IngestDeviceEvents(ctx context.Context, events []*model.DeviceEventHistory) (int64, error) {
+	if err := h.payloads.DeviceEvents(ctx, events); err != nil {
+		return 0, err
 	}
-	return nil
+	return h.events.IngestEvents(ctx, events)
 }
 
 // ListDeviceEvents lists device events with pagination
-func (h *historyImpl) ListDeviceEvents(ctx context.Context, params *model.HistoryQueryParams) ([]*model.DeviceEventHistory, int64, error) {
+func (h *historyImpl) ListDeviceEvents(ctx context.Context, params *model.HistoryQueryParams) ([]*model.DeviceEventHistory, model.PageCount, error) {
+	if err := checkFilters(params); err != nil {
+		return nil, model.PageCount{}, err
+	}
 	var events []*model.DeviceEventHistory
 	var total int64
 
-	query := h.DBWithContext(ctx).Model(&model.DeviceEventHistory{})
+	query := h.events.ReadDB(ctx).Model(&model.DeviceEventHistory{})
 	query = h.applyDeviceEventFilters(query, params)
 
-	if err := query.Count(&total).Error; err != nil {
-		logger.Errorf(ctx, "ListDeviceEvents count fail: %+v", err)
-		return nil, 0, code.QueryRecordErr.WithErr(err)
+	if !params.SkipTotal {
+		if err := query.Count(&total).Error; err != nil {
+			logger.Errorf(ctx, "ListDeviceEvents count fail: %+v", err)
+			return nil, model.PageCount{}, code.QueryRecordErr.WithErr(err)
+		}
 	}
 
+	if len(params.Columns) > 0 {
+		query = query.Select(params.Columns)
+	}
 	offset := (params.Page - 1) * params.PageSize
-	if err := query.Order("timestamp DESC").Offset(offset).Limit(params.PageSize).Find(&events).Error; err != nil {
+	if err := query.Order("timestamp DESC").Offset(offset).Limit(pageLimit(params)).Find(&events).Error; err != nil {
 		logger.Errorf(ctx, "ListDeviceEvents find fail: %+v", err)
-		return nil, 0, code.QueryRecordErr.WithErr(err)
+		return nil, model.PageCount{}, code.QueryRecordErr.WithErr(err)
 	}
 
-	return events, total, nil
+	count, keep := model.NewPageCount(params, len(events), total)
+	return events[:keep], count, nil
 }
 
 func (h *historyImpl) applyDeviceEventFilters(query *gorm.DB, params *model.HistoryQueryParams) *gorm.DB {
@@ -265,6 +790,9 @@ func (h *historyImpl) applyDeviceEventFilters(query *gorm.DB, params *model.Hist
 	if params.DeviceID != nil {
 		query = query.Where("device_id = ?", *params.DeviceID)
 	}
+	if params.DeviceIDs != nil {
+		query = query.Where("device_id IN ?", params.DeviceIDs)
+	}
 	if params.EventType != nil {
 		query = query.Where("event_type = ?", *params.EventType)
 	}
@@ -278,30 +806,61 @@ func (h *historyImpl) applyDeviceEventFilters(query *gorm.DB, params *model.Hist
 }
 
 // GetLabStats retrieves aggregated statistics for a lab
-func (h *historyImpl) GetLabStats(ctx context.Context, labID int64, startTime, endTime *time.Time) (*model.HistoryStats, error) {
+func (h *historyImpl) GetLabStats(ctx context.Context, labID int64, startTime, endTime *time.Time, deviceIDs []int64) (*model.HistoryStats, error) {
 	stats := &model.HistoryStats{}
-
-	// Workflow execution stats
-	wfQuery := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).Where("lab_id = ?", labID)
-	if startTime != nil {
-		wfQuery = wfQuery.Where("started_at >= ?", *startTime)
+	// 只统计有动作在这些设备上运行的执行
+	byDevice := func(query *gorm.DB) *gorm.DB {
+		if deviceIDs == nil {
+			return query
+		}
+		return query.Where("id IN (SELECT workflow_execution_id FROM action_execution_history WHERE device_id IN ?)", deviceIDs)
 	}
-	if endTime != nil {
-		wfQuery = wfQuery.Where("started_at <= ?", *endTime)
+
+	// Workflow execution stats，每次计数使用新的查询，避免状态条件叠加
+	wfQuery := func() *gorm.DB {
+		query := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).Where("lab_id = ?", labID)
+		if startTime != nil {
+			query = query.Where("started_at >= ?", *startTime)
+		}
+		if endTime != nil {
+			query = query.Where("started_at <= ?", *endTime)
+		}
+		return byDevice(query)
 	}
 
-	wfQuery.Count(&stats.TotalExecutions)
-	wfQuery.Where("status = ?", model.ExecutionStatusSuccess).Count(&stats.SuccessfulCount)
-	wfQuery.Where("status = ?", model.ExecutionStatusFailed).Count(&stats.FailedCount)
+	wfQuery().Count(&stats.TotalExecutions)
+	wfQuery().Where("status = ?", model.ExecutionStatusSuccess).Count(&stats.SuccessfulCount)
+	wfQuery().Where("status = ?", model.ExecutionStatusFailed).Count(&stats.FailedCount)
 
 	if stats.TotalExecutions > 0 {
 		stats.SuccessRate = float64(stats.SuccessfulCount) / float64(stats.TotalExecutions) * 100
 	}
 
+	// SLA compliance，只统计设置了 SLA 且已结束的执行
+	var sla struct {
+		Tracked  int64
+		Breached int64
+	}
+	slaQuery := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("lab_id = ? AND sla_breached IS NOT NULL", labID)
+	if startTime != nil {
+		slaQuery = slaQuery.Where("started_at >= ?", *startTime)
+	}
+	if endTime != nil {
+		slaQuery = slaQuery.Where("started_at <= ?", *endTime)
+	}
+	slaQuery = byDevice(slaQuery)
+	slaQuery.Select("COUNT(*) AS tracked, COUNT(*) FILTER (WHERE sla_breached) AS breached").Scan(&sla)
+	stats.SLATracked = sla.Tracked
+	stats.SLABreachedCount = sla.Breached
+	if stats.SLATracked > 0 {
+		stats.SLACompliance = float64(stats.SLATracked-stats.SLABreachedCount) / float64(stats.SLATracked) * 100
+	}
+
 	// Average duration
 	var avgDuration struct{ Avg float64 }
-	h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
-		Where("lab_id = ? AND duration_ms > 0", labID).
+	byDevice(h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("lab_id = ? AND duration_ms > 0", labID)).
 		Select("AVG(duration_ms) as avg").Scan(&avgDuration)
 	stats.AverageDurationMs = avgDuration.Avg
 
@@ -313,35 +872,574 @@ func (h *historyImpl) GetLabStats(ctx context.Context, labID int64, startTime, e
 	if endTime != nil {
 		actionQuery = actionQuery.Where("created_at <= ?", *endTime)
 	}
+	if deviceIDs != nil {
+		actionQuery = actionQuery.Where("device_id IN ?", deviceIDs)
+	}
 	actionQuery.Count(&stats.TotalActionsCount)
 
 	// Device event count
-	eventQuery := h.DBWithContext(ctx).Model(&model.DeviceEventHistory{}).Where("lab_id = ?", labID)
+	eventQuery := h.events.ReadDB(ctx).Model(&model.DeviceEventHistory{}).Where("lab_id = ?", labID)
 	if startTime != nil {
 		eventQuery = eventQuery.Where("timestamp >= ?", *startTime)
 	}
 	if endTime != nil {
 		eventQuery = eventQuery.Where("timestamp <= ?", *endTime)
 	}
+	if deviceIDs != nil {
+		eventQuery = eventQuery.Where("device_id IN ?", deviceIDs)
+	}
 	eventQuery.Count(&stats.TotalDeviceEvents)
 
+	// Material usage count
+	usageQuery := func() *gorm.DB {
+		query := h.DBWithContext(ctx).Model(&model.MaterialUsage{}).Where("lab_id = ?", labID)
+		if startTime != nil {
+			query = query.Where("created_at >= ?", *startTime)
+		}
+		if endTime != nil {
+			query = query.Where("created_at <= ?", *endTime)
+		}
+		if deviceIDs != nil {
+			query = query.Where("action_execution_id IN (SELECT id FROM action_execution_history WHERE device_id IN ?)", deviceIDs)
+		}
+		return query
+	}
+	usageQuery().Count(&stats.MaterialUsages)
+	usageQuery().Distinct("material_id").Count(&stats.MaterialsConsumed)
+
+	return stats, nil
+}
+
+// ListOrgLabs lists labs created within an organization
+func (h *historyImpl) ListOrgLabs(ctx context.Context, orgID string) ([]*model.Laboratory, error) {
+	var labs []*model.Laboratory
+	if err := h.DBWithContext(ctx).
+		Select("id", "uuid", "name").
+		Where("org_id = ? AND status <> ?", orgID, model.DELETED).
+		Order("id ASC").
+		Find(&labs).Error; err != nil {
+		logger.Errorf(ctx, "ListOrgLabs fail org=%s: %+v", orgID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return labs, nil
+}
+
+// GetOrgStats retrieves aggregated statistics across labs
+func (h *historyImpl) GetOrgStats(ctx context.Context, labs []*model.Laboratory, startTime, endTime time.Time, topN int) (*model.OrgStats, error) {
+	labIDs := make([]int64, 0, len(labs))
+	for _, lab := range labs {
+		labIDs = append(labIDs, lab.ID)
+	}
+
+	current, err := h.labBreakdown(ctx, labs, labIDs, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := h.labBreakdown(ctx, labs, labIDs, startTime.Add(-endTime.Sub(startTime)), startTime)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &model.OrgStats{
+		StartTime:    startTime,
+		EndTime:      endTime,
+		LabCount:     len(labs),
+		Current:      model.SumLabStats(current),
+		Previous:     model.SumLabStats(previous),
+		Labs:         current,
+		TopWorkflows: []*model.WorkflowRank{},
+		TopDevices:   []*model.DeviceRank{},
+	}
+	stats.Trend = model.NewStatsTrend(stats.Current, stats.Previous)
+	if len(labIDs) == 0 {
+		return stats, nil
+	}
+
+	if err := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Select(`lab_id, workflow_uuid, MAX(workflow_name) AS workflow_name,
+			COUNT(*) AS executions,
+			COUNT(*) FILTER (WHERE status = ?) AS successful_count`, model.ExecutionStatusSuccess).
+		Where("lab_id IN ? AND started_at >= ? AND started_at < ?", labIDs, startTime, endTime).
+		Group("lab_id, workflow_uuid").
+		Order("executions DESC").
+		Limit(topN).
+		Scan(&stats.TopWorkflows).Error; err != nil {
+		logger.Errorf(ctx, "GetOrgStats top workflows fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	if err := h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select("lab_id, device_name, COUNT(*) AS executions, COALESCE(SUM(duration_ms), 0) AS total_duration_ms").
+		Where("lab_id IN ? AND created_at >= ? AND created_at < ?", labIDs, startTime, endTime).
+		Group("lab_id, device_name").
+		Order("executions DESC").
+		Limit(topN).
+		Scan(&stats.TopDevices).Error; err != nil {
+		logger.Errorf(ctx, "GetOrgStats top devices fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return stats, nil
+}
+
+// labBreakdown computes per-lab statistics, labs without activity included
+func (h *historyImpl) labBreakdown(ctx context.Context, labs []*model.Laboratory, labIDs []int64, startTime, endTime time.Time) ([]*model.LabStats, error) {
+	result := make([]*model.LabStats, 0, len(labs))
+	byLab := make(map[int64]*model.LabStats, len(labs))
+	for _, lab := range labs {
+		l := &model.LabStats{LabID: lab.ID, LabUUID: lab.UUID, LabName: lab.Name}
+		byLab[lab.ID] = l
+		result = append(result, l)
+	}
+	if len(labIDs) == 0 {
+		return result, nil
+	}
+
+	var wfRows []*model.LabStats
+	if err := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Select(`lab_id,
+			COUNT(*) AS total_executions,
+			COUNT(*) FILTER (WHERE status = ?) AS successful_count,
+			COUNT(*) FILTER (WHERE status = ?) AS failed_count,
+			COALESCE(AVG(duration_ms) FILTER (WHERE duration_ms > 0), 0) AS average_duration_ms,
+			COUNT(*) FILTER (WHERE duration_ms > 0) AS duration_count`,
+			model.ExecutionStatusSuccess, model.ExecutionStatusFailed).
+		Where("lab_id IN ? AND started_at >= ? AND started_at < ?", labIDs, startTime, endTime).
+		Group("lab_id").
+		Scan(&wfRows).Error; err != nil {
+		logger.Errorf(ctx, "labBreakdown workflow fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	for _, row := range wfRows {
+		if l, ok := byLab[row.LabID]; ok {
+			l.TotalExecutions = row.TotalExecutions
+			l.SuccessfulCount = row.SuccessfulCount
+			l.FailedCount = row.FailedCount
+			l.AverageDurationMs = row.AverageDurationMs
+			l.DurationCount = row.DurationCount
+			if l.TotalExecutions > 0 {
+				l.SuccessRate = float64(l.SuccessfulCount) / float64(l.TotalExecutions) * 100
+			}
+		}
+	}
+
+	counts := []struct {
+		db     func(ctx context.Context) *gorm.DB
+		model  any
+		column string
+		assign func(l *model.LabStats, count int64)
+	}{
+		{h.DBWithContext, &model.ActionExecutionHistory{}, "created_at", func(l *model.LabStats, count int64) { l.TotalActionsCount = count }},
+		{h.events.ReadDB, &model.DeviceEventHistory{}, "timestamp", func(l *model.LabStats, count int64) { l.TotalDeviceEvents = count }},
+	}
+	for _, c := range counts {
+		var rows []struct {
+			LabID int64
+			Count int64
+		}
+		if err := c.db(ctx).Model(c.model).
+			Select("lab_id, COUNT(*) AS count").
+			Where("lab_id IN ? AND "+c.column+" >= ? AND "+c.column+" < ?", labIDs, startTime, endTime).
+			Group("lab_id").
+			Scan(&rows).Error; err != nil {
+			logger.Errorf(ctx, "labBreakdown count fail: %+v", err)
+			return nil, code.QueryRecordErr.WithErr(err)
+		}
+		for _, row := range rows {
+			if l, ok := byLab[row.LabID]; ok {
+				c.assign(l, row.Count)
+			}
+		}
+	}
+	return result, nil
+}
+
+// ListSlowestSteps aggregates finished action durations per step, slowest first
+func (h *historyImpl) ListSlowestSteps(ctx context.Context, labID int64, startTime, endTime *time.Time, limit int) ([]*model.StepDurationStats, error) {
+	query := h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select(`action_name, device_name,
+			COUNT(*) AS executions,
+			AVG(duration_ms) AS avg_duration_ms,
+			MAX(duration_ms) AS max_duration_ms,
+			MAX(expected_ms) AS expected_ms,
+			COUNT(*) FILTER (WHERE expected_ms > 0 AND duration_ms > expected_ms) AS over_budget_count,
+			COALESCE(AVG(duration_ms - expected_ms) FILTER (WHERE expected_ms > 0), 0) AS avg_deviation_ms`).
+		Where("lab_id = ? AND duration_ms > 0", labID)
+	if startTime != nil {
+		query = query.Where("created_at >= ?", *startTime)
+	}
+	if endTime != nil {
+		query = query.Where("created_at <= ?", *endTime)
+	}
+
+	var stats []*model.StepDurationStats
+	if err := query.Group("action_name, device_name").
+		Order("avg_duration_ms DESC").
+		Limit(limit).
+		Scan(&stats).Error; err != nil {
+		logger.Errorf(ctx, "ListSlowestSteps fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return stats, nil
+}
+
+// ActivityHeatmap aggregates execution starts by weekday and hour
+func (h *historyImpl) ActivityHeatmap(ctx context.Context, labID int64, startTime, endTime time.Time, timezone string) ([]*model.HeatmapCell, error) {
+	if db.IsSQLite(h.DBWithContext(ctx)) {
+		return h.activityHeatmapSQLite(ctx, labID, startTime, endTime, timezone)
+	}
+
+	var cells []*model.HeatmapCell
+	if err := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Select(`EXTRACT(DOW FROM started_at AT TIME ZONE ?)::int AS weekday,
+			EXTRACT(HOUR FROM started_at AT TIME ZONE ?)::int AS hour,
+			COUNT(*) AS count`, timezone, timezone).
+		Where("lab_id = ? AND started_at >= ? AND started_at < ?", labID, startTime, endTime).
+		Group("weekday, hour").
+		Scan(&cells).Error; err != nil {
+		logger.Errorf(ctx, "ActivityHeatmap fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return cells, nil
+}
+
+// GetDeviceUtilization loads the busy time of the current and previous period
+// and the liveness events of the lab devices
+func (h *historyImpl) GetDeviceUtilization(ctx context.Context, labID int64, startTime, endTime time.Time) (*model.UtilizationReport, error) {
+	prevStart := startTime.Add(-endTime.Sub(startTime))
+	busyQuery := func(start, end time.Time) *gorm.DB {
+		return h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+			Where("lab_id = ? AND duration_ms > 0 AND created_at >= ? AND created_at < ?", labID, start, end)
+	}
+
+	var busy []*model.DeviceBusyTime
+	if err := busyQuery(startTime, endTime).
+		Select("device_id, device_uuid, MAX(device_name) AS device_name, COUNT(*) AS actions, SUM(duration_ms) AS busy_ms").
+		Group("device_id, device_uuid").
+		Scan(&busy).Error; err != nil {
+		logger.Errorf(ctx, "GetDeviceUtilization busy fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	var prevBusy []*model.DeviceBusyTime
+	if err := busyQuery(prevStart, startTime).
+		Select("device_id, SUM(duration_ms) AS busy_ms").
+		Group("device_id").
+		Scan(&prevBusy).Error; err != nil {
+		logger.Errorf(ctx, "GetDeviceUtilization previous busy fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	var buckets []struct {
+		Bucket int
+		BusyMs int64
+	}
+	bucket := "FLOOR(EXTRACT(EPOCH FROM created_at - ?) / ?)::int AS bucket, SUM(duration_ms) AS busy_ms"
+	if db.IsSQLite(h.DBWithContext(ctx)) {
+		bucket = "CAST((julianday(created_at) - julianday(?)) * 86400 / ? AS INTEGER) AS bucket, SUM(duration_ms) AS busy_ms"
+	}
+	if err := busyQuery(startTime, endTime).
+		Select(bucket, startTime, model.UtilizationBucket.Seconds()).
+		Group("bucket").
+		Scan(&buckets).Error; err != nil {
+		logger.Errorf(ctx, "GetDeviceUtilization trend fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	// 心跳记录覆盖所有接入过 edge 的设备，没有执行动作也参与排名
+	var tracked []*model.DeviceLiveness
+	if err := h.DBWithContext(ctx).Where("lab_id = ?", labID).
+		Select("device_id", "device_uuid", "device_name").
+		Find(&tracked).Error; err != nil {
+		logger.Errorf(ctx, "GetDeviceUtilization liveness fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	livenessTypes := []model.DeviceEventType{model.DeviceEventConnected, model.DeviceEventDisconnected}
+	var events []*model.DeviceEventHistory
+	initial := `SELECT * FROM (
+				SELECT DISTINCT ON (device_id) * FROM device_event_history
+				WHERE lab_id = ? AND event_type IN ? AND timestamp < ?
+				ORDER BY device_id, timestamp DESC
+			) AS initial`
+	if db.IsSQLite(h.events.ReadDB(ctx)) {
+		initial = `SELECT * FROM device_event_history WHERE id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY device_id ORDER BY timestamp DESC) AS rn
+					FROM device_event_history
+					WHERE lab_id = ? AND event_type IN ? AND timestamp < ?
+				) AS ranked WHERE rn = 1
+			)`
+	}
+	if err := h.events.ReadDB(ctx).
+		Raw(initial+`
+			UNION ALL
+			SELECT * FROM device_event_history
+			WHERE lab_id = ? AND event_type IN ? AND timestamp >= ? AND timestamp < ?
+			ORDER BY timestamp ASC, id ASC`,
+			labID, livenessTypes, prevStart,
+			labID, livenessTypes, prevStart, endTime).
+		Scan(&events).Error; err != nil {
+		logger.Errorf(ctx, "GetDeviceUtilization events fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	data := &model.UtilizationData{
+		Devices:      busy,
+		PreviousBusy: make(map[int64]int64, len(prevBusy)),
+		BucketBusy:   make(map[int]int64, len(buckets)),
+		Events:       make(map[int64][]*model.DeviceEventHistory),
+	}
+	for _, b := range prevBusy {
+		data.PreviousBusy[b.DeviceID] = b.BusyMs
+	}
+	for _, b := range buckets {
+		data.BucketBusy[b.Bucket] = b.BusyMs
+	}
+	for _, event := range events {
+		data.Events[event.DeviceID] = append(data.Events[event.DeviceID], event)
+	}
+	seen := utils.Slice2Map(busy, func(b *model.DeviceBusyTime) (int64, bool) {
+		return b.DeviceID, true
+	})
+	for _, device := range tracked {
+		if !seen[device.DeviceID] {
+			seen[device.DeviceID] = true
+			data.Devices = append(data.Devices, &model.DeviceBusyTime{
+				DeviceID:   device.DeviceID,
+				DeviceUUID: device.DeviceUUID,
+				DeviceName: device.DeviceName,
+			})
+		}
+	}
+
+	report := model.NewUtilizationReport(data, startTime, endTime, time.Now())
+	report.LabID = labID
+	return report, nil
+}
+
+// GetFailureStats groups failures by error category, executions by start time
+// and actions by creation time
+func (h *historyImpl) GetFailureStats(ctx context.Context, labID int64, startTime, endTime time.Time) (*model.FailureStats, error) {
+	category := fmt.Sprintf("COALESCE(NULLIF(error_category, ''), '%s') AS category", model.ErrorCategoryUnclassified)
+	var executions, actions []struct {
+		Category string
+		Count    int64
+	}
+	if err := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Select(category+", COUNT(*) AS count").
+		Where("lab_id = ? AND status IN ? AND started_at >= ? AND started_at < ?",
+			labID, model.FailedStatuses, startTime, endTime).
+		Group("1").
+		Scan(&executions).Error; err != nil {
+		logger.Errorf(ctx, "GetFailureStats executions fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	if err := h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select(category+", COUNT(*) AS count").
+		Where("lab_id = ? AND status IN ? AND created_at >= ? AND created_at < ?",
+			labID, model.FailedStatuses, startTime, endTime).
+		Group("1").
+		Scan(&actions).Error; err != nil {
+		logger.Errorf(ctx, "GetFailureStats actions fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	stats := &model.FailureStats{LabID: labID, StartTime: startTime, EndTime: endTime}
+	counts := make(map[string]*model.FailureCategoryCount)
+	get := func(category string) *model.FailureCategoryCount {
+		if counts[category] == nil {
+			counts[category] = &model.FailureCategoryCount{Category: category}
+			stats.Categories = append(stats.Categories, counts[category])
+		}
+		return counts[category]
+	}
+	for _, row := range executions {
+		get(row.Category).Executions += row.Count
+		stats.Executions += row.Count
+	}
+	for _, row := range actions {
+		get(row.Category).Actions += row.Count
+		stats.Actions += row.Count
+	}
+	stats.SortCategories()
 	return stats, nil
 }
 
-// CleanupOldRecords removes records older than the specified time
+// exportQuery selects the rows of a history list matching the filters
+func (h *historyImpl) exportQuery(ctx context.Context, kind model.HistoryExportKind, params *model.HistoryQueryParams) *gorm.DB {
+	switch kind {
+	case model.HistoryExportAction:
+		return h.applyActionFilters(h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}), params)
+	case model.HistoryExportDeviceEvent:
+		return h.applyDeviceEventFilters(h.events.ReadDB(ctx).Model(&model.DeviceEventHistory{}), params)
+	default:
+		return h.applyWorkflowFilters(h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}), params)
+	}
+}
+
+// ExportBounds counts the rows to export
+func (h *historyImpl) ExportBounds(ctx context.Context, kind model.HistoryExportKind, params *model.HistoryQueryParams) (int64, int64, error) {
+	var bounds struct {
+		Count int64
+		MaxID int64
+	}
+	if err := h.exportQuery(ctx, kind, params).
+		Select("COUNT(*) AS count, COALESCE(MAX(id), 0) AS max_id").
+		Scan(&bounds).Error; err != nil {
+		logger.Errorf(ctx, "ExportBounds fail kind=%s lab=%d: %+v", kind, params.LabID, err)
+		return 0, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return bounds.Count, bounds.MaxID, nil
+}
+
+// ScanWorkflowExecutions reads one keyset page of executions
+func (h *historyImpl) ScanWorkflowExecutions(ctx context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.WorkflowExecutionHistory, error) {
+	var executions []*model.WorkflowExecutionHistory
+	if err := h.exportQuery(ctx, model.HistoryExportWorkflow, params).
+		Where("id > ? AND id <= ?", afterID, maxID).
+		Order("id ASC").Limit(limit).Find(&executions).Error; err != nil {
+		logger.Errorf(ctx, "ScanWorkflowExecutions fail lab=%d: %+v", params.LabID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return executions, nil
+}
+
+// ScanActionExecutions reads one keyset page of actions
+func (h *historyImpl) ScanActionExecutions(ctx context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.ActionExecutionHistory, error) {
+	var executions []*model.ActionExecutionHistory
+	if err := h.exportQuery(ctx, model.HistoryExportAction, params).
+		Where("id > ? AND id <= ?", afterID, maxID).
+		Order("id ASC").Limit(limit).Find(&executions).Error; err != nil {
+		logger.Errorf(ctx, "ScanActionExecutions fail lab=%d: %+v", params.LabID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return executions, nil
+}
+
+// ScanDeviceEvents reads one keyset page of device events
+func (h *historyImpl) ScanDeviceEvents(ctx context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.DeviceEventHistory, error) {
+	var events []*model.DeviceEventHistory
+	if err := h.exportQuery(ctx, model.HistoryExportDeviceEvent, params).
+		Where("id > ? AND id <= ?", afterID, maxID).
+		Order("id ASC").Limit(limit).Find(&events).Error; err != nil {
+		logger.Errorf(ctx, "ScanDeviceEvents fail lab=%d: %+v", params.LabID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return events, nil
+}
+
+// StreamWorkflowExecutions iterates the rows of the execution list query
+func (h *historyImpl) StreamWorkflowExecutions(ctx context.Context, params *model.HistoryQueryParams, limit int, fn func(*model.WorkflowExecutionHistory) error) error {
+	if err := checkFilters(params); err != nil {
+		return err
+	}
+	query := h.applyWorkflowFilters(h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}), params)
+	if len(params.Columns) > 0 {
+		query = query.Select(params.Columns)
+	}
+	query = query.Order("started_at DESC, id DESC").Limit(limit)
+	return streamRows(ctx, "StreamWorkflowExecutions", query, fn)
+}
+
+// StreamDeviceEvents iterates the rows of the device event list query
+func (h *historyImpl) StreamDeviceEvents(ctx context.Context, params *model.HistoryQueryParams, limit int, fn func(*model.DeviceEventHistory) error) error {
+	if err := checkFilters(params); err != nil {
+		return err
+	}
+	query := h.applyDeviceEventFilters(h.events.ReadDB(ctx).Model(&model.DeviceEventHistory{}), params)
+	if len(params.Columns) > 0 {
+		query = query.Select(params.Columns)
+	}
+	query = query.Order("timestamp DESC").Limit(limit)
+	return streamRows(ctx, "StreamDeviceEvents", query, fn)
+}
+
+// streamRows scans the rows of the query one at a time, keeping only the
+// current row in memory. Errors of fn are returned as they are
+func streamRows[T any](ctx context.Context, name string, query *gorm.DB, fn func(*T) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		logger.Errorf(ctx, "%s query fail: %+v", name, err)
+		return code.QueryRecordErr.WithErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := new(T)
+		if err := query.ScanRows(rows, row); err != nil {
+			logger.Errorf(ctx, "%s scan fail: %+v", name, err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger.Errorf(ctx, "%s rows fail: %+v", name, err)
+		return code.QueryRecordErr.WithErr(err)
+	}
+	return nil
+}
+
+// LabPinnedExecutionIDs returns the pinned executions of the user in the lab
+func (h *historyImpl) LabPinnedExecutionIDs(ctx context.Context, userID string, labID int64) (map[int64]bool, error) {
+	pinned := make(map[int64]bool)
+	if userID == "" {
+		return pinned, nil
+	}
+
+	var ids []int64
+	if err := h.DBWithContext(ctx).Model(&model.ExecutionPin{}).
+		Where("user_id = ? AND lab_id = ?", userID, labID).
+		Pluck("execution_id", &ids).Error; err != nil {
+		logger.Errorf(ctx, "LabPinnedExecutionIDs fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	for _, id := range ids {
+		pinned[id] = true
+	}
+	return pinned, nil
+}
+
+// CleanupOldRecords removes records older than the specified time, keeping
+// the records under an active legal hold
 func (h *historyImpl) CleanupOldRecords(ctx context.Context, before time.Time) (int64, error) {
 	var totalDeleted int64
 
+	holds, err := h.holds.ListActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+	expired := func() *gorm.DB {
+		query := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).Where("started_at < ?", before)
+		return legalhold.Exclude(query, holds, executionHoldColumns)
+	}
+
+	// Mark sealed executions as pruned so that chain verification skips their content
+	if err := h.DBWithContext(ctx).Model(&model.HistoryChainEntry{}).
+		Where("record_type = ? AND pruned_at IS NULL", model.ChainWorkflowExecution).
+		Where("record_id IN (?)", expired().Select("id")).
+		Update("pruned_at", time.Now()).Error; err != nil {
+		logger.Errorf(ctx, "CleanupOldRecords mark chain pruned fail: %+v", err)
+		return 0, code.UpdateDataErr.WithErr(err)
+	}
+
 	// Cleanup workflow executions
-	result := h.DBWithContext(ctx).Where("started_at < ?", before).Delete(&model.WorkflowExecutionHistory{})
+	result := expired().Delete(&model.WorkflowExecutionHistory{})
 	if result.Error != nil {
 		logger.Errorf(ctx, "CleanupOldRecords workflow fail: %+v", result.Error)
 		return 0, code.DeleteDataErr.WithErr(result.Error)
 	}
 	totalDeleted += result.RowsAffected
 
-	// Cleanup action executions
-	result = h.DBWithContext(ctx).Where("created_at < ?", before).Delete(&model.ActionExecutionHistory{})
+	// Cleanup action executions, keeping the actions of held executions
+	query := h.DBWithContext(ctx).Where("created_at < ?", before)
+	query = legalhold.Exclude(query, holds, legalhold.Columns{LabID: "lab_id", Time: "created_at"})
+	if cond, args := legalhold.Condition(holds, executionHoldColumns); cond != "" {
+		query = query.Where("workflow_execution_id IS NULL OR workflow_execution_id NOT IN (?)",
+			h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).Select("id").Where(cond, args...))
+	}
+	result = query.Delete(&model.ActionExecutionHistory{})
 	if result.Error != nil {
 		logger.Errorf(ctx, "CleanupOldRecords action fail: %+v", result.Error)
 		return totalDeleted, code.DeleteDataErr.WithErr(result.Error)
@@ -349,13 +1447,75 @@ func (h *historyImpl) CleanupOldRecords(ctx context.Context, before time.Time) (
 	totalDeleted += result.RowsAffected
 
 	// Cleanup device events
-	result = h.DBWithContext(ctx).Where("timestamp < ?", before).Delete(&model.DeviceEventHistory{})
-	if result.Error != nil {
-		logger.Errorf(ctx, "CleanupOldRecords device fail: %+v", result.Error)
-		return totalDeleted, code.DeleteDataErr.WithErr(result.Error)
+	count, err := h.events.DeleteBefore(ctx, before, nil, holds)
+	if err != nil {
+		return totalDeleted, err
 	}
-	totalDeleted += result.RowsAffected
+	totalDeleted += count
 
 	return totalDeleted, nil
 }
 
+// ListActiveExecutions lists the pending and running executions of a lab
+func (h *historyImpl) ListActiveExecutions(ctx context.Context, labID int64, limit int) ([]*model.WorkflowExecutionHistory, error) {
+	var executions []*model.WorkflowExecutionHistory
+	if err := h.DBWithContext(ctx).
+		Where("lab_id = ? AND status IN ?", labID, []model.ExecutionStatus{model.ExecutionStatusRunning, model.ExecutionStatusPending}).
+		Order("started_at ASC, id ASC").
+		Limit(limit).
+		Find(&executions).Error; err != nil {
+		logger.Errorf(ctx, "ListActiveExecutions fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return executions, nil
+}
+
+// ListDurationSamples returns recent successful durations by workflow
+func (h *historyImpl) ListDurationSamples(ctx context.Context, workflowIDs []int64, since time.Time, limit int) (map[int64][]int64, error) {
+	samples := make(map[int64][]int64, len(workflowIDs))
+	if len(workflowIDs) == 0 {
+		return samples, nil
+	}
+
+	var rows []struct {
+		WorkflowID int64
+		DurationMs int64
+	}
+	if err := h.DBWithContext(ctx).Raw(`SELECT workflow_id, duration_ms FROM (
+			SELECT workflow_id, duration_ms,
+				ROW_NUMBER() OVER (PARTITION BY workflow_id ORDER BY started_at DESC) AS rn
+			FROM workflow_execution_history
+			WHERE workflow_id IN ? AND status = ? AND duration_ms > 0 AND started_at >= ?
+		) t WHERE rn <= ?`, workflowIDs, model.ExecutionStatusSuccess, since, limit).
+		Scan(&rows).Error; err != nil {
+		logger.Errorf(ctx, "ListDurationSamples fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	for _, row := range rows {
+		samples[row.WorkflowID] = append(samples[row.WorkflowID], row.DurationMs)
+	}
+	return samples, nil
+}
+
+// ListStepDurationPercentiles returns the step duration percentiles of a lab
+func (h *historyImpl) ListStepDurationPercentiles(ctx context.Context, labID int64, since time.Time) ([]*model.StepDurationPercentiles, error) {
+	if db.IsSQLite(h.DBWithContext(ctx)) {
+		return h.stepDurationPercentilesSQLite(ctx, labID, since)
+	}
+
+	var stats []*model.StepDurationPercentiles
+	if err := h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select(`device_name,
+			CASE WHEN GROUPING(action_name) = 1 THEN '' ELSE action_name END AS action_name,
+			COUNT(*) AS samples,
+			percentile_cont(0.1) WITHIN GROUP (ORDER BY duration_ms) AS p10_ms,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY duration_ms) AS p50_ms,
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY duration_ms) AS p90_ms`).
+		Where("lab_id = ? AND status = ? AND duration_ms > 0 AND created_at >= ?", labID, model.ExecutionStatusSuccess, since).
+		Group("GROUPING SETS ((device_name, action_name), (device_name))").
+		Scan(&stats).Error; err != nil {
+		logger.Errorf(ctx, "ListStepDurationPercentiles fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// sqlite 没有时区转换和 percentile_cont，本地开发模式下读取明细在内存中聚合
+
+// activityHeatmapSQLite aggregates execution starts by weekday and hour in the timezone
+func (h *historyImpl) activityHeatmapSQLite(ctx context.Context, labID int64, startTime, endTime time.Time, timezone string) ([]*model.HeatmapCell, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, code.ParamErr.WithErr(err)
+	}
+
+	var startedAts []time.Time
+	if err := h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).
+		Where("lab_id = ? AND started_at >= ? AND started_at < ?", labID, startTime, endTime).
+		Pluck("started_at", &startedAts).Error; err != nil {
+		logger.Errorf(ctx, "ActivityHeatmap fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	counts := make(map[[2]int]*model.HeatmapCell)
+	cells := make([]*model.HeatmapCell, 0)
+	for _, t := range startedAts {
+		t = t.In(loc)
+		key := [2]int{int(t.Weekday()), t.Hour()}
+		if counts[key] == nil {
+			counts[key] = &model.HeatmapCell{Weekday: key[0], Hour: key[1]}
+			cells = append(cells, counts[key])
+		}
+		counts[key].Count++
+	}
+	return cells, nil
+}
+
+// stepDurationPercentilesSQLite computes the percentiles per step and per device
+// with the interpolation of percentile_cont
+func (h *historyImpl) stepDurationPercentilesSQLite(ctx context.Context, labID int64, since time.Time) ([]*model.StepDurationPercentiles, error) {
+	var rows []struct {
+		DeviceName string
+		ActionName string
+		DurationMs int64
+	}
+	if err := h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Select("device_name, action_name, duration_ms").
+		Where("lab_id = ? AND status = ? AND duration_ms > 0 AND created_at >= ?", labID, model.ExecutionStatusSuccess, since).
+		Scan(&rows).Error; err != nil {
+		logger.Errorf(ctx, "ListStepDurationPercentiles fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	// 与 GROUPING SETS ((device_name, action_name), (device_name)) 一致，设备汇总行的 action_name 为空
+	groups := make(map[[2]string][]float64)
+	for _, row := range rows {
+		for _, key := range [][2]string{{row.DeviceName, row.ActionName}, {row.DeviceName, ""}} {
+			groups[key] = append(groups[key], float64(row.DurationMs))
+		}
+	}
+
+	stats := make([]*model.StepDurationPercentiles, 0, len(groups))
+	for key, durations := range groups {
+		sort.Float64s(durations)
+		stats = append(stats, &model.StepDurationPercentiles{
+			DeviceName: key[0],
+			ActionName: key[1],
+			Samples:    int64(len(durations)),
+			P10Ms:      percentileCont(durations, 0.1),
+			P50Ms:      percentileCont(durations, 0.5),
+			P90Ms:      percentileCont(durations, 0.9),
+		})
+	}
+	return stats, nil
+}
+
+// percentileCont interpolates linearly between the sorted values like percentile_cont
+func percentileCont(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (pos-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
@@ -0,0 +1,528 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+// newSQLiteRepo migrates a fresh in-memory sqlite database and returns a history repo on it
+func newSQLiteRepo(t *testing.T) HistoryRepo {
+	t.Helper()
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+	// 再次迁移不应修改已有的表
+	require.NoError(t, migrate.Table(ctx))
+	return New()
+}
+
+func sqliteExecution(labID int64, status model.ExecutionStatus, startedAt time.Time, labels map[string]string) *model.WorkflowExecutionHistory {
+	return &model.WorkflowExecutionHistory{
+		LabID:        labID,
+		UserID:       "user-1",
+		WorkflowID:   1,
+		WorkflowUUID: uuid.NewV4(),
+		Status:       status,
+		DurationMs:   60000,
+		StartedAt:    startedAt,
+		Labels:       datatypes.NewJSONType(labels),
+	}
+}
+
+func TestSQLiteWorkflowExecutions(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+
+	shanghai := time.FixedZone("CST", 8*3600)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	execs := []*model.WorkflowExecutionHistory{
+		sqliteExecution(1, model.ExecutionStatusSuccess, base, map[string]string{"project": "a"}),
+		sqliteExecution(1, model.ExecutionStatusFailed, base.Add(time.Hour).In(shanghai), map[string]string{"project": "b", "run": "1"}),
+		sqliteExecution(1, model.ExecutionStatusSuccess, base.Add(2*time.Hour), map[string]string{"project": "a"}),
+		sqliteExecution(2, model.ExecutionStatusSuccess, base, nil),
+	}
+	for _, exec := range execs {
+		require.NoError(t, h.CreateWorkflowExecution(ctx, exec))
+		assert.NotZero(t, exec.ID)
+		// 新记录默认使用按时间排序的 v7
+		assert.EqualValues(t, 7, exec.UUID.Version())
+	}
+	got, err := h.GetWorkflowExecutionByUUID(ctx, execs[0].UUID)
+	require.NoError(t, err)
+	assert.Equal(t, execs[0].ID, got.ID)
+	assert.Equal(t, "a", got.Labels.Data()["project"])
+
+	params := model.NewHistoryQueryParams()
+	params.LabID = 1
+	list, count, err := h.ListWorkflowExecutions(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count.Total)
+	require.Len(t, list, 3)
+	assert.Equal(t, []int64{execs[2].ID, execs[1].ID, execs[0].ID}, []int64{list[0].ID, list[1].ID, list[2].ID})
+
+	// 不同时区写入的时间按同一时刻比较
+	start := base.Add(30 * time.Minute).In(shanghai)
+	end := base.Add(90 * time.Minute)
+	params.StartTime, params.EndTime = &start, &end
+	list, _, err = h.ListWorkflowExecutions(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, execs[1].ID, list[0].ID)
+
+	params = model.NewHistoryQueryParams()
+	params.LabID = 1
+	params.Labels = map[string]string{"project": "a"}
+	_, count, err = h.ListWorkflowExecutions(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count.Total)
+
+	// 稀疏字段集只查询需要的列，总数不受影响
+	params = model.NewHistoryQueryParams()
+	params.LabID = 1
+	params.Columns = []string{"id", "started_at", "status"}
+	list, count, err = h.ListWorkflowExecutions(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count.Total)
+	require.Len(t, list, 3)
+	assert.Equal(t, execs[2].ID, list[0].ID)
+	assert.Equal(t, model.ExecutionStatusSuccess, list[0].Status)
+	assert.Empty(t, list[0].WorkflowName)
+	assert.Nil(t, list[0].Result)
+
+	// 流式读取按列表顺序逐行返回，忽略分页
+	params.PageSize = 1
+	var streamed []int64
+	require.NoError(t, h.StreamWorkflowExecutions(ctx, params, 2, func(e *model.WorkflowExecutionHistory) error {
+		streamed = append(streamed, e.ID)
+		assert.Empty(t, e.WorkflowName)
+		return nil
+	}))
+	assert.Equal(t, []int64{execs[2].ID, execs[1].ID}, streamed)
+	stop := errors.New("stop")
+	streamed = nil
+	assert.Equal(t, stop, h.StreamWorkflowExecutions(ctx, params, 3, func(e *model.WorkflowExecutionHistory) error {
+		streamed = append(streamed, e.ID)
+		return stop
+	}))
+	assert.Len(t, streamed, 1)
+
+	keys, err := h.ListLabelKeys(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, model.LabelCount{Value: "project", Count: 3}, *keys[0])
+	values, err := h.ListLabelValues(ctx, 1, "project", 10)
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+	assert.Equal(t, model.LabelCount{Value: "a", Count: 2}, *values[0])
+
+	require.NoError(t, h.UpdateWorkflowExecution(ctx, execs[2].ID, map[string]interface{}{"status": model.ExecutionStatusFailed}))
+	stats, err := h.GetLabStats(ctx, 1, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.TotalExecutions)
+	assert.Equal(t, int64(2), stats.FailedCount)
+
+	require.NoError(t, h.PinExecution(ctx, &model.ExecutionPin{UserID: "u", ExecutionID: execs[0].ID, LabID: 1}))
+	require.NoError(t, h.PinExecution(ctx, &model.ExecutionPin{UserID: "u", ExecutionID: execs[0].ID, LabID: 1}))
+	pinned, err := h.PinnedExecutionIDs(ctx, "u", []int64{execs[0].ID, execs[1].ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]bool{execs[0].ID: true}, pinned)
+	pinned, err = h.LabPinnedExecutionIDs(ctx, "u", 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]bool{execs[0].ID: true}, pinned)
+}
+
+func TestSQLiteActionsAndEvents(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+	base := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC) // 周一
+
+	exec := sqliteExecution(1, model.ExecutionStatusSuccess, base, nil)
+	require.NoError(t, h.CreateWorkflowExecution(ctx, exec))
+	deviceUUID := uuid.NewV4()
+	actions := make([]*model.ActionExecutionHistory, 0, 4)
+	for i, d := range []int64{100, 200, 300, 400} {
+		actions = append(actions, &model.ActionExecutionHistory{
+			BaseModel:           model.BaseModel{CreatedAt: base.Add(time.Duration(i) * time.Minute)},
+			WorkflowExecutionID: &exec.ID,
+			LabID:               1,
+			DeviceID:            7,
+			DeviceUUID:          deviceUUID,
+			DeviceName:          "pump",
+			ActionType:          "transfer",
+			ActionName:          "transfer",
+			Status:              model.ExecutionStatusSuccess,
+			DurationMs:          d,
+		})
+	}
+	require.NoError(t, h.CreateActionExecutionBatch(ctx, actions))
+
+	firstTwo, err := h.ListActionsByWorkflowExecutions(ctx, []int64{exec.ID}, 2)
+	require.NoError(t, err)
+	assert.Len(t, firstTwo, 2)
+
+	percentiles, err := h.ListStepDurationPercentiles(ctx, 1, base.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, percentiles, 2)
+	for _, p := range percentiles {
+		assert.Equal(t, int64(4), p.Samples)
+		assert.InDelta(t, 250, p.P50Ms, 0.001)
+		assert.InDelta(t, 130, p.P10Ms, 0.001)
+	}
+
+	cells, err := h.ActivityHeatmap(ctx, 1, base.Add(-time.Hour), base.Add(time.Hour), "Asia/Shanghai")
+	require.NoError(t, err)
+	require.Len(t, cells, 1)
+	assert.Equal(t, model.HeatmapCell{Weekday: 1, Hour: 17, Count: 1}, *cells[0])
+
+	count, err := h.IngestDeviceEvents(ctx, []*model.DeviceEventHistory{
+		{LabID: 1, DeviceID: 7, DeviceUUID: uuid.NewV4(), EventType: model.DeviceEventConnected, Timestamp: base.Add(-2 * time.Hour)},
+		{LabID: 1, DeviceID: 7, DeviceUUID: uuid.NewV4(), EventType: model.DeviceEventError, Timestamp: base},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// 创建时间由 BeforeCreate 设置为当前时间
+	now := time.Now()
+	report, err := h.GetDeviceUtilization(ctx, 1, now.Add(-time.Hour), now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, report.Devices, 1)
+	assert.Equal(t, int64(4), report.Devices[0].Actions)
+	assert.Equal(t, int64(1000), report.Devices[0].BusyMs)
+
+	deleted, err := h.CleanupOldRecords(ctx, base.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestSQLiteWorkflowExecutionCursor(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	seen := make(map[int64]int)
+	for i := range 7 {
+		// 每两个执行的开始时间相同，按 id 区分先后
+		require.NoError(t, h.CreateWorkflowExecution(ctx, sqliteExecution(1, model.ExecutionStatusSuccess, base.Add(time.Duration(i/2)*time.Minute), nil)))
+	}
+
+	params := model.NewHistoryQueryParams()
+	params.LabID, params.PageSize = 1, 3
+	list, count, err := h.ListWorkflowExecutions(ctx, params)
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, count.Total)
+	require.NotNil(t, count.Next)
+	for _, e := range list {
+		seen[e.ID]++
+	}
+
+	for pages := 1; count.Next != nil; pages++ {
+		require.Less(t, pages, 5)
+		// 翻页期间写入更新和更早开始的执行
+		require.NoError(t, h.CreateWorkflowExecution(ctx, sqliteExecution(1, model.ExecutionStatusRunning, base.Add(time.Hour), nil)))
+		require.NoError(t, h.CreateWorkflowExecution(ctx, sqliteExecution(1, model.ExecutionStatusRunning, base.Add(-time.Hour), nil)))
+
+		cursor, err := model.ParseHistoryCursor(count.Next.Encode())
+		require.NoError(t, err)
+		params := model.NewHistoryQueryParams()
+		params.LabID, params.PageSize, params.Cursor = 1, 3, cursor
+		list, count, err = h.ListWorkflowExecutions(ctx, params)
+		require.NoError(t, err)
+		assert.EqualValues(t, -1, count.Total)
+		for _, e := range list {
+			assert.Equal(t, model.ExecutionStatusSuccess, e.Status)
+			seen[e.ID]++
+		}
+	}
+	assert.Len(t, seen, 7)
+	for id, n := range seen {
+		assert.Equal(t, 1, n, id)
+	}
+
+	_, err = model.ParseHistoryCursor("not-a-cursor")
+	assert.ErrorIs(t, err, model.ErrInvalidCursor)
+}
+
+func TestSQLiteListChanges(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	exec := sqliteExecution(1, model.ExecutionStatusRunning, base, nil)
+	require.NoError(t, h.CreateWorkflowExecution(ctx, exec))
+	require.NoError(t, h.CreateWorkflowExecution(ctx, sqliteExecution(2, model.ExecutionStatusRunning, base, nil)))
+	action := &model.ActionExecutionHistory{
+		WorkflowExecutionID: &exec.ID, LabID: 1, DeviceID: 7, DeviceUUID: uuid.NewV4(),
+		DeviceName: "pump", ActionType: "transfer", ActionName: "transfer", Status: model.ExecutionStatusSuccess,
+	}
+	require.NoError(t, h.CreateActionExecution(ctx, action))
+	event := &model.DeviceEventHistory{LabID: 1, DeviceID: 7, DeviceUUID: uuid.NewV4(), EventType: model.DeviceEventConnected, Timestamp: base}
+	require.NoError(t, h.CreateDeviceEvent(ctx, event))
+	// 三类记录的更新时间相同，按类型排序
+	updated := time.Now().Add(-time.Minute)
+	for _, table := range []string{"workflow_execution_history", "action_execution_history", "device_event_history"} {
+		require.NoError(t, db.DB().DBIns().Table(table).Where("1 = 1").
+			UpdateColumns(map[string]any{"created_at": updated, "updated_at": updated}).Error)
+	}
+
+	now := time.Now()
+	changes, err := h.ListChanges(ctx, 1, nil, now, 2)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, exec.ID, changes[0].Execution.ID)
+	assert.Equal(t, action.ID, changes[1].Action.ID)
+	assert.True(t, changes[1].Created)
+	assert.Empty(t, changes[1].Action.Input)
+
+	since, err := model.ParseHistoryWatermark(changes[1].Watermark().Encode())
+	require.NoError(t, err)
+	changes, err = h.ListChanges(ctx, 1, since, now, 2)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, event.ID, changes[0].Event.ID)
+
+	// 更新过的记录在之后的水位中再次返回，更新时间晚于 until 的暂不返回
+	since = changes[0].Watermark()
+	require.NoError(t, h.UpdateWorkflowExecution(ctx, exec.ID, map[string]any{"status": model.ExecutionStatusSuccess}))
+	changes, err = h.ListChanges(ctx, 1, since, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	changes, err = h.ListChanges(ctx, 1, since, time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, exec.ID, changes[0].Execution.ID)
+	assert.Equal(t, model.ExecutionStatusSuccess, changes[0].Execution.Status)
+	assert.False(t, changes[0].Created)
+}
+
+func TestSQLiteWorkflowExecutionVersion(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+
+	exec := sqliteExecution(1, model.ExecutionStatusRunning, time.Now(), nil)
+	require.NoError(t, h.CreateWorkflowExecution(ctx, exec))
+	assert.Zero(t, exec.Version)
+
+	// 未加锁的更新同样增加版本，持有旧版本的更新冲突
+	require.NoError(t, h.UpdateWorkflowExecution(ctx, exec.ID, map[string]interface{}{"steps_completed": 2}))
+	err := h.UpdateWorkflowExecutionVersion(ctx, exec.ID, 0, map[string]interface{}{"steps_completed": 1})
+	assert.ErrorIs(t, err, code.HistoryVersionConflictErr)
+	require.NoError(t, h.UpdateWorkflowExecutionVersion(ctx, exec.ID, 1, map[string]interface{}{"steps_failed": 1}))
+	assert.ErrorIs(t, h.UpdateWorkflowExecutionVersion(ctx, exec.ID+100, 0, map[string]interface{}{"steps_failed": 1}), code.RecordNotFound)
+
+	// 冲突时重新读取，按最新的状态计算更新
+	calls := 0
+	require.NoError(t, CompareAndUpdate(ctx, h, exec.UUID, func(cur *model.WorkflowExecutionHistory) (map[string]interface{}, error) {
+		calls++
+		if calls == 1 {
+			require.NoError(t, h.UpdateWorkflowExecution(ctx, cur.ID, map[string]interface{}{"steps_completed": 5}))
+		}
+		return map[string]interface{}{"steps_total": cur.StepsCompleted + cur.StepsFailed}, nil
+	}))
+	assert.Equal(t, 2, calls)
+
+	got, err := h.GetWorkflowExecution(ctx, exec.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, got.StepsTotal)
+	assert.Equal(t, int64(4), got.Version)
+}
+
+func TestSQLiteStepCountersAndCompletion(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+
+	startedAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	exec := sqliteExecution(1, model.ExecutionStatusRunning, startedAt, nil)
+	require.NoError(t, h.CreateWorkflowExecution(ctx, exec))
+
+	// 增量写入互不覆盖，不需要先读取当前的计数
+	require.NoError(t, h.IncrementStepCounters(ctx, exec.ID, 1, 0))
+	require.NoError(t, h.IncrementStepCounters(ctx, exec.ID, 2, 1))
+	require.NoError(t, h.IncrementStepCounters(ctx, exec.ID, 0, 0))
+	assert.ErrorIs(t, h.IncrementStepCounters(ctx, exec.ID+100, 1, 0), code.RecordNotFound)
+
+	completedAt := startedAt.Add(90 * time.Second)
+	assert.ErrorIs(t, h.CompleteWorkflowExecution(ctx, exec.ID, 0, completedAt, nil), code.HistoryVersionConflictErr)
+	assert.ErrorIs(t, h.CompleteWorkflowExecution(ctx, exec.ID+100, 2, completedAt, nil), code.RecordNotFound)
+	require.NoError(t, h.CompleteWorkflowExecution(ctx, exec.ID, 2, completedAt, map[string]interface{}{
+		"status": model.ExecutionStatusSuccess,
+	}))
+
+	got, err := h.GetWorkflowExecution(ctx, exec.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.StepsCompleted)
+	assert.Equal(t, 1, got.StepsFailed)
+	assert.Equal(t, model.ExecutionStatusSuccess, got.Status)
+	assert.Equal(t, int64(90000), got.DurationMs)
+	require.NotNil(t, got.CompletedAt)
+	assert.True(t, completedAt.Equal(*got.CompletedAt))
+	assert.Equal(t, int64(3), got.Version)
+}
+
+func TestSQLiteReportAction(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+
+	exec := sqliteExecution(1, model.ExecutionStatusRunning, time.Now().Add(-time.Hour), nil)
+	require.NoError(t, h.CreateWorkflowExecution(ctx, exec))
+	deviceUUID := uuid.NewV4()
+
+	report := func(actionUUID uuid.UUID, labID int64, status model.ExecutionStatus) *model.ActionExecutionHistory {
+		return &model.ActionExecutionHistory{
+			BaseModel:           model.BaseModel{UUID: actionUUID},
+			WorkflowExecutionID: &exec.ID,
+			LabID:               labID,
+			DeviceID:            7,
+			DeviceUUID:          deviceUUID,
+			DeviceName:          "robot",
+			ActionType:          "move",
+			ActionName:          "pick",
+			Status:              status,
+		}
+	}
+
+	// 开始后结束只计数一次，重复的结束上报被忽略
+	started := uuid.NewV4()
+	finished, err := h.ReportAction(ctx, report(started, 1, model.ExecutionStatusRunning))
+	require.NoError(t, err)
+	assert.False(t, finished)
+	done := report(started, 1, model.ExecutionStatusSuccess)
+	done.DurationMs = 1500
+	finished, err = h.ReportAction(ctx, done)
+	require.NoError(t, err)
+	assert.True(t, finished)
+	finished, err = h.ReportAction(ctx, report(started, 1, model.ExecutionStatusFailed))
+	require.NoError(t, err)
+	assert.False(t, finished)
+
+	// 没有开始上报的动作直接以结束状态写入
+	finished, err = h.ReportAction(ctx, report(uuid.NewV4(), 1, model.ExecutionStatusTimeout))
+	require.NoError(t, err)
+	assert.True(t, finished)
+
+	_, err = h.ReportAction(ctx, report(started, 2, model.ExecutionStatusSuccess))
+	assert.ErrorIs(t, err, code.NoPermission)
+
+	action, err := h.GetActionExecutionByUUID(ctx, started)
+	require.NoError(t, err)
+	assert.Equal(t, model.ExecutionStatusSuccess, action.Status)
+	assert.Equal(t, int64(1500), action.DurationMs)
+
+	got, err := h.GetWorkflowExecution(ctx, exec.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.StepsCompleted)
+	assert.Equal(t, 1, got.StepsFailed)
+}
+
+func TestSQLiteUnknownFilters(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+
+	status := model.ExecutionStatus("banana")
+	params := model.NewHistoryQueryParams()
+	params.LabID, params.Status = 1, &status
+	_, _, err := h.ListWorkflowExecutions(ctx, params)
+	var withMsg code.ErrCodeWithMsg
+	require.ErrorAs(t, err, &withMsg)
+	assert.Equal(t, code.ParamErr, withMsg.ErrCode)
+	assert.Contains(t, withMsg.Msgs(), "pending, running, success, failed, cancelled, timeout")
+	_, _, err = h.ListActionExecutions(ctx, params)
+	require.ErrorAs(t, err, &withMsg)
+
+	eventType := model.DeviceEventType("boom")
+	params = model.NewHistoryQueryParams()
+	params.LabID, params.EventType = 1, &eventType
+	_, _, err = h.ListDeviceEvents(ctx, params)
+	require.ErrorAs(t, err, &withMsg)
+	assert.Contains(t, withMsg.Msgs(), "lock_acquired")
+
+	eventType = model.DeviceEventLockAcquired
+	_, _, err = h.ListDeviceEvents(ctx, params)
+	assert.NoError(t, err)
+}
+
+func TestSQLiteListSlowestSteps(t *testing.T) {
+	ctx := context.Background()
+	h := newSQLiteRepo(t)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	exec := sqliteExecution(1, model.ExecutionStatusSuccess, base, nil)
+	require.NoError(t, h.CreateWorkflowExecution(ctx, exec))
+	step := func(labID int64, name string, durationMs, expectedMs int64, createdAt time.Time) *model.ActionExecutionHistory {
+		return &model.ActionExecutionHistory{
+			BaseModel:           model.BaseModel{CreatedAt: createdAt},
+			WorkflowExecutionID: &exec.ID,
+			LabID:               labID,
+			DeviceID:            7,
+			DeviceUUID:          uuid.NewV4(),
+			DeviceName:          "pump",
+			ActionType:          "transfer",
+			ActionName:          name,
+			Status:              model.ExecutionStatusSuccess,
+			DurationMs:          durationMs,
+			ExpectedMs:          expectedMs,
+		}
+	}
+	for _, a := range []*model.ActionExecutionHistory{
+		// aspirate 两次超出预算一次
+		step(1, "aspirate", 3000, 2000, base),
+		step(1, "aspirate", 1000, 2000, base.Add(time.Minute)),
+		// mix 未设预算
+		step(1, "mix", 5000, 0, base.Add(2*time.Minute)),
+		step(1, "dispense", 500, 1000, base.Add(3*time.Minute)),
+		// 未结束的动作和其他实验室的动作不参与统计
+		step(1, "wash", 0, 1000, base.Add(4*time.Minute)),
+		step(2, "aspirate", 9000, 2000, base),
+	} {
+		createdAt := a.CreatedAt
+		require.NoError(t, h.CreateActionExecution(ctx, a))
+		// 创建时会写入当前时间，按用例时间回填
+		require.NoError(t, db.DB().DBIns().Model(a).UpdateColumn("created_at", createdAt).Error)
+	}
+
+	stats, err := h.ListSlowestSteps(ctx, 1, nil, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Equal(t, []string{"mix", "aspirate", "dispense"}, []string{stats[0].ActionName, stats[1].ActionName, stats[2].ActionName})
+
+	mix, aspirate, dispense := stats[0], stats[1], stats[2]
+	assert.Zero(t, mix.ExpectedMs)
+	assert.Zero(t, mix.OverBudgetCount)
+	assert.Zero(t, mix.AvgDeviationMs)
+
+	assert.Equal(t, int64(2), aspirate.Executions)
+	assert.InDelta(t, 2000, aspirate.AvgDurationMs, 0.001)
+	assert.Equal(t, int64(3000), aspirate.MaxDurationMs)
+	assert.Equal(t, int64(2000), aspirate.ExpectedMs)
+	assert.Equal(t, int64(1), aspirate.OverBudgetCount)
+	assert.InDelta(t, 0, aspirate.AvgDeviationMs, 0.001)
+
+	assert.Zero(t, dispense.OverBudgetCount)
+	assert.InDelta(t, -500, dispense.AvgDeviationMs, 0.001)
+
+	stats, err = h.ListSlowestSteps(ctx, 1, nil, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "mix", stats[0].ActionName)
+
+	// 时间范围只包含第二次 aspirate 和 mix
+	start, end := base.Add(30*time.Second), base.Add(150*time.Second)
+	stats, err = h.ListSlowestSteps(ctx, 1, &start, &end, 10)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "mix", stats[0].ActionName)
+	assert.Equal(t, "aspirate", stats[1].ActionName)
+	assert.Equal(t, int64(1), stats[1].Executions)
+	assert.Zero(t, stats[1].OverBudgetCount)
+}
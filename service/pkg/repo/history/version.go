@@ -0,0 +1,66 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// casRetries 版本冲突时重新读取并重试的次数
+const casRetries = 5
+
+// UpdateFunc computes the updates of an execution from its current state,
+// returning nil updates skips the write
+type UpdateFunc func(exec *model.WorkflowExecutionHistory) (map[string]interface{}, error)
+
+// CompareAndUpdate reads an execution, computes the updates from it and
+// writes them only when nobody updated the execution in between. On a
+// conflict the execution is read again and fn called with the new state,
+// HistoryVersionConflictErr is returned when every retry conflicts
+func CompareAndUpdate(ctx context.Context, h HistoryRepo, execUUID uuid.UUID, fn UpdateFunc) error {
+	return compareAndWrite(ctx, h, execUUID, fn, func(exec *model.WorkflowExecutionHistory, updates map[string]interface{}) error {
+		return h.UpdateWorkflowExecutionVersion(ctx, exec.ID, exec.Version, updates)
+	})
+}
+
+// CompareAndComplete is CompareAndUpdate for finishing an execution, the
+// duration is derived from started_at and completedAt by the repository
+func CompareAndComplete(ctx context.Context, h HistoryRepo, execUUID uuid.UUID, completedAt time.Time, fn UpdateFunc) error {
+	return compareAndWrite(ctx, h, execUUID, fn, func(exec *model.WorkflowExecutionHistory, updates map[string]interface{}) error {
+		return h.CompleteWorkflowExecution(ctx, exec.ID, exec.Version, completedAt, updates)
+	})
+}
+
+// compareAndWrite 读取执行、计算更新并按读取时的版本写入，版本冲突时重试
+func compareAndWrite(ctx context.Context, h HistoryRepo, execUUID uuid.UUID, fn UpdateFunc,
+	write func(exec *model.WorkflowExecutionHistory, updates map[string]interface{}) error) error {
+	for range casRetries {
+		exec, err := h.GetWorkflowExecutionByUUID(ctx, execUUID)
+		if err != nil {
+			return err
+		}
+		updates, err := fn(exec)
+		if err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		err = write(exec, updates)
+		if !errors.Is(err, code.HistoryVersionConflictErr) {
+			return err
+		}
+	}
+	return code.HistoryVersionConflictErr
+}
+
+// MergeSteps sets the step counters in updates without lowering the ones
+// already recorded, counters reported late by another writer are ignored
+func MergeSteps(exec *model.WorkflowExecutionHistory, updates map[string]interface{}, completed, failed int) {
+	updates["steps_completed"] = max(exec.StepsCompleted, completed)
+	updates["steps_failed"] = max(exec.StepsFailed, failed)
+}
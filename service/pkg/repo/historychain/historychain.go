@@ -0,0 +1,265 @@
+// Package historychain provides repository operations for the tamper-evident
+// hash chain of history records.
+package historychain
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm/clause"
+)
+
+// chainLockClass namespaces the per-lab advisory locks taken while appending
+const chainLockClass int32 = 0x6863
+
+// HistoryChainRepo defines the interface for history chain repository operations
+type HistoryChainRepo interface {
+	// ListUnsealedExecutions lists executions finished before the time that are not sealed yet
+	ListUnsealedExecutions(ctx context.Context, before time.Time, limit int) ([]*model.WorkflowExecutionHistory, error)
+	// ListUnsealedAuditLogs lists audit log entries created before the time that are not sealed yet
+	ListUnsealedAuditLogs(ctx context.Context, before time.Time, limit int) ([]*model.AuditLog, error)
+	// Append seals the records of a lab after the head of its chain, skipping
+	// records sealed concurrently, and returns the number of entries added
+	Append(ctx context.Context, labID int64, records []*model.ChainRecord) (int, error)
+
+	// ListEntries lists the entries of a lab chain after the seq in seq order
+	ListEntries(ctx context.Context, labID, afterSeq int64, limit int) ([]*model.HistoryChainEntry, error)
+	// ListExecutions and ListAuditLogs load the sealed records by id
+	ListExecutions(ctx context.Context, ids []int64) ([]*model.WorkflowExecutionHistory, error)
+	ListAuditLogs(ctx context.Context, ids []int64) ([]*model.AuditLog, error)
+	// ListHeads returns the last entry of every lab chain
+	ListHeads(ctx context.Context) ([]*model.HistoryChainEntry, error)
+
+	CreateAnchor(ctx context.Context, data *model.HistoryChainAnchor) error
+	UpdateAnchor(ctx context.Context, id int64, updates map[string]any) error
+	// LatestAnchorSeqs returns the seq of the latest published anchor of every lab
+	LatestAnchorSeqs(ctx context.Context) (map[int64]int64, error)
+	// ListAnchors lists the anchors of a lab, newest first
+	ListAnchors(ctx context.Context, labID int64, page, pageSize int) ([]*model.HistoryChainAnchor, int64, error)
+	// AllAnchors lists every anchor of a lab in seq order
+	AllAnchors(ctx context.Context, labID int64) ([]*model.HistoryChainAnchor, error)
+}
+
+type historyChainImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new history chain repository instance
+func New() HistoryChainRepo {
+	return &historyChainImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// ListUnsealedExecutions lists finished executions without a chain entry
+func (h *historyChainImpl) ListUnsealedExecutions(ctx context.Context, before time.Time, limit int) ([]*model.WorkflowExecutionHistory, error) {
+	var datas []*model.WorkflowExecutionHistory
+	if err := h.DBWithContext(ctx).
+		Where("completed_at IS NOT NULL AND completed_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM history_chain c WHERE c.record_type = ? AND c.record_id = workflow_execution_history.id)",
+			model.ChainWorkflowExecution).
+		Order("id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListUnsealedExecutions fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListUnsealedAuditLogs lists audit log entries without a chain entry
+func (h *historyChainImpl) ListUnsealedAuditLogs(ctx context.Context, before time.Time, limit int) ([]*model.AuditLog, error) {
+	var datas []*model.AuditLog
+	if err := h.DBWithContext(ctx).
+		Where("created_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM history_chain c WHERE c.record_type = ? AND c.record_id = audit_log.id)",
+			model.ChainAuditLog).
+		Order("id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListUnsealedAuditLogs fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// Append seals records after the head of the lab chain
+func (h *historyChainImpl) Append(ctx context.Context, labID int64, records []*model.ChainRecord) (int, error) {
+	added := 0
+	if len(records) == 0 {
+		return added, nil
+	}
+	err := h.ExecTx(ctx, func(txCtx context.Context) error {
+		// 同一实验室的追加串行执行，保证序号连续
+		if err := h.DBWithContext(txCtx).Exec("SELECT pg_advisory_xact_lock(?, ?)", chainLockClass, int32(labID)).Error; err != nil {
+			logger.Errorf(ctx, "Append lock fail lab=%d: %+v", labID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+
+		var heads []*model.HistoryChainEntry
+		if err := h.DBWithContext(txCtx).
+			Where("lab_id = ?", labID).
+			Order("seq DESC").
+			Limit(1).
+			Find(&heads).Error; err != nil {
+			logger.Errorf(ctx, "Append head fail lab=%d: %+v", labID, err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+		var head *model.HistoryChainEntry
+		if len(heads) > 0 {
+			head = heads[0]
+		}
+
+		for _, rec := range records {
+			entry := model.NextChainEntry(head, rec)
+			ret := h.DBWithContext(txCtx).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "record_type"}, {Name: "record_id"}},
+				DoNothing: true,
+			}).Create(entry)
+			if ret.Error != nil {
+				logger.Errorf(ctx, "Append fail lab=%d: %+v", labID, ret.Error)
+				return code.CreateDataErr.WithErr(ret.Error)
+			}
+			if ret.RowsAffected == 0 {
+				continue
+			}
+			head = entry
+			added++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// ListEntries lists chain entries of a lab after the seq
+func (h *historyChainImpl) ListEntries(ctx context.Context, labID, afterSeq int64, limit int) ([]*model.HistoryChainEntry, error) {
+	var datas []*model.HistoryChainEntry
+	if err := h.DBWithContext(ctx).
+		Where("lab_id = ? AND seq > ?", labID, afterSeq).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListEntries fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListExecutions loads executions by id
+func (h *historyChainImpl) ListExecutions(ctx context.Context, ids []int64) ([]*model.WorkflowExecutionHistory, error) {
+	datas := make([]*model.WorkflowExecutionHistory, 0, len(ids))
+	if len(ids) == 0 {
+		return datas, nil
+	}
+	if err := h.DBWithContext(ctx).Where("id IN ?", ids).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListExecutions fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListAuditLogs loads audit log entries by id
+func (h *historyChainImpl) ListAuditLogs(ctx context.Context, ids []int64) ([]*model.AuditLog, error) {
+	datas := make([]*model.AuditLog, 0, len(ids))
+	if len(ids) == 0 {
+		return datas, nil
+	}
+	if err := h.DBWithContext(ctx).Where("id IN ?", ids).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListAuditLogs fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListHeads returns the last entry of every lab chain
+func (h *historyChainImpl) ListHeads(ctx context.Context) ([]*model.HistoryChainEntry, error) {
+	var datas []*model.HistoryChainEntry
+	if err := h.DBWithContext(ctx).
+		Raw("SELECT DISTINCT ON (lab_id) * FROM history_chain ORDER BY lab_id, seq DESC").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListHeads fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// CreateAnchor creates an anchor record
+func (h *historyChainImpl) CreateAnchor(ctx context.Context, data *model.HistoryChainAnchor) error {
+	if err := h.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateAnchor fail lab=%d: %+v", data.LabID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// UpdateAnchor records the publication result of an anchor
+func (h *historyChainImpl) UpdateAnchor(ctx context.Context, id int64, updates map[string]any) error {
+	if err := h.DBWithContext(ctx).Model(&model.HistoryChainAnchor{}).
+		Where("id = ?", id).Updates(updates).Error; err != nil {
+		logger.Errorf(ctx, "UpdateAnchor fail id=%d: %+v", id, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// LatestAnchorSeqs returns the latest anchored seq of every lab, anchors that
+// failed to publish are ignored so that the head is published again
+func (h *historyChainImpl) LatestAnchorSeqs(ctx context.Context) (map[int64]int64, error) {
+	var rows []struct {
+		LabID int64
+		Seq   int64
+	}
+	if err := h.DBWithContext(ctx).Model(&model.HistoryChainAnchor{}).
+		Select("lab_id, MAX(seq) AS seq").
+		Where("error IS NULL OR error = ''").
+		Group("lab_id").
+		Scan(&rows).Error; err != nil {
+		logger.Errorf(ctx, "LatestAnchorSeqs fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	seqs := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		seqs[row.LabID] = row.Seq
+	}
+	return seqs, nil
+}
+
+// ListAnchors lists the anchors of a lab
+func (h *historyChainImpl) ListAnchors(ctx context.Context, labID int64, page, pageSize int) ([]*model.HistoryChainAnchor, int64, error) {
+	query := h.DBWithContext(ctx).Model(&model.HistoryChainAnchor{}).Where("lab_id = ?", labID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListAnchors count fail lab=%d: %+v", labID, err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	datas := make([]*model.HistoryChainAnchor, 0)
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListAnchors fail lab=%d: %+v", labID, err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// AllAnchors lists every anchor of a lab
+func (h *historyChainImpl) AllAnchors(ctx context.Context, labID int64) ([]*model.HistoryChainAnchor, error) {
+	var datas []*model.HistoryChainAnchor
+	if err := h.DBWithContext(ctx).
+		Where("lab_id = ?", labID).
+		Order("seq ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "AllAnchors fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
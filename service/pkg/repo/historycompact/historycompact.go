@@ -0,0 +1,159 @@
+// Package historycompact provides repository operations for compacting the
+// payloads of old action executions and compressing large payloads.
+package historycompact
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/legalhold"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// HistoryCompactRepo defines the interface for history compaction repository operations
+type HistoryCompactRepo interface {
+	// ListCompactable lists finished actions created before the time, not
+	// compacted and not under a legal hold, whose input and output take more
+	// than minBytes, with id greater than afterID in id order
+	ListCompactable(ctx context.Context, before time.Time, minBytes int, afterID int64, limit int) ([]*model.ActionExecutionHistory, error)
+	// Compact replaces the payloads of an action not compacted yet, returning
+	// false when it was compacted concurrently
+	Compact(ctx context.Context, id int64, input, output datatypes.JSON) (bool, error)
+
+	// CompressWorkflowExecutions and CompressActionExecutions rewrite up to
+	// limit rows with id greater than afterID that have an uncompressed
+	// payload over threshold bytes, so that the model serializer compresses
+	// it. They return the last id read and the number of rows rewritten
+	CompressWorkflowExecutions(ctx context.Context, afterID int64, threshold, limit int) (int64, int, error)
+	CompressActionExecutions(ctx context.Context, afterID int64, threshold, limit int) (int64, int, error)
+}
+
+type historyCompactImpl struct {
+	repo.IDOrUUIDTranslate
+	holds legalhold.LegalHoldRepo
+}
+
+// executionHoldColumns matches legal holds against workflow executions
+var executionHoldColumns = legalhold.Columns{
+	LabID:       "lab_id",
+	ExecutionID: "id",
+	WorkflowID:  "workflow_id",
+	Time:        "started_at",
+}
+
+// New creates a new history compaction repository instance
+func New() HistoryCompactRepo {
+	return &historyCompactImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+		holds:             legalhold.New(),
+	}
+}
+
+// ListCompactable lists one keyset page of actions to compact
+func (h *historyCompactImpl) ListCompactable(ctx context.Context, before time.Time, minBytes int, afterID int64, limit int) ([]*model.ActionExecutionHistory, error) {
+	holds, err := h.holds.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := h.DBWithContext(ctx)
+	query := conn.
+		Where("id > ? AND created_at < ? AND compacted_at IS NULL", afterID, before).
+		Where("status NOT IN ?", []model.ExecutionStatus{model.ExecutionStatusPending, model.ExecutionStatusRunning}).
+		Where(payloadSize(conn)+" > ?", minBytes)
+	query = legalhold.Exclude(query, holds, legalhold.Columns{LabID: "lab_id", Time: "created_at"})
+	if cond, args := legalhold.Condition(holds, executionHoldColumns); cond != "" {
+		query = query.Where("workflow_execution_id IS NULL OR workflow_execution_id NOT IN (?)",
+			h.DBWithContext(ctx).Model(&model.WorkflowExecutionHistory{}).Select("id").Where(cond, args...))
+	}
+
+	var datas []*model.ActionExecutionHistory
+	if err := query.Order("id ASC").Limit(limit).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListCompactable fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// Compact writes the summaries in place of the payloads
+func (h *historyCompactImpl) Compact(ctx context.Context, id int64, input, output datatypes.JSON) (bool, error) {
+	result := h.DBWithContext(ctx).Model(&model.ActionExecutionHistory{}).
+		Where("id = ? AND compacted_at IS NULL", id).
+		Updates(map[string]any{
+			"input":        input,
+			"output":       output,
+			"compacted_at": time.Now(),
+		})
+	if result.Error != nil {
+		logger.Errorf(ctx, "Compact fail id=%d: %+v", id, result.Error)
+		return false, code.UpdateDataErr.WithErr(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CompressWorkflowExecutions compresses the input and result of executions
+func (h *historyCompactImpl) CompressWorkflowExecutions(ctx context.Context, afterID int64, threshold, limit int) (int64, int, error) {
+	return compressRows(ctx, h.DBWithContext(ctx), []string{"input", "result"}, afterID, threshold, limit,
+		func(data *model.WorkflowExecutionHistory) int64 { return data.ID })
+}
+
+// CompressActionExecutions compresses the input and output of actions
+func (h *historyCompactImpl) CompressActionExecutions(ctx context.Context, afterID int64, threshold, limit int) (int64, int, error) {
+	return compressRows(ctx, h.DBWithContext(ctx), []string{"input", "output"}, afterID, threshold, limit,
+		func(data *model.ActionExecutionHistory) int64 { return data.ID })
+}
+
+func compressRows[T any](ctx context.Context, conn *gorm.DB, columns []string, afterID int64, threshold, limit int, id func(T) int64) (int64, int, error) {
+	conds := make([]string, 0, len(columns))
+	args := make([]any, 0, len(columns))
+	for _, column := range columns {
+		conds = append(conds, "("+columnSize(conn, column)+" > ? AND "+columnText(conn, column)+" NOT LIKE ?)")
+		args = append(args, threshold, `{"`+model.CompressedMarker+`"%`)
+	}
+
+	var datas []T
+	if err := conn.Where("id > ?", afterID).
+		Where(strings.Join(conds, " OR "), args...).
+		Order("id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "compressRows find fail: %+v", err)
+		return afterID, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	for _, data := range datas {
+		afterID = id(data)
+		// 只写回负载列，不改变更新时间
+		if err := conn.Model(data).Select(columns).UpdateColumns(data).Error; err != nil {
+			logger.Errorf(ctx, "compressRows update fail id=%d: %+v", afterID, err)
+			return afterID, 0, code.UpdateDataErr.WithErr(err)
+		}
+	}
+	return afterID, len(datas), nil
+}
+
+// payloadSize is the SQL size of the input and output in bytes
+func payloadSize(tx *gorm.DB) string {
+	return columnSize(tx, "input") + " + " + columnSize(tx, "output")
+}
+
+func columnSize(tx *gorm.DB, column string) string {
+	if db.IsSQLite(tx) {
+		return "COALESCE(length(CAST(" + column + " AS BLOB)), 0)"
+	}
+	return "COALESCE(octet_length(" + column + "::text), 0)"
+}
+
+func columnText(tx *gorm.DB, column string) string {
+	if db.IsSQLite(tx) {
+		return "COALESCE(" + column + ", '')"
+	}
+	return "COALESCE(" + column + "::text, '')"
+}
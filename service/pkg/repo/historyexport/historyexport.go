@@ -0,0 +1,166 @@
+// Package historyexport provides repository operations for resumable history
+// exports.
+package historyexport
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HistoryExportRepo defines the interface for history export repository operations
+type HistoryExportRepo interface {
+	// CreateExport creates an export, reporting false without creating it when
+	// the user already has an export of the lab with the same idempotency key
+	CreateExport(ctx context.Context, data *model.HistoryExport) (bool, error)
+	GetExportByUUID(ctx context.Context, exportUUID uuid.UUID) (*model.HistoryExport, error)
+	// GetExportByKey retrieves the export of a user and lab by idempotency key
+	GetExportByKey(ctx context.Context, labID int64, userID string, key string) (*model.HistoryExport, error)
+	// ListExports lists the exports of a lab, newest first
+	ListExports(ctx context.Context, labID int64) ([]*model.HistoryExport, error)
+	// UpdateExport updates the given columns of an export
+	UpdateExport(ctx context.Context, data *model.HistoryExport, columns ...string) error
+	// ClaimExport marks the oldest pending export, or a running export not
+	// checkpointed since staleBefore, as running and returns it; nil when none
+	ClaimExport(ctx context.Context, staleBefore time.Time) (*model.HistoryExport, error)
+	// ListExpiredExports lists completed exports whose files expired before the given time
+	ListExpiredExports(ctx context.Context, before time.Time, limit int) ([]*model.HistoryExport, error)
+}
+
+type historyExportImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new history export repository instance
+func New() HistoryExportRepo {
+	return &historyExportImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateExport creates an export unless the idempotency key is taken
+func (h *historyExportImpl) CreateExport(ctx context.Context, data *model.HistoryExport) (bool, error) {
+	res := h.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lab_id"}, {Name: "requested_by"}, {Name: "idempotency_key"}},
+		DoNothing: true,
+	}).Create(data)
+	if res.Error != nil {
+		logger.Errorf(ctx, "CreateExport fail lab=%d: %+v", data.LabID, res.Error)
+		return false, code.CreateDataErr.WithErr(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// GetExportByUUID retrieves an export by UUID
+func (h *historyExportImpl) GetExportByUUID(ctx context.Context, exportUUID uuid.UUID) (*model.HistoryExport, error) {
+	var data model.HistoryExport
+	if err := h.DBWithContext(ctx).Where("uuid = ?", exportUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetExportByUUID fail uuid=%s: %+v", exportUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// GetExportByKey retrieves an export by idempotency key
+func (h *historyExportImpl) GetExportByKey(ctx context.Context, labID int64, userID string, key string) (*model.HistoryExport, error) {
+	var data model.HistoryExport
+	if err := h.DBWithContext(ctx).
+		Where("lab_id = ? AND requested_by = ? AND idempotency_key = ?", labID, userID, key).
+		First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetExportByKey fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListExports lists the exports of a lab
+func (h *historyExportImpl) ListExports(ctx context.Context, labID int64) ([]*model.HistoryExport, error) {
+	var datas []*model.HistoryExport
+	if err := h.DBWithContext(ctx).Where("lab_id = ?", labID).
+		Order("id DESC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListExports fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateExport updates the given columns of an export
+func (h *historyExportImpl) UpdateExport(ctx context.Context, data *model.HistoryExport, columns ...string) error {
+	data.UpdatedAt = time.Now()
+	columns = append(columns, "updated_at")
+	res := h.DBWithContext(ctx).Model(data).Select(columns).Updates(data)
+	if res.Error != nil {
+		logger.Errorf(ctx, "UpdateExport fail uuid=%s: %+v", data.UUID, res.Error)
+		return code.UpdateDataErr.WithErr(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// ClaimExport locks and claims the next export to run
+func (h *historyExportImpl) ClaimExport(ctx context.Context, staleBefore time.Time) (*model.HistoryExport, error) {
+	var claimed *model.HistoryExport
+	err := h.ExecTx(ctx, func(txCtx context.Context) error {
+		var data model.HistoryExport
+		if err := h.DBWithContext(txCtx).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND updated_at < ?)",
+				model.HistoryExportPending, model.HistoryExportRunning, staleBefore).
+			Order("id ASC").First(&data).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			logger.Errorf(ctx, "ClaimExport query fail: %+v", err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+
+		now := time.Now()
+		if err := h.DBWithContext(txCtx).Model(&model.HistoryExport{}).
+			Where("id = ?", data.ID).
+			Updates(map[string]any{
+				"status":     model.HistoryExportRunning,
+				"attempts":   gorm.Expr("attempts + 1"),
+				"updated_at": now,
+			}).Error; err != nil {
+			logger.Errorf(ctx, "ClaimExport update fail id=%d: %+v", data.ID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+
+		data.Status = model.HistoryExportRunning
+		data.Attempts++
+		data.UpdatedAt = now
+		claimed = &data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// ListExpiredExports lists expired completed exports
+func (h *historyExportImpl) ListExpiredExports(ctx context.Context, before time.Time, limit int) ([]*model.HistoryExport, error) {
+	var datas []*model.HistoryExport
+	if err := h.DBWithContext(ctx).
+		Where("status = ? AND expires_at < ?", model.HistoryExportCompleted, before).
+		Order("expires_at ASC").Limit(limit).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListExpiredExports fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
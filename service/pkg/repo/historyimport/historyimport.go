@@ -0,0 +1,211 @@
+// Package historyimport provides repository operations for importing execution
+// history archives back into the history tables.
+package historyimport
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const insertBatch = 500
+
+// HistoryImportRepo defines the interface for history import repository operations
+type HistoryImportRepo interface {
+	ExecTx(ctx context.Context, fn func(ctx context.Context) error) error
+
+	CreateImport(ctx context.Context, data *model.HistoryImport) error
+	GetImportByUUID(ctx context.Context, importUUID uuid.UUID) (*model.HistoryImport, error)
+	// ListImports lists the imports of a lab, newest first
+	ListImports(ctx context.Context, labID int64) ([]*model.HistoryImport, error)
+	// UpdateImport updates the given columns of an import
+	UpdateImport(ctx context.Context, data *model.HistoryImport, columns ...string) error
+	// CountActiveImports counts unfinished imports of a lab updated since the given time
+	CountActiveImports(ctx context.Context, labID int64, since time.Time) (int64, error)
+
+	// ExistingRecords returns the records of a history table with the given UUIDs
+	ExistingRecords(ctx context.Context, table string, uuids []uuid.UUID) (map[uuid.UUID]*ExistingRecord, error)
+	// WorkflowIDs resolves workflow UUIDs of a lab to ids
+	WorkflowIDs(ctx context.Context, labID int64, uuids []uuid.UUID) (map[uuid.UUID]int64, error)
+	// DeviceIDs resolves device UUIDs of a lab to material node ids
+	DeviceIDs(ctx context.Context, labID int64, uuids []uuid.UUID) (map[uuid.UUID]int64, error)
+	// SaveRecords inserts history records, replacing the records with the same UUID;
+	// datas must be a slice of history models
+	SaveRecords(ctx context.Context, datas any) error
+}
+
+type historyImportImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new history import repository instance
+func New() HistoryImportRepo {
+	return &historyImportImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateImport creates an import
+func (h *historyImportImpl) CreateImport(ctx context.Context, data *model.HistoryImport) error {
+	if err := h.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateImport fail lab=%d: %+v", data.LabID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetImportByUUID retrieves an import by UUID
+func (h *historyImportImpl) GetImportByUUID(ctx context.Context, importUUID uuid.UUID) (*model.HistoryImport, error) {
+	var data model.HistoryImport
+	if err := h.DBWithContext(ctx).Where("uuid = ?", importUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetImportByUUID fail uuid=%s: %+v", importUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListImports lists the imports of a lab
+func (h *historyImportImpl) ListImports(ctx context.Context, labID int64) ([]*model.HistoryImport, error) {
+	var datas []*model.HistoryImport
+	if err := h.DBWithContext(ctx).Where("lab_id = ?", labID).
+		Order("id DESC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListImports fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateImport updates the given columns of an import
+func (h *historyImportImpl) UpdateImport(ctx context.Context, data *model.HistoryImport, columns ...string) error {
+	data.UpdatedAt = time.Now()
+	columns = append(columns, "updated_at")
+	res := h.DBWithContext(ctx).Model(data).Select(columns).Updates(data)
+	if res.Error != nil {
+		logger.Errorf(ctx, "UpdateImport fail uuid=%s: %+v", data.UUID, res.Error)
+		return code.UpdateDataErr.WithErr(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// CountActiveImports counts running imports of a lab
+func (h *historyImportImpl) CountActiveImports(ctx context.Context, labID int64, since time.Time) (int64, error) {
+	var count int64
+	if err := h.DBWithContext(ctx).Model(&model.HistoryImport{}).
+		Where("lab_id = ? AND status IN ? AND updated_at >= ?", labID,
+			[]model.HistoryImportStatus{model.HistoryImportPending, model.HistoryImportRunning}, since).
+		Count(&count).Error; err != nil {
+		logger.Errorf(ctx, "CountActiveImports fail lab=%d: %+v", labID, err)
+		return 0, code.QueryRecordErr.WithErr(err)
+	}
+	return count, nil
+}
+
+// ExistingRecord is a history record already stored with an imported UUID
+type ExistingRecord struct {
+	UUID  uuid.UUID
+	ID    int64
+	LabID int64
+}
+
+type uuidID struct {
+	UUID uuid.UUID
+	ID   int64
+}
+
+// ExistingRecords looks up existing records by UUID
+func (h *historyImportImpl) ExistingRecords(ctx context.Context, table string, uuids []uuid.UUID) (map[uuid.UUID]*ExistingRecord, error) {
+	var datas []*ExistingRecord
+	if len(uuids) > 0 {
+		if err := h.DBWithContext(ctx).Table(table).Select("uuid, id, lab_id").
+			Where("uuid IN ?", uuids).Scan(&datas).Error; err != nil {
+			logger.Errorf(ctx, "ExistingRecords fail table=%s: %+v", table, err)
+			return nil, code.QueryRecordErr.WithErr(err)
+		}
+	}
+
+	res := make(map[uuid.UUID]*ExistingRecord, len(datas))
+	for _, d := range datas {
+		res[d.UUID] = d
+	}
+	return res, nil
+}
+
+// WorkflowIDs resolves workflow UUIDs
+func (h *historyImportImpl) WorkflowIDs(ctx context.Context, labID int64, uuids []uuid.UUID) (map[uuid.UUID]int64, error) {
+	return h.resolve(ctx, &model.Workflow{}, labID, uuids)
+}
+
+// DeviceIDs resolves device UUIDs
+func (h *historyImportImpl) DeviceIDs(ctx context.Context, labID int64, uuids []uuid.UUID) (map[uuid.UUID]int64, error) {
+	return h.resolve(ctx, &model.MaterialNode{}, labID, uuids)
+}
+
+func (h *historyImportImpl) resolve(ctx context.Context, tableModel schema.Tabler, labID int64, uuids []uuid.UUID) (map[uuid.UUID]int64, error) {
+	if len(uuids) == 0 {
+		return map[uuid.UUID]int64{}, nil
+	}
+
+	var datas []*uuidID
+	if err := h.DBWithContext(ctx).Table(tableModel.TableName()).Select("uuid, id").
+		Where("lab_id = ? AND uuid IN ?", labID, uuids).Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "resolve %s fail lab=%d: %+v", tableModel.TableName(), labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return toMap(datas), nil
+}
+
+// SaveRecords upserts history records by UUID
+func (h *historyImportImpl) SaveRecords(ctx context.Context, datas any) error {
+	// 分区后的设备事件表唯一索引包含 timestamp，不能按 uuid 冲突更新，先删除再写入
+	if events, ok := datas.([]*model.DeviceEventHistory); ok {
+		return h.saveEvents(ctx, events)
+	}
+	if err := h.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uuid"}},
+		UpdateAll: true,
+	}).CreateInBatches(datas, insertBatch).Error; err != nil {
+		logger.Errorf(ctx, "SaveRecords fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+func (h *historyImportImpl) saveEvents(ctx context.Context, events []*model.DeviceEventHistory) error {
+	uuids := make([]uuid.UUID, 0, len(events))
+	for _, e := range events {
+		uuids = append(uuids, e.UUID)
+	}
+	// 已在事务中时为保存点
+	if err := h.DBWithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("uuid IN ?", uuids).Delete(&model.DeviceEventHistory{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(events, insertBatch).Error
+	}); err != nil {
+		logger.Errorf(ctx, "SaveRecords fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+func toMap(datas []*uuidID) map[uuid.UUID]int64 {
+	res := make(map[uuid.UUID]int64, len(datas))
+	for _, d := range datas {
+		res[d.UUID] = d.ID
+	}
+	return res
+}
@@ -0,0 +1,163 @@
+// Package impersonation provides repository operations for admin
+// impersonation sessions and their audit log.
+package impersonation
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// AuditFilter filters audit log entries, zero values match all entries
+type AuditFilter struct {
+	SessionID      int64
+	ImpersonatorID string
+	LabID          int64
+	Actions        []model.AuditAction
+}
+
+// ImpersonationRepo defines the interface for impersonation repository operations
+type ImpersonationRepo interface {
+	CreateSession(ctx context.Context, data *model.ImpersonationSession) error
+	GetSessionByUUID(ctx context.Context, sessionUUID uuid.UUID) (*model.ImpersonationSession, error)
+	// GetSessionByToken retrieves a session by the hash of its token
+	GetSessionByToken(ctx context.Context, tokenHash string) (*model.ImpersonationSession, error)
+	// ListSessions lists sessions newest first, only unexpired and unrevoked ones when active
+	ListSessions(ctx context.Context, active bool, page, pageSize int) ([]*model.ImpersonationSession, int64, error)
+	// RevokeSession revokes a session, reporting false when it was already revoked
+	RevokeSession(ctx context.Context, sessionID int64, revokedBy string) (bool, error)
+	CreateAuditLog(ctx context.Context, data *model.AuditLog) error
+	// ListAuditLogs lists audit log entries newest first
+	ListAuditLogs(ctx context.Context, filter *AuditFilter, page, pageSize int) ([]*model.AuditLog, int64, error)
+}
+
+type impersonationImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new impersonation repository instance
+func New() ImpersonationRepo {
+	return &impersonationImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateSession creates an impersonation session
+func (i *impersonationImpl) CreateSession(ctx context.Context, data *model.ImpersonationSession) error {
+	if err := i.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateSession fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetSessionByUUID retrieves a session by UUID
+func (i *impersonationImpl) GetSessionByUUID(ctx context.Context, sessionUUID uuid.UUID) (*model.ImpersonationSession, error) {
+	var data model.ImpersonationSession
+	if err := i.DBWithContext(ctx).Where("uuid = ?", sessionUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetSessionByUUID fail uuid=%s: %+v", sessionUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// GetSessionByToken retrieves a session by token hash
+func (i *impersonationImpl) GetSessionByToken(ctx context.Context, tokenHash string) (*model.ImpersonationSession, error) {
+	var data model.ImpersonationSession
+	if err := i.DBWithContext(ctx).Where("token_hash = ?", tokenHash).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetSessionByToken fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListSessions lists sessions
+func (i *impersonationImpl) ListSessions(ctx context.Context, active bool, page, pageSize int) ([]*model.ImpersonationSession, int64, error) {
+	var datas []*model.ImpersonationSession
+	var total int64
+
+	query := i.DBWithContext(ctx).Model(&model.ImpersonationSession{})
+	if active {
+		query = query.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	}
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListSessions count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListSessions fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// RevokeSession revokes a session
+func (i *impersonationImpl) RevokeSession(ctx context.Context, sessionID int64, revokedBy string) (bool, error) {
+	now := time.Now()
+	res := i.DBWithContext(ctx).Model(&model.ImpersonationSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]any{
+			"revoked_by": revokedBy,
+			"revoked_at": now,
+			"updated_at": now,
+		})
+	if res.Error != nil {
+		logger.Errorf(ctx, "RevokeSession fail id=%d: %+v", sessionID, res.Error)
+		return false, code.UpdateDataErr.WithErr(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// CreateAuditLog creates an audit log entry
+func (i *impersonationImpl) CreateAuditLog(ctx context.Context, data *model.AuditLog) error {
+	if err := i.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateAuditLog fail action=%s: %+v", data.Action, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListAuditLogs lists audit log entries
+func (i *impersonationImpl) ListAuditLogs(ctx context.Context, filter *AuditFilter, page, pageSize int) ([]*model.AuditLog, int64, error) {
+	var datas []*model.AuditLog
+	var total int64
+
+	query := i.DBWithContext(ctx).Model(&model.AuditLog{})
+	if filter.SessionID > 0 {
+		query = query.Where("session_id = ?", filter.SessionID)
+	}
+	if filter.ImpersonatorID != "" {
+		query = query.Where("impersonator_id = ?", filter.ImpersonatorID)
+	}
+	if filter.LabID > 0 {
+		query = query.Where("lab_id = ?", filter.LabID)
+	}
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListAuditLogs count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListAuditLogs fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
@@ -0,0 +1,254 @@
+// Package ingest provides repository operations for replaying the action
+// results edge agents buffered while offline.
+package ingest
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/payload"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const insertBatch = 200
+
+// IngestRepo defines the interface for edge ingestion repository operations
+type IngestRepo interface {
+	// Ingest writes the records of a stream exactly once: records whose sequence
+	// number or UUID was already ingested are reported as duplicates. Records are
+	// written in sequence order with their original timestamps
+	Ingest(ctx context.Context, labID int64, stream string, records []*model.IngestRecord) (*model.IngestResult, error)
+	GetStream(ctx context.Context, labID int64, stream string) (*model.EdgeIngestStream, error)
+	// ListReceivedSeqs lists the sequence numbers ingested above the high-water mark
+	ListReceivedSeqs(ctx context.Context, streamID int64) ([]int64, error)
+	// ListStreams lists the streams of a lab, most recently ingested first
+	ListStreams(ctx context.Context, labID int64) ([]*model.EdgeIngestStream, error)
+}
+
+type ingestImpl struct {
+	repo.IDOrUUIDTranslate
+	payloads *payload.Limiter
+}
+
+// New creates a new edge ingestion repository instance
+func New() IngestRepo {
+	return &ingestImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+		payloads:          payload.New(),
+	}
+}
+
+// Ingest holds the stream row lock so that concurrent replays of one stream
+// see each other's sequence numbers
+func (i *ingestImpl) Ingest(ctx context.Context, labID int64, stream string, records []*model.IngestRecord) (*model.IngestResult, error) {
+	now := time.Now()
+	result := &model.IngestResult{
+		Accepted:   make(map[int64]bool, len(records)),
+		Duplicates: make(map[int64]bool),
+	}
+
+	err := i.ExecTx(ctx, func(txCtx context.Context) error {
+		st := &model.EdgeIngestStream{LabID: labID, Stream: stream, LastIngestedAt: now}
+		if err := i.DBWithContext(txCtx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "lab_id"}, {Name: "stream"}},
+			DoNothing: true,
+		}).Create(st).Error; err != nil {
+			logger.Errorf(ctx, "Ingest create stream fail lab=%d stream=%s: %+v", labID, stream, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		if err := i.DBWithContext(txCtx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("lab_id = ? AND stream = ?", labID, stream).Take(st).Error; err != nil {
+			logger.Errorf(ctx, "Ingest lock stream fail lab=%d stream=%s: %+v", labID, stream, err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+
+		seqs := make([]int64, 0, len(records))
+		uuids := make([]uuid.UUID, 0, len(records))
+		for _, r := range records {
+			if r.Seq <= st.HighWaterMark {
+				continue
+			}
+			seqs = append(seqs, r.Seq)
+			if r.Action != nil {
+				uuids = append(uuids, r.Action.UUID)
+			}
+		}
+		seenSeqs := make(map[int64]bool, len(seqs))
+		seenUUIDs := make(map[uuid.UUID]bool, len(uuids))
+		if len(seqs) > 0 {
+			var existing []int64
+			if err := i.DBWithContext(txCtx).Model(&model.EdgeIngestSeq{}).
+				Where("stream_id = ? AND seq IN ?", st.ID, seqs).
+				Pluck("seq", &existing).Error; err != nil {
+				logger.Errorf(ctx, "Ingest query seqs fail stream id=%d: %+v", st.ID, err)
+				return code.QueryRecordErr.WithErr(err)
+			}
+			for _, seq := range existing {
+				seenSeqs[seq] = true
+			}
+
+			var written []uuid.UUID
+			if len(uuids) > 0 {
+				if err := i.DBWithContext(txCtx).Model(&model.ActionExecutionHistory{}).
+					Where("uuid IN ?", uuids).
+					Pluck("uuid", &written).Error; err != nil {
+					logger.Errorf(ctx, "Ingest query uuids fail stream id=%d: %+v", st.ID, err)
+					return code.QueryRecordErr.WithErr(err)
+				}
+			}
+			for _, id := range written {
+				seenUUIDs[id] = true
+			}
+		}
+
+		sorted := slices.Clone(records)
+		slices.SortStableFunc(sorted, func(a, b *model.IngestRecord) int {
+			switch {
+			case a.Seq < b.Seq:
+				return -1
+			case a.Seq > b.Seq:
+				return 1
+			}
+			return 0
+		})
+
+		actions := make([]*model.ActionExecutionHistory, 0, len(sorted))
+		consumed := make([]*model.EdgeIngestSeq, 0, len(sorted))
+		for _, r := range sorted {
+			if r.Seq <= st.HighWaterMark || seenSeqs[r.Seq] {
+				result.Duplicates[r.Seq] = true
+				continue
+			}
+			seenSeqs[r.Seq] = true
+			consumed = append(consumed, &model.EdgeIngestSeq{StreamID: st.ID, Seq: r.Seq})
+			if r.Action == nil {
+				continue
+			}
+			// 序号不同但 UUID 已写入，视为同一条记录的重复上报
+			if seenUUIDs[r.Action.UUID] {
+				result.Duplicates[r.Seq] = true
+				continue
+			}
+			seenUUIDs[r.Action.UUID] = true
+			actions = append(actions, r.Action)
+			result.Accepted[r.Seq] = true
+		}
+
+		if len(actions) > 0 {
+			// 只检查未写入过的记录，重复上报不会再次转存
+			for _, action := range actions {
+				if err := i.payloads.ActionExecution(txCtx, action); err != nil {
+					return err
+				}
+			}
+			// 跳过 hook，保留 edge 上报的原始时间
+			if err := i.DBWithContext(txCtx).Session(&gorm.Session{SkipHooks: true}).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "uuid"}},
+				DoNothing: true,
+			}).CreateInBatches(actions, insertBatch).Error; err != nil {
+				logger.Errorf(ctx, "Ingest actions fail stream id=%d: %+v", st.ID, err)
+				return code.CreateDataErr.WithErr(err)
+			}
+		}
+		if len(consumed) > 0 {
+			if err := i.DBWithContext(txCtx).Clauses(clause.OnConflict{DoNothing: true}).
+				CreateInBatches(consumed, insertBatch).Error; err != nil {
+				logger.Errorf(ctx, "Ingest seqs fail stream id=%d: %+v", st.ID, err)
+				return code.CreateDataErr.WithErr(err)
+			}
+		}
+
+		var received []int64
+		if err := i.DBWithContext(txCtx).Model(&model.EdgeIngestSeq{}).
+			Where("stream_id = ? AND seq > ?", st.ID, st.HighWaterMark).
+			Order("seq ASC").
+			Pluck("seq", &received).Error; err != nil {
+			logger.Errorf(ctx, "Ingest query received seqs fail stream id=%d: %+v", st.ID, err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+		hwm := model.AdvanceHighWaterMark(st.HighWaterMark, received)
+		if hwm > st.HighWaterMark {
+			if err := i.DBWithContext(txCtx).
+				Where("stream_id = ? AND seq <= ?", st.ID, hwm).
+				Delete(&model.EdgeIngestSeq{}).Error; err != nil {
+				logger.Errorf(ctx, "Ingest delete seqs fail stream id=%d: %+v", st.ID, err)
+				return code.DeleteDataErr.WithErr(err)
+			}
+		}
+
+		maxSeq := st.MaxSeq
+		if len(received) > 0 {
+			maxSeq = max(maxSeq, received[len(received)-1])
+		}
+		updates := map[string]any{
+			"high_water_mark":  hwm,
+			"max_seq":          max(maxSeq, hwm),
+			"accepted":         gorm.Expr("accepted + ?", len(result.Accepted)),
+			"duplicates":       gorm.Expr("duplicates + ?", len(result.Duplicates)),
+			"last_ingested_at": now,
+			"updated_at":       now,
+		}
+		if err := i.DBWithContext(txCtx).Model(&model.EdgeIngestStream{}).
+			Where("id = ?", st.ID).Updates(updates).Error; err != nil {
+			logger.Errorf(ctx, "Ingest update stream fail id=%d: %+v", st.ID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+		st.HighWaterMark = hwm
+		st.MaxSeq = max(maxSeq, hwm)
+		st.Accepted += int64(len(result.Accepted))
+		st.Duplicates += int64(len(result.Duplicates))
+		st.LastIngestedAt = now
+		result.Stream = st
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetStream retrieves a stream of a lab
+func (i *ingestImpl) GetStream(ctx context.Context, labID int64, stream string) (*model.EdgeIngestStream, error) {
+	var data model.EdgeIngestStream
+	if err := i.DBWithContext(ctx).Where("lab_id = ? AND stream = ?", labID, stream).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetStream fail lab=%d stream=%s: %+v", labID, stream, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListReceivedSeqs lists the sequence numbers waiting for the gaps below them
+func (i *ingestImpl) ListReceivedSeqs(ctx context.Context, streamID int64) ([]int64, error) {
+	var seqs []int64
+	if err := i.DBWithContext(ctx).Model(&model.EdgeIngestSeq{}).
+		Where("stream_id = ?", streamID).
+		Order("seq ASC").
+		Pluck("seq", &seqs).Error; err != nil {
+		logger.Errorf(ctx, "ListReceivedSeqs fail stream id=%d: %+v", streamID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return seqs, nil
+}
+
+// ListStreams lists the streams of a lab
+func (i *ingestImpl) ListStreams(ctx context.Context, labID int64) ([]*model.EdgeIngestStream, error) {
+	var datas []*model.EdgeIngestStream
+	if err := i.DBWithContext(ctx).
+		Where("lab_id = ?", labID).
+		Order("last_ingested_at DESC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListStreams fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
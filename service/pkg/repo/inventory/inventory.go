@@ -0,0 +1,170 @@
+// Package inventory provides repository operations for material lots and their usage.
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// MaterialQuery represents filters for listing material lots
+type MaterialQuery struct {
+	LabID    int64
+	Kind     model.MaterialKind
+	Keyword  string // 名称或批号
+	Page     int
+	PageSize int
+}
+
+// InventoryRepo defines the interface for material inventory repository operations
+type InventoryRepo interface {
+	CreateMaterial(ctx context.Context, material *model.Material) error
+	GetMaterialByUUID(ctx context.Context, uuid uuid.UUID) (*model.Material, error)
+	ListMaterials(ctx context.Context, query *MaterialQuery) ([]*model.Material, int64, error)
+	// RecordUsages deducts the consumed quantities and creates the usage records
+	// in one transaction, failing with MaterialInsufficientErr when a lot runs out
+	RecordUsages(ctx context.Context, usages []*model.MaterialUsage) error
+	ListUsagesByMaterial(ctx context.Context, materialID int64) ([]*model.MaterialUsage, error)
+	// SummarizeUsages sums the consumed quantity per lot of a workflow execution
+	SummarizeUsages(ctx context.Context, workflowExecutionID int64) ([]*model.MaterialUsageSummary, error)
+
+	// ListSyncLabs lists labs whose inventory is synced with the LIMS
+	ListSyncLabs(ctx context.Context) ([]*model.Laboratory, error)
+	ListLabMaterials(ctx context.Context, labID int64) ([]*model.Material, error)
+	// ListUsedSinceSync returns ids of lots consumed locally after their last sync
+	ListUsedSinceSync(ctx context.Context, labID int64) (map[int64]bool, error)
+	// ApplySync creates and updates lots and upserts conflicts of a sync run in one transaction
+	ApplySync(ctx context.Context, run *model.MaterialSyncRun, creates, updates []*model.Material, conflicts []*model.MaterialSyncConflict) error
+	CreateSyncRun(ctx context.Context, run *model.MaterialSyncRun) error
+	FinishSyncRun(ctx context.Context, run *model.MaterialSyncRun) error
+	// GetRunningSyncRun returns the run of a lab started after since and not finished yet
+	GetRunningSyncRun(ctx context.Context, labID int64, since time.Time) (*model.MaterialSyncRun, error)
+	ListSyncRuns(ctx context.Context, labID int64, limit int) ([]*model.MaterialSyncRun, error)
+	ListSyncConflicts(ctx context.Context, labID int64, includeResolved bool) ([]*model.MaterialSyncConflict, error)
+	DeleteSyncRunsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type inventoryImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new material inventory repository instance
+func New() InventoryRepo {
+	return &inventoryImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateMaterial registers a material lot
+func (i *inventoryImpl) CreateMaterial(ctx context.Context, material *model.Material) error {
+	if err := i.DBWithContext(ctx).Create(material).Error; err != nil {
+		logger.Errorf(ctx, "CreateMaterial fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetMaterialByUUID retrieves a material lot by UUID
+func (i *inventoryImpl) GetMaterialByUUID(ctx context.Context, id uuid.UUID) (*model.Material, error) {
+	var data model.Material
+	if err := i.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetMaterialByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListMaterials lists material lots with pagination
+func (i *inventoryImpl) ListMaterials(ctx context.Context, query *MaterialQuery) ([]*model.Material, int64, error) {
+	db := i.DBWithContext(ctx).Model(&model.Material{}).Where("lab_id = ?", query.LabID)
+	if query.Kind != "" {
+		db = db.Where("kind = ?", query.Kind)
+	}
+	if query.Keyword != "" {
+		like := "%" + query.Keyword + "%"
+		db = db.Where("name ILIKE ? OR lot_number ILIKE ?", like, like)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListMaterials count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	var datas []*model.Material
+	if err := db.Order("created_at DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListMaterials fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// RecordUsages deducts remaining quantities and records usages
+func (i *inventoryImpl) RecordUsages(ctx context.Context, usages []*model.MaterialUsage) error {
+	return i.ExecTx(ctx, func(txCtx context.Context) error {
+		now := time.Now()
+		for _, usage := range usages {
+			ret := i.DBWithContext(txCtx).Model(&model.Material{}).
+				Where("id = ? AND remaining >= ?", usage.MaterialID, usage.Quantity).
+				Updates(map[string]any{
+					"remaining":  gorm.Expr("remaining - ?", usage.Quantity),
+					"updated_at": now,
+				})
+			if ret.Error != nil {
+				logger.Errorf(ctx, "RecordUsages deduct fail material=%d: %+v", usage.MaterialID, ret.Error)
+				return code.UpdateDataErr.WithErr(ret.Error)
+			}
+			if ret.RowsAffected == 0 {
+				return code.MaterialInsufficientErr.WithMsgf("material %s remaining insufficient", usage.MaterialUUID)
+			}
+		}
+
+		if err := i.DBWithContext(txCtx).Create(&usages).Error; err != nil {
+			logger.Errorf(ctx, "RecordUsages create fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// ListUsagesByMaterial lists usages of a material lot, newest first
+func (i *inventoryImpl) ListUsagesByMaterial(ctx context.Context, materialID int64) ([]*model.MaterialUsage, error) {
+	var datas []*model.MaterialUsage
+	if err := i.DBWithContext(ctx).
+		Where("material_id = ?", materialID).
+		Order("created_at DESC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListUsagesByMaterial fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// SummarizeUsages sums usages of a workflow execution per material lot
+func (i *inventoryImpl) SummarizeUsages(ctx context.Context, workflowExecutionID int64) ([]*model.MaterialUsageSummary, error) {
+	var datas []*model.MaterialUsageSummary
+	if err := i.DBWithContext(ctx).Table("material_usage AS u").
+		Select(`m.uuid AS material_uuid, m.name, m.lot_number, m.kind, m.unit,
+			SUM(u.quantity) AS quantity`).
+		Joins("JOIN material AS m ON m.id = u.material_id").
+		Where("u.workflow_execution_id = ?", workflowExecutionID).
+		Group("m.uuid, m.name, m.lot_number, m.kind, m.unit").
+		Order("m.name ASC, m.lot_number ASC").
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "SummarizeUsages fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
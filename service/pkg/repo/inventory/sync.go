@@ -0,0 +1,197 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListSyncLabs lists labs that are not deleted
+func (i *inventoryImpl) ListSyncLabs(ctx context.Context) ([]*model.Laboratory, error) {
+	var datas []*model.Laboratory
+	if err := i.DBWithContext(ctx).
+		Select("id", "uuid").
+		Where("status <> ?", model.DELETED).
+		Order("id ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListSyncLabs fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListLabMaterials lists all lots of a lab
+func (i *inventoryImpl) ListLabMaterials(ctx context.Context, labID int64) ([]*model.Material, error) {
+	var datas []*model.Material
+	if err := i.DBWithContext(ctx).Where("lab_id = ?", labID).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListLabMaterials fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListUsedSinceSync returns lots with usages recorded after their last sync
+func (i *inventoryImpl) ListUsedSinceSync(ctx context.Context, labID int64) (map[int64]bool, error) {
+	var ids []int64
+	if err := i.DBWithContext(ctx).Table("material_usage AS u").
+		Joins("JOIN material AS m ON m.id = u.material_id").
+		Where("m.lab_id = ? AND m.synced_at IS NOT NULL AND u.created_at > m.synced_at", labID).
+		Distinct("u.material_id").
+		Pluck("u.material_id", &ids).Error; err != nil {
+		logger.Errorf(ctx, "ListUsedSinceSync fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	used := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		used[id] = true
+	}
+	return used, nil
+}
+
+// ApplySync writes the reconciliation result of a sync run
+func (i *inventoryImpl) ApplySync(ctx context.Context, run *model.MaterialSyncRun, creates, updates []*model.Material, conflicts []*model.MaterialSyncConflict) error {
+	return i.ExecTx(ctx, func(txCtx context.Context) error {
+		if len(creates) > 0 {
+			if err := i.DBWithContext(txCtx).Create(&creates).Error; err != nil {
+				logger.Errorf(ctx, "ApplySync create fail: %+v", err)
+				return code.CreateDataErr.WithErr(err)
+			}
+		}
+
+		now := time.Now()
+		for _, m := range updates {
+			m.UpdatedAt = now
+			if err := i.DBWithContext(txCtx).Model(m).
+				Select("name", "kind", "unit", "quantity", "remaining", "expires_at", "source", "external_id", "synced_at", "updated_at").
+				Updates(m).Error; err != nil {
+				logger.Errorf(ctx, "ApplySync update fail id=%d: %+v", m.ID, err)
+				return code.UpdateDataErr.WithErr(err)
+			}
+		}
+
+		if len(conflicts) > 0 {
+			if err := i.DBWithContext(txCtx).Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "lab_id"}, {Name: "external_id"}, {Name: "field"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"material_id", "lot_number", "local_value", "remote_value", "resolution", "run_id", "updated_at",
+				}),
+			}).Create(&conflicts).Error; err != nil {
+				logger.Errorf(ctx, "ApplySync upsert conflicts fail: %+v", err)
+				return code.CreateDataErr.WithErr(err)
+			}
+		}
+
+		// 本次同步不再出现的冲突视为已解决，重新出现时重新打开
+		if err := i.DBWithContext(txCtx).Model(&model.MaterialSyncConflict{}).
+			Where("lab_id = ? AND run_id = ?", run.LabID, run.ID).
+			Update("resolved_at", nil).Error; err != nil {
+			logger.Errorf(ctx, "ApplySync reopen conflicts fail: %+v", err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+		if err := i.DBWithContext(txCtx).Model(&model.MaterialSyncConflict{}).
+			Where("lab_id = ? AND run_id <> ? AND resolved_at IS NULL", run.LabID, run.ID).
+			Updates(map[string]any{
+				"resolved_at": now,
+				"updated_at":  now,
+			}).Error; err != nil {
+			logger.Errorf(ctx, "ApplySync resolve conflicts fail: %+v", err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// CreateSyncRun creates a running sync run
+func (i *inventoryImpl) CreateSyncRun(ctx context.Context, run *model.MaterialSyncRun) error {
+	run.Status = model.MaterialSyncRunning
+	if err := i.DBWithContext(ctx).Create(run).Error; err != nil {
+		logger.Errorf(ctx, "CreateSyncRun fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// FinishSyncRun saves the result of a sync run
+func (i *inventoryImpl) FinishSyncRun(ctx context.Context, run *model.MaterialSyncRun) error {
+	now := time.Now()
+	run.FinishedAt = &now
+	if err := i.DBWithContext(ctx).Model(run).
+		Select("status", "finished_at", "fetched", "created", "updated", "conflicts", "error", "updated_at").
+		Updates(&model.MaterialSyncRun{
+			BaseModel:  model.BaseModel{UpdatedAt: now},
+			Status:     run.Status,
+			FinishedAt: run.FinishedAt,
+			Fetched:    run.Fetched,
+			Created:    run.Created,
+			Updated:    run.Updated,
+			Conflicts:  run.Conflicts,
+			Error:      run.Error,
+		}).Error; err != nil {
+		logger.Errorf(ctx, "FinishSyncRun fail id=%d: %+v", run.ID, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetRunningSyncRun retrieves an unfinished run of a lab
+func (i *inventoryImpl) GetRunningSyncRun(ctx context.Context, labID int64, since time.Time) (*model.MaterialSyncRun, error) {
+	var data model.MaterialSyncRun
+	if err := i.DBWithContext(ctx).
+		Where("lab_id = ? AND status = ? AND started_at > ?", labID, model.MaterialSyncRunning, since).
+		Order("started_at DESC").
+		First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetRunningSyncRun fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListSyncRuns lists the latest sync runs of a lab
+func (i *inventoryImpl) ListSyncRuns(ctx context.Context, labID int64, limit int) ([]*model.MaterialSyncRun, error) {
+	var datas []*model.MaterialSyncRun
+	if err := i.DBWithContext(ctx).
+		Where("lab_id = ?", labID).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListSyncRuns fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListSyncConflicts lists sync conflicts of a lab
+func (i *inventoryImpl) ListSyncConflicts(ctx context.Context, labID int64, includeResolved bool) ([]*model.MaterialSyncConflict, error) {
+	query := i.DBWithContext(ctx).Where("lab_id = ?", labID)
+	if !includeResolved {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	var datas []*model.MaterialSyncConflict
+	if err := query.Order("updated_at DESC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListSyncConflicts fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// DeleteSyncRunsBefore deletes finished sync runs started before the given time
+func (i *inventoryImpl) DeleteSyncRunsBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := i.DBWithContext(ctx).
+		Where("started_at < ? AND status <> ?", before, model.MaterialSyncRunning).
+		Delete(&model.MaterialSyncRun{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteSyncRunsBefore fail: %+v", ret.Error)
+		return 0, code.DeleteDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
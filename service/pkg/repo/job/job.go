@@ -0,0 +1,254 @@
+// Package job provides repository operations for background job definitions and runs.
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// JobRepo defines the interface for background job repository operations
+type JobRepo interface {
+	// SaveDefinition creates the job or updates its definition fields,
+	// keeping pause and schedule state untouched
+	SaveDefinition(ctx context.Context, job *model.BackgroundJob) (*model.BackgroundJob, error)
+	GetJobByName(ctx context.Context, name string) (*model.BackgroundJob, error)
+	ListJobs(ctx context.Context) ([]*model.BackgroundJob, error)
+	// ListDueJobs returns jobs whose next run is due or that were triggered manually
+	ListDueJobs(ctx context.Context, now time.Time) ([]*model.BackgroundJob, error)
+	// ClaimJob moves the job to its next run time, returning false if another
+	// scheduler already claimed it or it is no longer due
+	ClaimJob(ctx context.Context, job *model.BackgroundJob, nextRunAt *time.Time) (bool, error)
+	SetNextRun(ctx context.Context, name string, nextRunAt *time.Time) error
+	SetPaused(ctx context.Context, name string, paused bool) error
+	RequestTrigger(ctx context.Context, name string) error
+	CreateRun(ctx context.Context, run *model.BackgroundJobRun) error
+	// FinishRun stores the run result and the last status of its job
+	FinishRun(ctx context.Context, run *model.BackgroundJobRun) error
+	ListRuns(ctx context.Context, jobID int64, page, pageSize int) ([]*model.BackgroundJobRun, int64, error)
+	DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type jobImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new background job repository instance
+func New() JobRepo {
+	return &jobImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// SaveDefinition creates or updates a job definition
+func (j *jobImpl) SaveDefinition(ctx context.Context, job *model.BackgroundJob) (*model.BackgroundJob, error) {
+	data, err := j.GetJobByName(ctx, job.Name)
+	if err == code.RecordNotFound {
+		if err := j.DBWithContext(ctx).Create(job).Error; err != nil {
+			logger.Errorf(ctx, "SaveDefinition create fail name=%s: %+v", job.Name, err)
+			return nil, code.CreateDataErr.WithErr(err)
+		}
+		return job, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.DBWithContext(ctx).Model(&model.BackgroundJob{}).
+		Where("id = ?", data.ID).
+		Updates(map[string]any{
+			"description":       job.Description,
+			"schedule_type":     job.ScheduleType,
+			"schedule":          job.Schedule,
+			"timeout_sec":       job.TimeoutSec,
+			"max_retries":       job.MaxRetries,
+			"retry_backoff_sec": job.RetryBackoffSec,
+			"updated_at":        time.Now(),
+		}).Error; err != nil {
+		logger.Errorf(ctx, "SaveDefinition update fail name=%s: %+v", job.Name, err)
+		return nil, code.UpdateDataErr.WithErr(err)
+	}
+
+	data.Description = job.Description
+	data.ScheduleType = job.ScheduleType
+	data.Schedule = job.Schedule
+	data.TimeoutSec = job.TimeoutSec
+	data.MaxRetries = job.MaxRetries
+	data.RetryBackoffSec = job.RetryBackoffSec
+	return data, nil
+}
+
+// GetJobByName retrieves a job by name
+func (j *jobImpl) GetJobByName(ctx context.Context, name string) (*model.BackgroundJob, error) {
+	var data model.BackgroundJob
+	if err := j.DBWithContext(ctx).Where("name = ?", name).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetJobByName fail name=%s: %+v", name, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListJobs lists all jobs ordered by name
+func (j *jobImpl) ListJobs(ctx context.Context) ([]*model.BackgroundJob, error) {
+	var datas []*model.BackgroundJob
+	if err := j.DBWithContext(ctx).Order("name ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListJobs fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListDueJobs lists jobs that should run now
+func (j *jobImpl) ListDueJobs(ctx context.Context, now time.Time) ([]*model.BackgroundJob, error) {
+	var datas []*model.BackgroundJob
+	if err := j.DBWithContext(ctx).
+		Where("trigger_requested = ? OR (paused = ? AND next_run_at <= ?)", true, false, now).
+		Order("next_run_at ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListDueJobs fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ClaimJob conditionally advances the schedule of a due job
+func (j *jobImpl) ClaimJob(ctx context.Context, job *model.BackgroundJob, nextRunAt *time.Time) (bool, error) {
+	query := j.DBWithContext(ctx).Model(&model.BackgroundJob{}).Where("id = ?", job.ID)
+	if job.TriggerRequested {
+		query = query.Where("trigger_requested = ?", true)
+	} else {
+		query = query.Where("paused = ? AND next_run_at = ?", false, job.NextRunAt)
+	}
+
+	updates := map[string]any{
+		"trigger_requested": false,
+		"last_run_at":       time.Now(),
+		"updated_at":        time.Now(),
+	}
+	// 手动触发不影响原有调度
+	if !job.TriggerRequested {
+		updates["next_run_at"] = nextRunAt
+	}
+
+	ret := query.Updates(updates)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "ClaimJob fail name=%s: %+v", job.Name, ret.Error)
+		return false, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected == 1, nil
+}
+
+// SetNextRun sets the next scheduled run of a job
+func (j *jobImpl) SetNextRun(ctx context.Context, name string, nextRunAt *time.Time) error {
+	return j.updateByName(ctx, "SetNextRun", name, map[string]any{
+		"next_run_at": nextRunAt,
+		"updated_at":  time.Now(),
+	})
+}
+
+// SetPaused pauses or resumes a job
+func (j *jobImpl) SetPaused(ctx context.Context, name string, paused bool) error {
+	return j.updateByName(ctx, "SetPaused", name, map[string]any{
+		"paused":     paused,
+		"updated_at": time.Now(),
+	})
+}
+
+// RequestTrigger asks the scheduler to run the job as soon as possible
+func (j *jobImpl) RequestTrigger(ctx context.Context, name string) error {
+	return j.updateByName(ctx, "RequestTrigger", name, map[string]any{
+		"trigger_requested": true,
+		"updated_at":        time.Now(),
+	})
+}
+
+func (j *jobImpl) updateByName(ctx context.Context, op, name string, updates map[string]any) error {
+	ret := j.DBWithContext(ctx).Model(&model.BackgroundJob{}).Where("name = ?", name).Updates(updates)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "%s fail name=%s: %+v", op, name, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// CreateRun stores a new run record
+func (j *jobImpl) CreateRun(ctx context.Context, run *model.BackgroundJobRun) error {
+	if err := j.DBWithContext(ctx).Create(run).Error; err != nil {
+		logger.Errorf(ctx, "CreateRun fail job=%s: %+v", run.JobName, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// FinishRun stores the result of a run
+func (j *jobImpl) FinishRun(ctx context.Context, run *model.BackgroundJobRun) error {
+	return j.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := j.DBWithContext(txCtx).Model(&model.BackgroundJobRun{}).
+			Where("id = ?", run.ID).
+			Updates(map[string]any{
+				"status":      run.Status,
+				"attempts":    run.Attempts,
+				"finished_at": run.FinishedAt,
+				"duration_ms": run.DurationMs,
+				"error":       run.Error,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+			logger.Errorf(ctx, "FinishRun fail id=%d: %+v", run.ID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+
+		if err := j.DBWithContext(txCtx).Model(&model.BackgroundJob{}).
+			Where("id = ?", run.JobID).
+			Updates(map[string]any{
+				"last_status": run.Status,
+				"last_error":  run.Error,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+			logger.Errorf(ctx, "FinishRun update job fail id=%d: %+v", run.JobID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// ListRuns lists runs of a job, newest first
+func (j *jobImpl) ListRuns(ctx context.Context, jobID int64, page, pageSize int) ([]*model.BackgroundJobRun, int64, error) {
+	var datas []*model.BackgroundJobRun
+	var total int64
+
+	query := j.DBWithContext(ctx).Model(&model.BackgroundJobRun{}).Where("job_id = ?", jobID)
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListRuns count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("started_at DESC").Offset(offset).Limit(pageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListRuns fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// DeleteRunsBefore removes finished runs started before the given time
+func (j *jobImpl) DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := j.DBWithContext(ctx).
+		Where("started_at < ? AND status <> ?", before, model.JobRunStatusRunning).
+		Delete(&model.BackgroundJobRun{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteRunsBefore fail: %+v", ret.Error)
+		return 0, code.DeleteDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
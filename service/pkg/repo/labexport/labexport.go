@@ -0,0 +1,181 @@
+// Package labexport provides repository operations for lab data exports and
+// the deletion of all data of a lab.
+package labexport
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// LabExportRepo defines the interface for lab export repository operations
+type LabExportRepo interface {
+	CreateExport(ctx context.Context, data *model.LabExport) error
+	GetExportByUUID(ctx context.Context, exportUUID uuid.UUID) (*model.LabExport, error)
+	// ListExports lists the exports of a lab, newest first
+	ListExports(ctx context.Context, labID int64) ([]*model.LabExport, error)
+	// UpdateExport updates the given columns of an export
+	UpdateExport(ctx context.Context, data *model.LabExport, columns ...string) error
+	// ListExpiredExports lists completed exports whose archive expired before the given time
+	ListExpiredExports(ctx context.Context, before time.Time, limit int) ([]*model.LabExport, error)
+	// FailStaleExports marks unfinished exports not updated since the given time as failed
+	FailStaleExports(ctx context.Context, before time.Time) (int64, error)
+
+	// TableRows reads rows of a lab table with id greater than afterID in id order
+	TableRows(ctx context.Context, table model.LabTable, labID int64, afterID int64, limit int) ([]map[string]any, error)
+	// StorageKeys lists the object storage keys of the files and reports of a lab
+	StorageKeys(ctx context.Context, labID int64) ([]string, error)
+	// PurgeLab deletes the rows of all lab tables not kept, children first
+	PurgeLab(ctx context.Context, labID int64) (int64, error)
+}
+
+type labExportImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new lab export repository instance
+func New() LabExportRepo {
+	return &labExportImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateExport creates an export
+func (l *labExportImpl) CreateExport(ctx context.Context, data *model.LabExport) error {
+	if err := l.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateExport fail lab=%d: %+v", data.LabID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetExportByUUID retrieves an export by UUID
+func (l *labExportImpl) GetExportByUUID(ctx context.Context, exportUUID uuid.UUID) (*model.LabExport, error) {
+	var data model.LabExport
+	if err := l.DBWithContext(ctx).Where("uuid = ?", exportUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetExportByUUID fail uuid=%s: %+v", exportUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListExports lists the exports of a lab
+func (l *labExportImpl) ListExports(ctx context.Context, labID int64) ([]*model.LabExport, error) {
+	var datas []*model.LabExport
+	if err := l.DBWithContext(ctx).Where("lab_id = ?", labID).
+		Order("id DESC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListExports fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateExport updates the given columns of an export
+func (l *labExportImpl) UpdateExport(ctx context.Context, data *model.LabExport, columns ...string) error {
+	data.UpdatedAt = time.Now()
+	columns = append(columns, "updated_at")
+	res := l.DBWithContext(ctx).Model(data).Select(columns).Updates(data)
+	if res.Error != nil {
+		logger.Errorf(ctx, "UpdateExport fail uuid=%s: %+v", data.UUID, res.Error)
+		return code.UpdateDataErr.WithErr(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// ListExpiredExports lists expired completed exports
+func (l *labExportImpl) ListExpiredExports(ctx context.Context, before time.Time, limit int) ([]*model.LabExport, error) {
+	var datas []*model.LabExport
+	if err := l.DBWithContext(ctx).
+		Where("status = ? AND expires_at < ?", model.LabExportCompleted, before).
+		Order("expires_at ASC").Limit(limit).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListExpiredExports fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// FailStaleExports fails exports interrupted by a restart
+func (l *labExportImpl) FailStaleExports(ctx context.Context, before time.Time) (int64, error) {
+	res := l.DBWithContext(ctx).Model(&model.LabExport{}).
+		Where("status IN ? AND updated_at < ?",
+			[]model.LabExportStatus{model.LabExportPending, model.LabExportRunning}, before).
+		Updates(map[string]any{
+			"status":     model.LabExportFailed,
+			"error":      "export interrupted",
+			"updated_at": time.Now(),
+		})
+	if res.Error != nil {
+		logger.Errorf(ctx, "FailStaleExports fail: %+v", res.Error)
+		return 0, code.UpdateDataErr.WithErr(res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// TableRows reads one page of a lab table
+func (l *labExportImpl) TableRows(ctx context.Context, table model.LabTable, labID int64, afterID int64, limit int) ([]map[string]any, error) {
+	var datas []map[string]any
+	if err := l.DBWithContext(ctx).Table(table.Name).
+		Where(table.Filter, labID).
+		Where("id > ?", afterID).
+		Order("id ASC").Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "TableRows fail table=%s lab=%d: %+v", table.Name, labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	for _, data := range datas {
+		for _, column := range table.Omit {
+			delete(data, column)
+		}
+	}
+	return datas, nil
+}
+
+// StorageKeys lists the storage keys of a lab
+func (l *labExportImpl) StorageKeys(ctx context.Context, labID int64) ([]string, error) {
+	var keys []string
+	if err := l.DBWithContext(ctx).Raw(
+		`SELECT storage_key FROM result_file WHERE lab_id = ? AND storage_key <> ''
+		UNION ALL
+		SELECT storage_key FROM report_delivery WHERE lab_id = ? AND storage_key <> ''`,
+		labID, labID).Scan(&keys).Error; err != nil {
+		logger.Errorf(ctx, "StorageKeys fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return keys, nil
+}
+
+// PurgeLab deletes the data of a lab in one transaction
+func (l *labExportImpl) PurgeLab(ctx context.Context, labID int64) (int64, error) {
+	var deleted int64
+	err := l.ExecTx(ctx, func(txCtx context.Context) error {
+		for i := len(model.LabDataTables) - 1; i >= 0; i-- {
+			table := model.LabDataTables[i]
+			if table.Keep {
+				continue
+			}
+			res := l.DBWithContext(txCtx).Exec("DELETE FROM "+table.Name+" WHERE "+table.Filter, labID)
+			if res.Error != nil {
+				logger.Errorf(ctx, "PurgeLab fail table=%s lab=%d: %+v", table.Name, labID, res.Error)
+				return code.DeleteDataErr.WithErr(res.Error)
+			}
+			deleted += res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,206 @@
+// Package legalhold provides repository operations for legal holds and the
+// filters that keep held history records out of the retention cleanup.
+package legalhold
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// ListFilter filters legal holds
+type ListFilter struct {
+	LabID  int64 // 0 表示不限
+	Active *bool
+}
+
+// LegalHoldRepo defines the interface for legal hold repository operations
+type LegalHoldRepo interface {
+	// Place creates the hold together with its audit log entry
+	Place(ctx context.Context, data *model.LegalHold, audit *model.AuditLog) error
+	// Release releases an active hold together with its audit log entry,
+	// reporting false when the hold was already released
+	Release(ctx context.Context, id int64, updates map[string]any, audit *model.AuditLog) (bool, error)
+	GetByUUID(ctx context.Context, holdUUID uuid.UUID) (*model.LegalHold, error)
+	// List lists holds newest first
+	List(ctx context.Context, filter *ListFilter, page, pageSize int) ([]*model.LegalHold, int64, error)
+	// ListActive lists every hold that is not released
+	ListActive(ctx context.Context) ([]*model.LegalHold, error)
+}
+
+type legalHoldImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new legal hold repository instance
+func New() LegalHoldRepo {
+	return &legalHoldImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// Place creates a hold and audits it in one transaction
+func (l *legalHoldImpl) Place(ctx context.Context, data *model.LegalHold, audit *model.AuditLog) error {
+	return l.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := l.DBWithContext(txCtx).Create(data).Error; err != nil {
+			logger.Errorf(ctx, "Place legal hold fail scope=%s: %+v", data.Scope, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		if err := l.DBWithContext(txCtx).Create(audit).Error; err != nil {
+			logger.Errorf(ctx, "Place legal hold audit fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// Release releases a hold and audits it in one transaction
+func (l *legalHoldImpl) Release(ctx context.Context, id int64, updates map[string]any, audit *model.AuditLog) (bool, error) {
+	released := false
+	err := l.ExecTx(ctx, func(txCtx context.Context) error {
+		ret := l.DBWithContext(txCtx).Model(&model.LegalHold{}).
+			Where("id = ? AND released_at IS NULL", id).
+			Updates(updates)
+		if ret.Error != nil {
+			logger.Errorf(ctx, "Release legal hold fail id=%d: %+v", id, ret.Error)
+			return code.UpdateDataErr.WithErr(ret.Error)
+		}
+		if ret.RowsAffected == 0 {
+			return nil
+		}
+		released = true
+		if err := l.DBWithContext(txCtx).Create(audit).Error; err != nil {
+			logger.Errorf(ctx, "Release legal hold audit fail id=%d: %+v", id, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return released, nil
+}
+
+// GetByUUID gets a hold by UUID
+func (l *legalHoldImpl) GetByUUID(ctx context.Context, holdUUID uuid.UUID) (*model.LegalHold, error) {
+	data := &model.LegalHold{}
+	if err := l.DBWithContext(ctx).Where("uuid = ?", holdUUID).First(data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetByUUID legal hold fail uuid=%s: %+v", holdUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return data, nil
+}
+
+// List lists holds
+func (l *legalHoldImpl) List(ctx context.Context, filter *ListFilter, page, pageSize int) ([]*model.LegalHold, int64, error) {
+	query := l.DBWithContext(ctx).Model(&model.LegalHold{})
+	if filter.LabID > 0 {
+		query = query.Where("lab_id = ?", filter.LabID)
+	}
+	if filter.Active != nil {
+		if *filter.Active {
+			query = query.Where("released_at IS NULL")
+		} else {
+			query = query.Where("released_at IS NOT NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "List legal hold count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	datas := make([]*model.LegalHold, 0)
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "List legal hold fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// ListActive lists the holds that are not released
+func (l *legalHoldImpl) ListActive(ctx context.Context) ([]*model.LegalHold, error) {
+	datas := make([]*model.LegalHold, 0)
+	if err := l.DBWithContext(ctx).Where("released_at IS NULL").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListActive legal hold fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// Columns names the columns of a history table that holds are matched
+// against, a scope whose column is empty does not apply to the table
+type Columns struct {
+	LabID       string
+	ExecutionID string
+	WorkflowID  string
+	Time        string
+}
+
+// Condition returns the SQL matching the rows covered by any of the holds,
+// empty when none applies to the table. The holds are expanded into the SQL
+// so that it also works on stores without the legal_hold table
+func Condition(holds []*model.LegalHold, cols Columns) (string, []any) {
+	conds := make([]string, 0, len(holds))
+	args := make([]any, 0, len(holds))
+	for _, h := range holds {
+		switch h.Scope {
+		case model.LegalHoldExecution:
+			if cols.ExecutionID != "" {
+				conds = append(conds, cols.ExecutionID+" = ?")
+				args = append(args, h.ExecutionID)
+			}
+		case model.LegalHoldWorkflow:
+			if cols.WorkflowID != "" {
+				conds = append(conds, cols.WorkflowID+" = ?")
+				args = append(args, h.WorkflowID)
+			}
+		case model.LegalHoldLab:
+			if cols.LabID != "" {
+				conds = append(conds, cols.LabID+" = ?")
+				args = append(args, h.LabID)
+			}
+		case model.LegalHoldTimeRange:
+			if cols.Time == "" || h.StartsAt == nil || h.EndsAt == nil {
+				continue
+			}
+			if h.LabID > 0 {
+				if cols.LabID == "" {
+					continue
+				}
+				conds = append(conds, "("+cols.Time+" >= ? AND "+cols.Time+" < ? AND "+cols.LabID+" = ?)")
+				args = append(args, *h.StartsAt, *h.EndsAt, h.LabID)
+				continue
+			}
+			conds = append(conds, "("+cols.Time+" >= ? AND "+cols.Time+" < ?)")
+			args = append(args, *h.StartsAt, *h.EndsAt)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return strings.Join(conds, " OR "), args
+}
+
+// Exclude filters the rows covered by the holds out of the query
+func Exclude(query *gorm.DB, holds []*model.LegalHold, cols Columns) *gorm.DB {
+	cond, args := Condition(holds, cols)
+	if cond == "" {
+		return query
+	}
+	// 可为空的列比较结果为 NULL 时视为未保全
+	return query.Where("NOT COALESCE(("+cond+"), false)", args...)
+}
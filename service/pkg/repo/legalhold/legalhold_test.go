@@ -0,0 +1,39 @@
+package legalhold
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCondition(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	holds := []*model.LegalHold{
+		{Scope: model.LegalHoldExecution, LabID: 1, ExecutionID: 10},
+		{Scope: model.LegalHoldWorkflow, LabID: 1, WorkflowID: 20},
+		{Scope: model.LegalHoldLab, LabID: 2},
+		{Scope: model.LegalHoldTimeRange, StartsAt: &start, EndsAt: &end},
+		{Scope: model.LegalHoldTimeRange, LabID: 3, StartsAt: &start, EndsAt: &end},
+	}
+
+	cond, args := Condition(holds, Columns{LabID: "lab_id", ExecutionID: "id", WorkflowID: "workflow_id", Time: "started_at"})
+	assert.Equal(t, "id = ? OR workflow_id = ? OR lab_id = ? OR "+
+		"(started_at >= ? AND started_at < ?) OR (started_at >= ? AND started_at < ? AND lab_id = ?)", cond)
+	assert.Equal(t, []any{int64(10), int64(20), int64(2), start, end, start, end, int64(3)}, args)
+
+	// 设备事件没有执行和工作流列
+	cond, args = Condition(holds, Columns{LabID: "lab_id", Time: "timestamp"})
+	assert.Equal(t, "lab_id = ? OR (timestamp >= ? AND timestamp < ?) OR (timestamp >= ? AND timestamp < ? AND lab_id = ?)", cond)
+	assert.Len(t, args, 6)
+
+	// 限定实验室的时间段不适用于没有实验室列的表
+	cond, _ = Condition(holds[4:], Columns{Time: "created_at"})
+	assert.Empty(t, cond)
+
+	cond, args = Condition(nil, Columns{LabID: "lab_id"})
+	assert.Empty(t, cond)
+	assert.Nil(t, args)
+}
@@ -0,0 +1,87 @@
+// Package lims fetches material inventory from an external LIMS.
+package lims
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Item is one inventory lot reported by the LIMS
+type Item struct {
+	ExternalID string     `json:"external_id"`
+	Name       string     `json:"name"`
+	LotNumber  string     `json:"lot_number"`
+	Kind       string     `json:"kind"`
+	Unit       string     `json:"unit"`
+	Quantity   float64    `json:"quantity"`
+	Remaining  float64    `json:"remaining"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// InventoryResp is the LIMS inventory response of one lab
+type InventoryResp struct {
+	Items []*Item `json:"items"`
+}
+
+// LIMS defines the external inventory source
+type LIMS interface {
+	// Enabled reports whether a LIMS endpoint is configured
+	Enabled() bool
+	ListInventory(ctx context.Context, labUUID uuid.UUID) ([]*Item, error)
+}
+
+type limsImpl struct {
+	conf   config.LIMSConfig
+	client *resty.Client
+}
+
+// New creates a LIMS client from the material configuration
+func New() LIMS {
+	conf := config.GetStudioConfig().Material.LIMS
+	timeout := defaultTimeout
+	if conf.TimeoutSeconds > 0 {
+		timeout = time.Duration(conf.TimeoutSeconds) * time.Second
+	}
+
+	client := resty.New().
+		SetTimeout(timeout).
+		SetHeader("Accept", "application/json")
+	if conf.AuthToken != "" {
+		client.SetAuthToken(conf.AuthToken)
+	}
+	return &limsImpl{
+		conf:   conf,
+		client: client,
+	}
+}
+
+func (l *limsImpl) Enabled() bool {
+	return l.conf.Enabled && l.conf.Endpoint != ""
+}
+
+// ListInventory fetches the inventory of a lab
+func (l *limsImpl) ListInventory(ctx context.Context, labUUID uuid.UUID) ([]*Item, error) {
+	ret := &InventoryResp{}
+	res, err := l.client.R().SetContext(ctx).
+		SetQueryParam("lab_uuid", labUUID.String()).
+		SetResult(ret).
+		Get(l.conf.Endpoint)
+	if err != nil {
+		logger.Errorf(ctx, "ListInventory request lims err: %+v", err)
+		return nil, code.RPCHttpErr.WithErr(err)
+	}
+	if res.StatusCode() != http.StatusOK {
+		logger.Errorf(ctx, "ListInventory lims lab: %s, http code: %d", labUUID, res.StatusCode())
+		return nil, code.RPCHttpCodeErr.WithMsgf("http code: %d", res.StatusCode())
+	}
+	return ret.Items, nil
+}
@@ -0,0 +1,166 @@
+// Package liveness provides repository operations for device heartbeat tracking.
+package liveness
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/utils"
+)
+
+// LivenessRepo defines the interface for device liveness repository operations
+type LivenessRepo interface {
+	// RecordHeartbeat marks the devices online and returns those that were
+	// offline or seen for the first time
+	RecordHeartbeat(ctx context.Context, labID int64, devices []*model.DeviceLiveness) ([]*model.DeviceLiveness, error)
+	ListByLab(ctx context.Context, labID int64) ([]*model.DeviceLiveness, error)
+	// ListStale lists online devices whose last heartbeat is older than before
+	ListStale(ctx context.Context, before time.Time, limit int) ([]*model.DeviceLiveness, error)
+	// MarkOffline marks the device offline unless a newer heartbeat arrived
+	MarkOffline(ctx context.Context, device *model.DeviceLiveness) (bool, error)
+	Summary(ctx context.Context) (*model.LivenessSummary, error)
+}
+
+type livenessImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new device liveness repository instance
+func New() LivenessRepo {
+	return &livenessImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// RecordHeartbeat upserts the liveness record of each device
+func (l *livenessImpl) RecordHeartbeat(ctx context.Context, labID int64, devices []*model.DeviceLiveness) ([]*model.DeviceLiveness, error) {
+	if len(devices) == 0 {
+		return nil, nil
+	}
+
+	names := utils.FilterSlice(devices, func(d *model.DeviceLiveness) (string, bool) {
+		return d.DeviceName, true
+	})
+
+	var connected []*model.DeviceLiveness
+	err := l.ExecTx(ctx, func(txCtx context.Context) error {
+		var olds []*model.DeviceLiveness
+		if err := l.DBWithContext(txCtx).
+			Where("lab_id = ? AND device_name IN ?", labID, names).
+			Find(&olds).Error; err != nil {
+			logger.Errorf(ctx, "RecordHeartbeat query fail: %+v", err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+		oldMap := utils.Slice2Map(olds, func(d *model.DeviceLiveness) (string, *model.DeviceLiveness) {
+			return d.DeviceName, d
+		})
+
+		for _, device := range devices {
+			device.LabID = labID
+			device.Status = model.LivenessOnline
+
+			old, ok := oldMap[device.DeviceName]
+			if !ok {
+				if err := l.DBWithContext(txCtx).Create(device).Error; err != nil {
+					logger.Errorf(ctx, "RecordHeartbeat create fail device: %s, err: %+v", device.DeviceName, err)
+					return code.CreateDataErr.WithErr(err)
+				}
+				connected = append(connected, device)
+				continue
+			}
+
+			if err := l.DBWithContext(txCtx).Model(&model.DeviceLiveness{}).
+				Where("id = ?", old.ID).
+				Updates(map[string]any{
+					"device_id":     device.DeviceID,
+					"device_uuid":   device.DeviceUUID,
+					"agent_version": device.AgentVersion,
+					"ip":            device.IP,
+					"status":        model.LivenessOnline,
+					"last_seen_at":  device.LastSeenAt,
+					"updated_at":    time.Now(),
+				}).Error; err != nil {
+				logger.Errorf(ctx, "RecordHeartbeat update fail device: %s, err: %+v", device.DeviceName, err)
+				return code.UpdateDataErr.WithErr(err)
+			}
+			device.BaseModel = old.BaseModel
+			if old.Status != model.LivenessOnline {
+				connected = append(connected, device)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return connected, nil
+}
+
+// ListByLab lists liveness records of a lab ordered by device name
+func (l *livenessImpl) ListByLab(ctx context.Context, labID int64) ([]*model.DeviceLiveness, error) {
+	var datas []*model.DeviceLiveness
+	if err := l.DBWithContext(ctx).Where("lab_id = ?", labID).Order("device_name ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListByLab liveness fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListStale lists online devices that missed their heartbeats
+func (l *livenessImpl) ListStale(ctx context.Context, before time.Time, limit int) ([]*model.DeviceLiveness, error) {
+	var datas []*model.DeviceLiveness
+	if err := l.DBWithContext(ctx).
+		Where("status = ? AND last_seen_at < ?", model.LivenessOnline, before).
+		Order("last_seen_at ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListStale liveness fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// MarkOffline conditionally marks a device offline
+func (l *livenessImpl) MarkOffline(ctx context.Context, device *model.DeviceLiveness) (bool, error) {
+	ret := l.DBWithContext(ctx).Model(&model.DeviceLiveness{}).
+		Where("id = ? AND status = ? AND last_seen_at = ?", device.ID, model.LivenessOnline, device.LastSeenAt).
+		Updates(map[string]any{
+			"status":     model.LivenessOffline,
+			"updated_at": time.Now(),
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "MarkOffline fail id=%d: %+v", device.ID, ret.Error)
+		return false, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected == 1, nil
+}
+
+// Summary counts devices by status
+func (l *livenessImpl) Summary(ctx context.Context) (*model.LivenessSummary, error) {
+	var rows []struct {
+		Status model.LivenessStatus
+		Count  int64
+	}
+	if err := l.DBWithContext(ctx).Model(&model.DeviceLiveness{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		logger.Errorf(ctx, "liveness Summary fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	summary := &model.LivenessSummary{}
+	for _, row := range rows {
+		switch row.Status {
+		case model.LivenessOnline:
+			summary.Online = row.Count
+		case model.LivenessOffline:
+			summary.Offline = row.Count
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,212 @@
+// Package llm calls the LLM provider configured for the assistant.
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+)
+
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+
+	defaultTimeout   = 60 * time.Second
+	defaultMaxTokens = 1024
+
+	openAIBaseURL    = "https://api.openai.com/v1"
+	anthropicBaseURL = "https://api.anthropic.com/v1"
+	anthropicVersion = "2023-06-01"
+)
+
+// Request is one completion request, a system prompt and a user prompt
+type Request struct {
+	System string
+	Prompt string
+}
+
+// Completion is the answer of the provider
+type Completion struct {
+	Text             string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// LLM defines the assistant model provider
+type LLM interface {
+	// Enabled reports whether a provider is configured
+	Enabled() bool
+	Provider() string
+	Model() string
+	Complete(ctx context.Context, req *Request) (*Completion, error)
+}
+
+type llmImpl struct {
+	conf   config.AssistantConfig
+	client *resty.Client
+}
+
+// New creates an LLM client from the assistant configuration
+func New() LLM {
+	return newLLM(config.GetStudioConfig().Assistant)
+}
+
+func newLLM(conf config.AssistantConfig) *llmImpl {
+	if conf.Provider == "" {
+		conf.Provider = ProviderOpenAI
+	}
+	if conf.BaseURL == "" {
+		conf.BaseURL = openAIBaseURL
+		if conf.Provider == ProviderAnthropic {
+			conf.BaseURL = anthropicBaseURL
+		}
+	}
+	conf.BaseURL = strings.TrimRight(conf.BaseURL, "/")
+	if conf.MaxTokens <= 0 {
+		conf.MaxTokens = defaultMaxTokens
+	}
+	timeout := defaultTimeout
+	if conf.TimeoutSeconds > 0 {
+		timeout = time.Duration(conf.TimeoutSeconds) * time.Second
+	}
+
+	client := otel.RestyClientWithTracing().
+		SetTimeout(timeout).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Accept", "application/json")
+	if conf.Provider == ProviderAnthropic {
+		client.SetHeader("x-api-key", conf.APIKey).
+			SetHeader("anthropic-version", anthropicVersion)
+	} else {
+		client.SetAuthToken(conf.APIKey)
+	}
+	return &llmImpl{
+		conf:   conf,
+		client: client,
+	}
+}
+
+func (l *llmImpl) Enabled() bool {
+	return l.conf.APIKey != "" && l.conf.Model != "" &&
+		(l.conf.Provider == ProviderOpenAI || l.conf.Provider == ProviderAnthropic)
+}
+
+func (l *llmImpl) Provider() string {
+	return l.conf.Provider
+}
+
+func (l *llmImpl) Model() string {
+	return l.conf.Model
+}
+
+// Complete sends the prompt to the configured provider
+func (l *llmImpl) Complete(ctx context.Context, req *Request) (*Completion, error) {
+	if l.conf.Provider == ProviderAnthropic {
+		return l.anthropic(ctx, req)
+	}
+	return l.openAI(ctx, req)
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResp struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openAI calls the chat completions API, also served by compatible providers
+func (l *llmImpl) openAI(ctx context.Context, req *Request) (*Completion, error) {
+	ret := &openAIResp{}
+	res, err := l.client.R().SetContext(ctx).
+		SetBody(map[string]any{
+			"model":      l.conf.Model,
+			"max_tokens": l.conf.MaxTokens,
+			"messages": []message{
+				{Role: "system", Content: req.System},
+				{Role: "user", Content: req.Prompt},
+			},
+		}).
+		SetResult(ret).
+		Post(l.conf.BaseURL + "/chat/completions")
+	if err := checkResp(ctx, res, err); err != nil {
+		return nil, err
+	}
+	if len(ret.Choices) == 0 {
+		return nil, code.RPCHttpErr.WithMsg("empty completion")
+	}
+	return &Completion{
+		Text:             ret.Choices[0].Message.Content,
+		PromptTokens:     ret.Usage.PromptTokens,
+		CompletionTokens: ret.Usage.CompletionTokens,
+	}, nil
+}
+
+type anthropicResp struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropic calls the messages API
+func (l *llmImpl) anthropic(ctx context.Context, req *Request) (*Completion, error) {
+	ret := &anthropicResp{}
+	res, err := l.client.R().SetContext(ctx).
+		SetBody(map[string]any{
+			"model":      l.conf.Model,
+			"max_tokens": l.conf.MaxTokens,
+			"system":     req.System,
+			"messages":   []message{{Role: "user", Content: req.Prompt}},
+		}).
+		SetResult(ret).
+		Post(l.conf.BaseURL + "/messages")
+	if err := checkResp(ctx, res, err); err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, 0, len(ret.Content))
+	for _, block := range ret.Content {
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return nil, code.RPCHttpErr.WithMsg("empty completion")
+	}
+	return &Completion{
+		Text:             strings.Join(texts, ""),
+		PromptTokens:     ret.Usage.InputTokens,
+		CompletionTokens: ret.Usage.OutputTokens,
+	}, nil
+}
+
+func checkResp(ctx context.Context, res *resty.Response, err error) error {
+	if err != nil {
+		logger.Errorf(ctx, "llm request err: %+v", err)
+		return code.RPCHttpErr.WithErr(err)
+	}
+	if res.StatusCode() != http.StatusOK {
+		logger.Errorf(ctx, "llm request http code: %d, body: %s", res.StatusCode(), res.String())
+		return code.RPCHttpCodeErr.WithMsgf("http code: %d", res.StatusCode())
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnabled(t *testing.T) {
+	assert.False(t, newLLM(config.AssistantConfig{}).Enabled())
+	assert.False(t, newLLM(config.AssistantConfig{APIKey: "k"}).Enabled())
+	assert.False(t, newLLM(config.AssistantConfig{Provider: "other", APIKey: "k", Model: "m"}).Enabled())
+	assert.True(t, newLLM(config.AssistantConfig{APIKey: "k", Model: "m"}).Enabled())
+	assert.True(t, newLLM(config.AssistantConfig{Provider: ProviderAnthropic, APIKey: "k", Model: "m"}).Enabled())
+}
+
+func TestOpenAI(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	l := newLLM(config.AssistantConfig{BaseURL: srv.URL + "/v1/", APIKey: "key", Model: "m"})
+	ret, err := l.Complete(context.Background(), &Request{System: "sys", Prompt: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, &Completion{Text: "ok", PromptTokens: 12, CompletionTokens: 3}, ret)
+	assert.Equal(t, "m", body["model"])
+	assert.Len(t, body["messages"], 2)
+}
+
+func TestAnthropic(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicVersion, r.Header.Get("anthropic-version"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"o"},{"type":"text","text":"k"}],"usage":{"input_tokens":7,"output_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	l := newLLM(config.AssistantConfig{Provider: ProviderAnthropic, BaseURL: srv.URL, APIKey: "key", Model: "m"})
+	ret, err := l.Complete(context.Background(), &Request{System: "sys", Prompt: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, &Completion{Text: "ok", PromptTokens: 7, CompletionTokens: 2}, ret)
+	assert.Equal(t, "sys", body["system"])
+	assert.Len(t, body["messages"], 1)
+}
+
+func TestHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	l := newLLM(config.AssistantConfig{BaseURL: srv.URL, APIKey: "key", Model: "m"})
+	_, err := l.Complete(context.Background(), &Request{Prompt: "hello"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+// Package maintenance provides repository operations for maintenance windows.
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm/clause"
+)
+
+// MaintenanceRepo defines the interface for maintenance repository operations
+type MaintenanceRepo interface {
+	// ListWindows lists the active maintenance windows
+	ListWindows(ctx context.Context) ([]*model.MaintenanceWindow, error)
+	// UpsertWindow starts a window, or updates the message and ETA of the
+	// window already active for the lab
+	UpsertWindow(ctx context.Context, data *model.MaintenanceWindow) error
+	// DeleteWindow ends the window of a lab, 0 for the global window,
+	// reporting false when none was active
+	DeleteWindow(ctx context.Context, labID int64) (bool, error)
+}
+
+type maintenanceImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new maintenance repository instance
+func New() MaintenanceRepo {
+	return &maintenanceImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// ListWindows lists the active windows
+func (m *maintenanceImpl) ListWindows(ctx context.Context) ([]*model.MaintenanceWindow, error) {
+	var datas []*model.MaintenanceWindow
+	if err := m.DBWithContext(ctx).Order("lab_id").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListWindows fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpsertWindow starts or updates a window
+func (m *maintenanceImpl) UpsertWindow(ctx context.Context, data *model.MaintenanceWindow) error {
+	data.UpdatedAt = time.Now()
+	if err := m.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lab_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"message", "eta", "started_by", "updated_at"}),
+	}).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "UpsertWindow fail lab=%d: %+v", data.LabID, err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// DeleteWindow ends a window
+func (m *maintenanceImpl) DeleteWindow(ctx context.Context, labID int64) (bool, error) {
+	res := m.DBWithContext(ctx).Where("lab_id = ?", labID).Delete(&model.MaintenanceWindow{})
+	if res.Error != nil {
+		logger.Errorf(ctx, "DeleteWindow fail lab=%d: %+v", labID, res.Error)
+		return false, code.DeleteDataErr.WithErr(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
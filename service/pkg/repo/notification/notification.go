@@ -0,0 +1,183 @@
+// Package notification provides repository operations for the in-app
+// notification inbox and notification preferences.
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepo defines the interface for notification repository operations
+type NotificationRepo interface {
+	// CreateNotifications stores notifications, one per recipient
+	CreateNotifications(ctx context.Context, datas []*model.Notification) error
+	// ListNotifications lists the inbox of a user, newest first
+	ListNotifications(ctx context.Context, userID string, unread bool, page, pageSize int) ([]*model.Notification, int64, error)
+	// ListNotificationsAfter lists the notifications of a user created after
+	// the one with afterUUID and not before since, oldest first. Returns
+	// RecordNotFound when the user has no notification with afterUUID
+	ListNotificationsAfter(ctx context.Context, userID string, afterUUID uuid.UUID, since time.Time, limit int) ([]*model.Notification, error)
+	// CountUnread counts the unread notifications of a user
+	CountUnread(ctx context.Context, userID string) (int64, error)
+	// MarkRead marks notifications of a user as read, every unread one when
+	// uuids is empty, returning the number marked
+	MarkRead(ctx context.Context, userID string, uuids []uuid.UUID) (int64, error)
+	// ListPreferences lists the preferences a user has set
+	ListPreferences(ctx context.Context, userID string) ([]*model.NotificationPreference, error)
+	// UpsertPreferences sets preferences of a user
+	UpsertPreferences(ctx context.Context, datas []*model.NotificationPreference) error
+	// DisabledUsers returns the users among userIDs who turned a kind off
+	DisabledUsers(ctx context.Context, kind model.NotificationKind, userIDs []string) (map[string]bool, error)
+}
+
+type notificationImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new notification repository instance
+func New() NotificationRepo {
+	return &notificationImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateNotifications stores notifications
+func (n *notificationImpl) CreateNotifications(ctx context.Context, datas []*model.Notification) error {
+	if len(datas) == 0 {
+		return nil
+	}
+	if err := n.DBWithContext(ctx).Create(datas).Error; err != nil {
+		logger.Errorf(ctx, "CreateNotifications fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListNotifications lists the inbox of a user
+func (n *notificationImpl) ListNotifications(ctx context.Context, userID string, unread bool, page, pageSize int) ([]*model.Notification, int64, error) {
+	var datas []*model.Notification
+	var total int64
+
+	query := n.DBWithContext(ctx).Model(&model.Notification{}).Where("user_id = ?", userID)
+	if unread {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListNotifications count fail user=%s: %+v", userID, err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListNotifications fail user=%s: %+v", userID, err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
+
+// ListNotificationsAfter lists the notifications after another one
+func (n *notificationImpl) ListNotificationsAfter(ctx context.Context, userID string, afterUUID uuid.UUID, since time.Time, limit int) ([]*model.Notification, error) {
+	var after model.Notification
+	if err := n.DBWithContext(ctx).Select("id").
+		Where("user_id = ? AND uuid = ?", userID, afterUUID).
+		First(&after).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "ListNotificationsAfter fail user=%s uuid=%s: %+v", userID, afterUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	var datas []*model.Notification
+	if err := n.DBWithContext(ctx).
+		Where("user_id = ? AND id > ? AND created_at >= ?", userID, after.ID, since).
+		Order("id ASC").Limit(limit).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListNotificationsAfter fail user=%s uuid=%s: %+v", userID, afterUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// CountUnread counts the unread notifications of a user
+func (n *notificationImpl) CountUnread(ctx context.Context, userID string) (int64, error) {
+	var total int64
+	if err := n.DBWithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "CountUnread fail user=%s: %+v", userID, err)
+		return 0, code.QueryRecordErr.WithErr(err)
+	}
+	return total, nil
+}
+
+// MarkRead marks notifications as read
+func (n *notificationImpl) MarkRead(ctx context.Context, userID string, uuids []uuid.UUID) (int64, error) {
+	query := n.DBWithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID)
+	if len(uuids) > 0 {
+		query = query.Where("uuid IN ?", uuids)
+	}
+	now := time.Now()
+	res := query.Updates(map[string]any{"read_at": now, "updated_at": now})
+	if res.Error != nil {
+		logger.Errorf(ctx, "MarkRead fail user=%s: %+v", userID, res.Error)
+		return 0, code.UpdateDataErr.WithErr(res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// ListPreferences lists the preferences of a user
+func (n *notificationImpl) ListPreferences(ctx context.Context, userID string) ([]*model.NotificationPreference, error) {
+	var datas []*model.NotificationPreference
+	if err := n.DBWithContext(ctx).Where("user_id = ?", userID).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListPreferences fail user=%s: %+v", userID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpsertPreferences sets preferences
+func (n *notificationImpl) UpsertPreferences(ctx context.Context, datas []*model.NotificationPreference) error {
+	if len(datas) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, data := range datas {
+		data.UpdatedAt = now
+	}
+	if err := n.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "kind"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(datas).Error; err != nil {
+		logger.Errorf(ctx, "UpsertPreferences fail: %+v", err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// DisabledUsers returns the users who turned a kind off
+func (n *notificationImpl) DisabledUsers(ctx context.Context, kind model.NotificationKind, userIDs []string) (map[string]bool, error) {
+	disabled := make(map[string]bool)
+	if len(userIDs) == 0 {
+		return disabled, nil
+	}
+	var ids []string
+	if err := n.DBWithContext(ctx).Model(&model.NotificationPreference{}).
+		Where("kind = ? AND enabled = ? AND user_id IN ?", kind, false, userIDs).
+		Pluck("user_id", &ids).Error; err != nil {
+		logger.Errorf(ctx, "DisabledUsers fail kind=%s: %+v", kind, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	for _, id := range ids {
+		disabled[id] = true
+	}
+	return disabled, nil
+}
@@ -0,0 +1,79 @@
+package payload
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/file"
+)
+
+// Artifacts keeps JSON payloads moved out of history records as available
+// result files of their lab
+type Artifacts struct {
+	files   file.FileRepo
+	storage storage.Storage
+}
+
+// NewArtifacts 转存的负载写入默认存储
+func NewArtifacts() *Artifacts {
+	return &Artifacts{files: file.New()}
+}
+
+// NewArtifactsWithStorage 使用指定的存储，用于测试
+func NewArtifactsWithStorage(files file.FileRepo, store storage.Storage) *Artifacts {
+	return &Artifacts{files: files, storage: store}
+}
+
+// Save stores data under key as a result file named fileName and returns its uuid
+func (a *Artifacts) Save(ctx context.Context, labID int64, fileName, key string, data []byte, checksum string) (uuid.UUID, error) {
+	id := uuid.New()
+	artifact := &model.ResultFile{
+		BaseModel:  model.BaseModel{UUID: id},
+		LabID:      labID,
+		FileName:   fileName,
+		Size:       int64(len(data)),
+		StorageKey: key,
+	}
+	if err := a.files.CreateFile(ctx, artifact); err != nil {
+		return uuid.UUID{}, err
+	}
+	if err := a.store().Put(ctx, artifact.StorageKey, bytes.NewReader(data), int64(len(data))); err != nil {
+		return uuid.UUID{}, err
+	}
+	if err := a.files.AppendChunk(ctx, artifact, int64(len(data))); err != nil {
+		return uuid.UUID{}, err
+	}
+	if err := a.files.MarkAvailable(ctx, artifact, artifactContentType, checksum); err != nil {
+		return uuid.UUID{}, err
+	}
+	return id, nil
+}
+
+// Load reads the content of a result file saved by Save
+func (a *Artifacts) Load(ctx context.Context, fileUUID uuid.UUID) ([]byte, error) {
+	artifact, err := a.files.GetFileByUUID(ctx, fileUUID)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.Status != model.ResultFileAvailable {
+		return nil, code.FileUploadStatusErr.WithMsgf("file %s is %s", fileUUID, artifact.Status)
+	}
+	r, err := a.store().Open(ctx, artifact.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (a *Artifacts) store() storage.Storage {
+	if a.storage != nil {
+		return a.storage
+	}
+	return storage.Default()
+}
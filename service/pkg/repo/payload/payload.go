@@ -0,0 +1,204 @@
+// Package payload limits the size and nesting depth of the JSON payloads that
+// history records store in jsonb columns. Edge agents occasionally report
+// outputs of tens of megabytes; such payloads are rejected, truncated to a
+// preview, or moved to a result file in object storage, and the record keeps
+// a model.PayloadStub flagged with "_truncated" in their place.
+package payload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/file"
+	"gorm.io/datatypes"
+)
+
+// 超过限制时的处理方式
+const (
+	ActionReject   = "reject"
+	ActionTruncate = "truncate"
+	ActionArtifact = "artifact"
+)
+
+const (
+	defaultMaxBytes     = 1 << 20
+	defaultMaxDepth     = 64
+	defaultPreviewBytes = 1024
+	artifactContentType = "application/json"
+)
+
+// Limiter checks the payloads before the repositories write them
+type Limiter struct {
+	artifacts *Artifacts
+	conf      func() config.PayloadLimitsConfig
+}
+
+// New 使用 studio 配置的限制，转存的负载写入默认存储
+func New() *Limiter {
+	return &Limiter{
+		artifacts: NewArtifacts(),
+		conf: func() config.PayloadLimitsConfig {
+			return config.GetStudioConfig().PayloadLimits
+		},
+	}
+}
+
+// NewWithStorage 使用指定的限制和存储，用于测试
+func NewWithStorage(conf config.PayloadLimitsConfig, files file.FileRepo, store storage.Storage) *Limiter {
+	return &Limiter{
+		artifacts: NewArtifactsWithStorage(files, store),
+		conf:      func() config.PayloadLimitsConfig { return conf },
+	}
+}
+
+func (l *Limiter) limits() config.PayloadLimitsConfig {
+	conf := l.conf()
+	if conf.MaxBytes <= 0 {
+		conf.MaxBytes = defaultMaxBytes
+	}
+	if conf.MaxDepth <= 0 {
+		conf.MaxDepth = defaultMaxDepth
+	}
+	if conf.PreviewBytes <= 0 {
+		conf.PreviewBytes = defaultPreviewBytes
+	}
+	switch conf.Action {
+	case ActionReject, ActionTruncate, ActionArtifact:
+	default:
+		conf.Action = ActionArtifact
+	}
+	return conf
+}
+
+// WorkflowExecution 检查执行的运行参数和结果
+func (l *Limiter) WorkflowExecution(ctx context.Context, exec *model.WorkflowExecutionHistory) error {
+	table := exec.TableName()
+	var err error
+	if exec.Input, err = l.Apply(ctx, exec.LabID, table, "input", exec.Input); err != nil {
+		return err
+	}
+	exec.Result, err = l.Apply(ctx, exec.LabID, table, "result", exec.Result)
+	return err
+}
+
+// ActionExecution 检查动作的输入和输出
+func (l *Limiter) ActionExecution(ctx context.Context, action *model.ActionExecutionHistory) error {
+	table := action.TableName()
+	var err error
+	if action.Input, err = l.Apply(ctx, action.LabID, table, "input", action.Input); err != nil {
+		return err
+	}
+	action.Output, err = l.Apply(ctx, action.LabID, table, "output", action.Output)
+	return err
+}
+
+// DeviceEvents 检查设备事件数据
+func (l *Limiter) DeviceEvents(ctx context.Context, events []*model.DeviceEventHistory) error {
+	for _, e := range events {
+		data, err := l.Apply(ctx, e.LabID, e.TableName(), "event_data", e.EventData)
+		if err != nil {
+			return err
+		}
+		e.EventData = data
+	}
+	return nil
+}
+
+// Apply returns the payload to write for table.field of a record in labID:
+// data itself within the limits, otherwise PayloadTooLargeErr in reject mode
+// or a model.PayloadStub
+func (l *Limiter) Apply(ctx context.Context, labID int64, table, field string, data datatypes.JSON) (datatypes.JSON, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	conf := l.limits()
+	var reason model.PayloadLimitReason
+	switch {
+	case len(data) > conf.MaxBytes:
+		reason = model.PayloadLimitSize
+	case depthExceeds(data, conf.MaxDepth):
+		reason = model.PayloadLimitDepth
+	default:
+		return data, nil
+	}
+
+	if conf.Action == ActionReject {
+		otel.GetMetrics().RecordOversizedPayload(ctx, table, field, "rejected")
+		return nil, code.PayloadTooLargeErr.WithMsgf("%s.%s has %d bytes, limit %d bytes and depth %d",
+			table, field, len(data), conf.MaxBytes, conf.MaxDepth)
+	}
+
+	sum := sha256.Sum256(data)
+	stub := &model.PayloadStub{
+		Truncated: true,
+		Reason:    reason,
+		Size:      len(data),
+		SHA256:    hex.EncodeToString(sum[:]),
+	}
+	action := "truncated"
+	if conf.Action == ActionArtifact && labID > 0 {
+		key := fmt.Sprintf("payloads/%d/%s/%s.json", labID, time.Now().Format("20060102"), uuid.NewV4())
+		fileUUID, err := l.artifacts.Save(ctx, labID, fmt.Sprintf("%s.%s.json", table, field), key, data, stub.SHA256)
+		if err != nil {
+			// 转存失败时仍写入记录，只保留前缀
+			logger.Warnf(ctx, "payload save artifact fail %s.%s lab=%d, truncated: %+v", table, field, labID, err)
+		} else {
+			stub.FileUUID = &fileUUID
+			action = "artifact"
+		}
+	}
+	if stub.FileUUID == nil {
+		stub.Preview = preview(data, conf.PreviewBytes)
+	}
+	otel.GetMetrics().RecordOversizedPayload(ctx, table, field, action)
+	logger.Warnf(ctx, "payload %s.%s lab=%d over limit by %s, %d bytes, %s", table, field, labID, reason, len(data), action)
+
+	ret, err := json.Marshal(stub)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// depthExceeds 逐个读取 token 计算对象和数组的嵌套层数，不完整解析负载
+func depthExceeds(data []byte, maxDepth int) bool {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			// 读完或不是合法 JSON，交给数据库校验
+			return false
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
+
+// preview 截取前缀，去掉被截断的多字节字符
+func preview(data []byte, n int) string {
+	if len(data) < n {
+		n = len(data)
+	}
+	return strings.ToValidUTF8(string(data[:n]), "")
+}
@@ -0,0 +1,132 @@
+package payload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/middleware/storage"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/scienceol/studio/service/pkg/repo/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func newLimiter(t *testing.T, conf config.PayloadLimitsConfig) (*Limiter, file.FileRepo, storage.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+	files := file.New()
+	store := storage.NewLocal(t.TempDir())
+	return NewWithStorage(conf, files, store), files, store
+}
+
+func stubOf(t *testing.T, data datatypes.JSON) *model.PayloadStub {
+	t.Helper()
+	stub := &model.PayloadStub{}
+	require.NoError(t, json.Unmarshal(data, stub))
+	require.True(t, stub.Truncated)
+	return stub
+}
+
+// largeOutput 返回 n 字节左右的合法 JSON
+func largeOutput(n int) datatypes.JSON {
+	return datatypes.JSON(`{"spectrum":"` + strings.Repeat("光", n/3) + `"}`)
+}
+
+func nested(depth int) datatypes.JSON {
+	return datatypes.JSON(strings.Repeat(`{"a":`, depth) + "1" + strings.Repeat("}", depth))
+}
+
+func TestApplyWithinLimits(t *testing.T) {
+	l, _, _ := newLimiter(t, config.PayloadLimitsConfig{MaxBytes: 1024, MaxDepth: 4})
+	ctx := context.Background()
+
+	for _, data := range []datatypes.JSON{nil, datatypes.JSON(`{"ok":true}`), nested(4), datatypes.JSON(`not json`)} {
+		ret, err := l.Apply(ctx, 1, "action_execution_history", "output", data)
+		require.NoError(t, err)
+		assert.Equal(t, data, ret)
+	}
+}
+
+func TestApplyReject(t *testing.T) {
+	l, _, _ := newLimiter(t, config.PayloadLimitsConfig{MaxBytes: 1024, MaxDepth: 4, Action: ActionReject})
+	ctx := context.Background()
+
+	_, err := l.Apply(ctx, 1, "action_execution_history", "output", largeOutput(2048))
+	var withMsg code.ErrCodeWithMsg
+	require.True(t, errors.As(err, &withMsg))
+	assert.Equal(t, code.PayloadTooLargeErr, withMsg.ErrCode)
+
+	_, err = l.Apply(ctx, 1, "device_event_history", "event_data", nested(5))
+	assert.Error(t, err)
+}
+
+func TestApplyTruncate(t *testing.T) {
+	l, _, _ := newLimiter(t, config.PayloadLimitsConfig{MaxBytes: 1024, MaxDepth: 4, Action: ActionTruncate, PreviewBytes: 15})
+	ctx := context.Background()
+
+	data := largeOutput(2048)
+	ret, err := l.Apply(ctx, 1, "action_execution_history", "output", data)
+	require.NoError(t, err)
+	stub := stubOf(t, ret)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, model.PayloadLimitSize, stub.Reason)
+	assert.Equal(t, len(data), stub.Size)
+	assert.Equal(t, hex.EncodeToString(sum[:]), stub.SHA256)
+	assert.Nil(t, stub.FileUUID)
+	// 前缀在多字节字符中间截断时去掉不完整的字符
+	assert.Equal(t, `{"spectrum":"`, stub.Preview)
+
+	ret, err = l.Apply(ctx, 1, "device_event_history", "event_data", nested(5))
+	require.NoError(t, err)
+	assert.Equal(t, model.PayloadLimitDepth, stubOf(t, ret).Reason)
+}
+
+func TestApplyArtifact(t *testing.T) {
+	l, files, store := newLimiter(t, config.PayloadLimitsConfig{MaxBytes: 1024})
+	ctx := context.Background()
+
+	action := &model.ActionExecutionHistory{LabID: 7, Input: datatypes.JSON(`{"volume":1}`), Output: largeOutput(4096)}
+	original := action.Output
+	require.NoError(t, l.ActionExecution(ctx, action))
+	assert.JSONEq(t, `{"volume":1}`, string(action.Input))
+
+	stub := stubOf(t, action.Output)
+	require.NotNil(t, stub.FileUUID)
+	assert.Empty(t, stub.Preview)
+
+	artifact, err := files.GetFileByUUID(ctx, *stub.FileUUID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), artifact.LabID)
+	assert.Equal(t, model.ResultFileAvailable, artifact.Status)
+	assert.Equal(t, "action_execution_history.output.json", artifact.FileName)
+	assert.Equal(t, int64(len(original)), artifact.Offset)
+	assert.Equal(t, stub.SHA256, artifact.Checksum)
+
+	r, err := store.Open(ctx, artifact.StorageKey)
+	require.NoError(t, err)
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(original), content)
+
+	// 没有实验室的记录无法关联结果文件，只保留前缀
+	ret, err := l.Apply(ctx, 0, "device_event_history", "event_data", largeOutput(4096))
+	require.NoError(t, err)
+	stub = stubOf(t, ret)
+	assert.Nil(t, stub.FileUUID)
+	assert.NotEmpty(t, stub.Preview)
+}
@@ -0,0 +1,176 @@
+// Package report provides repository operations for report subscriptions and their deliveries.
+package report
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// ReportRepo defines the interface for report subscription repository operations
+type ReportRepo interface {
+	CreateSubscription(ctx context.Context, data *model.ReportSubscription) error
+	GetSubscriptionByUUID(ctx context.Context, subUUID uuid.UUID) (*model.ReportSubscription, error)
+	// ListSubscriptions lists the subscriptions of a lab, all owners when ownerID is empty
+	ListSubscriptions(ctx context.Context, labID int64, ownerID string) ([]*model.ReportSubscription, error)
+	UpdateSubscription(ctx context.Context, data *model.ReportSubscription) error
+	DeleteSubscription(ctx context.Context, id int64) error
+	// ListDueSubscriptions lists enabled subscriptions whose next run is not after now
+	ListDueSubscriptions(ctx context.Context, now time.Time, limit int) ([]*model.ReportSubscription, error)
+	// FinishSubscriptionRun records a delivery and moves the subscription to its next run
+	FinishSubscriptionRun(ctx context.Context, sub *model.ReportSubscription, delivery *model.ReportDelivery) error
+
+	GetDeliveryByUUID(ctx context.Context, deliveryUUID uuid.UUID) (*model.ReportDelivery, error)
+	ListDeliveries(ctx context.Context, subID int64, page, pageSize int) ([]*model.ReportDelivery, int64, error)
+}
+
+type reportImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new report subscription repository instance
+func New() ReportRepo {
+	return &reportImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateSubscription creates a report subscription
+func (r *reportImpl) CreateSubscription(ctx context.Context, data *model.ReportSubscription) error {
+	if err := r.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateSubscription fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetSubscriptionByUUID retrieves a report subscription by UUID
+func (r *reportImpl) GetSubscriptionByUUID(ctx context.Context, subUUID uuid.UUID) (*model.ReportSubscription, error) {
+	var data model.ReportSubscription
+	if err := r.DBWithContext(ctx).Where("uuid = ?", subUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetSubscriptionByUUID fail uuid=%s: %+v", subUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListSubscriptions lists report subscriptions ordered by name
+func (r *reportImpl) ListSubscriptions(ctx context.Context, labID int64, ownerID string) ([]*model.ReportSubscription, error) {
+	query := r.DBWithContext(ctx).Where("lab_id = ?", labID)
+	if ownerID != "" {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+
+	var datas []*model.ReportSubscription
+	if err := query.Order("name ASC, id ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListSubscriptions fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateSubscription updates the settings and next run of a subscription
+func (r *reportImpl) UpdateSubscription(ctx context.Context, data *model.ReportSubscription) error {
+	data.UpdatedAt = time.Now()
+	ret := r.DBWithContext(ctx).Model(data).
+		Select("name", "schedule", "weekday", "hour", "format", "channel", "target",
+			"enabled", "next_run_at", "updated_at").
+		Updates(data)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "UpdateSubscription fail id=%d: %+v", data.ID, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// DeleteSubscription deletes a subscription, its delivery history is kept
+func (r *reportImpl) DeleteSubscription(ctx context.Context, id int64) error {
+	ret := r.DBWithContext(ctx).Where("id = ?", id).Delete(&model.ReportSubscription{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteSubscription fail id=%d: %+v", id, ret.Error)
+		return code.DeleteDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// ListDueSubscriptions lists due subscriptions, the longest overdue first
+func (r *reportImpl) ListDueSubscriptions(ctx context.Context, now time.Time, limit int) ([]*model.ReportSubscription, error) {
+	var datas []*model.ReportSubscription
+	if err := r.DBWithContext(ctx).
+		Where("enabled = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListDueSubscriptions fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// FinishSubscriptionRun creates the delivery and updates the run times of the subscription
+func (r *reportImpl) FinishSubscriptionRun(ctx context.Context, sub *model.ReportSubscription, delivery *model.ReportDelivery) error {
+	return r.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := r.DBWithContext(txCtx).Create(delivery).Error; err != nil {
+			logger.Errorf(ctx, "FinishSubscriptionRun create delivery fail sub=%d: %+v", sub.ID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		if err := r.DBWithContext(txCtx).Model(&model.ReportSubscription{}).
+			Where("id = ?", sub.ID).
+			Updates(map[string]any{
+				"next_run_at": sub.NextRunAt,
+				"last_run_at": sub.LastRunAt,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+			logger.Errorf(ctx, "FinishSubscriptionRun update subscription fail id=%d: %+v", sub.ID, err)
+			return code.UpdateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// GetDeliveryByUUID retrieves a delivery by UUID
+func (r *reportImpl) GetDeliveryByUUID(ctx context.Context, deliveryUUID uuid.UUID) (*model.ReportDelivery, error) {
+	var data model.ReportDelivery
+	if err := r.DBWithContext(ctx).Where("uuid = ?", deliveryUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetDeliveryByUUID fail uuid=%s: %+v", deliveryUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListDeliveries lists deliveries of a subscription, newest first
+func (r *reportImpl) ListDeliveries(ctx context.Context, subID int64, page, pageSize int) ([]*model.ReportDelivery, int64, error) {
+	var datas []*model.ReportDelivery
+	var total int64
+
+	query := r.DBWithContext(ctx).Model(&model.ReportDelivery{}).Where("subscription_id = ?", subID)
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "ListDeliveries count fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListDeliveries fail: %+v", err)
+		return nil, 0, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, total, nil
+}
@@ -0,0 +1,173 @@
+// Package reservation provides repository operations for device reservations.
+package reservation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// CalendarQuery represents the query of reservations in a time window
+type CalendarQuery struct {
+	LabID     int64
+	DeviceID  *int64
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// ReservationRepo defines the interface for reservation repository operations
+type ReservationRepo interface {
+	// CreateReservation creates a reservation, failing with ReservationConflictErr
+	// when it overlaps another active reservation of the same device
+	CreateReservation(ctx context.Context, r *model.DeviceReservation) error
+	CancelReservation(ctx context.Context, uuid uuid.UUID, userID string) error
+	GetReservationByUUID(ctx context.Context, uuid uuid.UUID) (*model.DeviceReservation, error)
+	// ListCalendar lists active reservations overlapping the query window
+	ListCalendar(ctx context.Context, query *CalendarQuery) ([]*model.DeviceReservation, error)
+	// GetActiveReservation returns the reservation covering the given time, nil if none
+	GetActiveReservation(ctx context.Context, labUUID uuid.UUID, deviceName string, at time.Time) (*model.DeviceReservation, error)
+}
+
+type reservationImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new reservation repository instance
+func New() ReservationRepo {
+	return &reservationImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// overlap 时间区间 [start, end) 相交
+func overlap(query *gorm.DB, start, end time.Time) *gorm.DB {
+	return query.Where("start_time < ? AND end_time > ?", end, start)
+}
+
+// CreateReservation creates a reservation after checking conflicts
+func (r *reservationImpl) CreateReservation(ctx context.Context, data *model.DeviceReservation) error {
+	if !data.EndTime.After(data.StartTime) {
+		return code.ReservationTimeInvalidErr
+	}
+
+	device := &model.MaterialNode{}
+	if err := r.GetData(ctx, device, map[string]any{
+		"lab_id": data.LabID,
+		"name":   data.DeviceName,
+		"type":   model.MATERIALDEVICE,
+	}, "id", "name"); err != nil {
+		if errors.Is(err, code.RecordNotFound) {
+			return code.CanNotFoundMaterialNodeErr.WithMsgf("device: %s", data.DeviceName)
+		}
+		return err
+	}
+	data.DeviceID = device.ID
+
+	return r.ExecTx(ctx, func(txCtx context.Context) error {
+		// 同一设备的预约串行化，避免并发预约同时通过冲突检测
+		if err := r.DBWithContext(txCtx).Exec("SELECT pg_advisory_xact_lock(?)", data.DeviceID).Error; err != nil {
+			logger.Errorf(ctx, "CreateReservation lock device fail device id=%d: %+v", data.DeviceID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+
+		var count int64
+		query := r.DBWithContext(txCtx).Model(&model.DeviceReservation{}).
+			Where("device_id = ? AND status = ?", data.DeviceID, model.ReservationStatusActive)
+		if err := overlap(query, data.StartTime, data.EndTime).Count(&count).Error; err != nil {
+			logger.Errorf(ctx, "CreateReservation check conflict fail: %+v", err)
+			return code.QueryRecordErr.WithErr(err)
+		}
+		if count > 0 {
+			return code.ReservationConflictErr
+		}
+
+		data.Status = model.ReservationStatusActive
+		if err := r.DBWithContext(txCtx).Create(data).Error; err != nil {
+			logger.Errorf(ctx, "CreateReservation fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// CancelReservation cancels an active reservation created by the user
+func (r *reservationImpl) CancelReservation(ctx context.Context, id uuid.UUID, userID string) error {
+	data, err := r.GetReservationByUUID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if data.UserID != userID {
+		return code.PermissionDenied
+	}
+
+	if err := r.DBWithContext(ctx).Model(&model.DeviceReservation{}).
+		Where("id = ?", data.ID).
+		Updates(map[string]any{
+			"status":     model.ReservationStatusCancelled,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		logger.Errorf(ctx, "CancelReservation fail uuid=%s: %+v", id, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetReservationByUUID retrieves a reservation by UUID
+func (r *reservationImpl) GetReservationByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceReservation, error) {
+	var data model.DeviceReservation
+	if err := r.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetReservationByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListCalendar lists active reservations in the window
+func (r *reservationImpl) ListCalendar(ctx context.Context, q *CalendarQuery) ([]*model.DeviceReservation, error) {
+	var datas []*model.DeviceReservation
+	query := r.DBWithContext(ctx).
+		Where("lab_id = ? AND status = ?", q.LabID, model.ReservationStatusActive)
+	if q.DeviceID != nil {
+		query = query.Where("device_id = ?", *q.DeviceID)
+	}
+
+	if err := overlap(query, q.StartTime, q.EndTime).
+		Order("start_time ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListCalendar fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// GetActiveReservation returns the active reservation of the device at the time
+func (r *reservationImpl) GetActiveReservation(ctx context.Context, labUUID uuid.UUID, deviceName string, at time.Time) (*model.DeviceReservation, error) {
+	var datas []*model.DeviceReservation
+	if err := r.DBWithContext(ctx).
+		Select("device_reservation.*").
+		Joins("JOIN laboratory ON laboratory.id = device_reservation.lab_id").
+		Where("laboratory.uuid = ? AND device_reservation.device_name = ? AND device_reservation.status = ?",
+			labUUID, deviceName, model.ReservationStatusActive).
+		Where("device_reservation.start_time <= ? AND device_reservation.end_time > ?", at, at).
+		Limit(1).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "GetActiveReservation fail device=%s: %+v", deviceName, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	if len(datas) == 0 {
+		return nil, nil
+	}
+	return datas[0], nil
+}
@@ -0,0 +1,147 @@
+// Package rule provides repository operations for device event rules.
+package rule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/eventstore"
+	"gorm.io/gorm"
+)
+
+// RuleRepo defines the interface for device rule repository operations
+type RuleRepo interface {
+	CreateRule(ctx context.Context, rule *model.DeviceRule) error
+	UpdateRule(ctx context.Context, rule *model.DeviceRule) error
+	DeleteRule(ctx context.Context, id int64) error
+	GetRuleByUUID(ctx context.Context, uuid uuid.UUID) (*model.DeviceRule, error)
+	ListRules(ctx context.Context, labID int64) ([]*model.DeviceRule, error)
+	ListEnabledRules(ctx context.Context) ([]*model.DeviceRule, error)
+	// Evaluate aggregates the events matched by the rule in [start, end) per device
+	Evaluate(ctx context.Context, rule *model.DeviceRule, start, end time.Time) ([]*model.RuleEvaluation, error)
+}
+
+type ruleImpl struct {
+	repo.IDOrUUIDTranslate
+	events eventstore.EventStore
+}
+
+// New creates a new device rule repository instance
+func New() RuleRepo {
+	return &ruleImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+		events:            eventstore.New(),
+	}
+}
+
+// CreateRule creates a rule
+func (r *ruleImpl) CreateRule(ctx context.Context, rule *model.DeviceRule) error {
+	if err := r.DBWithContext(ctx).Create(rule).Error; err != nil {
+		logger.Errorf(ctx, "CreateRule fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// UpdateRule saves the editable fields of a rule
+func (r *ruleImpl) UpdateRule(ctx context.Context, rule *model.DeviceRule) error {
+	rule.UpdatedAt = time.Now()
+	if err := r.DBWithContext(ctx).Model(rule).
+		Select("name", "description", "enabled", "device_uuid", "event_type", "metric", "aggregation",
+			"operator", "threshold", "window_sec", "severity", "channels", "escalate_after_min",
+			"escalation_channels", "updated_at").
+		Updates(rule).Error; err != nil {
+		logger.Errorf(ctx, "UpdateRule fail id=%d: %+v", rule.ID, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// DeleteRule deletes a rule
+func (r *ruleImpl) DeleteRule(ctx context.Context, id int64) error {
+	if err := r.DBWithContext(ctx).Where("id = ?", id).Delete(&model.DeviceRule{}).Error; err != nil {
+		logger.Errorf(ctx, "DeleteRule fail id=%d: %+v", id, err)
+		return code.DeleteDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetRuleByUUID retrieves a rule by UUID
+func (r *ruleImpl) GetRuleByUUID(ctx context.Context, id uuid.UUID) (*model.DeviceRule, error) {
+	var data model.DeviceRule
+	if err := r.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetRuleByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListRules lists the rules of a lab
+func (r *ruleImpl) ListRules(ctx context.Context, labID int64) ([]*model.DeviceRule, error) {
+	var datas []*model.DeviceRule
+	if err := r.DBWithContext(ctx).Where("lab_id = ?", labID).Order("id ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListRules fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListEnabledRules lists the enabled rules of all labs
+func (r *ruleImpl) ListEnabledRules(ctx context.Context) ([]*model.DeviceRule, error) {
+	var datas []*model.DeviceRule
+	if err := r.DBWithContext(ctx).Where("enabled = ?", true).Order("id ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListEnabledRules fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+var evaluateAggs = map[model.TelemetryAgg]string{
+	model.TelemetryAggAvg:   "AVG",
+	model.TelemetryAggMin:   "MIN",
+	model.TelemetryAggMax:   "MAX",
+	model.TelemetryAggSum:   "SUM",
+	model.TelemetryAggCount: "COUNT",
+}
+
+// Evaluate aggregates the rule metric per device, devices without matching events are omitted
+func (r *ruleImpl) Evaluate(ctx context.Context, rule *model.DeviceRule, start, end time.Time) ([]*model.RuleEvaluation, error) {
+	agg, ok := evaluateAggs[rule.Aggregation]
+	if !ok {
+		return nil, code.RuleInvalidErr.WithMsgf("unknown aggregation: %s", rule.Aggregation)
+	}
+
+	query := r.events.ReadDB(ctx).Model(&model.DeviceEventHistory{}).
+		Where("lab_id = ? AND event_type = ? AND timestamp >= ? AND timestamp < ?",
+			rule.LabID, rule.EventType, start, end)
+	if rule.DeviceUUID != nil {
+		query = query.Where("device_uuid = ?", *rule.DeviceUUID)
+	}
+
+	if rule.Metric == "" {
+		query = query.Select("device_id, device_uuid, COUNT(*) AS value, COUNT(*) AS samples")
+	} else {
+		path := "{" + strings.Join(strings.Split(rule.Metric, "."), ",") + "}"
+		query = query.
+			Select(fmt.Sprintf(`device_id, device_uuid,
+				%s((event_data #>> ?::text[])::double precision) AS value, COUNT(*) AS samples`, agg), path).
+			Where("jsonb_typeof(event_data #> ?::text[]) = 'number'", path)
+	}
+
+	var datas []*model.RuleEvaluation
+	if err := query.Group("device_id, device_uuid").Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "Evaluate rule fail id=%d: %+v", rule.ID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/scienceol/studio/service/internal/config"
 	"github.com/scienceol/studio/service/pkg/common/code"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/scienceol/studio/service/pkg/repo"
 )
 
@@ -34,7 +35,7 @@ func NewSandbox() repo.Sandbox {
 
 	return &SandboxImpl{
 		base: NewBaseTemplateTransformer(),
-		client: resty.New().
+		client: otel.InternalRestyClient().
 			EnableTrace().
 			SetHeaders(map[string]string{
 				"X-Api-Key":    sandboxConf.ApiKey,
@@ -0,0 +1,116 @@
+// Package savedview provides repository operations for saved history views.
+package savedview
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// SavedViewRepo defines the interface for saved view repository operations
+type SavedViewRepo interface {
+	CreateView(ctx context.Context, data *model.SavedView) error
+	GetView(ctx context.Context, id int64) (*model.SavedView, error)
+	GetViewByUUID(ctx context.Context, viewUUID uuid.UUID) (*model.SavedView, error)
+	// ListViews lists views of a lab owned by the user or shared with the lab
+	ListViews(ctx context.Context, labID int64, userID string, target model.SavedViewTarget) ([]*model.SavedView, error)
+	UpdateView(ctx context.Context, data *model.SavedView) error
+	DeleteView(ctx context.Context, id int64) error
+}
+
+type savedViewImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new saved view repository instance
+func New() SavedViewRepo {
+	return &savedViewImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateView creates a saved view
+func (s *savedViewImpl) CreateView(ctx context.Context, data *model.SavedView) error {
+	if err := s.DBWithContext(ctx).Create(data).Error; err != nil {
+		logger.Errorf(ctx, "CreateView fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetView retrieves a saved view by ID
+func (s *savedViewImpl) GetView(ctx context.Context, id int64) (*model.SavedView, error) {
+	var data model.SavedView
+	if err := s.DBWithContext(ctx).Where("id = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetView fail id=%d: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// GetViewByUUID retrieves a saved view by UUID
+func (s *savedViewImpl) GetViewByUUID(ctx context.Context, viewUUID uuid.UUID) (*model.SavedView, error) {
+	var data model.SavedView
+	if err := s.DBWithContext(ctx).Where("uuid = ?", viewUUID).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetViewByUUID fail uuid=%s: %+v", viewUUID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListViews lists views visible to a user ordered by name
+func (s *savedViewImpl) ListViews(ctx context.Context, labID int64, userID string, target model.SavedViewTarget) ([]*model.SavedView, error) {
+	query := s.DBWithContext(ctx).
+		Where("lab_id = ? AND (owner_id = ? OR shared = ?)", labID, userID, true)
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+
+	var datas []*model.SavedView
+	if err := query.Order("name ASC, id ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListViews fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateView updates the name, filters and sharing of a view
+func (s *savedViewImpl) UpdateView(ctx context.Context, data *model.SavedView) error {
+	data.UpdatedAt = time.Now()
+	ret := s.DBWithContext(ctx).Model(data).
+		Select("name", "target", "filters", "shared", "updated_at").
+		Updates(data)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "UpdateView fail id=%d: %+v", data.ID, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
+
+// DeleteView deletes a saved view
+func (s *savedViewImpl) DeleteView(ctx context.Context, id int64) error {
+	ret := s.DBWithContext(ctx).Where("id = ?", id).Delete(&model.SavedView{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteView fail id=%d: %+v", id, ret.Error)
+		return code.DeleteDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.RecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Package seed provides repository operations for populating demo labs with
+// synthetic history.
+package seed
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+const insertBatch = 500
+
+// SeedRepo defines the interface for demo seed repository operations
+type SeedRepo interface {
+	// LabDevices lists the device nodes of a lab
+	LabDevices(ctx context.Context, labID int64) ([]*model.MaterialNode, error)
+	// LabWorkflows lists the workflows of a lab, oldest first
+	LabWorkflows(ctx context.Context, labID int64, limit int) ([]*model.Workflow, error)
+	// CreateDevices creates device nodes, nodes with an existing name are loaded instead
+	CreateDevices(ctx context.Context, datas []*model.MaterialNode) error
+	CreateWorkflows(ctx context.Context, datas []*model.Workflow) error
+	// InsertHistory inserts history records keeping their created_at, datas must
+	// be a slice of history models
+	InsertHistory(ctx context.Context, datas any) error
+}
+
+type seedImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new demo seed repository instance
+func New() SeedRepo {
+	return &seedImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+func (s *seedImpl) LabDevices(ctx context.Context, labID int64) ([]*model.MaterialNode, error) {
+	var datas []*model.MaterialNode
+	if err := s.DBWithContext(ctx).Select("id, uuid, lab_id, name, display_name").
+		Where("lab_id = ? AND type = ?", labID, model.MATERIALDEVICE).
+		Order("id ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "LabDevices fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+func (s *seedImpl) LabWorkflows(ctx context.Context, labID int64, limit int) ([]*model.Workflow, error) {
+	var datas []*model.Workflow
+	if err := s.DBWithContext(ctx).Select("id, uuid, lab_id, name, sla_minutes").
+		Where("lab_id = ?", labID).
+		Order("id ASC").Limit(limit).Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "LabWorkflows fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+func (s *seedImpl) CreateDevices(ctx context.Context, datas []*model.MaterialNode) error {
+	for _, data := range datas {
+		if err := s.DBWithContext(ctx).
+			Where("lab_id = ? AND parent_id = ? AND name = ?", data.LabID, data.ParentID, data.Name).
+			FirstOrCreate(data).Error; err != nil {
+			logger.Errorf(ctx, "CreateDevices fail lab=%d name=%s: %+v", data.LabID, data.Name, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+	}
+	return nil
+}
+
+func (s *seedImpl) CreateWorkflows(ctx context.Context, datas []*model.Workflow) error {
+	if err := s.DBWithContext(ctx).Create(datas).Error; err != nil {
+		logger.Errorf(ctx, "CreateWorkflows fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// InsertHistory skips the hooks, BaseModel.BeforeCreate would reset created_at
+// which the action statistics are based on
+func (s *seedImpl) InsertHistory(ctx context.Context, datas any) error {
+	if err := s.DBWithContext(ctx).Session(&gorm.Session{SkipHooks: true}).
+		CreateInBatches(datas, insertBatch).Error; err != nil {
+		logger.Errorf(ctx, "InsertHistory fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
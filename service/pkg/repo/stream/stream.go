@@ -0,0 +1,202 @@
+// Package stream provides repository operations for camera streams and viewing sessions.
+package stream
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StreamRepo defines the interface for camera stream repository operations
+type StreamRepo interface {
+	// UpsertStreams registers streams by lab and camera id
+	UpsertStreams(ctx context.Context, streams []*model.CameraStream) error
+	GetStreamByUUID(ctx context.Context, uuid uuid.UUID) (*model.CameraStream, error)
+	ListStreams(ctx context.Context, labID int64) ([]*model.CameraStream, error)
+	DeleteStream(ctx context.Context, labID int64, cameraID string) error
+
+	CreateSession(ctx context.Context, session *model.StreamSession) error
+	GetSessionByUUID(ctx context.Context, uuid uuid.UUID) (*model.StreamSession, error)
+	ListActiveSessions(ctx context.Context, labID int64) ([]*model.StreamSession, error)
+	// MarkConnected records the signaling client of an active session
+	MarkConnected(ctx context.Context, session *model.StreamSession, clientID string) error
+	// EndSession ends an active session, returning false if it had ended already
+	EndSession(ctx context.Context, session *model.StreamSession, reason string) (bool, error)
+	// ListStaleSessions lists active sessions never connected before their token
+	// expired or started before startedBefore
+	ListStaleSessions(ctx context.Context, now, startedBefore time.Time, limit int) ([]*model.StreamSession, error)
+}
+
+type streamImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new camera stream repository instance
+func New() StreamRepo {
+	return &streamImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// UpsertStreams creates or updates camera streams
+func (s *streamImpl) UpsertStreams(ctx context.Context, streams []*model.CameraStream) error {
+	if len(streams) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, stream := range streams {
+		stream.UpdatedAt = now
+	}
+
+	if err := s.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "lab_id"}, {Name: "camera_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"device_id", "device_uuid", "device_name", "host_id", "name", "updated_at",
+		}),
+	}).Create(&streams).Error; err != nil {
+		logger.Errorf(ctx, "UpsertStreams fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetStreamByUUID retrieves a stream by UUID
+func (s *streamImpl) GetStreamByUUID(ctx context.Context, id uuid.UUID) (*model.CameraStream, error) {
+	var data model.CameraStream
+	if err := s.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetStreamByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListStreams lists the streams of a lab
+func (s *streamImpl) ListStreams(ctx context.Context, labID int64) ([]*model.CameraStream, error) {
+	var datas []*model.CameraStream
+	if err := s.DBWithContext(ctx).Where("lab_id = ?", labID).Order("device_name ASC, camera_id ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListStreams fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// DeleteStream deletes a stream of a lab
+func (s *streamImpl) DeleteStream(ctx context.Context, labID int64, cameraID string) error {
+	if err := s.DBWithContext(ctx).
+		Where("lab_id = ? AND camera_id = ?", labID, cameraID).
+		Delete(&model.CameraStream{}).Error; err != nil {
+		logger.Errorf(ctx, "DeleteStream fail camera=%s: %+v", cameraID, err)
+		return code.DeleteDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// CreateSession creates an active viewing session
+func (s *streamImpl) CreateSession(ctx context.Context, session *model.StreamSession) error {
+	session.Status = model.StreamSessionActive
+	if err := s.DBWithContext(ctx).Create(session).Error; err != nil {
+		logger.Errorf(ctx, "CreateSession fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetSessionByUUID retrieves a session by UUID
+func (s *streamImpl) GetSessionByUUID(ctx context.Context, id uuid.UUID) (*model.StreamSession, error) {
+	var data model.StreamSession
+	if err := s.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetSessionByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListActiveSessions lists the active sessions of a lab
+func (s *streamImpl) ListActiveSessions(ctx context.Context, labID int64) ([]*model.StreamSession, error) {
+	var datas []*model.StreamSession
+	if err := s.DBWithContext(ctx).
+		Where("lab_id = ? AND status = ?", labID, model.StreamSessionActive).
+		Order("started_at ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListActiveSessions fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// MarkConnected records the client attached to a session
+func (s *streamImpl) MarkConnected(ctx context.Context, session *model.StreamSession, clientID string) error {
+	now := time.Now()
+	ret := s.DBWithContext(ctx).Model(&model.StreamSession{}).
+		Where("id = ? AND status = ?", session.ID, model.StreamSessionActive).
+		Updates(map[string]any{
+			"client_id":    clientID,
+			"connected_at": now,
+			"updated_at":   now,
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "MarkConnected session fail id=%d: %+v", session.ID, ret.Error)
+		return code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return code.StreamSessionEndedErr
+	}
+
+	session.ClientID = clientID
+	session.ConnectedAt = &now
+	return nil
+}
+
+// EndSession ends an active session
+func (s *streamImpl) EndSession(ctx context.Context, session *model.StreamSession, reason string) (bool, error) {
+	now := time.Now()
+	ret := s.DBWithContext(ctx).Model(&model.StreamSession{}).
+		Where("id = ? AND status = ?", session.ID, model.StreamSessionActive).
+		Updates(map[string]any{
+			"status":     model.StreamSessionEnded,
+			"ended_at":   now,
+			"end_reason": reason,
+			"updated_at": now,
+		})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "EndSession fail id=%d: %+v", session.ID, ret.Error)
+		return false, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	if ret.RowsAffected == 0 {
+		return false, nil
+	}
+
+	session.Status = model.StreamSessionEnded
+	session.EndedAt = &now
+	session.EndReason = reason
+	return true, nil
+}
+
+// ListStaleSessions lists active sessions that should be ended
+func (s *streamImpl) ListStaleSessions(ctx context.Context, now, startedBefore time.Time, limit int) ([]*model.StreamSession, error) {
+	var datas []*model.StreamSession
+	if err := s.DBWithContext(ctx).
+		Where("status = ?", model.StreamSessionActive).
+		Where("(connected_at IS NULL AND token_expiry < ?) OR started_at < ?", now, startedBefore).
+		Order("id ASC").
+		Limit(limit).
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListStaleSessions fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
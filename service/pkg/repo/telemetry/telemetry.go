@@ -0,0 +1,209 @@
+// Package telemetry provides repository operations for device telemetry rollups.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/eventstore"
+	"github.com/scienceol/studio/service/pkg/repo/legalhold"
+	"gorm.io/gorm/clause"
+)
+
+// TelemetryRepo defines the interface for telemetry rollup repository operations
+type TelemetryRepo interface {
+	// Rollup recomputes the buckets of resolution in [start, end). Minute
+	// buckets are built from raw data_received events, hour buckets from
+	// minute buckets, so re-running a window is idempotent
+	Rollup(ctx context.Context, resolution model.TelemetryResolution, start, end time.Time) (int64, error)
+	ListRollups(ctx context.Context, q *model.TelemetryRollupQuery) ([]*model.DeviceTelemetryRollup, error)
+	DeleteRollupsBefore(ctx context.Context, resolution model.TelemetryResolution, before time.Time) (int64, error)
+	// DeleteRawBefore deletes raw data_received events older than before, keeping held events
+	DeleteRawBefore(ctx context.Context, before time.Time) (int64, error)
+	// Series buckets one numeric event_data field per device, ordered by device and time
+	Series(ctx context.Context, q *model.TelemetrySeriesQuery) ([]*model.TelemetryPoint, error)
+}
+
+type telemetryImpl struct {
+	repo.IDOrUUIDTranslate
+	events eventstore.EventStore
+	holds  legalhold.LegalHoldRepo
+}
+
+// New creates a new telemetry repository instance
+func New() TelemetryRepo {
+	return &telemetryImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+		events:            eventstore.New(),
+		holds:             legalhold.New(),
+	}
+}
+
+const rollupConflict = `
+ON CONFLICT (resolution, device_id, metric, bucket_start) DO UPDATE SET
+	lab_id = EXCLUDED.lab_id,
+	device_uuid = EXCLUDED.device_uuid,
+	min_value = EXCLUDED.min_value,
+	max_value = EXCLUDED.max_value,
+	sum_value = EXCLUDED.sum_value,
+	count = EXCLUDED.count,
+	updated_at = EXCLUDED.updated_at`
+
+// 非数值字段和非对象的 event_data 不参与汇总。原始事件可能不在主库，查询结果再写入汇总表
+const rollupRawSQL = `
+SELECT e.lab_id, e.device_id, e.device_uuid, kv.key AS metric, date_trunc('minute', e.timestamp) AS bucket_start,
+	MIN((kv.value #>> '{}')::double precision) AS min_value,
+	MAX((kv.value #>> '{}')::double precision) AS max_value,
+	SUM((kv.value #>> '{}')::double precision) AS sum_value,
+	COUNT(*) AS count
+FROM device_event_history e
+CROSS JOIN LATERAL jsonb_each(CASE WHEN jsonb_typeof(e.event_data) = 'object' THEN e.event_data ELSE '{}'::jsonb END) kv
+WHERE e.event_type = ? AND e.timestamp >= ? AND e.timestamp < ? AND jsonb_typeof(kv.value) = 'number'
+GROUP BY e.lab_id, e.device_id, e.device_uuid, kv.key, date_trunc('minute', e.timestamp)`
+
+const rollupMinuteSQL = `
+INSERT INTO device_telemetry_rollup
+	(resolution, lab_id, device_id, device_uuid, metric, bucket_start, min_value, max_value, sum_value, count, created_at, updated_at)
+SELECT ?, lab_id, device_id, device_uuid, metric, date_trunc('hour', bucket_start),
+	MIN(min_value), MAX(max_value), SUM(sum_value), SUM(count), NOW(), NOW()
+FROM device_telemetry_rollup
+WHERE resolution = ? AND bucket_start >= ? AND bucket_start < ?
+GROUP BY lab_id, device_id, device_uuid, metric, date_trunc('hour', bucket_start)` + rollupConflict
+
+// Rollup aggregates the source data of resolution into its buckets
+func (t *telemetryImpl) Rollup(ctx context.Context, resolution model.TelemetryResolution, start, end time.Time) (int64, error) {
+	switch resolution {
+	case model.TelemetryMinute:
+		return t.rollupRaw(ctx, start, end)
+	case model.TelemetryHour:
+	default:
+		return 0, code.ParamErr.WithMsgf("unknown telemetry resolution: %s", resolution)
+	}
+
+	ret := t.DBWithContext(ctx).Exec(rollupMinuteSQL, resolution, model.TelemetryMinute, start, end)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "Telemetry Rollup fail resolution=%s: %+v", resolution, ret.Error)
+		return 0, code.CreateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
+
+func (t *telemetryImpl) rollupRaw(ctx context.Context, start, end time.Time) (int64, error) {
+	var datas []*model.DeviceTelemetryRollup
+	if err := t.events.ReadDB(ctx).Raw(rollupRawSQL, model.DeviceEventDataReceived, start, end).
+		Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "Telemetry Rollup raw events fail: %+v", err)
+		return 0, code.QueryRecordErr.WithErr(err)
+	}
+	if len(datas) == 0 {
+		return 0, nil
+	}
+	for _, data := range datas {
+		data.Resolution = model.TelemetryMinute
+	}
+
+	ret := t.DBWithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "resolution"}, {Name: "device_id"}, {Name: "metric"}, {Name: "bucket_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"lab_id", "device_uuid", "min_value", "max_value", "sum_value", "count", "updated_at",
+		}),
+	}).CreateInBatches(datas, 500)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "Telemetry Rollup fail resolution=%s: %+v", model.TelemetryMinute, ret.Error)
+		return 0, code.CreateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
+
+// ListRollups lists the buckets of a lab ordered by device, metric and time
+func (t *telemetryImpl) ListRollups(ctx context.Context, q *model.TelemetryRollupQuery) ([]*model.DeviceTelemetryRollup, error) {
+	var datas []*model.DeviceTelemetryRollup
+	query := t.DBWithContext(ctx).
+		Where("lab_id = ? AND resolution = ? AND bucket_start >= ? AND bucket_start < ?",
+			q.LabID, q.Resolution, q.StartTime, q.EndTime)
+	if len(q.Devices) > 0 {
+		query = query.Where("device_uuid IN ?", q.Devices)
+	}
+	if len(q.Metrics) > 0 {
+		query = query.Where("metric IN ?", q.Metrics)
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+
+	if err := query.Order("device_id ASC, metric ASC, bucket_start ASC").Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListRollups fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+
+	for _, data := range datas {
+		if data.Count > 0 {
+			data.AvgValue = data.SumValue / float64(data.Count)
+		}
+	}
+	return datas, nil
+}
+
+// DeleteRollupsBefore deletes buckets of resolution older than before
+func (t *telemetryImpl) DeleteRollupsBefore(ctx context.Context, resolution model.TelemetryResolution, before time.Time) (int64, error) {
+	ret := t.DBWithContext(ctx).
+		Where("resolution = ? AND bucket_start < ?", resolution, before).
+		Delete(&model.DeviceTelemetryRollup{})
+	if ret.Error != nil {
+		logger.Errorf(ctx, "DeleteRollupsBefore fail resolution=%s: %+v", resolution, ret.Error)
+		return 0, code.DeleteDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected, nil
+}
+
+// DeleteRawBefore deletes raw data_received events older than before
+func (t *telemetryImpl) DeleteRawBefore(ctx context.Context, before time.Time) (int64, error) {
+	holds, err := t.holds.ListActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+	eventType := model.DeviceEventDataReceived
+	return t.events.DeleteBefore(ctx, before, &eventType, holds)
+}
+
+var seriesAggs = map[model.TelemetryAgg]string{
+	model.TelemetryAggAvg:   "AVG",
+	model.TelemetryAggMin:   "MIN",
+	model.TelemetryAggMax:   "MAX",
+	model.TelemetryAggSum:   "SUM",
+	model.TelemetryAggCount: "COUNT",
+}
+
+// Series aggregates the field at q.Path into buckets of q.Interval aligned to the epoch
+func (t *telemetryImpl) Series(ctx context.Context, q *model.TelemetrySeriesQuery) ([]*model.TelemetryPoint, error) {
+	agg, ok := seriesAggs[q.Agg]
+	if !ok {
+		return nil, code.ParamErr.WithMsgf("unknown aggregation: %s", q.Agg)
+	}
+	interval := q.Interval.Seconds()
+	path := "{" + strings.Join(q.Path, ",") + "}"
+
+	query := t.events.ReadDB(ctx).Model(&model.DeviceEventHistory{}).
+		Select(fmt.Sprintf(`device_uuid,
+			to_timestamp(floor(extract(epoch FROM timestamp) / ?) * ?) AS bucket,
+			%s((event_data #>> ?::text[])::double precision) AS value`, agg), interval, interval, path).
+		Where("lab_id = ? AND event_type = ? AND timestamp >= ? AND timestamp < ?",
+			q.LabID, q.EventType, q.StartTime, q.EndTime).
+		Where("jsonb_typeof(event_data #> ?::text[]) = 'number'", path)
+	if len(q.Devices) > 0 {
+		query = query.Where("device_uuid IN ?", q.Devices)
+	}
+
+	var datas []*model.TelemetryPoint
+	if err := query.Group("device_uuid, bucket").Order("device_uuid ASC, bucket ASC").Scan(&datas).Error; err != nil {
+		logger.Errorf(ctx, "Telemetry Series fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
@@ -0,0 +1,188 @@
+// Package topology provides repository operations for the lab resource
+// hierarchy of rooms, benches and device groups.
+package topology
+
+import (
+	"context"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TopologyRepo defines the interface for lab topology repository operations
+type TopologyRepo interface {
+	CreateNode(ctx context.Context, node *model.TopologyNode) error
+	GetNodeByUUID(ctx context.Context, id uuid.UUID) (*model.TopologyNode, error)
+	ListNodes(ctx context.Context, labID int64) ([]*model.TopologyNode, error)
+	UpdateNode(ctx context.Context, id int64, updates map[string]any) error
+	// DeleteNode deletes the node together with its device memberships
+	DeleteNode(ctx context.Context, id int64) error
+
+	// AddDevices adds the devices to the node; for a room or bench the devices
+	// are moved out of the room or bench they were placed in before
+	AddDevices(ctx context.Context, node *model.TopologyNode, deviceIDs []int64) error
+	RemoveDevices(ctx context.Context, nodeID int64, deviceIDs []int64) error
+	ListDevices(ctx context.Context, labID int64) ([]*model.TopologyDevice, error)
+	// DeviceIDs lists the distinct devices of the nodes
+	DeviceIDs(ctx context.Context, nodeIDs []int64) ([]int64, error)
+}
+
+type topologyImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new lab topology repository instance
+func New() TopologyRepo {
+	return &topologyImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateNode creates a topology node
+func (t *topologyImpl) CreateNode(ctx context.Context, node *model.TopologyNode) error {
+	if err := t.DBWithContext(ctx).Create(node).Error; err != nil {
+		logger.Errorf(ctx, "CreateNode fail: %+v", err)
+		return code.CreateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// GetNodeByUUID retrieves a topology node by UUID
+func (t *topologyImpl) GetNodeByUUID(ctx context.Context, id uuid.UUID) (*model.TopologyNode, error) {
+	var data model.TopologyNode
+	if err := t.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetNodeByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListNodes lists all topology nodes of a lab
+func (t *topologyImpl) ListNodes(ctx context.Context, labID int64) ([]*model.TopologyNode, error) {
+	var datas []*model.TopologyNode
+	if err := t.DBWithContext(ctx).
+		Where("lab_id = ?", labID).
+		Order("id ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListNodes fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateNode updates a topology node
+func (t *topologyImpl) UpdateNode(ctx context.Context, id int64, updates map[string]any) error {
+	if err := t.DBWithContext(ctx).Model(&model.TopologyNode{}).
+		Where("id = ?", id).Updates(updates).Error; err != nil {
+		logger.Errorf(ctx, "UpdateNode fail id=%d: %+v", id, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// DeleteNode deletes a topology node and its device memberships
+func (t *topologyImpl) DeleteNode(ctx context.Context, id int64) error {
+	return t.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := t.DBWithContext(txCtx).Where("node_id = ?", id).
+			Delete(&model.TopologyDevice{}).Error; err != nil {
+			logger.Errorf(ctx, "DeleteNode devices fail id=%d: %+v", id, err)
+			return code.DeleteDataErr.WithErr(err)
+		}
+		if err := t.DBWithContext(txCtx).Where("id = ?", id).
+			Delete(&model.TopologyNode{}).Error; err != nil {
+			logger.Errorf(ctx, "DeleteNode fail id=%d: %+v", id, err)
+			return code.DeleteDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// AddDevices adds devices to a node, keeping a single room or bench per device
+func (t *topologyImpl) AddDevices(ctx context.Context, node *model.TopologyNode, deviceIDs []int64) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+	return t.ExecTx(ctx, func(txCtx context.Context) error {
+		if node.Type.Placement() {
+			placements := t.DBWithContext(txCtx).Model(&model.TopologyNode{}).
+				Select("id").
+				Where("lab_id = ? AND type IN ? AND id <> ?", node.LabID,
+					[]model.TopologyNodeType{model.TopologyRoom, model.TopologyBench}, node.ID)
+			if err := t.DBWithContext(txCtx).
+				Where("device_id IN ? AND node_id IN (?)", deviceIDs, placements).
+				Delete(&model.TopologyDevice{}).Error; err != nil {
+				logger.Errorf(ctx, "AddDevices move fail node id=%d: %+v", node.ID, err)
+				return code.DeleteDataErr.WithErr(err)
+			}
+		}
+
+		datas := make([]*model.TopologyDevice, 0, len(deviceIDs))
+		for _, id := range deviceIDs {
+			datas = append(datas, &model.TopologyDevice{
+				LabID:    node.LabID,
+				NodeID:   node.ID,
+				DeviceID: id,
+			})
+		}
+		if err := t.DBWithContext(txCtx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "node_id"}, {Name: "device_id"}},
+			DoNothing: true,
+		}).Create(&datas).Error; err != nil {
+			logger.Errorf(ctx, "AddDevices fail node id=%d: %+v", node.ID, err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// RemoveDevices removes devices from a node
+func (t *topologyImpl) RemoveDevices(ctx context.Context, nodeID int64, deviceIDs []int64) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+	if err := t.DBWithContext(ctx).
+		Where("node_id = ? AND device_id IN ?", nodeID, deviceIDs).
+		Delete(&model.TopologyDevice{}).Error; err != nil {
+		logger.Errorf(ctx, "RemoveDevices fail node id=%d: %+v", nodeID, err)
+		return code.DeleteDataErr.WithErr(err)
+	}
+	return nil
+}
+
+// ListDevices lists the device memberships of a lab
+func (t *topologyImpl) ListDevices(ctx context.Context, labID int64) ([]*model.TopologyDevice, error) {
+	var datas []*model.TopologyDevice
+	if err := t.DBWithContext(ctx).
+		Where("lab_id = ?", labID).
+		Order("id ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListDevices fail lab=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// DeviceIDs lists the distinct devices of the nodes
+func (t *topologyImpl) DeviceIDs(ctx context.Context, nodeIDs []int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	if len(nodeIDs) == 0 {
+		return ids, nil
+	}
+	if err := t.DBWithContext(ctx).Model(&model.TopologyDevice{}).
+		Distinct("device_id").
+		Where("node_id IN ?", nodeIDs).
+		Order("device_id ASC").
+		Pluck("device_id", &ids).Error; err != nil {
+		logger.Errorf(ctx, "DeviceIDs fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return ids, nil
+}
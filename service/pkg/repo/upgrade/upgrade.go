@@ -0,0 +1,138 @@
+// Package upgrade provides repository operations for edge agent upgrade campaigns.
+package upgrade
+
+import (
+	"context"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"gorm.io/gorm"
+)
+
+// UpgradeRepo defines the interface for upgrade campaign repository operations
+type UpgradeRepo interface {
+	// CreateCampaign stores the campaign together with its targets
+	CreateCampaign(ctx context.Context, campaign *model.AgentUpgradeCampaign, targets []*model.AgentUpgradeTarget) error
+	GetCampaignByUUID(ctx context.Context, id uuid.UUID) (*model.AgentUpgradeCampaign, error)
+	// ListCampaigns lists the campaigns of a lab, newest first
+	ListCampaigns(ctx context.Context, labID int64) ([]*model.AgentUpgradeCampaign, error)
+	// ListActiveCampaigns lists the running, paused and rolling back campaigns
+	ListActiveCampaigns(ctx context.Context) ([]*model.AgentUpgradeCampaign, error)
+	// UpdateCampaign updates the campaign unless its status changed meanwhile
+	UpdateCampaign(ctx context.Context, campaign *model.AgentUpgradeCampaign, from model.UpgradeCampaignStatus, updates map[string]any) (bool, error)
+	// ListTargets lists the targets of a campaign in rollout order
+	ListTargets(ctx context.Context, campaignID int64) ([]*model.AgentUpgradeTarget, error)
+	UpdateTarget(ctx context.Context, id int64, updates map[string]any) error
+}
+
+type upgradeImpl struct {
+	repo.IDOrUUIDTranslate
+}
+
+// New creates a new upgrade campaign repository instance
+func New() UpgradeRepo {
+	return &upgradeImpl{
+		IDOrUUIDTranslate: repo.NewBaseDB(),
+	}
+}
+
+// CreateCampaign creates the campaign and its targets
+func (u *upgradeImpl) CreateCampaign(ctx context.Context, campaign *model.AgentUpgradeCampaign, targets []*model.AgentUpgradeTarget) error {
+	return u.ExecTx(ctx, func(txCtx context.Context) error {
+		if err := u.DBWithContext(txCtx).Create(campaign).Error; err != nil {
+			logger.Errorf(ctx, "CreateCampaign fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		for _, target := range targets {
+			target.CampaignID = campaign.ID
+		}
+		if err := u.DBWithContext(txCtx).CreateInBatches(targets, 200).Error; err != nil {
+			logger.Errorf(ctx, "CreateCampaign targets fail: %+v", err)
+			return code.CreateDataErr.WithErr(err)
+		}
+		return nil
+	})
+}
+
+// GetCampaignByUUID retrieves a campaign by UUID
+func (u *upgradeImpl) GetCampaignByUUID(ctx context.Context, id uuid.UUID) (*model.AgentUpgradeCampaign, error) {
+	var data model.AgentUpgradeCampaign
+	if err := u.DBWithContext(ctx).Where("uuid = ?", id).First(&data).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, code.RecordNotFound
+		}
+		logger.Errorf(ctx, "GetCampaignByUUID fail uuid=%s: %+v", id, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return &data, nil
+}
+
+// ListCampaigns lists the campaigns of a lab
+func (u *upgradeImpl) ListCampaigns(ctx context.Context, labID int64) ([]*model.AgentUpgradeCampaign, error) {
+	var datas []*model.AgentUpgradeCampaign
+	if err := u.DBWithContext(ctx).
+		Where("lab_id = ?", labID).
+		Order("id DESC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListCampaigns fail lab id=%d: %+v", labID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// ListActiveCampaigns lists the campaigns the sweep advances
+func (u *upgradeImpl) ListActiveCampaigns(ctx context.Context) ([]*model.AgentUpgradeCampaign, error) {
+	var datas []*model.AgentUpgradeCampaign
+	if err := u.DBWithContext(ctx).
+		Where("status IN ?", []model.UpgradeCampaignStatus{
+			model.UpgradeCampaignRunning, model.UpgradeCampaignPaused, model.UpgradeCampaignRollingBack,
+		}).
+		Order("id ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListActiveCampaigns fail: %+v", err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateCampaign conditionally updates a campaign
+func (u *upgradeImpl) UpdateCampaign(ctx context.Context, campaign *model.AgentUpgradeCampaign, from model.UpgradeCampaignStatus, updates map[string]any) (bool, error) {
+	updates["updated_at"] = time.Now()
+	ret := u.DBWithContext(ctx).Model(&model.AgentUpgradeCampaign{}).
+		Where("id = ? AND status = ?", campaign.ID, from).
+		Updates(updates)
+	if ret.Error != nil {
+		logger.Errorf(ctx, "UpdateCampaign fail id=%d: %+v", campaign.ID, ret.Error)
+		return false, code.UpdateDataErr.WithErr(ret.Error)
+	}
+	return ret.RowsAffected == 1, nil
+}
+
+// ListTargets lists the targets of a campaign
+func (u *upgradeImpl) ListTargets(ctx context.Context, campaignID int64) ([]*model.AgentUpgradeTarget, error) {
+	var datas []*model.AgentUpgradeTarget
+	if err := u.DBWithContext(ctx).
+		Where("campaign_id = ?", campaignID).
+		Order("seq ASC").
+		Find(&datas).Error; err != nil {
+		logger.Errorf(ctx, "ListTargets fail campaign id=%d: %+v", campaignID, err)
+		return nil, code.QueryRecordErr.WithErr(err)
+	}
+	return datas, nil
+}
+
+// UpdateTarget updates a target
+func (u *upgradeImpl) UpdateTarget(ctx context.Context, id int64, updates map[string]any) error {
+	updates["updated_at"] = time.Now()
+	if err := u.DBWithContext(ctx).Model(&model.AgentUpgradeTarget{}).
+		Where("id = ?", id).
+		Updates(updates).Error; err != nil {
+		logger.Errorf(ctx, "UpdateTarget fail id=%d: %+v", id, err)
+		return code.UpdateDataErr.WithErr(err)
+	}
+	return nil
+}
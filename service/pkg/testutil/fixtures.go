@@ -0,0 +1,60 @@
+package testutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"gorm.io/datatypes"
+)
+
+// NewExecution builds a workflow execution of a lab, finished executions get
+// a one minute duration
+func NewExecution(labID int64, status model.ExecutionStatus, startedAt time.Time) *model.WorkflowExecutionHistory {
+	exec := &model.WorkflowExecutionHistory{
+		LabID:        labID,
+		UserID:       "user-1",
+		WorkflowID:   1,
+		WorkflowUUID: uuid.NewV4(),
+		WorkflowName: "workflow-1",
+		Status:       status,
+		StartedAt:    startedAt,
+		Labels:       datatypes.NewJSONType(map[string]string{}),
+	}
+	if status != model.ExecutionStatusPending && status != model.ExecutionStatusRunning {
+		completedAt := startedAt.Add(time.Minute)
+		exec.CompletedAt = &completedAt
+		exec.DurationMs = time.Minute.Milliseconds()
+	}
+	return exec
+}
+
+// NewAction builds an action of an execution that ran on a device
+func NewAction(exec *model.WorkflowExecutionHistory, deviceID int64, status model.ExecutionStatus, createdAt time.Time) *model.ActionExecutionHistory {
+	action := &model.ActionExecutionHistory{
+		BaseModel:  model.BaseModel{CreatedAt: createdAt},
+		LabID:      exec.LabID,
+		DeviceID:   deviceID,
+		DeviceUUID: uuid.NewV4(),
+		DeviceName: fmt.Sprintf("device-%d", deviceID),
+		ActionType: "transfer",
+		ActionName: "transfer",
+		Status:     status,
+	}
+	if exec.ID > 0 {
+		action.WorkflowExecutionID = &exec.ID
+	}
+	return action
+}
+
+// NewDeviceEvent builds an event of a device
+func NewDeviceEvent(labID, deviceID int64, eventType model.DeviceEventType, timestamp time.Time) *model.DeviceEventHistory {
+	return &model.DeviceEventHistory{
+		LabID:      labID,
+		DeviceID:   deviceID,
+		DeviceUUID: uuid.NewV4(),
+		EventType:  eventType,
+		Timestamp:  timestamp,
+	}
+}
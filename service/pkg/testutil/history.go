@@ -0,0 +1,1095 @@
+// Package testutil provides in-memory repositories and record fixtures for
+// tests that should not need a database.
+package testutil
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/history"
+	"gorm.io/datatypes"
+	"gorm.io/gorm/schema"
+)
+
+// FakeHistoryRepo is an in-memory history.HistoryRepo. Executions, actions and
+// device events are filtered, ordered and paginated like the database
+// implementation, and lab statistics are computed from them. Aggregates that
+// need SQL, such as organization statistics or heatmaps, return the values
+// set on the exported fields. When Err is set every method returns it.
+type FakeHistoryRepo struct {
+	Err error
+
+	OrgLabs         map[string][]*model.Laboratory
+	OrgStats        *model.OrgStats
+	SlowestSteps    []*model.StepDurationStats
+	Heatmap         []*model.HeatmapCell
+	Utilization     *model.UtilizationReport
+	FailureStats    *model.FailureStats
+	StepPercentiles []*model.StepDurationPercentiles
+
+	mu         sync.Mutex
+	nextID     int64
+	executions []*model.WorkflowExecutionHistory
+	actions    []*model.ActionExecutionHistory
+	events     []*model.DeviceEventHistory
+	pins       []*model.ExecutionPin
+	snapshots  map[string]*model.WorkflowSnapshot
+}
+
+var _ history.HistoryRepo = (*FakeHistoryRepo)(nil)
+
+// NewFakeHistoryRepo creates an empty fake history repository
+func NewFakeHistoryRepo() *FakeHistoryRepo {
+	return &FakeHistoryRepo{
+		snapshots: make(map[string]*model.WorkflowSnapshot),
+	}
+}
+
+// Executions returns a copy of the stored executions in insertion order
+func (f *FakeHistoryRepo) Executions() []*model.WorkflowExecutionHistory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return cloneAll(f.executions)
+}
+
+// Actions returns a copy of the stored actions in insertion order
+func (f *FakeHistoryRepo) Actions() []*model.ActionExecutionHistory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return cloneAll(f.actions)
+}
+
+// Events returns a copy of the stored device events in insertion order
+func (f *FakeHistoryRepo) Events() []*model.DeviceEventHistory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return cloneAll(f.events)
+}
+
+// CreateWorkflowExecution stores an execution, filling in the id and UUID
+func (f *FakeHistoryRepo) CreateWorkflowExecution(_ context.Context, exec *model.WorkflowExecutionHistory) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.assign(&exec.BaseModel)
+	f.executions = append(f.executions, clone(exec))
+	return nil
+}
+
+// UpdateWorkflowExecution updates the columns of an execution
+func (f *FakeHistoryRepo) UpdateWorkflowExecution(ctx context.Context, id int64, updates map[string]interface{}) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.executions {
+		if e.ID == id {
+			if err := setColumns(ctx, e, updates); err != nil {
+				return err
+			}
+			e.Version++
+			return nil
+		}
+	}
+	return nil
+}
+
+// IncrementStepCounters adds to the step counters of an execution
+func (f *FakeHistoryRepo) IncrementStepCounters(_ context.Context, id int64, completedDelta, failedDelta int) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.executions {
+		if e.ID == id {
+			e.StepsCompleted += completedDelta
+			e.StepsFailed += failedDelta
+			e.Version++
+			return nil
+		}
+	}
+	return code.RecordNotFound
+}
+
+// CompleteWorkflowExecution sets the final columns and the duration of an execution when its version matches
+func (f *FakeHistoryRepo) CompleteWorkflowExecution(ctx context.Context, id, version int64, completedAt time.Time, updates map[string]interface{}) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.executions {
+		if e.ID == id {
+			if e.Version != version {
+				return code.HistoryVersionConflictErr
+			}
+			if err := setColumns(ctx, e, updates); err != nil {
+				return err
+			}
+			e.CompletedAt = &completedAt
+			e.DurationMs = max(completedAt.Sub(e.StartedAt).Milliseconds(), 0)
+			e.Version++
+			return nil
+		}
+	}
+	return code.RecordNotFound
+}
+
+// UpdateWorkflowExecutionVersion updates the columns of an execution when its version matches
+func (f *FakeHistoryRepo) UpdateWorkflowExecutionVersion(ctx context.Context, id, version int64, updates map[string]interface{}) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.executions {
+		if e.ID == id {
+			if e.Version != version {
+				return code.HistoryVersionConflictErr
+			}
+			if err := setColumns(ctx, e, updates); err != nil {
+				return err
+			}
+			e.Version++
+			return nil
+		}
+	}
+	return code.RecordNotFound
+}
+
+// GetWorkflowExecution gets an execution by id
+func (f *FakeHistoryRepo) GetWorkflowExecution(_ context.Context, id int64) (*model.WorkflowExecutionHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.executions {
+		if e.ID == id {
+			return clone(e), nil
+		}
+	}
+	return nil, code.RecordNotFound
+}
+
+// GetWorkflowExecutionByUUID gets an execution by UUID
+func (f *FakeHistoryRepo) GetWorkflowExecutionByUUID(_ context.Context, execUUID uuid.UUID) (*model.WorkflowExecutionHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.executions {
+		if e.UUID == execUUID {
+			return clone(e), nil
+		}
+	}
+	return nil, code.RecordNotFound
+}
+
+// ListWorkflowExecutions lists executions newest first
+func (f *FakeHistoryRepo) ListWorkflowExecutions(_ context.Context, params *model.HistoryQueryParams) ([]*model.WorkflowExecutionHistory, model.PageCount, error) {
+	if f.Err != nil {
+		return nil, model.PageCount{}, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	matched := sortExecutions(f.filterExecutions(params))
+	if params.Cursor == nil {
+		var snapshotID int64
+		for _, e := range f.executions {
+			snapshotID = max(snapshotID, e.ID)
+		}
+		datas, count := paginate(matched, params)
+		if params.Page == 1 {
+			count.Next = model.NextHistoryCursor(params, count, snapshotID, datas)
+		}
+		return datas, count, nil
+	}
+	after := make([]*model.WorkflowExecutionHistory, 0, len(matched))
+	for _, e := range matched {
+		if params.Cursor.Includes(e.StartedAt, e.ID) {
+			after = append(after, e)
+		}
+	}
+	datas, count := paginate(after, &model.HistoryQueryParams{Page: 1, PageSize: params.PageSize, Cursor: params.Cursor})
+	count.Next = model.NextHistoryCursor(params, count, 0, datas)
+	return datas, count, nil
+}
+
+// SetExecutionLabels replaces the labels of an execution
+func (f *FakeHistoryRepo) SetExecutionLabels(_ context.Context, id int64, labels map[string]string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.executions {
+		if e.ID == id {
+			e.Labels = datatypes.NewJSONType(labels)
+			e.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return code.RecordNotFound
+}
+
+// ListLabelKeys counts the executions using each label key, most used first
+func (f *FakeHistoryRepo) ListLabelKeys(_ context.Context, labID int64, limit int) ([]*model.LabelCount, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, e := range f.executions {
+		if e.LabID != labID {
+			continue
+		}
+		for k := range e.Labels.Data() {
+			counts[k]++
+		}
+	}
+	return labelCounts(counts, limit), nil
+}
+
+// ListLabelValues counts the executions using each value of a label key, most used first
+func (f *FakeHistoryRepo) ListLabelValues(_ context.Context, labID int64, key string, limit int) ([]*model.LabelCount, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, e := range f.executions {
+		if v, ok := e.Labels.Data()[key]; ok && e.LabID == labID {
+			counts[v]++
+		}
+	}
+	return labelCounts(counts, limit), nil
+}
+
+// PinExecution pins an execution, pinning twice keeps the first pin
+func (f *FakeHistoryRepo) PinExecution(_ context.Context, pin *model.ExecutionPin) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.pins {
+		if p.UserID == pin.UserID && p.ExecutionID == pin.ExecutionID {
+			return nil
+		}
+	}
+	f.assign(&pin.BaseModel)
+	f.pins = append(f.pins, clone(pin))
+	return nil
+}
+
+// UnpinExecution removes the pin of a user
+func (f *FakeHistoryRepo) UnpinExecution(_ context.Context, userID string, executionID int64) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pins = remove(f.pins, func(p *model.ExecutionPin) bool {
+		return p.UserID == userID && p.ExecutionID == executionID
+	})
+	return nil
+}
+
+// PinnedExecutionIDs returns the pinned subset of the executions
+func (f *FakeHistoryRepo) PinnedExecutionIDs(_ context.Context, userID string, executionIDs []int64) (map[int64]bool, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pinned := make(map[int64]bool)
+	for _, p := range f.pins {
+		if p.UserID == userID && contains(executionIDs, p.ExecutionID) {
+			pinned[p.ExecutionID] = true
+		}
+	}
+	return pinned, nil
+}
+
+// SaveWorkflowSnapshot stores a snapshot unless one with the same hash exists
+func (f *FakeHistoryRepo) SaveWorkflowSnapshot(_ context.Context, snapshot *model.WorkflowSnapshot) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.snapshots[snapshot.Hash]; !ok {
+		f.snapshots[snapshot.Hash] = clone(snapshot)
+	}
+	return nil
+}
+
+// GetWorkflowSnapshot gets a snapshot by hash
+func (f *FakeHistoryRepo) GetWorkflowSnapshot(_ context.Context, hash string) (*model.WorkflowSnapshot, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	snapshot, ok := f.snapshots[hash]
+	if !ok {
+		return nil, code.RecordNotFound
+	}
+	return clone(snapshot), nil
+}
+
+// CreateActionExecution stores an action
+func (f *FakeHistoryRepo) CreateActionExecution(ctx context.Context, exec *model.ActionExecutionHistory) error {
+	return f.CreateActionExecutionBatch(ctx, []*model.ActionExecutionHistory{exec})
+}
+
+// CreateActionExecutionBatch stores actions
+func (f *FakeHistoryRepo) CreateActionExecutionBatch(_ context.Context, execs []*model.ActionExecutionHistory) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, a := range execs {
+		f.assign(&a.BaseModel)
+		f.actions = append(f.actions, clone(a))
+	}
+	return nil
+}
+
+// ReportAction creates a new action or finishes a stored unfinished one,
+// adding a newly finished action to the step counters of its execution
+func (f *FakeHistoryRepo) ReportAction(_ context.Context, action *model.ActionExecutionHistory) (bool, error) {
+	if f.Err != nil {
+		return false, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var stored *model.ActionExecutionHistory
+	for _, a := range f.actions {
+		if a.UUID == action.UUID {
+			stored = a
+		}
+	}
+	switch {
+	case stored == nil:
+		f.assign(&action.BaseModel)
+		f.actions = append(f.actions, clone(action))
+	case stored.LabID != action.LabID:
+		return false, code.NoPermission
+	case stored.Status.Finished() || !action.Status.Finished():
+		return false, nil
+	default:
+		stored.Status = action.Status
+		stored.Output = action.Output
+		stored.DurationMs = action.DurationMs
+		stored.ErrorMessage = action.ErrorMessage
+		stored.ErrorCategory = action.ErrorCategory
+		stored.UpdatedAt = action.UpdatedAt
+		action.ID = stored.ID
+		action.WorkflowExecutionID = stored.WorkflowExecutionID
+	}
+
+	if !action.Status.Finished() {
+		return false, nil
+	}
+	for _, e := range f.executions {
+		if action.WorkflowExecutionID == nil || e.ID != *action.WorkflowExecutionID {
+			continue
+		}
+		switch action.Status {
+		case model.ExecutionStatusSuccess:
+			e.StepsCompleted++
+			e.Version++
+		case model.ExecutionStatusFailed, model.ExecutionStatusTimeout:
+			e.StepsFailed++
+			e.Version++
+		}
+	}
+	return true, nil
+}
+
+// ListActionExecutions lists actions newest first
+func (f *FakeHistoryRepo) ListActionExecutions(_ context.Context, params *model.HistoryQueryParams) ([]*model.ActionExecutionHistory, model.PageCount, error) {
+	if f.Err != nil {
+		return nil, model.PageCount{}, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	matched := f.filterActions(params)
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+	datas, count := paginate(matched, params)
+	return datas, count, nil
+}
+
+// GetActionExecutionByUUID gets an action by UUID
+func (f *FakeHistoryRepo) GetActionExecutionByUUID(_ context.Context, actionUUID uuid.UUID) (*model.ActionExecutionHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, a := range f.actions {
+		if a.UUID == actionUUID {
+			return clone(a), nil
+		}
+	}
+	return nil, code.RecordNotFound
+}
+
+// ListActionsByWorkflowExecution lists the actions of an execution in time order
+func (f *FakeHistoryRepo) ListActionsByWorkflowExecution(_ context.Context, workflowExecID int64) ([]*model.ActionExecutionHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	datas := make([]*model.ActionExecutionHistory, 0)
+	for _, a := range f.actions {
+		if a.WorkflowExecutionID != nil && *a.WorkflowExecutionID == workflowExecID {
+			datas = append(datas, clone(a))
+		}
+	}
+	sortActions(datas)
+	return datas, nil
+}
+
+// ListActionsByWorkflowExecutions lists at most limit actions of each execution
+func (f *FakeHistoryRepo) ListActionsByWorkflowExecutions(_ context.Context, workflowExecIDs []int64, limit int) ([]*model.ActionExecutionHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	datas := make([]*model.ActionExecutionHistory, 0)
+	for _, a := range f.actions {
+		if a.WorkflowExecutionID != nil && contains(workflowExecIDs, *a.WorkflowExecutionID) {
+			datas = append(datas, clone(a))
+		}
+	}
+	sortActions(datas)
+
+	kept := datas[:0]
+	perExecution := make(map[int64]int)
+	for _, a := range datas {
+		if perExecution[*a.WorkflowExecutionID] < limit {
+			perExecution[*a.WorkflowExecutionID]++
+			kept = append(kept, a)
+		}
+	}
+	return kept, nil
+}
+
+// CreateDeviceEvent stores a device event
+func (f *FakeHistoryRepo) CreateDeviceEvent(ctx context.Context, event *model.DeviceEventHistory) error {
+	return f.CreateDeviceEventBatch(ctx, []*model.DeviceEventHistory{event})
+}
+
+// CreateDeviceEventBatch stores device events
+func (f *FakeHistoryRepo) CreateDeviceEventBatch(_ context.Context, events []*model.DeviceEventHistory) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range events {
+		f.assign(&e.BaseModel)
+		f.events = append(f.events, clone(e))
+	}
+	return nil
+}
+
+// IngestDeviceEvents stores device events like CreateDeviceEventBatch
+func (f *FakeHistoryRepo) IngestDeviceEvents(ctx context.Context, events []*model.DeviceEventHistory) (int64, error) {
+	if err := f.CreateDeviceEventBatch(ctx, events); err != nil {
+		return 0, err
+	}
+	return int64(len(events)), nil
+}
+
+// ListDeviceEvents lists device events newest first
+func (f *FakeHistoryRepo) ListDeviceEvents(_ context.Context, params *model.HistoryQueryParams) ([]*model.DeviceEventHistory, model.PageCount, error) {
+	if f.Err != nil {
+		return nil, model.PageCount{}, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	datas, count := paginate(sortEvents(f.filterEvents(params)), params)
+	return datas, count, nil
+}
+
+// GetLabStats computes the execution, action and event statistics of a lab,
+// material usage is not tracked by the fake
+func (f *FakeHistoryRepo) GetLabStats(_ context.Context, labID int64, startTime, endTime *time.Time, deviceIDs []int64) (*model.HistoryStats, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	params := &model.HistoryQueryParams{LabID: labID, StartTime: startTime, EndTime: endTime, DeviceIDs: deviceIDs}
+
+	stats := &model.HistoryStats{}
+	var durationSum, durationCount int64
+	for _, e := range f.filterExecutions(params) {
+		stats.TotalExecutions++
+		switch e.Status {
+		case model.ExecutionStatusSuccess:
+			stats.SuccessfulCount++
+		case model.ExecutionStatusFailed:
+			stats.FailedCount++
+		}
+		if e.SLABreached != nil {
+			stats.SLATracked++
+			if *e.SLABreached {
+				stats.SLABreachedCount++
+			}
+		}
+		if e.DurationMs > 0 {
+			durationSum += e.DurationMs
+			durationCount++
+		}
+	}
+	if stats.TotalExecutions > 0 {
+		stats.SuccessRate = float64(stats.SuccessfulCount) / float64(stats.TotalExecutions) * 100
+	}
+	if stats.SLATracked > 0 {
+		stats.SLACompliance = float64(stats.SLATracked-stats.SLABreachedCount) / float64(stats.SLATracked) * 100
+	}
+	if durationCount > 0 {
+		stats.AverageDurationMs = float64(durationSum) / float64(durationCount)
+	}
+	stats.TotalActionsCount = int64(len(f.filterActions(params)))
+	stats.TotalDeviceEvents = int64(len(f.filterEvents(params)))
+	return stats, nil
+}
+
+// ListOrgLabs returns the labs set on OrgLabs
+func (f *FakeHistoryRepo) ListOrgLabs(_ context.Context, orgID string) ([]*model.Laboratory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.OrgLabs[orgID], nil
+}
+
+// GetOrgStats returns OrgStats
+func (f *FakeHistoryRepo) GetOrgStats(context.Context, []*model.Laboratory, time.Time, time.Time, int) (*model.OrgStats, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.OrgStats, nil
+}
+
+// ListSlowestSteps returns SlowestSteps
+func (f *FakeHistoryRepo) ListSlowestSteps(context.Context, int64, *time.Time, *time.Time, int) ([]*model.StepDurationStats, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.SlowestSteps, nil
+}
+
+// ActivityHeatmap returns Heatmap
+func (f *FakeHistoryRepo) ActivityHeatmap(context.Context, int64, time.Time, time.Time, string) ([]*model.HeatmapCell, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Heatmap, nil
+}
+
+// GetDeviceUtilization returns Utilization
+func (f *FakeHistoryRepo) GetDeviceUtilization(context.Context, int64, time.Time, time.Time) (*model.UtilizationReport, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Utilization, nil
+}
+
+// GetFailureStats returns FailureStats
+func (f *FakeHistoryRepo) GetFailureStats(context.Context, int64, time.Time, time.Time) (*model.FailureStats, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.FailureStats, nil
+}
+
+// ListActiveExecutions lists the pending and running executions of a lab in queue order
+func (f *FakeHistoryRepo) ListActiveExecutions(_ context.Context, labID int64, limit int) ([]*model.WorkflowExecutionHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	datas := make([]*model.WorkflowExecutionHistory, 0)
+	for _, e := range f.executions {
+		if e.LabID == labID && (e.Status == model.ExecutionStatusPending || e.Status == model.ExecutionStatusRunning) {
+			datas = append(datas, clone(e))
+		}
+	}
+	sort.SliceStable(datas, func(i, j int) bool {
+		if !datas[i].StartedAt.Equal(datas[j].StartedAt) {
+			return datas[i].StartedAt.Before(datas[j].StartedAt)
+		}
+		return datas[i].ID < datas[j].ID
+	})
+	if len(datas) > limit {
+		datas = datas[:limit]
+	}
+	return datas, nil
+}
+
+// ListDurationSamples returns the durations of the latest successful executions of each workflow
+func (f *FakeHistoryRepo) ListDurationSamples(_ context.Context, workflowIDs []int64, since time.Time, limit int) (map[int64][]int64, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	matched := make([]*model.WorkflowExecutionHistory, 0)
+	for _, e := range f.executions {
+		if contains(workflowIDs, e.WorkflowID) && e.Status == model.ExecutionStatusSuccess &&
+			e.DurationMs > 0 && !e.StartedAt.Before(since) {
+			matched = append(matched, e)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].StartedAt.After(matched[j].StartedAt)
+	})
+	samples := make(map[int64][]int64, len(workflowIDs))
+	for _, e := range matched {
+		if len(samples[e.WorkflowID]) < limit {
+			samples[e.WorkflowID] = append(samples[e.WorkflowID], e.DurationMs)
+		}
+	}
+	return samples, nil
+}
+
+// ListStepDurationPercentiles returns StepPercentiles
+func (f *FakeHistoryRepo) ListStepDurationPercentiles(context.Context, int64, time.Time) ([]*model.StepDurationPercentiles, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.StepPercentiles, nil
+}
+
+// ExportBounds counts the matching records and returns their largest id
+func (f *FakeHistoryRepo) ExportBounds(_ context.Context, kind model.HistoryExportKind, params *model.HistoryQueryParams) (int64, int64, error) {
+	if f.Err != nil {
+		return 0, 0, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []int64
+	switch kind {
+	case model.HistoryExportAction:
+		ids = idsOf(f.filterActions(params), func(a *model.ActionExecutionHistory) int64 { return a.ID })
+	case model.HistoryExportDeviceEvent:
+		ids = idsOf(f.filterEvents(params), func(e *model.DeviceEventHistory) int64 { return e.ID })
+	default:
+		ids = idsOf(f.filterExecutions(params), func(e *model.WorkflowExecutionHistory) int64 { return e.ID })
+	}
+	var maxID int64
+	for _, id := range ids {
+		maxID = max(maxID, id)
+	}
+	return int64(len(ids)), maxID, nil
+}
+
+// ScanWorkflowExecutions reads the matching executions with id in (afterID, maxID] in id order
+func (f *FakeHistoryRepo) ScanWorkflowExecutions(_ context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.WorkflowExecutionHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return scan(f.filterExecutions(params), func(e *model.WorkflowExecutionHistory) int64 { return e.ID }, afterID, maxID, limit), nil
+}
+
+// ScanActionExecutions reads the matching actions with id in (afterID, maxID] in id order
+func (f *FakeHistoryRepo) ScanActionExecutions(_ context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.ActionExecutionHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return scan(f.filterActions(params), func(a *model.ActionExecutionHistory) int64 { return a.ID }, afterID, maxID, limit), nil
+}
+
+// ScanDeviceEvents reads the matching device events with id in (afterID, maxID] in id order
+func (f *FakeHistoryRepo) ScanDeviceEvents(_ context.Context, params *model.HistoryQueryParams, afterID, maxID int64, limit int) ([]*model.DeviceEventHistory, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return scan(f.filterEvents(params), func(e *model.DeviceEventHistory) int64 { return e.ID }, afterID, maxID, limit), nil
+}
+
+// StreamWorkflowExecutions calls fn with the first limit matching executions
+// in list order, fn runs without holding the lock
+func (f *FakeHistoryRepo) StreamWorkflowExecutions(_ context.Context, params *model.HistoryQueryParams, limit int, fn func(*model.WorkflowExecutionHistory) error) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	matched := sortExecutions(f.filterExecutions(params))
+	f.mu.Unlock()
+	return stream(matched, limit, fn)
+}
+
+// StreamDeviceEvents calls fn with the first limit matching device events in list order
+func (f *FakeHistoryRepo) StreamDeviceEvents(_ context.Context, params *model.HistoryQueryParams, limit int, fn func(*model.DeviceEventHistory) error) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	matched := sortEvents(f.filterEvents(params))
+	f.mu.Unlock()
+	return stream(matched, limit, fn)
+}
+
+// LabPinnedExecutionIDs returns the executions of the lab pinned by the user
+func (f *FakeHistoryRepo) LabPinnedExecutionIDs(_ context.Context, userID string, labID int64) (map[int64]bool, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pinned := make(map[int64]bool)
+	for _, p := range f.pins {
+		if p.UserID == userID && p.LabID == labID {
+			pinned[p.ExecutionID] = true
+		}
+	}
+	return pinned, nil
+}
+
+// ListChanges lists the records of the lab after the watermark in (updated_at, kind, id) order
+func (f *FakeHistoryRepo) ListChanges(_ context.Context, labID int64, since *model.HistoryWatermark, until time.Time, limit int) ([]*model.HistoryChange, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	changes := make([]*model.HistoryChange, 0)
+	add := func(c *model.HistoryChange, b model.BaseModel) {
+		w := c.Watermark()
+		if w.UpdatedAt.After(until) || (since != nil && !since.Before(w)) {
+			return
+		}
+		c.Created = since == nil || b.CreatedAt.After(since.UpdatedAt)
+		changes = append(changes, c)
+	}
+	for _, e := range f.executions {
+		if e.LabID == labID {
+			add(&model.HistoryChange{Kind: model.HistoryChangeWorkflow, Execution: clone(e)}, e.BaseModel)
+		}
+	}
+	for _, a := range f.actions {
+		if a.LabID == labID {
+			add(&model.HistoryChange{Kind: model.HistoryChangeAction, Action: clone(a)}, a.BaseModel)
+		}
+	}
+	for _, e := range f.events {
+		if e.LabID == labID {
+			add(&model.HistoryChange{Kind: model.HistoryChangeEvent, Event: clone(e)}, e.BaseModel)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Watermark().Before(changes[j].Watermark())
+	})
+	return changes[:min(limit, len(changes))], nil
+}
+
+// CleanupOldRecords removes the records older than the time, the fake has no legal holds
+func (f *FakeHistoryRepo) CleanupOldRecords(_ context.Context, before time.Time) (int64, error) {
+	if f.Err != nil {
+		return 0, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	total := len(f.executions) + len(f.actions) + len(f.events)
+	f.executions = remove(f.executions, func(e *model.WorkflowExecutionHistory) bool { return e.StartedAt.Before(before) })
+	f.actions = remove(f.actions, func(a *model.ActionExecutionHistory) bool { return a.CreatedAt.Before(before) })
+	f.events = remove(f.events, func(e *model.DeviceEventHistory) bool { return e.Timestamp.Before(before) })
+	return int64(total - len(f.executions) - len(f.actions) - len(f.events)), nil
+}
+
+// assign fills in the columns the database would generate
+func (f *FakeHistoryRepo) assign(base *model.BaseModel) {
+	if base.ID == 0 {
+		f.nextID++
+		base.ID = f.nextID
+	} else {
+		f.nextID = max(f.nextID, base.ID)
+	}
+	if base.UUID.IsNil() {
+		base.UUID = uuid.New()
+	}
+	now := time.Now()
+	if base.CreatedAt.IsZero() {
+		base.CreatedAt = now
+	}
+	if base.UpdatedAt.IsZero() {
+		base.UpdatedAt = now
+	}
+}
+
+// sortExecutions orders executions newest first
+func sortExecutions(datas []*model.WorkflowExecutionHistory) []*model.WorkflowExecutionHistory {
+	sort.SliceStable(datas, func(i, j int) bool {
+		if !datas[i].StartedAt.Equal(datas[j].StartedAt) {
+			return datas[i].StartedAt.After(datas[j].StartedAt)
+		}
+		return datas[i].ID > datas[j].ID
+	})
+	return datas
+}
+
+// sortEvents orders device events newest first
+func sortEvents(datas []*model.DeviceEventHistory) []*model.DeviceEventHistory {
+	sort.SliceStable(datas, func(i, j int) bool {
+		return datas[i].Timestamp.After(datas[j].Timestamp)
+	})
+	return datas
+}
+
+// stream calls fn with the first limit datas, stopping at the first error
+func stream[T any](datas []*T, limit int, fn func(*T) error) error {
+	if len(datas) > limit {
+		datas = datas[:limit]
+	}
+	for _, data := range datas {
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterExecutions mirrors the workflow filters of the database implementation
+func (f *FakeHistoryRepo) filterExecutions(params *model.HistoryQueryParams) []*model.WorkflowExecutionHistory {
+	datas := make([]*model.WorkflowExecutionHistory, 0)
+	for _, e := range f.executions {
+		if params.LabID > 0 && e.LabID != params.LabID ||
+			params.UserID != "" && e.UserID != params.UserID ||
+			params.WorkflowID != nil && e.WorkflowID != *params.WorkflowID ||
+			params.DeviceID != nil && !f.ranOnDevices(e.ID, []int64{*params.DeviceID}) ||
+			params.DeviceIDs != nil && !f.ranOnDevices(e.ID, params.DeviceIDs) ||
+			params.ExperimentID != nil && (e.ExperimentID == nil || *e.ExperimentID != *params.ExperimentID) ||
+			params.Status != nil && e.Status != *params.Status ||
+			!hasLabels(e.Labels.Data(), params.Labels) ||
+			params.PinnedBy != "" && !f.pinned(params.PinnedBy, e.ID) ||
+			params.Breached != nil && (e.SLABreached == nil || *e.SLABreached != *params.Breached) ||
+			!inRange(e.StartedAt, params) {
+			continue
+		}
+		datas = append(datas, clone(e))
+	}
+	return datas
+}
+
+// filterActions mirrors the action filters of the database implementation
+func (f *FakeHistoryRepo) filterActions(params *model.HistoryQueryParams) []*model.ActionExecutionHistory {
+	datas := make([]*model.ActionExecutionHistory, 0)
+	for _, a := range f.actions {
+		if params.LabID > 0 && a.LabID != params.LabID ||
+			params.DeviceID != nil && a.DeviceID != *params.DeviceID ||
+			params.DeviceIDs != nil && !contains(params.DeviceIDs, a.DeviceID) ||
+			params.ExperimentID != nil && !f.inExperiment(a.WorkflowExecutionID, *params.ExperimentID) ||
+			params.Status != nil && a.Status != *params.Status ||
+			!inRange(a.CreatedAt, params) {
+			continue
+		}
+		datas = append(datas, clone(a))
+	}
+	return datas
+}
+
+// filterEvents mirrors the device event filters of the database implementation
+func (f *FakeHistoryRepo) filterEvents(params *model.HistoryQueryParams) []*model.DeviceEventHistory {
+	datas := make([]*model.DeviceEventHistory, 0)
+	for _, e := range f.events {
+		if params.LabID > 0 && e.LabID != params.LabID ||
+			params.DeviceID != nil && e.DeviceID != *params.DeviceID ||
+			params.DeviceIDs != nil && !contains(params.DeviceIDs, e.DeviceID) ||
+			params.EventType != nil && e.EventType != *params.EventType ||
+			!inRange(e.Timestamp, params) {
+			continue
+		}
+		datas = append(datas, clone(e))
+	}
+	return datas
+}
+
+func (f *FakeHistoryRepo) ranOnDevices(executionID int64, deviceIDs []int64) bool {
+	for _, a := range f.actions {
+		if a.WorkflowExecutionID != nil && *a.WorkflowExecutionID == executionID && contains(deviceIDs, a.DeviceID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeHistoryRepo) inExperiment(executionID *int64, experimentID int64) bool {
+	if executionID == nil {
+		return false
+	}
+	for _, e := range f.executions {
+		if e.ID == *executionID {
+			return e.ExperimentID != nil && *e.ExperimentID == experimentID
+		}
+	}
+	return false
+}
+
+func (f *FakeHistoryRepo) pinned(userID string, executionID int64) bool {
+	for _, p := range f.pins {
+		if p.UserID == userID && p.ExecutionID == executionID {
+			return true
+		}
+	}
+	return false
+}
+
+// paginate cuts a page from sorted records, fetching one more when the total is skipped
+func paginate[T any](datas []T, params *model.HistoryQueryParams) ([]T, model.PageCount) {
+	offset := min((params.Page-1)*params.PageSize, len(datas))
+	limit := params.PageSize
+	if params.SkipTotal || params.Cursor != nil {
+		limit++
+	}
+	page := datas[offset:min(offset+limit, len(datas))]
+	count, keep := model.NewPageCount(params, len(page), int64(len(datas)))
+	return page[:keep], count
+}
+
+func scan[T any](datas []T, id func(T) int64, afterID, maxID int64, limit int) []T {
+	sort.SliceStable(datas, func(i, j int) bool { return id(datas[i]) < id(datas[j]) })
+	page := make([]T, 0, limit)
+	for _, d := range datas {
+		if id(d) > afterID && id(d) <= maxID && len(page) < limit {
+			page = append(page, d)
+		}
+	}
+	return page
+}
+
+func sortActions(datas []*model.ActionExecutionHistory) {
+	sort.SliceStable(datas, func(i, j int) bool {
+		a, b := datas[i], datas[j]
+		if *a.WorkflowExecutionID != *b.WorkflowExecutionID {
+			return *a.WorkflowExecutionID < *b.WorkflowExecutionID
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+}
+
+func labelCounts(counts map[string]int64, limit int) []*model.LabelCount {
+	datas := make([]*model.LabelCount, 0, len(counts))
+	for value, count := range counts {
+		datas = append(datas, &model.LabelCount{Value: value, Count: count})
+	}
+	sort.Slice(datas, func(i, j int) bool {
+		if datas[i].Count != datas[j].Count {
+			return datas[i].Count > datas[j].Count
+		}
+		return datas[i].Value < datas[j].Value
+	})
+	if len(datas) > limit {
+		datas = datas[:limit]
+	}
+	return datas
+}
+
+func hasLabels(labels, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func inRange(t time.Time, params *model.HistoryQueryParams) bool {
+	return (params.StartTime == nil || !t.Before(*params.StartTime)) &&
+		(params.EndTime == nil || !t.After(*params.EndTime))
+}
+
+func contains(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func idsOf[T any](datas []T, id func(T) int64) []int64 {
+	ids := make([]int64, 0, len(datas))
+	for _, d := range datas {
+		ids = append(ids, id(d))
+	}
+	return ids
+}
+
+func remove[T any](datas []T, drop func(T) bool) []T {
+	kept := datas[:0]
+	for _, d := range datas {
+		if !drop(d) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+func clone[T any](data *T) *T {
+	c := *data
+	return &c
+}
+
+func cloneAll[T any](datas []*T) []*T {
+	res := make([]*T, 0, len(datas))
+	for _, d := range datas {
+		res = append(res, clone(d))
+	}
+	return res
+}
+
+var schemaCache sync.Map
+
+// setColumns applies column updates by their database names like gorm Updates
+func setColumns(ctx context.Context, data any, updates map[string]any) error {
+	s, err := schema.Parse(data, &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return code.UpdateDataErr.WithErr(err)
+	}
+	value := reflect.ValueOf(data).Elem()
+	for column, v := range updates {
+		field := s.LookUpField(column)
+		if field == nil {
+			return code.UpdateDataErr.WithMsgf("unknown column %s", column)
+		}
+		if err := field.Set(ctx, value, v); err != nil {
+			return code.UpdateDataErr.WithErr(err)
+		}
+	}
+	return nil
+}
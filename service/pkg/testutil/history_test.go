@@ -0,0 +1,186 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+var base = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func seedExecutions(t *testing.T, repo *FakeHistoryRepo, n int) []*model.WorkflowExecutionHistory {
+	t.Helper()
+	execs := make([]*model.WorkflowExecutionHistory, 0, n)
+	for i := 0; i < n; i++ {
+		exec := NewExecution(1, model.ExecutionStatusSuccess, base.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, repo.CreateWorkflowExecution(context.Background(), exec))
+		execs = append(execs, exec)
+	}
+	return execs
+}
+
+func TestFakeListWorkflowExecutionsPagination(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFakeHistoryRepo()
+	execs := seedExecutions(t, repo, 5)
+
+	params := model.NewHistoryQueryParams()
+	params.LabID = 1
+	params.Page, params.PageSize = 1, 2
+	datas, count, err := repo.ListWorkflowExecutions(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), count.Total)
+	assert.True(t, count.HasMore)
+	require.Len(t, datas, 2)
+	assert.Equal(t, execs[4].ID, datas[0].ID)
+	assert.Equal(t, execs[3].ID, datas[1].ID)
+
+	params.Page = 3
+	datas, count, err = repo.ListWorkflowExecutions(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, datas, 1)
+	assert.Equal(t, execs[0].ID, datas[0].ID)
+	assert.False(t, count.HasMore)
+
+	params.Page, params.PageSize, params.SkipTotal = 1, 5, true
+	datas, count, err = repo.ListWorkflowExecutions(ctx, params)
+	require.NoError(t, err)
+	assert.Len(t, datas, 5)
+	assert.Equal(t, int64(-1), count.Total)
+	assert.False(t, count.HasMore)
+}
+
+func TestFakeListWorkflowExecutionsFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFakeHistoryRepo()
+	execs := seedExecutions(t, repo, 3)
+	require.NoError(t, repo.SetExecutionLabels(ctx, execs[0].ID, map[string]string{"project": "a"}))
+	require.NoError(t, repo.UpdateWorkflowExecution(ctx, execs[1].ID, map[string]interface{}{"status": model.ExecutionStatusFailed}))
+	require.NoError(t, repo.CreateActionExecution(ctx, NewAction(execs[2], 7, model.ExecutionStatusSuccess, base)))
+	require.NoError(t, repo.PinExecution(ctx, &model.ExecutionPin{UserID: "u", ExecutionID: execs[2].ID, LabID: 1}))
+
+	list := func(mutate func(*model.HistoryQueryParams)) []int64 {
+		params := model.NewHistoryQueryParams()
+		params.LabID = 1
+		mutate(params)
+		datas, _, err := repo.ListWorkflowExecutions(ctx, params)
+		require.NoError(t, err)
+		ids := make([]int64, 0, len(datas))
+		for _, d := range datas {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int64{execs[0].ID}, list(func(p *model.HistoryQueryParams) { p.Labels = map[string]string{"project": "a"} }))
+	failed := model.ExecutionStatusFailed
+	assert.Equal(t, []int64{execs[1].ID}, list(func(p *model.HistoryQueryParams) { p.Status = &failed }))
+	device := int64(7)
+	assert.Equal(t, []int64{execs[2].ID}, list(func(p *model.HistoryQueryParams) { p.DeviceID = &device }))
+	assert.Empty(t, list(func(p *model.HistoryQueryParams) { p.DeviceIDs = []int64{} }))
+	assert.Equal(t, []int64{execs[2].ID}, list(func(p *model.HistoryQueryParams) { p.PinnedBy = "u" }))
+	end := base.Add(time.Hour)
+	assert.Equal(t, []int64{execs[1].ID, execs[0].ID}, list(func(p *model.HistoryQueryParams) { p.EndTime = &end }))
+	assert.Empty(t, list(func(p *model.HistoryQueryParams) { p.LabID = 2 }))
+}
+
+func TestFakeUpdateWorkflowExecution(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFakeHistoryRepo()
+	exec := seedExecutions(t, repo, 1)[0]
+
+	msg := "boom"
+	require.NoError(t, repo.UpdateWorkflowExecution(ctx, exec.ID, map[string]interface{}{
+		"status":        model.ExecutionStatusFailed,
+		"error_message": &msg,
+		"duration_ms":   int64(42),
+		"result":        datatypes.JSON(`{"ok":false}`),
+	}))
+	got, err := repo.GetWorkflowExecution(ctx, exec.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ExecutionStatusFailed, got.Status)
+	assert.Equal(t, "boom", *got.ErrorMessage)
+	assert.Equal(t, int64(42), got.DurationMs)
+	assert.JSONEq(t, `{"ok":false}`, string(got.Result))
+
+	assert.Error(t, repo.UpdateWorkflowExecution(ctx, exec.ID, map[string]interface{}{"no_such_column": 1}))
+
+	_, err = repo.GetWorkflowExecution(ctx, exec.ID+1)
+	assert.ErrorIs(t, err, code.RecordNotFound)
+}
+
+func TestFakeActionsAndEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFakeHistoryRepo()
+	execs := seedExecutions(t, repo, 2)
+	for i := 0; i < 3; i++ {
+		for _, exec := range execs {
+			require.NoError(t, repo.CreateActionExecution(ctx, NewAction(exec, int64(i), model.ExecutionStatusSuccess, exec.StartedAt.Add(time.Duration(i)*time.Minute))))
+		}
+	}
+
+	actions, err := repo.ListActionsByWorkflowExecution(ctx, execs[0].ID)
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+	assert.True(t, actions[0].CreatedAt.Before(actions[2].CreatedAt))
+
+	actions, err = repo.ListActionsByWorkflowExecutions(ctx, []int64{execs[0].ID, execs[1].ID}, 2)
+	require.NoError(t, err)
+	require.Len(t, actions, 4)
+	assert.Equal(t, execs[0].ID, *actions[0].WorkflowExecutionID)
+	assert.Equal(t, execs[1].ID, *actions[3].WorkflowExecutionID)
+
+	_, err = repo.IngestDeviceEvents(ctx, []*model.DeviceEventHistory{
+		NewDeviceEvent(1, 1, model.DeviceEventError, base),
+		NewDeviceEvent(1, 2, model.DeviceEventConnected, base.Add(time.Minute)),
+	})
+	require.NoError(t, err)
+	params := model.NewHistoryQueryParams()
+	params.LabID = 1
+	eventType := model.DeviceEventError
+	params.EventType = &eventType
+	events, count, err := repo.ListDeviceEvents(ctx, params)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, int64(1), count.Total)
+
+	removed, err := repo.CleanupOldRecords(ctx, base.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1+3+2), removed)
+	assert.Len(t, repo.Executions(), 1)
+	assert.Len(t, repo.Actions(), 3)
+	assert.Empty(t, repo.Events())
+}
+
+func TestFakeScanAndBounds(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFakeHistoryRepo()
+	execs := seedExecutions(t, repo, 5)
+	params := model.NewHistoryQueryParams()
+	params.LabID = 1
+
+	total, maxID, err := repo.ExportBounds(ctx, model.HistoryExportWorkflow, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, execs[4].ID, maxID)
+
+	datas, err := repo.ScanWorkflowExecutions(ctx, params, execs[0].ID, execs[3].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, datas, 2)
+	assert.Equal(t, execs[1].ID, datas[0].ID)
+	assert.Equal(t, execs[2].ID, datas[1].ID)
+}
+
+func TestFakeErr(t *testing.T) {
+	repo := NewFakeHistoryRepo()
+	repo.Err = assert.AnError
+	_, _, err := repo.ListWorkflowExecutions(context.Background(), model.NewHistoryQueryParams())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.ErrorIs(t, repo.CreateWorkflowExecution(context.Background(), NewExecution(1, model.ExecutionStatusSuccess, base)), assert.AnError)
+}
@@ -12,6 +12,12 @@ const (
 	LabControlPrefix = "lab_control_queue_%s"
 	LabHeartPrefix   = "lab_heart_key_%s"
 
+	DeviceLockPrefix        = "device_lock_%s_%s"
+	DeviceLockQueuePrefix   = "device_lock_queue_%s_%s"
+	DeviceLockWaitersPrefix = "device_lock_waiters_%s_%s"
+
+	LeaderPrefix = "leader_election_%s"
+
 	LabHeartTime = 5 * time.Second
 )
 
@@ -26,3 +32,19 @@ func LabControlName(labUUID uuid.UUID) string {
 func LabHeartName(labUUID uuid.UUID) string {
 	return fmt.Sprintf(LabHeartPrefix, labUUID.String())
 }
+
+func LeaderName(name string) string {
+	return fmt.Sprintf(LeaderPrefix, name)
+}
+
+func DeviceLockName(labUUID uuid.UUID, deviceName string) string {
+	return fmt.Sprintf(DeviceLockPrefix, labUUID.String(), deviceName)
+}
+
+func DeviceLockQueueName(labUUID uuid.UUID, deviceName string) string {
+	return fmt.Sprintf(DeviceLockQueuePrefix, labUUID.String(), deviceName)
+}
+
+func DeviceLockWaitersName(labUUID uuid.UUID, deviceName string) string {
+	return fmt.Sprintf(DeviceLockWaitersPrefix, labUUID.String(), deviceName)
+}
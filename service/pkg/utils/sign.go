@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/scienceol/studio/service/internal/config"
+)
+
+// ErrNoURLSecret 未配置下载链接签名密钥
+var ErrNoURLSecret = errors.New("signed url secret is not configured, set export.url_secret or UPLOAD_URL_SECRET")
+
+// SignedURLSecret 返回下载链接的签名密钥，未配置导出密钥时使用上传密钥。
+// 所有实例必须使用同一个密钥，否则一个实例签发的链接在其他实例和重启后无法校验
+func SignedURLSecret() []byte {
+	if secret := config.GetStudioConfig().Export.URLSecret; secret != "" {
+		return []byte(secret)
+	}
+	return []byte(config.Global().Upload.URLSecret)
+}
+
+// CheckSignedURLSecret 启动时校验已配置签名密钥
+func CheckSignedURLSecret() error {
+	if len(SignedURLSecret()) == 0 {
+		return ErrNoURLSecret
+	}
+	return nil
+}
+
+// SignClaims 生成 base64url(json).base64url(hmac-sha256) 格式的签名令牌
+func SignClaims(secret []byte, claims any) string {
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(hmacSum(secret, encoded))
+}
+
+// VerifyClaims 校验 SignClaims 生成的令牌签名并解析到 claims，有效期由调用方校验
+func VerifyClaims(secret []byte, token string, claims any) bool {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, hmacSum(secret, encoded)) {
+		return false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(payload, claims) == nil
+}
+
+func hmacSum(secret []byte, data string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
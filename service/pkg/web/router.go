@@ -12,11 +12,17 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/scienceol/studio/service/internal/config"
+	mMaintenance "github.com/scienceol/studio/service/pkg/core/maintenance"
+	"github.com/scienceol/studio/service/pkg/middleware/apiversion"
 	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/compress"
+	"github.com/scienceol/studio/service/pkg/middleware/etag"
+	"github.com/scienceol/studio/service/pkg/middleware/loadshed"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
 	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/scienceol/studio/service/pkg/middleware/ratelimit"
 	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"github.com/scienceol/studio/service/pkg/middleware/respcache"
 	"github.com/scienceol/studio/service/pkg/web/views/laboratory"
 	"github.com/scienceol/studio/service/pkg/web/views/material"
 	"github.com/scienceol/studio/service/pkg/web/views/realtime"
@@ -24,10 +30,53 @@ import (
 
 	"github.com/scienceol/studio/service/pkg/web/views"
 	"github.com/scienceol/studio/service/pkg/web/views/action"
+	"github.com/scienceol/studio/service/pkg/web/views/alert"
+	"github.com/scienceol/studio/service/pkg/web/views/annotation"
+	"github.com/scienceol/studio/service/pkg/web/views/approval"
+	"github.com/scienceol/studio/service/pkg/web/views/assistant"
+	"github.com/scienceol/studio/service/pkg/web/views/chaos"
+	"github.com/scienceol/studio/service/pkg/web/views/command"
+	"github.com/scienceol/studio/service/pkg/web/views/cost"
+	"github.com/scienceol/studio/service/pkg/web/views/dashboard"
+	"github.com/scienceol/studio/service/pkg/web/views/deadletter"
+	"github.com/scienceol/studio/service/pkg/web/views/device"
+	"github.com/scienceol/studio/service/pkg/web/views/deviceconfig"
+	"github.com/scienceol/studio/service/pkg/web/views/devicelock"
+	"github.com/scienceol/studio/service/pkg/web/views/devicerisk"
+	"github.com/scienceol/studio/service/pkg/web/views/edgeevent"
+	"github.com/scienceol/studio/service/pkg/web/views/errorrule"
+	"github.com/scienceol/studio/service/pkg/web/views/eventschema"
+	"github.com/scienceol/studio/service/pkg/web/views/experiment"
+	"github.com/scienceol/studio/service/pkg/web/views/file"
 	"github.com/scienceol/studio/service/pkg/web/views/foo"
 	"github.com/scienceol/studio/service/pkg/web/views/history"
+	"github.com/scienceol/studio/service/pkg/web/views/hub"
+	"github.com/scienceol/studio/service/pkg/web/views/historychain"
+	"github.com/scienceol/studio/service/pkg/web/views/historycopy"
+	"github.com/scienceol/studio/service/pkg/web/views/historyexport"
+	"github.com/scienceol/studio/service/pkg/web/views/historyimport"
+	"github.com/scienceol/studio/service/pkg/web/views/impersonation"
+	"github.com/scienceol/studio/service/pkg/web/views/ingest"
+	"github.com/scienceol/studio/service/pkg/web/views/inventory"
+	"github.com/scienceol/studio/service/pkg/web/views/jobs"
+	"github.com/scienceol/studio/service/pkg/web/views/labexport"
 	"github.com/scienceol/studio/service/pkg/web/views/labstatus"
+	"github.com/scienceol/studio/service/pkg/web/views/legalhold"
+	"github.com/scienceol/studio/service/pkg/web/views/liveness"
 	"github.com/scienceol/studio/service/pkg/web/views/login"
+	"github.com/scienceol/studio/service/pkg/web/views/maintenance"
+	"github.com/scienceol/studio/service/pkg/web/views/migration"
+	"github.com/scienceol/studio/service/pkg/web/views/notification"
+	"github.com/scienceol/studio/service/pkg/web/views/report"
+	"github.com/scienceol/studio/service/pkg/web/views/reservation"
+	"github.com/scienceol/studio/service/pkg/web/views/rule"
+	"github.com/scienceol/studio/service/pkg/web/views/savedview"
+	"github.com/scienceol/studio/service/pkg/web/views/seed"
+	"github.com/scienceol/studio/service/pkg/web/views/stream"
+	"github.com/scienceol/studio/service/pkg/web/views/systemhealth"
+	"github.com/scienceol/studio/service/pkg/web/views/telemetry"
+	"github.com/scienceol/studio/service/pkg/web/views/topology"
+	"github.com/scienceol/studio/service/pkg/web/views/upgrade"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
@@ -45,8 +94,8 @@ func installMiddleware(g *gin.Engine) {
 	g.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:32234", "http://localhost:*", "https://sciol.ac.cn", "https://*.sciol.ac.cn"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-None-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-API-Version", "Deprecation", "Sunset", "Link", "Retry-After", "Server-Timing"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -54,7 +103,8 @@ func installMiddleware(g *gin.Engine) {
 	// OpenTelemetry tracing middleware (base)
 	g.Use(otelgin.Middleware(fmt.Sprintf("%s-%s",
 		server.Platform,
-		server.Service)))
+		server.Service),
+		otelgin.WithSpanNameFormatter(otel.SpanNameFormatter)))
 
 	// Enhanced OpenTelemetry middleware (business metrics + span attributes)
 	g.Use(otel.EnhancedMiddleware())
@@ -64,6 +114,25 @@ func installMiddleware(g *gin.Engine) {
 	rateLimiter := ratelimit.New(redis.GetClient(), rateLimitConfig)
 	g.Use(rateLimiter.Middleware())
 
+	// Load shedding middleware, rejects low priority requests while saturated
+	shedder := loadshed.New(buildLoadSheddingConfig())
+	if err := shedder.RegisterMetrics(); err != nil {
+		logger.Errorf(context.Background(), "register load shedding metrics err: %+v", err)
+	}
+	g.Use(shedder.Middleware())
+
+	// Maintenance middleware, rejects writes to labs in read-only maintenance
+	g.Use(mMaintenance.Middleware())
+
+	// Response compression middleware
+	g.Use(compress.Middleware(buildCompressionConfig()))
+
+	// Response cache, applied per route
+	respcache.Init(buildResponseCacheConfig())
+
+	// API versioning, resolved per version group
+	apiversion.Init(buildAPIVersionConfig())
+
 	// Logging middleware
 	g.Use(logger.LogWithWriter())
 }
@@ -110,6 +179,72 @@ func buildRateLimitConfig() *ratelimit.Config {
 	return cfg
 }
 
+// buildLoadSheddingConfig builds load shedding config from studio configuration.
+func buildLoadSheddingConfig() *loadshed.Config {
+	studioConfig := config.GetStudioConfig()
+	if studioConfig == nil {
+		return loadshed.DefaultConfig()
+	}
+	shedding := studioConfig.LoadShedding
+	cfg := &loadshed.Config{
+		Enabled:       shedding.Enabled,
+		MaxInFlight:   shedding.MaxInFlight,
+		TargetLatency: time.Duration(shedding.TargetLatencyMs) * time.Millisecond,
+		ShedAt:        make(map[loadshed.Class]float64, len(shedding.ShedAt)),
+		Routes:        make(map[string]loadshed.Class, len(shedding.Routes)),
+	}
+	for class, load := range shedding.ShedAt {
+		cfg.ShedAt[loadshed.Class(class)] = load
+	}
+	for path, class := range shedding.Routes {
+		cfg.Routes[path] = loadshed.Class(class)
+	}
+	return cfg
+}
+
+// buildCompressionConfig builds compression config from studio configuration.
+func buildCompressionConfig() *compress.Config {
+	studioConfig := config.GetStudioConfig()
+	if studioConfig == nil {
+		return compress.DefaultConfig()
+	}
+	return &compress.Config{
+		Enabled:   studioConfig.Compression.Enabled,
+		MinSize:   studioConfig.Compression.MinSizeBytes,
+		Encodings: studioConfig.Compression.Encodings,
+	}
+}
+
+// buildResponseCacheConfig builds response cache config from studio configuration.
+func buildResponseCacheConfig() *respcache.Config {
+	studioConfig := config.GetStudioConfig()
+	if studioConfig == nil {
+		return nil
+	}
+	cfg := &respcache.Config{
+		Enabled: studioConfig.ResponseCache.Enabled,
+		Routes:  make(map[string]time.Duration, len(studioConfig.ResponseCache.Routes)),
+	}
+	for path, seconds := range studioConfig.ResponseCache.Routes {
+		cfg.Routes[path] = time.Duration(seconds) * time.Second
+	}
+	return cfg
+}
+
+// buildAPIVersionConfig builds API versioning config from studio configuration.
+func buildAPIVersionConfig() *apiversion.Config {
+	studioConfig := config.GetStudioConfig()
+	if studioConfig == nil || studioConfig.APIVersioning.V1Sunset == "" {
+		return nil
+	}
+	sunset, err := time.Parse(time.DateOnly, studioConfig.APIVersioning.V1Sunset)
+	if err != nil {
+		logger.Warnf(context.Background(), "invalid api_versioning.v1_sunset %q: %+v", studioConfig.APIVersioning.V1Sunset, err)
+		return nil
+	}
+	return &apiversion.Config{Sunset: sunset}
+}
+
 func InstallURL(ctx context.Context, g *gin.Engine) {
 	api := g.Group("/api")
 	api.GET("/health", views.Health)
@@ -141,11 +276,11 @@ func InstallURL(ctx context.Context, g *gin.Engine) {
 
 	// V1 API
 	{
-		v1 := api.Group("/v1")
-		wsRouter := v1.Group("/ws", auth.Auth())
+		v1 := api.Group("/v1", apiversion.Middleware(apiversion.V1))
+		wsRouter := v1.Group("/ws", otel.SpanName("WS /api/v1/ws"), auth.Auth())
 
 		// Realtime (prototype, no auth for now) -- mount under /api/realtime
-		realtimeGroup := api.Group("/realtime")
+		realtimeGroup := api.Group("/realtime", otel.SpanName("realtime"))
 		{
 			rh := realtime.NewHandle()
 			realtimeGroup.GET("/signal/client", rh.ClientSignal)
@@ -159,22 +294,116 @@ func InstallURL(ctx context.Context, g *gin.Engine) {
 			wsRouter.GET("/lab/status", labStatusHandle.ConnectLabStatus)
 		}
 
+		// WebSocket 消息中心，按主题订阅执行进度、实验室事件和站内通知
+		{
+			wsRouter.GET("/hub", hub.NewHandler().Connect)
+		}
+
+		// 管理员模拟用户，模拟会话不能访问
+		{
+			impersonationHandle := impersonation.NewHandler()
+			impersonationRouter := v1.Group("/admin/impersonation", auth.Auth(), auth.NoImpersonation())
+			impersonationRouter.POST("", impersonationHandle.Start)          // 创建模拟会话
+			impersonationRouter.GET("", impersonationHandle.List)            // 模拟会话列表
+			impersonationRouter.DELETE("/:uuid", impersonationHandle.Revoke) // 撤销模拟会话
+			impersonationRouter.GET("/audit", impersonationHandle.ListAudit) // 审计日志
+		}
+
+		// 执行失败诊断助手，需开启 ai_assistant 功能
+		{
+			assistantHandle := assistant.NewHandler()
+			v1.POST("/assistant/diagnose", auth.Auth(), assistantHandle.Diagnose)                            // 诊断执行失败原因
+			v1.GET("/admin/assistant/audit", auth.Auth(), auth.NoImpersonation(), assistantHandle.ListAudit) // 助手审计记录
+		}
+
+		// 维护模式，维护状态无需登录，供前端展示维护提示
+		{
+			maintenanceHandle := maintenance.NewHandler()
+			v1.GET("/maintenance/status", maintenanceHandle.Status) // 维护状态
+			maintenanceRouter := v1.Group("/admin/maintenance", auth.Auth(), auth.NoImpersonation())
+			maintenanceRouter.PUT("", maintenanceHandle.StartGlobal)             // 开始全局维护
+			maintenanceRouter.DELETE("", maintenanceHandle.EndGlobal)            // 结束全局维护
+			maintenanceRouter.PUT("/lab/:lab_uuid", maintenanceHandle.StartLab)  // 开始实验室维护
+			maintenanceRouter.DELETE("/lab/:lab_uuid", maintenanceHandle.EndLab) // 结束实验室维护
+		}
+
+		// 数据库迁移状态，模拟会话不能访问
+		{
+			migrationHandle := migration.NewHandler()
+			v1.GET("/admin/migrations", auth.Auth(), auth.NoImpersonation(), migrationHandle.Status) // 迁移状态
+		}
+
+		// 历史记录法律保全，模拟会话不能访问
+		{
+			legalHoldHandle := legalhold.NewHandler()
+			legalHoldRouter := v1.Group("/admin/legal-hold", auth.Auth(), auth.NoImpersonation())
+			legalHoldRouter.POST("", legalHoldHandle.Place)                 // 设置法律保全
+			legalHoldRouter.GET("", legalHoldHandle.List)                   // 法律保全列表
+			legalHoldRouter.POST("/:uuid/release", legalHoldHandle.Release) // 解除法律保全
+		}
+
+		// 历史记录跨环境复制，生产环境不提供，模拟会话不能访问
+		{
+			historyCopyHandle := historycopy.NewHandler()
+			v1.GET("/admin/history/dump", auth.Auth(), auth.NoImpersonation(), historyCopyHandle.Dump) // 读取脱敏后的历史记录
+		}
+
+		// 演示历史数据，生产环境不提供，模拟会话不能访问
+		{
+			seedHandle := seed.NewHandler()
+			v1.POST("/admin/seed", auth.Auth(), auth.NoImpersonation(), seedHandle.Seed) // 生成演示历史数据
+		}
+
+		// 故障注入，生产环境不提供，模拟会话不能访问
+		{
+			chaosHandle := chaos.NewHandler()
+			v1.GET("/admin/chaos", auth.Auth(), auth.NoImpersonation(), chaosHandle.Get) // 故障注入配置
+			v1.PUT("/admin/chaos", auth.Auth(), auth.NoImpersonation(), chaosHandle.Set) // 修改故障注入配置
+		}
+
+		// 系统健康汇总，模拟会话不能访问
+		{
+			systemHealthHandle := systemhealth.NewHandler()
+			v1.GET("/admin/health", auth.Auth(), auth.NoImpersonation(), systemHealthHandle.Get) // 请求量、依赖耗时、队列、后台任务和配置变更
+		}
+
+		// 站内通知，stream 为 SSE 推送新通知
+		{
+			notificationHandle := notification.NewHandler()
+			notificationRouter := v1.Group("/notification", auth.Auth())
+			notificationRouter.GET("", notificationHandle.List)                          // 通知列表
+			notificationRouter.GET("/unread", notificationHandle.Unread)                 // 未读数量
+			notificationRouter.POST("/read", notificationHandle.MarkRead)                // 标记已读
+			notificationRouter.GET("/preferences", notificationHandle.Preferences)       // 通知设置
+			notificationRouter.PUT("/preferences", notificationHandle.UpdatePreferences) // 更新通知设置
+			notificationRouter.GET("/stream", notificationHandle.Stream)                 // 新通知推送
+		}
+
+		// 事件 schema 注册表，供 webhook 和事件总线的接收方生成和校验代码
+		{
+			eventSchemaHandle := eventschema.NewHandler()
+			eventSchemaRouter := v1.Group("/events/schemas", etag.Middleware())
+			eventSchemaRouter.GET("", eventSchemaHandle.List)               // 事件类型和演进规则
+			eventSchemaRouter.GET("/envelope", eventSchemaHandle.Envelope)  // 事件信封 schema
+			eventSchemaRouter.GET("/:type/:version", eventSchemaHandle.Get) // 事件 schema
+		}
+
 		// 环境相关
 		{
 			labRouter := v1.Group("/lab", auth.Auth())
 
 			{
 				labHandle := laboratory.NewEnvironment()
-				labRouter.POST("", labHandle.CreateLabEnv)                                 // 创建实验室
-				labRouter.PATCH("", labHandle.UpdateLabEnv)                                // 更新实验室
-				labRouter.GET("/list", labHandle.LabList)                                  // 获取当前用户的所有实验室
-				labRouter.GET("/info/:uuid", labHandle.LabInfo)                            // 获取当前用户的所有实验室
-				labRouter.POST("/resource", labHandle.CreateLabResource)                   // 从 edge 侧创建资源
-				labRouter.GET("/member/:lab_uuid", labHandle.GetLabMemeber)                // 根据实验室获取当前实验室成员
-				labRouter.DELETE("/member/:lab_uuid/:member_uuid", labHandle.DelLabMember) // 删除实验室成员
-				labRouter.POST("/invite/:lab_uuid", labHandle.CreateInvite)                // 创建邀请链接
-				labRouter.GET("/invite/:uuid", labHandle.AcceptInvite)                     // 接受邀请链接
-				labRouter.GET("/user/info", labHandle.UserInfo)                            // 获取用户信息
+				labRouter.POST("", auth.NoImpersonation(), labHandle.CreateLabEnv)                                 // 创建实验室
+				labRouter.PATCH("", labHandle.UpdateLabEnv)                                                        // 更新实验室
+				labRouter.GET("/list", labHandle.LabList)                                                          // 获取当前用户的所有实验室
+				labRouter.GET("/info/:uuid", labHandle.LabInfo)                                                    // 获取当前用户的所有实验室
+				labRouter.POST("/resource", labHandle.CreateLabResource)                                           // 从 edge 侧创建资源
+				labRouter.GET("/member/:lab_uuid", labHandle.GetLabMemeber)                                        // 根据实验室获取当前实验室成员
+				labRouter.DELETE("/member/:lab_uuid/:member_uuid", auth.NoImpersonation(), labHandle.DelLabMember) // 删除实验室成员
+				labRouter.POST("/invite/:lab_uuid", auth.NoImpersonation(), labHandle.CreateInvite)                // 创建邀请链接
+				labRouter.GET("/invite/:uuid", auth.NoImpersonation(), labHandle.AcceptInvite)                     // 接受邀请链接
+				labRouter.GET("/user/info", labHandle.UserInfo)                                                    // 获取用户信息
 			}
 
 			{
@@ -254,8 +483,16 @@ func InstallURL(ctx context.Context, g *gin.Engine) {
 					owner.POST("/import", workflowHandle.Import)       // 导入工作流
 					owner.PUT("/duplicate", workflowHandle.Duplicate)  // 复制工作流
 				}
+				{
+					// 批量运行
+					batch := workflowRouter.Group("/batch")
+					batch.POST("", workflowHandle.BatchRunWorkflow)         // 批量启动工作流
+					batch.GET("/:uuid", workflowHandle.GetBatch)            // 批次状态
+					batch.POST("/:uuid/cancel", workflowHandle.CancelBatch) // 取消批次
+				}
 
 				v1.PUT("/lab/run/workflow", workflowHandle.RunWorkflow)
+				labRouter.POST("/:lab_id/workflow/:workflow_uuid/run", workflowHandle.RunLabWorkflow) // 实验室成员启动工作流
 
 				workflowRouter.GET("/ws/workflow/:uuid", workflowHandle.LabWorkflow) // TODO: websocket 放在统一的路由下
 			}
@@ -264,13 +501,363 @@ func InstallURL(ctx context.Context, g *gin.Engine) {
 			{
 				historyHandle := history.NewHandler()
 				historyRouter := labRouter.Group("/history")
-				historyRouter.GET("/workflow", historyHandle.ListWorkflowExecutions)                        // 工作流执行历史列表
-				historyRouter.GET("/workflow/execution/:execution_uuid", historyHandle.GetWorkflowExecution) // 工作流执行详情
-				historyRouter.GET("/device", historyHandle.ListDeviceEvents)                                 // 设备事件历史
+				historyRouter.GET("/workflow", apiversion.Deprecated(), etag.Middleware(), historyHandle.ListWorkflowExecutions)                         // 工作流执行历史列表
+				historyRouter.GET("/workflow/execution/:execution_uuid", apiversion.Deprecated(), etag.Middleware(), historyHandle.GetWorkflowExecution) // 工作流执行详情
+				historyRouter.GET("/device", apiversion.Deprecated(), etag.Middleware(), historyHandle.ListDeviceEvents)                                 // 设备事件历史
+				historyRouter.GET("/action/:action_uuid", etag.Middleware(), historyHandle.GetActionExecution)                                           // 动作执行详情及输入输出
+				historyRouter.GET("/changes", historyHandle.ListChanges)                                                                                 // 增量同步历史记录，长轮询
+				historyRouter.PUT("/workflow/execution/:execution_uuid/labels", historyHandle.SetExecutionLabels)                                        // 设置执行标签
+				historyRouter.GET("/workflow/labels", historyHandle.ListExecutionLabels)                                                                 // 执行标签键与取值
+				historyRouter.PUT("/workflow/execution/:execution_uuid/pin", historyHandle.PinExecution)                                                 // 置顶执行
+				historyRouter.DELETE("/workflow/execution/:execution_uuid/pin", historyHandle.UnpinExecution)                                            // 取消置顶执行
+				historyRouter.POST("/search", historyHandle.Search)                                                                                      // 自然语言搜索历史
+
+				savedViewHandle := savedview.NewHandler()
+				historyRouter.POST("/view", savedViewHandle.Create)         // 创建保存的视图
+				historyRouter.GET("/view", savedViewHandle.List)            // 保存的视图列表
+				historyRouter.GET("/view/:uuid", savedViewHandle.Get)       // 保存的视图详情
+				historyRouter.PUT("/view/:uuid", savedViewHandle.Update)    // 更新保存的视图
+				historyRouter.DELETE("/view/:uuid", savedViewHandle.Delete) // 删除保存的视图
+
+				// Error classification rules
+				errorRuleHandle := errorrule.NewHandler()
+				historyRouter.POST("/error-rule", errorRuleHandle.Create)         // 创建错误分类规则
+				historyRouter.GET("/error-rule", errorRuleHandle.List)            // 错误分类规则列表
+				historyRouter.POST("/error-rule/test", errorRuleHandle.Test)      // 测试错误分类
+				historyRouter.PUT("/error-rule/:uuid", errorRuleHandle.Update)    // 更新错误分类规则
+				historyRouter.DELETE("/error-rule/:uuid", errorRuleHandle.Delete) // 删除错误分类规则
+
+				annotationHandle := annotation.NewHandler()
+				historyRouter.GET("/workflow/execution/:execution_uuid/annotation", annotationHandle.List)    // 执行记录批注
+				historyRouter.POST("/workflow/execution/:execution_uuid/annotation", annotationHandle.Create) // 添加批注
+				historyRouter.PUT("/annotation/:uuid", annotationHandle.Update)                               // 编辑批注
+				historyRouter.DELETE("/annotation/:uuid", annotationHandle.Delete)                            // 删除批注
+				historyRouter.GET("/annotation/:uuid/revisions", annotationHandle.Revisions)                  // 批注编辑历史
+
+				importHandle := historyimport.NewHandler()
+				historyRouter.POST("/import", importHandle.Create)   // 导入历史记录
+				historyRouter.GET("/import", importHandle.List)      // 历史记录导入列表
+				historyRouter.GET("/import/:uuid", importHandle.Get) // 导入进度和校验报告
+
+				exportHandle := historyexport.NewHandler()
+				v1.GET("/history/export/download", exportHandle.Download) // 签名链接下载历史记录导出
+				historyRouter.POST("/export", exportHandle.Create)        // 创建历史记录导出
+				historyRouter.GET("/export", exportHandle.List)           // 历史记录导出列表
+				historyRouter.GET("/export/:uuid", exportHandle.Get)      // 导出进度和下载链接
+
+				chainHandle := historychain.NewHandler()
+				historyRouter.GET("/chain/verify", chainHandle.Verify)      // 校验历史记录哈希链
+				historyRouter.GET("/chain/anchor", chainHandle.ListAnchors) // 哈希链锚点列表
 
 				// Lab stats (mounted at lab level)
-				labRouter.GET("/:lab_id/stats", historyHandle.GetLabStats) // 实验室统计
+				labRouter.GET("/:lab_id/stats", etag.Middleware(), respcache.Middleware(respcache.TagStats), historyHandle.GetLabStats)          // 实验室统计
+				labRouter.GET("/:lab_id/stats/slowest-steps", respcache.Middleware(respcache.TagStats), historyHandle.ListSlowestSteps)          // 最慢步骤报表
+				labRouter.GET("/:lab_id/stats/heatmap", respcache.Middleware(respcache.TagStats), historyHandle.GetActivityHeatmap)              // 实验室活动热力图
+				labRouter.GET("/:lab_id/stats/device-utilization", respcache.Middleware(respcache.TagStats), historyHandle.GetDeviceUtilization) // 设备利用率报表
+				labRouter.GET("/:lab_id/stats/failures", respcache.Middleware(respcache.TagStats), historyHandle.GetFailureStats)                // 失败分类统计
+				labRouter.GET("/:lab_id/queue", historyHandle.GetQueueForecast)                                                                  // 排队执行的预计完成时间
+				labRouter.GET("/:lab_id/dashboard", dashboard.NewHandler().Get)                                                                  // 实验室看板，一次返回多个分区
+
+				v1.GET("/org/:org_id/stats", auth.Auth(), respcache.Middleware(respcache.TagStats), historyHandle.GetOrgStats) // 组织统计
+			}
+
+			// Dead letter API
+			{
+				deadLetterHandle := deadletter.NewHandler()
+				deadLetterRouter := labRouter.Group("/deadletter")
+				deadLetterRouter.GET("", deadLetterHandle.List)                   // 死信任务列表
+				deadLetterRouter.GET("/:uuid", deadLetterHandle.Get)              // 死信任务详情
+				deadLetterRouter.POST("/:uuid/requeue", deadLetterHandle.Requeue) // 重新投递
 			}
+
+			// Approval API
+			{
+				approvalHandle := approval.NewHandler()
+				approvalRouter := labRouter.Group("/approval")
+				approvalRouter.GET("/pending", approvalHandle.ListPending)    // 待审批列表
+				approvalRouter.POST("/:uuid/approve", approvalHandle.Approve) // 审批通过
+				approvalRouter.POST("/:uuid/reject", approvalHandle.Reject)   // 审批拒绝
+			}
+
+			// Device lock API
+			{
+				deviceLockHandle := devicelock.NewHandler()
+				labRouter.GET("/device/lock/:lab_uuid", deviceLockHandle.ListLocks) // 设备锁状态
+			}
+
+			// Device liveness API
+			{
+				livenessHandle := liveness.NewHandler()
+				v1.POST("/edge/heartbeat", auth.Auth(), livenessHandle.Heartbeat)             // edge 心跳上报
+				labRouter.GET("/device/liveness/:lab_uuid", livenessHandle.List)              // 设备在线状态
+				labRouter.GET("/device/liveness/:lab_uuid/versions", livenessHandle.Versions) // agent 版本分布
+			}
+
+			// Agent upgrade API
+			{
+				upgradeHandle := upgrade.NewHandler()
+				upgradeRouter := labRouter.Group("/agent/upgrade")
+				upgradeRouter.POST("", upgradeHandle.Create)                  // 创建升级任务
+				upgradeRouter.GET("", upgradeHandle.List)                     // 升级任务列表
+				upgradeRouter.GET("/:uuid", upgradeHandle.Get)                // 升级任务详情
+				upgradeRouter.PUT("/:uuid/rollout", upgradeHandle.Rollout)    // 调整放量比例
+				upgradeRouter.POST("/:uuid/pause", upgradeHandle.Pause)       // 暂停升级
+				upgradeRouter.POST("/:uuid/rollback", upgradeHandle.Rollback) // 回滚升级
+			}
+
+			// Device risk API
+			{
+				deviceRiskHandle := devicerisk.NewHandler()
+				labRouter.GET("/device/risk/:lab_uuid", deviceRiskHandle.List)                 // 设备维护风险评分
+				labRouter.GET("/device/risk/:lab_uuid/:device_uuid", deviceRiskHandle.History) // 设备评分历史
+			}
+
+			// Device registry API
+			{
+				deviceHandle := device.NewHandler()
+				edgeDeviceRouter := v1.Group("/edge/device", auth.Auth())
+				edgeDeviceRouter.POST("", deviceHandle.Register)           // edge 注册设备
+				edgeDeviceRouter.DELETE("/:name", deviceHandle.Unregister) // edge 注销设备
+
+				labRouter.GET("/device/registry/:lab_uuid", respcache.Middleware(respcache.TagDevice), deviceHandle.List)     // 设备注册表
+				labRouter.GET("/device/registry/:lab_uuid/:name", deviceHandle.Get)                                           // 设备能力描述
+				labRouter.GET("/device/capacity/:lab_uuid", respcache.Middleware(respcache.TagDevice), deviceHandle.Capacity) // 设备容量
+				labRouter.PUT("/device/capacity/:lab_uuid", deviceHandle.SetLimit)                                            // 设置设备上限
+				labRouter.DELETE("/device/capacity/:lab_uuid", deviceHandle.ResetLimit)                                       // 恢复默认设备上限
+			}
+
+			// Device config API
+			{
+				deviceConfigHandle := deviceconfig.NewHandler()
+				v1.PUT("/edge/device/:name/config", auth.Auth(), deviceConfigHandle.Report) // edge 上报设备配置
+
+				configRouter := labRouter.Group("/device/config")
+				configRouter.POST("/profile", deviceConfigHandle.CreateProfile)               // 创建配置模板
+				configRouter.GET("/profile", deviceConfigHandle.ListProfiles)                 // 配置模板列表
+				configRouter.GET("/profile/:uuid", deviceConfigHandle.GetProfile)             // 配置模板详情
+				configRouter.POST("/profile/:uuid/version", deviceConfigHandle.CreateVersion) // 创建配置版本
+				configRouter.POST("/profile/:uuid/assign", deviceConfigHandle.Assign)         // 分配配置
+				configRouter.GET("/assignment/:lab_uuid", deviceConfigHandle.ListAssignments) // 配置分配列表
+				configRouter.DELETE("/assignment/:uuid", deviceConfigHandle.Unassign)         // 删除配置分配
+				configRouter.GET("/status/:lab_uuid", deviceConfigHandle.Status)              // 设备配置状态和偏差
+				configRouter.POST("/push/:lab_uuid", deviceConfigHandle.Push)                 // 下发配置
+				configRouter.GET("/history/:lab_uuid", deviceConfigHandle.History)            // 配置下发历史
+			}
+
+			// Edge ingest API
+			{
+				ingestHandle := ingest.NewHandler()
+				v1.POST("/edge/ingest", auth.Auth(), ingestHandle.Ingest)         // edge 重放缓存的执行结果
+				v1.GET("/edge/ingest/:stream", auth.Auth(), ingestHandle.Ack)     // edge 获取重放确认状态
+				labRouter.GET("/edge/ingest/:lab_uuid", ingestHandle.ListStreams) // 实验室重放流
+			}
+
+			// Edge event and action reporting API
+			{
+				edgeEventHandle := edgeevent.NewHandler()
+				v1.POST("/edge/events", auth.Auth(), edgeEventHandle.Ingest)               // 设备使用设备密钥批量上报事件
+				v1.POST("/edge/actions", auth.Auth(), edgeEventHandle.IngestActions)       // edge 批量上报动作执行
+				labRouter.POST("/device-key", edgeEventHandle.CreateKey)                   // 创建设备密钥
+				labRouter.GET("/device-key", edgeEventHandle.ListKeys)                     // 设备密钥列表
+				labRouter.DELETE("/device-key/:lab_uuid/:uuid", edgeEventHandle.RevokeKey) // 撤销设备密钥
+			}
+
+			// Lab topology API
+			{
+				topologyHandle := topology.NewHandler()
+				topologyRouter := labRouter.Group("/topology")
+				topologyRouter.POST("/node", topologyHandle.CreateNode)                   // 创建房间、工作台或设备组
+				topologyRouter.PATCH("/node/:uuid", topologyHandle.UpdateNode)            // 修改拓扑节点
+				topologyRouter.DELETE("/node/:uuid", topologyHandle.DeleteNode)           // 删除拓扑节点
+				topologyRouter.POST("/node/:uuid/device", topologyHandle.AddDevices)      // 添加节点设备
+				topologyRouter.DELETE("/node/:uuid/device", topologyHandle.RemoveDevices) // 移除节点设备
+				topologyRouter.GET("/:lab_uuid", topologyHandle.Tree)                     // 实验室拓扑树
+			}
+
+			// Experiment API
+			{
+				experimentHandle := experiment.NewHandler()
+				experimentRouter := labRouter.Group("/experiment")
+				experimentRouter.POST("", experimentHandle.Create)                             // 创建实验
+				experimentRouter.GET("", experimentHandle.List)                                // 实验列表
+				experimentRouter.GET("/:uuid", experimentHandle.Get)                           // 实验详情及进度
+				experimentRouter.PATCH("/:uuid", experimentHandle.Update)                      // 修改实验
+				experimentRouter.POST("/:uuid/execution", experimentHandle.AddExecutions)      // 关联执行到实验
+				experimentRouter.DELETE("/:uuid/execution", experimentHandle.RemoveExecutions) // 从实验移出执行
+				experimentRouter.GET("/:uuid/stats", experimentHandle.Stats)                   // 实验统计
+			}
+
+			// Device command API
+			{
+				commandHandle := command.NewHandler()
+				edgeCommandRouter := v1.Group("/edge/command", auth.Auth())
+				edgeCommandRouter.GET("", commandHandle.Poll)                 // edge 长轮询拉取指令
+				edgeCommandRouter.POST("/:uuid/ack", commandHandle.Ack)       // edge 确认指令
+				edgeCommandRouter.POST("/:uuid/result", commandHandle.Result) // edge 上报指令结果
+
+				labRouter.POST("/device/command", commandHandle.Enqueue)             // 下发设备指令
+				labRouter.GET("/device/command", commandHandle.List)                 // 设备指令列表
+				labRouter.GET("/device/command/:uuid", commandHandle.Get)            // 设备指令详情
+				labRouter.POST("/device/command/group", commandHandle.EnqueueGroup)  // 下发设备组指令
+				labRouter.GET("/device/command/group", commandHandle.ListGroups)     // 设备组指令列表
+				labRouter.GET("/device/command/group/:uuid", commandHandle.GetGroup) // 设备组指令详情
+			}
+
+			// Telemetry API
+			{
+				telemetryHandle := telemetry.NewHandler()
+				telemetryRouter := labRouter.Group("/telemetry")
+				telemetryRouter.GET("/rollup", respcache.Middleware(respcache.TagTelemetry), telemetryHandle.Rollups) // 设备数据汇总
+				telemetryRouter.GET("/series", respcache.Middleware(respcache.TagTelemetry), telemetryHandle.Series)  // 设备数值时间序列
+			}
+
+			// Device rule API
+			{
+				ruleHandle := rule.NewHandler()
+				ruleRouter := labRouter.Group("/rule")
+				ruleRouter.POST("", ruleHandle.Create)         // 创建规则
+				ruleRouter.GET("", ruleHandle.List)            // 规则列表
+				ruleRouter.POST("/test", ruleHandle.Test)      // 试运行规则
+				ruleRouter.GET("/:uuid", ruleHandle.Get)       // 规则详情
+				ruleRouter.PUT("/:uuid", ruleHandle.Update)    // 更新规则
+				ruleRouter.DELETE("/:uuid", ruleHandle.Delete) // 删除规则
+			}
+
+			// Alert API
+			{
+				alertHandle := alert.NewHandler()
+				alertRouter := labRouter.Group("/alert")
+				alertRouter.GET("", alertHandle.List)                           // 告警列表
+				alertRouter.POST("/silence", alertHandle.CreateSilence)         // 创建静默
+				alertRouter.GET("/silence", alertHandle.ListSilences)           // 静默列表
+				alertRouter.DELETE("/silence/:uuid", alertHandle.DeleteSilence) // 删除静默
+				alertRouter.GET("/:uuid", alertHandle.Get)                      // 告警详情
+				alertRouter.POST("/:uuid/ack", alertHandle.Ack)                 // 确认告警
+				alertRouter.POST("/:uuid/resolve", alertHandle.Resolve)         // 恢复告警
+				alertRouter.PUT("/:uuid/assign", alertHandle.Assign)            // 指派告警
+			}
+
+			// Camera stream API
+			{
+				streamHandle := stream.NewHandler()
+				v1.POST("/edge/camera", auth.Auth(), streamHandle.Register)                // edge 注册摄像头
+				v1.DELETE("/edge/camera/:camera_id", auth.Auth(), streamHandle.Unregister) // edge 注销摄像头
+
+				cameraRouter := labRouter.Group("/camera")
+				cameraRouter.GET("", streamHandle.List)                            // 摄像头列表
+				cameraRouter.POST("/session", streamHandle.StartSession)           // 开始观看
+				cameraRouter.GET("/session", streamHandle.ListSessions)            // 观看会话列表
+				cameraRouter.POST("/session/:uuid/stop", streamHandle.StopSession) // 结束观看
+			}
+
+			// Result file API
+			{
+				fileHandle := file.NewHandler()
+				v1.GET("/file/download", fileHandle.Download) // 签名链接下载文件
+
+				edgeFileRouter := v1.Group("/edge/file", auth.Auth())
+				edgeFileRouter.POST("", fileHandle.Create)            // edge 创建上传
+				edgeFileRouter.GET("/:uuid", fileHandle.Get)          // edge 查询上传进度
+				edgeFileRouter.PATCH("/:uuid", fileHandle.WriteChunk) // edge 上传分片
+				edgeFileRouter.DELETE("/:uuid", fileHandle.Delete)    // edge 取消上传
+
+				fileRouter := labRouter.Group("/file")
+				fileRouter.POST("", fileHandle.Create)                // 创建上传
+				fileRouter.GET("", fileHandle.List)                   // 文件列表
+				fileRouter.GET("/:uuid", fileHandle.Get)              // 文件详情及上传进度
+				fileRouter.PATCH("/:uuid", fileHandle.WriteChunk)     // 上传分片
+				fileRouter.DELETE("/:uuid", fileHandle.Delete)        // 删除文件
+				fileRouter.POST("/:uuid/url", fileHandle.DownloadURL) // 生成下载链接
+			}
+
+			// Material inventory API
+			{
+				inventoryHandle := inventory.NewHandler()
+				v1.POST("/edge/inventory/usage", auth.Auth(), inventoryHandle.RecordUsage) // edge 上报物料消耗
+
+				inventoryRouter := labRouter.Group("/inventory")
+				inventoryRouter.POST("/material", inventoryHandle.Register)              // 登记物料批次
+				inventoryRouter.GET("/material", inventoryHandle.List)                   // 物料批次列表
+				inventoryRouter.GET("/material/:uuid", inventoryHandle.Get)              // 物料批次详情
+				inventoryRouter.GET("/material/:uuid/usage", inventoryHandle.ListUsages) // 物料批次消耗记录
+				inventoryRouter.POST("/usage", inventoryHandle.RecordUsage)              // 记录物料消耗
+				inventoryRouter.GET("/sync", inventoryHandle.SyncStatus)                 // 库存同步状态
+				inventoryRouter.POST("/sync", inventoryHandle.TriggerSync)               // 手动触发库存同步
+				inventoryRouter.GET("/sync/conflicts", inventoryHandle.ListConflicts)    // 库存同步冲突
+			}
+
+			// Cost accounting API
+			{
+				costHandle := cost.NewHandler()
+				costRouter := labRouter.Group("/cost")
+				costRouter.GET("", costHandle.Breakdown)      // 费用明细
+				costRouter.GET("/export", costHandle.Export)  // 导出计费数据
+				costRouter.GET("/model", costHandle.GetModel) // 计费模型
+				costRouter.PUT("/model", costHandle.SetModel) // 设置计费模型
+			}
+
+			// Report subscription API
+			{
+				reportHandle := report.NewHandler()
+				reportRouter := labRouter.Group("/report")
+				reportRouter.POST("/subscription", reportHandle.Create)                         // 创建报表订阅
+				reportRouter.GET("/subscription", reportHandle.List)                            // 报表订阅列表
+				reportRouter.GET("/subscription/:uuid", reportHandle.Get)                       // 报表订阅详情
+				reportRouter.PUT("/subscription/:uuid", reportHandle.Update)                    // 更新报表订阅
+				reportRouter.DELETE("/subscription/:uuid", reportHandle.Delete)                 // 删除报表订阅
+				reportRouter.GET("/subscription/:uuid/deliveries", reportHandle.ListDeliveries) // 报表发送记录
+				reportRouter.GET("/delivery/:uuid/download", reportHandle.Download)             // 下载报表
+			}
+
+			// Lab data export API
+			{
+				exportHandle := labexport.NewHandler()
+				v1.GET("/export/download", exportHandle.Download) // 签名链接下载导出归档
+
+				exportRouter := labRouter.Group("/export")
+				exportRouter.POST("", exportHandle.Create)                                      // 创建实验室数据导出
+				exportRouter.GET("", exportHandle.List)                                         // 实验室数据导出列表
+				exportRouter.GET("/:uuid", exportHandle.Get)                                    // 导出进度
+				exportRouter.POST("/:uuid/url", exportHandle.DownloadURL)                       // 生成下载链接
+				labRouter.POST("/purge/token", auth.NoImpersonation(), exportHandle.PurgeToken) // 申请删除实验室全部数据
+				labRouter.POST("/purge", auth.NoImpersonation(), exportHandle.Purge)            // 确认删除实验室全部数据
+			}
+
+			// Device reservation API
+			{
+				reservationHandle := reservation.NewHandler()
+				reservationRouter := labRouter.Group("/reservation")
+				reservationRouter.POST("", reservationHandle.CreateReservation)         // 预约设备
+				reservationRouter.DELETE("/:uuid", reservationHandle.CancelReservation) // 取消预约
+				reservationRouter.GET("/calendar", reservationHandle.Calendar)          // 预约日历
+			}
+		}
+
+		// Admin API
+		{
+			adminRouter := v1.Group("/admin", auth.Auth())
+
+			jobsHandle := jobs.NewHandler()
+			jobsRouter := adminRouter.Group("/jobs")
+			jobsRouter.GET("", jobsHandle.List)                   // 后台任务列表
+			jobsRouter.GET("/:name/runs", jobsHandle.Runs)        // 后台任务运行记录
+			jobsRouter.POST("/:name/trigger", jobsHandle.Trigger) // 立即执行
+			jobsRouter.POST("/:name/pause", jobsHandle.Pause)     // 暂停调度
+			jobsRouter.POST("/:name/resume", jobsHandle.Resume)   // 恢复调度
+		}
+	}
+
+	// V2 API，与 v1 共用处理逻辑，按版本输出响应
+	{
+		v2 := api.Group("/v2", apiversion.Middleware(apiversion.V2))
+
+		// History API
+		{
+			historyHandle := history.NewHandler()
+			historyRouter := v2.Group("/lab/history", auth.Auth())
+			historyRouter.GET("/workflow", etag.Middleware(), historyHandle.ListWorkflowExecutions)                         // 工作流执行历史列表
+			historyRouter.GET("/workflow/execution/:execution_uuid", etag.Middleware(), historyHandle.GetWorkflowExecution) // 工作流执行详情
+			historyRouter.GET("/device", etag.Middleware(), historyHandle.ListDeviceEvents)                                 // 设备事件历史
 		}
 	}
 }
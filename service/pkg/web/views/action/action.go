@@ -15,6 +15,7 @@ import (
 	"github.com/scienceol/studio/service/pkg/common/code"
 	"github.com/scienceol/studio/service/pkg/common/constant"
 	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/device"
 	"github.com/scienceol/studio/service/pkg/core/notify"
 	"github.com/scienceol/studio/service/pkg/core/notify/events"
 	"github.com/scienceol/studio/service/pkg/core/schedule/edge"
@@ -22,6 +23,7 @@ import (
 	actionEngine "github.com/scienceol/studio/service/pkg/core/schedule/engine/action"
 	"github.com/scienceol/studio/service/pkg/middleware/auth"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/otel"
 	"github.com/scienceol/studio/service/pkg/middleware/redis"
 	"github.com/scienceol/studio/service/pkg/utils"
 )
@@ -34,6 +36,7 @@ type Handle struct {
 	rClient    *r.Client
 	wsClient   *melody.Melody
 	boardEvent notify.MsgCenter
+	devices    *device.Registry
 }
 
 func NewActionHandle(ctx context.Context) *Handle {
@@ -44,6 +47,7 @@ func NewActionHandle(ctx context.Context) *Handle {
 		rClient:    redis.GetClient(),
 		wsClient:   wsClient,
 		boardEvent: events.NewEvents(),
+		devices:    device.New(),
 	}
 
 	// 注册通知处理
@@ -69,7 +73,7 @@ func (h *Handle) RunAction(ctx *gin.Context) {
 	req := &actionEngine.RunActionReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse RunAction param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -106,6 +110,11 @@ func (h *Handle) RunAction(ctx *gin.Context) {
 		req.UUID = uuid.NewV4()
 	}
 
+	if err := h.devices.ValidateLabAction(ctx, req.LabUUID, req.DeviceID, req.Action, req.ActionType, req.Param); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
 	if exists, err := h.rClient.Exists(ctx, utils.LabHeartName(req.LabUUID)).Result(); err != nil || exists == 0 {
 		common.ReplyErr(ctx, code.EdgeNotStartedErr)
 		return
@@ -113,7 +122,9 @@ func (h *Handle) RunAction(ctx *gin.Context) {
 
 	data := edge.ApiControlData[engine.WorkflowInfo]{
 		ApiControlMsg: edge.ApiControlMsg{
-			Action: edge.StartAction,
+			Action:      edge.StartAction,
+			TraceParent: otel.TraceParent(ctx),
+			Baggage:     otel.Baggage(ctx),
 		},
 		Data: engine.WorkflowInfo{
 			TaskUUID:     req.UUID,
@@ -0,0 +1,196 @@
+// Package alert provides HTTP handlers for device alerts and silences.
+package alert
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/alert"
+)
+
+// Handler handles alert HTTP requests
+type Handler struct {
+	service *alert.Service
+}
+
+// NewHandler creates a new alert handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: alert.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one alert or silence by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// ListSilenceRequest represents the request for listing silences
+type ListSilenceRequest struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// @Summary 获取告警列表
+// @Description 分页获取实验室告警，按触发时间倒序
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param status query string false "状态过滤 (firing, acked, resolved)"
+// @Param severity query string false "级别过滤 (info, warning, critical)"
+// @Param device_uuid query string false "设备UUID"
+// @Param assignee_id query string false "负责人ID"
+// @Param start_time query string false "触发时间起 (RFC3339格式)"
+// @Param end_time query string false "触发时间止 (RFC3339格式)"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} common.Resp{data=common.ListResp[model.Alert]}
+// @Router /v1/lab/alert [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &alert.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取告警详情
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param uuid path string true "告警UUID"
+// @Success 200 {object} common.Resp{data=model.Alert}
+// @Router /v1/lab/alert/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 确认告警
+// @Description 确认触发中的告警，确认后不再升级
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param uuid path string true "告警UUID"
+// @Success 200 {object} common.Resp{data=model.Alert}
+// @Router /v1/lab/alert/{uuid}/ack [post]
+func (h *Handler) Ack(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Ack(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 恢复告警
+// @Description 手动恢复告警，规则条件仍满足时会重新触发
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param uuid path string true "告警UUID"
+// @Success 200 {object} common.Resp{data=model.Alert}
+// @Router /v1/lab/alert/{uuid}/resolve [post]
+func (h *Handler) Resolve(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Resolve(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 指派告警
+// @Description 指派告警负责人，负责人必须是实验室成员，assignee_id 为空时取消指派
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param uuid path string true "告警UUID"
+// @Param req body alert.AssignReq true "指派请求"
+// @Success 200 {object} common.Resp{data=model.Alert}
+// @Router /v1/lab/alert/{uuid}/assign [put]
+func (h *Handler) Assign(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	req := &alert.AssignReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Assign(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 创建告警静默
+// @Description 静默设备或整个实验室的告警，窗口内触发的告警不发送通知也不升级，最长 30 天
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param req body alert.SilenceReq true "静默请求"
+// @Success 200 {object} common.Resp{data=model.AlertSilence}
+// @Router /v1/lab/alert/silence [post]
+func (h *Handler) CreateSilence(ctx *gin.Context) {
+	req := &alert.SilenceReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.CreateSilence(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取告警静默列表
+// @Description 获取实验室当前及将来的静默窗口
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.AlertSilence}
+// @Router /v1/lab/alert/silence [get]
+func (h *Handler) ListSilences(ctx *gin.Context) {
+	var req ListSilenceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.ListSilences(ctx, req.LabUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 删除告警静默
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param uuid path string true "静默UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/alert/silence/{uuid} [delete]
+func (h *Handler) DeleteSilence(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.DeleteSilence(ctx, uri.UUID))
+}
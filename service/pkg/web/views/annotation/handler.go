@@ -0,0 +1,138 @@
+// Package annotation provides HTTP handlers for workflow execution annotations.
+package annotation
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/annotation"
+)
+
+// Handler handles execution annotation HTTP requests
+type Handler struct {
+	service *annotation.Service
+}
+
+// NewHandler creates a new execution annotation handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: annotation.New(),
+	}
+}
+
+// ExecutionRequest represents a request addressing the annotations of one execution
+type ExecutionRequest struct {
+	ExecutionUUID uuid.UUID `uri:"execution_uuid" binding:"required"`
+}
+
+// UUIDRequest represents a request addressing one annotation by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 获取执行记录的批注
+// @Description 按时间顺序返回批注，回复嵌套在 replies 中，已删除的批注内容为空
+// @Tags Annotation
+// @Accept json
+// @Produce json
+// @Param execution_uuid path string true "执行记录UUID"
+// @Success 200 {object} common.Resp{data=[]model.ExecutionAnnotation}
+// @Router /v1/lab/history/workflow/execution/{execution_uuid}/annotation [get]
+func (h *Handler) List(ctx *gin.Context) {
+	var uri ExecutionRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, uri.ExecutionUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 添加批注
+// @Description 添加批注或回复已有批注，mentions 中的实验室成员会收到通知
+// @Tags Annotation
+// @Accept json
+// @Produce json
+// @Param execution_uuid path string true "执行记录UUID"
+// @Param req body annotation.CreateReq true "批注"
+// @Success 200 {object} common.Resp{data=model.ExecutionAnnotation}
+// @Router /v1/lab/history/workflow/execution/{execution_uuid}/annotation [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	var uri ExecutionRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &annotation.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, uri.ExecutionUUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 编辑批注
+// @Description 仅作者可以编辑，编辑前的内容保存在编辑历史中
+// @Tags Annotation
+// @Accept json
+// @Produce json
+// @Param uuid path string true "批注UUID"
+// @Param req body annotation.UpdateReq true "批注"
+// @Success 200 {object} common.Resp{data=model.ExecutionAnnotation}
+// @Router /v1/lab/history/annotation/{uuid} [put]
+func (h *Handler) Update(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &annotation.UpdateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Update(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 删除批注
+// @Description 作者与实验室管理员可以删除，回复保留
+// @Tags Annotation
+// @Accept json
+// @Produce json
+// @Param uuid path string true "批注UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/history/annotation/{uuid} [delete]
+func (h *Handler) Delete(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.Delete(ctx, uri.UUID))
+}
+
+// @Summary 获取批注编辑历史
+// @Description 按时间顺序返回每次编辑前的内容
+// @Tags Annotation
+// @Accept json
+// @Produce json
+// @Param uuid path string true "批注UUID"
+// @Success 200 {object} common.Resp{data=[]model.ExecutionAnnotationRevision}
+// @Router /v1/lab/history/annotation/{uuid}/revisions [get]
+func (h *Handler) Revisions(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.Revisions(ctx, uri.UUID)
+	common.Reply(ctx, err, datas)
+}
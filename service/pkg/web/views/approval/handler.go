@@ -0,0 +1,241 @@
+// Package approval provides HTTP handlers for workflow step approval APIs.
+package approval
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/notification"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/approval"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+)
+
+// Handler handles approval-related HTTP requests
+type Handler struct {
+	repo     approval.ApprovalRepo
+	envStore repo.LaboratoryRepo
+	inbox    *notification.Sender
+}
+
+// NewHandler creates a new approval handler
+func NewHandler() *Handler {
+	return &Handler{
+		repo:     approval.New(),
+		envStore: environment.New(),
+		inbox:    notification.NewSender(),
+	}
+}
+
+// ListPendingRequest represents the request for listing pending approvals,
+// an empty lab_id lists the pending approvals of all labs of the caller
+type ListPendingRequest struct {
+	LabID    int64 `form:"lab_id"`
+	Page     int   `form:"page,default=1"`
+	PageSize int   `form:"page_size,default=20"`
+}
+
+// ApprovalResponse represents an approval request in response
+type ApprovalResponse struct {
+	UUID        uuid.UUID            `json:"uuid"`
+	TaskUUID    uuid.UUID            `json:"task_uuid"`
+	NodeUUID    uuid.UUID            `json:"node_uuid"`
+	StepName    string               `json:"step_name"`
+	RequestedBy string               `json:"requested_by"`
+	Status      model.ApprovalStatus `json:"status"`
+	ReviewerID  *string              `json:"reviewer_id,omitempty"`
+	Comment     *string              `json:"comment,omitempty"`
+	ReviewedAt  *time.Time           `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// DecideRequest represents the request for approving or rejecting a step
+type DecideRequest struct {
+	Comment string `json:"comment"`
+}
+
+func toResponse(a *model.ApprovalRequest) ApprovalResponse {
+	return ApprovalResponse{
+		UUID:        a.UUID,
+		TaskUUID:    a.TaskUUID,
+		NodeUUID:    a.NodeUUID,
+		StepName:    a.StepName,
+		RequestedBy: a.RequestedBy,
+		Status:      a.Status,
+		ReviewerID:  a.ReviewerID,
+		Comment:     a.Comment,
+		ReviewedAt:  a.ReviewedAt,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// @Summary 获取待审批列表
+// @Description 获取当前用户所在实验室中等待人工审批的工作流步骤，不传实验室时返回所有所在实验室
+// @Tags Approval
+// @Accept json
+// @Produce json
+// @Param lab_id query int false "实验室ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} common.Resp{data=common.ListResp[ApprovalResponse]}
+// @Router /v1/lab/approval/pending [get]
+func (h *Handler) ListPending(ctx *gin.Context) {
+	var req ListPendingRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		common.ReplyErr(ctx, code.UnLogin)
+		return
+	}
+
+	var labIDs []int64
+	if req.LabID != 0 {
+		if err := h.checkLab(ctx, req.LabID, userInfo.ID); err != nil {
+			common.ReplyErr(ctx, err)
+			return
+		}
+		labIDs = []int64{req.LabID}
+	} else {
+		members := make([]*model.LaboratoryMember, 0)
+		if err := h.envStore.FindDatas(ctx, &members, map[string]any{
+			"user_id": userInfo.ID,
+		}, "lab_id"); err != nil {
+			common.ReplyErr(ctx, err)
+			return
+		}
+		for _, m := range members {
+			labIDs = append(labIDs, m.LabID)
+		}
+	}
+
+	approvals, total, err := h.repo.ListPendingApprovals(ctx, labIDs, req.Page, req.PageSize)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	items := make([]ApprovalResponse, 0, len(approvals))
+	for _, a := range approvals {
+		items = append(items, toResponse(a))
+	}
+
+	common.ReplyOk(ctx, common.NewListResp(items, total, req.Page, req.PageSize))
+}
+
+// @Summary 审批通过
+// @Description 通过工作流步骤的审批，调度器将继续执行该步骤
+// @Tags Approval
+// @Accept json
+// @Produce json
+// @Param uuid path string true "审批UUID"
+// @Param req body DecideRequest false "审批意见"
+// @Success 200 {object} common.Resp{data=ApprovalResponse}
+// @Router /v1/lab/approval/{uuid}/approve [post]
+func (h *Handler) Approve(ctx *gin.Context) {
+	h.decide(ctx, model.ApprovalStatusApproved)
+}
+
+// @Summary 审批拒绝
+// @Description 拒绝工作流步骤的审批，调度器将终止该步骤
+// @Tags Approval
+// @Accept json
+// @Produce json
+// @Param uuid path string true "审批UUID"
+// @Param req body DecideRequest false "审批意见"
+// @Success 200 {object} common.Resp{data=ApprovalResponse}
+// @Router /v1/lab/approval/{uuid}/reject [post]
+func (h *Handler) Reject(ctx *gin.Context) {
+	h.decide(ctx, model.ApprovalStatusRejected)
+}
+
+func (h *Handler) decide(ctx *gin.Context, status model.ApprovalStatus) {
+	approvalUUID, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid approval UUID"))
+		return
+	}
+
+	req := &DecideRequest{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+			return
+		}
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		common.ReplyErr(ctx, code.UnLogin)
+		return
+	}
+
+	// 只有实验室成员可以审批，且不能审批自己发起的步骤
+	pending, err := h.repo.GetApprovalByUUID(ctx, approvalUUID)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	if err := h.checkLab(ctx, pending.LabID, userInfo.ID); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	if pending.RequestedBy == userInfo.ID {
+		common.ReplyErr(ctx, code.ApprovalSelfDecideErr)
+		return
+	}
+
+	var comment *string
+	if req.Comment != "" {
+		comment = &req.Comment
+	}
+
+	ret, err := h.repo.DecideApproval(ctx, approvalUUID, status, userInfo.ID, comment)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	// 通知提交任务的用户审批结果
+	h.inbox.Send(ctx, &notification.Message{
+		Kind:    model.NotificationApproval,
+		UserIDs: []string{ret.RequestedBy},
+		LabID:   ret.LabID,
+		RefUUID: ret.UUID,
+		Title:   fmt.Sprintf("step %s %s by %s", ret.StepName, ret.Status, userInfo.Name),
+		Body:    req.Comment,
+	})
+
+	common.ReplyOk(ctx, toResponse(ret))
+}
+
+// checkLab rejects users that are neither a member of the lab nor an admin
+func (h *Handler) checkLab(ctx *gin.Context, labID int64, userID string) error {
+	if auth.IsAdmin(userID) {
+		return nil
+	}
+	count, err := h.envStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
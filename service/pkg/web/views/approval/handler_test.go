@@ -0,0 +1,167 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/approval"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandler(t *testing.T) {
+	handler := NewHandler()
+	assert.NotNil(t, handler)
+	assert.NotNil(t, handler.repo)
+}
+
+func TestListPendingUnLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler := NewHandler()
+	router.GET("/approval/pending", handler.ListPending)
+
+	req := httptest.NewRequest(http.MethodGet, "/approval/pending", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "not logged in")
+}
+
+func TestApproveInvalidUUID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler := NewHandler()
+	router.POST("/approval/:uuid/approve", handler.Approve)
+
+	req := httptest.NewRequest(http.MethodPost, "/approval/invalid-uuid/approve", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid approval UUID")
+}
+
+// approvalEnv sets up an in-memory database with two labs, lab 1 has the
+// members "starter" and "reviewer", lab 2 only has "other"
+type approvalEnv struct {
+	router  *gin.Engine
+	repo    approval.ApprovalRepo
+	pending map[int64]*model.ApprovalRequest
+}
+
+func newApprovalEnv(t *testing.T) *approvalEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+
+	base := repo.NewBaseDB()
+	for _, m := range []*model.LaboratoryMember{
+		{LabID: 1, UserID: "starter", Role: model.LaboratoryMemberNormal},
+		{LabID: 1, UserID: "reviewer", Role: model.LaboratoryMemberNormal},
+		{LabID: 2, UserID: "other", Role: model.LaboratoryMemberNormal},
+	} {
+		require.NoError(t, base.CreateData(ctx, m))
+	}
+
+	env := &approvalEnv{repo: approval.New(), pending: map[int64]*model.ApprovalRequest{}}
+	for _, labID := range []int64{1, 2} {
+		req := &model.ApprovalRequest{
+			LabID:       labID,
+			TaskUUID:    uuid.NewV4(),
+			NodeUUID:    uuid.NewV4(),
+			StepName:    "add reagent",
+			RequestedBy: "starter",
+		}
+		require.NoError(t, env.repo.CreateApproval(ctx, &model.ActionExecutionHistory{
+			LabID:      labID,
+			DeviceUUID: uuid.NewV4(),
+			DeviceName: "pump",
+			ActionName: "add",
+		}, req))
+		env.pending[labID] = req
+	}
+
+	handler := NewHandler()
+	env.router = gin.New()
+	env.router.Use(func(ctx *gin.Context) {
+		ctx.Set(auth.USERKEY, &model.UserData{ID: ctx.GetHeader("X-User")})
+		ctx.Next()
+	})
+	env.router.GET("/approval/pending", handler.ListPending)
+	env.router.POST("/approval/:uuid/approve", handler.Approve)
+	return env
+}
+
+type testResp struct {
+	Code code.ErrCode    `json:"code"`
+	Data json.RawMessage `json:"data"`
+}
+
+func (e *approvalEnv) do(t *testing.T, method, target, userID string) testResp {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("X-User", userID)
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+
+	var resp testResp
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestListPendingOnlyCallerLabs(t *testing.T) {
+	env := newApprovalEnv(t)
+
+	resp := env.do(t, http.MethodGet, "/approval/pending", "reviewer")
+	require.Equal(t, code.Success, resp.Code)
+	var list common.ListResp[ApprovalResponse]
+	require.NoError(t, json.Unmarshal(resp.Data, &list))
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, env.pending[1].UUID, list.Items[0].UUID)
+
+	resp = env.do(t, http.MethodGet, "/approval/pending?lab_id=2", "reviewer")
+	assert.Equal(t, code.NoPermission, resp.Code)
+}
+
+func TestDecideRequiresLabMember(t *testing.T) {
+	env := newApprovalEnv(t)
+
+	resp := env.do(t, http.MethodPost, "/approval/"+env.pending[1].UUID.String()+"/approve", "other")
+	assert.Equal(t, code.NoPermission, resp.Code)
+
+	got, err := env.repo.GetApprovalByUUID(context.Background(), env.pending[1].UUID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ApprovalStatusPending, got.Status)
+}
+
+func TestDecideRejectsRequester(t *testing.T) {
+	env := newApprovalEnv(t)
+
+	resp := env.do(t, http.MethodPost, "/approval/"+env.pending[1].UUID.String()+"/approve", "starter")
+	assert.Equal(t, code.ApprovalSelfDecideErr, resp.Code)
+
+	got, err := env.repo.GetApprovalByUUID(context.Background(), env.pending[1].UUID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ApprovalStatusPending, got.Status)
+}
@@ -0,0 +1,65 @@
+// Package assistant provides HTTP handlers for the execution failure assistant.
+package assistant
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/assistant"
+)
+
+// Handler handles assistant HTTP requests
+type Handler struct {
+	service *assistant.Service
+}
+
+// NewHandler creates a new assistant handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: assistant.New(),
+	}
+}
+
+// @Summary 诊断执行失败原因
+// @Description 汇总执行的步骤、错误、节点日志和执行期间的设备事件，由配置的大模型给出失败原因和处理建议。需开启 ai_assistant 功能，每个用户每小时的次数有限制，发送的提示词会被审计
+// @Tags Assistant
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body assistant.DiagnoseReq true "执行UUID"
+// @Success 200 {object} common.Resp{data=model.Diagnosis}
+// @Router /v1/assistant/diagnose [post]
+func (h *Handler) Diagnose(ctx *gin.Context) {
+	req := &assistant.DiagnoseReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Diagnose(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取助手审计记录
+// @Description 发送给大模型的提示词和回答，包括失败的调用，只有管理员可以查看
+// @Tags Assistant
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param kind query string false "调用类型 (diagnose, query)"
+// @Param user_id query string false "用户ID"
+// @Param lab_uuid query string false "实验室UUID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[model.AssistantAudit]}
+// @Router /v1/admin/assistant/audit [get]
+func (h *Handler) ListAudit(ctx *gin.Context) {
+	req := &assistant.AuditReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	resp, err := h.service.ListAudit(ctx, req)
+	common.Reply(ctx, err, resp)
+}
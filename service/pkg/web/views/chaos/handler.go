@@ -0,0 +1,51 @@
+// Package chaos provides HTTP handlers for the dependency failure injection.
+package chaos
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/chaos"
+)
+
+// Handler handles chaos HTTP requests
+type Handler struct {
+	service *chaos.Service
+}
+
+// NewHandler creates a new chaos handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: chaos.New(),
+	}
+}
+
+// @Summary 故障注入配置
+// @Description 返回当前实例注入的 redis 延迟、数据库错误和调度消息丢弃比例。生产环境不提供，仅管理员可查看
+// @Tags Chaos
+// @Accept json
+// @Produce json
+// @Success 200 {object} common.Resp{data=chaos.ChaosConf}
+// @Router /v1/admin/chaos [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	data, err := h.service.Get(ctx)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 修改故障注入配置
+// @Description 修改处理该请求的实例注入的故障，用于验证本地限流、fail-open 和重试等降级路径，重启后恢复为配置文件的值。生产环境不提供，仅管理员可操作
+// @Tags Chaos
+// @Accept json
+// @Produce json
+// @Param req body chaos.ChaosConf true "注入的故障"
+// @Success 200 {object} common.Resp{data=chaos.ChaosConf}
+// @Router /v1/admin/chaos [put]
+func (h *Handler) Set(ctx *gin.Context) {
+	req := &chaos.ChaosConf{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	data, err := h.service.Set(ctx, req)
+	common.Reply(ctx, err, data)
+}
@@ -0,0 +1,226 @@
+// Package command provides HTTP handlers for the device command queue.
+package command
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/command"
+)
+
+// Handler handles device command HTTP requests
+type Handler struct {
+	service *command.Service
+}
+
+// NewHandler creates a new device command handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: command.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one command by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 下发设备指令
+// @Description 校验设备能力后将指令加入设备队列，由 edge 拉取执行，同时创建待执行的动作记录
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Param req body command.EnqueueReq true "指令请求"
+// @Success 200 {object} common.Resp{data=model.DeviceCommand}
+// @Router /v1/lab/device/command [post]
+func (h *Handler) Enqueue(ctx *gin.Context) {
+	req := &command.EnqueueReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Enqueue(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取设备指令列表
+// @Description 分页获取实验室设备指令，可按设备和状态过滤
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param device_name query string false "设备名称"
+// @Param status query string false "状态过滤 (queued, delivered, acked, succeeded, failed, timeout)"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} common.Resp{data=common.ListResp[model.DeviceCommand]}
+// @Router /v1/lab/device/command [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &command.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取设备指令详情
+// @Description 获取指令状态、投递次数及执行结果
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Param uuid path string true "指令UUID"
+// @Success 200 {object} common.Resp{data=model.DeviceCommand}
+// @Router /v1/lab/device/command/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 下发设备组指令
+// @Description 将指令扇出到设备组（或房间、工作台）下的所有设备，每个设备一条指令；不支持该动作或参数不符合能力声明的设备跳过并在 skipped 中返回
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Param req body command.GroupEnqueueReq true "设备组指令请求"
+// @Success 200 {object} common.Resp{data=command.GroupCommandResp}
+// @Router /v1/lab/device/command/group [post]
+func (h *Handler) EnqueueGroup(ctx *gin.Context) {
+	req := &command.GroupEnqueueReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.EnqueueGroup(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取设备组指令列表
+// @Description 分页获取实验室的设备组指令及各自的汇总结果，新的在前
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[command.GroupCommandResp]}
+// @Router /v1/lab/device/command/group [get]
+func (h *Handler) ListGroups(ctx *gin.Context) {
+	req := &command.GroupListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.ListGroups(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取设备组指令详情
+// @Description 获取设备组指令的汇总结果：有未结束的指令时为 running，全部成功为 succeeded，部分失败或超时为 partial，全部失败或超时为 failed；同时返回每个设备的指令
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Param uuid path string true "设备组指令UUID"
+// @Success 200 {object} common.Resp{data=command.GroupCommandResp}
+// @Router /v1/lab/device/command/group/{uuid} [get]
+func (h *Handler) GetGroup(ctx *gin.Context) {
+	groupUUID, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid group command UUID"))
+		return
+	}
+
+	data, err := h.service.GetGroup(ctx, groupUUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 边缘端拉取设备指令
+// @Description edge 长轮询拉取下一条指令，wait 为最长等待秒数（最大 25 秒），无指令时返回空
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param devices query []string false "设备名称，不传则拉取实验室全部设备" collectionFormat(multi)
+// @Param wait query int false "最长等待秒数" default(0)
+// @Success 200 {object} common.Resp{data=model.DeviceCommand}
+// @Router /v1/edge/command [get]
+func (h *Handler) Poll(ctx *gin.Context) {
+	req := &command.PollReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	if wait := ctx.Query("wait"); wait != "" {
+		sec, err := strconv.Atoi(wait)
+		if err != nil || sec < 0 {
+			common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid wait"))
+			return
+		}
+		req.Wait = time.Duration(sec) * time.Second
+	}
+
+	data, err := h.service.Poll(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 边缘端确认设备指令
+// @Description edge 确认已收到指令，确认后开始计算结果超时
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param uuid path string true "指令UUID"
+// @Success 200 {object} common.Resp{data=model.DeviceCommand}
+// @Router /v1/edge/command/{uuid}/ack [post]
+func (h *Handler) Ack(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Ack(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 边缘端上报指令结果
+// @Description edge 上报指令执行结果，写入设备事件并更新动作执行记录
+// @Tags DeviceCommand
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param uuid path string true "指令UUID"
+// @Param req body command.ResultReq true "执行结果"
+// @Success 200 {object} common.Resp{data=model.DeviceCommand}
+// @Router /v1/edge/command/{uuid}/result [post]
+func (h *Handler) Result(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	req := &command.ResultReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Result(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
@@ -0,0 +1,120 @@
+// Package cost provides HTTP handlers for lab cost models, cost breakdowns and billing export.
+package cost
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/cost"
+)
+
+// Handler handles cost accounting HTTP requests
+type Handler struct {
+	service *cost.Service
+}
+
+// NewHandler creates a new cost accounting handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: cost.New(),
+	}
+}
+
+// ModelQuery represents a request for the cost model of a lab
+type ModelQuery struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// @Summary 获取实验室计费模型
+// @Description 获取实验室生效的计费模型，未单独设置时返回全局默认模型
+// @Tags Cost
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=model.CostModel}
+// @Router /v1/lab/cost/model [get]
+func (h *Handler) GetModel(ctx *gin.Context) {
+	req := &ModelQuery{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.GetModel(ctx, req.LabUUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 设置实验室计费模型
+// @Description 实验室创建者设置机时、动作类型与存储单价，新价格从最近未结算的日期开始生效
+// @Tags Cost
+// @Accept json
+// @Produce json
+// @Param req body cost.ModelReq true "计费模型"
+// @Success 200 {object} common.Resp{data=model.CostModel}
+// @Router /v1/lab/cost/model [put]
+func (h *Handler) SetModel(ctx *gin.Context) {
+	req := &cost.ModelReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.SetModel(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取实验室费用明细
+// @Description 按类别、计费项和日期汇总实验室费用，默认最近 30 天
+// @Tags Cost
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param start query string false "开始日期 (2006-01-02)"
+// @Param end query string false "结束日期 (2006-01-02)，包含当天"
+// @Success 200 {object} common.Resp{data=cost.BreakdownResp}
+// @Router /v1/lab/cost [get]
+func (h *Handler) Breakdown(ctx *gin.Context) {
+	req := &cost.RangeReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Breakdown(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 导出计费数据
+// @Description 导出计费系统使用的 CSV，每行为一天一个计费项的费用，account_id 为实验室UUID
+// @Tags Cost
+// @Accept json
+// @Produce text/csv
+// @Param lab_uuid query string true "实验室UUID"
+// @Param start query string false "开始日期 (2006-01-02)"
+// @Param end query string false "结束日期 (2006-01-02)，包含当天"
+// @Success 200 {file} file "CSV 文件"
+// @Failure 200 {object} common.Resp{code=code.ErrCode} "请求参数错误"
+// @Router /v1/lab/cost/export [get]
+func (h *Handler) Export(ctx *gin.Context) {
+	req := &cost.RangeReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	res, err := h.service.Export(ctx, req)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	filename := fmt.Sprintf("cost_%s_%s.csv", req.LabUUID, time.Now().Format("20060102"))
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Header("Content-Length", fmt.Sprintf("%d", res.Len()))
+	ctx.Data(http.StatusOK, "text/csv", res.Bytes())
+}
@@ -0,0 +1,70 @@
+// Package dashboard provides the HTTP handler for the lab dashboard.
+package dashboard
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/dashboard"
+)
+
+// Handler handles lab dashboard HTTP requests
+type Handler struct {
+	service *dashboard.Service
+}
+
+// NewHandler creates a new dashboard handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: dashboard.New(),
+	}
+}
+
+// SectionError is the reason a dashboard section is missing
+type SectionError struct {
+	Code code.ErrCode `json:"code"`
+	Msg  string       `json:"msg"`
+}
+
+// Response represents the lab dashboard, errors is keyed by section
+type Response struct {
+	*dashboard.Dashboard
+	Errors map[string]*SectionError `json:"errors,omitempty"`
+}
+
+// @Summary 获取实验室看板
+// @Description 一次返回实验室统计、最近执行、未恢复告警、设备在线状态和排队执行数。各分区并发查询，失败的分区为 null，原因在 errors 中按分区返回，其他分区照常返回
+// @Tags Dashboard
+// @Accept json
+// @Produce json
+// @Param lab_id path int true "实验室ID" example(1)
+// @Param start_time query string false "统计开始时间 (RFC3339格式)"
+// @Param end_time query string false "统计结束时间 (RFC3339格式)"
+// @Success 200 {object} common.Resp{data=Response}
+// @Router /v1/lab/{lab_id}/dashboard [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	req := &dashboard.Req{}
+	if err := ctx.ShouldBindUri(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, req)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	resp := &Response{Dashboard: data}
+	for section, err := range data.Errors {
+		if resp.Errors == nil {
+			resp.Errors = make(map[string]*SectionError, len(data.Errors))
+		}
+		errCode, e := common.LocalizeErr(ctx, err)
+		resp.Errors[section] = &SectionError{Code: errCode, Msg: e.Msg}
+	}
+	common.ReplyOk(ctx, resp)
+}
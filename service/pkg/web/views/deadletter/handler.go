@@ -0,0 +1,174 @@
+// Package deadletter provides HTTP handlers to inspect and requeue dead-lettered scheduler jobs.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/deadletter"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
+)
+
+// Handler handles dead-letter HTTP requests
+type Handler struct {
+	repo     deadletter.DeadLetterRepo
+	labStore repo.LaboratoryRepo
+}
+
+// NewHandler creates a new dead-letter handler
+func NewHandler() *Handler {
+	return &Handler{
+		repo:     deadletter.New(),
+		labStore: environment.New(),
+	}
+}
+
+// ListRequest represents the request for listing dead-letter entries
+type ListRequest struct {
+	LabID    int64  `form:"lab_id" binding:"required"`
+	Status   string `form:"status"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// checkLabOwner 死信涉及实验室全部任务，仅实验室所有者可以查看和重新投递
+func (h *Handler) checkLabOwner(ctx context.Context, labID int64) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+
+	lab, err := h.labStore.GetLabByID(ctx, labID, "id", "user_id")
+	if err != nil {
+		return err
+	}
+	if lab.UserID != userInfo.ID {
+		return code.PermissionDenied
+	}
+	return nil
+}
+
+// @Summary 获取死信任务列表
+// @Description 获取实验室中重试耗尽的调度任务
+// @Tags DeadLetter
+// @Accept json
+// @Produce json
+// @Param lab_id query int true "实验室ID"
+// @Param status query string false "状态过滤 (dead, requeued)"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} common.Resp{data=common.ListResp[model.DeadLetterJob]}
+// @Router /v1/lab/deadletter [get]
+func (h *Handler) List(ctx *gin.Context) {
+	var req ListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	if err := h.checkLabOwner(ctx, req.LabID); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	var status *model.DeadLetterStatus
+	if req.Status != "" {
+		s := model.DeadLetterStatus(req.Status)
+		status = &s
+	}
+
+	items, total, err := h.repo.ListDeadLetters(ctx, req.LabID, status, req.Page, req.PageSize)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.ReplyOk(ctx, common.NewListResp(items, total, req.Page, req.PageSize))
+}
+
+// @Summary 获取死信任务详情
+// @Description 获取死信任务的完整消息及失败记录
+// @Tags DeadLetter
+// @Accept json
+// @Produce json
+// @Param uuid path string true "死信UUID"
+// @Success 200 {object} common.Resp{data=model.DeadLetterJob}
+// @Router /v1/lab/deadletter/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	job, err := h.getJob(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.ReplyOk(ctx, job)
+}
+
+// @Summary 重新投递死信任务
+// @Description 将死信任务的原始消息重新投递到原队列
+// @Tags DeadLetter
+// @Accept json
+// @Produce json
+// @Param uuid path string true "死信UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/deadletter/{uuid}/requeue [post]
+func (h *Handler) Requeue(ctx *gin.Context) {
+	job, err := h.getJob(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	err = h.repo.RequeueDeadLetter(ctx, job, userInfo.ID, func() error {
+		return publish(ctx, redis.GetClient(), job)
+	})
+	common.Reply(ctx, err)
+}
+
+func (h *Handler) getJob(ctx *gin.Context) (*model.DeadLetterJob, error) {
+	jobUUID, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		return nil, code.ParamErr.WithMsg("invalid dead letter UUID")
+	}
+
+	job, err := h.repo.GetDeadLetterByUUID(ctx, jobUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.checkLabOwner(ctx, job.LabID); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// publish 还原原始消息并投递，非 JSON 消息在入库时被编码为 JSON 字符串
+func publish(ctx context.Context, client *r.Client, job *model.DeadLetterJob) error {
+	msg := string(job.Payload)
+	var raw string
+	if err := json.Unmarshal(job.Payload, &raw); err == nil {
+		msg = raw
+	}
+
+	if err := client.LPush(ctx, job.Queue, msg).Err(); err != nil {
+		return code.ParamErr.WithMsgf("push dead letter msg err: %+v", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Package device provides HTTP handlers for the device registry.
+package device
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/device"
+)
+
+// Handler handles device registry HTTP requests
+type Handler struct {
+	registry *device.Registry
+}
+
+// NewHandler creates a new device registry handler
+func NewHandler() *Handler {
+	return &Handler{
+		registry: device.New(),
+	}
+}
+
+// DeviceRequest represents a request addressing one device of a lab
+type DeviceRequest struct {
+	LabUUID string `uri:"lab_uuid" binding:"required"`
+	Name    string `uri:"name"`
+}
+
+// @Summary 边缘端注册设备
+// @Description edge 批量注册或更新设备及其能力描述，param_schema 为动作参数的 JSON Schema，同名设备整体覆盖
+// @Tags Device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body device.RegisterReq true "设备注册请求"
+// @Success 200 {object} common.Resp{data=[]model.Device}
+// @Router /v1/edge/device [post]
+func (h *Handler) Register(ctx *gin.Context) {
+	req := &device.RegisterReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.registry.Register(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 边缘端注销设备
+// @Description edge 从设备注册表中移除设备，移除后该设备的动作不再做能力校验
+// @Tags Device
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "设备名称"
+// @Success 200 {object} common.Resp
+// @Router /v1/edge/device/{name} [delete]
+func (h *Handler) Unregister(ctx *gin.Context) {
+	common.Reply(ctx, h.registry.Unregister(ctx, ctx.Param("name")))
+}
+
+// @Summary 获取实验室设备注册表
+// @Description 获取实验室所有已注册设备及其能力描述
+// @Tags Device
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.Device}
+// @Router /v1/lab/device/registry/{lab_uuid} [get]
+func (h *Handler) List(ctx *gin.Context) {
+	labUUID, _, err := bindDevice(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	datas, err := h.registry.List(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取设备能力描述
+// @Description 获取单个设备的注册信息与支持的动作
+// @Tags Device
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Param name path string true "设备名称"
+// @Success 200 {object} common.Resp{data=model.Device}
+// @Router /v1/lab/device/registry/{lab_uuid}/{name} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	labUUID, name, err := bindDevice(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	data, err := h.registry.Get(ctx, labUUID, name)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取实验室设备容量
+// @Description 获取实验室已注册设备数与设备上限，max_devices 为 0 表示不限制
+// @Tags Device
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=device.CapacityResp}
+// @Router /v1/lab/device/capacity/{lab_uuid} [get]
+func (h *Handler) Capacity(ctx *gin.Context) {
+	labUUID, _, err := bindDevice(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	data, err := h.registry.Capacity(ctx, labUUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 设置实验室设备上限
+// @Description 实验室创建者单独设置设备上限，覆盖全局配置；低于已注册设备数时只阻止新增设备
+// @Tags Device
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Param req body device.SetLimitReq true "设备上限"
+// @Success 200 {object} common.Resp{data=device.CapacityResp}
+// @Router /v1/lab/device/capacity/{lab_uuid} [put]
+func (h *Handler) SetLimit(ctx *gin.Context) {
+	labUUID, _, err := bindDevice(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	req := &device.SetLimitReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.registry.SetLimit(ctx, labUUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 恢复实验室默认设备上限
+// @Description 删除实验室单独设置的设备上限，恢复使用全局配置
+// @Tags Device
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=device.CapacityResp}
+// @Router /v1/lab/device/capacity/{lab_uuid} [delete]
+func (h *Handler) ResetLimit(ctx *gin.Context) {
+	labUUID, _, err := bindDevice(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	data, err := h.registry.ResetLimit(ctx, labUUID)
+	common.Reply(ctx, err, data)
+}
+
+func bindDevice(ctx *gin.Context) (uuid.UUID, string, error) {
+	var req DeviceRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		return uuid.NewNil(), "", code.ParamErr.WithErr(err)
+	}
+
+	labUUID, err := uuid.FromString(req.LabUUID)
+	if err != nil {
+		return uuid.NewNil(), "", code.ParamErr.WithMsg("invalid lab UUID")
+	}
+	return labUUID, req.Name, nil
+}
@@ -0,0 +1,285 @@
+// Package deviceconfig provides HTTP handlers for device configuration profiles.
+package deviceconfig
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/deviceconfig"
+)
+
+// Handler handles device config HTTP requests
+type Handler struct {
+	service *deviceconfig.Service
+}
+
+// NewHandler creates a new device config handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: deviceconfig.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one config profile or assignment by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// LabRequest represents a request addressing the device configs of a lab
+type LabRequest struct {
+	LabUUID string `uri:"lab_uuid" form:"lab_uuid" binding:"required"`
+}
+
+// @Summary 创建设备配置模板
+// @Description 创建按设备类型区分的 JSON 配置模板，config 为第一个版本，仅实验室创建者可以操作
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param req body deviceconfig.CreateProfileReq true "配置模板"
+// @Success 200 {object} common.Resp{data=deviceconfig.ProfileResp}
+// @Router /v1/lab/device/config/profile [post]
+func (h *Handler) CreateProfile(ctx *gin.Context) {
+	req := &deviceconfig.CreateProfileReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.CreateProfile(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取设备配置模板列表
+// @Description 获取实验室的配置模板，按名称排序
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.DeviceConfigProfile}
+// @Router /v1/lab/device/config/profile [get]
+func (h *Handler) ListProfiles(ctx *gin.Context) {
+	var req LabRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	labUUID, err := uuid.FromString(req.LabUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+
+	datas, err := h.service.ListProfiles(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取设备配置模板详情
+// @Description 获取配置模板及其所有版本，新版本在前
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param uuid path string true "配置模板UUID"
+// @Success 200 {object} common.Resp{data=deviceconfig.ProfileResp}
+// @Router /v1/lab/device/config/profile/{uuid} [get]
+func (h *Handler) GetProfile(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.GetProfile(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 创建设备配置版本
+// @Description 为配置模板创建新版本，push 为 true 时立即下发到跟随最新版本的设备
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param uuid path string true "配置模板UUID"
+// @Param req body deviceconfig.CreateVersionReq true "配置版本"
+// @Success 200 {object} common.Resp{data=model.DeviceConfigVersion}
+// @Router /v1/lab/device/config/profile/{uuid}/version [post]
+func (h *Handler) CreateVersion(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &deviceconfig.CreateVersionReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.CreateVersion(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 分配设备配置
+// @Description 将配置模板分配给设备，device_name 为空时分配给模板设备类型的所有设备；设备级分配优先于设备类型的分配
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param uuid path string true "配置模板UUID"
+// @Param req body deviceconfig.AssignReq true "分配"
+// @Success 200 {object} common.Resp{data=model.DeviceConfigAssignment}
+// @Router /v1/lab/device/config/profile/{uuid}/assign [post]
+func (h *Handler) Assign(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &deviceconfig.AssignReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Assign(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取设备配置分配
+// @Description 获取实验室的设备级和设备类型级配置分配
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.DeviceConfigAssignment}
+// @Router /v1/lab/device/config/assignment/{lab_uuid} [get]
+func (h *Handler) ListAssignments(ctx *gin.Context) {
+	labUUID, err := bindLab(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	datas, err := h.service.ListAssignments(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 删除设备配置分配
+// @Description 删除配置分配，设备上已应用的配置不变
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param uuid path string true "分配UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/device/config/assignment/{uuid} [delete]
+func (h *Handler) Unassign(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.Unassign(ctx, uri.UUID))
+}
+
+// @Summary 获取设备配置状态
+// @Description 获取各设备分配的配置版本、最近成功应用的版本和最近一次下发，并比较设备上报的配置与分配的配置
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.DeviceConfigStatus}
+// @Router /v1/lab/device/config/status/{lab_uuid} [get]
+func (h *Handler) Status(ctx *gin.Context) {
+	labUUID, err := bindLab(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	datas, err := h.service.Status(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 下发设备配置
+// @Description 通过设备指令队列下发设备当前分配的配置版本，device_names 为空时下发到所有分配了配置的设备
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Param req body deviceconfig.PushReq true "下发设备"
+// @Success 200 {object} common.Resp{data=[]model.DeviceConfigApply}
+// @Router /v1/lab/device/config/push/{lab_uuid} [post]
+func (h *Handler) Push(ctx *gin.Context) {
+	labUUID, err := bindLab(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	req := &deviceconfig.PushReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.Push(ctx, labUUID, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取设备配置下发历史
+// @Description 分页获取配置下发记录及其确认和应用状态，新的在前
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Param device_name query string false "设备名称"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[model.DeviceConfigApply]}
+// @Router /v1/lab/device/config/history/{lab_uuid} [get]
+func (h *Handler) History(ctx *gin.Context) {
+	labUUID, err := bindLab(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	req := &deviceconfig.HistoryReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.History(ctx, labUUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 边缘端上报设备配置
+// @Description edge 上报设备当前运行的配置，用于检测与分配配置的偏差
+// @Tags DeviceConfig
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "设备名称"
+// @Param req body deviceconfig.ReportReq true "设备配置"
+// @Success 200 {object} common.Resp{data=model.DeviceConfigReport}
+// @Router /v1/edge/device/{name}/config [put]
+func (h *Handler) Report(ctx *gin.Context) {
+	req := &deviceconfig.ReportReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Report(ctx, ctx.Param("name"), req)
+	common.Reply(ctx, err, data)
+}
+
+func bindLab(ctx *gin.Context) (uuid.UUID, error) {
+	var req LabRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		return uuid.NewNil(), code.ParamErr.WithErr(err)
+	}
+	labUUID, err := uuid.FromString(req.LabUUID)
+	if err != nil {
+		return uuid.NewNil(), code.ParamErr.WithMsg("invalid lab UUID")
+	}
+	return labUUID, nil
+}
@@ -0,0 +1,64 @@
+// Package devicelock provides HTTP handlers for device lock state APIs.
+package devicelock
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/schedule/lock"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
+)
+
+// Handler handles device lock HTTP requests
+type Handler struct {
+	locker lock.DeviceLocker
+}
+
+// NewHandler creates a new device lock handler
+func NewHandler() *Handler {
+	return &Handler{
+		locker: lock.New(redis.GetClient()),
+	}
+}
+
+// ListLocksRequest represents the request for listing device locks
+type ListLocksRequest struct {
+	LabUUID string `uri:"lab_uuid" binding:"required"`
+}
+
+// @Summary 获取设备锁状态
+// @Description 获取实验室内所有被占用设备的持有者和排队情况
+// @Tags DeviceLock
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]lock.State}
+// @Router /v1/lab/device/lock/{lab_uuid} [get]
+func (h *Handler) ListLocks(ctx *gin.Context) {
+	var req ListLocksRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	labUUID, err := uuid.FromString(req.LabUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+
+	states, err := h.locker.List(ctx, labUUID)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].DeviceName < states[j].DeviceName
+	})
+
+	common.ReplyOk(ctx, states)
+}
@@ -0,0 +1,96 @@
+// Package devicerisk provides HTTP handlers for device maintenance risk scores.
+package devicerisk
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/devicerisk"
+)
+
+// Handler handles device risk HTTP requests
+type Handler struct {
+	service *devicerisk.Service
+}
+
+// NewHandler creates a new device risk handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: devicerisk.New(),
+	}
+}
+
+// ListRequest represents the request for listing the latest device risk scores
+type ListRequest struct {
+	LabUUID string `uri:"lab_uuid" binding:"required"`
+}
+
+// @Summary 获取设备维护风险评分
+// @Description 获取实验室各设备最近一次的维护风险评分 (0-100)，由错误事件频率、动作失败率和动作时长漂移计算，风险高的在前
+// @Tags DeviceRisk
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.DeviceRiskScore}
+// @Router /v1/lab/device/risk/{lab_uuid} [get]
+func (h *Handler) List(ctx *gin.Context) {
+	var req ListRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	labUUID, err := uuid.FromString(req.LabUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+
+	datas, err := h.service.List(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// HistoryRequest represents the request for the risk score history of a device
+type HistoryRequest struct {
+	LabUUID    string `uri:"lab_uuid" binding:"required"`
+	DeviceUUID string `uri:"device_uuid" binding:"required"`
+}
+
+// @Summary 获取设备维护风险评分历史
+// @Description 按时间顺序返回设备的维护风险评分，默认最近 30 天，最长 366 天
+// @Tags DeviceRisk
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Param device_uuid path string true "设备UUID"
+// @Param start_time query string false "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)"
+// @Success 200 {object} common.Resp{data=[]model.DeviceRiskScore}
+// @Router /v1/lab/device/risk/{lab_uuid}/{device_uuid} [get]
+func (h *Handler) History(ctx *gin.Context) {
+	var uri HistoryRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &devicerisk.HistoryReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	labUUID, err := uuid.FromString(uri.LabUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+	deviceUUID, err := uuid.FromString(uri.DeviceUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid device UUID"))
+		return
+	}
+
+	datas, err := h.service.History(ctx, labUUID, deviceUUID, req)
+	common.Reply(ctx, err, datas)
+}
@@ -0,0 +1,159 @@
+// Package edgeevent provides HTTP handlers for edge event and action
+// reporting and the device API keys events are reported with.
+package edgeevent
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/edgeevent"
+)
+
+// Handler handles edge reporting HTTP requests
+type Handler struct {
+	service *edgeevent.Service
+}
+
+// NewHandler creates a new edge reporting handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: edgeevent.New(),
+	}
+}
+
+// @Summary 设备批量上报事件
+// @Description 使用设备密钥（Authorization: Device <key>）批量上报设备事件。请求体为 {"events": [...]}，或 Content-Type: application/x-ndjson 时每行一个事件；Content-Encoding: gzip 时按解压后的大小限制。每个事件单独校验，无效的事件被拒绝而其余事件照常写入，存在被拒绝的事件时响应状态码为 207。超过设备密钥的上报频率时返回 429 和 Retry-After
+// @Tags EdgeEvent
+// @Accept json,application/x-ndjson
+// @Produce json
+// @Security BearerAuth
+// @Param req body edgeevent.IngestReq true "事件，条目为 edgeevent.EventReq"
+// @Success 200 {object} common.Resp{data=edgeevent.IngestResp}
+// @Success 207 {object} common.Resp{data=edgeevent.IngestResp}
+// @Router /v1/edge/events [post]
+func (h *Handler) Ingest(ctx *gin.Context) {
+	retryAfter, err := h.service.Allow(ctx)
+	if err == code.DeviceKeyRateLimitErr {
+		errCode, e := common.LocalizeErr(ctx, err)
+		ctx.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		ctx.JSON(http.StatusTooManyRequests, &common.Resp{Code: errCode, Error: e})
+		return
+	}
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	events, err := edgeevent.Decode(ctx.Request.Body, ctx.ContentType(), ctx.GetHeader("Content-Encoding"), "events")
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	data, err := h.service.Ingest(ctx, events)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	replyBatch(ctx, data)
+}
+
+// @Summary 边缘端批量上报动作执行
+// @Description edge 批量上报动作的开始（status 为 running）和结束，开始和结束使用 edge 生成的同一个 uuid，设备必须已在实验室注册，按 workflow_execution_uuid 关联实验室的工作流执行。请求体为 {"actions": [...]}，或 Content-Type: application/x-ndjson 时每行一个动作，支持 Content-Encoding: gzip。新结束的动作在同一事务中计入执行的步骤计数，重复上报已结束的动作不会重复计数；无效的动作被拒绝而其余动作照常写入，存在被拒绝的动作时响应状态码为 207
+// @Tags EdgeEvent
+// @Accept json,application/x-ndjson
+// @Produce json
+// @Security BearerAuth
+// @Param req body edgeevent.ActionIngestReq true "动作，条目为 edgeevent.ActionReq"
+// @Success 200 {object} common.Resp{data=edgeevent.IngestResp}
+// @Success 207 {object} common.Resp{data=edgeevent.IngestResp}
+// @Router /v1/edge/actions [post]
+func (h *Handler) IngestActions(ctx *gin.Context) {
+	actions, err := edgeevent.Decode(ctx.Request.Body, ctx.ContentType(), ctx.GetHeader("Content-Encoding"), "actions")
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	data, err := h.service.IngestActions(ctx, actions)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	replyBatch(ctx, data)
+}
+
+// replyBatch 存在被拒绝的记录时使用 207 状态码
+func replyBatch(ctx *gin.Context, data *edgeevent.IngestResp) {
+	status := http.StatusOK
+	if data.Rejected > 0 {
+		status = http.StatusMultiStatus
+	}
+	ctx.JSON(status, &common.Resp{Code: code.Success, Data: data})
+}
+
+// @Summary 创建设备密钥
+// @Description 创建设备上报事件使用的密钥，指定设备名称时只能上报该设备的事件。密钥明文只在创建时返回，仅实验室管理员可以操作
+// @Tags EdgeEvent
+// @Accept json
+// @Produce json
+// @Param req body edgeevent.CreateKeyReq true "设备密钥"
+// @Success 200 {object} common.Resp{data=edgeevent.CreateKeyResp}
+// @Router /v1/lab/device-key [post]
+func (h *Handler) CreateKey(ctx *gin.Context) {
+	req := &edgeevent.CreateKeyReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.CreateKey(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取设备密钥列表
+// @Description 获取实验室的设备密钥，包括已撤销的密钥，仅实验室管理员可以操作
+// @Tags EdgeEvent
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.DeviceAPIKey}
+// @Router /v1/lab/device-key [get]
+func (h *Handler) ListKeys(ctx *gin.Context) {
+	req := &edgeevent.ListKeyReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.ListKeys(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 撤销设备密钥
+// @Description 撤销后使用该密钥的上报立即被拒绝，仅实验室管理员可以操作
+// @Tags EdgeEvent
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Param uuid path string true "设备密钥UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/device-key/{lab_uuid}/{uuid} [delete]
+func (h *Handler) RevokeKey(ctx *gin.Context) {
+	labUUID, err := uuid.FromString(ctx.Param("lab_uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+	keyUUID, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid device key UUID"))
+		return
+	}
+
+	common.Reply(ctx, h.service.RevokeKey(ctx, labUUID, keyUUID))
+}
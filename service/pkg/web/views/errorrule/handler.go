@@ -0,0 +1,126 @@
+// Package errorrule provides HTTP handlers for error classification rules.
+package errorrule
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/errorrule"
+)
+
+// Handler handles error rule HTTP requests
+type Handler struct {
+	service *errorrule.Service
+}
+
+// NewHandler creates a new error rule handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: errorrule.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one error rule by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 创建错误分类规则
+// @Description 失败或超时的执行与动作按优先级依次匹配实验室的规则，第一个匹配的规则决定错误分类，都不匹配时为 unclassified
+// @Tags ErrorRule
+// @Accept json
+// @Produce json
+// @Param req body errorrule.CreateReq true "规则"
+// @Success 200 {object} common.Resp{data=model.ErrorRule}
+// @Router /v1/lab/history/error-rule [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &errorrule.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取错误分类规则列表
+// @Description 按匹配顺序返回实验室的规则
+// @Tags ErrorRule
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.ErrorRule}
+// @Router /v1/lab/history/error-rule [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &errorrule.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 更新错误分类规则
+// @Description 只影响之后的失败记录，已分类的记录不会重新分类
+// @Tags ErrorRule
+// @Accept json
+// @Produce json
+// @Param uuid path string true "规则UUID"
+// @Param req body errorrule.UpdateReq true "规则"
+// @Success 200 {object} common.Resp{data=model.ErrorRule}
+// @Router /v1/lab/history/error-rule/{uuid} [put]
+func (h *Handler) Update(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &errorrule.UpdateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Update(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 删除错误分类规则
+// @Tags ErrorRule
+// @Accept json
+// @Produce json
+// @Param uuid path string true "规则UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/history/error-rule/{uuid} [delete]
+func (h *Handler) Delete(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.Delete(ctx, uri.UUID))
+}
+
+// @Summary 测试错误分类
+// @Description 用实验室当前的规则对一条错误信息分类，返回分类和匹配的规则
+// @Tags ErrorRule
+// @Accept json
+// @Produce json
+// @Param req body errorrule.TestReq true "错误信息"
+// @Success 200 {object} common.Resp{data=errorrule.TestResp}
+// @Router /v1/lab/history/error-rule/test [post]
+func (h *Handler) Test(ctx *gin.Context) {
+	req := &errorrule.TestReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Test(ctx, req)
+	common.Reply(ctx, err, data)
+}
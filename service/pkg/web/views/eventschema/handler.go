@@ -0,0 +1,71 @@
+// Package eventschema provides HTTP handlers for the event schema registry.
+package eventschema
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/eventschema"
+)
+
+const contentTypeSchema = "application/schema+json"
+
+// Handler handles event schema registry HTTP requests
+type Handler struct{}
+
+// NewHandler creates a new event schema handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// @Summary 获取事件 schema 列表
+// @Description 返回所有 webhook 和事件总线事件的类型、已发布版本、事件信封 schema 以及 schema 演进规则
+// @Tags EventSchema
+// @Produce json
+// @Success 200 {object} common.Resp{data=eventschema.CatalogResp}
+// @Router /v1/events/schemas [get]
+func (h *Handler) List(ctx *gin.Context) {
+	common.ReplyOk(ctx, eventschema.Catalog())
+}
+
+// @Summary 获取事件信封 schema
+// @Description 事件总线上的事件都包裹在信封中，webhook 以信封的 data 为请求体，其他字段放在 X-Studio-Event 请求头中
+// @Tags EventSchema
+// @Produce json
+// @Success 200 {object} object
+// @Router /v1/events/schemas/envelope [get]
+func (h *Handler) Envelope(ctx *gin.Context) {
+	h.reply(ctx, eventschema.Envelope())
+}
+
+// @Summary 获取事件 schema
+// @Description 返回事件类型某个版本的 JSON Schema，已发布的版本不会修改
+// @Tags EventSchema
+// @Produce json
+// @Param type path string true "事件类型，如 execution.completed"
+// @Param version path int true "schema 版本"
+// @Success 200 {object} object
+// @Router /v1/events/schemas/{type}/{version} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid version"))
+		return
+	}
+
+	schema, err := eventschema.Schema(eventschema.Type(ctx.Param("type")), version)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	h.reply(ctx, schema)
+}
+
+func (h *Handler) reply(ctx *gin.Context, schema json.RawMessage) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.Data(http.StatusOK, contentTypeSchema, schema)
+}
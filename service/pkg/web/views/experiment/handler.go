@@ -0,0 +1,181 @@
+// Package experiment provides HTTP handlers for experiments grouping workflow executions.
+package experiment
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/experiment"
+)
+
+// Handler handles experiment HTTP requests
+type Handler struct {
+	service *experiment.Service
+}
+
+// NewHandler creates a new experiment handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: experiment.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one experiment by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 创建实验
+// @Description 创建实验，实验室成员可以操作，同一实验室内名称不能重复；提交工作流时传入 experiment_uuid 即可关联到实验
+// @Tags Experiment
+// @Accept json
+// @Produce json
+// @Param req body experiment.CreateReq true "实验"
+// @Success 200 {object} common.Resp{data=experiment.ExperimentResp}
+// @Router /v1/lab/experiment [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &experiment.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 实验列表
+// @Description 获取实验室的实验列表及各实验的执行进度
+// @Tags Experiment
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param status query string false "实验状态 active|completed|archived"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[experiment.ExperimentResp]}
+// @Router /v1/lab/experiment [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &experiment.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取实验
+// @Description 获取实验及其执行进度
+// @Tags Experiment
+// @Accept json
+// @Produce json
+// @Param uuid path string true "实验UUID"
+// @Success 200 {object} common.Resp{data=experiment.ExperimentResp}
+// @Router /v1/lab/experiment/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 修改实验
+// @Description 修改实验名称、描述、状态或计划执行次数，归档的实验不再接受新的执行
+// @Tags Experiment
+// @Accept json
+// @Produce json
+// @Param uuid path string true "实验UUID"
+// @Param req body experiment.UpdateReq true "修改内容"
+// @Success 200 {object} common.Resp{data=experiment.ExperimentResp}
+// @Router /v1/lab/experiment/{uuid} [patch]
+func (h *Handler) Update(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &experiment.UpdateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Update(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 关联执行到实验
+// @Description 将实验室的工作流执行关联到实验，已关联到其他实验的执行会被移过来
+// @Tags Experiment
+// @Accept json
+// @Produce json
+// @Param uuid path string true "实验UUID"
+// @Param req body experiment.ExecutionsReq true "执行UUID"
+// @Success 200 {object} common.Resp{data=experiment.ExecutionsResp}
+// @Router /v1/lab/experiment/{uuid}/execution [post]
+func (h *Handler) AddExecutions(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &experiment.ExecutionsReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.AddExecutions(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 从实验移出执行
+// @Description 将工作流执行移出实验
+// @Tags Experiment
+// @Accept json
+// @Produce json
+// @Param uuid path string true "实验UUID"
+// @Param req body experiment.ExecutionsReq true "执行UUID"
+// @Success 200 {object} common.Resp{data=experiment.ExecutionsResp}
+// @Router /v1/lab/experiment/{uuid}/execution [delete]
+func (h *Handler) RemoveExecutions(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &experiment.ExecutionsReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.RemoveExecutions(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 实验统计
+// @Description 获取实验的执行进度、成功率、耗时及按工作流的统计
+// @Tags Experiment
+// @Accept json
+// @Produce json
+// @Param uuid path string true "实验UUID"
+// @Success 200 {object} common.Resp{data=model.ExperimentStats}
+// @Router /v1/lab/experiment/{uuid}/stats [get]
+func (h *Handler) Stats(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Stats(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
@@ -0,0 +1,193 @@
+// Package file provides HTTP handlers for resumable result file uploads and downloads.
+package file
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/file"
+)
+
+// UploadOffsetHeader 分片上传偏移量请求/响应头
+const UploadOffsetHeader = "Upload-Offset"
+
+// Handler handles result file HTTP requests
+type Handler struct {
+	service *file.Service
+}
+
+// NewHandler creates a new result file handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: file.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one file by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 创建文件上传
+// @Description 创建可续传的上传任务，之后通过 PATCH 按顺序上传分片。用户上传时 lab_uuid 必填，edge 上传使用所属实验室；可关联产生文件的动作执行记录
+// @Tags File
+// @Accept json
+// @Produce json
+// @Param req body file.CreateReq true "上传请求"
+// @Success 200 {object} common.Resp{data=model.ResultFile}
+// @Router /v1/lab/file [post]
+// @Router /v1/edge/file [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &file.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 上传文件分片
+// @Description 请求体为分片原始内容，Upload-Offset 头必须等于已接收的字节数，否则返回当前偏移量供客户端续传。最后一个分片写入后校验内容类型、校验和并做病毒扫描
+// @Tags File
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Param uuid path string true "文件UUID"
+// @Param Upload-Offset header int true "分片起始偏移量"
+// @Success 200 {object} common.Resp{data=model.ResultFile}
+// @Router /v1/lab/file/{uuid} [patch]
+// @Router /v1/edge/file/{uuid} [patch]
+func (h *Handler) WriteChunk(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	offset, err := strconv.ParseInt(ctx.GetHeader(UploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid Upload-Offset header"))
+		return
+	}
+
+	data, err := h.service.WriteChunk(ctx, uri.UUID, offset, ctx.Request.ContentLength, ctx.Request.Body)
+	if data != nil {
+		ctx.Header(UploadOffsetHeader, strconv.FormatInt(data.Offset, 10))
+	}
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取文件及上传进度
+// @Description offset 为已接收的字节数，续传时从该位置开始
+// @Tags File
+// @Accept json
+// @Produce json
+// @Param uuid path string true "文件UUID"
+// @Success 200 {object} common.Resp{data=model.ResultFile}
+// @Router /v1/lab/file/{uuid} [get]
+// @Router /v1/edge/file/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	if data != nil {
+		ctx.Header(UploadOffsetHeader, strconv.FormatInt(data.Offset, 10))
+	}
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取文件列表
+// @Description 获取实验室已上传完成的文件，可按动作执行记录过滤
+// @Tags File
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param action_execution_uuid query string false "动作执行记录UUID"
+// @Success 200 {object} common.Resp{data=[]model.ResultFile}
+// @Router /v1/lab/file [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &file.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 删除文件
+// @Description 删除文件，或取消未完成的上传
+// @Tags File
+// @Accept json
+// @Produce json
+// @Param uuid path string true "文件UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/file/{uuid} [delete]
+// @Router /v1/edge/file/{uuid} [delete]
+func (h *Handler) Delete(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.Delete(ctx, uri.UUID))
+}
+
+// @Summary 生成签名下载链接
+// @Description 生成带有效期的下载链接，链接无需登录即可访问
+// @Tags File
+// @Accept json
+// @Produce json
+// @Param uuid path string true "文件UUID"
+// @Success 200 {object} common.Resp{data=file.URLResp}
+// @Router /v1/lab/file/{uuid}/url [post]
+func (h *Handler) DownloadURL(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.DownloadURL(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 下载文件
+// @Description 使用签名下载链接下载文件内容
+// @Tags File
+// @Produce octet-stream
+// @Param token query string true "下载令牌"
+// @Success 200 {file} binary
+// @Router /v1/file/download [get]
+func (h *Handler) Download(ctx *gin.Context) {
+	data, r, err := h.service.Open(ctx, ctx.Query("token"))
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	defer r.Close()
+
+	// 大文件下载时间可能超过 server 的写超时
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	contentType := data.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.DataFromReader(http.StatusOK, data.Size, contentType, r, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": data.FileName}),
+		"Cache-Control":       "private, no-store",
+	})
+}
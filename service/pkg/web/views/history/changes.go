@@ -0,0 +1,172 @@
+package history
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 500
+	maxChangesWait      = 25 * time.Second
+	changesPollInterval = time.Second
+	// 只返回更新时间早于该时长的记录，更新时间在应用中生成，提交较晚的写入不会被水位跳过
+	changesSettle = 2 * time.Second
+)
+
+// 变更是新建还是更新的记录
+const (
+	changeCreated = "created"
+	changeUpdated = "updated"
+)
+
+// ListChangesRequest represents the request for syncing history changes
+type ListChangesRequest struct {
+	LabID int64  `form:"lab_id" binding:"required"`
+	Since string `form:"since"` // 上次响应的 watermark，不传时从头同步
+	Limit int    `form:"limit,default=100"`
+	Wait  int    `form:"wait"` // 没有变更时最长等待秒数
+}
+
+// HistoryChangeResponse is a history record created or updated after the
+// watermark, the field named by kind holds the record
+type HistoryChangeResponse struct {
+	Kind      model.HistoryChangeKind    `json:"kind"`
+	Op        string                     `json:"op"` // created, updated
+	UpdatedAt time.Time                  `json:"updated_at"`
+	Execution *WorkflowExecutionResponse `json:"execution,omitempty"`
+	Action    *ActionExecutionResponse   `json:"action,omitempty"`
+	Event     *DeviceEventResponse       `json:"event,omitempty"`
+}
+
+// ListChangesResponse represents the history changes since a watermark
+type ListChangesResponse struct {
+	Changes   []HistoryChangeResponse `json:"changes"`
+	Watermark string                  `json:"watermark"` // 下次请求的 since，没有变更时与请求的 since 相同
+	HasMore   bool                    `json:"has_more"`  // 还有变更未返回，应立即再次请求
+}
+
+// @Summary 增量同步历史记录
+// @Description 按水位返回之后新建或更新的工作流执行、动作执行和设备事件，按 (updated_at, kind, id) 排序。没有变更时最多等待 wait 秒（最大 25 秒）后返回空列表
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_id query int true "实验室ID" example(1)
+// @Param since query string false "上次响应的 watermark，不传时从头同步"
+// @Param limit query int false "最多返回的变更数，最大 500" default(100)
+// @Param wait query int false "没有变更时最长等待秒数" default(0)
+// @Success 200 {object} common.Resp{data=ListChangesResponse}
+// @Router /v1/lab/history/changes [get]
+func (h *Handler) ListChanges(ctx *gin.Context) {
+	var req ListChangesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	if req.Wait < 0 {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid wait"))
+		return
+	}
+	if req.Limit < 1 || req.Limit > maxChangesLimit {
+		req.Limit = defaultChangesLimit
+	}
+	var since *model.HistoryWatermark
+	if req.Since != "" {
+		var err error
+		if since, err = model.ParseHistoryWatermark(req.Since); err != nil {
+			common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+			return
+		}
+	}
+
+	changes, err := h.waitChanges(ctx, req.LabID, since, req.Limit+1, min(time.Duration(req.Wait)*time.Second, maxChangesWait))
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	resp := &ListChangesResponse{Watermark: req.Since}
+	if len(changes) > req.Limit {
+		changes, resp.HasMore = changes[:req.Limit], true
+	}
+	if resp.Changes, err = h.changeResponses(ctx, changes); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	if len(changes) > 0 {
+		resp.Watermark = changes[len(changes)-1].Watermark().Encode()
+	}
+	common.ReplyOk(ctx, resp)
+}
+
+// waitChanges polls the changes until there are some or wait has passed
+func (h *Handler) waitChanges(ctx *gin.Context, labID int64, since *model.HistoryWatermark, limit int, wait time.Duration) ([]*model.HistoryChange, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		changes, err := h.repo.ListChanges(ctx, labID, since, time.Now().Add(-changesSettle), limit)
+		if err != nil || len(changes) > 0 {
+			return changes, err
+		}
+		if !time.Now().Add(changesPollInterval).Before(deadline) {
+			return changes, nil
+		}
+		select {
+		case <-ctx.Request.Context().Done():
+			return changes, nil
+		case <-time.After(changesPollInterval):
+		}
+	}
+}
+
+// changeResponses converts the changes in the v1 response shape of each kind
+func (h *Handler) changeResponses(ctx *gin.Context, changes []*model.HistoryChange) ([]HistoryChangeResponse, error) {
+	var executions []*model.WorkflowExecutionHistory
+	var actions []*model.ActionExecutionHistory
+	for _, c := range changes {
+		switch {
+		case c.Execution != nil:
+			executions = append(executions, c.Execution)
+		case c.Action != nil:
+			actions = append(actions, c.Action)
+		}
+	}
+	pinned, err := h.pinnedIDs(ctx, auth.GetCurrentUser(ctx), executions)
+	if err != nil {
+		return nil, err
+	}
+	actionResps, err := h.actionResponses(ctx, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	resps := make([]HistoryChangeResponse, 0, len(changes))
+	for _, c := range changes {
+		w := c.Watermark()
+		resp := HistoryChangeResponse{Kind: c.Kind, Op: changeUpdated, UpdatedAt: w.UpdatedAt}
+		if c.Created {
+			resp.Op = changeCreated
+		}
+		switch {
+		case c.Execution != nil:
+			execution := newWorkflowExecutionResponse(c.Execution, pinned[c.Execution.ID])
+			resp.Execution = &execution
+		case c.Action != nil:
+			resp.Action = &actionResps[0]
+			actionResps = actionResps[1:]
+		case c.Event != nil:
+			resp.Event = &DeviceEventResponse{
+				UUID:       c.Event.UUID,
+				DeviceUUID: c.Event.DeviceUUID,
+				EventType:  c.Event.EventType,
+				EventData:  c.Event.EventData,
+				Timestamp:  c.Event.Timestamp,
+			}
+		}
+		resps = append(resps, resp)
+	}
+	return resps, nil
+}
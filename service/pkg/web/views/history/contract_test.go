@@ -0,0 +1,321 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 契约测试按 docs/swagger.json 中的参数示例请求历史接口，并按文档中的响应结构校验返回值。
+// 修改处理函数的请求或响应后需要更新注释并重新生成文档：
+//   swag init -g main.go --parseDependency
+
+const specPath = "../../../../docs/swagger.json"
+
+// swaggerSpec 契约测试用到的 swagger 2.0 文档字段
+type swaggerSpec struct {
+	BasePath    string                                  `json:"basePath"`
+	Paths       map[string]map[string]*swaggerOperation `json:"paths"`
+	Definitions map[string]map[string]any               `json:"definitions"`
+}
+
+type swaggerOperation struct {
+	Parameters []*swaggerParameter         `json:"parameters"`
+	Responses  map[string]*swaggerResponse `json:"responses"`
+}
+
+type swaggerParameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Example  any    `json:"example"`
+}
+
+type swaggerResponse struct {
+	Schema map[string]any `json:"schema"`
+}
+
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+func loadSpec(t *testing.T) *swaggerSpec {
+	t.Helper()
+	data, err := os.ReadFile(specPath)
+	require.NoError(t, err)
+	spec := &swaggerSpec{}
+	require.NoError(t, json.Unmarshal(data, spec))
+	return spec
+}
+
+// contractRoutes 只读取历史仓储的接口，使用内存仓储即可完整执行
+func contractRoutes(h *Handler) map[string]gin.HandlerFunc {
+	return map[string]gin.HandlerFunc{
+		"/v1/lab/history/workflow":                  h.ListWorkflowExecutions,
+		"/v1/lab/history/device":                    h.ListDeviceEvents,
+		"/v1/lab/{lab_id}/stats":                    h.GetLabStats,
+		"/v1/lab/{lab_id}/stats/slowest-steps":      h.ListSlowestSteps,
+		"/v1/lab/{lab_id}/stats/heatmap":            h.GetActivityHeatmap,
+		"/v1/lab/{lab_id}/stats/device-utilization": h.GetDeviceUtilization,
+		"/v1/lab/{lab_id}/stats/failures":           h.GetFailureStats,
+	}
+}
+
+// seedContract 写入示例实验室 1 的记录，列表和统计接口返回非空数据
+func seedContract(t *testing.T, repo *testutil.FakeHistoryRepo) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Hour)
+	for i, status := range []model.ExecutionStatus{model.ExecutionStatusSuccess, model.ExecutionStatusFailed, model.ExecutionStatusRunning} {
+		exec := testutil.NewExecution(1, status, now.Add(-time.Duration(i+1)*time.Hour))
+		require.NoError(t, repo.CreateWorkflowExecution(ctx, exec))
+		require.NoError(t, repo.CreateActionExecution(ctx, testutil.NewAction(exec, 10, status, exec.StartedAt)))
+	}
+	event := testutil.NewDeviceEvent(1, 10, model.DeviceEventStatusChange, now.Add(-time.Hour))
+	event.EventData = []byte(`{"status":"busy"}`)
+	require.NoError(t, repo.CreateDeviceEvent(ctx, event))
+
+	change := 1.5
+	repo.SlowestSteps = []*model.StepDurationStats{{ActionName: "transfer", DeviceName: "device-10", Executions: 3, AvgDurationMs: 1200}}
+	repo.Heatmap = []*model.HeatmapCell{{Weekday: 1, Hour: 9, Count: 2}}
+	repo.Utilization = &model.UtilizationReport{
+		LabID:   1,
+		Devices: []*model.DeviceUtilization{{Rank: 1, DeviceUUID: uuid.NewV4(), DeviceName: "device-10", UtilizationChange: &change}},
+		Trend:   []*model.UtilizationPoint{{}},
+	}
+	repo.FailureStats = &model.FailureStats{LabID: 1, Categories: []*model.FailureCategoryCount{{}}}
+}
+
+func TestContract(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	spec := loadSpec(t)
+	handler, repo := newTestHandler()
+	seedContract(t, repo)
+
+	router := gin.New()
+	routes := contractRoutes(handler)
+	for path, fn := range routes {
+		op := spec.Paths[path]["get"]
+		require.NotNil(t, op, "GET %s is not documented", path)
+		router.GET(spec.BasePath+pathParam.ReplaceAllString(path, ":$1"), fn)
+	}
+
+	paths := make([]string, 0, len(routes))
+	for path := range routes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			op := spec.Paths[path]["get"]
+			target := exampleURL(t, spec.BasePath+path, op.Parameters)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			require.EqualValues(t, 0, body["code"], w.Body.String())
+			ok := op.Responses["200"]
+			require.NotNil(t, ok, "200 response of GET %s is not documented", path)
+			assert.Empty(t, validateSchema(spec, ok.Schema, body, "$"), w.Body.String())
+		})
+	}
+}
+
+// exampleURL 使用参数示例填充路径和查询参数，必填参数必须有示例
+func exampleURL(t *testing.T, path string, params []*swaggerParameter) string {
+	t.Helper()
+	query := url.Values{}
+	for _, p := range params {
+		if p.Example == nil {
+			require.False(t, p.Required, "required parameter %s of %s has no example", p.Name, path)
+			continue
+		}
+		value := fmt.Sprint(p.Example)
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(value))
+		case "query":
+			query.Set(p.Name, value)
+		}
+	}
+	require.NotContains(t, path, "{", "path parameter of %s has no example", path)
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// validateSchema returns the differences between value and the swagger schema.
+// Fields missing from the schema are reported so that undocumented response
+// fields fail the test; null is accepted for fields that are not required.
+func validateSchema(spec *swaggerSpec, schema map[string]any, value any, at string) []string {
+	schema = resolve(spec, schema)
+	if len(schema) == 0 || value == nil {
+		return nil
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return []string{fmt.Sprintf("%s: %v is not one of %v", at, value, enum)}
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expect object, got %T", at, value)}
+		}
+		return validateObject(spec, schema, obj, at)
+	case "array":
+		list, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expect array, got %T", at, value)}
+		}
+		items, _ := schema["items"].(map[string]any)
+		errs := make([]string, 0)
+		for i, item := range list {
+			errs = append(errs, validateSchema(spec, items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+		return errs
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expect string, got %T", at, value)}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				return []string{fmt.Sprintf("%s: %q is not a date-time", at, s)}
+			}
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return []string{fmt.Sprintf("%s: expect integer, got %v", at, value)}
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return []string{fmt.Sprintf("%s: expect number, got %T", at, value)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s: expect boolean, got %T", at, value)}
+		}
+	}
+	return nil
+}
+
+func validateObject(spec *swaggerSpec, schema map[string]any, obj map[string]any, at string) []string {
+	errs := make([]string, 0)
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := obj[name.(string)]; !ok {
+			errs = append(errs, fmt.Sprintf("%s: missing required field %s", at, name))
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	additional, _ := schema["additionalProperties"].(map[string]any)
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := at + "." + key
+		if prop, ok := properties[key].(map[string]any); ok {
+			errs = append(errs, validateSchema(spec, prop, obj[key], field)...)
+			continue
+		}
+		switch {
+		case additional != nil:
+			errs = append(errs, validateSchema(spec, additional, obj[key], field)...)
+		case properties != nil:
+			errs = append(errs, fmt.Sprintf("%s: field is not documented", field))
+		}
+	}
+	return errs
+}
+
+// resolve follows $ref and merges allOf into a single schema, later members
+// override the properties of earlier ones like common.Resp{data=T}
+func resolve(spec *swaggerSpec, schema map[string]any) map[string]any {
+	if ref, ok := schema["$ref"].(string); ok {
+		return resolve(spec, spec.Definitions[strings.TrimPrefix(ref, "#/definitions/")])
+	}
+	all, ok := schema["allOf"].([]any)
+	if !ok {
+		return schema
+	}
+	merged := map[string]any{"type": "object"}
+	properties := map[string]any{}
+	required := []any{}
+	for _, member := range all {
+		m := resolve(spec, member.(map[string]any))
+		if t, ok := m["type"]; ok && t != "object" {
+			return m
+		}
+		if props, ok := m["properties"].(map[string]any); ok {
+			for k, v := range props {
+				properties[k] = v
+			}
+		}
+		if req, ok := m["required"].([]any); ok {
+			required = append(required, req...)
+		}
+	}
+	merged["properties"] = properties
+	merged["required"] = required
+	return merged
+}
+
+func TestValidateSchema(t *testing.T) {
+	spec := &swaggerSpec{Definitions: map[string]map[string]any{
+		"item": {
+			"type":     "object",
+			"required": []any{"id"},
+			"properties": map[string]any{
+				"id":      map[string]any{"type": "integer"},
+				"status":  map[string]any{"type": "string", "enum": []any{"ok", "failed"}},
+				"created": map[string]any{"type": "string", "format": "date-time"},
+				"labels":  map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+			},
+		},
+	}}
+	schema := map[string]any{"allOf": []any{
+		map[string]any{"type": "object", "properties": map[string]any{"code": map[string]any{"type": "integer"}, "data": map[string]any{}}},
+		map[string]any{"type": "object", "properties": map[string]any{"data": map[string]any{
+			"type": "array", "items": map[string]any{"$ref": "#/definitions/item"},
+		}}},
+	}}
+	decode := func(s string) any {
+		var v any
+		require.NoError(t, json.Unmarshal([]byte(s), &v))
+		return v
+	}
+
+	assert.Empty(t, validateSchema(spec, schema, decode(`{"code":0,"data":[{"id":1,"status":"ok","created":null,"labels":{"a":"b"}}]}`), "$"))
+	assert.Equal(t, []string{
+		"$.data[0]: missing required field id",
+		"$.data[0].created: \"yesterday\" is not a date-time",
+		"$.data[0].extra: field is not documented",
+		"$.data[0].labels.a: expect string, got float64",
+		"$.data[0].status: unknown is not one of [ok failed]",
+		"$.data[1].id: expect integer, got 1.5",
+	}, validateSchema(spec, schema, decode(`{"code":0,"data":[
+		{"status":"unknown","created":"yesterday","labels":{"a":1},"extra":true},
+		{"id":1.5}
+	]}`), "$"))
+}
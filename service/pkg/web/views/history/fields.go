@@ -0,0 +1,179 @@
+package history
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/apiversion"
+)
+
+// fieldColumns maps the fields of a list item to the columns they are built
+// from, fields built from other data have no columns
+type fieldColumns map[string][]string
+
+var workflowFieldsV1 = fieldColumns{
+	"uuid":              {"uuid"},
+	"workflow_uuid":     {"workflow_uuid"},
+	"workflow_name":     {"workflow_name"},
+	"batch_id":          {"batch_id"},
+	"experiment_id":     {"experiment_id"},
+	"status":            {"status"},
+	"steps_total":       {"steps_total"},
+	"steps_completed":   {"steps_completed"},
+	"steps_failed":      {"steps_failed"},
+	"duration_ms":       {"duration_ms"},
+	"error_message":     {"error_message"},
+	"error_category":    {"error_category"},
+	"started_at":        {"started_at"},
+	"completed_at":      {"completed_at"},
+	"labels":            {"labels"},
+	"pinned":            nil,
+	"sla_target_ms":     {"sla_target_ms"},
+	"sla_breached":      {"sla_breached"},
+	"actions":           nil,
+	"actions_truncated": nil,
+}
+
+var workflowFieldsV2 = fieldColumns{
+	"uuid":              {"uuid"},
+	"workflow":          {"workflow_uuid", "workflow_name"},
+	"batch_id":          {"batch_id"},
+	"experiment_id":     {"experiment_id"},
+	"status":            {"status"},
+	"steps":             {"steps_total", "steps_completed", "steps_failed"},
+	"started_at":        {"started_at"},
+	"completed_at":      {"completed_at"},
+	"duration_ms":       {"duration_ms"},
+	"error":             {"error_message", "error_category"},
+	"labels":            {"labels"},
+	"pinned":            nil,
+	"sla":               {"sla_target_ms", "sla_breached"},
+	"actions":           nil,
+	"actions_truncated": nil,
+}
+
+var deviceEventFieldsV1 = fieldColumns{
+	"uuid":        {"uuid"},
+	"device_uuid": {"device_uuid"},
+	"event_type":  {"event_type"},
+	"event_data":  {"event_data"},
+	"timestamp":   {"timestamp"},
+}
+
+var deviceEventFieldsV2 = fieldColumns{
+	"uuid":        {"uuid"},
+	"device_uuid": {"device_uuid"},
+	"type":        {"event_type"},
+	"data":        {"event_data"},
+	"timestamp":   {"timestamp"},
+}
+
+// 无论请求哪些字段都查询的列，用于排序、游标翻页、置顶和展开动作
+var (
+	workflowKeyColumns    = []string{"id", "started_at"}
+	deviceEventKeyColumns = []string{"id", "timestamp"}
+)
+
+// workflowFields returns the fields of workflow execution items in the API version
+func workflowFields(version apiversion.Version) fieldColumns {
+	if version == apiversion.V2 {
+		return workflowFieldsV2
+	}
+	return workflowFieldsV1
+}
+
+// deviceEventFields returns the fields of device event items in the API version
+func deviceEventFields(version apiversion.Version) fieldColumns {
+	if version == apiversion.V2 {
+		return deviceEventFieldsV2
+	}
+	return deviceEventFieldsV1
+}
+
+// fieldSet is the sparse fieldset requested by fields=, nil keeps every field
+type fieldSet map[string]bool
+
+// parseFields validates the requested fields, repeated or separated by commas
+func parseFields(values []string, fields fieldColumns) (fieldSet, error) {
+	var set fieldSet
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if _, ok := fields[field]; !ok {
+				return nil, code.ParamErr.WithMsgf("unknown field: %s", field)
+			}
+			if set == nil {
+				set = fieldSet{}
+			}
+			set[field] = true
+		}
+	}
+	return set, nil
+}
+
+// columns returns the key columns and the columns of the fields in the set,
+// columns only needed by other fields, such as the JSON payloads, are not read
+func (s fieldSet) columns(fields fieldColumns, key []string) []string {
+	seen := make(map[string]bool)
+	var extra []string
+	for field, columns := range fields {
+		if s != nil && !s[field] {
+			continue
+		}
+		for _, column := range columns {
+			if !seen[column] {
+				seen[column] = true
+				extra = append(extra, column)
+			}
+		}
+	}
+	sort.Strings(extra)
+
+	ret := append([]string{}, key...)
+	for _, column := range extra {
+		if !slices.Contains(key, column) {
+			ret = append(ret, column)
+		}
+	}
+	return ret
+}
+
+// sparse keeps only the fields of the set in each item, a nil set returns the
+// items as they are
+func sparse[T any](items []T, s fieldSet) any {
+	if s == nil {
+		return items
+	}
+	return sparseItems(items, s)
+}
+
+// sparseItems keeps only the fields of the set in each item
+func sparseItems[T any](items []T, s fieldSet) []any {
+	ret := make([]any, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, sparseItem(item, s))
+	}
+	return ret
+}
+
+// sparseItem keeps only the fields of the set in the item
+func sparseItem(item any, s fieldSet) any {
+	if s == nil {
+		return item
+	}
+	data, _ := json.Marshal(item)
+	fields := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(data, &fields)
+	for field := range fields {
+		if !s[field] {
+			delete(fields, field)
+		}
+	}
+	return fields
+}
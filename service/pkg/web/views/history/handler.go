@@ -3,37 +3,111 @@ package history
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/internal/config"
 	"github.com/scienceol/studio/service/pkg/common"
 	"github.com/scienceol/studio/service/pkg/common/code"
 	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/assistant"
+	"github.com/scienceol/studio/service/pkg/core/estimate"
+	"github.com/scienceol/studio/service/pkg/core/historycompact"
+	"github.com/scienceol/studio/service/pkg/core/savedview"
+	"github.com/scienceol/studio/service/pkg/core/topology"
+	"github.com/scienceol/studio/service/pkg/middleware/apiversion"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/middleware/redis"
 	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/repo/approval"
+	"github.com/scienceol/studio/service/pkg/repo/dependency"
+	"github.com/scienceol/studio/service/pkg/repo/environment"
 	"github.com/scienceol/studio/service/pkg/repo/history"
+	"github.com/scienceol/studio/service/pkg/repo/inventory"
+	"gorm.io/datatypes"
 )
 
+const (
+	defaultOrgStatsPeriod = 30 * 24 * time.Hour
+	maxOrgStatsPeriod     = 366 * 24 * time.Hour
+	labelSuggestLimit     = 100
+	// 列表展开动作时每个执行最多返回的动作数
+	maxIncludedActions = 200
+)
+
+// includeActions 列表响应中展开执行的动作
+const includeActions = "actions"
+
 // Handler handles history-related HTTP requests
 type Handler struct {
-	repo history.HistoryRepo
+	repo          history.HistoryRepo
+	approvalRepo  approval.ApprovalRepo
+	depRepo       dependency.DependencyRepo
+	inventoryRepo inventory.InventoryRepo
+	labStore      repo.LaboratoryRepo
+	savedViews    *savedview.Service
+	assistant     *assistant.Service
+	estimator     *estimate.Service
+	topology      *topology.Service
+	totals        *totalCache
+	compactor     *historycompact.Service
+	streaming     func() config.ListStreamingConfig
 }
 
 // NewHandler creates a new history handler
 func NewHandler() *Handler {
+	return NewHandlerWithRepo(history.New())
+}
+
+// NewHandlerWithRepo creates a history handler reading history records from the given repository
+func NewHandlerWithRepo(historyRepo history.HistoryRepo) *Handler {
 	return &Handler{
-		repo: history.New(),
+		repo:          historyRepo,
+		approvalRepo:  approval.New(),
+		depRepo:       dependency.New(),
+		inventoryRepo: inventory.New(),
+		labStore:      environment.New(),
+		savedViews:    savedview.New(),
+		assistant:     assistant.New(),
+		estimator:     estimate.New(),
+		topology:      topology.New(),
+		totals:        &totalCache{client: redis.GetClient()},
+		compactor:     historycompact.New(),
+		streaming: func() config.ListStreamingConfig {
+			return config.GetStudioConfig().ListStreaming
+		},
 	}
 }
 
 // ListWorkflowExecutionsRequest represents the request for listing workflow executions
 type ListWorkflowExecutionsRequest struct {
-	LabID      int64  `form:"lab_id" binding:"required"`
-	WorkflowID *int64 `form:"workflow_id"`
-	Status     string `form:"status"`
-	StartTime  string `form:"start_time"`
-	EndTime    string `form:"end_time"`
-	Page       int    `form:"page,default=1"`
-	PageSize   int    `form:"page_size,default=20"`
+	LabID      int64                 `form:"lab_id" binding:"required"`
+	WorkflowID *int64                `form:"workflow_id"`
+	DeviceID   *int64                `form:"device_id"` // 有动作在该设备上运行的执行
+	Status     model.ExecutionStatus `form:"status" binding:"omitempty,enum"`
+	StartTime  string                `form:"start_time" binding:"omitempty,rfc3339"`
+	EndTime    string                `form:"end_time" binding:"omitempty,rfc3339"`
+	Page       int                   `form:"page,default=1" binding:"page"`
+	PageSize   int                   `form:"page_size,default=20" binding:"page_size=100"`
+	ViewID     string                `form:"view_id" binding:"omitempty,uuid"` // 保存的视图，请求中显式传入的过滤条件优先
+	Labels     []string              `form:"label"`                            // key:value，可重复，需全部匹配
+	Pinned     bool                  `form:"pinned"`                           // 只返回当前用户置顶的执行
+	Breached   *bool                 `form:"breached"`                         // 按是否违反 SLA 过滤
+	// 为 false 时不统计总数，只返回 has_more；不传时总数很大的实验室也不统计
+	IncludeTotal *bool `form:"include_total"`
+	// 展开的关联数据，目前支持 actions，可重复或以逗号分隔
+	Include []string `form:"include"`
+	// 房间、工作台或设备组，有动作在其下任一设备上运行的执行
+	TopologyNode string `form:"topology_node" binding:"omitempty,uuid"`
+	// 关联到该实验的执行
+	Experiment string `form:"experiment" binding:"omitempty,uuid"`
+	// 上一页返回的 next_cursor，按第一页请求时的快照继续翻页，设置时忽略 page
+	Cursor string `form:"cursor"`
+	// 只返回这些字段，可重复或以逗号分隔，不传时返回全部字段
+	Fields []string `form:"fields"`
 }
 
 // WorkflowExecutionResponse represents a workflow execution in response
@@ -41,51 +115,154 @@ type WorkflowExecutionResponse struct {
 	UUID           uuid.UUID              `json:"uuid"`
 	WorkflowUUID   uuid.UUID              `json:"workflow_uuid"`
 	WorkflowName   string                 `json:"workflow_name"`
+	BatchID        *int64                 `json:"batch_id,omitempty"`
+	ExperimentID   *int64                 `json:"experiment_id,omitempty"`
 	Status         model.ExecutionStatus  `json:"status"`
 	StepsTotal     int                    `json:"steps_total"`
 	StepsCompleted int                    `json:"steps_completed"`
 	StepsFailed    int                    `json:"steps_failed"`
 	DurationMs     int64                  `json:"duration_ms"`
 	ErrorMessage   *string                `json:"error_message,omitempty"`
+	ErrorCategory  string                 `json:"error_category,omitempty"`
 	StartedAt      time.Time              `json:"started_at"`
 	CompletedAt    *time.Time             `json:"completed_at,omitempty"`
+	Labels         map[string]string      `json:"labels"`
+	Pinned         bool                   `json:"pinned"`        // 当前用户是否置顶
+	SLATargetMs    int64                  `json:"sla_target_ms"` // 0 表示未设置 SLA
+	SLABreached    *bool                  `json:"sla_breached"`  // 结束时计算
 }
 
-// ListResponse represents a paginated list response
-type ListResponse struct {
-	Items      interface{} `json:"items"`
-	Total      int64       `json:"total"`
-	Page       int         `json:"page"`
-	PageSize   int         `json:"page_size"`
-	TotalPages int         `json:"total_pages"`
+// WorkflowExecutionListItem represents a workflow execution in list responses,
+// with its actions when requested by include=actions
+type WorkflowExecutionListItem struct {
+	WorkflowExecutionResponse
+	Actions          []ActionExecutionResponse `json:"actions,omitempty"`
+	ActionsTruncated bool                      `json:"actions_truncated,omitempty"` // 动作数超过上限，只返回前面的部分
 }
 
 // @Summary 获取工作流执行历史列表
-// @Description 获取实验室的工作流执行历史记录
+// @Description 获取实验室的工作流执行历史记录。v2（/v2 前缀或 Accept: application/vnd.studio.v2+json）返回 ListResponseV2，条目为 WorkflowExecutionV2。Accept: application/x-ndjson 时不分页，按列表顺序逐行流式返回条目（不支持 include），最多返回配置的行数，最后一行为 {"end": StreamEnd}
 // @Tags History
 // @Accept json
-// @Produce json
-// @Param lab_id query int true "实验室ID"
+// @Produce json,application/x-ndjson
+// @Param lab_id query int true "实验室ID" example(1)
 // @Param workflow_id query int false "工作流ID (可选)"
-// @Param status query string false "状态过滤 (pending, running, success, failed, cancelled)"
+// @Param device_id query int false "设备ID，只返回有动作在该设备上运行的执行"
+// @Param topology_node query string false "拓扑节点UUID，只返回有动作在该房间、工作台或设备组下任一设备上运行的执行"
+// @Param experiment query string false "实验UUID，只返回关联到该实验的执行"
+// @Param status query string false "状态过滤 (pending, running, success, failed, cancelled, timeout)，其他取值返回参数错误"
 // @Param start_time query string false "开始时间 (RFC3339格式)"
 // @Param end_time query string false "结束时间 (RFC3339格式)"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
-// @Success 200 {object} common.Resp{data=ListResponse}
+// @Param view_id query string false "保存的视图UUID，展开为视图的过滤条件"
+// @Param include_total query bool false "是否统计总数，为 false 时 total 为 -1，按 has_more 翻页"
+// @Param label query []string false "执行标签过滤 (key:value)，可重复" collectionFormat(multi)
+// @Param pinned query bool false "只返回当前用户置顶的执行"
+// @Param breached query bool false "按是否违反 SLA 过滤，只匹配设置了 SLA 且已结束的执行"
+// @Param include query []string false "展开关联数据，支持 actions，每个执行最多返回 200 个动作" collectionFormat(multi)
+// @Param cursor query string false "上一页返回的 next_cursor，按第一页请求时的快照继续翻页，期间新写入的执行不会造成重复或遗漏；设置时忽略 page 且不统计总数"
+// @Param fields query []string false "稀疏字段集，只返回并查询这些字段，字段名为所请求版本的响应字段，可重复或以逗号分隔" collectionFormat(multi)
+// @Success 200 {object} common.Resp{data=common.ListResp[WorkflowExecutionListItem]}
 // @Router /v1/lab/history/workflow [get]
+// @Router /v2/lab/history/workflow [get]
 func (h *Handler) ListWorkflowExecutions(ctx *gin.Context) {
 	var req ListWorkflowExecutionsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
+	if wantsStream(ctx) {
+		h.streamWorkflowExecutions(ctx, &req)
+		return
+	}
+
+	page, err := h.listWorkflowExecutions(ctx, &req)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	if apiversion.Get(ctx) == apiversion.V2 {
+		common.ReplyOk(ctx, page.v2())
+		return
+	}
+	common.ReplyOk(ctx, page.v1())
+}
+
+// executionPage is a page of workflow executions with the data shared by the
+// response versions
+type executionPage struct {
+	params      *model.HistoryQueryParams
+	count       model.PageCount
+	executions  []*model.WorkflowExecutionHistory
+	pinned      map[int64]bool
+	withActions bool
+	actions     map[int64][]ActionExecutionResponse
+	fields      fieldSet
+}
+
+// executionQuery is the parsed filters and options of a workflow execution list request
+type executionQuery struct {
+	params       *model.HistoryQueryParams
+	includeTotal *bool
+	withActions  bool
+	fields       fieldSet
+	userInfo     *model.UserData
+}
+
+// listWorkflowExecutions queries the executions of the request
+func (h *Handler) listWorkflowExecutions(ctx *gin.Context, req *ListWorkflowExecutionsRequest) (*executionPage, error) {
+	q, err := h.parseExecutionQuery(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	params := q.params
+
+	cached, hit := h.totals.prepare(ctx, "workflow", params, q.includeTotal)
+	executions, count, err := h.repo.ListWorkflowExecutions(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	h.totals.store(ctx, "workflow", params, count.Total)
+	count = pageCount(params, count, cached, hit)
+
+	pinned, err := h.pinnedIDs(ctx, q.userInfo, executions)
+	if err != nil {
+		return nil, err
+	}
 
+	page := &executionPage{
+		params:      params,
+		count:       count,
+		executions:  executions,
+		pinned:      pinned,
+		withActions: q.withActions,
+		fields:      q.fields,
+	}
+	if q.withActions {
+		if page.actions, err = h.includedActions(ctx, executions); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// parseExecutionQuery builds the query params of a workflow execution list request
+func (h *Handler) parseExecutionQuery(ctx *gin.Context, req *ListWorkflowExecutionsRequest) (*executionQuery, error) {
+	var err error
 	params := model.NewHistoryQueryParams()
 	params.LabID = req.LabID
 	params.WorkflowID = req.WorkflowID
+	params.DeviceID = req.DeviceID
 	params.Page = req.Page
 	params.PageSize = req.PageSize
+	params.Breached = req.Breached
+	if params.DeviceIDs, err = h.topologyDevices(ctx, req.LabID, req.TopologyNode); err != nil {
+		return nil, err
+	}
+	if params.ExperimentID, err = h.experimentID(ctx, req.LabID, req.Experiment); err != nil {
+		return nil, err
+	}
 
 	if params.Page < 1 {
 		params.Page = 1
@@ -93,59 +270,182 @@ func (h *Handler) ListWorkflowExecutions(ctx *gin.Context) {
 	if params.PageSize < 1 || params.PageSize > 100 {
 		params.PageSize = 20
 	}
+	includeTotal := req.IncludeTotal
+	if req.Cursor != "" {
+		if params.Cursor, err = model.ParseHistoryCursor(req.Cursor); err != nil {
+			return nil, code.ParamErr.WithErr(err)
+		}
+		if params.Cursor.LabID != req.LabID {
+			return nil, code.ParamErr.WithMsg("cursor does not belong to the lab")
+		}
+		// 游标翻页不统计总数
+		includeTotal = new(bool)
+	}
 
 	if req.Status != "" {
-		status := model.ExecutionStatus(req.Status)
+		status := req.Status
 		params.Status = &status
 	}
+	labels, err := model.ParseLabelFilters(req.Labels)
+	if err != nil {
+		return nil, code.ParamErr.WithErr(err)
+	}
+	params.Labels = labels
+	withActions, err := parseInclude(req.Include)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := parseFields(req.Fields, workflowFields(apiversion.Get(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	params.Columns = fields.columns(workflowFields(apiversion.Get(ctx)), workflowKeyColumns)
+	userInfo := auth.GetCurrentUser(ctx)
+	if req.Pinned {
+		if userInfo == nil {
+			return nil, code.UnLogin
+		}
+		params.PinnedBy = userInfo.ID
+	}
 
-	if req.StartTime != "" {
-		if t, err := time.Parse(time.RFC3339, req.StartTime); err == nil {
-			params.StartTime = &t
+	if params.StartTime, params.EndTime, err = parseTimeRange(req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+
+	if err := h.applyView(ctx, req.ViewID, model.SavedViewWorkflow, params); err != nil {
+		return nil, err
+	}
+	return &executionQuery{
+		params:       params,
+		includeTotal: includeTotal,
+		withActions:  withActions,
+		fields:       fields,
+		userInfo:     userInfo,
+	}, nil
+}
+
+// includedActionsOf returns the included actions of the execution capped at
+// maxIncludedActions, reporting whether they were truncated
+func (p *executionPage) includedActionsOf(id int64) ([]ActionExecutionResponse, bool) {
+	actions := p.actions[id]
+	if len(actions) > maxIncludedActions {
+		return actions[:maxIncludedActions], true
+	}
+	return actions, false
+}
+
+// v1 serializes the page in the v1 response shape
+func (p *executionPage) v1() any {
+	items := make([]WorkflowExecutionListItem, 0, len(p.executions))
+	for _, e := range p.executions {
+		item := WorkflowExecutionListItem{WorkflowExecutionResponse: newWorkflowExecutionResponse(e, p.pinned[e.ID])}
+		if p.withActions {
+			item.Actions, item.ActionsTruncated = p.includedActionsOf(e.ID)
 		}
+		items = append(items, item)
+	}
+	return newListResponse(items, p.fields, p.params, p.count)
+}
+
+// newWorkflowExecutionResponse converts an execution to the v1 response shape
+func newWorkflowExecutionResponse(e *model.WorkflowExecutionHistory, pinned bool) WorkflowExecutionResponse {
+	return WorkflowExecutionResponse{
+		UUID:           e.UUID,
+		WorkflowUUID:   e.WorkflowUUID,
+		WorkflowName:   e.WorkflowName,
+		BatchID:        e.BatchID,
+		ExperimentID:   e.ExperimentID,
+		Status:         e.Status,
+		StepsTotal:     e.StepsTotal,
+		StepsCompleted: e.StepsCompleted,
+		StepsFailed:    e.StepsFailed,
+		DurationMs:     e.DurationMs,
+		ErrorMessage:   e.ErrorMessage,
+		ErrorCategory:  e.ErrorCategory,
+		StartedAt:      e.StartedAt,
+		CompletedAt:    e.CompletedAt,
+		Labels:         e.Labels.Data(),
+		Pinned:         pinned,
+		SLATargetMs:    e.SLATargetMs,
+		SLABreached:    e.SLABreached,
 	}
-	if req.EndTime != "" {
-		if t, err := time.Parse(time.RFC3339, req.EndTime); err == nil {
-			params.EndTime = &t
+}
+
+// parseInclude validates the include options, reporting whether actions are included
+func parseInclude(include []string) (bool, error) {
+	withActions := false
+	for _, value := range include {
+		for _, option := range strings.Split(value, ",") {
+			switch strings.TrimSpace(option) {
+			case "":
+			case includeActions:
+				withActions = true
+			default:
+				return false, code.ParamErr.WithMsgf("unknown include: %s", option)
+			}
 		}
 	}
+	return withActions, nil
+}
 
-	executions, total, err := h.repo.ListWorkflowExecutions(ctx, params)
+// includedActions batch loads the actions of the executions keyed by execution
+// id, fetching one more than the cap to detect truncation
+func (h *Handler) includedActions(ctx *gin.Context, executions []*model.WorkflowExecutionHistory) (map[int64][]ActionExecutionResponse, error) {
+	ids := make([]int64, 0, len(executions))
+	for _, e := range executions {
+		ids = append(ids, e.ID)
+	}
+	actions, err := h.repo.ListActionsByWorkflowExecutions(ctx, ids, maxIncludedActions+1)
 	if err != nil {
-		common.ReplyErr(ctx, err)
-		return
+		return nil, err
+	}
+	responses, err := h.actionResponses(ctx, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	datas := make(map[int64][]ActionExecutionResponse, len(executions))
+	for i, a := range actions {
+		if a.WorkflowExecutionID != nil {
+			datas[*a.WorkflowExecutionID] = append(datas[*a.WorkflowExecutionID], responses[i])
+		}
 	}
+	return datas, nil
+}
 
-	// Convert to response format
-	items := make([]WorkflowExecutionResponse, 0, len(executions))
+// pinnedIDs returns which of the executions the user has pinned
+func (h *Handler) pinnedIDs(ctx *gin.Context, userInfo *model.UserData, executions []*model.WorkflowExecutionHistory) (map[int64]bool, error) {
+	if userInfo == nil {
+		return map[int64]bool{}, nil
+	}
+	ids := make([]int64, 0, len(executions))
 	for _, e := range executions {
-		items = append(items, WorkflowExecutionResponse{
-			UUID:           e.UUID,
-			WorkflowUUID:   e.WorkflowUUID,
-			WorkflowName:   e.WorkflowName,
-			Status:         e.Status,
-			StepsTotal:     e.StepsTotal,
-			StepsCompleted: e.StepsCompleted,
-			StepsFailed:    e.StepsFailed,
-			DurationMs:     e.DurationMs,
-			ErrorMessage:   e.ErrorMessage,
-			StartedAt:      e.StartedAt,
-			CompletedAt:    e.CompletedAt,
-		})
+		ids = append(ids, e.ID)
 	}
+	return h.repo.PinnedExecutionIDs(ctx, userInfo.ID, ids)
+}
 
-	totalPages := int(total) / params.PageSize
-	if int(total)%params.PageSize > 0 {
-		totalPages++
+// applyView expands a saved view into the query parameters, keeping the
+// filters given explicitly in the request
+func (h *Handler) applyView(ctx *gin.Context, viewID string, target model.SavedViewTarget, params *model.HistoryQueryParams) error {
+	if viewID == "" {
+		return nil
+	}
+	viewUUID, err := uuid.FromString(viewID)
+	if err != nil {
+		return code.ParamErr.WithMsg("invalid view_id")
 	}
 
-	common.ReplyOk(ctx, ListResponse{
-		Items:      items,
-		Total:      total,
-		Page:       params.Page,
-		PageSize:   params.PageSize,
-		TotalPages: totalPages,
-	})
+	filters, err := h.savedViews.Expand(ctx, viewUUID, params.LabID, target)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	for key := range ctx.Request.URL.Query() {
+		set[key] = true
+	}
+	filters.Apply(params, set, time.Now())
+	return nil
 }
 
 // GetWorkflowExecutionRequest represents the request for getting a workflow execution
@@ -156,34 +456,111 @@ type GetWorkflowExecutionRequest struct {
 // WorkflowExecutionDetailResponse represents detailed workflow execution response
 type WorkflowExecutionDetailResponse struct {
 	WorkflowExecutionResponse
-	Actions []ActionExecutionResponse `json:"actions"`
+	Actions      []ActionExecutionResponse     `json:"actions"`
+	Dependencies *DependencyResponse           `json:"dependencies,omitempty"`
+	Materials    []*model.MaterialUsageSummary `json:"materials"`          // 本次执行消耗的物料批次
+	Estimate     *model.ExecutionEstimate      `json:"estimate,omitempty"` // 排队中或运行中时的预计完成时间
+	Definition   *model.WorkflowSnapshot       `json:"definition"`         // 执行开始时的工作流定义，早于快照功能的执行为空
+}
+
+// DependencyResponse represents the dependency graph around an execution
+type DependencyResponse struct {
+	DependsOn  []uuid.UUID            `json:"depends_on"`
+	Dependents []uuid.UUID            `json:"dependents"`
+	Policy     model.DependencyPolicy `json:"policy,omitempty"`
+	HoldStatus model.HeldTaskStatus   `json:"hold_status,omitempty"`
 }
 
 // ActionExecutionResponse represents an action execution in response
 type ActionExecutionResponse struct {
-	UUID         uuid.UUID              `json:"uuid"`
-	DeviceUUID   uuid.UUID              `json:"device_uuid"`
-	DeviceName   string                 `json:"device_name"`
-	ActionType   string                 `json:"action_type"`
-	ActionName   string                 `json:"action_name"`
-	Status       model.ExecutionStatus  `json:"status"`
-	DurationMs   int64                  `json:"duration_ms"`
-	ErrorMessage *string                `json:"error_message,omitempty"`
-	CreatedAt    time.Time              `json:"created_at"`
+	UUID          uuid.UUID              `json:"uuid"`
+	DeviceUUID    uuid.UUID              `json:"device_uuid"`
+	DeviceName    string                 `json:"device_name"`
+	ActionType    string                 `json:"action_type"`
+	ActionName    string                 `json:"action_name"`
+	Status        model.ExecutionStatus  `json:"status"`
+	DurationMs    int64                  `json:"duration_ms"`
+	ExpectedMs    int64                  `json:"expected_ms"`
+	DeviationMs   *int64                 `json:"deviation_ms,omitempty"` // 超出预算为正，未设预算时为空
+	OverBudget    bool                   `json:"over_budget"`
+	ErrorMessage  *string                `json:"error_message,omitempty"`
+	ErrorCategory string                 `json:"error_category,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Approval      *model.ApprovalRequest `json:"approval,omitempty"`
+}
+
+// GetActionExecutionRequest represents the request for getting an action execution
+type GetActionExecutionRequest struct {
+	ActionUUID string `uri:"action_uuid" binding:"required"`
+}
+
+// ActionExecutionDetailResponse represents an action execution with its payloads
+type ActionExecutionDetailResponse struct {
+	ActionExecutionResponse
+	Input       datatypes.JSON `json:"input" swaggertype:"object"`
+	Output      datatypes.JSON `json:"output" swaggertype:"object"`
+	CompactedAt *time.Time     `json:"compacted_at,omitempty"` // 输入输出已归档，响应中为从归档读取的完整内容
+}
+
+// @Summary 获取动作执行详情
+// @Description 获取单个动作的执行信息及完整的输入输出，已压缩的动作从归档中读取
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param action_uuid path string true "动作UUID"
+// @Success 200 {object} common.Resp{data=ActionExecutionDetailResponse}
+// @Router /v1/lab/history/action/{action_uuid} [get]
+func (h *Handler) GetActionExecution(ctx *gin.Context) {
+	var req GetActionExecutionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	actionUUID, err := uuid.FromString(req.ActionUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid action UUID"))
+		return
+	}
+
+	action, err := h.repo.GetActionExecutionByUUID(ctx, actionUUID)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	responses, err := h.actionResponses(ctx, []*model.ActionExecutionHistory{action})
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	if err := h.compactor.Restore(ctx, action); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.ReplyOk(ctx, ActionExecutionDetailResponse{
+		ActionExecutionResponse: responses[0],
+		Input:                   action.Input,
+		Output:                  action.Output,
+		CompactedAt:             action.CompactedAt,
+	})
 }
 
 // @Summary 获取工作流执行详情
-// @Description 获取单次工作流执行的详细信息，包含所有动作
+// @Description 获取单次工作流执行的详细信息，包含所有动作及执行开始时的工作流定义快照。v2 返回 WorkflowExecutionDetailV2
 // @Tags History
 // @Accept json
 // @Produce json
 // @Param execution_uuid path string true "执行UUID"
 // @Success 200 {object} common.Resp{data=WorkflowExecutionDetailResponse}
 // @Router /v1/lab/history/workflow/execution/{execution_uuid} [get]
+// @Router /v2/lab/history/workflow/execution/{execution_uuid} [get]
 func (h *Handler) GetWorkflowExecution(ctx *gin.Context) {
 	var req GetWorkflowExecutionRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -206,48 +583,336 @@ func (h *Handler) GetWorkflowExecution(ctx *gin.Context) {
 		return
 	}
 
-	actionResponses := make([]ActionExecutionResponse, 0, len(actions))
-	for _, a := range actions {
-		actionResponses = append(actionResponses, ActionExecutionResponse{
-			UUID:         a.UUID,
-			DeviceUUID:   a.DeviceUUID,
-			DeviceName:   a.DeviceName,
-			ActionType:   a.ActionType,
-			ActionName:   a.ActionName,
-			Status:       a.Status,
-			DurationMs:   a.DurationMs,
-			ErrorMessage: a.ErrorMessage,
-			CreatedAt:    a.CreatedAt,
-		})
+	actionResponses, err := h.actionResponses(ctx, actions)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	deps, err := h.getDependencies(ctx, exec.UUID)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	materials, err := h.inventoryRepo.SummarizeUsages(ctx, exec.ID)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	pinned, err := h.pinnedIDs(ctx, auth.GetCurrentUser(ctx), []*model.WorkflowExecutionHistory{exec})
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
 	}
 
+	// 预计完成时间只是参考，估算失败不影响详情
+	est, err := h.estimator.Execution(ctx, exec)
+	if err != nil {
+		logger.Warnf(ctx, "GetWorkflowExecution estimate fail uuid: %s, err: %+v", exec.UUID, err)
+	}
+
+	var definition *model.WorkflowSnapshot
+	if exec.DefinitionHash != "" {
+		definition, err = h.repo.GetWorkflowSnapshot(ctx, exec.DefinitionHash)
+		if err != nil {
+			common.ReplyErr(ctx, err)
+			return
+		}
+	}
+
+	if apiversion.Get(ctx) == apiversion.V2 {
+		common.ReplyOk(ctx, WorkflowExecutionDetailV2{
+			WorkflowExecutionV2: newWorkflowExecutionV2(exec, pinned[exec.ID]),
+			Actions:             actionResponses,
+			Dependencies:        deps,
+			Materials:           materials,
+			Estimate:            est,
+			Definition:          definition,
+		})
+		return
+	}
 	common.ReplyOk(ctx, WorkflowExecutionDetailResponse{
-		WorkflowExecutionResponse: WorkflowExecutionResponse{
-			UUID:           exec.UUID,
-			WorkflowUUID:   exec.WorkflowUUID,
-			WorkflowName:   exec.WorkflowName,
-			Status:         exec.Status,
-			StepsTotal:     exec.StepsTotal,
-			StepsCompleted: exec.StepsCompleted,
-			StepsFailed:    exec.StepsFailed,
-			DurationMs:     exec.DurationMs,
-			ErrorMessage:   exec.ErrorMessage,
-			StartedAt:      exec.StartedAt,
-			CompletedAt:    exec.CompletedAt,
-		},
-		Actions: actionResponses,
+		WorkflowExecutionResponse: newWorkflowExecutionResponse(exec, pinned[exec.ID]),
+		Actions:                   actionResponses,
+		Dependencies:              deps,
+		Materials:                 materials,
+		Estimate:                  est,
+		Definition:                definition,
 	})
 }
 
+// SetExecutionLabelsRequest represents the request for replacing the labels of an execution
+type SetExecutionLabelsRequest struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// @Summary 设置工作流执行标签
+// @Description 整体替换执行记录的键值标签，传入空对象清空标签
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param execution_uuid path string true "执行UUID"
+// @Param req body SetExecutionLabelsRequest true "标签"
+// @Success 200 {object} common.Resp{data=map[string]string}
+// @Router /v1/lab/history/workflow/execution/{execution_uuid}/labels [put]
+func (h *Handler) SetExecutionLabels(ctx *gin.Context) {
+	var req SetExecutionLabelsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	if req.Labels == nil {
+		req.Labels = map[string]string{}
+	}
+	if err := model.ValidateLabels(req.Labels); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	exec, err := h.memberExecution(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	if err := h.repo.SetExecutionLabels(ctx, exec.ID, req.Labels); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	common.ReplyOk(ctx, req.Labels)
+}
+
+// @Summary 置顶工作流执行
+// @Description 为当前用户置顶执行记录，列表接口使用 pinned=true 只返回置顶的执行
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param execution_uuid path string true "执行UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/history/workflow/execution/{execution_uuid}/pin [put]
+func (h *Handler) PinExecution(ctx *gin.Context) {
+	exec, err := h.memberExecution(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.Reply(ctx, h.repo.PinExecution(ctx, &model.ExecutionPin{
+		UserID:      auth.GetCurrentUser(ctx).ID,
+		ExecutionID: exec.ID,
+		LabID:       exec.LabID,
+	}))
+}
+
+// @Summary 取消置顶工作流执行
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param execution_uuid path string true "执行UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/history/workflow/execution/{execution_uuid}/pin [delete]
+func (h *Handler) UnpinExecution(ctx *gin.Context) {
+	exec, err := h.memberExecution(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.Reply(ctx, h.repo.UnpinExecution(ctx, auth.GetCurrentUser(ctx).ID, exec.ID))
+}
+
+// memberExecution loads the execution of the uri when the current user is a member of its lab
+func (h *Handler) memberExecution(ctx *gin.Context) (*model.WorkflowExecutionHistory, error) {
+	var uri GetWorkflowExecutionRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		return nil, code.ParamErr.WithErr(err)
+	}
+	execUUID, err := uuid.FromString(uri.ExecutionUUID)
+	if err != nil {
+		return nil, code.ParamErr.WithMsg("invalid execution UUID")
+	}
+
+	exec, err := h.repo.GetWorkflowExecutionByUUID(ctx, execUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.checkMember(ctx, exec.LabID); err != nil {
+		return nil, err
+	}
+	return exec, nil
+}
+
+// ListExecutionLabelsRequest represents the request for label autocompletion
+type ListExecutionLabelsRequest struct {
+	LabID int64  `form:"lab_id" binding:"required"`
+	Key   string `form:"key"` // 为空时返回标签键，否则返回该键的取值
+}
+
+// @Summary 获取工作流执行标签
+// @Description 返回实验室执行记录使用的标签键及次数，传入 key 时返回该键的取值，用于过滤条件自动补全
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_id query int true "实验室ID" example(1)
+// @Param key query string false "标签键"
+// @Success 200 {object} common.Resp{data=[]model.LabelCount}
+// @Router /v1/lab/history/workflow/labels [get]
+func (h *Handler) ListExecutionLabels(ctx *gin.Context) {
+	var req ListExecutionLabelsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	if req.Key == "" {
+		datas, err := h.repo.ListLabelKeys(ctx, req.LabID, labelSuggestLimit)
+		common.Reply(ctx, err, datas)
+		return
+	}
+	datas, err := h.repo.ListLabelValues(ctx, req.LabID, req.Key, labelSuggestLimit)
+	common.Reply(ctx, err, datas)
+}
+
+// topologyDevices resolves a topology node of the lab to the devices below it,
+// nil when no node is given
+func (h *Handler) topologyDevices(ctx *gin.Context, labID int64, nodeUUID string) ([]int64, error) {
+	if nodeUUID == "" {
+		return nil, nil
+	}
+	id, err := uuid.FromString(nodeUUID)
+	if err != nil {
+		return nil, code.ParamErr.WithMsg("invalid topology_node UUID")
+	}
+	return h.topology.DeviceIDs(ctx, labID, id)
+}
+
+// experimentID resolves an experiment of the lab, nil when no experiment is given
+func (h *Handler) experimentID(ctx *gin.Context, labID int64, experimentUUID string) (*int64, error) {
+	if experimentUUID == "" {
+		return nil, nil
+	}
+	id, err := uuid.FromString(experimentUUID)
+	if err != nil {
+		return nil, code.ParamErr.WithMsg("invalid experiment UUID")
+	}
+	datas := make([]*model.Experiment, 0, 1)
+	if err := h.labStore.FindDatas(ctx, &datas, map[string]any{
+		"uuid": id,
+	}, "id", "lab_id"); err != nil {
+		return nil, err
+	}
+	if len(datas) == 0 || datas[0].LabID != labID {
+		return nil, code.RecordNotFound.WithMsg("experiment not found in lab")
+	}
+	return &datas[0].ID, nil
+}
+
+// checkMember checks that the current user is a member of the lab
+func (h *Handler) checkMember(ctx *gin.Context, labID int64) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+	count, err := h.labStore.Count(ctx, &model.LaboratoryMember{}, map[string]any{
+		"lab_id":  labID,
+		"user_id": userInfo.ID,
+	})
+	if err != nil || count == 0 {
+		return code.NoPermission
+	}
+	return nil
+}
+
+// actionResponses converts actions with the approval audit of gated actions
+func (h *Handler) actionResponses(ctx *gin.Context, actions []*model.ActionExecutionHistory) ([]ActionExecutionResponse, error) {
+	actionIDs := make([]int64, 0, len(actions))
+	for _, a := range actions {
+		actionIDs = append(actionIDs, a.ID)
+	}
+	approvals, err := h.approvalRepo.ListApprovalsByActions(ctx, actionIDs)
+	if err != nil {
+		return nil, err
+	}
+	approvalMap := make(map[int64]*model.ApprovalRequest, len(approvals))
+	for _, a := range approvals {
+		approvalMap[a.ActionExecutionID] = a
+	}
+
+	actionResponses := make([]ActionExecutionResponse, 0, len(actions))
+	for _, a := range actions {
+		resp := ActionExecutionResponse{
+			UUID:          a.UUID,
+			DeviceUUID:    a.DeviceUUID,
+			DeviceName:    a.DeviceName,
+			ActionType:    a.ActionType,
+			ActionName:    a.ActionName,
+			Status:        a.Status,
+			DurationMs:    a.DurationMs,
+			ExpectedMs:    a.ExpectedMs,
+			ErrorMessage:  a.ErrorMessage,
+			ErrorCategory: a.ErrorCategory,
+			CreatedAt:     a.CreatedAt,
+			Approval:      approvalMap[a.ID],
+		}
+		if deviation, ok := a.BudgetDeviation(); ok {
+			resp.DeviationMs = &deviation
+			resp.OverBudget = deviation > 0
+		}
+		actionResponses = append(actionResponses, resp)
+	}
+	return actionResponses, nil
+}
+
+// getDependencies builds the upstream and downstream edges of the execution
+func (h *Handler) getDependencies(ctx *gin.Context, execUUID uuid.UUID) (*DependencyResponse, error) {
+	edges, err := h.depRepo.ListEdges(ctx, execUUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(edges) == 0 {
+		return nil, nil
+	}
+
+	resp := &DependencyResponse{
+		DependsOn:  make([]uuid.UUID, 0, len(edges)),
+		Dependents: make([]uuid.UUID, 0, len(edges)),
+	}
+	for _, e := range edges {
+		if e.TaskUUID == execUUID {
+			resp.DependsOn = append(resp.DependsOn, e.DependsOnUUID)
+		} else {
+			resp.Dependents = append(resp.Dependents, e.TaskUUID)
+		}
+	}
+
+	held, err := h.depRepo.GetHeldTask(ctx, execUUID)
+	if err != nil {
+		return nil, err
+	}
+	if held != nil {
+		resp.Policy = held.Policy
+		resp.HoldStatus = held.Status
+	}
+	return resp, nil
+}
+
 // ListDeviceEventsRequest represents the request for listing device events
 type ListDeviceEventsRequest struct {
-	LabID     int64  `form:"lab_id" binding:"required"`
-	DeviceID  *int64 `form:"device_id"`
-	EventType string `form:"event_type"`
-	StartTime string `form:"start_time"`
-	EndTime   string `form:"end_time"`
-	Page      int    `form:"page,default=1"`
-	PageSize  int    `form:"page_size,default=20"`
+	LabID     int64                 `form:"lab_id" binding:"required"`
+	DeviceID  *int64                `form:"device_id"`
+	EventType model.DeviceEventType `form:"event_type" binding:"omitempty,enum"`
+	StartTime string                `form:"start_time" binding:"omitempty,rfc3339"`
+	EndTime   string                `form:"end_time" binding:"omitempty,rfc3339"`
+	Page      int                   `form:"page,default=1" binding:"page"`
+	PageSize  int                   `form:"page_size,default=20" binding:"page_size=100"`
+	ViewID    string                `form:"view_id" binding:"omitempty,uuid"` // 保存的视图，请求中显式传入的过滤条件优先
+	// 为 false 时不统计总数，只返回 has_more；不传时总数很大的实验室也不统计
+	IncludeTotal *bool `form:"include_total"`
+	// 房间、工作台或设备组，其下任一设备的事件
+	TopologyNode string `form:"topology_node" binding:"omitempty,uuid"`
+	// 只返回这些字段，可重复或以逗号分隔，不传时返回全部字段
+	Fields []string `form:"fields"`
 }
 
 // DeviceEventResponse represents a device event in response
@@ -260,31 +925,65 @@ type DeviceEventResponse struct {
 }
 
 // @Summary 获取设备事件历史
-// @Description 获取实验室的设备事件历史记录
+// @Description 获取实验室的设备事件历史记录。v2 返回 ListResponseV2，条目为 DeviceEventV2。Accept: application/x-ndjson 时不分页，按列表顺序逐行流式返回条目，最多返回配置的行数，最后一行为 {"end": StreamEnd}
 // @Tags History
 // @Accept json
-// @Produce json
-// @Param lab_id query int true "实验室ID"
+// @Produce json,application/x-ndjson
+// @Param lab_id query int true "实验室ID" example(1)
 // @Param device_id query int false "设备ID (可选)"
-// @Param event_type query string false "事件类型过滤"
+// @Param topology_node query string false "拓扑节点UUID，只返回该房间、工作台或设备组下设备的事件"
+// @Param event_type query string false "事件类型过滤，未知的类型返回参数错误并列出允许的取值"
 // @Param start_time query string false "开始时间 (RFC3339格式)"
 // @Param end_time query string false "结束时间 (RFC3339格式)"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
-// @Success 200 {object} common.Resp{data=ListResponse}
+// @Param view_id query string false "保存的视图UUID，展开为视图的过滤条件"
+// @Param include_total query bool false "是否统计总数，为 false 时 total 为 -1，按 has_more 翻页"
+// @Param fields query []string false "稀疏字段集，只返回并查询这些字段，字段名为所请求版本的响应字段，可重复或以逗号分隔" collectionFormat(multi)
+// @Success 200 {object} common.Resp{data=common.ListResp[DeviceEventResponse]}
 // @Router /v1/lab/history/device [get]
+// @Router /v2/lab/history/device [get]
 func (h *Handler) ListDeviceEvents(ctx *gin.Context) {
 	var req ListDeviceEventsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	if wantsStream(ctx) {
+		h.streamDeviceEvents(ctx, &req)
+		return
+	}
+
+	params, fields, err := h.parseDeviceEventQuery(ctx, &req)
+	if err != nil {
+		common.ReplyErr(ctx, err)
 		return
 	}
 
+	cached, hit := h.totals.prepare(ctx, "device", params, req.IncludeTotal)
+	events, count, err := h.repo.ListDeviceEvents(ctx, params)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	h.totals.store(ctx, "device", params, count.Total)
+	count = pageCount(params, count, cached, hit)
+
+	common.ReplyOk(ctx, deviceEventList(ctx, events, params, count, fields))
+}
+
+// parseDeviceEventQuery builds the query params of a device event list request
+func (h *Handler) parseDeviceEventQuery(ctx *gin.Context, req *ListDeviceEventsRequest) (*model.HistoryQueryParams, fieldSet, error) {
 	params := model.NewHistoryQueryParams()
 	params.LabID = req.LabID
 	params.DeviceID = req.DeviceID
 	params.Page = req.Page
 	params.PageSize = req.PageSize
+	deviceIDs, err := h.topologyDevices(ctx, req.LabID, req.TopologyNode)
+	if err != nil {
+		return nil, nil, err
+	}
+	params.DeviceIDs = deviceIDs
 
 	if params.Page < 1 {
 		params.Page = 1
@@ -294,57 +993,145 @@ func (h *Handler) ListDeviceEvents(ctx *gin.Context) {
 	}
 
 	if req.EventType != "" {
-		eventType := model.DeviceEventType(req.EventType)
+		eventType := req.EventType
 		params.EventType = &eventType
 	}
+	fields, err := parseFields(req.Fields, deviceEventFields(apiversion.Get(ctx)))
+	if err != nil {
+		return nil, nil, err
+	}
+	params.Columns = fields.columns(deviceEventFields(apiversion.Get(ctx)), deviceEventKeyColumns)
 
-	if req.StartTime != "" {
-		if t, err := time.Parse(time.RFC3339, req.StartTime); err == nil {
-			params.StartTime = &t
-		}
+	if params.StartTime, params.EndTime, err = parseTimeRange(req.StartTime, req.EndTime); err != nil {
+		return nil, nil, err
+	}
+
+	if err := h.applyView(ctx, req.ViewID, model.SavedViewDeviceEvent, params); err != nil {
+		return nil, nil, err
 	}
-	if req.EndTime != "" {
-		if t, err := time.Parse(time.RFC3339, req.EndTime); err == nil {
-			params.EndTime = &t
+	return params, fields, nil
+}
+
+// deviceEventList serializes a page of device events in the response shape of the API version
+func deviceEventList(ctx *gin.Context, events []*model.DeviceEventHistory, params *model.HistoryQueryParams, count model.PageCount, fields fieldSet) any {
+	if apiversion.Get(ctx) == apiversion.V2 {
+		items := make([]DeviceEventV2, 0, len(events))
+		for _, e := range events {
+			items = append(items, newDeviceEventV2(e))
 		}
+		return newListResponseV2(sparse(items, fields), params, count)
+	}
+
+	items := make([]DeviceEventResponse, 0, len(events))
+	for _, e := range events {
+		items = append(items, newDeviceEventResponse(e))
+	}
+	return newListResponse(items, fields, params, count)
+}
+
+// newDeviceEventResponse converts a device event to the v1 response shape
+func newDeviceEventResponse(e *model.DeviceEventHistory) DeviceEventResponse {
+	return DeviceEventResponse{
+		UUID:       e.UUID,
+		DeviceUUID: e.DeviceUUID,
+		EventType:  e.EventType,
+		EventData:  e.EventData,
+		Timestamp:  e.Timestamp,
+	}
+}
+
+// SearchRequest represents a natural-language history search
+type SearchRequest struct {
+	assistant.InterpretReq
+	Page     int `json:"page"`
+	PageSize int `json:"page_size"`
+}
+
+// SearchResponse is the filter interpreted from the question with a page of
+// the history it matches
+type SearchResponse struct {
+	Interpretation *model.HistoryInterpretation `json:"interpretation"`
+	Results        any                          `json:"results"` // 与对应列表接口的响应相同
+}
+
+// @Summary 自然语言搜索执行历史
+// @Description 将问题（例如"上周 3 号实验室离心机失败的运行"）解析为历史查询条件并执行查询，同时返回解析出的条件，可修改后用于列表接口或保存为视图。开启 ai_assistant 功能并配置了模型时由模型解析，否则使用内置的中英文规则；问题中的 lab N 优先于 lab_id
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param req body SearchRequest true "问题"
+// @Success 200 {object} common.Resp{data=SearchResponse}
+// @Router /v1/lab/history/search [post]
+func (h *Handler) Search(ctx *gin.Context) {
+	req := &SearchRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
 	}
 
-	events, total, err := h.repo.ListDeviceEvents(ctx, params)
+	interp, err := h.assistant.Interpret(ctx, &req.InterpretReq)
 	if err != nil {
 		common.ReplyErr(ctx, err)
 		return
 	}
+	resp := &SearchResponse{Interpretation: interp}
 
-	items := make([]DeviceEventResponse, 0, len(events))
-	for _, e := range events {
-		items = append(items, DeviceEventResponse{
-			UUID:       e.UUID,
-			DeviceUUID: e.DeviceUUID,
-			EventType:  e.EventType,
-			EventData:  e.EventData,
-			Timestamp:  e.Timestamp,
-		})
+	if interp.Target == model.SavedViewDeviceEvent {
+		params := model.NewHistoryQueryParams()
+		params.LabID = interp.LabID
+		if req.Page > 0 {
+			params.Page = req.Page
+		}
+		if req.PageSize >= 1 && req.PageSize <= 100 {
+			params.PageSize = req.PageSize
+		}
+		interp.Filters.Apply(params, nil, time.Now())
+		events, count, err := h.repo.ListDeviceEvents(ctx, params)
+		if err != nil {
+			common.ReplyErr(ctx, err)
+			return
+		}
+		resp.Results = deviceEventList(ctx, events, params, count, nil)
+		common.ReplyOk(ctx, resp)
+		return
 	}
 
-	totalPages := int(total) / params.PageSize
-	if int(total)%params.PageSize > 0 {
-		totalPages++
+	f := interp.Filters
+	listReq := &ListWorkflowExecutionsRequest{
+		LabID:      interp.LabID,
+		WorkflowID: f.WorkflowID,
+		DeviceID:   f.DeviceID,
+		Status:     model.ExecutionStatus(f.Status),
+		Labels:     f.Labels,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
 	}
-
-	common.ReplyOk(ctx, ListResponse{
-		Items:      items,
-		Total:      total,
-		Page:       params.Page,
-		PageSize:   params.PageSize,
-		TotalPages: totalPages,
-	})
+	if f.StartTime != nil {
+		listReq.StartTime = f.StartTime.Format(time.RFC3339Nano)
+	}
+	if f.EndTime != nil {
+		listReq.EndTime = f.EndTime.Format(time.RFC3339Nano)
+	}
+	page, err := h.listWorkflowExecutions(ctx, listReq)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	if apiversion.Get(ctx) == apiversion.V2 {
+		resp.Results = page.v2()
+	} else {
+		resp.Results = page.v1()
+	}
+	common.ReplyOk(ctx, resp)
 }
 
 // GetLabStatsRequest represents the request for getting lab stats
 type GetLabStatsRequest struct {
 	LabID     int64  `uri:"lab_id" binding:"required"`
-	StartTime string `form:"start_time"`
-	EndTime   string `form:"end_time"`
+	StartTime string `form:"start_time" binding:"omitempty,rfc3339"`
+	EndTime   string `form:"end_time" binding:"omitempty,rfc3339"`
+	// 房间、工作台或设备组，只统计其下设备
+	TopologyNode string `form:"topology_node" binding:"omitempty,uuid"`
 }
 
 // @Summary 获取实验室使用统计
@@ -352,37 +1139,338 @@ type GetLabStatsRequest struct {
 // @Tags History
 // @Accept json
 // @Produce json
-// @Param lab_id path int true "实验室ID"
+// @Param lab_id path int true "实验室ID" example(1)
 // @Param start_time query string false "开始时间 (RFC3339格式)"
 // @Param end_time query string false "结束时间 (RFC3339格式)"
+// @Param topology_node query string false "拓扑节点UUID，只统计该房间、工作台或设备组下设备的执行、动作和事件"
 // @Success 200 {object} common.Resp{data=model.HistoryStats}
 // @Router /v1/lab/{lab_id}/stats [get]
 func (h *Handler) GetLabStats(ctx *gin.Context) {
-	labIDStr := ctx.Param("lab_id")
-	labID, err := strconv.ParseInt(labIDStr, 10, 64)
+	var req GetLabStatsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	startTime, endTime, err := parseTimeRange(req.StartTime, req.EndTime)
 	if err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab_id"))
+		common.ReplyErr(ctx, err)
 		return
 	}
 
-	var startTime, endTime *time.Time
+	deviceIDs, err := h.topologyDevices(ctx, req.LabID, req.TopologyNode)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	stats, err := h.repo.GetLabStats(ctx, req.LabID, startTime, endTime, deviceIDs)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.ReplyOk(ctx, stats)
+}
+
+
+// @Summary 获取组织统计
+// @Description 汇总组织内所有实验室的执行统计，包含各实验室明细、执行次数最多的工作流与设备，以及与上一个等长周期的对比。默认最近 30 天
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param org_id path string true "组织ID"
+// @Param start_time query string false "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)"
+// @Param top query int false "排行数量" default(10)
+// @Success 200 {object} common.Resp{data=model.OrgStats}
+// @Router /v1/org/{org_id}/stats [get]
+func (h *Handler) GetOrgStats(ctx *gin.Context) {
+	orgID := ctx.Param("org_id")
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		common.ReplyErr(ctx, code.UnLogin)
+		return
+	}
+	if orgID == "" || userInfo.Owner != orgID {
+		common.ReplyErr(ctx, code.NoPermission)
+		return
+	}
+
+	endTime := time.Now()
+	if et := ctx.Query("end_time"); et != "" {
+		t, err := time.Parse(time.RFC3339, et)
+		if err != nil {
+			common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid end_time"))
+			return
+		}
+		endTime = t
+	}
+	startTime := endTime.Add(-defaultOrgStatsPeriod)
 	if st := ctx.Query("start_time"); st != "" {
-		if t, err := time.Parse(time.RFC3339, st); err == nil {
-			startTime = &t
+		t, err := time.Parse(time.RFC3339, st)
+		if err != nil {
+			common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid start_time"))
+			return
 		}
+		startTime = t
 	}
-	if et := ctx.Query("end_time"); et != "" {
-		if t, err := time.Parse(time.RFC3339, et); err == nil {
-			endTime = &t
+	if !startTime.Before(endTime) {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("start_time must be before end_time"))
+		return
+	}
+	if endTime.Sub(startTime) > maxOrgStatsPeriod {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("time range must not exceed 366 days"))
+		return
+	}
+
+	top := 10
+	if v := ctx.Query("top"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			top = n
 		}
 	}
 
-	stats, err := h.repo.GetLabStats(ctx, labID, startTime, endTime)
+	labs, err := h.repo.ListOrgLabs(ctx, orgID)
 	if err != nil {
 		common.ReplyErr(ctx, err)
 		return
 	}
+	stats, err := h.repo.GetOrgStats(ctx, labs, startTime, endTime, top)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	stats.OrgID = orgID
 
 	common.ReplyOk(ctx, stats)
 }
 
+// ListSlowestStepsRequest represents the query of the slowest steps report
+type ListSlowestStepsRequest struct {
+	LabID     int64  `uri:"lab_id" binding:"required"`
+	StartTime string `form:"start_time" binding:"omitempty,rfc3339"`
+	EndTime   string `form:"end_time" binding:"omitempty,rfc3339"`
+	Limit     int    `form:"limit,default=10"`
+}
+
+// @Summary 获取最慢步骤报表
+// @Description 按步骤聚合动作执行时长，返回平均耗时最长的步骤及其与时长预算的偏差
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_id path int true "实验室ID" example(1)
+// @Param start_time query string false "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)"
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} common.Resp{data=[]model.StepDurationStats}
+// @Router /v1/lab/{lab_id}/stats/slowest-steps [get]
+func (h *Handler) ListSlowestSteps(ctx *gin.Context) {
+	var req ListSlowestStepsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	startTime, endTime, err := parseTimeRange(req.StartTime, req.EndTime)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	limit := req.Limit
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	stats, err := h.repo.ListSlowestSteps(ctx, req.LabID, startTime, endTime, limit)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.ReplyOk(ctx, stats)
+}
+
+// @Summary 获取实验室活动热力图
+// @Description 按星期和小时统计工作流执行的开始次数，用于查看设备使用规律和安排维护时间。matrix[weekday][hour]，weekday 0 为周日。默认最近 30 天，最长 366 天
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_id path int true "实验室ID" example(1)
+// @Param start_time query string false "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)"
+// @Param tz query string false "统计使用的时区 (IANA 名称，例如 Asia/Shanghai)" default(UTC)
+// @Success 200 {object} common.Resp{data=model.ActivityHeatmap}
+// @Router /v1/lab/{lab_id}/stats/heatmap [get]
+func (h *Handler) GetActivityHeatmap(ctx *gin.Context) {
+	labID, err := strconv.ParseInt(ctx.Param("lab_id"), 10, 64)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab_id"))
+		return
+	}
+
+	timezone := ctx.DefaultQuery("tz", "UTC")
+	if _, err := time.LoadLocation(timezone); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid tz"))
+		return
+	}
+
+	startTime, endTime, err := statsRange(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	cells, err := h.repo.ActivityHeatmap(ctx, labID, startTime, endTime, timezone)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	heatmap := model.NewActivityHeatmap(cells)
+	heatmap.LabID = labID
+	heatmap.StartTime = startTime
+	heatmap.EndTime = endTime
+	heatmap.Timezone = timezone
+	common.ReplyOk(ctx, heatmap)
+}
+
+// @Summary 获取设备利用率报表
+// @Description 按设备统计动作执行的忙碌时间与心跳记录的在线时间，按利用率排名，并给出与上一周期相比的变化和每天的趋势。没有心跳记录的设备在线时间按整个周期计算。默认最近 30 天，最长 366 天
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_id path int true "实验室ID" example(1)
+// @Param start_time query string false "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)"
+// @Success 200 {object} common.Resp{data=model.UtilizationReport}
+// @Router /v1/lab/{lab_id}/stats/device-utilization [get]
+func (h *Handler) GetDeviceUtilization(ctx *gin.Context) {
+	labID, err := strconv.ParseInt(ctx.Param("lab_id"), 10, 64)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab_id"))
+		return
+	}
+
+	startTime, endTime, err := statsRange(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	report, err := h.repo.GetDeviceUtilization(ctx, labID, startTime, endTime)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	common.ReplyOk(ctx, report)
+}
+
+// @Summary 获取失败分类统计
+// @Description 按错误分类统计失败或超时的工作流执行与动作，分类由实验室的错误分类规则决定，未分类的记录计入 unclassified。默认最近 30 天，最长 366 天
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_id path int true "实验室ID" example(1)
+// @Param start_time query string false "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)"
+// @Success 200 {object} common.Resp{data=model.FailureStats}
+// @Router /v1/lab/{lab_id}/stats/failures [get]
+func (h *Handler) GetFailureStats(ctx *gin.Context) {
+	labID, err := strconv.ParseInt(ctx.Param("lab_id"), 10, 64)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab_id"))
+		return
+	}
+
+	startTime, endTime, err := statsRange(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	stats, err := h.repo.GetFailureStats(ctx, labID, startTime, endTime)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	common.ReplyOk(ctx, stats)
+}
+
+// parseTimeRange parses the optional start_time and end_time filters, which
+// the binding rules have already checked to be RFC3339 times
+func parseTimeRange(start, end string) (*time.Time, *time.Time, error) {
+	var startTime, endTime *time.Time
+	if start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return nil, nil, code.ParamErr.WithMsg("invalid start_time")
+		}
+		startTime = &t
+	}
+	if end != "" {
+		t, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return nil, nil, code.ParamErr.WithMsg("invalid end_time")
+		}
+		endTime = &t
+	}
+	return startTime, endTime, nil
+}
+
+// statsRange parses start_time and end_time of a lab statistics request,
+// defaulting to the last 30 days
+func statsRange(ctx *gin.Context) (time.Time, time.Time, error) {
+	endTime := time.Now()
+	if et := ctx.Query("end_time"); et != "" {
+		t, err := time.Parse(time.RFC3339, et)
+		if err != nil {
+			return time.Time{}, time.Time{}, code.ParamErr.WithMsg("invalid end_time")
+		}
+		endTime = t
+	}
+	startTime := endTime.Add(-defaultOrgStatsPeriod)
+	if st := ctx.Query("start_time"); st != "" {
+		t, err := time.Parse(time.RFC3339, st)
+		if err != nil {
+			return time.Time{}, time.Time{}, code.ParamErr.WithMsg("invalid start_time")
+		}
+		startTime = t
+	}
+	if !startTime.Before(endTime) {
+		return time.Time{}, time.Time{}, code.ParamErr.WithMsg("start_time must be before end_time")
+	}
+	if endTime.Sub(startTime) > maxOrgStatsPeriod {
+		return time.Time{}, time.Time{}, code.ParamErr.WithMsg("time range must not exceed 366 days")
+	}
+	return startTime, endTime, nil
+}
+
+// @Summary 获取实验室排队预测
+// @Description 按排队顺序返回实验室排队中和运行中执行的预计完成时间，根据工作流或各设备步骤的历史时长估算
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_id path int true "实验室ID" example(1)
+// @Success 200 {object} common.Resp{data=model.QueueForecast}
+// @Router /v1/lab/{lab_id}/queue [get]
+func (h *Handler) GetQueueForecast(ctx *gin.Context) {
+	labID, err := strconv.ParseInt(ctx.Param("lab_id"), 10, 64)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab_id"))
+		return
+	}
+	if err := h.checkMember(ctx, labID); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	forecast, err := h.estimator.Queue(ctx, labID)
+	common.Reply(ctx, err, forecast)
+}
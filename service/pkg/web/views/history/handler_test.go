@@ -1,12 +1,26 @@
 package history
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/jsonschema"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/db"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/model/migrate"
+	"github.com/scienceol/studio/service/pkg/repo"
+	"github.com/scienceol/studio/service/pkg/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewHandler(t *testing.T) {
@@ -15,11 +29,38 @@ func TestNewHandler(t *testing.T) {
 	assert.NotNil(t, handler.repo)
 }
 
+func newTestHandler() (*Handler, *testutil.FakeHistoryRepo) {
+	repo := testutil.NewFakeHistoryRepo()
+	return NewHandlerWithRepo(repo), repo
+}
+
+// listBody decodes the data of a v1 list response
+type listBody struct {
+	Items      []map[string]any `json:"items"`
+	Total      int64            `json:"total"`
+	TotalPages int              `json:"total_pages"`
+	HasMore    bool             `json:"has_more"`
+}
+
+func getList(t *testing.T, router *gin.Engine, path string) listBody {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Code int      `json:"code"`
+		Data listBody `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	require.Equal(t, 0, body.Code, w.Body.String())
+	return body.Data
+}
+
 func TestListWorkflowExecutionsMissingLabID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	handler := NewHandler()
+	handler, _ := newTestHandler()
 	router.GET("/history/workflow", handler.ListWorkflowExecutions)
 
 	req := httptest.NewRequest(http.MethodGet, "/history/workflow", nil)
@@ -32,15 +73,173 @@ func TestListWorkflowExecutionsMissingLabID(t *testing.T) {
 }
 
 func TestListWorkflowExecutionsWithParams(t *testing.T) {
-	// Skip this test as it requires database connection
-	t.Skip("Requires database connection")
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	handler, repo := newTestHandler()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	statuses := []model.ExecutionStatus{
+		model.ExecutionStatusSuccess,
+		model.ExecutionStatusFailed,
+		model.ExecutionStatusSuccess,
+		model.ExecutionStatusRunning,
+		model.ExecutionStatusSuccess,
+	}
+	for i, status := range statuses {
+		exec := testutil.NewExecution(1, status, base.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, repo.CreateWorkflowExecution(ctx, exec))
+		require.NoError(t, repo.CreateActionExecution(ctx, testutil.NewAction(exec, int64(10+i%2), status, exec.StartedAt)))
+	}
+	require.NoError(t, repo.CreateWorkflowExecution(ctx, testutil.NewExecution(2, model.ExecutionStatusSuccess, base)))
+
+	router := gin.New()
+	router.GET("/history/workflow", handler.ListWorkflowExecutions)
+
+	body := getList(t, router, "/history/workflow?lab_id=1&page_size=2")
+	assert.Equal(t, int64(5), body.Total)
+	assert.Equal(t, 3, body.TotalPages)
+	assert.True(t, body.HasMore)
+	require.Len(t, body.Items, 2)
+	assert.Equal(t, "success", body.Items[0]["status"])
+	assert.Equal(t, "running", body.Items[1]["status"])
+
+	body = getList(t, router, "/history/workflow?lab_id=1&page=3&page_size=2")
+	assert.Len(t, body.Items, 1)
+	assert.False(t, body.HasMore)
+
+	body = getList(t, router, "/history/workflow?lab_id=1&status=success")
+	assert.Equal(t, int64(3), body.Total)
+
+	body = getList(t, router, "/history/workflow?lab_id=1&device_id=11")
+	assert.Equal(t, int64(2), body.Total)
+
+	body = getList(t, router, "/history/workflow?lab_id=1&start_time=2025-01-01T01:00:00Z&end_time=2025-01-01T03:00:00Z")
+	assert.Equal(t, int64(3), body.Total)
+
+	body = getList(t, router, "/history/workflow?lab_id=1&page_size=4&include_total=false")
+	assert.Equal(t, int64(-1), body.Total)
+	assert.Equal(t, -1, body.TotalPages)
+	assert.True(t, body.HasMore)
+	assert.Len(t, body.Items, 4)
+}
+
+func TestListInvalidFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, _ := newTestHandler()
+	router := gin.New()
+	router.GET("/history/workflow", handler.ListWorkflowExecutions)
+	router.GET("/history/device", handler.ListDeviceEvents)
+	router.GET("/lab/:lab_id/stats", handler.GetLabStats)
+
+	// 格式错误的过滤条件逐个字段返回参数错误，而不是被忽略
+	for path, invalid := range map[string]int{
+		"/history/workflow?lab_id=1&start_time=yesterday&status=done":  2,
+		"/history/workflow?lab_id=1&page=0&page_size=101&view_id=bad":  3,
+		"/history/device?lab_id=1&event_type=boom&end_time=2025-01-01": 2,
+		"/lab/1/stats?start_time=2025-13-01T00:00:00Z":                 1,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body struct {
+			Code  code.ErrCode `json:"code"`
+			Error struct {
+				Detail []jsonschema.FieldError `json:"detail"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), path)
+		assert.Equal(t, code.ParamErr, body.Code, path)
+		assert.Len(t, body.Error.Detail, invalid, path)
+	}
+
+	// 未知的状态提示允许的取值
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/workflow?lab_id=1&status=banana", nil))
+	assert.Contains(t, w.Body.String(), "[pending running success failed cancelled timeout]")
+}
+
+func TestListWorkflowExecutionsRepoError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, repo := newTestHandler()
+	repo.Err = assert.AnError
+	router := gin.New()
+	router.GET("/history/workflow", handler.ListWorkflowExecutions)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/workflow?lab_id=1", nil))
+	assert.NotContains(t, w.Body.String(), `"code":0`)
+}
+
+func TestListDeviceEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	handler, repo := newTestHandler()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.CreateDeviceEventBatch(ctx, []*model.DeviceEventHistory{
+		testutil.NewDeviceEvent(1, 10, model.DeviceEventConnected, base),
+		testutil.NewDeviceEvent(1, 10, model.DeviceEventError, base.Add(time.Minute)),
+		testutil.NewDeviceEvent(1, 11, model.DeviceEventError, base.Add(2*time.Minute)),
+		testutil.NewDeviceEvent(2, 10, model.DeviceEventError, base),
+	}))
+
+	router := gin.New()
+	router.GET("/history/device", handler.ListDeviceEvents)
+
+	body := getList(t, router, "/history/device?lab_id=1")
+	assert.Equal(t, int64(3), body.Total)
+	require.Len(t, body.Items, 3)
+	assert.Equal(t, "2025-01-01T00:02:00Z", body.Items[0]["timestamp"])
+
+	body = getList(t, router, "/history/device?lab_id=1&event_type=error&device_id=10")
+	assert.Equal(t, int64(1), body.Total)
+}
+
+func TestListFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	handler, repo := newTestHandler()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.CreateWorkflowExecution(ctx, testutil.NewExecution(1, model.ExecutionStatusSuccess, base)))
+	require.NoError(t, repo.CreateDeviceEventBatch(ctx, []*model.DeviceEventHistory{
+		testutil.NewDeviceEvent(1, 10, model.DeviceEventConnected, base),
+	}))
+
+	router := gin.New()
+	router.GET("/history/workflow", handler.ListWorkflowExecutions)
+	router.GET("/history/device", handler.ListDeviceEvents)
+
+	body := getList(t, router, "/history/workflow?lab_id=1&fields=uuid,status&fields=pinned")
+	require.Len(t, body.Items, 1)
+	assert.Len(t, body.Items[0], 3)
+	assert.Equal(t, "success", body.Items[0]["status"])
+	assert.Contains(t, body.Items[0], "uuid")
+	assert.Equal(t, false, body.Items[0]["pinned"])
+
+	body = getList(t, router, "/history/device?lab_id=1&fields=timestamp")
+	require.Len(t, body.Items, 1)
+	assert.Equal(t, map[string]any{"timestamp": "2025-01-01T00:00:00Z"}, body.Items[0])
+
+	// v2 的字段名与 v1 不同
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/workflow?lab_id=1&fields=workflow", nil))
+	assert.NotContains(t, w.Body.String(), `"code":0`)
+
+	assert.Equal(t, []string{"id", "started_at", "status", "uuid"},
+		fieldSet{"uuid": true, "status": true, "pinned": true}.columns(workflowFieldsV1, workflowKeyColumns))
+	assert.Equal(t, []string{"id", "started_at", "workflow_name", "workflow_uuid"},
+		fieldSet{"workflow": true}.columns(workflowFieldsV2, workflowKeyColumns))
+	// 不传 fields 时也不查询响应中没有的输入输出
+	assert.NotContains(t, fieldSet(nil).columns(workflowFieldsV1, workflowKeyColumns), "result")
 }
 
 func TestListDeviceEventsMissingLabID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	handler := NewHandler()
+	handler, _ := newTestHandler()
 	router.GET("/history/device", handler.ListDeviceEvents)
 
 	req := httptest.NewRequest(http.MethodGet, "/history/device", nil)
@@ -55,7 +254,7 @@ func TestGetWorkflowExecutionInvalidUUID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	handler := NewHandler()
+	handler, _ := newTestHandler()
 	router.GET("/history/workflow/execution/:execution_uuid", handler.GetWorkflowExecution)
 
 	req := httptest.NewRequest(http.MethodGet, "/history/workflow/execution/invalid-uuid", nil)
@@ -67,11 +266,52 @@ func TestGetWorkflowExecutionInvalidUUID(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestGetLabStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	handler, repo := newTestHandler()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breached, met := true, false
+	for i, status := range []model.ExecutionStatus{model.ExecutionStatusSuccess, model.ExecutionStatusSuccess, model.ExecutionStatusFailed, model.ExecutionStatusRunning} {
+		exec := testutil.NewExecution(1, status, base.Add(time.Duration(i)*time.Hour))
+		if i == 0 {
+			exec.SLABreached = &met
+		}
+		if i == 2 {
+			exec.SLABreached = &breached
+		}
+		require.NoError(t, repo.CreateWorkflowExecution(ctx, exec))
+		require.NoError(t, repo.CreateActionExecution(ctx, testutil.NewAction(exec, 10, status, exec.StartedAt)))
+	}
+	require.NoError(t, repo.CreateDeviceEvent(ctx, testutil.NewDeviceEvent(1, 10, model.DeviceEventError, base)))
+
+	router := gin.New()
+	router.GET("/lab/:lab_id/stats", handler.GetLabStats)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lab/1/stats", nil))
+
+	var body struct {
+		Code int                `json:"code"`
+		Data model.HistoryStats `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, 0, body.Code)
+	assert.Equal(t, int64(4), body.Data.TotalExecutions)
+	assert.Equal(t, int64(2), body.Data.SuccessfulCount)
+	assert.Equal(t, int64(1), body.Data.FailedCount)
+	assert.InDelta(t, 50, body.Data.SuccessRate, 0.001)
+	assert.Equal(t, int64(2), body.Data.SLATracked)
+	assert.InDelta(t, 50, body.Data.SLACompliance, 0.001)
+	assert.Equal(t, int64(4), body.Data.TotalActionsCount)
+	assert.Equal(t, int64(1), body.Data.TotalDeviceEvents)
+}
+
 func TestGetLabStatsInvalidLabID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	handler := NewHandler()
+	handler, _ := newTestHandler()
 	router.GET("/lab/:lab_id/stats", handler.GetLabStats)
 
 	req := httptest.NewRequest(http.MethodGet, "/lab/invalid/stats", nil)
@@ -82,18 +322,309 @@ func TestGetLabStatsInvalidLabID(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
-func TestListResponseStruct(t *testing.T) {
-	resp := ListResponse{
-		Items:      []string{"item1", "item2"},
-		Total:      100,
-		Page:       1,
-		PageSize:   20,
-		TotalPages: 5,
+func TestGetActivityHeatmapInvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler, _ := newTestHandler()
+	router.GET("/lab/:lab_id/stats/heatmap", handler.GetActivityHeatmap)
+
+	for _, path := range []string{
+		"/lab/invalid/stats/heatmap",
+		"/lab/1/stats/heatmap?tz=Mars/Olympus",
+		"/lab/1/stats/heatmap?start_time=2025-02-01T00:00:00Z&end_time=2025-01-01T00:00:00Z",
+		"/lab/1/stats/heatmap?start_time=2023-01-01T00:00:00Z&end_time=2025-01-01T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"code":`, path)
+		assert.NotContains(t, w.Body.String(), `"code":0`, path)
+	}
+}
+
+func TestGetDeviceUtilizationInvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler, _ := newTestHandler()
+	router.GET("/lab/:lab_id/stats/device-utilization", handler.GetDeviceUtilization)
+
+	for _, path := range []string{
+		"/lab/invalid/stats/device-utilization",
+		"/lab/1/stats/device-utilization?end_time=yesterday",
+		"/lab/1/stats/device-utilization?start_time=2025-02-01T00:00:00Z&end_time=2025-01-01T00:00:00Z",
+		"/lab/1/stats/device-utilization?start_time=2023-01-01T00:00:00Z&end_time=2025-01-01T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"code":`, path)
+		assert.NotContains(t, w.Body.String(), `"code":0`, path)
+	}
+}
+
+func TestNewListResponse(t *testing.T) {
+	params := &model.HistoryQueryParams{Page: 2, PageSize: 20}
+	items := []DeviceEventResponse{{EventType: "lock_acquired"}}
+
+	resp, ok := newListResponse(items, nil, params, model.PageCount{Total: 101, HasMore: true}).(*common.ListResp[DeviceEventResponse])
+	require.True(t, ok)
+	assert.Equal(t, items, resp.Items)
+	assert.Equal(t, int64(101), resp.Total)
+	assert.Equal(t, 2, resp.Page)
+	assert.Equal(t, 6, resp.TotalPages)
+	assert.True(t, resp.HasMore)
+
+	// 总数未统计时 total_pages 同为 -1，稀疏字段集的条目只保留请求的字段
+	sparsed, ok := newListResponse(items, fieldSet{"event_type": true}, params, model.PageCount{Total: -1}).(*common.ListResp[any])
+	require.True(t, ok)
+	assert.Equal(t, -1, sparsed.TotalPages)
+	assert.False(t, sparsed.HasMore)
+	data, err := json.Marshal(sparsed.Items)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"event_type":"lock_acquired"}]`, string(data))
+}
+
+
+func TestParseInclude(t *testing.T) {
+	withActions, err := parseInclude(nil)
+	assert.NoError(t, err)
+	assert.False(t, withActions)
+
+	withActions, err = parseInclude([]string{"actions"})
+	assert.NoError(t, err)
+	assert.True(t, withActions)
+
+	withActions, err = parseInclude([]string{" actions,"})
+	assert.NoError(t, err)
+	assert.True(t, withActions)
+
+	_, err = parseInclude([]string{"actions,materials"})
+	assert.Error(t, err)
+}
+
+func TestSearchMissingQuestion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler, _ := newTestHandler()
+	router.POST("/history/search", handler.Search)
+
+	req := httptest.NewRequest(http.MethodPost, "/history/search", strings.NewReader(`{"lab_id": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Question")
+}
+
+func TestListChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	// 动作的审批记录从数据库读取
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+	handler, repo := newTestHandler()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	exec := testutil.NewExecution(1, model.ExecutionStatusSuccess, base)
+	exec.CreatedAt, exec.UpdatedAt = base, base
+	require.NoError(t, repo.CreateWorkflowExecution(ctx, exec))
+	action := testutil.NewAction(exec, 10, model.ExecutionStatusSuccess, base)
+	action.CreatedAt, action.UpdatedAt = base, base.Add(time.Second)
+	require.NoError(t, repo.CreateActionExecution(ctx, action))
+	event := testutil.NewDeviceEvent(1, 10, model.DeviceEventConnected, base)
+	event.CreatedAt, event.UpdatedAt = base, base
+	require.NoError(t, repo.CreateDeviceEvent(ctx, event))
+	// 刚写入的记录等待提交后再返回
+	require.NoError(t, repo.CreateDeviceEvent(ctx, testutil.NewDeviceEvent(1, 10, model.DeviceEventError, base)))
+
+	router := gin.New()
+	router.GET("/history/changes", handler.ListChanges)
+	get := func(path string) ListChangesResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body struct {
+			Code int                 `json:"code"`
+			Data ListChangesResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+		require.Equal(t, 0, body.Code, w.Body.String())
+		return body.Data
 	}
 
-	assert.Equal(t, int64(100), resp.Total)
-	assert.Equal(t, 1, resp.Page)
-	assert.Equal(t, 20, resp.PageSize)
-	assert.Equal(t, 5, resp.TotalPages)
+	page := get("/history/changes?lab_id=1&limit=2")
+	assert.True(t, page.HasMore)
+	require.Len(t, page.Changes, 2)
+	assert.Equal(t, model.HistoryChangeWorkflow, page.Changes[0].Kind)
+	assert.Equal(t, exec.UUID, page.Changes[0].Execution.UUID)
+	assert.Equal(t, model.HistoryChangeEvent, page.Changes[1].Kind)
+	assert.Equal(t, changeCreated, page.Changes[1].Op)
+
+	page = get("/history/changes?lab_id=1&limit=2&since=" + page.Watermark)
+	assert.False(t, page.HasMore)
+	require.Len(t, page.Changes, 1)
+	assert.Equal(t, action.UUID, page.Changes[0].Action.UUID)
+	assert.Equal(t, changeUpdated, page.Changes[0].Op)
+
+	last := page.Watermark
+	page = get("/history/changes?lab_id=1&wait=1&since=" + last)
+	assert.Empty(t, page.Changes)
+	assert.Equal(t, last, page.Watermark)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/changes?lab_id=1&since=bad", nil))
+	assert.NotContains(t, w.Body.String(), `"code":0`)
+}
+
+func TestGetWorkflowExecutionApproval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+	handler := NewHandler()
+
+	exec := testutil.NewExecution(1, model.ExecutionStatusRunning, time.Now())
+	require.NoError(t, handler.repo.CreateWorkflowExecution(ctx, exec))
+	device := &model.MaterialNode{LabID: 1, Name: "pump", DisplayName: "pump", Type: model.MATERIALDEVICE}
+	require.NoError(t, repo.NewBaseDB().CreateData(ctx, device))
+
+	// 调度器只知道任务 uuid 和设备名，审批的动作记录由仓库关联到执行和设备
+	req := &model.ApprovalRequest{
+		LabID:       1,
+		TaskUUID:    exec.UUID,
+		NodeUUID:    uuid.NewV4(),
+		StepName:    "add reagent",
+		RequestedBy: "starter",
+	}
+	require.NoError(t, handler.approvalRepo.CreateApproval(ctx, &model.ActionExecutionHistory{
+		LabID:      1,
+		DeviceName: "pump",
+		ActionType: "unilabos_msgs/action/Add",
+		ActionName: "add",
+	}, req))
+	_, err := handler.approvalRepo.DecideApproval(ctx, req.UUID, model.ApprovalStatusApproved, "reviewer", nil)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/history/workflow/execution/:execution_uuid", handler.GetWorkflowExecution)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/workflow/execution/"+exec.UUID.String(), nil))
+	var body struct {
+		Code int `json:"code"`
+		Data struct {
+			Actions []ActionExecutionResponse `json:"actions"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	require.Equal(t, 0, body.Code, w.Body.String())
+
+	require.Len(t, body.Data.Actions, 1)
+	action := body.Data.Actions[0]
+	assert.Equal(t, device.UUID, action.DeviceUUID)
+	require.NotNil(t, action.Approval)
+	assert.Equal(t, req.UUID, action.Approval.UUID)
+	assert.Equal(t, model.ApprovalStatusApproved, action.Approval.Status)
+	require.NotNil(t, action.Approval.ReviewerID)
+	assert.Equal(t, "reviewer", *action.Approval.ReviewerID)
 }
 
+func TestListSlowestStepsInvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler, _ := newTestHandler()
+	router.GET("/lab/:lab_id/stats/slowest-steps", handler.ListSlowestSteps)
+
+	for _, path := range []string{
+		"/lab/invalid/stats/slowest-steps",
+		"/lab/1/stats/slowest-steps?start_time=yesterday",
+		"/lab/1/stats/slowest-steps?end_time=2025-13-01T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body struct {
+			Code code.ErrCode `json:"code"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), path)
+		assert.Equal(t, code.ParamErr, body.Code, path)
+	}
+}
+
+func TestStepDurationBudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	db.InitPostgres(ctx, &db.Config{Driver: db.DriverSQLite, SQLitePath: ":memory:"})
+	t.Cleanup(func() { db.ClosePostgres(ctx) })
+	require.NoError(t, migrate.Table(ctx))
+	handler := NewHandler()
+
+	exec := testutil.NewExecution(1, model.ExecutionStatusSuccess, time.Now())
+	require.NoError(t, handler.repo.CreateWorkflowExecution(ctx, exec))
+	for _, a := range []struct {
+		name       string
+		durationMs int64
+		expectedMs int64
+	}{
+		{"aspirate", 3000, 2000},
+		{"dispense", 1500, 2000},
+		{"mix", 1000, 0},
+	} {
+		action := testutil.NewAction(exec, 10, model.ExecutionStatusSuccess, time.Now())
+		action.ActionName, action.DurationMs, action.ExpectedMs = a.name, a.durationMs, a.expectedMs
+		require.NoError(t, handler.repo.CreateActionExecution(ctx, action))
+	}
+
+	router := gin.New()
+	router.GET("/history/workflow/execution/:execution_uuid", handler.GetWorkflowExecution)
+	router.GET("/lab/:lab_id/stats/slowest-steps", handler.ListSlowestSteps)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/workflow/execution/"+exec.UUID.String(), nil))
+	var detail struct {
+		Code int `json:"code"`
+		Data struct {
+			Actions []ActionExecutionResponse `json:"actions"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail), w.Body.String())
+	require.Equal(t, 0, detail.Code, w.Body.String())
+	actions := make(map[string]ActionExecutionResponse)
+	for _, a := range detail.Data.Actions {
+		actions[a.ActionName] = a
+	}
+	require.Len(t, actions, 3)
+
+	// 超出预算为正偏差，提前完成为负偏差，未设预算时不标记
+	require.NotNil(t, actions["aspirate"].DeviationMs)
+	assert.Equal(t, int64(1000), *actions["aspirate"].DeviationMs)
+	assert.True(t, actions["aspirate"].OverBudget)
+	require.NotNil(t, actions["dispense"].DeviationMs)
+	assert.Equal(t, int64(-500), *actions["dispense"].DeviationMs)
+	assert.False(t, actions["dispense"].OverBudget)
+	assert.Nil(t, actions["mix"].DeviationMs)
+	assert.False(t, actions["mix"].OverBudget)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lab/1/stats/slowest-steps?limit=2", nil))
+	var slowest struct {
+		Code int                        `json:"code"`
+		Data []*model.StepDurationStats `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &slowest), w.Body.String())
+	require.Equal(t, 0, slowest.Code, w.Body.String())
+	require.Len(t, slowest.Data, 2)
+	assert.Equal(t, "aspirate", slowest.Data[0].ActionName)
+	assert.Equal(t, int64(1), slowest.Data[0].OverBudgetCount)
+	assert.InDelta(t, 1000, slowest.Data[0].AvgDeviationMs, 0.001)
+	assert.Equal(t, "dispense", slowest.Data[1].ActionName)
+	assert.Zero(t, slowest.Data[1].OverBudgetCount)
+}
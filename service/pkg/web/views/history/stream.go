@@ -0,0 +1,187 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/middleware/apiversion"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// MIMENDJSON 列表接口按该类型请求时逐行流式返回条目
+const MIMENDJSON = "application/x-ndjson"
+
+const (
+	defaultStreamMaxRows       = 100000
+	defaultStreamFlushRows     = 500
+	defaultStreamFlushInterval = time.Second
+	defaultStreamMaxDuration   = 10 * time.Minute
+)
+
+// errStreamCapped stops reading once the row cap is reached
+var errStreamCapped = errors.New("stream row cap reached")
+
+// StreamError is the reason a streamed list stopped early
+type StreamError struct {
+	Code code.ErrCode `json:"code"`
+	Msg  string       `json:"msg"`
+}
+
+// StreamEnd is the summary of a streamed list, sent as its last line
+type StreamEnd struct {
+	Rows      int          `json:"rows"`
+	Truncated bool         `json:"truncated"`       // 超过行数上限，之后的条目未返回
+	Error     *StreamError `json:"error,omitempty"` // 中途失败，之前的条目已返回
+}
+
+// streamTrailer wraps the summary so it cannot be mistaken for an item
+type streamTrailer struct {
+	End StreamEnd `json:"end"`
+}
+
+// wantsStream reports whether the request asks for a streamed NDJSON list
+func wantsStream(ctx *gin.Context) bool {
+	return strings.Contains(ctx.GetHeader("Accept"), MIMENDJSON)
+}
+
+// streamLimits returns the configured streaming limits with defaults for unset values
+func (h *Handler) streamLimits() config.ListStreamingConfig {
+	conf := h.streaming()
+	if conf.MaxRows <= 0 {
+		conf.MaxRows = defaultStreamMaxRows
+	}
+	if conf.FlushRows <= 0 {
+		conf.FlushRows = defaultStreamFlushRows
+	}
+	if conf.FlushIntervalMs <= 0 {
+		conf.FlushIntervalMs = int(defaultStreamFlushInterval / time.Millisecond)
+	}
+	if conf.MaxDurationSecs <= 0 {
+		conf.MaxDurationSecs = int(defaultStreamMaxDuration / time.Second)
+	}
+	return conf
+}
+
+// streamWorkflowExecutions streams the executions of the request as NDJSON
+func (h *Handler) streamWorkflowExecutions(ctx *gin.Context, req *ListWorkflowExecutionsRequest) {
+	q, err := h.parseExecutionQuery(ctx, req)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	if q.withActions {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("include is not supported when streaming"))
+		return
+	}
+	// 置顶状态在读取前一次查出，读取期间不再查询数据库
+	pinned := map[int64]bool{}
+	if q.userInfo != nil && (q.fields == nil || q.fields["pinned"]) {
+		if pinned, err = h.repo.LabPinnedExecutionIDs(ctx, q.userInfo.ID, q.params.LabID); err != nil {
+			common.ReplyErr(ctx, err)
+			return
+		}
+	}
+
+	v2 := apiversion.Get(ctx) == apiversion.V2
+	h.stream(ctx, q.fields, func(sctx context.Context, limit int, write func(any) error) error {
+		return h.repo.StreamWorkflowExecutions(sctx, q.params, limit, func(e *model.WorkflowExecutionHistory) error {
+			if v2 {
+				return write(newWorkflowExecutionV2(e, pinned[e.ID]))
+			}
+			return write(WorkflowExecutionListItem{WorkflowExecutionResponse: newWorkflowExecutionResponse(e, pinned[e.ID])})
+		})
+	})
+}
+
+// streamDeviceEvents streams the device events of the request as NDJSON
+func (h *Handler) streamDeviceEvents(ctx *gin.Context, req *ListDeviceEventsRequest) {
+	params, fields, err := h.parseDeviceEventQuery(ctx, req)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	v2 := apiversion.Get(ctx) == apiversion.V2
+	h.stream(ctx, fields, func(sctx context.Context, limit int, write func(any) error) error {
+		return h.repo.StreamDeviceEvents(sctx, params, limit, func(e *model.DeviceEventHistory) error {
+			if v2 {
+				return write(newDeviceEventV2(e))
+			}
+			return write(newDeviceEventResponse(e))
+		})
+	})
+}
+
+// stream writes the items read by run one JSON object per line, flushing
+// every FlushRows items or FlushIntervalMs and ending with a StreamEnd line.
+// Reading stops at the row cap, after MaxDurationSecs or when the client
+// disconnects; errors after the first line are reported in the StreamEnd
+func (h *Handler) stream(ctx *gin.Context, fields fieldSet, run func(ctx context.Context, limit int, write func(any) error) error) {
+	limits := h.streamLimits()
+	maxDuration := time.Duration(limits.MaxDurationSecs) * time.Second
+	flushInterval := time.Duration(limits.FlushIntervalMs) * time.Millisecond
+
+	// 客户端断开时取消查询，不依赖 gin 是否回退到请求的 context
+	sctx, cancel := context.WithTimeout(ctx, maxDuration)
+	defer cancel()
+	stop := context.AfterFunc(ctx.Request.Context(), cancel)
+	defer stop()
+	// 服务端的写超时按普通请求设置，流式列表延长到最长耗时
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Now().Add(maxDuration)); err != nil &&
+		!errors.Is(err, http.ErrNotSupported) {
+		logger.Warnf(ctx, "stream set write deadline fail: %+v", err)
+	}
+
+	ctx.Header("Content-Type", MIMENDJSON)
+	ctx.Status(http.StatusOK)
+	enc := json.NewEncoder(ctx.Writer)
+	end := StreamEnd{}
+	pending := 0
+	flushedAt := time.Now()
+	err := run(sctx, limits.MaxRows+1, func(item any) error {
+		if end.Rows >= limits.MaxRows {
+			end.Truncated = true
+			return errStreamCapped
+		}
+		if err := ctx.Request.Context().Err(); err != nil {
+			return err
+		}
+		if err := sctx.Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(sparseItem(item, fields)); err != nil {
+			return err
+		}
+		end.Rows++
+		pending++
+		if pending >= limits.FlushRows || time.Since(flushedAt) >= flushInterval {
+			ctx.Writer.Flush()
+			pending = 0
+			flushedAt = time.Now()
+		}
+		return nil
+	})
+	if ctx.Request.Context().Err() != nil {
+		logger.Infof(ctx, "stream %s stopped by client after %d rows", ctx.FullPath(), end.Rows)
+		return
+	}
+	if err != nil && !errors.Is(err, errStreamCapped) {
+		logger.Errorf(ctx, "stream %s fail after %d rows: %+v", ctx.FullPath(), end.Rows, err)
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = code.QueryRecordErr.WithMsg("stream exceeded the max duration")
+		}
+		errCode, e := common.LocalizeErr(ctx, err)
+		end.Error = &StreamError{Code: errCode, Msg: e.Msg}
+	}
+	_ = enc.Encode(streamTrailer{End: end})
+	ctx.Writer.Flush()
+}
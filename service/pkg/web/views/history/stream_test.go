@@ -0,0 +1,106 @@
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getStream requests path as NDJSON and decodes the item lines and the end line
+func getStream(t *testing.T, router *gin.Engine, path string) ([]map[string]any, *StreamEnd) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept", MIMENDJSON)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, MIMENDJSON, w.Header().Get("Content-Type"), w.Body.String())
+
+	var items []map[string]any
+	var end *StreamEnd
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		require.Nil(t, end, "line after the end line")
+		var line map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		if data, ok := line["end"]; ok && len(line) == 1 {
+			end = &StreamEnd{}
+			require.NoError(t, json.Unmarshal(data, end))
+			continue
+		}
+		item := map[string]any{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &item))
+		items = append(items, item)
+	}
+	return items, end
+}
+
+func TestStreamList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	handler, repo := newTestHandler()
+	handler.streaming = func() config.ListStreamingConfig {
+		return config.ListStreamingConfig{MaxRows: 3, FlushRows: 2}
+	}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		require.NoError(t, repo.CreateWorkflowExecution(ctx, testutil.NewExecution(1, model.ExecutionStatusSuccess, base.Add(time.Duration(i)*time.Minute))))
+	}
+	require.NoError(t, repo.CreateDeviceEventBatch(ctx, []*model.DeviceEventHistory{
+		testutil.NewDeviceEvent(1, 10, model.DeviceEventConnected, base),
+		testutil.NewDeviceEvent(1, 10, model.DeviceEventDisconnected, base.Add(time.Minute)),
+	}))
+
+	router := gin.New()
+	router.GET("/history/workflow", handler.ListWorkflowExecutions)
+	router.GET("/history/device", handler.ListDeviceEvents)
+
+	// 超过行数上限时截断，忽略分页
+	items, end := getStream(t, router, "/history/workflow?lab_id=1&page_size=1&fields=started_at")
+	require.Len(t, items, 3)
+	assert.Equal(t, map[string]any{"started_at": "2025-01-01T00:04:00Z"}, items[0])
+	assert.Equal(t, map[string]any{"started_at": "2025-01-01T00:02:00Z"}, items[2])
+	require.NotNil(t, end)
+	assert.Equal(t, StreamEnd{Rows: 3, Truncated: true}, *end)
+
+	items, end = getStream(t, router, "/history/device?lab_id=1")
+	require.Len(t, items, 2)
+	assert.Equal(t, "disconnected", items[0]["event_type"])
+	assert.Equal(t, StreamEnd{Rows: 2}, *end)
+
+	// 读取失败时在最后一行返回原因
+	repo.Err = errors.New("query fail")
+	items, end = getStream(t, router, "/history/device?lab_id=1")
+	assert.Empty(t, items)
+	require.NotNil(t, end.Error)
+	repo.Err = nil
+
+	// 不支持展开动作
+	req := httptest.NewRequest(http.MethodGet, "/history/workflow?lab_id=1&include=actions", nil)
+	req.Header.Set("Accept", MIMENDJSON)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	// 客户端断开后不再写出
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	req = httptest.NewRequest(http.MethodGet, "/history/workflow?lab_id=1", nil).WithContext(cancelled)
+	req.Header.Set("Accept", MIMENDJSON)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Body.String())
+}
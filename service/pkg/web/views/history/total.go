@@ -0,0 +1,110 @@
+package history
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	r "github.com/redis/go-redis/v9"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+const (
+	totalCacheTTL = 30 * time.Second
+	// 总数超过阈值的列表在 largeListTTL 内默认不再统计总数
+	largeListThreshold = 100000
+	largeListTTL       = 10 * time.Minute
+)
+
+// totalCache 按过滤条件缓存列表总数，未初始化 redis 时不缓存
+type totalCache struct {
+	client *r.Client
+}
+
+func totalKey(kind string, params *model.HistoryQueryParams) string {
+	filters := *params
+	filters.Page, filters.PageSize, filters.SkipTotal, filters.Cursor, filters.Columns = 0, 0, false, nil, nil
+	data, _ := json.Marshal(filters)
+	sum := sha1.Sum(data)
+	return fmt.Sprintf("history:total:%s:%d:%s", kind, params.LabID, hex.EncodeToString(sum[:]))
+}
+
+func largeKey(kind string, labID int64) string {
+	return fmt.Sprintf("history:total:large:%s:%d", kind, labID)
+}
+
+// prepare 决定列表是否统计总数：include_total=false 时不统计；命中缓存时使用缓存的总数；
+// 未指定 include_total 且该实验室的列表近期超过阈值时不统计
+func (c *totalCache) prepare(ctx context.Context, kind string, params *model.HistoryQueryParams, includeTotal *bool) (cached int64, ok bool) {
+	if includeTotal != nil && !*includeTotal {
+		params.SkipTotal = true
+		return 0, false
+	}
+	if c.client == nil {
+		return 0, false
+	}
+
+	total, err := c.client.Get(ctx, totalKey(kind, params)).Int64()
+	if err == nil {
+		params.SkipTotal = true
+		return total, true
+	}
+	if err != r.Nil {
+		logger.Warnf(ctx, "get history total cache fail: %+v", err)
+		return 0, false
+	}
+	if includeTotal == nil && c.client.Exists(ctx, largeKey(kind, params.LabID)).Val() > 0 {
+		params.SkipTotal = true
+	}
+	return 0, false
+}
+
+// store 缓存统计得到的总数
+func (c *totalCache) store(ctx context.Context, kind string, params *model.HistoryQueryParams, total int64) {
+	if c.client == nil || params.SkipTotal {
+		return
+	}
+	if err := c.client.Set(ctx, totalKey(kind, params), total, totalCacheTTL).Err(); err != nil {
+		logger.Warnf(ctx, "set history total cache fail: %+v", err)
+	}
+	if total > largeListThreshold {
+		c.client.Set(ctx, largeKey(kind, params.LabID), 1, largeListTTL)
+	}
+}
+
+// pageCount 将缓存的总数合并到列表结果
+func pageCount(params *model.HistoryQueryParams, count model.PageCount, cached int64, hit bool) model.PageCount {
+	if !hit {
+		return count
+	}
+	count.Total = cached
+	return count
+}
+
+// newListResponse 总数未统计时 total 和 total_pages 为 -1，请求稀疏字段集时条目只包含这些字段
+func newListResponse[T any](items []T, fields fieldSet, params *model.HistoryQueryParams, count model.PageCount) any {
+	if fields != nil {
+		return listPage(sparseItems(items, fields), params, count)
+	}
+	return listPage(items, params, count)
+}
+
+// listPage builds the shared list envelope from the count of the repository
+func listPage[T any](items []T, params *model.HistoryQueryParams, count model.PageCount) *common.ListResp[T] {
+	resp := common.NewListResp(items, count.Total, params.Page, params.PageSize)
+	resp.HasMore = count.HasMore
+	resp.NextCursor = nextCursor(count)
+	return resp
+}
+
+func nextCursor(count model.PageCount) string {
+	if count.Next == nil {
+		return ""
+	}
+	return count.Next.Encode()
+}
@@ -0,0 +1,156 @@
+package history
+
+import (
+	"time"
+
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// PaginationV2 is the pagination of v2 list responses, Total and TotalPages
+// are null when the total is not counted
+type PaginationV2 struct {
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	Total      *int64 `json:"total"`
+	TotalPages *int   `json:"total_pages"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"` // 下一页的游标，只有工作流执行列表返回
+}
+
+// ListResponseV2 represents a paginated list response of the v2 API
+type ListResponseV2 struct {
+	Items      interface{}  `json:"items"`
+	Pagination PaginationV2 `json:"pagination"`
+}
+
+// WorkflowRefV2 identifies the workflow of an execution
+type WorkflowRefV2 struct {
+	UUID uuid.UUID `json:"uuid"`
+	Name string    `json:"name"`
+}
+
+// StepCountsV2 counts the steps of an execution
+type StepCountsV2 struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// ExecutionErrorV2 describes why an execution failed
+type ExecutionErrorV2 struct {
+	Message  string `json:"message"`
+	Category string `json:"category,omitempty"` // 按实验室的错误分类规则确定
+}
+
+// ExecutionSLAV2 is the SLA target of an execution and whether it was missed,
+// Breached is nil until the execution finishes
+type ExecutionSLAV2 struct {
+	TargetMs int64 `json:"target_ms"`
+	Breached *bool `json:"breached"`
+}
+
+// WorkflowExecutionV2 represents a workflow execution in v2 responses
+type WorkflowExecutionV2 struct {
+	UUID             uuid.UUID                 `json:"uuid"`
+	Workflow         WorkflowRefV2             `json:"workflow"`
+	BatchID          *int64                    `json:"batch_id"`
+	ExperimentID     *int64                    `json:"experiment_id"`
+	Status           model.ExecutionStatus     `json:"status"`
+	Steps            StepCountsV2              `json:"steps"`
+	StartedAt        time.Time                 `json:"started_at"`
+	CompletedAt      *time.Time                `json:"completed_at"`
+	DurationMs       int64                     `json:"duration_ms"`
+	Error            *ExecutionErrorV2         `json:"error"`
+	Labels           map[string]string         `json:"labels"`
+	Pinned           bool                      `json:"pinned"`
+	SLA              *ExecutionSLAV2           `json:"sla"`               // 未设置 SLA 时为空
+	Actions          []ActionExecutionResponse `json:"actions,omitempty"` // include=actions 时返回
+	ActionsTruncated bool                      `json:"actions_truncated,omitempty"`
+}
+
+// WorkflowExecutionDetailV2 represents detailed workflow execution in v2 responses
+type WorkflowExecutionDetailV2 struct {
+	WorkflowExecutionV2
+	Actions      []ActionExecutionResponse     `json:"actions"`
+	Dependencies *DependencyResponse           `json:"dependencies"`
+	Materials    []*model.MaterialUsageSummary `json:"materials"`
+	Estimate     *model.ExecutionEstimate      `json:"estimate,omitempty"`
+	Definition   *model.WorkflowSnapshot       `json:"definition"`
+}
+
+// DeviceEventV2 represents a device event in v2 responses
+type DeviceEventV2 struct {
+	UUID       uuid.UUID             `json:"uuid"`
+	DeviceUUID uuid.UUID             `json:"device_uuid"`
+	Type       model.DeviceEventType `json:"type"`
+	Data       interface{}           `json:"data"`
+	Timestamp  time.Time             `json:"timestamp"`
+}
+
+// v2 serializes the page in the v2 response shape
+func (p *executionPage) v2() ListResponseV2 {
+	items := make([]WorkflowExecutionV2, 0, len(p.executions))
+	for _, e := range p.executions {
+		item := newWorkflowExecutionV2(e, p.pinned[e.ID])
+		if p.withActions {
+			item.Actions, item.ActionsTruncated = p.includedActionsOf(e.ID)
+		}
+		items = append(items, item)
+	}
+	return newListResponseV2(sparse(items, p.fields), p.params, p.count)
+}
+
+func newWorkflowExecutionV2(e *model.WorkflowExecutionHistory, pinned bool) WorkflowExecutionV2 {
+	resp := WorkflowExecutionV2{
+		UUID:         e.UUID,
+		Workflow:     WorkflowRefV2{UUID: e.WorkflowUUID, Name: e.WorkflowName},
+		BatchID:      e.BatchID,
+		ExperimentID: e.ExperimentID,
+		Status:       e.Status,
+		Steps: StepCountsV2{
+			Total:     e.StepsTotal,
+			Completed: e.StepsCompleted,
+			Failed:    e.StepsFailed,
+		},
+		StartedAt:   e.StartedAt,
+		CompletedAt: e.CompletedAt,
+		DurationMs:  e.DurationMs,
+		Labels:      e.Labels.Data(),
+		Pinned:      pinned,
+	}
+	if e.ErrorMessage != nil {
+		resp.Error = &ExecutionErrorV2{Message: *e.ErrorMessage, Category: e.ErrorCategory}
+	}
+	if e.SLATargetMs > 0 {
+		resp.SLA = &ExecutionSLAV2{TargetMs: e.SLATargetMs, Breached: e.SLABreached}
+	}
+	return resp
+}
+
+func newDeviceEventV2(e *model.DeviceEventHistory) DeviceEventV2 {
+	return DeviceEventV2{
+		UUID:       e.UUID,
+		DeviceUUID: e.DeviceUUID,
+		Type:       e.EventType,
+		Data:       e.EventData,
+		Timestamp:  e.Timestamp,
+	}
+}
+
+// newListResponseV2 总数未统计时 total 和 total_pages 为 null
+func newListResponseV2(items any, params *model.HistoryQueryParams, count model.PageCount) ListResponseV2 {
+	pagination := PaginationV2{
+		Page:       params.Page,
+		PageSize:   params.PageSize,
+		HasMore:    count.HasMore,
+		NextCursor: nextCursor(count),
+	}
+	if count.Total >= 0 {
+		total, totalPages := count.Total, common.TotalPages(count.Total, params.PageSize)
+		pagination.Total = &total
+		pagination.TotalPages = &totalPages
+	}
+	return ListResponseV2{Items: items, Pagination: pagination}
+}
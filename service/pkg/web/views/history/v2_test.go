@@ -0,0 +1,43 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewListResponseV2(t *testing.T) {
+	params := &model.HistoryQueryParams{Page: 2, PageSize: 20}
+
+	resp := newListResponseV2([]int{}, params, model.PageCount{Total: 45, HasMore: true})
+	assert.Equal(t, int64(45), *resp.Pagination.Total)
+	assert.Equal(t, 3, *resp.Pagination.TotalPages)
+	assert.True(t, resp.Pagination.HasMore)
+
+	resp = newListResponseV2([]int{}, params, model.PageCount{Total: -1})
+	assert.Nil(t, resp.Pagination.Total)
+	assert.Nil(t, resp.Pagination.TotalPages)
+	assert.Equal(t, 2, resp.Pagination.Page)
+}
+
+func TestNewWorkflowExecutionV2(t *testing.T) {
+	msg := "device offline"
+	e := &model.WorkflowExecutionHistory{
+		WorkflowName:   "titration",
+		Status:         model.ExecutionStatusFailed,
+		StepsTotal:     5,
+		StepsCompleted: 3,
+		StepsFailed:    1,
+		ErrorMessage:   &msg,
+	}
+
+	resp := newWorkflowExecutionV2(e, true)
+	assert.Equal(t, "titration", resp.Workflow.Name)
+	assert.Equal(t, StepCountsV2{Total: 5, Completed: 3, Failed: 1}, resp.Steps)
+	assert.Equal(t, msg, resp.Error.Message)
+	assert.True(t, resp.Pinned)
+
+	e.ErrorMessage = nil
+	assert.Nil(t, newWorkflowExecutionV2(e, false).Error)
+}
@@ -0,0 +1,61 @@
+// Package historychain provides HTTP handlers for the hash chain of history records.
+package historychain
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/historychain"
+)
+
+// Handler handles history chain HTTP requests
+type Handler struct {
+	service *historychain.Service
+}
+
+// NewHandler creates a new history chain handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: historychain.New(),
+	}
+}
+
+// @Summary 校验历史记录哈希链
+// @Description 逐条校验实验室的工作流执行和审计日志哈希链，重新计算记录内容的哈希并与已发布的锚点比对，返回发现的篡改、删除和断链问题。按保留策略清理的记录只校验链接
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=model.ChainVerifyResult}
+// @Router /v1/lab/history/chain/verify [get]
+func (h *Handler) Verify(ctx *gin.Context) {
+	req := &historychain.VerifyReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Verify(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 哈希链锚点列表
+// @Description 获取实验室哈希链定期发布到外部的链头锚点及发布结果
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[model.HistoryChainAnchor]}
+// @Router /v1/lab/history/chain/anchor [get]
+func (h *Handler) ListAnchors(ctx *gin.Context) {
+	req := &historychain.AnchorListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.ListAnchors(ctx, req)
+	common.Reply(ctx, err, datas)
+}
@@ -0,0 +1,45 @@
+// Package historycopy provides HTTP handlers for copying history between environments.
+package historycopy
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/historycopy"
+)
+
+// Handler handles history copy HTTP requests
+type Handler struct {
+	service *historycopy.Service
+}
+
+// NewHandler creates a new history copy handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: historycopy.New(),
+	}
+}
+
+// @Summary 读取待复制的历史记录
+// @Description 按实验室和时间段分页读取脱敏后的历史记录，供 `history copy` 命令复制到本地开发环境。用户 ID 替换为稳定的别名，邮箱、手机号和 IP 被屏蔽，JSON 中的敏感字段被移除。生产环境不提供，仅管理员可操作
+// @Tags HistoryCopy
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param kind query string true "记录类型" Enums(workflow, action, device_event)
+// @Param start_time query string false "开始时间"
+// @Param end_time query string false "结束时间"
+// @Param after_id query int false "上一页返回的 next_after_id"
+// @Param limit query int false "每页数量，默认 500，最大 2000"
+// @Success 200 {object} common.Resp{data=historycopy.DumpResp}
+// @Router /v1/admin/history/dump [get]
+func (h *Handler) Dump(ctx *gin.Context) {
+	req := &historycopy.DumpReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Dump(ctx, req)
+	common.Reply(ctx, err, data)
+}
@@ -0,0 +1,111 @@
+// Package historyexport provides HTTP handlers for resumable history exports.
+package historyexport
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/historyexport"
+)
+
+// headerIdempotencyKey 客户端重试创建请求时携带相同的值，返回同一个导出
+const headerIdempotencyKey = "Idempotency-Key"
+
+// Handler handles history export HTTP requests
+type Handler struct {
+	service *historyexport.Service
+}
+
+// NewHandler creates a new history export handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: historyexport.New(),
+	}
+}
+
+// @Summary 创建历史记录导出
+// @Description 在后台将符合过滤条件的历史记录分片导出为 NDJSON，每个分片写入后保存检查点，中断后从检查点继续。相同 Idempotency-Key（未传时为相同的类型和过滤条件）返回已有的导出，失败的导出从检查点重新执行
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "幂等键"
+// @Param req body historyexport.CreateReq true "导出"
+// @Success 200 {object} common.Resp{data=historyexport.ExportResp}
+// @Router /v1/lab/history/export [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &historyexport.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req.IdempotencyKey = ctx.GetHeader(headerIdempotencyKey)
+
+	data, err := h.service.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取历史记录导出列表
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_id query int true "实验室ID"
+// @Success 200 {object} common.Resp{data=[]model.HistoryExport}
+// @Router /v1/lab/history/export [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &historyexport.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取历史记录导出进度
+// @Description 返回导出进度，导出完成后附带签名下载链接
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param uuid path string true "导出UUID"
+// @Success 200 {object} common.Resp{data=historyexport.ExportResp}
+// @Router /v1/lab/history/export/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	exportUUID, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid UUID"))
+		return
+	}
+
+	data, err := h.service.Get(ctx, exportUUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 下载历史记录导出
+// @Description 使用签名下载链接下载导出的 NDJSON 文件，每行一条记录
+// @Tags History
+// @Produce octet-stream
+// @Param token query string true "下载令牌"
+// @Success 200 {file} binary
+// @Router /v1/history/export/download [get]
+func (h *Handler) Download(ctx *gin.Context) {
+	data, r, err := h.service.Open(ctx, ctx.Query("token"))
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	defer r.Close()
+
+	// 导出文件较大时下载时间可能超过 server 的写超时
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	ctx.DataFromReader(http.StatusOK, data.Size, "application/x-ndjson", r, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": data.FileName()}),
+		"Cache-Control":       "private, no-store",
+	})
+}
@@ -0,0 +1,81 @@
+// Package historyimport provides HTTP handlers for importing execution history archives.
+package historyimport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/historyimport"
+)
+
+// Handler handles history import HTTP requests
+type Handler struct {
+	service *historyimport.Service
+}
+
+// NewHandler creates a new history import handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: historyimport.New(),
+	}
+}
+
+// @Summary 导入历史记录
+// @Description 请求体为实验室导出归档(zip)或单张历史表(jsonl/parquet)，在后台按 UUID 导入，仅实验室管理员可操作
+// @Tags History
+// @Accept octet-stream
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param format query string true "文件格式 zip | jsonl | parquet"
+// @Param table query string false "jsonl、parquet 文件对应的历史表"
+// @Param policy query string false "UUID 已存在时的处理方式 skip | overwrite | fail，默认 skip"
+// @Param dry_run query bool false "只校验并生成报告，不写入"
+// @Success 200 {object} common.Resp{data=model.HistoryImport}
+// @Router /v1/lab/history/import [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &historyimport.CreateReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, req, ctx.Request.Body)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取历史记录导入列表
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.HistoryImport}
+// @Router /v1/lab/history/import [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &historyimport.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取历史记录导入进度和校验报告
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param uuid path string true "导入UUID"
+// @Success 200 {object} common.Resp{data=model.HistoryImport}
+// @Router /v1/lab/history/import/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	importUUID, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid UUID"))
+		return
+	}
+
+	data, err := h.service.Get(ctx, importUUID)
+	common.Reply(ctx, err, data)
+}
@@ -0,0 +1,35 @@
+// Package hub provides the HTTP handler upgrading to the WebSocket hub.
+package hub
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/core/hub"
+)
+
+// Handler handles WebSocket hub connections
+type Handler struct {
+	hub *hub.Hub
+}
+
+// NewHandler creates a new WebSocket hub handler
+func NewHandler() *Handler {
+	return &Handler{
+		hub: hub.Default(),
+	}
+}
+
+// @Summary WebSocket 消息中心
+// @Description 连接后发送 {"action":"subscribe","msg_uuid":"...","data":{"topic":"..."}} 订阅主题，action 为 unsubscribe 时取消订阅。主题为 execution:<执行uuid>（执行所在实验室的成员）、lab:<实验室id>:events（实验室成员）和 notifications:<用户id>（仅本人），订阅时检查权限。主题的消息以 action 为 message 推送，data 为 hub.Message。单个用户的连接数和单个连接的主题数有上限
+// @Description 断线重连后订阅时在 data 中带上 resume_from（断线前收到的最后一条消息的 id），会先补发保留期内错过的消息，订阅结果为 hub.SubscribeResp，replay.complete 为 false 表示部分消息已超过保留期或被裁剪
+// @Tags Hub
+// @Accept json
+// @Produce json
+// @Success 200 {object} common.Resp{} "连接成功（协议升级）"
+// @Failure 200 {object} common.Resp{code=code.ErrCode} "未登录或连接数超过限制"
+// @Router /v1/ws/hub [get]
+func (h *Handler) Connect(ctx *gin.Context) {
+	if err := h.hub.Connect(ctx); err != nil {
+		common.ReplyErr(ctx, err)
+	}
+}
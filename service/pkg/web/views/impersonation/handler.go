@@ -0,0 +1,105 @@
+// Package impersonation provides HTTP handlers for admin impersonation sessions.
+package impersonation
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/impersonation"
+)
+
+// Handler handles impersonation HTTP requests
+type Handler struct {
+	service *impersonation.Service
+}
+
+// NewHandler creates a new impersonation handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: impersonation.New(),
+	}
+}
+
+// @Summary 创建模拟会话
+// @Description 管理员以实验室成员的身份访问该实验室，用于复现用户遇到的问题。返回的令牌以 "Impersonate <token>" 作为 Authorization 请求头，会话到期或撤销后失效，每个请求都写入审计日志
+// @Tags Impersonation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body impersonation.StartReq true "模拟会话"
+// @Success 200 {object} common.Resp{data=impersonation.StartResp}
+// @Router /v1/admin/impersonation [post]
+func (h *Handler) Start(ctx *gin.Context) {
+	req := &impersonation.StartReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	resp, err := h.service.Start(ctx, req)
+	common.Reply(ctx, err, resp)
+}
+
+// @Summary 获取模拟会话列表
+// @Tags Impersonation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param active query bool false "只返回有效的会话"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[model.ImpersonationSession]}
+// @Router /v1/admin/impersonation [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &impersonation.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	resp, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, resp)
+}
+
+// @Summary 撤销模拟会话
+// @Tags Impersonation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param uuid path string true "会话UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/admin/impersonation/{uuid} [delete]
+func (h *Handler) Revoke(ctx *gin.Context) {
+	sessionUUID, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid UUID"))
+		return
+	}
+
+	common.Reply(ctx, h.service.Revoke(ctx, sessionUUID))
+}
+
+// @Summary 获取审计日志
+// @Description 模拟会话的创建、撤销以及模拟会话发起的每个请求
+// @Tags Impersonation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param session_uuid query string false "会话UUID"
+// @Param impersonator_id query string false "管理员ID"
+// @Param lab_uuid query string false "实验室UUID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[model.AuditLog]}
+// @Router /v1/admin/impersonation/audit [get]
+func (h *Handler) ListAudit(ctx *gin.Context) {
+	req := &impersonation.AuditReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	resp, err := h.service.ListAudit(ctx, req)
+	common.Reply(ctx, err, resp)
+}
@@ -0,0 +1,75 @@
+// Package ingest provides HTTP handlers for replaying buffered edge results.
+package ingest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/ingest"
+)
+
+// Handler handles edge ingestion HTTP requests
+type Handler struct {
+	service *ingest.Service
+}
+
+// NewHandler creates a new edge ingestion handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: ingest.New(),
+	}
+}
+
+// @Summary 边缘端重放缓存的执行结果
+// @Description edge 在网络恢复后按序号重放离线期间缓存的动作执行结果，保留 edge 生成的 UUID 和原始时间；已写入的序号或 UUID 确认为 duplicate，无效的记录确认为 rejected
+// @Tags Ingest
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body ingest.IngestReq true "缓存的记录"
+// @Success 200 {object} common.Resp{data=ingest.IngestResp}
+// @Router /v1/edge/ingest [post]
+func (h *Handler) Ingest(ctx *gin.Context) {
+	req := &ingest.IngestReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Ingest(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 边缘端获取重放确认状态
+// @Description 获取流的高水位和缺失的序号，edge 可以丢弃高水位及以下的记录并从缺失的序号继续重放
+// @Tags Ingest
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param stream path string true "流标识"
+// @Success 200 {object} common.Resp{data=ingest.AckResp}
+// @Router /v1/edge/ingest/{stream} [get]
+func (h *Handler) Ack(ctx *gin.Context) {
+	data, err := h.service.Ack(ctx, ctx.Param("stream"))
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取实验室重放流
+// @Description 获取实验室各 edge 重放流的高水位和写入统计
+// @Tags Ingest
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.EdgeIngestStream}
+// @Router /v1/lab/edge/ingest/{lab_uuid} [get]
+func (h *Handler) ListStreams(ctx *gin.Context) {
+	labUUID, err := uuid.FromString(ctx.Param("lab_uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+
+	datas, err := h.service.ListStreams(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
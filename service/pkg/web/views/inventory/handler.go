@@ -0,0 +1,182 @@
+// Package inventory provides HTTP handlers for material lots and their usage.
+package inventory
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/inventory"
+)
+
+// Handler handles material inventory HTTP requests
+type Handler struct {
+	service *inventory.Service
+}
+
+// NewHandler creates a new material inventory handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: inventory.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one material by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 登记物料批次
+// @Description 登记样品、试剂或耗材批次，同一实验室批号唯一
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param req body inventory.RegisterReq true "物料批次"
+// @Success 200 {object} common.Resp{data=model.Material}
+// @Router /v1/lab/inventory/material [post]
+func (h *Handler) Register(ctx *gin.Context) {
+	req := &inventory.RegisterReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Register(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取物料批次列表
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param kind query string false "类型过滤 (sample, reagent, consumable)"
+// @Param keyword query string false "名称或批号"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} common.Resp{data=common.ListResp[model.Material]}
+// @Router /v1/lab/inventory/material [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &inventory.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取物料批次详情
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param uuid path string true "物料批次UUID"
+// @Success 200 {object} common.Resp{data=model.Material}
+// @Router /v1/lab/inventory/material/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取物料批次消耗记录
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param uuid path string true "物料批次UUID"
+// @Success 200 {object} common.Resp{data=[]model.MaterialUsage}
+// @Router /v1/lab/inventory/material/{uuid}/usage [get]
+func (h *Handler) ListUsages(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.ListUsages(ctx, uri.UUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 记录物料消耗
+// @Description 记录一次工作流执行消耗的物料批次并扣减剩余数量，任一批次剩余不足时整体失败。用户上报时 lab_uuid 必填
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param req body inventory.UsageReq true "物料消耗"
+// @Success 200 {object} common.Resp{data=[]model.MaterialUsage}
+// @Router /v1/lab/inventory/usage [post]
+// @Router /v1/edge/inventory/usage [post]
+func (h *Handler) RecordUsage(ctx *gin.Context) {
+	req := &inventory.UsageReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.RecordUsage(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取库存同步状态
+// @Description 获取 LIMS 库存同步是否启用、同步间隔及最近的同步记录
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=inventory.SyncStatusResp}
+// @Router /v1/lab/inventory/sync [get]
+func (h *Handler) SyncStatus(ctx *gin.Context) {
+	req := &inventory.SyncStatusReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.SyncStatus(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 手动触发库存同步
+// @Description 立即从 LIMS 同步实验室库存，同步在后台执行，已有进行中的同步时返回错误
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param req body inventory.SyncTriggerReq true "同步请求"
+// @Success 200 {object} common.Resp{data=model.MaterialSyncRun}
+// @Router /v1/lab/inventory/sync [post]
+func (h *Handler) TriggerSync(ctx *gin.Context) {
+	req := &inventory.SyncTriggerReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.TriggerSync(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取库存同步冲突
+// @Description 获取 LIMS 与本地库存无法自动对账的差异，默认只返回未解决的冲突
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param include_resolved query bool false "包含已解决的冲突"
+// @Success 200 {object} common.Resp{data=[]model.MaterialSyncConflict}
+// @Router /v1/lab/inventory/sync/conflicts [get]
+func (h *Handler) ListConflicts(ctx *gin.Context) {
+	req := &inventory.ConflictListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.ListConflicts(ctx, req)
+	common.Reply(ctx, err, datas)
+}
@@ -0,0 +1,210 @@
+// Package jobs provides admin HTTP handlers to inspect and control background jobs.
+package jobs
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/internal/config"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/jobs"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/middleware/logger"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/impersonation"
+	"github.com/scienceol/studio/service/pkg/repo/job"
+)
+
+// Handler handles background job admin requests
+type Handler struct {
+	repo    job.JobRepo
+	manager *jobs.Manager
+	audit   impersonation.ImpersonationRepo
+}
+
+// NewHandler creates a new background job handler
+func NewHandler() *Handler {
+	return &Handler{
+		repo:    job.New(),
+		manager: jobs.Default(),
+		audit:   impersonation.New(),
+	}
+}
+
+// JobResponse is a job definition with its schedule state
+type JobResponse struct {
+	*model.BackgroundJob
+	Registered bool `json:"registered"` // 当前版本是否仍注册该任务
+}
+
+// RunsRequest represents the request for listing runs of a job
+type RunsRequest struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=20"`
+}
+
+// checkAdmin 后台任务影响所有实验室，仅配置的管理员可以操作
+func checkAdmin(ctx context.Context) error {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		return code.UnLogin
+	}
+	if !slices.Contains(config.Global().Job.AdminUserIDs, userInfo.ID) {
+		return code.PermissionDenied
+	}
+	return nil
+}
+
+// @Summary 获取后台任务列表
+// @Description 获取所有后台任务的调度配置、暂停状态与最近一次运行结果
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Success 200 {object} common.Resp{data=[]JobResponse}
+// @Router /v1/admin/jobs [get]
+func (h *Handler) List(ctx *gin.Context) {
+	if err := checkAdmin(ctx); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	datas, err := h.repo.ListJobs(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	resp := make([]*JobResponse, 0, len(datas))
+	for _, data := range datas {
+		resp = append(resp, &JobResponse{
+			BackgroundJob: data,
+			Registered:    h.manager.Registered(data.Name),
+		})
+	}
+	common.ReplyOk(ctx, resp)
+}
+
+// @Summary 获取后台任务运行记录
+// @Description 分页获取后台任务的运行历史，最新的在前
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param name path string true "任务名称"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} common.Resp{data=common.ListResp[model.BackgroundJobRun]}
+// @Router /v1/admin/jobs/{name}/runs [get]
+func (h *Handler) Runs(ctx *gin.Context) {
+	var req RunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.getJob(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	items, total, err := h.repo.ListRuns(ctx, data.ID, req.Page, req.PageSize)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.ReplyOk(ctx, common.NewListResp(items, total, req.Page, req.PageSize))
+}
+
+// @Summary 立即执行后台任务
+// @Description 请求主副本尽快执行一次任务，不影响原有调度，暂停中的任务同样可以手动执行
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param name path string true "任务名称"
+// @Success 200 {object} common.Resp
+// @Router /v1/admin/jobs/{name}/trigger [post]
+func (h *Handler) Trigger(ctx *gin.Context) {
+	data, err := h.getJob(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	if !h.manager.Registered(data.Name) {
+		common.ReplyErr(ctx, code.JobNotRegisteredErr)
+		return
+	}
+
+	common.Reply(ctx, h.repo.RequestTrigger(ctx, data.Name))
+}
+
+// @Summary 暂停后台任务
+// @Description 暂停任务的定时调度，正在运行的实例不受影响
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param name path string true "任务名称"
+// @Success 200 {object} common.Resp
+// @Router /v1/admin/jobs/{name}/pause [post]
+func (h *Handler) Pause(ctx *gin.Context) {
+	h.setPaused(ctx, true)
+}
+
+// @Summary 恢复后台任务
+// @Description 恢复任务的定时调度
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param name path string true "任务名称"
+// @Success 200 {object} common.Resp
+// @Router /v1/admin/jobs/{name}/resume [post]
+func (h *Handler) Resume(ctx *gin.Context) {
+	h.setPaused(ctx, false)
+}
+
+func (h *Handler) setPaused(ctx *gin.Context, paused bool) {
+	data, err := h.getJob(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	if err := h.repo.SetPaused(ctx, data.Name, paused); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	action := model.AuditJobResume
+	if paused {
+		action = model.AuditJobPause
+	}
+	if err := h.audit.CreateAuditLog(context.WithoutCancel(ctx), &model.AuditLog{
+		Action: action,
+		UserID: auth.GetCurrentUser(ctx).ID,
+		Detail: "job: " + data.Name,
+	}); err != nil {
+		logger.Errorf(ctx, "job audit fail action: %s, job: %s, err: %+v", action, data.Name, err)
+	}
+	common.ReplyOk(ctx)
+}
+
+func (h *Handler) getJob(ctx *gin.Context) (*model.BackgroundJob, error) {
+	if err := checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	name := ctx.Param("name")
+	if name == "" {
+		return nil, code.ParamErr.WithMsg("job name is required")
+	}
+	return h.repo.GetJobByName(ctx, name)
+}
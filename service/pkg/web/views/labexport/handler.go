@@ -0,0 +1,167 @@
+// Package labexport provides HTTP handlers for lab data export and deletion.
+package labexport
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/labexport"
+)
+
+// Handler handles lab export HTTP requests
+type Handler struct {
+	service *labexport.Service
+}
+
+// NewHandler creates a new lab export handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: labexport.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one lab export by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 创建实验室数据导出
+// @Description 在后台将实验室的历史记录、设备事件、报表、批注和配置导出为 zip 归档，仅实验室管理员可操作
+// @Tags LabExport
+// @Accept json
+// @Produce json
+// @Param req body labexport.CreateReq true "导出"
+// @Success 200 {object} common.Resp{data=model.LabExport}
+// @Router /v1/lab/export [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &labexport.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取实验室数据导出列表
+// @Tags LabExport
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.LabExport}
+// @Router /v1/lab/export [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &labexport.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取实验室数据导出进度
+// @Tags LabExport
+// @Accept json
+// @Produce json
+// @Param uuid path string true "导出UUID"
+// @Success 200 {object} common.Resp{data=model.LabExport}
+// @Router /v1/lab/export/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 生成导出归档签名下载链接
+// @Description 生成带有效期的下载链接，链接无需登录即可访问，有效期不超过归档过期时间
+// @Tags LabExport
+// @Accept json
+// @Produce json
+// @Param uuid path string true "导出UUID"
+// @Success 200 {object} common.Resp{data=labexport.URLResp}
+// @Router /v1/lab/export/{uuid}/url [post]
+func (h *Handler) DownloadURL(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.DownloadURL(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 下载导出归档
+// @Description 使用签名下载链接下载实验室数据归档
+// @Tags LabExport
+// @Produce octet-stream
+// @Param token query string true "下载令牌"
+// @Success 200 {file} binary
+// @Router /v1/export/download [get]
+func (h *Handler) Download(ctx *gin.Context) {
+	data, r, err := h.service.Open(ctx, ctx.Query("token"))
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	defer r.Close()
+
+	// 归档较大时下载时间可能超过 server 的写超时
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	ctx.DataFromReader(http.StatusOK, data.Size, "application/zip", r, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": data.FileName}),
+		"Cache-Control":       "private, no-store",
+	})
+}
+
+// @Summary 申请删除实验室全部数据
+// @Description 仅实验室创建者可申请，返回短时有效的确认令牌和需要输入的确认内容
+// @Tags LabExport
+// @Accept json
+// @Produce json
+// @Param req body labexport.PurgeTokenReq true "申请"
+// @Success 200 {object} common.Resp{data=labexport.PurgeTokenResp}
+// @Router /v1/lab/purge/token [post]
+func (h *Handler) PurgeToken(ctx *gin.Context) {
+	req := &labexport.PurgeTokenReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.PurgeToken(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 确认删除实验室全部数据
+// @Description 提交确认令牌和实验室名称后删除实验室的历史记录、设备事件、报表、批注、配置和文件，实验室及成员保留
+// @Tags LabExport
+// @Accept json
+// @Produce json
+// @Param req body labexport.PurgeReq true "确认"
+// @Success 200 {object} common.Resp{data=labexport.PurgeResp}
+// @Router /v1/lab/purge [post]
+func (h *Handler) Purge(ctx *gin.Context) {
+	req := &labexport.PurgeReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Purge(ctx, req)
+	common.Reply(ctx, err, data)
+}
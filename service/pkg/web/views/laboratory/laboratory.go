@@ -33,7 +33,7 @@ func (l *EnvHandle) CreateLabEnv(ctx *gin.Context) {
 	req := &environment.LaboratoryEnvReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse body err: %+v", err)
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -61,7 +61,7 @@ func (l *EnvHandle) UpdateLabEnv(ctx *gin.Context) {
 	req := &environment.UpdateEnvReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse body err: %+v", err)
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -89,7 +89,7 @@ func (l *EnvHandle) DelLabEnv(ctx *gin.Context) {
 	req := &environment.DelLabReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse body err: %+v", err)
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -111,7 +111,7 @@ func (l *EnvHandle) LabList(ctx *gin.Context) {
 	req := &common.PageReq{}
 	if err := ctx.ShouldBindQuery(req); err != nil {
 		logger.Errorf(ctx, "parse body err: %+v", err)
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -143,13 +143,13 @@ func (l *EnvHandle) LabInfo(ctx *gin.Context) {
 	req := &environment.LabInfoReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
 		logger.Errorf(ctx, "parse body err: %+v", err)
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
 	if err := ctx.ShouldBindQuery(req); err != nil {
 		logger.Errorf(ctx, "parse body err: %+v", err)
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -171,7 +171,7 @@ func (l *EnvHandle) CreateLabResource(ctx *gin.Context) {
 	req := &environment.ResourceReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse body err: %+v", err)
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -199,12 +199,12 @@ func (l *EnvHandle) CreateLabResource(ctx *gin.Context) {
 func (l *EnvHandle) GetLabMemeber(ctx *gin.Context) {
 	req := &environment.LabMemberReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -235,7 +235,7 @@ func (l *EnvHandle) GetLabMemeber(ctx *gin.Context) {
 func (l *EnvHandle) DelLabMember(ctx *gin.Context) {
 	req := &environment.DelLabMemberReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -262,7 +262,7 @@ func (l *EnvHandle) DelLabMember(ctx *gin.Context) {
 func (l *EnvHandle) CreateInvite(ctx *gin.Context) {
 	req := &environment.InviteReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -289,7 +289,7 @@ func (l *EnvHandle) CreateInvite(ctx *gin.Context) {
 func (l EnvHandle) AcceptInvite(ctx *gin.Context) {
 	req := &environment.AcceptInviteReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -0,0 +1,87 @@
+// Package legalhold provides HTTP handlers for legal holds on history records.
+package legalhold
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/legalhold"
+)
+
+// Handler handles legal hold HTTP requests
+type Handler struct {
+	service *legalhold.Service
+}
+
+// NewHandler creates a new legal hold handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: legalhold.New(),
+	}
+}
+
+// @Summary 设置法律保全
+// @Description 对单次执行、工作流、实验室或时间段内的历史记录设置法律保全，保全期间保留策略不会清理这些记录。保全设置后不能修改，只能解除，设置和解除都会写入审计日志。仅管理员可操作
+// @Tags LegalHold
+// @Accept json
+// @Produce json
+// @Param req body legalhold.PlaceReq true "保全范围"
+// @Success 200 {object} common.Resp{data=legalhold.HoldResp}
+// @Router /v1/admin/legal-hold [post]
+func (h *Handler) Place(ctx *gin.Context) {
+	req := &legalhold.PlaceReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Place(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 法律保全列表
+// @Tags LegalHold
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string false "实验室UUID"
+// @Param active query bool false "只看生效中或已解除的保全"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[legalhold.HoldResp]}
+// @Router /v1/admin/legal-hold [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &legalhold.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 解除法律保全
+// @Description 解除后记录按保留策略正常清理，已解除的保全不能再次解除
+// @Tags LegalHold
+// @Accept json
+// @Produce json
+// @Param uuid path string true "保全UUID"
+// @Param req body legalhold.ReleaseReq true "解除原因"
+// @Success 200 {object} common.Resp{data=legalhold.HoldResp}
+// @Router /v1/admin/legal-hold/{uuid}/release [post]
+func (h *Handler) Release(ctx *gin.Context) {
+	id, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid legal hold UUID"))
+		return
+	}
+	req := &legalhold.ReleaseReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Release(ctx, id, req)
+	common.Reply(ctx, err, data)
+}
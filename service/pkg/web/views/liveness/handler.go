@@ -0,0 +1,98 @@
+// Package liveness provides HTTP handlers for edge heartbeats and device liveness.
+package liveness
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/liveness"
+)
+
+// Handler handles device liveness HTTP requests
+type Handler struct {
+	service *liveness.Service
+}
+
+// NewHandler creates a new device liveness handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: liveness.New(),
+	}
+}
+
+// @Summary 边缘端心跳
+// @Description edge 定期上报心跳，devices 为空时视为实验室下所有设备在线，versions 按设备上报各自的 agent 版本，超过 offline_after_sec 未上报的设备标记为离线
+// @Tags DeviceLiveness
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body liveness.HeartbeatReq true "心跳请求"
+// @Success 200 {object} common.Resp{data=liveness.HeartbeatResp}
+// @Router /v1/edge/heartbeat [post]
+func (h *Handler) Heartbeat(ctx *gin.Context) {
+	req := &liveness.HeartbeatReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req.IP = ctx.ClientIP()
+
+	resp, err := h.service.Heartbeat(ctx, req)
+	common.Reply(ctx, err, resp)
+}
+
+// ListRequest represents the request for listing device liveness
+type ListRequest struct {
+	LabUUID string `uri:"lab_uuid" binding:"required"`
+}
+
+// @Summary 获取设备在线状态
+// @Description 获取实验室内设备最近一次心跳时间、edge 版本与 IP
+// @Tags DeviceLiveness
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.DeviceLiveness}
+// @Router /v1/lab/device/liveness/{lab_uuid} [get]
+func (h *Handler) List(ctx *gin.Context) {
+	var req ListRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	labUUID, err := uuid.FromString(req.LabUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+
+	datas, err := h.service.List(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取设备 agent 版本分布
+// @Description 按心跳上报的 agent 版本统计实验室设备数和在线设备数
+// @Tags DeviceLiveness
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.AgentVersionCount}
+// @Router /v1/lab/device/liveness/{lab_uuid}/versions [get]
+func (h *Handler) Versions(ctx *gin.Context) {
+	var req ListRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	labUUID, err := uuid.FromString(req.LabUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+
+	datas, err := h.service.Versions(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
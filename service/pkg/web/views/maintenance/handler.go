@@ -0,0 +1,110 @@
+// Package maintenance provides HTTP handlers for maintenance mode.
+package maintenance
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/maintenance"
+)
+
+// Handler handles maintenance HTTP requests
+type Handler struct {
+	service *maintenance.Service
+}
+
+// NewHandler creates a new maintenance handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: maintenance.New(),
+	}
+}
+
+// @Summary 获取维护状态
+// @Description 返回全局和实验室的只读维护状态，供前端展示维护提示，无需登录
+// @Tags Maintenance
+// @Produce json
+// @Param lab_uuid query string false "实验室UUID"
+// @Success 200 {object} common.Resp{data=maintenance.StatusResp}
+// @Router /v1/maintenance/status [get]
+func (h *Handler) Status(ctx *gin.Context) {
+	req := &maintenance.StatusReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.ReplyOk(ctx, h.service.Status(ctx, req))
+}
+
+// @Summary 开始全局维护
+// @Description 整个服务进入只读维护，写请求返回 503，调度器暂停领取任务。已在维护时更新提示和预计结束时间
+// @Tags Maintenance
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body maintenance.StartReq true "维护信息"
+// @Success 200 {object} common.Resp{data=model.MaintenanceWindow}
+// @Router /v1/admin/maintenance [put]
+func (h *Handler) StartGlobal(ctx *gin.Context) {
+	h.start(ctx, uuid.UUID{})
+}
+
+// @Summary 结束全局维护
+// @Tags Maintenance
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Resp
+// @Router /v1/admin/maintenance [delete]
+func (h *Handler) EndGlobal(ctx *gin.Context) {
+	common.Reply(ctx, h.service.End(ctx, uuid.UUID{}))
+}
+
+// @Summary 开始实验室维护
+// @Description 实验室进入只读维护，该实验室的写请求返回 503，调度器暂停领取该实验室的任务
+// @Tags Maintenance
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param lab_uuid path string true "实验室UUID"
+// @Param req body maintenance.StartReq true "维护信息"
+// @Success 200 {object} common.Resp{data=model.MaintenanceWindow}
+// @Router /v1/admin/maintenance/lab/{lab_uuid} [put]
+func (h *Handler) StartLab(ctx *gin.Context) {
+	labUUID, err := uuid.FromString(ctx.Param("lab_uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid UUID"))
+		return
+	}
+
+	h.start(ctx, labUUID)
+}
+
+// @Summary 结束实验室维护
+// @Tags Maintenance
+// @Produce json
+// @Security BearerAuth
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/admin/maintenance/lab/{lab_uuid} [delete]
+func (h *Handler) EndLab(ctx *gin.Context) {
+	labUUID, err := uuid.FromString(ctx.Param("lab_uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid UUID"))
+		return
+	}
+
+	common.Reply(ctx, h.service.End(ctx, labUUID))
+}
+
+func (h *Handler) start(ctx *gin.Context, labUUID uuid.UUID) {
+	req := &maintenance.StartReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	resp, err := h.service.Start(ctx, labUUID, req)
+	common.Reply(ctx, err, resp)
+}
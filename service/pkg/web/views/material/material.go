@@ -55,7 +55,7 @@ func (m *Handle) CreateLabMaterial(ctx *gin.Context) {
 	req := &material.GraphNodeReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse CreateLabMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	if err := m.mService.CreateMaterial(ctx, req); err != nil {
@@ -82,7 +82,7 @@ func (m *Handle) EdgeCreateMaterial(ctx *gin.Context) {
 	req := &material.CreateMaterialReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse EdgeCreateMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	resp, err := m.mService.EdgeCreateMaterial(ctx, req)
@@ -104,7 +104,7 @@ func (m *Handle) EdgeUpsertMaterial(ctx *gin.Context) {
 	req := &material.UpsertMaterialReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse EdgeCreateMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	resp, err := m.mService.EdgeUpsertMaterial(ctx, req)
@@ -126,7 +126,7 @@ func (m *Handle) EdgeCreateEdge(ctx *gin.Context) {
 	req := &material.CreateMaterialEdgeReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse EdgeCreateMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	err := m.mService.EdgeCreateEdge(ctx, req)
@@ -148,7 +148,7 @@ func (m *Handle) SaveMaterial(ctx *gin.Context) {
 	req := &material.SaveGrapReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse CreateLabMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	if err := m.mService.SaveMaterial(ctx, req); err != nil {
@@ -175,7 +175,7 @@ func (m *Handle) QueryMaterial(ctx *gin.Context) {
 	req := &material.MaterialReq{}
 	if err := ctx.ShouldBindQuery(req); err != nil {
 		logger.Errorf(ctx, "parse LabMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -197,7 +197,7 @@ func (m *Handle) QueryMaterialByUUID(ctx *gin.Context) {
 	req := &material.MaterialQueryReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse QueryMaterialByUUID param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -232,7 +232,7 @@ func (m *Handle) BatchUpdateMaterial(ctx *gin.Context) {
 	req := &material.UpdateMaterialReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse BatchUpdateMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -255,7 +255,7 @@ func (m *Handle) ResourceList(ctx *gin.Context) {
 	req := &material.ResourceReq{}
 	if err := ctx.ShouldBindQuery(req); err != nil {
 		logger.Errorf(ctx, "parse ResourceList param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -277,7 +277,7 @@ func (m *Handle) ResourceTemplateList(ctx *gin.Context) {
 	req := &material.ResourceTemplateReq{}
 	if err := ctx.ShouldBindQuery(req); err != nil {
 		logger.Errorf(ctx, "parse ResourceTemplateList param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -300,7 +300,7 @@ func (m *Handle) Actions(ctx *gin.Context) {
 	req := &material.DeviceActionReq{}
 	if err := ctx.ShouldBindQuery(req); err != nil {
 		logger.Errorf(ctx, "parse BatchUpdateMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -322,7 +322,7 @@ func (m *Handle) CreateMaterialEdge(ctx *gin.Context) {
 	req := &material.GraphEdge{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Errorf(ctx, "parse CreateMaterialEdge param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	if err := m.mService.CreateEdge(ctx, req); err != nil {
@@ -340,7 +340,7 @@ func (m *Handle) DownloadMaterial(ctx *gin.Context) {
 	req := &material.DownloadMaterial{}
 	if err := ctx.ShouldBindUri(req); err != nil {
 		logger.Errorf(ctx, "parse DownloadMaterial param err: %+v", err.Error())
-		common.ReplyErr(ctx, code.ParamErr, err.Error())
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -462,7 +462,7 @@ func (m *Handle) LabMaterial(ctx *gin.Context) {
 	labUUIDStr := ctx.Param("lab_uuid")
 	req.LabUUID, err = uuid.FromString(labUUIDStr)
 	if err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	userInfo := auth.GetCurrentUser(ctx)
@@ -0,0 +1,32 @@
+// Package migration provides HTTP handlers for the database migration status.
+package migration
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/core/migration"
+)
+
+// Handler handles migration HTTP requests
+type Handler struct {
+	service *migration.Service
+}
+
+// NewHandler creates a new migration handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: migration.New(),
+	}
+}
+
+// @Summary 数据库迁移状态
+// @Description 返回 AutoMigrate 的模型结构版本和每个版本化迁移是否已执行，未执行的迁移可通过 migrate up 命令执行。仅管理员可查看
+// @Tags Migration
+// @Accept json
+// @Produce json
+// @Success 200 {object} common.Resp{data=migrate.Status}
+// @Router /v1/admin/migrations [get]
+func (h *Handler) Status(ctx *gin.Context) {
+	data, err := h.service.Status(ctx)
+	common.Reply(ctx, err, data)
+}
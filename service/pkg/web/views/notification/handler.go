@@ -0,0 +1,193 @@
+// Package notification provides HTTP handlers for the in-app notification inbox.
+package notification
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/notification"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+)
+
+// heartbeatInterval 通知流的心跳间隔，避免代理关闭空闲连接
+const heartbeatInterval = 30 * time.Second
+
+// SSE 事件名
+const (
+	eventUnread       = "unread"
+	eventNotification = "notification"
+	eventPing         = "ping"
+)
+
+// Handler handles notification HTTP requests
+type Handler struct {
+	service *notification.Service
+}
+
+// NewHandler creates a new notification handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: notification.New(),
+	}
+}
+
+// @Summary 获取通知列表
+// @Description 当前用户的站内通知，最新的在前
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "只返回未读通知"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[model.Notification]}
+// @Router /v1/notification [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &notification.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	resp, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, resp)
+}
+
+// @Summary 获取未读数量
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Resp{data=notification.UnreadResp}
+// @Router /v1/notification/unread [get]
+func (h *Handler) Unread(ctx *gin.Context) {
+	resp, err := h.service.Unread(ctx)
+	common.Reply(ctx, err, resp)
+}
+
+// @Summary 标记已读
+// @Description 标记指定通知为已读，all 为 true 时标记全部未读通知
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body notification.MarkReadReq true "标记已读请求"
+// @Success 200 {object} common.Resp{data=notification.MarkReadResp}
+// @Router /v1/notification/read [post]
+func (h *Handler) MarkRead(ctx *gin.Context) {
+	req := &notification.MarkReadReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	resp, err := h.service.MarkRead(ctx, req)
+	common.Reply(ctx, err, resp)
+}
+
+// @Summary 获取通知设置
+// @Description 每类通知的开关，未设置的类别默认开启
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Resp{data=[]notification.Preference}
+// @Router /v1/notification/preferences [get]
+func (h *Handler) Preferences(ctx *gin.Context) {
+	resp, err := h.service.Preferences(ctx)
+	common.Reply(ctx, err, resp)
+}
+
+// @Summary 更新通知设置
+// @Description 关闭的类别不再写入收件箱，未包含的类别保持不变
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body notification.PreferencesReq true "通知设置"
+// @Success 200 {object} common.Resp{data=[]notification.Preference}
+// @Router /v1/notification/preferences [put]
+func (h *Handler) UpdatePreferences(ctx *gin.Context) {
+	req := &notification.PreferencesReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	resp, err := h.service.UpdatePreferences(ctx, req)
+	common.Reply(ctx, err, resp)
+}
+
+// @Summary 新通知推送
+// @Description Server-Sent Events 通知流。连接后先发送 unread 事件（未读数量），之后每条新通知发送一个 notification 事件，事件 id 为通知的 uuid，空闲时每 30 秒发送 ping 事件。
+// @Description 重连时带上 Last-Event-ID 请求头，会先补发保留期内错过的通知
+// @Param Last-Event-ID header string false "断线前收到的最后一条通知的 uuid"
+// @Tags Notification
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {object} model.Notification
+// @Router /v1/notification/stream [get]
+func (h *Handler) Stream(ctx *gin.Context) {
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		common.ReplyErr(ctx, code.UnLogin)
+		return
+	}
+
+	unread, err := h.service.Unread(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	// 先订阅再查询错过的通知，查询期间的新通知在通知流中去重
+	ch, closeFn := h.service.Subscribe(ctx, userInfo.ID)
+	defer closeFn()
+	missed, err := h.service.Missed(ctx, userInfo.ID, ctx.GetHeader("Last-Event-ID"))
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	sent := make(map[uuid.UUID]struct{}, len(missed))
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.SSEvent(eventUnread, unread)
+	for _, data := range missed {
+		sent[data.UUID] = struct{}{}
+		renderNotification(ctx, data)
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	ctx.Stream(func(_ io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case data := <-ch:
+			if _, ok := sent[data.UUID]; !ok {
+				renderNotification(ctx, data)
+			}
+		case <-heartbeat.C:
+			ctx.SSEvent(eventPing, time.Now().Unix())
+		}
+		return true
+	})
+}
+
+// renderNotification sends a notification event with its uuid as the event id
+func renderNotification(ctx *gin.Context, data *model.Notification) {
+	ctx.Render(-1, sse.Event{
+		Id:    data.UUID.String(),
+		Event: eventNotification,
+		Data:  data,
+	})
+}
@@ -1,25 +1,44 @@
 package realtime
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/scienceol/studio/service/pkg/common"
 	"github.com/scienceol/studio/service/pkg/core/realtime"
+	"github.com/scienceol/studio/service/pkg/core/stream"
 )
 
-// Handle holds no state for prototype; uses global realtime.Manager.
-type Handle struct{}
+// Handle uses global realtime.Manager for signaling and the stream service
+// for token-based viewing sessions.
+type Handle struct {
+	streams *stream.Service
+}
 
-func NewHandle() *Handle { return &Handle{} }
+func NewHandle() *Handle { return &Handle{streams: stream.New()} }
 
 // ClientSignal upgrades to websocket for client signaling.
+// When a viewing token is passed the connection is bound to its stream session,
+// which ends once the client disconnects.
 func (h *Handle) ClientSignal(ctx *gin.Context) {
 	clientID := ctx.Query("clientId")
 	if clientID == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "clientId required"})
 		return
 	}
+
+	token := ctx.Query("token")
+	if token != "" {
+		session, err := h.streams.Attach(ctx, token, clientID)
+		if err != nil {
+			common.ReplyErr(ctx, err)
+			return
+		}
+		defer h.streams.Detach(context.WithoutCancel(ctx), session)
+	}
+
 	conn, err := realtime.Upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
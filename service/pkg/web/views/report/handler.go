@@ -0,0 +1,180 @@
+// Package report provides HTTP handlers for scheduled report subscriptions.
+package report
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/report"
+)
+
+// Handler handles report subscription HTTP requests
+type Handler struct {
+	service *report.Service
+}
+
+// NewHandler creates a new report subscription handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: report.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one report subscription or delivery by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 创建报表订阅
+// @Description 按天或按周将保存的视图或实验室统计生成 CSV/PDF 报表，通过邮件或 webhook 发送
+// @Tags Report
+// @Accept json
+// @Produce json
+// @Param req body report.CreateReq true "订阅"
+// @Success 200 {object} common.Resp{data=model.ReportSubscription}
+// @Router /v1/lab/report/subscription [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &report.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取报表订阅列表
+// @Description 获取当前用户在实验室创建的订阅
+// @Tags Report
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.ReportSubscription}
+// @Router /v1/lab/report/subscription [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &report.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取报表订阅
+// @Tags Report
+// @Accept json
+// @Produce json
+// @Param uuid path string true "订阅UUID"
+// @Success 200 {object} common.Resp{data=model.ReportSubscription}
+// @Router /v1/lab/report/subscription/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 更新报表订阅
+// @Description 整体覆盖发送设置，修改发送时间或重新启用后重新计算下次发送时间
+// @Tags Report
+// @Accept json
+// @Produce json
+// @Param uuid path string true "订阅UUID"
+// @Param req body report.UpdateReq true "订阅"
+// @Success 200 {object} common.Resp{data=model.ReportSubscription}
+// @Router /v1/lab/report/subscription/{uuid} [put]
+func (h *Handler) Update(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &report.UpdateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Update(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 删除报表订阅
+// @Description 已生成的报表与发送记录保留
+// @Tags Report
+// @Accept json
+// @Produce json
+// @Param uuid path string true "订阅UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/report/subscription/{uuid} [delete]
+func (h *Handler) Delete(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.Delete(ctx, uri.UUID))
+}
+
+// @Summary 获取报表发送记录
+// @Tags Report
+// @Accept json
+// @Produce json
+// @Param uuid path string true "订阅UUID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} common.Resp{data=common.ListResp[model.ReportDelivery]}
+// @Router /v1/lab/report/subscription/{uuid}/deliveries [get]
+func (h *Handler) ListDeliveries(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &common.PageReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.ListDeliveries(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 下载已生成的报表
+// @Tags Report
+// @Produce octet-stream
+// @Param uuid path string true "发送记录UUID"
+// @Success 200 {file} binary
+// @Router /v1/lab/report/delivery/{uuid}/download [get]
+func (h *Handler) Download(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	res, err := h.service.Download(ctx, uri.UUID)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	defer res.Reader.Close()
+
+	ctx.DataFromReader(http.StatusOK, res.Delivery.Size, res.ContentType, res.Reader, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": res.Delivery.FileName}),
+		"Cache-Control":       "private, no-store",
+	})
+}
@@ -0,0 +1,205 @@
+// Package reservation provides HTTP handlers for device reservation APIs.
+package reservation
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/middleware/auth"
+	"github.com/scienceol/studio/service/pkg/model"
+	"github.com/scienceol/studio/service/pkg/repo/reservation"
+)
+
+// maxCalendarWindow limits the time span of a calendar query
+const maxCalendarWindow = 93 * 24 * time.Hour
+
+// Handler handles reservation-related HTTP requests
+type Handler struct {
+	repo reservation.ReservationRepo
+}
+
+// NewHandler creates a new reservation handler
+func NewHandler() *Handler {
+	return &Handler{
+		repo: reservation.New(),
+	}
+}
+
+// CreateReservationRequest represents the request for booking a device
+type CreateReservationRequest struct {
+	LabID      int64     `json:"lab_id" binding:"required"`
+	DeviceName string    `json:"device_name" binding:"required"`
+	Title      string    `json:"title"`
+	StartTime  time.Time `json:"start_time" binding:"required"`
+	EndTime    time.Time `json:"end_time" binding:"required"`
+}
+
+// CalendarRequest represents the request for the reservation calendar
+type CalendarRequest struct {
+	LabID     int64  `form:"lab_id" binding:"required"`
+	DeviceID  *int64 `form:"device_id"`
+	StartTime string `form:"start_time" binding:"required"`
+	EndTime   string `form:"end_time" binding:"required"`
+}
+
+// ReservationResponse represents a reservation in response
+type ReservationResponse struct {
+	UUID       uuid.UUID               `json:"uuid"`
+	DeviceID   int64                   `json:"device_id"`
+	DeviceName string                  `json:"device_name"`
+	UserID     string                  `json:"user_id"`
+	Title      string                  `json:"title"`
+	StartTime  time.Time               `json:"start_time"`
+	EndTime    time.Time               `json:"end_time"`
+	Status     model.ReservationStatus `json:"status"`
+}
+
+// CalendarResponse represents reservations within a time window
+type CalendarResponse struct {
+	StartTime time.Time             `json:"start_time"`
+	EndTime   time.Time             `json:"end_time"`
+	Items     []ReservationResponse `json:"items"`
+}
+
+func toResponse(r *model.DeviceReservation) ReservationResponse {
+	return ReservationResponse{
+		UUID:       r.UUID,
+		DeviceID:   r.DeviceID,
+		DeviceName: r.DeviceName,
+		UserID:     r.UserID,
+		Title:      r.Title,
+		StartTime:  r.StartTime,
+		EndTime:    r.EndTime,
+		Status:     r.Status,
+	}
+}
+
+// @Summary 预约设备
+// @Description 预约设备的一个时间段，与已有预约冲突时返回错误
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param req body CreateReservationRequest true "预约信息"
+// @Success 200 {object} common.Resp{data=ReservationResponse}
+// @Router /v1/lab/reservation [post]
+func (h *Handler) CreateReservation(ctx *gin.Context) {
+	var req CreateReservationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	if !req.EndTime.After(req.StartTime) || req.EndTime.Before(time.Now()) {
+		common.ReplyErr(ctx, code.ReservationTimeInvalidErr)
+		return
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		common.ReplyErr(ctx, code.UnLogin)
+		return
+	}
+
+	data := &model.DeviceReservation{
+		LabID:      req.LabID,
+		DeviceName: req.DeviceName,
+		UserID:     userInfo.ID,
+		Title:      req.Title,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+	}
+	if err := h.repo.CreateReservation(ctx, data); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.ReplyOk(ctx, toResponse(data))
+}
+
+// @Summary 取消预约
+// @Description 取消自己创建的设备预约
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param uuid path string true "预约UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/reservation/{uuid} [delete]
+func (h *Handler) CancelReservation(ctx *gin.Context) {
+	reservationUUID, err := uuid.FromString(ctx.Param("uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid reservation UUID"))
+		return
+	}
+
+	userInfo := auth.GetCurrentUser(ctx)
+	if userInfo == nil {
+		common.ReplyErr(ctx, code.UnLogin)
+		return
+	}
+
+	if err := h.repo.CancelReservation(ctx, reservationUUID, userInfo.ID); err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	common.ReplyOk(ctx)
+}
+
+// @Summary 获取设备预约日历
+// @Description 获取时间窗口内实验室设备的预约情况
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param lab_id query int true "实验室ID"
+// @Param device_id query int false "设备ID (可选)"
+// @Param start_time query string true "开始时间 (RFC3339格式)"
+// @Param end_time query string true "结束时间 (RFC3339格式)"
+// @Success 200 {object} common.Resp{data=CalendarResponse}
+// @Router /v1/lab/reservation/calendar [get]
+func (h *Handler) Calendar(ctx *gin.Context) {
+	var req CalendarRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid start_time"))
+		return
+	}
+	endTime, err := time.Parse(time.RFC3339, req.EndTime)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid end_time"))
+		return
+	}
+	if !endTime.After(startTime) || endTime.Sub(startTime) > maxCalendarWindow {
+		common.ReplyErr(ctx, code.ReservationTimeInvalidErr)
+		return
+	}
+
+	datas, err := h.repo.ListCalendar(ctx, &reservation.CalendarQuery{
+		LabID:     req.LabID,
+		DeviceID:  req.DeviceID,
+		StartTime: startTime,
+		EndTime:   endTime,
+	})
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+
+	items := make([]ReservationResponse, 0, len(datas))
+	for _, r := range datas {
+		items = append(items, toResponse(r))
+	}
+
+	common.ReplyOk(ctx, CalendarResponse{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Items:     items,
+	})
+}
@@ -0,0 +1,151 @@
+// Package rule provides HTTP handlers for device event rules.
+package rule
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/rule"
+)
+
+// Handler handles device rule HTTP requests
+type Handler struct {
+	engine *rule.Engine
+}
+
+// NewHandler creates a new device rule handler
+func NewHandler() *Handler {
+	return &Handler{
+		engine: rule.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one rule by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// ListRequest represents the request for listing rules
+type ListRequest struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// @Summary 创建设备规则
+// @Description 在滑动窗口内聚合设备事件并与阈值比较，例如温度持续 5 分钟高于 60 为 metric=temperature、aggregation=min、operator=gt、threshold=60、window_sec=300
+// @Tags Rule
+// @Accept json
+// @Produce json
+// @Param req body rule.RuleReq true "规则"
+// @Success 200 {object} common.Resp{data=model.DeviceRule}
+// @Router /v1/lab/rule [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &rule.RuleReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.engine.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取设备规则列表
+// @Description 获取实验室所有设备规则
+// @Tags Rule
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.DeviceRule}
+// @Router /v1/lab/rule [get]
+func (h *Handler) List(ctx *gin.Context) {
+	var req ListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.engine.List(ctx, req.LabUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取设备规则详情
+// @Tags Rule
+// @Accept json
+// @Produce json
+// @Param uuid path string true "规则UUID"
+// @Success 200 {object} common.Resp{data=model.DeviceRule}
+// @Router /v1/lab/rule/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.engine.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 更新设备规则
+// @Description 整体覆盖规则配置，lab_uuid 不可修改
+// @Tags Rule
+// @Accept json
+// @Produce json
+// @Param uuid path string true "规则UUID"
+// @Param req body rule.RuleReq true "规则"
+// @Success 200 {object} common.Resp{data=model.DeviceRule}
+// @Router /v1/lab/rule/{uuid} [put]
+func (h *Handler) Update(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	req := &rule.RuleReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.engine.Update(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 删除设备规则
+// @Description 删除规则并恢复其未恢复的告警
+// @Tags Rule
+// @Accept json
+// @Produce json
+// @Param uuid path string true "规则UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/rule/{uuid} [delete]
+func (h *Handler) Delete(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.engine.Delete(ctx, uri.UUID))
+}
+
+// @Summary 试运行设备规则
+// @Description 使用最近一个窗口的数据评估规则，返回每个设备的聚合值及是否命中，不会产生告警
+// @Tags Rule
+// @Accept json
+// @Produce json
+// @Param req body rule.RuleReq true "规则"
+// @Success 200 {object} common.Resp{data=rule.TestResp}
+// @Router /v1/lab/rule/test [post]
+func (h *Handler) Test(ctx *gin.Context) {
+	req := &rule.RuleReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.engine.Test(ctx, req)
+	common.Reply(ctx, err, data)
+}
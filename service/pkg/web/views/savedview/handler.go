@@ -0,0 +1,127 @@
+// Package savedview provides HTTP handlers for saved history views.
+package savedview
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/savedview"
+)
+
+// Handler handles saved view HTTP requests
+type Handler struct {
+	service *savedview.Service
+}
+
+// NewHandler creates a new saved view handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: savedview.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one saved view by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 创建保存的视图
+// @Description 保存历史查询的过滤条件组合，可共享给实验室成员；列表接口通过 view_id 使用
+// @Tags SavedView
+// @Accept json
+// @Produce json
+// @Param req body savedview.CreateReq true "视图"
+// @Success 200 {object} common.Resp{data=model.SavedView}
+// @Router /v1/lab/history/view [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &savedview.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取保存的视图列表
+// @Description 获取自己创建的以及实验室共享的视图
+// @Tags SavedView
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param target query string false "适用列表 (workflow, device_event)"
+// @Success 200 {object} common.Resp{data=[]model.SavedView}
+// @Router /v1/lab/history/view [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &savedview.ListReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.List(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取保存的视图
+// @Tags SavedView
+// @Accept json
+// @Produce json
+// @Param uuid path string true "视图UUID"
+// @Success 200 {object} common.Resp{data=model.SavedView}
+// @Router /v1/lab/history/view/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 更新保存的视图
+// @Description 整体覆盖视图的名称、过滤条件与共享设置，仅创建者可以修改
+// @Tags SavedView
+// @Accept json
+// @Produce json
+// @Param uuid path string true "视图UUID"
+// @Param req body savedview.UpdateReq true "视图"
+// @Success 200 {object} common.Resp{data=model.SavedView}
+// @Router /v1/lab/history/view/{uuid} [put]
+func (h *Handler) Update(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &savedview.UpdateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Update(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 删除保存的视图
+// @Description 仅创建者可以删除
+// @Tags SavedView
+// @Accept json
+// @Produce json
+// @Param uuid path string true "视图UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/history/view/{uuid} [delete]
+func (h *Handler) Delete(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.Delete(ctx, uri.UUID))
+}
@@ -0,0 +1,40 @@
+// Package seed provides HTTP handlers for populating demo labs with synthetic history.
+package seed
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/seed"
+)
+
+// Handler handles demo seed HTTP requests
+type Handler struct {
+	service *seed.Service
+}
+
+// NewHandler creates a new demo seed handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: seed.New(),
+	}
+}
+
+// @Summary 生成演示历史数据
+// @Description 为实验室生成工作流执行、动作和设备事件历史，用于演示和统计接口的压测。实验室没有设备或工作流时会创建演示设备和工作流，生成的执行带有 seed=demo 标签。生产环境不提供，仅管理员可操作
+// @Tags Seed
+// @Accept json
+// @Produce json
+// @Param req body seed.SeedReq true "生成参数"
+// @Success 200 {object} common.Resp{data=seed.SeedResp}
+// @Router /v1/admin/seed [post]
+func (h *Handler) Seed(ctx *gin.Context) {
+	req := &seed.SeedReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Seed(ctx, req)
+	common.Reply(ctx, err, data)
+}
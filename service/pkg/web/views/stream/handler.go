@@ -0,0 +1,138 @@
+// Package stream provides HTTP handlers for camera streams and viewing sessions.
+package stream
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/stream"
+)
+
+// Handler handles camera stream HTTP requests
+type Handler struct {
+	service *stream.Service
+}
+
+// NewHandler creates a new camera stream handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: stream.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one viewing session by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// LabRequest represents a request scoped to one lab
+type LabRequest struct {
+	LabUUID uuid.UUID `form:"lab_uuid" binding:"required"`
+}
+
+// @Summary 边缘端注册摄像头
+// @Description edge 批量注册或更新设备摄像头，host_id 为 edge 连接实时信令时使用的 id，同一 camera_id 整体覆盖
+// @Tags Camera
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param req body stream.RegisterReq true "摄像头注册请求"
+// @Success 200 {object} common.Resp{data=[]model.CameraStream}
+// @Router /v1/edge/camera [post]
+func (h *Handler) Register(ctx *gin.Context) {
+	req := &stream.RegisterReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.RegisterStreams(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 边缘端注销摄像头
+// @Description edge 移除摄像头，正在观看的会话随之结束
+// @Tags Camera
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param camera_id path string true "摄像头ID"
+// @Success 200 {object} common.Resp
+// @Router /v1/edge/camera/{camera_id} [delete]
+func (h *Handler) Unregister(ctx *gin.Context) {
+	common.Reply(ctx, h.service.UnregisterStream(ctx, ctx.Param("camera_id")))
+}
+
+// @Summary 获取实验室摄像头
+// @Tags Camera
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.CameraStream}
+// @Router /v1/lab/camera [get]
+func (h *Handler) List(ctx *gin.Context) {
+	req := &LabRequest{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.ListStreams(ctx, req.LabUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 开始观看摄像头
+// @Description 需开启 realtime_camera 功能。返回短期有效的观看令牌，连接 /api/realtime/signal/client 时通过 token 参数传入，连接断开后会话结束
+// @Tags Camera
+// @Accept json
+// @Produce json
+// @Param req body stream.StartSessionReq true "观看请求"
+// @Success 200 {object} common.Resp{data=stream.StartSessionResp}
+// @Router /v1/lab/camera/session [post]
+func (h *Handler) StartSession(ctx *gin.Context) {
+	req := &stream.StartSessionReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.StartSession(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取正在进行的观看会话
+// @Tags Camera
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.StreamSession}
+// @Router /v1/lab/camera/session [get]
+func (h *Handler) ListSessions(ctx *gin.Context) {
+	req := &LabRequest{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.ListSessions(ctx, req.LabUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 结束观看会话
+// @Tags Camera
+// @Accept json
+// @Produce json
+// @Param uuid path string true "会话UUID"
+// @Success 200 {object} common.Resp{data=model.StreamSession}
+// @Router /v1/lab/camera/session/{uuid}/stop [post]
+func (h *Handler) StopSession(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.StopSession(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
@@ -0,0 +1,57 @@
+// Package systemhealth provides the HTTP handler for the admin health summary.
+package systemhealth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/systemhealth"
+)
+
+// Handler handles system health HTTP requests
+type Handler struct {
+	service *systemhealth.Service
+}
+
+// NewHandler creates a new system health handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: systemhealth.New(),
+	}
+}
+
+// SectionError is the reason a health section is missing
+type SectionError struct {
+	Code code.ErrCode `json:"code"`
+	Msg  string       `json:"msg"`
+}
+
+// Response represents the system health, errors is keyed by section
+type Response struct {
+	*systemhealth.Health
+	Errors map[string]*SectionError `json:"errors,omitempty"`
+}
+
+// @Summary 获取系统健康汇总
+// @Description 返回处理该请求的实例最近 5 分钟的请求速率、5xx 错误率、限流拒绝和降载数，数据库和 redis 耗时分位数，以及所有实例共享的排队执行数、死信队列、后台任务状态和最近的配置、功能开关、维护和任务调度变更。失败的分区为 null，原因在 errors 中按分区返回。仅管理员可查看
+// @Tags SystemHealth
+// @Accept json
+// @Produce json
+// @Success 200 {object} common.Resp{data=Response}
+// @Router /v1/admin/health [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	data, err := h.service.Get(ctx)
+	if err != nil {
+		common.ReplyErr(ctx, err)
+		return
+	}
+	resp := &Response{Health: data}
+	for section, err := range data.Errors {
+		if resp.Errors == nil {
+			resp.Errors = make(map[string]*SectionError, len(data.Errors))
+		}
+		errCode, e := common.LocalizeErr(ctx, err)
+		resp.Errors[section] = &SectionError{Code: errCode, Msg: e.Msg}
+	}
+	common.ReplyOk(ctx, resp)
+}
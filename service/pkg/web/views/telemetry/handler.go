@@ -0,0 +1,71 @@
+// Package telemetry provides HTTP handlers for device telemetry queries.
+package telemetry
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/core/telemetry"
+)
+
+// Handler handles device telemetry HTTP requests
+type Handler struct {
+	service *telemetry.Service
+}
+
+// NewHandler creates a new device telemetry handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: telemetry.New(),
+	}
+}
+
+// @Summary 获取设备数据汇总
+// @Description 获取设备 data_received 事件数值字段的分钟级或小时级汇总（min/max/avg/count），分钟级最多查询 7 天，小时级最多 90 天
+// @Tags Telemetry
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param resolution query string false "汇总粒度 (1m, 1h)" default(1m)
+// @Param device_uuid query []string false "设备UUID" collectionFormat(multi)
+// @Param metric query []string false "指标名称" collectionFormat(multi)
+// @Param start_time query string true "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)，默认当前时间"
+// @Success 200 {object} common.Resp{data=[]model.DeviceTelemetryRollup}
+// @Router /v1/lab/telemetry/rollup [get]
+func (h *Handler) Rollups(ctx *gin.Context) {
+	req := &telemetry.RollupReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	datas, err := h.service.Rollups(ctx, req)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取设备数值时间序列
+// @Description 从设备事件 event_data 中按字段路径（如 sensor.temperature）提取数值，按时间桶聚合，返回每个设备每个指标一条可直接绘图的序列
+// @Tags Telemetry
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Param device_uuid query []string false "设备UUID，不传则返回所有有数据的设备" collectionFormat(multi)
+// @Param metric query []string true "指标字段路径，以 . 分隔" collectionFormat(multi)
+// @Param event_type query string false "事件类型" default(data_received)
+// @Param agg query string false "聚合函数 (avg, min, max, sum, count)" default(avg)
+// @Param interval query string false "时间桶大小，如 30s、5m、1h" default(1m)
+// @Param start_time query string true "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)，默认当前时间"
+// @Success 200 {object} common.Resp{data=telemetry.SeriesResp}
+// @Router /v1/lab/telemetry/series [get]
+func (h *Handler) Series(ctx *gin.Context) {
+	req := &telemetry.SeriesReq{}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Series(ctx, req)
+	common.Reply(ctx, err, data)
+}
@@ -0,0 +1,156 @@
+// Package topology provides HTTP handlers for the lab resource hierarchy.
+package topology
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/topology"
+)
+
+// Handler handles lab topology HTTP requests
+type Handler struct {
+	service *topology.Service
+}
+
+// NewHandler creates a new lab topology handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: topology.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one topology node by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 创建拓扑节点
+// @Description 创建房间、工作台或设备组：房间位于实验室下，工作台位于房间下，设备组位于实验室、房间或工作台下，仅实验室创建者可以操作
+// @Tags Topology
+// @Accept json
+// @Produce json
+// @Param req body topology.CreateNodeReq true "拓扑节点"
+// @Success 200 {object} common.Resp{data=model.TopologyNode}
+// @Router /v1/lab/topology/node [post]
+func (h *Handler) CreateNode(ctx *gin.Context) {
+	req := &topology.CreateNodeReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.CreateNode(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 获取实验室拓扑
+// @Description 获取实验室的房间、工作台和设备组树及各节点直接包含的设备
+// @Tags Topology
+// @Accept json
+// @Produce json
+// @Param lab_uuid path string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.TopologyTree}
+// @Router /v1/lab/topology/{lab_uuid} [get]
+func (h *Handler) Tree(ctx *gin.Context) {
+	labUUID, err := uuid.FromString(ctx.Param("lab_uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+
+	datas, err := h.service.Tree(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 修改拓扑节点
+// @Description 修改节点名称、描述或移动到其他父节点，parent_uuid 为全 0 的 UUID 时移动到实验室下
+// @Tags Topology
+// @Accept json
+// @Produce json
+// @Param uuid path string true "拓扑节点UUID"
+// @Param req body topology.UpdateNodeReq true "修改内容"
+// @Success 200 {object} common.Resp{data=model.TopologyNode}
+// @Router /v1/lab/topology/node/{uuid} [patch]
+func (h *Handler) UpdateNode(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &topology.UpdateNodeReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.UpdateNode(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 删除拓扑节点
+// @Description 删除没有子节点的拓扑节点，节点中的设备不会被删除
+// @Tags Topology
+// @Accept json
+// @Produce json
+// @Param uuid path string true "拓扑节点UUID"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/topology/node/{uuid} [delete]
+func (h *Handler) DeleteNode(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.DeleteNode(ctx, uri.UUID))
+}
+
+// @Summary 添加节点设备
+// @Description 将设备放入房间或工作台，或加入设备组；每个设备只能位于一个房间或工作台，放入新位置时从原来的位置移出
+// @Tags Topology
+// @Accept json
+// @Produce json
+// @Param uuid path string true "拓扑节点UUID"
+// @Param req body topology.DevicesReq true "设备名称"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/topology/node/{uuid}/device [post]
+func (h *Handler) AddDevices(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &topology.DevicesReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.AddDevices(ctx, uri.UUID, req))
+}
+
+// @Summary 移除节点设备
+// @Description 将设备从房间、工作台或设备组移出
+// @Tags Topology
+// @Accept json
+// @Produce json
+// @Param uuid path string true "拓扑节点UUID"
+// @Param req body topology.DevicesReq true "设备名称"
+// @Success 200 {object} common.Resp
+// @Router /v1/lab/topology/node/{uuid}/device [delete]
+func (h *Handler) RemoveDevices(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+	req := &topology.DevicesReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	common.Reply(ctx, h.service.RemoveDevices(ctx, uri.UUID, req))
+}
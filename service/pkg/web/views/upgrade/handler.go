@@ -0,0 +1,159 @@
+// Package upgrade provides HTTP handlers for edge agent upgrade campaigns.
+package upgrade
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scienceol/studio/service/pkg/common"
+	"github.com/scienceol/studio/service/pkg/common/code"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
+	"github.com/scienceol/studio/service/pkg/core/upgrade"
+)
+
+// Handler handles agent upgrade HTTP requests
+type Handler struct {
+	service *upgrade.Service
+}
+
+// NewHandler creates a new agent upgrade handler
+func NewHandler() *Handler {
+	return &Handler{
+		service: upgrade.New(),
+	}
+}
+
+// UUIDRequest represents a request addressing one upgrade campaign by UUID
+type UUIDRequest struct {
+	UUID uuid.UUID `uri:"uuid" binding:"required"`
+}
+
+// @Summary 创建 agent 升级任务
+// @Description 为符合过滤条件且版本不是目标版本的设备创建升级任务，按设备名称顺序通过设备指令队列向前 rollout_percent 的设备下发升级，仅实验室创建者可以操作
+// @Tags AgentUpgrade
+// @Accept json
+// @Produce json
+// @Param req body upgrade.CreateReq true "升级任务"
+// @Success 200 {object} common.Resp{data=upgrade.CampaignResp}
+// @Router /v1/lab/agent/upgrade [post]
+func (h *Handler) Create(ctx *gin.Context) {
+	req := &upgrade.CreateReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Create(ctx, req)
+	common.Reply(ctx, err, data)
+}
+
+// ListRequest represents the request for listing the upgrade campaigns of a lab
+type ListRequest struct {
+	LabUUID string `form:"lab_uuid" binding:"required"`
+}
+
+// @Summary 获取 agent 升级任务列表
+// @Description 获取实验室的升级任务，新的在前
+// @Tags AgentUpgrade
+// @Accept json
+// @Produce json
+// @Param lab_uuid query string true "实验室UUID"
+// @Success 200 {object} common.Resp{data=[]model.AgentUpgradeCampaign}
+// @Router /v1/lab/agent/upgrade [get]
+func (h *Handler) List(ctx *gin.Context) {
+	var req ListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	labUUID, err := uuid.FromString(req.LabUUID)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab UUID"))
+		return
+	}
+
+	datas, err := h.service.List(ctx, labUUID)
+	common.Reply(ctx, err, datas)
+}
+
+// @Summary 获取 agent 升级任务详情
+// @Description 获取升级任务的进度和各设备的升级状态
+// @Tags AgentUpgrade
+// @Accept json
+// @Produce json
+// @Param uuid path string true "升级任务UUID"
+// @Success 200 {object} common.Resp{data=upgrade.CampaignResp}
+// @Router /v1/lab/agent/upgrade/{uuid} [get]
+func (h *Handler) Get(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Get(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 调整 agent 升级放量比例
+// @Description 调整升级任务的放量比例，暂停的任务同时恢复运行
+// @Tags AgentUpgrade
+// @Accept json
+// @Produce json
+// @Param uuid path string true "升级任务UUID"
+// @Param req body upgrade.RolloutReq true "放量比例"
+// @Success 200 {object} common.Resp{data=upgrade.CampaignResp}
+// @Router /v1/lab/agent/upgrade/{uuid}/rollout [put]
+func (h *Handler) Rollout(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	req := &upgrade.RolloutReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Rollout(ctx, uri.UUID, req)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 暂停 agent 升级任务
+// @Description 暂停后不再下发新的升级，已下发的升级继续执行
+// @Tags AgentUpgrade
+// @Accept json
+// @Produce json
+// @Param uuid path string true "升级任务UUID"
+// @Success 200 {object} common.Resp{data=upgrade.CampaignResp}
+// @Router /v1/lab/agent/upgrade/{uuid}/pause [post]
+func (h *Handler) Pause(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Pause(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
+
+// @Summary 回滚 agent 升级任务
+// @Description 取消未下发的升级，为已下发升级的设备下发回到升级前版本的指令
+// @Tags AgentUpgrade
+// @Accept json
+// @Produce json
+// @Param uuid path string true "升级任务UUID"
+// @Success 200 {object} common.Resp{data=upgrade.CampaignResp}
+// @Router /v1/lab/agent/upgrade/{uuid}/rollback [post]
+func (h *Handler) Rollback(ctx *gin.Context) {
+	var uri UUIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	data, err := h.service.Rollback(ctx, uri.UUID)
+	common.Reply(ctx, err, data)
+}
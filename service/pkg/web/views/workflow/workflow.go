@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +14,7 @@ import (
 	"github.com/scienceol/studio/service/pkg/common"
 	"github.com/scienceol/studio/service/pkg/common/code"
 	"github.com/scienceol/studio/service/pkg/common/constant"
+	"github.com/scienceol/studio/service/pkg/common/uuid"
 	"github.com/scienceol/studio/service/pkg/core/workflow"
 	impl "github.com/scienceol/studio/service/pkg/core/workflow/workflow"
 	"github.com/scienceol/studio/service/pkg/middleware/logger"
@@ -51,7 +53,7 @@ func (w *Handle) TemplateList(ctx *gin.Context) {
 
 	req := workflow.TplPageReq{}
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	if res, err := w.wService.TemplateList(ctx, &req); err != nil {
@@ -74,7 +76,7 @@ func (w *Handle) TemplateTags(ctx *gin.Context) {
 
 	req := workflow.TemplateTagsReq{}
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	if res, err := w.wService.TemplateTags(ctx, &req); err != nil {
@@ -117,7 +119,7 @@ func (w *Handle) WorkflowTemplateTagsByLab(ctx *gin.Context) {
 	// @Router /v1/lab/workflow/template/tags/{lab_uuid} [get]
 	req := &workflow.TemplateTagsReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	if res, err := w.wService.WorkflowTemplateTagsByLab(ctx, req); err != nil {
@@ -140,7 +142,7 @@ func (w *Handle) WorkflowTemplateList(ctx *gin.Context) {
 
 	req := workflow.TemplateListReq{}
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -164,7 +166,7 @@ func (w *Handle) ForkTemplate(ctx *gin.Context) {
 
 	req := &workflow.ForkReq{}
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -189,12 +191,12 @@ func (w *Handle) TaskList(ctx *gin.Context) {
 
 	req := workflow.TaskReq{}
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -218,7 +220,7 @@ func (w *Handle) DownloadTask(ctx *gin.Context) {
 
 	req := workflow.TaskDownloadReq{}
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -250,7 +252,7 @@ func (w *Handle) NodeTemplateDetail(ctx *gin.Context) {
 
 	req := &workflow.NodeTemplateReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -279,7 +281,7 @@ func (w *Handle) UpdateWorkflow(ctx *gin.Context) {
 
 	req := &workflow.UpdateReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -308,7 +310,7 @@ func (w *Handle) DelWorkflow(ctx *gin.Context) {
 
 	req := &workflow.DelReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -332,7 +334,7 @@ func (w *Handle) Create(ctx *gin.Context) {
 
 	req := &workflow.CreateReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -356,7 +358,7 @@ func (w *Handle) GetWorkflowList(ctx *gin.Context) {
 
 	req := &workflow.ListReq{}
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -380,7 +382,7 @@ func (w *Handle) GetWorkflowDetail(ctx *gin.Context) {
 
 	req := &workflow.DetailReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -409,7 +411,7 @@ func (w *Handle) Export(ctx *gin.Context) {
 
 	req := &workflow.ExportReq{}
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	if req.UUID.IsNil() {
@@ -436,7 +438,7 @@ func (w *Handle) Import(ctx *gin.Context) {
 
 	req := &workflow.ImportReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	if req.TargetLabUUID.IsNil() || req.Data == nil {
@@ -530,7 +532,7 @@ func (w *Handle) LabWorkflow(ctx *gin.Context) {
 	req := &workflow.NodeTemplateReq{}
 	if err := ctx.ShouldBindUri(req); err != nil {
 		logger.Errorf(ctx, "unmarshal uuid err: %+v", err)
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -562,7 +564,7 @@ func (w *Handle) Duplicate(ctx *gin.Context) {
 
 	req := &workflow.DuplicateReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 
@@ -583,9 +585,101 @@ func (w *Handle) RunWorkflow(ctx *gin.Context) {
 
 	req := &workflow.RunReq{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		common.ReplyErr(ctx, code.ParamErr.WithMsg(err.Error()))
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
 		return
 	}
 	taskUUID, err := w.wService.HttpRunWorkflow(ctx, req)
 	common.Reply(ctx, err, taskUUID)
 }
+
+// @Summary 启动实验室工作流
+// @Description 实验室成员以自己的身份启动工作流：校验运行参数，创建 pending 状态的执行记录并投递到调度队列，返回执行 UUID 和排队位置预估
+// @Tags Workflow
+// @Accept json
+// @Produce json
+// @Param lab_id path int true "实验室ID"
+// @Param workflow_uuid path string true "工作流 uuid"
+// @Param req body workflow.LabRunReq true "启动请求"
+// @Success 200 {object} common.Resp{data=workflow.LabRunResp} "启动成功"
+// @Failure 200 {object} common.Resp{code=code.ErrCode} "请求参数错误"
+// @Router /v1/lab/{lab_id}/workflow/{workflow_uuid}/run [post]
+func (w *Handle) RunLabWorkflow(ctx *gin.Context) {
+	labID, err := strconv.ParseInt(ctx.Param("lab_id"), 10, 64)
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid lab_id"))
+		return
+	}
+	workflowUUID, err := uuid.FromString(ctx.Param("workflow_uuid"))
+	if err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithMsg("invalid workflow uuid"))
+		return
+	}
+	req := &workflow.LabRunReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	res, err := w.wService.RunLabWorkflow(ctx, labID, workflowUUID, req)
+	common.Reply(ctx, err, res)
+}
+
+// @Summary 批量启动工作流
+// @Description 按输入矩阵批量启动工作流，每行输入生成一个子任务
+// @Tags Workflow
+// @Accept json
+// @Produce json
+// @Param req body workflow.BatchRunReq true "批量启动请求"
+// @Success 200 {object} common.Resp{data=workflow.BatchResp} "启动成功"
+// @Failure 200 {object} common.Resp{code=code.ErrCode} "请求参数错误"
+// @Router /v1/lab/workflow/batch [post]
+func (w *Handle) BatchRunWorkflow(ctx *gin.Context) {
+	req := &workflow.BatchRunReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	res, err := w.wService.BatchRunWorkflow(ctx, req)
+	common.Reply(ctx, err, res)
+}
+
+// @Summary 获取批次状态
+// @Description 获取批次汇总状态、进度及子任务列表
+// @Tags Workflow
+// @Accept json
+// @Produce json
+// @Param uuid path string true "批次 uuid"
+// @Success 200 {object} common.Resp{data=workflow.BatchResp} "获取成功"
+// @Failure 200 {object} common.Resp{code=code.ErrCode} "请求参数错误"
+// @Router /v1/lab/workflow/batch/{uuid} [get]
+func (w *Handle) GetBatch(ctx *gin.Context) {
+	req := &workflow.BatchReq{}
+	if err := ctx.ShouldBindUri(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	res, err := w.wService.GetBatch(ctx, req)
+	common.Reply(ctx, err, res)
+}
+
+// @Summary 取消批次
+// @Description 取消批次内所有未结束的子任务
+// @Tags Workflow
+// @Accept json
+// @Produce json
+// @Param uuid path string true "批次 uuid"
+// @Success 200 {object} common.Resp "取消成功"
+// @Failure 200 {object} common.Resp{code=code.ErrCode} "请求参数错误"
+// @Router /v1/lab/workflow/batch/{uuid}/cancel [post]
+func (w *Handle) CancelBatch(ctx *gin.Context) {
+	req := &workflow.BatchReq{}
+	if err := ctx.ShouldBindUri(req); err != nil {
+		common.ReplyErr(ctx, code.ParamErr.WithErr(err))
+		return
+	}
+
+	err := w.wService.CancelBatch(ctx, req)
+	common.Reply(ctx, err)
+}